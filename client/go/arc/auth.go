@@ -0,0 +1,279 @@
+package arc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultRefreshSkew renews the access token this long before it expires.
+	defaultRefreshSkew = 30 * time.Second
+	defaultPlatform    = "desktop"
+	maxResponseBytes   = 1 << 20
+)
+
+// User is the account returned by login.
+type User struct {
+	ID          string    `json:"id"`
+	Username    *string   `json:"username"`
+	Email       *string   `json:"email"`
+	DisplayName *string   `json:"display_name"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Session holds the tokens of a logged-in session.
+type Session struct {
+	SessionID        string    `json:"session_id"`
+	AccessToken      string    `json:"access_token"`
+	AccessExpiresAt  time.Time `json:"access_expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+// Credentials identify the account to log in; set Username or Email.
+type Credentials struct {
+	Username   string
+	Email      string
+	Password   string
+	RememberMe bool
+}
+
+// Challenge is a pending second login step (an emailed code).
+type Challenge struct {
+	ID        string    `json:"challenge_id"`
+	Method    string    `json:"method"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ChallengeRequiredError is returned by Login when the server asks for a code;
+// finish with AuthClient.CompleteChallenge.
+type ChallengeRequiredError struct {
+	Challenge Challenge
+}
+
+func (e *ChallengeRequiredError) Error() string {
+	return "arc: login challenge required (" + e.Challenge.Method + ")"
+}
+
+// AuthClient performs the login and refresh flows against the auth API.
+//
+// It keeps the current Session and rotates it transparently: AccessToken refreshes
+// when the access token is about to expire, and concurrent callers share a single
+// rotation so a refresh token is never presented twice. It is safe for concurrent use.
+type AuthClient struct {
+	baseURL string
+	http    *http.Client
+
+	// Platform is sent on login and refresh ("desktop" by default; "web" would
+	// move the refresh token into cookies, which this client does not handle).
+	Platform string
+	// RefreshSkew renews the access token this long before it expires.
+	RefreshSkew time.Duration
+
+	mu      sync.Mutex
+	session *Session
+	now     func() time.Time
+}
+
+// NewAuthClient returns a client for the auth API at baseURL (e.g. "https://arc.example").
+// A nil httpClient uses http.DefaultClient.
+func NewAuthClient(baseURL string, httpClient *http.Client) *AuthClient {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &AuthClient{
+		baseURL:     strings.TrimRight(baseURL, "/"),
+		http:        httpClient,
+		Platform:    defaultPlatform,
+		RefreshSkew: defaultRefreshSkew,
+		now:         time.Now,
+	}
+}
+
+type loginResponse struct {
+	User    User    `json:"user"`
+	Session Session `json:"session"`
+	Challenge
+	ChallengeRequired bool `json:"challenge_required"`
+}
+
+// Login authenticates with a password. It returns *ChallengeRequiredError when
+// the server requires a second step.
+func (c *AuthClient) Login(ctx context.Context, cred Credentials) (User, error) {
+	body := map[string]any{
+		"password":    cred.Password,
+		"remember_me": cred.RememberMe,
+		"platform":    c.Platform,
+	}
+	if cred.Username != "" {
+		body["username"] = cred.Username
+	}
+	if cred.Email != "" {
+		body["email"] = cred.Email
+	}
+
+	var out loginResponse
+	if err := c.post(ctx, "/v1/auth/login", body, &out); err != nil {
+		return User{}, err
+	}
+	if out.ChallengeRequired {
+		return User{}, &ChallengeRequiredError{Challenge: out.Challenge}
+	}
+	c.setSession(&out.Session)
+	return out.User, nil
+}
+
+// CompleteChallenge finishes a challenged login with the emailed code.
+func (c *AuthClient) CompleteChallenge(ctx context.Context, challengeID, code string) (User, error) {
+	var out loginResponse
+	body := map[string]any{"challenge_id": challengeID, "code": code}
+	if err := c.post(ctx, "/v1/auth/login/challenge", body, &out); err != nil {
+		return User{}, err
+	}
+	c.setSession(&out.Session)
+	return out.User, nil
+}
+
+// SetSession installs a session obtained elsewhere (e.g. restored from disk).
+func (c *AuthClient) SetSession(s Session) {
+	c.setSession(&s)
+}
+
+// Session returns a copy of the current session and whether one exists.
+// Persist it after rotations to survive restarts.
+func (c *AuthClient) Session() (Session, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.session == nil {
+		return Session{}, false
+	}
+	return *c.session, true
+}
+
+// AccessToken returns a valid access token, rotating the session first when the
+// current token expires within RefreshSkew.
+func (c *AuthClient) AccessToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.session == nil {
+		return "", ErrNotLoggedIn
+	}
+	if c.now().Add(c.RefreshSkew).Before(c.session.AccessExpiresAt) {
+		return c.session.AccessToken, nil
+	}
+	if err := c.refreshLocked(ctx); err != nil {
+		return "", err
+	}
+	return c.session.AccessToken, nil
+}
+
+// Refresh rotates the session now.
+func (c *AuthClient) Refresh(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.refreshLocked(ctx)
+}
+
+func (c *AuthClient) refreshLocked(ctx context.Context) error {
+	if c.session == nil || c.session.RefreshToken == "" {
+		return ErrNotLoggedIn
+	}
+
+	var out struct {
+		Session Session `json:"session"`
+	}
+	body := map[string]any{
+		"refresh_token": c.session.RefreshToken,
+		"platform":      c.Platform,
+	}
+	if err := c.post(ctx, "/v1/auth/refresh", body, &out); err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			switch apiErr.Code {
+			case "refresh_reuse_detected":
+				// The whole session family is revoked server-side; never retry with it.
+				c.session = nil
+				return ErrRefreshReuse
+			case "session_not_active":
+				c.session = nil
+				return ErrSessionEnded
+			}
+		}
+		return err
+	}
+	c.session = &out.Session
+	return nil
+}
+
+// Logout revokes the current session and forgets it locally.
+func (c *AuthClient) Logout(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.session == nil {
+		return nil
+	}
+	body := map[string]any{"refresh_token": c.session.RefreshToken}
+	err := c.post(ctx, "/v1/auth/logout", body, nil)
+	c.session = nil
+	return err
+}
+
+// Do sends req with the current access token, refreshing it as needed.
+func (c *AuthClient) Do(req *http.Request) (*http.Response, error) {
+	token, err := c.AccessToken(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return c.http.Do(req)
+}
+
+func (c *AuthClient) setSession(s *Session) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.session = s
+}
+
+// post sends a JSON body and decodes a 2xx JSON response into out (if non-nil);
+// error responses become *APIError.
+func (c *AuthClient) post(ctx context.Context, path string, body, out any) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	dec := json.NewDecoder(http.MaxBytesReader(nil, resp.Body, maxResponseBytes))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var e struct {
+			Error struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		_ = dec.Decode(&e)
+		return &APIError{Status: resp.StatusCode, Code: e.Error.Code, Message: e.Error.Message}
+	}
+	if out != nil && resp.StatusCode != http.StatusNoContent {
+		return dec.Decode(out)
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package arc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeAuthServer issues sessions whose access tokens expire after ttl and detects
+// refresh token reuse like the real server.
+type fakeAuthServer struct {
+	ttl       time.Duration
+	mu        sync.Mutex
+	n         int
+	live      map[string]bool
+	used      map[string]bool
+	refreshes atomic.Int64
+}
+
+func newFakeAuthServer(t *testing.T, ttl time.Duration) (*fakeAuthServer, *httptest.Server) {
+	t.Helper()
+	f := &fakeAuthServer{ttl: ttl, live: map[string]bool{}, used: map[string]bool{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/auth/login", func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req["username"] == "challenged" {
+			writeTestJSON(w, http.StatusAccepted, map[string]any{"challenge_required": true, "challenge_id": "ch1", "method": "email"})
+			return
+		}
+		if req["password"] != "secret" {
+			writeTestJSON(w, http.StatusUnauthorized, map[string]any{"error": map[string]string{"code": "invalid_credentials", "message": "invalid credentials"}})
+			return
+		}
+		writeTestJSON(w, http.StatusOK, map[string]any{"user": map[string]string{"id": "u1"}, "session": f.issue()})
+	})
+	mux.HandleFunc("/v1/auth/refresh", func(w http.ResponseWriter, r *http.Request) {
+		f.refreshes.Add(1)
+		var req map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		f.mu.Lock()
+		rt := req["refresh_token"]
+		if f.used[rt] {
+			f.live = map[string]bool{}
+			f.mu.Unlock()
+			writeTestJSON(w, http.StatusUnauthorized, map[string]any{"error": map[string]string{"code": "refresh_reuse_detected", "message": "refresh token reuse detected"}})
+			return
+		}
+		ok := f.live[rt]
+		delete(f.live, rt)
+		f.used[rt] = true
+		f.mu.Unlock()
+		if !ok {
+			writeTestJSON(w, http.StatusUnauthorized, map[string]any{"error": map[string]string{"code": "session_not_active", "message": "session not active"}})
+			return
+		}
+		writeTestJSON(w, http.StatusOK, map[string]any{"session": f.issue()})
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+func (f *fakeAuthServer) issue() Session {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.n++
+	rt := "rt" + string(rune('a'+f.n))
+	f.live[rt] = true
+	return Session{
+		SessionID:       "s1",
+		AccessToken:     "at" + string(rune('a'+f.n)),
+		AccessExpiresAt: time.Now().Add(f.ttl),
+		RefreshToken:    rt,
+	}
+}
+
+func writeTestJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func TestAuthClient_LoginAndRotation(t *testing.T) {
+	t.Parallel()
+
+	f, srv := newFakeAuthServer(t, time.Second)
+	c := NewAuthClient(srv.URL, srv.Client())
+	c.RefreshSkew = 2 * time.Second // every token is "about to expire"
+	ctx := context.Background()
+
+	if _, err := c.AccessToken(ctx); !errors.Is(err, ErrNotLoggedIn) {
+		t.Fatalf("expected ErrNotLoggedIn, got %v", err)
+	}
+	var apiErr *APIError
+	if _, err := c.Login(ctx, Credentials{Username: "u", Password: "wrong"}); !errors.As(err, &apiErr) || apiErr.Code != "invalid_credentials" {
+		t.Fatalf("expected invalid_credentials, got %v", err)
+	}
+	var chErr *ChallengeRequiredError
+	if _, err := c.Login(ctx, Credentials{Username: "challenged", Password: "secret"}); !errors.As(err, &chErr) || chErr.Challenge.ID != "ch1" {
+		t.Fatalf("expected challenge, got %v", err)
+	}
+
+	user, err := c.Login(ctx, Credentials{Username: "u", Password: "secret"})
+	if err != nil || user.ID != "u1" {
+		t.Fatalf("login: %+v %v", user, err)
+	}
+
+	// Concurrent rotations are serialized, so no refresh token is presented twice.
+	var wg sync.WaitGroup
+	tokens := make([]string, 8)
+	for i := range tokens {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tokens[i], _ = c.AccessToken(ctx)
+		}()
+	}
+	wg.Wait()
+	if got := f.refreshes.Load(); got != 8 {
+		t.Fatalf("expected serialized rotations, got %d", got)
+	}
+	for _, tok := range tokens {
+		if tok == "" {
+			t.Fatalf("expected every caller to get a token, got %q", tokens)
+		}
+	}
+
+	// Presenting a rotated refresh token again revokes the family.
+	s, _ := c.Session()
+	s.RefreshToken = "rtb"
+	c.SetSession(s)
+	if err := c.Refresh(ctx); !errors.Is(err, ErrRefreshReuse) {
+		t.Fatalf("expected ErrRefreshReuse, got %v", err)
+	}
+	if _, ok := c.Session(); ok {
+		t.Fatalf("expected session to be cleared after reuse detection")
+	}
+}
+
+func TestAuthClient_AccessTokenCachedUntilSkew(t *testing.T) {
+	t.Parallel()
+
+	f, srv := newFakeAuthServer(t, time.Hour)
+	c := NewAuthClient(srv.URL, srv.Client())
+	ctx := context.Background()
+	if _, err := c.Login(ctx, Credentials{Email: "u@example.com", Password: "secret"}); err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	first, _ := c.AccessToken(ctx)
+	second, _ := c.AccessToken(ctx)
+	if first != second || f.refreshes.Load() != 0 {
+		t.Fatalf("expected cached token without refresh, got %q %q (%d refreshes)", first, second, f.refreshes.Load())
+	}
+
+	c.now = func() time.Time { return time.Now().Add(time.Hour) }
+	third, err := c.AccessToken(ctx)
+	if err != nil || third == first || f.refreshes.Load() != 1 {
+		t.Fatalf("expected one rotation near expiry, got %q %v (%d refreshes)", third, err, f.refreshes.Load())
+	}
+}
@@ -0,0 +1,7 @@
+// Package arc is a Go client for the Arc auth API and realtime v1 protocol.
+//
+// AuthClient covers login (including email challenges), refresh token rotation and
+// logout; Realtime keeps a WebSocket session alive across reconnects, resumes missed
+// messages and correlates message.send with its ack. Internal tools and bots should
+// use it instead of reimplementing the protocol.
+package arc
@@ -0,0 +1,46 @@
+package arc
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNotLoggedIn is returned when an operation needs a session and there is none.
+	ErrNotLoggedIn = errors.New("arc: not logged in")
+	// ErrRefreshReuse means the server saw a refresh token used twice and revoked the
+	// session family; the local session is cleared and the caller must log in again.
+	ErrRefreshReuse = errors.New("arc: refresh token reuse detected")
+	// ErrSessionEnded means the session was revoked or expired; log in again.
+	ErrSessionEnded = errors.New("arc: session not active")
+	// ErrClosed is returned by Realtime operations after Close.
+	ErrClosed = errors.New("arc: realtime client closed")
+)
+
+// APIError is an error response from the auth API: {"error":{"code","message"}}.
+type APIError struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("arc: %s (%d): %s", e.Code, e.Status, e.Message)
+}
+
+// ServerError is a realtime error envelope answering a request.
+type ServerError struct {
+	Code    string
+	Reason  string
+	Message string
+	// MaxChars and ActualChars are set when Reason is "too_long".
+	MaxChars    int
+	ActualChars int
+}
+
+func (e *ServerError) Error() string {
+	if e.Reason != "" {
+		return fmt.Sprintf("arc: %s/%s: %s", e.Code, e.Reason, e.Message)
+	}
+	return fmt.Sprintf("arc: %s: %s", e.Code, e.Message)
+}
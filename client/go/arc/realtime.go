@@ -0,0 +1,541 @@
+package arc
+
+import (
+	"context"
+	cryptorand "crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"time"
+
+	v1 "arc/shared/contracts/realtime/v1"
+
+	"github.com/coder/websocket"
+)
+
+const (
+	subprotocolV1 = "arc.realtime.v1"
+
+	defaultReconnectMin = 500 * time.Millisecond
+	defaultReconnectMax = 30 * time.Second
+	helloTimeout        = 10 * time.Second
+	writeTimeout        = 10 * time.Second
+	maxReadBytes        = 1 << 20
+
+	// clientCapabilities are announced in hello; the dialer does not offer compression.
+	clientCapabilities = v1.CapResume
+)
+
+// sendErrorCodes are the error codes the server answers a message.send with.
+var sendErrorCodes = map[string]bool{
+	"send_failed":               true,
+	"send_suspended":            true,
+	"message_rejected":          true,
+	"not_joined":                true,
+	"rate_limited_conversation": true,
+}
+
+// TokenSource supplies access tokens for the WebSocket upgrade; *AuthClient implements it.
+type TokenSource interface {
+	AccessToken(ctx context.Context) (string, error)
+}
+
+// RealtimeConfig configures a Realtime client.
+type RealtimeConfig struct {
+	// URL is the WebSocket endpoint, e.g. "wss://arc.example/ws".
+	URL string
+	// Tokens authenticates each (re)connect; nil connects anonymously.
+	Tokens TokenSource
+	// Origin is sent when the server requires one.
+	Origin string
+	// ClientVersion and Platform ("web", "ios", "android", "desktop") are announced
+	// in hello; they are informational.
+	ClientVersion string
+	Platform      string
+	// OnEvent receives server events that are not replies to Join or Send
+	// (message.new, presence.update, resume.ok, ...). It runs on the read goroutine
+	// and must not block.
+	OnEvent func(v1.Envelope)
+	// ReconnectMin and ReconnectMax bound the jittered reconnect backoff.
+	ReconnectMin time.Duration
+	ReconnectMax time.Duration
+
+	// dial replaces the WebSocket dialer in tests.
+	dial func(ctx context.Context, url string, header http.Header) (wireConn, error)
+}
+
+// wireConn is one connection carrying JSON envelopes.
+type wireConn interface {
+	Read(ctx context.Context) (v1.Envelope, error)
+	Write(ctx context.Context, env v1.Envelope) error
+	Close() error
+}
+
+// Realtime is a realtime v1 session that survives disconnects.
+//
+// After a reconnect it says hello with the last resume token, rejoins the joined
+// conversation, resumes from the seqs it already delivered and resends unacknowledged
+// messages under their original client_msg_id (the server deduplicates them).
+// It is safe for concurrent use.
+type Realtime struct {
+	cfg    RealtimeConfig
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	writeMu sync.Mutex
+
+	mu          sync.Mutex
+	conn        wireConn
+	joined      string
+	joinPrev    string
+	joinWait    chan error
+	sends       []*pendingSend
+	cursors     map[string]int64
+	resumeToken string
+	retryAfter  time.Duration
+}
+
+type pendingSend struct {
+	payload v1.MessageSendPayload
+	done    chan sendResult
+}
+
+type sendResult struct {
+	ack v1.MessageAckPayload
+	err error
+}
+
+// DialRealtime connects to cfg.URL and keeps the session connected until Close.
+// The first connection is made synchronously so configuration errors surface here.
+func DialRealtime(ctx context.Context, cfg RealtimeConfig) (*Realtime, error) {
+	if cfg.ReconnectMin <= 0 {
+		cfg.ReconnectMin = defaultReconnectMin
+	}
+	if cfg.ReconnectMax < cfg.ReconnectMin {
+		cfg.ReconnectMax = max(defaultReconnectMax, cfg.ReconnectMin)
+	}
+	if cfg.dial == nil {
+		cfg.dial = dialWebSocket
+	}
+
+	runCtx, cancel := context.WithCancel(context.Background())
+	r := &Realtime{
+		cfg:     cfg,
+		ctx:     runCtx,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+		cursors: make(map[string]int64),
+	}
+	conn, err := r.connect(ctx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	go r.run(conn)
+	return r, nil
+}
+
+// Close ends the session; pending Join and Send calls fail with ErrClosed.
+func (r *Realtime) Close() error {
+	r.cancel()
+	<-r.done
+	return nil
+}
+
+// Join joins conversationID (leaving the previous one) and waits for the server's echo.
+func (r *Realtime) Join(ctx context.Context, conversationID string) error {
+	wait := make(chan error, 1)
+
+	r.mu.Lock()
+	if r.ctx.Err() != nil {
+		r.mu.Unlock()
+		return ErrClosed
+	}
+	if r.joinWait != nil {
+		r.joinWait <- errors.New("arc: superseded by another join")
+	} else {
+		r.joinPrev = r.joined
+	}
+	r.joined = conversationID
+	r.joinWait = wait
+	conn := r.conn
+	r.mu.Unlock()
+
+	// A failed write is retried by the reconnect, which rejoins r.joined.
+	if conn != nil {
+		_ = r.write(ctx, conn, v1.TypeConversationJoin, v1.ConversationJoinPayload{ConversationID: conversationID})
+	}
+
+	select {
+	case err := <-wait:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-r.ctx.Done():
+		return ErrClosed
+	}
+}
+
+// Send posts text to the joined conversation and waits for its message.ack.
+// Server rejections are returned as *ServerError.
+func (r *Realtime) Send(ctx context.Context, text string) (v1.MessageAckPayload, error) {
+	ps := &pendingSend{
+		payload: v1.MessageSendPayload{ClientMsgID: newClientMsgID(), Text: text},
+		done:    make(chan sendResult, 1),
+	}
+
+	r.mu.Lock()
+	if r.ctx.Err() != nil {
+		r.mu.Unlock()
+		return v1.MessageAckPayload{}, ErrClosed
+	}
+	if r.joined == "" {
+		r.mu.Unlock()
+		return v1.MessageAckPayload{}, errors.New("arc: join a conversation first")
+	}
+	ps.payload.ConversationID = r.joined
+	r.sends = append(r.sends, ps)
+	conn := r.conn
+	r.mu.Unlock()
+
+	if conn != nil {
+		_ = r.write(ctx, conn, v1.TypeMessageSend, ps.payload)
+	}
+
+	select {
+	case res := <-ps.done:
+		return res.ack, res.err
+	case <-ctx.Done():
+		r.dropSend(ps)
+		return v1.MessageAckPayload{}, ctx.Err()
+	case <-r.ctx.Done():
+		return v1.MessageAckPayload{}, ErrClosed
+	}
+}
+
+// Cursors returns the highest message.new seq received per conversation.
+func (r *Realtime) Cursors() map[string]int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := make(map[string]int64, len(r.cursors))
+	for id, seq := range r.cursors {
+		out[id] = seq
+	}
+	return out
+}
+
+// run reads from conn and reconnects with backoff until Close.
+func (r *Realtime) run(conn wireConn) {
+	defer close(r.done)
+
+	attempt := 0
+	for {
+		if conn != nil {
+			attempt = 0
+			r.readLoop(conn)
+			r.mu.Lock()
+			r.conn = nil
+			r.mu.Unlock()
+			_ = conn.Close()
+			conn = nil
+		}
+		if r.ctx.Err() != nil {
+			return
+		}
+
+		select {
+		case <-time.After(r.backoff(attempt)):
+		case <-r.ctx.Done():
+			return
+		}
+		attempt++
+
+		c, err := r.connect(r.ctx)
+		if err == nil {
+			conn = c
+		}
+	}
+}
+
+// backoff is the delay before reconnect attempt n: the server's retry_after when it
+// announced a shutdown, else jittered exponential backoff.
+func (r *Realtime) backoff(n int) time.Duration {
+	r.mu.Lock()
+	hint := r.retryAfter
+	r.retryAfter = 0
+	r.mu.Unlock()
+	if hint > 0 {
+		return hint
+	}
+
+	d := r.cfg.ReconnectMin << min(n, 16)
+	if d <= 0 || d > r.cfg.ReconnectMax {
+		d = r.cfg.ReconnectMax
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+// connect dials, says hello and restores the session state.
+func (r *Realtime) connect(ctx context.Context) (wireConn, error) {
+	header := http.Header{}
+	if r.cfg.Tokens != nil {
+		token, err := r.cfg.Tokens.AccessToken(ctx)
+		if err != nil {
+			return nil, err
+		}
+		header.Set("Authorization", "Bearer "+token)
+	}
+	if r.cfg.Origin != "" {
+		header.Set("Origin", r.cfg.Origin)
+	}
+
+	conn, err := r.cfg.dial(ctx, r.cfg.URL, header)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	resumeToken := r.resumeToken
+	r.mu.Unlock()
+
+	hctx, cancel := context.WithTimeout(ctx, helloTimeout)
+	defer cancel()
+	if err := r.write(hctx, conn, v1.TypeHello, v1.HelloPayload{
+		ResumeToken:   resumeToken,
+		ClientVersion: r.cfg.ClientVersion,
+		Platform:      r.cfg.Platform,
+		Capabilities:  clientCapabilities,
+	}); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	for {
+		env, err := conn.Read(hctx)
+		if err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+		if env.Type == v1.TypeHelloAck {
+			var ack v1.HelloAckPayload
+			_ = json.Unmarshal(env.Payload, &ack)
+			if ack.ResumeToken != "" {
+				r.mu.Lock()
+				r.resumeToken = ack.ResumeToken
+				r.mu.Unlock()
+			}
+			break
+		}
+		r.dispatch(env)
+	}
+
+	if err := r.restore(hctx, conn); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// restore rejoins, resumes and resends pending messages on a fresh connection,
+// then publishes it for Join and Send.
+func (r *Realtime) restore(ctx context.Context, conn wireConn) error {
+	r.mu.Lock()
+	joined := r.joined
+	resume := v1.ResumePayload{ResumeToken: r.resumeToken}
+	for id, seq := range r.cursors {
+		resume.Conversations = append(resume.Conversations, v1.ResumeConversation{ConversationID: id, LastSeq: seq})
+	}
+	sends := make([]v1.MessageSendPayload, 0, len(r.sends))
+	for _, ps := range r.sends {
+		sends = append(sends, ps.payload)
+	}
+	r.conn = conn
+	r.mu.Unlock()
+
+	if joined != "" {
+		if err := r.write(ctx, conn, v1.TypeConversationJoin, v1.ConversationJoinPayload{ConversationID: joined}); err != nil {
+			return err
+		}
+	}
+	if r.cfg.Tokens != nil && len(resume.Conversations) > 0 {
+		if err := r.write(ctx, conn, v1.TypeResume, resume); err != nil {
+			return err
+		}
+	}
+	for _, p := range sends {
+		if err := r.write(ctx, conn, v1.TypeMessageSend, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Realtime) readLoop(conn wireConn) {
+	for {
+		env, err := conn.Read(r.ctx)
+		if err != nil {
+			return
+		}
+		r.dispatch(env)
+	}
+}
+
+// dispatch completes pending requests answered by env and forwards other events.
+func (r *Realtime) dispatch(env v1.Envelope) {
+	switch env.Type {
+	case v1.TypeConversationJoin:
+		var p v1.ConversationJoinPayload
+		_ = json.Unmarshal(env.Payload, &p)
+		r.mu.Lock()
+		if r.joinWait != nil && p.ConversationID == r.joined {
+			r.joinWait <- nil
+			r.joinWait = nil
+		}
+		r.mu.Unlock()
+		return
+
+	case v1.TypeMessageAck:
+		var p v1.MessageAckPayload
+		_ = json.Unmarshal(env.Payload, &p)
+		r.mu.Lock()
+		for i, ps := range r.sends {
+			if ps.payload.ClientMsgID == p.ClientMsgID {
+				r.sends = append(r.sends[:i], r.sends[i+1:]...)
+				ps.done <- sendResult{ack: p}
+				break
+			}
+		}
+		r.mu.Unlock()
+		return
+
+	case v1.TypeMessageNew:
+		var p v1.MessageNewPayload
+		if err := json.Unmarshal(env.Payload, &p); err == nil && p.ConversationID != "" {
+			r.mu.Lock()
+			if p.Seq > r.cursors[p.ConversationID] {
+				r.cursors[p.ConversationID] = p.Seq
+			}
+			r.mu.Unlock()
+		}
+
+	case v1.TypeServerShutdown:
+		var p v1.ServerShutdownPayload
+		_ = json.Unmarshal(env.Payload, &p)
+		r.mu.Lock()
+		if p.ResumeToken != "" {
+			r.resumeToken = p.ResumeToken
+		}
+		r.retryAfter = time.Duration(p.RetryAfterMS) * time.Millisecond
+		r.mu.Unlock()
+
+	case v1.TypeError:
+		var p v1.ErrorPayload
+		_ = json.Unmarshal(env.Payload, &p)
+		if r.failRequest(p) {
+			return
+		}
+	}
+
+	if r.cfg.OnEvent != nil {
+		r.cfg.OnEvent(env)
+	}
+}
+
+// failRequest fails the oldest request of the kind p answers. The server handles
+// one connection's envelopes in order, so its errors arrive in request order.
+func (r *Realtime) failRequest(p v1.ErrorPayload) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	err := &ServerError{Code: p.Code, Reason: p.Reason, Message: p.Message, MaxChars: p.MaxChars, ActualChars: p.ActualChars}
+	switch {
+	case p.Code == "join_failed" && r.joinWait != nil:
+		r.joinWait <- err
+		r.joinWait = nil
+		// The server stays in the previously joined conversation.
+		r.joined = r.joinPrev
+		return true
+	case sendErrorCodes[p.Code] && len(r.sends) > 0:
+		ps := r.sends[0]
+		r.sends = r.sends[1:]
+		ps.done <- sendResult{err: err}
+		return true
+	}
+	return false
+}
+
+func (r *Realtime) dropSend(ps *pendingSend) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, p := range r.sends {
+		if p == ps {
+			r.sends = append(r.sends[:i], r.sends[i+1:]...)
+			return
+		}
+	}
+}
+
+func (r *Realtime) write(ctx context.Context, conn wireConn, typ string, payload any) error {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	env := v1.Envelope{V: v1.Version, Type: typ, ID: newClientMsgID(), TS: time.Now().UTC(), Payload: b}
+
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+	wctx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
+	return conn.Write(wctx, env)
+}
+
+func newClientMsgID() string {
+	var b [16]byte
+	_, _ = cryptorand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// wsConn carries v1 envelopes as JSON text frames.
+type wsConn struct {
+	c *websocket.Conn
+}
+
+func dialWebSocket(ctx context.Context, url string, header http.Header) (wireConn, error) {
+	c, _, err := websocket.Dial(ctx, url, &websocket.DialOptions{
+		HTTPHeader:   header,
+		Subprotocols: []string{subprotocolV1},
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.SetReadLimit(maxReadBytes)
+	return &wsConn{c: c}, nil
+}
+
+func (w *wsConn) Read(ctx context.Context) (v1.Envelope, error) {
+	_, b, err := w.c.Read(ctx)
+	if err != nil {
+		return v1.Envelope{}, err
+	}
+	var env v1.Envelope
+	if err := json.Unmarshal(b, &env); err != nil {
+		return v1.Envelope{}, err
+	}
+	return env, nil
+}
+
+func (w *wsConn) Write(ctx context.Context, env v1.Envelope) error {
+	b, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+	return w.c.Write(ctx, websocket.MessageText, b)
+}
+
+func (w *wsConn) Close() error {
+	return w.c.Close(websocket.StatusNormalClosure, "bye")
+}
@@ -0,0 +1,208 @@
+package arc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	v1 "arc/shared/contracts/realtime/v1"
+)
+
+// pipeConn is the client end of an in-memory connection; the test plays the server.
+type pipeConn struct {
+	toClient   chan v1.Envelope
+	fromClient chan v1.Envelope
+	closed     chan struct{}
+	once       sync.Once
+}
+
+func newPipeConn() *pipeConn {
+	return &pipeConn{
+		toClient:   make(chan v1.Envelope, 16),
+		fromClient: make(chan v1.Envelope, 16),
+		closed:     make(chan struct{}),
+	}
+}
+
+func (c *pipeConn) Read(ctx context.Context) (v1.Envelope, error) {
+	select {
+	case env := <-c.toClient:
+		return env, nil
+	case <-c.closed:
+		return v1.Envelope{}, io.EOF
+	case <-ctx.Done():
+		return v1.Envelope{}, ctx.Err()
+	}
+}
+
+func (c *pipeConn) Write(_ context.Context, env v1.Envelope) error {
+	select {
+	case <-c.closed:
+		return io.ErrClosedPipe
+	case c.fromClient <- env:
+		return nil
+	}
+}
+
+func (c *pipeConn) Close() error {
+	c.once.Do(func() { close(c.closed) })
+	return nil
+}
+
+// expect reads the next envelope from the client and checks its type.
+func (c *pipeConn) expect(t *testing.T, typ string, payload any) {
+	t.Helper()
+	select {
+	case env := <-c.fromClient:
+		if env.Type != typ {
+			t.Fatalf("expected %q, got %q (%s)", typ, env.Type, env.Payload)
+		}
+		if payload != nil {
+			if err := json.Unmarshal(env.Payload, payload); err != nil {
+				t.Fatalf("decode %s: %v", typ, err)
+			}
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for %q", typ)
+	}
+}
+
+func (c *pipeConn) reply(typ string, payload any) {
+	b, _ := json.Marshal(payload)
+	c.toClient <- v1.Envelope{V: v1.Version, Type: typ, Payload: b}
+}
+
+type staticTokens string
+
+func (s staticTokens) AccessToken(context.Context) (string, error) { return string(s), nil }
+
+func TestRealtime_JoinSendAndResumeAfterReconnect(t *testing.T) {
+	t.Parallel()
+
+	conns := make(chan *pipeConn, 4)
+	headers := make(chan http.Header, 4)
+	dial := func(_ context.Context, _ string, h http.Header) (wireConn, error) {
+		c := newPipeConn()
+		conns <- c
+		headers <- h
+		return c, nil
+	}
+	events := make(chan v1.Envelope, 16)
+	cfg := RealtimeConfig{
+		URL:          "ws://arc.test/ws",
+		Tokens:       staticTokens("at1"),
+		OnEvent:      func(env v1.Envelope) { events <- env },
+		ReconnectMin: time.Millisecond,
+		ReconnectMax: 5 * time.Millisecond,
+		dial:         dial,
+	}
+
+	type dialResult struct {
+		r   *Realtime
+		err error
+	}
+	dialed := make(chan dialResult, 1)
+	go func() {
+		r, err := DialRealtime(context.Background(), cfg)
+		dialed <- dialResult{r, err}
+	}()
+
+	first := <-conns
+	if got := (<-headers).Get("Authorization"); got != "Bearer at1" {
+		t.Fatalf("expected bearer token on upgrade, got %q", got)
+	}
+	first.expect(t, v1.TypeHello, nil)
+	first.reply(v1.TypeHelloAck, v1.HelloAckPayload{SessionID: "s1", ResumeToken: "rt1"})
+	res := <-dialed
+	if res.err != nil {
+		t.Fatalf("dial: %v", res.err)
+	}
+	r := res.r
+	defer func() { _ = r.Close() }()
+	ctx := context.Background()
+
+	if _, err := r.Send(ctx, "too early"); err == nil {
+		t.Fatalf("expected send before join to fail")
+	}
+
+	joined := make(chan error, 1)
+	go func() { joined <- r.Join(ctx, "c1") }()
+	first.expect(t, v1.TypeConversationJoin, nil)
+	first.reply(v1.TypeConversationJoin, v1.ConversationJoinPayload{ConversationID: "c1"})
+	if err := <-joined; err != nil {
+		t.Fatalf("join: %v", err)
+	}
+
+	// One send is acked, one rejected.
+	type sendOut struct {
+		ack v1.MessageAckPayload
+		err error
+	}
+	sent := make(chan sendOut, 2)
+	go func() {
+		ack, err := r.Send(ctx, "hi")
+		sent <- sendOut{ack, err}
+	}()
+	var send v1.MessageSendPayload
+	first.expect(t, v1.TypeMessageSend, &send)
+	first.reply(v1.TypeMessageNew, v1.MessageNewPayload{ConversationID: "c1", ClientMsgID: send.ClientMsgID, Seq: 7})
+	first.reply(v1.TypeMessageAck, v1.MessageAckPayload{ConversationID: "c1", ClientMsgID: send.ClientMsgID, Seq: 7})
+	if out := <-sent; out.err != nil || out.ack.Seq != 7 {
+		t.Fatalf("expected ack seq 7, got %+v", out)
+	}
+	if ev := <-events; ev.Type != v1.TypeMessageNew {
+		t.Fatalf("expected message.new event, got %q", ev.Type)
+	}
+
+	go func() {
+		ack, err := r.Send(ctx, "spam")
+		sent <- sendOut{ack, err}
+	}()
+	first.expect(t, v1.TypeMessageSend, nil)
+	first.reply(v1.TypeError, v1.ErrorPayload{Code: "message_rejected", Reason: "blocklist", Message: "rejected"})
+	var serverErr *ServerError
+	if out := <-sent; !errors.As(out.err, &serverErr) || serverErr.Reason != "blocklist" {
+		t.Fatalf("expected rejection, got %+v", out)
+	}
+
+	// The node drains: the client reconnects with the new token, rejoins, resumes
+	// and resends the message that was never acked.
+	go func() {
+		ack, err := r.Send(ctx, "in flight")
+		sent <- sendOut{ack, err}
+	}()
+	var inflight v1.MessageSendPayload
+	first.expect(t, v1.TypeMessageSend, &inflight)
+	first.reply(v1.TypeServerShutdown, v1.ServerShutdownPayload{RetryAfterMS: 1, ResumeToken: "rt2"})
+	<-events
+	_ = first.Close()
+
+	second := <-conns
+	<-headers
+	var hello v1.HelloPayload
+	second.expect(t, v1.TypeHello, &hello)
+	if hello.ResumeToken != "rt2" || !hello.Capabilities.Has(v1.CapResume) {
+		t.Fatalf("expected hello with the shutdown resume token, got %+v", hello)
+	}
+	second.reply(v1.TypeHelloAck, v1.HelloAckPayload{SessionID: "s1"})
+	second.expect(t, v1.TypeConversationJoin, nil)
+	var resume v1.ResumePayload
+	second.expect(t, v1.TypeResume, &resume)
+	if resume.ResumeToken != "rt2" || len(resume.Conversations) != 1 || resume.Conversations[0] != (v1.ResumeConversation{ConversationID: "c1", LastSeq: 7}) {
+		t.Fatalf("unexpected resume %+v", resume)
+	}
+	var resent v1.MessageSendPayload
+	second.expect(t, v1.TypeMessageSend, &resent)
+	if resent.ClientMsgID != inflight.ClientMsgID {
+		t.Fatalf("expected resend under the same client_msg_id")
+	}
+	second.reply(v1.TypeMessageAck, v1.MessageAckPayload{ConversationID: "c1", ClientMsgID: resent.ClientMsgID, Seq: 8})
+	if out := <-sent; out.err != nil || out.ack.Seq != 8 {
+		t.Fatalf("expected resent message to be acked, got %+v", out)
+	}
+}
@@ -0,0 +1,67 @@
+// Command arc-conformance runs the arc.realtime.v1 conformance suite against a
+// gateway endpoint and exits non-zero when any case fails.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"arc/client/conformance"
+)
+
+func main() {
+	var (
+		wsURL   = flag.String("url", "ws://127.0.0.1:8080/ws", "WebSocket URL")
+		origin  = flag.String("origin", "", "Origin header to send")
+		prefix  = flag.String("conv-prefix", "conformance-", "Prefix of the conversations the suite joins")
+		timeout = flag.Duration("timeout", 5*time.Second, "Per-step timeout")
+		// auth-bearer may be passed via flag or ARC_CONFORMANCE_AUTH_BEARER env.
+		authBearer = flag.String("auth-bearer", "", "Access token used as Authorization: Bearer <token>")
+		jsonOut    = flag.Bool("json", false, "Print the report as JSON")
+	)
+	flag.Parse()
+
+	header := http.Header{}
+	if o := strings.TrimSpace(*origin); o != "" {
+		header.Set("Origin", o)
+	}
+	bearer := strings.TrimSpace(*authBearer)
+	if bearer == "" {
+		bearer = strings.TrimSpace(os.Getenv("ARC_CONFORMANCE_AUTH_BEARER"))
+	}
+	if bearer != "" {
+		header.Set("Authorization", "Bearer "+bearer)
+	}
+
+	rep := conformance.Run(context.Background(), conformance.Config{
+		Dial:               conformance.WebSocketDialer(*wsURL, header),
+		ConversationPrefix: *prefix,
+		StepTimeout:        *timeout,
+	})
+
+	if *jsonOut {
+		_ = json.NewEncoder(os.Stdout).Encode(rep)
+	} else {
+		for _, r := range rep.Results {
+			status := "PASS"
+			if !r.Passed {
+				status = "FAIL"
+			}
+			fmt.Printf("%s  %-28s %s", status, r.Name, r.Duration.Round(time.Millisecond))
+			if r.Error != "" {
+				fmt.Printf("  %s", r.Error)
+			}
+			fmt.Println()
+		}
+		fmt.Printf("passed=%d failed=%d\n", rep.Passed, rep.Failed)
+	}
+	if !rep.OK() {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,236 @@
+package conformance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	v1 "arc/shared/contracts/realtime/v1"
+)
+
+func caseHello(ctx context.Context, s *Scenario) error {
+	_, err := s.Connect(ctx)
+	return err
+}
+
+func caseJoinEcho(ctx context.Context, s *Scenario) error {
+	c, err := s.Connect(ctx)
+	if err != nil {
+		return err
+	}
+	return c.Join(ctx)
+}
+
+func caseSendRequiresJoin(ctx context.Context, s *Scenario) error {
+	c, err := s.Connect(ctx)
+	if err != nil {
+		return err
+	}
+	if err := c.Write(ctx, v1.TypeMessageSend, v1.MessageSendPayload{
+		ConversationID: s.conv, ClientMsgID: randomID(), Text: "not joined",
+	}); err != nil {
+		return err
+	}
+	return c.ExpectError(ctx, "not_joined")
+}
+
+func caseSendAck(ctx context.Context, s *Scenario) error {
+	c, err := s.Connect(ctx)
+	if err != nil {
+		return err
+	}
+	if err := c.Join(ctx); err != nil {
+		return err
+	}
+	_, err = c.Send(ctx, randomID(), "hello")
+	return err
+}
+
+func caseFanout(ctx context.Context, s *Scenario) error {
+	a, b, err := s.joinedPair(ctx)
+	if err != nil {
+		return err
+	}
+	id := randomID()
+	ack, err := a.Send(ctx, id, "fanout")
+	if err != nil {
+		return err
+	}
+	var got v1.MessageNewPayload
+	if err := b.Expect(ctx, v1.TypeMessageNew, &got); err != nil {
+		return err
+	}
+	return sameMessage(got, ack, "fanout")
+}
+
+func caseDedupe(ctx context.Context, s *Scenario) error {
+	a, b, err := s.joinedPair(ctx)
+	if err != nil {
+		return err
+	}
+	id := randomID()
+	first, err := a.Send(ctx, id, "once")
+	if err != nil {
+		return err
+	}
+	if err := b.Expect(ctx, v1.TypeMessageNew, nil); err != nil {
+		return err
+	}
+	second, err := a.Send(ctx, id, "once")
+	if err != nil {
+		return err
+	}
+	if second.Seq != first.Seq || second.ServerMsgID != first.ServerMsgID {
+		return fmt.Errorf("duplicate send acked as seq=%d id=%q, want seq=%d id=%q",
+			second.Seq, second.ServerMsgID, first.Seq, first.ServerMsgID)
+	}
+	return b.ExpectNone(ctx, v1.TypeMessageNew)
+}
+
+func caseSeqMonotonic(ctx context.Context, s *Scenario) error {
+	c, err := s.Connect(ctx)
+	if err != nil {
+		return err
+	}
+	if err := c.Join(ctx); err != nil {
+		return err
+	}
+	var last int64
+	for i := range 3 {
+		ack, err := c.Send(ctx, randomID(), fmt.Sprintf("m%d", i))
+		if err != nil {
+			return err
+		}
+		if ack.Seq <= last {
+			return fmt.Errorf("seq %d after %d, want strictly increasing", ack.Seq, last)
+		}
+		last = ack.Seq
+	}
+	return nil
+}
+
+func caseHistory(ctx context.Context, s *Scenario) error {
+	c, err := s.Connect(ctx)
+	if err != nil {
+		return err
+	}
+	if err := c.Join(ctx); err != nil {
+		return err
+	}
+	var acks []v1.MessageAckPayload
+	for i := range 3 {
+		ack, err := c.Send(ctx, randomID(), fmt.Sprintf("h%d", i))
+		if err != nil {
+			return err
+		}
+		acks = append(acks, ack)
+	}
+
+	chunk, err := c.fetchHistory(ctx, v1.ConversationHistoryFetchPayload{ConversationID: s.conv, Limit: 50})
+	if err != nil {
+		return err
+	}
+	if len(chunk.Messages) != len(acks) {
+		return fmt.Errorf("history returned %d messages, want %d", len(chunk.Messages), len(acks))
+	}
+	for i, m := range chunk.Messages {
+		if err := sameMessage(m, acks[i], fmt.Sprintf("h%d", i)); err != nil {
+			return fmt.Errorf("history[%d]: %w", i, err)
+		}
+	}
+
+	after := acks[len(acks)-1].Seq
+	chunk, err = c.fetchHistory(ctx, v1.ConversationHistoryFetchPayload{ConversationID: s.conv, AfterSeq: &after, Limit: 50})
+	if err != nil {
+		return err
+	}
+	if len(chunk.Messages) != 0 || chunk.HasMore {
+		return fmt.Errorf("history after last seq: %d messages has_more=%v, want none", len(chunk.Messages), chunk.HasMore)
+	}
+	return nil
+}
+
+func caseUnknownType(ctx context.Context, s *Scenario) error {
+	c, err := s.Connect(ctx)
+	if err != nil {
+		return err
+	}
+	if err := c.WriteEnvelope(ctx, v1.Envelope{V: v1.Version, Type: "conformance.unknown", ID: randomID(), TS: time.Now().UTC()}); err != nil {
+		return err
+	}
+	return c.ExpectError(ctx, "bad_envelope")
+}
+
+func caseUnsupportedVersion(ctx context.Context, s *Scenario) error {
+	c, err := s.Connect(ctx)
+	if err != nil {
+		return err
+	}
+	if err := c.WriteEnvelope(ctx, v1.Envelope{V: v1.Version + 98, Type: v1.TypeHello, ID: randomID(), TS: time.Now().UTC()}); err != nil {
+		return err
+	}
+	return c.ExpectError(ctx, "bad_envelope")
+}
+
+func caseEmptyText(ctx context.Context, s *Scenario) error {
+	c, err := s.Connect(ctx)
+	if err != nil {
+		return err
+	}
+	if err := c.Join(ctx); err != nil {
+		return err
+	}
+	if err := c.Write(ctx, v1.TypeMessageSend, v1.MessageSendPayload{
+		ConversationID: s.conv, ClientMsgID: randomID(), Text: "   ",
+	}); err != nil {
+		return err
+	}
+	return c.ExpectError(ctx, "send_failed")
+}
+
+// joinedPair connects and joins two sessions to the scenario's conversation.
+func (s *Scenario) joinedPair(ctx context.Context) (*Client, *Client, error) {
+	a, err := s.Connect(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	b, err := s.Connect(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if a.SessionID == b.SessionID {
+		// Session-keyed fanout needs distinct sessions; the dialer must provide them.
+		return nil, nil, fmt.Errorf("two connections share session_id %q", a.SessionID)
+	}
+	if err := a.Join(ctx); err != nil {
+		return nil, nil, err
+	}
+	if err := b.Join(ctx); err != nil {
+		return nil, nil, err
+	}
+	return a, b, nil
+}
+
+func (c *Client) fetchHistory(ctx context.Context, p v1.ConversationHistoryFetchPayload) (v1.ConversationHistoryChunkPayload, error) {
+	var chunk v1.ConversationHistoryChunkPayload
+	if err := c.Write(ctx, v1.TypeConversationHistoryFetch, p); err != nil {
+		return chunk, err
+	}
+	if err := c.Expect(ctx, v1.TypeConversationHistoryChunk, &chunk); err != nil {
+		return chunk, err
+	}
+	if chunk.ConversationID != c.s.conv {
+		return chunk, fmt.Errorf("history chunk: conversation_id=%q, want %q", chunk.ConversationID, c.s.conv)
+	}
+	return chunk, nil
+}
+
+func sameMessage(got v1.MessageNewPayload, ack v1.MessageAckPayload, text string) error {
+	if got.ConversationID != ack.ConversationID || got.ClientMsgID != ack.ClientMsgID ||
+		got.ServerMsgID != ack.ServerMsgID || got.Seq != ack.Seq || got.Text != text {
+		b, _ := json.Marshal(got)
+		return fmt.Errorf("message %s does not match ack seq=%d server_msg_id=%q text=%q", b, ack.Seq, ack.ServerMsgID, text)
+	}
+	return nil
+}
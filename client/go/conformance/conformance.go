@@ -0,0 +1,292 @@
+// Package conformance checks a gateway against the arc.realtime.v1 protocol.
+//
+// Run executes a fixed scenario (hello, join, send/ack, fanout, dedupe, seq order,
+// history and error cases) over fresh connections and returns a pass/fail Report.
+// It only depends on a Dialer, so it runs against any implementation: over the
+// network with WebSocketDialer, or in-process in a server's own tests.
+package conformance
+
+import (
+	"context"
+	cryptorand "crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	v1 "arc/shared/contracts/realtime/v1"
+)
+
+const defaultStepTimeout = 5 * time.Second
+
+// Conn is one connection to the gateway carrying v1 envelopes.
+type Conn interface {
+	Read(ctx context.Context) (v1.Envelope, error)
+	Write(ctx context.Context, env v1.Envelope) error
+	Close() error
+}
+
+// Dialer opens a new, authenticated connection.
+type Dialer func(ctx context.Context) (Conn, error)
+
+// Config configures a run.
+type Config struct {
+	Dial Dialer
+	// ConversationPrefix prefixes the conversation ids the scenario joins; each case
+	// uses its own fresh conversation. The caller must be allowed to join them.
+	ConversationPrefix string
+	// StepTimeout bounds every expected reply (default 5s).
+	StepTimeout time.Duration
+	// QuietPeriod is how long "nothing arrives" assertions wait (default StepTimeout/5).
+	QuietPeriod time.Duration
+}
+
+// Result is the outcome of one case.
+type Result struct {
+	Name     string        `json:"name"`
+	Passed   bool          `json:"passed"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// Report is the outcome of a run.
+type Report struct {
+	Results []Result `json:"results"`
+	Passed  int      `json:"passed"`
+	Failed  int      `json:"failed"`
+}
+
+// OK reports whether every case passed.
+func (r Report) OK() bool { return r.Failed == 0 && r.Passed > 0 }
+
+// Case is one named scenario step.
+type Case struct {
+	Name string
+	Run  func(ctx context.Context, s *Scenario) error
+}
+
+// Cases is the v1 conformance scenario, in execution order.
+var Cases = []Case{
+	{"hello", caseHello},
+	{"join_echo", caseJoinEcho},
+	{"send_requires_join", caseSendRequiresJoin},
+	{"send_ack", caseSendAck},
+	{"fanout", caseFanout},
+	{"dedupe_client_msg_id", caseDedupe},
+	{"seq_monotonic", caseSeqMonotonic},
+	{"history_fetch", caseHistory},
+	{"reject_unknown_type", caseUnknownType},
+	{"reject_unsupported_version", caseUnsupportedVersion},
+	{"reject_empty_text", caseEmptyText},
+}
+
+// Run executes Cases and reports each outcome; it never stops at the first failure.
+func Run(ctx context.Context, cfg Config) Report {
+	if cfg.StepTimeout <= 0 {
+		cfg.StepTimeout = defaultStepTimeout
+	}
+	if cfg.QuietPeriod <= 0 {
+		cfg.QuietPeriod = cfg.StepTimeout / 5
+	}
+	if cfg.ConversationPrefix == "" {
+		cfg.ConversationPrefix = "conformance-"
+	}
+	run := randomID()[:8]
+
+	var rep Report
+	for _, c := range Cases {
+		s := &Scenario{cfg: cfg, conv: fmt.Sprintf("%s%s-%s", cfg.ConversationPrefix, run, c.Name)}
+		start := time.Now()
+		err := c.Run(ctx, s)
+		s.closeAll()
+
+		res := Result{Name: c.Name, Passed: err == nil, Duration: time.Since(start)}
+		if err != nil {
+			res.Error = err.Error()
+			rep.Failed++
+		} else {
+			rep.Passed++
+		}
+		rep.Results = append(rep.Results, res)
+	}
+	return rep
+}
+
+// Scenario is the state of one case: its conversation and open connections.
+type Scenario struct {
+	cfg   Config
+	conv  string
+	conns []*Client
+}
+
+// Client wraps a Conn with request helpers.
+type Client struct {
+	s         *Scenario
+	conn      Conn
+	SessionID string
+}
+
+// Connect dials and completes hello.
+func (s *Scenario) Connect(ctx context.Context) (*Client, error) {
+	dctx, cancel := context.WithTimeout(ctx, s.cfg.StepTimeout)
+	defer cancel()
+	conn, err := s.cfg.Dial(dctx)
+	if err != nil {
+		return nil, fmt.Errorf("dial: %w", err)
+	}
+	c := &Client{s: s, conn: conn}
+	s.conns = append(s.conns, c)
+
+	if err := c.Write(ctx, v1.TypeHello, v1.HelloPayload{}); err != nil {
+		return nil, err
+	}
+	var ack v1.HelloAckPayload
+	if err := c.Expect(ctx, v1.TypeHelloAck, &ack); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(ack.SessionID) == "" {
+		return nil, errors.New("hello.ack: empty session_id")
+	}
+	c.SessionID = ack.SessionID
+	return c, nil
+}
+
+// Join joins the scenario's conversation and checks the echo.
+func (c *Client) Join(ctx context.Context) error {
+	if err := c.Write(ctx, v1.TypeConversationJoin, v1.ConversationJoinPayload{ConversationID: c.s.conv}); err != nil {
+		return err
+	}
+	var echo v1.ConversationJoinPayload
+	if err := c.Expect(ctx, v1.TypeConversationJoin, &echo); err != nil {
+		return err
+	}
+	if echo.ConversationID != c.s.conv {
+		return fmt.Errorf("join echo: conversation_id=%q, want %q", echo.ConversationID, c.s.conv)
+	}
+	return nil
+}
+
+// Send writes message.send and returns the ack.
+func (c *Client) Send(ctx context.Context, clientMsgID, text string) (v1.MessageAckPayload, error) {
+	err := c.Write(ctx, v1.TypeMessageSend, v1.MessageSendPayload{
+		ConversationID: c.s.conv,
+		ClientMsgID:    clientMsgID,
+		Text:           text,
+	})
+	if err != nil {
+		return v1.MessageAckPayload{}, err
+	}
+	var ack v1.MessageAckPayload
+	if err := c.Expect(ctx, v1.TypeMessageAck, &ack); err != nil {
+		return v1.MessageAckPayload{}, err
+	}
+	switch {
+	case ack.ConversationID != c.s.conv:
+		return ack, fmt.Errorf("ack: conversation_id=%q, want %q", ack.ConversationID, c.s.conv)
+	case ack.ClientMsgID != clientMsgID:
+		return ack, fmt.Errorf("ack: client_msg_id=%q, want %q", ack.ClientMsgID, clientMsgID)
+	case ack.ServerMsgID == "":
+		return ack, errors.New("ack: empty server_msg_id")
+	case ack.Seq <= 0:
+		return ack, fmt.Errorf("ack: seq=%d, want > 0", ack.Seq)
+	}
+	return ack, nil
+}
+
+// Write sends one envelope of type typ.
+func (c *Client) Write(ctx context.Context, typ string, payload any) error {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return c.WriteEnvelope(ctx, v1.Envelope{V: v1.Version, Type: typ, ID: randomID(), TS: time.Now().UTC(), Payload: b})
+}
+
+// WriteEnvelope sends env as is.
+func (c *Client) WriteEnvelope(ctx context.Context, env v1.Envelope) error {
+	wctx, cancel := context.WithTimeout(ctx, c.s.cfg.StepTimeout)
+	defer cancel()
+	if err := c.conn.Write(wctx, env); err != nil {
+		return fmt.Errorf("write %s: %w", env.Type, err)
+	}
+	return nil
+}
+
+// Expect reads until an envelope of type typ and decodes its payload into out.
+// Other event types are skipped; an error envelope fails the expectation.
+func (c *Client) Expect(ctx context.Context, typ string, out any) error {
+	rctx, cancel := context.WithTimeout(ctx, c.s.cfg.StepTimeout)
+	defer cancel()
+	for {
+		env, err := c.conn.Read(rctx)
+		if err != nil {
+			return fmt.Errorf("waiting for %s: %w", typ, err)
+		}
+		if env.Type == v1.TypeError && typ != v1.TypeError {
+			var p v1.ErrorPayload
+			_ = json.Unmarshal(env.Payload, &p)
+			return fmt.Errorf("waiting for %s: server error code=%q message=%q", typ, p.Code, p.Message)
+		}
+		if env.Type != typ {
+			continue
+		}
+		if err := env.Validate(); err != nil {
+			return fmt.Errorf("%s: invalid envelope: %w", typ, err)
+		}
+		if out != nil {
+			if err := json.Unmarshal(env.Payload, out); err != nil {
+				return fmt.Errorf("%s: decode payload: %w", typ, err)
+			}
+		}
+		return nil
+	}
+}
+
+// ExpectError reads until an error envelope and checks its code.
+func (c *Client) ExpectError(ctx context.Context, code string) error {
+	var p v1.ErrorPayload
+	if err := c.Expect(ctx, v1.TypeError, &p); err != nil {
+		return err
+	}
+	if p.Code != code {
+		return fmt.Errorf("error code=%q, want %q", p.Code, code)
+	}
+	return nil
+}
+
+// ExpectNone fails if an envelope of type typ arrives within the quiet period.
+// A cancelled WebSocket read closes the connection, so call it last on c.
+func (c *Client) ExpectNone(ctx context.Context, typ string) error {
+	rctx, cancel := context.WithTimeout(ctx, c.s.cfg.QuietPeriod)
+	defer cancel()
+	for {
+		env, err := c.conn.Read(rctx)
+		if err != nil {
+			if rctx.Err() != nil && ctx.Err() == nil {
+				return nil
+			}
+			if errors.Is(err, io.EOF) {
+				return fmt.Errorf("connection closed while expecting no %s", typ)
+			}
+			return err
+		}
+		if env.Type == typ {
+			return fmt.Errorf("unexpected %s: %s", typ, env.Payload)
+		}
+	}
+}
+
+func (s *Scenario) closeAll() {
+	for _, c := range s.conns {
+		_ = c.conn.Close()
+	}
+}
+
+func randomID() string {
+	var b [16]byte
+	_, _ = cryptorand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,63 @@
+package conformance
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	v1 "arc/shared/contracts/realtime/v1"
+
+	"github.com/coder/websocket"
+)
+
+const (
+	subprotocolV1 = "arc.realtime.v1"
+	maxReadBytes  = 1 << 20
+)
+
+// WebSocketDialer dials url with the arc.realtime.v1 subprotocol. header is sent on
+// every upgrade (e.g. Authorization and Origin).
+func WebSocketDialer(url string, header http.Header) Dialer {
+	return func(ctx context.Context) (Conn, error) {
+		c, resp, err := websocket.Dial(ctx, url, &websocket.DialOptions{
+			HTTPHeader:   header,
+			Subprotocols: []string{subprotocolV1},
+		})
+		if resp != nil && resp.Body != nil {
+			_ = resp.Body.Close()
+		}
+		if err != nil {
+			return nil, err
+		}
+		c.SetReadLimit(maxReadBytes)
+		return &wsConn{c: c}, nil
+	}
+}
+
+type wsConn struct {
+	c *websocket.Conn
+}
+
+func (w *wsConn) Read(ctx context.Context) (v1.Envelope, error) {
+	_, b, err := w.c.Read(ctx)
+	if err != nil {
+		return v1.Envelope{}, err
+	}
+	var env v1.Envelope
+	if err := json.Unmarshal(b, &env); err != nil {
+		return v1.Envelope{}, err
+	}
+	return env, nil
+}
+
+func (w *wsConn) Write(ctx context.Context, env v1.Envelope) error {
+	b, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+	return w.c.Write(ctx, websocket.MessageText, b)
+}
+
+func (w *wsConn) Close() error {
+	return w.c.Close(websocket.StatusNormalClosure, "bye")
+}
@@ -0,0 +1,7 @@
+module arc/client
+
+go 1.25.0
+
+toolchain go1.25.6
+
+require github.com/coder/websocket v1.8.14
@@ -16,7 +16,22 @@ env "local" {
   url = var.atlas_url
   dev = var.atlas_dev_url
 
+  // The schema is owned by the server's embedded migrations; list new up files here
+  // as they are added so Atlas diffs against the full desired state.
   schema {
-    src = "file://schema.sql"
+    src = [
+      "file://../../../server/go/cmd/internal/migrations/sql/0001_baseline.up.sql",
+      "file://../../../server/go/cmd/internal/migrations/sql/0002_audit_identifier_hash.up.sql",
+      "file://../../../server/go/cmd/internal/migrations/sql/0003_feature_flags.up.sql",
+      "file://../../../server/go/cmd/internal/migrations/sql/0004_invite_deliveries.up.sql",
+      "file://../../../server/go/cmd/internal/migrations/sql/0005_invite_payload.up.sql",
+      "file://../../../server/go/cmd/internal/migrations/sql/0006_invite_email_domains.up.sql",
+      "file://../../../server/go/cmd/internal/migrations/sql/0007_invite_stats_index.up.sql",
+      "file://../../../server/go/cmd/internal/migrations/sql/0008_invite_consumed_semantics.up.sql",
+      "file://../../../server/go/cmd/internal/migrations/sql/0009_reuse_incidents.up.sql",
+      "file://../../../server/go/cmd/internal/migrations/sql/0010_phone_otp.up.sql",
+      "file://../../../server/go/cmd/internal/migrations/sql/0011_session_revoke_notify.up.sql",
+      "file://../../../server/go/cmd/internal/migrations/sql/0012_ws_tickets.up.sql",
+    ]
   }
 }
@@ -12,7 +12,11 @@ import (
 )
 
 func main() {
-	if err := app.Run(); err != nil {
+	run := app.Run
+	if len(os.Args) > 1 {
+		run = func() error { return app.RunCommand(os.Args[1:]) }
+	}
+	if err := run(); err != nil {
 		slog.Error("arc.exit", "err", err)
 		os.Exit(1)
 	}
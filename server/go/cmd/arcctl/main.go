@@ -0,0 +1,27 @@
+// Package main is the arcctl operator CLI binary; see package arcctl.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"arc/cmd/internal/arcctl"
+)
+
+func main() {
+	os.Exit(run())
+}
+
+func run() int {
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	if err := arcctl.NewCommand(os.Stdout).ExecuteContext(ctx); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, "arcctl:", err)
+		return 1
+	}
+	return 0
+}
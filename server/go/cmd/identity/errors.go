@@ -81,3 +81,7 @@ func IsInvalidInput(err error) bool { return errors.Is(err, ErrInvalidInput) }
 
 // IsNotActive reports whether err represents ErrNotActive.
 func IsNotActive(err error) bool { return errors.Is(err, ErrNotActive) }
+
+// IsNotAllowed reports whether err represents ErrNotAllowed (e.g., an email outside
+// the signup domain allowlist).
+func IsNotAllowed(err error) bool { return errors.Is(err, ErrNotAllowed) }
@@ -8,4 +8,5 @@ var (
 	ErrNotFound     = errors.New("not_found")
 	ErrConflict     = errors.New("conflict")
 	ErrNotActive    = errors.New("not_active")
+	ErrNotAllowed   = errors.New("not_allowed")
 )
@@ -13,3 +13,35 @@ func NormalizeUsername(s string) string {
 func NormalizeEmail(s string) string {
 	return strings.ToLower(strings.TrimSpace(s))
 }
+
+// NormalizePhone canonicalizes an international phone number to E.164 ("+" and 7 to
+// 15 digits, no leading zero). Spaces, dots, dashes and parentheses are dropped and a
+// leading "00" is read as "+". Numbers without a country code are rejected: there is no
+// default region to assume.
+func NormalizePhone(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if rest, ok := strings.CutPrefix(s, "00"); ok {
+		s = "+" + rest
+	}
+	rest, ok := strings.CutPrefix(s, "+")
+	if !ok {
+		return "", false
+	}
+
+	var b strings.Builder
+	b.WriteByte('+')
+	for _, r := range rest {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == ' ' || r == '.' || r == '-' || r == '(' || r == ')':
+		default:
+			return "", false
+		}
+	}
+	out := b.String()
+	if n := len(out) - 1; n < 7 || n > 15 || out[1] == '0' {
+		return "", false
+	}
+	return out, true
+}
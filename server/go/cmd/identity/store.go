@@ -4,6 +4,8 @@ import (
 	"context"
 	"net"
 	"time"
+
+	"arc/cmd/internal/invite"
 )
 
 // User is Arc's canonical security principal.
@@ -15,10 +17,17 @@ type User struct {
 	EmailNorm    *string
 	// EmailVerifiedAt is nil when the email has not been verified yet.
 	EmailVerifiedAt *time.Time
+	// Phone is the E.164 number the user verified with an SMS code (nil when none);
+	// PhoneVerifiedAt is when.
+	Phone           *string
+	PhoneVerifiedAt *time.Time
 
 	DisplayName *string
 	Bio         *string
 
+	// LockedAt is set while the user is administratively locked.
+	LockedAt *time.Time
+
 	CreatedAt time.Time
 }
 
@@ -50,20 +59,6 @@ type UserAuth struct {
 	PasswordHash string
 }
 
-// Invite represents an invite token row.
-type Invite struct {
-	ID         string
-	CreatedBy  *string
-	CreatedAt  time.Time
-	ExpiresAt  time.Time
-	MaxUses    int
-	UsedCount  int
-	RevokedAt  *time.Time
-	Note       *string
-	ConsumedAt *time.Time
-	ConsumedBy *string
-}
-
 // CreateUserInput describes a user registration request.
 // At least one of Username or Email must be provided.
 type CreateUserInput struct {
@@ -78,6 +73,17 @@ type CreateUserResult struct {
 	User User
 }
 
+// UpdateUserInput describes a profile update.
+// Nil fields are left unchanged; a pointer to "" clears the field.
+// Username and email cannot both end up empty. Changing the email clears its verification.
+type UpdateUserInput struct {
+	UserID      string
+	Username    *string
+	Email       *string
+	DisplayName *string
+	Now         time.Time
+}
+
 // CreateSessionInput creates a session for an authenticated user.
 // TTL must be positive; if not, the store will apply a safe default.
 type CreateSessionInput struct {
@@ -96,50 +102,45 @@ type CreateSessionResult struct {
 	RefreshToken string
 }
 
-// CreateInviteInput describes invite creation.
-type CreateInviteInput struct {
-	CreatedBy *string
-	TTL       time.Duration
-	MaxUses   int
-	Note      *string
-	Now       time.Time
-}
-
-// CreateInviteResult returns the created invite and its plain token.
-type CreateInviteResult struct {
-	Invite Invite
-	Token  string
-}
-
 // ConsumeInviteInput describes invite consumption and user creation.
+// AllowedEmailDomains is the deployment-wide signup allowlist; it applies with or
+// without an invite, on top of the invite's own AllowedEmailDomains.
 type ConsumeInviteInput struct {
-	Token      string
-	Username   *string
-	Email      *string
-	Password   string
-	Now        time.Time
-	SessionTTL time.Duration
-	Platform   string
-	UserAgent  *string
-	IP         *net.IP
+	Token               string
+	Username            *string
+	Email               *string
+	Password            string
+	Now                 time.Time
+	SessionTTL          time.Duration
+	Platform            string
+	UserAgent           *string
+	IP                  *net.IP
+	AllowedEmailDomains []string
 }
 
 // ConsumeInviteResult returns the created user, session, and the consumed invite.
+// When the invite carries a conversation, the user is already a member of it.
 type ConsumeInviteResult struct {
 	User         User
 	Session      Session
 	RefreshToken string
-	Invite       Invite
+	Invite       invite.Invite
 }
 
 // Store is the identity/auth persistence boundary.
 type Store interface {
 	CreateUser(ctx context.Context, in CreateUserInput) (CreateUserResult, error)
 	GetUserByID(ctx context.Context, userID string) (User, error)
+	UpdateUser(ctx context.Context, in UpdateUserInput) (User, error)
 	GetUserAuthByUsername(ctx context.Context, username string) (UserAuth, error)
+	GetUserAuthByID(ctx context.Context, userID string) (UserAuth, error)
 	GetUserAuthByEmail(ctx context.Context, email string) (UserAuth, error)
+	// GetUserByPhone fetches the user whose verified phone is the E.164 number phone.
+	GetUserByPhone(ctx context.Context, phone string) (User, error)
+	// SetUserPhone records phone as userID's verified number (nil clears it). Returns
+	// ErrNotFound for unknown users and a phone ConflictError when another user has it.
+	SetUserPhone(ctx context.Context, userID string, phone *string, now time.Time) error
 	CreateSession(ctx context.Context, in CreateSessionInput) (CreateSessionResult, error)
-	CreateInvite(ctx context.Context, in CreateInviteInput) (CreateInviteResult, error)
 	ConsumeInviteAndCreateUser(ctx context.Context, in ConsumeInviteInput) (ConsumeInviteResult, error)
 
 	// RotateRefreshToken rotates refresh token for an active session.
@@ -156,4 +157,10 @@ type Store interface {
 
 	RevokeSession(ctx context.Context, sessionID string, now time.Time) error
 	RevokeAllSessions(ctx context.Context, userID string, now time.Time) error
+
+	// SetUserLocked locks (locked=true) or unlocks a user. Returns ErrNotFound for unknown users.
+	SetUserLocked(ctx context.Context, userID string, locked bool, reason *string, now time.Time) error
+
+	// DeleteUser permanently deletes a user and its owned rows. Returns ErrNotFound for unknown users.
+	DeleteUser(ctx context.Context, userID string) error
 }
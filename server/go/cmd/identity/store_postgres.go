@@ -10,6 +10,11 @@ import (
 	"strings"
 	"time"
 
+	"arc/cmd/internal/dbroute"
+	"arc/cmd/internal/dbtrace"
+	"arc/cmd/internal/invite"
+	"arc/cmd/internal/pgutil"
+
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -23,8 +28,12 @@ import (
 // - RotateRefreshToken is fully atomic and serialized via SELECT ... FOR UPDATE on the session row.
 // - Errors are mapped to identity sentinel kinds where appropriate.
 type PostgresStore struct {
-	pool   *pgxpool.Pool
-	schema string
+	pool *pgxpool.Pool
+	// readPool serves replica-safe reads (nil uses pool); see WithReadPool.
+	readPool *pgxpool.Pool
+	schema   string
+	// invites owns the invites table; see Invites.
+	invites *invite.Service
 }
 
 // PostgresOption configures the store.
@@ -48,6 +57,16 @@ func WithSchema(schema string) PostgresOption {
 	}
 }
 
+// WithReadPool routes GetUserByID to a read replica unless the context requires
+// the primary (dbroute.RequirePrimary). Credential lookups, lock checks inside
+// transactions and all writes stay on the primary pool. A nil pool is ignored.
+func WithReadPool(pool *pgxpool.Pool) PostgresOption {
+	return func(s *PostgresStore) error {
+		s.readPool = pool
+		return nil
+	}
+}
+
 // NewPostgresStore constructs a PostgresStore with secure defaults.
 func NewPostgresStore(pool *pgxpool.Pool, opts ...PostgresOption) (*PostgresStore, error) {
 	st := &PostgresStore{
@@ -65,9 +84,22 @@ func NewPostgresStore(pool *pgxpool.Pool, opts ...PostgresOption) (*PostgresStor
 	if st.pool == nil {
 		return nil, fmt.Errorf("identity: nil pool")
 	}
+
+	inviteStore, err := invite.NewPostgresStore(st.pool, invite.WithSchema(st.schema), invite.WithReadPool(st.readPool))
+	if err != nil {
+		return nil, err
+	}
+	if st.invites, err = invite.NewService(inviteStore); err != nil {
+		return nil, err
+	}
 	return st, nil
 }
 
+// Invites returns the invite service sharing this store's pool and schema.
+func (s *PostgresStore) Invites() *invite.Service {
+	return s.invites
+}
+
 const (
 	defaultSessionTTL = 30 * 24 * time.Hour
 	maxSessionTTL     = 180 * 24 * time.Hour
@@ -76,6 +108,7 @@ const (
 // CreateUser creates a new user and its credentials transactionally.
 func (s *PostgresStore) CreateUser(ctx context.Context, in CreateUserInput) (CreateUserResult, error) {
 	const op = "identity.CreateUser"
+	ctx = dbtrace.WithOp(ctx, "identity.create_user")
 
 	if s == nil || s.pool == nil {
 		return CreateUserResult{}, OpError{Op: op, Kind: ErrInvalidInput, Msg: "nil store"}
@@ -110,9 +143,10 @@ func (s *PostgresStore) CreateUser(ctx context.Context, in CreateUserInput) (Cre
 	return CreateUserResult{User: user}, nil
 }
 
-// GetUserByID fetches a user by ID.
+// GetUserByID fetches a user by ID, from the read replica when one is configured.
 func (s *PostgresStore) GetUserByID(ctx context.Context, userID string) (User, error) {
 	const op = "identity.GetUserByID"
+	ctx = dbtrace.WithOp(ctx, "identity.get_user_by_id")
 
 	if s == nil || s.pool == nil {
 		return User{}, OpError{Op: op, Kind: ErrInvalidInput, Msg: "nil store"}
@@ -128,8 +162,8 @@ func (s *PostgresStore) GetUserByID(ctx context.Context, userID string) (User, e
 	users := pgIdent(s.schema, "users")
 
 	var out User
-	err := s.pool.QueryRow(ctx,
-		`SELECT id, username, username_norm, email, email_norm, email_verified_at, display_name, bio, created_at
+	err := dbroute.Reader(ctx, s.pool, s.readPool).QueryRow(ctx,
+		`SELECT id, username, username_norm, email, email_norm, email_verified_at, phone, phone_verified_at, display_name, bio, locked_at, created_at
 		   FROM `+users+`
 		  WHERE id = $1`,
 		userID,
@@ -140,8 +174,11 @@ func (s *PostgresStore) GetUserByID(ctx context.Context, userID string) (User, e
 		&out.Email,
 		&out.EmailNorm,
 		&out.EmailVerifiedAt,
+		&out.Phone,
+		&out.PhoneVerifiedAt,
 		&out.DisplayName,
 		&out.Bio,
+		&out.LockedAt,
 		&out.CreatedAt,
 	)
 	if err != nil {
@@ -153,9 +190,130 @@ func (s *PostgresStore) GetUserByID(ctx context.Context, userID string) (User, e
 	return out, nil
 }
 
+// UpdateUser applies a partial profile update and returns the updated user.
+// Returns ErrNotFound for unknown users and ConflictError for taken usernames/emails.
+func (s *PostgresStore) UpdateUser(ctx context.Context, in UpdateUserInput) (User, error) {
+	const op = "identity.UpdateUser"
+	ctx = dbtrace.WithOp(ctx, "identity.update_user")
+
+	if s == nil || s.pool == nil {
+		return User{}, OpError{Op: op, Kind: ErrInvalidInput, Msg: "nil store"}
+	}
+	if err := ctx.Err(); err != nil {
+		return User{}, err
+	}
+	userID := strings.TrimSpace(in.UserID)
+	if userID == "" {
+		return User{}, pgInvalid(op, "missing user_id")
+	}
+
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.ReadCommitted,
+		AccessMode: pgx.ReadWrite,
+	})
+	if err != nil {
+		return User{}, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	users := pgIdent(s.schema, "users")
+
+	var cur User
+	err = tx.QueryRow(ctx,
+		`SELECT id, username, username_norm, email, email_norm, email_verified_at, phone, phone_verified_at, display_name, bio, locked_at, created_at
+		   FROM `+users+`
+		  WHERE id = $1
+		  FOR UPDATE`,
+		userID,
+	).Scan(
+		&cur.ID,
+		&cur.Username,
+		&cur.UsernameNorm,
+		&cur.Email,
+		&cur.EmailNorm,
+		&cur.EmailVerifiedAt,
+		&cur.Phone,
+		&cur.PhoneVerifiedAt,
+		&cur.DisplayName,
+		&cur.Bio,
+		&cur.LockedAt,
+		&cur.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return User{}, ErrNotFound
+		}
+		return User{}, err
+	}
+
+	next := cur
+	if in.Username != nil {
+		next.Username = pgTrimPtr(in.Username)
+		next.UsernameNorm = nil
+		if next.Username != nil {
+			n := NormalizeUsername(*next.Username)
+			next.UsernameNorm = &n
+		}
+	}
+	if in.Email != nil {
+		next.Email = pgTrimPtr(in.Email)
+		next.EmailNorm = nil
+		if next.Email != nil {
+			n := NormalizeEmail(*next.Email)
+			next.EmailNorm = &n
+		}
+		if !equalStrPtr(next.EmailNorm, cur.EmailNorm) {
+			next.EmailVerifiedAt = nil
+		}
+	}
+	if in.DisplayName != nil {
+		next.DisplayName = pgTrimPtr(in.DisplayName)
+	}
+	if next.Username == nil && next.Email == nil {
+		return User{}, pgInvalid(op, "username or email is required")
+	}
+
+	_, err = tx.Exec(ctx,
+		`UPDATE `+users+`
+		    SET username = $2,
+		        username_norm = $3,
+		        email = $4,
+		        email_norm = $5,
+		        email_verified_at = $6,
+		        display_name = $7
+		  WHERE id = $1`,
+		userID,
+		next.Username,
+		next.UsernameNorm,
+		next.Email,
+		next.EmailNorm,
+		next.EmailVerifiedAt,
+		next.DisplayName,
+	)
+	if err != nil {
+		if field, ok := pgClassifyUniqueViolation(err); ok {
+			return User{}, ConflictError{Op: op, Field: field}
+		}
+		return User{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return User{}, err
+	}
+	return next, nil
+}
+
+func equalStrPtr(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
 // GetUserAuthByUsername fetches a user + credentials by normalized username.
 func (s *PostgresStore) GetUserAuthByUsername(ctx context.Context, username string) (UserAuth, error) {
 	const op = "identity.GetUserAuthByUsername"
+	ctx = dbtrace.WithOp(ctx, "identity.get_user_auth_by_username")
 
 	if s == nil || s.pool == nil {
 		return UserAuth{}, OpError{Op: op, Kind: ErrInvalidInput, Msg: "nil store"}
@@ -174,7 +332,7 @@ func (s *PostgresStore) GetUserAuthByUsername(ctx context.Context, username stri
 
 	var out UserAuth
 	err := s.pool.QueryRow(ctx,
-		`SELECT u.id, u.username, u.username_norm, u.email, u.email_norm, u.email_verified_at, u.display_name, u.bio, u.created_at, c.password_hash
+		`SELECT u.id, u.username, u.username_norm, u.email, u.email_norm, u.email_verified_at, u.phone, u.phone_verified_at, u.display_name, u.bio, u.locked_at, u.created_at, c.password_hash
 		   FROM `+users+` u
 		   JOIN `+creds+` c ON c.user_id = u.id
 		  WHERE u.username_norm = $1`,
@@ -186,8 +344,61 @@ func (s *PostgresStore) GetUserAuthByUsername(ctx context.Context, username stri
 		&out.User.Email,
 		&out.User.EmailNorm,
 		&out.User.EmailVerifiedAt,
+		&out.User.Phone,
+		&out.User.PhoneVerifiedAt,
+		&out.User.DisplayName,
+		&out.User.Bio,
+		&out.User.LockedAt,
+		&out.User.CreatedAt,
+		&out.PasswordHash,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return UserAuth{}, ErrNotFound
+		}
+		return UserAuth{}, err
+	}
+	return out, nil
+}
+
+// GetUserAuthByID fetches a user + credentials by user ID.
+func (s *PostgresStore) GetUserAuthByID(ctx context.Context, userID string) (UserAuth, error) {
+	const op = "identity.GetUserAuthByID"
+	ctx = dbtrace.WithOp(ctx, "identity.get_user_auth_by_id")
+
+	if s == nil || s.pool == nil {
+		return UserAuth{}, OpError{Op: op, Kind: ErrInvalidInput, Msg: "nil store"}
+	}
+	if err := ctx.Err(); err != nil {
+		return UserAuth{}, err
+	}
+	userID = strings.TrimSpace(userID)
+	if userID == "" {
+		return UserAuth{}, pgInvalid(op, "missing user id")
+	}
+
+	users := pgIdent(s.schema, "users")
+	creds := pgIdent(s.schema, "user_credentials")
+
+	var out UserAuth
+	err := s.pool.QueryRow(ctx,
+		`SELECT u.id, u.username, u.username_norm, u.email, u.email_norm, u.email_verified_at, u.phone, u.phone_verified_at, u.display_name, u.bio, u.locked_at, u.created_at, c.password_hash
+		   FROM `+users+` u
+		   JOIN `+creds+` c ON c.user_id = u.id
+		  WHERE u.id = $1`,
+		userID,
+	).Scan(
+		&out.User.ID,
+		&out.User.Username,
+		&out.User.UsernameNorm,
+		&out.User.Email,
+		&out.User.EmailNorm,
+		&out.User.EmailVerifiedAt,
+		&out.User.Phone,
+		&out.User.PhoneVerifiedAt,
 		&out.User.DisplayName,
 		&out.User.Bio,
+		&out.User.LockedAt,
 		&out.User.CreatedAt,
 		&out.PasswordHash,
 	)
@@ -203,6 +414,7 @@ func (s *PostgresStore) GetUserAuthByUsername(ctx context.Context, username stri
 // GetUserAuthByEmail fetches a user + credentials by normalized email.
 func (s *PostgresStore) GetUserAuthByEmail(ctx context.Context, email string) (UserAuth, error) {
 	const op = "identity.GetUserAuthByEmail"
+	ctx = dbtrace.WithOp(ctx, "identity.get_user_auth_by_email")
 
 	if s == nil || s.pool == nil {
 		return UserAuth{}, OpError{Op: op, Kind: ErrInvalidInput, Msg: "nil store"}
@@ -221,7 +433,7 @@ func (s *PostgresStore) GetUserAuthByEmail(ctx context.Context, email string) (U
 
 	var out UserAuth
 	err := s.pool.QueryRow(ctx,
-		`SELECT u.id, u.username, u.username_norm, u.email, u.email_norm, u.email_verified_at, u.display_name, u.bio, u.created_at, c.password_hash
+		`SELECT u.id, u.username, u.username_norm, u.email, u.email_norm, u.email_verified_at, u.phone, u.phone_verified_at, u.display_name, u.bio, u.locked_at, u.created_at, c.password_hash
 		   FROM `+users+` u
 		   JOIN `+creds+` c ON c.user_id = u.id
 		  WHERE u.email_norm = $1`,
@@ -233,8 +445,11 @@ func (s *PostgresStore) GetUserAuthByEmail(ctx context.Context, email string) (U
 		&out.User.Email,
 		&out.User.EmailNorm,
 		&out.User.EmailVerifiedAt,
+		&out.User.Phone,
+		&out.User.PhoneVerifiedAt,
 		&out.User.DisplayName,
 		&out.User.Bio,
+		&out.User.LockedAt,
 		&out.User.CreatedAt,
 		&out.PasswordHash,
 	)
@@ -250,6 +465,7 @@ func (s *PostgresStore) GetUserAuthByEmail(ctx context.Context, email string) (U
 // CreateSession creates a new refresh-token backed session for a user.
 func (s *PostgresStore) CreateSession(ctx context.Context, in CreateSessionInput) (CreateSessionResult, error) {
 	const op = "identity.CreateSession"
+	ctx = dbtrace.WithOp(ctx, "identity.create_session")
 
 	if s == nil || s.pool == nil {
 		return CreateSessionResult{}, OpError{Op: op, Kind: ErrInvalidInput, Msg: "nil store"}
@@ -355,77 +571,11 @@ func (s *PostgresStore) CreateSession(ctx context.Context, in CreateSessionInput
 	return CreateSessionResult{Session: out, RefreshToken: plain}, nil
 }
 
-// CreateInvite creates a new invite token.
-func (s *PostgresStore) CreateInvite(ctx context.Context, in CreateInviteInput) (CreateInviteResult, error) {
-	const op = "identity.CreateInvite"
-
-	if s == nil || s.pool == nil {
-		return CreateInviteResult{}, OpError{Op: op, Kind: ErrInvalidInput, Msg: "nil store"}
-	}
-	if err := ctx.Err(); err != nil {
-		return CreateInviteResult{}, err
-	}
-
-	now := in.Now
-	if now.IsZero() {
-		now = time.Now().UTC()
-	}
-	ttl := in.TTL
-	if ttl <= 0 {
-		ttl = 7 * 24 * time.Hour
-	}
-	maxUses := in.MaxUses
-	if maxUses <= 0 {
-		maxUses = 1
-	}
-	note := pgTrimPtr(in.Note)
-	if note != nil && len(*note) > 512 {
-		return CreateInviteResult{}, pgInvalid(op, "note too long")
-	}
-
-	tokenPlain, err := NewOpaqueToken(32)
-	if err != nil {
-		return CreateInviteResult{}, err
-	}
-	tokenHash := HashRefreshTokenHex(tokenPlain)
-
-	inviteID, err := NewULID(now)
-	if err != nil {
-		return CreateInviteResult{}, err
-	}
-
-	expiresAt := now.Add(ttl)
-	invites := pgIdent(s.schema, "invites")
-
-	_, err = s.pool.Exec(ctx,
-		`INSERT INTO `+invites+` (
-		     id, token_hash, created_by, created_at, expires_at, max_uses, used_count, note
-		   ) VALUES ($1, $2, $3, $4, $5, $6, 0, $7)`,
-		inviteID, tokenHash, pgTrimPtr(in.CreatedBy), now, expiresAt, maxUses, note,
-	)
-	if err != nil {
-		if field, ok := pgClassifyUniqueViolation(err); ok {
-			return CreateInviteResult{}, ConflictError{Op: op, Field: field}
-		}
-		return CreateInviteResult{}, err
-	}
-
-	out := Invite{
-		ID:        inviteID,
-		CreatedBy: pgTrimPtr(in.CreatedBy),
-		CreatedAt: now,
-		ExpiresAt: expiresAt,
-		MaxUses:   maxUses,
-		UsedCount: 0,
-		Note:      note,
-	}
-
-	return CreateInviteResult{Invite: out, Token: tokenPlain}, nil
-}
-
 // ConsumeInviteAndCreateUser consumes an invite and creates a user + initial session atomically.
+// The transaction is retried on serialization failures and deadlocks (pgutil.WithTx).
 func (s *PostgresStore) ConsumeInviteAndCreateUser(ctx context.Context, in ConsumeInviteInput) (ConsumeInviteResult, error) {
 	const op = "identity.ConsumeInvite"
+	ctx = dbtrace.WithOp(ctx, "identity.consume_invite_and_create_user")
 
 	if s == nil || s.pool == nil {
 		return ConsumeInviteResult{}, OpError{Op: op, Kind: ErrInvalidInput, Msg: "nil store"}
@@ -441,84 +591,60 @@ func (s *PostgresStore) ConsumeInviteAndCreateUser(ctx context.Context, in Consu
 		now = time.Now().UTC()
 	}
 
-	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{
-		IsoLevel:   pgx.ReadCommitted,
-		AccessMode: pgx.ReadWrite,
-	})
-	if err != nil {
-		return ConsumeInviteResult{}, err
-	}
-	defer func() { _ = tx.Rollback(ctx) }()
-
-	// Lock invite row to ensure single-use (if provided).
-	var invite Invite
-	if token != "" {
-		var err error
-		invite, err = s.lockInviteByToken(ctx, tx, token)
-		if err != nil {
-			return ConsumeInviteResult{}, err
-		}
-		if invite.RevokedAt != nil {
-			return ConsumeInviteResult{}, ErrNotActive
-		}
-		if !invite.ExpiresAt.After(now) {
-			return ConsumeInviteResult{}, ErrNotActive
-		}
-		if invite.MaxUses > 0 && invite.UsedCount >= invite.MaxUses {
-			return ConsumeInviteResult{}, ErrNotActive
+	var out ConsumeInviteResult
+	err := pgutil.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		// Lock the invite row so its uses cannot be exceeded concurrently (if provided).
+		var inv invite.Invite
+		if token != "" {
+			var err error
+			inv, err = s.invites.LockTx(ctx, tx, token, now)
+			if err != nil {
+				return inviteError(err)
+			}
 		}
-	}
 
-	// Create user + credentials.
-	user, err := s.insertUserAndCredsTx(ctx, tx, op, CreateUserInput{
-		Username: in.Username,
-		Email:    in.Email,
-		Password: in.Password,
-		Now:      now,
-	}, now)
-	if err != nil {
-		return ConsumeInviteResult{}, err
-	}
+		// Checked under the invite lock so the allowlist in force is the one consumed against.
+		if !signupEmailAllowed(in.Email, in.AllowedEmailDomains) || !signupEmailAllowed(in.Email, inv.AllowedEmailDomains) {
+			return OpError{Op: op, Kind: ErrNotAllowed, Msg: "email domain not allowed"}
+		}
 
-	// Create session row.
-	refreshPlain, session, err := s.insertSessionTx(ctx, tx, user.ID, in, now)
-	if err != nil {
-		return ConsumeInviteResult{}, err
-	}
+		// Create user + credentials.
+		user, err := s.insertUserAndCredsTx(ctx, tx, op, CreateUserInput{
+			Username: in.Username,
+			Email:    in.Email,
+			Password: in.Password,
+			Now:      now,
+		}, now)
+		if err != nil {
+			return err
+		}
 
-	// Mark invite consumed when present.
-	if invite.ID != "" {
-		invites := pgIdent(s.schema, "invites")
-		tag, err := tx.Exec(ctx,
-			`UPDATE `+invites+`
-			    SET used_count = used_count + 1,
-			        consumed_at = $1,
-			        consumed_by = $2
-			  WHERE id = $3
-			    AND (max_uses <= 0 OR used_count < max_uses)`,
-			now, user.ID, invite.ID,
-		)
+		// Create session row.
+		refreshPlain, session, err := s.insertSessionTx(ctx, tx, user.ID, in, now)
 		if err != nil {
-			return ConsumeInviteResult{}, err
+			return err
 		}
-		if tag.RowsAffected() == 0 {
-			return ConsumeInviteResult{}, ErrNotActive
+
+		// Record the use and join the invite's conversation, if any.
+		if inv.ID != "" {
+			inv, err = s.invites.ConsumeTx(ctx, tx, inv, user.ID, now)
+			if err != nil {
+				return inviteError(err)
+			}
 		}
-		invite.UsedCount++
-		invite.ConsumedAt = &now
-		invite.ConsumedBy = &user.ID
-	}
 
-	if err := tx.Commit(ctx); err != nil {
+		out = ConsumeInviteResult{
+			User:         user,
+			Session:      session,
+			RefreshToken: refreshPlain,
+			Invite:       inv,
+		}
+		return nil
+	})
+	if err != nil {
 		return ConsumeInviteResult{}, err
 	}
-
-	return ConsumeInviteResult{
-		User:         user,
-		Session:      session,
-		RefreshToken: refreshPlain,
-		Invite:       invite,
-	}, nil
+	return out, nil
 }
 
 // RotateRefreshToken rotates the refresh token for an active session.
@@ -528,8 +654,11 @@ func (s *PostgresStore) ConsumeInviteAndCreateUser(ctx context.Context, in Consu
 // - session is missing, expired, revoked, already replaced, OR
 // - old token does not match, OR
 // - concurrent rotation already won.
+//
+// The transaction is retried on serialization failures and deadlocks (pgutil.WithTx).
 func (s *PostgresStore) RotateRefreshToken(ctx context.Context, sessionID string, oldRefreshToken string, now time.Time) (string, string, error) {
 	const op = "identity.RotateRefreshToken"
+	ctx = dbtrace.WithOp(ctx, "identity.rotate_refresh_token")
 
 	if s == nil || s.pool == nil {
 		return "", "", OpError{Op: op, Kind: ErrInvalidInput, Msg: "nil store"}
@@ -560,116 +689,109 @@ func (s *PostgresStore) RotateRefreshToken(ctx context.Context, sessionID string
 
 	sessions := pgIdent(s.schema, "sessions")
 
-	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{
-		IsoLevel:   pgx.ReadCommitted,
-		AccessMode: pgx.ReadWrite,
-	})
-	if err != nil {
-		return "", "", err
-	}
-	defer func() { _ = tx.Rollback(ctx) }()
-
-	// Lock the session row to serialize rotations (single-writer).
-	var (
-		userID     string
-		dbHash     string
-		revokedAt  *time.Time
-		expiresAt  time.Time
-		replacedBy *string
-		platform   string
-		userAgent  *string
-		ipText     *string
-	)
+	err = pgutil.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		// Lock the session row to serialize rotations (single-writer).
+		var (
+			userID     string
+			dbHash     string
+			revokedAt  *time.Time
+			expiresAt  time.Time
+			replacedBy *string
+			platform   string
+			userAgent  *string
+			ipText     *string
+		)
 
-	err = tx.QueryRow(ctx,
-		`SELECT user_id, refresh_token_hash, revoked_at, expires_at, replaced_by_session_id, platform, user_agent, ip::text
-		   FROM `+sessions+`
-		  WHERE id = $1
-		  FOR UPDATE`,
-		sessionID,
-	).Scan(&userID, &dbHash, &revokedAt, &expiresAt, &replacedBy, &platform, &userAgent, &ipText)
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return "", "", notActiveRotate()
+		err := tx.QueryRow(ctx,
+			`SELECT user_id, refresh_token_hash, revoked_at, expires_at, replaced_by_session_id, platform, user_agent, ip::text
+			   FROM `+sessions+`
+			  WHERE id = $1
+			  FOR UPDATE`,
+			sessionID,
+		).Scan(&userID, &dbHash, &revokedAt, &expiresAt, &replacedBy, &platform, &userAgent, &ipText)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return notActiveRotate()
+			}
+			return err
 		}
-		return "", "", err
-	}
 
-	// Active checks.
-	if revokedAt != nil {
-		return "", "", notActiveRotate()
-	}
-	if !expiresAt.After(now) {
-		return "", "", notActiveRotate()
-	}
-	if replacedBy != nil && strings.TrimSpace(*replacedBy) != "" {
-		return "", "", notActiveRotate()
-	}
+		// Active checks.
+		if revokedAt != nil {
+			return notActiveRotate()
+		}
+		if !expiresAt.After(now) {
+			return notActiveRotate()
+		}
+		if replacedBy != nil && strings.TrimSpace(*replacedBy) != "" {
+			return notActiveRotate()
+		}
 
-	// Constant-time compare of stored hash vs computed hash.
-	// English comment:
-	// - Hashes are expected to be 64-char hex (SHA-256 / HMAC-SHA256).
-	// - Enforce fixed-length comparison to avoid length-based side channels.
-	if !ctEqHex64(dbHash, oldHash) {
-		return "", "", notActiveRotate()
-	}
+		// Constant-time compare of stored hash vs computed hash.
+		// English comment:
+		// - Hashes are expected to be 64-char hex (SHA-256 / HMAC-SHA256).
+		// - Enforce fixed-length comparison to avoid length-based side channels.
+		if !ctEqHex64(dbHash, oldHash) {
+			return notActiveRotate()
+		}
 
-	// Create replacement session row (rotation does not extend lifetime).
-	newSessionID, err := NewULID(now)
-	if err != nil {
-		return "", "", err
-	}
+		// Create replacement session row (rotation does not extend lifetime).
+		newSessionID, err := NewULID(now)
+		if err != nil {
+			return err
+		}
 
-	var ipVal any
-	if ipText != nil && strings.TrimSpace(*ipText) != "" {
-		ipVal = *ipText
-	}
+		var ipVal any
+		if ipText != nil && strings.TrimSpace(*ipText) != "" {
+			ipVal = *ipText
+		}
 
-	// Insert new session first, then revoke+link old one.
-	_, err = tx.Exec(ctx,
-		`INSERT INTO `+sessions+` (
-		     id, user_id, refresh_token_hash, created_at, last_used_at, expires_at, revoked_at,
-		     replaced_by_session_id, platform, user_agent, ip
-		   ) VALUES ($1, $2, $3, $4, $4, $5, NULL, NULL, $6, $7, $8)`,
-		newSessionID,
-		userID,
-		newHash,
-		now,
-		expiresAt,
-		platform,
-		userAgent,
-		ipVal,
-	)
-	if err != nil {
-		if field, ok := pgClassifyUniqueViolation(err); ok {
-			return "", "", ConflictError{Op: op, Field: field}
+		// Insert new session first, then revoke+link old one.
+		_, err = tx.Exec(ctx,
+			`INSERT INTO `+sessions+` (
+			     id, user_id, refresh_token_hash, created_at, last_used_at, expires_at, revoked_at,
+			     replaced_by_session_id, platform, user_agent, ip
+			   ) VALUES ($1, $2, $3, $4, $4, $5, NULL, NULL, $6, $7, $8)`,
+			newSessionID,
+			userID,
+			newHash,
+			now,
+			expiresAt,
+			platform,
+			userAgent,
+			ipVal,
+		)
+		if err != nil {
+			if field, ok := pgClassifyUniqueViolation(err); ok {
+				return ConflictError{Op: op, Field: field}
+			}
+			return err
 		}
-		return "", "", err
-	}
 
-	// Revoke old session and link to replacement (single-writer enforcement).
-	ct, err := tx.Exec(ctx,
-		`UPDATE `+sessions+`
-		    SET revoked_at = $1,
-		        last_used_at = $1,
-		        replaced_by_session_id = $2
-		  WHERE id = $3
-		    AND revoked_at IS NULL
-		    AND expires_at > $1
-		    AND replaced_by_session_id IS NULL
-		    AND refresh_token_hash = $4`,
-		now, newSessionID, sessionID, oldHash,
-	)
+		// Revoke old session and link to replacement (single-writer enforcement).
+		ct, err := tx.Exec(ctx,
+			`UPDATE `+sessions+`
+			    SET revoked_at = $1,
+			        last_used_at = $1,
+			        replaced_by_session_id = $2
+			  WHERE id = $3
+			    AND revoked_at IS NULL
+			    AND expires_at > $1
+			    AND replaced_by_session_id IS NULL
+			    AND refresh_token_hash = $4`,
+			now, newSessionID, sessionID, oldHash,
+		)
+		if err != nil {
+			return err
+		}
+		if ct.RowsAffected() != 1 {
+			return notActiveRotate()
+		}
+		return nil
+	})
 	if err != nil {
 		return "", "", err
 	}
-	if ct.RowsAffected() != 1 {
-		return "", "", notActiveRotate()
-	}
-
-	if err := tx.Commit(ctx); err != nil {
-		return "", "", err
-	}
 
 	return newPlain, newHash, nil
 }
@@ -678,6 +800,7 @@ func (s *PostgresStore) RotateRefreshToken(ctx context.Context, sessionID string
 // Returns ErrNotFound if the session does not exist.
 func (s *PostgresStore) RevokeSession(ctx context.Context, sessionID string, now time.Time) error {
 	const op = "identity.RevokeSession"
+	ctx = dbtrace.WithOp(ctx, "identity.revoke_session")
 
 	if s == nil || s.pool == nil {
 		return OpError{Op: op, Kind: ErrInvalidInput, Msg: "nil store"}
@@ -712,6 +835,7 @@ func (s *PostgresStore) RevokeSession(ctx context.Context, sessionID string, now
 // RevokeAllSessions revokes all sessions for a user (idempotent).
 func (s *PostgresStore) RevokeAllSessions(ctx context.Context, userID string, now time.Time) error {
 	const op = "identity.RevokeAllSessions"
+	ctx = dbtrace.WithOp(ctx, "identity.revoke_all_sessions")
 
 	if s == nil || s.pool == nil {
 		return OpError{Op: op, Kind: ErrInvalidInput, Msg: "nil store"}
@@ -739,10 +863,184 @@ func (s *PostgresStore) RevokeAllSessions(ctx context.Context, userID string, no
 	return err
 }
 
+// SetUserLocked locks or unlocks a user account (idempotent).
+//
+// Locking does not revoke sessions by itself; callers pair it with RevokeAllSessions.
+func (s *PostgresStore) SetUserLocked(ctx context.Context, userID string, locked bool, reason *string, now time.Time) error {
+	const op = "identity.SetUserLocked"
+	ctx = dbtrace.WithOp(ctx, "identity.set_user_locked")
+
+	if s == nil || s.pool == nil {
+		return OpError{Op: op, Kind: ErrInvalidInput, Msg: "nil store"}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	userID = strings.TrimSpace(userID)
+	if userID == "" {
+		return OpError{Op: op, Kind: ErrInvalidInput, Msg: "missing user_id"}
+	}
+	if now.IsZero() {
+		now = time.Now().UTC()
+	}
+
+	users := pgIdent(s.schema, "users")
+
+	var ct pgconn.CommandTag
+	var err error
+	if locked {
+		ct, err = s.pool.Exec(ctx,
+			`UPDATE `+users+`
+			    SET locked_at = COALESCE(locked_at, $1),
+			        locked_reason = $2
+			  WHERE id = $3`,
+			now, pgTrimPtr(reason), userID,
+		)
+	} else {
+		ct, err = s.pool.Exec(ctx,
+			`UPDATE `+users+`
+			    SET locked_at = NULL,
+			        locked_reason = NULL
+			  WHERE id = $1`,
+			userID,
+		)
+	}
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// GetUserByPhone fetches the user whose verified phone is phone, which must already be
+// normalized with NormalizePhone. It reads the primary: callers sign the user in.
+func (s *PostgresStore) GetUserByPhone(ctx context.Context, phone string) (User, error) {
+	const op = "identity.GetUserByPhone"
+	ctx = dbtrace.WithOp(ctx, "identity.get_user_by_phone")
+
+	if s == nil || s.pool == nil {
+		return User{}, OpError{Op: op, Kind: ErrInvalidInput, Msg: "nil store"}
+	}
+	if err := ctx.Err(); err != nil {
+		return User{}, err
+	}
+	if phone == "" {
+		return User{}, pgInvalid(op, "missing phone")
+	}
+
+	var out User
+	err := s.pool.QueryRow(ctx,
+		`SELECT id, username, username_norm, email, email_norm, email_verified_at, phone, phone_verified_at, display_name, bio, locked_at, created_at
+		   FROM `+pgIdent(s.schema, "users")+`
+		  WHERE phone = $1`,
+		phone,
+	).Scan(
+		&out.ID,
+		&out.Username,
+		&out.UsernameNorm,
+		&out.Email,
+		&out.EmailNorm,
+		&out.EmailVerifiedAt,
+		&out.Phone,
+		&out.PhoneVerifiedAt,
+		&out.DisplayName,
+		&out.Bio,
+		&out.LockedAt,
+		&out.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return User{}, ErrNotFound
+		}
+		return User{}, err
+	}
+	return out, nil
+}
+
+// SetUserPhone records phone (normalized with NormalizePhone) as the verified number of
+// userID, or clears it when phone is nil. Returns ErrNotFound for unknown users and
+// ConflictError{Field: "phone"} when the number belongs to another user.
+func (s *PostgresStore) SetUserPhone(ctx context.Context, userID string, phone *string, now time.Time) error {
+	const op = "identity.SetUserPhone"
+	ctx = dbtrace.WithOp(ctx, "identity.set_user_phone")
+
+	if s == nil || s.pool == nil {
+		return OpError{Op: op, Kind: ErrInvalidInput, Msg: "nil store"}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	userID = strings.TrimSpace(userID)
+	if userID == "" {
+		return pgInvalid(op, "missing user_id")
+	}
+	if phone != nil && *phone == "" {
+		return pgInvalid(op, "empty phone")
+	}
+	if now.IsZero() {
+		now = time.Now().UTC()
+	}
+
+	var verifiedAt *time.Time
+	if phone != nil {
+		verifiedAt = &now
+	}
+	ct, err := s.pool.Exec(ctx,
+		`UPDATE `+pgIdent(s.schema, "users")+`
+		    SET phone = $2,
+		        phone_verified_at = $3
+		  WHERE id = $1`,
+		userID, phone, verifiedAt,
+	)
+	if err != nil {
+		if field, ok := pgClassifyUniqueViolation(err); ok {
+			return ConflictError{Op: op, Field: field}
+		}
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// DeleteUser permanently deletes a user. Rows owned by the user (sessions, credentials)
+// are removed by ON DELETE CASCADE; references from shared rows are nulled by the schema.
+// Returns ErrNotFound for unknown users.
+func (s *PostgresStore) DeleteUser(ctx context.Context, userID string) error {
+	const op = "identity.DeleteUser"
+	ctx = dbtrace.WithOp(ctx, "identity.delete_user")
+
+	if s == nil || s.pool == nil {
+		return OpError{Op: op, Kind: ErrInvalidInput, Msg: "nil store"}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	userID = strings.TrimSpace(userID)
+	if userID == "" {
+		return OpError{Op: op, Kind: ErrInvalidInput, Msg: "missing user_id"}
+	}
+
+	users := pgIdent(s.schema, "users")
+
+	ct, err := s.pool.Exec(ctx, `DELETE FROM `+users+` WHERE id = $1`, userID)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
 // TouchSessionLastUsed updates last_used_at if session is active.
 // If session is not active, returns ErrNotActive.
 func (s *PostgresStore) TouchSessionLastUsed(ctx context.Context, sessionID string, now time.Time) error {
 	const op = "identity.TouchSessionLastUsed"
+	ctx = dbtrace.WithOp(ctx, "identity.touch_session_last_used")
 
 	if s == nil || s.pool == nil {
 		return OpError{Op: op, Kind: ErrInvalidInput, Msg: "nil store"}
@@ -781,6 +1079,7 @@ func (s *PostgresStore) TouchSessionLastUsed(ctx context.Context, sessionID stri
 // Returns ErrNotActive when token is unknown or session is not active.
 func (s *PostgresStore) GetSessionByRefreshToken(ctx context.Context, refreshToken string, now time.Time) (Session, error) {
 	const op = "identity.GetSessionByRefreshToken"
+	ctx = dbtrace.WithOp(ctx, "identity.get_session_by_refresh_token")
 
 	if s == nil || s.pool == nil {
 		return Session{}, OpError{Op: op, Kind: ErrInvalidInput, Msg: "nil store"}
@@ -1025,36 +1324,26 @@ func (s *PostgresStore) insertSessionTx(ctx context.Context, tx pgx.Tx, userID s
 	return plain, out, nil
 }
 
-func (s *PostgresStore) lockInviteByToken(ctx context.Context, tx pgx.Tx, tokenPlain string) (Invite, error) {
-	invites := pgIdent(s.schema, "invites")
-	tokenHash := HashRefreshTokenHex(tokenPlain)
+// inviteError maps invite sentinels onto identity kinds.
+func inviteError(err error) error {
+	switch {
+	case errors.Is(err, invite.ErrNotFound):
+		return ErrNotFound
+	case errors.Is(err, invite.ErrNotActive):
+		return ErrNotActive
+	default:
+		return err
+	}
+}
 
-	var out Invite
-	err := tx.QueryRow(ctx,
-		`SELECT id, created_by, created_at, expires_at, max_uses, used_count, revoked_at, note, consumed_at, consumed_by
-		   FROM `+invites+`
-		  WHERE token_hash = $1
-		  FOR UPDATE`,
-		tokenHash,
-	).Scan(
-		&out.ID,
-		&out.CreatedBy,
-		&out.CreatedAt,
-		&out.ExpiresAt,
-		&out.MaxUses,
-		&out.UsedCount,
-		&out.RevokedAt,
-		&out.Note,
-		&out.ConsumedAt,
-		&out.ConsumedBy,
-	)
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return Invite{}, ErrNotFound
-		}
-		return Invite{}, err
+// signupEmailAllowed reports whether email passes the domain allowlist; a missing
+// email passes only an empty one.
+func signupEmailAllowed(email *string, domains []string) bool {
+	if len(domains) == 0 {
+		return true
 	}
-	return out, nil
+	e := pgTrimPtr(email)
+	return e != nil && invite.EmailDomainAllowed(*e, domains)
 }
 
 // ctEqHex64 compares two expected 64-char hex strings in constant time.
@@ -1121,6 +1410,8 @@ func pgClassifyUniqueViolation(err error) (field string, ok bool) {
 		return "username", true
 	case "uq_users_email_norm":
 		return "email", true
+	case "uq_users_phone":
+		return "phone", true
 	case "uq_sessions_refresh_token_hash":
 		return "refresh_token", true
 	case "uq_invites_token_hash":
@@ -11,7 +11,9 @@ import (
 	"testing"
 	"time"
 
-	"github.com/jackc/pgx/v5"
+	"arc/cmd/internal/invite"
+	"arc/cmd/internal/migrations/migrationstest"
+
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -24,9 +26,7 @@ func TestPostgresStore_CreateUser_ConflictUsername_CaseInsensitive(t *testing.T)
 	pool := mustOpenTestPool(t)
 	defer pool.Close()
 
-	schema := mustCreateTestSchema(t, pool)
-	t.Cleanup(func() { mustDropSchema(t, pool, schema) })
-	mustApplyIdentitySchema(t, pool, schema)
+	schema := migrationstest.NewSchema(t, pool)
 
 	s := mustNewIdentityStore(t, pool, schema)
 
@@ -66,9 +66,7 @@ func TestPostgresStore_CreateUser_ConflictEmail_CaseInsensitive(t *testing.T) {
 	pool := mustOpenTestPool(t)
 	defer pool.Close()
 
-	schema := mustCreateTestSchema(t, pool)
-	t.Cleanup(func() { mustDropSchema(t, pool, schema) })
-	mustApplyIdentitySchema(t, pool, schema)
+	schema := migrationstest.NewSchema(t, pool)
 
 	s := mustNewIdentityStore(t, pool, schema)
 
@@ -108,16 +106,14 @@ func TestPostgresStore_CreateSession_WithIPAndUA(t *testing.T) {
 	pool := mustOpenTestPool(t)
 	defer pool.Close()
 
-	schema := mustCreateTestSchema(t, pool)
-	t.Cleanup(func() { mustDropSchema(t, pool, schema) })
-	mustApplyIdentitySchema(t, pool, schema)
+	schema := migrationstest.NewSchema(t, pool)
 
 	s := mustNewIdentityStore(t, pool, schema)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
 	defer cancel()
 
-	u := "session-user-" + strings.ToLower(mustNewULIDLike(t))
+	u := mustTestUsername(t, "session-user-")
 	res, err := s.CreateUser(ctx, CreateUserInput{
 		Username: &u,
 		Email:    nil,
@@ -169,16 +165,14 @@ func TestPostgresStore_RotateRefreshToken_Success_ThenRevoke(t *testing.T) {
 	pool := mustOpenTestPool(t)
 	defer pool.Close()
 
-	schema := mustCreateTestSchema(t, pool)
-	t.Cleanup(func() { mustDropSchema(t, pool, schema) })
-	mustApplyIdentitySchema(t, pool, schema)
+	schema := migrationstest.NewSchema(t, pool)
 
 	s := mustNewIdentityStore(t, pool, schema)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 25*time.Second)
 	defer cancel()
 
-	u := "rotate-user-" + strings.ToLower(mustNewULIDLike(t))
+	u := mustTestUsername(t, "rotate-user-")
 	res, err := s.CreateUser(ctx, CreateUserInput{
 		Username: &u,
 		Email:    nil,
@@ -237,16 +231,14 @@ func TestPostgresStore_RotateRefreshToken_WrongToken_ReturnsNotActive(t *testing
 	pool := mustOpenTestPool(t)
 	defer pool.Close()
 
-	schema := mustCreateTestSchema(t, pool)
-	t.Cleanup(func() { mustDropSchema(t, pool, schema) })
-	mustApplyIdentitySchema(t, pool, schema)
+	schema := migrationstest.NewSchema(t, pool)
 
 	s := mustNewIdentityStore(t, pool, schema)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 25*time.Second)
 	defer cancel()
 
-	u := "wrongtok-user-" + strings.ToLower(mustNewULIDLike(t))
+	u := mustTestUsername(t, "wrongtok-user-")
 	res, err := s.CreateUser(ctx, CreateUserInput{
 		Username: &u,
 		Email:    nil,
@@ -282,16 +274,14 @@ func TestPostgresStore_InviteConsume_Succeeds_ThenRejectsReuse(t *testing.T) {
 	pool := mustOpenTestPool(t)
 	defer pool.Close()
 
-	schema := mustCreateTestSchema(t, pool)
-	t.Cleanup(func() { mustDropSchema(t, pool, schema) })
-	mustApplyIdentitySchema(t, pool, schema)
+	schema := migrationstest.NewSchema(t, pool)
 
 	s := mustNewIdentityStore(t, pool, schema)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 25*time.Second)
 	defer cancel()
 
-	inv, err := s.CreateInvite(ctx, CreateInviteInput{
+	inv, token, err := s.Invites().CreateInvite(ctx, invite.CreateInput{
 		CreatedBy: nil,
 		TTL:       24 * time.Hour,
 		MaxUses:   1,
@@ -300,13 +290,13 @@ func TestPostgresStore_InviteConsume_Succeeds_ThenRejectsReuse(t *testing.T) {
 	if err != nil {
 		t.Fatalf("create invite: %v", err)
 	}
-	if inv.Token == "" || inv.Invite.ID == "" {
+	if token == "" || inv.ID == "" {
 		t.Fatalf("expected invite token and id")
 	}
 
-	u := "invite-user-" + strings.ToLower(mustNewULIDLike(t))
+	u := mustTestUsername(t, "invite-user-")
 	out, err := s.ConsumeInviteAndCreateUser(ctx, ConsumeInviteInput{
-		Token:      inv.Token,
+		Token:      token,
 		Username:   &u,
 		Email:      nil,
 		Password:   "very-strong-password-8",
@@ -322,8 +312,8 @@ func TestPostgresStore_InviteConsume_Succeeds_ThenRejectsReuse(t *testing.T) {
 	if out.User.ID == "" || out.Session.ID == "" || out.RefreshToken == "" {
 		t.Fatalf("expected user, session, refresh token")
 	}
-	if out.Invite.ID != inv.Invite.ID {
-		t.Fatalf("expected invite id %q, got %q", inv.Invite.ID, out.Invite.ID)
+	if out.Invite.ID != inv.ID {
+		t.Fatalf("expected invite id %q, got %q", inv.ID, out.Invite.ID)
 	}
 	if out.Invite.UsedCount != 1 {
 		t.Fatalf("expected used_count=1, got %d", out.Invite.UsedCount)
@@ -331,7 +321,7 @@ func TestPostgresStore_InviteConsume_Succeeds_ThenRejectsReuse(t *testing.T) {
 
 	// Reuse should fail.
 	_, err = s.ConsumeInviteAndCreateUser(ctx, ConsumeInviteInput{
-		Token:      inv.Token,
+		Token:      token,
 		Username:   &u,
 		Email:      nil,
 		Password:   "very-strong-password-9",
@@ -352,9 +342,7 @@ func TestPostgresStore_InviteConsume_MaxUses_AllowsMultiple(t *testing.T) {
 	pool := mustOpenTestPool(t)
 	defer pool.Close()
 
-	schema := mustCreateTestSchema(t, pool)
-	t.Cleanup(func() { mustDropSchema(t, pool, schema) })
-	mustApplyIdentitySchema(t, pool, schema)
+	schema := migrationstest.NewSchema(t, pool)
 
 	s := mustNewIdentityStore(t, pool, schema)
 
@@ -362,7 +350,7 @@ func TestPostgresStore_InviteConsume_MaxUses_AllowsMultiple(t *testing.T) {
 	defer cancel()
 
 	maxUses := 2
-	inv, err := s.CreateInvite(ctx, CreateInviteInput{
+	_, token, err := s.Invites().CreateInvite(ctx, invite.CreateInput{
 		CreatedBy: nil,
 		TTL:       24 * time.Hour,
 		MaxUses:   maxUses,
@@ -373,9 +361,9 @@ func TestPostgresStore_InviteConsume_MaxUses_AllowsMultiple(t *testing.T) {
 	}
 
 	for i := 0; i < maxUses; i++ {
-		u := fmt.Sprintf("invite-user-%d-%s", i, strings.ToLower(mustNewULIDLike(t)))
+		u := mustTestUsername(t, fmt.Sprintf("invite-user-%d-", i))
 		out, err := s.ConsumeInviteAndCreateUser(ctx, ConsumeInviteInput{
-			Token:      inv.Token,
+			Token:      token,
 			Username:   &u,
 			Email:      nil,
 			Password:   "very-strong-password-8",
@@ -391,9 +379,9 @@ func TestPostgresStore_InviteConsume_MaxUses_AllowsMultiple(t *testing.T) {
 		}
 	}
 
-	u := "invite-user-over-" + strings.ToLower(mustNewULIDLike(t))
+	u := mustTestUsername(t, "invite-user-over-")
 	_, err = s.ConsumeInviteAndCreateUser(ctx, ConsumeInviteInput{
-		Token:      inv.Token,
+		Token:      token,
 		Username:   &u,
 		Email:      nil,
 		Password:   "very-strong-password-9",
@@ -412,9 +400,7 @@ func TestPostgresStore_InviteConsume_RevokedOrExpired(t *testing.T) {
 	pool := mustOpenTestPool(t)
 	defer pool.Close()
 
-	schema := mustCreateTestSchema(t, pool)
-	t.Cleanup(func() { mustDropSchema(t, pool, schema) })
-	mustApplyIdentitySchema(t, pool, schema)
+	schema := migrationstest.NewSchema(t, pool)
 
 	s := mustNewIdentityStore(t, pool, schema)
 
@@ -422,7 +408,7 @@ func TestPostgresStore_InviteConsume_RevokedOrExpired(t *testing.T) {
 	defer cancel()
 
 	// Expired invite.
-	expired, err := s.CreateInvite(ctx, CreateInviteInput{
+	_, expiredToken, err := s.Invites().CreateInvite(ctx, invite.CreateInput{
 		CreatedBy: nil,
 		TTL:       1 * time.Hour,
 		MaxUses:   1,
@@ -432,9 +418,9 @@ func TestPostgresStore_InviteConsume_RevokedOrExpired(t *testing.T) {
 		t.Fatalf("create expired invite: %v", err)
 	}
 
-	u1 := "invite-user-exp-" + strings.ToLower(mustNewULIDLike(t))
+	u1 := mustTestUsername(t, "invite-user-exp-")
 	_, err = s.ConsumeInviteAndCreateUser(ctx, ConsumeInviteInput{
-		Token:      expired.Token,
+		Token:      expiredToken,
 		Username:   &u1,
 		Email:      nil,
 		Password:   "very-strong-password-1",
@@ -447,7 +433,7 @@ func TestPostgresStore_InviteConsume_RevokedOrExpired(t *testing.T) {
 	}
 
 	// Revoked invite.
-	revoked, err := s.CreateInvite(ctx, CreateInviteInput{
+	revoked, revokedToken, err := s.Invites().CreateInvite(ctx, invite.CreateInput{
 		CreatedBy: nil,
 		TTL:       24 * time.Hour,
 		MaxUses:   1,
@@ -458,13 +444,13 @@ func TestPostgresStore_InviteConsume_RevokedOrExpired(t *testing.T) {
 	}
 
 	invites := pgIdent(schema, "invites")
-	if _, err := pool.Exec(ctx, `UPDATE `+invites+` SET revoked_at = $1 WHERE id = $2`, time.Now().UTC(), revoked.Invite.ID); err != nil {
+	if _, err := pool.Exec(ctx, `UPDATE `+invites+` SET revoked_at = $1 WHERE id = $2`, time.Now().UTC(), revoked.ID); err != nil {
 		t.Fatalf("revoke invite: %v", err)
 	}
 
-	u2 := "invite-user-rev-" + strings.ToLower(mustNewULIDLike(t))
+	u2 := mustTestUsername(t, "invite-user-rev-")
 	_, err = s.ConsumeInviteAndCreateUser(ctx, ConsumeInviteInput{
-		Token:      revoked.Token,
+		Token:      revokedToken,
 		Username:   &u2,
 		Email:      nil,
 		Password:   "very-strong-password-2",
@@ -483,9 +469,7 @@ func TestPostgresStore_InviteConsume_Concurrent_MaxUses(t *testing.T) {
 	pool := mustOpenTestPool(t)
 	defer pool.Close()
 
-	schema := mustCreateTestSchema(t, pool)
-	t.Cleanup(func() { mustDropSchema(t, pool, schema) })
-	mustApplyIdentitySchema(t, pool, schema)
+	schema := migrationstest.NewSchema(t, pool)
 
 	s := mustNewIdentityStore(t, pool, schema)
 
@@ -493,7 +477,7 @@ func TestPostgresStore_InviteConsume_Concurrent_MaxUses(t *testing.T) {
 	defer cancel()
 
 	maxUses := 2
-	inv, err := s.CreateInvite(ctx, CreateInviteInput{
+	_, token, err := s.Invites().CreateInvite(ctx, invite.CreateInput{
 		CreatedBy: nil,
 		TTL:       24 * time.Hour,
 		MaxUses:   maxUses,
@@ -511,9 +495,9 @@ func TestPostgresStore_InviteConsume_Concurrent_MaxUses(t *testing.T) {
 		i := i
 		go func() {
 			defer wg.Done()
-			u := fmt.Sprintf("invite-user-conc-%d-%s", i, strings.ToLower(mustNewULIDLike(t)))
+			u := mustTestUsername(t, fmt.Sprintf("invite-user-conc-%d-", i))
 			_, err := s.ConsumeInviteAndCreateUser(ctx, ConsumeInviteInput{
-				Token:      inv.Token,
+				Token:      token,
 				Username:   &u,
 				Email:      nil,
 				Password:   "very-strong-password-3",
@@ -543,22 +527,210 @@ func TestPostgresStore_InviteConsume_Concurrent_MaxUses(t *testing.T) {
 	}
 }
 
+func TestPostgresStore_InviteConsume_JoinsConversation(t *testing.T) {
+	t.Parallel()
+
+	pool := mustOpenTestPool(t)
+	defer pool.Close()
+
+	schema := migrationstest.NewSchema(t, pool)
+
+	s := mustNewIdentityStore(t, pool, schema)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 25*time.Second)
+	defer cancel()
+
+	convID := "conv-" + strings.ToLower(mustNewULIDLike(t))
+	mustExec(t, pool, `INSERT INTO `+pgIdent(schema, "conversations")+` (id, kind) VALUES ($1, 'group')`, convID)
+
+	role := "Admin"
+	inv, token, err := s.Invites().CreateInvite(ctx, invite.CreateInput{
+		TTL:              24 * time.Hour,
+		MaxUses:          1,
+		ConversationID:   &convID,
+		ConversationRole: &role,
+		Now:              time.Now().UTC(),
+	})
+	if err != nil {
+		t.Fatalf("create invite: %v", err)
+	}
+	if inv.ConversationRole == nil || *inv.ConversationRole != "admin" {
+		t.Fatalf("expected normalized admin role, got %v", inv.ConversationRole)
+	}
+
+	u := mustTestUsername(t, "invite-user-")
+	out, err := s.ConsumeInviteAndCreateUser(ctx, ConsumeInviteInput{
+		Token:      token,
+		Username:   &u,
+		Password:   "very-strong-password-8",
+		Now:        time.Now().UTC(),
+		SessionTTL: 24 * time.Hour,
+		Platform:   "web",
+	})
+	if err != nil {
+		t.Fatalf("consume invite: %v", err)
+	}
+
+	var got string
+	err = pool.QueryRow(ctx,
+		`SELECT role FROM `+pgIdent(schema, "conversation_members")+` WHERE conversation_id = $1 AND user_id = $2`,
+		convID, out.User.ID,
+	).Scan(&got)
+	if err != nil {
+		t.Fatalf("load membership: %v", err)
+	}
+	if got != "admin" {
+		t.Fatalf("expected admin membership, got %q", got)
+	}
+
+	missing := "conv-missing"
+	_, _, err = s.Invites().CreateInvite(ctx, invite.CreateInput{TTL: time.Hour, ConversationID: &missing, Now: time.Now().UTC()})
+	if !errors.Is(err, invite.ErrConversationNotFound) {
+		t.Fatalf("expected not found for missing conversation, got %v", err)
+	}
+	owner := "owner"
+	_, _, err = s.Invites().CreateInvite(ctx, invite.CreateInput{TTL: time.Hour, ConversationID: &convID, ConversationRole: &owner, Now: time.Now().UTC()})
+	if !errors.Is(err, invite.ErrInvalidInput) {
+		t.Fatalf("expected invalid input for owner role, got %v", err)
+	}
+}
+
+func TestPostgresStore_InviteConsume_AllowedEmailDomains(t *testing.T) {
+	t.Parallel()
+
+	pool := mustOpenTestPool(t)
+	defer pool.Close()
+
+	schema := migrationstest.NewSchema(t, pool)
+
+	s := mustNewIdentityStore(t, pool, schema)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 25*time.Second)
+	defer cancel()
+
+	inv, token, err := s.Invites().CreateInvite(ctx, invite.CreateInput{
+		TTL:                 24 * time.Hour,
+		MaxUses:             2,
+		AllowedEmailDomains: []string{"@Example.com", "example.com"},
+		Now:                 time.Now().UTC(),
+	})
+	if err != nil {
+		t.Fatalf("create invite: %v", err)
+	}
+	if len(inv.AllowedEmailDomains) != 1 || inv.AllowedEmailDomains[0] != "example.com" {
+		t.Fatalf("expected normalized domains, got %v", inv.AllowedEmailDomains)
+	}
+
+	consume := func(email string, global []string) error {
+		u := mustTestUsername(t, "domain-user-")
+		_, err := s.ConsumeInviteAndCreateUser(ctx, ConsumeInviteInput{
+			Token:               token,
+			Username:            &u,
+			Email:               &email,
+			Password:            "very-strong-password-8",
+			Now:                 time.Now().UTC(),
+			SessionTTL:          24 * time.Hour,
+			Platform:            "web",
+			AllowedEmailDomains: global,
+		})
+		return err
+	}
+
+	if err := consume("mallory@example.org", nil); !IsNotAllowed(err) {
+		t.Fatalf("expected not allowed for foreign domain, got %v", err)
+	}
+	if err := consume("alice@example.com", []string{"corp.example"}); !IsNotAllowed(err) {
+		t.Fatalf("expected not allowed by global allowlist, got %v", err)
+	}
+	if err := consume("alice@EXAMPLE.com", nil); err != nil {
+		t.Fatalf("consume with allowed domain: %v", err)
+	}
+
+	_, _, err = s.Invites().CreateInvite(ctx, invite.CreateInput{TTL: time.Hour, AllowedEmailDomains: []string{"not a domain"}, Now: time.Now().UTC()})
+	if !errors.Is(err, invite.ErrInvalidInput) {
+		t.Fatalf("expected invalid input for bad domain, got %v", err)
+	}
+}
+
+func TestPostgresStore_InviteStats(t *testing.T) {
+	t.Parallel()
+
+	pool := mustOpenTestPool(t)
+	defer pool.Close()
+
+	schema := migrationstest.NewSchema(t, pool)
+
+	s := mustNewIdentityStore(t, pool, schema)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 25*time.Second)
+	defer cancel()
+
+	now := time.Now().UTC()
+	inviter := mustTestUsername(t, "inviter-")
+	res, err := s.CreateUser(ctx, CreateUserInput{Username: &inviter, Password: "very-strong-password-8", Now: now})
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	// Two invites this week, one consumed; one from three weeks ago that has expired unused.
+	var tokens []string
+	for range 2 {
+		_, token, err := s.Invites().CreateInvite(ctx, invite.CreateInput{CreatedBy: &res.User.ID, TTL: 24 * time.Hour, Now: now})
+		if err != nil {
+			t.Fatalf("create invite: %v", err)
+		}
+		tokens = append(tokens, token)
+	}
+	old := now.AddDate(0, 0, -21)
+	if _, _, err := s.Invites().CreateInvite(ctx, invite.CreateInput{CreatedBy: &res.User.ID, TTL: 24 * time.Hour, Now: old}); err != nil {
+		t.Fatalf("create old invite: %v", err)
+	}
+	u := mustTestUsername(t, "invitee-")
+	if _, err := s.ConsumeInviteAndCreateUser(ctx, ConsumeInviteInput{
+		Token: tokens[0], Username: &u, Password: "very-strong-password-8", Now: now, SessionTTL: time.Hour, Platform: "web",
+	}); err != nil {
+		t.Fatalf("consume invite: %v", err)
+	}
+
+	stats, err := s.Invites().Stats(ctx, invite.StatsInput{Weeks: 4, TopN: 5, Now: now})
+	if err != nil {
+		t.Fatalf("invite stats: %v", err)
+	}
+	if len(stats.Weeks) != 4 {
+		t.Fatalf("expected 4 weeks, got %d", len(stats.Weeks))
+	}
+	last := stats.Weeks[3]
+	if !last.WeekStart.Equal(invite.WeekStart(now)) || last.Created != 2 || last.Consumed != 1 {
+		t.Fatalf("unexpected current week %+v", last)
+	}
+	var created, expired int
+	for _, w := range stats.Weeks {
+		created += w.Created
+		expired += w.Expired
+	}
+	if created != 3 || expired != 1 {
+		t.Fatalf("expected 3 created and 1 expired, got %d and %d", created, expired)
+	}
+	if len(stats.TopInviters) != 1 || stats.TopInviters[0].UserID != res.User.ID ||
+		stats.TopInviters[0].Created != 3 || stats.TopInviters[0].Signups != 1 {
+		t.Fatalf("unexpected top inviters %+v", stats.TopInviters)
+	}
+}
+
 func TestPostgresStore_RotateRefreshToken_ExpiredSession_ReturnsNotActive(t *testing.T) {
 	t.Parallel()
 
 	pool := mustOpenTestPool(t)
 	defer pool.Close()
 
-	schema := mustCreateTestSchema(t, pool)
-	t.Cleanup(func() { mustDropSchema(t, pool, schema) })
-	mustApplyIdentitySchema(t, pool, schema)
+	schema := migrationstest.NewSchema(t, pool)
 
 	s := mustNewIdentityStore(t, pool, schema)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	u := "expired-user-" + strings.ToLower(mustNewULIDLike(t))
+	u := mustTestUsername(t, "expired-user-")
 	res, err := s.CreateUser(ctx, CreateUserInput{
 		Username: &u,
 		Email:    nil,
@@ -585,6 +757,7 @@ func TestPostgresStore_RotateRefreshToken_ExpiredSession_ReturnsNotActive(t *tes
 	mustExec(t, pool,
 		`UPDATE `+sessions+`
 		    SET created_at = now() - interval '2 hours',
+		        last_used_at = now() - interval '2 hours',
 		        expires_at = now() - interval '1 second'
 		  WHERE id = $1`,
 		sess.Session.ID,
@@ -605,16 +778,14 @@ func TestPostgresStore_RevokeAllSessions_Idempotent(t *testing.T) {
 	pool := mustOpenTestPool(t)
 	defer pool.Close()
 
-	schema := mustCreateTestSchema(t, pool)
-	t.Cleanup(func() { mustDropSchema(t, pool, schema) })
-	mustApplyIdentitySchema(t, pool, schema)
+	schema := migrationstest.NewSchema(t, pool)
 
 	s := mustNewIdentityStore(t, pool, schema)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	u := "revokeall-user-" + strings.ToLower(mustNewULIDLike(t))
+	u := mustTestUsername(t, "revokeall-user-")
 	res, err := s.CreateUser(ctx, CreateUserInput{
 		Username: &u,
 		Email:    nil,
@@ -657,6 +828,216 @@ func TestPostgresStore_RevokeAllSessions_Idempotent(t *testing.T) {
 	}
 }
 
+func TestPostgresStore_SetUserLocked_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	pool := mustOpenTestPool(t)
+	defer pool.Close()
+
+	schema := migrationstest.NewSchema(t, pool)
+
+	s := mustNewIdentityStore(t, pool, schema)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	u := mustTestUsername(t, "lock-user-")
+	res, err := s.CreateUser(ctx, CreateUserInput{
+		Username: &u,
+		Password: "very-strong-password-8",
+		Now:      time.Now().UTC(),
+	})
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	reason := "abuse report"
+	if err := s.SetUserLocked(ctx, res.User.ID, true, &reason, time.Now().UTC()); err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+	auth, err := s.GetUserAuthByUsername(ctx, u)
+	if err != nil {
+		t.Fatalf("get user auth: %v", err)
+	}
+	if auth.User.LockedAt == nil {
+		t.Fatalf("expected locked_at to be set")
+	}
+	byID, err := s.GetUserAuthByID(ctx, res.User.ID)
+	if err != nil {
+		t.Fatalf("get user auth by id: %v", err)
+	}
+	if byID.PasswordHash != auth.PasswordHash || byID.User.LockedAt == nil {
+		t.Fatalf("expected GetUserAuthByID to match GetUserAuthByUsername")
+	}
+
+	if err := s.SetUserLocked(ctx, res.User.ID, false, nil, time.Now().UTC()); err != nil {
+		t.Fatalf("unlock: %v", err)
+	}
+	got, err := s.GetUserByID(ctx, res.User.ID)
+	if err != nil {
+		t.Fatalf("get user: %v", err)
+	}
+	if got.LockedAt != nil {
+		t.Fatalf("expected locked_at to be cleared")
+	}
+
+	if err := s.SetUserLocked(ctx, mustNewULIDLike(t), true, nil, time.Now().UTC()); !IsNotFound(err) {
+		t.Fatalf("expected ErrNotFound for unknown user, got: %v", err)
+	}
+}
+
+func TestPostgresStore_SetUserPhone_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	pool := mustOpenTestPool(t)
+	defer pool.Close()
+
+	schema := migrationstest.NewSchema(t, pool)
+
+	s := mustNewIdentityStore(t, pool, schema)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	var ids [2]string
+	for i := range ids {
+		u := mustTestUsername(t, fmt.Sprintf("phone-user-%d-", i))
+		res, err := s.CreateUser(ctx, CreateUserInput{
+			Username: &u,
+			Password: "very-strong-password-8",
+			Now:      time.Now().UTC(),
+		})
+		if err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+		ids[i] = res.User.ID
+	}
+
+	phone, ok := NormalizePhone("0044 20 7183-8750")
+	if !ok || phone != "+442071838750" {
+		t.Fatalf("NormalizePhone = %q, %v", phone, ok)
+	}
+	if _, err := s.GetUserByPhone(ctx, phone); !IsNotFound(err) {
+		t.Fatalf("expected ErrNotFound before linking, got: %v", err)
+	}
+	if err := s.SetUserPhone(ctx, ids[0], &phone, time.Now().UTC()); err != nil {
+		t.Fatalf("set phone: %v", err)
+	}
+	got, err := s.GetUserByPhone(ctx, phone)
+	if err != nil {
+		t.Fatalf("get by phone: %v", err)
+	}
+	if got.ID != ids[0] || got.Phone == nil || *got.Phone != phone || got.PhoneVerifiedAt == nil {
+		t.Fatalf("unexpected user %+v", got)
+	}
+
+	var ce ConflictError
+	if err := s.SetUserPhone(ctx, ids[1], &phone, time.Now().UTC()); !errors.As(err, &ce) || ce.Field != "phone" {
+		t.Fatalf("expected phone conflict, got: %v", err)
+	}
+
+	if err := s.SetUserPhone(ctx, ids[0], nil, time.Now().UTC()); err != nil {
+		t.Fatalf("clear phone: %v", err)
+	}
+	if _, err := s.GetUserByPhone(ctx, phone); !IsNotFound(err) {
+		t.Fatalf("expected ErrNotFound after clearing, got: %v", err)
+	}
+	if err := s.SetUserPhone(ctx, ids[1], &phone, time.Now().UTC()); err != nil {
+		t.Fatalf("set phone on second user: %v", err)
+	}
+	if err := s.SetUserPhone(ctx, mustNewULIDLike(t), nil, time.Now().UTC()); !IsNotFound(err) {
+		t.Fatalf("expected ErrNotFound for unknown user, got: %v", err)
+	}
+}
+
+func TestPostgresStore_DeleteUser(t *testing.T) {
+	t.Parallel()
+
+	pool := mustOpenTestPool(t)
+	defer pool.Close()
+
+	schema := migrationstest.NewSchema(t, pool)
+
+	s := mustNewIdentityStore(t, pool, schema)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	u := mustTestUsername(t, "delete-user-")
+	res, err := s.CreateUser(ctx, CreateUserInput{
+		Username: &u,
+		Password: "very-strong-password-8",
+		Now:      time.Now().UTC(),
+	})
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	if _, err := s.CreateSession(ctx, CreateSessionInput{UserID: res.User.ID, TTL: time.Hour, Now: time.Now().UTC()}); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+
+	if err := s.DeleteUser(ctx, res.User.ID); err != nil {
+		t.Fatalf("delete user: %v", err)
+	}
+	if _, err := s.GetUserByID(ctx, res.User.ID); !IsNotFound(err) {
+		t.Fatalf("expected deleted user to be gone, got: %v", err)
+	}
+	if err := s.DeleteUser(ctx, res.User.ID); !IsNotFound(err) {
+		t.Fatalf("expected ErrNotFound on second delete, got: %v", err)
+	}
+}
+
+func TestPostgresStore_UpdateUser(t *testing.T) {
+	t.Parallel()
+
+	pool := mustOpenTestPool(t)
+	defer pool.Close()
+
+	schema := migrationstest.NewSchema(t, pool)
+
+	s := mustNewIdentityStore(t, pool, schema)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	suffix := strings.ToLower(mustNewULIDLike(t))[:10]
+	a := "upd-a-" + suffix
+	b := "upd-b-" + suffix
+	resA, err := s.CreateUser(ctx, CreateUserInput{Username: &a, Password: "very-strong-password-8", Now: time.Now().UTC()})
+	if err != nil {
+		t.Fatalf("create user a: %v", err)
+	}
+	if _, err := s.CreateUser(ctx, CreateUserInput{Username: &b, Password: "very-strong-password-8", Now: time.Now().UTC()}); err != nil {
+		t.Fatalf("create user b: %v", err)
+	}
+
+	email := "Upd-" + suffix + "@Example.com"
+	display := "  Ada  "
+	got, err := s.UpdateUser(ctx, UpdateUserInput{UserID: resA.User.ID, Email: &email, DisplayName: &display})
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if got.EmailNorm == nil || *got.EmailNorm != strings.ToLower(email) || got.DisplayName == nil || *got.DisplayName != "Ada" {
+		t.Fatalf("unexpected updated user: %+v", got)
+	}
+	if got.Username == nil || *got.Username != a {
+		t.Fatalf("expected username to be unchanged")
+	}
+
+	if _, err := s.UpdateUser(ctx, UpdateUserInput{UserID: resA.User.ID, Username: &b}); !IsConflict(err) {
+		t.Fatalf("expected username conflict, got: %v", err)
+	}
+
+	empty := ""
+	if _, err := s.UpdateUser(ctx, UpdateUserInput{UserID: resA.User.ID, Username: &empty, Email: &empty}); !IsInvalidInput(err) {
+		t.Fatalf("expected invalid input when clearing both identifiers, got: %v", err)
+	}
+
+	if _, err := s.UpdateUser(ctx, UpdateUserInput{UserID: mustNewULIDLike(t), DisplayName: &display}); !IsNotFound(err) {
+		t.Fatalf("expected ErrNotFound for unknown user, got: %v", err)
+	}
+}
+
 // ---- helpers ----
 
 func mustNewIdentityStore(t *testing.T, pool *pgxpool.Pool, schema string) *PostgresStore {
@@ -706,125 +1087,6 @@ func mustOpenTestPool(t *testing.T) *pgxpool.Pool {
 	return pool
 }
 
-func mustCreateTestSchema(t *testing.T, pool *pgxpool.Pool) string {
-	t.Helper()
-
-	schema := "arc_it_" + strings.ToLower(mustNewULIDLike(t))
-
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	if _, err := pool.Exec(ctx, `CREATE SCHEMA `+pgxIdent1(schema)); err != nil {
-		t.Fatalf("create schema: %v", err)
-	}
-	return schema
-}
-
-func mustDropSchema(t *testing.T, pool *pgxpool.Pool, schema string) {
-	t.Helper()
-
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	_, _ = pool.Exec(ctx, `DROP SCHEMA IF EXISTS `+pgxIdent1(schema)+` CASCADE`)
-}
-
-func mustApplyIdentitySchema(t *testing.T, pool *pgxpool.Pool, schema string) {
-	t.Helper()
-
-	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
-	defer cancel()
-
-	users := pgIdent(schema, "users")
-	creds := pgIdent(schema, "user_credentials")
-	sessions := pgIdent(schema, "sessions")
-	invites := pgIdent(schema, "invites")
-
-	schemaSQL := fmt.Sprintf(`
-CREATE TABLE IF NOT EXISTS %s (
-  id TEXT PRIMARY KEY,
-  username TEXT NULL,
-  username_norm TEXT NULL,
-  email TEXT NULL,
-  email_norm TEXT NULL,
-  email_verified_at TIMESTAMPTZ NULL,
-  display_name TEXT NULL,
-  bio TEXT NULL,
-  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
-
-  CONSTRAINT chk_users_id_ulid_len CHECK (char_length(id) = 26),
-  CONSTRAINT uq_users_username_norm UNIQUE (username_norm),
-  CONSTRAINT uq_users_email_norm UNIQUE (email_norm)
-);
-
-CREATE TABLE IF NOT EXISTS %s (
-  user_id TEXT PRIMARY KEY REFERENCES %s(id) ON DELETE CASCADE,
-  password_hash TEXT NOT NULL,
-  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
-  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
-);
-
-CREATE TABLE IF NOT EXISTS %s (
-  id TEXT PRIMARY KEY,
-  user_id TEXT NOT NULL REFERENCES %s(id) ON DELETE CASCADE,
-  refresh_token_hash TEXT NOT NULL,
-
-  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
-  last_used_at TIMESTAMPTZ NULL,
-  expires_at TIMESTAMPTZ NOT NULL,
-  revoked_at TIMESTAMPTZ NULL,
-
-  replaced_by_session_id TEXT NULL REFERENCES %s(id) ON DELETE SET NULL,
-
-  user_agent TEXT NULL,
-  ip INET NULL,
-  platform TEXT NOT NULL DEFAULT 'unknown',
-
-  CONSTRAINT chk_sessions_id_ulid_len CHECK (char_length(id) = 26),
-  CONSTRAINT chk_sessions_user_id_ulid_len CHECK (char_length(user_id) = 26),
-  CONSTRAINT chk_sessions_refresh_hash_len CHECK (char_length(refresh_token_hash) = 64),
-
-  CONSTRAINT uq_sessions_refresh_token_hash UNIQUE (refresh_token_hash),
-  CONSTRAINT chk_sessions_expires_after_created CHECK (expires_at > created_at),
-  CONSTRAINT chk_sessions_platform CHECK (platform IN ('web', 'ios', 'android', 'desktop', 'unknown')),
-  CONSTRAINT chk_sessions_replaced_not_self CHECK (replaced_by_session_id IS NULL OR replaced_by_session_id <> id)
-);
-
-CREATE TABLE IF NOT EXISTS %s (
-  id TEXT PRIMARY KEY,
-  token_hash TEXT NOT NULL,
-  created_by TEXT NULL REFERENCES %s(id) ON DELETE SET NULL,
-  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
-  expires_at TIMESTAMPTZ NOT NULL,
-  max_uses INT NOT NULL DEFAULT 1,
-  used_count INT NOT NULL DEFAULT 0,
-  revoked_at TIMESTAMPTZ NULL,
-  note TEXT NULL,
-  consumed_at TIMESTAMPTZ NULL,
-  consumed_by TEXT NULL REFERENCES %s(id) ON DELETE SET NULL,
-  CONSTRAINT chk_invites_id_ulid_len CHECK (char_length(id) = 26),
-  CONSTRAINT chk_invites_token_hash_len CHECK (char_length(token_hash) = 64),
-  CONSTRAINT chk_invites_max_uses CHECK (max_uses >= 1),
-  CONSTRAINT chk_invites_used_count CHECK (used_count >= 0 AND used_count <= max_uses)
-);
-
-CREATE UNIQUE INDEX IF NOT EXISTS uq_invites_token_hash ON %s (token_hash);
-
-CREATE INDEX IF NOT EXISTS idx_sessions_user_id
-  ON %s (user_id);
-
-CREATE INDEX IF NOT EXISTS idx_sessions_expires_at
-  ON %s (expires_at);
-
-CREATE INDEX IF NOT EXISTS idx_sessions_replaced_by
-  ON %s (replaced_by_session_id);
-`, users, creds, users, sessions, users, sessions, invites, users, users, invites, sessions, sessions, sessions)
-
-	if _, err := pool.Exec(ctx, schemaSQL); err != nil {
-		t.Fatalf("apply schema: %v", err)
-	}
-}
-
 func shouldSkipIntegration(err error) bool {
 	if err == nil {
 		return false
@@ -862,6 +1124,14 @@ func mustExec(t *testing.T, pool *pgxpool.Pool, sql string, args ...any) {
 	}
 }
 
+// mustTestUsername returns prefix plus the random tail of a ULID, short enough
+// for chk_users_username_len.
+func mustTestUsername(t *testing.T, prefix string) string {
+	t.Helper()
+
+	return prefix + strings.ToLower(mustNewULIDLike(t))[16:]
+}
+
 func mustNewULIDLike(t *testing.T) string {
 	t.Helper()
 
@@ -871,8 +1141,3 @@ func mustNewULIDLike(t *testing.T) string {
 	}
 	return id
 }
-
-func pgxIdent1(ident string) string {
-	// pgx.Identifier safely quotes identifiers, preventing SQL injection in dynamic DDL.
-	return pgx.Identifier{ident}.Sanitize()
-}
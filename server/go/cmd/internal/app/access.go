@@ -0,0 +1,21 @@
+package app
+
+import (
+	"arc/cmd/internal/geoip"
+	"arc/cmd/internal/ipaccess"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// newAccessPolicy builds the IP and country access lists of cfg, with denials
+// audited in schema's audit_log. It returns nil when no list is set.
+func newAccessPolicy(log Logger, cfg ipaccess.Config, pool *pgxpool.Pool, schema string, geo geoip.Resolver) (*ipaccess.Policy, error) {
+	if !cfg.Enabled() {
+		return nil, nil
+	}
+	auditor, err := ipaccess.NewAuditor(ComponentLogger(log, "authapi"), pool, schema)
+	if err != nil {
+		return nil, err
+	}
+	return ipaccess.New(cfg, geo, auditor), nil
+}
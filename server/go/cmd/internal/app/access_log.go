@@ -0,0 +1,264 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"arc/cmd/internal/redact"
+)
+
+// Access log formats for ARC_ACCESS_LOG_FORMAT.
+const (
+	AccessLogCommon   = "common"
+	AccessLogCombined = "combined"
+	AccessLogJSON     = "json"
+)
+
+// accessLogger writes one line per request to a sink separate from the app log,
+// in Common or Combined Log Format or as JSON Lines. Remote addresses, URIs and
+// referers go through the redact package like the app log does.
+type accessLogger struct {
+	format string
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+}
+
+// newAccessLogger opens the ARC_ACCESS_LOG target and adds extra (may be nil).
+// It returns nil when neither is configured.
+func newAccessLogger(cfg Config, extra io.Writer) (*accessLogger, error) {
+	var writers []io.Writer
+	var closer io.Closer
+	switch target := strings.TrimSpace(cfg.AccessLog); target {
+	case "":
+	case "stdout":
+		writers = append(writers, os.Stdout)
+	case "stderr":
+		writers = append(writers, os.Stderr)
+	default:
+		f, err := openRotatingFile(target, int64(cfg.AccessLogMaxSizeMB)<<20, cfg.AccessLogMaxBackups)
+		if err != nil {
+			return nil, fmt.Errorf("access log: %w", err)
+		}
+		writers = append(writers, f)
+		closer = f
+	}
+	if extra != nil {
+		writers = append(writers, extra)
+	}
+	if len(writers) == 0 {
+		return nil, nil
+	}
+
+	format := strings.ToLower(strings.TrimSpace(cfg.AccessLogFormat))
+	if format == "" {
+		format = AccessLogCombined
+	}
+	return &accessLogger{format: format, w: io.MultiWriter(writers...), closer: closer}, nil
+}
+
+// Close closes the access log file, if any.
+func (l *accessLogger) Close() error {
+	if l == nil || l.closer == nil {
+		return nil
+	}
+	return l.closer.Close()
+}
+
+type accessEntry struct {
+	Time       string `json:"time"`
+	Remote     string `json:"remote"`
+	Host       string `json:"host"`
+	Method     string `json:"method"`
+	URI        string `json:"uri"`
+	Route      string `json:"route,omitempty"`
+	Proto      string `json:"proto"`
+	Status     int    `json:"status"`
+	Bytes      int64  `json:"bytes"`
+	DurationMS int64  `json:"duration_ms"`
+	Referer    string `json:"referer,omitempty"`
+	UserAgent  string `json:"user_agent,omitempty"`
+}
+
+// line renders one request; it always ends with a newline.
+func (l *accessLogger) line(r *http.Request, status int, bytes int64, start time.Time, d time.Duration) []byte {
+	red := redact.Default()
+	remote := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(remote); err == nil {
+		remote = host
+	}
+	remote = red.String(remote)
+	uri := red.String(r.RequestURI)
+	if uri == "" {
+		uri = red.String(r.URL.RequestURI())
+	}
+	referer := red.String(r.Referer())
+
+	if l.format == AccessLogJSON {
+		b, _ := json.Marshal(accessEntry{
+			Time:       start.UTC().Format(time.RFC3339Nano),
+			Remote:     remote,
+			Host:       r.Host,
+			Method:     r.Method,
+			URI:        uri,
+			Route:      r.Pattern,
+			Proto:      r.Proto,
+			Status:     status,
+			Bytes:      bytes,
+			DurationMS: d.Milliseconds(),
+			Referer:    referer,
+			UserAgent:  r.UserAgent(),
+		})
+		return append(b, '\n')
+	}
+
+	size := "-"
+	if bytes > 0 {
+		size = strconv.FormatInt(bytes, 10)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s - - [%s] \"%s %s %s\" %d %s",
+		clfField(remote), start.Format("02/Jan/2006:15:04:05 -0700"),
+		clfEscape(r.Method), clfEscape(uri), clfEscape(r.Proto), status, size)
+	if l.format == AccessLogCombined {
+		fmt.Fprintf(&b, " \"%s\" \"%s\"", clfEscape(referer), clfEscape(r.UserAgent()))
+	}
+	b.WriteByte('\n')
+	return []byte(b.String())
+}
+
+func (l *accessLogger) write(line []byte) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, _ = l.w.Write(line)
+}
+
+// clfEscape makes s safe inside a quoted CLF field.
+func clfEscape(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`).Replace(s)
+}
+
+func clfField(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return strings.ReplaceAll(s, " ", "_")
+}
+
+// WithAccessLog writes each request to l once it completes. A nil l returns next.
+func WithAccessLog(next http.Handler, l *accessLogger) http.Handler {
+	if l == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		lrw := &loggingResponseWriter{
+			ResponseWriter: w,
+			status:         http.StatusOK,
+		}
+
+		next.ServeHTTP(lrw, r)
+
+		l.write(l.line(r, lrw.status, lrw.bytes, start, time.Since(start)))
+	})
+}
+
+// rotatingFile is an append-only file renamed to path.1 (shifting older copies up
+// to path.<backups>) once it would exceed maxBytes.
+type rotatingFile struct {
+	path     string
+	maxBytes int64
+	backups  int
+
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+}
+
+func openRotatingFile(path string, maxBytes int64, backups int) (*rotatingFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, err
+	}
+	rf := &rotatingFile{path: path, maxBytes: maxBytes, backups: backups}
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+	return rf, nil
+}
+
+func (rf *rotatingFile) open() error {
+	f, err := os.OpenFile(rf.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	rf.f, rf.size = f, fi.Size()
+	return nil
+}
+
+func (rf *rotatingFile) Write(p []byte) (int, error) {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if rf.f == nil {
+		return 0, os.ErrClosed
+	}
+	if rf.maxBytes > 0 && rf.size > 0 && rf.size+int64(len(p)) > rf.maxBytes {
+		if err := rf.rotate(); err != nil && rf.f == nil {
+			return 0, err
+		}
+	}
+	n, err := rf.f.Write(p)
+	rf.size += int64(n)
+	return n, err
+}
+
+func (rf *rotatingFile) rotate() error {
+	if err := rf.f.Close(); err != nil {
+		return err
+	}
+	rf.f = nil
+	if rf.backups <= 0 {
+		if err := os.Remove(rf.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return rf.open()
+	}
+	_ = os.Remove(fmt.Sprintf("%s.%d", rf.path, rf.backups))
+	for i := rf.backups - 1; i >= 1; i-- {
+		_ = os.Rename(fmt.Sprintf("%s.%d", rf.path, i), fmt.Sprintf("%s.%d", rf.path, i+1))
+	}
+	// A failed rename keeps appending to the current file rather than losing lines.
+	renameErr := os.Rename(rf.path, rf.path+".1")
+	if err := rf.open(); err != nil {
+		return err
+	}
+	return renameErr
+}
+
+func (rf *rotatingFile) Close() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	if rf.f == nil {
+		return nil
+	}
+	err := rf.f.Close()
+	rf.f = nil
+	return err
+}
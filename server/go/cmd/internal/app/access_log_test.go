@@ -0,0 +1,96 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestWithAccessLog(t *testing.T) {
+	t.Parallel()
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("hello"))
+	})
+	request := func() *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/auth/login?access_token=secret", nil)
+		r.RemoteAddr = "203.0.113.9:40000"
+		r.Header.Set("User-Agent", `curl/8 "test"`)
+		return r
+	}
+
+	var combined bytes.Buffer
+	l, err := newAccessLogger(Config{AccessLogFormat: AccessLogCombined}, &combined)
+	if err != nil {
+		t.Fatal(err)
+	}
+	WithAccessLog(handler, l).ServeHTTP(httptest.NewRecorder(), request())
+	want := regexp.MustCompile(`^203\.0\.113\.0/24 - - \[\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}\] ` +
+		`"POST /auth/login\?access_token=\[REDACTED\] HTTP/1\.1" 201 5 "-" "curl/8 \\"test\\""\n$`)
+	if !want.MatchString(combined.String()) {
+		t.Fatalf("combined line = %q", combined.String())
+	}
+
+	var jsonl bytes.Buffer
+	l, err = newAccessLogger(Config{AccessLogFormat: AccessLogJSON}, &jsonl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	WithAccessLog(handler, l).ServeHTTP(httptest.NewRecorder(), request())
+	var e accessEntry
+	if err := json.Unmarshal(jsonl.Bytes(), &e); err != nil {
+		t.Fatalf("json line %q: %v", jsonl.String(), err)
+	}
+	if e.Status != http.StatusCreated || e.Bytes != 5 || e.Method != http.MethodPost || strings.Contains(e.URI, "secret") {
+		t.Fatalf("json entry = %+v", e)
+	}
+
+	if l, err := newAccessLogger(Config{}, nil); l != nil || err != nil {
+		t.Fatalf("unconfigured access log = %v, %v", l, err)
+	}
+}
+
+func TestRotatingFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "logs", "access.log")
+	rf, err := openRotatingFile(path, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"one\n", "two\n", "three\n", "four\n", "five\n", "six\n"} {
+		if _, err := rf.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := rf.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	read := func(p string) string {
+		b, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+	if got := read(path); got != "six\n" {
+		t.Fatalf("current file = %q", got)
+	}
+	if got := read(path + ".1"); got != "four\nfive\n" {
+		t.Fatalf("first backup = %q", got)
+	}
+	if got := read(path + ".2"); got != "three\n" {
+		t.Fatalf("second backup = %q", got)
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Fatalf("only two backups should be kept, stat err = %v", err)
+	}
+}
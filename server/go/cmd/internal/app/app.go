@@ -9,11 +9,25 @@ import (
 	"net"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
+	"arc/cmd/internal/attachments"
 	authapi "arc/cmd/internal/auth/api"
 	"arc/cmd/internal/auth/session"
+	"arc/cmd/internal/bots"
+	"arc/cmd/internal/dbtrace"
+	"arc/cmd/internal/e2ee"
+	"arc/cmd/internal/geoip"
+	"arc/cmd/internal/jobs"
+	"arc/cmd/internal/maintenance"
+	"arc/cmd/internal/migrations"
+	"arc/cmd/internal/moderation"
+	"arc/cmd/internal/push"
 	"arc/cmd/internal/realtime"
+	"arc/cmd/internal/retention"
+	"arc/cmd/internal/scim"
+	"arc/cmd/internal/tenant"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -41,93 +55,412 @@ type App struct {
 
 	ws *realtime.WSGateway
 
-	auth *authapi.Handler
+	hub           *realtime.Hub
+	broker        realtime.Broker
+	brokerChannel string
+	// csrfHeader is the auth API's CSRF header, allowed and exposed by WithCORS.
+	csrfHeader string
+
+	auth        *authapi.Handler
+	scim        *scim.Handler
+	attachments *attachments.Handler
+	push        *push.Handler
+	e2ee        *e2ee.Handler
+	pusher      *push.Dispatcher
+	bots        *bots.Handler
+	botCommands *bots.Dispatcher
+	moderation  *moderation.Handler
+	// jobs runs the periodic background jobs (nil when none run on this instance).
+	jobs *jobs.Scheduler
+
+	accessLog   *accessLogger
+	reporter    PanicReporter
+	maintenance *maintenance.Mode
+	tenants     *tenants
 }
 
-// New constructs a fully wired App instance from config and logger.
-func New(cfg Config, log Logger) (*App, error) {
+// New constructs a fully wired App from config and logger: the database pool and
+// stores, identity and sessions (authapi), the realtime hub and gateway, feature
+// handlers, and background jobs, with each subsystem configured from its ARC_*
+// variables. opts replace individual dependencies. Server builds the same App
+// from explicit settings.
+func New(cfg Config, log Logger, opts ...Option) (*App, error) {
 	if log == nil {
-		log = NewLogger(cfg.LogLevel, cfg.LogFormat)
+		log = NewLoggerFromConfig(cfg)
 	}
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	// Session and auth settings are only read when there is a database to use them.
+	comps, err := loadComponentsFromEnv(o.pool != nil || cfg.DatabaseURL != "")
+	if err != nil {
+		return nil, err
+	}
+	return build(cfg, log, comps, o)
+}
 
-	st, dbPool, dbEnabled, msgStore, err := newStore(context.Background(), cfg, log)
+// build assembles the App New and Server.Build return. The store, and with it the
+// database pools, is closed when any later step fails.
+func build(cfg Config, log Logger, comps Components, o options) (_ *App, err error) {
+	dbLog := ComponentLogger(log, "db")
+
+	var (
+		st        Store
+		dbPool    *pgxpool.Pool
+		dbEnabled bool
+		msgStore  realtime.MessageStore
+	)
+	if o.pool != nil {
+		st, dbPool, dbEnabled, msgStore, err = storeFromPool(o.pool, nil, dbLog)
+	} else {
+		st, dbPool, dbEnabled, msgStore, err = newStore(context.Background(), cfg, dbLog)
+	}
 	if err != nil {
 		return nil, err
 	}
+	defer func() {
+		if err != nil {
+			_ = st.Close(context.Background())
+		}
+	}()
+	if o.msgStore != nil {
+		msgStore = o.msgStore
+	}
+	var dbReplica *pgxpool.Pool
+	if ds, ok := st.(dbStore); ok {
+		dbReplica = ds.replica
+	}
+	if dbPool != nil && cfg.AutoMigrate {
+		if err := autoMigrate(context.Background(), dbLog, dbPool); err != nil {
+			return nil, err
+		}
+	}
+	if dbPool != nil {
+		metricsPool.Store(dbPool)
+	}
 
 	var authHandler *authapi.Handler
 	var sessionSvc *session.Service
 	var memberStore realtime.MembershipStore
+	var memberManager realtime.MembershipManager
+	var scimHandler *scim.Handler
+	var attachmentHandler *attachments.Handler
+	var pushHandler *push.Handler
+	var pushDispatcher *push.Dispatcher
+	var e2eeHandler *e2ee.Handler
+	var botDispatcher *bots.Dispatcher
+	var wsOpts []realtime.GatewayOption
+
+	maint := newMaintenanceMode(cfg, ComponentLogger(log, "http"))
+	wsOpts = append(wsOpts, realtime.WithMaintenance(maint))
+
+	flags, err := newFeatureFlags(comps.FeatureFlags, dbPool, dbEnabled, tenant.DefaultSchema)
+	if err != nil {
+		return nil, err
+	}
+	reloadFeatureFlags(flags, log)
+	wsOpts = append(wsOpts, realtime.WithFeatureFlags(flags))
+
+	realtimeLog := ComponentLogger(log, "realtime")
+	hub := realtime.NewHub(realtimeLog)
+
+	if filters := comps.Filters.Filters(); len(filters) > 0 {
+		wsOpts = append(wsOpts, realtime.WithMessageFilters(filters...))
+	}
 
 	if dbEnabled {
-		sessCfg, err := session.LoadConfigFromEnv()
+		authCfg := comps.Auth
+		if authCfg.TrustProxy && len(authCfg.TrustedProxyCIDRs) == 0 {
+			// Any client can then pick its own address; kept for existing deployments.
+			log.Warn("server.proxy.trust_any_peer", "hint", "set ARC_AUTH_TRUSTED_PROXY_CIDRS")
+		}
+		geoResolver, err := geoip.NewResolver(comps.GeoIP)
 		if err != nil {
 			return nil, err
 		}
-		authCfg := authapi.LoadConfigFromEnv()
-		authHandler, err = authapi.NewHandler(log, dbPool, authCfg, sessCfg, dbEnabled)
+		accessPolicy, err := newAccessPolicy(log, comps.Access, dbPool, tenant.DefaultSchema, geoResolver)
+		if err != nil {
+			return nil, err
+		}
+		wsOpts = append(wsOpts, realtime.WithAccessPolicy(accessPolicy, authCfg.Proxy()))
+		authOpts := []authapi.HandlerOption{
+			authapi.WithGeoResolver(geoResolver),
+			authapi.WithMaintenance(maint),
+			authapi.WithFeatureFlags(flags),
+			authapi.WithReadPool(dbReplica),
+			authapi.WithAccessPolicy(accessPolicy),
+		}
+		if authored, ok := msgStore.(realtime.AuthoredMessageLister); ok {
+			authOpts = append(authOpts, authapi.WithAuthoredMessages(authored))
+		}
+		if scrubber, ok := msgStore.(realtime.MessageAuthorScrubber); ok {
+			authOpts = append(authOpts, authapi.WithMessageScrubber(scrubber))
+		}
+		authHandler, err = authapi.NewHandler(ComponentLogger(log, "authapi"), dbPool, authCfg, comps.Session, dbEnabled, authOpts...)
 		if err != nil {
 			return nil, err
 		}
 		sessionSvc = authHandler.SessionService()
 
+		if comps.SCIM.Enabled() {
+			scimHandler, err = scim.NewHandler(ComponentLogger(log, "scim"), dbPool, comps.SCIM, sessionSvc)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if comps.Attachments.Enabled() {
+			attachmentHandler, err = attachments.NewHandler(ComponentLogger(log, "attachments"), dbPool, comps.Attachments, sessionSvc)
+			if err != nil {
+				return nil, err
+			}
+			wsOpts = append(wsOpts, realtime.WithAttachmentVerifier(attachmentHandler.Store()))
+		}
+
+		if comps.Push.Enabled() {
+			pushHandler, err = push.NewHandler(ComponentLogger(log, "push"), dbPool, sessionSvc)
+			if err != nil {
+				return nil, err
+			}
+			pushDispatcher, err = push.NewDispatcher(ComponentLogger(log, "push"), dbPool, comps.Push, hub.UserConnected)
+			if err != nil {
+				return nil, err
+			}
+			wsOpts = append(wsOpts, realtime.WithOfflineNotifier(pushDispatcher))
+		}
+
+		e2eeHandler, err = e2ee.NewHandler(ComponentLogger(log, "e2ee"), dbPool, sessionSvc)
+		if err != nil {
+			return nil, err
+		}
+
+		// The bots handler posts through the gateway, so only the dispatcher is built here.
+		if comps.Bots.Enabled {
+			botDispatcher, err = bots.NewDispatcher(ComponentLogger(log, "bots"), dbPool, comps.Bots)
+			if err != nil {
+				return nil, err
+			}
+			wsOpts = append(wsOpts, realtime.WithCommandDispatcher(botDispatcher))
+		}
+
+		abuseAuditor, err := moderation.NewAbuseAuditor(ComponentLogger(log, "moderation"), dbPool)
+		if err != nil {
+			return nil, err
+		}
+		wsOpts = append(wsOpts, realtime.WithAbuseReporter(abuseAuditor))
+
 		members, err := realtime.NewPostgresMembershipStore(dbPool)
 		if err != nil {
 			return nil, err
 		}
 		memberStore = members
+		memberManager = members
+	}
+
+	broker := o.broker
+	if !o.brokerSet {
+		broker, err = realtime.NewBroker(comps.Broker, dbPool)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	wsOpts = append(wsOpts, o.gatewayOpts...)
+	ws := realtime.NewWSGateway(realtimeLog, hub, msgStore, sessionSvc, memberStore, wsOpts...)
+
+	var botHandler *bots.Handler
+	if botDispatcher != nil {
+		botHandler, err = bots.NewHandler(ComponentLogger(log, "bots"), dbPool, comps.Bots, sessionSvc, memberManager, ws)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	ws := realtime.NewWSGateway(log, realtime.NewHub(log), msgStore, sessionSvc, memberStore)
+	var moderationHandler *moderation.Handler
+	if dbEnabled {
+		moderationHandler, err = moderation.NewHandler(ComponentLogger(log, "moderation"), dbPool, comps.Moderation, sessionSvc, memberManager, ws)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var scheduled []jobs.Job
+	if dbEnabled && comps.Retention.Enabled() {
+		retentionJob, err := retention.NewJob(ComponentLogger(log, "retention"), dbPool, comps.Retention)
+		if err != nil {
+			return nil, err
+		}
+		job, err := retentionJob.Scheduled()
+		if err != nil {
+			return nil, err
+		}
+		scheduled = append(scheduled, job)
+	}
+	scheduler, err := newScheduler(ComponentLogger(log, "jobs"), comps.Jobs, dbPool, dbEnabled, scheduled...)
+	if err != nil {
+		return nil, err
+	}
+
+	tenantSet, err := newTenants(comps.Tenants, log, dbPool, dbReplica, dbEnabled, maint)
+	if err != nil {
+		return nil, err
+	}
+
+	accessLog, err := newAccessLogger(cfg, o.accessLog)
+	if err != nil {
+		return nil, err
+	}
+	reporter := o.reporter
+	if reporter == nil && cfg.SentryDSN != "" {
+		sentry, err := newSentryReporter(cfg.SentryDSN, cfg.SentryEnvironment, ComponentLogger(log, "http"))
+		if err != nil {
+			return nil, err
+		}
+		reporter = sentry
+	}
 
 	return &App{
-		cfg:       cfg,
-		log:       log,
-		store:     st,
-		dbPool:    dbPool,
-		dbEnabled: dbEnabled,
-		ws:        ws,
-		auth:      authHandler,
+		cfg:         cfg,
+		log:         log,
+		store:       st,
+		dbPool:      dbPool,
+		dbEnabled:   dbEnabled,
+		ws:          ws,
+		hub:         hub,
+		broker:      broker,
+		auth:        authHandler,
+		scim:        scimHandler,
+		attachments: attachmentHandler,
+		push:        pushHandler,
+		e2ee:        e2eeHandler,
+		pusher:      pushDispatcher,
+		bots:        botHandler,
+		botCommands: botDispatcher,
+		moderation:  moderationHandler,
+		jobs:        scheduler,
+		accessLog:   accessLog,
+		reporter:    reporter,
+		maintenance: maint,
+		tenants:     tenantSet,
+
+		brokerChannel: comps.Broker.Channel,
+		csrfHeader:    comps.Auth.CSRFHeaderName,
 	}, nil
 }
 
-// Run starts the HTTP server and blocks until context cancellation or fatal server error.
-func (a *App) Run(ctx context.Context) error {
+// Handler returns every route wrapped in the standard middleware chain. Run
+// serves it; tests can drive it directly without a listener.
+func (a *App) Handler() http.Handler {
 	mux := http.NewServeMux()
-
-	// Use the canonical HTTP registration from http.go (so it is not "unused").
-	registerHTTP(mux, a.log, a.cfg, a.dbPool, a.dbEnabled, a.ws, a.auth)
-
-	handler := WithRequestLogging(
-		WithSecurityHeaders(
-			WithCORS(mux, a.cfg, a.log),
+	registerHTTP(mux, a.log, a.cfg, a.dbPool, a.dbEnabled, a.broker, a.ws, a.auth, a.scim, a.attachments, a.push, a.e2ee, a.bots, a.moderation)
+
+	return WithAccessLog(
+		WithRequestLogging(
+			WithMetrics(
+				WithSecurityHeaders(
+					WithRecover(WithCORS(WithMaintenance(a.tenants.handler(mux), a.maintenance), a.cfg, a.csrfHeader, a.log), ComponentLogger(a.log, "http"), a.reporter),
+				),
+			),
+			ComponentLogger(a.log, "http"),
 		),
-		a.log,
+		a.accessLog,
 	)
+}
 
-	srv := &http.Server{
-		Addr:              a.cfg.HTTPAddr,
-		Handler:           handler,
-		ReadHeaderTimeout: nonZeroDuration(a.cfg.ReadHeaderTimeout, 5*time.Second),
-		ReadTimeout:       nonZeroDuration(a.cfg.ReadTimeout, 15*time.Second),
-		WriteTimeout:      nonZeroDuration(a.cfg.WriteTimeout, 15*time.Second),
-		IdleTimeout:       nonZeroDuration(a.cfg.IdleTimeout, 60*time.Second),
-		MaxHeaderBytes:    nonZeroInt(a.cfg.MaxHeaderBytes, 1<<20),
+// Run starts the HTTP server and blocks until context cancellation or fatal server error.
+func (a *App) Run(ctx context.Context) error {
+	srv := newHTTPServer(a.cfg, a.Handler())
+
+	tlsOn := a.cfg.TLSEnabled()
+	if tlsOn {
+		tlsConfig, acmeManager, err := newTLSConfig(a.cfg, a.log)
+		if err != nil {
+			a.log.Error("tls.config.fail", "err", err, "result", "server_error")
+			return err
+		}
+		srv.TLSConfig = tlsConfig
+		if srv.Protocols != nil {
+			srv.Protocols.SetHTTP2(true)
+		}
+		if acmeManager != nil && a.cfg.ACMEHTTPAddr != "" {
+			go runACMEHTTPServer(ctx, a.log, a.cfg.ACMEHTTPAddr, acmeManager)
+		}
 	}
 
-	baseURL := runtimeBaseURL(a.cfg.HTTPAddr)
-	a.log.Info("server.start", "addr", a.cfg.HTTPAddr, "db_enabled", a.dbEnabled, "log_format", a.cfg.LogFormat)
+	// Background workers outlive ctx: they are stopped only after the HTTP server
+	// and realtime sessions shut down, so work accepted until then still completes.
+	workerCtx, stopWorkers := context.WithCancel(context.WithoutCancel(ctx))
+	defer stopWorkers()
+	var workers sync.WaitGroup
+
+	// Cross-node fanout stops and the broker is closed once workerCtx is done.
+	if a.broker != nil {
+		a.hub.UseBroker(workerCtx, a.broker, a.brokerChannel)
+		for id, st := range a.tenants.all() {
+			st.hub.UseBroker(workerCtx, a.broker, a.brokerChannel+"."+id)
+		}
+	}
+	// Realtime activity is written to sessions.last_used_at in batches.
+	workers.Go(func() { a.ws.RunSessionTouches(workerCtx) })
+	for _, st := range a.tenants.all() {
+		workers.Go(func() { st.ws.RunSessionTouches(workerCtx) })
+	}
+	// Revoked auth sessions close their connections as soon as the revocation commits.
+	if a.dbEnabled && a.dbPool != nil {
+		revocations, err := realtime.NewPostgresBroker(a.dbPool)
+		if err != nil {
+			// Revoked sessions then stay connected until their access token expires.
+			a.log.Error("ws.revocations.start.fail", "err", err, "result", "server_error")
+		} else {
+			workers.Go(func() { a.ws.WatchSessionRevocations(workerCtx, revocations) })
+			for _, st := range a.tenants.all() {
+				workers.Go(func() { st.ws.WatchSessionRevocations(workerCtx, revocations) })
+			}
+		}
+	}
+	if a.pusher != nil {
+		workers.Go(func() { a.pusher.Run(workerCtx) })
+	}
+	if a.botCommands != nil {
+		workers.Go(func() { a.botCommands.Run(workerCtx) })
+	}
+	if a.jobs != nil {
+		workers.Go(func() { a.jobs.Run(workerCtx) })
+	}
+	if a.auth != nil {
+		workers.Go(func() { a.auth.RunStatsFlusher(workerCtx) })
+		workers.Go(func() { a.auth.BackfillIdentifierHashes(workerCtx) })
+	}
+	for _, st := range a.tenants.all() {
+		workers.Go(func() { st.auth.RunStatsFlusher(workerCtx) })
+		workers.Go(func() { st.auth.BackfillIdentifierHashes(workerCtx) })
+	}
+	if a.cfg.DebugAddr != "" {
+		go runDebugServer(ctx, a.log, a.cfg.DebugAddr)
+	}
+
+	baseURL := serverBaseURL(a.cfg)
+	a.log.Info("server.start", "addr", a.cfg.HTTPAddr, "tls", tlsOn, "db_enabled", a.dbEnabled, "log_format", a.cfg.LogFormat)
 	a.log.Info("server.endpoints",
 		"base", baseURL,
 		"healthz", baseURL+"/healthz",
 		"readyz", baseURL+"/readyz",
 		"ws", wsBaseURL(baseURL)+"/ws",
+		"grpc", baseURL+realtime.GRPCPathPrefix,
 		"result", "success",
 	)
 
 	errCh := make(chan error, 1)
 	go func() {
-		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		var err error
+		if tlsOn {
+			// Certificates come from srv.TLSConfig.GetCertificate.
+			err = srv.ListenAndServeTLS("", "")
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
 			errCh <- err
 		}
 	}()
@@ -140,23 +473,86 @@ func (a *App) Run(ctx context.Context) error {
 		return err
 	}
 
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), nonZeroDuration(a.cfg.ShutdownTimeout, 30*time.Second))
 	defer cancel()
 
+	// Realtime sessions are hijacked connections that srv.Shutdown does not wait for:
+	// drain them first so clients get a reconnect hint instead of a dropped socket.
+	drainCtx, cancelDrain := context.WithTimeout(shutdownCtx, a.ws.DrainTimeout()+2*time.Second)
+	var drains sync.WaitGroup
+	drains.Go(func() { _ = a.ws.Drain(drainCtx) })
+	for _, st := range a.tenants.all() {
+		drains.Go(func() { _ = st.ws.Drain(drainCtx) })
+	}
+	drains.Wait()
+	cancelDrain()
+
 	if err := srv.Shutdown(shutdownCtx); err != nil {
 		a.log.Error("server.shutdown.fail", "err", err, "result", "server_error")
 		return err
 	}
 
+	stopWorkers()
+	if err := waitGroupContext(shutdownCtx, &workers); err != nil {
+		a.log.Warn("server.workers.incomplete", "err", err, "result", "server_error")
+	}
+	a.auth.Close()
+	for _, st := range a.tenants.all() {
+		st.auth.Close()
+	}
+
 	// Close store resources (pool etc).
 	if err := a.store.Close(shutdownCtx); err != nil {
 		a.log.Error("store.close.fail", "err", err, "result", "server_error")
 	}
+	if err := a.accessLog.Close(); err != nil {
+		a.log.Error("http.access_log.close.fail", "err", err, "result", "server_error")
+	}
 
 	a.log.Info("server.stopped", "result", "success")
 	return nil
 }
 
+// newHTTPServer builds the main listener's server from cfg's timeouts, header
+// limit and HTTP/2 settings. TLS is configured by the caller.
+func newHTTPServer(cfg Config, handler http.Handler) *http.Server {
+	srv := &http.Server{
+		Addr:              cfg.HTTPAddr,
+		Handler:           handler,
+		ReadHeaderTimeout: nonZeroDuration(cfg.ReadHeaderTimeout, 5*time.Second),
+		ReadTimeout:       nonZeroDuration(cfg.ReadTimeout, 15*time.Second),
+		WriteTimeout:      nonZeroDuration(cfg.WriteTimeout, 15*time.Second),
+		IdleTimeout:       nonZeroDuration(cfg.IdleTimeout, 60*time.Second),
+		MaxHeaderBytes:    nonZeroInt(cfg.MaxHeaderBytes, 1<<20),
+		HTTP2: &http.HTTP2Config{
+			MaxConcurrentStreams: cfg.HTTP2MaxConcurrentStreams,
+			SendPingTimeout:      cfg.HTTP2SendPingTimeout,
+		},
+	}
+	if cfg.H2C {
+		var protocols http.Protocols
+		protocols.SetHTTP1(true)
+		protocols.SetUnencryptedHTTP2(true)
+		srv.Protocols = &protocols
+	}
+	return srv
+}
+
+// waitGroupContext waits for wg, giving up when ctx is done first.
+func waitGroupContext(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func nonZeroDuration(v, def time.Duration) time.Duration {
 	if v <= 0 {
 		return def
@@ -186,6 +582,22 @@ func runtimeBaseURL(addr string) string {
 	return "http://" + host + ":" + port
 }
 
+// serverBaseURL is runtimeBaseURL with the scheme the listener actually speaks.
+// ACME deployments are reached by their first domain name.
+func serverBaseURL(cfg Config) string {
+	base := runtimeBaseURL(cfg.HTTPAddr)
+	if !cfg.TLSEnabled() {
+		return base
+	}
+	if len(cfg.ACMEDomains) > 0 {
+		base = "http://" + cfg.ACMEDomains[0]
+		if _, port, err := net.SplitHostPort(strings.TrimSpace(cfg.HTTPAddr)); err == nil && port != "443" {
+			base += ":" + port
+		}
+	}
+	return "https://" + strings.TrimPrefix(base, "http://")
+}
+
 func wsBaseURL(httpBase string) string {
 	switch {
 	case strings.HasPrefix(httpBase, "https://"):
@@ -204,27 +616,63 @@ func newStore(ctx context.Context, cfg Config, log Logger) (Store, *pgxpool.Pool
 		return nopStore{}, nil, false, realtime.NewInMemoryStore(), nil
 	}
 
-	pool, err := NewDBPool(ctx, cfg)
+	pool, err := NewDBPool(ctx, cfg, dbtrace.New(log, "primary", cfg.DBSlowQueryThreshold))
 	if err != nil {
 		return nil, nil, false, nil, err
 	}
+	var replica *pgxpool.Pool
+	if cfg.DatabaseReplicaURL != "" {
+		rcfg := cfg
+		rcfg.DatabaseURL = cfg.DatabaseReplicaURL
+		if replica, err = NewDBPool(ctx, rcfg, dbtrace.New(log, "replica", cfg.DBSlowQueryThreshold)); err != nil {
+			pool.Close()
+			log.Error("db.replica.connect.fail", "err", err, "result", "server_error")
+			return nil, nil, false, nil, err
+		}
+		log.Info("db.replica.enabled", "result", "success")
+	}
+	return storeFromPool(pool, replica, log)
+}
+
+// autoMigrate applies pending embedded migrations (ARC_DB_AUTO_MIGRATE).
+func autoMigrate(ctx context.Context, log Logger, pool *pgxpool.Pool) error {
+	r, err := migrations.NewRunner(log, pool)
+	if err != nil {
+		return err
+	}
+	applied, err := r.Up(ctx)
+	if err != nil {
+		log.Error("db.migrate.fail", "err", err, "result", "server_error")
+		return err
+	}
+	log.Info("db.migrate.done", "applied", len(applied), "result", "success")
+	return nil
+}
 
+// storeFromPool builds the Postgres-backed stores on an existing pool. replica,
+// when non-nil, serves the message store's replica-safe reads.
+func storeFromPool(pool, replica *pgxpool.Pool, log Logger) (Store, *pgxpool.Pool, bool, realtime.MessageStore, error) {
 	log.Info("db.enabled.postgres_store", "mode", "postgres", "result", "success")
 
 	// Ownership model:
 	// - app owns pool lifecycle
 	// - PostgresStore.Close() is a no-op
-	msgStore, err := realtime.NewPostgresStore(pool) // default schema "arc"
+	msgStore, err := realtime.NewPostgresStore(pool, realtime.WithReadPool(replica)) // default schema "arc"
 	if err != nil {
 		pool.Close()
+		if replica != nil {
+			replica.Close()
+		}
 		return nil, nil, false, nil, err
 	}
 
-	return dbStore{pool: pool, msgStore: msgStore}, pool, true, msgStore, nil
+	return dbStore{pool: pool, replica: replica, msgStore: msgStore}, pool, true, msgStore, nil
 }
 
 type dbStore struct {
-	pool     *pgxpool.Pool
+	pool *pgxpool.Pool
+	// replica is the ARC_DATABASE_REPLICA_URL pool (nil without one).
+	replica  *pgxpool.Pool
 	msgStore realtime.MessageStore
 }
 
@@ -234,6 +682,9 @@ func (s dbStore) Close(_ context.Context) error {
 	if s.msgStore != nil {
 		_ = s.msgStore.Close()
 	}
+	if s.replica != nil {
+		s.replica.Close()
+	}
 	if s.pool != nil {
 		s.pool.Close()
 	}
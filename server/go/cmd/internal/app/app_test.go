@@ -1,6 +1,16 @@
 package app
 
-import "testing"
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
 
 func TestRuntimeBaseURL(t *testing.T) {
 	t.Parallel()
@@ -47,3 +57,92 @@ func TestWSBaseURL(t *testing.T) {
 		}
 	}
 }
+
+func TestServerBaseURL(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		cfg  Config
+		want string
+	}{
+		{name: "plain", cfg: Config{HTTPAddr: "0.0.0.0:8080"}, want: "http://127.0.0.1:8080"},
+		{name: "cert files", cfg: Config{HTTPAddr: "0.0.0.0:8443", TLSCertFile: "c.pem", TLSKeyFile: "k.pem"}, want: "https://127.0.0.1:8443"},
+		{name: "acme default port", cfg: Config{HTTPAddr: ":443", ACMEDomains: []string{"chat.example.com"}}, want: "https://chat.example.com"},
+		{name: "acme other port", cfg: Config{HTTPAddr: ":8443", ACMEDomains: []string{"chat.example.com"}}, want: "https://chat.example.com:8443"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if got := serverBaseURL(tc.cfg); got != tc.want {
+				t.Fatalf("serverBaseURL=%q want=%q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNewHTTPServer(t *testing.T) {
+	t.Parallel()
+
+	srv := newHTTPServer(Config{HTTPAddr: ":8080", WriteTimeout: 30 * time.Second, HTTP2MaxConcurrentStreams: 100, H2C: true}, http.NotFoundHandler())
+	if srv.WriteTimeout != 30*time.Second || srv.ReadTimeout != 15*time.Second || srv.MaxHeaderBytes != 1<<20 {
+		t.Fatalf("timeouts: write=%s read=%s header_bytes=%d", srv.WriteTimeout, srv.ReadTimeout, srv.MaxHeaderBytes)
+	}
+	if srv.HTTP2 == nil || srv.HTTP2.MaxConcurrentStreams != 100 {
+		t.Fatalf("http2 config = %+v", srv.HTTP2)
+	}
+	if srv.Protocols == nil || !srv.Protocols.UnencryptedHTTP2() || !srv.Protocols.HTTP1() {
+		t.Fatalf("h2c protocols not enabled: %v", srv.Protocols)
+	}
+}
+
+func TestWaitGroupContext(t *testing.T) {
+	t.Parallel()
+
+	var wg sync.WaitGroup
+	release := make(chan struct{})
+	wg.Go(func() { <-release })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := waitGroupContext(ctx, &wg); err == nil {
+		t.Fatal("expected timeout while the worker is still running")
+	}
+
+	close(release)
+	if err := waitGroupContext(context.Background(), &wg); err != nil {
+		t.Fatalf("wait after release: %v", err)
+	}
+}
+
+func TestNew_Handler(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cfg := Config{HTTPAddr: "127.0.0.1:0", LogLevel: "info", LogFormat: "text", DBMaxConns: 1}
+	a, err := New(cfg, log, WithBroker(pingBroker{}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if a.dbEnabled || a.auth != nil {
+		t.Fatalf("expected the in-memory assembly without auth, got db=%v auth=%v", a.dbEnabled, a.auth != nil)
+	}
+	h := a.Handler()
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rr.Code != http.StatusOK || rr.Header().Get("X-Content-Type-Options") != "nosniff" {
+		t.Fatalf("healthz: status %d, headers %v", rr.Code, rr.Header())
+	}
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	var body struct {
+		Checks map[string]struct{ Status string } `json:"checks"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatalf("readyz body: %v", err)
+	}
+	if got := body.Checks["broker"].Status; got != "ok" {
+		t.Fatalf("injected broker not used: broker check %q", got)
+	}
+}
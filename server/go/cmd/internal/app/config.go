@@ -1,12 +1,25 @@
 package app
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
 
-// Config contains all runtime configuration loaded from environment variables.
+	"arc/cmd/internal/maintenance"
+)
+
+// Config contains all runtime configuration loaded from environment variables
+// (and the optional config file, see package config).
 type Config struct {
 	HTTPAddr  string
 	LogLevel  string
 	LogFormat string
+	// LogLevels overrides LogLevel per component ("realtime=debug,http=warn");
+	// see ComponentLogger.
+	LogLevels   string
+	LogSampling LogSampling
 
 	ReadHeaderTimeout time.Duration
 	ReadTimeout       time.Duration
@@ -14,9 +27,51 @@ type Config struct {
 	IdleTimeout       time.Duration
 	MaxHeaderBytes    int
 
+	// ShutdownTimeout bounds the whole graceful shutdown: draining realtime
+	// sessions, finishing in-flight HTTP requests and letting background workers
+	// (push, bot commands, retention, broker fanout) complete queued work.
+	ShutdownTimeout time.Duration
+
+	// HTTP/2 tuning; zero keeps the net/http defaults. Applies to h2c and TLS.
+	HTTP2MaxConcurrentStreams int
+	HTTP2SendPingTimeout      time.Duration
+
+	// If true, cleartext HTTP/2 (h2c) is accepted alongside HTTP/1.1 so gRPC
+	// clients can connect without TLS, e.g. behind a TLS-terminating proxy.
+	H2C bool
+
+	// Optional native TLS. Either a certificate and key (re-read when the files
+	// change) or ACME certificates for ACMEDomains, never both. See tls.go.
+	TLSCertFile      string
+	TLSKeyFile       string
+	ACMEDomains      []string
+	ACMEEmail        string
+	ACMECacheDir     string
+	ACMEDirectoryURL string
+	// ACMEHTTPAddr serves HTTP-01 challenges and redirects other requests to
+	// HTTPS; empty relies on TLS-ALPN-01 on the main listener only.
+	ACMEHTTPAddr string
+
 	DatabaseURL string
-	DBMaxConns  int32
-	DBMinConns  int32
+	// DatabaseReplicaURL points at a streaming read replica; when set, replica-safe
+	// reads (profiles, history pages, session lists) are served from it.
+	DatabaseReplicaURL string
+	DBMaxConns         int32
+	DBMinConns         int32
+	// DBStatementCacheMode is pgx's default query exec mode: cache_statement
+	// (prepare and cache every query, the default), cache_describe, describe_exec,
+	// exec or simple_protocol. Modes other than cache_statement suit
+	// transaction-pooling proxies and disable named prepared statements.
+	DBStatementCacheMode string
+	// DBStatementCacheCapacity bounds the per-connection statement (or describe) cache.
+	DBStatementCacheCapacity int
+	// DBHealthCheckPeriod is how often idle pool connections are checked.
+	DBHealthCheckPeriod time.Duration
+	// DBSlowQueryThreshold logs queries at least this slow as db.query.slow.
+	DBSlowQueryThreshold time.Duration
+	// AutoMigrate applies pending schema migrations at startup. Meant for
+	// development; production runs "arc migrate up" as a deploy step.
+	AutoMigrate bool
 
 	// Strict CORS allowlist for browser clients.
 	//
@@ -32,11 +87,113 @@ type Config struct {
 	// - /readyz returns 503 unless DB is configured and reachable.
 	ReadinessRequireDB bool
 
+	// If true, Prometheus metrics are served at /metrics. Restrict access at the
+	// proxy or network level; the endpoint is unauthenticated.
+	MetricsEnabled bool
+
+	// AccessLog is an optional request log separate from the app log: "stdout",
+	// "stderr" or a file path. Files rotate at AccessLogMaxSizeMB and keep
+	// AccessLogMaxBackups old copies. See access_log.go.
+	AccessLog           string
+	AccessLogFormat     string
+	AccessLogMaxSizeMB  int
+	AccessLogMaxBackups int
+
+	// SentryDSN enables reporting recovered handler panics to Sentry, tagged with
+	// SentryEnvironment (ARC_ENV). See WithRecover.
+	SentryDSN         string
+	SentryEnvironment string
+
+	// MaintenanceMode answers mutating requests with 503 and refuses new realtime
+	// sessions; MaintenanceRetryAfter is the Retry-After hint and MaintenanceMessage
+	// is shown to clients. All three follow config reloads. See maintenance.go.
+	MaintenanceMode       bool
+	MaintenanceRetryAfter time.Duration
+	MaintenanceMessage    string
+
+	// DebugAddr is the loopback-only listener for pprof, expvar and goroutine dumps;
+	// empty disables it.
+	DebugAddr string
+
 	// Security policy:
 	// If true, ARC_TOKEN_HMAC_KEY MUST be set (>= 32 bytes) and refresh-token hashing must be HMAC-based.
 	RequireTokenHMAC bool
 }
 
+// Validate rejects settings that would otherwise fail late or be silently ignored.
+func (c Config) Validate() error {
+	var errs []error
+	if _, _, err := net.SplitHostPort(c.HTTPAddr); err != nil {
+		errs = append(errs, fmt.Errorf("ARC_HTTP_ADDR %q: %w", c.HTTPAddr, err))
+	}
+	if !validLogLevel(c.LogLevel) {
+		errs = append(errs, fmt.Errorf("ARC_LOG_LEVEL %q: want debug, info, warn or error", c.LogLevel))
+	}
+	if _, err := parseComponentLevels(c.LogLevels); err != nil {
+		errs = append(errs, fmt.Errorf("ARC_LOG_LEVELS %w", err))
+	}
+	switch strings.ToLower(strings.TrimSpace(c.LogFormat)) {
+	case "auto", "pretty", "text", "json":
+	default:
+		errs = append(errs, fmt.Errorf("ARC_LOG_FORMAT %q: want auto, pretty, text or json", c.LogFormat))
+	}
+	if c.DBMaxConns < 1 {
+		errs = append(errs, errors.New("ARC_DB_MAX_CONNS must be at least 1"))
+	}
+	if c.DBMinConns > c.DBMaxConns {
+		errs = append(errs, fmt.Errorf("ARC_DB_MIN_CONNS (%d) exceeds ARC_DB_MAX_CONNS (%d)", c.DBMinConns, c.DBMaxConns))
+	}
+	if _, err := parseQueryExecMode(c.DBStatementCacheMode); err != nil {
+		errs = append(errs, fmt.Errorf("ARC_DB_STATEMENT_CACHE_MODE: %w", err))
+	}
+	if c.DBStatementCacheCapacity < 0 {
+		errs = append(errs, errors.New("ARC_DB_STATEMENT_CACHE_CAPACITY must not be negative"))
+	}
+	if c.DatabaseReplicaURL != "" && c.DatabaseURL == "" {
+		errs = append(errs, errors.New("ARC_DATABASE_REPLICA_URL requires ARC_DATABASE_URL"))
+	}
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		errs = append(errs, errors.New("ARC_TLS_CERT_FILE and ARC_TLS_KEY_FILE must be set together"))
+	}
+	if c.TLSCertFile != "" && len(c.ACMEDomains) > 0 {
+		errs = append(errs, errors.New("ARC_TLS_CERT_FILE and ARC_TLS_ACME_DOMAINS are mutually exclusive"))
+	}
+	if len(c.ACMEDomains) > 0 && strings.TrimSpace(c.ACMECacheDir) == "" {
+		errs = append(errs, errors.New("ARC_TLS_ACME_CACHE_DIR is required with ARC_TLS_ACME_DOMAINS"))
+	}
+	switch strings.ToLower(strings.TrimSpace(c.AccessLogFormat)) {
+	case "", AccessLogCommon, AccessLogCombined, AccessLogJSON:
+	default:
+		errs = append(errs, fmt.Errorf("ARC_ACCESS_LOG_FORMAT %q: want common, combined or json", c.AccessLogFormat))
+	}
+	if c.SentryDSN != "" {
+		if _, _, err := parseSentryDSN(c.SentryDSN); err != nil {
+			errs = append(errs, fmt.Errorf("ARC_SENTRY_DSN: %w", err))
+		}
+	}
+	if c.DebugAddr != "" {
+		if err := validateLoopbackAddr(c.DebugAddr); err != nil {
+			errs = append(errs, fmt.Errorf("ARC_DEBUG_ADDR %q: %w", c.DebugAddr, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateLoopbackAddr accepts host:port addresses that only listen on loopback.
+func validateLoopbackAddr(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return errors.New("debug endpoints must listen on a loopback address such as 127.0.0.1:6060")
+}
+
 // LoadConfig loads Config from environment variables with defaults.
 func LoadConfig() Config {
 	corsDefault := "http://localhost:*,http://127.0.0.1:*"
@@ -49,17 +206,44 @@ func LoadConfig() Config {
 		HTTPAddr:  EnvString("ARC_HTTP_ADDR", "0.0.0.0:8080"),
 		LogLevel:  EnvString("ARC_LOG_LEVEL", "info"),
 		LogFormat: EnvString("ARC_LOG_FORMAT", "auto"),
+		LogLevels: EnvString("ARC_LOG_LEVELS", ""),
+		LogSampling: LogSampling{
+			Messages:   parseCSV(EnvString("ARC_LOG_SAMPLE_MESSAGES", "http.request")),
+			First:      EnvInt("ARC_LOG_SAMPLE_FIRST", 0),
+			Thereafter: EnvInt("ARC_LOG_SAMPLE_THEREAFTER", 100),
+			Tick:       EnvDuration("ARC_LOG_SAMPLE_TICK", time.Second),
+		},
 
 		ReadHeaderTimeout: EnvDuration("ARC_HTTP_READ_HEADER_TIMEOUT", 5*time.Second),
 		ReadTimeout:       EnvDuration("ARC_HTTP_READ_TIMEOUT", 15*time.Second),
 		WriteTimeout:      EnvDuration("ARC_HTTP_WRITE_TIMEOUT", 15*time.Second),
 		IdleTimeout:       EnvDuration("ARC_HTTP_IDLE_TIMEOUT", 60*time.Second),
 
-		MaxHeaderBytes: EnvInt("ARC_HTTP_MAX_HEADER_BYTES", 1<<20),
+		MaxHeaderBytes:  EnvInt("ARC_HTTP_MAX_HEADER_BYTES", 1<<20),
+		ShutdownTimeout: EnvDuration("ARC_HTTP_SHUTDOWN_TIMEOUT", 30*time.Second),
+		H2C:             EnvBool("ARC_HTTP_H2C", false),
+
+		HTTP2MaxConcurrentStreams: EnvInt("ARC_HTTP2_MAX_CONCURRENT_STREAMS", 0),
+		HTTP2SendPingTimeout:      EnvDuration("ARC_HTTP2_SEND_PING_TIMEOUT", 0),
+
+		TLSCertFile:      EnvString("ARC_TLS_CERT_FILE", ""),
+		TLSKeyFile:       EnvString("ARC_TLS_KEY_FILE", ""),
+		ACMEDomains:      EnvCSV("ARC_TLS_ACME_DOMAINS"),
+		ACMEEmail:        EnvString("ARC_TLS_ACME_EMAIL", ""),
+		ACMECacheDir:     EnvString("ARC_TLS_ACME_CACHE_DIR", "acme-cache"),
+		ACMEDirectoryURL: EnvString("ARC_TLS_ACME_DIRECTORY_URL", ""),
+		ACMEHTTPAddr:     EnvString("ARC_TLS_ACME_HTTP_ADDR", ":80"),
+
+		DatabaseURL:        EnvString("ARC_DATABASE_URL", ""),
+		DatabaseReplicaURL: EnvString("ARC_DATABASE_REPLICA_URL", ""),
+		DBMaxConns:         EnvInt32("ARC_DB_MAX_CONNS", 10),
+		DBMinConns:         EnvInt32("ARC_DB_MIN_CONNS", 0),
+		AutoMigrate:        EnvBool("ARC_DB_AUTO_MIGRATE", false),
 
-		DatabaseURL: EnvString("ARC_DATABASE_URL", ""),
-		DBMaxConns:  EnvInt32("ARC_DB_MAX_CONNS", 10),
-		DBMinConns:  EnvInt32("ARC_DB_MIN_CONNS", 0),
+		DBStatementCacheMode:     EnvString("ARC_DB_STATEMENT_CACHE_MODE", "cache_statement"),
+		DBStatementCacheCapacity: EnvInt("ARC_DB_STATEMENT_CACHE_CAPACITY", 512),
+		DBHealthCheckPeriod:      EnvDuration("ARC_DB_HEALTH_CHECK_PERIOD", time.Minute),
+		DBSlowQueryThreshold:     EnvDuration("ARC_DB_SLOW_QUERY_THRESHOLD", 500*time.Millisecond),
 
 		CORSAllowedOrigins:   parseCSV(corsRaw),
 		CORSAllowCredentials: EnvBool("ARC_HTTP_CORS_ALLOW_CREDENTIALS", true),
@@ -67,6 +251,21 @@ func LoadConfig() Config {
 
 		ReadinessRequireDB: EnvBool("ARC_READINESS_REQUIRE_DB", false),
 
+		AccessLog:           EnvString("ARC_ACCESS_LOG", ""),
+		AccessLogFormat:     EnvString("ARC_ACCESS_LOG_FORMAT", AccessLogCombined),
+		AccessLogMaxSizeMB:  EnvInt("ARC_ACCESS_LOG_MAX_SIZE_MB", 100),
+		AccessLogMaxBackups: EnvInt("ARC_ACCESS_LOG_MAX_BACKUPS", 5),
+
+		SentryDSN:         EnvString("ARC_SENTRY_DSN", ""),
+		SentryEnvironment: EnvString("ARC_ENV", "development"),
+
+		MaintenanceMode:       EnvBool("ARC_MAINTENANCE_MODE", false),
+		MaintenanceRetryAfter: EnvDuration("ARC_MAINTENANCE_RETRY_AFTER", maintenance.DefaultRetryAfter),
+		MaintenanceMessage:    EnvString("ARC_MAINTENANCE_MESSAGE", ""),
+
+		MetricsEnabled: EnvBool("ARC_METRICS_ENABLED", true),
+		DebugAddr:      EnvString("ARC_DEBUG_ADDR", ""),
+
 		RequireTokenHMAC: EnvBool("ARC_REQUIRE_TOKEN_HMAC", false),
 	}
 }
@@ -0,0 +1,86 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	valid := Config{HTTPAddr: "0.0.0.0:8080", LogLevel: "info", LogFormat: "auto", LogLevels: "realtime=debug, http=warn", DBMaxConns: 10}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("valid config: %v", err)
+	}
+	for _, addr := range []string{"127.0.0.1:6060", "[::1]:6060", "localhost:6060"} {
+		cfg := valid
+		cfg.DebugAddr = addr
+		if err := cfg.Validate(); err != nil {
+			t.Fatalf("debug addr %s: %v", addr, err)
+		}
+	}
+
+	cases := []struct {
+		name string
+		edit func(*Config)
+		want string
+	}{
+		{"addr", func(c *Config) { c.HTTPAddr = "localhost" }, "ARC_HTTP_ADDR"},
+		{"level", func(c *Config) { c.LogLevel = "verbose" }, "ARC_LOG_LEVEL"},
+		{"format", func(c *Config) { c.LogFormat = "xml" }, "ARC_LOG_FORMAT"},
+		{"component level", func(c *Config) { c.LogLevels = "realtime=loud" }, "ARC_LOG_LEVELS"},
+		{"unknown component", func(c *Config) { c.LogLevels = "realtme=debug" }, "ARC_LOG_LEVELS"},
+		{"max conns", func(c *Config) { c.DBMaxConns = 0 }, "ARC_DB_MAX_CONNS"},
+		{"min conns", func(c *Config) { c.DBMinConns = 11 }, "ARC_DB_MIN_CONNS"},
+		{"debug all interfaces", func(c *Config) { c.DebugAddr = ":6060" }, "ARC_DEBUG_ADDR"},
+		{"debug public", func(c *Config) { c.DebugAddr = "10.0.0.5:6060" }, "ARC_DEBUG_ADDR"},
+		{"tls cert without key", func(c *Config) { c.TLSCertFile = "cert.pem" }, "ARC_TLS_KEY_FILE"},
+		{"tls files and acme", func(c *Config) {
+			c.TLSCertFile, c.TLSKeyFile = "cert.pem", "key.pem"
+			c.ACMEDomains = []string{"chat.example.com"}
+		}, "ARC_TLS_ACME_DOMAINS"},
+		{"sentry dsn", func(c *Config) { c.SentryDSN = "https://sentry.example.com/42" }, "ARC_SENTRY_DSN"},
+		{"access log format", func(c *Config) { c.AccessLogFormat = "apache" }, "ARC_ACCESS_LOG_FORMAT"},
+		{"acme without cache", func(c *Config) { c.ACMEDomains = []string{"chat.example.com"} }, "ARC_TLS_ACME_CACHE_DIR"},
+		{"statement cache mode", func(c *Config) { c.DBStatementCacheMode = "prepared" }, "ARC_DB_STATEMENT_CACHE_MODE"},
+		{"statement cache capacity", func(c *Config) { c.DBStatementCacheCapacity = -1 }, "ARC_DB_STATEMENT_CACHE_CAPACITY"},
+		{"replica without primary", func(c *Config) { c.DatabaseReplicaURL = "postgres://replica/arc" }, "ARC_DATABASE_REPLICA_URL"},
+	}
+	for _, tc := range cases {
+		cfg := valid
+		tc.edit(&cfg)
+		if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("%s: err = %v, want mention of %s", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestLoadConfig_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "arc.toml")
+	src := "[http]\naddr = \"127.0.0.1:9000\"\nread_timeout = \"20s\"\n\n[db]\nmax_conns = 4\n"
+	if err := os.WriteFile(path, []byte(src), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("ARC_CONFIG_FILE", path)
+	t.Setenv("ARC_DB_MAX_CONNS", "8")
+	for _, key := range []string{"ARC_HTTP_ADDR", "ARC_HTTP_READ_TIMEOUT"} {
+		t.Setenv(key, "")
+		_ = os.Unsetenv(key)
+	}
+
+	cfg, loaded, err := loadConfig()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.HTTPAddr != "127.0.0.1:9000" || cfg.ReadTimeout.String() != "20s" {
+		t.Fatalf("file values not applied: %+v", cfg)
+	}
+	if cfg.DBMaxConns != 8 {
+		t.Fatalf("env should override file, got max conns %d", cfg.DBMaxConns)
+	}
+	if loaded.Path != path {
+		t.Fatalf("loaded path = %q", loaded.Path)
+	}
+}
@@ -2,14 +2,18 @@ package app
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 // NewDBPool builds a pgxpool with sane defaults and validates connectivity.
+// tracer, when non-nil, sees every query (see dbtrace).
 // Note: it does NOT run migrations; schema management is handled by Atlas.
-func NewDBPool(ctx context.Context, cfg Config) (*pgxpool.Pool, error) {
+func NewDBPool(ctx context.Context, cfg Config, tracer pgx.QueryTracer) (*pgxpool.Pool, error) {
 	pcfg, err := pgxpool.ParseConfig(cfg.DatabaseURL)
 	if err != nil {
 		return nil, err
@@ -21,6 +25,23 @@ func NewDBPool(ctx context.Context, cfg Config) (*pgxpool.Pool, error) {
 	if cfg.DBMinConns >= 0 {
 		pcfg.MinConns = cfg.DBMinConns
 	}
+	if cfg.DBHealthCheckPeriod > 0 {
+		pcfg.HealthCheckPeriod = cfg.DBHealthCheckPeriod
+	}
+	if cfg.DBStatementCacheMode != "" {
+		mode, err := parseQueryExecMode(cfg.DBStatementCacheMode)
+		if err != nil {
+			return nil, err
+		}
+		pcfg.ConnConfig.DefaultQueryExecMode = mode
+	}
+	if tracer != nil {
+		pcfg.ConnConfig.Tracer = tracer
+	}
+	if cfg.DBStatementCacheCapacity > 0 {
+		pcfg.ConnConfig.StatementCacheCapacity = cfg.DBStatementCacheCapacity
+		pcfg.ConnConfig.DescriptionCacheCapacity = cfg.DBStatementCacheCapacity
+	}
 
 	pool, err := pgxpool.NewWithConfig(ctx, pcfg)
 	if err != nil {
@@ -35,7 +56,7 @@ func NewDBPool(ctx context.Context, cfg Config) (*pgxpool.Pool, error) {
 	return pool, nil
 }
 
-// PingDB checks if we can acquire a connection within timeout.
+// PingDB checks that a pooled connection answers a ping within timeout.
 func PingDB(parent context.Context, pool *pgxpool.Pool, timeout time.Duration) error {
 	ctx, cancel := context.WithTimeout(parent, timeout)
 	defer cancel()
@@ -44,6 +65,24 @@ func PingDB(parent context.Context, pool *pgxpool.Pool, timeout time.Duration) e
 	if err != nil {
 		return err
 	}
-	conn.Release()
-	return nil
+	defer conn.Release()
+	// An idle pooled connection may be dead; a round trip proves it is not.
+	return conn.Ping(ctx)
+}
+
+// parseQueryExecMode maps an ARC_DB_STATEMENT_CACHE_MODE value to a pgx exec mode.
+func parseQueryExecMode(s string) (pgx.QueryExecMode, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "cache_statement":
+		return pgx.QueryExecModeCacheStatement, nil
+	case "cache_describe":
+		return pgx.QueryExecModeCacheDescribe, nil
+	case "describe_exec":
+		return pgx.QueryExecModeDescribeExec, nil
+	case "exec":
+		return pgx.QueryExecModeExec, nil
+	case "simple_protocol":
+		return pgx.QueryExecModeSimpleProtocol, nil
+	}
+	return 0, fmt.Errorf("unknown mode %q: want cache_statement, cache_describe, describe_exec, exec or simple_protocol", s)
 }
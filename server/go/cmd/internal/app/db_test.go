@@ -0,0 +1,29 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func TestParseQueryExecMode(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]pgx.QueryExecMode{
+		"":                 pgx.QueryExecModeCacheStatement,
+		"cache_statement":  pgx.QueryExecModeCacheStatement,
+		" Cache_Describe ": pgx.QueryExecModeCacheDescribe,
+		"describe_exec":    pgx.QueryExecModeDescribeExec,
+		"exec":             pgx.QueryExecModeExec,
+		"simple_protocol":  pgx.QueryExecModeSimpleProtocol,
+	}
+	for in, want := range cases {
+		got, err := parseQueryExecMode(in)
+		if err != nil || got != want {
+			t.Fatalf("parseQueryExecMode(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	if _, err := parseQueryExecMode("prepared"); err == nil {
+		t.Fatalf("expected error for unknown mode")
+	}
+}
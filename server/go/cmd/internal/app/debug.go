@@ -0,0 +1,59 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	runtimepprof "runtime/pprof"
+	"strconv"
+	"time"
+)
+
+// newDebugHandler serves the operator debug endpoints:
+//   - /debug/pprof/*: CPU, heap, goroutine, block, mutex profiles and traces
+//   - /debug/vars: expvar (memstats, cmdline)
+//   - /debug/goroutines: a full stack dump of every goroutine, as text
+//
+// It is only mounted on the separate ARC_DEBUG_ADDR listener, which Config.Validate
+// restricts to loopback, never on the public mux.
+func newDebugHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+	mux.HandleFunc("/debug/goroutines", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("X-Goroutine-Count", strconv.Itoa(runtime.NumGoroutine()))
+		_ = runtimepprof.Lookup("goroutine").WriteTo(w, 2)
+	})
+	return mux
+}
+
+// runDebugServer serves newDebugHandler on addr until ctx is done. Failures are
+// logged and never stop the main server.
+func runDebugServer(ctx context.Context, log Logger, addr string) {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           newDebugHandler(),
+		ReadHeaderTimeout: 5 * time.Second,
+		// No write timeout: CPU profiles and traces stream for their whole duration.
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	log.Info("debug.start", "addr", addr, "pprof", "http://"+addr+"/debug/pprof/", "result", "success")
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Error("debug.fail", "addr", addr, "err", err, "result", "server_error")
+	}
+}
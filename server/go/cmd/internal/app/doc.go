@@ -1,3 +1,8 @@
 // Package app wires the Arc server runtime: config, logging, HTTP routes, and realtime gateways.
 // It is intentionally small and deterministic to keep CI gates strict and behavior predictable.
+//
+// New is the assembly point for the server; cmd/arc only calls Run. Server builds the same App
+// from explicit Config and Components instead of the ARC_* environment, for embedding and tests.
+// Options passed to either replace individual dependencies (pool, message store, broker) so tests
+// can drive App.Handler without a listener.
 package app
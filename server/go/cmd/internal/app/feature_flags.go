@@ -0,0 +1,36 @@
+package app
+
+import (
+	"arc/cmd/internal/config"
+	"arc/cmd/internal/featureflags"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// newFeatureFlags builds the flags for schema from cfg, with overrides from
+// <schema>.feature_flags when the database is enabled.
+func newFeatureFlags(cfg featureflags.Config, pool *pgxpool.Pool, dbEnabled bool, schema string) (*featureflags.Flags, error) {
+	var store featureflags.Store
+	if dbEnabled && pool != nil {
+		pg, err := featureflags.NewPostgresStore(pool, featureflags.WithSchema(schema))
+		if err != nil {
+			return nil, err
+		}
+		store = pg
+	}
+	return featureflags.New(cfg, store), nil
+}
+
+// reloadFeatureFlags keeps the static values of f in step with ARC_FEATURE_FLAGS.
+// An invalid value on reload is logged and the previous values stay in effect.
+func reloadFeatureFlags(f *featureflags.Flags, log Logger) {
+	config.Subscribe(func(_ []string) {
+		cfg, err := featureflags.LoadConfigFromEnv()
+		if err != nil {
+			log.Error("featureflags.reload.invalid", "err", err, "result", "client_error")
+			return
+		}
+		f.SetStatic(cfg.Values)
+		log.Info("featureflags.reloaded", "result", "success")
+	}, "ARC_FEATURE_FLAGS")
+}
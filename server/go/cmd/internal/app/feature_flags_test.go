@@ -0,0 +1,32 @@
+package app
+
+import (
+	"context"
+	"testing"
+
+	"arc/cmd/internal/featureflags"
+)
+
+func TestNewFeatureFlags(t *testing.T) {
+	t.Setenv("ARC_FEATURE_FLAGS", "auth.mfa_required,realtime.presence=false")
+	comps, err := loadComponentsFromEnv(false)
+	if err != nil {
+		t.Fatalf("loadComponentsFromEnv: %v", err)
+	}
+	flags, err := newFeatureFlags(comps.FeatureFlags, nil, false, "arc")
+	if err != nil {
+		t.Fatalf("newFeatureFlags: %v", err)
+	}
+	ctx := context.Background()
+	if !flags.Enabled(ctx, featureflags.AuthMFARequired) || flags.Enabled(ctx, featureflags.RealtimePresence) {
+		t.Fatalf("expected ARC_FEATURE_FLAGS values, got %+v", flags.List(ctx))
+	}
+	if _, err := flags.Set(ctx, featureflags.AuthMFARequired, false, ""); err == nil {
+		t.Fatalf("expected overrides to be unavailable without a database")
+	}
+
+	t.Setenv("ARC_FEATURE_FLAGS", "auth.unknown")
+	if _, err := loadComponentsFromEnv(false); err == nil {
+		t.Fatalf("expected error for unknown flag")
+	}
+}
@@ -2,10 +2,16 @@ package app
 
 import (
 	"net/http"
-	"time"
 
+	"arc/cmd/internal/attachments"
 	authapi "arc/cmd/internal/auth/api"
+	"arc/cmd/internal/bots"
+	"arc/cmd/internal/e2ee"
+	"arc/cmd/internal/metrics"
+	"arc/cmd/internal/moderation"
+	"arc/cmd/internal/push"
 	"arc/cmd/internal/realtime"
+	"arc/cmd/internal/scim"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -16,35 +22,69 @@ func registerHTTP(
 	cfg Config,
 	dbPool *pgxpool.Pool,
 	dbEnabled bool,
+	broker realtime.Broker,
 	ws *realtime.WSGateway,
 	auth *authapi.Handler,
+	scimHandler *scim.Handler,
+	attachmentHandler *attachments.Handler,
+	pushHandler *push.Handler,
+	e2eeHandler *e2ee.Handler,
+	botHandler *bots.Handler,
+	moderationHandler *moderation.Handler,
 ) {
 	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte("ok\n"))
 	})
 
-	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
-		if cfg.ReadinessRequireDB && !dbEnabled {
-			http.Error(w, "db not configured", http.StatusServiceUnavailable)
-			return
-		}
-
-		if dbEnabled && dbPool != nil {
-			if err := PingDB(r.Context(), dbPool, 2*time.Second); err != nil {
-				http.Error(w, "db not ready", http.StatusServiceUnavailable)
-				log.Info("readyz.db.not_ready", "err", err)
-				return
-			}
-		}
-
-		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write([]byte("ready\n"))
+	mux.Handle("/readyz", readiness{
+		log:         log,
+		cfg:         cfg,
+		dbPool:      dbPool,
+		dbEnabled:   dbEnabled,
+		authEnabled: auth != nil,
+		broker:      broker,
 	})
 
+	if cfg.MetricsEnabled {
+		mux.Handle("/metrics", metrics.Handler())
+	}
+
 	if auth != nil {
 		auth.Register(mux)
 	}
+	if scimHandler != nil {
+		scimHandler.Register(mux)
+	}
+	if attachmentHandler != nil {
+		attachmentHandler.Register(mux)
+	}
+	if pushHandler != nil {
+		pushHandler.Register(mux)
+	}
+	if e2eeHandler != nil {
+		e2eeHandler.Register(mux)
+	}
+	if botHandler != nil {
+		botHandler.Register(mux)
+	}
+	if moderationHandler != nil {
+		moderationHandler.Register(mux)
+	}
+
+	registerRealtimeHTTP(mux, ws)
+}
 
+// registerRealtimeHTTP wires the realtime gateway's WebSocket, gRPC and REST routes.
+func registerRealtimeHTTP(mux *http.ServeMux, ws *realtime.WSGateway) {
 	mux.HandleFunc("/ws", ws.HandleWS)
+	mux.HandleFunc(realtime.GRPCPathPrefix, ws.HandleGRPC)
+	mux.HandleFunc("/conversations/direct", ws.HandleDirectConversations)
+	mux.HandleFunc("/conversations/{id}/members", ws.HandleMembers)
+	mux.HandleFunc("/conversations/{id}/members/{user_id}", ws.HandleMember)
+	mux.HandleFunc("/conversations/{id}/messages/{msg_id}/receipts", ws.HandleMessageReceipts)
+	mux.HandleFunc("/me/conversations", ws.HandleMyConversations)
+	mux.HandleFunc("/users/{id}/presence", ws.HandleUserPresence)
+	mux.HandleFunc("/admin/ws/connections", ws.HandleAdminConnections)
+	mux.HandleFunc("/admin/ws/connections/{session_id}/close", ws.HandleAdminConnectionClose)
 }
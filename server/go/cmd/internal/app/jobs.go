@@ -0,0 +1,37 @@
+package app
+
+import (
+	"arc/cmd/internal/jobs"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// newScheduler registers the background jobs cfg allows on this instance
+// (ARC_JOBS_ENABLED and ARC_JOBS_DISABLED). With a database, instances elect one
+// leader per job through Postgres advisory locks. It returns nil when no job runs
+// here.
+func newScheduler(log Logger, cfg jobs.Config, pool *pgxpool.Pool, dbEnabled bool, candidates ...jobs.Job) (*jobs.Scheduler, error) {
+	var locker jobs.Locker
+	if dbEnabled && pool != nil {
+		pg, err := jobs.NewPostgresLocker(pool)
+		if err != nil {
+			return nil, err
+		}
+		locker = pg
+	}
+
+	s := jobs.New(log, locker)
+	for _, j := range candidates {
+		if !cfg.Allows(j.Name) {
+			log.Info("jobs.disabled", "job", j.Name)
+			continue
+		}
+		if err := s.Add(j); err != nil {
+			return nil, err
+		}
+	}
+	if s.Len() == 0 {
+		return nil, nil
+	}
+	return s, nil
+}
@@ -0,0 +1,110 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"arc/cmd/internal/metrics"
+)
+
+var logsSampled = metrics.NewCounterVec("arc_log_sampled_total",
+	"Log records dropped by sampling, by message.", "message")
+
+// LogSampling thins out high-volume INFO and DEBUG records such as http.request.
+// Within each Tick, the first First records of a message are logged, then every
+// Thereafter-th one. Warnings and errors are never sampled.
+type LogSampling struct {
+	Messages   []string
+	First      int
+	Thereafter int
+	Tick       time.Duration
+}
+
+func (s LogSampling) enabled() bool {
+	return len(s.Messages) > 0 && s.First > 0 && s.Tick > 0
+}
+
+// samplingHandler applies LogSampling in front of next. Handlers derived with
+// WithAttrs or WithGroup share the counters, so component loggers count against
+// the same budget.
+type samplingHandler struct {
+	next    slog.Handler
+	sampler *logSampler
+}
+
+type logSampler struct {
+	messages   map[string]bool
+	first      uint64
+	thereafter uint64
+	tick       time.Duration
+
+	mu     sync.Mutex
+	counts map[string]*sampleWindow
+}
+
+type sampleWindow struct {
+	start time.Time
+	n     uint64
+}
+
+func newSamplingHandler(next slog.Handler, cfg LogSampling) *samplingHandler {
+	s := &logSampler{
+		messages:   make(map[string]bool, len(cfg.Messages)),
+		first:      uint64(cfg.First),
+		thereafter: uint64(max(cfg.Thereafter, 0)),
+		tick:       cfg.Tick,
+		counts:     make(map[string]*sampleWindow, len(cfg.Messages)),
+	}
+	for _, m := range cfg.Messages {
+		s.messages[m] = true
+	}
+	return &samplingHandler{next: next, sampler: s}
+}
+
+// keep reports whether the n-th record of msg in the current window is logged.
+func (s *logSampler) keep(msg string, t time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w, ok := s.counts[msg]
+	if !ok {
+		w = &sampleWindow{}
+		s.counts[msg] = w
+	}
+	if start := t.Truncate(s.tick); !start.Equal(w.start) {
+		w.start, w.n = start, 0
+	}
+	w.n++
+	if w.n <= s.first {
+		return true
+	}
+	return s.thereafter > 0 && (w.n-s.first)%s.thereafter == 0
+}
+
+func (h *samplingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *samplingHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level < slog.LevelWarn && h.sampler.messages[r.Message] {
+		t := r.Time
+		if t.IsZero() {
+			t = time.Now()
+		}
+		if !h.sampler.keep(r.Message, t) {
+			logsSampled.With(r.Message).Inc()
+			return nil
+		}
+	}
+	return h.next.Handle(ctx, r)
+}
+
+func (h *samplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &samplingHandler{next: h.next.WithAttrs(attrs), sampler: h.sampler}
+}
+
+func (h *samplingHandler) WithGroup(name string) slog.Handler {
+	return &samplingHandler{next: h.next.WithGroup(name), sampler: h.sampler}
+}
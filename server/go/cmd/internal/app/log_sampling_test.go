@@ -0,0 +1,55 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSamplingHandler(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	h := newSamplingHandler(slog.NewJSONHandler(&buf, nil), LogSampling{
+		Messages:   []string{"http.request"},
+		First:      2,
+		Thereafter: 3,
+		Tick:       time.Second,
+	})
+	log := slog.New(h).With("component", "http")
+
+	emit := func(level slog.Level, msg string, at time.Time, n int) {
+		for i := range n {
+			r := slog.NewRecord(at, level, msg, 0)
+			r.AddAttrs(slog.Int("i", i))
+			if err := log.Handler().Handle(context.Background(), r); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	t0 := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	emit(slog.LevelInfo, "http.request", t0, 10)                 // keeps 1, 2, 5, 8
+	emit(slog.LevelInfo, "http.request", t0.Add(time.Second), 1) // new window
+	emit(slog.LevelWarn, "http.request", t0, 3)                  // never sampled
+	emit(slog.LevelInfo, "auth.login", t0, 5)                    // not configured
+
+	count := func(s string) int { return strings.Count(buf.String(), s) }
+	if n := count(`"level":"INFO","msg":"http.request"`); n != 5 {
+		t.Fatalf("kept %d info http.request records, want 5:\n%s", n, buf.String())
+	}
+	if n := count(`"level":"WARN","msg":"http.request"`); n != 3 {
+		t.Fatalf("kept %d warn records, want 3", n)
+	}
+	if n := count(`"msg":"auth.login"`); n != 5 {
+		t.Fatalf("kept %d auth.login records, want 5", n)
+	}
+	for _, i := range []string{`"i":0}`, `"i":1}`, `"i":4}`, `"i":7}`} {
+		if !strings.Contains(buf.String(), i) {
+			t.Fatalf("expected record %s to be kept:\n%s", i, buf.String())
+		}
+	}
+}
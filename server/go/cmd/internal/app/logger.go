@@ -1,19 +1,39 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"math"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"arc/cmd/internal/redact"
 )
 
 // Logger is the app-wide logger type (slog).
 type Logger = *slog.Logger
 
+// logLevel is the minimum level of the app logger; SetLogLevel changes it at runtime.
+var logLevel slog.LevelVar
+
+// handlerLevel is the level the output handler is built with: the lowest of
+// logLevel and every component override, so a component can log below the app
+// level. The levelHandlers in front of it do the actual filtering.
+var handlerLevel slog.LevelVar
+
+// LogComponents are the names ComponentLogger accepts in ARC_LOG_LEVELS.
+var LogComponents = []string{
+	"http", "db", "authapi", "realtime", "scim", "attachments", "push", "e2ee", "bots", "moderation", "retention",
+	"jobs",
+}
+
 // NewLogger creates an app logger with configurable level + format.
 //
 // ARC_LOG_FORMAT options:
@@ -22,14 +42,174 @@ type Logger = *slog.Logger
 // - "text"   : slog text
 // - "json"   : structured JSON
 func NewLogger(level string, format string) *slog.Logger {
-	lvl := parseLogLevel(level)
-	h := newHandler(lvl, format)
+	return newLogger(level, format, LogSampling{})
+}
+
+// NewLoggerFromConfig is NewLogger plus the per-component levels and sampling
+// from cfg, and the ARC_REDACT_* redaction settings.
+func NewLoggerFromConfig(cfg Config) *slog.Logger {
+	redact.SetDefault(redact.New(redact.LoadConfigFromEnv()))
+	return newConfigLogger(cfg)
+}
 
-	log := slog.New(h)
+// newConfigLogger is NewLoggerFromConfig with the current redaction settings.
+func newConfigLogger(cfg Config) *slog.Logger {
+	levels, _ := parseComponentLevels(cfg.LogLevels)
+	setComponentLevels(levels)
+	return newLogger(cfg.LogLevel, cfg.LogFormat, cfg.LogSampling)
+}
+
+func newLogger(level, format string, sampling LogSampling) *slog.Logger {
+	logLevel.Set(parseLogLevel(level))
+	updateHandlerLevel()
+
+	// Every record is redacted (tokens, emails, client IPs) before it is written.
+	var h slog.Handler = redact.NewHandler(newHandler(&handlerLevel, format), nil)
+	if sampling.enabled() {
+		h = newSamplingHandler(h, sampling)
+	}
+	log := slog.New(&levelHandler{level: &logLevel, next: h})
 	slog.SetDefault(log)
 	return log
 }
 
+// SetLogLevel changes the level of loggers built by NewLogger. Source locations stay
+// as configured at startup.
+func SetLogLevel(level string) {
+	logLevel.Set(parseLogLevel(level))
+	updateHandlerLevel()
+}
+
+// SetComponentLogLevels applies an ARC_LOG_LEVELS value ("realtime=debug,http=warn")
+// at runtime. Components it does not name follow the app level again.
+func SetComponentLogLevels(spec string) error {
+	levels, err := parseComponentLevels(spec)
+	if err != nil {
+		return err
+	}
+	setComponentLevels(levels)
+	return nil
+}
+
+// ComponentLogger returns log tagged with component=name whose level is set by
+// ARC_LOG_LEVELS, falling back to ARC_LOG_LEVEL. name should be one of
+// LogComponents.
+func ComponentLogger(log Logger, name string) Logger {
+	h := log.Handler()
+	if lh, ok := h.(*levelHandler); ok {
+		h = lh.next
+	}
+	return slog.New(&levelHandler{
+		level: componentLevelFor(name),
+		next:  h.WithAttrs([]slog.Attr{slog.String("component", name)}),
+	})
+}
+
+// componentLevel is a component's override of the app level.
+type componentLevel struct {
+	set   atomic.Bool
+	level slog.LevelVar
+}
+
+func (c *componentLevel) Level() slog.Level {
+	if c.set.Load() {
+		return c.level.Level()
+	}
+	return logLevel.Level()
+}
+
+var componentLevels struct {
+	mu sync.Mutex
+	m  map[string]*componentLevel
+}
+
+func componentLevelFor(name string) *componentLevel {
+	componentLevels.mu.Lock()
+	defer componentLevels.mu.Unlock()
+	if componentLevels.m == nil {
+		componentLevels.m = make(map[string]*componentLevel)
+	}
+	c, ok := componentLevels.m[name]
+	if !ok {
+		c = &componentLevel{}
+		componentLevels.m[name] = c
+	}
+	return c
+}
+
+func setComponentLevels(levels map[string]slog.Level) {
+	for _, name := range LogComponents {
+		c := componentLevelFor(name)
+		if lvl, ok := levels[name]; ok {
+			c.level.Set(lvl)
+			c.set.Store(true)
+		} else {
+			c.set.Store(false)
+		}
+	}
+	updateHandlerLevel()
+}
+
+func updateHandlerLevel() {
+	componentLevels.mu.Lock()
+	defer componentLevels.mu.Unlock()
+	lowest := logLevel.Level()
+	for _, c := range componentLevels.m {
+		if c.set.Load() {
+			lowest = min(lowest, c.level.Level())
+		}
+	}
+	handlerLevel.Set(lowest)
+}
+
+// parseComponentLevels parses "name=level" pairs separated by commas. Unknown
+// components and levels are errors so typos do not go unnoticed.
+func parseComponentLevels(spec string) (map[string]slog.Level, error) {
+	levels := make(map[string]slog.Level)
+	for _, pair := range parseCSV(spec) {
+		name, level, ok := strings.Cut(pair, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !ok || !slices.Contains(LogComponents, name) {
+			return nil, fmt.Errorf("%q: want component=level with component one of %s", pair, strings.Join(LogComponents, ", "))
+		}
+		if !validLogLevel(level) {
+			return nil, fmt.Errorf("%q: want debug, info, warn or error", pair)
+		}
+		levels[name] = parseLogLevel(level)
+	}
+	return levels, nil
+}
+
+// levelHandler filters records below level before they reach next.
+type levelHandler struct {
+	level slog.Leveler
+	next  slog.Handler
+}
+
+func (h *levelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level.Level() && h.next.Enabled(ctx, level)
+}
+
+func (h *levelHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.next.Handle(ctx, r)
+}
+
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelHandler{level: h.level, next: h.next.WithAttrs(attrs)}
+}
+
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{level: h.level, next: h.next.WithGroup(name)}
+}
+
+func validLogLevel(level string) bool {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug", "info", "warn", "warning", "error":
+		return true
+	}
+	return false
+}
+
 func parseLogLevel(level string) slog.Level {
 	switch strings.ToLower(strings.TrimSpace(level)) {
 	case "debug":
@@ -43,8 +223,9 @@ func parseLogLevel(level string) slog.Level {
 	}
 }
 
-func newHandler(level slog.Level, format string) slog.Handler {
+func newHandler(level slog.Leveler, format string) slog.Handler {
 	out := os.Stdout
+	debug := level.Level() <= slog.LevelDebug
 	format = strings.ToLower(strings.TrimSpace(format))
 	color := isLikelyTerminal(out)
 
@@ -60,12 +241,12 @@ func newHandler(level slog.Level, format string) slog.Handler {
 	case "pretty":
 		return newPrettyHandler(out, &slog.HandlerOptions{
 			Level:     level,
-			AddSource: level <= slog.LevelDebug,
+			AddSource: debug,
 		}, color)
 	case "text":
 		return slog.NewTextHandler(out, &slog.HandlerOptions{
 			Level:     level,
-			AddSource: level <= slog.LevelDebug,
+			AddSource: debug,
 			ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
 				return replaceTextAttr(a)
 			},
@@ -1,7 +1,9 @@
 package app
 
 import (
+	"bytes"
 	"log/slog"
+	"strings"
 	"testing"
 )
 
@@ -49,3 +51,53 @@ func TestLevelTag_NoColor(t *testing.T) {
 		}
 	}
 }
+
+func TestComponentLogger(t *testing.T) {
+	t.Cleanup(func() {
+		setComponentLevels(nil)
+		SetLogLevel("info")
+	})
+
+	var buf bytes.Buffer
+	root := slog.New(&levelHandler{
+		level: &logLevel,
+		next:  slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: &handlerLevel}),
+	})
+	SetLogLevel("info")
+	if err := SetComponentLogLevels("realtime=debug,http=warn"); err != nil {
+		t.Fatal(err)
+	}
+	rt := ComponentLogger(root, "realtime")
+	httpLog := ComponentLogger(root, "http")
+
+	root.Debug("root.debug")
+	rt.Debug("realtime.debug")
+	httpLog.Info("http.request")
+	httpLog.Warn("http.slow")
+
+	out := buf.String()
+	if strings.Contains(out, "root.debug") || strings.Contains(out, `"msg":"http.request"`) {
+		t.Fatalf("records below their level were logged:\n%s", out)
+	}
+	if !strings.Contains(out, `"msg":"realtime.debug","component":"realtime"`) || !strings.Contains(out, "http.slow") {
+		t.Fatalf("component records missing:\n%s", out)
+	}
+
+	// Dropping the override puts the component back on the app level.
+	buf.Reset()
+	if err := SetComponentLogLevels(""); err != nil {
+		t.Fatal(err)
+	}
+	rt.Debug("realtime.debug")
+	httpLog.Info("http.request")
+	if out := buf.String(); strings.Contains(out, "realtime.debug") || !strings.Contains(out, "http.request") {
+		t.Fatalf("after reset:\n%s", out)
+	}
+	if handlerLevel.Level() != slog.LevelInfo {
+		t.Fatalf("handler level = %v, want info", handlerLevel.Level())
+	}
+
+	if err := SetComponentLogLevels("identity=debug"); err == nil {
+		t.Fatal("unknown component accepted")
+	}
+}
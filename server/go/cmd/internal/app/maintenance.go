@@ -0,0 +1,78 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	authapi "arc/cmd/internal/auth/api"
+	"arc/cmd/internal/config"
+	"arc/cmd/internal/maintenance"
+	"arc/cmd/internal/metrics"
+	"arc/cmd/internal/realtime"
+)
+
+// maintenanceAdminPath stays writable during maintenance so an admin can turn it off.
+const maintenanceAdminPath = "/admin/maintenance"
+
+var httpMaintenanceRejected = metrics.NewCounter("arc_http_maintenance_rejected_total",
+	"Mutating requests answered with 503 during maintenance mode.")
+
+// newMaintenanceMode builds the maintenance switch from cfg and keeps it in step
+// with ARC_MAINTENANCE_* on config reloads. A reload overrides an earlier admin
+// toggle only when one of those keys changed.
+func newMaintenanceMode(cfg Config, log Logger) *maintenance.Mode {
+	status := func(c Config) maintenance.Status {
+		return maintenance.Status{
+			Enabled:    c.MaintenanceMode,
+			RetryAfter: c.MaintenanceRetryAfter,
+			Message:    c.MaintenanceMessage,
+			Source:     "config",
+		}
+	}
+	m := maintenance.New(status(cfg))
+	if cfg.MaintenanceMode {
+		log.Warn("http.maintenance.enabled", "source", "config", "retry_after", cfg.MaintenanceRetryAfter)
+	}
+	config.Subscribe(func(_ []string) {
+		st := status(LoadConfig())
+		m.Set(st)
+		log.Warn("http.maintenance.reloaded", "enabled", st.Enabled, "retry_after", st.RetryAfter, "result", "success")
+	}, "ARC_MAINTENANCE_MODE", "ARC_MAINTENANCE_RETRY_AFTER", "ARC_MAINTENANCE_MESSAGE")
+	return m
+}
+
+// WithMaintenance answers mutating requests with 503, Retry-After and a
+// {"error":{"code":"maintenance"}} body while m is enabled. GET, HEAD and OPTIONS
+// (so health checks, readiness, metrics and WebSocket upgrades) pass through, as
+// do /admin/maintenance (versioned or not) and gRPC calls, which the realtime gateway gates itself.
+// A nil m returns next.
+func WithMaintenance(next http.Handler, m *maintenance.Mode) http.Handler {
+	if m == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		st := m.Status()
+		if !st.Enabled || !maintenance.Mutating(r.Method) || r.URL.Path == maintenanceAdminPath ||
+			r.URL.Path == authapi.APIVersionPrefix+maintenanceAdminPath || strings.HasPrefix(r.URL.Path, realtime.GRPCPathPrefix) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		httpMaintenanceRejected.Inc()
+		msg := st.Message
+		if msg == "" {
+			msg = "service is under maintenance"
+		}
+		h := w.Header()
+		h.Set("Retry-After", strconv.Itoa(st.RetryAfterSeconds()))
+		h.Set("Content-Type", "application/json; charset=utf-8")
+		h.Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_ = json.NewEncoder(w).Encode(map[string]any{"error": map[string]string{
+			"code":    "maintenance",
+			"message": msg,
+		}})
+	})
+}
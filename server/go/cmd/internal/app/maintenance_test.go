@@ -0,0 +1,63 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"arc/cmd/internal/maintenance"
+)
+
+func TestWithMaintenance(t *testing.T) {
+	t.Parallel()
+
+	mode := maintenance.New(maintenance.Status{Enabled: true, RetryAfter: 90 * time.Second, Message: "db upgrade"})
+	h := WithMaintenance(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}), mode)
+
+	serve := func(method, target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+		return w
+	}
+
+	for _, tc := range []struct {
+		method, target string
+	}{
+		{http.MethodGet, "/healthz"},
+		{http.MethodGet, "/readyz"},
+		{http.MethodGet, "/me"},
+		{http.MethodOptions, "/auth/login"},
+		{http.MethodPost, "/admin/maintenance"},
+		{http.MethodPost, "/v1/admin/maintenance"},
+		{http.MethodPost, "/arc.realtime.v1.Realtime/Stream"},
+	} {
+		if w := serve(tc.method, tc.target); w.Code != http.StatusNoContent {
+			t.Fatalf("%s %s: status %d, want pass-through", tc.method, tc.target, w.Code)
+		}
+	}
+
+	for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+		w := serve(method, "/auth/login")
+		if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") != "90" {
+			t.Fatalf("%s: status %d, Retry-After %q", method, w.Code, w.Header().Get("Retry-After"))
+		}
+		var body struct {
+			Error struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Error.Code != "maintenance" || body.Error.Message != "db upgrade" {
+			t.Fatalf("body = %s (%v)", w.Body.String(), err)
+		}
+	}
+
+	mode.Set(maintenance.Status{Enabled: false})
+	if w := serve(http.MethodPost, "/auth/login"); w.Code != http.StatusNoContent {
+		t.Fatalf("after disable: status %d", w.Code)
+	}
+}
@@ -0,0 +1,83 @@
+package app
+
+import (
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"arc/cmd/internal/metrics"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	httpRequests = metrics.NewCounterVec("arc_http_requests_total",
+		"HTTP requests by method, route pattern and status code.", "method", "route", "status")
+	httpDuration = metrics.NewHistogramVec("arc_http_request_duration_seconds",
+		"HTTP request latency by method and route pattern. WebSocket upgrades count the whole session.",
+		metrics.DefBuckets, "method", "route")
+)
+
+// WithMetrics records request counts and latencies. Requests are labeled with the
+// ServeMux pattern that handled them, so path parameters do not create new series;
+// requests no pattern matched are labeled "unmatched".
+func WithMetrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		lrw := &loggingResponseWriter{
+			ResponseWriter: w,
+			status:         http.StatusOK,
+		}
+
+		next.ServeHTTP(lrw, r)
+
+		// ServeMux sets r.Pattern on the request it was given, which is this one.
+		route := r.Pattern
+		if route == "" {
+			route = "unmatched"
+		}
+		method := metricsMethod(r.Method)
+		httpRequests.With(method, route, strconv.Itoa(lrw.status)).Inc()
+		httpDuration.With(method, route).Observe(time.Since(start).Seconds())
+	})
+}
+
+// metricsMethod folds non-standard methods into one label value.
+func metricsMethod(m string) string {
+	switch m {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodOptions:
+		return m
+	}
+	return "OTHER"
+}
+
+// metricsPool is the pool the arc_db_pool_* metrics report on; nil without a database.
+var metricsPool atomic.Pointer[pgxpool.Pool]
+
+func init() {
+	stat := func(fn func(s *pgxpool.Stat) float64) func() float64 {
+		return func() float64 {
+			p := metricsPool.Load()
+			if p == nil {
+				return 0
+			}
+			return fn(p.Stat())
+		}
+	}
+	metrics.NewGaugeFunc("arc_db_pool_total_conns", "Open Postgres pool connections.",
+		stat(func(s *pgxpool.Stat) float64 { return float64(s.TotalConns()) }))
+	metrics.NewGaugeFunc("arc_db_pool_acquired_conns", "Postgres pool connections in use.",
+		stat(func(s *pgxpool.Stat) float64 { return float64(s.AcquiredConns()) }))
+	metrics.NewGaugeFunc("arc_db_pool_idle_conns", "Idle Postgres pool connections.",
+		stat(func(s *pgxpool.Stat) float64 { return float64(s.IdleConns()) }))
+	metrics.NewGaugeFunc("arc_db_pool_max_conns", "Maximum Postgres pool size.",
+		stat(func(s *pgxpool.Stat) float64 { return float64(s.MaxConns()) }))
+	metrics.NewCounterFunc("arc_db_pool_acquires_total", "Postgres pool connection acquires.",
+		stat(func(s *pgxpool.Stat) float64 { return float64(s.AcquireCount()) }))
+	metrics.NewCounterFunc("arc_db_pool_empty_acquires_total", "Acquires that waited because the Postgres pool was empty.",
+		stat(func(s *pgxpool.Stat) float64 { return float64(s.EmptyAcquireCount()) }))
+	metrics.NewCounterFunc("arc_db_pool_acquire_seconds_total", "Total time spent acquiring Postgres pool connections.",
+		stat(func(s *pgxpool.Stat) float64 { return s.AcquireDuration().Seconds() }))
+}
@@ -0,0 +1,66 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/common/model"
+)
+
+func TestWithMetrics_LabelsByPattern(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/test-metrics/{id}", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	h := WithMetrics(mux)
+
+	for _, path := range []string{"/test-metrics/a", "/test-metrics/b"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("PROPFIND", "/test-metrics-missing", nil))
+
+	if n := httpRequests.With("GET", "/test-metrics/{id}", "418").Value(); n != 2 {
+		t.Fatalf("pattern counter = %d, want 2", n)
+	}
+	if n := httpRequests.With("OTHER", "unmatched", "404").Value(); n < 1 {
+		t.Fatalf("unmatched counter = %d, want >= 1", n)
+	}
+	if n := httpDuration.With("GET", "/test-metrics/{id}").Count(); n != 2 {
+		t.Fatalf("latency observations = %d, want 2", n)
+	}
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	mux := http.NewServeMux()
+	registerHTTP(mux, nil, Config{MetricsEnabled: true}, nil, false, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	// Labeled families appear once a label set is used: the first scrape creates
+	// the arc_http_requests_total series the second one reads.
+	h := WithMetrics(mux)
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d", rr.Code)
+	}
+	parser := expfmt.NewTextParser(model.LegacyValidation)
+	families, err := parser.TextToMetricFamilies(rr.Body)
+	if err != nil {
+		t.Fatalf("parse /metrics: %v", err)
+	}
+	for _, name := range []string{"arc_http_requests_total", "arc_db_pool_acquired_conns", "arc_ws_connections", "arc_auth_refresh_rotations_total"} {
+		if _, ok := families[name]; !ok {
+			t.Fatalf("missing %s", name)
+		}
+	}
+
+	mux = http.NewServeMux()
+	registerHTTP(mux, nil, Config{}, nil, false, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("disabled status = %d", rr.Code)
+	}
+}
@@ -10,7 +10,10 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
+
+	"arc/cmd/internal/config"
 )
 
 // WithRequestLogging wraps an http.Handler and logs requests.
@@ -62,25 +65,32 @@ func WithSecurityHeaders(next http.Handler) http.Handler {
 	})
 }
 
-// WithCORS enforces an explicit allowlist and handles CORS preflight.
-func WithCORS(next http.Handler, cfg Config, log *slog.Logger) http.Handler {
+// WithCORS enforces an explicit allowlist and handles CORS preflight. csrfHeader
+// is the auth API's CSRF header (ARC_AUTH_CSRF_HEADER_NAME, default X-CSRF-Token),
+// which cross-origin clients may send and read.
+// The allowlist follows config reloads; the other CORS settings are fixed at startup.
+func WithCORS(next http.Handler, cfg Config, csrfHeader string, log *slog.Logger) http.Handler {
 	if log == nil {
 		log = slog.Default()
 	}
-
-	allowedOrigins := make([]string, 0, len(cfg.CORSAllowedOrigins))
-	for _, origin := range cfg.CORSAllowedOrigins {
-		origin = strings.TrimSpace(origin)
-		if origin == "" {
-			continue
-		}
-		allowedOrigins = append(allowedOrigins, origin)
+	csrfHeader = strings.TrimSpace(csrfHeader)
+	if csrfHeader == "" {
+		csrfHeader = "X-CSRF-Token"
 	}
 
+	var allowed atomic.Pointer[[]string]
+	origins := corsOrigins(cfg.CORSAllowedOrigins)
+	allowed.Store(&origins)
+	config.Subscribe(func(_ []string) {
+		origins := corsOrigins(LoadConfig().CORSAllowedOrigins)
+		allowed.Store(&origins)
+		log.Info("http.cors.reloaded", "allowed_origins", origins, "result", "success")
+	}, "ARC_HTTP_CORS_ALLOWED_ORIGINS", "ARC_CORS_ALLOWED_ORIGINS")
+
 	allowedMethods := []string{http.MethodGet, http.MethodPost, http.MethodOptions}
 	allowedMethodsHeader := strings.Join(allowedMethods, ", ")
 
-	allowedHeaders := []string{"Authorization", "Content-Type", "X-CSRF-Token"}
+	allowedHeaders := []string{"Authorization", "Content-Type", csrfHeader}
 	allowedHeadersHeader := strings.Join(allowedHeaders, ", ")
 	allowedHeadersSet := make(map[string]struct{}, len(allowedHeaders))
 	for _, h := range allowedHeaders {
@@ -106,7 +116,7 @@ func WithCORS(next http.Handler, cfg Config, log *slog.Logger) http.Handler {
 			return
 		}
 
-		if !corsOriginAllowed(origin, allowedOrigins) {
+		if !corsOriginAllowed(origin, *allowed.Load()) {
 			log.Warn("http.cors.origin_denied", "origin", origin, "path", r.URL.Path, "result", "client_error")
 			http.Error(w, "origin not allowed", http.StatusForbidden)
 			return
@@ -120,6 +130,8 @@ func WithCORS(next http.Handler, cfg Config, log *slog.Logger) http.Handler {
 		if cfg.CORSAllowCredentials {
 			h.Set("Access-Control-Allow-Credentials", "true")
 		}
+		// Cross-site web clients read the CSRF token from this header (SameSite=None cookie mode).
+		h.Set("Access-Control-Expose-Headers", csrfHeader)
 
 		if r.Method == http.MethodOptions && strings.TrimSpace(r.Header.Get("Access-Control-Request-Method")) != "" {
 			reqMethod := strings.TrimSpace(r.Header.Get("Access-Control-Request-Method"))
@@ -142,6 +154,18 @@ func WithCORS(next http.Handler, cfg Config, log *slog.Logger) http.Handler {
 	})
 }
 
+func corsOrigins(raw []string) []string {
+	out := make([]string, 0, len(raw))
+	for _, origin := range raw {
+		origin = strings.TrimSpace(origin)
+		if origin == "" {
+			continue
+		}
+		out = append(out, origin)
+	}
+	return out
+}
+
 func corsRequestHeadersAllowed(raw string, allowed map[string]struct{}) bool {
 	raw = strings.TrimSpace(raw)
 	if raw == "" {
@@ -255,14 +279,18 @@ type loggingResponseWriter struct {
 	http.ResponseWriter
 	status int
 	bytes  int64
+	// wrote is set once the status line is committed (or the connection hijacked).
+	wrote bool
 }
 
 func (w *loggingResponseWriter) WriteHeader(code int) {
 	w.status = code
+	w.wrote = true
 	w.ResponseWriter.WriteHeader(code)
 }
 
 func (w *loggingResponseWriter) Write(p []byte) (int, error) {
+	w.wrote = true
 	n, err := w.ResponseWriter.Write(p)
 	w.bytes += int64(n)
 	return n, err
@@ -273,11 +301,13 @@ func (w *loggingResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
 	if !ok {
 		return nil, nil, errors.New("underlying ResponseWriter does not support hijacking")
 	}
+	w.wrote = true
 	return hj.Hijack()
 }
 
 func (w *loggingResponseWriter) Flush() {
 	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		w.wrote = true
 		f.Flush()
 	}
 }
@@ -290,6 +320,7 @@ func (w *loggingResponseWriter) Push(target string, opts *http.PushOptions) erro
 }
 
 func (w *loggingResponseWriter) ReadFrom(r io.Reader) (int64, error) {
+	w.wrote = true
 	if rf, ok := w.ResponseWriter.(io.ReaderFrom); ok {
 		n, err := rf.ReadFrom(r)
 		w.bytes += n
@@ -5,6 +5,7 @@ import (
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
@@ -44,7 +45,7 @@ func TestWithCORS_PreflightAllowed(t *testing.T) {
 
 	h := WithCORS(http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
 		t.Fatalf("next handler should not be called for preflight")
-	}), cfg, log)
+	}), cfg, "", log)
 
 	req := httptest.NewRequest(http.MethodOptions, "/auth/refresh", nil)
 	req.Header.Set("Origin", "https://app.example.com")
@@ -65,6 +66,33 @@ func TestWithCORS_PreflightAllowed(t *testing.T) {
 	}
 }
 
+func TestWithCORS_CSRFHeaderName(t *testing.T) {
+	cfg := Config{CORSAllowedOrigins: []string{"https://app.example.com"}}
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	h := WithCORS(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}), cfg, "X-Arc-CSRF", log)
+
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	if got := rr.Header().Get("Access-Control-Expose-Headers"); got != "X-Arc-CSRF" {
+		t.Fatalf("expose-headers mismatch: %q", got)
+	}
+
+	req = httptest.NewRequest(http.MethodOptions, "/auth/refresh", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	req.Header.Set("Access-Control-Request-Headers", "x-arc-csrf")
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNoContent || !strings.Contains(rr.Header().Get("Access-Control-Allow-Headers"), "X-Arc-CSRF") {
+		t.Fatalf("preflight: status %d, allow-headers %q", rr.Code, rr.Header().Get("Access-Control-Allow-Headers"))
+	}
+}
+
 func TestWithCORS_DisallowedOrigin(t *testing.T) {
 	cfg := Config{
 		CORSAllowedOrigins: []string{"https://app.example.com"},
@@ -75,7 +103,7 @@ func TestWithCORS_DisallowedOrigin(t *testing.T) {
 	h := WithCORS(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 		called = true
 		w.WriteHeader(http.StatusOK)
-	}), cfg, log)
+	}), cfg, "", log)
 
 	req := httptest.NewRequest(http.MethodGet, "/me", nil)
 	req.Header.Set("Origin", "https://evil.example.com")
@@ -99,7 +127,7 @@ func TestWithCORS_WildcardPortAllowed(t *testing.T) {
 
 	h := WithCORS(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 		w.WriteHeader(http.StatusNoContent)
-	}), cfg, log)
+	}), cfg, "", log)
 
 	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
 	req.Header.Set("Origin", "http://127.0.0.1:55123")
@@ -0,0 +1,61 @@
+package app
+
+import (
+	"io"
+
+	"arc/cmd/internal/realtime"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Option customizes how New or a Server assembles the App. Production passes none
+// and every dependency comes from the settings; tests use options to swap in their
+// own pool, stores or broker.
+type Option func(*options)
+
+type options struct {
+	pool        *pgxpool.Pool
+	msgStore    realtime.MessageStore
+	broker      realtime.Broker
+	brokerSet   bool
+	gatewayOpts []realtime.GatewayOption
+	accessLog   io.Writer
+	reporter    PanicReporter
+}
+
+// WithDBPool uses pool instead of connecting to ARC_DATABASE_URL. The App owns the
+// pool from then on and closes it on shutdown.
+func WithDBPool(pool *pgxpool.Pool) Option {
+	return func(o *options) { o.pool = pool }
+}
+
+// WithMessageStore replaces the store realtime messages are persisted to.
+func WithMessageStore(s realtime.MessageStore) Option {
+	return func(o *options) { o.msgStore = s }
+}
+
+// WithBroker uses b for cross-node fanout instead of the ARC_BROKER_* settings;
+// nil disables fanout.
+func WithBroker(b realtime.Broker) Option {
+	return func(o *options) {
+		o.broker = b
+		o.brokerSet = true
+	}
+}
+
+// WithGatewayOptions appends opts to the realtime gateway's options.
+func WithGatewayOptions(opts ...realtime.GatewayOption) Option {
+	return func(o *options) { o.gatewayOpts = append(o.gatewayOpts, opts...) }
+}
+
+// WithAccessLogWriter also writes the access log to w, in ARC_ACCESS_LOG_FORMAT,
+// alongside any ARC_ACCESS_LOG target.
+func WithAccessLogWriter(w io.Writer) Option {
+	return func(o *options) { o.accessLog = w }
+}
+
+// WithPanicReporter sends recovered handler panics to r instead of the
+// ARC_SENTRY_DSN reporter.
+func WithPanicReporter(r PanicReporter) Option {
+	return func(o *options) { o.reporter = r }
+}
@@ -186,6 +186,7 @@ func (h *prettyHandler) renderHTTPRequestSummary(fields *[]prettyField) []string
 		}
 	}
 	_, _ = popField(fields, "status_class")
+	_, _ = popField(fields, "component")
 
 	duration := "?"
 	if f, ok := popField(fields, "duration_ms"); ok {
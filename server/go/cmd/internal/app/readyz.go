@@ -0,0 +1,149 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"arc/cmd/internal/auth/session"
+	"arc/cmd/internal/realtime"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// readyCheckTimeout bounds each dependency probe.
+const readyCheckTimeout = 2 * time.Second
+
+// Readiness check statuses.
+const (
+	checkOK      = "ok"
+	checkFail    = "fail"
+	checkSkipped = "skipped"
+)
+
+type readyCheck struct {
+	Status     string `json:"status"`
+	Detail     string `json:"detail,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+type readyResponse struct {
+	Status string                `json:"status"`
+	Checks map[string]readyCheck `json:"checks"`
+}
+
+// readiness reports whether the server's dependencies are usable.
+type readiness struct {
+	log         Logger
+	cfg         Config
+	dbPool      *pgxpool.Pool
+	dbEnabled   bool
+	authEnabled bool
+	broker      realtime.Broker
+}
+
+// ServeHTTP runs every check concurrently and answers 200 with "ready" only when
+// none failed; skipped checks (dependency not configured) do not count. Details
+// are short and generic: errors are logged, not returned to the caller.
+func (rd readiness) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	checks := map[string]func(ctx context.Context) (string, string){
+		"database":   rd.checkDatabase,
+		"paseto_key": rd.checkPasetoKey,
+		"token_hmac": rd.checkTokenHMAC,
+		"broker":     rd.checkBroker,
+	}
+
+	resp := readyResponse{Status: "ready", Checks: make(map[string]readyCheck, len(checks))}
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for name, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(r.Context(), readyCheckTimeout)
+			defer cancel()
+
+			start := time.Now()
+			status, detail := check(ctx)
+			c := readyCheck{Status: status, Detail: detail, DurationMS: time.Since(start).Milliseconds()}
+
+			mu.Lock()
+			resp.Checks[name] = c
+			if status == checkFail {
+				resp.Status = "not_ready"
+			}
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	code := http.StatusOK
+	if resp.Status != "ready" {
+		code = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (rd readiness) checkDatabase(ctx context.Context) (string, string) {
+	if !rd.dbEnabled || rd.dbPool == nil {
+		if rd.cfg.ReadinessRequireDB {
+			return checkFail, "database not configured"
+		}
+		return checkSkipped, "database not configured"
+	}
+	if err := PingDB(ctx, rd.dbPool, readyCheckTimeout); err != nil {
+		rd.log.Info("readyz.db.not_ready", "err", err)
+		return checkFail, "ping failed"
+	}
+	return checkOK, ""
+}
+
+// checkPasetoKey verifies the access token signing key when auth is served.
+func (rd readiness) checkPasetoKey(context.Context) (string, string) {
+	if !rd.authEnabled {
+		return checkSkipped, "auth disabled"
+	}
+	key := os.Getenv("ARC_PASETO_V4_SECRET_KEY_HEX")
+	if key == "" {
+		return checkFail, "ARC_PASETO_V4_SECRET_KEY_HEX missing"
+	}
+	if _, err := session.NewPasetoV4PublicManager(session.Config{PasetoV4SecretKeyHex: key}); err != nil {
+		return checkFail, "ARC_PASETO_V4_SECRET_KEY_HEX invalid"
+	}
+	return checkOK, ""
+}
+
+// checkTokenHMAC re-applies the refresh token hashing policy.
+func (rd readiness) checkTokenHMAC(context.Context) (string, string) {
+	if !rd.cfg.RequireTokenHMAC {
+		return checkSkipped, "not required"
+	}
+	if err := ValidateSecurityConfig(rd.cfg); err != nil {
+		rd.log.Warn("readyz.token_hmac.not_ready", "err", err)
+		return checkFail, "policy not met"
+	}
+	return checkOK, ""
+}
+
+func (rd readiness) checkBroker(ctx context.Context) (string, string) {
+	if rd.broker == nil {
+		return checkSkipped, "not configured"
+	}
+	p, ok := rd.broker.(realtime.BrokerPinger)
+	if !ok {
+		return checkSkipped, "broker does not support ping"
+	}
+	if err := p.Ping(ctx); err != nil {
+		rd.log.Info("readyz.broker.not_ready", "err", err)
+		return checkFail, "ping failed"
+	}
+	return checkOK, ""
+}
@@ -0,0 +1,94 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	paseto "aidanwoods.dev/go-paseto"
+)
+
+type pingBroker struct{ err error }
+
+func (pingBroker) Publish(context.Context, string, []byte) error { return nil }
+func (pingBroker) Subscribe(ctx context.Context, _ string, _ func([]byte)) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+func (pingBroker) Close() error                 { return nil }
+func (b pingBroker) Ping(context.Context) error { return b.err }
+
+func TestReadiness(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	t.Setenv("ARC_PASETO_V4_SECRET_KEY_HEX", paseto.NewV4AsymmetricSecretKey().ExportHex())
+	t.Setenv("ARC_TOKEN_HMAC_KEY", "")
+
+	cases := []struct {
+		name     string
+		rd       readiness
+		wantCode int
+		want     map[string]string
+	}{
+		{
+			name:     "nothing configured",
+			rd:       readiness{log: log},
+			wantCode: http.StatusOK,
+			want:     map[string]string{"database": checkSkipped, "paseto_key": checkSkipped, "token_hmac": checkSkipped, "broker": checkSkipped},
+		},
+		{
+			name:     "db required",
+			rd:       readiness{log: log, cfg: Config{ReadinessRequireDB: true}},
+			wantCode: http.StatusServiceUnavailable,
+			want:     map[string]string{"database": checkFail},
+		},
+		{
+			name:     "auth key and broker",
+			rd:       readiness{log: log, authEnabled: true, broker: pingBroker{}},
+			wantCode: http.StatusOK,
+			want:     map[string]string{"paseto_key": checkOK, "broker": checkOK},
+		},
+		{
+			name:     "broker down",
+			rd:       readiness{log: log, broker: pingBroker{err: errors.New("refused")}},
+			wantCode: http.StatusServiceUnavailable,
+			want:     map[string]string{"broker": checkFail},
+		},
+		{
+			name:     "hmac policy",
+			rd:       readiness{log: log, cfg: Config{RequireTokenHMAC: true}},
+			wantCode: http.StatusServiceUnavailable,
+			want:     map[string]string{"token_hmac": checkFail},
+		},
+	}
+	for _, tc := range cases {
+		rr := httptest.NewRecorder()
+		tc.rd.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		if rr.Code != tc.wantCode {
+			t.Fatalf("%s: status = %d, want %d (%s)", tc.name, rr.Code, tc.wantCode, rr.Body.String())
+		}
+		var resp readyResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%s: decode: %v", tc.name, err)
+		}
+		if len(resp.Checks) != 4 {
+			t.Fatalf("%s: checks = %v", tc.name, resp.Checks)
+		}
+		for name, status := range tc.want {
+			if got := resp.Checks[name].Status; got != status {
+				t.Fatalf("%s: %s = %q, want %q", tc.name, name, got, status)
+			}
+		}
+	}
+
+	t.Setenv("ARC_PASETO_V4_SECRET_KEY_HEX", "not-hex")
+	rr := httptest.NewRecorder()
+	readiness{log: log, authEnabled: true}.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("invalid paseto key: status = %d", rr.Code)
+	}
+}
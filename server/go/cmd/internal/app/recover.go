@@ -0,0 +1,176 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"arc/cmd/internal/metrics"
+)
+
+// RequestIDHeader carries the request ID: taken from a trusted proxy when present,
+// generated otherwise, and echoed on error responses.
+const RequestIDHeader = "X-Request-ID"
+
+var httpPanics = metrics.NewCounterVec("arc_http_panics_total",
+	"Handler panics recovered and answered with 500, by route pattern.", "route")
+
+// PanicReport describes a recovered handler panic.
+type PanicReport struct {
+	RequestID   string
+	Fingerprint string
+	Method      string
+	Route       string
+	Path        string
+	Value       any
+	Stack       []byte
+	Frames      []runtime.Frame
+	Time        time.Time
+}
+
+// PanicReporter forwards recovered panics to an error tracker. Implementations
+// must not block the request for long; see sentryReporter.
+type PanicReporter interface {
+	ReportPanic(ctx context.Context, p PanicReport)
+}
+
+// WithRecover turns a handler panic into a JSON 500 carrying the request ID, logs
+// the stack with a fingerprint that is stable across requests and deploys (it
+// hashes the panic type and calling functions, not line numbers), counts it in
+// arc_http_panics_total and passes it to reporter when set.
+//
+// http.ErrAbortHandler is re-raised so net/http aborts the response as intended.
+// If the response was already started, the client gets a truncated response.
+func WithRecover(next http.Handler, log *slog.Logger, reporter PanicReporter) http.Handler {
+	if log == nil {
+		log = slog.Default()
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lrw := &loggingResponseWriter{ResponseWriter: w, status: http.StatusOK}
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+
+			frames := panicFrames(4)
+			p := PanicReport{
+				RequestID:   requestID(r),
+				Fingerprint: panicFingerprint(v, frames),
+				Method:      r.Method,
+				Route:       r.Pattern,
+				Path:        r.URL.Path,
+				Value:       v,
+				Stack:       debug.Stack(),
+				Frames:      frames,
+				Time:        time.Now(),
+			}
+			route := p.Route
+			if route == "" {
+				route = "unmatched"
+			}
+			httpPanics.With(route).Inc()
+			log.Error("http.panic",
+				"request_id", p.RequestID,
+				"fingerprint", p.Fingerprint,
+				"panic", fmt.Sprint(v),
+				"method", p.Method,
+				"path", p.Path,
+				"stack", string(p.Stack),
+				"result", "server_error",
+			)
+			if reporter != nil {
+				reporter.ReportPanic(context.WithoutCancel(r.Context()), p)
+			}
+
+			if lrw.wrote {
+				return
+			}
+			h := w.Header()
+			h.Set(RequestIDHeader, p.RequestID)
+			h.Set("Content-Type", "application/json; charset=utf-8")
+			h.Set("Cache-Control", "no-store")
+			w.WriteHeader(http.StatusInternalServerError)
+			_ = json.NewEncoder(w).Encode(map[string]any{"error": map[string]string{
+				"code":       "internal_error",
+				"message":    "internal server error",
+				"request_id": p.RequestID,
+			}})
+		}()
+		next.ServeHTTP(lrw, r)
+	})
+}
+
+// requestID returns a well-formed X-Request-ID from the request or a new one.
+func requestID(r *http.Request) string {
+	if id := strings.TrimSpace(r.Header.Get(RequestIDHeader)); validRequestID(id) {
+		return id
+	}
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// panicFrames returns the stack of the panicking goroutine from the frame that
+// panicked, skipping the runtime's panic machinery and the deferred recover.
+func panicFrames(skip int) []runtime.Frame {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(skip, pcs)
+	it := runtime.CallersFrames(pcs[:n])
+
+	var frames []runtime.Frame
+	for {
+		f, more := it.Next()
+		frames = append(frames, f)
+		if f.Function == "runtime.gopanic" || strings.HasPrefix(f.Function, "runtime.panic") ||
+			f.Function == "runtime.sigpanic" {
+			frames = frames[:0]
+		}
+		if !more {
+			break
+		}
+	}
+	return frames
+}
+
+// panicFingerprint hashes the panic value's type and the first frames' functions
+// and file names. Messages and line numbers are left out so the same bug groups
+// together across inputs and deploys.
+func panicFingerprint(v any, frames []runtime.Frame) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%T", v)
+	for i, f := range frames {
+		if i == 8 {
+			break
+		}
+		fmt.Fprintf(h, "\n%s %s", f.Function, filepath.Base(f.File))
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
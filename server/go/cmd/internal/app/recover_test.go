@@ -0,0 +1,102 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type recordingReporter struct{ reports []PanicReport }
+
+func (r *recordingReporter) ReportPanic(_ context.Context, p PanicReport) {
+	r.reports = append(r.reports, p)
+}
+
+func panicOn(msg string) {
+	panic(errors.New(msg))
+}
+
+func TestWithRecover(t *testing.T) {
+	t.Parallel()
+
+	var logs bytes.Buffer
+	log := slog.New(slog.NewJSONHandler(&logs, nil))
+	rep := &recordingReporter{}
+	h := WithRecover(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		panicOn("boom " + r.URL.Query().Get("n"))
+	}), log, rep)
+
+	serve := func(target, reqID string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+		if reqID != "" {
+			r.Header.Set(RequestIDHeader, reqID)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	w := serve("/x?n=1", "req-123")
+	if w.Code != http.StatusInternalServerError || w.Header().Get(RequestIDHeader) != "req-123" {
+		t.Fatalf("status %d, request id %q", w.Code, w.Header().Get(RequestIDHeader))
+	}
+	var body struct {
+		Error struct {
+			Code      string `json:"code"`
+			RequestID string `json:"request_id"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Error.Code != "internal_error" || body.Error.RequestID != "req-123" {
+		t.Fatalf("body = %s (%v)", w.Body.String(), err)
+	}
+	if !strings.Contains(logs.String(), `"msg":"http.panic"`) || !strings.Contains(logs.String(), "panicOn") {
+		t.Fatalf("panic log missing stack:\n%s", logs.String())
+	}
+
+	// A malformed incoming ID is replaced; the fingerprint ignores the message.
+	w = serve("/x?n=2", "bad id\n")
+	if got := w.Header().Get(RequestIDHeader); len(got) != 32 {
+		t.Fatalf("generated request id = %q", got)
+	}
+	if len(rep.reports) != 2 {
+		t.Fatalf("reports = %d, want 2", len(rep.reports))
+	}
+	if a, b := rep.reports[0], rep.reports[1]; a.Fingerprint == "" || a.Fingerprint != b.Fingerprint {
+		t.Fatalf("fingerprints differ: %q vs %q", a.Fingerprint, b.Fingerprint)
+	}
+	if f := rep.reports[0].Frames; len(f) == 0 || !strings.HasSuffix(f[0].Function, ".panicOn") {
+		t.Fatalf("first frame should be the panicking function, got %+v", f)
+	}
+}
+
+func TestWithRecover_StartedResponseAndAbort(t *testing.T) {
+	t.Parallel()
+
+	log := slog.New(slog.NewJSONHandler(&bytes.Buffer{}, nil))
+	h := WithRecover(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte("partial"))
+		panic("late")
+	}), log, nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusAccepted || w.Body.String() != "partial" {
+		t.Fatalf("started response was rewritten: %d %q", w.Code, w.Body.String())
+	}
+
+	abort := WithRecover(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic(http.ErrAbortHandler)
+	}), log, nil)
+	defer func() {
+		if v := recover(); v != http.ErrAbortHandler {
+			t.Fatalf("recovered %v, want http.ErrAbortHandler", v)
+		}
+	}()
+	abort.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
@@ -2,15 +2,32 @@ package app
 
 import (
 	"context"
+	"errors"
+	"log/slog"
+	"os"
 	"os/signal"
 	"syscall"
+	"time"
+
+	"arc/cmd/internal/config"
+	"arc/cmd/internal/migrations"
+	"arc/cmd/internal/retention"
 )
 
 // Run is the CLI entrypoint used by cmd/arc.
 // It returns an error instead of calling os.Exit to keep defers effective and lint clean.
 func Run() error {
-	cfg := LoadConfig()
-	log := NewLogger(cfg.LogLevel, cfg.LogFormat)
+	cfg, loaded, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	log := NewLoggerFromConfig(cfg)
+
+	if err := cfg.Validate(); err != nil {
+		log.Error("config.invalid", "err", err)
+		return err
+	}
+	logConfig(log, loaded)
 
 	// Enforce security policy before wiring dependencies.
 	if err := ValidateSecurityConfig(cfg); err != nil {
@@ -26,5 +43,107 @@ func Run() error {
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 
+	go reloadConfig(ctx, log, loaded, EnvDuration("ARC_CONFIG_WATCH_INTERVAL", 0))
+
 	return a.Run(ctx)
 }
+
+// RunCommand runs an operator subcommand (e.g. "migrate up", "retention restore ...")
+// instead of the server.
+func RunCommand(args []string) error {
+	cfg, _, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	log := NewLoggerFromConfig(cfg)
+
+	if len(args) == 0 || (args[0] != "retention" && args[0] != "migrate") {
+		return errors.New("unknown command; available: migrate, retention")
+	}
+	if cfg.DatabaseURL == "" {
+		return errors.New(args[0] + " requires ARC_DATABASE_URL")
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	pool, err := NewDBPool(ctx, cfg, nil)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	if args[0] == "migrate" {
+		return migrations.RunCommand(ctx, log, pool, args[1:], os.Stdout)
+	}
+	return retention.RunCommand(ctx, log, pool, retention.LoadConfigFromEnv(), args[1:], os.Stdout)
+}
+
+// loadConfig applies the config file named by ARC_CONFIG_FILE (if any) beneath the
+// environment, then loads Config. Packages that read their own ARC_* variables later
+// see the file's values too.
+func loadConfig() (Config, *config.Loaded, error) {
+	loaded, err := config.Load(os.Getenv(config.EnvFile))
+	if err != nil {
+		return Config{}, nil, err
+	}
+	return LoadConfig(), loaded, nil
+}
+
+// logConfig logs the effective ARC_* settings with secrets redacted.
+func logConfig(log Logger, loaded *config.Loaded) {
+	settings := loaded.Settings()
+	attrs := make([]slog.Attr, 0, len(settings))
+	var fromFile []string
+	for _, s := range settings {
+		attrs = append(attrs, slog.String(s.Key, s.Value))
+		if s.Source == config.SourceFile {
+			fromFile = append(fromFile, s.Key)
+		}
+	}
+	log.Info("config.loaded",
+		"file", loaded.Path,
+		"from_file", fromFile,
+		slog.Attr{Key: "settings", Value: slog.GroupValue(attrs...)},
+	)
+}
+
+// reloadConfig re-reads the config file on SIGHUP and, when interval is positive,
+// whenever the file changes. Runtime-tunable settings are applied by their
+// subscribers; other changes are logged and take effect on the next restart.
+func reloadConfig(ctx context.Context, log Logger, loaded *config.Loaded, interval time.Duration) {
+	stop := config.Subscribe(func(_ []string) {
+		SetLogLevel(EnvString("ARC_LOG_LEVEL", "info"))
+		if err := SetComponentLogLevels(EnvString("ARC_LOG_LEVELS", "")); err != nil {
+			log.Error("config.reload.log_levels.invalid", "err", err, "result", "client_error")
+		}
+	}, "ARC_LOG_LEVEL", "ARC_LOG_LEVELS")
+	defer stop()
+
+	report := func(changed []string, err error) {
+		if err != nil {
+			log.Error("config.reload.fail", "file", loaded.Path, "err", err, "result", "server_error")
+			return
+		}
+		log.Info("config.reload", "file", loaded.Path, "changed", changed, "result", "success")
+		if pending := config.RestartRequired(changed); len(pending) > 0 {
+			log.Warn("config.reload.restart_required", "keys", pending)
+		}
+	}
+
+	if interval > 0 {
+		go loaded.Watch(ctx, interval, report)
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			report(loaded.Reload())
+		}
+	}
+}
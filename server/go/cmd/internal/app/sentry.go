@@ -0,0 +1,172 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"arc/cmd/internal/redact"
+)
+
+// sentryMaxInFlight bounds concurrent report uploads; a panic storm drops reports
+// instead of piling up goroutines.
+const sentryMaxInFlight = 4
+
+// sentryReporter is the PanicReporter for ARC_SENTRY_DSN. It posts events to the
+// project's store endpoint in the background, grouped by the panic fingerprint.
+type sentryReporter struct {
+	endpoint    string
+	auth        string
+	environment string
+	client      *http.Client
+	log         Logger
+	inFlight    chan struct{}
+}
+
+// parseSentryDSN splits "https://<key>[:<secret>]@<host>[/<prefix>]/<project>" into
+// the store endpoint and the X-Sentry-Auth header value.
+func parseSentryDSN(dsn string) (endpoint, auth string, err error) {
+	u, err := url.Parse(strings.TrimSpace(dsn))
+	if err != nil {
+		return "", "", err
+	}
+	if (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" || u.User == nil || u.User.Username() == "" {
+		return "", "", errors.New("want https://<key>@<host>/<project>")
+	}
+	prefix, project := path.Split(strings.TrimRight(u.Path, "/"))
+	if project == "" {
+		return "", "", errors.New("missing project id")
+	}
+	endpoint = fmt.Sprintf("%s://%s%sapi/%s/store/", u.Scheme, u.Host, prefix, project)
+	auth = "Sentry sentry_version=7, sentry_client=arc/1, sentry_key=" + u.User.Username()
+	if secret, ok := u.User.Password(); ok && secret != "" {
+		auth += ", sentry_secret=" + secret
+	}
+	return endpoint, auth, nil
+}
+
+func newSentryReporter(dsn, environment string, log Logger) (*sentryReporter, error) {
+	endpoint, auth, err := parseSentryDSN(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("ARC_SENTRY_DSN: %w", err)
+	}
+	return &sentryReporter{
+		endpoint:    endpoint,
+		auth:        auth,
+		environment: environment,
+		client:      &http.Client{Timeout: 5 * time.Second},
+		log:         log,
+		inFlight:    make(chan struct{}, sentryMaxInFlight),
+	}, nil
+}
+
+type sentryFrame struct {
+	Function string `json:"function"`
+	AbsPath  string `json:"abs_path,omitempty"`
+	Filename string `json:"filename,omitempty"`
+	Lineno   int    `json:"lineno,omitempty"`
+	InApp    bool   `json:"in_app"`
+}
+
+type sentryException struct {
+	Type       string `json:"type"`
+	Value      string `json:"value"`
+	Stacktrace struct {
+		Frames []sentryFrame `json:"frames"`
+	} `json:"stacktrace"`
+}
+
+type sentryEvent struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   string            `json:"timestamp"`
+	Level       string            `json:"level"`
+	Platform    string            `json:"platform"`
+	Logger      string            `json:"logger"`
+	Environment string            `json:"environment,omitempty"`
+	Fingerprint []string          `json:"fingerprint"`
+	Tags        map[string]string `json:"tags"`
+	Request     map[string]string `json:"request"`
+	Exception   struct {
+		Values []sentryException `json:"values"`
+	} `json:"exception"`
+}
+
+func (s *sentryReporter) event(p PanicReport) sentryEvent {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+
+	exc := sentryException{Type: fmt.Sprintf("%T", p.Value), Value: redact.Default().String(fmt.Sprint(p.Value))}
+	// Sentry lists frames oldest first.
+	for i := len(p.Frames) - 1; i >= 0; i-- {
+		f := p.Frames[i]
+		exc.Stacktrace.Frames = append(exc.Stacktrace.Frames, sentryFrame{
+			Function: f.Function,
+			AbsPath:  f.File,
+			Filename: path.Base(f.File),
+			Lineno:   f.Line,
+			InApp:    strings.HasPrefix(f.Function, "arc/"),
+		})
+	}
+
+	ev := sentryEvent{
+		EventID:     hex.EncodeToString(id[:]),
+		Timestamp:   p.Time.UTC().Format(time.RFC3339),
+		Level:       "error",
+		Platform:    "go",
+		Logger:      "arc.http",
+		Environment: s.environment,
+		Fingerprint: []string{p.Fingerprint},
+		Tags:        map[string]string{"request_id": p.RequestID, "route": p.Route},
+		Request:     map[string]string{"method": p.Method, "url": p.Path},
+	}
+	ev.Exception.Values = []sentryException{exc}
+	return ev
+}
+
+// ReportPanic implements PanicReporter.
+func (s *sentryReporter) ReportPanic(ctx context.Context, p PanicReport) {
+	select {
+	case s.inFlight <- struct{}{}:
+	default:
+		s.log.Warn("sentry.report.dropped", "fingerprint", p.Fingerprint, "reason", "busy", "result", "server_error")
+		return
+	}
+	body, err := json.Marshal(s.event(p))
+	if err != nil {
+		<-s.inFlight
+		return
+	}
+	go func() {
+		defer func() { <-s.inFlight }()
+		if err := s.send(ctx, body); err != nil {
+			s.log.Warn("sentry.report.fail", "fingerprint", p.Fingerprint, "err", err, "result", "server_error")
+		}
+	}()
+}
+
+func (s *sentryReporter) send(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", s.auth)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("sentry responded %s", resp.Status)
+	}
+	return nil
+}
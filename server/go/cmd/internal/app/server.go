@@ -0,0 +1,145 @@
+package app
+
+import (
+	"arc/cmd/internal/attachments"
+	authapi "arc/cmd/internal/auth/api"
+	"arc/cmd/internal/auth/session"
+	"arc/cmd/internal/bots"
+	"arc/cmd/internal/featureflags"
+	"arc/cmd/internal/geoip"
+	"arc/cmd/internal/ipaccess"
+	"arc/cmd/internal/jobs"
+	"arc/cmd/internal/moderation"
+	"arc/cmd/internal/push"
+	"arc/cmd/internal/realtime"
+	"arc/cmd/internal/retention"
+	"arc/cmd/internal/scim"
+	"arc/cmd/internal/tenant"
+)
+
+// Components holds the settings of the subsystems an App wires next to Config.
+// Session and Auth are only used with a database.
+type Components struct {
+	Session      session.Config
+	Auth         authapi.Config
+	GeoIP        geoip.Config
+	Access       ipaccess.Config
+	FeatureFlags featureflags.Config
+	Filters      realtime.FilterConfig
+	Broker       realtime.BrokerConfig
+	SCIM         scim.Config
+	Attachments  attachments.Config
+	Push         push.Config
+	Bots         bots.Config
+	Moderation   moderation.Config
+	Retention    retention.Config
+	Jobs         jobs.Config
+	Tenants      tenant.Config
+}
+
+// DefaultComponents returns the settings used when no ARC_* variable is set, without
+// reading the environment. Optional features (SCIM, attachments, push, bots,
+// retention, tenants, cross-node fanout) are off; Session still needs a signing key
+// before it can be used with a database.
+func DefaultComponents() Components {
+	return Components{
+		Session:      session.DefaultConfig(),
+		Auth:         authapi.DefaultConfig(),
+		GeoIP:        geoip.DefaultConfig(),
+		FeatureFlags: featureflags.Config{CacheTTL: featureflags.DefaultCacheTTL},
+		Broker:       realtime.DefaultBrokerConfig(),
+		Bots:         bots.DefaultConfig(),
+		Jobs:         jobs.Config{Enabled: true},
+	}
+}
+
+// LoadComponentsFromEnv loads every component from its ARC_* variables, as New
+// does. It fails without ARC_PASETO_V4_SECRET_KEY_HEX.
+func LoadComponentsFromEnv() (Components, error) {
+	return loadComponentsFromEnv(true)
+}
+
+// loadComponentsFromEnv is LoadComponentsFromEnv; Session and Auth keep their
+// defaults unless withAuth is set.
+func loadComponentsFromEnv(withAuth bool) (Components, error) {
+	c := DefaultComponents()
+	var err error
+	if withAuth {
+		if c.Session, err = session.LoadConfigFromEnv(); err != nil {
+			return Components{}, err
+		}
+		c.Auth = authapi.LoadConfigFromEnv()
+	}
+	c.GeoIP = geoip.LoadConfigFromEnv()
+	if c.Access, err = ipaccess.LoadConfigFromEnv(); err != nil {
+		return Components{}, err
+	}
+	if c.FeatureFlags, err = featureflags.LoadConfigFromEnv(); err != nil {
+		return Components{}, err
+	}
+	if c.Filters, err = realtime.LoadFilterConfigFromEnv(); err != nil {
+		return Components{}, err
+	}
+	c.Broker = realtime.LoadBrokerConfigFromEnv()
+	c.SCIM = scim.LoadConfigFromEnv()
+	c.Attachments = attachments.LoadConfigFromEnv()
+	c.Push = push.LoadConfigFromEnv()
+	c.Bots = bots.LoadConfigFromEnv()
+	c.Moderation = moderation.LoadConfigFromEnv()
+	c.Retention = retention.LoadConfigFromEnv()
+	c.Jobs = jobs.LoadConfigFromEnv()
+	c.Tenants = tenant.LoadConfigFromEnv()
+	return c, nil
+}
+
+// Server builds an App from explicit settings, for embedding Arc in another
+// program or test: unlike New, it reads no component settings from the
+// environment. The realtime gateway's ARC_WS_* tunables are still read (override
+// them with WithGatewayOptions), as are each tenant's overrides.
+//
+//	a, err := app.NewServer(cfg).
+//		WithComponents(comps).
+//		WithOptions(app.WithDBPool(pool)).
+//		Build()
+type Server struct {
+	cfg   Config
+	log   Logger
+	comps Components
+	opts  []Option
+}
+
+// NewServer starts a Server for cfg with DefaultComponents.
+func NewServer(cfg Config) *Server {
+	return &Server{cfg: cfg, comps: DefaultComponents()}
+}
+
+// WithLogger logs through log instead of a logger built from the Server's Config.
+func (s *Server) WithLogger(log Logger) *Server {
+	s.log = log
+	return s
+}
+
+// WithComponents replaces the component settings.
+func (s *Server) WithComponents(c Components) *Server {
+	s.comps = c
+	return s
+}
+
+// WithOptions appends opts, which replace individual dependencies as they do for New.
+func (s *Server) WithOptions(opts ...Option) *Server {
+	s.opts = append(s.opts, opts...)
+	return s
+}
+
+// Build assembles the App.
+func (s *Server) Build() (*App, error) {
+	log := s.log
+	if log == nil {
+		log = newConfigLogger(s.cfg)
+	}
+	var o options
+	for _, opt := range s.opts {
+		opt(&o)
+	}
+	return build(s.cfg, log, s.comps, o)
+}
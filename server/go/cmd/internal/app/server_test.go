@@ -0,0 +1,71 @@
+package app
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"arc/cmd/internal/realtime"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestServer_Build(t *testing.T) {
+	// New would fail on both; a Server never reads them.
+	t.Setenv("ARC_FEATURE_FLAGS", "auth.unknown")
+	t.Setenv("ARC_BROKER", realtime.BrokerRedis)
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cfg := Config{HTTPAddr: "127.0.0.1:0", LogLevel: "info", LogFormat: "text", DBMaxConns: 1}
+	if _, err := New(cfg, log); err == nil {
+		t.Fatalf("New: expected the environment to be rejected")
+	}
+
+	a, err := NewServer(cfg).WithLogger(log).Build()
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if a.dbEnabled || a.auth != nil || a.broker != nil {
+		t.Fatalf("expected the in-memory assembly without auth or broker, got db=%v auth=%v broker=%v", a.dbEnabled, a.auth != nil, a.broker != nil)
+	}
+	rr := httptest.NewRecorder()
+	a.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("healthz: status %d", rr.Code)
+	}
+
+	comps := DefaultComponents()
+	comps.Broker.Kind = realtime.BrokerRedis
+	if _, err := NewServer(cfg).WithLogger(log).WithComponents(comps).Build(); err == nil {
+		t.Fatalf("Build: expected the redis broker without a URL to be rejected")
+	}
+	a, err = NewServer(cfg).WithLogger(log).WithComponents(comps).WithOptions(WithBroker(pingBroker{})).Build()
+	if err != nil {
+		t.Fatalf("Build with broker option: %v", err)
+	}
+	if a.broker == nil || a.brokerChannel != realtime.DefaultBrokerConfig().Channel {
+		t.Fatalf("broker = %v on %q", a.broker, a.brokerChannel)
+	}
+}
+
+func TestServer_BuildClosesPoolOnError(t *testing.T) {
+	pool, err := pgxpool.New(context.Background(), "postgres://arc@127.0.0.1:1/arc?connect_timeout=1")
+	if err != nil {
+		t.Fatalf("pool: %v", err)
+	}
+	defer pool.Close()
+
+	// DefaultComponents has no session signing key, so the auth handler fails.
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cfg := Config{HTTPAddr: "127.0.0.1:0", LogLevel: "info", LogFormat: "text", DBMaxConns: 1}
+	if _, err := NewServer(cfg).WithLogger(log).WithOptions(WithDBPool(pool)).Build(); err == nil {
+		t.Fatalf("Build: expected an error without a signing key")
+	}
+	if err := pool.Ping(context.Background()); err == nil || !strings.Contains(err.Error(), "closed pool") {
+		t.Fatalf("expected the pool to be closed, ping = %v", err)
+	}
+}
@@ -0,0 +1,262 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	authapi "arc/cmd/internal/auth/api"
+	"arc/cmd/internal/auth/session"
+	"arc/cmd/internal/dbtrace"
+	"arc/cmd/internal/featureflags"
+	"arc/cmd/internal/geoip"
+	"arc/cmd/internal/ipaccess"
+	"arc/cmd/internal/maintenance"
+	"arc/cmd/internal/realtime"
+	"arc/cmd/internal/tenant"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// tenantNodePaths are served by the node itself, without resolving a tenant.
+var tenantNodePaths = []string{"/healthz", "/readyz", "/metrics"}
+
+// tenantStack is the auth handler and realtime gateway of one tenant, built over
+// the identity, session and realtime stores of the tenant's schema.
+type tenantStack struct {
+	tenant tenant.Tenant
+	auth   *authapi.Handler
+	hub    *realtime.Hub
+	ws     *realtime.WSGateway
+	mux    *http.ServeMux
+}
+
+// tenants is the multi-tenant routing state of an App; nil when ARC_TENANTS_FILE
+// is unset.
+type tenants struct {
+	resolver *tenant.Resolver
+	// stacks holds one entry per tenant outside the arc schema; tenants on arc
+	// are served by the App's own handlers.
+	stacks map[string]*tenantStack
+}
+
+// newTenants loads the tenant registry and builds a stack for every tenant whose
+// schema is not arc.
+func newTenants(cfg tenant.Config, log Logger, pool, replica *pgxpool.Pool, dbEnabled bool, maint *maintenance.Mode) (*tenants, error) {
+	if !cfg.Enabled() {
+		return nil, nil
+	}
+	if !dbEnabled {
+		return nil, errors.New("ARC_TENANTS_FILE requires ARC_DATABASE_URL")
+	}
+	reg, err := tenant.LoadRegistry(cfg.File)
+	if err != nil {
+		return nil, err
+	}
+	res, err := tenant.NewResolver(reg, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	ts := &tenants{resolver: res, stacks: map[string]*tenantStack{}}
+	for _, t := range reg.Tenants() {
+		if t.Schema == tenant.DefaultSchema {
+			continue
+		}
+		if err := checkTenantSchema(context.Background(), pool, tenant.DefaultSchema, t.Schema); err != nil {
+			return nil, fmt.Errorf("tenant %q: %w", t.ID, err)
+		}
+		st, err := newTenantStack(t, log, pool, replica, maint)
+		if err != nil {
+			return nil, fmt.Errorf("tenant %q: %w", t.ID, err)
+		}
+		ts.stacks[t.ID] = st
+	}
+	log.Info("tenant.registry.loaded", "file", cfg.File, "tenants", len(reg.Tenants()), "resolve", cfg.Resolve, "result", "success")
+	return ts, nil
+}
+
+// maxMissingObjects bounds how many missing columns and triggers a schema check reports.
+const maxMissingObjects = 5
+
+// checkTenantSchema verifies that schema has every table, column and trigger of
+// base (the arc schema outside tests). Migrations only manage arc, so tenant schemas are provisioned
+// outside the server; this refuses to serve a tenant whose schema was never
+// provisioned or lags behind a newer migration. Triggers matter as much as
+// columns: without trg_sessions_notify_revoked, say, revoking a tenant session
+// would leave its realtime connections open.
+func checkTenantSchema(ctx context.Context, pool *pgxpool.Pool, base, schema string) error {
+	ctx = dbtrace.WithOp(ctx, "app.check_tenant_schema")
+	// Partitions are skipped: their columns and triggers come with the parent table.
+	rows, err := pool.Query(ctx, `
+		WITH cols AS (
+			SELECT n.nspname, c.relname, a.attname, a.attnum
+			  FROM pg_attribute a
+			  JOIN pg_class c ON c.oid = a.attrelid
+			  JOIN pg_namespace n ON n.oid = c.relnamespace
+			 WHERE n.nspname IN ($1, $2)
+			   AND c.relkind IN ('r', 'p')
+			   AND NOT c.relispartition
+			   AND a.attnum > 0
+			   AND NOT a.attisdropped
+		), trgs AS (
+			SELECT n.nspname, c.relname, t.tgname
+			  FROM pg_trigger t
+			  JOIN pg_class c ON c.oid = t.tgrelid
+			  JOIN pg_namespace n ON n.oid = c.relnamespace
+			 WHERE n.nspname IN ($1, $2)
+			   AND NOT c.relispartition
+			   AND NOT t.tgisinternal
+		)
+		SELECT missing FROM (
+			SELECT 0 AS kind, a.relname, a.attnum AS pos, a.relname || '.' || a.attname AS missing
+			  FROM cols a
+			 WHERE a.nspname = $1
+			   AND NOT EXISTS (
+			       SELECT 1 FROM cols t
+			        WHERE t.nspname = $2 AND t.relname = a.relname AND t.attname = a.attname)
+			UNION ALL
+			SELECT 1, a.relname, 0, 'trigger ' || a.tgname || ' on ' || a.relname
+			  FROM trgs a
+			 WHERE a.nspname = $1
+			   AND NOT EXISTS (
+			       SELECT 1 FROM trgs t
+			        WHERE t.nspname = $2 AND t.relname = a.relname AND t.tgname = a.tgname)
+		) m
+		 ORDER BY kind, relname, pos, missing
+	`, base, schema)
+	if err != nil {
+		return fmt.Errorf("check schema %s: %w", schema, err)
+	}
+	defer rows.Close()
+
+	var missing []string
+	n := 0
+	for rows.Next() {
+		var obj string
+		if err := rows.Scan(&obj); err != nil {
+			return fmt.Errorf("check schema %s: %w", schema, err)
+		}
+		if n < maxMissingObjects {
+			missing = append(missing, obj)
+		}
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("check schema %s: %w", schema, err)
+	}
+	if n == 0 {
+		return nil
+	}
+	if n > len(missing) {
+		missing = append(missing, fmt.Sprintf("and %d more", n-len(missing)))
+	}
+	return fmt.Errorf("schema %s is not provisioned like %s (missing %s)",
+		schema, base, strings.Join(missing, ", "))
+}
+
+// newTenantStack builds t's handlers. Settings are read through t.Lookup, so the
+// handlers see the tenant's overrides, including on config reloads. replica (nil
+// without one) serves the stack's replica-safe reads.
+func newTenantStack(t tenant.Tenant, log Logger, pool, replica *pgxpool.Pool, maint *maintenance.Mode) (*tenantStack, error) {
+	lookup := t.Lookup()
+	authLog := ComponentLogger(log, "authapi").With("tenant", t.ID)
+	realtimeLog := ComponentLogger(log, "realtime").With("tenant", t.ID)
+
+	msgStore, err := realtime.NewPostgresStore(pool, realtime.WithSchema(t.Schema), realtime.WithReadPool(replica))
+	if err != nil {
+		return nil, err
+	}
+	members, err := realtime.NewPostgresMembershipStore(pool, realtime.WithMembershipSchema(t.Schema))
+	if err != nil {
+		return nil, err
+	}
+
+	sessCfg, err := session.LoadConfigFrom(lookup)
+	if err != nil {
+		return nil, err
+	}
+	geoResolver, err := geoip.NewResolver(geoip.LoadConfigFrom(lookup))
+	if err != nil {
+		return nil, err
+	}
+	flagsCfg, err := featureflags.LoadConfigFrom(lookup)
+	if err != nil {
+		return nil, err
+	}
+	flags, err := newFeatureFlags(flagsCfg, pool, true, t.Schema)
+	if err != nil {
+		return nil, err
+	}
+	authCfg := authapi.LoadConfigFrom(lookup)
+	accessCfg, err := ipaccess.LoadConfigFrom(lookup)
+	if err != nil {
+		return nil, err
+	}
+	accessPolicy, err := newAccessPolicy(log, accessCfg, pool, t.Schema, geoResolver)
+	if err != nil {
+		return nil, err
+	}
+	auth, err := authapi.NewHandler(authLog, pool, authCfg, sessCfg, true,
+		authapi.WithSchema(t.Schema),
+		authapi.WithGeoResolver(geoResolver),
+		authapi.WithAuthoredMessages(msgStore),
+		authapi.WithMessageScrubber(msgStore),
+		authapi.WithMaintenance(maint),
+		authapi.WithFeatureFlags(flags),
+		authapi.WithReadPool(replica),
+		authapi.WithAccessPolicy(accessPolicy),
+		authapi.WithConfigLookup(lookup),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	wsOpts := []realtime.GatewayOption{
+		realtime.WithConfigLookup(lookup),
+		realtime.WithMaintenance(maint),
+		realtime.WithFeatureFlags(flags),
+		realtime.WithAccessPolicy(accessPolicy, authCfg.Proxy()),
+	}
+	filterCfg, err := realtime.LoadFilterConfigFrom(lookup)
+	if err != nil {
+		return nil, err
+	}
+	if filters := filterCfg.Filters(); len(filters) > 0 {
+		wsOpts = append(wsOpts, realtime.WithMessageFilters(filters...))
+	}
+	hub := realtime.NewHub(realtimeLog)
+	ws := realtime.NewWSGateway(realtimeLog, hub, msgStore, auth.SessionService(), members, wsOpts...)
+
+	mux := http.NewServeMux()
+	auth.Register(mux)
+	registerRealtimeHTTP(mux, ws)
+	return &tenantStack{tenant: t, auth: auth, hub: hub, ws: ws, mux: mux}, nil
+}
+
+// all returns the tenant stacks by tenant ID (none when multi-tenancy is off).
+func (ts *tenants) all() map[string]*tenantStack {
+	if ts == nil {
+		return nil
+	}
+	return ts.stacks
+}
+
+// handler resolves the tenant of each request and serves it from the tenant's
+// stack, or from base for tenants on the arc schema and node paths.
+func (ts *tenants) handler(base http.Handler) http.Handler {
+	if ts == nil {
+		return base
+	}
+	return tenant.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if t, ok := tenant.FromContext(r.Context()); ok {
+			if st := ts.stacks[t.ID]; st != nil {
+				st.mux.ServeHTTP(w, r)
+				return
+			}
+		}
+		base.ServeHTTP(w, r)
+	}), ts.resolver, tenantNodePaths...)
+}
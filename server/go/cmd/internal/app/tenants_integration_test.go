@@ -0,0 +1,68 @@
+package app
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"arc/cmd/internal/migrations/migrationstest"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Integration tests are enabled when ARC_DATABASE_URL is set.
+
+func TestCheckTenantSchema(t *testing.T) {
+	pool := openTestPool(t)
+	ctx := context.Background()
+
+	base := migrationstest.NewSchema(t, pool)
+	schema := migrationstest.NewSchema(t, pool)
+	if err := checkTenantSchema(ctx, pool, base, schema); err != nil {
+		t.Fatalf("fully migrated schema: %v", err)
+	}
+
+	// A schema provisioned with the tables alone misses the revocation trigger.
+	if _, err := pool.Exec(ctx, `DROP TRIGGER trg_sessions_notify_revoked ON `+schema+`.sessions`); err != nil {
+		t.Fatalf("drop trigger: %v", err)
+	}
+	err := checkTenantSchema(ctx, pool, base, schema)
+	if err == nil || !strings.Contains(err.Error(), "trigger trg_sessions_notify_revoked on sessions") {
+		t.Fatalf("missing trigger: err = %v", err)
+	}
+
+	if _, err := pool.Exec(ctx, `ALTER TABLE `+schema+`.sessions DROP COLUMN revocation_reason`); err != nil {
+		t.Fatalf("drop column: %v", err)
+	}
+	err = checkTenantSchema(ctx, pool, base, schema)
+	if err == nil || !strings.Contains(err.Error(), "missing sessions.revocation_reason, trigger trg_sessions_notify_revoked on sessions") {
+		t.Fatalf("missing column: err = %v", err)
+	}
+}
+
+func openTestPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+
+	dbURL := strings.TrimSpace(os.Getenv("ARC_DATABASE_URL"))
+	if dbURL == "" {
+		t.Skip("ARC_DATABASE_URL is not set; skipping Postgres integration test")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	pool, err := pgxpool.New(ctx, dbURL)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		if os.Getenv("CI") == "" {
+			t.Skipf("Postgres unreachable (ARC_DATABASE_URL set): %v", err)
+		}
+		t.Fatalf("ping: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	return pool
+}
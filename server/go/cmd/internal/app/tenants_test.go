@@ -0,0 +1,99 @@
+package app
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"arc/cmd/internal/tenant"
+)
+
+func TestTenantsHandler(t *testing.T) {
+	t.Parallel()
+
+	reg, err := tenant.NewRegistry([]tenant.Tenant{
+		{ID: "main", Schema: tenant.DefaultSchema, Hosts: []string{"main.example"}},
+		{ID: "acme", Schema: "tenant_acme", Hosts: []string{"acme.example"}},
+	})
+	if err != nil {
+		t.Fatalf("NewRegistry: %v", err)
+	}
+	res, err := tenant.NewResolver(reg, tenant.Config{Resolve: tenant.ResolveHost})
+	if err != nil {
+		t.Fatalf("NewResolver: %v", err)
+	}
+
+	marker := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, _ *http.Request) { _, _ = w.Write([]byte(name)) }
+	}
+	acmeMux := http.NewServeMux()
+	acmeMux.Handle("/", marker("acme"))
+	acme, _ := reg.Get("acme")
+	ts := &tenants{resolver: res, stacks: map[string]*tenantStack{"acme": {tenant: acme, mux: acmeMux}}}
+	h := ts.handler(marker("base"))
+
+	for _, tc := range []struct {
+		host, path string
+		code       int
+		body       string
+	}{
+		{"acme.example", "/me", http.StatusOK, "acme"},
+		{"main.example", "/me", http.StatusOK, "base"},
+		{"acme.example", "/healthz", http.StatusOK, "base"},
+		{"other.example", "/readyz", http.StatusOK, "base"},
+		{"other.example", "/me", http.StatusNotFound, ""},
+	} {
+		r := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		r.Host = tc.host
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != tc.code || (tc.body != "" && w.Body.String() != tc.body) {
+			t.Fatalf("%s%s: %d %q", tc.host, tc.path, w.Code, w.Body.String())
+		}
+	}
+
+	var off *tenants
+	if off.handler(marker("base")) == nil || off.all() != nil {
+		t.Fatal("nil tenants must pass through")
+	}
+}
+
+func TestTenantsHandler_KeepsRoutePattern(t *testing.T) {
+	t.Parallel()
+
+	reg, err := tenant.NewRegistry([]tenant.Tenant{
+		{ID: "acme", Schema: "tenant_acme", Hosts: []string{"acme.example"}},
+	})
+	if err != nil {
+		t.Fatalf("NewRegistry: %v", err)
+	}
+	res, err := tenant.NewResolver(reg, tenant.Config{Resolve: tenant.ResolveHost})
+	if err != nil {
+		t.Fatalf("NewResolver: %v", err)
+	}
+	acmeMux := http.NewServeMux()
+	acmeMux.HandleFunc("/test-tenant-route/{id}", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+	acmeMux.HandleFunc("/test-tenant-panic", func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	})
+	acme, _ := reg.Get("acme")
+	ts := &tenants{resolver: res, stacks: map[string]*tenantStack{"acme": {tenant: acme, mux: acmeMux}}}
+	h := WithMetrics(WithRecover(ts.handler(http.NotFoundHandler()), slog.New(slog.NewTextHandler(io.Discard, nil)), nil))
+
+	for _, path := range []string{"/test-tenant-route/a", "/test-tenant-panic"} {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		r.Host = "acme.example"
+		h.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	if n := httpRequests.With("GET", "/test-tenant-route/{id}", "202").Value(); n != 1 {
+		t.Fatalf("tenant route counter = %d, want 1", n)
+	}
+	if n := httpPanics.With("/test-tenant-panic").Value(); n != 1 {
+		t.Fatalf("tenant panic counter = %d, want 1", n)
+	}
+}
@@ -0,0 +1,167 @@
+package app
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// certCheckInterval bounds how often the certificate files are stat'ed during
+// handshakes. Renewals (certbot, cert-manager, a cron copy) show up within it.
+const certCheckInterval = 10 * time.Second
+
+// TLSEnabled reports whether the main listener terminates TLS itself.
+func (c Config) TLSEnabled() bool {
+	return c.TLSCertFile != "" || len(c.ACMEDomains) > 0
+}
+
+// certReloader serves a certificate pair from disk and re-reads it when either
+// file changes, so renewed certificates are picked up without a restart.
+type certReloader struct {
+	certFile string
+	keyFile  string
+	log      Logger
+	now      func() time.Time
+
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	certStamp fileStamp
+	keyStamp  fileStamp
+	checkedAt time.Time
+}
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+func statStamp(path string) (fileStamp, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}, err
+	}
+	return fileStamp{modTime: fi.ModTime(), size: fi.Size()}, nil
+}
+
+// newCertReloader loads the pair once; a broken pair at startup is an error.
+func newCertReloader(certFile, keyFile string, log Logger) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile, log: log, now: time.Now}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	r.checkedAt = r.now()
+	return r, nil
+}
+
+func (r *certReloader) load() error {
+	certStamp, err := statStamp(r.certFile)
+	if err != nil {
+		return fmt.Errorf("tls cert: %w", err)
+	}
+	keyStamp, err := statStamp(r.keyFile)
+	if err != nil {
+		return fmt.Errorf("tls key: %w", err)
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("tls keypair: %w", err)
+	}
+	r.cert = &cert
+	r.certStamp = certStamp
+	r.keyStamp = keyStamp
+	return nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate. When the files changed
+// but no longer form a valid pair (e.g. mid-copy), the previous certificate keeps
+// being served and the next check retries.
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	if now.Sub(r.checkedAt) < certCheckInterval {
+		return r.cert, nil
+	}
+	r.checkedAt = now
+
+	certStamp, certErr := statStamp(r.certFile)
+	keyStamp, keyErr := statStamp(r.keyFile)
+	if certErr == nil && keyErr == nil && certStamp == r.certStamp && keyStamp == r.keyStamp {
+		return r.cert, nil
+	}
+
+	if err := r.load(); err != nil {
+		r.log.Error("tls.cert.reload.fail", "cert_file", r.certFile, "err", err, "result", "server_error")
+		return r.cert, nil
+	}
+	r.log.Info("tls.cert.reload", "cert_file", r.certFile, "result", "success")
+	return r.cert, nil
+}
+
+// newTLSConfig builds the listener's tls.Config from cfg. For ACME it also returns
+// the autocert manager, whose HTTPHandler answers HTTP-01 challenges.
+func newTLSConfig(cfg Config, log Logger) (*tls.Config, *autocert.Manager, error) {
+	switch {
+	case cfg.TLSCertFile != "":
+		r, err := newCertReloader(cfg.TLSCertFile, cfg.TLSKeyFile, log)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: r.GetCertificate,
+		}, nil, nil
+
+	case len(cfg.ACMEDomains) > 0:
+		m := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(cfg.ACMEDomains...),
+			Cache:      autocert.DirCache(cfg.ACMECacheDir),
+			Email:      cfg.ACMEEmail,
+		}
+		if cfg.ACMEDirectoryURL != "" {
+			m.Client = &acme.Client{DirectoryURL: cfg.ACMEDirectoryURL}
+		}
+		tc := m.TLSConfig()
+		tc.MinVersion = tls.VersionTLS12
+		return tc, m, nil
+
+	default:
+		return nil, nil, errors.New("tls is not configured")
+	}
+}
+
+// runACMEHTTPServer answers HTTP-01 challenges on addr and redirects every other
+// request to HTTPS until ctx is done. Failures are logged; TLS-ALPN-01 on the main
+// listener still works without it.
+func runACMEHTTPServer(ctx context.Context, log Logger, addr string, m *autocert.Manager) {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           m.HTTPHandler(nil),
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       10 * time.Second,
+		WriteTimeout:      10 * time.Second,
+		IdleTimeout:       30 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	log.Info("tls.acme.http.start", "addr", addr, "result", "success")
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Error("tls.acme.http.fail", "addr", addr, "err", err, "result", "server_error")
+	}
+}
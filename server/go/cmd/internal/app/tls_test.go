@@ -0,0 +1,104 @@
+package app
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log/slog"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeSelfSigned(t *testing.T, certFile, keyFile, cn string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{cn},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCertReloader(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	writeSelfSigned(t, certFile, keyFile, "one.example.com")
+
+	r, err := newCertReloader(certFile, keyFile, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := time.Now()
+	r.now = func() time.Time { return clock }
+
+	commonName := func() string {
+		t.Helper()
+		c, err := r.GetCertificate(nil)
+		if err != nil || c == nil {
+			t.Fatalf("GetCertificate: %v", err)
+		}
+		return c.Leaf.Subject.CommonName
+	}
+	if got := commonName(); got != "one.example.com" {
+		t.Fatalf("initial cert = %q", got)
+	}
+
+	// Renewed on disk: served once the check interval has passed.
+	writeSelfSigned(t, certFile, keyFile, "two.example.com")
+	future := time.Now().Add(time.Minute)
+	_ = os.Chtimes(certFile, future, future)
+	if got := commonName(); got != "one.example.com" {
+		t.Fatalf("reloaded before interval: %q", got)
+	}
+	clock = clock.Add(certCheckInterval)
+	if got := commonName(); got != "two.example.com" {
+		t.Fatalf("cert after renewal = %q", got)
+	}
+
+	// A half-written pair keeps the previous certificate.
+	if err := os.WriteFile(keyFile, []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	clock = clock.Add(certCheckInterval)
+	if got := commonName(); got != "two.example.com" {
+		t.Fatalf("cert after broken write = %q", got)
+	}
+}
+
+func TestNewCertReloader_MissingFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	if _, err := newCertReloader(filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"), slog.Default()); err == nil {
+		t.Fatal("expected error for missing files")
+	}
+}
@@ -0,0 +1,55 @@
+package arcctl
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// errNeedsDatabase is returned by the HTTP backend for commands without an admin
+// endpoint.
+var errNeedsDatabase = errors.New("not available over the admin API; use --database-url")
+
+// errNotFound reports an unknown user or invite.
+var errNotFound = errors.New("not found")
+
+// backend is what the commands need from either the database or the admin API.
+type backend interface {
+	CreateInvite(ctx context.Context, in inviteInput) (inviteCreated, error)
+	RevokeInvite(ctx context.Context, inviteID string) error
+	ListUsers(ctx context.Context, q userQuery) ([]userRow, error)
+	LockUser(ctx context.Context, userID, reason string) error
+	LogoutAll(ctx context.Context, userID string) error
+	PruneSessions(ctx context.Context, before time.Time) (int64, error)
+	PruneAudit(ctx context.Context, before time.Time) (int64, error)
+	Close()
+}
+
+type inviteInput struct {
+	TTL          time.Duration
+	MaxUses      int
+	Note         string
+	Conversation string
+	Role         string
+	Domains      []string
+}
+
+type inviteCreated struct {
+	ID        string    `json:"invite_id"`
+	Token     string    `json:"invite_token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type userQuery struct {
+	After      string
+	Limit      int
+	LockedOnly bool
+}
+
+type userRow struct {
+	ID        string     `json:"id"`
+	Username  string     `json:"username,omitempty"`
+	Email     string     `json:"email,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	LockedAt  *time.Time `json:"locked_at,omitempty"`
+}
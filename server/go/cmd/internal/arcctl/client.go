@@ -0,0 +1,129 @@
+package arcctl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// httpBackend runs commands through the server's admin HTTP API.
+type httpBackend struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+func newHTTPBackend(baseURL, token string) (*httpBackend, error) {
+	u, err := url.Parse(strings.TrimRight(strings.TrimSpace(baseURL), "/"))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid --api-url %q", baseURL)
+	}
+	if strings.TrimSpace(token) == "" {
+		return nil, fmt.Errorf("--token (or ARCCTL_TOKEN) is required with --api-url")
+	}
+	return &httpBackend{baseURL: u.String(), token: strings.TrimSpace(token), client: &http.Client{Timeout: 30 * time.Second}}, nil
+}
+
+func (b *httpBackend) Close() {}
+
+func (b *httpBackend) CreateInvite(ctx context.Context, in inviteInput) (inviteCreated, error) {
+	body := map[string]any{"max_uses": in.MaxUses}
+	if in.TTL > 0 {
+		body["expires_in_seconds"] = int64(in.TTL / time.Second)
+	}
+	if in.Note != "" {
+		body["note"] = in.Note
+	}
+	if in.Conversation != "" {
+		body["conversation_id"] = in.Conversation
+	}
+	if in.Role != "" {
+		body["role"] = in.Role
+	}
+	if len(in.Domains) > 0 {
+		body["allowed_email_domains"] = in.Domains
+	}
+	var out inviteCreated
+	err := b.do(ctx, "/v1/auth/invites/create", body, &out)
+	return out, err
+}
+
+func (b *httpBackend) LockUser(ctx context.Context, userID, reason string) error {
+	var body any
+	if reason != "" {
+		body = map[string]string{"reason": reason}
+	}
+	return b.do(ctx, "/v1/admin/users/"+url.PathEscape(userID)+"/lock", body, nil)
+}
+
+func (b *httpBackend) LogoutAll(ctx context.Context, userID string) error {
+	return b.do(ctx, "/v1/admin/users/"+url.PathEscape(userID)+"/logout_all", nil, nil)
+}
+
+func (b *httpBackend) RevokeInvite(context.Context, string) error { return errNeedsDatabase }
+
+func (b *httpBackend) ListUsers(context.Context, userQuery) ([]userRow, error) {
+	return nil, errNeedsDatabase
+}
+
+func (b *httpBackend) PruneSessions(context.Context, time.Time) (int64, error) {
+	return 0, errNeedsDatabase
+}
+
+func (b *httpBackend) PruneAudit(context.Context, time.Time) (int64, error) {
+	return 0, errNeedsDatabase
+}
+
+// do POSTs body (if any) as JSON to path and decodes a 2xx response into out.
+func (b *httpBackend) do(ctx context.Context, path string, body, out any) error {
+	var r io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(raw)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.baseURL+path, r)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+b.token)
+	req.Header.Set("User-Agent", "arcctl/1")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode == http.StatusNotFound {
+		return errNotFound
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		var apiErr struct {
+			Error struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		_ = json.NewDecoder(io.LimitReader(res.Body, 64<<10)).Decode(&apiErr)
+		if apiErr.Error.Code != "" {
+			return fmt.Errorf("%s: %s (%s)", res.Status, apiErr.Error.Message, apiErr.Error.Code)
+		}
+		return fmt.Errorf("%s", res.Status)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(out)
+}
@@ -0,0 +1,70 @@
+package arcctl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTTPBackend(t *testing.T) {
+	t.Parallel()
+
+	var lockBody map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer admin-token" || r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/auth/invites/create":
+			var req map[string]any
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			if req["expires_in_seconds"] != float64(3600) || req["max_uses"] != float64(2) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_, _ = w.Write([]byte(`{"invite_id":"01INV","invite_token":"tok","expires_at":"2026-01-01T00:00:00Z"}`))
+		case "/v1/admin/users/01USER/lock":
+			_ = json.NewDecoder(r.Body).Decode(&lockBody)
+			w.WriteHeader(http.StatusNoContent)
+		case "/v1/admin/users/01SELF/lock":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":{"code":"invalid_request","message":"cannot lock own account"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	b, err := newHTTPBackend(srv.URL+"/", "admin-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	inv, err := b.CreateInvite(ctx, inviteInput{TTL: time.Hour, MaxUses: 2})
+	if err != nil || inv.ID != "01INV" || inv.Token != "tok" {
+		t.Fatalf("CreateInvite = %+v, %v", inv, err)
+	}
+	if err := b.LockUser(ctx, "01USER", "spam"); err != nil || lockBody["reason"] != "spam" {
+		t.Fatalf("LockUser: %v, body %v", err, lockBody)
+	}
+	if err := b.LockUser(ctx, "01SELF", ""); err == nil || !strings.Contains(err.Error(), "cannot lock own account") {
+		t.Fatalf("LockUser self: %v", err)
+	}
+	if err := b.LogoutAll(ctx, "01GONE"); !errors.Is(err, errNotFound) {
+		t.Fatalf("LogoutAll unknown: %v", err)
+	}
+	if _, err := b.PruneAudit(ctx, time.Now()); !errors.Is(err, errNeedsDatabase) {
+		t.Fatalf("PruneAudit over HTTP: %v", err)
+	}
+
+	if _, err := newHTTPBackend(srv.URL, ""); err == nil {
+		t.Fatal("expected an error without a token")
+	}
+}
@@ -0,0 +1,335 @@
+package arcctl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	paseto "aidanwoods.dev/go-paseto"
+	"github.com/spf13/cobra"
+)
+
+const pasetoKeyEnv = "ARC_PASETO_V4_SECRET_KEY_HEX"
+
+type rootOptions struct {
+	out         io.Writer
+	databaseURL string
+	apiURL      string
+	token       string
+	jsonOutput  bool
+	now         func() time.Time
+	open        func(ctx context.Context, o *rootOptions) (backend, error)
+}
+
+// NewCommand returns the arcctl root command. Output goes to out; errors are
+// returned from Execute.
+func NewCommand(out io.Writer) *cobra.Command {
+	return newCommand(out, openBackend)
+}
+
+func newCommand(out io.Writer, open func(context.Context, *rootOptions) (backend, error)) *cobra.Command {
+	o := &rootOptions{out: out, now: time.Now, open: open}
+
+	root := &cobra.Command{
+		Use:           "arcctl",
+		Short:         "Operator CLI for Arc",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	root.SetOut(out)
+	f := root.PersistentFlags()
+	f.StringVar(&o.databaseURL, "database-url", os.Getenv("ARC_DATABASE_URL"), "Postgres URL (default $ARC_DATABASE_URL)")
+	f.StringVar(&o.apiURL, "api-url", os.Getenv("ARCCTL_API_URL"), "server base URL; use the admin API instead of the database (default $ARCCTL_API_URL)")
+	f.StringVar(&o.token, "token", os.Getenv("ARCCTL_TOKEN"), "admin access token for --api-url (default $ARCCTL_TOKEN)")
+	f.BoolVar(&o.jsonOutput, "json", false, "print results as JSON")
+
+	root.AddCommand(
+		o.createInviteCommand(),
+		o.revokeInviteCommand(),
+		o.listUsersCommand(),
+		o.lockUserCommand(),
+		o.logoutAllCommand(),
+		o.rotatePasetoKeyCommand(),
+		o.pruneSessionsCommand(),
+		o.pruneAuditCommand(),
+	)
+	return root
+}
+
+// openBackend picks the admin API when --api-url is set and the database otherwise.
+func openBackend(ctx context.Context, o *rootOptions) (backend, error) {
+	if strings.TrimSpace(o.apiURL) != "" {
+		return newHTTPBackend(o.apiURL, o.token)
+	}
+	if strings.TrimSpace(o.databaseURL) == "" {
+		return nil, errors.New("set --database-url (or ARC_DATABASE_URL), or --api-url with --token")
+	}
+	return newDBBackend(ctx, o.databaseURL)
+}
+
+// withBackend opens the backend for one command and closes it afterwards.
+func (o *rootOptions) withBackend(cmd *cobra.Command, fn func(context.Context, backend) error) error {
+	ctx := cmd.Context()
+	b, err := o.open(ctx, o)
+	if err != nil {
+		return err
+	}
+	defer b.Close()
+	return fn(ctx, b)
+}
+
+func (o *rootOptions) printJSON(v any) error {
+	enc := json.NewEncoder(o.out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func (o *rootOptions) createInviteCommand() *cobra.Command {
+	var in inviteInput
+	cmd := &cobra.Command{
+		Use:   "create-invite",
+		Short: "Create an invite and print its token",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return o.withBackend(cmd, func(ctx context.Context, b backend) error {
+				inv, err := b.CreateInvite(ctx, in)
+				if err != nil {
+					return err
+				}
+				if o.jsonOutput {
+					return o.printJSON(inv)
+				}
+				_, _ = fmt.Fprintf(o.out, "invite_id:  %s\ntoken:      %s\nexpires_at: %s\n", inv.ID, inv.Token, inv.ExpiresAt.UTC().Format(time.RFC3339))
+				return nil
+			})
+		},
+	}
+	cmd.Flags().DurationVar(&in.TTL, "ttl", 7*24*time.Hour, "how long the invite stays valid")
+	cmd.Flags().IntVar(&in.MaxUses, "max-uses", 1, "number of accounts the invite can create")
+	cmd.Flags().StringVar(&in.Note, "note", "", "free-form note stored with the invite")
+	cmd.Flags().StringVar(&in.Conversation, "conversation", "", "conversation ID the new user joins")
+	cmd.Flags().StringVar(&in.Role, "role", "", "role granted in --conversation: member (default) or admin")
+	cmd.Flags().StringSliceVar(&in.Domains, "allowed-domain", nil, "email domain allowed to use the invite (repeatable)")
+	return cmd
+}
+
+func (o *rootOptions) revokeInviteCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "revoke-invite INVITE_ID",
+		Short: "Revoke an invite so it can no longer be used",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.withBackend(cmd, func(ctx context.Context, b backend) error {
+				if err := b.RevokeInvite(ctx, args[0]); err != nil {
+					return fmt.Errorf("invite %s: %w", args[0], err)
+				}
+				_, _ = fmt.Fprintf(o.out, "revoked invite %s\n", args[0])
+				return nil
+			})
+		},
+	}
+}
+
+func (o *rootOptions) listUsersCommand() *cobra.Command {
+	q := userQuery{Limit: 50}
+	cmd := &cobra.Command{
+		Use:   "list-users",
+		Short: "List users ordered by id",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if q.Limit < 1 || q.Limit > 1000 {
+				return errors.New("--limit must be between 1 and 1000")
+			}
+			return o.withBackend(cmd, func(ctx context.Context, b backend) error {
+				users, err := b.ListUsers(ctx, q)
+				if err != nil {
+					return err
+				}
+				if o.jsonOutput {
+					return o.printJSON(users)
+				}
+				tw := tabwriter.NewWriter(o.out, 0, 4, 2, ' ', 0)
+				_, _ = fmt.Fprintln(tw, "ID\tUSERNAME\tEMAIL\tCREATED\tLOCKED")
+				for _, u := range users {
+					locked := "-"
+					if u.LockedAt != nil {
+						locked = u.LockedAt.UTC().Format(time.RFC3339)
+					}
+					_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", u.ID, dash(u.Username), dash(u.Email), u.CreatedAt.UTC().Format(time.RFC3339), locked)
+				}
+				return tw.Flush()
+			})
+		},
+	}
+	cmd.Flags().IntVar(&q.Limit, "limit", q.Limit, "maximum number of users to print")
+	cmd.Flags().StringVar(&q.After, "after", "", "print users with an id after this one (pagination)")
+	cmd.Flags().BoolVar(&q.LockedOnly, "locked", false, "only locked users")
+	return cmd
+}
+
+func (o *rootOptions) lockUserCommand() *cobra.Command {
+	var reason string
+	cmd := &cobra.Command{
+		Use:   "lock-user USER_ID",
+		Short: "Lock an account and revoke all of its sessions",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.withBackend(cmd, func(ctx context.Context, b backend) error {
+				if err := b.LockUser(ctx, args[0], reason); err != nil {
+					return fmt.Errorf("user %s: %w", args[0], err)
+				}
+				_, _ = fmt.Fprintf(o.out, "locked user %s\n", args[0])
+				return nil
+			})
+		},
+	}
+	cmd.Flags().StringVar(&reason, "reason", "", "reason recorded with the lock (max 512 characters)")
+	return cmd
+}
+
+func (o *rootOptions) logoutAllCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "logout-all USER_ID",
+		Short: "Revoke every session of a user",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.withBackend(cmd, func(ctx context.Context, b backend) error {
+				if err := b.LogoutAll(ctx, args[0]); err != nil {
+					return fmt.Errorf("user %s: %w", args[0], err)
+				}
+				_, _ = fmt.Fprintf(o.out, "revoked all sessions of user %s\n", args[0])
+				return nil
+			})
+		},
+	}
+}
+
+func (o *rootOptions) rotatePasetoKeyCommand() *cobra.Command {
+	var envFile string
+	cmd := &cobra.Command{
+		Use:   "rotate-paseto-key",
+		Short: "Generate a new PASETO v4 signing key",
+		Long: "Generates a new " + pasetoKeyEnv + ". With --env-file the variable is replaced (or added) in that\n" +
+			"file and the secret is not printed. Restart the server to apply it: access tokens signed with the\n" +
+			"old key stop validating and clients renew them with their refresh tokens, which stay valid.",
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			key := paseto.NewV4AsymmetricSecretKey()
+			if envFile == "" {
+				_, _ = fmt.Fprintf(o.out, "%s=%s\n", pasetoKeyEnv, key.ExportHex())
+				return nil
+			}
+			if err := setEnvFileValue(envFile, pasetoKeyEnv, key.ExportHex()); err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(o.out, "wrote %s to %s (public key %s); restart the server to apply it\n", pasetoKeyEnv, envFile, key.Public().ExportHex())
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&envFile, "env-file", "", "env file to update instead of printing the key")
+	return cmd
+}
+
+func (o *rootOptions) pruneSessionsCommand() *cobra.Command {
+	var olderThan time.Duration
+	cmd := &cobra.Command{
+		Use:   "prune-sessions",
+		Short: "Delete sessions that expired or were revoked long ago",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if olderThan <= 0 {
+				return errors.New("--older-than must be positive")
+			}
+			return o.withBackend(cmd, func(ctx context.Context, b backend) error {
+				n, err := b.PruneSessions(ctx, o.now().Add(-olderThan))
+				if err != nil {
+					return err
+				}
+				_, _ = fmt.Fprintf(o.out, "deleted %d sessions\n", n)
+				return nil
+			})
+		},
+	}
+	cmd.Flags().DurationVar(&olderThan, "older-than", 30*24*time.Hour, "delete sessions expired or revoked longer ago than this")
+	return cmd
+}
+
+func (o *rootOptions) pruneAuditCommand() *cobra.Command {
+	var olderThan time.Duration
+	cmd := &cobra.Command{
+		Use:   "prune-audit",
+		Short: "Delete old audit log entries",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if olderThan <= 0 {
+				return errors.New("--older-than must be positive")
+			}
+			return o.withBackend(cmd, func(ctx context.Context, b backend) error {
+				n, err := b.PruneAudit(ctx, o.now().Add(-olderThan))
+				if err != nil {
+					return err
+				}
+				_, _ = fmt.Fprintf(o.out, "deleted %d audit log entries\n", n)
+				return nil
+			})
+		},
+	}
+	cmd.Flags().DurationVar(&olderThan, "older-than", 90*24*time.Hour, "delete entries older than this")
+	return cmd
+}
+
+// setEnvFileValue replaces key's line in an env file (or appends it), keeping
+// every other line, and writes the result atomically with 0600 permissions.
+func setEnvFileValue(path, key, value string) error {
+	raw, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	lines := strings.Split(strings.TrimRight(string(raw), "\n"), "\n")
+	if len(raw) == 0 {
+		lines = nil
+	}
+	replaced := false
+	for i, line := range lines {
+		trimmed := strings.TrimPrefix(strings.TrimSpace(line), "export ")
+		if strings.HasPrefix(trimmed, key+"=") {
+			lines[i] = key + "=" + value
+			replaced = true
+		}
+	}
+	if !replaced {
+		lines = append(lines, key+"="+value)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".arcctl-env-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if err := tmp.Chmod(0o600); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if _, err := tmp.WriteString(strings.Join(lines, "\n") + "\n"); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
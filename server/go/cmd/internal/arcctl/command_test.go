@@ -0,0 +1,136 @@
+package arcctl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeBackend struct {
+	invite   inviteInput
+	locked   map[string]string
+	pruned   time.Time
+	users    []userRow
+	closed   bool
+	notFound bool
+}
+
+func (f *fakeBackend) CreateInvite(_ context.Context, in inviteInput) (inviteCreated, error) {
+	f.invite = in
+	return inviteCreated{ID: "01INVITE", Token: "tok", ExpiresAt: time.Date(2026, 1, 8, 0, 0, 0, 0, time.UTC)}, nil
+}
+
+func (f *fakeBackend) RevokeInvite(context.Context, string) error {
+	if f.notFound {
+		return errNotFound
+	}
+	return nil
+}
+
+func (f *fakeBackend) ListUsers(_ context.Context, q userQuery) ([]userRow, error) {
+	return f.users[:min(q.Limit, len(f.users))], nil
+}
+
+func (f *fakeBackend) LockUser(_ context.Context, userID, reason string) error {
+	if f.locked == nil {
+		f.locked = map[string]string{}
+	}
+	f.locked[userID] = reason
+	return nil
+}
+
+func (f *fakeBackend) LogoutAll(context.Context, string) error { return nil }
+
+func (f *fakeBackend) PruneSessions(_ context.Context, before time.Time) (int64, error) {
+	f.pruned = before
+	return 3, nil
+}
+
+func (f *fakeBackend) PruneAudit(_ context.Context, before time.Time) (int64, error) {
+	f.pruned = before
+	return 7, nil
+}
+
+func (f *fakeBackend) Close() { f.closed = true }
+
+func runCommand(t *testing.T, f *fakeBackend, args ...string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	cmd := newCommand(&out, func(context.Context, *rootOptions) (backend, error) { return f, nil })
+	cmd.SetArgs(args)
+	cmd.SetErr(&out)
+	err := cmd.ExecuteContext(context.Background())
+	return out.String(), err
+}
+
+func TestCommands(t *testing.T) {
+	t.Parallel()
+
+	f := &fakeBackend{}
+	out, err := runCommand(t, f, "create-invite", "--ttl", "48h", "--max-uses", "5", "--note", "team",
+		"--conversation", "01CONV", "--role", "admin", "--allowed-domain", "example.com")
+	if err != nil || !strings.Contains(out, "token:      tok") {
+		t.Fatalf("create-invite: %v\n%s", err, out)
+	}
+	want := inviteInput{TTL: 48 * time.Hour, MaxUses: 5, Note: "team", Conversation: "01CONV", Role: "admin", Domains: []string{"example.com"}}
+	if !reflect.DeepEqual(f.invite, want) || !f.closed {
+		t.Fatalf("create-invite input %+v closed=%v", f.invite, f.closed)
+	}
+
+	if _, err := runCommand(t, f, "lock-user", "01USER", "--reason", "spam"); err != nil || f.locked["01USER"] != "spam" {
+		t.Fatalf("lock-user: %v %v", err, f.locked)
+	}
+
+	out, err = runCommand(t, f, "prune-audit", "--older-than", "24h")
+	if err != nil || !strings.Contains(out, "deleted 7 audit log entries") {
+		t.Fatalf("prune-audit: %v\n%s", err, out)
+	}
+	if d := time.Since(f.pruned); d < 24*time.Hour || d > 25*time.Hour {
+		t.Fatalf("prune-audit cutoff %s before now", d)
+	}
+
+	f.users = []userRow{{ID: "01A", Username: "ada"}, {ID: "01B"}}
+	out, err = runCommand(t, f, "list-users", "--limit", "1", "--json")
+	if err != nil || !strings.Contains(out, `"username": "ada"`) || strings.Contains(out, "01B") {
+		t.Fatalf("list-users: %v\n%s", err, out)
+	}
+
+	f.notFound = true
+	if _, err := runCommand(t, f, "revoke-invite", "01NOPE"); !errors.Is(err, errNotFound) {
+		t.Fatalf("revoke-invite unknown: %v", err)
+	}
+	if _, err := runCommand(t, f, "lock-user"); err == nil {
+		t.Fatal("lock-user without an id should fail")
+	}
+}
+
+func TestRotatePasetoKey_EnvFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("ARC_HTTP_ADDR=:8080\nARC_PASETO_V4_SECRET_KEY_HEX=old\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out, err := runCommand(t, &fakeBackend{}, "rotate-paseto-key", "--env-file", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw, _ := os.ReadFile(path)
+	lines := strings.Split(strings.TrimSpace(string(raw)), "\n")
+	if len(lines) != 2 || lines[0] != "ARC_HTTP_ADDR=:8080" || !strings.HasPrefix(lines[1], pasetoKeyEnv+"=") || lines[1] == pasetoKeyEnv+"=old" {
+		t.Fatalf("env file = %q", raw)
+	}
+	if secret := strings.TrimPrefix(lines[1], pasetoKeyEnv+"="); strings.Contains(out, secret) {
+		t.Fatal("secret key printed although written to the env file")
+	}
+	if fi, _ := os.Stat(path); fi.Mode().Perm() != 0o600 {
+		t.Fatalf("env file mode = %v", fi.Mode().Perm())
+	}
+}
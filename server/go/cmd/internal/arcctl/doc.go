@@ -0,0 +1,11 @@
+// Package arcctl implements the arcctl operator CLI.
+//
+// Commands run against one of two backends:
+//   - the database (--database-url, default ARC_DATABASE_URL): every command is
+//     available and writes go through the same stores the server uses;
+//   - the admin HTTP API (--api-url with --token, default ARCCTL_API_URL and
+//     ARCCTL_TOKEN): the token is an access token of a user listed in
+//     ARC_AUTH_ADMIN_USER_IDS, and only commands with an admin endpoint work.
+//
+// rotate-paseto-key needs neither; it generates a new signing key locally.
+package arcctl
@@ -0,0 +1,224 @@
+package arcctl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"arc/cmd/identity"
+	"arc/cmd/internal/auth/session"
+	"arc/cmd/internal/dbtrace"
+	"arc/cmd/internal/invite"
+	"arc/cmd/internal/redact"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// pruneBatchSize bounds each DELETE so pruning large tables never holds long locks.
+const pruneBatchSize = 5000
+
+// dbBackend runs commands directly against the database.
+type dbBackend struct {
+	pool     *pgxpool.Pool
+	identity *identity.PostgresStore
+	sessions *session.PostgresStore
+	now      func() time.Time
+}
+
+func newDBBackend(ctx context.Context, databaseURL string) (*dbBackend, error) {
+	pool, err := pgxpool.New(ctx, databaseURL)
+	if err != nil {
+		return nil, err
+	}
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, err
+	}
+	ids, err := identity.NewPostgresStore(pool)
+	if err != nil {
+		pool.Close()
+		return nil, err
+	}
+	sessions, err := session.NewPostgresStore(pool)
+	if err != nil {
+		pool.Close()
+		return nil, err
+	}
+	return &dbBackend{pool: pool, identity: ids, sessions: sessions, now: time.Now}, nil
+}
+
+func (b *dbBackend) Close() { b.pool.Close() }
+
+func (b *dbBackend) CreateInvite(ctx context.Context, in inviteInput) (inviteCreated, error) {
+	var note, conv, role *string
+	if in.Note != "" {
+		note = &in.Note
+	}
+	if in.Conversation != "" {
+		conv = &in.Conversation
+	}
+	if in.Role != "" {
+		role = &in.Role
+	}
+	inv, token, err := b.identity.Invites().CreateInvite(ctx, invite.CreateInput{
+		TTL:                 in.TTL,
+		MaxUses:             in.MaxUses,
+		Note:                note,
+		ConversationID:      conv,
+		ConversationRole:    role,
+		AllowedEmailDomains: in.Domains,
+		Now:                 b.now().UTC(),
+	})
+	if err != nil {
+		return inviteCreated{}, err
+	}
+	meta := map[string]any{"invite_id": inv.ID}
+	if inv.ConversationID != nil {
+		meta["conversation_id"] = *inv.ConversationID
+	}
+	b.audit(ctx, "admin.invite.created", meta)
+	return inviteCreated{ID: inv.ID, Token: token, ExpiresAt: inv.ExpiresAt}, nil
+}
+
+func (b *dbBackend) RevokeInvite(ctx context.Context, inviteID string) error {
+	ctx = dbtrace.WithOp(ctx, "arcctl.revoke_invite")
+	tag, err := b.pool.Exec(ctx, `
+		UPDATE arc.invites
+		SET revoked_at = COALESCE(revoked_at, $2)
+		WHERE id = $1
+	`, inviteID, b.now().UTC())
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errNotFound
+	}
+	b.audit(ctx, "admin.invite.revoked", map[string]any{"invite_id": inviteID})
+	return nil
+}
+
+func (b *dbBackend) ListUsers(ctx context.Context, q userQuery) ([]userRow, error) {
+	ctx = dbtrace.WithOp(ctx, "arcctl.list_users")
+	rows, err := b.pool.Query(ctx, `
+		SELECT id, COALESCE(username, ''), COALESCE(email, ''), created_at, locked_at
+		FROM arc.users
+		WHERE id > $1
+		  AND ($2::boolean IS FALSE OR locked_at IS NOT NULL)
+		ORDER BY id
+		LIMIT $3
+	`, q.After, q.LockedOnly, q.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []userRow
+	for rows.Next() {
+		var u userRow
+		if err := rows.Scan(&u.ID, &u.Username, &u.Email, &u.CreatedAt, &u.LockedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, u)
+	}
+	return out, rows.Err()
+}
+
+func (b *dbBackend) LockUser(ctx context.Context, userID, reason string) error {
+	now := b.now().UTC()
+	var reasonPtr *string
+	if reason != "" {
+		reasonPtr = &reason
+	}
+	if err := b.identity.SetUserLocked(ctx, userID, true, reasonPtr, now); err != nil {
+		if errors.Is(err, identity.ErrNotFound) {
+			return errNotFound
+		}
+		return err
+	}
+	// Lock first, then revoke, as the admin API does.
+	if err := b.sessions.RevokeAll(ctx, now, userID, "admin"); err != nil {
+		return err
+	}
+	meta := map[string]any{"target_user_id": userID}
+	if reason != "" {
+		meta["reason"] = reason
+	}
+	b.audit(ctx, "admin.user.lock", meta)
+	return nil
+}
+
+func (b *dbBackend) LogoutAll(ctx context.Context, userID string) error {
+	if _, err := b.identity.GetUserByID(ctx, userID); err != nil {
+		if errors.Is(err, identity.ErrNotFound) {
+			return errNotFound
+		}
+		return err
+	}
+	if err := b.sessions.RevokeAll(ctx, b.now().UTC(), userID, "admin"); err != nil {
+		return err
+	}
+	b.audit(ctx, "admin.user.logout_all", map[string]any{"target_user_id": userID})
+	return nil
+}
+
+// PruneSessions deletes sessions that expired or were revoked before before.
+// Sessions still referenced as a message sender are kept.
+func (b *dbBackend) PruneSessions(ctx context.Context, before time.Time) (int64, error) {
+	return b.pruneBatches(ctx, `
+		WITH doomed AS (
+			SELECT s.id
+			FROM arc.sessions s
+			WHERE (s.expires_at < $1 OR s.revoked_at < $1)
+			  AND NOT EXISTS (SELECT 1 FROM arc.messages m WHERE m.sender_session = s.id)
+			LIMIT $2
+		)
+		DELETE FROM arc.sessions s
+		USING doomed
+		WHERE s.id = doomed.id
+	`, before)
+}
+
+// PruneAudit deletes audit log entries created before before.
+func (b *dbBackend) PruneAudit(ctx context.Context, before time.Time) (int64, error) {
+	return b.pruneBatches(ctx, `
+		DELETE FROM arc.audit_log
+		WHERE id IN (
+			SELECT id FROM arc.audit_log
+			WHERE created_at < $1
+			LIMIT $2
+		)
+	`, before)
+}
+
+func (b *dbBackend) pruneBatches(ctx context.Context, sql string, before time.Time) (int64, error) {
+	ctx = dbtrace.WithOp(ctx, "arcctl.prune_batches")
+	var total int64
+	for {
+		tag, err := b.pool.Exec(ctx, sql, before, pruneBatchSize)
+		if err != nil {
+			return total, err
+		}
+		total += tag.RowsAffected()
+		if tag.RowsAffected() < pruneBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// audit records an operator action. The acting user is unknown (arcctl talks to
+// the database directly), so meta carries the source instead. Failures are ignored:
+// the action itself already happened.
+func (b *dbBackend) audit(ctx context.Context, action string, meta map[string]any) {
+	ctx = dbtrace.WithOp(ctx, "arcctl.audit")
+	meta["source"] = "arcctl"
+	raw, err := json.Marshal(redact.Meta(meta))
+	if err != nil {
+		return
+	}
+	_, _ = b.pool.Exec(ctx, `
+		INSERT INTO arc.audit_log (action, created_at, user_agent, meta)
+		VALUES ($1, now(), $2, $3::jsonb)
+	`, strings.TrimSpace(action), "arcctl", string(raw))
+}
@@ -0,0 +1,44 @@
+package attachments
+
+import (
+	"errors"
+	"time"
+
+	"arc/cmd/security/token"
+)
+
+// localUploadKeyLabel derives the local upload signing key from ARC_TOKEN_HMAC_KEY,
+// so upload signatures say nothing about refresh token hashes.
+const localUploadKeyLabel = "arc-attachment-upload-v1"
+
+// UploadTarget tells the client where and how to upload an attachment.
+type UploadTarget struct {
+	Method string
+	URL    string
+	// Headers must be sent verbatim with the upload.
+	Headers   map[string]string
+	ExpiresAt time.Time
+}
+
+// Backend issues upload targets for attachment objects.
+type Backend interface {
+	Name() string
+	// PresignUpload returns a target accepting exactly a.SizeBytes of a.MIMEType until expires.
+	PresignUpload(a Attachment, expires time.Time) (UploadTarget, error)
+}
+
+// NewBackend constructs the configured storage backend.
+func NewBackend(cfg Config, now func() time.Time) (Backend, error) {
+	switch cfg.Backend {
+	case BackendLocal:
+		key, err := token.HMACKeyFromEnv(32)
+		if err != nil {
+			return nil, errors.New("attachments: local backend requires ARC_TOKEN_HMAC_KEY")
+		}
+		return NewLocalBackend(cfg.LocalDir, cfg.PublicBaseURL, []byte(token.HashHMACSHA256Hex(localUploadKeyLabel, key)))
+	case BackendS3:
+		return NewS3Backend(cfg.S3, now)
+	default:
+		return nil, errors.New("attachments: unknown backend " + cfg.Backend)
+	}
+}
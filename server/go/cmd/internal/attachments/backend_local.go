@@ -0,0 +1,104 @@
+package attachments
+
+import (
+	"crypto/hmac"
+	"errors"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"arc/cmd/security/token"
+)
+
+var errUploadSizeMismatch = errors.New("attachments: upload size mismatch")
+
+// LocalBackend stores uploads on local disk. Clients PUT to a signed URL served by Handler.
+type LocalBackend struct {
+	dir     string
+	baseURL string
+	key     []byte
+}
+
+// NewLocalBackend constructs a LocalBackend writing into dir and signing URLs with key.
+func NewLocalBackend(dir, baseURL string, key []byte) (*LocalBackend, error) {
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		return nil, errors.New("attachments: missing local dir")
+	}
+	if len(key) == 0 {
+		return nil, errors.New("attachments: missing upload signing key")
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+	return &LocalBackend{dir: dir, baseURL: baseURL, key: key}, nil
+}
+
+// Name implements Backend.
+func (b *LocalBackend) Name() string { return BackendLocal }
+
+// PresignUpload implements Backend.
+func (b *LocalBackend) PresignUpload(a Attachment, expires time.Time) (UploadTarget, error) {
+	expRaw := strconv.FormatInt(expires.Unix(), 10)
+	q := url.Values{}
+	q.Set("exp", expRaw)
+	q.Set("sig", b.sign(a, expRaw))
+	return UploadTarget{
+		Method:    "PUT",
+		URL:       b.baseURL + uploadPath(a.ID) + "?" + q.Encode(),
+		Headers:   map[string]string{"Content-Type": a.MIMEType},
+		ExpiresAt: expires,
+	}, nil
+}
+
+// sign binds the signature to the attachment's id, size and type so a leaked URL
+// cannot be reused for a different payload.
+func (b *LocalBackend) sign(a Attachment, expRaw string) string {
+	return token.HashHMACSHA256Hex(a.ID+"|"+strconv.FormatInt(a.SizeBytes, 10)+"|"+a.MIMEType+"|"+expRaw, b.key)
+}
+
+// verify checks the upload signature and that it has not expired.
+func (b *LocalBackend) verify(a Attachment, expRaw, sig string, now time.Time) bool {
+	if sig == "" {
+		return false
+	}
+	exp, err := strconv.ParseInt(expRaw, 10, 64)
+	if err != nil || !now.Before(time.Unix(exp, 0)) {
+		return false
+	}
+	return hmac.Equal([]byte(b.sign(a, expRaw)), []byte(strings.ToLower(sig)))
+}
+
+// write stores exactly size bytes from r under key. The file only appears once complete.
+func (b *LocalBackend) write(key string, r io.Reader, size int64) (err error) {
+	f, err := os.CreateTemp(b.dir, ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = f.Close()
+			_ = os.Remove(f.Name())
+		}
+	}()
+
+	n, err := io.Copy(f, io.LimitReader(r, size+1))
+	if err != nil {
+		return err
+	}
+	if n != size {
+		return errUploadSizeMismatch
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filepath.Join(b.dir, filepath.Base(key)))
+}
+
+func uploadPath(id string) string {
+	return "/attachments/" + url.PathEscape(id) + "/content"
+}
@@ -0,0 +1,88 @@
+package attachments
+
+import (
+	"bytes"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLocalBackend_SignedURL_RoundTrip(t *testing.T) {
+	b, err := NewLocalBackend(t.TempDir(), "https://api.example.com", []byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatalf("new backend: %v", err)
+	}
+	now := time.Unix(1_700_000_000, 0).UTC()
+	exp := now.Add(15 * time.Minute)
+	a := Attachment{ID: "01HZXATTACH000000000000000", MIMEType: "image/png", SizeBytes: 10}
+
+	target, err := b.PresignUpload(a, exp)
+	if err != nil {
+		t.Fatalf("presign: %v", err)
+	}
+	if !strings.HasPrefix(target.URL, "https://api.example.com/attachments/"+a.ID+"/content?") {
+		t.Fatalf("unexpected url %q", target.URL)
+	}
+	u, err := url.Parse(target.URL)
+	if err != nil {
+		t.Fatalf("parse url: %v", err)
+	}
+	q := u.Query()
+
+	if !b.verify(a, q.Get("exp"), q.Get("sig"), now) {
+		t.Fatalf("expected signature to verify")
+	}
+	if b.verify(a, q.Get("exp"), q.Get("sig"), exp) {
+		t.Fatalf("expected signature to be expired at exp")
+	}
+	bigger := a
+	bigger.SizeBytes = 11
+	if b.verify(bigger, q.Get("exp"), q.Get("sig"), now) {
+		t.Fatalf("expected signature to be bound to the size")
+	}
+	other := a
+	other.MIMEType = "text/plain"
+	if b.verify(other, q.Get("exp"), q.Get("sig"), now) {
+		t.Fatalf("expected signature to be bound to the type")
+	}
+	later := strconv.FormatInt(now.Add(time.Hour).Unix(), 10)
+	if b.verify(a, later, q.Get("sig"), now) {
+		t.Fatalf("expected extended expiry to fail")
+	}
+}
+
+func TestLocalBackend_Write_ExactSize(t *testing.T) {
+	dir := t.TempDir()
+	b, err := NewLocalBackend(dir, "", []byte("k"))
+	if err != nil {
+		t.Fatalf("new backend: %v", err)
+	}
+	key := "attachments/u1/01HZXATTACH000000000000000"
+
+	if err := b.write(key, bytes.NewReader([]byte("hello")), 4); !errors.Is(err, errUploadSizeMismatch) {
+		t.Fatalf("expected size mismatch for long body, got %v", err)
+	}
+	if err := b.write(key, bytes.NewReader([]byte("hel")), 4); !errors.Is(err, errUploadSizeMismatch) {
+		t.Fatalf("expected size mismatch for short body, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "01HZXATTACH000000000000000")); !os.IsNotExist(err) {
+		t.Fatalf("expected no file after failed writes, got %v", err)
+	}
+
+	if err := b.write(key, bytes.NewReader([]byte("hell")), 4); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "01HZXATTACH000000000000000"))
+	if err != nil || string(got) != "hell" {
+		t.Fatalf("unexpected stored file %q, %v", got, err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Fatalf("expected temp files to be cleaned up, got %d entries", len(entries))
+	}
+}
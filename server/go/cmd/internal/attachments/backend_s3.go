@@ -0,0 +1,191 @@
+package attachments
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	s3Algorithm   = "AWS4-HMAC-SHA256"
+	s3Service     = "s3"
+	s3AmzDate     = "20060102T150405Z"
+	s3ScopeDate   = "20060102"
+	s3MaxPresign  = 7 * 24 * time.Hour
+	s3PayloadHash = "UNSIGNED-PAYLOAD"
+)
+
+// S3Backend issues SigV4 query-string pre-signed PUT URLs for an S3-compatible bucket.
+type S3Backend struct {
+	cfg      S3Config
+	endpoint *url.URL
+	now      func() time.Time
+}
+
+// NewS3Backend validates cfg and constructs an S3Backend.
+func NewS3Backend(cfg S3Config, now func() time.Time) (*S3Backend, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" || cfg.Region == "" {
+		return nil, errors.New("attachments: s3 backend requires endpoint, region and bucket")
+	}
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, errors.New("attachments: s3 backend requires access key credentials")
+	}
+	u, err := url.Parse(cfg.Endpoint)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return nil, errors.New("attachments: invalid s3 endpoint")
+	}
+	if now == nil {
+		now = time.Now
+	}
+	return &S3Backend{cfg: cfg, endpoint: u, now: now}, nil
+}
+
+// Name implements Backend.
+func (b *S3Backend) Name() string { return BackendS3 }
+
+// PresignUpload implements Backend. Content-Type and Content-Length are signed, so
+// the bucket rejects uploads of a different type or size.
+func (b *S3Backend) PresignUpload(a Attachment, expires time.Time) (UploadTarget, error) {
+	now := b.now().UTC()
+	ttl := expires.Sub(now).Truncate(time.Second)
+	if ttl <= 0 || ttl > s3MaxPresign {
+		return UploadTarget{}, errors.New("attachments: invalid presign ttl")
+	}
+	headers := map[string]string{
+		"Content-Type":   a.MIMEType,
+		"Content-Length": strconv.FormatInt(a.SizeBytes, 10),
+	}
+	u := b.presign("PUT", a.StorageKey, headers, now, ttl)
+	return UploadTarget{
+		Method:    "PUT",
+		URL:       u,
+		Headers:   headers,
+		ExpiresAt: now.Add(ttl),
+	}, nil
+}
+
+// PresignObject returns a pre-signed URL for method on key, valid for ttl from now.
+// Headers are signed and must be sent verbatim.
+func (b *S3Backend) PresignObject(method, key string, headers map[string]string, ttl time.Duration) (string, error) {
+	ttl = ttl.Truncate(time.Second)
+	if ttl <= 0 || ttl > s3MaxPresign {
+		return "", errors.New("attachments: invalid presign ttl")
+	}
+	if key == "" {
+		return "", errors.New("attachments: empty object key")
+	}
+	return b.presign(method, key, headers, b.now().UTC(), ttl), nil
+}
+
+// presign builds a SigV4 pre-signed URL for method on key, signing host plus headers.
+func (b *S3Backend) presign(method, key string, headers map[string]string, now time.Time, ttl time.Duration) string {
+	host := b.endpoint.Host
+	path := "/" + key
+	if b.cfg.PathStyle {
+		path = "/" + b.cfg.Bucket + path
+	} else {
+		host = b.cfg.Bucket + "." + host
+	}
+	path = strings.TrimRight(b.endpoint.Path, "/") + path
+
+	amzDate := now.Format(s3AmzDate)
+	scope := now.Format(s3ScopeDate) + "/" + b.cfg.Region + "/" + s3Service + "/aws4_request"
+
+	signed := map[string]string{"host": host}
+	for k, v := range headers {
+		signed[strings.ToLower(k)] = strings.TrimSpace(v)
+	}
+	names := make([]string, 0, len(signed))
+	for k := range signed {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var canonHeaders strings.Builder
+	for _, k := range names {
+		canonHeaders.WriteString(k + ":" + signed[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	query := map[string]string{
+		"X-Amz-Algorithm":     s3Algorithm,
+		"X-Amz-Credential":    b.cfg.AccessKeyID + "/" + scope,
+		"X-Amz-Date":          amzDate,
+		"X-Amz-Expires":       strconv.FormatInt(int64(ttl/time.Second), 10),
+		"X-Amz-SignedHeaders": signedHeaders,
+	}
+	canonQuery := canonicalQuery(query)
+	canonURI := s3Escape(path, false)
+
+	canonRequest := strings.Join([]string{
+		method,
+		canonURI,
+		canonQuery,
+		canonHeaders.String(),
+		signedHeaders,
+		s3PayloadHash,
+	}, "\n")
+
+	reqHash := sha256.Sum256([]byte(canonRequest))
+	stringToSign := strings.Join([]string{
+		s3Algorithm,
+		amzDate,
+		scope,
+		hex.EncodeToString(reqHash[:]),
+	}, "\n")
+
+	kDate := hmacSHA256([]byte("AWS4"+b.cfg.SecretAccessKey), now.Format(s3ScopeDate))
+	kRegion := hmacSHA256(kDate, b.cfg.Region)
+	kService := hmacSHA256(kRegion, s3Service)
+	kSigning := hmacSHA256(kService, "aws4_request")
+	sig := hex.EncodeToString(hmacSHA256(kSigning, stringToSign))
+
+	return b.endpoint.Scheme + "://" + host + canonURI + "?" + canonQuery + "&X-Amz-Signature=" + sig
+}
+
+func canonicalQuery(q map[string]string) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, s3Escape(k, true)+"="+s3Escape(q[k], true))
+	}
+	return strings.Join(parts, "&")
+}
+
+// s3Escape applies SigV4 URI encoding: unreserved characters stay, everything else is %XX.
+// Slashes are kept in paths and encoded in query components.
+func s3Escape(s string, encodeSlash bool) string {
+	const hexDigits = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			b.WriteByte('%')
+			b.WriteByte(hexDigits[c>>4])
+			b.WriteByte(hexDigits[c&0x0f])
+		}
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	m := hmac.New(sha256.New, key)
+	_, _ = m.Write([]byte(data))
+	return m.Sum(nil)
+}
@@ -0,0 +1,156 @@
+package attachments
+
+import (
+	"mime"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Storage backends.
+const (
+	BackendLocal = "local"
+	BackendS3    = "s3"
+)
+
+const defaultAllowedMIMETypes = "image/jpeg,image/png,image/gif,image/webp,video/mp4,audio/mpeg,audio/ogg,application/pdf,text/plain"
+
+// Config controls attachment uploads.
+type Config struct {
+	// Backend is BackendLocal or BackendS3. Empty disables attachments.
+	Backend string
+
+	// MaxBytes caps a single attachment.
+	MaxBytes int64
+	// AllowedMIMETypes are lowercase media types without parameters.
+	AllowedMIMETypes []string
+	// UploadURLTTL bounds how long an upload target stays valid.
+	UploadURLTTL time.Duration
+
+	// LocalDir stores uploads for the local backend.
+	LocalDir string
+	// PublicBaseURL prefixes local upload URLs (e.g. https://api.example.com).
+	// Empty yields relative URLs.
+	PublicBaseURL string
+
+	S3 S3Config
+}
+
+// S3Config addresses an S3-compatible bucket.
+type S3Config struct {
+	// Endpoint is the service base URL, e.g. https://s3.us-east-1.amazonaws.com.
+	Endpoint string
+	Region   string
+	Bucket   string
+
+	AccessKeyID     string
+	SecretAccessKey string
+
+	// PathStyle addresses the bucket as {endpoint}/{bucket} instead of {bucket}.{host}
+	// (needed by most self-hosted S3-compatible stores).
+	PathStyle bool
+}
+
+// LoadConfigFromEnv loads attachment config from environment variables with safe defaults.
+func LoadConfigFromEnv() Config {
+	cfg := Config{
+		Backend:          strings.ToLower(strings.TrimSpace(os.Getenv("ARC_ATTACHMENTS_BACKEND"))),
+		MaxBytes:         envInt64("ARC_ATTACHMENTS_MAX_BYTES", 25<<20),
+		AllowedMIMETypes: parseMIMETypes(envString("ARC_ATTACHMENTS_ALLOWED_MIME", defaultAllowedMIMETypes)),
+		UploadURLTTL:     envDuration("ARC_ATTACHMENTS_UPLOAD_URL_TTL", 15*time.Minute),
+		LocalDir:         envString("ARC_ATTACHMENTS_LOCAL_DIR", "./data/attachments"),
+		PublicBaseURL:    strings.TrimRight(strings.TrimSpace(os.Getenv("ARC_ATTACHMENTS_PUBLIC_BASE_URL")), "/"),
+		S3: S3Config{
+			Endpoint:        strings.TrimRight(strings.TrimSpace(os.Getenv("ARC_ATTACHMENTS_S3_ENDPOINT")), "/"),
+			Region:          envString("ARC_ATTACHMENTS_S3_REGION", "us-east-1"),
+			Bucket:          strings.TrimSpace(os.Getenv("ARC_ATTACHMENTS_S3_BUCKET")),
+			AccessKeyID:     strings.TrimSpace(os.Getenv("ARC_ATTACHMENTS_S3_ACCESS_KEY_ID")),
+			SecretAccessKey: strings.TrimSpace(os.Getenv("ARC_ATTACHMENTS_S3_SECRET_ACCESS_KEY")),
+			PathStyle:       envBool("ARC_ATTACHMENTS_S3_PATH_STYLE", false),
+		},
+	}
+	// Pre-signed S3 URLs cannot outlive 7 days.
+	if cfg.UploadURLTTL > 7*24*time.Hour {
+		cfg.UploadURLTTL = 7 * 24 * time.Hour
+	}
+	return cfg
+}
+
+// Enabled reports whether a storage backend is configured.
+func (c Config) Enabled() bool {
+	return c.Backend != ""
+}
+
+// allowsMIME reports whether mimeType (already normalized) may be uploaded.
+func (c Config) allowsMIME(mimeType string) bool {
+	for _, t := range c.AllowedMIMETypes {
+		if t == mimeType {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeMIMEType lowercases a media type and drops its parameters.
+func normalizeMIMEType(raw string) (string, bool) {
+	mt, _, err := mime.ParseMediaType(strings.TrimSpace(raw))
+	if err != nil || !strings.Contains(mt, "/") {
+		return "", false
+	}
+	return mt, true
+}
+
+func parseMIMETypes(raw string) []string {
+	var out []string
+	for _, p := range strings.Split(raw, ",") {
+		if mt, ok := normalizeMIMEType(p); ok {
+			out = append(out, mt)
+		}
+	}
+	return out
+}
+
+func envString(key, def string) string {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return def
+	}
+	return v
+}
+
+func envInt64(key string, def int64) int64 {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return def
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n <= 0 {
+		return def
+	}
+	return n
+}
+
+func envDuration(key string, def time.Duration) time.Duration {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		return def
+	}
+	return d
+}
+
+func envBool(key string, def bool) bool {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return def
+	}
+	return b
+}
@@ -0,0 +1,8 @@
+// Package attachments issues upload targets for message attachments.
+//
+// Clients call POST /attachments with the file's MIME type and size, receive an
+// attachment ID plus a short-lived pre-signed upload target, upload the bytes
+// directly to it, and then reference the ID from message.send. Uploads land on
+// local disk (through a signed PUT served by this package) or in an
+// S3-compatible bucket (through a SigV4 pre-signed PUT URL).
+package attachments
@@ -0,0 +1,281 @@
+package attachments
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"arc/cmd/internal/auth/session"
+	"arc/cmd/internal/httpjson"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oklog/ulid/v2"
+)
+
+const (
+	attachmentsPath = "/attachments"
+
+	createMaxBodyBytes = 4 << 10 // 4 KiB
+	maxFilenameLen     = 255
+	attachmentIDLen    = 26 // ULID
+)
+
+// TokenValidator validates bearer access tokens.
+type TokenValidator interface {
+	ValidateAccessToken(ctx context.Context, token string, now time.Time) (session.AccessClaims, error)
+}
+
+// Handler serves the attachment endpoints.
+type Handler struct {
+	log *slog.Logger
+	cfg Config
+
+	store   *PostgresStore
+	backend Backend
+	tokens  TokenValidator
+	now     func() time.Time
+}
+
+type createRequest struct {
+	Filename  string `json:"filename,omitempty"`
+	MIMEType  string `json:"mime_type"`
+	SizeBytes int64  `json:"size_bytes"`
+}
+
+type uploadTargetResponse struct {
+	Method    string            `json:"method"`
+	URL       string            `json:"url"`
+	Headers   map[string]string `json:"headers"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+type createResponse struct {
+	AttachmentID string               `json:"attachment_id"`
+	MIMEType     string               `json:"mime_type"`
+	SizeBytes    int64                `json:"size_bytes"`
+	Upload       uploadTargetResponse `json:"upload"`
+}
+
+// NewHandler constructs an attachments Handler for the configured backend.
+func NewHandler(log *slog.Logger, pool *pgxpool.Pool, cfg Config, tokens TokenValidator) (*Handler, error) {
+	if log == nil {
+		log = slog.Default()
+	}
+	if tokens == nil {
+		return nil, errors.New("attachments: nil token validator")
+	}
+	store, err := NewPostgresStore(pool)
+	if err != nil {
+		return nil, err
+	}
+	backend, err := NewBackend(cfg, time.Now)
+	if err != nil {
+		return nil, err
+	}
+	return &Handler{log: log, cfg: cfg, store: store, backend: backend, tokens: tokens, now: time.Now}, nil
+}
+
+// Store returns the attachment store, which also verifies attachment ownership for message.send.
+func (h *Handler) Store() *PostgresStore {
+	return h.store
+}
+
+// Register wires attachment routes onto the provided mux.
+func (h *Handler) Register(mux *http.ServeMux) {
+	if h == nil || mux == nil {
+		return
+	}
+	mux.HandleFunc(attachmentsPath, h.handleCreate)
+	if _, ok := h.backend.(*LocalBackend); ok {
+		mux.HandleFunc(attachmentsPath+"/{id}/content", h.handleUpload)
+	}
+}
+
+// handleCreate serves POST /attachments: it validates the declared type and size,
+// records the attachment and returns a pre-signed upload target.
+func (h *Handler) handleCreate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		httpjson.WriteError(w, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+	claims, ok := h.requireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	var req createRequest
+	if err := httpjson.Decode(w, r, createMaxBodyBytes, &req); err != nil {
+		httpjson.WriteError(w, http.StatusBadRequest, "invalid_json", "invalid JSON body")
+		return
+	}
+	mimeType, ok := normalizeMIMEType(req.MIMEType)
+	if !ok {
+		httpjson.WriteError(w, http.StatusBadRequest, "invalid_request", "mime_type is required")
+		return
+	}
+	if !h.cfg.allowsMIME(mimeType) {
+		httpjson.WriteError(w, http.StatusUnsupportedMediaType, "unsupported_media_type", "mime_type not allowed")
+		return
+	}
+	if req.SizeBytes <= 0 {
+		httpjson.WriteError(w, http.StatusBadRequest, "invalid_request", "size_bytes must be positive")
+		return
+	}
+	if req.SizeBytes > h.cfg.MaxBytes {
+		httpjson.WriteError(w, http.StatusRequestEntityTooLarge, "too_large", "attachment exceeds the size limit")
+		return
+	}
+	filename, ok := normalizeFilename(req.Filename)
+	if !ok {
+		httpjson.WriteError(w, http.StatusBadRequest, "invalid_request", "invalid filename")
+		return
+	}
+
+	now := h.now().UTC()
+	id := ulid.Make().String()
+	a := Attachment{
+		ID:              id,
+		OwnerUserID:     claims.UserID,
+		Filename:        filename,
+		MIMEType:        mimeType,
+		SizeBytes:       req.SizeBytes,
+		Backend:         h.backend.Name(),
+		StorageKey:      storageKey(claims.UserID, id),
+		CreatedAt:       now,
+		UploadExpiresAt: now.Add(h.cfg.UploadURLTTL),
+	}
+
+	target, err := h.backend.PresignUpload(a, a.UploadExpiresAt)
+	if err != nil {
+		h.log.Error("attachment.presign.fail", "err", err)
+		httpjson.WriteError(w, http.StatusInternalServerError, "internal_error", "internal error")
+		return
+	}
+	if err := h.store.create(r.Context(), a); err != nil {
+		h.log.Error("attachment.create.fail", "err", err)
+		httpjson.WriteError(w, http.StatusInternalServerError, "internal_error", "internal error")
+		return
+	}
+
+	httpjson.Write(w, http.StatusCreated, createResponse{
+		AttachmentID: a.ID,
+		MIMEType:     a.MIMEType,
+		SizeBytes:    a.SizeBytes,
+		Upload: uploadTargetResponse{
+			Method:    target.Method,
+			URL:       target.URL,
+			Headers:   target.Headers,
+			ExpiresAt: target.ExpiresAt,
+		},
+	})
+}
+
+// handleUpload serves PUT /attachments/{id}/content for the local backend.
+// The signed URL is the credential; the body must match the declared type and size.
+func (h *Handler) handleUpload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		w.Header().Set("Allow", http.MethodPut)
+		httpjson.WriteError(w, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+	local, ok := h.backend.(*LocalBackend)
+	if !ok {
+		httpjson.WriteError(w, http.StatusNotFound, "not_found", "not found")
+		return
+	}
+
+	id := strings.TrimSpace(r.PathValue("id"))
+	if len(id) != attachmentIDLen {
+		httpjson.WriteError(w, http.StatusNotFound, "not_found", "attachment not found")
+		return
+	}
+	a, err := h.store.get(r.Context(), id)
+	if errors.Is(err, errAttachmentNotFound) {
+		httpjson.WriteError(w, http.StatusNotFound, "not_found", "attachment not found")
+		return
+	}
+	if err != nil {
+		h.log.Error("attachment.get.fail", "err", err)
+		httpjson.WriteError(w, http.StatusInternalServerError, "internal_error", "internal error")
+		return
+	}
+
+	q := r.URL.Query()
+	if !local.verify(a, q.Get("exp"), q.Get("sig"), h.now()) {
+		httpjson.WriteError(w, http.StatusForbidden, "invalid_signature", "invalid or expired upload URL")
+		return
+	}
+	if a.UploadedAt != nil {
+		httpjson.WriteError(w, http.StatusConflict, "already_uploaded", "attachment already uploaded")
+		return
+	}
+	if mt, ok := normalizeMIMEType(r.Header.Get("Content-Type")); !ok || mt != a.MIMEType {
+		httpjson.WriteError(w, http.StatusUnsupportedMediaType, "unsupported_media_type", "content type does not match the attachment")
+		return
+	}
+	if r.ContentLength >= 0 && r.ContentLength != a.SizeBytes {
+		httpjson.WriteError(w, http.StatusBadRequest, "size_mismatch", "content length does not match the attachment")
+		return
+	}
+
+	body := http.MaxBytesReader(w, r.Body, a.SizeBytes)
+	defer func() { _ = body.Close() }()
+	if err := local.write(a.StorageKey, body, a.SizeBytes); err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.Is(err, errUploadSizeMismatch) || errors.As(err, &maxErr) {
+			httpjson.WriteError(w, http.StatusBadRequest, "size_mismatch", "body does not match the attachment size")
+			return
+		}
+		h.log.Error("attachment.write.fail", "err", err)
+		httpjson.WriteError(w, http.StatusInternalServerError, "internal_error", "internal error")
+		return
+	}
+	if _, err := h.store.markUploaded(r.Context(), a.ID, h.now().UTC()); err != nil {
+		h.log.Error("attachment.mark_uploaded.fail", "err", err)
+		httpjson.WriteError(w, http.StatusInternalServerError, "internal_error", "internal error")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) requireAuth(w http.ResponseWriter, r *http.Request) (session.AccessClaims, bool) {
+	token := bearerToken(r)
+	if token == "" {
+		httpjson.WriteError(w, http.StatusUnauthorized, "unauthorized", "missing bearer token")
+		return session.AccessClaims{}, false
+	}
+	claims, err := h.tokens.ValidateAccessToken(r.Context(), token, h.now().UTC())
+	if err != nil || strings.TrimSpace(claims.UserID) == "" {
+		httpjson.WriteError(w, http.StatusUnauthorized, "unauthorized", "invalid token")
+		return session.AccessClaims{}, false
+	}
+	return claims, true
+}
+
+// normalizeFilename trims the client-supplied display name and rejects path-like values.
+func normalizeFilename(raw string) (string, bool) {
+	name := strings.TrimSpace(raw)
+	if len(name) > maxFilenameLen || strings.ContainsAny(name, "/\\\x00") {
+		return "", false
+	}
+	return name, true
+}
+
+// storageKey places objects under their owner so buckets can be audited per user.
+// The local backend stores by the key's base name (the attachment ID).
+func storageKey(ownerUserID, id string) string {
+	return "attachments/" + ownerUserID + "/" + id
+}
+
+func bearerToken(r *http.Request) string {
+	raw := strings.TrimSpace(r.Header.Get("Authorization"))
+	parts := strings.SplitN(raw, " ", 2)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(parts[1])
+}
@@ -0,0 +1,107 @@
+package attachments
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"arc/cmd/internal/auth/session"
+	"arc/cmd/internal/httpjson/httpjsontest"
+)
+
+type fakeTokens struct{}
+
+func (fakeTokens) ValidateAccessToken(_ context.Context, token string, _ time.Time) (session.AccessClaims, error) {
+	if token != "good" {
+		return session.AccessClaims{}, errors.New("invalid")
+	}
+	return session.AccessClaims{UserID: "01HZXUSER00000000000000000"}, nil
+}
+
+func newTestHandler(t *testing.T) *Handler {
+	t.Helper()
+	b, err := NewLocalBackend(t.TempDir(), "", []byte("k"))
+	if err != nil {
+		t.Fatalf("new backend: %v", err)
+	}
+	return &Handler{
+		cfg: Config{
+			Backend:          BackendLocal,
+			MaxBytes:         100,
+			AllowedMIMETypes: []string{"image/png"},
+			UploadURLTTL:     time.Minute,
+		},
+		backend: b,
+		tokens:  fakeTokens{},
+		now:     time.Now,
+	}
+}
+
+// Requests rejected before any store access.
+func TestHandleCreate_Validation(t *testing.T) {
+	h := newTestHandler(t)
+
+	cases := []struct {
+		name   string
+		method string
+		auth   string
+		body   string
+		status int
+		code   string
+	}{
+		{"method", http.MethodGet, "good", "", http.StatusMethodNotAllowed, "method_not_allowed"},
+		{"no token", http.MethodPost, "", `{"mime_type":"image/png","size_bytes":1}`, http.StatusUnauthorized, "unauthorized"},
+		{"bad token", http.MethodPost, "bad", `{"mime_type":"image/png","size_bytes":1}`, http.StatusUnauthorized, "unauthorized"},
+		{"bad json", http.MethodPost, "good", `{"mime_type":`, http.StatusBadRequest, "invalid_json"},
+		{"missing mime", http.MethodPost, "good", `{"size_bytes":1}`, http.StatusBadRequest, "invalid_request"},
+		{"mime not allowed", http.MethodPost, "good", `{"mime_type":"application/x-msdownload","size_bytes":1}`, http.StatusUnsupportedMediaType, "unsupported_media_type"},
+		{"zero size", http.MethodPost, "good", `{"mime_type":"image/png","size_bytes":0}`, http.StatusBadRequest, "invalid_request"},
+		{"too large", http.MethodPost, "good", `{"mime_type":"IMAGE/PNG; foo=bar","size_bytes":101}`, http.StatusRequestEntityTooLarge, "too_large"},
+		{"path filename", http.MethodPost, "good", `{"mime_type":"image/png","size_bytes":1,"filename":"../etc/passwd"}`, http.StatusBadRequest, "invalid_request"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httpjsontest.Do(http.HandlerFunc(h.handleCreate), tc.method, attachmentsPath, tc.auth, tc.body)
+			if rec.Code != tc.status {
+				t.Fatalf("expected %d, got %d: %s", tc.status, rec.Code, rec.Body.String())
+			}
+			if !strings.Contains(rec.Body.String(), `"code":"`+tc.code+`"`) {
+				t.Fatalf("expected code %q, got %s", tc.code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandleUpload_RejectsMalformedID(t *testing.T) {
+	h := newTestHandler(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(attachmentsPath+"/{id}/content", h.handleUpload)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/attachments/short/content", strings.NewReader("x")))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/attachments/01HZXATTACH000000000000000/content", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", rec.Code)
+	}
+}
+
+func TestNormalizeMIMEType(t *testing.T) {
+	if mt, ok := normalizeMIMEType(" Image/PNG; charset=x "); !ok || mt != "image/png" {
+		t.Fatalf("unexpected %q %v", mt, ok)
+	}
+	for _, raw := range []string{"", "png", ";;"} {
+		if _, ok := normalizeMIMEType(raw); ok {
+			t.Fatalf("expected %q to be rejected", raw)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package attachments
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"arc/cmd/internal/dbtrace"
+	"arc/cmd/internal/realtime"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var errAttachmentNotFound = errors.New("attachment not found")
+
+// Attachment is one issued upload and its metadata.
+type Attachment struct {
+	ID          string
+	OwnerUserID string
+	Filename    string
+	MIMEType    string
+	SizeBytes   int64
+	Backend     string
+	StorageKey  string
+	CreatedAt   time.Time
+	// UploadExpiresAt is when the upload target stops being valid.
+	UploadExpiresAt time.Time
+	// UploadedAt is set once a local upload completes; S3 uploads are not observed.
+	UploadedAt *time.Time
+}
+
+// PostgresStore persists attachment metadata in arc.attachments.
+type PostgresStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresStore constructs a PostgresStore.
+func NewPostgresStore(pool *pgxpool.Pool) (*PostgresStore, error) {
+	if pool == nil {
+		return nil, errors.New("attachments: nil db pool")
+	}
+	return &PostgresStore{pool: pool}, nil
+}
+
+func (s *PostgresStore) create(ctx context.Context, a Attachment) error {
+	ctx = dbtrace.WithOp(ctx, "attachments.create")
+	_, err := s.pool.Exec(ctx, `
+		INSERT INTO arc.attachments (
+			id, owner_user_id, filename, mime_type, size_bytes, backend, storage_key, created_at, upload_expires_at
+		) VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7, $8, $9)
+	`, a.ID, a.OwnerUserID, a.Filename, a.MIMEType, a.SizeBytes, a.Backend, a.StorageKey, a.CreatedAt, a.UploadExpiresAt)
+	return err
+}
+
+func (s *PostgresStore) get(ctx context.Context, id string) (Attachment, error) {
+	ctx = dbtrace.WithOp(ctx, "attachments.get")
+	var a Attachment
+	err := s.pool.QueryRow(ctx, `
+		SELECT id, owner_user_id, COALESCE(filename, ''), mime_type, size_bytes, backend, storage_key,
+		       created_at, upload_expires_at, uploaded_at
+		FROM arc.attachments
+		WHERE id = $1
+	`, id).Scan(
+		&a.ID, &a.OwnerUserID, &a.Filename, &a.MIMEType, &a.SizeBytes, &a.Backend, &a.StorageKey,
+		&a.CreatedAt, &a.UploadExpiresAt, &a.UploadedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Attachment{}, errAttachmentNotFound
+	}
+	return a, err
+}
+
+// markUploaded records a completed upload. It reports false when the attachment was already uploaded.
+func (s *PostgresStore) markUploaded(ctx context.Context, id string, now time.Time) (bool, error) {
+	ctx = dbtrace.WithOp(ctx, "attachments.mark_uploaded")
+	ct, err := s.pool.Exec(ctx, `
+		UPDATE arc.attachments SET uploaded_at = $2 WHERE id = $1 AND uploaded_at IS NULL
+	`, id, now)
+	if err != nil {
+		return false, err
+	}
+	return ct.RowsAffected() == 1, nil
+}
+
+// VerifyAttachments implements realtime.AttachmentVerifier. ids must be distinct.
+func (s *PostgresStore) VerifyAttachments(ctx context.Context, userID string, ids []string) error {
+	ctx = dbtrace.WithOp(ctx, "attachments.verify_attachments")
+	if s == nil || s.pool == nil {
+		return errors.New("attachments: nil store")
+	}
+	userID = strings.TrimSpace(userID)
+	if userID == "" || len(ids) == 0 {
+		return realtime.ErrAttachmentNotFound
+	}
+
+	var owned int
+	if err := s.pool.QueryRow(ctx, `
+		SELECT count(*) FROM arc.attachments WHERE id = ANY($1) AND owner_user_id = $2
+	`, ids, userID).Scan(&owned); err != nil {
+		return err
+	}
+	if owned != len(ids) {
+		return realtime.ErrAttachmentNotFound
+	}
+	return nil
+}
+
+var _ realtime.AttachmentVerifier = (*PostgresStore)(nil)
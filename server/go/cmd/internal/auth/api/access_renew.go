@@ -0,0 +1,94 @@
+package authapi
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"arc/cmd/internal/auth/session"
+)
+
+// handleAccessRenew issues a new access token for a refresh token without rotating it.
+//
+// Long-lived clients (e.g. WebSocket connections) use it to keep an access token fresh;
+// renewals are capped per session, see session.Config.AccessRenewMinInterval.
+func (h *Handler) handleAccessRenew(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.dbEnabled {
+		writeError(w, http.StatusServiceUnavailable, "db_unavailable", "database not configured")
+		return
+	}
+	if !h.allowAccess(w, r, "access_renew") {
+		return
+	}
+
+	var req accessRenewRequest
+	if r.ContentLength != 0 {
+		if err := decodeJSON(w, r, h.cfg.MaxBodyBytes, &req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_json", "invalid request body")
+			return
+		}
+	}
+	refreshToken := strings.TrimSpace(req.RefreshToken)
+	fromCookie := false
+	if refreshToken == "" {
+		refreshToken, fromCookie = h.refreshTokenFromCookie(r)
+	}
+	if refreshToken == "" {
+		writeError(w, http.StatusBadRequest, "invalid_request", "refresh_token is required")
+		return
+	}
+	proof, ok := decodeBindingProof(w, req.BindingProof)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	ip := h.clientIP(r)
+	ua := strings.TrimSpace(r.UserAgent())
+
+	renewed, err := h.sessions.RenewAccessToken(ctx, time.Now().UTC(), refreshToken, session.DeviceContext{BindingProof: proof})
+	if err != nil {
+		switch {
+		case errors.Is(err, session.ErrRefreshRateLimited):
+			var rlErr session.RefreshRateLimitError
+			errors.As(err, &rlErr)
+			writeRateLimitedError(w, rlErr.RetryAfter, "access_renew_rate_limited", "access token renewed too frequently")
+		case errors.Is(err, session.ErrRefreshReuseDetected):
+			h.auditRefreshReuse(ctx, ip, ua)
+			writeError(w, http.StatusUnauthorized, "refresh_reuse_detected", "refresh token reuse detected")
+		case errors.Is(err, session.ErrSessionExpired), errors.Is(err, session.ErrSessionRevoked), errors.Is(err, session.ErrSessionNotFound):
+			writeError(w, http.StatusUnauthorized, "session_not_active", "session not active")
+		case errors.Is(err, session.ErrUserLocked):
+			writeError(w, http.StatusForbidden, "account_locked", "account locked")
+		case errors.Is(err, session.ErrBindingProofInvalid):
+			h.auditRefreshBindingFailed(ctx, ip, ua)
+			writeError(w, http.StatusUnauthorized, "binding_proof_invalid", "refresh binding proof invalid")
+		case errors.Is(err, session.ErrBindingRequired):
+			writeError(w, http.StatusUnauthorized, "binding_required", "session must be re-established with a binding key")
+		default:
+			h.log.Error("auth.token.access.fail", "err", err)
+			writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+		}
+		return
+	}
+
+	h.auditAccessRenewed(ctx, renewed.SessionID, ip, ua)
+
+	resp := accessRenewResponse{
+		SessionID:       renewed.SessionID,
+		AccessToken:     renewed.AccessToken,
+		AccessExpiresAt: renewed.AccessExp,
+		BindingNonce:    renewed.BindingNonce,
+	}
+	// BFF mode: a browser renewing with its refresh cookie gets the access token as a cookie too.
+	if fromCookie && h.cfg.WebAccessCookieEnabled {
+		h.setAccessCookie(w, resp.AccessToken, resp.AccessExpiresAt)
+		resp.AccessToken = ""
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
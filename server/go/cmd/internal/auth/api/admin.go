@@ -0,0 +1,259 @@
+package authapi
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"arc/cmd/identity"
+	"arc/cmd/internal/auth/session"
+	"arc/cmd/internal/dbroute"
+)
+
+// maxLockReasonLen mirrors chk_users_locked_reason_len.
+const maxLockReasonLen = 512
+
+// requireAdmin authenticates the caller and checks it against the configured admin allow-list.
+func (h *Handler) requireAdmin(w http.ResponseWriter, r *http.Request) (session.AccessClaims, bool) {
+	claims, ok := h.requireAuth(w, r)
+	if !ok {
+		return session.AccessClaims{}, false
+	}
+	if !h.isAdmin(claims.UserID) {
+		writeError(w, http.StatusForbidden, "forbidden", "admin privileges required")
+		return session.AccessClaims{}, false
+	}
+	return claims, true
+}
+
+func (h *Handler) isAdmin(userID string) bool {
+	if userID == "" {
+		return false
+	}
+	for _, id := range h.cfg.AdminUserIDs {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *Handler) handleAdminSessionsRevoke(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.dbEnabled {
+		writeError(w, http.StatusServiceUnavailable, "db_unavailable", "database not configured")
+		return
+	}
+
+	claims, ok := h.requireAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	var req adminSessionsRevokeRequest
+	if err := decodeJSON(w, r, h.cfg.MaxBodyBytes, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_json", "invalid request body")
+		return
+	}
+
+	filter, err := req.toFilter()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	ctx := r.Context()
+	now := time.Now().UTC()
+	res, err := h.sessions.RevokeByFilter(ctx, now, filter, req.DryRun)
+	if err != nil {
+		switch {
+		case errors.Is(err, session.ErrEmptyFilter):
+			writeError(w, http.StatusBadRequest, "invalid_request", "at least one filter is required")
+		case errors.Is(err, session.ErrConfig):
+			writeError(w, http.StatusBadRequest, "invalid_request", "invalid filter")
+		default:
+			h.log.Error("auth.admin.sessions_revoke.fail", "err", err, "revoked", res.Revoked)
+			writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+		}
+		return
+	}
+
+	h.auditAdminSessionsRevoke(ctx, claims.UserID, h.clientIP(r), strings.TrimSpace(r.UserAgent()), req.auditSummary(), res)
+
+	writeJSON(w, http.StatusOK, adminSessionsRevokeResponse{
+		Matched: res.Matched,
+		Revoked: res.Revoked,
+		Batches: res.Batches,
+		DryRun:  res.DryRun,
+	})
+}
+
+func (h *Handler) handleAdminUserLock(w http.ResponseWriter, r *http.Request) {
+	claims, targetID, ok := h.beginAdminUserAction(w, r)
+	if !ok {
+		return
+	}
+	if targetID == claims.UserID {
+		writeError(w, http.StatusBadRequest, "invalid_request", "cannot lock own account")
+		return
+	}
+
+	var req adminUserLockRequest
+	if r.ContentLength != 0 {
+		if err := decodeJSON(w, r, h.cfg.MaxBodyBytes, &req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_json", "invalid request body")
+			return
+		}
+	}
+	if req.Reason != nil {
+		reason := truncateRunes(*req.Reason, maxLockReasonLen)
+		req.Reason = &reason
+	}
+
+	ctx := r.Context()
+	now := time.Now().UTC()
+	if err := h.identity.SetUserLocked(ctx, targetID, true, req.Reason, now); err != nil {
+		h.writeAdminUserError(w, "auth.admin.user_lock.fail", err)
+		return
+	}
+	// Lock first, then revoke: a refresh racing the revocation is rejected by the lock check.
+	if err := h.sessions.RevokeAllSessions(ctx, now, targetID, "admin"); err != nil {
+		h.log.Error("auth.admin.user_lock.revoke.fail", "err", err)
+		writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+		return
+	}
+
+	meta := map[string]any{}
+	if req.Reason != nil && *req.Reason != "" {
+		meta["reason"] = *req.Reason
+	}
+	h.auditAdminUserAction(ctx, "admin.user.lock", claims.UserID, targetID, h.clientIP(r), strings.TrimSpace(r.UserAgent()), meta)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) handleAdminUserUnlock(w http.ResponseWriter, r *http.Request) {
+	claims, targetID, ok := h.beginAdminUserAction(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	if err := h.identity.SetUserLocked(ctx, targetID, false, nil, time.Now().UTC()); err != nil {
+		h.writeAdminUserError(w, "auth.admin.user_unlock.fail", err)
+		return
+	}
+
+	h.auditAdminUserAction(ctx, "admin.user.unlock", claims.UserID, targetID, h.clientIP(r), strings.TrimSpace(r.UserAgent()), nil)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) handleAdminUserLogoutAll(w http.ResponseWriter, r *http.Request) {
+	claims, targetID, ok := h.beginAdminUserAction(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	if _, err := h.identity.GetUserByID(dbroute.RequirePrimary(ctx), targetID); err != nil {
+		h.writeAdminUserError(w, "auth.admin.user_logout_all.fail", err)
+		return
+	}
+	if err := h.sessions.RevokeAllSessions(ctx, time.Now().UTC(), targetID, "admin"); err != nil {
+		h.log.Error("auth.admin.user_logout_all.fail", "err", err)
+		writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+		return
+	}
+
+	h.auditAdminUserAction(ctx, "admin.user.logout_all", claims.UserID, targetID, h.clientIP(r), strings.TrimSpace(r.UserAgent()), nil)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// beginAdminUserAction runs the shared preamble of /admin/users/{id}/* handlers.
+func (h *Handler) beginAdminUserAction(w http.ResponseWriter, r *http.Request) (session.AccessClaims, string, bool) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return session.AccessClaims{}, "", false
+	}
+	if !h.dbEnabled {
+		writeError(w, http.StatusServiceUnavailable, "db_unavailable", "database not configured")
+		return session.AccessClaims{}, "", false
+	}
+
+	claims, ok := h.requireAdmin(w, r)
+	if !ok {
+		return session.AccessClaims{}, "", false
+	}
+
+	targetID, ok := adminTargetUserID(w, r)
+	if !ok {
+		return session.AccessClaims{}, "", false
+	}
+	return claims, targetID, true
+}
+
+func (h *Handler) writeAdminUserError(w http.ResponseWriter, logMsg string, err error) {
+	if identity.IsNotFound(err) {
+		writeError(w, http.StatusNotFound, "not_found", "user not found")
+		return
+	}
+	h.log.Error(logMsg, "err", err)
+	writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+}
+
+// toFilter converts the request body into a session filter.
+// Input errors are returned with client-safe messages.
+func (req adminSessionsRevokeRequest) toFilter() (session.Filter, error) {
+	var f session.Filter
+
+	if p := strings.TrimSpace(req.Platform); p != "" {
+		f.Platform = normalizePlatform(p)
+		if f.Platform == session.PlatformUnknown && !strings.EqualFold(p, string(session.PlatformUnknown)) {
+			return session.Filter{}, errors.New("unknown platform")
+		}
+	}
+
+	if c := strings.TrimSpace(req.IPCIDR); c != "" {
+		_, network, err := net.ParseCIDR(c)
+		if err != nil {
+			return session.Filter{}, errors.New("invalid ip_cidr")
+		}
+		f.IPRange = network
+	}
+
+	if req.CreatedBefore != nil {
+		f.CreatedBefore = req.CreatedBefore.UTC()
+	}
+
+	for _, id := range req.UserIDs {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			return session.Filter{}, errors.New("user_ids must not contain empty values")
+		}
+		f.UserIDs = append(f.UserIDs, id)
+	}
+
+	return f, nil
+}
+
+// auditSummary renders the requested filter for the audit log.
+func (req adminSessionsRevokeRequest) auditSummary() map[string]any {
+	out := map[string]any{}
+	if p := strings.TrimSpace(req.Platform); p != "" {
+		out["platform"] = strings.ToLower(p)
+	}
+	if c := strings.TrimSpace(req.IPCIDR); c != "" {
+		out["ip_cidr"] = c
+	}
+	if req.CreatedBefore != nil {
+		out["created_before"] = req.CreatedBefore.UTC().Format(time.RFC3339)
+	}
+	if n := len(req.UserIDs); n > 0 {
+		out["user_ids_count"] = n
+	}
+	return out
+}
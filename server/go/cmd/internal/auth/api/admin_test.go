@@ -0,0 +1,58 @@
+package authapi
+
+import (
+	"testing"
+	"time"
+
+	"arc/cmd/internal/auth/session"
+)
+
+func TestAdminSessionsRevokeRequest_ToFilter(t *testing.T) {
+	before := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	req := adminSessionsRevokeRequest{
+		Platform:      " IOS ",
+		CreatedBefore: &before,
+		IPCIDR:        "10.0.0.0/8",
+		UserIDs:       []string{" u1 ", "u2"},
+	}
+
+	f, err := req.toFilter()
+	if err != nil {
+		t.Fatalf("toFilter: %v", err)
+	}
+	if f.Platform != session.PlatformIOS {
+		t.Fatalf("expected platform ios, got %q", f.Platform)
+	}
+	if f.IPRange == nil || f.IPRange.String() != "10.0.0.0/8" {
+		t.Fatalf("unexpected ip range: %v", f.IPRange)
+	}
+	if !f.CreatedBefore.Equal(before) {
+		t.Fatalf("unexpected created_before: %v", f.CreatedBefore)
+	}
+	if len(f.UserIDs) != 2 || f.UserIDs[0] != "u1" {
+		t.Fatalf("unexpected user ids: %v", f.UserIDs)
+	}
+}
+
+func TestAdminSessionsRevokeRequest_ToFilterRejectsBadInput(t *testing.T) {
+	tests := []adminSessionsRevokeRequest{
+		{Platform: "blackberry"},
+		{IPCIDR: "10.0.0.1"},
+		{UserIDs: []string{"u1", "  "}},
+	}
+	for i, req := range tests {
+		if _, err := req.toFilter(); err == nil {
+			t.Fatalf("case %d: expected error", i)
+		}
+	}
+}
+
+func TestHandlerIsAdmin(t *testing.T) {
+	h := &Handler{cfg: Config{AdminUserIDs: []string{"admin-1"}}}
+	if !h.isAdmin("admin-1") {
+		t.Fatalf("expected admin-1 to be admin")
+	}
+	if h.isAdmin("user-2") || h.isAdmin("") {
+		t.Fatalf("unexpected admin match")
+	}
+}
@@ -6,9 +6,37 @@ import (
 	"net"
 	"strings"
 	"time"
+
+	"arc/cmd/internal/auth/session"
+	"arc/cmd/internal/dbtrace"
+	"arc/cmd/internal/redact"
+	"arc/cmd/security/token"
+
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// identifierHashKeyLabel derives the login identifier hash key from ARC_TOKEN_HMAC_KEY,
+// so an identifier hash says nothing about refresh token hashes.
+const identifierHashKeyLabel = "arc-audit-identifier-v1"
+
+// geoAuditActions are the audit actions annotated with the client's location:
+// sign-ins and the events that suggest a stolen credential. Routine ones such as
+// auth.refresh.success are not, so the hot paths skip the GeoIP lookup.
+var geoAuditActions = map[string]bool{
+	"auth.signup":                 true,
+	"auth.login.success":          true,
+	"auth.login.failed":           true,
+	"auth.login.challenge_issued": true,
+	"auth.login.challenge_passed": true,
+	"auth.token.exchanged":        true,
+	"auth.recovery_code.used":     true,
+	"auth.refresh.reuse_detected": true,
+	"auth.refresh.binding_failed": true,
+}
+
 func (h *Handler) auditLoginFailed(ctx context.Context, userID *string, ip net.IP, ua string, identifier string, reason string) {
+	loginTotal.With(reason).Inc()
+	h.stats.add(statLoginFailedPrefix+reason, 1)
 	h.insertAudit(ctx, "auth.login.failed", userID, nil, ip, ua, map[string]any{
 		"identifier": identifier,
 		"reason":     reason,
@@ -16,22 +44,33 @@ func (h *Handler) auditLoginFailed(ctx context.Context, userID *string, ip net.I
 }
 
 func (h *Handler) auditLoginSuccess(ctx context.Context, userID *string, sessionID string, ip net.IP, ua string, identifier string) {
+	loginTotal.With("success").Inc()
 	h.insertAudit(ctx, "auth.login.success", userID, &sessionID, ip, ua, map[string]any{
 		"identifier": identifier,
 	})
 }
 
-func (h *Handler) auditLoginRateLimited(ctx context.Context, userID *string, ip net.IP, ua string, identifier string, retryAfter time.Duration) {
+// auditLoginRateLimited records a login refused by a throttle: throttleIP or
+// throttleIdentifier (the latter are counted as active lockouts).
+func (h *Handler) auditLoginRateLimited(ctx context.Context, userID *string, ip net.IP, ua string, identifier string, throttle string, retryAfter time.Duration) {
+	loginTotal.With("rate_limited").Inc()
+	h.stats.add(statLoginRateLimited, 1)
 	h.insertAudit(ctx, "auth.login.rate_limited", userID, nil, ip, ua, map[string]any{
 		"identifier":    identifier,
+		"throttle":      throttle,
 		"retry_after_s": int64(retryAfter.Seconds()),
 	})
 }
 
 func (h *Handler) auditRefreshSuccess(ctx context.Context, sessionID string, ip net.IP, ua string) {
+	refreshRotations.Inc()
 	h.insertAudit(ctx, "auth.refresh.success", nil, &sessionID, ip, ua, nil)
 }
 
+func (h *Handler) auditAccessRenewed(ctx context.Context, sessionID string, ip net.IP, ua string) {
+	h.insertAudit(ctx, "auth.token.access_renewed", nil, &sessionID, ip, ua, nil)
+}
+
 func (h *Handler) auditRefreshRateLimited(ctx context.Context, sessionID string, ip net.IP, ua string, retryAfter time.Duration) {
 	sessionID = strings.TrimSpace(sessionID)
 	var sid *string
@@ -48,9 +87,33 @@ func (h *Handler) auditRefreshRateLimited(ctx context.Context, sessionID string,
 }
 
 func (h *Handler) auditRefreshReuse(ctx context.Context, ip net.IP, ua string) {
+	refreshReuseDetected.Inc()
+	h.stats.add(statRefreshReuse, 1)
 	h.insertAudit(ctx, "auth.refresh.reuse_detected", nil, nil, ip, ua, nil)
 }
 
+func (h *Handler) auditRefreshBindingFailed(ctx context.Context, ip net.IP, ua string) {
+	h.insertAudit(ctx, "auth.refresh.binding_failed", nil, nil, ip, ua, nil)
+}
+
+func (h *Handler) auditRecoveryCodesGenerated(ctx context.Context, userID string, sessionID string, ip net.IP, ua string, count int) {
+	h.insertAudit(ctx, "auth.recovery_codes.generated", &userID, &sessionID, ip, ua, map[string]any{
+		"count": count,
+	})
+}
+
+func (h *Handler) auditRecoveryCodeUsed(ctx context.Context, userID string, challengeID string, ip net.IP, ua string) {
+	h.insertAudit(ctx, "auth.recovery_code.used", &userID, nil, ip, ua, map[string]any{
+		"challenge_id": challengeID,
+	})
+}
+
+func (h *Handler) auditPrivacyExportRequested(ctx context.Context, userID string, sessionID string, ip net.IP, ua string, exportID string) {
+	h.insertAudit(ctx, "privacy.export.requested", &userID, &sessionID, ip, ua, map[string]any{
+		"export_id": exportID,
+	})
+}
+
 func (h *Handler) auditLogout(ctx context.Context, userID string, sessionID string, ip net.IP, ua string) {
 	h.insertAudit(ctx, "auth.logout", &userID, &sessionID, ip, ua, nil)
 }
@@ -65,13 +128,64 @@ func (h *Handler) auditInviteCreated(ctx context.Context, userID string, inviteI
 	})
 }
 
+func (h *Handler) auditInviteSent(ctx context.Context, userID string, inviteID string, email string, status string, ip net.IP, ua string) {
+	h.insertAudit(ctx, "auth.invite.sent", &userID, nil, ip, ua, map[string]any{
+		"invite_id":       inviteID,
+		"email":           email,
+		"delivery_status": status,
+	})
+}
+
 func (h *Handler) auditInviteConsumed(ctx context.Context, userID string, inviteID string, ip net.IP, ua string) {
 	h.insertAudit(ctx, "auth.invite.consumed", &userID, nil, ip, ua, map[string]any{
 		"invite_id": inviteID,
 	})
 }
 
+func (h *Handler) auditLoginChallengeIssued(ctx context.Context, userID string, challengeID string, ip net.IP, ua string, identifier string) {
+	loginTotal.With("challenge_issued").Inc()
+	h.insertAudit(ctx, "auth.login.challenge_issued", &userID, nil, ip, ua, map[string]any{
+		"challenge_id": challengeID,
+		"identifier":   identifier,
+	})
+}
+
+func (h *Handler) auditLoginChallengePassed(ctx context.Context, userID string, sessionID string, challengeID string, ip net.IP, ua string) {
+	loginTotal.With("success").Inc()
+	h.insertAudit(ctx, "auth.login.challenge_passed", &userID, &sessionID, ip, ua, map[string]any{
+		"challenge_id": challengeID,
+	})
+}
+
+func (h *Handler) auditPhoneOTPSent(ctx context.Context, userID string, otp phoneOTP, ip net.IP, ua string) {
+	h.insertAudit(ctx, "auth.otp.sent", &userID, nil, ip, ua, map[string]any{
+		"otp_id":     otp.ID,
+		"purpose":    otp.Purpose,
+		"channel":    otp.Channel,
+		"identifier": otp.Phone,
+	})
+}
+
+func (h *Handler) auditAdminSessionsRevoke(ctx context.Context, adminID string, ip net.IP, ua string, filter map[string]any, res session.RevokeResult) {
+	h.insertAudit(ctx, "admin.sessions.revoke", &adminID, nil, ip, ua, map[string]any{
+		"filter":  filter,
+		"matched": res.Matched,
+		"revoked": res.Revoked,
+		"batches": res.Batches,
+		"dry_run": res.DryRun,
+	})
+}
+
+func (h *Handler) auditAdminUserAction(ctx context.Context, action string, adminID string, targetUserID string, ip net.IP, ua string, meta map[string]any) {
+	if meta == nil {
+		meta = map[string]any{}
+	}
+	meta["target_user_id"] = targetUserID
+	h.insertAudit(ctx, action, &adminID, nil, ip, ua, meta)
+}
+
 func (h *Handler) insertAudit(ctx context.Context, action string, userID *string, sessionID *string, ip net.IP, ua string, meta map[string]any) {
+	ctx = dbtrace.WithOp(ctx, "authapi.insert_audit")
 	if h == nil || h.pool == nil || !h.dbEnabled {
 		return
 	}
@@ -86,16 +200,30 @@ func (h *Handler) insertAudit(ctx context.Context, action string, userID *string
 		ipVal = ip.String()
 	}
 
+	if geoAuditActions[action] {
+		if loc := h.lookupGeo(ctx, ip); !loc.IsZero() {
+			if meta == nil {
+				meta = map[string]any{}
+			}
+			meta["geo"] = loc
+		}
+	}
+	// The identifier itself is redacted (emails partially); login throttling
+	// matches on its hash instead.
+	if id, ok := meta["identifier"].(string); ok && id != "" {
+		meta["identifier_hash"] = h.identifierHash(id)
+	}
+
 	var metaVal *string
 	if len(meta) > 0 {
-		if b, err := json.Marshal(meta); err == nil {
+		if b, err := json.Marshal(redact.Meta(meta)); err == nil {
 			s := string(b)
 			metaVal = &s
 		}
 	}
 
 	_, err := h.pool.Exec(ctx, `
-		INSERT INTO arc.audit_log (
+		INSERT INTO `+pgIdent(h.schema, "audit_log")+` (
 			user_id, session_id, action, created_at, ip, user_agent, meta
 		) VALUES ($1, $2, $3, now(), $4, $5, $6::jsonb)
 	`, userID, sessionID, action, ipVal, trimOrNil(ua), metaVal)
@@ -104,6 +232,100 @@ func (h *Handler) insertAudit(ctx context.Context, action string, userID *string
 	}
 }
 
+// identifierHashBackfillWindow is how far back BackfillIdentifierHashes looks; no
+// login throttle or lockout window is longer.
+const identifierHashBackfillWindow = 24 * time.Hour
+
+// BackfillIdentifierHashes adds meta.identifier_hash to recent failed logins
+// recorded before audit meta carried it, so throttles in progress keep counting
+// across the upgrade. The hash needs the server's key, so this runs here rather
+// than in a migration. It is safe to run on every start and on several instances
+// at once.
+func (h *Handler) BackfillIdentifierHashes(ctx context.Context) {
+	if h == nil || !h.dbEnabled {
+		return
+	}
+	n, err := backfillIdentifierHashes(ctx, h.pool, h.schema, time.Now().UTC().Add(-identifierHashBackfillWindow), h.identifierHash)
+	if err != nil {
+		h.log.Error("auth.audit.identifier_hash.backfill.fail", "err", err)
+		return
+	}
+	if n > 0 {
+		h.log.Info("auth.audit.identifier_hash.backfill", "rows", n, "result", "success")
+	}
+}
+
+func backfillIdentifierHashes(ctx context.Context, pool *pgxpool.Pool, schema string, since time.Time, hash func(string) string) (int64, error) {
+	ctx = dbtrace.WithOp(ctx, "authapi.backfill_identifier_hashes")
+	rows, err := pool.Query(ctx, `
+		SELECT id, meta ->> 'identifier'
+		FROM `+pgIdent(schema, "audit_log")+`
+		WHERE action = 'auth.login.failed'
+		  AND meta ->> 'identifier' IS NOT NULL
+		  AND meta ->> 'identifier_hash' IS NULL
+		  AND created_at >= $1
+	`, since)
+	if err != nil {
+		return 0, err
+	}
+	var (
+		ids    []int64
+		hashes []string
+	)
+	for rows.Next() {
+		var (
+			id         int64
+			identifier string
+		)
+		if err := rows.Scan(&id, &identifier); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+		hashes = append(hashes, hash(identifier))
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	tag, err := pool.Exec(ctx, `
+		UPDATE `+pgIdent(schema, "audit_log")+` AS a
+		SET meta = a.meta || jsonb_build_object('identifier_hash', v.hash)
+		FROM unnest($1::bigint[], $2::text[]) AS v(id, hash)
+		WHERE a.id = v.id
+		  AND a.meta ->> 'identifier_hash' IS NULL
+	`, ids, hashes)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// identifierHashKey derives the identifier hash key from the token HMAC key. It
+// returns nil when no key is configured.
+func identifierHashKey() []byte {
+	key, err := token.HMACKeyFromEnv(0)
+	if err != nil {
+		return nil
+	}
+	return []byte(token.HashHMACSHA256Hex(identifierHashKeyLabel, key))
+}
+
+// identifierHash is the stable key of a normalized login identifier (or phone
+// number) in audit meta and phone codes: an HMAC-SHA256 under the server's key,
+// so the stored hashes cannot be reversed by hashing guessed emails. Without
+// ARC_TOKEN_HMAC_KEY it falls back to plain SHA-256, as refresh token hashes do.
+func (h *Handler) identifierHash(identifier string) string {
+	if len(h.identifierKey) == 0 {
+		return token.HashSHA256Hex(identifier)
+	}
+	return token.HashHMACSHA256Hex(identifier, h.identifierKey)
+}
+
 func trimOrNil(s string) any {
 	v := strings.TrimSpace(s)
 	if v == "" {
@@ -0,0 +1,275 @@
+package authapi
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"arc/cmd/internal/dbroute"
+	"arc/cmd/internal/dbtrace"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Counter names kept by authStats and stored under "counts" in auth.stats.flushed
+// audit rows. Failed logins are counted per reason as statLoginFailedPrefix+reason.
+const (
+	statLoginFailedPrefix = "login_failed."
+	statLoginRateLimited  = "login_rate_limited"
+	statCaptchaIssued     = "captcha_issued"
+	statCaptchaFailed     = "captcha_failed"
+	statRefreshReuse      = "refresh_reuse_detected"
+
+	auditAuthStatsFlushed = "auth.stats.flushed"
+)
+
+// authStatsWindows are the rolling windows reported by GET /admin/stats/auth.
+var authStatsWindows = [3]time.Duration{15 * time.Minute, time.Hour, 24 * time.Hour}
+
+// windowCounts holds one total per entry of authStatsWindows.
+type windowCounts [len(authStatsWindows)]int64
+
+// authStats accumulates this instance's brute-force counters until the next flush
+// to the audit log, so every instance contributes to the cluster-wide totals.
+type authStats struct {
+	mu      sync.Mutex
+	pending map[string]int64
+}
+
+func (s *authStats) add(name string, n int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pending == nil {
+		s.pending = map[string]int64{}
+	}
+	s.pending[name] += n
+}
+
+// take returns the pending counters and resets them.
+func (s *authStats) take() map[string]int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := s.pending
+	s.pending = nil
+	return out
+}
+
+// restore adds counters back after a failed flush.
+func (s *authStats) restore(counts map[string]int64) {
+	for name, n := range counts {
+		s.add(name, n)
+	}
+}
+
+func (s *authStats) snapshot() map[string]int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return maps.Clone(s.pending)
+}
+
+// RunStatsFlusher writes the brute-force counters to the audit log every
+// AuthStatsFlushInterval until ctx is done, then flushes what is left.
+func (h *Handler) RunStatsFlusher(ctx context.Context) {
+	if h == nil || !h.dbEnabled {
+		return
+	}
+	t := time.NewTicker(h.config().AuthStatsFlushInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+			h.flushAuthStats(flushCtx, time.Now().UTC())
+			cancel()
+			return
+		case <-t.C:
+			h.flushAuthStats(ctx, time.Now().UTC())
+		}
+	}
+}
+
+func (h *Handler) flushAuthStats(ctx context.Context, now time.Time) {
+	counts := h.stats.take()
+	if len(counts) == 0 {
+		return
+	}
+	if err := insertAuthStatsFlush(ctx, h.pool, h.schema, now, counts); err != nil {
+		h.stats.restore(counts)
+		h.log.Error("auth.stats.flush.fail", "err", err)
+	}
+}
+
+type authStatsWindowResponse struct {
+	Window               string           `json:"window"`
+	Since                time.Time        `json:"since"`
+	FailedLogins         map[string]int64 `json:"failed_logins"`
+	FailedLoginsTotal    int64            `json:"failed_logins_total"`
+	LoginRateLimited     int64            `json:"login_rate_limited"`
+	CaptchaIssued        int64            `json:"captcha_issued"`
+	CaptchaFailed        int64            `json:"captcha_failed"`
+	RefreshReuseDetected int64            `json:"refresh_reuse_detected"`
+}
+
+type authStatsResponse struct {
+	GeneratedAt    time.Time                 `json:"generated_at"`
+	LockoutsActive int64                     `json:"lockouts_active"`
+	Windows        []authStatsWindowResponse `json:"windows"`
+}
+
+// handleAdminAuthStats serves GET /admin/stats/auth: failed logins by reason,
+// throttled logins, captcha challenges issued and failed, and refresh reuse
+// detections over the last 15m, 1h and 24h, plus the identifiers currently locked
+// out. Totals cover every instance up to its last flush and this instance's
+// unflushed counters.
+func (h *Handler) handleAdminAuthStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.dbEnabled {
+		writeError(w, http.StatusServiceUnavailable, "db_unavailable", "database not configured")
+		return
+	}
+
+	if _, ok := h.requireAdmin(w, r); !ok {
+		return
+	}
+
+	ctx := r.Context()
+	now := time.Now().UTC()
+	var since [len(authStatsWindows)]time.Time
+	for i, d := range authStatsWindows {
+		since[i] = now.Add(-d)
+	}
+	pool := dbroute.Reader(ctx, h.pool, h.readPool)
+
+	sums, err := sumAuthStatsFlushes(ctx, pool, h.schema, since)
+	if err != nil {
+		h.log.Error("auth.admin.auth_stats.fail", "err", err, "result", "server_error")
+		writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+		return
+	}
+	cfg := h.config()
+	lookback := maxDuration(cfg.LoginUserWindow, cfg.LockoutShortDuration, cfg.LockoutLongDuration, cfg.LockoutSevereDuration)
+	lockouts, err := countActiveLockouts(ctx, pool, h.schema, now.Add(-lookback), now)
+	if err != nil {
+		h.log.Error("auth.admin.auth_stats.fail", "err", err, "result", "server_error")
+		writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+		return
+	}
+
+	for name, n := range h.stats.snapshot() {
+		v := sums[name]
+		for i := range v {
+			v[i] += n
+		}
+		sums[name] = v
+	}
+	writeJSON(w, http.StatusOK, buildAuthStatsResponse(now, since, sums, lockouts))
+}
+
+func buildAuthStatsResponse(now time.Time, since [len(authStatsWindows)]time.Time, sums map[string]windowCounts, lockouts int64) authStatsResponse {
+	out := authStatsResponse{
+		GeneratedAt:    now,
+		LockoutsActive: lockouts,
+		Windows:        make([]authStatsWindowResponse, 0, len(authStatsWindows)),
+	}
+	for i, d := range authStatsWindows {
+		win := authStatsWindowResponse{
+			Window:               formatStatsWindow(d),
+			Since:                since[i],
+			FailedLogins:         map[string]int64{},
+			LoginRateLimited:     sums[statLoginRateLimited][i],
+			CaptchaIssued:        sums[statCaptchaIssued][i],
+			CaptchaFailed:        sums[statCaptchaFailed][i],
+			RefreshReuseDetected: sums[statRefreshReuse][i],
+		}
+		for name, v := range sums {
+			reason, ok := strings.CutPrefix(name, statLoginFailedPrefix)
+			if !ok || v[i] == 0 {
+				continue
+			}
+			win.FailedLogins[reason] = v[i]
+			win.FailedLoginsTotal += v[i]
+		}
+		out.Windows = append(out.Windows, win)
+	}
+	return out
+}
+
+// formatStatsWindow renders d as "15m", "1h" or "24h".
+func formatStatsWindow(d time.Duration) string {
+	if d%time.Hour == 0 {
+		return strings.TrimSuffix(d.String(), "0m0s")
+	}
+	return strings.TrimSuffix(d.String(), "0s")
+}
+
+// ---- auth stats queries ----
+
+func insertAuthStatsFlush(ctx context.Context, pool *pgxpool.Pool, schema string, now time.Time, counts map[string]int64) error {
+	ctx = dbtrace.WithOp(ctx, "authapi.insert_auth_stats_flush")
+	b, err := json.Marshal(map[string]any{"counts": counts})
+	if err != nil {
+		return err
+	}
+	_, err = pool.Exec(ctx, `
+		INSERT INTO `+pgIdent(schema, "audit_log")+` (action, created_at, meta)
+		VALUES ($1, $2, $3::jsonb)
+	`, auditAuthStatsFlushed, now, string(b))
+	return err
+}
+
+// sumAuthStatsFlushes totals the flushed counters per name for each window start in
+// since, which must be ordered from the most recent to the oldest.
+func sumAuthStatsFlushes(ctx context.Context, pool *pgxpool.Pool, schema string, since [len(authStatsWindows)]time.Time) (map[string]windowCounts, error) {
+	ctx = dbtrace.WithOp(ctx, "authapi.sum_auth_stats_flushes")
+	rows, err := pool.Query(ctx, `
+		SELECT c.key,
+		       COALESCE(sum(c.value::bigint) FILTER (WHERE a.created_at >= $2), 0),
+		       COALESCE(sum(c.value::bigint) FILTER (WHERE a.created_at >= $3), 0),
+		       COALESCE(sum(c.value::bigint), 0)
+		  FROM `+pgIdent(schema, "audit_log")+` a,
+		       jsonb_each_text(a.meta -> 'counts') c
+		 WHERE a.action = $1
+		   AND a.created_at >= $4
+		 GROUP BY c.key
+	`, auditAuthStatsFlushed, since[0], since[1], since[2])
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := map[string]windowCounts{}
+	for rows.Next() {
+		var (
+			name string
+			v    windowCounts
+		)
+		if err := rows.Scan(&name, &v[0], &v[1], &v[2]); err != nil {
+			return nil, err
+		}
+		out[name] = v
+	}
+	return out, rows.Err()
+}
+
+// countActiveLockouts counts identifiers refused by the per-identifier login throttle
+// whose Retry-After has not elapsed yet.
+func countActiveLockouts(ctx context.Context, pool *pgxpool.Pool, schema string, since, now time.Time) (int64, error) {
+	ctx = dbtrace.WithOp(ctx, "authapi.count_active_lockouts")
+	var n int64
+	err := pool.QueryRow(ctx, `
+		SELECT count(DISTINCT meta ->> 'identifier_hash')
+		  FROM `+pgIdent(schema, "audit_log")+`
+		 WHERE action = 'auth.login.rate_limited'
+		   AND created_at >= $1
+		   AND meta ->> 'throttle' = 'identifier'
+		   AND created_at + make_interval(secs => (meta ->> 'retry_after_s')::double precision) > $2
+	`, since, now).Scan(&n)
+	return n, err
+}
@@ -0,0 +1,84 @@
+package authapi
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestAuthStats_TakeAndRestore(t *testing.T) {
+	var s authStats
+	s.add(statLoginFailedPrefix+"bad_password", 1)
+	s.add(statLoginFailedPrefix+"bad_password", 2)
+	s.add(statCaptchaFailed, 1)
+
+	got := s.take()
+	if got[statLoginFailedPrefix+"bad_password"] != 3 || got[statCaptchaFailed] != 1 {
+		t.Fatalf("unexpected counters %v", got)
+	}
+	if len(s.take()) != 0 {
+		t.Fatalf("expected take to reset the counters")
+	}
+
+	// A failed flush puts counters back on top of new increments.
+	s.add(statCaptchaFailed, 1)
+	s.restore(got)
+	if snap := s.snapshot(); snap[statCaptchaFailed] != 2 || snap[statLoginFailedPrefix+"bad_password"] != 3 {
+		t.Fatalf("unexpected counters after restore %v", snap)
+	}
+}
+
+func TestBuildAuthStatsResponse(t *testing.T) {
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	var since [len(authStatsWindows)]time.Time
+	for i, d := range authStatsWindows {
+		since[i] = now.Add(-d)
+	}
+	sums := map[string]windowCounts{
+		statLoginFailedPrefix + "bad_password": {2, 5, 40},
+		statLoginFailedPrefix + "not_found":    {0, 1, 3},
+		statCaptchaIssued:                      {1, 1, 2},
+		statRefreshReuse:                       {0, 0, 1},
+	}
+
+	out := buildAuthStatsResponse(now, since, sums, 4)
+	if out.LockoutsActive != 4 || len(out.Windows) != 3 {
+		t.Fatalf("unexpected response %+v", out)
+	}
+	want := []string{"15m", "1h", "24h"}
+	for i, w := range out.Windows {
+		if w.Window != want[i] || !w.Since.Equal(since[i]) {
+			t.Fatalf("window %d: got %q since %v", i, w.Window, w.Since)
+		}
+	}
+	short := out.Windows[0]
+	if short.FailedLoginsTotal != 2 || len(short.FailedLogins) != 1 || short.FailedLogins["bad_password"] != 2 {
+		t.Fatalf("unexpected 15m failed logins %+v", short)
+	}
+	day := out.Windows[2]
+	if day.FailedLoginsTotal != 43 || day.FailedLogins["not_found"] != 3 || day.CaptchaIssued != 2 || day.RefreshReuseDetected != 1 {
+		t.Fatalf("unexpected 24h stats %+v", day)
+	}
+}
+
+type failingCaptcha struct{}
+
+func (failingCaptcha) Verify(context.Context, string, net.IP) error { return errors.New("rejected") }
+
+func TestEnforceCaptcha_CountsChallenges(t *testing.T) {
+	h := &Handler{cfg: Config{EnableCaptcha: true}, captcha: failingCaptcha{}}
+	ctx := context.Background()
+
+	if err := h.enforceCaptcha(ctx, "", nil); !errors.Is(err, ErrCaptchaRequired) {
+		t.Fatalf("expected ErrCaptchaRequired, got %v", err)
+	}
+	if err := h.enforceCaptcha(ctx, "token", nil); !errors.Is(err, ErrCaptchaInvalid) {
+		t.Fatalf("expected ErrCaptchaInvalid, got %v", err)
+	}
+	snap := h.stats.snapshot()
+	if snap[statCaptchaIssued] != 1 || snap[statCaptchaFailed] != 1 {
+		t.Fatalf("unexpected captcha counters %v", snap)
+	}
+}
@@ -0,0 +1,100 @@
+package authapi
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"arc/cmd/internal/auth/session"
+)
+
+// loginBindingKey parses the optional client binding key of a login request.
+// It writes the error response and returns false when the key is invalid or
+// missing on a platform that requires binding.
+func (h *Handler) loginBindingKey(w http.ResponseWriter, raw string, platform session.Platform) (ed25519.PublicKey, bool) {
+	mode := h.sessions.BindingMode(platform)
+	if strings.TrimSpace(raw) == "" {
+		if mode == session.BindingRequired {
+			writeError(w, http.StatusBadRequest, "binding_required", "binding_key is required for this platform")
+			return nil, false
+		}
+		return nil, true
+	}
+	if mode == session.BindingOff {
+		return nil, true
+	}
+	key, err := session.ParseBindingKey(raw)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "invalid binding_key")
+		return nil, false
+	}
+	return key, true
+}
+
+// handleRefreshNonce reissues the binding nonce of a bound session.
+//
+// Clients normally sign the nonce returned with their last login or refresh; this
+// endpoint covers clients that lost it (e.g. an app restart between refreshes).
+func (h *Handler) handleRefreshNonce(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.dbEnabled {
+		writeError(w, http.StatusServiceUnavailable, "db_unavailable", "database not configured")
+		return
+	}
+
+	var req refreshNonceRequest
+	if r.ContentLength != 0 {
+		if err := decodeJSON(w, r, h.cfg.MaxBodyBytes, &req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_json", "invalid request body")
+			return
+		}
+	}
+	refreshToken := strings.TrimSpace(req.RefreshToken)
+	if refreshToken == "" {
+		refreshToken, _ = h.refreshTokenFromCookie(r)
+	}
+	if refreshToken == "" {
+		writeError(w, http.StatusBadRequest, "invalid_request", "refresh_token is required")
+		return
+	}
+
+	nonce, err := h.sessions.ReissueBindingNonce(r.Context(), time.Now().UTC(), refreshToken)
+	if err != nil {
+		if errors.Is(err, session.ErrSessionNotFound) {
+			writeError(w, http.StatusUnauthorized, "session_not_active", "session not active")
+			return
+		}
+		h.log.Error("auth.refresh.nonce.fail", "err", err)
+		writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, refreshNonceResponse{BindingNonce: nonce})
+}
+
+// decodeBindingProof decodes an optional base64url binding proof, writing a 400 when malformed.
+func decodeBindingProof(w http.ResponseWriter, raw string) ([]byte, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, true
+	}
+	b, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "invalid binding_proof")
+		return nil, false
+	}
+	return b, true
+}
+
+func bytesOrNil(b []byte) any {
+	if len(b) == 0 {
+		return nil
+	}
+	return b
+}
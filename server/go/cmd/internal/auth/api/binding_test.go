@@ -0,0 +1,54 @@
+package authapi
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"arc/cmd/internal/auth/session"
+)
+
+func TestLoginBindingKey(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(pub)
+
+	sessCfg := session.DefaultConfig()
+	sessCfg.RefreshBinding = map[session.Platform]session.BindingMode{
+		session.PlatformIOS:     session.BindingRequired,
+		session.PlatformAndroid: session.BindingOptional,
+	}
+	h := &Handler{sessions: session.NewService(sessCfg, nil, nil, nil)}
+
+	tests := []struct {
+		name     string
+		raw      string
+		platform session.Platform
+		wantOK   bool
+		wantKey  bool
+		wantCode int
+	}{
+		{name: "required missing", platform: session.PlatformIOS, wantCode: http.StatusBadRequest},
+		{name: "required present", raw: encoded, platform: session.PlatformIOS, wantOK: true, wantKey: true},
+		{name: "optional missing", platform: session.PlatformAndroid, wantOK: true},
+		{name: "optional invalid", raw: "bad", platform: session.PlatformAndroid, wantCode: http.StatusBadRequest},
+		{name: "off ignores key", raw: encoded, platform: session.PlatformWeb, wantOK: true},
+	}
+	for _, tc := range tests {
+		rr := httptest.NewRecorder()
+		key, ok := h.loginBindingKey(rr, tc.raw, tc.platform)
+		if ok != tc.wantOK {
+			t.Fatalf("%s: expected ok=%v, got %v", tc.name, tc.wantOK, ok)
+		}
+		if (key != nil) != tc.wantKey {
+			t.Fatalf("%s: expected key=%v, got %v", tc.name, tc.wantKey, key != nil)
+		}
+		if !ok && rr.Code != tc.wantCode {
+			t.Fatalf("%s: expected status %d, got %d", tc.name, tc.wantCode, rr.Code)
+		}
+	}
+}
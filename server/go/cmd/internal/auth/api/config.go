@@ -2,10 +2,14 @@ package authapi
 
 import (
 	"net/http"
-	"os"
+	"net/netip"
 	"strconv"
 	"strings"
 	"time"
+
+	"arc/cmd/internal/config"
+	"arc/cmd/internal/invite"
+	"arc/cmd/internal/ipaccess"
 )
 
 // Config controls auth API behavior and security defaults.
@@ -20,6 +24,14 @@ type Config struct {
 	RequireEmailVerified bool
 	EnableCaptcha        bool
 
+	// Forwarding headers are honored only with TrustProxy. TrustedProxyCIDRs limits the
+	// peers allowed to set them (empty trusts any peer); ProxyHeader selects
+	// X-Forwarded-For or RFC 7239 Forwarded; ProxyHops, when positive, is the number
+	// of proxies in front of the server. See ipaccess.ClientIP.
+	TrustedProxyCIDRs []netip.Prefix
+	ProxyHeader       string
+	ProxyHops         int
+
 	// Optional web transport mode:
 	// refresh token in HttpOnly cookie + CSRF double-submit enforcement on refresh.
 	WebRefreshCookieEnabled bool
@@ -31,6 +43,13 @@ type Config struct {
 	CookieDomain            string
 	CookiePath              string
 
+	// WebAccessCookieEnabled (BFF mode, requires WebRefreshCookieEnabled) also delivers
+	// the access token to web clients as an HttpOnly cookie that expires with it, and
+	// lets requireAuth accept that cookie in place of a bearer token. Mutating requests
+	// authenticated by the cookie must pass the CSRF double-submit check.
+	WebAccessCookieEnabled bool
+	AccessCookieName       string
+
 	LoginIPMax    int
 	LoginIPWindow time.Duration
 
@@ -43,38 +62,186 @@ type Config struct {
 	LockoutLongDuration    time.Duration
 	LockoutSevereThreshold int
 	LockoutSevereDuration  time.Duration
+
+	// Risk-based login challenge (unrecognized device -> emailed code).
+	LoginChallengeEnabled     bool
+	LoginChallengeTTL         time.Duration
+	LoginChallengeMaxAttempts int
+
+	// Phone login (POST /auth/otp/request, /auth/otp/verify) and phone linking (/me/phone).
+	// Codes expire after PhoneOTPTTL and allow PhoneOTPMaxAttempts guesses. At most
+	// PhoneOTPPhoneMax codes per number and PhoneOTPIPMax per client IP are sent within
+	// PhoneOTPWindow. PhoneOTPEmailFallback emails a login code to the account's address
+	// when every SMS sender fails.
+	PhoneOTPEnabled       bool
+	PhoneOTPTTL           time.Duration
+	PhoneOTPMaxAttempts   int
+	PhoneOTPPhoneMax      int
+	PhoneOTPIPMax         int
+	PhoneOTPWindow        time.Duration
+	PhoneOTPEmailFallback bool
+
+	// Certificate pinning failure reports (POST /security/pin-report, unauthenticated).
+	PinReportIPMax    int
+	PinReportIPWindow time.Duration
+
+	// Privacy data exports (POST /me/export).
+	// PrivacyExportTTL bounds how long a finished archive and its download link stay valid;
+	// PrivacyExportMinInterval is the minimum spacing between two exports of one user.
+	PrivacyExportTTL         time.Duration
+	PrivacyExportMinInterval time.Duration
+
+	// AdminUserIDs lists user IDs allowed to call /admin/* endpoints.
+	// Empty means admin endpoints reject every caller.
+	AdminUserIDs []string
+
+	// Emailed invites (POST /auth/invites/send). InviteLinkTemplate builds the deep
+	// link; {token} and {invite_id} are replaced with their URL-escaped values.
+	// InviteSendDailyMax caps the invites one user may email per rolling 24 hours.
+	InviteLinkTemplate string
+	InviteSendDailyMax int
+
+	// Token exchange (POST /auth/token/exchange). TokenExchangeClients maps client IDs to
+	// lowercase SHA-256 hex digests of their secrets; empty disables the endpoint. Clients
+	// may request any audience in TokenExchangeAudiences and any scopes in
+	// TokenExchangeScopes. TokenExchangeTTL caps the delegated token's lifetime.
+	TokenExchangeClients   map[string]string
+	TokenExchangeAudiences []string
+	TokenExchangeScopes    []string
+	TokenExchangeTTL       time.Duration
+
+	// AuthStatsFlushInterval is how often this instance writes its brute-force counters
+	// (failed logins, captcha, refresh reuse) to the audit log for GET /admin/stats/auth.
+	AuthStatsFlushInterval time.Duration
+
+	// The unversioned paths (/auth/login, /me, ...) are served next to /v1 with
+	// Deprecation and Link headers until DisableLegacyRoutes is set.
+	// LegacyRoutesDeprecatedAt is the date the Deprecation header announces.
+	// LegacyRoutesSunset, when set, is announced in a Sunset header as the date they
+	// stop working.
+	DisableLegacyRoutes      bool
+	LegacyRoutesDeprecatedAt time.Time
+	LegacyRoutesSunset       time.Time
+
+	// SignupEmailDomains restricts every signup, with or without an invite, to emails
+	// in these domains (exact match). Empty allows any address.
+	SignupEmailDomains []string
+}
+
+// DefaultConfig returns the auth config used when no ARC_AUTH_* variable is set.
+func DefaultConfig() Config {
+	return Config{
+		InviteOnly:                true,
+		InviteTTL:                 7 * 24 * time.Hour,
+		InviteMaxTTL:              30 * 24 * time.Hour,
+		InviteMaxUses:             1,
+		InviteMaxUsesMax:          50,
+		LegacyRoutesDeprecatedAt:  defaultLegacyRoutesDeprecatedAt,
+		InviteLinkTemplate:        defaultInviteLinkTemplate,
+		InviteSendDailyMax:        20,
+		ProxyHeader:               ipaccess.HeaderXForwardedFor,
+		MaxBodyBytes:              1 << 20, // 1 MiB
+		RefreshCookieName:         "arc_refresh_token",
+		CSRFCookieName:            "arc_csrf_token",
+		CSRFHeaderName:            "X-CSRF-Token",
+		CookieSecure:              true,
+		CookieSameSite:            http.SameSiteLaxMode,
+		CookiePath:                "/",
+		AccessCookieName:          "arc_access_token",
+		LoginIPMax:                20,
+		LoginIPWindow:             5 * time.Minute,
+		LoginUserMax:              5,
+		LoginUserWindow:           15 * time.Minute,
+		LockoutShortThreshold:     5,
+		LockoutShortDuration:      5 * time.Minute,
+		LockoutLongThreshold:      10,
+		LockoutLongDuration:       30 * time.Minute,
+		LockoutSevereThreshold:    20,
+		LockoutSevereDuration:     2 * time.Hour,
+		LoginChallengeTTL:         10 * time.Minute,
+		LoginChallengeMaxAttempts: 5,
+		PhoneOTPTTL:               5 * time.Minute,
+		PhoneOTPMaxAttempts:       5,
+		PhoneOTPPhoneMax:          5,
+		PhoneOTPIPMax:             20,
+		PhoneOTPWindow:            time.Hour,
+		PhoneOTPEmailFallback:     true,
+		PinReportIPMax:            10,
+		PinReportIPWindow:         time.Hour,
+		PrivacyExportTTL:          24 * time.Hour,
+		PrivacyExportMinInterval:  24 * time.Hour,
+		TokenExchangeTTL:          5 * time.Minute,
+		AuthStatsFlushInterval:    time.Minute,
+	}
 }
 
 // LoadConfigFromEnv loads auth config from environment variables with safe defaults.
 func LoadConfigFromEnv() Config {
+	return LoadConfigFrom(config.Env)
+}
+
+// LoadConfigFrom is LoadConfigFromEnv reading the settings through lookup.
+func LoadConfigFrom(lookup config.Lookup) Config {
+	d := DefaultConfig()
 	cfg := Config{
-		InviteOnly:              envBool("ARC_AUTH_INVITE_ONLY", true),
-		InviteTTL:               envDuration("ARC_AUTH_INVITE_TTL", 7*24*time.Hour),
-		InviteMaxTTL:            envDuration("ARC_AUTH_INVITE_TTL_MAX", 30*24*time.Hour),
-		InviteMaxUses:           envInt("ARC_AUTH_INVITE_MAX_USES", 1),
-		InviteMaxUsesMax:        envInt("ARC_AUTH_INVITE_MAX_USES_MAX", 50),
-		TrustProxy:              envBool("ARC_AUTH_TRUST_PROXY", false),
-		MaxBodyBytes:            envInt64("ARC_AUTH_MAX_BODY_BYTES", 1<<20), // 1 MiB
-		RequireEmailVerified:    envBool("ARC_AUTH_REQUIRE_EMAIL_VERIFIED", false),
-		EnableCaptcha:           envBool("ARC_AUTH_ENABLE_CAPTCHA", false),
-		WebRefreshCookieEnabled: envBool("ARC_AUTH_WEB_COOKIE_MODE", false),
-		RefreshCookieName:       envString("ARC_AUTH_REFRESH_COOKIE_NAME", "arc_refresh_token"),
-		CSRFCookieName:          envString("ARC_AUTH_CSRF_COOKIE_NAME", "arc_csrf_token"),
-		CSRFHeaderName:          envString("ARC_AUTH_CSRF_HEADER_NAME", "X-CSRF-Token"),
-		CookieSecure:            envBool("ARC_AUTH_COOKIE_SECURE", true),
-		CookieSameSite:          parseSameSite(envString("ARC_AUTH_COOKIE_SAMESITE", "lax")),
-		CookieDomain:            strings.TrimSpace(os.Getenv("ARC_AUTH_COOKIE_DOMAIN")),
-		CookiePath:              envString("ARC_AUTH_COOKIE_PATH", "/"),
-		LoginIPMax:              envInt("ARC_AUTH_LOGIN_IP_MAX", 20),
-		LoginIPWindow:           envDuration("ARC_AUTH_LOGIN_IP_WINDOW", 5*time.Minute),
-		LoginUserMax:            envInt("ARC_AUTH_LOGIN_USER_MAX", 5),
-		LoginUserWindow:         envDuration("ARC_AUTH_LOGIN_USER_WINDOW", 15*time.Minute),
-		LockoutShortThreshold:   envInt("ARC_AUTH_LOGIN_LOCKOUT_SHORT_THRESHOLD", 5),
-		LockoutShortDuration:    envDuration("ARC_AUTH_LOGIN_LOCKOUT_SHORT_DURATION", 5*time.Minute),
-		LockoutLongThreshold:    envInt("ARC_AUTH_LOGIN_LOCKOUT_LONG_THRESHOLD", 10),
-		LockoutLongDuration:     envDuration("ARC_AUTH_LOGIN_LOCKOUT_LONG_DURATION", 30*time.Minute),
-		LockoutSevereThreshold:  envInt("ARC_AUTH_LOGIN_LOCKOUT_SEVERE_THRESHOLD", 20),
-		LockoutSevereDuration:   envDuration("ARC_AUTH_LOGIN_LOCKOUT_SEVERE_DURATION", 2*time.Hour),
+		InviteOnly:                envBool(lookup, "ARC_AUTH_INVITE_ONLY", d.InviteOnly),
+		InviteTTL:                 envDuration(lookup, "ARC_AUTH_INVITE_TTL", d.InviteTTL),
+		InviteMaxTTL:              envDuration(lookup, "ARC_AUTH_INVITE_TTL_MAX", d.InviteMaxTTL),
+		InviteMaxUses:             envInt(lookup, "ARC_AUTH_INVITE_MAX_USES", d.InviteMaxUses),
+		InviteMaxUsesMax:          envInt(lookup, "ARC_AUTH_INVITE_MAX_USES_MAX", d.InviteMaxUsesMax),
+		InviteLinkTemplate:        envString(lookup, "ARC_AUTH_INVITE_LINK_TEMPLATE", d.InviteLinkTemplate),
+		InviteSendDailyMax:        envInt(lookup, "ARC_AUTH_INVITE_SEND_DAILY_MAX", d.InviteSendDailyMax),
+		TrustProxy:                envBool(lookup, "ARC_AUTH_TRUST_PROXY", d.TrustProxy),
+		TrustedProxyCIDRs:         parseProxyCIDRs(envCSV(lookup, "ARC_AUTH_TRUSTED_PROXY_CIDRS")),
+		ProxyHeader:               strings.ToLower(envString(lookup, "ARC_AUTH_PROXY_HEADER", d.ProxyHeader)),
+		ProxyHops:                 envInt(lookup, "ARC_AUTH_PROXY_HOPS", d.ProxyHops),
+		DisableLegacyRoutes:       envBool(lookup, "ARC_AUTH_DISABLE_LEGACY_ROUTES", d.DisableLegacyRoutes),
+		LegacyRoutesDeprecatedAt:  envTime(lookup, "ARC_AUTH_LEGACY_ROUTES_DEPRECATED_AT", d.LegacyRoutesDeprecatedAt),
+		LegacyRoutesSunset:        envTime(lookup, "ARC_AUTH_LEGACY_ROUTES_SUNSET", d.LegacyRoutesSunset),
+		MaxBodyBytes:              envInt64(lookup, "ARC_AUTH_MAX_BODY_BYTES", d.MaxBodyBytes), // 1 MiB
+		RequireEmailVerified:      envBool(lookup, "ARC_AUTH_REQUIRE_EMAIL_VERIFIED", d.RequireEmailVerified),
+		EnableCaptcha:             envBool(lookup, "ARC_AUTH_ENABLE_CAPTCHA", d.EnableCaptcha),
+		WebRefreshCookieEnabled:   envBool(lookup, "ARC_AUTH_WEB_COOKIE_MODE", d.WebRefreshCookieEnabled),
+		RefreshCookieName:         envString(lookup, "ARC_AUTH_REFRESH_COOKIE_NAME", d.RefreshCookieName),
+		CSRFCookieName:            envString(lookup, "ARC_AUTH_CSRF_COOKIE_NAME", d.CSRFCookieName),
+		CSRFHeaderName:            envString(lookup, "ARC_AUTH_CSRF_HEADER_NAME", d.CSRFHeaderName),
+		CookieSecure:              envBool(lookup, "ARC_AUTH_COOKIE_SECURE", d.CookieSecure),
+		CookieSameSite:            parseSameSite(envString(lookup, "ARC_AUTH_COOKIE_SAMESITE", "lax")),
+		CookieDomain:              strings.TrimSpace(lookup.Get("ARC_AUTH_COOKIE_DOMAIN")),
+		CookiePath:                envString(lookup, "ARC_AUTH_COOKIE_PATH", d.CookiePath),
+		WebAccessCookieEnabled:    envBool(lookup, "ARC_AUTH_WEB_ACCESS_COOKIE", d.WebAccessCookieEnabled),
+		AccessCookieName:          envString(lookup, "ARC_AUTH_ACCESS_COOKIE_NAME", d.AccessCookieName),
+		LoginIPMax:                envInt(lookup, "ARC_AUTH_LOGIN_IP_MAX", d.LoginIPMax),
+		LoginIPWindow:             envDuration(lookup, "ARC_AUTH_LOGIN_IP_WINDOW", d.LoginIPWindow),
+		LoginUserMax:              envInt(lookup, "ARC_AUTH_LOGIN_USER_MAX", d.LoginUserMax),
+		LoginUserWindow:           envDuration(lookup, "ARC_AUTH_LOGIN_USER_WINDOW", d.LoginUserWindow),
+		LockoutShortThreshold:     envInt(lookup, "ARC_AUTH_LOGIN_LOCKOUT_SHORT_THRESHOLD", d.LockoutShortThreshold),
+		LockoutShortDuration:      envDuration(lookup, "ARC_AUTH_LOGIN_LOCKOUT_SHORT_DURATION", d.LockoutShortDuration),
+		LockoutLongThreshold:      envInt(lookup, "ARC_AUTH_LOGIN_LOCKOUT_LONG_THRESHOLD", d.LockoutLongThreshold),
+		LockoutLongDuration:       envDuration(lookup, "ARC_AUTH_LOGIN_LOCKOUT_LONG_DURATION", d.LockoutLongDuration),
+		LockoutSevereThreshold:    envInt(lookup, "ARC_AUTH_LOGIN_LOCKOUT_SEVERE_THRESHOLD", d.LockoutSevereThreshold),
+		LockoutSevereDuration:     envDuration(lookup, "ARC_AUTH_LOGIN_LOCKOUT_SEVERE_DURATION", d.LockoutSevereDuration),
+		LoginChallengeEnabled:     envBool(lookup, "ARC_AUTH_LOGIN_CHALLENGE_ENABLED", d.LoginChallengeEnabled),
+		LoginChallengeTTL:         envDuration(lookup, "ARC_AUTH_LOGIN_CHALLENGE_TTL", d.LoginChallengeTTL),
+		LoginChallengeMaxAttempts: envInt(lookup, "ARC_AUTH_LOGIN_CHALLENGE_MAX_ATTEMPTS", d.LoginChallengeMaxAttempts),
+		PhoneOTPEnabled:           envBool(lookup, "ARC_AUTH_PHONE_OTP_ENABLED", d.PhoneOTPEnabled),
+		PhoneOTPTTL:               envDuration(lookup, "ARC_AUTH_PHONE_OTP_TTL", d.PhoneOTPTTL),
+		PhoneOTPMaxAttempts:       envInt(lookup, "ARC_AUTH_PHONE_OTP_MAX_ATTEMPTS", d.PhoneOTPMaxAttempts),
+		PhoneOTPPhoneMax:          envInt(lookup, "ARC_AUTH_PHONE_OTP_PHONE_MAX", d.PhoneOTPPhoneMax),
+		PhoneOTPIPMax:             envInt(lookup, "ARC_AUTH_PHONE_OTP_IP_MAX", d.PhoneOTPIPMax),
+		PhoneOTPWindow:            envDuration(lookup, "ARC_AUTH_PHONE_OTP_WINDOW", d.PhoneOTPWindow),
+		PhoneOTPEmailFallback:     envBool(lookup, "ARC_AUTH_PHONE_OTP_EMAIL_FALLBACK", d.PhoneOTPEmailFallback),
+		PinReportIPMax:            envInt(lookup, "ARC_SECURITY_PIN_REPORT_IP_MAX", d.PinReportIPMax),
+		PinReportIPWindow:         envDuration(lookup, "ARC_SECURITY_PIN_REPORT_IP_WINDOW", d.PinReportIPWindow),
+		PrivacyExportTTL:          envDuration(lookup, "ARC_PRIVACY_EXPORT_TTL", d.PrivacyExportTTL),
+		PrivacyExportMinInterval:  envDuration(lookup, "ARC_PRIVACY_EXPORT_MIN_INTERVAL", d.PrivacyExportMinInterval),
+		AdminUserIDs:              envCSV(lookup, "ARC_AUTH_ADMIN_USER_IDS"),
+		SignupEmailDomains:        envCSV(lookup, "ARC_AUTH_SIGNUP_EMAIL_DOMAINS"),
+		TokenExchangeClients:      parseExchangeClients(envCSV(lookup, "ARC_AUTH_TOKEN_EXCHANGE_CLIENTS")),
+		TokenExchangeAudiences:    envCSV(lookup, "ARC_AUTH_TOKEN_EXCHANGE_AUDIENCES"),
+		TokenExchangeScopes:       envCSV(lookup, "ARC_AUTH_TOKEN_EXCHANGE_SCOPES"),
+		TokenExchangeTTL:          envDuration(lookup, "ARC_AUTH_TOKEN_EXCHANGE_TTL", d.TokenExchangeTTL),
+		AuthStatsFlushInterval:    envDuration(lookup, "ARC_AUTH_STATS_FLUSH_INTERVAL", d.AuthStatsFlushInterval),
 	}
 
 	// Clamp TTLs to keep them sensible.
@@ -97,9 +264,29 @@ func LoadConfigFromEnv() Config {
 		cfg.InviteMaxUses = cfg.InviteMaxUsesMax
 	}
 
+	if !strings.Contains(cfg.InviteLinkTemplate, "{token}") {
+		cfg.InviteLinkTemplate = defaultInviteLinkTemplate
+	}
+	if cfg.InviteSendDailyMax <= 0 {
+		cfg.InviteSendDailyMax = 20
+	}
+	for i, d := range cfg.SignupEmailDomains {
+		cfg.SignupEmailDomains[i] = invite.NormalizeEmailDomain(d)
+	}
+
+	if cfg.TokenExchangeTTL <= 0 {
+		cfg.TokenExchangeTTL = 5 * time.Minute
+	}
+	if cfg.AuthStatsFlushInterval <= 0 {
+		cfg.AuthStatsFlushInterval = time.Minute
+	}
+
 	if cfg.MaxBodyBytes <= 0 {
 		cfg.MaxBodyBytes = 1 << 20
 	}
+	if cfg.ProxyHeader != ipaccess.HeaderForwarded {
+		cfg.ProxyHeader = ipaccess.HeaderXForwardedFor
+	}
 	if strings.TrimSpace(cfg.RefreshCookieName) == "" {
 		cfg.RefreshCookieName = "arc_refresh_token"
 	}
@@ -115,6 +302,13 @@ func LoadConfigFromEnv() Config {
 	if cfg.CSRFCookieName == cfg.RefreshCookieName {
 		cfg.CSRFCookieName = "arc_csrf_token"
 	}
+	if strings.TrimSpace(cfg.AccessCookieName) == "" ||
+		cfg.AccessCookieName == cfg.RefreshCookieName || cfg.AccessCookieName == cfg.CSRFCookieName {
+		cfg.AccessCookieName = "arc_access_token"
+	}
+	if !cfg.WebRefreshCookieEnabled {
+		cfg.WebAccessCookieEnabled = false
+	}
 	// SameSite=None cookies are ignored by modern browsers unless Secure=true.
 	if cfg.CookieSameSite == http.SameSiteNoneMode {
 		cfg.CookieSecure = true
@@ -125,12 +319,67 @@ func LoadConfigFromEnv() Config {
 	if cfg.LoginUserMax <= 0 {
 		cfg.LoginUserMax = 5
 	}
+	if cfg.LoginChallengeTTL <= 0 {
+		cfg.LoginChallengeTTL = 10 * time.Minute
+	}
+	if cfg.LoginChallengeMaxAttempts <= 0 {
+		cfg.LoginChallengeMaxAttempts = 5
+	}
+	if cfg.PhoneOTPTTL <= 0 {
+		cfg.PhoneOTPTTL = 5 * time.Minute
+	}
+	if cfg.PhoneOTPTTL > time.Hour {
+		cfg.PhoneOTPTTL = time.Hour
+	}
+	if cfg.PhoneOTPWindow <= 0 {
+		cfg.PhoneOTPWindow = time.Hour
+	}
+	if cfg.PinReportIPMax <= 0 {
+		cfg.PinReportIPMax = 10
+	}
+	if cfg.PinReportIPWindow <= 0 {
+		cfg.PinReportIPWindow = time.Hour
+	}
+	if cfg.PrivacyExportTTL <= 0 {
+		cfg.PrivacyExportTTL = 24 * time.Hour
+	}
+	if cfg.PrivacyExportTTL > 7*24*time.Hour {
+		cfg.PrivacyExportTTL = 7 * 24 * time.Hour
+	}
+	if cfg.PrivacyExportMinInterval <= 0 {
+		cfg.PrivacyExportMinInterval = 24 * time.Hour
+	}
 
 	return cfg
 }
 
-func envBool(key string, def bool) bool {
-	v := strings.TrimSpace(os.Getenv(key))
+// Proxy returns the forwarding-header settings used to find the client address.
+func (c Config) Proxy() ipaccess.ProxyConfig {
+	return ipaccess.ProxyConfig{
+		Trust:        c.TrustProxy,
+		TrustedCIDRs: c.TrustedProxyCIDRs,
+		Header:       c.ProxyHeader,
+		Hops:         c.ProxyHops,
+	}
+}
+
+// parseProxyCIDRs reads prefixes or single addresses, dropping malformed entries
+// (a dropped proxy is simply not trusted).
+func parseProxyCIDRs(entries []string) []netip.Prefix {
+	var out []netip.Prefix
+	for _, e := range entries {
+		if p, err := netip.ParsePrefix(e); err == nil {
+			out = append(out, p.Masked())
+		} else if a, err := netip.ParseAddr(e); err == nil {
+			a = a.Unmap()
+			out = append(out, netip.PrefixFrom(a, a.BitLen()))
+		}
+	}
+	return out
+}
+
+func envBool(lookup config.Lookup, key string, def bool) bool {
+	v := strings.TrimSpace(lookup.Get(key))
 	if v == "" {
 		return def
 	}
@@ -141,8 +390,8 @@ func envBool(key string, def bool) bool {
 	return b
 }
 
-func envInt(key string, def int) int {
-	v := strings.TrimSpace(os.Getenv(key))
+func envInt(lookup config.Lookup, key string, def int) int {
+	v := strings.TrimSpace(lookup.Get(key))
 	if v == "" {
 		return def
 	}
@@ -153,8 +402,8 @@ func envInt(key string, def int) int {
 	return n
 }
 
-func envInt64(key string, def int64) int64 {
-	v := strings.TrimSpace(os.Getenv(key))
+func envInt64(lookup config.Lookup, key string, def int64) int64 {
+	v := strings.TrimSpace(lookup.Get(key))
 	if v == "" {
 		return def
 	}
@@ -165,8 +414,8 @@ func envInt64(key string, def int64) int64 {
 	return n
 }
 
-func envDuration(key string, def time.Duration) time.Duration {
-	v := strings.TrimSpace(os.Getenv(key))
+func envDuration(lookup config.Lookup, key string, def time.Duration) time.Duration {
+	v := strings.TrimSpace(lookup.Get(key))
 	if v == "" {
 		return def
 	}
@@ -177,14 +426,49 @@ func envDuration(key string, def time.Duration) time.Duration {
 	return d
 }
 
-func envString(key, def string) string {
-	v := strings.TrimSpace(os.Getenv(key))
+// envTime parses an RFC 3339 timestamp, returning the zero time when unset or invalid.
+func envTime(lookup config.Lookup, key string, def time.Time) time.Time {
+	v := strings.TrimSpace(lookup.Get(key))
+	if v == "" {
+		return def
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return def
+	}
+	return t.UTC()
+}
+
+func envString(lookup config.Lookup, key, def string) string {
+	v := strings.TrimSpace(lookup.Get(key))
 	if v == "" {
 		return def
 	}
 	return v
 }
 
+func envCSV(lookup config.Lookup, key string) []string {
+	raw := strings.TrimSpace(lookup.Get(key))
+	if raw == "" {
+		return nil
+	}
+	parts := strings.Split(raw, ",")
+	out := make([]string, 0, len(parts))
+	seen := make(map[string]struct{}, len(parts))
+	for _, p := range parts {
+		v := strings.TrimSpace(p)
+		if v == "" {
+			continue
+		}
+		if _, ok := seen[v]; ok {
+			continue
+		}
+		seen[v] = struct{}{}
+		out = append(out, v)
+	}
+	return out
+}
+
 func parseSameSite(v string) http.SameSite {
 	switch strings.ToLower(strings.TrimSpace(v)) {
 	case "strict":
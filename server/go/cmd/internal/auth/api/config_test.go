@@ -1,8 +1,16 @@
 package authapi
 
 import (
+	"io"
+	"log/slog"
 	"net/http"
+	"reflect"
 	"testing"
+
+	"arc/cmd/internal/auth/session"
+	"arc/cmd/internal/config"
+	"arc/cmd/internal/ipaccess"
+	"arc/cmd/internal/tenant"
 )
 
 func TestLoadConfigFromEnv_CookieGuardrails(t *testing.T) {
@@ -24,6 +32,50 @@ func TestLoadConfigFromEnv_CookieGuardrails(t *testing.T) {
 	}
 }
 
+func TestLoadConfigFromEnv_AccessCookie(t *testing.T) {
+	t.Setenv("ARC_AUTH_WEB_ACCESS_COOKIE", "true")
+	t.Setenv("ARC_AUTH_ACCESS_COOKIE_NAME", "arc_refresh_token")
+
+	if cfg := LoadConfigFromEnv(); cfg.WebAccessCookieEnabled {
+		t.Fatalf("access cookie mode must require web cookie mode")
+	}
+
+	t.Setenv("ARC_AUTH_WEB_COOKIE_MODE", "true")
+	cfg := LoadConfigFromEnv()
+	if !cfg.WebAccessCookieEnabled {
+		t.Fatalf("expected access cookie mode enabled")
+	}
+	if cfg.AccessCookieName == cfg.RefreshCookieName || cfg.AccessCookieName == cfg.CSRFCookieName {
+		t.Fatalf("access cookie name must differ from the other cookies, got %q", cfg.AccessCookieName)
+	}
+}
+
+func TestLoadConfigFromEnv_Proxy(t *testing.T) {
+	t.Setenv("ARC_AUTH_TRUST_PROXY", "true")
+	t.Setenv("ARC_AUTH_TRUSTED_PROXY_CIDRS", "10.0.0.0/8, 192.0.2.7, not-a-cidr, 2001:db8::1/48")
+	t.Setenv("ARC_AUTH_PROXY_HEADER", "Forwarded")
+	t.Setenv("ARC_AUTH_PROXY_HOPS", "-1")
+
+	proxy := LoadConfigFromEnv().Proxy()
+	if !proxy.Trust || proxy.Header != ipaccess.HeaderForwarded || proxy.Hops != 0 {
+		t.Fatalf("unexpected proxy config %+v", proxy)
+	}
+	want := []string{"10.0.0.0/8", "192.0.2.7/32", "2001:db8::/48"}
+	if len(proxy.TrustedCIDRs) != len(want) {
+		t.Fatalf("got %v, want %v", proxy.TrustedCIDRs, want)
+	}
+	for i, p := range proxy.TrustedCIDRs {
+		if p.String() != want[i] {
+			t.Fatalf("got %v, want %v", proxy.TrustedCIDRs, want)
+		}
+	}
+
+	t.Setenv("ARC_AUTH_PROXY_HEADER", "x-real-ip")
+	if cfg := LoadConfigFromEnv(); cfg.ProxyHeader != ipaccess.HeaderXForwardedFor {
+		t.Fatalf("expected unknown header to fall back to x-forwarded-for, got %q", cfg.ProxyHeader)
+	}
+}
+
 func TestParseSameSite(t *testing.T) {
 	tests := []struct {
 		in   string
@@ -57,3 +109,60 @@ func TestLoadConfigFromEnv_EmailAndCaptchaFlags(t *testing.T) {
 		t.Fatalf("expected EnableCaptcha=true")
 	}
 }
+
+func TestReloadConfig_Tunables(t *testing.T) {
+	t.Setenv("ARC_AUTH_ENABLE_CAPTCHA", "true")
+	t.Setenv("ARC_AUTH_LOGIN_IP_MAX", "7")
+	t.Setenv("ARC_AUTH_INVITE_ONLY", "false")
+
+	h := &Handler{log: slog.New(slog.NewTextHandler(io.Discard, nil)), cfg: Config{InviteOnly: true, LoginIPMax: 3}, lookup: config.Env}
+	h.reloadConfig([]string{"ARC_AUTH_ENABLE_CAPTCHA", "ARC_AUTH_LOGIN_IP_MAX"})
+
+	got := h.config()
+	if !got.EnableCaptcha || got.LoginIPMax != 7 {
+		t.Fatalf("tunables not applied: captcha=%v login_ip_max=%d", got.EnableCaptcha, got.LoginIPMax)
+	}
+	if !got.InviteOnly {
+		t.Fatalf("non-tunable setting changed")
+	}
+}
+
+func TestReloadConfig_TenantOverrides(t *testing.T) {
+	t.Setenv("ARC_AUTH_LOGIN_IP_MAX", "7")
+	t.Setenv("ARC_AUTH_LOGIN_USER_MAX", "5")
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	newHandler := func(tn tenant.Tenant) *Handler {
+		h, err := NewHandler(log, nil, LoadConfigFrom(tn.Lookup()), session.Config{}, false, WithConfigLookup(tn.Lookup()))
+		if err != nil {
+			t.Fatalf("NewHandler: %v", err)
+		}
+		t.Cleanup(h.Close)
+		return h
+	}
+	acme := newHandler(tenant.Tenant{ID: "acme", Config: map[string]string{"ARC_AUTH_LOGIN_IP_MAX": "2"}})
+	globex := newHandler(tenant.Tenant{ID: "globex", Config: map[string]string{"ARC_AUTH_LOGIN_USER_MAX": "9"}})
+
+	t.Setenv("ARC_AUTH_LOGIN_IP_MAX", "8")
+	t.Setenv("ARC_AUTH_LOGIN_USER_MAX", "6")
+	for _, h := range []*Handler{acme, globex} {
+		h.reloadConfig([]string{"ARC_AUTH_LOGIN_IP_MAX", "ARC_AUTH_LOGIN_USER_MAX"})
+	}
+
+	if got := acme.config(); got.LoginIPMax != 2 || got.LoginUserMax != 6 {
+		t.Fatalf("acme: login ip max = %d, login user max = %d", got.LoginIPMax, got.LoginUserMax)
+	}
+	if got := globex.config(); got.LoginIPMax != 8 || got.LoginUserMax != 9 {
+		t.Fatalf("globex: login ip max = %d, login user max = %d", got.LoginIPMax, got.LoginUserMax)
+	}
+}
+
+func TestLoadConfigFromEnv_DefaultsMatchDefaultConfig(t *testing.T) {
+	got, want := LoadConfigFromEnv(), DefaultConfig()
+	if len(got.TokenExchangeClients) == 0 {
+		got.TokenExchangeClients = nil
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("LoadConfigFromEnv() with no ARC_AUTH_* set = %+v, want %+v", got, want)
+	}
+}
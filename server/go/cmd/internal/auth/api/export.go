@@ -0,0 +1,438 @@
+package authapi
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/hmac"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"arc/cmd/internal/dbtrace"
+	"arc/cmd/internal/realtime"
+	"arc/cmd/security/token"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oklog/ulid/v2"
+)
+
+const (
+	privacyExportPending = "pending"
+	privacyExportReady   = "ready"
+	privacyExportFailed  = "failed"
+	privacyExportExpired = "expired"
+
+	privacyArchiveFormat = "arc-privacy-export-v1"
+
+	// privacyExportLinkKeyLabel derives the link signing key from ARC_TOKEN_HMAC_KEY,
+	// so a leaked download signature says nothing about refresh token hashes.
+	privacyExportLinkKeyLabel = "arc-privacy-export-link-v1"
+
+	privacyExportBuildTimeout    = 5 * time.Minute
+	privacyExportFinalizeTimeout = 10 * time.Second
+	privacyExportDownloadPath    = "/me/export/download"
+)
+
+var errPrivacyExportNotFound = errors.New("privacy export not found")
+
+// privacyExportLinkKey derives the download link signing key from the token HMAC key.
+// It returns nil when no key is configured, which disables exports.
+func privacyExportLinkKey() []byte {
+	key, err := token.HMACKeyFromEnv(32)
+	if err != nil {
+		return nil
+	}
+	return []byte(token.HashHMACSHA256Hex(privacyExportLinkKeyLabel, key))
+}
+
+// handleMeExport starts (POST) or reports (GET) the caller's privacy data export.
+//
+// The archive is assembled in the background; clients poll GET until the export is
+// ready and then fetch it through the signed, expiring download_url. Repeated POSTs
+// within PrivacyExportMinInterval return the existing export instead of rebuilding it.
+func (h *Handler) handleMeExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.dbEnabled {
+		writeError(w, http.StatusServiceUnavailable, "db_unavailable", "database not configured")
+		return
+	}
+	if len(h.exportKey) == 0 {
+		writeError(w, http.StatusServiceUnavailable, "export_unavailable", "privacy export not configured")
+		return
+	}
+
+	claims, ok := h.requireAuth(w, r)
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+	now := time.Now().UTC()
+
+	latest, err := latestPrivacyExport(ctx, h.pool, h.schema, claims.UserID)
+	if err != nil && !errors.Is(err, errPrivacyExportNotFound) {
+		h.log.Error("privacy.export.get.fail", "err", err)
+		writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+		return
+	}
+	found := err == nil
+
+	if r.Method == http.MethodGet {
+		if !found {
+			writeError(w, http.StatusNotFound, "not_found", "no export requested")
+			return
+		}
+		writeJSON(w, http.StatusOK, h.toPrivacyExportResponse(latest, now))
+		return
+	}
+
+	if found && latest.Status != privacyExportFailed && now.Sub(latest.CreatedAt) < h.cfg.PrivacyExportMinInterval {
+		writeJSON(w, http.StatusOK, h.toPrivacyExportResponse(latest, now))
+		return
+	}
+
+	ex := privacyExport{
+		ID:        ulid.Make().String(),
+		UserID:    claims.UserID,
+		Status:    privacyExportPending,
+		CreatedAt: now,
+	}
+	if err := insertPrivacyExport(ctx, h.pool, h.schema, ex); err != nil {
+		h.log.Error("privacy.export.insert.fail", "err", err)
+		writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+		return
+	}
+
+	h.auditPrivacyExportRequested(ctx, claims.UserID, claims.SessionID, h.clientIP(r), strings.TrimSpace(r.UserAgent()), ex.ID)
+
+	go h.runPrivacyExport(ex.ID, ex.UserID)
+
+	writeJSON(w, http.StatusAccepted, h.toPrivacyExportResponse(ex, now))
+}
+
+// handleMeExportDownload serves a ready archive to the holder of a valid signed link.
+//
+// The link itself is the credential (it is opened by a browser, without a bearer
+// token), so it is bound to the export ID and expiry and checked in constant time.
+func (h *Handler) handleMeExportDownload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.dbEnabled {
+		writeError(w, http.StatusServiceUnavailable, "db_unavailable", "database not configured")
+		return
+	}
+	if len(h.exportKey) == 0 {
+		writeError(w, http.StatusServiceUnavailable, "export_unavailable", "privacy export not configured")
+		return
+	}
+
+	q := r.URL.Query()
+	exportID := strings.TrimSpace(q.Get("id"))
+	now := time.Now().UTC()
+	if !verifyPrivacyExportLink(h.exportKey, exportID, q.Get("exp"), q.Get("sig"), now) {
+		writeError(w, http.StatusForbidden, "invalid_link", "download link is invalid or expired")
+		return
+	}
+
+	archive, err := loadPrivacyExportArchive(r.Context(), h.pool, h.schema, now, exportID)
+	if err != nil {
+		if errors.Is(err, errPrivacyExportNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "export not available")
+			return
+		}
+		h.log.Error("privacy.export.download.fail", "err", err)
+		writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", `attachment; filename="arc-export-`+exportID+`.json.gz"`)
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Length", strconv.Itoa(len(archive)))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(archive)
+}
+
+// runPrivacyExport builds and stores the archive for exportID. It runs detached from
+// the request, so it uses its own deadlines and records failures on the export row.
+func (h *Handler) runPrivacyExport(exportID string, userID string) {
+	buildCtx, cancel := context.WithTimeout(context.Background(), privacyExportBuildTimeout)
+	archive, buildErr := h.buildPrivacyArchive(buildCtx, userID, time.Now().UTC())
+	cancel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), privacyExportFinalizeTimeout)
+	defer cancel()
+	now := time.Now().UTC()
+
+	if buildErr != nil {
+		h.log.Error("privacy.export.build.fail", "err", buildErr, "export_id", exportID)
+		if err := failPrivacyExport(ctx, h.pool, h.schema, now, exportID, "archive build failed"); err != nil {
+			h.log.Error("privacy.export.fail_mark.fail", "err", err, "export_id", exportID)
+		}
+		return
+	}
+	if err := completePrivacyExport(ctx, h.pool, h.schema, now, exportID, archive, now.Add(h.cfg.PrivacyExportTTL)); err != nil {
+		h.log.Error("privacy.export.complete.fail", "err", err, "export_id", exportID)
+		return
+	}
+	// Opportunistic cleanup keeps expired archives from accumulating without a separate job.
+	if err := expirePrivacyExports(ctx, h.pool, h.schema, now); err != nil {
+		h.log.Error("privacy.export.expire.fail", "err", err)
+	}
+	h.log.Info("privacy.export.ready", "export_id", exportID, "bytes", len(archive))
+}
+
+// buildPrivacyArchive assembles the gzip-compressed JSON archive for userID.
+func (h *Handler) buildPrivacyArchive(ctx context.Context, userID string, now time.Time) ([]byte, error) {
+	u, err := h.identity.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := privacyArchive{
+		Format:      privacyArchiveFormat,
+		GeneratedAt: now,
+		User:        toUserResponse(u),
+		Messages:    []privacyArchiveMessage{},
+	}
+	if doc.Sessions, err = listPrivacyArchiveSessions(ctx, h.pool, h.schema, userID); err != nil {
+		return nil, err
+	}
+	if doc.AuditEvents, err = listPrivacyArchiveAuditEvents(ctx, h.pool, h.schema, userID); err != nil {
+		return nil, err
+	}
+	if h.messages != nil {
+		err := h.messages.ListMessagesByAuthor(ctx, userID, func(m realtime.StoredMessage) error {
+			doc.Messages = append(doc.Messages, privacyArchiveMessage{
+				ConversationID: m.ConversationID,
+				ServerMsgID:    m.ServerMsgID,
+				ClientMsgID:    m.ClientMsgID,
+				Seq:            m.Seq,
+				SessionID:      m.SenderSession,
+				Text:           m.Text,
+				ServerTS:       m.ServerTS,
+			})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	enc := json.NewEncoder(zw)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (h *Handler) toPrivacyExportResponse(ex privacyExport, now time.Time) privacyExportResponse {
+	out := privacyExportResponse{
+		ExportID:    ex.ID,
+		Status:      ex.Status,
+		CreatedAt:   ex.CreatedAt,
+		CompletedAt: ex.CompletedAt,
+		ExpiresAt:   ex.ExpiresAt,
+	}
+	if ex.Status == privacyExportReady && ex.ExpiresAt != nil {
+		if !now.Before(*ex.ExpiresAt) {
+			out.Status = privacyExportExpired
+			return out
+		}
+		out.DownloadURL = privacyExportDownloadURL(h.exportKey, ex.ID, *ex.ExpiresAt)
+	}
+	return out
+}
+
+// privacyExportDownloadURL returns the relative download link for exportID, valid until exp.
+func privacyExportDownloadURL(key []byte, exportID string, exp time.Time) string {
+	expRaw := strconv.FormatInt(exp.Unix(), 10)
+	q := url.Values{}
+	q.Set("id", exportID)
+	q.Set("exp", expRaw)
+	q.Set("sig", signPrivacyExportLink(key, exportID, expRaw))
+	return APIVersionPrefix + privacyExportDownloadPath + "?" + q.Encode()
+}
+
+func signPrivacyExportLink(key []byte, exportID string, expRaw string) string {
+	return token.HashHMACSHA256Hex(exportID+"|"+expRaw, key)
+}
+
+// verifyPrivacyExportLink checks the link signature and that it has not expired.
+func verifyPrivacyExportLink(key []byte, exportID string, expRaw string, sig string, now time.Time) bool {
+	if len(key) == 0 || exportID == "" || sig == "" {
+		return false
+	}
+	exp, err := strconv.ParseInt(expRaw, 10, 64)
+	if err != nil || !now.Before(time.Unix(exp, 0)) {
+		return false
+	}
+	want := signPrivacyExportLink(key, exportID, expRaw)
+	return hmac.Equal([]byte(want), []byte(strings.ToLower(sig)))
+}
+
+// ---- privacy export queries ----
+
+type privacyExport struct {
+	ID          string
+	UserID      string
+	Status      string
+	CreatedAt   time.Time
+	CompletedAt *time.Time
+	ExpiresAt   *time.Time
+}
+
+func insertPrivacyExport(ctx context.Context, pool *pgxpool.Pool, schema string, ex privacyExport) error {
+	ctx = dbtrace.WithOp(ctx, "authapi.insert_privacy_export")
+	_, err := pool.Exec(ctx, `
+		INSERT INTO `+pgIdent(schema, "privacy_exports")+` (id, user_id, status, created_at)
+		VALUES ($1, $2, $3, $4)
+	`, ex.ID, ex.UserID, ex.Status, ex.CreatedAt)
+	return err
+}
+
+func latestPrivacyExport(ctx context.Context, pool *pgxpool.Pool, schema string, userID string) (privacyExport, error) {
+	ctx = dbtrace.WithOp(ctx, "authapi.latest_privacy_export")
+	var ex privacyExport
+	err := pool.QueryRow(ctx, `
+		SELECT id, user_id, status, created_at, completed_at, expires_at
+		FROM `+pgIdent(schema, "privacy_exports")+`
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT 1
+	`, userID).Scan(&ex.ID, &ex.UserID, &ex.Status, &ex.CreatedAt, &ex.CompletedAt, &ex.ExpiresAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return privacyExport{}, errPrivacyExportNotFound
+	}
+	return ex, err
+}
+
+func completePrivacyExport(ctx context.Context, pool *pgxpool.Pool, schema string, now time.Time, exportID string, archive []byte, expiresAt time.Time) error {
+	ctx = dbtrace.WithOp(ctx, "authapi.complete_privacy_export")
+	_, err := pool.Exec(ctx, `
+		UPDATE `+pgIdent(schema, "privacy_exports")+`
+		SET status = 'ready', archive = $2, completed_at = $3, expires_at = $4
+		WHERE id = $1
+		  AND status = 'pending'
+	`, exportID, archive, now, expiresAt)
+	return err
+}
+
+func failPrivacyExport(ctx context.Context, pool *pgxpool.Pool, schema string, now time.Time, exportID string, reason string) error {
+	ctx = dbtrace.WithOp(ctx, "authapi.fail_privacy_export")
+	_, err := pool.Exec(ctx, `
+		UPDATE `+pgIdent(schema, "privacy_exports")+`
+		SET status = 'failed', completed_at = $2, error = $3
+		WHERE id = $1
+		  AND status = 'pending'
+	`, exportID, now, reason)
+	return err
+}
+
+// expirePrivacyExports drops the archives of exports whose download window has passed.
+func expirePrivacyExports(ctx context.Context, pool *pgxpool.Pool, schema string, now time.Time) error {
+	ctx = dbtrace.WithOp(ctx, "authapi.expire_privacy_exports")
+	_, err := pool.Exec(ctx, `
+		UPDATE `+pgIdent(schema, "privacy_exports")+`
+		SET status = 'expired', archive = NULL
+		WHERE status = 'ready'
+		  AND expires_at <= $1
+	`, now)
+	return err
+}
+
+func loadPrivacyExportArchive(ctx context.Context, pool *pgxpool.Pool, schema string, now time.Time, exportID string) ([]byte, error) {
+	ctx = dbtrace.WithOp(ctx, "authapi.load_privacy_export_archive")
+	var archive []byte
+	err := pool.QueryRow(ctx, `
+		SELECT archive
+		FROM `+pgIdent(schema, "privacy_exports")+`
+		WHERE id = $1
+		  AND status = 'ready'
+		  AND expires_at > $2
+		  AND archive IS NOT NULL
+	`, exportID, now).Scan(&archive)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, errPrivacyExportNotFound
+	}
+	return archive, err
+}
+
+func listPrivacyArchiveSessions(ctx context.Context, pool *pgxpool.Pool, schema string, userID string) ([]privacyArchiveSession, error) {
+	ctx = dbtrace.WithOp(ctx, "authapi.list_privacy_archive_sessions")
+	rows, err := pool.Query(ctx, `
+		SELECT id, platform, created_at, last_used_at, expires_at, revoked_at,
+			COALESCE(revocation_reason, ''), COALESCE(user_agent, ''), COALESCE(host(ip), '')
+		FROM `+pgIdent(schema, "sessions")+`
+		WHERE user_id = $1
+		ORDER BY created_at ASC, id ASC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []privacyArchiveSession{}
+	for rows.Next() {
+		var s privacyArchiveSession
+		if err := rows.Scan(&s.ID, &s.Platform, &s.CreatedAt, &s.LastUsedAt, &s.ExpiresAt, &s.RevokedAt, &s.RevocationReason, &s.UserAgent, &s.IP); err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func listPrivacyArchiveAuditEvents(ctx context.Context, pool *pgxpool.Pool, schema string, userID string) ([]privacyArchiveAuditEvent, error) {
+	ctx = dbtrace.WithOp(ctx, "authapi.list_privacy_archive_audit_events")
+	rows, err := pool.Query(ctx, `
+		SELECT action, created_at, COALESCE(session_id, ''), COALESCE(host(ip), ''), COALESCE(user_agent, ''), meta
+		FROM `+pgIdent(schema, "audit_log")+`
+		WHERE user_id = $1
+		ORDER BY created_at ASC, id ASC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []privacyArchiveAuditEvent{}
+	for rows.Next() {
+		var (
+			ev   privacyArchiveAuditEvent
+			meta []byte
+		)
+		if err := rows.Scan(&ev.Action, &ev.CreatedAt, &ev.SessionID, &ev.IP, &ev.UserAgent, &meta); err != nil {
+			return nil, err
+		}
+		if len(meta) > 0 {
+			if err := json.Unmarshal(meta, &ev.Meta); err != nil {
+				return nil, err
+			}
+		}
+		out = append(out, ev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
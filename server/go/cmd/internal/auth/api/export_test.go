@@ -0,0 +1,97 @@
+package authapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrivacyExportLink_RoundTrip(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	now := time.Unix(1_700_000_000, 0).UTC()
+	exp := now.Add(time.Hour)
+
+	link := privacyExportDownloadURL(key, "01HZXEXPORT0000000000000000", exp)
+	if !strings.HasPrefix(link, APIVersionPrefix+privacyExportDownloadPath+"?") {
+		t.Fatalf("unexpected link %q", link)
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		t.Fatalf("parse link: %v", err)
+	}
+	q := u.Query()
+
+	if !verifyPrivacyExportLink(key, q.Get("id"), q.Get("exp"), q.Get("sig"), now) {
+		t.Fatalf("expected link to verify")
+	}
+	if verifyPrivacyExportLink(key, q.Get("id"), q.Get("exp"), q.Get("sig"), exp) {
+		t.Fatalf("expected link to be expired at exp")
+	}
+	if verifyPrivacyExportLink([]byte("another-key-another-key-another-k"), q.Get("id"), q.Get("exp"), q.Get("sig"), now) {
+		t.Fatalf("expected link signed with another key to fail")
+	}
+	if verifyPrivacyExportLink(key, "01HZXOTHER00000000000000000", q.Get("exp"), q.Get("sig"), now) {
+		t.Fatalf("expected signature to be bound to the export id")
+	}
+	later := strconv.FormatInt(now.Add(48*time.Hour).Unix(), 10)
+	if verifyPrivacyExportLink(key, q.Get("id"), later, q.Get("sig"), now) {
+		t.Fatalf("expected extended expiry to fail")
+	}
+}
+
+func TestPrivacyExportLink_RejectsMissingParts(t *testing.T) {
+	now := time.Now()
+	exp := "9999999999"
+	if verifyPrivacyExportLink(nil, "id", exp, "sig", now) {
+		t.Fatalf("expected empty key to fail")
+	}
+	if verifyPrivacyExportLink([]byte("k"), "", exp, "sig", now) {
+		t.Fatalf("expected empty id to fail")
+	}
+	if verifyPrivacyExportLink([]byte("k"), "id", "not-a-number", signPrivacyExportLink([]byte("k"), "id", "not-a-number"), now) {
+		t.Fatalf("expected malformed expiry to fail")
+	}
+}
+
+func TestToPrivacyExportResponse_ReadyAndExpired(t *testing.T) {
+	h := &Handler{exportKey: []byte("0123456789abcdef0123456789abcdef")}
+	now := time.Now().UTC()
+	exp := now.Add(time.Hour)
+	ex := privacyExport{ID: "01HZXEXPORT0000000000000000", Status: privacyExportReady, CreatedAt: now, ExpiresAt: &exp}
+
+	resp := h.toPrivacyExportResponse(ex, now)
+	if resp.Status != privacyExportReady || resp.DownloadURL == "" {
+		t.Fatalf("expected ready export with download url, got %+v", resp)
+	}
+
+	resp = h.toPrivacyExportResponse(ex, exp)
+	if resp.Status != privacyExportExpired || resp.DownloadURL != "" {
+		t.Fatalf("expected expired export without download url, got %+v", resp)
+	}
+
+	ex.Status = privacyExportPending
+	ex.ExpiresAt = nil
+	if resp := h.toPrivacyExportResponse(ex, now); resp.DownloadURL != "" {
+		t.Fatalf("expected no download url while pending, got %q", resp.DownloadURL)
+	}
+}
+
+func TestHandleMeExport_DisabledWithoutKey(t *testing.T) {
+	h := &Handler{dbEnabled: true, cfg: Config{MaxBodyBytes: 1 << 20}}
+
+	rr := httptest.NewRecorder()
+	h.handleMeExport(rr, httptest.NewRequest(http.MethodPost, "/me/export", nil))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 without link key, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	h.handleMeExportDownload(rr, httptest.NewRequest(http.MethodGet, privacyExportDownloadPath+"?id=x&exp=1&sig=y", nil))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 without link key, got %d", rr.Code)
+	}
+}
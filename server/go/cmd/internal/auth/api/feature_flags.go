@@ -0,0 +1,147 @@
+package authapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"arc/cmd/internal/featureflags"
+)
+
+type featureFlagRequest struct {
+	Enabled *bool `json:"enabled"`
+}
+
+type featureFlagResponse struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Enabled     bool       `json:"enabled"`
+	Default     bool       `json:"default"`
+	Source      string     `json:"source"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+	UpdatedBy   string     `json:"updated_by,omitempty"`
+}
+
+type featureFlagsResponse struct {
+	Flags []featureFlagResponse `json:"flags"`
+}
+
+func toFeatureFlagResponse(st featureflags.State) featureFlagResponse {
+	resp := featureFlagResponse{
+		Name:        st.Name,
+		Description: st.Description,
+		Enabled:     st.Enabled,
+		Default:     st.Default,
+		Source:      st.Source,
+		UpdatedBy:   st.UpdatedBy,
+	}
+	if !st.UpdatedAt.IsZero() {
+		updatedAt := st.UpdatedAt
+		resp.UpdatedAt = &updatedAt
+	}
+	return resp
+}
+
+// inviteRequired reports whether signup needs an invite token. An auth.open_signup
+// value from config or an override wins over ARC_AUTH_INVITE_ONLY.
+func (h *Handler) inviteRequired(ctx context.Context) bool {
+	if st := h.flags.State(ctx, featureflags.AuthOpenSignup); st.Source != featureflags.SourceDefault {
+		return !st.Enabled
+	}
+	return h.cfg.InviteOnly
+}
+
+// handleAdminFeatureFlags serves GET /admin/feature-flags: every known flag with
+// its value and source.
+func (h *Handler) handleAdminFeatureFlags(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if h.flags == nil {
+		writeError(w, http.StatusNotFound, "not_found", "feature flags not available")
+		return
+	}
+	if _, ok := h.requireAdmin(w, r); !ok {
+		return
+	}
+
+	states := h.flags.List(r.Context())
+	resp := featureFlagsResponse{Flags: make([]featureFlagResponse, 0, len(states))}
+	for _, st := range states {
+		resp.Flags = append(resp.Flags, toFeatureFlagResponse(st))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleAdminFeatureFlag serves /admin/feature-flags/{name}: GET reads the flag,
+// PUT {"enabled": bool} stores an override and DELETE clears it. Overrides take
+// effect on this node at once and on others within the flag cache TTL.
+func (h *Handler) handleAdminFeatureFlag(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPut && r.Method != http.MethodDelete {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if h.flags == nil {
+		writeError(w, http.StatusNotFound, "not_found", "feature flags not available")
+		return
+	}
+
+	claims, ok := h.requireAdmin(w, r)
+	if !ok {
+		return
+	}
+	name := strings.ToLower(strings.TrimSpace(r.PathValue("name")))
+	if _, ok := featureflags.Lookup(name); !ok {
+		writeError(w, http.StatusNotFound, "unknown_flag", "unknown feature flag")
+		return
+	}
+
+	ctx := r.Context()
+	if r.Method == http.MethodGet {
+		writeJSON(w, http.StatusOK, toFeatureFlagResponse(h.flags.State(ctx, name)))
+		return
+	}
+
+	var (
+		st     featureflags.State
+		err    error
+		action string
+	)
+	if r.Method == http.MethodPut {
+		var req featureFlagRequest
+		if err := decodeJSON(w, r, h.cfg.MaxBodyBytes, &req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_json", "invalid request body")
+			return
+		}
+		if req.Enabled == nil {
+			writeError(w, http.StatusBadRequest, "invalid_request", "enabled is required")
+			return
+		}
+		st, err = h.flags.Set(ctx, name, *req.Enabled, claims.UserID)
+		action = "admin.feature_flag.set"
+	} else {
+		st, err = h.flags.Clear(ctx, name)
+		action = "admin.feature_flag.cleared"
+	}
+	if err != nil {
+		if errors.Is(err, featureflags.ErrNoStore) {
+			writeError(w, http.StatusServiceUnavailable, "db_unavailable", "database not configured")
+			return
+		}
+		h.log.Error(action+".fail", "err", err, "flag", name)
+		writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+		return
+	}
+
+	h.log.Warn(action, "admin_id", claims.UserID, "flag", name, "enabled", st.Enabled, "source", st.Source, "result", "success")
+	h.insertAudit(ctx, action, &claims.UserID, nil, h.clientIP(r), strings.TrimSpace(r.UserAgent()), map[string]any{
+		"flag":    name,
+		"enabled": st.Enabled,
+		"source":  st.Source,
+	})
+
+	writeJSON(w, http.StatusOK, toFeatureFlagResponse(st))
+}
@@ -0,0 +1,48 @@
+package authapi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"arc/cmd/internal/featureflags"
+)
+
+func TestInviteRequired(t *testing.T) {
+	ctx := context.Background()
+
+	h := &Handler{cfg: Config{InviteOnly: true}}
+	if !h.inviteRequired(ctx) {
+		t.Fatalf("expected ARC_AUTH_INVITE_ONLY to apply without flags")
+	}
+
+	h.flags = featureflags.New(featureflags.Config{Values: map[string]bool{featureflags.AuthOpenSignup: true}}, nil)
+	if h.inviteRequired(ctx) {
+		t.Fatalf("expected auth.open_signup=true to lift the invite requirement")
+	}
+
+	h.cfg.InviteOnly = false
+	h.flags.SetStatic(map[string]bool{featureflags.AuthOpenSignup: false})
+	if !h.inviteRequired(ctx) {
+		t.Fatalf("expected auth.open_signup=false to require invites")
+	}
+
+	h.flags.SetStatic(nil)
+	if h.inviteRequired(ctx) {
+		t.Fatalf("expected ARC_AUTH_INVITE_ONLY=false to apply when the flag is unset")
+	}
+}
+
+func TestToFeatureFlagResponse(t *testing.T) {
+	def, _ := featureflags.Lookup(featureflags.RealtimePresence)
+	resp := toFeatureFlagResponse(featureflags.State{Flag: def, Enabled: true, Source: featureflags.SourceDefault})
+	if resp.Name != featureflags.RealtimePresence || !resp.Default || resp.UpdatedAt != nil {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	resp = toFeatureFlagResponse(featureflags.State{Flag: def, Source: featureflags.SourceOverride, UpdatedAt: at, UpdatedBy: "admin-1"})
+	if resp.Enabled || resp.UpdatedAt == nil || !resp.UpdatedAt.Equal(at) || resp.UpdatedBy != "admin-1" {
+		t.Fatalf("unexpected override response: %+v", resp)
+	}
+}
@@ -0,0 +1,48 @@
+package authapi
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"arc/cmd/internal/geoip"
+)
+
+const maxGeoCityLen = 128
+
+// lookupGeo resolves ip to a location. Resolution is best-effort: failures are
+// logged and yield a zero Location so auth flows never depend on GeoIP availability.
+func (h *Handler) lookupGeo(ctx context.Context, ip net.IP) geoip.Location {
+	if h == nil || h.geo == nil || ip == nil {
+		return geoip.Location{}
+	}
+	loc, err := h.geo.Lookup(ctx, ip)
+	if err != nil {
+		h.log.Warn("auth.geoip.lookup.fail", "err", err)
+		return geoip.Location{}
+	}
+	return sanitizeLocation(loc)
+}
+
+// sanitizeLocation keeps resolver output within the persisted column constraints.
+func sanitizeLocation(loc geoip.Location) geoip.Location {
+	if len(loc.Country) != 2 {
+		loc.Country = ""
+	}
+	loc.City = truncateRunes(loc.City, maxGeoCityLen)
+	return loc
+}
+
+// allowAccess applies the IP and country access lists before endpoint runs and
+// answers 403 access_denied when they refuse the client.
+func (h *Handler) allowAccess(w http.ResponseWriter, r *http.Request, endpoint string) bool {
+	if h.access == nil {
+		return true
+	}
+	if h.access.Allow(r.Context(), h.clientIP(r), endpoint, strings.TrimSpace(r.UserAgent())) {
+		return true
+	}
+	writeError(w, http.StatusForbidden, "access_denied", "access from this network is not allowed")
+	return false
+}
@@ -0,0 +1,68 @@
+package authapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"arc/cmd/internal/geoip"
+	"arc/cmd/internal/ipaccess"
+)
+
+func TestSanitizeLocation(t *testing.T) {
+	loc := sanitizeLocation(geoip.Location{
+		Country: "DEU",
+		City:    strings.Repeat("x", maxGeoCityLen+10),
+		ASN:     3320,
+	})
+	if loc.Country != "" {
+		t.Fatalf("expected invalid country to be dropped, got %q", loc.Country)
+	}
+	if len(loc.City) != maxGeoCityLen {
+		t.Fatalf("expected city truncated to %d, got %d", maxGeoCityLen, len(loc.City))
+	}
+	if loc.ASN != 3320 {
+		t.Fatalf("expected ASN preserved, got %d", loc.ASN)
+	}
+}
+
+func TestAllowAccess_RejectsDeniedClients(t *testing.T) {
+	policy := ipaccess.New(ipaccess.Config{
+		DenyCIDRs: []netip.Prefix{netip.MustParsePrefix("198.51.100.0/24")},
+	}, nil, nil)
+	h := &Handler{cfg: Config{TrustProxy: true, MaxBodyBytes: 1 << 20}, dbEnabled: true, access: policy}
+
+	for _, tc := range []struct {
+		path    string
+		handler http.HandlerFunc
+	}{
+		{"/auth/login", h.handleLogin},
+		{"/auth/login/challenge", h.handleLoginChallenge},
+		{"/auth/refresh", h.handleRefresh},
+		{"/auth/token/access", h.handleAccessRenew},
+	} {
+		req := httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader("{}"))
+		req.Header.Set("X-Forwarded-For", "198.51.100.7")
+		rec := httptest.NewRecorder()
+		tc.handler(rec, req)
+		if rec.Code != http.StatusForbidden {
+			t.Fatalf("%s: expected 403, got %d", tc.path, rec.Code)
+		}
+		var er errorResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &er); err != nil || er.Error.Code != "access_denied" {
+			t.Fatalf("%s: expected access_denied, got %s", tc.path, rec.Body.String())
+		}
+	}
+
+	// Other clients get past the check (and fail later on the empty body).
+	req := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader("{}"))
+	req.Header.Set("X-Forwarded-For", "192.0.2.1")
+	rec := httptest.NewRecorder()
+	h.handleLogin(rec, req)
+	if rec.Code == http.StatusForbidden {
+		t.Fatalf("expected allowed client to pass the access check")
+	}
+}
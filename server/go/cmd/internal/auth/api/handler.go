@@ -7,10 +7,18 @@ import (
 	"net"
 	"net/http"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"arc/cmd/identity"
 	"arc/cmd/internal/auth/session"
+	"arc/cmd/internal/config"
+	"arc/cmd/internal/featureflags"
+	"arc/cmd/internal/geoip"
+	"arc/cmd/internal/invite"
+	"arc/cmd/internal/ipaccess"
+	"arc/cmd/internal/maintenance"
+	"arc/cmd/internal/realtime"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -19,16 +27,48 @@ import (
 type Handler struct {
 	log *slog.Logger
 	cfg Config
+	// reloaded is cfg with the tunables of the latest config reload (nil until one).
+	reloaded atomic.Pointer[Config]
+	// lookup reads the tunables on reload (default the process environment); see WithConfigLookup.
+	lookup config.Lookup
+	// stopReloading cancels the config.Subscribe of NewHandler; see Close.
+	stopReloading func()
 
 	dbEnabled bool
 	pool      *pgxpool.Pool
+	// readPool serves replica-safe identity and session reads (nil uses pool); see WithReadPool.
+	readPool *pgxpool.Pool
+	// schema holds every table the handler touches (default "arc"); see WithSchema.
+	schema string
 
 	identity *identity.PostgresStore
+	invites  *invite.Service
 	sessions *session.Service
 	sessCfg  session.Config
 
 	emailSender EmailSender
-	captcha     CaptchaVerifier
+	// smsSenders deliver phone codes, tried in order until one succeeds.
+	smsSenders []SMSSender
+	captcha    CaptchaVerifier
+	geo        geoip.Resolver
+
+	// messages supplies authored messages for privacy exports (nil omits them).
+	messages realtime.AuthoredMessageLister
+	// scrubber detaches messages from users erased by a purge (nil skips the step).
+	scrubber realtime.MessageAuthorScrubber
+	// exportKey signs privacy export download links; exports are disabled when empty.
+	exportKey []byte
+	// identifierKey keys the login identifier and phone hashes (empty falls back to SHA-256).
+	identifierKey []byte
+	// maintenance is the node's maintenance switch served at /admin/maintenance (nil disables the route).
+	maintenance *maintenance.Mode
+	// flags gates open signup and MFA and is served at /admin/feature-flags (nil uses the defaults).
+	flags *featureflags.Flags
+	// access holds the IP and country lists checked before login and refresh (nil allows all).
+	access *ipaccess.Policy
+
+	// stats holds brute-force counters until RunStatsFlusher writes them to the audit log.
+	stats authStats
 
 	dummyHash string
 }
@@ -46,6 +86,25 @@ func WithEmailSender(sender EmailSender) HandlerOption {
 	}
 }
 
+// WithSMSSenders overrides the default no-op SMS sender. Codes go to the first
+// sender that accepts them; later senders are fallbacks.
+func WithSMSSenders(senders ...SMSSender) HandlerOption {
+	return func(h *Handler) {
+		if h == nil {
+			return
+		}
+		var out []SMSSender
+		for _, s := range senders {
+			if s != nil {
+				out = append(out, s)
+			}
+		}
+		if len(out) > 0 {
+			h.smsSenders = out
+		}
+	}
+}
+
 // WithCaptchaVerifier overrides the default no-op captcha verifier.
 func WithCaptchaVerifier(verifier CaptchaVerifier) HandlerOption {
 	return func(h *Handler) {
@@ -56,6 +115,100 @@ func WithCaptchaVerifier(verifier CaptchaVerifier) HandlerOption {
 	}
 }
 
+// WithGeoResolver overrides the default no-op GeoIP resolver.
+func WithGeoResolver(resolver geoip.Resolver) HandlerOption {
+	return func(h *Handler) {
+		if h == nil || resolver == nil {
+			return
+		}
+		h.geo = resolver
+	}
+}
+
+// WithAuthoredMessages sets the message source included in privacy exports.
+func WithAuthoredMessages(src realtime.AuthoredMessageLister) HandlerOption {
+	return func(h *Handler) {
+		if h == nil || src == nil {
+			return
+		}
+		h.messages = src
+	}
+}
+
+// WithMessageScrubber sets the message store used to erase authorship during user purges.
+func WithMessageScrubber(scrubber realtime.MessageAuthorScrubber) HandlerOption {
+	return func(h *Handler) {
+		if h == nil || scrubber == nil {
+			return
+		}
+		h.scrubber = scrubber
+	}
+}
+
+// WithSchema serves users, sessions, audit rows and the other auth tables from
+// schema instead of "arc". Multi-tenant deployments build one Handler per tenant.
+func WithSchema(schema string) HandlerOption {
+	return func(h *Handler) {
+		if h == nil || schema == "" {
+			return
+		}
+		h.schema = schema
+	}
+}
+
+// WithReadPool serves profile, export and session-list reads from a read replica.
+// Credential, session and lock checks always read the primary.
+func WithReadPool(pool *pgxpool.Pool) HandlerOption {
+	return func(h *Handler) {
+		if h == nil || pool == nil {
+			return
+		}
+		h.readPool = pool
+	}
+}
+
+// WithMaintenance exposes m at /admin/maintenance.
+func WithMaintenance(m *maintenance.Mode) HandlerOption {
+	return func(h *Handler) {
+		if h == nil || m == nil {
+			return
+		}
+		h.maintenance = m
+	}
+}
+
+// WithFeatureFlags consults f for auth.* flags and exposes it at /admin/feature-flags.
+func WithFeatureFlags(f *featureflags.Flags) HandlerOption {
+	return func(h *Handler) {
+		if h == nil || f == nil {
+			return
+		}
+		h.flags = f
+	}
+}
+
+// WithAccessPolicy checks p before login, login challenges, refresh and access renewal.
+func WithAccessPolicy(p *ipaccess.Policy) HandlerOption {
+	return func(h *Handler) {
+		if h == nil {
+			return
+		}
+		h.access = p
+	}
+}
+
+// WithConfigLookup re-reads the reloadable settings through lookup instead of the
+// process environment, so a tenant's overrides survive a config reload. lookup
+// should be the one cfg was loaded from.
+func WithConfigLookup(lookup config.Lookup) HandlerOption {
+	return func(h *Handler) {
+		if h == nil || lookup == nil {
+			return
+		}
+		h.lookup = lookup
+	}
+}
+
 // NewHandler constructs an auth Handler. If dbEnabled is false, handlers return 503.
 func NewHandler(log *slog.Logger, pool *pgxpool.Pool, cfg Config, sessCfg session.Config, dbEnabled bool, opts ...HandlerOption) (*Handler, error) {
 	if log == nil {
@@ -63,13 +216,19 @@ func NewHandler(log *slog.Logger, pool *pgxpool.Pool, cfg Config, sessCfg sessio
 	}
 
 	h := &Handler{
-		log:         log,
-		cfg:         cfg,
-		dbEnabled:   dbEnabled,
-		pool:        pool,
-		sessCfg:     sessCfg,
-		emailSender: NoopEmailSender{},
-		captcha:     NoopCaptchaVerifier{},
+		log:           log,
+		cfg:           cfg,
+		dbEnabled:     dbEnabled,
+		pool:          pool,
+		sessCfg:       sessCfg,
+		emailSender:   NoopEmailSender{},
+		smsSenders:    []SMSSender{NoopSMSSender{}},
+		captcha:       NoopCaptchaVerifier{},
+		geo:           geoip.NoopResolver{},
+		schema:        "arc",
+		exportKey:     privacyExportLinkKey(),
+		identifierKey: identifierHashKey(),
+		lookup:        config.Env,
 	}
 
 	for _, opt := range opts {
@@ -78,47 +237,66 @@ func NewHandler(log *slog.Logger, pool *pgxpool.Pool, cfg Config, sessCfg sessio
 		}
 		opt(h)
 	}
-
-	if !dbEnabled {
-		return h, nil
+	if dbEnabled {
+		if err := h.openStores(pool); err != nil {
+			return nil, err
+		}
 	}
+	h.stopReloading = config.Subscribe(h.reloadConfig, authTunableKeys...)
+	return h, nil
+}
+
+// openStores builds the identity and session stores on pool.
+func (h *Handler) openStores(pool *pgxpool.Pool) error {
 	if pool == nil {
-		return nil, errors.New("auth: nil db pool")
+		return errors.New("auth: nil db pool")
 	}
 
-	idStore, err := identity.NewPostgresStore(pool)
+	idStore, err := identity.NewPostgresStore(pool, identity.WithSchema(h.schema), identity.WithReadPool(h.readPool))
 	if err != nil {
-		return nil, err
+		return err
 	}
 	h.identity = idStore
+	h.invites = idStore.Invites()
 
-	tokens, err := session.NewPasetoV4PublicManager(sessCfg)
+	tokens, err := session.NewPasetoV4PublicManager(h.sessCfg)
+	if err != nil {
+		return err
+	}
+	sessStore, err := session.NewPostgresStore(pool, session.WithSchema(h.schema), session.WithReadPool(h.readPool))
 	if err != nil {
-		return nil, err
+		return err
 	}
-	sessStore := session.NewPostgresStore(pool)
-	h.sessions = session.NewService(sessCfg, pool, sessStore, tokens)
+	h.sessions = session.NewService(h.sessCfg, pool, sessStore, tokens)
 
 	// Dummy hash for timing-resistant login checks.
 	if hash, err := identity.HashPassword("dummy-password-for-timing-only", identity.DefaultArgon2idParams()); err == nil {
 		h.dummyHash = hash
 	}
+	return nil
+}
 
-	return h, nil
+// Close stops applying config reloads to h. It is idempotent; the handler keeps
+// serving with the settings it had.
+func (h *Handler) Close() {
+	if h == nil || h.stopReloading == nil {
+		return
+	}
+	h.stopReloading()
 }
 
-// Register wires auth routes onto the provided mux.
+// Register wires auth routes onto the provided mux under APIVersionPrefix and, unless
+// DisableLegacyRoutes is set, at their deprecated unversioned paths.
 func (h *Handler) Register(mux *http.ServeMux) {
 	if h == nil || mux == nil {
 		return
 	}
-	mux.HandleFunc("/auth/login", h.handleLogin)
-	mux.HandleFunc("/auth/refresh", h.handleRefresh)
-	mux.HandleFunc("/auth/logout", h.handleLogout)
-	mux.HandleFunc("/auth/logout_all", h.handleLogoutAll)
-	mux.HandleFunc("/auth/invites/create", h.handleInviteCreate)
-	mux.HandleFunc("/auth/invites/consume", h.handleInviteConsume)
-	mux.HandleFunc("/me", h.handleMe)
+	for _, rt := range h.routes() {
+		mux.Handle(APIVersionPrefix+rt.pattern, rt.handler)
+		if !h.cfg.DisableLegacyRoutes {
+			mux.Handle(rt.pattern, h.legacyRoute(rt.pattern, rt.handler))
+		}
+	}
 }
 
 // SessionService returns the underlying session service (may be nil when DB is disabled).
@@ -140,6 +318,9 @@ func (h *Handler) handleLogin(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusServiceUnavailable, "db_unavailable", "database not configured")
 		return
 	}
+	if !h.allowAccess(w, r, "login") {
+		return
+	}
 
 	var req loginRequest
 	if err := decodeJSON(w, r, h.cfg.MaxBodyBytes, &req); err != nil {
@@ -152,10 +333,14 @@ func (h *Handler) handleLogin(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, "invalid_request", "username/email and password are required")
 		return
 	}
+	bindingKey, ok := h.loginBindingKey(w, req.BindingKey, platform)
+	if !ok {
+		return
+	}
 
 	ctx := r.Context()
 	now := time.Now().UTC()
-	ip := clientIP(r, h.cfg.TrustProxy)
+	ip := h.clientIP(r)
 	ua := strings.TrimSpace(r.UserAgent())
 	identifier := loginIdentifier(username, email)
 
@@ -165,7 +350,7 @@ func (h *Handler) handleLogin(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusServiceUnavailable, "server_busy", "please retry later")
 		return
 	} else if blocked {
-		h.auditLoginRateLimited(ctx, nil, ip, ua, identifier, retryAfter)
+		h.auditLoginRateLimited(ctx, nil, ip, ua, identifier, throttleIP, retryAfter)
 		writeRateLimited(w, retryAfter)
 		return
 	}
@@ -175,7 +360,7 @@ func (h *Handler) handleLogin(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusServiceUnavailable, "server_busy", "please retry later")
 		return
 	} else if blocked {
-		h.auditLoginRateLimited(ctx, nil, ip, ua, identifier, retryAfter)
+		h.auditLoginRateLimited(ctx, nil, ip, ua, identifier, throttleIdentifier, retryAfter)
 		writeRateLimited(w, retryAfter)
 		return
 	}
@@ -208,6 +393,11 @@ func (h *Handler) handleLogin(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusUnauthorized, "invalid_credentials", "invalid credentials")
 		return
 	}
+	if userAuth.User.LockedAt != nil {
+		h.auditLoginFailed(ctx, &userAuth.User.ID, ip, ua, identifier, "locked")
+		writeError(w, http.StatusForbidden, "account_locked", "account locked")
+		return
+	}
 	if err := h.enforceEmailVerified(userAuth.User); err != nil {
 		h.auditLoginFailed(ctx, &userAuth.User.ID, ip, ua, identifier, "email_not_verified")
 		writeError(w, http.StatusForbidden, "email_not_verified", "email verification required")
@@ -219,17 +409,51 @@ func (h *Handler) handleLogin(w http.ResponseWriter, r *http.Request) {
 		RememberMe: rememberMe,
 		UserAgent:  ua,
 		IP:         ip,
+		Geo:        h.lookupGeo(ctx, ip),
+		BindingKey: bindingKey,
+	}
+
+	fingerprint := deviceFingerprint(ua, platform, ip, dev.Geo)
+	mfaRequired := h.flags.Enabled(ctx, featureflags.AuthMFARequired)
+	if mfaRequired && !hasDeliverableEmail(userAuth.User) {
+		h.auditLoginFailed(ctx, &userAuth.User.ID, ip, ua, identifier, "mfa_unavailable")
+		writeError(w, http.StatusForbidden, "mfa_unavailable", "an email address is required to sign in")
+		return
+	}
+	if mfaRequired || h.loginChallengeApplies(userAuth.User) {
+		known := false
+		if !mfaRequired {
+			known, err = isKnownDevice(ctx, h.pool, h.schema, userAuth.User.ID, fingerprint)
+			if err != nil {
+				h.log.Error("auth.login.known_device.fail", "err", err)
+				writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+				return
+			}
+		}
+		if !known {
+			h.startLoginChallenge(w, r, now, userAuth.User, fingerprint, dev, identifier)
+			return
+		}
 	}
 
 	issued, err := h.sessions.IssueSession(ctx, now, userAuth.User.ID, dev)
 	if err != nil {
+		if errors.Is(err, session.ErrBindingRequired) {
+			writeError(w, http.StatusBadRequest, "binding_required", "binding_key is required for this platform")
+			return
+		}
 		h.log.Error("auth.login.issue_session.fail", "err", err)
 		writeError(w, http.StatusInternalServerError, "server_error", "internal error")
 		return
 	}
 
 	h.auditLoginSuccess(ctx, &userAuth.User.ID, issued.SessionID, ip, ua, identifier)
+	h.rememberDevice(ctx, now, userAuth.User.ID, fingerprint)
+	h.writeLoginSession(w, platform, userAuth.User, issued)
+}
 
+// writeLoginSession writes the login response, moving the refresh token into cookies for web cookie mode.
+func (h *Handler) writeLoginSession(w http.ResponseWriter, platform session.Platform, user identity.User, issued session.Issued) {
 	respSession := toSessionResponse(issued)
 	if h.shouldUseWebCookieTransport(platform) {
 		if _, err := h.setWebSessionCookies(w, issued.RefreshToken, issued.RefreshExp); err != nil {
@@ -238,10 +462,14 @@ func (h *Handler) handleLogin(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		respSession.RefreshToken = ""
+		if h.cfg.WebAccessCookieEnabled {
+			h.setAccessCookie(w, respSession.AccessToken, respSession.AccessExpiresAt)
+			respSession.AccessToken = ""
+		}
 	}
 
 	writeJSON(w, http.StatusOK, loginResponse{
-		User:    toUserResponse(userAuth.User),
+		User:    toUserResponse(user),
 		Session: respSession,
 	})
 }
@@ -255,6 +483,9 @@ func (h *Handler) handleRefresh(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusServiceUnavailable, "db_unavailable", "database not configured")
 		return
 	}
+	if !h.allowAccess(w, r, "refresh") {
+		return
+	}
 
 	var req refreshRequest
 	if r.ContentLength != 0 {
@@ -275,21 +506,25 @@ func (h *Handler) handleRefresh(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, "invalid_request", "refresh_token is required")
 		return
 	}
-	if fromCookie && !h.csrfDoubleSubmitValid(r) {
-		writeError(w, http.StatusForbidden, "csrf_invalid", "missing or invalid csrf token")
-		return
-	}
+	// CSRF for cookie-authenticated refresh is enforced by CSRFMiddleware in Register.
 
 	ctx := r.Context()
 	now := time.Now().UTC()
-	ip := clientIP(r, h.cfg.TrustProxy)
+	ip := h.clientIP(r)
 	ua := strings.TrimSpace(r.UserAgent())
 
+	proof, ok := decodeBindingProof(w, req.BindingProof)
+	if !ok {
+		return
+	}
+
 	dev := session.DeviceContext{
-		Platform:   normalizePlatform(req.Platform),
-		RememberMe: req.RememberMe,
-		UserAgent:  ua,
-		IP:         ip,
+		Platform:     normalizePlatform(req.Platform),
+		RememberMe:   req.RememberMe,
+		UserAgent:    ua,
+		IP:           ip,
+		Geo:          h.lookupGeo(ctx, ip),
+		BindingProof: proof,
 	}
 
 	issued, err := h.sessions.RotateRefresh(ctx, now, refreshToken, dev)
@@ -310,6 +545,13 @@ func (h *Handler) handleRefresh(w http.ResponseWriter, r *http.Request) {
 			writeError(w, http.StatusUnauthorized, "refresh_reuse_detected", "refresh token reuse detected")
 		case errors.Is(err, session.ErrSessionExpired), errors.Is(err, session.ErrSessionRevoked), errors.Is(err, session.ErrSessionNotFound):
 			writeError(w, http.StatusUnauthorized, "session_not_active", "session not active")
+		case errors.Is(err, session.ErrUserLocked):
+			writeError(w, http.StatusForbidden, "account_locked", "account locked")
+		case errors.Is(err, session.ErrBindingProofInvalid):
+			h.auditRefreshBindingFailed(ctx, ip, ua)
+			writeError(w, http.StatusUnauthorized, "binding_proof_invalid", "refresh binding proof invalid")
+		case errors.Is(err, session.ErrBindingRequired):
+			writeError(w, http.StatusUnauthorized, "binding_required", "session must be re-established with a binding key")
 		default:
 			h.log.Error("auth.refresh.fail", "err", err)
 			writeError(w, http.StatusInternalServerError, "server_error", "internal error")
@@ -327,6 +569,10 @@ func (h *Handler) handleRefresh(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		respSession.RefreshToken = ""
+		if h.cfg.WebAccessCookieEnabled {
+			h.setAccessCookie(w, respSession.AccessToken, respSession.AccessExpiresAt)
+			respSession.AccessToken = ""
+		}
 	}
 
 	writeJSON(w, http.StatusOK, refreshResponse{
@@ -344,6 +590,13 @@ func (h *Handler) handleLogout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Without an access token (e.g. expired on a native client), the refresh token
+	// from the body or cookie identifies the session to end.
+	if bearerToken(r) == "" {
+		h.logoutWithRefreshToken(w, r)
+		return
+	}
+
 	claims, ok := h.requireAuth(w, r)
 	if !ok {
 		return
@@ -357,7 +610,42 @@ func (h *Handler) handleLogout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.auditLogout(ctx, claims.UserID, claims.SessionID, clientIP(r, h.cfg.TrustProxy), strings.TrimSpace(r.UserAgent()))
+	h.auditLogout(ctx, claims.UserID, claims.SessionID, h.clientIP(r), strings.TrimSpace(r.UserAgent()))
+	h.clearWebSessionCookies(w)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) logoutWithRefreshToken(w http.ResponseWriter, r *http.Request) {
+	var req logoutRequest
+	if r.ContentLength != 0 {
+		if err := decodeJSON(w, r, h.cfg.MaxBodyBytes, &req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_json", "invalid request body")
+			return
+		}
+	}
+	refreshToken := strings.TrimSpace(req.RefreshToken)
+	if refreshToken == "" {
+		refreshToken, _ = h.refreshTokenFromCookie(r)
+	}
+	if refreshToken == "" {
+		writeError(w, http.StatusUnauthorized, "unauthorized", "missing bearer or refresh token")
+		return
+	}
+
+	ctx := r.Context()
+	row, err := h.sessions.RevokeByRefreshToken(ctx, time.Now().UTC(), refreshToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, session.ErrSessionExpired), errors.Is(err, session.ErrSessionRevoked), errors.Is(err, session.ErrSessionNotFound):
+			writeError(w, http.StatusUnauthorized, "session_not_active", "session not active")
+		default:
+			h.log.Error("auth.logout.fail", "err", err)
+			writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+		}
+		return
+	}
+
+	h.auditLogout(ctx, row.UserID, row.ID, h.clientIP(r), strings.TrimSpace(r.UserAgent()))
 	h.clearWebSessionCookies(w)
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -385,7 +673,7 @@ func (h *Handler) handleLogoutAll(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.auditLogoutAll(ctx, claims.UserID, clientIP(r, h.cfg.TrustProxy), strings.TrimSpace(r.UserAgent()))
+	h.auditLogoutAll(ctx, claims.UserID, h.clientIP(r), strings.TrimSpace(r.UserAgent()))
 	h.clearWebSessionCookies(w)
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -420,6 +708,36 @@ func (h *Handler) handleMe(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, meResponse{User: toUserResponse(u)})
 }
 
+func (h *Handler) handleMeSessions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.dbEnabled {
+		writeError(w, http.StatusServiceUnavailable, "db_unavailable", "database not configured")
+		return
+	}
+
+	claims, ok := h.requireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	list, err := h.sessions.ListActiveSessions(ctx, time.Now().UTC(), claims.UserID)
+	if err != nil {
+		h.log.Error("auth.me.sessions.fail", "err", err)
+		writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+		return
+	}
+
+	out := make([]activeSessionResponse, 0, len(list))
+	for _, info := range list {
+		out = append(out, toActiveSessionResponse(info, claims.SessionID))
+	}
+	writeJSON(w, http.StatusOK, meSessionsResponse{Sessions: out})
+}
+
 func (h *Handler) handleInviteCreate(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		w.WriteHeader(http.StatusMethodNotAllowed)
@@ -443,16 +761,7 @@ func (h *Handler) handleInviteCreate(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	ttl := h.cfg.InviteTTL
-	if req.ExpiresInSeconds > 0 {
-		ttl = time.Duration(req.ExpiresInSeconds) * time.Second
-	}
-	if ttl > h.cfg.InviteMaxTTL {
-		ttl = h.cfg.InviteMaxTTL
-	}
-	if ttl <= 0 {
-		ttl = h.cfg.InviteTTL
-	}
+	ttl := h.inviteTTL(req.ExpiresInSeconds)
 	maxUses := h.cfg.InviteMaxUses
 	if req.MaxUses > 0 {
 		maxUses = req.MaxUses
@@ -469,25 +778,43 @@ func (h *Handler) handleInviteCreate(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	now := time.Now().UTC()
 
-	res, err := h.identity.CreateInvite(ctx, identity.CreateInviteInput{
-		CreatedBy: &claims.UserID,
-		TTL:       ttl,
-		MaxUses:   maxUses,
-		Note:      note,
-		Now:       now,
+	convID, role, ok := h.inviteConversation(ctx, w, claims.UserID, req.ConversationID, req.Role)
+	if !ok {
+		return
+	}
+
+	inv, token, err := h.invites.CreateInvite(ctx, invite.CreateInput{
+		CreatedBy:           &claims.UserID,
+		TTL:                 ttl,
+		MaxUses:             maxUses,
+		Note:                note,
+		ConversationID:      convID,
+		ConversationRole:    role,
+		AllowedEmailDomains: req.AllowedDomains,
+		Now:                 now,
 	})
 	if err != nil {
+		switch {
+		case errors.Is(err, invite.ErrConversationNotFound):
+			writeError(w, http.StatusNotFound, "conversation_not_found", "conversation not found")
+			return
+		case errors.Is(err, invite.ErrInvalidInput):
+			writeError(w, http.StatusBadRequest, "invalid_request", "invalid allowed_email_domains")
+			return
+		}
 		h.log.Error("auth.invite.create.fail", "err", err)
 		writeError(w, http.StatusInternalServerError, "server_error", "internal error")
 		return
 	}
 
-	h.auditInviteCreated(ctx, claims.UserID, res.Invite.ID, clientIP(r, h.cfg.TrustProxy), strings.TrimSpace(r.UserAgent()))
+	h.auditInviteCreated(ctx, claims.UserID, inv.ID, h.clientIP(r), strings.TrimSpace(r.UserAgent()))
 
 	writeJSON(w, http.StatusOK, inviteCreateResponse{
-		InviteID:    res.Invite.ID,
-		InviteToken: res.Token,
-		ExpiresAt:   res.Invite.ExpiresAt,
+		InviteID:       inv.ID,
+		InviteToken:    token,
+		ExpiresAt:      inv.ExpiresAt,
+		ConversationID: inv.ConversationID,
+		Role:           inv.ConversationRole,
 	})
 }
 
@@ -507,7 +834,7 @@ func (h *Handler) handleInviteConsume(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if h.cfg.InviteOnly && strings.TrimSpace(req.InviteToken) == "" {
+	if h.inviteRequired(r.Context()) && strings.TrimSpace(req.InviteToken) == "" {
 		writeError(w, http.StatusBadRequest, "invalid_request", "invite_token is required")
 		return
 	}
@@ -529,7 +856,7 @@ func (h *Handler) handleInviteConsume(w http.ResponseWriter, r *http.Request) {
 
 	ctx := r.Context()
 	now := time.Now().UTC()
-	ip := clientIP(r, h.cfg.TrustProxy)
+	ip := h.clientIP(r)
 	if err := h.enforceCaptcha(ctx, req.Captcha, ip); err != nil {
 		switch {
 		case errors.Is(err, ErrCaptchaRequired), errors.Is(err, ErrCaptchaInvalid):
@@ -552,20 +879,23 @@ func (h *Handler) handleInviteConsume(w http.ResponseWriter, r *http.Request) {
 	}
 
 	res, err := h.identity.ConsumeInviteAndCreateUser(ctx, identity.ConsumeInviteInput{
-		Token:      strings.TrimSpace(req.InviteToken),
-		Username:   username,
-		Email:      email,
-		Password:   req.Password,
-		Now:        now,
-		SessionTTL: ttl,
-		Platform:   string(platform),
-		UserAgent:  uaPtr,
-		IP:         ipPtr,
+		Token:               strings.TrimSpace(req.InviteToken),
+		Username:            username,
+		Email:               email,
+		Password:            req.Password,
+		Now:                 now,
+		SessionTTL:          ttl,
+		Platform:            string(platform),
+		UserAgent:           uaPtr,
+		IP:                  ipPtr,
+		AllowedEmailDomains: h.cfg.SignupEmailDomains,
 	})
 	if err != nil {
 		switch {
 		case identity.IsConflict(err):
 			writeError(w, http.StatusConflict, "conflict", "username or email already exists")
+		case identity.IsNotAllowed(err):
+			writeError(w, http.StatusForbidden, "email_domain_not_allowed", "email domain is not allowed")
 		case identity.IsInvalidInput(err):
 			writeError(w, http.StatusBadRequest, "invalid_request", "invalid input")
 		case identity.IsNotActive(err) || identity.IsNotFound(err):
@@ -605,25 +935,38 @@ func (h *Handler) handleInviteConsume(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		respSession.RefreshToken = ""
+		if h.cfg.WebAccessCookieEnabled {
+			h.setAccessCookie(w, respSession.AccessToken, respSession.AccessExpiresAt)
+			respSession.AccessToken = ""
+		}
 	}
 
 	writeJSON(w, http.StatusOK, inviteConsumeResponse{
-		User:     toUserResponse(res.User),
-		Session:  respSession,
-		InviteID: res.Invite.ID,
+		User:           toUserResponse(res.User),
+		Session:        respSession,
+		InviteID:       res.Invite.ID,
+		ConversationID: res.Invite.ConversationID,
 	})
 }
 
 // ---- helpers ----
 
 func (h *Handler) requireAuth(w http.ResponseWriter, r *http.Request) (session.AccessClaims, bool) {
-	token := bearerToken(r)
+	token, fromCookie := h.accessTokenFromRequest(r)
 	if token == "" {
 		writeError(w, http.StatusUnauthorized, "unauthorized", "missing bearer token")
 		return session.AccessClaims{}, false
 	}
+	// The browser attaches the access cookie to cross-site requests too.
+	if fromCookie && !isSafeMethod(r.Method) && !h.csrfDoubleSubmitValid(r) {
+		writeError(w, http.StatusForbidden, "csrf_invalid", "missing or invalid csrf token")
+		return session.AccessClaims{}, false
+	}
 	claims, err := h.sessions.ValidateAccessToken(r.Context(), token, time.Now().UTC())
 	if err != nil {
+		if reason := session.InvalidTokenReason(err); reason != "" {
+			h.log.Info("auth.token.reject", "reason", reason, "result", "unauthorized")
+		}
 		writeError(w, http.StatusUnauthorized, "unauthorized", "invalid token")
 		return session.AccessClaims{}, false
 	}
@@ -723,11 +1066,12 @@ func (h *Handler) lookupUserForLogin(ctx context.Context, username, email *strin
 }
 
 func (h *Handler) enforceCaptcha(ctx context.Context, token string, ip net.IP) error {
-	if h == nil || !h.cfg.EnableCaptcha {
+	if h == nil || !h.config().EnableCaptcha {
 		return nil
 	}
 	token = normalizeCaptchaToken(token)
 	if token == "" {
+		h.stats.add(statCaptchaIssued, 1)
 		return ErrCaptchaRequired
 	}
 	if h.captcha == nil {
@@ -737,6 +1081,7 @@ func (h *Handler) enforceCaptcha(ctx context.Context, token string, ip net.IP) e
 		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
 			return err
 		}
+		h.stats.add(statCaptchaFailed, 1)
 		return ErrCaptchaInvalid
 	}
 	return nil
@@ -775,33 +1120,6 @@ func (h *Handler) maybeSendVerificationEmail(ctx context.Context, user identity.
 	}
 }
 
-func clientIP(r *http.Request, trustProxy bool) net.IP {
-	if trustProxy {
-		if ip := parseForwardedIP(r.Header.Get("X-Forwarded-For")); ip != nil {
-			return ip
-		}
-		if ip := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); ip != nil {
-			return ip
-		}
-	}
-	host, _, err := net.SplitHostPort(strings.TrimSpace(r.RemoteAddr))
-	if err == nil {
-		if ip := net.ParseIP(host); ip != nil {
-			return ip
-		}
-	}
-	return nil
-}
-
-func parseForwardedIP(raw string) net.IP {
-	if raw == "" {
-		return nil
-	}
-	parts := strings.Split(raw, ",")
-	for _, p := range parts {
-		if ip := net.ParseIP(strings.TrimSpace(p)); ip != nil {
-			return ip
-		}
-	}
-	return nil
+func (h *Handler) clientIP(r *http.Request) net.IP {
+	return ipaccess.ClientIP(r, h.cfg.Proxy())
 }
@@ -19,6 +19,7 @@ import (
 
 	"arc/cmd/identity"
 	"arc/cmd/internal/auth/session"
+	"arc/cmd/internal/invite"
 
 	paseto "aidanwoods.dev/go-paseto"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -293,6 +294,79 @@ func TestAuthAPI_LogoutAndLogoutAll(t *testing.T) {
 	}
 }
 
+func TestAuthAPI_LogoutWithRefreshToken(t *testing.T) {
+	pool := mustOpenAuthTestPool(t)
+	defer pool.Close()
+
+	cfg := testAuthConfig()
+	h := mustNewAuthHandler(t, pool, cfg)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mux := http.NewServeMux()
+		h.Register(mux)
+		mux.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	client := ts.Client()
+	idStore, err := identity.NewPostgresStore(pool)
+	if err != nil {
+		t.Fatalf("identity.NewPostgresStore: %v", err)
+	}
+
+	username := newTestUsername(t, "alrt")
+	password := "Very-Strong-Password-3!"
+	now := time.Now().UTC()
+
+	createRes, err := idStore.CreateUser(context.Background(), identity.CreateUserInput{
+		Username: &username,
+		Password: password,
+		Now:      now,
+	})
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	t.Cleanup(func() { cleanupAuthUser(context.Background(), t, pool, createRes.User.ID) })
+
+	login := mustLoginForTest(t, client, ts.URL, username, password, "ios")
+
+	statusMissing, _ := doJSON(t, client, ts.URL+"/auth/logout", struct{}{}, nil)
+	if statusMissing != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without credentials, got %d", statusMissing)
+	}
+
+	statusLogout, bodyLogout := doJSON(t, client, ts.URL+"/auth/logout", logoutRequest{
+		RefreshToken: login.Session.RefreshToken,
+	}, nil)
+	if statusLogout != http.StatusNoContent {
+		t.Fatalf("logout status=%d body=%s", statusLogout, string(bodyLogout))
+	}
+
+	statusAgain, bodyAgain := doJSON(t, client, ts.URL+"/auth/logout", logoutRequest{
+		RefreshToken: login.Session.RefreshToken,
+	}, nil)
+	if statusAgain != http.StatusUnauthorized {
+		t.Fatalf("expected session_not_active on repeated logout, got %d body=%s", statusAgain, string(bodyAgain))
+	}
+
+	statusR, bodyR := doJSON(t, client, ts.URL+"/auth/refresh", refreshRequest{
+		RefreshToken: login.Session.RefreshToken,
+		Platform:     "ios",
+	}, nil)
+	if statusR != http.StatusUnauthorized {
+		t.Fatalf("expected session revoked after logout, got %d body=%s", statusR, string(bodyR))
+	}
+
+	var count int
+	if err := pool.QueryRow(context.Background(), `
+		SELECT count(*) FROM arc.audit_log WHERE action = 'auth.logout' AND session_id = $1
+	`, login.Session.SessionID).Scan(&count); err != nil {
+		t.Fatalf("count audit: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("expected one auth.logout audit entry, got %d", count)
+	}
+}
+
 func TestAuthAPI_WebCookieCSRFRefreshFlow(t *testing.T) {
 	pool := mustOpenAuthTestPool(t)
 	defer pool.Close()
@@ -325,7 +399,7 @@ func TestAuthAPI_WebCookieCSRFRefreshFlow(t *testing.T) {
 		t.Fatalf("identity.NewPostgresStore: %v", err)
 	}
 
-	inviteRes, err := idStore.CreateInvite(context.Background(), identity.CreateInviteInput{
+	inv, inviteToken, err := idStore.Invites().CreateInvite(context.Background(), invite.CreateInput{
 		TTL:     24 * time.Hour,
 		MaxUses: 1,
 		Now:     time.Now().UTC(),
@@ -333,13 +407,13 @@ func TestAuthAPI_WebCookieCSRFRefreshFlow(t *testing.T) {
 	if err != nil {
 		t.Fatalf("CreateInvite: %v", err)
 	}
-	t.Cleanup(func() { cleanupInvite(context.Background(), t, pool, inviteRes.Invite.ID) })
+	t.Cleanup(func() { cleanupInvite(context.Background(), t, pool, inv.ID) })
 
 	username := newTestUsername(t, "aweb")
 	password := "Very-Strong-Password-4!"
 
 	statusConsume, bodyConsume := doJSON(t, client, ts.URL+"/auth/invites/consume", inviteConsumeRequest{
-		InviteToken: inviteRes.Token,
+		InviteToken: inviteToken,
 		Username:    &username,
 		Password:    password,
 		Platform:    "web",
@@ -474,6 +548,32 @@ func TestAuthAPI_LoginRateLimited_ByIdentifier(t *testing.T) {
 	if strings.TrimSpace(hdr.Get("Retry-After")) == "" {
 		t.Fatalf("expected Retry-After header on throttled response")
 	}
+
+	// The refusal shows up as an active lockout, and flushed counters feed /admin/stats/auth.
+	ctx := context.Background()
+	h.flushAuthStats(ctx, time.Now().UTC())
+	now := time.Now().UTC()
+	lockouts, err := countActiveLockouts(ctx, pool, "arc", now.Add(-time.Hour), now)
+	if err != nil {
+		t.Fatalf("countActiveLockouts: %v", err)
+	}
+	if lockouts != 1 {
+		t.Fatalf("expected 1 active lockout, got %d", lockouts)
+	}
+	var since [len(authStatsWindows)]time.Time
+	for i, d := range authStatsWindows {
+		since[i] = now.Add(-d)
+	}
+	sums, err := sumAuthStatsFlushes(ctx, pool, "arc", since)
+	if err != nil {
+		t.Fatalf("sumAuthStatsFlushes: %v", err)
+	}
+	if got := sums[statLoginFailedPrefix+"bad_password"]; got[0] != 2 || got[2] != 2 {
+		t.Fatalf("expected 2 bad_password failures in every window, got %v", got)
+	}
+	if got := sums[statLoginRateLimited]; got[0] != 1 {
+		t.Fatalf("expected 1 rate limited login, got %v", got)
+	}
 }
 
 func TestAuthAPI_LoginRateLimited_ByIP(t *testing.T) {
@@ -584,6 +684,42 @@ func TestAuthAPI_RefreshRateLimited(t *testing.T) {
 	}
 }
 
+// TestAuthAPI_BackfillIdentifierHashes hashes failed logins recorded with a plain
+// identifier and leaves them alone on a second run.
+func TestAuthAPI_BackfillIdentifierHashes(t *testing.T) {
+	pool := mustOpenAuthTestPool(t)
+	defer pool.Close()
+	ctx := context.Background()
+	clearAuthAuditLog(ctx, t, pool)
+
+	h := mustNewAuthHandler(t, pool, testAuthConfig())
+	h.identifierKey = []byte("0123456789abcdef0123456789abcdef")
+
+	var id int64
+	if err := pool.QueryRow(ctx, `
+		INSERT INTO arc.audit_log (action, meta)
+		VALUES ('auth.login.failed', '{"identifier":"legacy@example.com"}'::jsonb)
+		RETURNING id
+	`).Scan(&id); err != nil {
+		t.Fatalf("insert audit row: %v", err)
+	}
+
+	for range 2 {
+		h.BackfillIdentifierHashes(ctx)
+	}
+	var got string
+	if err := pool.QueryRow(ctx, `SELECT meta ->> 'identifier_hash' FROM arc.audit_log WHERE id = $1`, id).Scan(&got); err != nil {
+		t.Fatalf("read audit row: %v", err)
+	}
+	if want := h.identifierHash("legacy@example.com"); got != want {
+		t.Fatalf("identifier_hash = %q, want %q", got, want)
+	}
+	n, err := backfillIdentifierHashes(ctx, pool, "arc", time.Now().Add(-time.Hour), h.identifierHash)
+	if err != nil || n != 0 {
+		t.Fatalf("second backfill = %d, %v; want 0", n, err)
+	}
+}
+
 func mustLoginForTest(t *testing.T, client *http.Client, baseURL, username, password, platform string) loginResponse {
 	t.Helper()
 	status, body := doJSON(t, client, baseURL+"/auth/login", loginRequest{
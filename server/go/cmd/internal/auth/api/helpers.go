@@ -3,14 +3,23 @@ package authapi
 import (
 	"arc/cmd/identity"
 	"arc/cmd/internal/auth/session"
+
+	"github.com/jackc/pgx/v5"
 )
 
+// pgIdent safely quotes a schema-qualified identifier: "schema"."name".
+func pgIdent(schema, name string) string {
+	return pgx.Identifier{schema, name}.Sanitize()
+}
+
 func toUserResponse(u identity.User) userResponse {
 	return userResponse{
 		ID:              u.ID,
 		Username:        u.Username,
 		Email:           u.Email,
 		EmailVerifiedAt: u.EmailVerifiedAt,
+		Phone:           u.Phone,
+		PhoneVerifiedAt: u.PhoneVerifiedAt,
 		DisplayName:     u.DisplayName,
 		Bio:             u.Bio,
 		CreatedAt:       u.CreatedAt,
@@ -24,5 +33,26 @@ func toSessionResponse(issued session.Issued) sessionResponse {
 		AccessExpiresAt:  issued.AccessExp,
 		RefreshToken:     issued.RefreshToken,
 		RefreshExpiresAt: issued.RefreshExp,
+		BindingNonce:     issued.BindingNonce,
+	}
+}
+
+func toActiveSessionResponse(info session.Info, currentSessionID string) activeSessionResponse {
+	out := activeSessionResponse{
+		ID:         info.ID,
+		Platform:   string(info.Platform),
+		CreatedAt:  info.CreatedAt,
+		LastUsedAt: info.LastUsedAt,
+		ExpiresAt:  info.ExpiresAt,
+		UserAgent:  info.UserAgent,
+		Current:    info.ID == currentSessionID,
+	}
+	if info.IP != nil {
+		out.IP = info.IP.String()
+	}
+	if !info.Geo.IsZero() {
+		loc := info.Geo
+		out.Location = &loc
 	}
+	return out
 }
@@ -0,0 +1,66 @@
+package authapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"arc/cmd/internal/dbtrace"
+	"arc/cmd/internal/realtime"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// inviteConversation validates the optional conversation payload of an invite
+// request and checks that userID may grant role there: owners and admins may
+// invite members, only owners may invite admins. It writes the error response
+// and returns ok=false on failure; both results are nil for a plain invite.
+func (h *Handler) inviteConversation(ctx context.Context, w http.ResponseWriter, userID string, conversationID *string, role string) (*string, *string, bool) {
+	convID := trimPtr(conversationID)
+	if convID == nil {
+		if strings.TrimSpace(role) != "" {
+			writeError(w, http.StatusBadRequest, "invalid_request", "role requires conversation_id")
+			return nil, nil, false
+		}
+		return nil, nil, true
+	}
+	r, err := realtime.NormalizeMemberRole(role)
+	if err != nil || r == realtime.MemberRoleOwner {
+		writeError(w, http.StatusBadRequest, "invalid_role", "role must be member or admin")
+		return nil, nil, false
+	}
+
+	actorRole, err := conversationMemberRole(ctx, h.pool, h.schema, *convID, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// Non-members get 404 so private conversations are not revealed.
+			writeError(w, http.StatusNotFound, "conversation_not_found", "conversation not found")
+			return nil, nil, false
+		}
+		h.log.Error("auth.invite.conversation.fail", "err", err, "conversation_id", *convID, "result", "server_error")
+		writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+		return nil, nil, false
+	}
+	allowed := actorRole == realtime.MemberRoleOwner ||
+		(actorRole == realtime.MemberRoleAdmin && r == realtime.MemberRoleMember)
+	if !allowed {
+		writeError(w, http.StatusForbidden, "forbidden", "insufficient conversation role")
+		return nil, nil, false
+	}
+	return convID, &r, true
+}
+
+// conversationMemberRole returns userID's role in conversationID, or pgx.ErrNoRows
+// when they are not a member.
+func conversationMemberRole(ctx context.Context, pool *pgxpool.Pool, schema string, conversationID, userID string) (string, error) {
+	ctx = dbtrace.WithOp(ctx, "authapi.conversation_member_role")
+	var role string
+	err := pool.QueryRow(ctx, `
+		SELECT role
+		FROM `+pgIdent(schema, "conversation_members")+`
+		WHERE conversation_id = $1 AND user_id = $2
+	`, conversationID, userID).Scan(&role)
+	return role, err
+}
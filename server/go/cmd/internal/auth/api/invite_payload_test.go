@@ -0,0 +1,44 @@
+package authapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestInviteConversation_Validation(t *testing.T) {
+	h := &Handler{}
+	conv := "conv-1"
+
+	tests := []struct {
+		name   string
+		convID *string
+		role   string
+		ok     bool
+		status int
+	}{
+		{name: "plain invite", ok: true},
+		{name: "role without conversation", role: "member", status: http.StatusBadRequest},
+		{name: "owner role", convID: &conv, role: "owner", status: http.StatusBadRequest},
+		{name: "unknown role", convID: &conv, role: "moderator", status: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			convID, role, ok := h.inviteConversation(context.Background(), rec, "user-1", tt.convID, tt.role)
+			if ok != tt.ok {
+				t.Fatalf("expected ok=%v, got %v", tt.ok, ok)
+			}
+			if ok {
+				if convID != nil || role != nil {
+					t.Fatalf("expected no payload, got %v %v", convID, role)
+				}
+				return
+			}
+			if rec.Code != tt.status {
+				t.Fatalf("expected status %d, got %d", tt.status, rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,352 @@
+package authapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"arc/cmd/identity"
+	"arc/cmd/internal/dbtrace"
+	"arc/cmd/internal/invite"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// defaultInviteLinkTemplate opens the app's invite screen with the token.
+const defaultInviteLinkTemplate = "arc://invite?token={token}"
+
+// inviteSendWindow is the rolling window InviteSendDailyMax applies to.
+const inviteSendWindow = 24 * time.Hour
+
+// Invite delivery statuses stored in invite_deliveries.status.
+const (
+	inviteDeliveryPending = "pending"
+	inviteDeliverySent    = "sent"
+	inviteDeliveryFailed  = "failed"
+)
+
+type inviteSendRequest struct {
+	Email            string   `json:"email"`
+	ExpiresInSeconds int64    `json:"expires_in_seconds"`
+	Note             *string  `json:"note"`
+	ConversationID   *string  `json:"conversation_id"`
+	Role             string   `json:"role"`
+	AllowedDomains   []string `json:"allowed_email_domains"`
+}
+
+type inviteDeliveryResponse struct {
+	InviteID       string    `json:"invite_id"`
+	Email          string    `json:"email"`
+	DeliveryStatus string    `json:"delivery_status"`
+	CreatedAt      time.Time `json:"created_at"`
+	ExpiresAt      time.Time `json:"expires_at"`
+}
+
+type inviteDeliveriesResponse struct {
+	Deliveries []inviteDeliveryResponse `json:"deliveries"`
+}
+
+// inviteDelivery is one invite_deliveries row joined with its invite's expiry.
+type inviteDelivery struct {
+	InviteID  string
+	Email     string
+	Status    string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// handleInviteSend serves POST /auth/invites/send: it creates a single-use invite and
+// emails its deep link to the given address.
+//
+// Each user may send InviteSendDailyMax invites per rolling 24 hours; failed deliveries
+// count too, so a broken address cannot be retried without limit. The token is only
+// ever sent by email, never returned to the caller.
+func (h *Handler) handleInviteSend(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.dbEnabled {
+		writeError(w, http.StatusServiceUnavailable, "db_unavailable", "database not configured")
+		return
+	}
+
+	claims, ok := h.requireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	var req inviteSendRequest
+	if err := decodeJSON(w, r, h.cfg.MaxBodyBytes, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_json", "invalid request body")
+		return
+	}
+	email, ok := parseInviteEmail(req.Email)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid_request", "a valid email is required")
+		return
+	}
+	note := trimPtr(req.Note)
+	if note != nil && len(*note) > 512 {
+		writeError(w, http.StatusBadRequest, "invalid_request", "note is too long")
+		return
+	}
+	// An invite the recipient could never consume is not worth sending.
+	if !inviteEmailAllowed(email, h.cfg.SignupEmailDomains, req.AllowedDomains) {
+		writeError(w, http.StatusBadRequest, "email_domain_not_allowed", "email domain is not allowed")
+		return
+	}
+
+	ctx := r.Context()
+	now := time.Now().UTC()
+
+	sent, oldest, err := countInviteDeliveriesSince(ctx, h.pool, h.schema, claims.UserID, now.Add(-inviteSendWindow))
+	if err != nil {
+		h.log.Error("auth.invite.send.count.fail", "err", err, "result", "server_error")
+		writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+		return
+	}
+	if sent >= h.cfg.InviteSendDailyMax {
+		inviteEmails.With("rate_limited").Inc()
+		writeRateLimitedError(w, oldest.Add(inviteSendWindow).Sub(now), "rate_limited", "daily invite limit reached")
+		return
+	}
+
+	convID, role, ok := h.inviteConversation(ctx, w, claims.UserID, req.ConversationID, req.Role)
+	if !ok {
+		return
+	}
+
+	inv, token, err := h.invites.CreateInvite(ctx, invite.CreateInput{
+		CreatedBy:           &claims.UserID,
+		TTL:                 h.inviteTTL(req.ExpiresInSeconds),
+		MaxUses:             1,
+		Note:                note,
+		ConversationID:      convID,
+		ConversationRole:    role,
+		AllowedEmailDomains: req.AllowedDomains,
+		Now:                 now,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, invite.ErrConversationNotFound):
+			writeError(w, http.StatusNotFound, "conversation_not_found", "conversation not found")
+			return
+		case errors.Is(err, invite.ErrInvalidInput):
+			writeError(w, http.StatusBadRequest, "invalid_request", "invalid allowed_email_domains")
+			return
+		}
+		h.log.Error("auth.invite.send.create.fail", "err", err, "result", "server_error")
+		writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+		return
+	}
+	ip, ua := h.clientIP(r), strings.TrimSpace(r.UserAgent())
+	h.auditInviteCreated(ctx, claims.UserID, inv.ID, ip, ua)
+
+	d := inviteDelivery{
+		InviteID:  inv.ID,
+		Email:     email,
+		Status:    inviteDeliveryPending,
+		CreatedAt: now,
+		ExpiresAt: inv.ExpiresAt,
+	}
+	if err := insertInviteDelivery(ctx, h.pool, h.schema, claims.UserID, d); err != nil {
+		h.log.Error("auth.invite.send.insert.fail", "err", err, "result", "server_error")
+		writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+		return
+	}
+
+	sendErr := h.emailSender.SendInvite(ctx, InviteMessage{
+		InviteID:  inv.ID,
+		InviterID: claims.UserID,
+		Email:     email,
+		Link:      inviteLink(h.cfg.InviteLinkTemplate, token, inv.ID),
+		Note:      note,
+		ExpiresAt: inv.ExpiresAt,
+	})
+	d.Status = inviteDeliverySent
+	var reason *string
+	if sendErr != nil {
+		d.Status = inviteDeliveryFailed
+		msg := truncateRunes(sendErr.Error(), 512)
+		reason = &msg
+	}
+	// The delivery already happened (or failed); a lost status update only leaves it pending.
+	if err := updateInviteDelivery(context.WithoutCancel(ctx), h.pool, h.schema, d.InviteID, d.Status, reason, time.Now().UTC()); err != nil {
+		h.log.Error("auth.invite.send.status.fail", "err", err, "invite_id", d.InviteID, "result", "server_error")
+	}
+	inviteEmails.With(d.Status).Inc()
+	h.auditInviteSent(ctx, claims.UserID, d.InviteID, email, d.Status, ip, ua)
+
+	if sendErr != nil {
+		h.log.Error("auth.invite.send.fail", "err", sendErr, "invite_id", d.InviteID, "result", "server_error")
+		writeError(w, http.StatusServiceUnavailable, "delivery_failed", "invite email could not be sent")
+		return
+	}
+	h.log.Info("auth.invite.send", "invite_id", d.InviteID, "result", "success")
+	writeJSON(w, http.StatusOK, toInviteDeliveryResponse(d))
+}
+
+// handleInviteDeliveries serves GET /auth/invites/deliveries: the caller's most recent
+// emailed invites with their delivery status.
+func (h *Handler) handleInviteDeliveries(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.dbEnabled {
+		writeError(w, http.StatusServiceUnavailable, "db_unavailable", "database not configured")
+		return
+	}
+
+	claims, ok := h.requireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	list, err := listInviteDeliveries(r.Context(), h.pool, h.schema, claims.UserID, 50)
+	if err != nil {
+		h.log.Error("auth.invite.deliveries.fail", "err", err, "result", "server_error")
+		writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+		return
+	}
+	out := inviteDeliveriesResponse{Deliveries: make([]inviteDeliveryResponse, 0, len(list))}
+	for _, d := range list {
+		out.Deliveries = append(out.Deliveries, toInviteDeliveryResponse(d))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// inviteTTL clamps a requested invite lifetime in seconds to the configured bounds.
+func (h *Handler) inviteTTL(expiresInSeconds int64) time.Duration {
+	ttl := h.cfg.InviteTTL
+	if expiresInSeconds > 0 {
+		ttl = time.Duration(expiresInSeconds) * time.Second
+	}
+	if ttl > h.cfg.InviteMaxTTL {
+		ttl = h.cfg.InviteMaxTTL
+	}
+	if ttl <= 0 {
+		ttl = h.cfg.InviteTTL
+	}
+	return ttl
+}
+
+// inviteLink fills the {token} and {invite_id} placeholders of tmpl.
+func inviteLink(tmpl, token, inviteID string) string {
+	return strings.NewReplacer(
+		"{token}", url.QueryEscape(token),
+		"{invite_id}", url.QueryEscape(inviteID),
+	).Replace(tmpl)
+}
+
+// inviteEmailAllowed reports whether email passes the signup allowlist and the
+// invite's own (raw, as requested) allowlist.
+func inviteEmailAllowed(email string, signupDomains, inviteDomains []string) bool {
+	normalized := make([]string, 0, len(inviteDomains))
+	for _, d := range inviteDomains {
+		normalized = append(normalized, invite.NormalizeEmailDomain(d))
+	}
+	return invite.EmailDomainAllowed(email, signupDomains) && invite.EmailDomainAllowed(email, normalized)
+}
+
+// parseInviteEmail normalizes raw and accepts only a bare address (no display name).
+func parseInviteEmail(raw string) (string, bool) {
+	email := identity.NormalizeEmail(raw)
+	if len(email) < 3 || len(email) > 320 {
+		return "", false
+	}
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || addr.Name != "" {
+		return "", false
+	}
+	return email, true
+}
+
+func toInviteDeliveryResponse(d inviteDelivery) inviteDeliveryResponse {
+	return inviteDeliveryResponse{
+		InviteID:       d.InviteID,
+		Email:          d.Email,
+		DeliveryStatus: d.Status,
+		CreatedAt:      d.CreatedAt,
+		ExpiresAt:      d.ExpiresAt,
+	}
+}
+
+// countInviteDeliveriesSince counts the invites userID emailed after since and
+// returns the oldest of them, from which the limit's retry time follows.
+func countInviteDeliveriesSince(ctx context.Context, pool *pgxpool.Pool, schema string, userID string, since time.Time) (int, time.Time, error) {
+	ctx = dbtrace.WithOp(ctx, "authapi.count_invite_deliveries_since")
+	var (
+		n      int
+		oldest *time.Time
+	)
+	err := pool.QueryRow(ctx, `
+		SELECT count(*), min(created_at)
+		FROM `+pgIdent(schema, "invite_deliveries")+`
+		WHERE sent_by = $1 AND created_at > $2
+	`, userID, since).Scan(&n, &oldest)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	if oldest == nil {
+		return n, time.Time{}, nil
+	}
+	return n, *oldest, nil
+}
+
+func insertInviteDelivery(ctx context.Context, pool *pgxpool.Pool, schema string, sentBy string, d inviteDelivery) error {
+	ctx = dbtrace.WithOp(ctx, "authapi.insert_invite_delivery")
+	_, err := pool.Exec(ctx, `
+		INSERT INTO `+pgIdent(schema, "invite_deliveries")+` (
+			invite_id, sent_by, email, status, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $5)
+	`, d.InviteID, sentBy, d.Email, d.Status, d.CreatedAt)
+	return err
+}
+
+func updateInviteDelivery(ctx context.Context, pool *pgxpool.Pool, schema string, inviteID string, status string, reason *string, now time.Time) error {
+	ctx = dbtrace.WithOp(ctx, "authapi.update_invite_delivery")
+	tag, err := pool.Exec(ctx, `
+		UPDATE `+pgIdent(schema, "invite_deliveries")+`
+		SET status = $2, error = $3, updated_at = $4
+		WHERE invite_id = $1
+	`, inviteID, status, reason, now)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("invite delivery not found")
+	}
+	return nil
+}
+
+func listInviteDeliveries(ctx context.Context, pool *pgxpool.Pool, schema string, userID string, limit int) ([]inviteDelivery, error) {
+	ctx = dbtrace.WithOp(ctx, "authapi.list_invite_deliveries")
+	rows, err := pool.Query(ctx, `
+		SELECT d.invite_id, d.email, d.status, d.created_at, i.expires_at
+		FROM `+pgIdent(schema, "invite_deliveries")+` d
+		JOIN `+pgIdent(schema, "invites")+` i ON i.id = d.invite_id
+		WHERE d.sent_by = $1
+		ORDER BY d.created_at DESC
+		LIMIT $2
+	`, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []inviteDelivery
+	for rows.Next() {
+		var d inviteDelivery
+		if err := rows.Scan(&d.InviteID, &d.Email, &d.Status, &d.CreatedAt, &d.ExpiresAt); err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
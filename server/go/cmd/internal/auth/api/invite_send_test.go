@@ -0,0 +1,59 @@
+package authapi
+
+import "testing"
+
+func TestInviteLink(t *testing.T) {
+	got := inviteLink("https://arc.example/join?t={token}&id={invite_id}", "a+b/c", "inv-1")
+	if want := "https://arc.example/join?t=a%2Bb%2Fc&id=inv-1"; got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestParseInviteEmail(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{in: "  Alice@Example.COM ", want: "alice@example.com", ok: true},
+		{in: "not-an-email", ok: false},
+		{in: "Alice <alice@example.com>", ok: false},
+		{in: "", ok: false},
+	}
+	for _, tt := range tests {
+		got, ok := parseInviteEmail(tt.in)
+		if ok != tt.ok || got != tt.want {
+			t.Fatalf("parseInviteEmail(%q) = %q, %v; want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestLoadConfigFromEnv_InviteSend(t *testing.T) {
+	t.Setenv("ARC_AUTH_INVITE_LINK_TEMPLATE", "https://arc.example/join")
+	t.Setenv("ARC_AUTH_INVITE_SEND_DAILY_MAX", "0")
+	t.Setenv("ARC_AUTH_SIGNUP_EMAIL_DOMAINS", "@Example.com, corp.example.")
+
+	cfg := LoadConfigFromEnv()
+
+	if cfg.InviteLinkTemplate != defaultInviteLinkTemplate {
+		t.Fatalf("expected template without {token} to fall back to default, got %q", cfg.InviteLinkTemplate)
+	}
+	if cfg.InviteSendDailyMax <= 0 {
+		t.Fatalf("expected positive daily max, got %d", cfg.InviteSendDailyMax)
+	}
+	if len(cfg.SignupEmailDomains) != 2 || cfg.SignupEmailDomains[0] != "example.com" || cfg.SignupEmailDomains[1] != "corp.example" {
+		t.Fatalf("expected normalized signup domains, got %v", cfg.SignupEmailDomains)
+	}
+}
+
+func TestInviteEmailAllowed(t *testing.T) {
+	if !inviteEmailAllowed("alice@example.com", nil, []string{"@Example.COM"}) {
+		t.Fatal("expected invite allowlist entries to be normalized")
+	}
+	if inviteEmailAllowed("alice@example.com", []string{"corp.example"}, nil) {
+		t.Fatal("expected the signup allowlist to apply")
+	}
+	if !inviteEmailAllowed("alice@example.com", nil, nil) {
+		t.Fatal("expected no allowlists to allow every address")
+	}
+}
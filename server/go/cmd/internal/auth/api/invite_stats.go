@@ -0,0 +1,108 @@
+package authapi
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"arc/cmd/internal/invite"
+)
+
+const (
+	defaultInviteStatsWeeks = 12
+	maxInviteStatsWeeks     = 52
+	defaultInviteStatsTop   = 10
+	maxInviteStatsTop       = 50
+)
+
+type inviteWeekStatsResponse struct {
+	WeekStart time.Time `json:"week_start"`
+	Created   int       `json:"created"`
+	Consumed  int       `json:"consumed"`
+	Expired   int       `json:"expired"`
+}
+
+type inviterStatsResponse struct {
+	UserID   string  `json:"user_id"`
+	Username *string `json:"username,omitempty"`
+	Created  int     `json:"created"`
+	Signups  int     `json:"signups"`
+}
+
+type inviteStatsResponse struct {
+	Since       time.Time                 `json:"since"`
+	Weeks       []inviteWeekStatsResponse `json:"weeks"`
+	TopInviters []inviterStatsResponse    `json:"top_inviters"`
+}
+
+// handleAdminInviteStats serves GET /admin/invites/stats: invites created, consumed
+// and expired per week, oldest first, and the most active inviters over the same
+// window. Query parameters weeks (default 12, max 52) and top (default 10, max 50)
+// size the report.
+func (h *Handler) handleAdminInviteStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.dbEnabled {
+		writeError(w, http.StatusServiceUnavailable, "db_unavailable", "database not configured")
+		return
+	}
+
+	if _, ok := h.requireAdmin(w, r); !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	weeks, ok := queryLimit(q.Get("weeks"), defaultInviteStatsWeeks, maxInviteStatsWeeks)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid_request", "invalid weeks")
+		return
+	}
+	top, ok := queryLimit(q.Get("top"), defaultInviteStatsTop, maxInviteStatsTop)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid_request", "invalid top")
+		return
+	}
+
+	stats, err := h.invites.Stats(r.Context(), invite.StatsInput{
+		Weeks: weeks,
+		TopN:  top,
+		Now:   time.Now().UTC(),
+	})
+	if err != nil {
+		h.log.Error("auth.admin.invite_stats.fail", "err", err, "result", "server_error")
+		writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+		return
+	}
+
+	out := inviteStatsResponse{
+		Weeks:       make([]inviteWeekStatsResponse, 0, len(stats.Weeks)),
+		TopInviters: make([]inviterStatsResponse, 0, len(stats.TopInviters)),
+	}
+	for _, wk := range stats.Weeks {
+		out.Weeks = append(out.Weeks, inviteWeekStatsResponse(wk))
+	}
+	if len(stats.Weeks) > 0 {
+		out.Since = stats.Weeks[0].WeekStart
+	}
+	for _, is := range stats.TopInviters {
+		out.TopInviters = append(out.TopInviters, inviterStatsResponse(is))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// queryLimit parses a positive integer query parameter, defaulting when empty and
+// capping at maxN.
+func queryLimit(raw string, def, maxN int) (int, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return def, true
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	return min(n, maxN), true
+}
@@ -0,0 +1,23 @@
+package authapi
+
+import "testing"
+
+func TestQueryLimit(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want int
+		ok   bool
+	}{
+		{raw: "", want: 12, ok: true},
+		{raw: "4", want: 4, ok: true},
+		{raw: "500", want: 52, ok: true},
+		{raw: "0", ok: false},
+		{raw: "abc", ok: false},
+	}
+	for _, tt := range tests {
+		got, ok := queryLimit(tt.raw, defaultInviteStatsWeeks, maxInviteStatsWeeks)
+		if ok != tt.ok || got != tt.want {
+			t.Fatalf("queryLimit(%q) = %d, %v; want %d, %v", tt.raw, got, ok, tt.want, tt.ok)
+		}
+	}
+}
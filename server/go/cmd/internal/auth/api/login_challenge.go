@@ -0,0 +1,385 @@
+package authapi
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"arc/cmd/identity"
+	"arc/cmd/internal/auth/session"
+	"arc/cmd/internal/dbroute"
+	"arc/cmd/internal/dbtrace"
+	"arc/cmd/internal/geoip"
+	"arc/cmd/security/token"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oklog/ulid/v2"
+)
+
+const loginChallengeCodeDigits = 6
+
+var (
+	errLoginChallengeNotFound = errors.New("login challenge not found")
+	errLoginChallengeInvalid  = errors.New("login challenge code invalid")
+)
+
+// loginChallenge is a pending second step for a login from an unrecognized device.
+type loginChallenge struct {
+	ID          string
+	UserID      string
+	Fingerprint string
+	CodeHash    string
+	Identifier  string
+	Platform    session.Platform
+	RememberMe  bool
+	BindingKey  []byte
+	ExpiresAt   time.Time
+	Attempts    int
+}
+
+// deviceFingerprint derives a stable device key from the user agent, platform and coarse geo.
+//
+// The resolved country is used when available, so a login from a new country is
+// always unrecognized. Otherwise the network prefix (/16 for IPv4, /48 for IPv6)
+// stands in, so routine address churn inside one provider does not trigger a challenge.
+func deviceFingerprint(ua string, platform session.Platform, ip net.IP, loc geoip.Location) string {
+	coarse := coarseNetwork(ip)
+	if loc.Country != "" {
+		coarse = "country:" + loc.Country
+	}
+	return token.HashSHA256Hex(strings.Join([]string{
+		strings.ToLower(strings.TrimSpace(ua)),
+		string(platform),
+		coarse,
+	}, "|"))
+}
+
+func coarseNetwork(ip net.IP) string {
+	if ip == nil {
+		return ""
+	}
+	if v4 := ip.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(16, 32)).String() + "/16"
+	}
+	return ip.Mask(net.CIDRMask(48, 128)).String() + "/48"
+}
+
+// loginChallengeApplies reports whether a login for user may be challenged.
+// Users without an email address cannot receive a code and are never challenged.
+func (h *Handler) loginChallengeApplies(user identity.User) bool {
+	if h == nil || !h.cfg.LoginChallengeEnabled {
+		return false
+	}
+	return hasDeliverableEmail(user)
+}
+
+// hasDeliverableEmail reports whether user can receive a login challenge code.
+func hasDeliverableEmail(user identity.User) bool {
+	return user.Email != nil && strings.TrimSpace(*user.Email) != ""
+}
+
+func (h *Handler) startLoginChallenge(w http.ResponseWriter, r *http.Request, now time.Time, user identity.User, fingerprint string, dev session.DeviceContext, identifier string) {
+	ctx := r.Context()
+
+	code, err := newLoginChallengeCode()
+	if err != nil {
+		h.log.Error("auth.login.challenge.code.fail", "err", err)
+		writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+		return
+	}
+
+	ch := loginChallenge{
+		ID:          ulid.Make().String(),
+		UserID:      user.ID,
+		Fingerprint: fingerprint,
+		Identifier:  identifier,
+		Platform:    dev.Platform,
+		RememberMe:  dev.RememberMe,
+		BindingKey:  dev.BindingKey,
+		ExpiresAt:   now.Add(h.cfg.LoginChallengeTTL),
+	}
+	ch.CodeHash = hashLoginChallengeCode(ch.ID, code)
+
+	if err := insertLoginChallenge(ctx, h.pool, h.schema, now, ch); err != nil {
+		h.log.Error("auth.login.challenge.insert.fail", "err", err)
+		writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+		return
+	}
+
+	if err := h.emailSender.SendLoginChallenge(ctx, LoginChallengeMessage{
+		UserID:      user.ID,
+		Email:       strings.TrimSpace(*user.Email),
+		ChallengeID: ch.ID,
+		Code:        code,
+		ExpiresAt:   ch.ExpiresAt,
+	}); err != nil {
+		h.log.Error("auth.login.challenge.send.fail", "err", err, "user_id", user.ID)
+		writeError(w, http.StatusServiceUnavailable, "server_busy", "please retry later")
+		return
+	}
+
+	h.auditLoginChallengeIssued(ctx, user.ID, ch.ID, dev.IP, dev.UserAgent, identifier)
+
+	writeJSON(w, http.StatusAccepted, loginChallengeResponse{
+		ChallengeRequired: true,
+		ChallengeID:       ch.ID,
+		Method:            "email",
+		ExpiresAt:         ch.ExpiresAt,
+	})
+}
+
+func (h *Handler) handleLoginChallenge(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.dbEnabled {
+		writeError(w, http.StatusServiceUnavailable, "db_unavailable", "database not configured")
+		return
+	}
+	if !h.allowAccess(w, r, "login_challenge") {
+		return
+	}
+
+	var req loginChallengeRequest
+	if err := decodeJSON(w, r, h.cfg.MaxBodyBytes, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_json", "invalid request body")
+		return
+	}
+	challengeID := strings.TrimSpace(req.ChallengeID)
+	code := strings.TrimSpace(req.Code)
+	recoveryCode := strings.TrimSpace(req.RecoveryCode)
+	if challengeID == "" || len(challengeID) > 64 || len(code) > 32 || len(recoveryCode) > 32 || (code == "") == (recoveryCode == "") {
+		writeError(w, http.StatusBadRequest, "invalid_request", "challenge_id and either code or recovery_code are required")
+		return
+	}
+	// A recovery code stands in for the emailed code when the mailbox is unavailable.
+	factor, reason := emailCodeFactor(code), "challenge_invalid"
+	if recoveryCode != "" {
+		factor, reason = recoveryCodeFactor(h.schema, recoveryCode), "recovery_code_invalid"
+	}
+
+	ctx := r.Context()
+	now := time.Now().UTC()
+	ip := h.clientIP(r)
+	ua := strings.TrimSpace(r.UserAgent())
+
+	// Failed codes are audited as login failures, so the login IP throttle covers this endpoint too.
+	if blocked, retryAfter, err := h.checkLoginIPThrottle(ctx, ip, now); err != nil {
+		h.log.Error("auth.login.challenge.throttle_ip.fail", "err", err)
+		writeError(w, http.StatusServiceUnavailable, "server_busy", "please retry later")
+		return
+	} else if blocked {
+		h.auditLoginRateLimited(ctx, nil, ip, ua, "", throttleIP, retryAfter)
+		writeRateLimited(w, retryAfter)
+		return
+	}
+
+	ch, err := consumeLoginChallenge(ctx, h.pool, h.schema, now, challengeID, h.cfg.LoginChallengeMaxAttempts, factor)
+	if err != nil {
+		switch {
+		case errors.Is(err, errLoginChallengeInvalid):
+			h.auditLoginFailed(ctx, &ch.UserID, ip, ua, ch.Identifier, reason)
+			writeError(w, http.StatusUnauthorized, "invalid_challenge", "invalid or expired challenge")
+		case errors.Is(err, errLoginChallengeNotFound):
+			h.auditLoginFailed(ctx, nil, ip, ua, "", "challenge_not_found")
+			writeError(w, http.StatusUnauthorized, "invalid_challenge", "invalid or expired challenge")
+		default:
+			h.log.Error("auth.login.challenge.consume.fail", "err", err)
+			writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+		}
+		return
+	}
+
+	// The lock and disable state must be current, so this read skips the replica.
+	user, err := h.identity.GetUserByID(dbroute.RequirePrimary(ctx), ch.UserID)
+	if err != nil {
+		if identity.IsNotFound(err) {
+			writeError(w, http.StatusUnauthorized, "invalid_challenge", "invalid or expired challenge")
+			return
+		}
+		h.log.Error("auth.login.challenge.user.fail", "err", err)
+		writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+		return
+	}
+	if user.LockedAt != nil {
+		h.auditLoginFailed(ctx, &user.ID, ip, ua, ch.Identifier, "locked")
+		writeError(w, http.StatusForbidden, "account_locked", "account locked")
+		return
+	}
+
+	dev := session.DeviceContext{
+		Platform:   ch.Platform,
+		RememberMe: ch.RememberMe,
+		UserAgent:  ua,
+		IP:         ip,
+		Geo:        h.lookupGeo(ctx, ip),
+		BindingKey: ch.BindingKey,
+	}
+	issued, err := h.sessions.IssueSession(ctx, now, user.ID, dev)
+	if err != nil {
+		h.log.Error("auth.login.challenge.issue_session.fail", "err", err)
+		writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+		return
+	}
+
+	if recoveryCode != "" {
+		h.auditRecoveryCodeUsed(ctx, user.ID, ch.ID, ip, ua)
+	}
+	h.auditLoginChallengePassed(ctx, user.ID, issued.SessionID, ch.ID, ip, ua)
+	h.auditLoginSuccess(ctx, &user.ID, issued.SessionID, ip, ua, ch.Identifier)
+	h.rememberDevice(ctx, now, user.ID, ch.Fingerprint)
+	h.writeLoginSession(w, ch.Platform, user, issued)
+}
+
+// rememberDevice records fingerprint as known for userID. Failures are logged, not surfaced.
+func (h *Handler) rememberDevice(ctx context.Context, now time.Time, userID string, fingerprint string) {
+	if h == nil || !h.cfg.LoginChallengeEnabled || h.pool == nil {
+		return
+	}
+	if err := upsertKnownDevice(ctx, h.pool, h.schema, now, userID, fingerprint); err != nil {
+		h.log.Error("auth.login.known_device.upsert.fail", "err", err, "user_id", userID)
+	}
+}
+
+func newLoginChallengeCode() (string, error) {
+	limit := big.NewInt(1)
+	for i := 0; i < loginChallengeCodeDigits; i++ {
+		limit.Mul(limit, big.NewInt(10))
+	}
+	n, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", loginChallengeCodeDigits, n.Int64()), nil
+}
+
+// hashLoginChallengeCode binds the code to its challenge so equal codes never share a hash.
+func hashLoginChallengeCode(challengeID string, code string) string {
+	return token.HashRefreshTokenHex(challengeID + ":" + code)
+}
+
+// ---- known device / challenge queries ----
+
+// isKnownDevice reports whether fingerprint is known for userID.
+//
+// A user without any known device is treated as known: the first login after
+// enabling challenges enrolls the device instead of locking existing users out.
+func isKnownDevice(ctx context.Context, pool *pgxpool.Pool, schema string, userID string, fingerprint string) (bool, error) {
+	ctx = dbtrace.WithOp(ctx, "authapi.is_known_device")
+	if pool == nil {
+		return true, nil
+	}
+	var matched, hasAny bool
+	err := pool.QueryRow(ctx, `
+		SELECT
+			EXISTS (SELECT 1 FROM `+pgIdent(schema, "user_known_devices")+` WHERE user_id = $1 AND fingerprint = $2),
+			EXISTS (SELECT 1 FROM `+pgIdent(schema, "user_known_devices")+` WHERE user_id = $1)
+	`, userID, fingerprint).Scan(&matched, &hasAny)
+	if err != nil {
+		return false, err
+	}
+	return matched || !hasAny, nil
+}
+
+func upsertKnownDevice(ctx context.Context, pool *pgxpool.Pool, schema string, now time.Time, userID string, fingerprint string) error {
+	ctx = dbtrace.WithOp(ctx, "authapi.upsert_known_device")
+	_, err := pool.Exec(ctx, `
+		INSERT INTO `+pgIdent(schema, "user_known_devices")+` (user_id, fingerprint, first_seen_at, last_seen_at)
+		VALUES ($1, $2, $3, $3)
+		ON CONFLICT (user_id, fingerprint) DO UPDATE SET last_seen_at = EXCLUDED.last_seen_at
+	`, userID, fingerprint, now)
+	return err
+}
+
+func insertLoginChallenge(ctx context.Context, pool *pgxpool.Pool, schema string, now time.Time, ch loginChallenge) error {
+	ctx = dbtrace.WithOp(ctx, "authapi.insert_login_challenge")
+	_, err := pool.Exec(ctx, `
+		INSERT INTO `+pgIdent(schema, "login_challenges")+` (
+			id, user_id, fingerprint, code_hash, identifier, platform, remember_me, binding_key, created_at, expires_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`, ch.ID, ch.UserID, ch.Fingerprint, ch.CodeHash, ch.Identifier, string(ch.Platform), ch.RememberMe, bytesOrNil(ch.BindingKey), now, ch.ExpiresAt)
+	return err
+}
+
+// challengeFactor verifies the second factor presented for a locked challenge row.
+// It runs inside the consuming transaction, so any side effects commit only on success.
+type challengeFactor func(ctx context.Context, tx pgx.Tx, now time.Time, ch loginChallenge) (bool, error)
+
+// emailCodeFactor accepts the code emailed for the challenge.
+func emailCodeFactor(code string) challengeFactor {
+	return func(_ context.Context, _ pgx.Tx, _ time.Time, ch loginChallenge) (bool, error) {
+		want := hashLoginChallengeCode(ch.ID, code)
+		return subtle.ConstantTimeCompare([]byte(want), []byte(ch.CodeHash)) == 1, nil
+	}
+}
+
+// consumeLoginChallenge verifies the factor and marks the challenge consumed in one transaction.
+//
+// A wrong factor increments the attempt counter; the returned challenge still carries
+// UserID/Identifier so callers can audit the failure against the right account.
+func consumeLoginChallenge(ctx context.Context, pool *pgxpool.Pool, schema string, now time.Time, challengeID string, maxAttempts int, verify challengeFactor) (loginChallenge, error) {
+	ctx = dbtrace.WithOp(ctx, "authapi.consume_login_challenge")
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return loginChallenge{}, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var (
+		ch       loginChallenge
+		platform string
+	)
+	err = tx.QueryRow(ctx, `
+		SELECT id, user_id, fingerprint, code_hash, identifier, platform, remember_me, binding_key, expires_at, attempts
+		FROM `+pgIdent(schema, "login_challenges")+`
+		WHERE id = $1
+		  AND consumed_at IS NULL
+		FOR UPDATE
+	`, challengeID).Scan(&ch.ID, &ch.UserID, &ch.Fingerprint, &ch.CodeHash, &ch.Identifier, &platform, &ch.RememberMe, &ch.BindingKey, &ch.ExpiresAt, &ch.Attempts)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return loginChallenge{}, errLoginChallengeNotFound
+		}
+		return loginChallenge{}, err
+	}
+	ch.Platform = session.Platform(platform)
+
+	if !ch.ExpiresAt.After(now) || ch.Attempts >= maxAttempts {
+		return ch, errLoginChallengeInvalid
+	}
+
+	ok, err := verify(ctx, tx, now, ch)
+	if err != nil {
+		return loginChallenge{}, err
+	}
+	if !ok {
+		if _, err := tx.Exec(ctx, `
+			UPDATE `+pgIdent(schema, "login_challenges")+` SET attempts = attempts + 1 WHERE id = $1
+		`, ch.ID); err != nil {
+			return loginChallenge{}, err
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return loginChallenge{}, err
+		}
+		return ch, errLoginChallengeInvalid
+	}
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE `+pgIdent(schema, "login_challenges")+` SET consumed_at = $2 WHERE id = $1
+	`, ch.ID, now); err != nil {
+		return loginChallenge{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return loginChallenge{}, err
+	}
+	return ch, nil
+}
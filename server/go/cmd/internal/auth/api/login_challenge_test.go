@@ -0,0 +1,83 @@
+package authapi
+
+import (
+	"net"
+	"testing"
+
+	"arc/cmd/identity"
+	"arc/cmd/internal/auth/session"
+	"arc/cmd/internal/geoip"
+)
+
+func TestDeviceFingerprint_CoarseNetwork(t *testing.T) {
+	ua := "Mozilla/5.0 Test"
+	a := deviceFingerprint(ua, session.PlatformWeb, net.ParseIP("203.0.113.10"), geoip.Location{})
+	b := deviceFingerprint(" mozilla/5.0 test ", session.PlatformWeb, net.ParseIP("203.0.200.99"), geoip.Location{})
+	if a != b {
+		t.Fatalf("expected same fingerprint inside one /16 network")
+	}
+	if len(a) != 64 {
+		t.Fatalf("expected 64-char hex fingerprint, got %d", len(a))
+	}
+
+	if c := deviceFingerprint(ua, session.PlatformWeb, net.ParseIP("198.51.100.10"), geoip.Location{}); c == a {
+		t.Fatalf("expected different fingerprint for a different network")
+	}
+	if d := deviceFingerprint(ua, session.PlatformIOS, net.ParseIP("203.0.113.10"), geoip.Location{}); d == a {
+		t.Fatalf("expected different fingerprint for a different platform")
+	}
+}
+
+func TestDeviceFingerprint_PrefersCountry(t *testing.T) {
+	ua := "Mozilla/5.0 Test"
+	de := geoip.Location{Country: "DE"}
+	a := deviceFingerprint(ua, session.PlatformWeb, net.ParseIP("203.0.113.10"), de)
+	b := deviceFingerprint(ua, session.PlatformWeb, net.ParseIP("198.51.100.10"), de)
+	if a != b {
+		t.Fatalf("expected same fingerprint for the same country across networks")
+	}
+	if c := deviceFingerprint(ua, session.PlatformWeb, net.ParseIP("203.0.113.10"), geoip.Location{Country: "FR"}); c == a {
+		t.Fatalf("expected different fingerprint for a different country")
+	}
+}
+
+func TestNewLoginChallengeCode(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		code, err := newLoginChallengeCode()
+		if err != nil {
+			t.Fatalf("newLoginChallengeCode: %v", err)
+		}
+		if len(code) != loginChallengeCodeDigits {
+			t.Fatalf("expected %d digits, got %q", loginChallengeCodeDigits, code)
+		}
+		for _, c := range code {
+			if c < '0' || c > '9' {
+				t.Fatalf("expected numeric code, got %q", code)
+			}
+		}
+	}
+}
+
+func TestHashLoginChallengeCode_BoundToChallenge(t *testing.T) {
+	if hashLoginChallengeCode("a", "123456") == hashLoginChallengeCode("b", "123456") {
+		t.Fatalf("expected hash to depend on challenge id")
+	}
+}
+
+func TestLoginChallengeApplies(t *testing.T) {
+	email := "user@example.com"
+	blank := "  "
+
+	h := &Handler{cfg: Config{LoginChallengeEnabled: true}}
+	if !h.loginChallengeApplies(identity.User{Email: &email}) {
+		t.Fatalf("expected challenge for user with email")
+	}
+	if h.loginChallengeApplies(identity.User{Email: &blank}) || h.loginChallengeApplies(identity.User{}) {
+		t.Fatalf("expected no challenge without deliverable email")
+	}
+
+	h.cfg.LoginChallengeEnabled = false
+	if h.loginChallengeApplies(identity.User{Email: &email}) {
+		t.Fatalf("expected no challenge when disabled")
+	}
+}
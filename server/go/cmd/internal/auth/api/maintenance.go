@@ -0,0 +1,96 @@
+package authapi
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"arc/cmd/internal/maintenance"
+)
+
+// maxMaintenanceMessageLen bounds the message echoed in maintenance 503s.
+const maxMaintenanceMessageLen = 512
+
+type maintenanceRequest struct {
+	Enabled           bool    `json:"enabled"`
+	RetryAfterSeconds int     `json:"retry_after_seconds,omitempty"`
+	Message           *string `json:"message,omitempty"`
+}
+
+type maintenanceResponse struct {
+	Enabled           bool      `json:"enabled"`
+	RetryAfterSeconds int       `json:"retry_after_seconds"`
+	Message           string    `json:"message,omitempty"`
+	Since             time.Time `json:"since"`
+	Source            string    `json:"source"`
+}
+
+func toMaintenanceResponse(st maintenance.Status) maintenanceResponse {
+	return maintenanceResponse{
+		Enabled:           st.Enabled,
+		RetryAfterSeconds: st.RetryAfterSeconds(),
+		Message:           st.Message,
+		Since:             st.Since,
+		Source:            st.Source,
+	}
+}
+
+// handleAdminMaintenance reads (GET) or flips (POST) this node's maintenance
+// switch. The app middleware exempts this path so the switch can be turned off.
+func (h *Handler) handleAdminMaintenance(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if h.maintenance == nil {
+		writeError(w, http.StatusNotFound, "not_found", "maintenance mode not available")
+		return
+	}
+
+	claims, ok := h.requireAdmin(w, r)
+	if !ok {
+		return
+	}
+	if r.Method == http.MethodGet {
+		writeJSON(w, http.StatusOK, toMaintenanceResponse(h.maintenance.Status()))
+		return
+	}
+
+	var req maintenanceRequest
+	if err := decodeJSON(w, r, h.cfg.MaxBodyBytes, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_json", "invalid request body")
+		return
+	}
+	if req.RetryAfterSeconds < 0 {
+		writeError(w, http.StatusBadRequest, "invalid_request", "retry_after_seconds must not be negative")
+		return
+	}
+
+	prev := h.maintenance.Status()
+	next := maintenance.Status{
+		Enabled:    req.Enabled,
+		RetryAfter: prev.RetryAfter,
+		Message:    prev.Message,
+		Source:     "admin",
+	}
+	if req.RetryAfterSeconds > 0 {
+		next.RetryAfter = time.Duration(req.RetryAfterSeconds) * time.Second
+	}
+	if req.Message != nil {
+		next.Message = truncateRunes(strings.TrimSpace(*req.Message), maxMaintenanceMessageLen)
+	}
+	h.maintenance.Set(next)
+	st := h.maintenance.Status()
+
+	action := "admin.maintenance.disabled"
+	if st.Enabled {
+		action = "admin.maintenance.enabled"
+	}
+	h.log.Warn(action, "admin_id", claims.UserID, "retry_after", st.RetryAfter, "result", "success")
+	h.insertAudit(r.Context(), action, &claims.UserID, nil, h.clientIP(r), strings.TrimSpace(r.UserAgent()), map[string]any{
+		"retry_after_seconds": st.RetryAfterSeconds(),
+		"message":             st.Message,
+	})
+
+	writeJSON(w, http.StatusOK, toMaintenanceResponse(st))
+}
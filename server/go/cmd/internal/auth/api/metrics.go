@@ -0,0 +1,23 @@
+package authapi
+
+import "arc/cmd/internal/metrics"
+
+// The login and refresh audit helpers increment these, so each outcome is counted
+// once whether or not the database audit log is written.
+var (
+	loginTotal = metrics.NewCounterVec("arc_auth_login_total",
+		"Login attempts by outcome: success, challenge_issued, rate_limited or the failure reason.", "outcome")
+	refreshRotations = metrics.NewCounter("arc_auth_refresh_rotations_total",
+		"Refresh tokens rotated.")
+	inviteEmails = metrics.NewCounterVec("arc_auth_invite_emails_total",
+		"Invite emails by result: sent, failed or rate_limited.", "result")
+	phoneOTPDeliveries = metrics.NewCounterVec("arc_auth_otp_deliveries_total",
+		"Phone codes by channel (sms, email) and result: sent, failed or rate_limited.", "channel", "result")
+	refreshReuseDetected = metrics.NewCounter("arc_auth_refresh_reuse_detected_total",
+		"Rotated refresh tokens presented again; each revokes all of the user's sessions.")
+)
+
+// legacyRouteRequests shows which clients still need to move to /v1 before the
+// unversioned routes are switched off.
+var legacyRouteRequests = metrics.NewCounterVec("arc_auth_legacy_route_requests_total",
+	"Requests served at deprecated unversioned routes, by route pattern.", "route")
@@ -1,6 +1,11 @@
 package authapi
 
-import "time"
+import (
+	"time"
+
+	"arc/cmd/internal/auth/session"
+	"arc/cmd/internal/geoip"
+)
 
 type loginRequest struct {
 	Username   *string `json:"username"`
@@ -9,18 +14,38 @@ type loginRequest struct {
 	Captcha    string  `json:"captcha_token"`
 	RememberMe bool    `json:"remember_me"`
 	Platform   string  `json:"platform"`
+	// BindingKey is an optional base64url Ed25519 public key to bind the refresh token to.
+	BindingKey string `json:"binding_key"`
 }
 
 type refreshRequest struct {
 	RefreshToken string `json:"refresh_token"`
 	RememberMe   bool   `json:"remember_me"`
 	Platform     string `json:"platform"`
+	// BindingProof is the base64url signature over the session's binding nonce (bound sessions only).
+	BindingProof string `json:"binding_proof"`
+}
+
+type accessRenewRequest struct {
+	RefreshToken string `json:"refresh_token"`
+	BindingProof string `json:"binding_proof"`
+}
+
+type logoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type refreshNonceRequest struct {
+	RefreshToken string `json:"refresh_token"`
 }
 
 type inviteCreateRequest struct {
-	ExpiresInSeconds int64   `json:"expires_in_seconds"`
-	MaxUses          int     `json:"max_uses"`
-	Note             *string `json:"note"`
+	ExpiresInSeconds int64    `json:"expires_in_seconds"`
+	MaxUses          int      `json:"max_uses"`
+	Note             *string  `json:"note"`
+	ConversationID   *string  `json:"conversation_id"`
+	Role             string   `json:"role"`
+	AllowedDomains   []string `json:"allowed_email_domains"`
 }
 
 type inviteConsumeRequest struct {
@@ -38,6 +63,8 @@ type userResponse struct {
 	Username        *string    `json:"username"`
 	Email           *string    `json:"email"`
 	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+	Phone           *string    `json:"phone,omitempty"`
+	PhoneVerifiedAt *time.Time `json:"phone_verified_at,omitempty"`
 	DisplayName     *string    `json:"display_name"`
 	Bio             *string    `json:"bio"`
 	CreatedAt       time.Time  `json:"created_at"`
@@ -49,6 +76,7 @@ type sessionResponse struct {
 	AccessExpiresAt  time.Time `json:"access_expires_at"`
 	RefreshToken     string    `json:"refresh_token"`
 	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+	BindingNonce     string    `json:"binding_nonce,omitempty"`
 }
 
 type loginResponse struct {
@@ -56,22 +84,256 @@ type loginResponse struct {
 	Session sessionResponse `json:"session"`
 }
 
+type loginChallengeResponse struct {
+	ChallengeRequired bool      `json:"challenge_required"`
+	ChallengeID       string    `json:"challenge_id"`
+	Method            string    `json:"method"`
+	ExpiresAt         time.Time `json:"expires_at"`
+}
+
+type loginChallengeRequest struct {
+	ChallengeID  string `json:"challenge_id"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+type otpRequestRequest struct {
+	Phone string `json:"phone"`
+}
+
+type otpRequestResponse struct {
+	OTPID string `json:"otp_id"`
+	// Channel is "sms", or "email" when the SMS senders failed and the code was emailed.
+	Channel   string    `json:"channel"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type otpVerifyRequest struct {
+	OTPID      string `json:"otp_id"`
+	Code       string `json:"code"`
+	RememberMe bool   `json:"remember_me"`
+	Platform   string `json:"platform"`
+	// BindingKey is an optional base64url Ed25519 public key to bind the refresh token to.
+	BindingKey string `json:"binding_key"`
+}
+
+type phoneVerifyRequest struct {
+	OTPID string `json:"otp_id"`
+	Code  string `json:"code"`
+}
+
+type recoveryCodesRegenerateRequest struct {
+	Password string `json:"password"`
+}
+
+type recoveryCodesResponse struct {
+	Codes     []string `json:"codes,omitempty"`
+	Remaining int      `json:"remaining"`
+}
+
+type activeSessionResponse struct {
+	ID         string          `json:"id"`
+	Platform   string          `json:"platform"`
+	CreatedAt  time.Time       `json:"created_at"`
+	LastUsedAt *time.Time      `json:"last_used_at,omitempty"`
+	ExpiresAt  time.Time       `json:"expires_at"`
+	UserAgent  string          `json:"user_agent,omitempty"`
+	IP         string          `json:"ip,omitempty"`
+	Location   *geoip.Location `json:"location,omitempty"`
+	Current    bool            `json:"current"`
+}
+
+type meSessionsResponse struct {
+	Sessions []activeSessionResponse `json:"sessions"`
+}
+
 type refreshResponse struct {
 	Session sessionResponse `json:"session"`
 }
 
+type accessRenewResponse struct {
+	SessionID       string    `json:"session_id"`
+	AccessToken     string    `json:"access_token"`
+	AccessExpiresAt time.Time `json:"access_expires_at"`
+	BindingNonce    string    `json:"binding_nonce,omitempty"`
+}
+
+// tokenExchangeRequest follows the RFC 8693 parameter names.
+type tokenExchangeRequest struct {
+	GrantType          string `json:"grant_type"`
+	SubjectToken       string `json:"subject_token"`
+	SubjectTokenType   string `json:"subject_token_type"`
+	RequestedTokenType string `json:"requested_token_type,omitempty"`
+	Audience           string `json:"audience"`
+	Scope              string `json:"scope,omitempty"`
+}
+
+type tokenExchangeResponse struct {
+	AccessToken     string `json:"access_token"`
+	IssuedTokenType string `json:"issued_token_type"`
+	TokenType       string `json:"token_type"`
+	ExpiresIn       int64  `json:"expires_in"`
+	Scope           string `json:"scope,omitempty"`
+}
+
+type refreshNonceResponse struct {
+	BindingNonce string `json:"binding_nonce"`
+}
+
+type wsTicketResponse struct {
+	Ticket    string    `json:"ticket"`
+	ExpiresAt time.Time `json:"expires_at"`
+	ExpiresIn int64     `json:"expires_in"`
+}
+
 type meResponse struct {
 	User userResponse `json:"user"`
 }
 
 type inviteCreateResponse struct {
-	InviteID    string    `json:"invite_id"`
-	InviteToken string    `json:"invite_token"`
-	ExpiresAt   time.Time `json:"expires_at"`
+	InviteID       string    `json:"invite_id"`
+	InviteToken    string    `json:"invite_token"`
+	ExpiresAt      time.Time `json:"expires_at"`
+	ConversationID *string   `json:"conversation_id,omitempty"`
+	Role           *string   `json:"role,omitempty"`
 }
 
 type inviteConsumeResponse struct {
-	User     userResponse    `json:"user"`
-	Session  sessionResponse `json:"session"`
-	InviteID string          `json:"invite_id"`
+	User           userResponse    `json:"user"`
+	Session        sessionResponse `json:"session"`
+	InviteID       string          `json:"invite_id"`
+	ConversationID *string         `json:"conversation_id,omitempty"`
+}
+
+type adminSessionsRevokeRequest struct {
+	Platform      string     `json:"platform"`
+	CreatedBefore *time.Time `json:"created_before"`
+	IPCIDR        string     `json:"ip_cidr"`
+	UserIDs       []string   `json:"user_ids"`
+	DryRun        bool       `json:"dry_run"`
+}
+
+type adminUserLockRequest struct {
+	Reason *string `json:"reason"`
+}
+
+type adminSessionsRevokeResponse struct {
+	Matched int64 `json:"matched"`
+	Revoked int64 `json:"revoked"`
+	Batches int   `json:"batches"`
+	DryRun  bool  `json:"dry_run"`
+}
+
+type pinReportRequest struct {
+	Hostname          string     `json:"hostname"`
+	Port              int        `json:"port"`
+	Platform          string     `json:"platform"`
+	AppVersion        string     `json:"app_version"`
+	KnownPins         []string   `json:"known_pins"`
+	ServedChainPins   []string   `json:"served_chain_pins"`
+	FailureReason     string     `json:"failure_reason"`
+	OccurredAt        *time.Time `json:"occurred_at"`
+	IncludeSubdomains bool       `json:"include_subdomains"`
+}
+
+type securityEventResponse struct {
+	ID        int64          `json:"id"`
+	Kind      string         `json:"kind"`
+	CreatedAt time.Time      `json:"created_at"`
+	IP        string         `json:"ip,omitempty"`
+	UserAgent string         `json:"user_agent,omitempty"`
+	Platform  string         `json:"platform"`
+	Meta      map[string]any `json:"meta,omitempty"`
+}
+
+type securityEventsResponse struct {
+	Events []securityEventResponse `json:"events"`
+}
+
+type reuseIncidentPresenter struct {
+	At        *time.Time `json:"at,omitempty"`
+	IP        string     `json:"ip,omitempty"`
+	UserAgent string     `json:"user_agent,omitempty"`
+	Platform  string     `json:"platform,omitempty"`
+}
+
+type reuseIncidentResponse struct {
+	ID              int64                     `json:"id"`
+	UserID          string                    `json:"user_id"`
+	DetectedAt      time.Time                 `json:"detected_at"`
+	Endpoint        string                    `json:"endpoint"`
+	ReusedSessionID string                    `json:"reused_session_id"`
+	Presenter       reuseIncidentPresenter    `json:"presenter"`
+	Rotated         *reuseIncidentPresenter   `json:"rotated,omitempty"`
+	SessionsRevoked int64                     `json:"sessions_revoked"`
+	SessionChain    []session.ReuseChainEntry `json:"session_chain"`
+}
+
+type reuseIncidentsResponse struct {
+	Incidents []reuseIncidentResponse `json:"incidents"`
+}
+
+type privacyExportResponse struct {
+	ExportID    string     `json:"export_id"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	DownloadURL string     `json:"download_url,omitempty"`
+}
+
+// privacyArchive is the JSON document delivered by a privacy export.
+type privacyArchive struct {
+	Format      string                     `json:"format"`
+	GeneratedAt time.Time                  `json:"generated_at"`
+	User        userResponse               `json:"user"`
+	Sessions    []privacyArchiveSession    `json:"sessions"`
+	AuditEvents []privacyArchiveAuditEvent `json:"audit_events"`
+	Messages    []privacyArchiveMessage    `json:"messages"`
+}
+
+type privacyArchiveSession struct {
+	ID               string     `json:"id"`
+	Platform         string     `json:"platform"`
+	CreatedAt        time.Time  `json:"created_at"`
+	LastUsedAt       *time.Time `json:"last_used_at,omitempty"`
+	ExpiresAt        time.Time  `json:"expires_at"`
+	RevokedAt        *time.Time `json:"revoked_at,omitempty"`
+	RevocationReason string     `json:"revocation_reason,omitempty"`
+	UserAgent        string     `json:"user_agent,omitempty"`
+	IP               string     `json:"ip,omitempty"`
+}
+
+type privacyArchiveAuditEvent struct {
+	Action    string         `json:"action"`
+	CreatedAt time.Time      `json:"created_at"`
+	SessionID string         `json:"session_id,omitempty"`
+	IP        string         `json:"ip,omitempty"`
+	UserAgent string         `json:"user_agent,omitempty"`
+	Meta      map[string]any `json:"meta,omitempty"`
+}
+
+type privacyArchiveMessage struct {
+	ConversationID string    `json:"conversation_id"`
+	ServerMsgID    string    `json:"server_msg_id"`
+	ClientMsgID    string    `json:"client_msg_id"`
+	Seq            int64     `json:"seq"`
+	SessionID      string    `json:"session_id"`
+	Text           string    `json:"text"`
+	ServerTS       time.Time `json:"server_ts"`
+}
+
+type userPurgeJobResponse struct {
+	JobID            string     `json:"job_id"`
+	TargetUserID     string     `json:"target_user_id"`
+	Status           string     `json:"status"`
+	CurrentStep      string     `json:"current_step,omitempty"`
+	StepsCompleted   int        `json:"steps_completed"`
+	StepsTotal       int        `json:"steps_total"`
+	MessagesScrubbed int64      `json:"messages_scrubbed"`
+	InvitesRevoked   int64      `json:"invites_revoked"`
+	CreatedAt        time.Time  `json:"created_at"`
+	StartedAt        *time.Time `json:"started_at,omitempty"`
+	CompletedAt      *time.Time `json:"completed_at,omitempty"`
+	Error            string     `json:"error,omitempty"`
 }
@@ -0,0 +1,656 @@
+package authapi
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"arc/cmd/identity"
+	"arc/cmd/internal/auth/session"
+	"arc/cmd/internal/dbroute"
+	"arc/cmd/internal/dbtrace"
+	"arc/cmd/internal/featureflags"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oklog/ulid/v2"
+)
+
+// Purposes of a phone code: signing in, or confirming a number added at /me/phone.
+const (
+	phoneOTPLogin = "login"
+	phoneOTPLink  = "link"
+)
+
+// Channels a phone code was delivered over.
+const (
+	otpChannelSMS   = "sms"
+	otpChannelEmail = "email"
+)
+
+var (
+	errPhoneOTPNotFound = errors.New("phone otp not found")
+	errPhoneOTPInvalid  = errors.New("phone otp code invalid")
+)
+
+// phoneOTP is one code sent to a phone number.
+type phoneOTP struct {
+	ID      string
+	Purpose string
+	Phone   string
+	// PhoneHash is identifierHash(Phone), which the per-number throttle matches on.
+	PhoneHash string
+	// UserID is the account the code signs in or links to; nil for a login code
+	// requested for a number no account has, which never verifies.
+	UserID    *string
+	CodeHash  string
+	Channel   string
+	IP        net.IP
+	ExpiresAt time.Time
+	Attempts  int
+}
+
+// handleOTPRequest serves POST /auth/otp/request: it sends a login code by SMS to the
+// account with the given phone number. Numbers without an account get the same
+// response and count towards the same limits, but no code is sent.
+func (h *Handler) handleOTPRequest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.phoneLoginAvailable(w, r) {
+		return
+	}
+	if !h.allowAccess(w, r, "otp_request") {
+		return
+	}
+
+	var req otpRequestRequest
+	if err := decodeJSON(w, r, h.cfg.MaxBodyBytes, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_json", "invalid request body")
+		return
+	}
+	phone, ok := identity.NormalizePhone(req.Phone)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid_phone", "phone must be an international number such as +14155550100")
+		return
+	}
+
+	ctx := r.Context()
+	now := time.Now().UTC()
+	ip := h.clientIP(r)
+	ua := strings.TrimSpace(r.UserAgent())
+
+	if !h.checkPhoneOTPThrottle(ctx, w, phone, ip, now) {
+		return
+	}
+
+	var user *identity.User
+	u, err := h.identity.GetUserByPhone(ctx, phone)
+	switch {
+	case err == nil:
+		user = &u
+	case !identity.IsNotFound(err):
+		h.log.Error("auth.otp.request.user.fail", "err", err, "result", "server_error")
+		writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+		return
+	}
+
+	otp, code, err := h.newPhoneOTP(now, phoneOTPLogin, phone, user, ip)
+	if err != nil {
+		h.log.Error("auth.otp.request.code.fail", "err", err, "result", "server_error")
+		writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+		return
+	}
+	if err := insertPhoneOTP(ctx, h.pool, h.schema, now, otp); err != nil {
+		h.log.Error("auth.otp.request.insert.fail", "err", err, "result", "server_error")
+		writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+		return
+	}
+
+	if user == nil {
+		h.log.Info("auth.otp.request", "otp_id", otp.ID, "result", "unknown_phone")
+		h.writePhoneOTP(w, otp)
+		return
+	}
+
+	var email string
+	if h.cfg.PhoneOTPEmailFallback && hasDeliverableEmail(*user) {
+		email = strings.TrimSpace(*user.Email)
+	}
+	if !h.sendPhoneOTP(ctx, w, &otp, code, email) {
+		return
+	}
+	h.auditPhoneOTPSent(ctx, user.ID, otp, ip, ua)
+	h.writePhoneOTP(w, otp)
+}
+
+// handleOTPVerify serves POST /auth/otp/verify: a valid login code signs the owner of
+// the number in, like POST /auth/login.
+func (h *Handler) handleOTPVerify(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.phoneLoginAvailable(w, r) {
+		return
+	}
+	if !h.allowAccess(w, r, "otp_verify") {
+		return
+	}
+
+	var req otpVerifyRequest
+	if err := decodeJSON(w, r, h.cfg.MaxBodyBytes, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_json", "invalid request body")
+		return
+	}
+	otpID, code, ok := normalizeOTPCode(req.OTPID, req.Code)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid_request", "otp_id and code are required")
+		return
+	}
+	platform := normalizePlatform(req.Platform)
+	bindingKey, ok := h.loginBindingKey(w, req.BindingKey, platform)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	now := time.Now().UTC()
+	ip := h.clientIP(r)
+	ua := strings.TrimSpace(r.UserAgent())
+
+	// Wrong codes are audited as login failures, so the login IP throttle covers this endpoint too.
+	if blocked, retryAfter, err := h.checkLoginIPThrottle(ctx, ip, now); err != nil {
+		h.log.Error("auth.otp.verify.throttle_ip.fail", "err", err)
+		writeError(w, http.StatusServiceUnavailable, "server_busy", "please retry later")
+		return
+	} else if blocked {
+		h.auditLoginRateLimited(ctx, nil, ip, ua, "", throttleIP, retryAfter)
+		writeRateLimited(w, retryAfter)
+		return
+	}
+
+	otp, err := consumePhoneOTP(ctx, h.pool, h.schema, now, otpID, phoneOTPLogin, "", code, h.cfg.PhoneOTPMaxAttempts)
+	if err != nil {
+		switch {
+		case errors.Is(err, errPhoneOTPInvalid):
+			h.auditLoginFailed(ctx, otp.UserID, ip, ua, otp.Phone, "otp_invalid")
+			writeError(w, http.StatusUnauthorized, "invalid_otp", "invalid or expired code")
+		case errors.Is(err, errPhoneOTPNotFound):
+			h.auditLoginFailed(ctx, nil, ip, ua, "", "otp_not_found")
+			writeError(w, http.StatusUnauthorized, "invalid_otp", "invalid or expired code")
+		default:
+			h.log.Error("auth.otp.verify.consume.fail", "err", err)
+			writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+		}
+		return
+	}
+
+	// The lock state and phone must be current, so this read skips the replica.
+	user, err := h.identity.GetUserByID(dbroute.RequirePrimary(ctx), *otp.UserID)
+	if err != nil {
+		if identity.IsNotFound(err) {
+			writeError(w, http.StatusUnauthorized, "invalid_otp", "invalid or expired code")
+			return
+		}
+		h.log.Error("auth.otp.verify.user.fail", "err", err)
+		writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+		return
+	}
+	// The number may have moved to another account or been removed since the code was sent.
+	if user.Phone == nil || *user.Phone != otp.Phone {
+		h.auditLoginFailed(ctx, &user.ID, ip, ua, otp.Phone, "otp_phone_changed")
+		writeError(w, http.StatusUnauthorized, "invalid_otp", "invalid or expired code")
+		return
+	}
+	if user.LockedAt != nil {
+		h.auditLoginFailed(ctx, &user.ID, ip, ua, otp.Phone, "locked")
+		writeError(w, http.StatusForbidden, "account_locked", "account locked")
+		return
+	}
+
+	dev := session.DeviceContext{
+		Platform:   platform,
+		RememberMe: req.RememberMe,
+		UserAgent:  ua,
+		IP:         ip,
+		Geo:        h.lookupGeo(ctx, ip),
+		BindingKey: bindingKey,
+	}
+	issued, err := h.sessions.IssueSession(ctx, now, user.ID, dev)
+	if err != nil {
+		if errors.Is(err, session.ErrBindingRequired) {
+			writeError(w, http.StatusBadRequest, "binding_required", "binding_key is required for this platform")
+			return
+		}
+		h.log.Error("auth.otp.verify.issue_session.fail", "err", err)
+		writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+		return
+	}
+
+	h.auditLoginSuccess(ctx, &user.ID, issued.SessionID, ip, ua, otp.Phone)
+	// Possession of the number proves the device as well as an emailed challenge would.
+	h.rememberDevice(ctx, now, user.ID, deviceFingerprint(ua, platform, ip, dev.Geo))
+	h.writeLoginSession(w, platform, user, issued)
+}
+
+// handleMePhone serves /me/phone. POST sends a code by SMS to a number the caller
+// wants to add (confirmed at POST /me/phone/verify); DELETE removes the caller's number.
+func (h *Handler) handleMePhone(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.dbEnabled {
+		writeError(w, http.StatusServiceUnavailable, "db_unavailable", "database not configured")
+		return
+	}
+	claims, ok := h.requireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	now := time.Now().UTC()
+	ip := h.clientIP(r)
+	ua := strings.TrimSpace(r.UserAgent())
+
+	if r.Method == http.MethodDelete {
+		if err := h.identity.SetUserPhone(ctx, claims.UserID, nil, now); err != nil {
+			if identity.IsNotFound(err) {
+				writeError(w, http.StatusUnauthorized, "unauthorized", "invalid session")
+				return
+			}
+			h.log.Error("auth.me.phone.remove.fail", "err", err, "result", "server_error")
+			writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+			return
+		}
+		h.insertAudit(ctx, "auth.phone.removed", &claims.UserID, &claims.SessionID, ip, ua, nil)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if !h.cfg.PhoneOTPEnabled {
+		writeError(w, http.StatusServiceUnavailable, "phone_otp_unavailable", "phone numbers are not enabled")
+		return
+	}
+	var req otpRequestRequest
+	if err := decodeJSON(w, r, h.cfg.MaxBodyBytes, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_json", "invalid request body")
+		return
+	}
+	phone, ok := identity.NormalizePhone(req.Phone)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid_phone", "phone must be an international number such as +14155550100")
+		return
+	}
+	if !h.checkPhoneOTPThrottle(ctx, w, phone, ip, now) {
+		return
+	}
+
+	switch owner, err := h.identity.GetUserByPhone(ctx, phone); {
+	case err == nil && owner.ID != claims.UserID:
+		writeError(w, http.StatusConflict, "phone_taken", "phone number is already in use")
+		return
+	case err != nil && !identity.IsNotFound(err):
+		h.log.Error("auth.me.phone.lookup.fail", "err", err, "result", "server_error")
+		writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+		return
+	}
+
+	user := identity.User{ID: claims.UserID}
+	otp, code, err := h.newPhoneOTP(now, phoneOTPLink, phone, &user, ip)
+	if err != nil {
+		h.log.Error("auth.me.phone.code.fail", "err", err, "result", "server_error")
+		writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+		return
+	}
+	if err := insertPhoneOTP(ctx, h.pool, h.schema, now, otp); err != nil {
+		h.log.Error("auth.me.phone.insert.fail", "err", err, "result", "server_error")
+		writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+		return
+	}
+	// Only an SMS proves possession of the number, so there is no email fallback here.
+	if !h.sendPhoneOTP(ctx, w, &otp, code, "") {
+		return
+	}
+	h.auditPhoneOTPSent(ctx, user.ID, otp, ip, ua)
+	h.writePhoneOTP(w, otp)
+}
+
+// handleMePhoneVerify serves POST /me/phone/verify: a valid link code sets the
+// caller's phone number.
+func (h *Handler) handleMePhoneVerify(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.dbEnabled {
+		writeError(w, http.StatusServiceUnavailable, "db_unavailable", "database not configured")
+		return
+	}
+	if !h.cfg.PhoneOTPEnabled {
+		writeError(w, http.StatusServiceUnavailable, "phone_otp_unavailable", "phone numbers are not enabled")
+		return
+	}
+	claims, ok := h.requireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	var req phoneVerifyRequest
+	if err := decodeJSON(w, r, h.cfg.MaxBodyBytes, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_json", "invalid request body")
+		return
+	}
+	otpID, code, ok := normalizeOTPCode(req.OTPID, req.Code)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid_request", "otp_id and code are required")
+		return
+	}
+
+	ctx := r.Context()
+	now := time.Now().UTC()
+	ip := h.clientIP(r)
+	ua := strings.TrimSpace(r.UserAgent())
+
+	otp, err := consumePhoneOTP(ctx, h.pool, h.schema, now, otpID, phoneOTPLink, claims.UserID, code, h.cfg.PhoneOTPMaxAttempts)
+	if err != nil {
+		if errors.Is(err, errPhoneOTPInvalid) || errors.Is(err, errPhoneOTPNotFound) {
+			writeError(w, http.StatusBadRequest, "invalid_otp", "invalid or expired code")
+			return
+		}
+		h.log.Error("auth.me.phone.consume.fail", "err", err, "result", "server_error")
+		writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+		return
+	}
+
+	if err := h.identity.SetUserPhone(ctx, claims.UserID, &otp.Phone, now); err != nil {
+		switch {
+		case identity.IsConflict(err):
+			writeError(w, http.StatusConflict, "phone_taken", "phone number is already in use")
+		case identity.IsNotFound(err):
+			writeError(w, http.StatusUnauthorized, "unauthorized", "invalid session")
+		default:
+			h.log.Error("auth.me.phone.set.fail", "err", err, "result", "server_error")
+			writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+		}
+		return
+	}
+	h.insertAudit(ctx, "auth.phone.linked", &claims.UserID, &claims.SessionID, ip, ua, map[string]any{
+		"otp_id":     otp.ID,
+		"identifier": otp.Phone,
+	})
+
+	user, err := h.identity.GetUserByID(dbroute.RequirePrimary(ctx), claims.UserID)
+	if err != nil {
+		h.log.Error("auth.me.phone.user.fail", "err", err, "result", "server_error")
+		writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+		return
+	}
+	writeJSON(w, http.StatusOK, meResponse{User: toUserResponse(user)})
+}
+
+// phoneLoginAvailable rejects the request unless phone login is enabled. A code
+// alone is a single factor, so phone login is also refused while MFA is required.
+func (h *Handler) phoneLoginAvailable(w http.ResponseWriter, r *http.Request) bool {
+	if !h.dbEnabled {
+		writeError(w, http.StatusServiceUnavailable, "db_unavailable", "database not configured")
+		return false
+	}
+	if !h.cfg.PhoneOTPEnabled {
+		writeError(w, http.StatusServiceUnavailable, "phone_otp_unavailable", "phone login is not enabled")
+		return false
+	}
+	if h.flags.Enabled(r.Context(), featureflags.AuthMFARequired) {
+		writeError(w, http.StatusForbidden, "mfa_required", "phone login is unavailable while multi-factor authentication is required")
+		return false
+	}
+	return true
+}
+
+// checkPhoneOTPThrottle enforces the per-number and per-IP limits on codes sent,
+// writing the error response when one is exceeded.
+func (h *Handler) checkPhoneOTPThrottle(ctx context.Context, w http.ResponseWriter, phone string, ip net.IP, now time.Time) bool {
+	cfg := h.cfg
+	since := now.Add(-cfg.PhoneOTPWindow)
+
+	sent, err := recentPhoneOTPTimes(ctx, h.pool, h.schema, "phone_hash", h.identifierHash(phone), since, cfg.PhoneOTPPhoneMax)
+	blocked, retryAfter := evaluateWindowThrottle(now, sent, cfg.PhoneOTPPhoneMax, cfg.PhoneOTPWindow)
+	if err == nil && !blocked && ip != nil {
+		sent, err = recentPhoneOTPTimes(ctx, h.pool, h.schema, "ip", ip.String(), since, cfg.PhoneOTPIPMax)
+		blocked, retryAfter = evaluateWindowThrottle(now, sent, cfg.PhoneOTPIPMax, cfg.PhoneOTPWindow)
+	}
+	if err != nil {
+		h.log.Error("auth.otp.throttle.fail", "err", err)
+		writeError(w, http.StatusServiceUnavailable, "server_busy", "please retry later")
+		return false
+	}
+	if blocked {
+		phoneOTPDeliveries.With(otpChannelSMS, "rate_limited").Inc()
+		writeRateLimitedError(w, retryAfter, "rate_limited", "too many codes requested")
+		return false
+	}
+	return true
+}
+
+// newPhoneOTP builds a code row for phone and returns it with the plain code.
+func (h *Handler) newPhoneOTP(now time.Time, purpose string, phone string, user *identity.User, ip net.IP) (phoneOTP, string, error) {
+	code, err := newLoginChallengeCode()
+	if err != nil {
+		return phoneOTP{}, "", err
+	}
+	otp := phoneOTP{
+		ID:        ulid.Make().String(),
+		Purpose:   purpose,
+		Phone:     phone,
+		PhoneHash: h.identifierHash(phone),
+		Channel:   otpChannelSMS,
+		IP:        ip,
+		ExpiresAt: now.Add(h.cfg.PhoneOTPTTL),
+	}
+	if user != nil {
+		otp.UserID = &user.ID
+	}
+	otp.CodeHash = hashLoginChallengeCode(otp.ID, code)
+	return otp, code, nil
+}
+
+// sendPhoneOTP delivers code and records the channel used on otp, writing a 503 when
+// every channel failed.
+func (h *Handler) sendPhoneOTP(ctx context.Context, w http.ResponseWriter, otp *phoneOTP, code string, fallbackEmail string) bool {
+	channel, err := h.deliverPhoneOTP(ctx, *otp, code, fallbackEmail)
+	if err != nil {
+		h.log.Error("auth.otp.send.fail", "err", err, "otp_id", otp.ID, "result", "delivery_failed")
+		writeError(w, http.StatusServiceUnavailable, "otp_delivery_failed", "the code could not be sent, please retry later")
+		return false
+	}
+	otp.Channel = channel
+	if channel != otpChannelSMS {
+		if err := setPhoneOTPChannel(ctx, h.pool, h.schema, otp.ID, channel); err != nil {
+			h.log.Error("auth.otp.channel.update.fail", "err", err, "otp_id", otp.ID)
+		}
+	}
+	return true
+}
+
+// deliverPhoneOTP tries each SMS sender in order, then, when fallbackEmail is set,
+// emails the code instead. It returns the channel that accepted the code.
+func (h *Handler) deliverPhoneOTP(ctx context.Context, otp phoneOTP, code string, fallbackEmail string) (string, error) {
+	var errs []error
+	for i, sender := range h.smsSenders {
+		err := sender.SendOTP(ctx, PhoneOTPMessage{
+			Phone:     otp.Phone,
+			OTPID:     otp.ID,
+			Code:      code,
+			Purpose:   otp.Purpose,
+			ExpiresAt: otp.ExpiresAt,
+		})
+		if err == nil {
+			phoneOTPDeliveries.With(otpChannelSMS, "sent").Inc()
+			return otpChannelSMS, nil
+		}
+		phoneOTPDeliveries.With(otpChannelSMS, "failed").Inc()
+		h.log.Warn("auth.otp.sms.fail", "err", err, "otp_id", otp.ID, "sender", i)
+		errs = append(errs, err)
+	}
+	if fallbackEmail == "" || otp.UserID == nil {
+		return "", errors.Join(errs...)
+	}
+
+	err := h.emailSender.SendLoginChallenge(ctx, LoginChallengeMessage{
+		UserID:      *otp.UserID,
+		Email:       fallbackEmail,
+		ChallengeID: otp.ID,
+		Code:        code,
+		ExpiresAt:   otp.ExpiresAt,
+	})
+	if err != nil {
+		phoneOTPDeliveries.With(otpChannelEmail, "failed").Inc()
+		return "", errors.Join(append(errs, err)...)
+	}
+	phoneOTPDeliveries.With(otpChannelEmail, "sent").Inc()
+	return otpChannelEmail, nil
+}
+
+func (h *Handler) writePhoneOTP(w http.ResponseWriter, otp phoneOTP) {
+	writeJSON(w, http.StatusAccepted, otpRequestResponse{
+		OTPID:     otp.ID,
+		Channel:   otp.Channel,
+		ExpiresAt: otp.ExpiresAt,
+	})
+}
+
+// normalizeOTPCode trims an otp_id and code pair and reports whether both are usable.
+func normalizeOTPCode(rawID, rawCode string) (string, string, bool) {
+	id, code := strings.TrimSpace(rawID), strings.TrimSpace(rawCode)
+	if id == "" || len(id) > 64 || code == "" || len(code) > 32 {
+		return "", "", false
+	}
+	return id, code, true
+}
+
+// ---- phone otp queries ----
+
+func insertPhoneOTP(ctx context.Context, pool *pgxpool.Pool, schema string, now time.Time, otp phoneOTP) error {
+	ctx = dbtrace.WithOp(ctx, "authapi.insert_phone_otp")
+	var ipVal any
+	if otp.IP != nil {
+		ipVal = otp.IP.String()
+	}
+	_, err := pool.Exec(ctx, `
+		INSERT INTO `+pgIdent(schema, "phone_otps")+` (
+			id, purpose, phone, phone_hash, user_id, code_hash, channel, ip, created_at, expires_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`, otp.ID, otp.Purpose, otp.Phone, otp.PhoneHash, otp.UserID, otp.CodeHash, otp.Channel, ipVal, now, otp.ExpiresAt)
+	return err
+}
+
+func setPhoneOTPChannel(ctx context.Context, pool *pgxpool.Pool, schema string, id string, channel string) error {
+	ctx = dbtrace.WithOp(ctx, "authapi.set_phone_otp_channel")
+	_, err := pool.Exec(ctx, `
+		UPDATE `+pgIdent(schema, "phone_otps")+` SET channel = $2 WHERE id = $1
+	`, id, channel)
+	return err
+}
+
+// recentPhoneOTPTimes returns the creation times of codes whose column ("phone_hash"
+// or "ip") equals value since since, newest first.
+func recentPhoneOTPTimes(ctx context.Context, pool *pgxpool.Pool, schema string, column string, value string, since time.Time, limit int) ([]time.Time, error) {
+	ctx = dbtrace.WithOp(ctx, "authapi.recent_phone_otp_times")
+	if pool == nil || limit <= 0 {
+		return nil, nil
+	}
+	filter := "phone_hash = $1"
+	if column == "ip" {
+		filter = "ip = $1::inet"
+	}
+	rows, err := pool.Query(ctx, `
+		SELECT created_at
+		FROM `+pgIdent(schema, "phone_otps")+`
+		WHERE `+filter+`
+		  AND created_at >= $2
+		ORDER BY created_at DESC
+		LIMIT $3
+	`, value, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]time.Time, 0, limit)
+	for rows.Next() {
+		var ts time.Time
+		if err := rows.Scan(&ts); err != nil {
+			return nil, err
+		}
+		out = append(out, ts)
+	}
+	return out, rows.Err()
+}
+
+// consumePhoneOTP verifies code and marks the code consumed in one transaction.
+// userID, when set, must own the code (link codes).
+//
+// A wrong code increments the attempt counter; the returned row still carries
+// UserID/Phone so callers can audit the failure against the right account. Login
+// codes for numbers without an account never match.
+func consumePhoneOTP(ctx context.Context, pool *pgxpool.Pool, schema string, now time.Time, id string, purpose string, userID string, code string, maxAttempts int) (phoneOTP, error) {
+	ctx = dbtrace.WithOp(ctx, "authapi.consume_phone_otp")
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return phoneOTP{}, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	otp := phoneOTP{ID: id, Purpose: purpose}
+	err = tx.QueryRow(ctx, `
+		SELECT phone, user_id, code_hash, channel, expires_at, attempts
+		FROM `+pgIdent(schema, "phone_otps")+`
+		WHERE id = $1
+		  AND purpose = $2
+		  AND consumed_at IS NULL
+		FOR UPDATE
+	`, id, purpose).Scan(&otp.Phone, &otp.UserID, &otp.CodeHash, &otp.Channel, &otp.ExpiresAt, &otp.Attempts)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return phoneOTP{}, errPhoneOTPNotFound
+		}
+		return phoneOTP{}, err
+	}
+	if userID != "" && (otp.UserID == nil || *otp.UserID != userID) {
+		return phoneOTP{}, errPhoneOTPNotFound
+	}
+
+	if !otp.ExpiresAt.After(now) || otp.Attempts >= maxAttempts {
+		return otp, errPhoneOTPInvalid
+	}
+
+	want := hashLoginChallengeCode(otp.ID, code)
+	if otp.UserID == nil || subtle.ConstantTimeCompare([]byte(want), []byte(otp.CodeHash)) != 1 {
+		if _, err := tx.Exec(ctx, `
+			UPDATE `+pgIdent(schema, "phone_otps")+` SET attempts = attempts + 1 WHERE id = $1
+		`, otp.ID); err != nil {
+			return phoneOTP{}, err
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return phoneOTP{}, err
+		}
+		return otp, errPhoneOTPInvalid
+	}
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE `+pgIdent(schema, "phone_otps")+` SET consumed_at = $2 WHERE id = $1
+	`, otp.ID, now); err != nil {
+		return phoneOTP{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return phoneOTP{}, err
+	}
+	return otp, nil
+}
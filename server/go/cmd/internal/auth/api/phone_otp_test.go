@@ -0,0 +1,96 @@
+package authapi
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type smsSenderStub struct {
+	calls int
+	last  PhoneOTPMessage
+	err   error
+}
+
+func (s *smsSenderStub) SendOTP(_ context.Context, msg PhoneOTPMessage) error {
+	s.calls++
+	s.last = msg
+	return s.err
+}
+
+func TestDeliverPhoneOTP_FailsOverToNextSender(t *testing.T) {
+	first := &smsSenderStub{err: errors.New("provider down")}
+	second := &smsSenderStub{}
+	email := &emailSenderStub{}
+	h := &Handler{log: slog.New(slog.NewTextHandler(io.Discard, nil)), emailSender: email}
+	WithSMSSenders(first, nil, second)(h)
+
+	uid := "01USER"
+	otp := phoneOTP{ID: "01OTP", Purpose: phoneOTPLogin, Phone: "+14155550100", UserID: &uid, ExpiresAt: time.Now().Add(time.Minute)}
+	channel, err := h.deliverPhoneOTP(context.Background(), otp, "123456", "a@example.com")
+	if err != nil || channel != otpChannelSMS {
+		t.Fatalf("expected sms delivery, got %q, %v", channel, err)
+	}
+	if first.calls != 1 || second.calls != 1 || email.calls != 0 {
+		t.Fatalf("unexpected calls: first=%d second=%d email=%d", first.calls, second.calls, email.calls)
+	}
+	if second.last.Phone != otp.Phone || second.last.Code != "123456" || second.last.OTPID != otp.ID {
+		t.Fatalf("unexpected message %+v", second.last)
+	}
+}
+
+func TestDeliverPhoneOTP_EmailFallback(t *testing.T) {
+	sms := &smsSenderStub{err: errors.New("provider down")}
+	email := &emailSenderStub{}
+	h := &Handler{log: slog.New(slog.NewTextHandler(io.Discard, nil)), emailSender: email, smsSenders: []SMSSender{sms}}
+
+	uid := "01USER"
+	otp := phoneOTP{ID: "01OTP", Purpose: phoneOTPLogin, Phone: "+14155550100", UserID: &uid}
+	channel, err := h.deliverPhoneOTP(context.Background(), otp, "123456", "a@example.com")
+	if err != nil || channel != otpChannelEmail || email.calls != 1 {
+		t.Fatalf("expected email fallback, got %q, %v (email calls %d)", channel, err, email.calls)
+	}
+
+	// Without a fallback address every failure is reported.
+	if _, err := h.deliverPhoneOTP(context.Background(), otp, "123456", ""); err == nil || !strings.Contains(err.Error(), "provider down") {
+		t.Fatalf("expected delivery error, got %v", err)
+	}
+}
+
+func TestHandleOTPRequest_Disabled(t *testing.T) {
+	h := &Handler{dbEnabled: true, cfg: Config{PhoneOTPEnabled: false}}
+	rr := httptest.NewRecorder()
+	h.handleOTPRequest(rr, httptest.NewRequest(http.MethodPost, "/auth/otp/request", strings.NewReader(`{"phone":"+14155550100"}`)))
+	if rr.Code != http.StatusServiceUnavailable || !strings.Contains(rr.Body.String(), "phone_otp_unavailable") {
+		t.Fatalf("expected 503 phone_otp_unavailable, got %d %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestHandleOTPRequest_InvalidPhone(t *testing.T) {
+	h := &Handler{dbEnabled: true, cfg: Config{PhoneOTPEnabled: true, MaxBodyBytes: 1 << 10}}
+	for _, phone := range []string{"", "4155550100", "+0123456789", "+1 415 555 0100 ext 2"} {
+		rr := httptest.NewRecorder()
+		body := `{"phone":"` + phone + `"}`
+		h.handleOTPRequest(rr, httptest.NewRequest(http.MethodPost, "/auth/otp/request", strings.NewReader(body)))
+		if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "invalid_phone") {
+			t.Fatalf("phone %q: expected 400 invalid_phone, got %d %s", phone, rr.Code, rr.Body.String())
+		}
+	}
+}
+
+func TestNormalizeOTPCode(t *testing.T) {
+	if id, code, ok := normalizeOTPCode(" 01OTP ", " 123456 "); !ok || id != "01OTP" || code != "123456" {
+		t.Fatalf("unexpected result %q %q %v", id, code, ok)
+	}
+	for _, tc := range [][2]string{{"", "123456"}, {"01OTP", " "}, {strings.Repeat("x", 65), "1"}} {
+		if _, _, ok := normalizeOTPCode(tc[0], tc[1]); ok {
+			t.Fatalf("expected %q/%q to be rejected", tc[0], tc[1])
+		}
+	}
+}
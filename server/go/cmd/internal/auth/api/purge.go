@@ -0,0 +1,417 @@
+package authapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"arc/cmd/identity"
+	"arc/cmd/internal/dbroute"
+	"arc/cmd/internal/dbtrace"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oklog/ulid/v2"
+)
+
+const (
+	userPurgePending   = "pending"
+	userPurgeRunning   = "running"
+	userPurgeCompleted = "completed"
+	userPurgeFailed    = "failed"
+
+	userPurgeTimeout      = 30 * time.Minute
+	userPurgeMessageBatch = 500
+
+	userPurgeLockReason = "account deletion in progress"
+)
+
+// userPurgeSteps is the ordered deletion plan. Every step is idempotent, so a failed
+// job can be re-requested and replays from the start without side effects.
+//
+//   - lock: blocks new logins while the purge runs
+//   - sessions: revokes outstanding refresh tokens
+//   - invites: revokes unused invites the user created and drops their notes
+//   - messages: detaches authored messages (sender_session FK is ON DELETE RESTRICT)
+//   - audit: strips IP and user agent from the user's audit entries
+//   - identity: deletes the user row; owned rows go with it via ON DELETE CASCADE
+var userPurgeSteps = []string{"lock", "sessions", "invites", "messages", "audit", "identity"}
+
+var errUserPurgeJobNotFound = errors.New("user purge job not found")
+
+// handleAdminUser serves DELETE /admin/users/{id}?purge=true.
+//
+// The purge runs as a background job; the response carries the job, whose progress is
+// available from GET /admin/users/{id}/purge. While a job for the user is pending or
+// running, repeated requests return it instead of starting another one.
+func (h *Handler) handleAdminUser(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.dbEnabled {
+		writeError(w, http.StatusServiceUnavailable, "db_unavailable", "database not configured")
+		return
+	}
+
+	claims, ok := h.requireAdmin(w, r)
+	if !ok {
+		return
+	}
+	targetID, ok := adminTargetUserID(w, r)
+	if !ok {
+		return
+	}
+	if r.URL.Query().Get("purge") != "true" {
+		writeError(w, http.StatusBadRequest, "invalid_request", "purge=true is required")
+		return
+	}
+	if targetID == claims.UserID {
+		writeError(w, http.StatusBadRequest, "invalid_request", "cannot purge own account")
+		return
+	}
+
+	ctx := r.Context()
+	latest, err := latestUserPurgeJob(ctx, h.pool, h.schema, targetID)
+	switch {
+	case err == nil && (latest.Status == userPurgePending || latest.Status == userPurgeRunning):
+		writeJSON(w, http.StatusAccepted, latest.toResponse())
+		return
+	case err != nil && !errors.Is(err, errUserPurgeJobNotFound):
+		h.log.Error("auth.admin.user_purge.get.fail", "err", err)
+		writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+		return
+	}
+
+	// Only a failed job may be retried for a user that no longer exists.
+	if err != nil || latest.Status != userPurgeFailed {
+		if _, err := h.identity.GetUserByID(dbroute.RequirePrimary(ctx), targetID); err != nil {
+			h.writeAdminUserError(w, "auth.admin.user_purge.fail", err)
+			return
+		}
+	}
+
+	job := userPurgeJob{
+		ID:           ulid.Make().String(),
+		TargetUserID: targetID,
+		RequestedBy:  claims.UserID,
+		Status:       userPurgePending,
+		StepsTotal:   len(userPurgeSteps),
+		CreatedAt:    time.Now().UTC(),
+	}
+	if err := insertUserPurgeJob(ctx, h.pool, h.schema, job); err != nil {
+		h.log.Error("auth.admin.user_purge.insert.fail", "err", err)
+		writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+		return
+	}
+
+	h.auditAdminUserAction(ctx, "admin.user.purge_requested", claims.UserID, targetID, h.clientIP(r), strings.TrimSpace(r.UserAgent()), map[string]any{
+		"job_id": job.ID,
+	})
+
+	go h.runUserPurge(job)
+
+	writeJSON(w, http.StatusAccepted, job.toResponse())
+}
+
+// handleAdminUserPurge reports the latest purge job for a user.
+func (h *Handler) handleAdminUserPurge(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.dbEnabled {
+		writeError(w, http.StatusServiceUnavailable, "db_unavailable", "database not configured")
+		return
+	}
+
+	if _, ok := h.requireAdmin(w, r); !ok {
+		return
+	}
+	targetID, ok := adminTargetUserID(w, r)
+	if !ok {
+		return
+	}
+
+	job, err := latestUserPurgeJob(r.Context(), h.pool, h.schema, targetID)
+	if err != nil {
+		if errors.Is(err, errUserPurgeJobNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "no purge requested")
+			return
+		}
+		h.log.Error("auth.admin.user_purge.get.fail", "err", err)
+		writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+		return
+	}
+	writeJSON(w, http.StatusOK, job.toResponse())
+}
+
+// adminTargetUserID validates the {id} path value of /admin/users/{id}/* routes.
+func adminTargetUserID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	targetID := strings.TrimSpace(r.PathValue("id"))
+	if targetID == "" || len(targetID) > 64 {
+		writeError(w, http.StatusBadRequest, "invalid_request", "invalid user id")
+		return "", false
+	}
+	return targetID, true
+}
+
+// runUserPurge executes the purge plan for job, recording progress after each step.
+// It runs detached from the request and audits the outcome on behalf of the requesting admin.
+func (h *Handler) runUserPurge(job userPurgeJob) {
+	ctx, cancel := context.WithTimeout(context.Background(), userPurgeTimeout)
+	defer cancel()
+
+	if err := startUserPurgeJob(ctx, h.pool, h.schema, time.Now().UTC(), job.ID); err != nil {
+		h.log.Error("auth.admin.user_purge.start.fail", "err", err, "job_id", job.ID)
+		return
+	}
+
+	for i, step := range userPurgeSteps {
+		if err := setUserPurgeStep(ctx, h.pool, h.schema, job.ID, step, i); err != nil {
+			h.log.Error("auth.admin.user_purge.progress.fail", "err", err, "job_id", job.ID)
+		}
+		if err := h.runUserPurgeStep(ctx, job, step); err != nil {
+			h.log.Error("auth.admin.user_purge.step.fail", "err", err, "job_id", job.ID, "step", step)
+			h.finishUserPurge(job, userPurgeFailed, "step "+step+" failed")
+			return
+		}
+	}
+	h.finishUserPurge(job, userPurgeCompleted, "")
+}
+
+func (h *Handler) runUserPurgeStep(ctx context.Context, job userPurgeJob, step string) error {
+	now := time.Now().UTC()
+	switch step {
+	case "lock":
+		reason := userPurgeLockReason
+		return ignoreNotFound(h.identity.SetUserLocked(ctx, job.TargetUserID, true, &reason, now))
+	case "sessions":
+		return h.sessions.RevokeAllSessions(ctx, now, job.TargetUserID, "admin")
+	case "invites":
+		n, err := revokeInvitesCreatedBy(ctx, h.pool, h.schema, now, job.TargetUserID)
+		if err != nil {
+			return err
+		}
+		return addUserPurgeCounts(ctx, h.pool, h.schema, job.ID, 0, n)
+	case "messages":
+		return h.scrubPurgedUserMessages(ctx, job)
+	case "audit":
+		return scrubAuditLogForUser(ctx, h.pool, h.schema, job.TargetUserID)
+	case "identity":
+		// Messages sent between the scrub and now would block the delete; catch them up first.
+		if err := h.scrubPurgedUserMessages(ctx, job); err != nil {
+			return err
+		}
+		return ignoreNotFound(h.identity.DeleteUser(ctx, job.TargetUserID))
+	default:
+		return errors.New("unknown purge step")
+	}
+}
+
+// scrubPurgedUserMessages detaches the user's messages in batches, recording progress per batch.
+func (h *Handler) scrubPurgedUserMessages(ctx context.Context, job userPurgeJob) error {
+	if h.scrubber == nil {
+		return nil
+	}
+	for {
+		n, err := h.scrubber.ScrubMessagesByAuthor(ctx, job.TargetUserID, userPurgeMessageBatch)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return nil
+		}
+		if err := addUserPurgeCounts(ctx, h.pool, h.schema, job.ID, n, 0); err != nil {
+			return err
+		}
+	}
+}
+
+// finishUserPurge records the final job state and its audit entry. It uses a fresh
+// context so a timed-out run is still recorded as failed.
+func (h *Handler) finishUserPurge(job userPurgeJob, status string, reason string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	done, err := finishUserPurgeJob(ctx, h.pool, h.schema, time.Now().UTC(), job.ID, status, reason)
+	if err != nil {
+		h.log.Error("auth.admin.user_purge.finish.fail", "err", err, "job_id", job.ID)
+		return
+	}
+
+	action := "admin.user.purged"
+	if status == userPurgeFailed {
+		action = "admin.user.purge_failed"
+	}
+	meta := map[string]any{
+		"job_id":            job.ID,
+		"steps_completed":   done.StepsCompleted,
+		"messages_scrubbed": done.MessagesScrubbed,
+		"invites_revoked":   done.InvitesRevoked,
+	}
+	if reason != "" {
+		meta["error"] = reason
+	}
+	h.auditAdminUserAction(ctx, action, job.RequestedBy, job.TargetUserID, nil, "", meta)
+	h.log.Info("auth.admin.user_purge.done", "job_id", job.ID, "status", status)
+}
+
+func ignoreNotFound(err error) error {
+	if identity.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// ---- purge queries ----
+
+type userPurgeJob struct {
+	ID               string
+	TargetUserID     string
+	RequestedBy      string
+	Status           string
+	CurrentStep      *string
+	StepsCompleted   int
+	StepsTotal       int
+	MessagesScrubbed int64
+	InvitesRevoked   int64
+	CreatedAt        time.Time
+	StartedAt        *time.Time
+	CompletedAt      *time.Time
+	Error            *string
+}
+
+func (j userPurgeJob) toResponse() userPurgeJobResponse {
+	out := userPurgeJobResponse{
+		JobID:            j.ID,
+		TargetUserID:     j.TargetUserID,
+		Status:           j.Status,
+		StepsCompleted:   j.StepsCompleted,
+		StepsTotal:       j.StepsTotal,
+		MessagesScrubbed: j.MessagesScrubbed,
+		InvitesRevoked:   j.InvitesRevoked,
+		CreatedAt:        j.CreatedAt,
+		StartedAt:        j.StartedAt,
+		CompletedAt:      j.CompletedAt,
+	}
+	if j.CurrentStep != nil {
+		out.CurrentStep = *j.CurrentStep
+	}
+	if j.Error != nil {
+		out.Error = *j.Error
+	}
+	return out
+}
+
+const userPurgeJobColumns = `id, target_user_id, COALESCE(requested_by, ''), status, current_step, steps_completed, steps_total,
+	messages_scrubbed, invites_revoked, created_at, started_at, completed_at, error`
+
+func scanUserPurgeJob(row pgx.Row) (userPurgeJob, error) {
+	var j userPurgeJob
+	err := row.Scan(
+		&j.ID, &j.TargetUserID, &j.RequestedBy, &j.Status, &j.CurrentStep, &j.StepsCompleted, &j.StepsTotal,
+		&j.MessagesScrubbed, &j.InvitesRevoked, &j.CreatedAt, &j.StartedAt, &j.CompletedAt, &j.Error,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return userPurgeJob{}, errUserPurgeJobNotFound
+	}
+	return j, err
+}
+
+func insertUserPurgeJob(ctx context.Context, pool *pgxpool.Pool, schema string, j userPurgeJob) error {
+	ctx = dbtrace.WithOp(ctx, "authapi.insert_user_purge_job")
+	_, err := pool.Exec(ctx, `
+		INSERT INTO `+pgIdent(schema, "user_purge_jobs")+` (id, target_user_id, requested_by, status, steps_total, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, j.ID, j.TargetUserID, j.RequestedBy, j.Status, j.StepsTotal, j.CreatedAt)
+	return err
+}
+
+func latestUserPurgeJob(ctx context.Context, pool *pgxpool.Pool, schema string, targetUserID string) (userPurgeJob, error) {
+	ctx = dbtrace.WithOp(ctx, "authapi.latest_user_purge_job")
+	return scanUserPurgeJob(pool.QueryRow(ctx, `
+		SELECT `+userPurgeJobColumns+`
+		FROM `+pgIdent(schema, "user_purge_jobs")+`
+		WHERE target_user_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT 1
+	`, targetUserID))
+}
+
+func startUserPurgeJob(ctx context.Context, pool *pgxpool.Pool, schema string, now time.Time, jobID string) error {
+	ctx = dbtrace.WithOp(ctx, "authapi.start_user_purge_job")
+	_, err := pool.Exec(ctx, `
+		UPDATE `+pgIdent(schema, "user_purge_jobs")+`
+		SET status = 'running', started_at = $2
+		WHERE id = $1
+	`, jobID, now)
+	return err
+}
+
+func setUserPurgeStep(ctx context.Context, pool *pgxpool.Pool, schema string, jobID string, step string, completed int) error {
+	ctx = dbtrace.WithOp(ctx, "authapi.set_user_purge_step")
+	_, err := pool.Exec(ctx, `
+		UPDATE `+pgIdent(schema, "user_purge_jobs")+`
+		SET current_step = $2, steps_completed = $3
+		WHERE id = $1
+	`, jobID, step, completed)
+	return err
+}
+
+func addUserPurgeCounts(ctx context.Context, pool *pgxpool.Pool, schema string, jobID string, messages int64, invites int64) error {
+	ctx = dbtrace.WithOp(ctx, "authapi.add_user_purge_counts")
+	_, err := pool.Exec(ctx, `
+		UPDATE `+pgIdent(schema, "user_purge_jobs")+`
+		SET messages_scrubbed = messages_scrubbed + $2,
+		    invites_revoked = invites_revoked + $3
+		WHERE id = $1
+	`, jobID, messages, invites)
+	return err
+}
+
+// finishUserPurgeJob marks the job completed or failed and returns its final state.
+// A completed job has every step counted; a failed one keeps the count it reached.
+func finishUserPurgeJob(ctx context.Context, pool *pgxpool.Pool, schema string, now time.Time, jobID string, status string, reason string) (userPurgeJob, error) {
+	ctx = dbtrace.WithOp(ctx, "authapi.finish_user_purge_job")
+	return scanUserPurgeJob(pool.QueryRow(ctx, `
+		UPDATE `+pgIdent(schema, "user_purge_jobs")+`
+		SET status = $2,
+		    completed_at = $3,
+		    error = NULLIF($4, ''),
+		    current_step = CASE WHEN $2 = 'completed' THEN NULL ELSE current_step END,
+		    steps_completed = CASE WHEN $2 = 'completed' THEN steps_total ELSE steps_completed END
+		WHERE id = $1
+		RETURNING `+userPurgeJobColumns+`
+	`, jobID, status, now, reason))
+}
+
+// revokeInvitesCreatedBy revokes the user's unused invites and clears notes on all of them.
+// created_by itself is nulled by ON DELETE SET NULL when the user row goes away.
+func revokeInvitesCreatedBy(ctx context.Context, pool *pgxpool.Pool, schema string, now time.Time, userID string) (int64, error) {
+	ctx = dbtrace.WithOp(ctx, "authapi.revoke_invites_created_by")
+	tag, err := pool.Exec(ctx, `
+		UPDATE `+pgIdent(schema, "invites")+`
+		SET revoked_at = COALESCE(revoked_at, $2),
+		    note = NULL
+		WHERE created_by = $1
+	`, userID, now)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// scrubAuditLogForUser removes network identifiers from the user's audit entries.
+// The entries themselves stay (user_id becomes NULL on delete) to keep the trail intact.
+func scrubAuditLogForUser(ctx context.Context, pool *pgxpool.Pool, schema string, userID string) error {
+	ctx = dbtrace.WithOp(ctx, "authapi.scrub_audit_log_for_user")
+	_, err := pool.Exec(ctx, `
+		UPDATE `+pgIdent(schema, "audit_log")+`
+		SET ip = NULL, user_agent = NULL
+		WHERE user_id = $1
+	`, userID)
+	return err
+}
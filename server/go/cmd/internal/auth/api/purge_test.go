@@ -0,0 +1,62 @@
+package authapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestUserPurgeJob_ToResponse(t *testing.T) {
+	step := "messages"
+	reason := "step messages failed"
+	j := userPurgeJob{
+		ID:               "job-1",
+		TargetUserID:     "user-1",
+		Status:           userPurgeFailed,
+		CurrentStep:      &step,
+		StepsCompleted:   3,
+		StepsTotal:       len(userPurgeSteps),
+		MessagesScrubbed: 1200,
+		CreatedAt:        time.Now().UTC(),
+		Error:            &reason,
+	}
+
+	out := j.toResponse()
+	if out.CurrentStep != step || out.Error != reason {
+		t.Fatalf("expected step and error to be copied, got %+v", out)
+	}
+	if out.StepsCompleted != 3 || out.StepsTotal != len(userPurgeSteps) || out.MessagesScrubbed != 1200 {
+		t.Fatalf("unexpected progress: %+v", out)
+	}
+
+	j.CurrentStep, j.Error = nil, nil
+	if out := j.toResponse(); out.CurrentStep != "" || out.Error != "" {
+		t.Fatalf("expected empty optional fields, got %+v", out)
+	}
+}
+
+func TestUserPurgeSteps_DeleteIdentityLast(t *testing.T) {
+	if got := userPurgeSteps[len(userPurgeSteps)-1]; got != "identity" {
+		t.Fatalf("identity must be the last purge step, got %q", got)
+	}
+	if userPurgeSteps[0] != "lock" {
+		t.Fatalf("lock must be the first purge step, got %q", userPurgeSteps[0])
+	}
+}
+
+func TestHandleAdminUser_MethodAndDB(t *testing.T) {
+	h := &Handler{}
+
+	rr := httptest.NewRecorder()
+	h.handleAdminUser(rr, httptest.NewRequest(http.MethodPost, "/admin/users/u1?purge=true", nil))
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	h.handleAdminUser(rr, httptest.NewRequest(http.MethodDelete, "/admin/users/u1?purge=true", nil))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 without db, got %d", rr.Code)
+	}
+}
@@ -9,20 +9,29 @@ import (
 	"strings"
 	"time"
 
+	"arc/cmd/internal/dbtrace"
+
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// Throttles recorded as "throttle" in auth.login.rate_limited audit meta.
+const (
+	throttleIP         = "ip"
+	throttleIdentifier = "identifier"
+)
+
 func (h *Handler) checkLoginIPThrottle(ctx context.Context, ip net.IP, now time.Time) (bool, time.Duration, error) {
-	if ip == nil || h.cfg.LoginIPMax <= 0 || h.cfg.LoginIPWindow <= 0 {
+	cfg := h.config()
+	if ip == nil || cfg.LoginIPMax <= 0 || cfg.LoginIPWindow <= 0 {
 		return false, 0, nil
 	}
-	cut := now.Add(-h.cfg.LoginIPWindow)
-	failures, err := recentLoginFailureTimesByIP(ctx, h.pool, ip, cut, h.cfg.LoginIPMax)
+	cut := now.Add(-cfg.LoginIPWindow)
+	failures, err := recentLoginFailureTimesByIP(ctx, h.pool, h.schema, ip, cut, cfg.LoginIPMax)
 	if err != nil {
 		return false, 0, err
 	}
 
-	blocked, retryAfter := evaluateWindowThrottle(now, failures, h.cfg.LoginIPMax, h.cfg.LoginIPWindow)
+	blocked, retryAfter := evaluateWindowThrottle(now, failures, cfg.LoginIPMax, cfg.LoginIPWindow)
 	return blocked, retryAfter, nil
 }
 
@@ -31,38 +40,39 @@ func (h *Handler) checkLoginIdentifierThrottle(ctx context.Context, identifier s
 	if identifier == "" {
 		return false, 0, nil
 	}
+	cfg := h.config()
 
 	limit := maxInt(
-		h.cfg.LoginUserMax,
-		h.cfg.LockoutShortThreshold,
-		h.cfg.LockoutLongThreshold,
-		h.cfg.LockoutSevereThreshold,
+		cfg.LoginUserMax,
+		cfg.LockoutShortThreshold,
+		cfg.LockoutLongThreshold,
+		cfg.LockoutSevereThreshold,
 	)
 	lookback := maxDuration(
-		h.cfg.LoginUserWindow,
-		h.cfg.LockoutShortDuration,
-		h.cfg.LockoutLongDuration,
-		h.cfg.LockoutSevereDuration,
+		cfg.LoginUserWindow,
+		cfg.LockoutShortDuration,
+		cfg.LockoutLongDuration,
+		cfg.LockoutSevereDuration,
 	)
 	if limit <= 0 || lookback <= 0 {
 		return false, 0, nil
 	}
 
-	failures, err := recentLoginFailureTimesByIdentifier(ctx, h.pool, identifier, now.Add(-lookback), limit)
+	failures, err := recentLoginFailureTimesByIdentifier(ctx, h.pool, h.schema, h.identifierHash(identifier), now.Add(-lookback), limit)
 	if err != nil {
 		return false, 0, err
 	}
 
 	// Strongest lockout tier wins.
 	if blocked, retryAfter := evaluateProgressiveLockout(now, failures, []lockoutTier{
-		{Threshold: h.cfg.LockoutSevereThreshold, Duration: h.cfg.LockoutSevereDuration},
-		{Threshold: h.cfg.LockoutLongThreshold, Duration: h.cfg.LockoutLongDuration},
-		{Threshold: h.cfg.LockoutShortThreshold, Duration: h.cfg.LockoutShortDuration},
+		{Threshold: cfg.LockoutSevereThreshold, Duration: cfg.LockoutSevereDuration},
+		{Threshold: cfg.LockoutLongThreshold, Duration: cfg.LockoutLongDuration},
+		{Threshold: cfg.LockoutShortThreshold, Duration: cfg.LockoutShortDuration},
 	}); blocked {
 		return true, retryAfter, nil
 	}
 
-	blocked, retryAfter := evaluateWindowThrottle(now, failures, h.cfg.LoginUserMax, h.cfg.LoginUserWindow)
+	blocked, retryAfter := evaluateWindowThrottle(now, failures, cfg.LoginUserMax, cfg.LoginUserWindow)
 	return blocked, retryAfter, nil
 }
 
@@ -154,14 +164,15 @@ func maxDuration(vals ...time.Duration) time.Duration {
 
 // ---- audit queries ----
 
-func recentLoginFailureTimesByIP(ctx context.Context, pool *pgxpool.Pool, ip net.IP, since time.Time, limit int) ([]time.Time, error) {
+func recentLoginFailureTimesByIP(ctx context.Context, pool *pgxpool.Pool, schema string, ip net.IP, since time.Time, limit int) ([]time.Time, error) {
+	ctx = dbtrace.WithOp(ctx, "authapi.recent_login_failure_times_by_ip")
 	if pool == nil || ip == nil || limit <= 0 {
 		return nil, nil
 	}
 
 	rows, err := pool.Query(ctx, `
 		SELECT created_at
-		FROM arc.audit_log
+		FROM `+pgIdent(schema, "audit_log")+`
 		WHERE action = 'auth.login.failed'
 		  AND ip = $1
 		  AND created_at >= $2
@@ -187,20 +198,21 @@ func recentLoginFailureTimesByIP(ctx context.Context, pool *pgxpool.Pool, ip net
 	return out, nil
 }
 
-func recentLoginFailureTimesByIdentifier(ctx context.Context, pool *pgxpool.Pool, identifier string, since time.Time, limit int) ([]time.Time, error) {
-	if pool == nil || strings.TrimSpace(identifier) == "" || limit <= 0 {
+func recentLoginFailureTimesByIdentifier(ctx context.Context, pool *pgxpool.Pool, schema string, identifierHash string, since time.Time, limit int) ([]time.Time, error) {
+	ctx = dbtrace.WithOp(ctx, "authapi.recent_login_failure_times_by_identifier")
+	if pool == nil || identifierHash == "" || limit <= 0 {
 		return nil, nil
 	}
 
 	rows, err := pool.Query(ctx, `
 		SELECT created_at
-		FROM arc.audit_log
+		FROM `+pgIdent(schema, "audit_log")+`
 		WHERE action = 'auth.login.failed'
-		  AND meta ->> 'identifier' = $1
+		  AND meta ->> 'identifier_hash' = $1
 		  AND created_at >= $2
 		ORDER BY created_at DESC
 		LIMIT $3
-	`, identifier, since, limit)
+	`, identifierHash, since, limit)
 	if err != nil {
 		return nil, err
 	}
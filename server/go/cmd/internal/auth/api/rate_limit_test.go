@@ -3,8 +3,27 @@ package authapi
 import (
 	"testing"
 	"time"
+
+	"arc/cmd/security/token"
 )
 
+func TestIdentifierHash(t *testing.T) {
+	h := &Handler{}
+	if got, want := h.identifierHash("a@example.com"), token.HashSHA256Hex("a@example.com"); got != want {
+		t.Fatalf("without a key: got %q, want %q", got, want)
+	}
+
+	h.identifierKey = []byte("0123456789abcdef0123456789abcdef")
+	keyed := h.identifierHash("a@example.com")
+	if keyed == token.HashSHA256Hex("a@example.com") || keyed != h.identifierHash("a@example.com") {
+		t.Fatalf("keyed hash %q is not a stable HMAC", keyed)
+	}
+	other := &Handler{identifierKey: []byte("fedcba9876543210fedcba9876543210")}
+	if other.identifierHash("a@example.com") == keyed {
+		t.Fatalf("hashes under different keys match")
+	}
+}
+
 func TestEvaluateWindowThrottle(t *testing.T) {
 	now := time.Date(2026, 2, 13, 12, 0, 0, 0, time.UTC)
 
@@ -0,0 +1,202 @@
+package authapi
+
+import (
+	"context"
+	"crypto/rand"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"arc/cmd/identity"
+	"arc/cmd/internal/dbtrace"
+	"arc/cmd/security/token"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	recoveryCodeCount = 10
+	recoveryCodeLen   = 10
+
+	// recoveryCodeAlphabet omits characters that are easily confused when written down (0/o, 1/i/l).
+	recoveryCodeAlphabet = "23456789abcdefghjkmnpqrstuvwxyz"
+)
+
+// handleMeRecoveryCodes reports (GET) or regenerates (POST) the caller's recovery codes.
+//
+// Recovery codes are single-use fallbacks for the login challenge. Regeneration
+// invalidates all previous codes and requires the current password, so a stolen
+// access token alone cannot mint a way past the second step.
+func (h *Handler) handleMeRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.dbEnabled {
+		writeError(w, http.StatusServiceUnavailable, "db_unavailable", "database not configured")
+		return
+	}
+
+	claims, ok := h.requireAuth(w, r)
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+
+	if r.Method == http.MethodGet {
+		n, err := countRecoveryCodes(ctx, h.pool, h.schema, claims.UserID)
+		if err != nil {
+			h.log.Error("auth.recovery_codes.count.fail", "err", err)
+			writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+			return
+		}
+		writeJSON(w, http.StatusOK, recoveryCodesResponse{Remaining: n})
+		return
+	}
+
+	var req recoveryCodesRegenerateRequest
+	if err := decodeJSON(w, r, h.cfg.MaxBodyBytes, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_json", "invalid request body")
+		return
+	}
+	if strings.TrimSpace(req.Password) == "" {
+		writeError(w, http.StatusBadRequest, "invalid_request", "password is required")
+		return
+	}
+
+	userAuth, err := h.identity.GetUserAuthByID(ctx, claims.UserID)
+	if err != nil {
+		if identity.IsNotFound(err) {
+			writeError(w, http.StatusUnauthorized, "not_found", "user not found")
+			return
+		}
+		h.log.Error("auth.recovery_codes.user.fail", "err", err)
+		writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+		return
+	}
+	okPw, err := identity.VerifyPassword(strings.TrimSpace(req.Password), userAuth.PasswordHash)
+	if err != nil || !okPw {
+		writeError(w, http.StatusUnauthorized, "invalid_credentials", "invalid credentials")
+		return
+	}
+
+	codes, err := newRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		h.log.Error("auth.recovery_codes.generate.fail", "err", err)
+		writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+		return
+	}
+	if err := replaceRecoveryCodes(ctx, h.pool, h.schema, time.Now().UTC(), claims.UserID, codes); err != nil {
+		h.log.Error("auth.recovery_codes.store.fail", "err", err)
+		writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+		return
+	}
+
+	h.auditRecoveryCodesGenerated(ctx, claims.UserID, claims.SessionID, h.clientIP(r), strings.TrimSpace(r.UserAgent()), len(codes))
+	writeJSON(w, http.StatusOK, recoveryCodesResponse{Codes: codes, Remaining: len(codes)})
+}
+
+// recoveryCodeFactor accepts an unused recovery code of the challenged user, consuming it.
+func recoveryCodeFactor(schema, code string) challengeFactor {
+	return func(ctx context.Context, tx pgx.Tx, now time.Time, ch loginChallenge) (bool, error) {
+		normalized, ok := normalizeRecoveryCode(code)
+		if !ok {
+			return false, nil
+		}
+		return consumeRecoveryCodeTx(ctx, tx, schema, now, ch.UserID, hashRecoveryCode(ch.UserID, normalized))
+	}
+}
+
+// newRecoveryCodes returns n random codes formatted as "xxxxx-xxxxx".
+func newRecoveryCodes(n int) ([]string, error) {
+	alphabetLen := big.NewInt(int64(len(recoveryCodeAlphabet)))
+	out := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		var b strings.Builder
+		for j := 0; j < recoveryCodeLen; j++ {
+			if j == recoveryCodeLen/2 {
+				b.WriteByte('-')
+			}
+			k, err := rand.Int(rand.Reader, alphabetLen)
+			if err != nil {
+				return nil, err
+			}
+			b.WriteByte(recoveryCodeAlphabet[k.Int64()])
+		}
+		out = append(out, b.String())
+	}
+	return out, nil
+}
+
+// normalizeRecoveryCode strips separators and case so codes can be typed loosely.
+func normalizeRecoveryCode(code string) (string, bool) {
+	code = strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(code)))
+	if len(code) != recoveryCodeLen {
+		return "", false
+	}
+	for i := 0; i < len(code); i++ {
+		if !strings.ContainsRune(recoveryCodeAlphabet, rune(code[i])) {
+			return "", false
+		}
+	}
+	return code, true
+}
+
+// hashRecoveryCode binds the code to its user so equal codes never share a hash.
+func hashRecoveryCode(userID string, normalized string) string {
+	return token.HashRefreshTokenHex(userID + ":" + normalized)
+}
+
+// ---- recovery code queries ----
+
+// replaceRecoveryCodes deletes all codes of userID and stores the new set in one transaction.
+func replaceRecoveryCodes(ctx context.Context, pool *pgxpool.Pool, schema string, now time.Time, userID string, codes []string) error {
+	ctx = dbtrace.WithOp(ctx, "authapi.replace_recovery_codes")
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx, `DELETE FROM `+pgIdent(schema, "recovery_codes")+` WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	for _, code := range codes {
+		normalized, _ := normalizeRecoveryCode(code)
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO `+pgIdent(schema, "recovery_codes")+` (user_id, code_hash, created_at)
+			VALUES ($1, $2, $3)
+		`, userID, hashRecoveryCode(userID, normalized), now); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+func countRecoveryCodes(ctx context.Context, pool *pgxpool.Pool, schema string, userID string) (int, error) {
+	ctx = dbtrace.WithOp(ctx, "authapi.count_recovery_codes")
+	var n int
+	err := pool.QueryRow(ctx, `
+		SELECT count(*)
+		FROM `+pgIdent(schema, "recovery_codes")+`
+		WHERE user_id = $1
+		  AND used_at IS NULL
+	`, userID).Scan(&n)
+	return n, err
+}
+
+func consumeRecoveryCodeTx(ctx context.Context, tx pgx.Tx, schema string, now time.Time, userID string, codeHash string) (bool, error) {
+	tag, err := tx.Exec(ctx, `
+		UPDATE `+pgIdent(schema, "recovery_codes")+`
+		SET used_at = $3
+		WHERE user_id = $1
+		  AND code_hash = $2
+		  AND used_at IS NULL
+	`, userID, codeHash, now)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
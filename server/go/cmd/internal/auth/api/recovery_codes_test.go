@@ -0,0 +1,51 @@
+package authapi
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewRecoveryCodes(t *testing.T) {
+	codes, err := newRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		t.Fatalf("newRecoveryCodes: %v", err)
+	}
+	if len(codes) != recoveryCodeCount {
+		t.Fatalf("expected %d codes, got %d", recoveryCodeCount, len(codes))
+	}
+	seen := map[string]bool{}
+	for _, c := range codes {
+		if len(c) != recoveryCodeLen+1 || c[recoveryCodeLen/2] != '-' {
+			t.Fatalf("unexpected code format %q", c)
+		}
+		if _, ok := normalizeRecoveryCode(c); !ok {
+			t.Fatalf("generated code %q does not normalize", c)
+		}
+		if seen[c] {
+			t.Fatalf("duplicate code %q", c)
+		}
+		seen[c] = true
+	}
+}
+
+func TestNormalizeRecoveryCode(t *testing.T) {
+	got, ok := normalizeRecoveryCode(" ABCDE-fghjk ")
+	if !ok || got != "abcdefghjk" {
+		t.Fatalf("expected normalized code, got %q ok=%v", got, ok)
+	}
+	for _, in := range []string{"", "abcde", "abcde-fghjkm", "abcde-fghi1"} {
+		if _, ok := normalizeRecoveryCode(in); ok {
+			t.Fatalf("expected %q to be rejected", in)
+		}
+	}
+}
+
+func TestHashRecoveryCode_BoundToUser(t *testing.T) {
+	a := hashRecoveryCode("user-a", "abcdefghjk")
+	if a == hashRecoveryCode("user-b", "abcdefghjk") {
+		t.Fatalf("expected per-user hashes to differ")
+	}
+	if len(a) != 64 || strings.ToLower(a) != a {
+		t.Fatalf("expected 64 lowercase hex chars, got %q", a)
+	}
+}
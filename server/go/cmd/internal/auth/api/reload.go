@@ -0,0 +1,48 @@
+package authapi
+
+import "strings"
+
+// authTunableKeys are the settings a config reload applies without a restart.
+var authTunableKeys = []string{
+	"ARC_AUTH_ENABLE_CAPTCHA",
+	"ARC_AUTH_LOGIN_IP_MAX",
+	"ARC_AUTH_LOGIN_IP_WINDOW",
+	"ARC_AUTH_LOGIN_USER_MAX",
+	"ARC_AUTH_LOGIN_USER_WINDOW",
+	"ARC_AUTH_LOGIN_LOCKOUT_SHORT_THRESHOLD",
+	"ARC_AUTH_LOGIN_LOCKOUT_SHORT_DURATION",
+	"ARC_AUTH_LOGIN_LOCKOUT_LONG_THRESHOLD",
+	"ARC_AUTH_LOGIN_LOCKOUT_LONG_DURATION",
+	"ARC_AUTH_LOGIN_LOCKOUT_SEVERE_THRESHOLD",
+	"ARC_AUTH_LOGIN_LOCKOUT_SEVERE_DURATION",
+}
+
+// config returns the current config: the constructor's, with the tunables of the
+// latest reload applied.
+func (h *Handler) config() Config {
+	if c := h.reloaded.Load(); c != nil {
+		return *c
+	}
+	return h.cfg
+}
+
+// reloadConfig re-reads the captcha switch and login throttles through h.lookup.
+// Other settings keep their startup values.
+func (h *Handler) reloadConfig(changed []string) {
+	env := LoadConfigFrom(h.lookup)
+	cfg := h.cfg
+	cfg.EnableCaptcha = env.EnableCaptcha
+	cfg.LoginIPMax, cfg.LoginIPWindow = env.LoginIPMax, env.LoginIPWindow
+	cfg.LoginUserMax, cfg.LoginUserWindow = env.LoginUserMax, env.LoginUserWindow
+	cfg.LockoutShortThreshold, cfg.LockoutShortDuration = env.LockoutShortThreshold, env.LockoutShortDuration
+	cfg.LockoutLongThreshold, cfg.LockoutLongDuration = env.LockoutLongThreshold, env.LockoutLongDuration
+	cfg.LockoutSevereThreshold, cfg.LockoutSevereDuration = env.LockoutSevereThreshold, env.LockoutSevereDuration
+	h.reloaded.Store(&cfg)
+
+	h.log.Info("auth.config.reloaded",
+		"changed", strings.Join(changed, ","),
+		"captcha", cfg.EnableCaptcha,
+		"login_ip_max", cfg.LoginIPMax,
+		"login_user_max", cfg.LoginUserMax,
+	)
+}
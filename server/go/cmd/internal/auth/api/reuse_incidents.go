@@ -0,0 +1,119 @@
+package authapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"arc/cmd/internal/dbroute"
+	"arc/cmd/internal/dbtrace"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	defaultReuseIncidentsN  = 50
+	maxReuseIncidentsListed = 200
+)
+
+// handleAdminReuseIncidents serves GET /admin/security/reuse-incidents: refresh token
+// reuse detections, newest first, with the replaying and the original presenter and
+// the rotation chain at detection. ?user_id= narrows to one user; ?before= takes the
+// id of the last incident of the previous page.
+func (h *Handler) handleAdminReuseIncidents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.dbEnabled {
+		writeError(w, http.StatusServiceUnavailable, "db_unavailable", "database not configured")
+		return
+	}
+
+	if _, ok := h.requireAdmin(w, r); !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	userID := strings.TrimSpace(q.Get("user_id"))
+	limit := defaultReuseIncidentsN
+	if raw := strings.TrimSpace(q.Get("limit")); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			writeError(w, http.StatusBadRequest, "invalid_request", "invalid limit")
+			return
+		}
+		limit = min(n, maxReuseIncidentsListed)
+	}
+	var before int64
+	if raw := strings.TrimSpace(q.Get("before")); raw != "" {
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || n <= 0 {
+			writeError(w, http.StatusBadRequest, "invalid_request", "invalid before")
+			return
+		}
+		before = n
+	}
+
+	ctx := r.Context()
+	incidents, err := listReuseIncidents(ctx, dbroute.Reader(ctx, h.pool, h.readPool), h.schema, userID, before, limit)
+	if err != nil {
+		h.log.Error("auth.admin.reuse_incidents.fail", "err", err, "result", "server_error")
+		writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+		return
+	}
+	writeJSON(w, http.StatusOK, reuseIncidentsResponse{Incidents: incidents})
+}
+
+// ---- reuse incident queries ----
+
+func listReuseIncidents(ctx context.Context, pool *pgxpool.Pool, schema string, userID string, before int64, limit int) ([]reuseIncidentResponse, error) {
+	ctx = dbtrace.WithOp(ctx, "authapi.list_reuse_incidents")
+	rows, err := pool.Query(ctx, `
+		SELECT id, user_id, detected_at, endpoint, reused_session_id,
+		       COALESCE(host(presenter_ip), ''), COALESCE(presenter_user_agent, ''), presenter_platform,
+		       rotated_at, COALESCE(host(rotated_ip), ''), COALESCE(rotated_user_agent, ''),
+		       sessions_revoked, session_chain
+		  FROM `+pgIdent(schema, "reuse_incidents")+`
+		 WHERE ($1 = '' OR user_id = $1)
+		   AND ($2 = 0 OR id < $2)
+		 ORDER BY id DESC
+		 LIMIT $3
+	`, userID, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]reuseIncidentResponse, 0, limit)
+	for rows.Next() {
+		var (
+			inc       reuseIncidentResponse
+			rotatedAt *time.Time
+			rotated   reuseIncidentPresenter
+			chain     []byte
+		)
+		if err := rows.Scan(&inc.ID, &inc.UserID, &inc.DetectedAt, &inc.Endpoint, &inc.ReusedSessionID,
+			&inc.Presenter.IP, &inc.Presenter.UserAgent, &inc.Presenter.Platform,
+			&rotatedAt, &rotated.IP, &rotated.UserAgent,
+			&inc.SessionsRevoked, &chain); err != nil {
+			return nil, err
+		}
+		inc.Presenter.At = &inc.DetectedAt
+		if rotatedAt != nil {
+			rotated.At = rotatedAt
+			inc.Rotated = &rotated
+		}
+		if err := json.Unmarshal(chain, &inc.SessionChain); err != nil {
+			return nil, err
+		}
+		out = append(out, inc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,81 @@
+package authapi
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// APIVersionPrefix is prepended to every route of the current API version.
+const APIVersionPrefix = "/v1"
+
+// defaultLegacyRoutesDeprecatedAt is the date the unversioned routes were
+// deprecated, announced in their Deprecation header (RFC 9745) unless
+// ARC_AUTH_LEGACY_ROUTES_DEPRECATED_AT overrides it.
+var defaultLegacyRoutesDeprecatedAt = time.Date(2026, time.October, 16, 0, 0, 0, 0, time.UTC)
+
+type route struct {
+	pattern string
+	handler http.Handler
+}
+
+// routes lists every endpoint without its version prefix.
+func (h *Handler) routes() []route {
+	return []route{
+		{"/auth/login", http.HandlerFunc(h.handleLogin)},
+		{"/auth/login/challenge", http.HandlerFunc(h.handleLoginChallenge)},
+		{"/auth/otp/request", http.HandlerFunc(h.handleOTPRequest)},
+		{"/auth/otp/verify", http.HandlerFunc(h.handleOTPVerify)},
+		{"/auth/refresh", h.csrf(h.handleRefresh)},
+		{"/auth/refresh/nonce", h.csrf(h.handleRefreshNonce)},
+		{"/auth/token/access", h.csrf(h.handleAccessRenew)},
+		{"/auth/token/exchange", http.HandlerFunc(h.handleTokenExchange)},
+		{"/auth/ws-ticket", http.HandlerFunc(h.handleWSTicket)},
+		{"/auth/logout", h.csrf(h.handleLogout)},
+		{"/auth/logout_all", h.csrf(h.handleLogoutAll)},
+		{"/auth/invites/create", http.HandlerFunc(h.handleInviteCreate)},
+		{"/auth/invites/consume", http.HandlerFunc(h.handleInviteConsume)},
+		{"/auth/invites/send", http.HandlerFunc(h.handleInviteSend)},
+		{"/auth/invites/deliveries", http.HandlerFunc(h.handleInviteDeliveries)},
+		{"/me", http.HandlerFunc(h.handleMe)},
+		{"/me/sessions", http.HandlerFunc(h.handleMeSessions)},
+		{"/me/phone", http.HandlerFunc(h.handleMePhone)},
+		{"/me/phone/verify", http.HandlerFunc(h.handleMePhoneVerify)},
+		{"/me/recovery_codes", http.HandlerFunc(h.handleMeRecoveryCodes)},
+		{"/me/export", http.HandlerFunc(h.handleMeExport)},
+		{privacyExportDownloadPath, http.HandlerFunc(h.handleMeExportDownload)},
+		{"/security/pin-report", http.HandlerFunc(h.handlePinReport)},
+		{"/admin/sessions/revoke", http.HandlerFunc(h.handleAdminSessionsRevoke)},
+		{"/admin/users/{id}", http.HandlerFunc(h.handleAdminUser)},
+		{"/admin/users/{id}/purge", http.HandlerFunc(h.handleAdminUserPurge)},
+		{"/admin/users/{id}/lock", http.HandlerFunc(h.handleAdminUserLock)},
+		{"/admin/users/{id}/unlock", http.HandlerFunc(h.handleAdminUserUnlock)},
+		{"/admin/users/{id}/logout_all", http.HandlerFunc(h.handleAdminUserLogoutAll)},
+		{"/admin/security/events", http.HandlerFunc(h.handleAdminSecurityEvents)},
+		{"/admin/security/reuse-incidents", http.HandlerFunc(h.handleAdminReuseIncidents)},
+		{"/admin/invites/stats", http.HandlerFunc(h.handleAdminInviteStats)},
+		{"/admin/stats/auth", http.HandlerFunc(h.handleAdminAuthStats)},
+		{"/admin/maintenance", http.HandlerFunc(h.handleAdminMaintenance)},
+		{"/admin/feature-flags", http.HandlerFunc(h.handleAdminFeatureFlags)},
+		{"/admin/feature-flags/{name}", http.HandlerFunc(h.handleAdminFeatureFlag)},
+	}
+}
+
+// legacyRoute serves next at the unversioned pattern, marking the response deprecated
+// and linking to the versioned successor.
+func (h *Handler) legacyRoute(pattern string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hdr := w.Header()
+		deprecatedAt := h.cfg.LegacyRoutesDeprecatedAt
+		if deprecatedAt.IsZero() {
+			deprecatedAt = defaultLegacyRoutesDeprecatedAt
+		}
+		hdr.Set("Deprecation", "@"+strconv.FormatInt(deprecatedAt.Unix(), 10))
+		if sunset := h.cfg.LegacyRoutesSunset; !sunset.IsZero() {
+			hdr.Set("Sunset", sunset.Format(http.TimeFormat))
+		}
+		hdr.Add("Link", "<"+APIVersionPrefix+r.URL.EscapedPath()+`>; rel="successor-version"`)
+		legacyRouteRequests.With(pattern).Inc()
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,67 @@
+package authapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRegister_VersionedAndLegacyRoutes(t *testing.T) {
+	sunset := time.Date(2027, time.April, 1, 0, 0, 0, 0, time.UTC)
+	mux := http.NewServeMux()
+	(&Handler{cfg: Config{LegacyRoutesSunset: sunset}}).Register(mux)
+
+	serve := func(target string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, target, nil))
+		return rr
+	}
+
+	v1 := serve("/v1/auth/login")
+	if v1.Code != http.StatusMethodNotAllowed || v1.Header().Get("Deprecation") != "" {
+		t.Fatalf("v1: status %d, deprecation %q", v1.Code, v1.Header().Get("Deprecation"))
+	}
+
+	legacy := serve("/admin/users/01USER/lock")
+	if legacy.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("legacy: status %d", legacy.Code)
+	}
+	if got := legacy.Header().Get("Deprecation"); got != "@1792108800" {
+		t.Fatalf("unexpected Deprecation %q", got)
+	}
+	if got := legacy.Header().Get("Sunset"); got != "Thu, 01 Apr 2027 00:00:00 GMT" {
+		t.Fatalf("unexpected Sunset %q", got)
+	}
+	if got := legacy.Header().Get("Link"); got != `</v1/admin/users/01USER/lock>; rel="successor-version"` {
+		t.Fatalf("unexpected Link %q", got)
+	}
+}
+
+func TestRegister_DisableLegacyRoutes(t *testing.T) {
+	mux := http.NewServeMux()
+	(&Handler{cfg: Config{DisableLegacyRoutes: true}}).Register(mux)
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/auth/login", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected legacy route to be gone, got %d", rr.Code)
+	}
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/auth/login", nil))
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected v1 route, got %d", rr.Code)
+	}
+}
+
+func TestRegister_LegacyRoutesDeprecatedAt(t *testing.T) {
+	t.Setenv("ARC_AUTH_LEGACY_ROUTES_DEPRECATED_AT", "2027-01-01T00:00:00Z")
+	mux := http.NewServeMux()
+	(&Handler{cfg: LoadConfigFromEnv()}).Register(mux)
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/auth/login", nil))
+	if got := rr.Header().Get("Deprecation"); got != "@1798761600" {
+		t.Fatalf("unexpected Deprecation %q", got)
+	}
+}
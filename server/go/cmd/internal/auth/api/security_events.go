@@ -0,0 +1,302 @@
+package authapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"arc/cmd/internal/dbtrace"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	securityEventPinFailure = "pin_failure"
+
+	maxPinReportPins        = 16
+	maxPinReportPinLen      = 128
+	maxPinReportFieldLen    = 256
+	maxSecurityEventUALen   = 512
+	defaultSecurityEventsN  = 100
+	maxSecurityEventsListed = 500
+)
+
+var errInvalidPinReport = errors.New("invalid pin report")
+
+// handlePinReport accepts certificate pinning validation failures from native clients.
+//
+// The endpoint is unauthenticated by design: a client that failed pinning cannot
+// trust the channel it would authenticate over. Abuse is bounded by a per-IP window.
+func (h *Handler) handlePinReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.dbEnabled {
+		writeError(w, http.StatusServiceUnavailable, "db_unavailable", "database not configured")
+		return
+	}
+
+	var req pinReportRequest
+	if err := decodeJSON(w, r, h.cfg.MaxBodyBytes, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_json", "invalid request body")
+		return
+	}
+	meta, err := req.normalize()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "hostname and pins are required")
+		return
+	}
+
+	ctx := r.Context()
+	now := time.Now().UTC()
+	ip := h.clientIP(r)
+
+	if blocked, retryAfter, err := h.checkPinReportThrottle(ctx, ip, now); err != nil {
+		h.log.Error("security.pin_report.throttle_ip.fail", "err", err)
+		writeError(w, http.StatusServiceUnavailable, "server_busy", "please retry later")
+		return
+	} else if blocked {
+		writeRateLimited(w, retryAfter)
+		return
+	}
+
+	if loc := h.lookupGeo(ctx, ip); !loc.IsZero() {
+		meta["geo"] = loc
+	}
+	platform := normalizePlatform(req.Platform)
+	if err := insertSecurityEvent(ctx, h.pool, h.schema, now, securityEventPinFailure, ip, truncateRunes(r.UserAgent(), maxSecurityEventUALen), string(platform), meta); err != nil {
+		h.log.Error("security.pin_report.insert.fail", "err", err)
+		writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+		return
+	}
+
+	h.log.Warn("security.pin_report", "hostname", meta["hostname"], "platform", platform, "ip", ipString(ip))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// normalize validates the report and returns the metadata persisted with the event.
+func (req pinReportRequest) normalize() (map[string]any, error) {
+	hostname := strings.ToLower(strings.TrimSpace(req.Hostname))
+	if hostname == "" || len(hostname) > 253 {
+		return nil, errInvalidPinReport
+	}
+	if req.Port < 0 || req.Port > 65535 {
+		return nil, errInvalidPinReport
+	}
+
+	known, err := normalizePins(req.KnownPins)
+	if err != nil {
+		return nil, err
+	}
+	served, err := normalizePins(req.ServedChainPins)
+	if err != nil {
+		return nil, err
+	}
+	if len(known) == 0 && len(served) == 0 {
+		return nil, errInvalidPinReport
+	}
+
+	meta := map[string]any{
+		"hostname":           hostname,
+		"known_pins":         known,
+		"served_chain_pins":  served,
+		"include_subdomains": req.IncludeSubdomains,
+	}
+	if req.Port > 0 {
+		meta["port"] = req.Port
+	}
+	if v := truncateRunes(req.AppVersion, maxPinReportFieldLen); v != "" {
+		meta["app_version"] = v
+	}
+	if v := truncateRunes(req.FailureReason, maxPinReportFieldLen); v != "" {
+		meta["failure_reason"] = v
+	}
+	if req.OccurredAt != nil {
+		meta["occurred_at"] = req.OccurredAt.UTC().Format(time.RFC3339)
+	}
+	return meta, nil
+}
+
+func normalizePins(pins []string) ([]string, error) {
+	if len(pins) > maxPinReportPins {
+		return nil, errInvalidPinReport
+	}
+	out := make([]string, 0, len(pins))
+	for _, p := range pins {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if len(p) > maxPinReportPinLen {
+			return nil, errInvalidPinReport
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+func (h *Handler) checkPinReportThrottle(ctx context.Context, ip net.IP, now time.Time) (bool, time.Duration, error) {
+	if ip == nil || h.cfg.PinReportIPMax <= 0 || h.cfg.PinReportIPWindow <= 0 {
+		return false, 0, nil
+	}
+	recent, err := recentSecurityEventTimesByIP(ctx, h.pool, h.schema, securityEventPinFailure, ip, now.Add(-h.cfg.PinReportIPWindow), h.cfg.PinReportIPMax)
+	if err != nil {
+		return false, 0, err
+	}
+	blocked, retryAfter := evaluateWindowThrottle(now, recent, h.cfg.PinReportIPMax, h.cfg.PinReportIPWindow)
+	return blocked, retryAfter, nil
+}
+
+// handleAdminSecurityEvents lists recent security events for the security dashboard.
+func (h *Handler) handleAdminSecurityEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.dbEnabled {
+		writeError(w, http.StatusServiceUnavailable, "db_unavailable", "database not configured")
+		return
+	}
+
+	if _, ok := h.requireAdmin(w, r); !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	kind := strings.TrimSpace(q.Get("kind"))
+	limit := defaultSecurityEventsN
+	if raw := strings.TrimSpace(q.Get("limit")); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			writeError(w, http.StatusBadRequest, "invalid_request", "invalid limit")
+			return
+		}
+		limit = min(n, maxSecurityEventsListed)
+	}
+
+	events, err := listSecurityEvents(r.Context(), h.pool, h.schema, kind, limit)
+	if err != nil {
+		h.log.Error("security.events.list.fail", "err", err)
+		writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+		return
+	}
+	writeJSON(w, http.StatusOK, securityEventsResponse{Events: events})
+}
+
+// truncateRunes trims s and caps it at n runes.
+func truncateRunes(s string, n int) string {
+	s = strings.TrimSpace(s)
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
+
+func ipString(ip net.IP) string {
+	if ip == nil {
+		return ""
+	}
+	return ip.String()
+}
+
+// ---- security event queries ----
+
+func insertSecurityEvent(ctx context.Context, pool *pgxpool.Pool, schema string, now time.Time, kind string, ip net.IP, ua string, platform string, meta map[string]any) error {
+	ctx = dbtrace.WithOp(ctx, "authapi.insert_security_event")
+	var ipVal any
+	if ip != nil {
+		ipVal = ip.String()
+	}
+	var metaVal *string
+	if len(meta) > 0 {
+		b, err := json.Marshal(meta)
+		if err != nil {
+			return err
+		}
+		s := string(b)
+		metaVal = &s
+	}
+
+	_, err := pool.Exec(ctx, `
+		INSERT INTO `+pgIdent(schema, "security_events")+` (kind, created_at, ip, user_agent, platform, meta)
+		VALUES ($1, $2, $3, $4, $5, $6::jsonb)
+	`, kind, now, ipVal, trimOrNil(ua), platform, metaVal)
+	return err
+}
+
+func recentSecurityEventTimesByIP(ctx context.Context, pool *pgxpool.Pool, schema string, kind string, ip net.IP, since time.Time, limit int) ([]time.Time, error) {
+	ctx = dbtrace.WithOp(ctx, "authapi.recent_security_event_times_by_ip")
+	if pool == nil || ip == nil || limit <= 0 {
+		return nil, nil
+	}
+
+	rows, err := pool.Query(ctx, `
+		SELECT created_at
+		FROM `+pgIdent(schema, "security_events")+`
+		WHERE kind = $1
+		  AND ip = $2
+		  AND created_at >= $3
+		ORDER BY created_at DESC
+		LIMIT $4
+	`, kind, ip.String(), since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]time.Time, 0, limit)
+	for rows.Next() {
+		var ts time.Time
+		if err := rows.Scan(&ts); err != nil {
+			return nil, err
+		}
+		out = append(out, ts)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func listSecurityEvents(ctx context.Context, pool *pgxpool.Pool, schema string, kind string, limit int) ([]securityEventResponse, error) {
+	ctx = dbtrace.WithOp(ctx, "authapi.list_security_events")
+	rows, err := pool.Query(ctx, `
+		SELECT id, kind, created_at, COALESCE(host(ip), ''), COALESCE(user_agent, ''), platform, meta
+		FROM `+pgIdent(schema, "security_events")+`
+		WHERE ($1 = '' OR kind = $1)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2
+	`, kind, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]securityEventResponse, 0, limit)
+	for rows.Next() {
+		var (
+			ev   securityEventResponse
+			meta []byte
+		)
+		if err := rows.Scan(&ev.ID, &ev.Kind, &ev.CreatedAt, &ev.IP, &ev.UserAgent, &ev.Platform, &meta); err != nil {
+			return nil, err
+		}
+		if len(meta) > 0 {
+			if err := json.Unmarshal(meta, &ev.Meta); err != nil {
+				return nil, err
+			}
+		}
+		out = append(out, ev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,50 @@
+package authapi
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPinReportNormalize(t *testing.T) {
+	req := pinReportRequest{
+		Hostname:        " API.Example.com ",
+		Port:            443,
+		Platform:        "ios",
+		KnownPins:       []string{"sha256/AAAA", " "},
+		ServedChainPins: []string{"sha256/BBBB"},
+		FailureReason:   "pin mismatch",
+	}
+	meta, err := req.normalize()
+	if err != nil {
+		t.Fatalf("normalize: %v", err)
+	}
+	if meta["hostname"] != "api.example.com" {
+		t.Fatalf("expected normalized hostname, got %v", meta["hostname"])
+	}
+	if pins, _ := meta["known_pins"].([]string); len(pins) != 1 {
+		t.Fatalf("expected blank pins dropped, got %v", meta["known_pins"])
+	}
+	if meta["port"] != 443 {
+		t.Fatalf("expected port, got %v", meta["port"])
+	}
+}
+
+func TestPinReportNormalize_Rejects(t *testing.T) {
+	tooMany := make([]string, maxPinReportPins+1)
+	for i := range tooMany {
+		tooMany[i] = "sha256/x"
+	}
+
+	tests := []pinReportRequest{
+		{KnownPins: []string{"sha256/AAAA"}},
+		{Hostname: "api.example.com"},
+		{Hostname: "api.example.com", Port: 70000, KnownPins: []string{"sha256/AAAA"}},
+		{Hostname: "api.example.com", KnownPins: tooMany},
+		{Hostname: "api.example.com", KnownPins: []string{strings.Repeat("a", maxPinReportPinLen+1)}},
+	}
+	for i, req := range tests {
+		if _, err := req.normalize(); err == nil {
+			t.Fatalf("case %d: expected error", i)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"net"
 	"strings"
+	"time"
 )
 
 var (
@@ -22,12 +23,34 @@ type EmailVerificationMessage struct {
 	Email  string
 }
 
-// EmailSender sends verification emails.
+// LoginChallengeMessage is the canonical payload for login challenge code delivery.
+type LoginChallengeMessage struct {
+	UserID      string
+	Email       string
+	ChallengeID string
+	Code        string
+	ExpiresAt   time.Time
+}
+
+// InviteMessage is the canonical payload for invite email delivery. Link is the
+// deep link built from ARC_AUTH_INVITE_LINK_TEMPLATE and carries the invite token.
+type InviteMessage struct {
+	InviteID  string
+	InviterID string
+	Email     string
+	Link      string
+	Note      *string
+	ExpiresAt time.Time
+}
+
+// EmailSender sends verification, login challenge and invite emails.
 //
 // NOTE:
 // PR-011 ships with no-op defaults only. Real delivery providers are wired later.
 type EmailSender interface {
 	SendEmailVerification(ctx context.Context, msg EmailVerificationMessage) error
+	SendLoginChallenge(ctx context.Context, msg LoginChallengeMessage) error
+	SendInvite(ctx context.Context, msg InviteMessage) error
 }
 
 // NoopEmailSender is the default email sender used in this phase.
@@ -38,6 +61,38 @@ func (NoopEmailSender) SendEmailVerification(_ context.Context, _ EmailVerificat
 	return nil
 }
 
+// SendLoginChallenge is a no-op implementation; codes are only delivered once a provider is wired.
+func (NoopEmailSender) SendLoginChallenge(_ context.Context, _ LoginChallengeMessage) error {
+	return nil
+}
+
+// SendInvite is a no-op implementation; invites are only delivered once a provider is wired.
+func (NoopEmailSender) SendInvite(_ context.Context, _ InviteMessage) error {
+	return nil
+}
+
+// PhoneOTPMessage is the canonical payload for SMS one-time code delivery.
+// Purpose is "login" or "link" (confirming a number added at /me/phone).
+type PhoneOTPMessage struct {
+	Phone     string
+	OTPID     string
+	Code      string
+	Purpose   string
+	ExpiresAt time.Time
+}
+
+// SMSSender delivers one-time codes by SMS. Handlers try the configured senders in
+// order (see WithSMSSenders), so a second provider can take over when the first fails.
+type SMSSender interface {
+	SendOTP(ctx context.Context, msg PhoneOTPMessage) error
+}
+
+// NoopSMSSender is the default SMS sender; codes are only delivered once a provider is wired.
+type NoopSMSSender struct{}
+
+// SendOTP is a no-op implementation.
+func (NoopSMSSender) SendOTP(_ context.Context, _ PhoneOTPMessage) error { return nil }
+
 // CaptchaVerifier verifies user-provided captcha tokens.
 //
 // NOTE:
@@ -188,3 +188,13 @@ func (s *emailSenderStub) SendEmailVerification(_ context.Context, _ EmailVerifi
 	s.calls++
 	return nil
 }
+
+func (s *emailSenderStub) SendLoginChallenge(_ context.Context, _ LoginChallengeMessage) error {
+	s.calls++
+	return nil
+}
+
+func (s *emailSenderStub) SendInvite(_ context.Context, _ InviteMessage) error {
+	s.calls++
+	return nil
+}
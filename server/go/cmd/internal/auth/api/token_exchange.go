@@ -0,0 +1,168 @@
+package authapi
+
+import (
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"arc/cmd/internal/auth/session"
+	"arc/cmd/security/token"
+)
+
+// RFC 8693 identifiers accepted and returned by POST /auth/token/exchange.
+const (
+	grantTypeTokenExchange = "urn:ietf:params:oauth:grant-type:token-exchange"
+	tokenTypeAccessToken   = "urn:ietf:params:oauth:token-type:access_token"
+)
+
+// handleTokenExchange serves POST /auth/token/exchange: a trusted client (HTTP Basic
+// with its ID and secret) trades a user's access token for a delegated token limited
+// to one audience and a subset of the configured scopes. The delegated token names
+// the client in its "act" claim and is not accepted by Arc's own endpoints.
+//
+// Errors use the RFC 8693 / RFC 6749 codes (invalid_client, invalid_grant,
+// invalid_target, invalid_scope, ...).
+func (h *Handler) handleTokenExchange(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.dbEnabled {
+		writeError(w, http.StatusServiceUnavailable, "db_unavailable", "database not configured")
+		return
+	}
+	if len(h.cfg.TokenExchangeClients) == 0 {
+		writeError(w, http.StatusServiceUnavailable, "token_exchange_unavailable", "token exchange not configured")
+		return
+	}
+
+	clientID, ok := h.authenticateExchangeClient(r)
+	if !ok {
+		w.Header().Set("WWW-Authenticate", `Basic realm="arc"`)
+		writeError(w, http.StatusUnauthorized, "invalid_client", "client authentication failed")
+		return
+	}
+
+	var req tokenExchangeRequest
+	if err := decodeJSON(w, r, h.cfg.MaxBodyBytes, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "invalid request body")
+		return
+	}
+	if req.GrantType != grantTypeTokenExchange {
+		writeError(w, http.StatusBadRequest, "unsupported_grant_type", "grant_type must be "+grantTypeTokenExchange)
+		return
+	}
+	if strings.TrimSpace(req.SubjectToken) == "" || req.SubjectTokenType != tokenTypeAccessToken {
+		writeError(w, http.StatusBadRequest, "invalid_request", "subject_token must be an access token")
+		return
+	}
+	if req.RequestedTokenType != "" && req.RequestedTokenType != tokenTypeAccessToken {
+		writeError(w, http.StatusBadRequest, "invalid_request", "only access tokens can be requested")
+		return
+	}
+	audience := strings.TrimSpace(req.Audience)
+	if audience == "" || !slices.Contains(h.cfg.TokenExchangeAudiences, audience) {
+		writeError(w, http.StatusBadRequest, "invalid_target", "audience not allowed")
+		return
+	}
+	scopes, ok := exchangeScopes(req.Scope, h.cfg.TokenExchangeScopes)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid_scope", "scope not allowed")
+		return
+	}
+
+	ctx := r.Context()
+	now := time.Now().UTC()
+	out, err := h.sessions.ExchangeToken(ctx, now, session.ExchangeInput{
+		SubjectToken: req.SubjectToken,
+		Actor:        clientID,
+		Audience:     audience,
+		Scopes:       scopes,
+		TTL:          h.cfg.TokenExchangeTTL,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, session.ErrInvalidToken), errors.Is(err, session.ErrSessionExpired),
+			errors.Is(err, session.ErrSessionRevoked), errors.Is(err, session.ErrSessionNotFound),
+			errors.Is(err, session.ErrUserLocked):
+			h.log.Info("auth.token.exchange", "client_id", clientID,
+				"reason", session.InvalidTokenReason(err), "result", "invalid_grant")
+			writeError(w, http.StatusBadRequest, "invalid_grant", "subject_token is not valid")
+		default:
+			h.log.Error("auth.token.exchange.fail", "err", err, "result", "server_error")
+			writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+		}
+		return
+	}
+
+	claims := out.Claims
+	h.insertAudit(ctx, "auth.token.exchanged", &claims.UserID, &claims.SessionID,
+		h.clientIP(r), strings.TrimSpace(r.UserAgent()), map[string]any{
+			"client_id": clientID,
+			"audience":  audience,
+			"scope":     strings.Join(scopes, " "),
+		})
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, tokenExchangeResponse{
+		AccessToken:     out.AccessToken,
+		IssuedTokenType: tokenTypeAccessToken,
+		TokenType:       "Bearer",
+		ExpiresIn:       int64(claims.ExpiresAt.Sub(now).Seconds()),
+		Scope:           strings.Join(scopes, " "),
+	})
+}
+
+// authenticateExchangeClient checks HTTP Basic credentials against the configured
+// secret digests. Every client is compared so timing does not reveal which ID exists.
+func (h *Handler) authenticateExchangeClient(r *http.Request) (string, bool) {
+	id, secret, ok := r.BasicAuth()
+	id = strings.TrimSpace(id)
+	if !ok || id == "" || secret == "" {
+		return "", false
+	}
+	sum := []byte(token.HashSHA256Hex(secret))
+
+	match := 0
+	for clientID, want := range h.cfg.TokenExchangeClients {
+		eq := subtle.ConstantTimeCompare(sum, []byte(want))
+		match |= eq & subtle.ConstantTimeCompare([]byte(id), []byte(clientID))
+	}
+	return id, match == 1
+}
+
+// exchangeScopes splits a space-delimited scope parameter and checks every entry
+// against allowed. Duplicates are dropped; an empty parameter requests no scopes.
+func exchangeScopes(raw string, allowed []string) ([]string, bool) {
+	var out []string
+	for _, s := range strings.Fields(raw) {
+		if !slices.Contains(allowed, s) {
+			return nil, false
+		}
+		if !slices.Contains(out, s) {
+			out = append(out, s)
+		}
+	}
+	return out, true
+}
+
+// parseExchangeClients reads "client_id:sha256hex" entries, dropping malformed ones.
+func parseExchangeClients(entries []string) map[string]string {
+	out := make(map[string]string, len(entries))
+	for _, e := range entries {
+		id, sum, ok := strings.Cut(e, ":")
+		id, sum = strings.TrimSpace(id), strings.ToLower(strings.TrimSpace(sum))
+		if !ok || id == "" || len(sum) != 64 {
+			continue
+		}
+		if _, err := hex.DecodeString(sum); err != nil {
+			continue
+		}
+		out[id] = sum
+	}
+	return out
+}
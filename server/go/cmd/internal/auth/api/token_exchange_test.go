@@ -0,0 +1,88 @@
+package authapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"arc/cmd/security/token"
+)
+
+func TestParseExchangeClients(t *testing.T) {
+	sum := token.HashSHA256Hex("s3cret")
+	got := parseExchangeClients([]string{"gateway:" + strings.ToUpper(sum), "bad", "short:abc", ":" + sum})
+	if len(got) != 1 || got["gateway"] != sum {
+		t.Fatalf("unexpected clients %v", got)
+	}
+}
+
+func TestExchangeScopes(t *testing.T) {
+	allowed := []string{"profile", "messages:read"}
+	got, ok := exchangeScopes(" profile  messages:read profile ", allowed)
+	if !ok || !reflect.DeepEqual(got, []string{"profile", "messages:read"}) {
+		t.Fatalf("unexpected scopes %v (ok=%v)", got, ok)
+	}
+	if got, ok := exchangeScopes("", allowed); !ok || got != nil {
+		t.Fatalf("expected no scopes, got %v (ok=%v)", got, ok)
+	}
+	if _, ok := exchangeScopes("profile admin", allowed); ok {
+		t.Fatalf("expected unknown scope to be rejected")
+	}
+}
+
+func TestHandleTokenExchange_RejectsBeforeExchanging(t *testing.T) {
+	h := &Handler{
+		log:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+		dbEnabled: true,
+		cfg: Config{
+			MaxBodyBytes:           1 << 20,
+			TokenExchangeClients:   map[string]string{"gateway": token.HashSHA256Hex("s3cret")},
+			TokenExchangeAudiences: []string{"billing"},
+			TokenExchangeScopes:    []string{"profile"},
+		},
+	}
+	valid := tokenExchangeRequest{
+		GrantType:        grantTypeTokenExchange,
+		SubjectToken:     "user-token",
+		SubjectTokenType: tokenTypeAccessToken,
+		Audience:         "billing",
+		Scope:            "profile",
+	}
+
+	tests := []struct {
+		name   string
+		secret string
+		mutate func(*tokenExchangeRequest)
+		status int
+		code   string
+	}{
+		{name: "wrong secret", secret: "nope", status: http.StatusUnauthorized, code: "invalid_client"},
+		{name: "grant type", secret: "s3cret", mutate: func(r *tokenExchangeRequest) { r.GrantType = "password" }, status: http.StatusBadRequest, code: "unsupported_grant_type"},
+		{name: "token type", secret: "s3cret", mutate: func(r *tokenExchangeRequest) { r.SubjectTokenType = "urn:x" }, status: http.StatusBadRequest, code: "invalid_request"},
+		{name: "audience", secret: "s3cret", mutate: func(r *tokenExchangeRequest) { r.Audience = "payroll" }, status: http.StatusBadRequest, code: "invalid_target"},
+		{name: "scope", secret: "s3cret", mutate: func(r *tokenExchangeRequest) { r.Scope = "profile admin" }, status: http.StatusBadRequest, code: "invalid_scope"},
+	}
+	for _, tc := range tests {
+		req := valid
+		if tc.mutate != nil {
+			tc.mutate(&req)
+		}
+		body, _ := json.Marshal(req)
+		r := httptest.NewRequest(http.MethodPost, "/auth/token/exchange", bytes.NewReader(body))
+		r.SetBasicAuth("gateway", tc.secret)
+		rr := httptest.NewRecorder()
+		h.handleTokenExchange(rr, r)
+
+		var resp errorResponse
+		_ = json.Unmarshal(rr.Body.Bytes(), &resp)
+		if rr.Code != tc.status || resp.Error.Code != tc.code {
+			t.Fatalf("%s: got %d %q, want %d %q", tc.name, rr.Code, resp.Error.Code, tc.status, tc.code)
+		}
+	}
+}
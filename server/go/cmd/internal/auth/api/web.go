@@ -23,6 +23,12 @@ func (h *Handler) setWebSessionCookies(w http.ResponseWriter, refreshToken strin
 
 	h.setRefreshCookie(w, refreshToken, refreshExp)
 	h.setCSRFCookie(w, csrf, refreshExp)
+
+	// With SameSite=None the client typically runs on another site and cannot read
+	// the API's CSRF cookie, so the token is also handed out in the response header.
+	if h.cfg.CookieSameSite == http.SameSiteNoneMode && strings.TrimSpace(h.cfg.CSRFHeaderName) != "" {
+		w.Header().Set(h.cfg.CSRFHeaderName, csrf)
+	}
 	return csrf, nil
 }
 
@@ -32,6 +38,25 @@ func (h *Handler) clearWebSessionCookies(w http.ResponseWriter) {
 	}
 	h.expireCookie(w, h.cfg.RefreshCookieName, true)
 	h.expireCookie(w, h.cfg.CSRFCookieName, false)
+	if h.cfg.WebAccessCookieEnabled {
+		h.expireCookie(w, h.cfg.AccessCookieName, true)
+	}
+}
+
+// accessTokenFromRequest returns the bearer token, falling back to the access cookie
+// in BFF mode; fromCookie reports the fallback.
+func (h *Handler) accessTokenFromRequest(r *http.Request) (token string, fromCookie bool) {
+	if token := bearerToken(r); token != "" {
+		return token, false
+	}
+	if h == nil || !h.cfg.WebRefreshCookieEnabled || !h.cfg.WebAccessCookieEnabled {
+		return "", false
+	}
+	c, err := r.Cookie(h.cfg.AccessCookieName)
+	if err != nil {
+		return "", false
+	}
+	return strings.TrimSpace(c.Value), true
 }
 
 func (h *Handler) refreshTokenFromCookie(r *http.Request) (string, bool) {
@@ -50,19 +75,10 @@ func (h *Handler) refreshTokenFromCookie(r *http.Request) (string, bool) {
 }
 
 func (h *Handler) csrfDoubleSubmitValid(r *http.Request) bool {
-	if h == nil || r == nil || !h.cfg.WebRefreshCookieEnabled {
+	if h == nil {
 		return false
 	}
-	c, err := r.Cookie(h.cfg.CSRFCookieName)
-	if err != nil {
-		return false
-	}
-	cv := strings.TrimSpace(c.Value)
-	hv := strings.TrimSpace(r.Header.Get(h.cfg.CSRFHeaderName))
-	if cv == "" || hv == "" {
-		return false
-	}
-	return secureStringEqual(cv, hv)
+	return csrfDoubleSubmitValid(h.cfg, r)
 }
 
 func (h *Handler) setRefreshCookie(w http.ResponseWriter, value string, exp time.Time) {
@@ -81,6 +97,23 @@ func (h *Handler) setRefreshCookie(w http.ResponseWriter, value string, exp time
 	})
 }
 
+// setAccessCookie stores the access token in an HttpOnly cookie that expires with it.
+func (h *Handler) setAccessCookie(w http.ResponseWriter, value string, exp time.Time) {
+	if h == nil || w == nil {
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     h.cfg.AccessCookieName,
+		Value:    value,
+		Path:     h.cfg.CookiePath,
+		Domain:   h.cfg.CookieDomain,
+		Expires:  exp,
+		HttpOnly: true,
+		Secure:   h.cfg.CookieSecure,
+		SameSite: h.cfg.CookieSameSite,
+	})
+}
+
 func (h *Handler) setCSRFCookie(w http.ResponseWriter, value string, exp time.Time) {
 	if h == nil || w == nil {
 		return
@@ -83,3 +83,155 @@ func TestRefreshTokenFromCookie(t *testing.T) {
 		t.Fatalf("unexpected cookie token: %q", token)
 	}
 }
+
+func TestCSRFMiddleware(t *testing.T) {
+	cfg := Config{
+		WebRefreshCookieEnabled: true,
+		RefreshCookieName:       "arc_refresh_token",
+		CSRFCookieName:          "arc_csrf_token",
+		CSRFHeaderName:          "X-CSRF-Token",
+	}
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mw := CSRFMiddleware(cfg, next)
+
+	tests := []struct {
+		name   string
+		method string
+		cookie bool
+		header string
+		want   int
+	}{
+		{name: "bearer only passes", method: http.MethodPost, want: http.StatusNoContent},
+		{name: "safe method passes", method: http.MethodGet, cookie: true, want: http.StatusNoContent},
+		{name: "cookie without header rejected", method: http.MethodPost, cookie: true, want: http.StatusForbidden},
+		{name: "cookie with wrong header rejected", method: http.MethodPost, cookie: true, header: "nope", want: http.StatusForbidden},
+		{name: "cookie with matching header passes", method: http.MethodPost, cookie: true, header: "csrf-abc", want: http.StatusNoContent},
+	}
+	for _, tc := range tests {
+		req := httptest.NewRequest(tc.method, "/auth/logout", nil)
+		if tc.cookie {
+			req.AddCookie(&http.Cookie{Name: "arc_refresh_token", Value: "refresh"})
+			req.AddCookie(&http.Cookie{Name: "arc_csrf_token", Value: "csrf-abc"})
+		}
+		if tc.header != "" {
+			req.Header.Set("X-CSRF-Token", tc.header)
+		}
+		rr := httptest.NewRecorder()
+		mw.ServeHTTP(rr, req)
+		if rr.Code != tc.want {
+			t.Fatalf("%s: expected %d, got %d", tc.name, tc.want, rr.Code)
+		}
+	}
+}
+
+func TestSetWebSessionCookies_SameSiteNoneExposesToken(t *testing.T) {
+	h := &Handler{cfg: Config{
+		WebRefreshCookieEnabled: true,
+		RefreshCookieName:       "arc_refresh_token",
+		CSRFCookieName:          "arc_csrf_token",
+		CSRFHeaderName:          "X-CSRF-Token",
+		CookiePath:              "/",
+		CookieSecure:            true,
+		CookieSameSite:          http.SameSiteNoneMode,
+	}}
+
+	rr := httptest.NewRecorder()
+	csrf, err := h.setWebSessionCookies(rr, "refresh-token-123", time.Now().UTC().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("setWebSessionCookies: %v", err)
+	}
+	if got := rr.Header().Get("X-CSRF-Token"); got != csrf {
+		t.Fatalf("expected csrf token in response header, got %q", got)
+	}
+}
+
+func TestAccessTokenFromRequest(t *testing.T) {
+	h := &Handler{cfg: Config{
+		WebRefreshCookieEnabled: true,
+		WebAccessCookieEnabled:  true,
+		AccessCookieName:        "arc_access_token",
+	}}
+
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.AddCookie(&http.Cookie{Name: "arc_access_token", Value: "cookie-tok"})
+	if tok, fromCookie := h.accessTokenFromRequest(req); tok != "cookie-tok" || !fromCookie {
+		t.Fatalf("expected cookie token, got %q (fromCookie=%v)", tok, fromCookie)
+	}
+
+	req.Header.Set("Authorization", "Bearer bearer-tok")
+	if tok, fromCookie := h.accessTokenFromRequest(req); tok != "bearer-tok" || fromCookie {
+		t.Fatalf("expected bearer token to win, got %q (fromCookie=%v)", tok, fromCookie)
+	}
+
+	h.cfg.WebAccessCookieEnabled = false
+	req.Header.Del("Authorization")
+	if tok, _ := h.accessTokenFromRequest(req); tok != "" {
+		t.Fatalf("expected cookie ignored when access cookie mode is off, got %q", tok)
+	}
+}
+
+func TestRequireAuth_AccessCookieRequiresCSRF(t *testing.T) {
+	h := &Handler{cfg: Config{
+		WebRefreshCookieEnabled: true,
+		WebAccessCookieEnabled:  true,
+		AccessCookieName:        "arc_access_token",
+		CSRFCookieName:          "arc_csrf_token",
+		CSRFHeaderName:          "X-CSRF-Token",
+	}}
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/invites/create", nil)
+	req.AddCookie(&http.Cookie{Name: "arc_access_token", Value: "cookie-tok"})
+	req.AddCookie(&http.Cookie{Name: "arc_csrf_token", Value: "csrf-abc"})
+
+	rr := httptest.NewRecorder()
+	if _, ok := h.requireAuth(rr, req); ok || rr.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 without csrf header, got ok=%v code=%d", ok, rr.Code)
+	}
+}
+
+func TestCSRFMiddleware_AccessCookie(t *testing.T) {
+	cfg := Config{
+		WebRefreshCookieEnabled: true,
+		WebAccessCookieEnabled:  true,
+		RefreshCookieName:       "arc_refresh_token",
+		AccessCookieName:        "arc_access_token",
+		CSRFCookieName:          "arc_csrf_token",
+		CSRFHeaderName:          "X-CSRF-Token",
+	}
+	mw := CSRFMiddleware(cfg, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/logout", nil)
+	req.AddCookie(&http.Cookie{Name: "arc_access_token", Value: "access"})
+	rr := httptest.NewRecorder()
+	mw.ServeHTTP(rr, req)
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("expected access cookie without csrf to be rejected, got %d", rr.Code)
+	}
+}
+
+func TestClearWebSessionCookies_AccessCookie(t *testing.T) {
+	h := &Handler{cfg: Config{
+		WebRefreshCookieEnabled: true,
+		WebAccessCookieEnabled:  true,
+		RefreshCookieName:       "arc_refresh_token",
+		AccessCookieName:        "arc_access_token",
+		CSRFCookieName:          "arc_csrf_token",
+		CookiePath:              "/",
+	}}
+
+	rr := httptest.NewRecorder()
+	h.clearWebSessionCookies(rr)
+	cookies := rr.Result().Cookies()
+	if len(cookies) != 3 {
+		t.Fatalf("expected 3 expired cookies, got %d", len(cookies))
+	}
+	for _, c := range cookies {
+		if c.MaxAge >= 0 {
+			t.Fatalf("expected %s to be expired", c.Name)
+		}
+	}
+}
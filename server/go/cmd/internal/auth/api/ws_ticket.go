@@ -0,0 +1,41 @@
+package authapi
+
+import (
+	"net/http"
+	"time"
+)
+
+// handleWSTicket serves POST /auth/ws-ticket: it trades the caller's access token
+// for a short-lived, single-use ticket that authenticates one WebSocket upgrade
+// (/ws?ticket=...). Browsers cannot set Authorization on the upgrade, and a ticket
+// in the URL is worthless once used, unlike the access token.
+func (h *Handler) handleWSTicket(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.dbEnabled {
+		writeError(w, http.StatusServiceUnavailable, "db_unavailable", "database not configured")
+		return
+	}
+
+	claims, ok := h.requireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	now := time.Now().UTC()
+	ticket, err := h.sessions.IssueWSTicket(r.Context(), now, claims.UserID, claims.SessionID)
+	if err != nil {
+		h.log.Error("auth.ws_ticket.fail", "err", err, "result", "server_error")
+		writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, wsTicketResponse{
+		Ticket:    ticket.Ticket,
+		ExpiresAt: ticket.ExpiresAt,
+		ExpiresIn: int64(ticket.ExpiresAt.Sub(now).Seconds()),
+	})
+}
@@ -0,0 +1,31 @@
+package authapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandleWSTicket_RequiresPostAndAuth(t *testing.T) {
+	h := &Handler{dbEnabled: true}
+
+	rr := httptest.NewRecorder()
+	h.handleWSTicket(rr, httptest.NewRequest(http.MethodGet, "/auth/ws-ticket", nil))
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	h.handleWSTicket(rr, httptest.NewRequest(http.MethodPost, "/auth/ws-ticket", nil))
+	if rr.Code != http.StatusUnauthorized || !strings.Contains(rr.Body.String(), "unauthorized") {
+		t.Fatalf("expected 401 unauthorized, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	h.dbEnabled = false
+	rr = httptest.NewRecorder()
+	h.handleWSTicket(rr, httptest.NewRequest(http.MethodPost, "/auth/ws-ticket", nil))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 without a database, got %d", rr.Code)
+	}
+}
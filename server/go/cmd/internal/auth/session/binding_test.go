@@ -0,0 +1,56 @@
+package session
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func TestParseBindingKey(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+
+	got, err := ParseBindingKey(base64.RawURLEncoding.EncodeToString(pub))
+	if err != nil {
+		t.Fatalf("ParseBindingKey: %v", err)
+	}
+	if !got.Equal(pub) {
+		t.Fatalf("decoded key mismatch")
+	}
+
+	for _, in := range []string{"", "not base64!", base64.RawURLEncoding.EncodeToString(pub[:16])} {
+		if _, err := ParseBindingKey(in); err == nil {
+			t.Fatalf("expected error for %q", in)
+		}
+	}
+}
+
+func TestCheckBinding(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	nonce := "nonce-1"
+	svc := &Service{cfg: Config{RefreshBinding: map[Platform]BindingMode{PlatformIOS: BindingRequired}}}
+
+	bound := Row{Platform: PlatformIOS, BindingKey: pub, BindingNonce: &nonce}
+	if err := svc.checkBinding(bound, ed25519.Sign(priv, BindingProofMessage(nonce))); err != nil {
+		t.Fatalf("expected valid proof, got %v", err)
+	}
+	if err := svc.checkBinding(bound, ed25519.Sign(priv, BindingProofMessage("stale"))); !errors.Is(err, ErrBindingProofInvalid) {
+		t.Fatalf("expected ErrBindingProofInvalid for stale nonce, got %v", err)
+	}
+	if err := svc.checkBinding(bound, nil); !errors.Is(err, ErrBindingProofInvalid) {
+		t.Fatalf("expected ErrBindingProofInvalid without proof, got %v", err)
+	}
+
+	if err := svc.checkBinding(Row{Platform: PlatformIOS}, nil); !errors.Is(err, ErrBindingRequired) {
+		t.Fatalf("expected ErrBindingRequired for unbound ios session, got %v", err)
+	}
+	if err := svc.checkBinding(Row{Platform: PlatformWeb}, nil); err != nil {
+		t.Fatalf("expected unbound web session to pass, got %v", err)
+	}
+}
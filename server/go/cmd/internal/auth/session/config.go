@@ -1,8 +1,10 @@
 package session
 
 import (
-	"os"
+	"arc/cmd/internal/config"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -17,9 +19,23 @@ type Config struct {
 	// Issuer is the value set in the "iss" claim of access tokens.
 	Issuer string
 
+	// Audience is the value set in the "aud" claim of access tokens.
+	Audience string
+
+	// AllowedAudiences lists the "aud" values accepted during verification. Empty
+	// means only Audience, so tokens minted for other services sharing the keypair
+	// are rejected.
+	AllowedAudiences []string
+
 	// AccessTokenTTL defines the lifetime of PASETO access tokens.
 	AccessTokenTTL time.Duration
 
+	// ResumeTokenTTL defines the lifetime of realtime resume tokens.
+	ResumeTokenTTL time.Duration
+
+	// WSTicketTTL defines the lifetime of one-time WebSocket tickets (at most MaxWSTicketTTL).
+	WSTicketTTL time.Duration
+
 	// Refresh token TTL policies per platform.
 	RefreshTTLWeb         time.Duration
 	RefreshTTLNative      time.Duration
@@ -29,6 +45,10 @@ type Config struct {
 	// for the same active session. Zero disables refresh throttling.
 	RefreshMinInterval time.Duration
 
+	// AccessRenewMinInterval caps how often a session may obtain a new access token
+	// without rotating its refresh token. Zero disables the cap.
+	AccessRenewMinInterval time.Duration
+
 	// ClockSkew defines the allowed time skew during token validation.
 	ClockSkew time.Duration
 
@@ -36,6 +56,10 @@ type Config struct {
 	// to generate opaque refresh tokens.
 	RefreshTokenBytes int
 
+	// RefreshBinding maps platforms to their refresh token key-binding mode.
+	// Platforms not present are BindingOff.
+	RefreshBinding map[Platform]BindingMode
+
 	// PasetoV4SecretKeyHex is the hex-encoded Ed25519 secret key
 	// used to sign PASETO v4.public access tokens.
 	PasetoV4SecretKeyHex string
@@ -46,14 +70,18 @@ type Config struct {
 // Production environments should override values via environment variables.
 func DefaultConfig() Config {
 	return Config{
-		Issuer:                "arc",
-		AccessTokenTTL:        15 * time.Minute,
-		RefreshTTLWeb:         7 * 24 * time.Hour,
-		RefreshTTLNative:      60 * 24 * time.Hour,
-		RefreshTTLNativeShort: 14 * 24 * time.Hour,
-		RefreshMinInterval:    0,
-		ClockSkew:             30 * time.Second,
-		RefreshTokenBytes:     32,
+		Issuer:                 "arc",
+		Audience:               "arc",
+		AccessTokenTTL:         15 * time.Minute,
+		ResumeTokenTTL:         10 * time.Minute,
+		WSTicketTTL:            30 * time.Second,
+		RefreshTTLWeb:          7 * 24 * time.Hour,
+		RefreshTTLNative:       60 * 24 * time.Hour,
+		RefreshTTLNativeShort:  14 * 24 * time.Hour,
+		RefreshMinInterval:     0,
+		AccessRenewMinInterval: time.Minute,
+		ClockSkew:              30 * time.Second,
+		RefreshTokenBytes:      32,
 	}
 }
 
@@ -64,23 +92,50 @@ func DefaultConfig() Config {
 //
 // Optional (durations must be valid Go duration strings):
 //   - ARC_AUTH_ISSUER
+//   - ARC_AUTH_AUDIENCE
+//   - ARC_AUTH_ALLOWED_AUDIENCES (comma-separated; must include ARC_AUTH_AUDIENCE)
 //   - ARC_AUTH_ACCESS_TTL
+//   - ARC_AUTH_RESUME_TOKEN_TTL
+//   - ARC_AUTH_WS_TICKET_TTL
 //   - ARC_AUTH_REFRESH_TTL_WEB
 //   - ARC_AUTH_REFRESH_TTL_NATIVE
 //   - ARC_AUTH_REFRESH_TTL_NATIVE_SHORT
 //   - ARC_AUTH_REFRESH_MIN_INTERVAL
+//   - ARC_AUTH_ACCESS_RENEW_MIN_INTERVAL
 //   - ARC_AUTH_CLOCK_SKEW
 //   - ARC_AUTH_REFRESH_TOKEN_BYTES
+//   - ARC_AUTH_REFRESH_BINDING (comma-separated platform=mode, e.g. "ios=required,web=optional")
 //
 // Returns ErrConfig if configuration is invalid.
 func LoadConfigFromEnv() (Config, error) {
+	return LoadConfigFrom(config.Env)
+}
+
+// LoadConfigFrom is LoadConfigFromEnv reading the settings through lookup.
+func LoadConfigFrom(lookup config.Lookup) (Config, error) {
 	cfg := DefaultConfig()
 
-	if v := os.Getenv("ARC_AUTH_ISSUER"); v != "" {
+	if v := lookup.Get("ARC_AUTH_ISSUER"); v != "" {
 		cfg.Issuer = v
 	}
 
-	if v := os.Getenv("ARC_AUTH_ACCESS_TTL"); v != "" {
+	if v := lookup.Get("ARC_AUTH_AUDIENCE"); v != "" {
+		cfg.Audience = v
+	}
+
+	if v := lookup.Get("ARC_AUTH_ALLOWED_AUDIENCES"); v != "" {
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				cfg.AllowedAudiences = append(cfg.AllowedAudiences, part)
+			}
+		}
+		// Arc must accept the tokens it issues.
+		if !slices.Contains(cfg.AllowedAudiences, cfg.Audience) {
+			return Config{}, ErrConfig
+		}
+	}
+
+	if v := lookup.Get("ARC_AUTH_ACCESS_TTL"); v != "" {
 		d, err := time.ParseDuration(v)
 		if err != nil || d <= 0 {
 			return Config{}, ErrConfig
@@ -88,7 +143,23 @@ func LoadConfigFromEnv() (Config, error) {
 		cfg.AccessTokenTTL = d
 	}
 
-	if v := os.Getenv("ARC_AUTH_REFRESH_TTL_WEB"); v != "" {
+	if v := lookup.Get("ARC_AUTH_RESUME_TOKEN_TTL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return Config{}, ErrConfig
+		}
+		cfg.ResumeTokenTTL = d
+	}
+
+	if v := lookup.Get("ARC_AUTH_WS_TICKET_TTL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 || d > MaxWSTicketTTL {
+			return Config{}, ErrConfig
+		}
+		cfg.WSTicketTTL = d
+	}
+
+	if v := lookup.Get("ARC_AUTH_REFRESH_TTL_WEB"); v != "" {
 		d, err := time.ParseDuration(v)
 		if err != nil || d <= 0 {
 			return Config{}, ErrConfig
@@ -96,7 +167,7 @@ func LoadConfigFromEnv() (Config, error) {
 		cfg.RefreshTTLWeb = d
 	}
 
-	if v := os.Getenv("ARC_AUTH_REFRESH_TTL_NATIVE"); v != "" {
+	if v := lookup.Get("ARC_AUTH_REFRESH_TTL_NATIVE"); v != "" {
 		d, err := time.ParseDuration(v)
 		if err != nil || d <= 0 {
 			return Config{}, ErrConfig
@@ -104,7 +175,7 @@ func LoadConfigFromEnv() (Config, error) {
 		cfg.RefreshTTLNative = d
 	}
 
-	if v := os.Getenv("ARC_AUTH_REFRESH_TTL_NATIVE_SHORT"); v != "" {
+	if v := lookup.Get("ARC_AUTH_REFRESH_TTL_NATIVE_SHORT"); v != "" {
 		d, err := time.ParseDuration(v)
 		if err != nil || d <= 0 {
 			return Config{}, ErrConfig
@@ -112,7 +183,7 @@ func LoadConfigFromEnv() (Config, error) {
 		cfg.RefreshTTLNativeShort = d
 	}
 
-	if v := os.Getenv("ARC_AUTH_REFRESH_MIN_INTERVAL"); v != "" {
+	if v := lookup.Get("ARC_AUTH_REFRESH_MIN_INTERVAL"); v != "" {
 		d, err := time.ParseDuration(v)
 		if err != nil || d < 0 {
 			return Config{}, ErrConfig
@@ -120,7 +191,15 @@ func LoadConfigFromEnv() (Config, error) {
 		cfg.RefreshMinInterval = d
 	}
 
-	if v := os.Getenv("ARC_AUTH_CLOCK_SKEW"); v != "" {
+	if v := lookup.Get("ARC_AUTH_ACCESS_RENEW_MIN_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return Config{}, ErrConfig
+		}
+		cfg.AccessRenewMinInterval = d
+	}
+
+	if v := lookup.Get("ARC_AUTH_CLOCK_SKEW"); v != "" {
 		d, err := time.ParseDuration(v)
 		if err != nil || d < 0 {
 			return Config{}, ErrConfig
@@ -128,7 +207,7 @@ func LoadConfigFromEnv() (Config, error) {
 		cfg.ClockSkew = d
 	}
 
-	if v := os.Getenv("ARC_AUTH_REFRESH_TOKEN_BYTES"); v != "" {
+	if v := lookup.Get("ARC_AUTH_REFRESH_TOKEN_BYTES"); v != "" {
 		n, err := strconv.Atoi(v)
 		if err != nil || n < 32 || n > 64 {
 			return Config{}, ErrConfig
@@ -136,7 +215,15 @@ func LoadConfigFromEnv() (Config, error) {
 		cfg.RefreshTokenBytes = n
 	}
 
-	cfg.PasetoV4SecretKeyHex = os.Getenv("ARC_PASETO_V4_SECRET_KEY_HEX")
+	if v := lookup.Get("ARC_AUTH_REFRESH_BINDING"); v != "" {
+		binding, err := parseRefreshBinding(v)
+		if err != nil {
+			return Config{}, err
+		}
+		cfg.RefreshBinding = binding
+	}
+
+	cfg.PasetoV4SecretKeyHex = lookup.Get("ARC_PASETO_V4_SECRET_KEY_HEX")
 	if cfg.PasetoV4SecretKeyHex == "" {
 		return Config{}, ErrConfig
 	}
@@ -148,3 +235,29 @@ func LoadConfigFromEnv() (Config, error) {
 
 	return cfg, nil
 }
+
+func parseRefreshBinding(v string) (map[Platform]BindingMode, error) {
+	out := make(map[Platform]BindingMode)
+	for _, part := range strings.Split(v, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, rawMode, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, ErrConfig
+		}
+		p := Platform(strings.ToLower(strings.TrimSpace(name)))
+		switch p {
+		case PlatformWeb, PlatformIOS, PlatformAndroid, PlatformDesktop:
+		default:
+			return nil, ErrConfig
+		}
+		mode, ok := parseBindingMode(rawMode)
+		if !ok {
+			return nil, ErrConfig
+		}
+		out[p] = mode
+	}
+	return out, nil
+}
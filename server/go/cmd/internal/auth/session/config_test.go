@@ -57,11 +57,37 @@ func TestLoadConfigFromEnv_InvalidRefreshMinInterval(t *testing.T) {
 	}
 }
 
+func TestLoadConfigFromEnv_InvalidAccessRenewMinInterval(t *testing.T) {
+	secret := paseto.NewV4AsymmetricSecretKey()
+	t.Setenv("ARC_PASETO_V4_SECRET_KEY_HEX", secret.ExportHex())
+	t.Setenv("ARC_AUTH_ACCESS_RENEW_MIN_INTERVAL", "-1s")
+
+	_, err := LoadConfigFromEnv()
+	if err != ErrConfig {
+		t.Fatalf("expected ErrConfig for negative access renew min interval, got %v", err)
+	}
+}
+
+func TestLoadConfigFromEnv_InvalidWSTicketTTL(t *testing.T) {
+	secret := paseto.NewV4AsymmetricSecretKey()
+	t.Setenv("ARC_PASETO_V4_SECRET_KEY_HEX", secret.ExportHex())
+	t.Setenv("ARC_AUTH_WS_TICKET_TTL", "10m")
+
+	_, err := LoadConfigFromEnv()
+	if err != ErrConfig {
+		t.Fatalf("expected ErrConfig for ws ticket ttl above the maximum, got %v", err)
+	}
+}
+
 func TestLoadConfigFromEnv_Valid(t *testing.T) {
 	secret := paseto.NewV4AsymmetricSecretKey()
 	t.Setenv("ARC_PASETO_V4_SECRET_KEY_HEX", secret.ExportHex())
 	t.Setenv("ARC_AUTH_ISSUER", "arc-test")
+	t.Setenv("ARC_AUTH_AUDIENCE", "arc-api")
+	t.Setenv("ARC_AUTH_ALLOWED_AUDIENCES", "arc-api, arc")
 	t.Setenv("ARC_AUTH_ACCESS_TTL", "10m")
+	t.Setenv("ARC_AUTH_RESUME_TOKEN_TTL", "5m")
+	t.Setenv("ARC_AUTH_WS_TICKET_TTL", "45s")
 	t.Setenv("ARC_AUTH_REFRESH_TTL_WEB", "48h")
 	t.Setenv("ARC_AUTH_REFRESH_TTL_NATIVE", "720h")
 	t.Setenv("ARC_AUTH_REFRESH_TTL_NATIVE_SHORT", "168h")
@@ -77,9 +103,21 @@ func TestLoadConfigFromEnv_Valid(t *testing.T) {
 	if cfg.Issuer != "arc-test" {
 		t.Fatalf("issuer mismatch: %q", cfg.Issuer)
 	}
+	if cfg.Audience != "arc-api" {
+		t.Fatalf("audience mismatch: %q", cfg.Audience)
+	}
+	if len(cfg.AllowedAudiences) != 2 || cfg.AllowedAudiences[0] != "arc-api" || cfg.AllowedAudiences[1] != "arc" {
+		t.Fatalf("allowed audiences mismatch: %v", cfg.AllowedAudiences)
+	}
 	if cfg.AccessTokenTTL != 10*time.Minute {
 		t.Fatalf("access ttl mismatch: %v", cfg.AccessTokenTTL)
 	}
+	if cfg.ResumeTokenTTL != 5*time.Minute {
+		t.Fatalf("resume ttl mismatch: %v", cfg.ResumeTokenTTL)
+	}
+	if cfg.WSTicketTTL != 45*time.Second {
+		t.Fatalf("ws ticket ttl mismatch: %v", cfg.WSTicketTTL)
+	}
 	if cfg.RefreshTTLWeb != 48*time.Hour {
 		t.Fatalf("refresh web ttl mismatch: %v", cfg.RefreshTTLWeb)
 	}
@@ -99,3 +137,41 @@ func TestLoadConfigFromEnv_Valid(t *testing.T) {
 		t.Fatalf("refresh token bytes mismatch: %d", cfg.RefreshTokenBytes)
 	}
 }
+
+func TestLoadConfigFromEnv_RefreshBinding(t *testing.T) {
+	secret := paseto.NewV4AsymmetricSecretKey()
+	t.Setenv("ARC_PASETO_V4_SECRET_KEY_HEX", secret.ExportHex())
+	t.Setenv("ARC_AUTH_REFRESH_BINDING", "ios=required, Android=optional,web=off")
+
+	cfg, err := LoadConfigFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.RefreshBinding[PlatformIOS] != BindingRequired {
+		t.Fatalf("ios binding mismatch: %q", cfg.RefreshBinding[PlatformIOS])
+	}
+	if cfg.RefreshBinding[PlatformAndroid] != BindingOptional {
+		t.Fatalf("android binding mismatch: %q", cfg.RefreshBinding[PlatformAndroid])
+	}
+	if cfg.RefreshBinding[PlatformWeb] != BindingOff {
+		t.Fatalf("web binding mismatch: %q", cfg.RefreshBinding[PlatformWeb])
+	}
+
+	for _, v := range []string{"ios", "ios=always", "unknown=required"} {
+		t.Setenv("ARC_AUTH_REFRESH_BINDING", v)
+		if _, err := LoadConfigFromEnv(); err != ErrConfig {
+			t.Fatalf("expected ErrConfig for %q, got %v", v, err)
+		}
+	}
+}
+
+func TestLoadConfigFromEnv_AllowedAudiencesMustIncludeAudience(t *testing.T) {
+	secret := paseto.NewV4AsymmetricSecretKey()
+	t.Setenv("ARC_PASETO_V4_SECRET_KEY_HEX", secret.ExportHex())
+	t.Setenv("ARC_AUTH_ALLOWED_AUDIENCES", "billing")
+
+	_, err := LoadConfigFromEnv()
+	if err != ErrConfig {
+		t.Fatalf("expected ErrConfig when the own audience is not allowed, got %v", err)
+	}
+}
@@ -26,6 +26,17 @@ var (
 	// ErrRefreshRateLimited is returned when refresh is attempted too frequently for a session.
 	ErrRefreshRateLimited = errors.New("refresh rate limited")
 
+	// ErrUserLocked is returned when the session owner has been administratively locked.
+	ErrUserLocked = errors.New("user locked")
+
+	// ErrBindingRequired is returned when the platform requires a bound refresh token
+	// and the client presented no key (or the session predates binding).
+	ErrBindingRequired = errors.New("refresh binding required")
+
+	// ErrBindingProofInvalid is returned when a bound session is rotated without a valid
+	// signature over its current nonce.
+	ErrBindingProofInvalid = errors.New("refresh binding proof invalid")
+
 	// ErrConfig is returned for invalid configuration.
 	ErrConfig = errors.New("invalid config")
 )
@@ -44,3 +55,38 @@ func (e RefreshRateLimitError) Error() string {
 }
 
 func (e RefreshRateLimitError) Unwrap() error { return ErrRefreshRateLimited }
+
+// Reasons carried by TokenError. They are stable strings meant for logs and metrics.
+const (
+	TokenReasonSignature   = "signature"
+	TokenReasonIssuer      = "issuer"
+	TokenReasonAudience    = "audience"
+	TokenReasonExpired     = "expired"
+	TokenReasonNotYetValid = "not_yet_valid"
+	TokenReasonClaims      = "claims"
+	TokenReasonDelegated   = "delegated"
+	// TokenReasonWSTicket: a WebSocket ticket that is unknown, used or expired.
+	TokenReasonWSTicket = "ws_ticket"
+)
+
+// TokenError is returned when an access token fails verification. It matches
+// ErrInvalidToken via errors.Is; Reason tells why without exposing the token.
+type TokenError struct {
+	Reason string
+}
+
+func (e TokenError) Error() string {
+	return ErrInvalidToken.Error() + ": " + e.Reason
+}
+
+func (e TokenError) Unwrap() error { return ErrInvalidToken }
+
+// InvalidTokenReason returns the TokenError reason carried by err, or "" when err
+// is not a TokenError.
+func InvalidTokenReason(err error) string {
+	var te TokenError
+	if errors.As(err, &te) {
+		return te.Reason
+	}
+	return ""
+}
@@ -0,0 +1,63 @@
+package session
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// ExchangeInput describes an RFC 8693-style token exchange: a trusted client trades a
+// user's access token for a delegated token limited to one audience and a set of scopes.
+type ExchangeInput struct {
+	SubjectToken string
+	// Actor identifies the client performing the exchange (the token's "act" claim).
+	Actor    string
+	Audience string
+	Scopes   []string
+	// TTL bounds the delegated token's lifetime; it never outlives the subject token.
+	TTL time.Duration
+}
+
+// Exchanged is the result of ExchangeToken.
+type Exchanged struct {
+	AccessToken string
+	Claims      AccessClaims
+}
+
+// ExchangeToken validates the subject token (its session must be active) and issues a
+// delegated token for the same user and session. Delegated tokens cannot be exchanged
+// again and are rejected by ValidateAccessToken, so they only work at their audience.
+//
+// Policy (which clients may ask for which audiences and scopes) is the caller's job.
+func (s *Service) ExchangeToken(ctx context.Context, now time.Time, in ExchangeInput) (Exchanged, error) {
+	actor := strings.TrimSpace(in.Actor)
+	audience := strings.TrimSpace(in.Audience)
+	if actor == "" || audience == "" || in.TTL <= 0 {
+		return Exchanged{}, ErrConfig
+	}
+
+	subject, err := s.ValidateAccessToken(ctx, in.SubjectToken, now)
+	if err != nil {
+		return Exchanged{}, err
+	}
+
+	exp := now.Add(in.TTL)
+	if subject.ExpiresAt.Before(exp) {
+		exp = subject.ExpiresAt
+	}
+	claims := AccessClaims{
+		UserID:    subject.UserID,
+		SessionID: subject.SessionID,
+		ExpiresAt: exp,
+		IssuedAt:  now,
+		Issuer:    subject.Issuer,
+		Audience:  audience,
+		Scopes:    in.Scopes,
+		Actor:     actor,
+	}
+	tok, err := s.tokens.IssueDelegated(claims, now)
+	if err != nil {
+		return Exchanged{}, err
+	}
+	return Exchanged{AccessToken: tok, Claims: claims}, nil
+}
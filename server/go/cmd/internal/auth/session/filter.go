@@ -0,0 +1,107 @@
+package session
+
+import (
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultRevokeBatchSize bounds how many sessions a single revocation transaction touches.
+	DefaultRevokeBatchSize = 500
+
+	// maxFilterUserIDs keeps the user set parameter within a sane size for one query.
+	maxFilterUserIDs = 1000
+)
+
+// ErrEmptyFilter is returned when a bulk operation is requested without any selector.
+// An empty filter would match every active session, which is never what an operator wants.
+var ErrEmptyFilter = errors.New("empty session filter")
+
+// Filter selects active sessions for bulk administrative operations.
+//
+// All non-zero fields are combined with AND. Only active sessions
+// (not revoked, not expired) are ever matched.
+type Filter struct {
+	// Platform matches sessions issued for a single client platform.
+	Platform Platform
+
+	// IPRange matches sessions whose recorded IP falls inside the network.
+	IPRange *net.IPNet
+
+	// CreatedBefore matches sessions created strictly before this instant.
+	CreatedBefore time.Time
+
+	// UserIDs matches sessions belonging to any of the listed users.
+	UserIDs []string
+}
+
+// IsEmpty reports whether the filter has no selectors.
+func (f Filter) IsEmpty() bool {
+	return f.Platform == "" && f.IPRange == nil && f.CreatedBefore.IsZero() && len(f.UserIDs) == 0
+}
+
+// Validate checks the filter for obviously invalid or dangerous input.
+func (f Filter) Validate() error {
+	if f.IsEmpty() {
+		return ErrEmptyFilter
+	}
+	switch f.Platform {
+	case "", PlatformWeb, PlatformIOS, PlatformAndroid, PlatformDesktop, PlatformUnknown:
+	default:
+		return ErrConfig
+	}
+	if len(f.UserIDs) > maxFilterUserIDs {
+		return ErrConfig
+	}
+	for _, id := range f.UserIDs {
+		if strings.TrimSpace(id) == "" {
+			return ErrConfig
+		}
+	}
+	return nil
+}
+
+// whereClause renders the filter as a SQL predicate over <schema>.sessions.
+//
+// Placeholders start at argOffset+1 so callers can prepend their own arguments.
+// The predicate always restricts to active sessions relative to the first argument ($1 = now).
+func (f Filter) whereClause(argOffset int) (string, []any) {
+	var (
+		conds = []string{"revoked_at IS NULL", "expires_at > $1"}
+		args  []any
+	)
+	next := func(v any) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(argOffset+len(args))
+	}
+
+	if f.Platform != "" {
+		conds = append(conds, "platform = "+next(string(f.Platform)))
+	}
+	if f.IPRange != nil {
+		conds = append(conds, "ip <<= "+next(f.IPRange.String())+"::inet")
+	}
+	if !f.CreatedBefore.IsZero() {
+		conds = append(conds, "created_at < "+next(f.CreatedBefore))
+	}
+	if len(f.UserIDs) > 0 {
+		conds = append(conds, "user_id = ANY("+next(f.UserIDs)+"::text[])")
+	}
+
+	return strings.Join(conds, " AND "), args
+}
+
+// RevokeResult summarizes a bulk revocation run.
+type RevokeResult struct {
+	// Matched is the number of active sessions matching the filter at start.
+	Matched int64
+	// Revoked is the number of sessions actually revoked (zero for dry runs).
+	Revoked int64
+	// Batches is the number of revocation transactions executed.
+	Batches int
+	// DryRun reports whether the run only counted matches.
+	DryRun bool
+}
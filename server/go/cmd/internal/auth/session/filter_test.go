@@ -0,0 +1,54 @@
+package session
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFilterValidate(t *testing.T) {
+	if err := (Filter{}).Validate(); !errors.Is(err, ErrEmptyFilter) {
+		t.Fatalf("expected ErrEmptyFilter, got %v", err)
+	}
+	if err := (Filter{Platform: "blackberry"}).Validate(); !errors.Is(err, ErrConfig) {
+		t.Fatalf("expected ErrConfig for unknown platform, got %v", err)
+	}
+	if err := (Filter{UserIDs: []string{" "}}).Validate(); !errors.Is(err, ErrConfig) {
+		t.Fatalf("expected ErrConfig for blank user id, got %v", err)
+	}
+	if err := (Filter{UserIDs: make([]string, maxFilterUserIDs+1)}).Validate(); !errors.Is(err, ErrConfig) {
+		t.Fatalf("expected ErrConfig for oversized user set, got %v", err)
+	}
+	if err := (Filter{Platform: PlatformWeb}).Validate(); err != nil {
+		t.Fatalf("expected valid filter, got %v", err)
+	}
+}
+
+func TestFilterWhereClause(t *testing.T) {
+	_, network, _ := net.ParseCIDR("192.168.0.0/16")
+	f := Filter{
+		Platform:      PlatformAndroid,
+		IPRange:       network,
+		CreatedBefore: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		UserIDs:       []string{"u1"},
+	}
+
+	where, args := f.whereClause(3)
+	for _, want := range []string{
+		"revoked_at IS NULL",
+		"expires_at > $1",
+		"platform = $4",
+		"ip <<= $5::inet",
+		"created_at < $6",
+		"user_id = ANY($7::text[])",
+	} {
+		if !strings.Contains(where, want) {
+			t.Fatalf("expected %q in %q", want, where)
+		}
+	}
+	if len(args) != 4 {
+		t.Fatalf("expected 4 args, got %d", len(args))
+	}
+}
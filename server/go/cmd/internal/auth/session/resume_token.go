@@ -0,0 +1,106 @@
+package session
+
+import (
+	"context"
+	"time"
+
+	paseto "aidanwoods.dev/go-paseto"
+)
+
+// resumeImplicit is bound into resume tokens as the PASETO implicit assertion, so a
+// resume token never verifies as an access token and vice versa.
+var resumeImplicit = []byte("arc.realtime.resume")
+
+// ResumeClaims is the content of a realtime resume token: the session it belongs to
+// and the last seq delivered per conversation.
+type ResumeClaims struct {
+	UserID    string
+	SessionID string
+	Cursors   map[string]int64
+	ExpiresAt time.Time
+}
+
+// ResumeTokenManager issues and verifies realtime resume tokens.
+//
+// Resume tokens let a client reconnecting to any node replay what it missed
+// without server-side connection state; they are signed with the access token key.
+type ResumeTokenManager interface {
+	IssueResume(userID, sessionID string, cursors map[string]int64, now time.Time) (token string, exp time.Time, err error)
+	VerifyResume(token string, now time.Time) (ResumeClaims, error)
+}
+
+func (m *pasetoV4PublicManager) IssueResume(userID, sessionID string, cursors map[string]int64, now time.Time) (string, time.Time, error) {
+	exp := now.Add(m.resumeTTL)
+
+	tok := paseto.NewToken()
+	tok.SetIssuer(m.issuer)
+	tok.SetIssuedAt(now)
+	tok.SetNotBefore(now)
+	tok.SetExpiration(exp)
+
+	_ = tok.Set("uid", userID)
+	_ = tok.Set("sid", sessionID)
+	if cursors == nil {
+		cursors = map[string]int64{}
+	}
+	if err := tok.Set("cur", cursors); err != nil {
+		return "", time.Time{}, err
+	}
+
+	return tok.V4Sign(m.secret, resumeImplicit), exp, nil
+}
+
+func (m *pasetoV4PublicManager) VerifyResume(token string, now time.Time) (ResumeClaims, error) {
+	p := paseto.NewParser()
+	p.AddRule(paseto.IssuedBy(m.issuer))
+	p.AddRule(paseto.NotExpired())
+	p.AddRule(paseto.ValidAt(now.Add(m.clockSkew)))
+
+	parsed, err := p.ParseV4Public(m.public, token, resumeImplicit)
+	if err != nil {
+		return ResumeClaims{}, ErrInvalidToken
+	}
+
+	uid, err := parsed.GetString("uid")
+	if err != nil || uid == "" {
+		return ResumeClaims{}, ErrInvalidToken
+	}
+	sid, err := parsed.GetString("sid")
+	if err != nil || sid == "" {
+		return ResumeClaims{}, ErrInvalidToken
+	}
+	var cursors map[string]int64
+	if err := parsed.Get("cur", &cursors); err != nil {
+		return ResumeClaims{}, ErrInvalidToken
+	}
+	exp, _ := parsed.GetExpiration()
+
+	return ResumeClaims{UserID: uid, SessionID: sid, Cursors: cursors, ExpiresAt: exp}, nil
+}
+
+// IssueResumeToken issues a resume token carrying cursors for an existing session.
+func (s *Service) IssueResumeToken(userID, sessionID string, cursors map[string]int64, now time.Time) (token string, exp time.Time, err error) {
+	rm, ok := s.tokens.(ResumeTokenManager)
+	if !ok {
+		return "", time.Time{}, ErrConfig
+	}
+	return rm.IssueResume(userID, sessionID, cursors, now)
+}
+
+// ValidateResumeToken verifies a resume token and ensures its session was not revoked.
+// A session rotated by a refresh since the token was issued is still accepted, since
+// clients usually refresh their access token before reconnecting.
+func (s *Service) ValidateResumeToken(ctx context.Context, token string, now time.Time) (ResumeClaims, error) {
+	rm, ok := s.tokens.(ResumeTokenManager)
+	if !ok {
+		return ResumeClaims{}, ErrConfig
+	}
+	claims, err := rm.VerifyResume(token, now)
+	if err != nil {
+		return ResumeClaims{}, err
+	}
+	if err := s.checkSession(ctx, claims.UserID, claims.SessionID, now, true); err != nil {
+		return ResumeClaims{}, err
+	}
+	return claims, nil
+}
@@ -0,0 +1,158 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Endpoints recorded in reuse_incidents.endpoint.
+const (
+	reuseEndpointRefresh     = "refresh"
+	reuseEndpointAccessRenew = "access_renew"
+)
+
+const (
+	// maxReuseChainLen caps how many sessions on each side of the reused one are
+	// recorded, so a long-lived rotation chain cannot bloat an incident.
+	maxReuseChainLen = 50
+
+	// maxIncidentUserAgentLen mirrors chk_reuse_incidents_presenter_user_agent_len. A
+	// longer header is truncated rather than failing the revocation it is recorded with.
+	maxIncidentUserAgentLen = 512
+)
+
+// ReuseChainEntry is one session of the rotation chain stored with a reuse incident.
+// CreatedAt, IP and UserAgent describe the request that created it, i.e. the client
+// that presented the previous refresh token of the chain.
+type ReuseChainEntry struct {
+	SessionID        string     `json:"session_id"`
+	CreatedAt        time.Time  `json:"created_at"`
+	LastUsedAt       *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt        *time.Time `json:"revoked_at,omitempty"`
+	RevocationReason string     `json:"revocation_reason,omitempty"`
+	IP               string     `json:"ip,omitempty"`
+	UserAgent        string     `json:"user_agent,omitempty"`
+	Platform         string     `json:"platform"`
+	// Reused marks the session whose refresh token was replayed.
+	Reused bool `json:"reused,omitempty"`
+}
+
+// recordReuseIncidentTx revokes every session of the reused row's owner and records
+// the incident. The chain is read first so it shows which sessions were still active
+// when the replay arrived.
+func recordReuseIncidentTx(ctx context.Context, tx pgx.Tx, schema string, now time.Time, row Row, dev DeviceContext, endpoint string) error {
+	chain, err := reuseChainTx(ctx, tx, schema, row.ID)
+	if err != nil {
+		return err
+	}
+	revoked, err := revokeAllTx(ctx, tx, schema, now, row.UserID)
+	if err != nil {
+		return err
+	}
+	rotated := rotatedBy(chain)
+	b, err := json.Marshal(chain)
+	if err != nil {
+		return err
+	}
+
+	var rotatedAt *time.Time
+	var rotatedIP, rotatedUA any
+	if rotated != nil {
+		rotatedAt = &rotated.CreatedAt
+		rotatedIP, rotatedUA = nullIfEmpty(rotated.IP), nullIfEmpty(rotated.UserAgent)
+	}
+	var ip any
+	if dev.IP != nil {
+		ip = dev.IP.String()
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO `+pgIdent(schema, "reuse_incidents")+` (
+			user_id, detected_at, endpoint, reused_session_id,
+			presenter_ip, presenter_user_agent, presenter_platform,
+			rotated_at, rotated_ip, rotated_user_agent,
+			session_chain, sessions_revoked
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11::jsonb, $12)
+	`, row.UserID, now, endpoint, row.ID,
+		ip, nullIfEmpty(truncateUserAgent(dev.UserAgent)), string(normalizedPlatform(dev.Platform)),
+		rotatedAt, rotatedIP, rotatedUA,
+		string(b), revoked)
+	return err
+}
+
+// reuseChainTx returns the sessions linked to sessionID by replaced_by_session_id,
+// oldest first, up to maxReuseChainLen on each side.
+func reuseChainTx(ctx context.Context, tx pgx.Tx, schema string, sessionID string) ([]ReuseChainEntry, error) {
+	sessions := pgIdent(schema, "sessions")
+	rows, err := tx.Query(ctx, `
+		WITH RECURSIVE back AS (
+			SELECT id, 0 AS depth FROM `+sessions+` WHERE id = $1
+			UNION ALL
+			SELECT s.id, b.depth - 1
+			  FROM `+sessions+` s
+			  JOIN back b ON s.replaced_by_session_id = b.id
+			 WHERE b.depth > -$2
+		), fwd AS (
+			SELECT id, replaced_by_session_id, 0 AS depth FROM `+sessions+` WHERE id = $1
+			UNION ALL
+			SELECT s.id, s.replaced_by_session_id, f.depth + 1
+			  FROM `+sessions+` s
+			  JOIN fwd f ON s.id = f.replaced_by_session_id
+			 WHERE f.depth < $2
+		), chain AS (
+			SELECT id, depth FROM back
+			UNION
+			SELECT id, depth FROM fwd
+		)
+		SELECT s.id, s.created_at, s.last_used_at, s.revoked_at, COALESCE(s.revocation_reason, ''),
+		       COALESCE(host(s.ip), ''), COALESCE(s.user_agent, ''), s.platform, c.depth = 0
+		  FROM chain c
+		  JOIN `+sessions+` s ON s.id = c.id
+		 ORDER BY c.depth
+	`, sessionID, maxReuseChainLen)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []ReuseChainEntry
+	for rows.Next() {
+		var e ReuseChainEntry
+		if err := rows.Scan(&e.SessionID, &e.CreatedAt, &e.LastUsedAt, &e.RevokedAt, &e.RevocationReason,
+			&e.IP, &e.UserAgent, &e.Platform, &e.Reused); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// rotatedBy returns the session that replaced the reused one: it was created by the
+// first presenter of the reused refresh token.
+func rotatedBy(chain []ReuseChainEntry) *ReuseChainEntry {
+	for i, e := range chain {
+		if e.Reused && i+1 < len(chain) {
+			return &chain[i+1]
+		}
+	}
+	return nil
+}
+
+func truncateUserAgent(ua string) string {
+	if utf8.RuneCountInString(ua) <= maxIncidentUserAgentLen {
+		return ua
+	}
+	return string([]rune(ua)[:maxIncidentUserAgentLen])
+}
+
+// normalizedPlatform maps an unset platform to PlatformUnknown.
+func normalizedPlatform(p Platform) Platform {
+	if p == "" {
+		return PlatformUnknown
+	}
+	return p
+}
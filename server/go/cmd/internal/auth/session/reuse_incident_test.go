@@ -0,0 +1,31 @@
+package session
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRotatedBy(t *testing.T) {
+	chain := []ReuseChainEntry{
+		{SessionID: "login"},
+		{SessionID: "reused", Reused: true},
+		{SessionID: "successor", IP: "198.51.100.7"},
+		{SessionID: "head"},
+	}
+	if got := rotatedBy(chain); got == nil || got.SessionID != "successor" {
+		t.Fatalf("expected the successor of the reused session, got %+v", got)
+	}
+	if got := rotatedBy(chain[:2]); got != nil {
+		t.Fatalf("expected no successor at the end of the chain, got %+v", got)
+	}
+}
+
+func TestTruncateUserAgent(t *testing.T) {
+	long := strings.Repeat("é", maxIncidentUserAgentLen+10)
+	if got := truncateUserAgent(long); len([]rune(got)) != maxIncidentUserAgentLen {
+		t.Fatalf("expected %d runes, got %d", maxIncidentUserAgentLen, len([]rune(got)))
+	}
+	if got := truncateUserAgent("arc-test/1.0"); got != "arc-test/1.0" {
+		t.Fatalf("short user agent changed: %q", got)
+	}
+}
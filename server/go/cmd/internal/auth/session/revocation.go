@@ -0,0 +1,85 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"arc/cmd/internal/dbtrace"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// revokedChannelSuffix names the NOTIFY channel of trg_sessions_notify_revoked:
+	// "<schema>.session_revoked".
+	revokedChannelSuffix = ".session_revoked"
+
+	// maxRevocationChainLen caps how many rotations SessionEnded follows, so a
+	// corrupted chain cannot make the lookup unbounded.
+	maxRevocationChainLen = 1000
+)
+
+// RevokedChannel is the Postgres NOTIFY channel on which revocations of the
+// service's sessions are announced (migration 0011). Refresh rotation is not
+// announced.
+func (s *Service) RevokedChannel() string {
+	return s.schema + revokedChannelSuffix
+}
+
+// ParseRevokedNotification splits a RevokedChannel payload into the user and
+// session IDs.
+func ParseRevokedNotification(payload string) (userID, sessionID string, ok bool) {
+	userID, sessionID, ok = strings.Cut(strings.TrimSpace(payload), " ")
+	if !ok || userID == "" || sessionID == "" || strings.ContainsRune(sessionID, ' ') {
+		return "", "", false
+	}
+	return userID, sessionID, true
+}
+
+// SessionEnded reports whether sessionID no longer authenticates its user: the
+// newest session of its rotation chain was revoked, expired or deleted.
+//
+// Connections opened with a session outlive its refresh rotations, so a
+// revocation of the replacement also ends them.
+func (s *Service) SessionEnded(ctx context.Context, now time.Time, sessionID string) (bool, error) {
+	ctx = dbtrace.WithOp(ctx, "session.session_ended")
+	if s.pool == nil {
+		return false, errors.New("session: nil pool")
+	}
+
+	sessions := pgIdent(s.schema, "sessions")
+	var (
+		revokedAt  *time.Time
+		expiresAt  time.Time
+		replacedBy *string
+	)
+	err := s.pool.QueryRow(ctx, `
+		WITH RECURSIVE chain AS (
+			SELECT id, replaced_by_session_id, 0 AS depth FROM `+sessions+` WHERE id = $1
+			UNION ALL
+			SELECT s.id, s.replaced_by_session_id, c.depth + 1
+			  FROM `+sessions+` s
+			  JOIN chain c ON s.id = c.replaced_by_session_id
+			 WHERE c.depth < $2
+		)
+		SELECT s.revoked_at, s.expires_at, s.replaced_by_session_id
+		  FROM chain c
+		  JOIN `+sessions+` s ON s.id = c.id
+		 ORDER BY c.depth DESC
+		 LIMIT 1
+	`, sessionID, maxRevocationChainLen).Scan(&revokedAt, &expiresAt, &replacedBy)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	// A head that is still replaced means the cap was hit; keep the session.
+	if replacedBy != nil {
+		return false, nil
+	}
+	return revokedAt != nil || !expiresAt.After(now), nil
+}
@@ -0,0 +1,21 @@
+package session
+
+import "testing"
+
+func TestParseRevokedNotification(t *testing.T) {
+	userID, sessionID, ok := ParseRevokedNotification("01USER 01SESSION\n")
+	if !ok || userID != "01USER" || sessionID != "01SESSION" {
+		t.Fatalf("unexpected result %q %q %v", userID, sessionID, ok)
+	}
+	for _, p := range []string{"", "01USER", "01USER ", " 01SESSION", "a b c"} {
+		if _, _, ok := ParseRevokedNotification(p); ok {
+			t.Fatalf("expected %q to be rejected", p)
+		}
+	}
+}
+
+func TestService_RevokedChannel(t *testing.T) {
+	if got := NewService(Config{}, nil, nil, nil).RevokedChannel(); got != "arc.session_revoked" {
+		t.Fatalf("unexpected channel %q", got)
+	}
+}
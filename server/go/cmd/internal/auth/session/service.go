@@ -2,9 +2,16 @@ package session
 
 import (
 	"context"
+	"crypto/ed25519"
+	"slices"
 	"strings"
 	"time"
 
+	"arc/cmd/internal/dbroute"
+	"arc/cmd/internal/dbtrace"
+	"arc/cmd/internal/pgutil"
+
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -20,6 +27,8 @@ type Service struct {
 
 	// pool is used to create explicit transactions for rotation safety.
 	pool *pgxpool.Pool
+	// schema holds the sessions the rotation transactions touch (the store's schema).
+	schema string
 }
 
 // Issued is the result of issuing or rotating a session.
@@ -30,13 +39,33 @@ type Issued struct {
 	AccessExp    time.Time
 	RefreshToken string
 	RefreshExp   time.Time
+	// BindingNonce is the nonce the client must sign on the next rotation (empty when unbound).
+	BindingNonce string
+}
+
+// Renewed is the result of renewing an access token without refresh rotation.
+type Renewed struct {
+	SessionID   string
+	AccessToken string
+	AccessExp   time.Time
+	// BindingNonce replaces the nonce of a bound session (empty when unbound).
+	BindingNonce string
 }
 
 // NewService constructs a Service with the provided configuration, store, and token manager.
 //
 // The pool is required for refresh rotation, which must run inside a single transaction.
 func NewService(cfg Config, pool *pgxpool.Pool, store Store, tokens AccessTokenManager) *Service {
-	return &Service{cfg: cfg, pool: pool, store: store, tokens: tokens}
+	schema := defaultSchema
+	if ps, ok := store.(*PostgresStore); ok && ps != nil {
+		schema = ps.schema
+	}
+	return &Service{cfg: cfg, pool: pool, store: store, tokens: tokens, schema: schema}
+}
+
+// Schema is the DB schema of the service's sessions.
+func (s *Service) Schema() string {
+	return s.schema
 }
 
 func (s *Service) refreshTTL(dev DeviceContext) time.Duration {
@@ -58,7 +87,20 @@ func (s *Service) refreshTTL(dev DeviceContext) time.Duration {
 //
 // Refresh tokens are opaque random strings and must never be persisted in plaintext.
 // Only the SHA-256 hash (hex) is stored in the database.
+//
+// When the platform's binding mode is not BindingOff and dev carries a client key,
+// the session is bound to it and Issued.BindingNonce holds the first nonce to sign.
 func (s *Service) IssueSession(ctx context.Context, now time.Time, userID string, dev DeviceContext) (Issued, error) {
+	ctx = dbtrace.WithOp(ctx, "session.issue_session")
+	switch s.BindingMode(dev.Platform) {
+	case BindingOff:
+		dev.BindingKey = nil
+	case BindingRequired:
+		if len(dev.BindingKey) == 0 {
+			return Issued{}, ErrBindingRequired
+		}
+	}
+
 	refreshPlain, refreshHash, err := newOpaqueRefreshToken(s.cfg.RefreshTokenBytes)
 	if err != nil {
 		return Issued{}, err
@@ -66,7 +108,20 @@ func (s *Service) IssueSession(ctx context.Context, now time.Time, userID string
 
 	refreshExp := now.Add(s.refreshTTL(dev))
 
-	sessionID, err := s.store.Create(ctx, now, userID, dev, refreshHash, refreshExp, nil)
+	var bindingNonce string
+	if len(dev.BindingKey) > 0 {
+		if bindingNonce, err = newBindingNonce(); err != nil {
+			return Issued{}, err
+		}
+	}
+
+	// A bound session is stored with its first nonce, so it never exists without one.
+	var sessionID string
+	err = pgutil.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		var err error
+		sessionID, err = createTx(ctx, tx, s.schema, now, userID, dev, refreshHash, refreshExp, bindingNonce)
+		return err
+	})
 	if err != nil {
 		return Issued{}, err
 	}
@@ -82,6 +137,7 @@ func (s *Service) IssueSession(ctx context.Context, now time.Time, userID string
 		AccessExp:    accessExp,
 		RefreshToken: refreshPlain,
 		RefreshExp:   refreshExp,
+		BindingNonce: bindingNonce,
 	}, nil
 }
 
@@ -96,24 +152,38 @@ func (s *Service) ValidateAccessToken(ctx context.Context, token string, now tim
 	if err != nil {
 		return AccessClaims{}, err
 	}
+	// Delegated tokens are meant for their audience, not for Arc's own endpoints.
+	if claims.Delegated() {
+		return AccessClaims{}, TokenError{Reason: TokenReasonDelegated}
+	}
+	if err := s.checkSession(ctx, claims.UserID, claims.SessionID, now, false); err != nil {
+		return AccessClaims{}, err
+	}
+	return claims, nil
+}
 
-	// Server-authoritative session check to honor revocations.
-	row, err := s.store.GetByID(ctx, claims.SessionID)
+// checkSession is the server-authoritative session check that honors revocations.
+// allowRotated accepts sessions replaced by a refresh rotation.
+func (s *Service) checkSession(ctx context.Context, userID, sessionID string, now time.Time, allowRotated bool) error {
+	row, err := s.store.GetByID(ctx, sessionID)
 	if err != nil {
-		return AccessClaims{}, err
+		return err
 	}
 
-	if row.UserID != claims.UserID {
-		return AccessClaims{}, ErrInvalidToken
+	if row.UserID != userID {
+		return ErrInvalidToken
 	}
-	if row.RevokedAt != nil || row.ReplacedBySessionID != nil {
-		return AccessClaims{}, ErrSessionRevoked
+	rotated := row.ReplacedBySessionID != nil
+	if (row.RevokedAt != nil || rotated) && !(allowRotated && rotated) {
+		return ErrSessionRevoked
 	}
 	if !row.ExpiresAt.After(now) {
-		return AccessClaims{}, ErrSessionExpired
+		return ErrSessionExpired
 	}
-
-	return claims, nil
+	if row.UserLockedAt != nil {
+		return ErrUserLocked
+	}
+	return nil
 }
 
 // RevokeSession revokes a single session by ID (e.g., logout from a device).
@@ -121,27 +191,155 @@ func (s *Service) RevokeSession(ctx context.Context, now time.Time, sessionID st
 	return s.store.Revoke(ctx, now, sessionID, "logout")
 }
 
+// RevokeByRefreshToken revokes the active session owning refreshTokenPlain and returns its row.
+//
+// It lets clients whose access token has expired still log out. The session row is
+// locked like in RotateRefresh, so a concurrent rotation either completes first (and
+// the presented token is no longer active) or observes the revocation.
+// Bound sessions do not require a binding proof: a stolen token can only end the session.
+func (s *Service) RevokeByRefreshToken(ctx context.Context, now time.Time, refreshTokenPlain string) (Row, error) {
+	ctx = dbtrace.WithOp(ctx, "session.revoke_by_refresh_token")
+	refreshTokenPlain = strings.TrimSpace(refreshTokenPlain)
+	if refreshTokenPlain == "" || len(refreshTokenPlain) > 4096 {
+		return Row{}, ErrSessionNotFound
+	}
+
+	var row Row
+	err := pgutil.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		var err error
+		row, err = getByRefreshHashForUpdateTx(ctx, tx, s.schema, hashRefreshTokenHex(refreshTokenPlain))
+		if err != nil {
+			return err
+		}
+		if row.RevokedAt != nil {
+			return ErrSessionRevoked
+		}
+		if !row.ExpiresAt.After(now) {
+			return ErrSessionExpired
+		}
+		return revokeTx(ctx, tx, s.schema, now, row.ID, "logout")
+	})
+	if err != nil {
+		return Row{}, err
+	}
+	return row, nil
+}
+
+// RevokeAllSessions revokes all sessions for a user with an explicit revocation reason
+// (e.g., "admin" for abuse response).
+func (s *Service) RevokeAllSessions(ctx context.Context, now time.Time, userID string, reason string) error {
+	return s.store.RevokeAll(ctx, now, userID, reason)
+}
+
 // RevokeAll revokes all sessions for a user (e.g., logout everywhere).
 func (s *Service) RevokeAll(ctx context.Context, now time.Time, userID string) error {
 	return s.store.RevokeAll(ctx, now, userID, "logout")
 }
 
+// ListActiveSessions lists the active sessions of a user, newest first.
+func (s *Service) ListActiveSessions(ctx context.Context, now time.Time, userID string) ([]Info, error) {
+	return s.store.ListActiveByUser(ctx, now, userID)
+}
+
+// RevokeByFilter revokes all active sessions matching f (e.g., incident response for a leaked client build).
+//
+// Revocation runs in bounded batches so a large match set never holds row locks for long.
+// When dryRun is true, only the number of matching sessions is reported.
+func (s *Service) RevokeByFilter(ctx context.Context, now time.Time, f Filter, dryRun bool) (RevokeResult, error) {
+	if err := f.Validate(); err != nil {
+		return RevokeResult{}, err
+	}
+
+	// A lagging replica could under-count sessions created moments ago; only a dry
+	// run may read one.
+	if !dryRun {
+		ctx = dbroute.RequirePrimary(ctx)
+	}
+	matched, err := s.store.CountByFilter(ctx, now, f)
+	if err != nil {
+		return RevokeResult{}, err
+	}
+	res := RevokeResult{Matched: matched, DryRun: dryRun}
+	if dryRun || matched == 0 {
+		return res, nil
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		n, err := s.store.RevokeBatchByFilter(ctx, now, f, "admin", DefaultRevokeBatchSize)
+		if err != nil {
+			return res, err
+		}
+		if n == 0 {
+			return res, nil
+		}
+		res.Revoked += n
+		res.Batches++
+	}
+}
+
+// ReissueBindingNonce issues a fresh nonce for the bound session owning refreshTokenPlain,
+// for clients that lost the nonce returned with their last rotation.
+//
+// Possession of the refresh token alone is not enough to rotate a bound session,
+// so handing out nonces to its holder does not weaken the binding.
+func (s *Service) ReissueBindingNonce(ctx context.Context, now time.Time, refreshTokenPlain string) (string, error) {
+	refreshTokenPlain = strings.TrimSpace(refreshTokenPlain)
+	if refreshTokenPlain == "" || len(refreshTokenPlain) > 4096 {
+		return "", ErrSessionNotFound
+	}
+
+	nonce, err := newBindingNonce()
+	if err != nil {
+		return "", err
+	}
+	if err := s.store.SetBindingNonce(ctx, now, hashRefreshTokenHex(refreshTokenPlain), nonce); err != nil {
+		return "", err
+	}
+	return nonce, nil
+}
+
 // TouchSession updates last_used_at for a session (best-effort).
 func (s *Service) TouchSession(ctx context.Context, now time.Time, sessionID string) error {
 	return s.store.Touch(ctx, now, sessionID)
 }
 
+// DefaultTouchBatchSize bounds how many sessions a single TouchSessions statement updates.
+const DefaultTouchBatchSize = 1000
+
+// TouchSessions updates last_used_at for many sessions (best-effort), in statements
+// of at most DefaultTouchBatchSize sessions. It reports how many were updated.
+func (s *Service) TouchSessions(ctx context.Context, now time.Time, sessionIDs []string) (int64, error) {
+	var touched int64
+	for batch := range slices.Chunk(sessionIDs, DefaultTouchBatchSize) {
+		n, err := s.store.TouchBatch(ctx, now, batch)
+		touched += n
+		if err != nil {
+			return touched, err
+		}
+	}
+	return touched, nil
+}
+
 // RotateRefresh performs refresh rotation with reuse detection.
 //
 // Security model:
 //   - Lock the session row by refresh hash (SELECT ... FOR UPDATE).
 //   - If the token belongs to a rotated session (revoked + replaced_by), treat it as reuse:
-//     revoke all sessions for the user and return ErrRefreshReuseDetected.
+//     revoke all sessions for the user, record a reuse incident and return
+//     ErrRefreshReuseDetected.
 //   - If the token belongs to a revoked session without replacement, return ErrSessionRevoked.
+//   - If the session is bound to a client key, require dev.BindingProof to be a valid
+//     signature over the current nonce; the new session inherits the key with a fresh nonce.
 //   - Otherwise, create a new session, revoke the old session, and link replaced_by_session_id.
 //
-// This method must be executed within a single database transaction to be safe.
+// All of this runs in a single database transaction, retried on serialization
+// failures and deadlocks (pgutil.WithTx). A detected reuse is committed before
+// ErrRefreshReuseDetected is returned, so a retry never records it twice.
 func (s *Service) RotateRefresh(ctx context.Context, now time.Time, refreshTokenPlain string, dev DeviceContext) (Issued, error) {
+	ctx = dbtrace.WithOp(ctx, "session.rotate_refresh")
 	refreshTokenPlain = strings.TrimSpace(refreshTokenPlain)
 	// Basic sanity bounds to avoid pathological inputs.
 	if refreshTokenPlain == "" || len(refreshTokenPlain) > 4096 {
@@ -151,84 +349,198 @@ func (s *Service) RotateRefresh(ctx context.Context, now time.Time, refreshToken
 	// Hash refresh token in-memory (never persist the plain token).
 	refreshHash := hashRefreshTokenHex(refreshTokenPlain)
 
-	tx, err := s.pool.Begin(ctx)
-	if err != nil {
-		return Issued{}, err
-	}
-	defer func() { _ = tx.Rollback(ctx) }()
+	var (
+		out    Issued
+		reused bool
+	)
+	err := pgutil.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		// The transaction may run again after a serialization failure or deadlock.
+		reused = false
+
+		// Lock the session row by refresh hash to make rotation safe.
+		row, err := getByRefreshHashForUpdateTx(ctx, tx, s.schema, refreshHash)
+		if err != nil {
+			return err
+		}
 
-	// Lock the session row by refresh hash to make rotation safe.
-	row, err := getByRefreshHashForUpdateTx(ctx, tx, refreshHash)
-	if err != nil {
-		return Issued{}, err
-	}
+		// Expiry check.
+		if !row.ExpiresAt.After(now) {
+			return ErrSessionExpired
+		}
 
-	// Expiry check.
-	if !row.ExpiresAt.After(now) {
-		return Issued{}, ErrSessionExpired
-	}
+		// Reuse detection: a rotated refresh token presented again.
+		if row.RevokedAt != nil && row.ReplacedBySessionID != nil {
+			// Revoke all sessions for the user. This is a security incident, so the
+			// revocation and the incident are committed before the error is returned.
+			reused = true
+			return recordReuseIncidentTx(ctx, tx, s.schema, now, row, dev, reuseEndpointRefresh)
+		}
 
-	// Reuse detection: a rotated refresh token presented again.
-	if row.RevokedAt != nil && row.ReplacedBySessionID != nil {
-		// Revoke all sessions for the user. This is a security incident.
-		if err := revokeAllTx(ctx, tx, now, row.UserID); err != nil {
-			return Issued{}, err
+		// If revoked without replacement: treat as revoked (logout).
+		if row.RevokedAt != nil {
+			return ErrSessionRevoked
 		}
-		if err := tx.Commit(ctx); err != nil {
-			return Issued{}, err
+
+		// Locked users cannot extend their sessions even if a revocation was missed.
+		if row.UserLockedAt != nil {
+			return ErrUserLocked
 		}
-		return Issued{}, ErrRefreshReuseDetected
-	}
 
-	// If revoked without replacement: treat as revoked (logout).
-	if row.RevokedAt != nil {
-		return Issued{}, ErrSessionRevoked
-	}
+		// Proof of possession for sessions bound to a client key.
+		if err := s.checkBinding(row, dev.BindingProof); err != nil {
+			return err
+		}
+
+		// Per-session refresh throttling to reduce refresh storms and abuse.
+		if s.cfg.RefreshMinInterval > 0 {
+			lastUsed := row.CreatedAt
+			if row.LastUsedAt != nil {
+				lastUsed = *row.LastUsedAt
+			}
+			if retryAfter := lastUsed.Add(s.cfg.RefreshMinInterval).Sub(now); retryAfter > 0 {
+				return RefreshRateLimitError{
+					SessionID:  row.ID,
+					RetryAfter: retryAfter,
+				}
+			}
+		}
 
-	// Per-session refresh throttling to reduce refresh storms and abuse.
-	if s.cfg.RefreshMinInterval > 0 {
-		lastUsed := row.CreatedAt
-		if row.LastUsedAt != nil {
-			lastUsed = *row.LastUsedAt
+		// Rotate: create new session + revoke old + point replaced_by.
+		newRefreshPlain, newRefreshHash, err := newOpaqueRefreshToken(s.cfg.RefreshTokenBytes)
+		if err != nil {
+			return err
 		}
-		if retryAfter := lastUsed.Add(s.cfg.RefreshMinInterval).Sub(now); retryAfter > 0 {
-			return Issued{}, RefreshRateLimitError{
-				SessionID:  row.ID,
-				RetryAfter: retryAfter,
+		newRefreshExp := now.Add(s.refreshTTL(dev))
+
+		// The binding follows the session, never the request.
+		newDev := dev
+		newDev.BindingKey = nil
+		var bindingNonce string
+		if len(row.BindingKey) > 0 {
+			newDev.BindingKey = ed25519.PublicKey(row.BindingKey)
+			if bindingNonce, err = newBindingNonce(); err != nil {
+				return err
 			}
 		}
-	}
 
-	// Rotate: create new session + revoke old + point replaced_by.
-	newRefreshPlain, newRefreshHash, err := newOpaqueRefreshToken(s.cfg.RefreshTokenBytes)
-	if err != nil {
-		return Issued{}, err
-	}
-	newRefreshExp := now.Add(s.refreshTTL(dev))
+		newSessionID, err := createTx(ctx, tx, s.schema, now, row.UserID, newDev, newRefreshHash, newRefreshExp, bindingNonce)
+		if err != nil {
+			return err
+		}
+
+		if err := markRotatedTx(ctx, tx, s.schema, now, row.ID, newSessionID); err != nil {
+			return err
+		}
+
+		accessToken, accessExp, err := s.tokens.Issue(row.UserID, newSessionID, now)
+		if err != nil {
+			return err
+		}
 
-	newSessionID, err := createTx(ctx, tx, now, row.UserID, dev, newRefreshHash, newRefreshExp)
+		out = Issued{
+			SessionID:    newSessionID,
+			AccessToken:  accessToken,
+			AccessExp:    accessExp,
+			RefreshToken: newRefreshPlain,
+			RefreshExp:   newRefreshExp,
+			BindingNonce: bindingNonce,
+		}
+		return nil
+	})
 	if err != nil {
 		return Issued{}, err
 	}
+	if reused {
+		return Issued{}, ErrRefreshReuseDetected
+	}
+	return out, nil
+}
 
-	if err := markRotatedTx(ctx, tx, now, row.ID, newSessionID); err != nil {
-		return Issued{}, err
+// RenewAccessToken issues a new access token for the session owning refreshTokenPlain
+// without rotating the refresh token.
+//
+// It serves clients that only need a fresh access token (e.g. long-lived WebSocket
+// connections) and would otherwise churn through rotations. The same checks as
+// RotateRefresh apply, including reuse detection and binding proofs (the nonce is
+// replaced on success so each proof is single-use). Renewals are capped per session
+// by Config.AccessRenewMinInterval, counted from the last renewal or session creation.
+func (s *Service) RenewAccessToken(ctx context.Context, now time.Time, refreshTokenPlain string, dev DeviceContext) (Renewed, error) {
+	ctx = dbtrace.WithOp(ctx, "session.renew_access_token")
+	refreshTokenPlain = strings.TrimSpace(refreshTokenPlain)
+	if refreshTokenPlain == "" || len(refreshTokenPlain) > 4096 {
+		return Renewed{}, ErrSessionNotFound
 	}
 
-	accessToken, accessExp, err := s.tokens.Issue(row.UserID, newSessionID, now)
+	var (
+		out    Renewed
+		reused bool
+	)
+	err := pgutil.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		reused = false
+
+		row, err := getByRefreshHashForUpdateTx(ctx, tx, s.schema, hashRefreshTokenHex(refreshTokenPlain))
+		if err != nil {
+			return err
+		}
+
+		if !row.ExpiresAt.After(now) {
+			return ErrSessionExpired
+		}
+		if row.RevokedAt != nil && row.ReplacedBySessionID != nil {
+			reused = true
+			return recordReuseIncidentTx(ctx, tx, s.schema, now, row, dev, reuseEndpointAccessRenew)
+		}
+		if row.RevokedAt != nil {
+			return ErrSessionRevoked
+		}
+		if row.UserLockedAt != nil {
+			return ErrUserLocked
+		}
+		if err := s.checkBinding(row, dev.BindingProof); err != nil {
+			return err
+		}
+
+		if s.cfg.AccessRenewMinInterval > 0 {
+			last := row.CreatedAt
+			if row.AccessRenewedAt != nil {
+				last = *row.AccessRenewedAt
+			}
+			if retryAfter := last.Add(s.cfg.AccessRenewMinInterval).Sub(now); retryAfter > 0 {
+				return RefreshRateLimitError{
+					SessionID:  row.ID,
+					RetryAfter: retryAfter,
+				}
+			}
+		}
+
+		var bindingNonce string
+		if len(row.BindingKey) > 0 {
+			if bindingNonce, err = newBindingNonce(); err != nil {
+				return err
+			}
+		}
+		if err := markAccessRenewedTx(ctx, tx, s.schema, now, row.ID, bindingNonce); err != nil {
+			return err
+		}
+
+		accessToken, accessExp, err := s.tokens.Issue(row.UserID, row.ID, now)
+		if err != nil {
+			return err
+		}
+
+		out = Renewed{
+			SessionID:    row.ID,
+			AccessToken:  accessToken,
+			AccessExp:    accessExp,
+			BindingNonce: bindingNonce,
+		}
+		return nil
+	})
 	if err != nil {
-		return Issued{}, err
+		return Renewed{}, err
 	}
-
-	if err := tx.Commit(ctx); err != nil {
-		return Issued{}, err
+	if reused {
+		return Renewed{}, ErrRefreshReuseDetected
 	}
-
-	return Issued{
-		SessionID:    newSessionID,
-		AccessToken:  accessToken,
-		AccessExp:    accessExp,
-		RefreshToken: newRefreshPlain,
-		RefreshExp:   newRefreshExp,
-	}, nil
+	return out, nil
 }
@@ -1,6 +1,7 @@
 package session
 
 import (
+	"errors"
 	"testing"
 	"time"
 
@@ -33,4 +34,130 @@ func TestPasetoV4_IssueAndVerify(t *testing.T) {
 	if claims.UserID == "" || claims.SessionID == "" {
 		t.Fatalf("missing claims")
 	}
+	if claims.Issuer != "arc" || claims.Audience != "arc" {
+		t.Fatalf("unexpected iss/aud %q/%q", claims.Issuer, claims.Audience)
+	}
+}
+
+func TestPasetoV4_VerifyRejectsForeignIssuerAndAudience(t *testing.T) {
+	secret := paseto.NewV4AsymmetricSecretKey()
+	base := DefaultConfig()
+	base.PasetoV4SecretKeyHex = secret.ExportHex()
+
+	arc, err := NewPasetoV4PublicManager(base)
+	if err != nil {
+		t.Fatalf("NewPasetoV4PublicManager: %v", err)
+	}
+
+	// Another service sharing the keypair.
+	otherAud := base
+	otherAud.Audience = "billing"
+	otherIss := base
+	otherIss.Issuer = "billing"
+
+	now := time.Now().UTC()
+	cases := []struct {
+		name   string
+		cfg    Config
+		reason string
+	}{
+		{"audience", otherAud, TokenReasonAudience},
+		{"issuer", otherIss, TokenReasonIssuer},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mgr, err := NewPasetoV4PublicManager(tc.cfg)
+			if err != nil {
+				t.Fatalf("NewPasetoV4PublicManager: %v", err)
+			}
+			tok, _, err := mgr.Issue("u", "s", now)
+			if err != nil {
+				t.Fatalf("Issue: %v", err)
+			}
+			_, err = arc.Verify(tok, now)
+			if !errors.Is(err, ErrInvalidToken) {
+				t.Fatalf("expected ErrInvalidToken, got %v", err)
+			}
+			if got := InvalidTokenReason(err); got != tc.reason {
+				t.Fatalf("expected reason %q, got %q", tc.reason, got)
+			}
+		})
+	}
+
+	// Listing the other audience accepts its tokens, e.g. while renaming the audience.
+	multi := base
+	multi.AllowedAudiences = []string{"arc", "billing"}
+	arcMulti, err := NewPasetoV4PublicManager(multi)
+	if err != nil {
+		t.Fatalf("NewPasetoV4PublicManager: %v", err)
+	}
+	other, _ := NewPasetoV4PublicManager(otherAud)
+	tok, _, _ := other.Issue("u", "s", now)
+	if _, err := arcMulti.Verify(tok, now); err != nil {
+		t.Fatalf("expected token for listed audience to verify, got %v", err)
+	}
+}
+
+func TestPasetoV4_VerifyReasons(t *testing.T) {
+	secret := paseto.NewV4AsymmetricSecretKey()
+	cfg := DefaultConfig()
+	cfg.PasetoV4SecretKeyHex = secret.ExportHex()
+
+	mgr, err := NewPasetoV4PublicManager(cfg)
+	if err != nil {
+		t.Fatalf("NewPasetoV4PublicManager: %v", err)
+	}
+	now := time.Now().UTC()
+	tok, _, _ := mgr.Issue("u", "s", now)
+
+	if _, err := mgr.Verify(tok, now.Add(cfg.AccessTokenTTL)); InvalidTokenReason(err) != TokenReasonExpired {
+		t.Fatalf("expected expired, got %v", err)
+	}
+	if _, err := mgr.Verify(tok, now.Add(-time.Hour)); InvalidTokenReason(err) != TokenReasonNotYetValid {
+		t.Fatalf("expected not_yet_valid, got %v", err)
+	}
+	if _, err := mgr.Verify(tok+"x", now); InvalidTokenReason(err) != TokenReasonSignature {
+		t.Fatalf("expected signature, got %v", err)
+	}
+}
+
+func TestPasetoV4_IssueDelegatedAndVerify(t *testing.T) {
+	secret := paseto.NewV4AsymmetricSecretKey()
+	cfg := DefaultConfig()
+	cfg.PasetoV4SecretKeyHex = secret.ExportHex()
+	// Verify as the downstream service would, accepting its own audience.
+	cfg.AllowedAudiences = []string{"billing"}
+
+	mgr, err := NewPasetoV4PublicManager(cfg)
+	if err != nil {
+		t.Fatalf("NewPasetoV4PublicManager: %v", err)
+	}
+
+	now := time.Now().UTC()
+	tok, err := mgr.IssueDelegated(AccessClaims{
+		UserID:    "01HZZZZZZZZZZZZZZZZZZZZZZZ",
+		SessionID: "01HYYYYYYYYYYYYYYYYYYYYYYYY",
+		ExpiresAt: now.Add(time.Minute),
+		Audience:  "billing",
+		Scopes:    []string{"messages:read", "profile"},
+		Actor:     "gateway",
+	}, now)
+	if err != nil {
+		t.Fatalf("IssueDelegated: %v", err)
+	}
+
+	claims, err := mgr.Verify(tok, now.Add(time.Second))
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if !claims.Delegated() || claims.Actor != "gateway" || claims.Audience != "billing" {
+		t.Fatalf("unexpected delegated claims %+v", claims)
+	}
+	if len(claims.Scopes) != 2 || claims.Scopes[0] != "messages:read" || claims.Scopes[1] != "profile" {
+		t.Fatalf("unexpected scopes %v", claims.Scopes)
+	}
+
+	if _, err := mgr.IssueDelegated(AccessClaims{UserID: "u", SessionID: "s", ExpiresAt: now.Add(time.Minute)}, now); err == nil {
+		t.Fatalf("expected delegated token without actor and audience to be rejected")
+	}
 }
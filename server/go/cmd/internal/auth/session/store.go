@@ -2,8 +2,11 @@ package session
 
 import (
 	"context"
+	"crypto/ed25519"
 	"net"
 	"time"
+
+	"arc/cmd/internal/geoip"
 )
 
 // Platform represents the client platform associated with a session.
@@ -28,9 +31,27 @@ type DeviceContext struct {
 	RememberMe bool
 	UserAgent  string
 	IP         net.IP
+	// Geo is the resolved location of IP (zero when unknown).
+	Geo geoip.Location
+	// BindingKey is the client public key a new session is bound to (nil when unbound).
+	BindingKey ed25519.PublicKey
+	// BindingProof is the client's signature over the session nonce, presented on rotation.
+	BindingProof []byte
+}
+
+// Info is the user-facing view of an active session.
+type Info struct {
+	ID         string
+	Platform   Platform
+	CreatedAt  time.Time
+	LastUsedAt *time.Time
+	ExpiresAt  time.Time
+	UserAgent  string
+	IP         net.IP
+	Geo        geoip.Location
 }
 
-// Row mirrors the arc.sessions row used by the session subsystem.
+// Row mirrors the <schema>.sessions row used by the session subsystem.
 type Row struct {
 	ID                  string
 	UserID              string
@@ -41,6 +62,13 @@ type Row struct {
 	RevokedAt           *time.Time
 	ReplacedBySessionID *string
 	Platform            Platform
+	// UserLockedAt is set when the owning user is administratively locked.
+	UserLockedAt *time.Time
+	// BindingKey and BindingNonce are set for sessions bound to a client key.
+	BindingKey   []byte
+	BindingNonce *string
+	// AccessRenewedAt is the last access-token renewal without rotation.
+	AccessRenewedAt *time.Time
 }
 
 // Store abstracts persistence for session state.
@@ -71,9 +99,27 @@ type Store interface {
 	// Touch updates last_used_at for a session.
 	Touch(ctx context.Context, now time.Time, sessionID string) error
 
+	// TouchBatch updates last_used_at of the given sessions in one statement. Sessions
+	// already used at or after now, expired or created after now are skipped.
+	TouchBatch(ctx context.Context, now time.Time, sessionIDs []string) (int64, error)
+
 	// Revoke revokes a single session.
 	Revoke(ctx context.Context, now time.Time, sessionID string, reason string) error
 
 	// RevokeAll revokes all sessions for a user.
 	RevokeAll(ctx context.Context, now time.Time, userID string, reason string) error
+
+	// SetBindingNonce replaces the binding nonce of the active bound session with refreshHash.
+	// Returns ErrSessionNotFound when no such session exists.
+	SetBindingNonce(ctx context.Context, now time.Time, refreshHash string, nonce string) error
+
+	// ListActiveByUser lists active sessions for a user, newest first.
+	ListActiveByUser(ctx context.Context, now time.Time, userID string) ([]Info, error)
+
+	// CountByFilter counts active sessions matching the filter.
+	CountByFilter(ctx context.Context, now time.Time, f Filter) (int64, error)
+
+	// RevokeBatchByFilter revokes up to limit active sessions matching the filter
+	// in a single transaction and returns how many were revoked.
+	RevokeBatchByFilter(ctx context.Context, now time.Time, f Filter, reason string, limit int) (int64, error)
 }
@@ -3,26 +3,84 @@ package session
 import (
 	"context"
 	"errors"
+	"math"
 	"net"
+	"regexp"
+	"strings"
 	"time"
 
+	"arc/cmd/internal/dbroute"
+	"arc/cmd/internal/dbtrace"
+
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/oklog/ulid/v2"
 )
 
-// PostgresStore implements Store using PostgreSQL (arc.sessions).
+// PostgresStore implements Store using PostgreSQL (<schema>.sessions, default arc).
 type PostgresStore struct {
 	pool *pgxpool.Pool
+	// readPool serves replica-safe reads (nil uses pool); see WithReadPool.
+	readPool *pgxpool.Pool
+	schema   string
+}
+
+// defaultSchema is where the migrations create the sessions and users tables.
+const defaultSchema = "arc"
+
+// PostgresOption configures PostgresStore.
+type PostgresOption func(*PostgresStore) error
+
+var pgIdentRE = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// WithSchema sets the DB schema holding sessions and users (default: "arc").
+// The schema name is validated and safely quoted in queries.
+func WithSchema(schema string) PostgresOption {
+	return func(s *PostgresStore) error {
+		schema = strings.TrimSpace(schema)
+		if schema == "" {
+			return errors.New("session: empty schema")
+		}
+		if !pgIdentRE.MatchString(schema) {
+			return errors.New("session: invalid schema identifier")
+		}
+		s.schema = schema
+		return nil
+	}
+}
+
+// WithReadPool routes ListActiveByUser and CountByFilter to a read replica unless
+// the context requires the primary (dbroute.RequirePrimary). Lookups that
+// authorize a request or a rotation stay on the primary. A nil pool is ignored.
+func WithReadPool(pool *pgxpool.Pool) PostgresOption {
+	return func(s *PostgresStore) error {
+		s.readPool = pool
+		return nil
+	}
 }
 
 // NewPostgresStore creates a Postgres-backed session store.
-func NewPostgresStore(pool *pgxpool.Pool) *PostgresStore {
-	return &PostgresStore{pool: pool}
+func NewPostgresStore(pool *pgxpool.Pool, opts ...PostgresOption) (*PostgresStore, error) {
+	st := &PostgresStore{pool: pool, schema: defaultSchema}
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		if err := opt(st); err != nil {
+			return nil, err
+		}
+	}
+	return st, nil
+}
+
+// Schema is the DB schema the store reads and writes.
+func (s *PostgresStore) Schema() string {
+	return s.schema
 }
 
 // Create inserts a new session row and returns its ULID.
 func (s *PostgresStore) Create(ctx context.Context, now time.Time, userID string, dev DeviceContext, refreshHash string, expiresAt time.Time, revocationReason *string) (string, error) {
+	ctx = dbtrace.WithOp(ctx, "session.create")
 	id := ulid.Make().String()
 
 	var ip net.IP
@@ -31,16 +89,19 @@ func (s *PostgresStore) Create(ctx context.Context, now time.Time, userID string
 	}
 
 	_, err := s.pool.Exec(ctx, `
-		INSERT INTO arc.sessions (
+		INSERT INTO `+pgIdent(s.schema, "sessions")+` (
 			id, user_id, refresh_token_hash,
 			created_at, last_used_at, expires_at, revoked_at,
-			replaced_by_session_id, user_agent, ip, platform, revocation_reason
+			replaced_by_session_id, user_agent, ip, platform, revocation_reason,
+			geo_country, geo_city, geo_asn, binding_key
 		) VALUES (
 			$1, $2, $3,
 			$4, $4, $5, NULL,
-			NULL, $6, $7, $8, $9
+			NULL, $6, $7, $8, $9,
+			$10, $11, $12, $13
 		)
-	`, id, userID, refreshHash, now, expiresAt, nullIfEmpty(dev.UserAgent), ip, string(dev.Platform), revocationReason)
+	`, id, userID, refreshHash, now, expiresAt, nullIfEmpty(dev.UserAgent), ip, string(dev.Platform), revocationReason,
+		nullIfEmpty(dev.Geo.Country), nullIfEmpty(dev.Geo.City), nullIfZeroASN(dev.Geo.ASN), nullIfEmptyBytes(dev.BindingKey))
 	if err != nil {
 		return "", err
 	}
@@ -50,15 +111,18 @@ func (s *PostgresStore) Create(ctx context.Context, now time.Time, userID string
 
 // GetByID loads a session row by ID.
 func (s *PostgresStore) GetByID(ctx context.Context, sessionID string) (Row, error) {
+	ctx = dbtrace.WithOp(ctx, "session.get_by_id")
 	var row Row
 
 	err := s.pool.QueryRow(ctx, `
 		SELECT
-			id, user_id, refresh_token_hash,
-			created_at, last_used_at, expires_at, revoked_at,
-			replaced_by_session_id, platform
-		FROM arc.sessions
-		WHERE id = $1
+			s.id, s.user_id, s.refresh_token_hash,
+			s.created_at, s.last_used_at, s.expires_at, s.revoked_at,
+			s.replaced_by_session_id, s.platform, u.locked_at,
+			s.binding_key, s.binding_nonce, s.access_renewed_at
+		FROM `+pgIdent(s.schema, "sessions")+` s
+		JOIN `+pgIdent(s.schema, "users")+` u ON u.id = s.user_id
+		WHERE s.id = $1
 	`, sessionID).Scan(
 		&row.ID,
 		&row.UserID,
@@ -69,6 +133,10 @@ func (s *PostgresStore) GetByID(ctx context.Context, sessionID string) (Row, err
 		&row.RevokedAt,
 		&row.ReplacedBySessionID,
 		&row.Platform,
+		&row.UserLockedAt,
+		&row.BindingKey,
+		&row.BindingNonce,
+		&row.AccessRenewedAt,
 	)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return Row{}, ErrSessionNotFound
@@ -82,16 +150,19 @@ func (s *PostgresStore) GetByID(ctx context.Context, sessionID string) (Row, err
 
 // GetByRefreshHashForUpdate loads a session by refresh token hash and locks it.
 func (s *PostgresStore) GetByRefreshHashForUpdate(ctx context.Context, refreshHash string) (Row, error) {
+	ctx = dbtrace.WithOp(ctx, "session.get_by_refresh_hash_for_update")
 	var row Row
 
 	err := s.pool.QueryRow(ctx, `
 		SELECT
-			id, user_id, refresh_token_hash,
-			created_at, last_used_at, expires_at, revoked_at,
-			replaced_by_session_id, platform
-		FROM arc.sessions
-		WHERE refresh_token_hash = $1
-		FOR UPDATE
+			s.id, s.user_id, s.refresh_token_hash,
+			s.created_at, s.last_used_at, s.expires_at, s.revoked_at,
+			s.replaced_by_session_id, s.platform, u.locked_at,
+			s.binding_key, s.binding_nonce, s.access_renewed_at
+		FROM `+pgIdent(s.schema, "sessions")+` s
+		JOIN `+pgIdent(s.schema, "users")+` u ON u.id = s.user_id
+		WHERE s.refresh_token_hash = $1
+		FOR UPDATE OF s
 	`, refreshHash).Scan(
 		&row.ID,
 		&row.UserID,
@@ -102,6 +173,10 @@ func (s *PostgresStore) GetByRefreshHashForUpdate(ctx context.Context, refreshHa
 		&row.RevokedAt,
 		&row.ReplacedBySessionID,
 		&row.Platform,
+		&row.UserLockedAt,
+		&row.BindingKey,
+		&row.BindingNonce,
+		&row.AccessRenewedAt,
 	)
 
 	if errors.Is(err, pgx.ErrNoRows) {
@@ -116,8 +191,9 @@ func (s *PostgresStore) GetByRefreshHashForUpdate(ctx context.Context, refreshHa
 
 // MarkRotated revokes the old session and links it to the replacement session.
 func (s *PostgresStore) MarkRotated(ctx context.Context, now time.Time, sessionID string, replacedBy string) error {
+	ctx = dbtrace.WithOp(ctx, "session.mark_rotated")
 	_, err := s.pool.Exec(ctx, `
-		UPDATE arc.sessions
+		UPDATE `+pgIdent(s.schema, "sessions")+`
 		SET
 			last_used_at = $2,
 			revoked_at = $2,
@@ -130,18 +206,41 @@ func (s *PostgresStore) MarkRotated(ctx context.Context, now time.Time, sessionI
 
 // Touch updates last_used_at for a session.
 func (s *PostgresStore) Touch(ctx context.Context, now time.Time, sessionID string) error {
+	ctx = dbtrace.WithOp(ctx, "session.touch")
 	_, err := s.pool.Exec(ctx, `
-		UPDATE arc.sessions
+		UPDATE `+pgIdent(s.schema, "sessions")+`
 		SET last_used_at = $2
 		WHERE id = $1
 	`, sessionID, now)
 	return err
 }
 
+// TouchBatch updates last_used_at for many sessions at once.
+func (s *PostgresStore) TouchBatch(ctx context.Context, now time.Time, sessionIDs []string) (int64, error) {
+	ctx = dbtrace.WithOp(ctx, "session.touch_batch")
+	if len(sessionIDs) == 0 {
+		return 0, nil
+	}
+	// The bounds keep every row within the last_used_at check constraints.
+	tag, err := s.pool.Exec(ctx, `
+		UPDATE `+pgIdent(s.schema, "sessions")+`
+		SET last_used_at = $1
+		WHERE id = ANY($2::text[])
+		  AND created_at <= $1
+		  AND expires_at >= $1
+		  AND (last_used_at IS NULL OR last_used_at < $1)
+	`, now, sessionIDs)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
 // Revoke revokes a single session (idempotent).
 func (s *PostgresStore) Revoke(ctx context.Context, now time.Time, sessionID string, reason string) error {
+	ctx = dbtrace.WithOp(ctx, "session.revoke")
 	_, err := s.pool.Exec(ctx, `
-		UPDATE arc.sessions
+		UPDATE `+pgIdent(s.schema, "sessions")+`
 		SET revoked_at = COALESCE(revoked_at, $2),
 		    revocation_reason = COALESCE(revocation_reason, $3)
 		WHERE id = $1
@@ -151,8 +250,9 @@ func (s *PostgresStore) Revoke(ctx context.Context, now time.Time, sessionID str
 
 // RevokeAll revokes all sessions for a user (idempotent).
 func (s *PostgresStore) RevokeAll(ctx context.Context, now time.Time, userID string, reason string) error {
+	ctx = dbtrace.WithOp(ctx, "session.revoke_all")
 	_, err := s.pool.Exec(ctx, `
-		UPDATE arc.sessions
+		UPDATE `+pgIdent(s.schema, "sessions")+`
 		SET revoked_at = COALESCE(revoked_at, $2),
 		    revocation_reason = COALESCE(revocation_reason, $3)
 		WHERE user_id = $1
@@ -160,9 +260,145 @@ func (s *PostgresStore) RevokeAll(ctx context.Context, now time.Time, userID str
 	return err
 }
 
+// SetBindingNonce replaces the binding nonce of an active bound session.
+func (s *PostgresStore) SetBindingNonce(ctx context.Context, now time.Time, refreshHash string, nonce string) error {
+	ctx = dbtrace.WithOp(ctx, "session.set_binding_nonce")
+	tag, err := s.pool.Exec(ctx, `
+		UPDATE `+pgIdent(s.schema, "sessions")+`
+		SET binding_nonce = $3
+		WHERE refresh_token_hash = $1
+		  AND binding_key IS NOT NULL
+		  AND revoked_at IS NULL
+		  AND expires_at > $2
+	`, refreshHash, now, nonce)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// ListActiveByUser lists active sessions for a user, newest first.
+func (s *PostgresStore) ListActiveByUser(ctx context.Context, now time.Time, userID string) ([]Info, error) {
+	ctx = dbtrace.WithOp(ctx, "session.list_active_by_user")
+	rows, err := dbroute.Reader(ctx, s.pool, s.readPool).Query(ctx, `
+		SELECT
+			id, platform, created_at, last_used_at, expires_at,
+			COALESCE(user_agent, ''), host(ip),
+			COALESCE(geo_country, ''), COALESCE(geo_city, ''), COALESCE(geo_asn, 0)
+		FROM `+pgIdent(s.schema, "sessions")+`
+		WHERE user_id = $1
+		  AND revoked_at IS NULL
+		  AND expires_at > $2
+		ORDER BY created_at DESC
+	`, userID, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Info
+	for rows.Next() {
+		var (
+			info Info
+			ip   *string
+			asn  int64
+		)
+		if err := rows.Scan(
+			&info.ID,
+			&info.Platform,
+			&info.CreatedAt,
+			&info.LastUsedAt,
+			&info.ExpiresAt,
+			&info.UserAgent,
+			&ip,
+			&info.Geo.Country,
+			&info.Geo.City,
+			&asn,
+		); err != nil {
+			return nil, err
+		}
+		if ip != nil {
+			info.IP = net.ParseIP(*ip)
+		}
+		if asn > 0 && asn <= math.MaxUint32 {
+			info.Geo.ASN = uint32(asn)
+		}
+		out = append(out, info)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CountByFilter counts active sessions matching the filter.
+func (s *PostgresStore) CountByFilter(ctx context.Context, now time.Time, f Filter) (int64, error) {
+	ctx = dbtrace.WithOp(ctx, "session.count_by_filter")
+	where, args := f.whereClause(1)
+
+	var n int64
+	err := dbroute.Reader(ctx, s.pool, s.readPool).QueryRow(ctx, `
+		SELECT count(*)
+		FROM `+pgIdent(s.schema, "sessions")+`
+		WHERE `+where,
+		append([]any{now}, args...)...,
+	).Scan(&n)
+	return n, err
+}
+
+// RevokeBatchByFilter revokes up to limit matching sessions in one statement (one transaction).
+// Row locks serialize the batch with concurrent refresh rotations of the same sessions.
+func (s *PostgresStore) RevokeBatchByFilter(ctx context.Context, now time.Time, f Filter, reason string, limit int) (int64, error) {
+	ctx = dbtrace.WithOp(ctx, "session.revoke_batch_by_filter")
+	if limit <= 0 {
+		limit = DefaultRevokeBatchSize
+	}
+	where, args := f.whereClause(3)
+
+	tag, err := s.pool.Exec(ctx, `
+		UPDATE `+pgIdent(s.schema, "sessions")+`
+		SET revoked_at = $1,
+		    revocation_reason = COALESCE(revocation_reason, $2)
+		WHERE id IN (
+			SELECT id
+			FROM `+pgIdent(s.schema, "sessions")+`
+			WHERE `+where+`
+			ORDER BY id
+			LIMIT $3
+			FOR UPDATE
+		)
+	`, append([]any{now, reason, limit}, args...)...)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// pgIdent safely quotes a schema-qualified identifier: "schema"."name".
+func pgIdent(schema, name string) string {
+	return pgx.Identifier{schema, name}.Sanitize()
+}
+
 func nullIfEmpty(s string) any {
 	if s == "" {
 		return nil
 	}
 	return s
 }
+
+func nullIfEmptyBytes(b []byte) any {
+	if len(b) == 0 {
+		return nil
+	}
+	return b
+}
+
+func nullIfZeroASN(asn uint32) any {
+	if asn == 0 {
+		return nil
+	}
+	return int64(asn)
+}
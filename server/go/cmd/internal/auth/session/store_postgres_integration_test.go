@@ -2,6 +2,7 @@ package session
 
 import (
 	"context"
+	"crypto/ed25519"
 	"crypto/rand"
 	"errors"
 	"net"
@@ -11,6 +12,7 @@ import (
 	"time"
 
 	paseto "aidanwoods.dev/go-paseto"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/oklog/ulid/v2"
 )
@@ -31,7 +33,7 @@ func TestPostgresSession_IssueAndRotateRefresh_Succeeds(t *testing.T) {
 	defer pool.Close()
 
 	cfg, tokens := mustTestConfigAndTokens(t)
-	store := NewPostgresStore(pool)
+	store := newTestPostgresStore(t, pool)
 	svc := NewService(cfg, pool, store, tokens)
 
 	userID := newULID(t)
@@ -104,7 +106,7 @@ func TestPostgresSession_RotateRefresh_RateLimited(t *testing.T) {
 
 	cfg, tokens := mustTestConfigAndTokens(t)
 	cfg.RefreshMinInterval = 2 * time.Minute
-	store := NewPostgresStore(pool)
+	store := newTestPostgresStore(t, pool)
 	svc := NewService(cfg, pool, store, tokens)
 
 	userID := newULID(t)
@@ -150,6 +152,132 @@ func TestPostgresSession_RotateRefresh_RateLimited(t *testing.T) {
 	}
 }
 
+func TestPostgresSession_RotateRefresh_BoundSessionRequiresProof(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dbURL := os.Getenv("ARC_DATABASE_URL")
+	if dbURL == "" {
+		t.Skip("ARC_DATABASE_URL is not set; skipping Postgres integration test")
+	}
+
+	pool := mustPGXPool(ctx, t, dbURL)
+	defer pool.Close()
+
+	cfg, tokens := mustTestConfigAndTokens(t)
+	cfg.RefreshBinding = map[Platform]BindingMode{PlatformIOS: BindingRequired}
+	store := newTestPostgresStore(t, pool)
+	svc := NewService(cfg, pool, store, tokens)
+
+	userID := newULID(t)
+	mustCreateUser(ctx, t, pool, userID)
+	t.Cleanup(func() { cleanupUserData(ctx, t, pool, userID) })
+
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+
+	now := time.Now().UTC()
+	dev := DeviceContext{Platform: PlatformIOS, UserAgent: "arc-test/1.0"}
+	if _, err := svc.IssueSession(ctx, now, userID, dev); !errors.Is(err, ErrBindingRequired) {
+		t.Fatalf("expected ErrBindingRequired without key, got %v", err)
+	}
+
+	dev.BindingKey = pub
+	issued, err := svc.IssueSession(ctx, now, userID, dev)
+	if err != nil {
+		t.Fatalf("IssueSession: %v", err)
+	}
+	if issued.BindingNonce == "" {
+		t.Fatalf("expected binding nonce for bound session")
+	}
+
+	// A stolen refresh token without the private key must not rotate.
+	if _, err := svc.RotateRefresh(ctx, now.Add(time.Second), issued.RefreshToken, DeviceContext{Platform: PlatformIOS}); !errors.Is(err, ErrBindingProofInvalid) {
+		t.Fatalf("expected ErrBindingProofInvalid, got %v", err)
+	}
+
+	rotated, err := svc.RotateRefresh(ctx, now.Add(2*time.Second), issued.RefreshToken, DeviceContext{
+		Platform:     PlatformIOS,
+		BindingProof: ed25519.Sign(priv, BindingProofMessage(issued.BindingNonce)),
+	})
+	if err != nil {
+		t.Fatalf("RotateRefresh: %v", err)
+	}
+	if rotated.BindingNonce == "" || rotated.BindingNonce == issued.BindingNonce {
+		t.Fatalf("expected a fresh binding nonce after rotation")
+	}
+
+	nonce, err := svc.ReissueBindingNonce(ctx, now.Add(3*time.Second), rotated.RefreshToken)
+	if err != nil {
+		t.Fatalf("ReissueBindingNonce: %v", err)
+	}
+	if _, err := svc.RotateRefresh(ctx, now.Add(4*time.Second), rotated.RefreshToken, DeviceContext{
+		Platform:     PlatformIOS,
+		BindingProof: ed25519.Sign(priv, BindingProofMessage(rotated.BindingNonce)),
+	}); !errors.Is(err, ErrBindingProofInvalid) {
+		t.Fatalf("expected superseded nonce to be rejected, got %v", err)
+	}
+	if _, err := svc.RotateRefresh(ctx, now.Add(5*time.Second), rotated.RefreshToken, DeviceContext{
+		Platform:     PlatformIOS,
+		BindingProof: ed25519.Sign(priv, BindingProofMessage(nonce)),
+	}); err != nil {
+		t.Fatalf("RotateRefresh with reissued nonce: %v", err)
+	}
+}
+
+func TestPostgresSession_RenewAccessToken_CappedWithoutRotation(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dbURL := os.Getenv("ARC_DATABASE_URL")
+	if dbURL == "" {
+		t.Skip("ARC_DATABASE_URL is not set; skipping Postgres integration test")
+	}
+
+	pool := mustPGXPool(ctx, t, dbURL)
+	defer pool.Close()
+
+	cfg, tokens := mustTestConfigAndTokens(t)
+	cfg.AccessRenewMinInterval = time.Minute
+	store := newTestPostgresStore(t, pool)
+	svc := NewService(cfg, pool, store, tokens)
+
+	userID := newULID(t)
+	mustCreateUser(ctx, t, pool, userID)
+	t.Cleanup(func() { cleanupUserData(ctx, t, pool, userID) })
+
+	now := time.Now().UTC()
+	dev := DeviceContext{Platform: PlatformWeb, UserAgent: "arc-test/1.0"}
+	issued, err := svc.IssueSession(ctx, now, userID, dev)
+	if err != nil {
+		t.Fatalf("IssueSession: %v", err)
+	}
+
+	// The session was just issued with an access token, so renewal is capped from creation.
+	if _, err := svc.RenewAccessToken(ctx, now.Add(10*time.Second), issued.RefreshToken, dev); !errors.Is(err, ErrRefreshRateLimited) {
+		t.Fatalf("expected ErrRefreshRateLimited right after issue, got %v", err)
+	}
+
+	renewed, err := svc.RenewAccessToken(ctx, now.Add(61*time.Second), issued.RefreshToken, dev)
+	if err != nil {
+		t.Fatalf("RenewAccessToken: %v", err)
+	}
+	if renewed.SessionID != issued.SessionID || renewed.AccessToken == "" {
+		t.Fatalf("expected a new access token for the same session")
+	}
+
+	if _, err := svc.RenewAccessToken(ctx, now.Add(90*time.Second), issued.RefreshToken, dev); !errors.Is(err, ErrRefreshRateLimited) {
+		t.Fatalf("expected ErrRefreshRateLimited within interval of last renewal, got %v", err)
+	}
+
+	// The refresh token itself is unchanged and still rotates.
+	if _, err := svc.RotateRefresh(ctx, now.Add(2*time.Minute), issued.RefreshToken, dev); err != nil {
+		t.Fatalf("RotateRefresh after renewal: %v", err)
+	}
+}
+
 func TestPostgresSession_RotateRefresh_ReuseDetected_RevokesAll(t *testing.T) {
 	t.Parallel()
 
@@ -163,7 +291,7 @@ func TestPostgresSession_RotateRefresh_ReuseDetected_RevokesAll(t *testing.T) {
 	defer pool.Close()
 
 	cfg, tokens := mustTestConfigAndTokens(t)
-	store := NewPostgresStore(pool)
+	store := newTestPostgresStore(t, pool)
 	svc := NewService(cfg, pool, store, tokens)
 
 	userID := newULID(t)
@@ -200,6 +328,92 @@ func TestPostgresSession_RotateRefresh_ReuseDetected_RevokesAll(t *testing.T) {
 	if row2.RevokedAt == nil {
 		t.Fatalf("expected session2 revoked after reuse detection")
 	}
+
+	var (
+		reusedID, endpoint, presenterUA string
+		rotatedAt                       *time.Time
+		revoked                         int64
+		chainLen                        int
+	)
+	err = pool.QueryRow(ctx, `
+		SELECT reused_session_id, endpoint, COALESCE(presenter_user_agent, ''), rotated_at,
+		       sessions_revoked, jsonb_array_length(session_chain)
+		FROM arc.reuse_incidents
+		WHERE user_id = $1
+	`, userID).Scan(&reusedID, &endpoint, &presenterUA, &rotatedAt, &revoked, &chainLen)
+	if err != nil {
+		t.Fatalf("reuse incident: %v", err)
+	}
+	if reusedID != issued1.SessionID || endpoint != "refresh" || presenterUA != "arc-test/1.0" {
+		t.Fatalf("unexpected incident: session %s endpoint %s ua %q", reusedID, endpoint, presenterUA)
+	}
+	if rotatedAt == nil || revoked != 1 || chainLen != 2 {
+		t.Fatalf("unexpected incident: rotated_at %v revoked %d chain %d", rotatedAt, revoked, chainLen)
+	}
+}
+
+func TestPostgresSession_RotateRefresh_RetriesSerializationFailure(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dbURL := os.Getenv("ARC_DATABASE_URL")
+	if dbURL == "" {
+		t.Skip("ARC_DATABASE_URL is not set; skipping Postgres integration test")
+	}
+
+	pool := mustPGXPool(ctx, t, dbURL)
+	// Closed after the injected trigger is dropped (cleanups run last-in, first-out).
+	t.Cleanup(pool.Close)
+
+	cfg, tokens := mustTestConfigAndTokens(t)
+	store := newTestPostgresStore(t, pool)
+	svc := NewService(cfg, pool, store, tokens)
+
+	userID := newULID(t)
+	mustCreateUser(ctx, t, pool, userID)
+	t.Cleanup(func() { cleanupUserData(ctx, t, pool, userID) })
+
+	now := time.Now().UTC()
+	dev := DeviceContext{Platform: PlatformWeb, UserAgent: "arc-test/1.0"}
+	issued1, err := svc.IssueSession(ctx, now, userID, dev)
+	if err != nil {
+		t.Fatalf("IssueSession: %v", err)
+	}
+
+	// The first write of the rotation aborts with 40001; the retry must succeed
+	// and leave exactly one replacement session.
+	mustFailNextSessionWrite(ctx, t, pool, userID)
+	issued2, err := svc.RotateRefresh(ctx, now.Add(2*time.Second), issued1.RefreshToken, dev)
+	if err != nil {
+		t.Fatalf("RotateRefresh: %v", err)
+	}
+	row1 := mustGetSessionByID(ctx, t, pool, issued1.SessionID)
+	if row1.ReplacedBySessionID == nil || *row1.ReplacedBySessionID != issued2.SessionID {
+		t.Fatalf("expected session1 replaced by %s, got %v", issued2.SessionID, row1.ReplacedBySessionID)
+	}
+	var sessions int
+	if err := pool.QueryRow(ctx, `SELECT count(*) FROM arc.sessions WHERE user_id = $1`, userID).Scan(&sessions); err != nil {
+		t.Fatalf("count sessions: %v", err)
+	}
+	if sessions != 2 {
+		t.Fatalf("expected 2 sessions after a retried rotation, got %d", sessions)
+	}
+
+	// Reuse detection is retried too and records a single incident.
+	mustFailNextSessionWrite(ctx, t, pool, userID)
+	if _, err := svc.RotateRefresh(ctx, now.Add(4*time.Second), issued1.RefreshToken, dev); !errors.Is(err, ErrRefreshReuseDetected) {
+		t.Fatalf("expected ErrRefreshReuseDetected, got %v", err)
+	}
+	if row2 := mustGetSessionByID(ctx, t, pool, issued2.SessionID); row2.RevokedAt == nil {
+		t.Fatalf("expected session2 revoked after reuse detection")
+	}
+	var incidents int
+	if err := pool.QueryRow(ctx, `SELECT count(*) FROM arc.reuse_incidents WHERE user_id = $1`, userID).Scan(&incidents); err != nil {
+		t.Fatalf("count incidents: %v", err)
+	}
+	if incidents != 1 {
+		t.Fatalf("expected 1 reuse incident, got %d", incidents)
+	}
 }
 
 func TestPostgresSession_RotateRefresh_OnRevokedSession_ReturnsRevoked(t *testing.T) {
@@ -215,7 +429,7 @@ func TestPostgresSession_RotateRefresh_OnRevokedSession_ReturnsRevoked(t *testin
 	defer pool.Close()
 
 	cfg, tokens := mustTestConfigAndTokens(t)
-	store := NewPostgresStore(pool)
+	store := newTestPostgresStore(t, pool)
 	svc := NewService(cfg, pool, store, tokens)
 
 	userID := newULID(t)
@@ -256,7 +470,7 @@ func TestPostgresSession_ValidateAccessToken_Revoked(t *testing.T) {
 	defer pool.Close()
 
 	cfg, tokens := mustTestConfigAndTokens(t)
-	store := NewPostgresStore(pool)
+	store := newTestPostgresStore(t, pool)
 	svc := NewService(cfg, pool, store, tokens)
 
 	userID := newULID(t)
@@ -281,6 +495,182 @@ func TestPostgresSession_ValidateAccessToken_Revoked(t *testing.T) {
 	}
 }
 
+func TestPostgresSession_SessionEnded_FollowsRotation(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dbURL := os.Getenv("ARC_DATABASE_URL")
+	if dbURL == "" {
+		t.Skip("ARC_DATABASE_URL is not set; skipping Postgres integration test")
+	}
+
+	pool := mustPGXPool(ctx, t, dbURL)
+	defer pool.Close()
+
+	cfg, tokens := mustTestConfigAndTokens(t)
+	store := newTestPostgresStore(t, pool)
+	svc := NewService(cfg, pool, store, tokens)
+
+	userID := newULID(t)
+	mustCreateUser(ctx, t, pool, userID)
+	t.Cleanup(func() { cleanupUserData(ctx, t, pool, userID) })
+
+	now := time.Now().UTC()
+	dev := DeviceContext{Platform: PlatformWeb, RememberMe: false, UserAgent: "arc-test/1.0"}
+
+	issued, err := svc.IssueSession(ctx, now, userID, dev)
+	if err != nil {
+		t.Fatalf("IssueSession: %v", err)
+	}
+	rotated, err := svc.RotateRefresh(ctx, now.Add(1*time.Minute), issued.RefreshToken, dev)
+	if err != nil {
+		t.Fatalf("RotateRefresh: %v", err)
+	}
+
+	// Rotation alone does not end the original session.
+	if ended, err := svc.SessionEnded(ctx, now.Add(2*time.Minute), issued.SessionID); err != nil || ended {
+		t.Fatalf("expected rotated session to be live, got %v, %v", ended, err)
+	}
+
+	if err := svc.RevokeSession(ctx, now.Add(3*time.Minute), rotated.SessionID); err != nil {
+		t.Fatalf("RevokeSession: %v", err)
+	}
+	for _, sid := range []string{issued.SessionID, rotated.SessionID} {
+		if ended, err := svc.SessionEnded(ctx, now.Add(4*time.Minute), sid); err != nil || !ended {
+			t.Fatalf("%s: expected session to have ended, got %v, %v", sid, ended, err)
+		}
+	}
+	if ended, err := svc.SessionEnded(ctx, now, newULID(t)); err != nil || !ended {
+		t.Fatalf("expected unknown session to have ended, got %v, %v", ended, err)
+	}
+}
+
+func TestPostgresSession_WSTicket_SingleUse(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dbURL := os.Getenv("ARC_DATABASE_URL")
+	if dbURL == "" {
+		t.Skip("ARC_DATABASE_URL is not set; skipping Postgres integration test")
+	}
+
+	pool := mustPGXPool(ctx, t, dbURL)
+	defer pool.Close()
+
+	cfg, tokens := mustTestConfigAndTokens(t)
+	store := newTestPostgresStore(t, pool)
+	svc := NewService(cfg, pool, store, tokens)
+
+	userID := newULID(t)
+	mustCreateUser(ctx, t, pool, userID)
+	t.Cleanup(func() { cleanupUserData(ctx, t, pool, userID) })
+
+	now := time.Now().UTC()
+	dev := DeviceContext{Platform: PlatformWeb, RememberMe: false, UserAgent: "arc-test/1.0"}
+
+	issued, err := svc.IssueSession(ctx, now, userID, dev)
+	if err != nil {
+		t.Fatalf("IssueSession: %v", err)
+	}
+	ticket, err := svc.IssueWSTicket(ctx, now, userID, issued.SessionID)
+	if err != nil {
+		t.Fatalf("IssueWSTicket: %v", err)
+	}
+	if !ticket.ExpiresAt.Equal(now.Add(cfg.WSTicketTTL)) {
+		t.Fatalf("unexpected expiry %v", ticket.ExpiresAt)
+	}
+
+	claims, err := svc.RedeemWSTicket(ctx, now.Add(time.Second), ticket.Ticket)
+	if err != nil || claims.UserID != userID || claims.SessionID != issued.SessionID {
+		t.Fatalf("RedeemWSTicket: %+v, %v", claims, err)
+	}
+	if _, err := svc.RedeemWSTicket(ctx, now.Add(2*time.Second), ticket.Ticket); InvalidTokenReason(err) != TokenReasonWSTicket {
+		t.Fatalf("expected a used ticket to be rejected, got %v", err)
+	}
+
+	expired, err := svc.IssueWSTicket(ctx, now, userID, issued.SessionID)
+	if err != nil {
+		t.Fatalf("IssueWSTicket: %v", err)
+	}
+	if _, err := svc.RedeemWSTicket(ctx, now.Add(cfg.WSTicketTTL), expired.Ticket); InvalidTokenReason(err) != TokenReasonWSTicket {
+		t.Fatalf("expected an expired ticket to be rejected, got %v", err)
+	}
+
+	revoked, err := svc.IssueWSTicket(ctx, now, userID, issued.SessionID)
+	if err != nil {
+		t.Fatalf("IssueWSTicket: %v", err)
+	}
+	if err := svc.RevokeSession(ctx, now.Add(time.Second), issued.SessionID); err != nil {
+		t.Fatalf("RevokeSession: %v", err)
+	}
+	if _, err := svc.RedeemWSTicket(ctx, now.Add(2*time.Second), revoked.Ticket); err != ErrSessionRevoked {
+		t.Fatalf("expected ErrSessionRevoked, got %v", err)
+	}
+}
+
+func TestPostgresSession_ExchangeToken_Delegated(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dbURL := os.Getenv("ARC_DATABASE_URL")
+	if dbURL == "" {
+		t.Skip("ARC_DATABASE_URL is not set; skipping Postgres integration test")
+	}
+
+	pool := mustPGXPool(ctx, t, dbURL)
+	defer pool.Close()
+
+	cfg, tokens := mustTestConfigAndTokens(t)
+	store := newTestPostgresStore(t, pool)
+	svc := NewService(cfg, pool, store, tokens)
+
+	userID := newULID(t)
+	mustCreateUser(ctx, t, pool, userID)
+	t.Cleanup(func() { cleanupUserData(ctx, t, pool, userID) })
+
+	now := time.Now().UTC()
+	issued, err := svc.IssueSession(ctx, now, userID, DeviceContext{Platform: PlatformWeb, UserAgent: "arc-test/1.0"})
+	if err != nil {
+		t.Fatalf("IssueSession: %v", err)
+	}
+
+	out, err := svc.ExchangeToken(ctx, now, ExchangeInput{
+		SubjectToken: issued.AccessToken,
+		Actor:        "gateway",
+		Audience:     "billing",
+		Scopes:       []string{"profile"},
+		TTL:          24 * time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("ExchangeToken: %v", err)
+	}
+	if out.Claims.UserID != userID || out.Claims.SessionID != issued.SessionID {
+		t.Fatalf("unexpected claims %+v", out.Claims)
+	}
+	if out.Claims.ExpiresAt.After(issued.AccessExp) {
+		t.Fatalf("delegated token must not outlive the subject token")
+	}
+
+	// Delegated tokens are not accepted as Arc access tokens nor exchanged again.
+	if _, err := svc.ValidateAccessToken(ctx, out.AccessToken, now); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("expected ErrInvalidToken for delegated token, got %v", err)
+	}
+	if _, err := svc.ExchangeToken(ctx, now, ExchangeInput{
+		SubjectToken: out.AccessToken, Actor: "gateway", Audience: "billing", TTL: time.Minute,
+	}); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("expected ErrInvalidToken when exchanging a delegated token, got %v", err)
+	}
+
+	if err := svc.RevokeSession(ctx, now.Add(time.Second), issued.SessionID); err != nil {
+		t.Fatalf("RevokeSession: %v", err)
+	}
+	if _, err := svc.ExchangeToken(ctx, now.Add(2*time.Second), ExchangeInput{
+		SubjectToken: issued.AccessToken, Actor: "gateway", Audience: "billing", TTL: time.Minute,
+	}); err != ErrSessionRevoked {
+		t.Fatalf("expected ErrSessionRevoked, got %v", err)
+	}
+}
+
 func TestPostgresSession_ValidateAccessToken_Expired(t *testing.T) {
 	t.Parallel()
 
@@ -294,7 +684,7 @@ func TestPostgresSession_ValidateAccessToken_Expired(t *testing.T) {
 	defer pool.Close()
 
 	cfg, tokens := mustTestConfigAndTokens(t)
-	store := NewPostgresStore(pool)
+	store := newTestPostgresStore(t, pool)
 	svc := NewService(cfg, pool, store, tokens)
 
 	userID := newULID(t)
@@ -343,7 +733,7 @@ func TestPostgresSession_ValidateAccessToken_NotFound(t *testing.T) {
 	defer pool.Close()
 
 	cfg, tokens := mustTestConfigAndTokens(t)
-	store := NewPostgresStore(pool)
+	store := newTestPostgresStore(t, pool)
 	svc := NewService(cfg, pool, store, tokens)
 
 	userID := newULID(t)
@@ -382,7 +772,7 @@ func TestPostgresSession_ValidateAccessToken_UserMismatch(t *testing.T) {
 	defer pool.Close()
 
 	cfg, tokens := mustTestConfigAndTokens(t)
-	store := NewPostgresStore(pool)
+	store := newTestPostgresStore(t, pool)
 	svc := NewService(cfg, pool, store, tokens)
 
 	user1 := newULID(t)
@@ -426,7 +816,7 @@ func TestPostgresSession_TouchSession_UpdatesLastUsed(t *testing.T) {
 	defer pool.Close()
 
 	cfg, tokens := mustTestConfigAndTokens(t)
-	store := NewPostgresStore(pool)
+	store := newTestPostgresStore(t, pool)
 	svc := NewService(cfg, pool, store, tokens)
 
 	userID := newULID(t)
@@ -458,7 +848,95 @@ func TestPostgresSession_TouchSession_UpdatesLastUsed(t *testing.T) {
 	}
 }
 
-func mustPGXPool(ctx context.Context, t *testing.T, dbURL string) *pgxpool.Pool {
+func TestPostgresSession_TouchSessions_Batch(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dbURL := os.Getenv("ARC_DATABASE_URL")
+	if dbURL == "" {
+		t.Skip("ARC_DATABASE_URL is not set; skipping Postgres integration test")
+	}
+
+	pool := mustPGXPool(ctx, t, dbURL)
+	defer pool.Close()
+
+	cfg, tokens := mustTestConfigAndTokens(t)
+	store := newTestPostgresStore(t, pool)
+	svc := NewService(cfg, pool, store, tokens)
+
+	userID := newULID(t)
+	mustCreateUser(ctx, t, pool, userID)
+	t.Cleanup(func() { cleanupUserData(ctx, t, pool, userID) })
+
+	now := time.Now().UTC()
+	dev := DeviceContext{Platform: PlatformWeb, RememberMe: false, UserAgent: "arc-test/1.0"}
+
+	var ids []string
+	for range 2 {
+		issued, err := svc.IssueSession(ctx, now, userID, dev)
+		if err != nil {
+			t.Fatalf("IssueSession: %v", err)
+		}
+		ids = append(ids, issued.SessionID)
+	}
+
+	next := now.Add(30 * time.Second)
+	touched, err := svc.TouchSessions(ctx, next, append(ids, newULID(t)))
+	if err != nil || touched != 2 {
+		t.Fatalf("expected 2 sessions touched, got %d, %v", touched, err)
+	}
+	for _, id := range ids {
+		row := mustGetSessionByID(ctx, t, pool, id)
+		if row.LastUsedAt == nil || !row.LastUsedAt.UTC().Truncate(time.Microsecond).Equal(next.Truncate(time.Microsecond)) {
+			t.Fatalf("%s: expected last_used_at=%v, got %v", id, next, row.LastUsedAt)
+		}
+	}
+
+	// A stale batch never moves last_used_at backwards.
+	if touched, err := svc.TouchSessions(ctx, now.Add(10*time.Second), ids); err != nil || touched != 0 {
+		t.Fatalf("expected no sessions touched, got %d, %v", touched, err)
+	}
+}
+
+// BenchmarkPostgresSession_GetByID measures the per-request session check. The
+// cache_statement pool runs it as a named prepared statement; exec and
+// simple_protocol are the unprepared modes used behind transaction-pooling proxies.
+func BenchmarkPostgresSession_GetByID(b *testing.B) {
+	ctx := context.Background()
+	dbURL := os.Getenv("ARC_DATABASE_URL")
+	if dbURL == "" {
+		b.Skip("ARC_DATABASE_URL is not set; skipping Postgres benchmark")
+	}
+
+	for _, mode := range []pgx.QueryExecMode{pgx.QueryExecModeCacheStatement, pgx.QueryExecModeExec, pgx.QueryExecModeSimpleProtocol} {
+		b.Run(mode.String(), func(b *testing.B) {
+			pool := mustPGXPool(ctx, b, dbURL, func(c *pgxpool.Config) { c.ConnConfig.DefaultQueryExecMode = mode })
+			defer pool.Close()
+
+			cfg, tokens := mustTestConfigAndTokens(b)
+			store := newTestPostgresStore(b, pool)
+			svc := NewService(cfg, pool, store, tokens)
+
+			userID := newULID(b)
+			mustCreateUser(ctx, b, pool, userID)
+			b.Cleanup(func() { cleanupUserData(ctx, b, pool, userID) })
+
+			issued, err := svc.IssueSession(ctx, time.Now().UTC(), userID, DeviceContext{Platform: PlatformWeb, UserAgent: "arc-bench/1.0"})
+			if err != nil {
+				b.Fatalf("IssueSession: %v", err)
+			}
+
+			b.ResetTimer()
+			for b.Loop() {
+				if _, err := store.GetByID(ctx, issued.SessionID); err != nil {
+					b.Fatalf("GetByID: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func mustPGXPool(ctx context.Context, t testing.TB, dbURL string, tune ...func(*pgxpool.Config)) *pgxpool.Pool {
 	t.Helper()
 
 	cfg, err := pgxpool.ParseConfig(dbURL)
@@ -469,6 +947,9 @@ func mustPGXPool(ctx context.Context, t *testing.T, dbURL string) *pgxpool.Pool
 	cfg.MaxConns = 4
 	cfg.MinConns = 0
 	cfg.MaxConnLifetime = 30 * time.Second
+	for _, fn := range tune {
+		fn(cfg)
+	}
 
 	pool, err := pgxpool.NewWithConfig(ctx, cfg)
 	if err != nil {
@@ -486,7 +967,7 @@ func mustPGXPool(ctx context.Context, t *testing.T, dbURL string) *pgxpool.Pool
 	return pool
 }
 
-func mustTestConfigAndTokens(t *testing.T) (Config, AccessTokenManager) {
+func mustTestConfigAndTokens(t testing.TB) (Config, AccessTokenManager) {
 	t.Helper()
 
 	secret := paseto.NewV4AsymmetricSecretKey()
@@ -528,7 +1009,7 @@ func shouldSkipIntegration(err error) bool {
 	return false
 }
 
-func newULID(t *testing.T) string {
+func newULID(t testing.TB) string {
 	t.Helper()
 
 	entropy := ulid.Monotonic(rand.Reader, 0)
@@ -540,7 +1021,7 @@ func newULID(t *testing.T) string {
 	return id
 }
 
-func mustCreateUser(ctx context.Context, t *testing.T, pool *pgxpool.Pool, userID string) {
+func mustCreateUser(ctx context.Context, t testing.TB, pool *pgxpool.Pool, userID string) {
 	t.Helper()
 
 	_, err := pool.Exec(ctx, `
@@ -552,7 +1033,7 @@ func mustCreateUser(ctx context.Context, t *testing.T, pool *pgxpool.Pool, userI
 	}
 }
 
-func cleanupUserData(ctx context.Context, t *testing.T, pool *pgxpool.Pool, userID string) {
+func cleanupUserData(ctx context.Context, t testing.TB, pool *pgxpool.Pool, userID string) {
 	t.Helper()
 
 	_, _ = pool.Exec(ctx, `DELETE FROM arc.sessions WHERE user_id = $1`, userID)
@@ -560,6 +1041,41 @@ func cleanupUserData(ctx context.Context, t *testing.T, pool *pgxpool.Pool, user
 	_, _ = pool.Exec(ctx, `DELETE FROM arc.users WHERE id = $1`, userID)
 }
 
+// mustFailNextSessionWrite makes the next insert or update of a session of userID
+// abort with a serialization failure (40001). A sequence counts the attempts
+// because it is not rolled back with the failed transaction.
+func mustFailNextSessionWrite(ctx context.Context, t *testing.T, pool *pgxpool.Pool, userID string) {
+	t.Helper()
+
+	name := "arc.test_fail_" + strings.ToLower(userID)
+	trigger := "test_fail_" + strings.ToLower(userID)
+	for _, stmt := range []string{
+		`DROP TRIGGER IF EXISTS ` + trigger + ` ON arc.sessions`,
+		`DROP SEQUENCE IF EXISTS ` + name,
+		`CREATE SEQUENCE ` + name,
+		`CREATE OR REPLACE FUNCTION ` + name + `() RETURNS trigger LANGUAGE plpgsql AS $$
+		BEGIN
+			IF NEW.user_id = '` + userID + `' THEN
+				IF nextval('` + name + `') = 1 THEN
+					RAISE EXCEPTION 'injected serialization failure' USING ERRCODE = 'serialization_failure';
+				END IF;
+			END IF;
+			RETURN NEW;
+		END $$`,
+		`CREATE TRIGGER ` + trigger + ` AFTER INSERT OR UPDATE ON arc.sessions
+			FOR EACH ROW EXECUTE FUNCTION ` + name + `()`,
+	} {
+		if _, err := pool.Exec(ctx, stmt); err != nil {
+			t.Fatalf("inject serialization failure: %v", err)
+		}
+	}
+	t.Cleanup(func() {
+		_, _ = pool.Exec(ctx, `DROP TRIGGER IF EXISTS `+trigger+` ON arc.sessions`)
+		_, _ = pool.Exec(ctx, `DROP FUNCTION IF EXISTS `+name+`()`)
+		_, _ = pool.Exec(ctx, `DROP SEQUENCE IF EXISTS `+name)
+	})
+}
+
 func mustGetSessionByID(ctx context.Context, t *testing.T, pool *pgxpool.Pool, sessionID string) Row {
 	t.Helper()
 
@@ -587,3 +1103,12 @@ func mustGetSessionByID(ctx context.Context, t *testing.T, pool *pgxpool.Pool, s
 	}
 	return row
 }
+
+func newTestPostgresStore(t testing.TB, pool *pgxpool.Pool) *PostgresStore {
+	t.Helper()
+	store, err := NewPostgresStore(pool)
+	if err != nil {
+		t.Fatalf("NewPostgresStore: %v", err)
+	}
+	return store
+}
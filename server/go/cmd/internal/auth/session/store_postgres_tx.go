@@ -16,17 +16,19 @@ func hashRefreshTokenHex(s string) string {
 	return token.HashRefreshTokenHex(s)
 }
 
-func getByRefreshHashForUpdateTx(ctx context.Context, tx pgx.Tx, refreshHash string) (Row, error) {
+func getByRefreshHashForUpdateTx(ctx context.Context, tx pgx.Tx, schema string, refreshHash string) (Row, error) {
 	var row Row
 
 	err := tx.QueryRow(ctx, `
 		SELECT
-			id, user_id, refresh_token_hash,
-			created_at, last_used_at, expires_at, revoked_at,
-			replaced_by_session_id, platform
-		FROM arc.sessions
-		WHERE refresh_token_hash = $1
-		FOR UPDATE
+			s.id, s.user_id, s.refresh_token_hash,
+			s.created_at, s.last_used_at, s.expires_at, s.revoked_at,
+			s.replaced_by_session_id, s.platform, u.locked_at,
+			s.binding_key, s.binding_nonce, s.access_renewed_at
+		FROM `+pgIdent(schema, "sessions")+` s
+		JOIN `+pgIdent(schema, "users")+` u ON u.id = s.user_id
+		WHERE s.refresh_token_hash = $1
+		FOR UPDATE OF s
 	`, refreshHash).Scan(
 		&row.ID,
 		&row.UserID,
@@ -37,6 +39,10 @@ func getByRefreshHashForUpdateTx(ctx context.Context, tx pgx.Tx, refreshHash str
 		&row.RevokedAt,
 		&row.ReplacedBySessionID,
 		&row.Platform,
+		&row.UserLockedAt,
+		&row.BindingKey,
+		&row.BindingNonce,
+		&row.AccessRenewedAt,
 	)
 
 	if errors.Is(err, pgx.ErrNoRows) {
@@ -52,11 +58,13 @@ func getByRefreshHashForUpdateTx(ctx context.Context, tx pgx.Tx, refreshHash str
 func createTx(
 	ctx context.Context,
 	tx pgx.Tx,
+	schema string,
 	now time.Time,
 	userID string,
 	dev DeviceContext,
 	refreshHash string,
 	expiresAt time.Time,
+	bindingNonce string,
 ) (string, error) {
 	id := ulid.Make().String()
 
@@ -66,16 +74,20 @@ func createTx(
 	}
 
 	_, err := tx.Exec(ctx, `
-		INSERT INTO arc.sessions (
+		INSERT INTO `+pgIdent(schema, "sessions")+` (
 			id, user_id, refresh_token_hash,
 			created_at, last_used_at, expires_at, revoked_at,
-			replaced_by_session_id, user_agent, ip, platform, revocation_reason
+			replaced_by_session_id, user_agent, ip, platform, revocation_reason,
+			geo_country, geo_city, geo_asn, binding_key, binding_nonce
 		) VALUES (
 			$1, $2, $3,
 			$4, $4, $5, NULL,
-			NULL, $6, $7, $8, NULL
+			NULL, $6, $7, $8, NULL,
+			$9, $10, $11, $12, $13
 		)
-	`, id, userID, refreshHash, now, expiresAt, nullIfEmpty(dev.UserAgent), ip, string(dev.Platform))
+	`, id, userID, refreshHash, now, expiresAt, nullIfEmpty(dev.UserAgent), ip, string(dev.Platform),
+		nullIfEmpty(dev.Geo.Country), nullIfEmpty(dev.Geo.City), nullIfZeroASN(dev.Geo.ASN),
+		nullIfEmptyBytes(dev.BindingKey), nullIfEmpty(bindingNonce))
 	if err != nil {
 		return "", err
 	}
@@ -83,9 +95,9 @@ func createTx(
 	return id, nil
 }
 
-func markRotatedTx(ctx context.Context, tx pgx.Tx, now time.Time, oldID string, newID string) error {
+func markRotatedTx(ctx context.Context, tx pgx.Tx, schema string, now time.Time, oldID string, newID string) error {
 	_, err := tx.Exec(ctx, `
-		UPDATE arc.sessions
+		UPDATE `+pgIdent(schema, "sessions")+`
 		SET
 			last_used_at = $2,
 			revoked_at = $2,
@@ -96,12 +108,40 @@ func markRotatedTx(ctx context.Context, tx pgx.Tx, now time.Time, oldID string,
 	return err
 }
 
-func revokeAllTx(ctx context.Context, tx pgx.Tx, now time.Time, userID string) error {
+func markAccessRenewedTx(ctx context.Context, tx pgx.Tx, schema string, now time.Time, sessionID string, bindingNonce string) error {
+	_, err := tx.Exec(ctx, `
+		UPDATE `+pgIdent(schema, "sessions")+`
+		SET
+			last_used_at = $2,
+			access_renewed_at = $2,
+			binding_nonce = COALESCE($3, binding_nonce)
+		WHERE id = $1
+	`, sessionID, now, nullIfEmpty(bindingNonce))
+	return err
+}
+
+func revokeTx(ctx context.Context, tx pgx.Tx, schema string, now time.Time, sessionID string, reason string) error {
 	_, err := tx.Exec(ctx, `
-		UPDATE arc.sessions
+		UPDATE `+pgIdent(schema, "sessions")+`
 		SET revoked_at = COALESCE(revoked_at, $2),
-		    revocation_reason = COALESCE(revocation_reason, 'reuse_detected')
+		    revocation_reason = COALESCE(revocation_reason, $3)
+		WHERE id = $1
+	`, sessionID, now, reason)
+	return err
+}
+
+// revokeAllTx revokes every session of userID after reuse detection and returns how
+// many were still active.
+func revokeAllTx(ctx context.Context, tx pgx.Tx, schema string, now time.Time, userID string) (int64, error) {
+	tag, err := tx.Exec(ctx, `
+		UPDATE `+pgIdent(schema, "sessions")+`
+		SET revoked_at = $2,
+		    revocation_reason = 'reuse_detected'
 		WHERE user_id = $1
+		  AND revoked_at IS NULL
 	`, userID, now)
-	return err
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
 }
@@ -1,6 +1,8 @@
 package session
 
 import (
+	"slices"
+	"strings"
 	"time"
 
 	paseto "aidanwoods.dev/go-paseto"
@@ -13,18 +15,34 @@ type AccessClaims struct {
 	ExpiresAt time.Time
 	IssuedAt  time.Time
 	Issuer    string
+	Audience  string
+
+	// Scopes and Actor are set only on delegated tokens (see ExchangeToken):
+	// Actor is the client that obtained the token on the user's behalf.
+	Scopes []string
+	Actor  string
+}
+
+// Delegated reports whether the claims come from a token exchange.
+func (c AccessClaims) Delegated() bool {
+	return c.Actor != ""
 }
 
 // AccessTokenManager issues and verifies short-lived access tokens.
 type AccessTokenManager interface {
 	Issue(userID, sessionID string, now time.Time) (token string, exp time.Time, err error)
+	// IssueDelegated signs a delegated token for claims, which expires at claims.ExpiresAt.
+	IssueDelegated(claims AccessClaims, now time.Time) (token string, err error)
 	Verify(token string, now time.Time) (AccessClaims, error)
 	PublicKeyHex() string
 }
 
 type pasetoV4PublicManager struct {
 	issuer    string
+	audience  string
+	audiences []string
 	ttl       time.Duration
+	resumeTTL time.Duration
 	clockSkew time.Duration
 
 	secret paseto.V4AsymmetricSecretKey
@@ -33,19 +51,27 @@ type pasetoV4PublicManager struct {
 
 // NewPasetoV4PublicManager builds an AccessTokenManager based on PASETO v4.public.
 //
-// It uses an Ed25519 asymmetric keypair and enforces issuer and expiration rules.
-// Clock skew is applied during verification via ValidAt to tolerate minor clock differences.
+// It uses an Ed25519 asymmetric keypair and enforces issuer, audience and expiration
+// rules. Clock skew is applied during verification to tolerate minor clock differences.
+// An empty AllowedAudiences accepts only cfg.Audience.
 func NewPasetoV4PublicManager(cfg Config) (AccessTokenManager, error) {
 	secret, err := paseto.NewV4AsymmetricSecretKeyFromHex(cfg.PasetoV4SecretKeyHex)
 	if err != nil {
 		return nil, ErrConfig
 	}
+	audiences := cfg.AllowedAudiences
+	if len(audiences) == 0 {
+		audiences = []string{cfg.Audience}
+	}
 
 	public := secret.Public()
 
 	return &pasetoV4PublicManager{
 		issuer:    cfg.Issuer,
+		audience:  cfg.Audience,
+		audiences: audiences,
 		ttl:       cfg.AccessTokenTTL,
+		resumeTTL: cfg.ResumeTokenTTL,
 		clockSkew: cfg.ClockSkew,
 		secret:    secret,
 		public:    public,
@@ -61,6 +87,7 @@ func (m *pasetoV4PublicManager) Issue(userID, sessionID string, now time.Time) (
 
 	tok := paseto.NewToken()
 	tok.SetIssuer(m.issuer)
+	tok.SetAudience(m.audience)
 	tok.SetIssuedAt(now)
 	tok.SetNotBefore(now) // Access tokens valid immediately.
 	tok.SetExpiration(exp)
@@ -73,41 +100,99 @@ func (m *pasetoV4PublicManager) Issue(userID, sessionID string, now time.Time) (
 	return signed, exp, nil
 }
 
+func (m *pasetoV4PublicManager) IssueDelegated(claims AccessClaims, now time.Time) (string, error) {
+	if claims.UserID == "" || claims.SessionID == "" || claims.Actor == "" || claims.Audience == "" ||
+		!claims.ExpiresAt.After(now) {
+		return "", ErrInvalidToken
+	}
+
+	tok := paseto.NewToken()
+	tok.SetIssuer(m.issuer)
+	tok.SetIssuedAt(now)
+	tok.SetNotBefore(now)
+	tok.SetExpiration(claims.ExpiresAt)
+	tok.SetAudience(claims.Audience)
+
+	_ = tok.Set("uid", claims.UserID)
+	_ = tok.Set("sid", claims.SessionID)
+	// RFC 8693 claims: space-delimited "scope" and the acting party in "act".
+	if len(claims.Scopes) > 0 {
+		_ = tok.Set("scope", strings.Join(claims.Scopes, " "))
+	}
+	_ = tok.Set("act", map[string]string{"sub": claims.Actor})
+
+	return tok.V4Sign(m.secret, nil), nil
+}
+
 func (m *pasetoV4PublicManager) Verify(token string, now time.Time) (AccessClaims, error) {
 	// Clock-skew tolerance:
 	// Validate slightly in the future to avoid failing "nbf" when clocks differ.
 	// This also makes expiration checks slightly stricter, which is typically desirable.
 	validNow := now.Add(m.clockSkew)
 
-	// Build a fresh parser per call to avoid accumulating rules across verifies.
-	p := paseto.NewParser()
-	p.AddRule(paseto.IssuedBy(m.issuer))
-	p.AddRule(paseto.NotExpired())
-	p.AddRule(paseto.ValidAt(validNow))
-
+	// Registered claims are checked by hand rather than with parser rules so the
+	// failure reason survives into TokenError.
+	p := paseto.NewParserWithoutExpiryCheck()
 	parsed, err := p.ParseV4Public(m.public, token, nil)
 	if err != nil {
-		return AccessClaims{}, ErrInvalidToken
+		return AccessClaims{}, TokenError{Reason: TokenReasonSignature}
 	}
 
 	iss, _ := parsed.GetIssuer()
-	exp, _ := parsed.GetExpiration()
-	iat, _ := parsed.GetIssuedAt()
+	if iss != m.issuer {
+		return AccessClaims{}, TokenError{Reason: TokenReasonIssuer}
+	}
+	aud, _ := parsed.GetAudience()
+	if aud == "" || !slices.Contains(m.audiences, aud) {
+		return AccessClaims{}, TokenError{Reason: TokenReasonAudience}
+	}
+	exp, err := parsed.GetExpiration()
+	if err != nil {
+		return AccessClaims{}, TokenError{Reason: TokenReasonClaims}
+	}
+	if !exp.After(validNow) {
+		return AccessClaims{}, TokenError{Reason: TokenReasonExpired}
+	}
+	iat, err := parsed.GetIssuedAt()
+	if err != nil {
+		return AccessClaims{}, TokenError{Reason: TokenReasonClaims}
+	}
+	nbf, err := parsed.GetNotBefore()
+	if err != nil {
+		return AccessClaims{}, TokenError{Reason: TokenReasonClaims}
+	}
+	if iat.After(validNow) || nbf.After(validNow) {
+		return AccessClaims{}, TokenError{Reason: TokenReasonNotYetValid}
+	}
 
 	uid, err := parsed.GetString("uid")
 	if err != nil || uid == "" {
-		return AccessClaims{}, ErrInvalidToken
+		return AccessClaims{}, TokenError{Reason: TokenReasonClaims}
 	}
 	sid, err := parsed.GetString("sid")
 	if err != nil || sid == "" {
-		return AccessClaims{}, ErrInvalidToken
+		return AccessClaims{}, TokenError{Reason: TokenReasonClaims}
 	}
 
-	return AccessClaims{
+	out := AccessClaims{
 		UserID:    uid,
 		SessionID: sid,
 		ExpiresAt: exp,
 		IssuedAt:  iat,
 		Issuer:    iss,
-	}, nil
+		Audience:  aud,
+	}
+	var act struct {
+		Sub string `json:"sub"`
+	}
+	if err := parsed.Get("act", &act); err == nil {
+		if act.Sub == "" {
+			return AccessClaims{}, TokenError{Reason: TokenReasonClaims}
+		}
+		out.Actor = act.Sub
+		if scope, err := parsed.GetString("scope"); err == nil {
+			out.Scopes = strings.Fields(scope)
+		}
+	}
+	return out, nil
 }
@@ -0,0 +1,104 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"arc/cmd/internal/dbtrace"
+	"arc/cmd/security/token"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// MaxWSTicketTTL bounds ARC_AUTH_WS_TICKET_TTL: tickets travel in URLs and may be logged.
+	MaxWSTicketTTL = 5 * time.Minute
+
+	// wsTicketBytes is the entropy of a ticket.
+	wsTicketBytes = 32
+	// maxWSTicketLen rejects oversized input before hashing (43 base64url characters).
+	maxWSTicketLen = 64
+	// wsTicketRetention keeps expired tickets around briefly before they are pruned.
+	wsTicketRetention = "1 hour"
+)
+
+// WSTicket is a one-time credential for a WebSocket upgrade.
+type WSTicket struct {
+	Ticket    string
+	ExpiresAt time.Time
+}
+
+// IssueWSTicket issues a single-use ticket that authenticates one WebSocket upgrade
+// as sessionID, for clients that cannot send an Authorization header there. Only
+// the ticket's SHA-256 is stored; expired tickets are pruned on the way.
+func (s *Service) IssueWSTicket(ctx context.Context, now time.Time, userID, sessionID string) (WSTicket, error) {
+	ctx = dbtrace.WithOp(ctx, "session.issue_ws_ticket")
+	if s.pool == nil {
+		return WSTicket{}, errors.New("session: nil pool")
+	}
+	b := make([]byte, wsTicketBytes)
+	if _, err := rand.Read(b); err != nil {
+		return WSTicket{}, err
+	}
+	plain := base64.RawURLEncoding.EncodeToString(b)
+	exp := now.Add(s.wsTicketTTL())
+
+	tickets := pgIdent(s.schema, "ws_tickets")
+	_, err := s.pool.Exec(ctx, `
+		WITH pruned AS (
+			DELETE FROM `+tickets+` WHERE expires_at < $4::timestamptz - $6::interval
+		)
+		INSERT INTO `+tickets+` (ticket_hash, user_id, session_id, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, token.HashSHA256Hex(plain), userID, sessionID, now, exp, wsTicketRetention)
+	if err != nil {
+		return WSTicket{}, err
+	}
+	return WSTicket{Ticket: plain, ExpiresAt: exp}, nil
+}
+
+// RedeemWSTicket consumes ticket and returns the claims of its session, which must
+// still be active. Unknown, used and expired tickets fail with a TokenError.
+func (s *Service) RedeemWSTicket(ctx context.Context, now time.Time, ticket string) (AccessClaims, error) {
+	ctx = dbtrace.WithOp(ctx, "session.redeem_ws_ticket")
+	ticket = strings.TrimSpace(ticket)
+	if ticket == "" || len(ticket) > maxWSTicketLen {
+		return AccessClaims{}, TokenError{Reason: TokenReasonWSTicket}
+	}
+	if s.pool == nil {
+		return AccessClaims{}, errors.New("session: nil pool")
+	}
+
+	var claims AccessClaims
+	err := s.pool.QueryRow(ctx, `
+		UPDATE `+pgIdent(s.schema, "ws_tickets")+`
+		SET consumed_at = $2
+		WHERE ticket_hash = $1
+		  AND consumed_at IS NULL
+		  AND expires_at > $2
+		RETURNING user_id, session_id, created_at, expires_at
+	`, token.HashSHA256Hex(ticket), now).Scan(&claims.UserID, &claims.SessionID, &claims.IssuedAt, &claims.ExpiresAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return AccessClaims{}, TokenError{Reason: TokenReasonWSTicket}
+	}
+	if err != nil {
+		return AccessClaims{}, err
+	}
+
+	if err := s.checkSession(ctx, claims.UserID, claims.SessionID, now, false); err != nil {
+		return AccessClaims{}, err
+	}
+	claims.Issuer, claims.Audience = s.cfg.Issuer, s.cfg.Audience
+	return claims, nil
+}
+
+func (s *Service) wsTicketTTL() time.Duration {
+	if s.cfg.WSTicketTTL <= 0 {
+		return DefaultConfig().WSTicketTTL
+	}
+	return min(s.cfg.WSTicketTTL, MaxWSTicketTTL)
+}
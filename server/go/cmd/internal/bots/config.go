@@ -0,0 +1,87 @@
+package bots
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config controls bot webhooks.
+type Config struct {
+	// Enabled turns on bot registration and command delivery.
+	Enabled bool
+
+	// WebhookTimeout bounds a single webhook delivery attempt.
+	WebhookTimeout time.Duration
+	// MaxAttempts bounds deliveries per command, retrying network errors and 5xx responses.
+	MaxAttempts int
+	// QueueSize bounds commands waiting for delivery; further commands are dropped.
+	QueueSize int
+	// Workers is the number of concurrent delivery loops.
+	Workers int
+
+	// AllowPrivateWebhooks permits http:// webhooks and loopback or private
+	// network addresses. Only meant for local development.
+	AllowPrivateWebhooks bool
+}
+
+// DefaultConfig returns the bot config used when no ARC_BOTS_* variable is set;
+// bots are disabled.
+func DefaultConfig() Config {
+	return Config{
+		WebhookTimeout: 5 * time.Second,
+		MaxAttempts:    3,
+		QueueSize:      256,
+		Workers:        4,
+	}
+}
+
+// LoadConfigFromEnv loads bot config from environment variables with safe defaults.
+func LoadConfigFromEnv() Config {
+	d := DefaultConfig()
+	return Config{
+		Enabled:              envBool("ARC_BOTS_ENABLED", d.Enabled),
+		WebhookTimeout:       envDuration("ARC_BOTS_WEBHOOK_TIMEOUT", d.WebhookTimeout),
+		MaxAttempts:          envInt("ARC_BOTS_WEBHOOK_MAX_ATTEMPTS", d.MaxAttempts),
+		QueueSize:            envInt("ARC_BOTS_QUEUE_SIZE", d.QueueSize),
+		Workers:              envInt("ARC_BOTS_WORKERS", d.Workers),
+		AllowPrivateWebhooks: envBool("ARC_BOTS_ALLOW_PRIVATE_WEBHOOKS", d.AllowPrivateWebhooks),
+	}
+}
+
+func envInt(key string, def int) int {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		return def
+	}
+	return n
+}
+
+func envDuration(key string, def time.Duration) time.Duration {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		return def
+	}
+	return d
+}
+
+func envBool(key string, def bool) bool {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return def
+	}
+	return b
+}
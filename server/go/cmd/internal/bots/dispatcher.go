@@ -0,0 +1,216 @@
+package bots
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"arc/cmd/internal/realtime"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// commandRE matches a slash command: "/" followed by 1-32 lowercase letters, digits, '_' or '-'.
+var commandRE = regexp.MustCompile(`^/[a-z0-9_-]{1,32}$`)
+
+// retryBackoff is the wait before the second delivery attempt; it doubles per attempt.
+const retryBackoff = 500 * time.Millisecond
+
+// dispatchStore is the subset of PostgresStore the Dispatcher needs.
+type dispatchStore interface {
+	commandBot(ctx context.Context, conversationID, command string) (Bot, error)
+}
+
+// webhookEvent is the JSON body POSTed to a bot's webhook.
+type webhookEvent struct {
+	Type           string    `json:"type"`
+	BotID          string    `json:"bot_id"`
+	ConversationID string    `json:"conversation_id"`
+	ServerMsgID    string    `json:"server_msg_id"`
+	Seq            int64     `json:"seq"`
+	UserID         string    `json:"user_id"`
+	Command        string    `json:"command"`
+	Args           string    `json:"args"`
+	Text           string    `json:"text"`
+	TS             time.Time `json:"ts"`
+}
+
+// Dispatcher delivers slash commands to bot webhooks. It implements
+// realtime.CommandDispatcher.
+type Dispatcher struct {
+	log    *slog.Logger
+	store  dispatchStore
+	client *http.Client
+	now    func() time.Time
+
+	queue       chan realtime.CommandMessage
+	workers     int
+	maxAttempts int
+	backoff     time.Duration
+}
+
+// NewDispatcher constructs a Dispatcher.
+func NewDispatcher(log *slog.Logger, pool *pgxpool.Pool, cfg Config) (*Dispatcher, error) {
+	store, err := NewPostgresStore(pool)
+	if err != nil {
+		return nil, err
+	}
+	return newDispatcher(log, store, newWebhookClient(cfg.WebhookTimeout, cfg.AllowPrivateWebhooks), cfg), nil
+}
+
+func newDispatcher(log *slog.Logger, store dispatchStore, client *http.Client, cfg Config) *Dispatcher {
+	if log == nil {
+		log = slog.Default()
+	}
+	return &Dispatcher{
+		log:         log,
+		store:       store,
+		client:      client,
+		now:         time.Now,
+		queue:       make(chan realtime.CommandMessage, max(cfg.QueueSize, 1)),
+		workers:     max(cfg.Workers, 1),
+		maxAttempts: max(cfg.MaxAttempts, 1),
+		backoff:     retryBackoff,
+	}
+}
+
+// DispatchCommand queues msg without blocking; it is dropped when the queue is full.
+func (d *Dispatcher) DispatchCommand(msg realtime.CommandMessage) {
+	select {
+	case d.queue <- msg:
+	default:
+		d.log.Warn("bots.queue.full", "conversation_id", msg.ConversationID, "server_msg_id", msg.ServerMsgID, "result", "dropped")
+	}
+}
+
+// Run delivers queued commands until ctx is done and then drains the queue, so
+// commands accepted before shutdown still reach their bots.
+func (d *Dispatcher) Run(ctx context.Context) {
+	done := make(chan struct{})
+	for range d.workers {
+		go func() {
+			defer func() { done <- struct{}{} }()
+			for {
+				select {
+				case <-ctx.Done():
+					d.flush(context.WithoutCancel(ctx))
+					return
+				case msg := <-d.queue:
+					d.deliver(context.WithoutCancel(ctx), msg)
+				}
+			}
+		}()
+	}
+	for range d.workers {
+		<-done
+	}
+}
+
+// flush handles what is left in the queue when Run is stopped.
+func (d *Dispatcher) flush(ctx context.Context) {
+	for {
+		select {
+		case msg := <-d.queue:
+			d.deliver(ctx, msg)
+		default:
+			return
+		}
+	}
+}
+
+// parseCommand splits text into a lowercase command and its trimmed arguments.
+func parseCommand(text string) (command, args string, ok bool) {
+	text = strings.TrimSpace(text)
+	command, args, _ = strings.Cut(text, " ")
+	if i := strings.IndexAny(command, "\t\n"); i >= 0 {
+		command, args = command[:i], text[i+1:]
+	}
+	command = strings.ToLower(command)
+	if !commandRE.MatchString(command) {
+		return "", "", false
+	}
+	return command, strings.TrimSpace(args), true
+}
+
+// deliver posts msg to the bot registered for its command, if any.
+func (d *Dispatcher) deliver(ctx context.Context, msg realtime.CommandMessage) {
+	command, args, ok := parseCommand(msg.Text)
+	if !ok {
+		return
+	}
+	bot, err := d.store.commandBot(ctx, msg.ConversationID, command)
+	if errors.Is(err, errBotNotFound) {
+		return
+	}
+	if err != nil {
+		d.log.Error("bots.lookup.fail", "conversation_id", msg.ConversationID, "err", err)
+		return
+	}
+
+	body, err := json.Marshal(webhookEvent{
+		Type:           "command",
+		BotID:          bot.ID,
+		ConversationID: msg.ConversationID,
+		ServerMsgID:    msg.ServerMsgID,
+		Seq:            msg.Seq,
+		UserID:         msg.SenderUserID,
+		Command:        command,
+		Args:           args,
+		Text:           msg.Text,
+		TS:             msg.ServerTS,
+	})
+	if err != nil {
+		return
+	}
+
+	backoff := d.backoff
+	for attempt := 1; ; attempt++ {
+		retry, err := d.post(ctx, bot, body)
+		if err == nil {
+			return
+		}
+		if !retry || attempt >= d.maxAttempts {
+			d.log.Warn("bots.webhook.fail", "bot_id", bot.ID, "conversation_id", msg.ConversationID, "attempts", attempt, "err", err)
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// post sends one signed delivery. retry reports whether a failure is transient.
+func (d *Dispatcher) post(ctx context.Context, bot Bot, body []byte) (retry bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, bot.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	ts := d.now().UTC()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "arc-bots/1")
+	req.Header.Set(HeaderBotID, bot.ID)
+	req.Header.Set(HeaderTimestamp, fmt.Sprint(ts.Unix()))
+	req.Header.Set(HeaderSignature, Sign(bot.WebhookSecret, ts, body))
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return !errors.Is(err, errPrivateAddress), err
+	}
+	_ = res.Body.Close()
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return false, nil
+	}
+	return res.StatusCode >= 500 || res.StatusCode == http.StatusTooManyRequests, fmt.Errorf("webhook status %d", res.StatusCode)
+}
+
+var _ realtime.CommandDispatcher = (*Dispatcher)(nil)
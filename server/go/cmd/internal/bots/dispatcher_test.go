@@ -0,0 +1,137 @@
+package bots
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"arc/cmd/internal/realtime"
+)
+
+type fakeDispatchStore struct {
+	bots map[string]Bot
+}
+
+func (s fakeDispatchStore) commandBot(_ context.Context, conversationID, command string) (Bot, error) {
+	b, ok := s.bots[conversationID+" "+command]
+	if !ok {
+		return Bot{}, errBotNotFound
+	}
+	return b, nil
+}
+
+func TestParseCommand(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		text, command, args string
+		ok                  bool
+	}{
+		{text: "/remind me in 5m", command: "/remind", args: "me in 5m", ok: true},
+		{text: "/Remind", command: "/remind", ok: true},
+		{text: "/poll\tlunch?", command: "/poll", args: "lunch?", ok: true},
+		{text: "/", ok: false},
+		{text: "/not.a.command", ok: false},
+		{text: "hello /remind", ok: false},
+	}
+	for _, tc := range cases {
+		command, args, ok := parseCommand(tc.text)
+		if ok != tc.ok || command != tc.command || args != tc.args {
+			t.Fatalf("parseCommand(%q) = %q, %q, %v", tc.text, command, args, ok)
+		}
+	}
+}
+
+func TestDispatcherDeliverSigned(t *testing.T) {
+	t.Parallel()
+
+	const secret = "s3cret"
+	var got webhookEvent
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		sec, _ := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+		want := Sign(secret, time.Unix(sec, 0), body)
+		if !hmac.Equal([]byte(r.Header.Get(HeaderSignature)), []byte(want)) || r.Header.Get(HeaderBotID) != "b1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.Unmarshal(body, &got)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	store := fakeDispatchStore{bots: map[string]Bot{
+		"c1 /remind": {ID: "b1", ConversationID: "c1", WebhookURL: srv.URL, WebhookSecret: secret},
+	}}
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	d := newDispatcher(log, store, srv.Client(), Config{MaxAttempts: 1})
+
+	d.deliver(context.Background(), realtime.CommandMessage{
+		ConversationID: "c1",
+		ServerMsgID:    "m1",
+		Seq:            7,
+		SenderUserID:   "u1",
+		Text:           "/remind me later",
+	})
+	if got.Type != "command" || got.BotID != "b1" || got.Command != "/remind" || got.Args != "me later" || got.UserID != "u1" || got.Seq != 7 {
+		t.Fatalf("unexpected delivery %+v", got)
+	}
+}
+
+func TestDispatcherRetries(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	store := fakeDispatchStore{bots: map[string]Bot{
+		"c1 /poll": {ID: "b1", ConversationID: "c1", WebhookURL: srv.URL, WebhookSecret: "x"},
+	}}
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	d := newDispatcher(log, store, srv.Client(), Config{MaxAttempts: 3})
+	d.backoff = time.Millisecond
+
+	d.deliver(context.Background(), realtime.CommandMessage{ConversationID: "c1", Text: "/poll"})
+	if n := calls.Load(); n != 3 {
+		t.Fatalf("expected 3 attempts, got %d", n)
+	}
+
+	calls.Store(10)
+	d.deliver(context.Background(), realtime.CommandMessage{ConversationID: "c1", Text: "/unknown"})
+	if n := calls.Load(); n != 10 {
+		t.Fatalf("expected unregistered commands to be skipped")
+	}
+}
+
+func TestWebhookClientRejectsPrivateAddresses(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	if _, err := newWebhookClient(time.Second, false).Get(srv.URL); err == nil {
+		t.Fatalf("expected loopback webhook to be refused")
+	}
+	res, err := newWebhookClient(time.Second, true).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("expected loopback webhook to be allowed: %v", err)
+	}
+	_ = res.Body.Close()
+}
@@ -0,0 +1,11 @@
+// Package bots connects conversations to external integrations over webhooks.
+//
+// A conversation owner or admin registers a bot with POST /conversations/{id}/bots,
+// naming the slash commands it handles (e.g. /remind) and the HTTPS webhook that
+// receives them. Registration returns a bot token, scoped to that conversation,
+// and a webhook secret; both are shown once. When a member sends a message whose
+// first word is one of the bot's commands, the Dispatcher POSTs it to the webhook
+// signed with HMAC-SHA256 over the timestamp and body. The bot replies through
+// POST /bots/messages with its token; replies are attributed to the bot with
+// sender_bot_id instead of a session.
+package bots
@@ -0,0 +1,482 @@
+package bots
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"arc/cmd/internal/auth/session"
+	"arc/cmd/internal/httpjson"
+	"arc/cmd/internal/realtime"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oklog/ulid/v2"
+)
+
+const (
+	conversationBotsPath = "/conversations/{id}/bots"
+	conversationBotPath  = "/conversations/{id}/bots/{bot_id}"
+	botMessagesPath      = "/bots/messages"
+	botMessageBatchPath  = "/bots/messages/batch"
+
+	requestMaxBodyBytes = 16 << 10 // 16 KiB
+	batchMaxBodyBytes   = 1 << 20  // 1 MiB
+	maxBotNameLen       = 64
+	maxBotCommands      = 20
+
+	// botTokenPrefix marks bot API tokens so they are recognisable in logs and secret scanners.
+	botTokenPrefix = "arcb_"
+)
+
+// TokenValidator validates bearer access tokens.
+type TokenValidator interface {
+	ValidateAccessToken(ctx context.Context, token string, now time.Time) (session.AccessClaims, error)
+}
+
+// MemberRoles looks up conversation roles for authorization.
+type MemberRoles interface {
+	GetMemberRole(ctx context.Context, userID, conversationID string) (string, error)
+}
+
+// MessagePoster stores and fans out bot replies; *realtime.WSGateway implements it.
+type MessagePoster interface {
+	PostBotMessage(ctx context.Context, in realtime.BotMessageInput) (realtime.AppendMessageResult, error)
+	PostBotMessages(ctx context.Context, in []realtime.BotMessageInput) ([]realtime.AppendMessageResult, error)
+}
+
+// botStore is the subset of PostgresStore the Handler needs.
+type botStore interface {
+	create(ctx context.Context, b Bot, tokenHash string) error
+	list(ctx context.Context, conversationID string) ([]Bot, error)
+	delete(ctx context.Context, conversationID, botID string) (bool, error)
+	byTokenHash(ctx context.Context, tokenHash string) (Bot, error)
+}
+
+// Handler serves bot registration and the bot message API.
+type Handler struct {
+	log     *slog.Logger
+	cfg     Config
+	store   botStore
+	tokens  TokenValidator
+	members MemberRoles
+	poster  MessagePoster
+	now     func() time.Time
+}
+
+type createBotRequest struct {
+	Name       string   `json:"name"`
+	WebhookURL string   `json:"webhook_url"`
+	Commands   []string `json:"commands"`
+}
+
+type botResponse struct {
+	BotID          string    `json:"bot_id"`
+	ConversationID string    `json:"conversation_id"`
+	Name           string    `json:"name"`
+	WebhookURL     string    `json:"webhook_url"`
+	Commands       []string  `json:"commands"`
+	CreatedBy      string    `json:"created_by,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type createBotResponse struct {
+	botResponse
+	// Token and WebhookSecret are only returned on creation.
+	Token         string `json:"token"`
+	WebhookSecret string `json:"webhook_secret"`
+}
+
+type listBotsResponse struct {
+	Bots []botResponse `json:"bots"`
+}
+
+type botMessageRequest struct {
+	ClientMsgID        string `json:"client_msg_id"`
+	Text               string `json:"text"`
+	ReplyToServerMsgID string `json:"reply_to_server_msg_id,omitempty"`
+}
+
+type botMessageResponse struct {
+	ConversationID string `json:"conversation_id"`
+	ServerMsgID    string `json:"server_msg_id"`
+	Seq            int64  `json:"seq"`
+	ClientMsgID    string `json:"client_msg_id"`
+	Duplicated     bool   `json:"duplicated,omitempty"`
+}
+
+type botMessageBatchRequest struct {
+	Messages []botMessageRequest `json:"messages"`
+}
+
+type botMessageBatchResponse struct {
+	Messages []botMessageResponse `json:"messages"`
+}
+
+// NewHandler constructs a bots Handler.
+func NewHandler(log *slog.Logger, pool *pgxpool.Pool, cfg Config, tokens TokenValidator, members MemberRoles, poster MessagePoster) (*Handler, error) {
+	if tokens == nil || members == nil || poster == nil {
+		return nil, errors.New("bots: missing token validator, member store or message poster")
+	}
+	store, err := NewPostgresStore(pool)
+	if err != nil {
+		return nil, err
+	}
+	return newHandler(log, store, cfg, tokens, members, poster), nil
+}
+
+func newHandler(log *slog.Logger, store botStore, cfg Config, tokens TokenValidator, members MemberRoles, poster MessagePoster) *Handler {
+	if log == nil {
+		log = slog.Default()
+	}
+	return &Handler{log: log, cfg: cfg, store: store, tokens: tokens, members: members, poster: poster, now: time.Now}
+}
+
+// Register wires bot routes onto the provided mux.
+func (h *Handler) Register(mux *http.ServeMux) {
+	if h == nil || mux == nil {
+		return
+	}
+	mux.HandleFunc(conversationBotsPath, h.handleConversationBots)
+	mux.HandleFunc(conversationBotPath, h.handleDeleteBot)
+	mux.HandleFunc(botMessagesPath, h.handleBotMessage)
+	mux.HandleFunc(botMessageBatchPath, h.handleBotMessageBatch)
+}
+
+// handleConversationBots serves GET (any member) and POST (owner or admin)
+// /conversations/{id}/bots.
+func (h *Handler) handleConversationBots(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		w.Header().Set("Allow", "GET, POST")
+		httpjson.WriteError(w, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+	claims, ok := h.requireAuth(w, r)
+	if !ok {
+		return
+	}
+	convID := strings.TrimSpace(r.PathValue("id"))
+	if !h.authorize(w, r, claims.UserID, convID, r.Method == http.MethodPost) {
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		bots, err := h.store.list(r.Context(), convID)
+		if err != nil {
+			h.log.Error("bots.list.fail", "conversation_id", convID, "err", err)
+			httpjson.WriteError(w, http.StatusInternalServerError, "internal_error", "internal error")
+			return
+		}
+		resp := listBotsResponse{Bots: make([]botResponse, 0, len(bots))}
+		for _, b := range bots {
+			resp.Bots = append(resp.Bots, toBotResponse(b))
+		}
+		httpjson.Write(w, http.StatusOK, resp)
+		return
+	}
+
+	var req createBotRequest
+	if err := httpjson.Decode(w, r, requestMaxBodyBytes, &req); err != nil {
+		httpjson.WriteError(w, http.StatusBadRequest, "invalid_json", "invalid JSON body")
+		return
+	}
+	b, err := req.bot(h.cfg.AllowPrivateWebhooks)
+	if err != nil {
+		httpjson.WriteError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	token, tokenHash, err := newBotToken()
+	if err != nil {
+		httpjson.WriteError(w, http.StatusInternalServerError, "internal_error", "internal error")
+		return
+	}
+	secret, err := randomHex(32)
+	if err != nil {
+		httpjson.WriteError(w, http.StatusInternalServerError, "internal_error", "internal error")
+		return
+	}
+	b.ID = ulid.Make().String()
+	b.ConversationID = convID
+	b.CreatedBy = claims.UserID
+	b.CreatedAt = h.now().UTC()
+	b.WebhookSecret = secret
+
+	err = h.store.create(r.Context(), b, tokenHash)
+	if errors.Is(err, errCommandTaken) {
+		httpjson.WriteError(w, http.StatusConflict, "command_taken", "a command is already handled by another bot")
+		return
+	}
+	if err != nil {
+		h.log.Error("bots.create.fail", "conversation_id", convID, "err", err)
+		httpjson.WriteError(w, http.StatusInternalServerError, "internal_error", "internal error")
+		return
+	}
+	h.log.Info("bots.create", "conversation_id", convID, "bot_id", b.ID, "user_id", claims.UserID)
+	httpjson.Write(w, http.StatusCreated, createBotResponse{
+		botResponse:   toBotResponse(b),
+		Token:         token,
+		WebhookSecret: secret,
+	})
+}
+
+// handleDeleteBot serves DELETE /conversations/{id}/bots/{bot_id} (owner or admin).
+// The bot's messages stay in history.
+func (h *Handler) handleDeleteBot(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		w.Header().Set("Allow", http.MethodDelete)
+		httpjson.WriteError(w, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+	claims, ok := h.requireAuth(w, r)
+	if !ok {
+		return
+	}
+	convID := strings.TrimSpace(r.PathValue("id"))
+	if !h.authorize(w, r, claims.UserID, convID, true) {
+		return
+	}
+	botID := strings.TrimSpace(r.PathValue("bot_id"))
+	found, err := h.store.delete(r.Context(), convID, botID)
+	if err != nil {
+		h.log.Error("bots.delete.fail", "conversation_id", convID, "bot_id", botID, "err", err)
+		httpjson.WriteError(w, http.StatusInternalServerError, "internal_error", "internal error")
+		return
+	}
+	if !found {
+		httpjson.WriteError(w, http.StatusNotFound, "bot_not_found", "bot not found")
+		return
+	}
+	h.log.Info("bots.delete", "conversation_id", convID, "bot_id", botID, "user_id", claims.UserID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleBotMessage serves POST /bots/messages. It authenticates with the bot
+// token and posts into the bot's conversation as the bot.
+func (h *Handler) handleBotMessage(w http.ResponseWriter, r *http.Request) {
+	bot, ok := h.requireBot(w, r)
+	if !ok {
+		return
+	}
+
+	var req botMessageRequest
+	if err := httpjson.Decode(w, r, requestMaxBodyBytes, &req); err != nil {
+		httpjson.WriteError(w, http.StatusBadRequest, "invalid_json", "invalid JSON body")
+		return
+	}
+	res, err := h.poster.PostBotMessage(r.Context(), realtime.BotMessageInput{
+		ConversationID:     bot.ConversationID,
+		BotID:              bot.ID,
+		ClientMsgID:        req.ClientMsgID,
+		Text:               req.Text,
+		ReplyToServerMsgID: req.ReplyToServerMsgID,
+	})
+	if !h.postError(w, bot, err) {
+		return
+	}
+	httpjson.Write(w, http.StatusCreated, messageResponse(res))
+}
+
+// handleBotMessageBatch serves POST /bots/messages/batch: up to
+// realtime.MaxAppendBatch messages stored in one transaction with consecutive seqs.
+func (h *Handler) handleBotMessageBatch(w http.ResponseWriter, r *http.Request) {
+	bot, ok := h.requireBot(w, r)
+	if !ok {
+		return
+	}
+
+	var req botMessageBatchRequest
+	if err := httpjson.Decode(w, r, batchMaxBodyBytes, &req); err != nil {
+		httpjson.WriteError(w, http.StatusBadRequest, "invalid_json", "invalid JSON body")
+		return
+	}
+	in := make([]realtime.BotMessageInput, 0, len(req.Messages))
+	for _, m := range req.Messages {
+		in = append(in, realtime.BotMessageInput{
+			ConversationID:     bot.ConversationID,
+			BotID:              bot.ID,
+			ClientMsgID:        m.ClientMsgID,
+			Text:               m.Text,
+			ReplyToServerMsgID: m.ReplyToServerMsgID,
+		})
+	}
+	res, err := h.poster.PostBotMessages(r.Context(), in)
+	if !h.postError(w, bot, err) {
+		return
+	}
+	out := botMessageBatchResponse{Messages: make([]botMessageResponse, 0, len(res))}
+	for _, m := range res {
+		out.Messages = append(out.Messages, messageResponse(m))
+	}
+	httpjson.Write(w, http.StatusCreated, out)
+}
+
+// requireBot authenticates a POST with a bot token.
+func (h *Handler) requireBot(w http.ResponseWriter, r *http.Request) (Bot, bool) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		httpjson.WriteError(w, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return Bot{}, false
+	}
+	token := bearerToken(r)
+	if !strings.HasPrefix(token, botTokenPrefix) {
+		httpjson.WriteError(w, http.StatusUnauthorized, "unauthorized", "missing bot token")
+		return Bot{}, false
+	}
+	bot, err := h.store.byTokenHash(r.Context(), hashToken(token))
+	if errors.Is(err, errBotNotFound) {
+		httpjson.WriteError(w, http.StatusUnauthorized, "unauthorized", "invalid bot token")
+		return Bot{}, false
+	}
+	if err != nil {
+		h.log.Error("bots.auth.fail", "err", err)
+		httpjson.WriteError(w, http.StatusInternalServerError, "internal_error", "internal error")
+		return Bot{}, false
+	}
+	return bot, true
+}
+
+// postError writes the response for a failed post and reports whether err was nil.
+func (h *Handler) postError(w http.ResponseWriter, bot Bot, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, realtime.ErrInvalidBotMessage):
+		httpjson.WriteError(w, http.StatusBadRequest, "invalid_request", strings.TrimPrefix(err.Error(), realtime.ErrInvalidBotMessage.Error()+": "))
+	case errors.Is(err, realtime.ErrReplyTargetNotFound):
+		httpjson.WriteError(w, http.StatusBadRequest, "reply_target_not_found", "reply target not found")
+	default:
+		h.log.Error("bots.message.fail", "bot_id", bot.ID, "conversation_id", bot.ConversationID, "err", err)
+		httpjson.WriteError(w, http.StatusInternalServerError, "internal_error", "internal error")
+	}
+	return false
+}
+
+func messageResponse(res realtime.AppendMessageResult) botMessageResponse {
+	return botMessageResponse{
+		ConversationID: res.Stored.ConversationID,
+		ServerMsgID:    res.Stored.ServerMsgID,
+		Seq:            res.Stored.Seq,
+		ClientMsgID:    res.Stored.ClientMsgID,
+		Duplicated:     res.Duplicated,
+	}
+}
+
+// authorize checks membership of userID in convID. Non-members get 404 so
+// private conversations are not disclosed; manage additionally requires owner or admin.
+func (h *Handler) authorize(w http.ResponseWriter, r *http.Request, userID, convID string, manage bool) bool {
+	if convID == "" {
+		httpjson.WriteError(w, http.StatusBadRequest, "invalid_request", "conversation id is required")
+		return false
+	}
+	role, err := h.members.GetMemberRole(r.Context(), userID, convID)
+	if errors.Is(err, realtime.ErrMembershipRequired) {
+		httpjson.WriteError(w, http.StatusNotFound, "conversation_not_found", "conversation not found")
+		return false
+	}
+	if err != nil {
+		h.log.Error("bots.role.fail", "conversation_id", convID, "err", err)
+		httpjson.WriteError(w, http.StatusInternalServerError, "internal_error", "internal error")
+		return false
+	}
+	if manage && role != realtime.MemberRoleOwner && role != realtime.MemberRoleAdmin {
+		httpjson.WriteError(w, http.StatusForbidden, "forbidden", "only owners and admins can manage bots")
+		return false
+	}
+	return true
+}
+
+// bot validates the request and returns the bot it describes.
+func (req createBotRequest) bot(allowPrivate bool) (Bot, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len([]rune(name)) > maxBotNameLen {
+		return Bot{}, errors.New("name is required and must be at most 64 characters")
+	}
+	webhookURL, err := validateWebhookURL(req.WebhookURL, allowPrivate)
+	if err != nil {
+		return Bot{}, err
+	}
+	if len(req.Commands) == 0 || len(req.Commands) > maxBotCommands {
+		return Bot{}, errors.New("commands must list 1 to 20 slash commands")
+	}
+	seen := make(map[string]bool, len(req.Commands))
+	commands := make([]string, 0, len(req.Commands))
+	for _, c := range req.Commands {
+		c = strings.ToLower(strings.TrimSpace(c))
+		if !commandRE.MatchString(c) {
+			return Bot{}, errors.New("invalid command " + c + `: want "/" followed by a-z, 0-9, '_' or '-'`)
+		}
+		if !seen[c] {
+			seen[c] = true
+			commands = append(commands, c)
+		}
+	}
+	return Bot{Name: name, WebhookURL: webhookURL, Commands: commands}, nil
+}
+
+func toBotResponse(b Bot) botResponse {
+	commands := b.Commands
+	if commands == nil {
+		commands = []string{}
+	}
+	return botResponse{
+		BotID:          b.ID,
+		ConversationID: b.ConversationID,
+		Name:           b.Name,
+		WebhookURL:     b.WebhookURL,
+		Commands:       commands,
+		CreatedBy:      b.CreatedBy,
+		CreatedAt:      b.CreatedAt.UTC(),
+	}
+}
+
+// newBotToken returns a bot API token and the hash stored in its place.
+func newBotToken() (token, hash string, err error) {
+	var b [32]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", "", err
+	}
+	token = botTokenPrefix + base64.RawURLEncoding.EncodeToString(b[:])
+	return token, hashToken(token), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func (h *Handler) requireAuth(w http.ResponseWriter, r *http.Request) (session.AccessClaims, bool) {
+	token := bearerToken(r)
+	if token == "" {
+		httpjson.WriteError(w, http.StatusUnauthorized, "unauthorized", "missing bearer token")
+		return session.AccessClaims{}, false
+	}
+	claims, err := h.tokens.ValidateAccessToken(r.Context(), token, h.now().UTC())
+	if err != nil || strings.TrimSpace(claims.UserID) == "" {
+		httpjson.WriteError(w, http.StatusUnauthorized, "unauthorized", "invalid token")
+		return session.AccessClaims{}, false
+	}
+	return claims, true
+}
+
+func bearerToken(r *http.Request) string {
+	raw := strings.TrimSpace(r.Header.Get("Authorization"))
+	parts := strings.SplitN(raw, " ", 2)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(parts[1])
+}
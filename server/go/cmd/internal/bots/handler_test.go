@@ -0,0 +1,255 @@
+package bots
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"arc/cmd/internal/auth/session"
+	"arc/cmd/internal/httpjson/httpjsontest"
+	"arc/cmd/internal/realtime"
+)
+
+type fakeTokens struct{}
+
+func (fakeTokens) ValidateAccessToken(_ context.Context, token string, _ time.Time) (session.AccessClaims, error) {
+	if token == "" || strings.HasPrefix(token, botTokenPrefix) {
+		return session.AccessClaims{}, errors.New("invalid")
+	}
+	return session.AccessClaims{UserID: token}, nil
+}
+
+type fakeRoles map[string]string
+
+func (f fakeRoles) GetMemberRole(_ context.Context, userID, conversationID string) (string, error) {
+	role, ok := f[userID+" "+conversationID]
+	if !ok {
+		return "", realtime.ErrMembershipRequired
+	}
+	return role, nil
+}
+
+type fakeBotStore struct {
+	bots   []Bot
+	hashes map[string]string
+}
+
+func (s *fakeBotStore) create(_ context.Context, b Bot, tokenHash string) error {
+	for _, existing := range s.bots {
+		for _, c := range existing.Commands {
+			for _, nc := range b.Commands {
+				if existing.ConversationID == b.ConversationID && c == nc {
+					return errCommandTaken
+				}
+			}
+		}
+	}
+	s.bots = append(s.bots, b)
+	s.hashes[tokenHash] = b.ID
+	return nil
+}
+
+func (s *fakeBotStore) list(_ context.Context, conversationID string) ([]Bot, error) {
+	var out []Bot
+	for _, b := range s.bots {
+		if b.ConversationID == conversationID {
+			out = append(out, b)
+		}
+	}
+	return out, nil
+}
+
+func (s *fakeBotStore) delete(_ context.Context, conversationID, botID string) (bool, error) {
+	for i, b := range s.bots {
+		if b.ConversationID == conversationID && b.ID == botID {
+			s.bots = append(s.bots[:i], s.bots[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *fakeBotStore) byTokenHash(_ context.Context, tokenHash string) (Bot, error) {
+	for _, b := range s.bots {
+		if s.hashes[tokenHash] == b.ID {
+			return b, nil
+		}
+	}
+	return Bot{}, errBotNotFound
+}
+
+type fakePoster struct {
+	got []realtime.BotMessageInput
+}
+
+func (p *fakePoster) PostBotMessage(_ context.Context, in realtime.BotMessageInput) (realtime.AppendMessageResult, error) {
+	if strings.TrimSpace(in.Text) == "" {
+		return realtime.AppendMessageResult{}, realtime.ErrInvalidBotMessage
+	}
+	p.got = append(p.got, in)
+	return realtime.AppendMessageResult{Stored: realtime.StoredMessage{
+		ConversationID: in.ConversationID,
+		ServerMsgID:    "m1",
+		Seq:            1,
+		ClientMsgID:    in.ClientMsgID,
+		SenderBotID:    in.BotID,
+	}}, nil
+}
+
+func (p *fakePoster) PostBotMessages(ctx context.Context, in []realtime.BotMessageInput) ([]realtime.AppendMessageResult, error) {
+	if len(in) == 0 {
+		return nil, realtime.ErrInvalidBotMessage
+	}
+	out := make([]realtime.AppendMessageResult, 0, len(in))
+	for i, m := range in {
+		res, err := p.PostBotMessage(ctx, m)
+		if err != nil {
+			return nil, err
+		}
+		res.Stored.Seq = int64(i + 1)
+		out = append(out, res)
+	}
+	return out, nil
+}
+
+func newTestHandler() (*Handler, *fakeBotStore, *fakePoster, *http.ServeMux) {
+	store := &fakeBotStore{hashes: map[string]string{}}
+	poster := &fakePoster{}
+	roles := fakeRoles{"owner c1": realtime.MemberRoleOwner, "member c1": realtime.MemberRoleMember}
+	h := newHandler(nil, store, Config{}, fakeTokens{}, roles, poster)
+	mux := http.NewServeMux()
+	h.Register(mux)
+	return h, store, poster, mux
+}
+
+func TestHandlerCreateBot(t *testing.T) {
+	t.Parallel()
+
+	_, store, _, mux := newTestHandler()
+	body := `{"name":"Reminder","webhook_url":"https://bots.example.com/hook","commands":["/Remind","/remind"]}`
+
+	if rec := httpjsontest.Do(mux, http.MethodPost, "/conversations/c1/bots", "member", body); rec.Code != http.StatusForbidden {
+		t.Fatalf("member create: status=%d", rec.Code)
+	}
+	if rec := httpjsontest.Do(mux, http.MethodPost, "/conversations/c1/bots", "stranger", body); rec.Code != http.StatusNotFound {
+		t.Fatalf("non-member create: status=%d", rec.Code)
+	}
+
+	rec := httpjsontest.Do(mux, http.MethodPost, "/conversations/c1/bots", "owner", body)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("owner create: status=%d body=%s", rec.Code, rec.Body.String())
+	}
+	var created createBotResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !strings.HasPrefix(created.Token, botTokenPrefix) || created.WebhookSecret == "" {
+		t.Fatalf("expected token and webhook secret in create response")
+	}
+	if len(store.bots) != 1 || len(store.bots[0].Commands) != 1 || store.bots[0].Commands[0] != "/remind" {
+		t.Fatalf("unexpected stored bots %+v", store.bots)
+	}
+
+	if rec := httpjsontest.Do(mux, http.MethodPost, "/conversations/c1/bots", "owner", body); rec.Code != http.StatusConflict {
+		t.Fatalf("duplicate command: status=%d", rec.Code)
+	}
+
+	rec = httpjsontest.Do(mux, http.MethodGet, "/conversations/c1/bots", "member", "")
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), created.WebhookSecret) {
+		t.Fatalf("list: status=%d body=%s", rec.Code, rec.Body.String())
+	}
+
+	if rec := httpjsontest.Do(mux, http.MethodDelete, "/conversations/c1/bots/"+created.BotID, "owner", ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("delete: status=%d", rec.Code)
+	}
+	if rec := httpjsontest.Do(mux, http.MethodDelete, "/conversations/c1/bots/"+created.BotID, "owner", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("second delete: status=%d", rec.Code)
+	}
+}
+
+func TestHandlerCreateBotValidation(t *testing.T) {
+	t.Parallel()
+
+	_, _, _, mux := newTestHandler()
+	for _, body := range []string{
+		`{"name":"","webhook_url":"https://x.example.com","commands":["/a"]}`,
+		`{"name":"b","webhook_url":"http://x.example.com","commands":["/a"]}`,
+		`{"name":"b","webhook_url":"https://user:pw@x.example.com","commands":["/a"]}`,
+		`{"name":"b","webhook_url":"https://x.example.com","commands":[]}`,
+		`{"name":"b","webhook_url":"https://x.example.com","commands":["remind"]}`,
+		`{"name":"b","webhook_url":"https://x.example.com","commands":["/a"],"extra":1}`,
+	} {
+		if rec := httpjsontest.Do(mux, http.MethodPost, "/conversations/c1/bots", "owner", body); rec.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400 for %s, got %d", body, rec.Code)
+		}
+	}
+}
+
+func TestHandlerBotMessage(t *testing.T) {
+	t.Parallel()
+
+	_, _, poster, mux := newTestHandler()
+	rec := httpjsontest.Do(mux, http.MethodPost, "/conversations/c1/bots", "owner",
+		`{"name":"Reminder","webhook_url":"https://bots.example.com/hook","commands":["/remind"]}`)
+	var created createBotResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	if rec := httpjsontest.Do(mux, http.MethodPost, "/bots/messages", "owner", `{"client_msg_id":"k1","text":"hi"}`); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("user token: status=%d", rec.Code)
+	}
+	if rec := httpjsontest.Do(mux, http.MethodPost, "/bots/messages", botTokenPrefix+"nope", `{"client_msg_id":"k1","text":"hi"}`); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("unknown bot token: status=%d", rec.Code)
+	}
+	if rec := httpjsontest.Do(mux, http.MethodPost, "/bots/messages", created.Token, `{"client_msg_id":"k1","text":""}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("empty text: status=%d", rec.Code)
+	}
+
+	rec = httpjsontest.Do(mux, http.MethodPost, "/bots/messages", created.Token, `{"client_msg_id":"k1","text":"Reminder set"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("post: status=%d body=%s", rec.Code, rec.Body.String())
+	}
+	if len(poster.got) != 1 || poster.got[0].BotID != created.BotID || poster.got[0].ConversationID != "c1" {
+		t.Fatalf("unexpected posts %+v", poster.got)
+	}
+}
+
+func TestHandlerBotMessageBatch(t *testing.T) {
+	t.Parallel()
+
+	_, _, poster, mux := newTestHandler()
+	rec := httpjsontest.Do(mux, http.MethodPost, "/conversations/c1/bots", "owner",
+		`{"name":"Importer","webhook_url":"https://bots.example.com/hook","commands":["/import"]}`)
+	var created createBotResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	if rec := httpjsontest.Do(mux, http.MethodPost, "/bots/messages/batch", "owner", `{"messages":[{"client_msg_id":"k1","text":"hi"}]}`); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("user token: status=%d", rec.Code)
+	}
+	if rec := httpjsontest.Do(mux, http.MethodPost, "/bots/messages/batch", created.Token, `{"messages":[]}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("empty batch: status=%d", rec.Code)
+	}
+
+	rec = httpjsontest.Do(mux, http.MethodPost, "/bots/messages/batch", created.Token,
+		`{"messages":[{"client_msg_id":"k1","text":"one"},{"client_msg_id":"k2","text":"two"}]}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("post: status=%d body=%s", rec.Code, rec.Body.String())
+	}
+	var out botMessageBatchResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(out.Messages) != 2 || out.Messages[1].ClientMsgID != "k2" || out.Messages[1].Seq != 2 {
+		t.Fatalf("unexpected response %+v", out)
+	}
+	if len(poster.got) != 2 || poster.got[0].BotID != created.BotID || poster.got[1].ConversationID != "c1" {
+		t.Fatalf("unexpected posts %+v", poster.got)
+	}
+}
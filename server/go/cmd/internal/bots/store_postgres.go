@@ -0,0 +1,145 @@
+package bots
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"arc/cmd/internal/dbtrace"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	errBotNotFound  = errors.New("bot not found")
+	errCommandTaken = errors.New("command already registered in conversation")
+)
+
+// Bot is a webhook integration installed in one conversation.
+type Bot struct {
+	ID             string
+	ConversationID string
+	Name           string
+	WebhookURL     string
+	// Commands are lowercase slash commands, e.g. "/remind".
+	Commands  []string
+	CreatedBy string
+	CreatedAt time.Time
+	// WebhookSecret signs deliveries. It is only loaded where deliveries need it.
+	WebhookSecret string
+}
+
+// PostgresStore persists bots in arc.bots and their commands in arc.bot_commands.
+type PostgresStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresStore constructs a PostgresStore.
+func NewPostgresStore(pool *pgxpool.Pool) (*PostgresStore, error) {
+	if pool == nil {
+		return nil, errors.New("bots: nil db pool")
+	}
+	return &PostgresStore{pool: pool}, nil
+}
+
+// create stores b with the hash of its API token. It returns errCommandTaken
+// when another bot of the conversation already handles one of b.Commands.
+func (s *PostgresStore) create(ctx context.Context, b Bot, tokenHash string) error {
+	ctx = dbtrace.WithOp(ctx, "bots.create")
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO arc.bots (id, conversation_id, name, webhook_url, webhook_secret, token_hash, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, b.ID, b.ConversationID, b.Name, b.WebhookURL, b.WebhookSecret, tokenHash, b.CreatedBy, b.CreatedAt); err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO arc.bot_commands (conversation_id, command, bot_id)
+		SELECT $1, unnest($2::text[]), $3
+	`, b.ConversationID, b.Commands, b.ID)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return errCommandTaken
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// list returns the bots of conversationID without their secrets.
+func (s *PostgresStore) list(ctx context.Context, conversationID string) ([]Bot, error) {
+	ctx = dbtrace.WithOp(ctx, "bots.list")
+	rows, err := s.pool.Query(ctx, `
+		SELECT b.id, b.conversation_id, b.name, b.webhook_url, COALESCE(b.created_by, ''), b.created_at,
+		       COALESCE(array_agg(c.command ORDER BY c.command) FILTER (WHERE c.command IS NOT NULL), '{}')
+		FROM arc.bots b
+		LEFT JOIN arc.bot_commands c ON c.bot_id = b.id
+		WHERE b.conversation_id = $1
+		GROUP BY b.id
+		ORDER BY b.created_at, b.id
+	`, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Bot
+	for rows.Next() {
+		var b Bot
+		if err := rows.Scan(&b.ID, &b.ConversationID, &b.Name, &b.WebhookURL, &b.CreatedBy, &b.CreatedAt, &b.Commands); err != nil {
+			return nil, err
+		}
+		out = append(out, b)
+	}
+	return out, rows.Err()
+}
+
+// delete removes a bot of conversationID. It reports false when there is none.
+func (s *PostgresStore) delete(ctx context.Context, conversationID, botID string) (bool, error) {
+	ctx = dbtrace.WithOp(ctx, "bots.delete")
+	ct, err := s.pool.Exec(ctx, `
+		DELETE FROM arc.bots WHERE conversation_id = $1 AND id = $2
+	`, conversationID, botID)
+	if err != nil {
+		return false, err
+	}
+	return ct.RowsAffected() == 1, nil
+}
+
+// byTokenHash returns the bot owning an API token, or errBotNotFound.
+func (s *PostgresStore) byTokenHash(ctx context.Context, tokenHash string) (Bot, error) {
+	ctx = dbtrace.WithOp(ctx, "bots.by_token_hash")
+	var b Bot
+	err := s.pool.QueryRow(ctx, `
+		SELECT id, conversation_id, name FROM arc.bots WHERE token_hash = $1
+	`, tokenHash).Scan(&b.ID, &b.ConversationID, &b.Name)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Bot{}, errBotNotFound
+	}
+	return b, err
+}
+
+// commandBot returns the bot handling command in conversationID, including its
+// webhook secret, or errBotNotFound.
+func (s *PostgresStore) commandBot(ctx context.Context, conversationID, command string) (Bot, error) {
+	ctx = dbtrace.WithOp(ctx, "bots.command_bot")
+	var b Bot
+	err := s.pool.QueryRow(ctx, `
+		SELECT b.id, b.conversation_id, b.name, b.webhook_url, b.webhook_secret
+		FROM arc.bot_commands c
+		JOIN arc.bots b ON b.id = c.bot_id
+		WHERE c.conversation_id = $1 AND c.command = $2
+	`, conversationID, command).Scan(&b.ID, &b.ConversationID, &b.Name, &b.WebhookURL, &b.WebhookSecret)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Bot{}, errBotNotFound
+	}
+	return b, err
+}
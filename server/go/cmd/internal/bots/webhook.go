@@ -0,0 +1,94 @@
+package bots
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Webhook request headers.
+const (
+	HeaderBotID     = "X-Arc-Bot-Id"
+	HeaderTimestamp = "X-Arc-Timestamp"
+	HeaderSignature = "X-Arc-Signature"
+
+	// signatureVersion prefixes signatures so the scheme can evolve.
+	signatureVersion = "v1="
+	maxWebhookURLLen = 2048
+)
+
+var errPrivateAddress = errors.New("bots: webhook address is not public")
+
+// Sign returns the X-Arc-Signature value for body sent at ts:
+// "v1=" + hex(HMAC-SHA256(secret, "<unix seconds>." + body)).
+// Receivers recompute it and should reject stale timestamps to prevent replays.
+func Sign(secret string, ts time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(ts.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signatureVersion + hex.EncodeToString(mac.Sum(nil))
+}
+
+// validateWebhookURL requires an absolute https URL without credentials
+// (http is accepted when private webhooks are allowed).
+func validateWebhookURL(raw string, allowPrivate bool) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || len(raw) > maxWebhookURLLen {
+		return "", errors.New("webhook_url is required")
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" || u.User != nil || u.Fragment != "" {
+		return "", errors.New("webhook_url must be an absolute URL without credentials")
+	}
+	switch {
+	case u.Scheme == "https":
+	case u.Scheme == "http" && allowPrivate:
+	default:
+		return "", errors.New("webhook_url must use https")
+	}
+	return u.String(), nil
+}
+
+// newWebhookClient returns a client that does not follow redirects and, unless
+// allowPrivate, refuses to connect to loopback, private or link-local addresses
+// so bot registrations cannot probe internal services.
+func newWebhookClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || !publicIP(ip) {
+				return errPrivateAddress
+			}
+			return nil
+		}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func publicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast())
+}
@@ -0,0 +1,268 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EnvFile names the environment variable holding the config file path.
+const EnvFile = "ARC_CONFIG_FILE"
+
+// envPrefix is prepended to every key read from a file.
+const envPrefix = "ARC_"
+
+// Setting sources.
+const (
+	SourceEnv  = "env"
+	SourceFile = "file"
+)
+
+// Setting is one effective ARC_* setting.
+type Setting struct {
+	Key    string
+	Value  string
+	Source string
+}
+
+// Loaded records which settings came from the config file.
+type Loaded struct {
+	// Path is the config file, empty when none was given.
+	Path string
+
+	mu sync.Mutex
+	// fromFile holds the env names exported from the file.
+	fromFile map[string]struct{}
+}
+
+// Load reads the config file at path (if any) and exports each of its values whose
+// variable is not already set in the environment.
+func Load(path string) (*Loaded, error) {
+	l := &Loaded{Path: strings.TrimSpace(path), fromFile: map[string]struct{}{}}
+	if l.Path == "" {
+		return l, nil
+	}
+	values, err := readSettings(l.Path)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range values {
+		if _, set := os.LookupEnv(key); set {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return nil, fmt.Errorf("config: set %s: %w", key, err)
+		}
+		l.fromFile[key] = struct{}{}
+	}
+	return l, nil
+}
+
+// ReadFile parses the config file at path into env names and values. The format
+// follows the extension: .toml for TOML, .yaml or .yml for YAML.
+func ReadFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	values, err := Parse(filepath.Ext(path), data)
+	if err != nil {
+		return nil, fmt.Errorf("config: %s: %w", path, err)
+	}
+	return values, nil
+}
+
+// readSettings is ReadFile for the server's config file, which may only set known
+// settings.
+func readSettings(path string) (map[string]string, error) {
+	values, err := ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var unknown []string
+	for key := range values {
+		if !Known(key) {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("config: %s: unknown settings %s", path, strings.Join(unknown, ", "))
+	}
+	return values, nil
+}
+
+// Parse parses data in the format named by ext (".yaml", ".yml" or ".toml").
+func Parse(ext string, data []byte) (map[string]string, error) {
+	p := &parser{values: map[string]string{}}
+	var err error
+	switch strings.ToLower(ext) {
+	case ".yaml", ".yml":
+		err = parseYAML(p, data)
+	case ".toml":
+		err = parseTOML(p, data)
+	default:
+		return nil, fmt.Errorf("unsupported config format %q (want .yaml, .yml or .toml)", ext)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p.values, nil
+}
+
+// Settings returns every ARC_* variable in the environment sorted by name, with
+// secrets redacted.
+func (l *Loaded) Settings() []Setting {
+	if l != nil {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+	}
+	var out []Setting
+	for _, kv := range os.Environ() {
+		key, value, _ := strings.Cut(kv, "=")
+		if !strings.HasPrefix(key, envPrefix) {
+			continue
+		}
+		s := Setting{Key: key, Value: Redact(key, value), Source: SourceEnv}
+		if l != nil {
+			if _, ok := l.fromFile[key]; ok {
+				s.Source = SourceFile
+			}
+		}
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out
+}
+
+const redacted = "[REDACTED]"
+
+var dsnPassword = regexp.MustCompile(`(?i)(password=)\S+`)
+
+// Redact hides secret values: keys naming a secret, password, key, token hash or DSN are
+// replaced entirely, and passwords inside URLs and DSNs are masked.
+func Redact(key, value string) string {
+	if value == "" {
+		return ""
+	}
+	k := strings.ToUpper(key)
+	switch {
+	case strings.Contains(k, "SECRET"),
+		strings.Contains(k, "PASSWORD"),
+		strings.HasSuffix(k, "_KEY"),
+		strings.HasSuffix(k, "_KEY_HEX"),
+		strings.HasSuffix(k, "_SHA256"),
+		strings.HasSuffix(k, "_DSN"):
+		return redacted
+	}
+	if u, err := url.Parse(value); err == nil && u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			return u.Redacted()
+		}
+	}
+	return dsnPassword.ReplaceAllString(value, "${1}"+redacted)
+}
+
+// parser collects values shared by the YAML and TOML readers.
+type parser struct {
+	values map[string]string
+}
+
+// flatten records every leaf of the decoded table m under path.
+func (p *parser) flatten(path []string, m map[string]any) error {
+	for _, k := range slices.Sorted(maps.Keys(m)) {
+		v := m[k]
+		key := append(append([]string(nil), path...), k)
+		if sub, ok := v.(map[string]any); ok {
+			if err := p.flatten(key, sub); err != nil {
+				return err
+			}
+			continue
+		}
+		value, err := leafValue(v)
+		if err != nil {
+			return fmt.Errorf("%s: %w", strings.Join(key, "."), err)
+		}
+		if err := p.set(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// leafValue formats a decoded scalar, or a list of scalars, the way the env loaders
+// parse it.
+func leafValue(v any) (string, error) {
+	items, ok := v.([]any)
+	if !ok {
+		return scalarValue(v)
+	}
+	out := make([]string, 0, len(items))
+	for _, it := range items {
+		s, err := scalarValue(it)
+		if err != nil {
+			return "", err
+		}
+		out = append(out, s)
+	}
+	return joinList(out)
+}
+
+func scalarValue(v any) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	}
+	return "", fmt.Errorf("unsupported value of type %T (want a scalar or a list of scalars)", v)
+}
+
+var keySegment = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// set records value under the env name of path.
+func (p *parser) set(path []string, value string) error {
+	for _, seg := range path {
+		if !keySegment.MatchString(seg) {
+			return fmt.Errorf("invalid key %q (use letters, digits and underscores)", strings.Join(path, "."))
+		}
+	}
+	key := envPrefix + strings.ToUpper(strings.Join(path, "_"))
+	if _, dup := p.values[key]; dup {
+		return fmt.Errorf("duplicate key %q (%s)", strings.Join(path, "."), key)
+	}
+	p.values[key] = value
+	return nil
+}
+
+// joinList joins list items the way the env loaders split them.
+func joinList(items []string) (string, error) {
+	for _, it := range items {
+		if strings.Contains(it, ",") {
+			return "", errors.New("list items cannot contain commas")
+		}
+	}
+	return strings.Join(items, ","), nil
+}
@@ -0,0 +1,246 @@
+package config
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	t.Parallel()
+
+	src := `---
+# Arc config
+log_level: debug
+http:
+  addr: 0.0.0.0:9090   # inline comment
+  cors_allowed_origins: [http://localhost:3000, "https://app.example.com"]
+  h2c: true
+ws:
+  max_frame_bytes: 65536
+  allowed_origins:
+  - https://a.example.com
+  - 'https://b.example.com'
+auth:
+  issuer: "arc \"dev\""
+  cookie_domain:
+  admin_user_ids:
+    - 01HZXUSER00000000000000000
+motto: don't #panic
+`
+	got, err := Parse(".yaml", []byte(src))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	want := map[string]string{
+		"ARC_LOG_LEVEL":                 "debug",
+		"ARC_HTTP_ADDR":                 "0.0.0.0:9090",
+		"ARC_HTTP_CORS_ALLOWED_ORIGINS": "http://localhost:3000,https://app.example.com",
+		"ARC_HTTP_H2C":                  "true",
+		"ARC_WS_MAX_FRAME_BYTES":        "65536",
+		"ARC_WS_ALLOWED_ORIGINS":        "https://a.example.com,https://b.example.com",
+		"ARC_AUTH_ISSUER":               `arc "dev"`,
+		"ARC_AUTH_COOKIE_DOMAIN":        "",
+		"ARC_AUTH_ADMIN_USER_IDS":       "01HZXUSER00000000000000000",
+		"ARC_MOTTO":                     "don't",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v\nwant %v", got, want)
+	}
+}
+
+func TestParseTOML(t *testing.T) {
+	t.Parallel()
+
+	src := `# Arc config
+log_level = "debug"
+
+[http]
+addr = "0.0.0.0:9090" # inline comment
+cors_allowed_origins = ["http://localhost:3000", 'https://app.example.com']
+h2c = true
+read_timeout = "20s"
+
+[ws]
+max_frame_bytes = 65_536
+history_cache.ttl = "1m"
+`
+	got, err := Parse(".toml", []byte(src))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	want := map[string]string{
+		"ARC_LOG_LEVEL":                 "debug",
+		"ARC_HTTP_ADDR":                 "0.0.0.0:9090",
+		"ARC_HTTP_CORS_ALLOWED_ORIGINS": "http://localhost:3000,https://app.example.com",
+		"ARC_HTTP_H2C":                  "true",
+		"ARC_HTTP_READ_TIMEOUT":         "20s",
+		"ARC_WS_MAX_FRAME_BYTES":        "65536",
+		"ARC_WS_HISTORY_CACHE_TTL":      "1m",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v\nwant %v", got, want)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name, ext, src, want string
+	}{
+		{"format", ".json", `{}`, "unsupported config format"},
+		{"yaml duplicate", ".yaml", "http:\n  addr: a\nhttp_addr: b\n", "duplicate key"},
+		{"yaml repeated key", ".yaml", "http:\n  addr: a\n  addr: b\n", "already defined"},
+		{"yaml indentation", ".yaml", "http:\n  addr: a\n   port: 1\n", "line 3"},
+		{"yaml nested list", ".yaml", "origins:\n  - [a, b]\n", "unsupported value"},
+		{"yaml bad key", ".yaml", "http-addr: a\n", "invalid key"},
+		{"yaml comma item", ".yaml", "origins: [\"a,b\"]\n", "commas"},
+		{"yaml not a mapping", ".yaml", "- a\n", "cannot unmarshal"},
+		{"toml bare string", ".toml", "addr = localhost\n", "expected value"},
+		{"toml array table", ".toml", "[[http]]\naddr = \"a\"\n", "unsupported value"},
+		{"toml duplicate", ".toml", "[ws]\nsend_queue = 1\n[ws]\nsend_queue = 2\n", "already been defined"},
+	}
+	for _, tc := range cases {
+		_, err := Parse(tc.ext, []byte(tc.src))
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("%s: err = %v, want %q", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestLoad_UnknownSetting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "arc.toml")
+	if err := os.WriteFile(path, []byte("[http]\naddr = \"0.0.0.0:8080\"\nadr = \"typo\"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("ARC_HTTP_ADDR", "0.0.0.0:9090")
+
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "unknown settings ARC_HTTP_ADR") {
+		t.Fatalf("err = %v, want the unknown setting", err)
+	}
+	if _, set := os.LookupEnv("ARC_HTTP_ADR"); set {
+		t.Fatalf("unknown setting was exported")
+	}
+}
+
+// TestSettingsCoverSource checks that every ARC_* variable named in the server's
+// code is a known setting, so a config file can set it.
+func TestSettingsCoverSource(t *testing.T) {
+	if !slices.IsSorted(settings) {
+		t.Fatalf("settings are not sorted")
+	}
+	literal := regexp.MustCompile(`"(ARC_[A-Z0-9_]*[A-Z0-9])"`)
+	err := filepath.WalkDir(filepath.Join("..", ".."), func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return err
+		}
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		for _, m := range literal.FindAllStringSubmatch(string(src), -1) {
+			if !Known(m[1]) {
+				t.Errorf("%s: %s is not in settings", path, m[1])
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoad_EnvOverridesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "arc.yaml")
+	src := "http:\n  addr: 127.0.0.1:9000\n  idle_timeout: 90s\ndatabase_url: postgres://arc:hunter2@db:5432/arc\n"
+	if err := os.WriteFile(path, []byte(src), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("ARC_HTTP_ADDR", "0.0.0.0:8080")
+	// Registered for cleanup by t.Setenv, then cleared so Load sets them.
+	t.Setenv("ARC_HTTP_IDLE_TIMEOUT", "")
+	t.Setenv("ARC_DATABASE_URL", "")
+	_ = os.Unsetenv("ARC_HTTP_IDLE_TIMEOUT")
+	_ = os.Unsetenv("ARC_DATABASE_URL")
+
+	l, err := Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if got := os.Getenv("ARC_HTTP_ADDR"); got != "0.0.0.0:8080" {
+		t.Fatalf("env should win, got %q", got)
+	}
+	if got := os.Getenv("ARC_HTTP_IDLE_TIMEOUT"); got != "90s" {
+		t.Fatalf("file value not applied, got %q", got)
+	}
+
+	settings := map[string]Setting{}
+	for _, s := range l.Settings() {
+		settings[s.Key] = s
+	}
+	if s := settings["ARC_HTTP_ADDR"]; s.Source != SourceEnv {
+		t.Fatalf("ARC_HTTP_ADDR = %+v, want env source", s)
+	}
+	if s := settings["ARC_HTTP_IDLE_TIMEOUT"]; s.Source != SourceFile || s.Value != "90s" {
+		t.Fatalf("ARC_HTTP_IDLE_TIMEOUT = %+v, want file source", s)
+	}
+	if s := settings["ARC_DATABASE_URL"]; strings.Contains(s.Value, "hunter2") {
+		t.Fatalf("database url not redacted: %+v", s)
+	}
+
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Fatalf("expected missing file error")
+	}
+	if l, err := Load(""); err != nil || l.Path != "" {
+		t.Fatalf("empty path: %+v %v", l, err)
+	}
+}
+
+func TestRedact(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct{ key, value, want string }{
+		{"ARC_TOKEN_HMAC_KEY", "0123456789abcdef", redacted},
+		{"ARC_PASETO_V4_SECRET_KEY_HEX", "abcd", redacted},
+		{"ARC_ATTACHMENTS_S3_SECRET_ACCESS_KEY", "s3cr3t", redacted},
+		{"ARC_SCIM_TOKEN_SHA256", "deadbeef", redacted},
+		{"ARC_SENTRY_DSN", "https://pub@o1.ingest.sentry.io/42", redacted},
+		{"ARC_ATTACHMENTS_S3_ACCESS_KEY_ID", "AKIA123", "AKIA123"},
+		{"ARC_PUSH_APNS_KEY_FILE", "/etc/arc/apns.p8", "/etc/arc/apns.p8"},
+		{"ARC_DATABASE_URL", "postgres://arc:pw@db/arc", "postgres://arc:xxxxx@db/arc"},
+		{"ARC_BROKER_URL", "host=db user=arc password=pw", "host=db user=arc password=" + redacted},
+		{"ARC_HTTP_ADDR", "0.0.0.0:8080", "0.0.0.0:8080"},
+		{"ARC_TOKEN_HMAC_KEY", "", ""},
+	}
+	for _, tc := range cases {
+		if got := Redact(tc.key, tc.value); got != tc.want {
+			t.Fatalf("Redact(%s, %q) = %q, want %q", tc.key, tc.value, got, tc.want)
+		}
+	}
+}
+
+func TestOverlay(t *testing.T) {
+	t.Setenv("ARC_OVERLAY_TEST_BASE", "base")
+	t.Setenv("ARC_OVERLAY_TEST_SHADOWED", "base")
+
+	l := Overlay(Env, map[string]string{"ARC_OVERLAY_TEST_SHADOWED": "override", "ARC_OVERLAY_TEST_ONLY": ""})
+	if got := l.Get("ARC_OVERLAY_TEST_SHADOWED"); got != "override" {
+		t.Fatalf("shadowed = %q", got)
+	}
+	if v, ok := l("ARC_OVERLAY_TEST_ONLY"); !ok || v != "" {
+		t.Fatalf("override-only = %q, %v", v, ok)
+	}
+	t.Setenv("ARC_OVERLAY_TEST_BASE", "reloaded")
+	if got := l.Get("ARC_OVERLAY_TEST_BASE"); got != "reloaded" {
+		t.Fatalf("base = %q, want the current environment", got)
+	}
+	if _, set := os.LookupEnv("ARC_OVERLAY_TEST_ONLY"); set {
+		t.Fatalf("overlay leaked into the environment")
+	}
+}
@@ -0,0 +1,29 @@
+// Package config loads Arc's optional configuration file.
+//
+// Every setting is an ARC_* environment variable, and the file is another way to set
+// them: nested keys are joined with underscores, upper-cased and prefixed with ARC_,
+// so
+//
+//	http:
+//	  addr: 0.0.0.0:8080
+//	ws:
+//	  max_frame_bytes: 65536
+//	auth:
+//	  admin_user_ids: [01HZX..., 01HZY...]
+//
+// sets ARC_HTTP_ADDR, ARC_WS_MAX_FRAME_BYTES and ARC_AUTH_ADMIN_USER_IDS (lists are
+// joined with commas). Files ending in .toml use TOML tables and key = value pairs
+// instead. Every key must name a known setting (see Known); anything else fails the
+// load, so a misspelled key is not silently ignored. Variables already present in
+// the environment win over the file, so a deployment can ship one file and override
+// single settings per instance.
+//
+// Load exports the file's values into the process environment before the app,
+// authapi, session and realtime packages read their settings, so every package keeps
+// a single source of truth. Settings lists the effective configuration with secrets
+// redacted for the startup log.
+//
+// Reload re-reads the file and updates the variables it owns. Packages that can apply
+// a setting while running register with Subscribe and re-read it when notified; any
+// other change waits for a restart, which RestartRequired reports.
+package config
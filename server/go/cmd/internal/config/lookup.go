@@ -0,0 +1,32 @@
+package config
+
+import "os"
+
+// Lookup reads one setting by its ARC_* name, reporting whether it is set. The
+// process environment is os.LookupEnv; package loaders take a Lookup so a tenant's
+// overrides can be read without touching the environment.
+type Lookup func(key string) (string, bool)
+
+// Env reads the process environment.
+var Env Lookup = os.LookupEnv
+
+// Get returns the value of key, or "" when it is unset.
+func (l Lookup) Get(key string) string {
+	v, _ := l(key)
+	return v
+}
+
+// Overlay returns a Lookup that reads overrides first and falls back to base. base
+// is consulted on every call, so a reload that changes the environment is seen by
+// every setting the overrides leave alone.
+func Overlay(base Lookup, overrides map[string]string) Lookup {
+	if len(overrides) == 0 {
+		return base
+	}
+	return func(key string) (string, bool) {
+		if v, ok := overrides[key]; ok {
+			return v, true
+		}
+		return base(key)
+	}
+}
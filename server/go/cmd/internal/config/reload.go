@@ -0,0 +1,181 @@
+package config
+
+import (
+	"context"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+type subscription struct {
+	keys map[string]struct{}
+	fn   func(changed []string)
+}
+
+var (
+	subMu  sync.Mutex
+	subs   = map[int]subscription{}
+	nextID int
+)
+
+// Subscribe registers fn to run after a reload changes any of keys, or any setting
+// when keys is empty. fn receives the changed keys and should re-read the settings
+// it owns from the environment. Only settings that are safe to change while
+// running should be subscribed. The returned function cancels the subscription.
+func Subscribe(fn func(changed []string), keys ...string) (cancel func()) {
+	s := subscription{fn: fn}
+	if len(keys) > 0 {
+		s.keys = make(map[string]struct{}, len(keys))
+		for _, k := range keys {
+			s.keys[k] = struct{}{}
+		}
+	}
+
+	subMu.Lock()
+	id := nextID
+	nextID++
+	subs[id] = s
+	subMu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			subMu.Lock()
+			delete(subs, id)
+			subMu.Unlock()
+		})
+	}
+}
+
+// RestartRequired returns the keys of changed that no subscriber reloads.
+func RestartRequired(changed []string) []string {
+	subMu.Lock()
+	defer subMu.Unlock()
+
+	var out []string
+	for _, k := range changed {
+		handled := false
+		for _, s := range subs {
+			if _, ok := s.keys[k]; ok {
+				handled = true
+				break
+			}
+		}
+		if !handled {
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+// notify runs the subscribers interested in changed.
+func notify(changed []string) {
+	subMu.Lock()
+	var fns []func([]string)
+	for _, s := range subs {
+		if s.keys == nil {
+			fns = append(fns, s.fn)
+			continue
+		}
+		for _, k := range changed {
+			if _, ok := s.keys[k]; ok {
+				fns = append(fns, s.fn)
+				break
+			}
+		}
+	}
+	subMu.Unlock()
+
+	for _, fn := range fns {
+		fn(changed)
+	}
+}
+
+// Reload re-reads the config file and updates the variables it owns: changed
+// values are exported and removed keys are unset. Variables set outside the file
+// are never touched. It returns the changed keys after notifying subscribers; on a
+// parse error nothing changes.
+func (l *Loaded) Reload() ([]string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.Path == "" {
+		return nil, nil
+	}
+	values, err := readSettings(l.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []string
+	for key, value := range values {
+		_, owned := l.fromFile[key]
+		if !owned {
+			if _, set := os.LookupEnv(key); set {
+				continue
+			}
+		}
+		if cur, _ := os.LookupEnv(key); owned && cur == value {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return changed, err
+		}
+		l.fromFile[key] = struct{}{}
+		changed = append(changed, key)
+	}
+	for key := range l.fromFile {
+		if _, ok := values[key]; ok {
+			continue
+		}
+		_ = os.Unsetenv(key)
+		delete(l.fromFile, key)
+		changed = append(changed, key)
+	}
+
+	sort.Strings(changed)
+	if len(changed) > 0 {
+		notify(changed)
+	}
+	return changed, nil
+}
+
+// Watch polls the config file every interval and reloads it when its size or
+// modification time changes, until ctx is done. report receives each reload's
+// outcome.
+func (l *Loaded) Watch(ctx context.Context, interval time.Duration, report func(changed []string, err error)) {
+	if l.Path == "" || interval <= 0 {
+		return
+	}
+	last := fileStamp(l.Path)
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		stamp := fileStamp(l.Path)
+		if stamp == last {
+			continue
+		}
+		last = stamp
+		changed, err := l.Reload()
+		report(changed, err)
+	}
+}
+
+type stamp struct {
+	size    int64
+	modTime time.Time
+}
+
+func fileStamp(path string) stamp {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return stamp{}
+	}
+	return stamp{size: fi.Size(), modTime: fi.ModTime()}
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "arc.yaml")
+	write := func(src string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(src), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("ws:\n  rate_events: 10\n  send_queue: 32\nhttp:\n  addr: 127.0.0.1:9000\n")
+	t.Setenv("ARC_HTTP_ADDR", "0.0.0.0:8080")
+	for _, k := range []string{"ARC_WS_RATE_EVENTS", "ARC_WS_SEND_QUEUE", "ARC_WS_RATE_WINDOW"} {
+		t.Setenv(k, "")
+		_ = os.Unsetenv(k)
+	}
+
+	l, err := Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	var got [][]string
+	cancel := Subscribe(func(changed []string) { got = append(got, changed) }, "ARC_WS_RATE_EVENTS", "ARC_WS_RATE_WINDOW")
+	defer cancel()
+
+	write("ws:\n  rate_events: 20\n  rate_window: 5s\nhttp:\n  addr: 127.0.0.1:9001\n")
+	changed, err := l.Reload()
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	want := []string{"ARC_WS_RATE_EVENTS", "ARC_WS_RATE_WINDOW", "ARC_WS_SEND_QUEUE"}
+	if !reflect.DeepEqual(changed, want) {
+		t.Fatalf("changed = %v, want %v", changed, want)
+	}
+	if !reflect.DeepEqual(got, [][]string{want}) {
+		t.Fatalf("subscriber got %v", got)
+	}
+	if v := os.Getenv("ARC_WS_RATE_EVENTS"); v != "20" {
+		t.Fatalf("ARC_WS_RATE_EVENTS = %q", v)
+	}
+	if _, ok := os.LookupEnv("ARC_WS_SEND_QUEUE"); ok {
+		t.Fatalf("removed key should be unset")
+	}
+	if v := os.Getenv("ARC_HTTP_ADDR"); v != "0.0.0.0:8080" {
+		t.Fatalf("env-owned key changed to %q", v)
+	}
+	if pending := RestartRequired(changed); !reflect.DeepEqual(pending, []string{"ARC_WS_SEND_QUEUE"}) {
+		t.Fatalf("restart required = %v", pending)
+	}
+
+	// Unchanged files notify nobody; invalid files change nothing.
+	if changed, err := l.Reload(); err != nil || len(changed) != 0 || len(got) != 1 {
+		t.Fatalf("no-op reload: %v %v (%d notifications)", changed, err, len(got))
+	}
+	write("ws: {rate_events: 30, rate_evnets: 30}\n")
+	if _, err := l.Reload(); err == nil || !strings.Contains(err.Error(), "ARC_WS_RATE_EVNETS") {
+		t.Fatalf("expected an unknown setting error, got %v", err)
+	}
+	if v := os.Getenv("ARC_WS_RATE_EVENTS"); v != "20" {
+		t.Fatalf("failed reload changed ARC_WS_RATE_EVENTS to %q", v)
+	}
+
+	cancel()
+	write("ws:\n  rate_events: 40\n")
+	if _, err := l.Reload(); err != nil || len(got) != 1 {
+		t.Fatalf("cancelled subscriber notified: %v (%d notifications)", err, len(got))
+	}
+}
@@ -0,0 +1,290 @@
+package config
+
+import "slices"
+
+// settings lists, sorted, every ARC_* variable Arc reads. A config file may only
+// set these, so a misspelled key fails at startup instead of being ignored.
+// TestSettingsCoverSource fails for an ARC_* literal in the server missing here.
+var settings = []string{
+	"ARC_ABUSE_DUPLICATE_MIN_CHARS",
+	"ARC_ABUSE_DUPLICATE_SUSPEND",
+	"ARC_ABUSE_DUPLICATE_WARN",
+	"ARC_ABUSE_DUPLICATE_WINDOW",
+	"ARC_ABUSE_GUARD",
+	"ARC_ABUSE_SUSPEND_DURATION",
+	"ARC_ACCESS_ALLOW_CIDRS",
+	"ARC_ACCESS_ALLOW_COUNTRIES",
+	"ARC_ACCESS_BYPASS_CIDRS",
+	"ARC_ACCESS_DENY_CIDRS",
+	"ARC_ACCESS_DENY_COUNTRIES",
+	"ARC_ACCESS_LOG",
+	"ARC_ACCESS_LOG_FORMAT",
+	"ARC_ACCESS_LOG_MAX_BACKUPS",
+	"ARC_ACCESS_LOG_MAX_SIZE_MB",
+	"ARC_ARGON2_ITERATIONS",
+	"ARC_ARGON2_KEY_LEN",
+	"ARC_ARGON2_MEMORY_KIB",
+	"ARC_ARGON2_PARALLELISM",
+	"ARC_ARGON2_SALT_LEN",
+	"ARC_ATTACHMENTS_ALLOWED_MIME",
+	"ARC_ATTACHMENTS_BACKEND",
+	"ARC_ATTACHMENTS_LOCAL_DIR",
+	"ARC_ATTACHMENTS_MAX_BYTES",
+	"ARC_ATTACHMENTS_PUBLIC_BASE_URL",
+	"ARC_ATTACHMENTS_S3_ACCESS_KEY_ID",
+	"ARC_ATTACHMENTS_S3_BUCKET",
+	"ARC_ATTACHMENTS_S3_ENDPOINT",
+	"ARC_ATTACHMENTS_S3_PATH_STYLE",
+	"ARC_ATTACHMENTS_S3_REGION",
+	"ARC_ATTACHMENTS_S3_SECRET_ACCESS_KEY",
+	"ARC_ATTACHMENTS_UPLOAD_URL_TTL",
+	"ARC_AUTH_ACCESS_COOKIE_NAME",
+	"ARC_AUTH_ACCESS_RENEW_MIN_INTERVAL",
+	"ARC_AUTH_ACCESS_TTL",
+	"ARC_AUTH_ADMIN_USER_IDS",
+	"ARC_AUTH_ALLOWED_AUDIENCES",
+	"ARC_AUTH_AUDIENCE",
+	"ARC_AUTH_CLOCK_SKEW",
+	"ARC_AUTH_COOKIE_DOMAIN",
+	"ARC_AUTH_COOKIE_PATH",
+	"ARC_AUTH_COOKIE_SAMESITE",
+	"ARC_AUTH_COOKIE_SECURE",
+	"ARC_AUTH_CSRF_COOKIE_NAME",
+	"ARC_AUTH_CSRF_HEADER_NAME",
+	"ARC_AUTH_DISABLE_LEGACY_ROUTES",
+	"ARC_AUTH_ENABLE_CAPTCHA",
+	"ARC_AUTH_INVITE_LINK_TEMPLATE",
+	"ARC_AUTH_INVITE_MAX_USES",
+	"ARC_AUTH_INVITE_MAX_USES_MAX",
+	"ARC_AUTH_INVITE_ONLY",
+	"ARC_AUTH_INVITE_SEND_DAILY_MAX",
+	"ARC_AUTH_INVITE_TTL",
+	"ARC_AUTH_INVITE_TTL_MAX",
+	"ARC_AUTH_ISSUER",
+	"ARC_AUTH_LEGACY_ROUTES_DEPRECATED_AT",
+	"ARC_AUTH_LEGACY_ROUTES_SUNSET",
+	"ARC_AUTH_LOGIN_CHALLENGE_ENABLED",
+	"ARC_AUTH_LOGIN_CHALLENGE_MAX_ATTEMPTS",
+	"ARC_AUTH_LOGIN_CHALLENGE_TTL",
+	"ARC_AUTH_LOGIN_IP_MAX",
+	"ARC_AUTH_LOGIN_IP_WINDOW",
+	"ARC_AUTH_LOGIN_LOCKOUT_LONG_DURATION",
+	"ARC_AUTH_LOGIN_LOCKOUT_LONG_THRESHOLD",
+	"ARC_AUTH_LOGIN_LOCKOUT_SEVERE_DURATION",
+	"ARC_AUTH_LOGIN_LOCKOUT_SEVERE_THRESHOLD",
+	"ARC_AUTH_LOGIN_LOCKOUT_SHORT_DURATION",
+	"ARC_AUTH_LOGIN_LOCKOUT_SHORT_THRESHOLD",
+	"ARC_AUTH_LOGIN_USER_MAX",
+	"ARC_AUTH_LOGIN_USER_WINDOW",
+	"ARC_AUTH_MAX_BODY_BYTES",
+	"ARC_AUTH_PHONE_OTP_EMAIL_FALLBACK",
+	"ARC_AUTH_PHONE_OTP_ENABLED",
+	"ARC_AUTH_PHONE_OTP_IP_MAX",
+	"ARC_AUTH_PHONE_OTP_MAX_ATTEMPTS",
+	"ARC_AUTH_PHONE_OTP_PHONE_MAX",
+	"ARC_AUTH_PHONE_OTP_TTL",
+	"ARC_AUTH_PHONE_OTP_WINDOW",
+	"ARC_AUTH_PROXY_HEADER",
+	"ARC_AUTH_PROXY_HOPS",
+	"ARC_AUTH_REFRESH_BINDING",
+	"ARC_AUTH_REFRESH_COOKIE_NAME",
+	"ARC_AUTH_REFRESH_MIN_INTERVAL",
+	"ARC_AUTH_REFRESH_TOKEN_BYTES",
+	"ARC_AUTH_REFRESH_TTL_NATIVE",
+	"ARC_AUTH_REFRESH_TTL_NATIVE_SHORT",
+	"ARC_AUTH_REFRESH_TTL_WEB",
+	"ARC_AUTH_REQUIRE_EMAIL_VERIFIED",
+	"ARC_AUTH_RESUME_TOKEN_TTL",
+	"ARC_AUTH_SIGNUP_EMAIL_DOMAINS",
+	"ARC_AUTH_STATS_FLUSH_INTERVAL",
+	"ARC_AUTH_TOKEN_EXCHANGE_AUDIENCES",
+	"ARC_AUTH_TOKEN_EXCHANGE_CLIENTS",
+	"ARC_AUTH_TOKEN_EXCHANGE_SCOPES",
+	"ARC_AUTH_TOKEN_EXCHANGE_TTL",
+	"ARC_AUTH_TRUSTED_PROXY_CIDRS",
+	"ARC_AUTH_TRUST_PROXY",
+	"ARC_AUTH_WEB_ACCESS_COOKIE",
+	"ARC_AUTH_WEB_COOKIE_MODE",
+	"ARC_AUTH_WS_TICKET_TTL",
+	"ARC_BOTS_ALLOW_PRIVATE_WEBHOOKS",
+	"ARC_BOTS_ENABLED",
+	"ARC_BOTS_QUEUE_SIZE",
+	"ARC_BOTS_WEBHOOK_MAX_ATTEMPTS",
+	"ARC_BOTS_WEBHOOK_TIMEOUT",
+	"ARC_BOTS_WORKERS",
+	"ARC_BROKER",
+	"ARC_BROKER_CHANNEL",
+	"ARC_BROKER_URL",
+	"ARC_CONFIG_FILE",
+	"ARC_CONFIG_WATCH_INTERVAL",
+	"ARC_CORS_ALLOWED_ORIGINS",
+	"ARC_DATABASE_REPLICA_URL",
+	"ARC_DATABASE_URL",
+	"ARC_DB_AUTO_MIGRATE",
+	"ARC_DB_HEALTH_CHECK_PERIOD",
+	"ARC_DB_MAX_CONNS",
+	"ARC_DB_MIN_CONNS",
+	"ARC_DB_SLOW_QUERY_THRESHOLD",
+	"ARC_DB_STATEMENT_CACHE_CAPACITY",
+	"ARC_DB_STATEMENT_CACHE_MODE",
+	"ARC_DEBUG_ADDR",
+	"ARC_ENV",
+	"ARC_FEATURE_FLAGS",
+	"ARC_FEATURE_FLAGS_CACHE_TTL",
+	"ARC_FIREHOSE_CHECKPOINT_INTERVAL",
+	"ARC_FIREHOSE_CONVERSATIONS",
+	"ARC_FIREHOSE_QUEUE_SIZE",
+	"ARC_FIREHOSE_TOKEN_SHA256",
+	"ARC_GEOIP_ASN_DB",
+	"ARC_GEOIP_CITY_DB",
+	"ARC_GEOIP_SERVICE_TIMEOUT",
+	"ARC_GEOIP_SERVICE_URL",
+	"ARC_HTTP2_MAX_CONCURRENT_STREAMS",
+	"ARC_HTTP2_SEND_PING_TIMEOUT",
+	"ARC_HTTP_ADDR",
+	"ARC_HTTP_CORS_ALLOWED_ORIGINS",
+	"ARC_HTTP_CORS_ALLOW_CREDENTIALS",
+	"ARC_HTTP_CORS_MAX_AGE_SECONDS",
+	"ARC_HTTP_H2C",
+	"ARC_HTTP_IDLE_TIMEOUT",
+	"ARC_HTTP_MAX_HEADER_BYTES",
+	"ARC_HTTP_READ_HEADER_TIMEOUT",
+	"ARC_HTTP_READ_TIMEOUT",
+	"ARC_HTTP_SHUTDOWN_TIMEOUT",
+	"ARC_HTTP_WRITE_TIMEOUT",
+	"ARC_JOBS_DISABLED",
+	"ARC_JOBS_ENABLED",
+	"ARC_LOG_FORMAT",
+	"ARC_LOG_LEVEL",
+	"ARC_LOG_LEVELS",
+	"ARC_LOG_SAMPLE_FIRST",
+	"ARC_LOG_SAMPLE_MESSAGES",
+	"ARC_LOG_SAMPLE_THEREAFTER",
+	"ARC_LOG_SAMPLE_TICK",
+	"ARC_LOG_WIDTH",
+	"ARC_MAINTENANCE_MESSAGE",
+	"ARC_MAINTENANCE_MODE",
+	"ARC_MAINTENANCE_RETRY_AFTER",
+	"ARC_MESSAGE_FILTER_BANNED_WORDS",
+	"ARC_MESSAGE_FILTER_BANNED_WORDS_FILE",
+	"ARC_MESSAGE_FILTER_MAX_CHARS",
+	"ARC_MESSAGE_FILTER_MAX_LINKS",
+	"ARC_MESSAGE_FILTER_MAX_MENTIONS",
+	"ARC_MESSAGE_SANITIZE",
+	"ARC_METRICS_ENABLED",
+	"ARC_MODERATION_MODERATOR_USER_IDS",
+	"ARC_MOTTO",
+	"ARC_OVERLAY_TEST_BASE",
+	"ARC_OVERLAY_TEST_ONLY",
+	"ARC_OVERLAY_TEST_SHADOWED",
+	"ARC_PASETO_V4_SECRET_KEY_HEX",
+	"ARC_PASSWORD_MAX_LEN",
+	"ARC_PASSWORD_MIN_LEN",
+	"ARC_PASSWORD_REJECT_VERY_WEAK",
+	"ARC_PRESENCE_LAST_SEEN",
+	"ARC_PRIVACY_EXPORT_MIN_INTERVAL",
+	"ARC_PRIVACY_EXPORT_TTL",
+	"ARC_PUSH_APNS_ENDPOINT",
+	"ARC_PUSH_APNS_KEY_FILE",
+	"ARC_PUSH_APNS_KEY_ID",
+	"ARC_PUSH_APNS_SANDBOX",
+	"ARC_PUSH_APNS_TEAM_ID",
+	"ARC_PUSH_APNS_TOPIC",
+	"ARC_PUSH_FCM_CREDENTIALS_FILE",
+	"ARC_PUSH_FCM_ENDPOINT",
+	"ARC_PUSH_FCM_PROJECT_ID",
+	"ARC_PUSH_QUEUE_SIZE",
+	"ARC_PUSH_SEND_TIMEOUT",
+	"ARC_PUSH_SHOW_PREVIEW",
+	"ARC_PUSH_WORKERS",
+	"ARC_READINESS_REQUIRE_DB",
+	"ARC_REDACT_EMAILS",
+	"ARC_REDACT_IPV4_PREFIX",
+	"ARC_REDACT_IPV6_PREFIX",
+	"ARC_REDACT_KEYS",
+	"ARC_REDACT_PHONES",
+	"ARC_REQUIRE_TOKEN_HMAC",
+	"ARC_RETENTION_ARCHIVE_BACKEND",
+	"ARC_RETENTION_ARCHIVE_LOCAL_DIR",
+	"ARC_RETENTION_ARCHIVE_PREFIX",
+	"ARC_RETENTION_ARCHIVE_S3_ACCESS_KEY_ID",
+	"ARC_RETENTION_ARCHIVE_S3_BUCKET",
+	"ARC_RETENTION_ARCHIVE_S3_ENDPOINT",
+	"ARC_RETENTION_ARCHIVE_S3_PATH_STYLE",
+	"ARC_RETENTION_ARCHIVE_S3_REGION",
+	"ARC_RETENTION_ARCHIVE_S3_SECRET_ACCESS_KEY",
+	"ARC_RETENTION_BATCH_SIZE",
+	"ARC_RETENTION_DIRECT_MAX_AGE",
+	"ARC_RETENTION_DIRECT_MAX_MESSAGES",
+	"ARC_RETENTION_GROUP_MAX_AGE",
+	"ARC_RETENTION_GROUP_MAX_MESSAGES",
+	"ARC_RETENTION_INTERVAL",
+	"ARC_RETENTION_ROOM_MAX_AGE",
+	"ARC_RETENTION_ROOM_MAX_MESSAGES",
+	"ARC_RETENTION_SCHEDULE",
+	"ARC_SCIM_MAX_PAGE_SIZE",
+	"ARC_SCIM_TOKEN_SHA256",
+	"ARC_SECURITY_PIN_REPORT_IP_MAX",
+	"ARC_SECURITY_PIN_REPORT_IP_WINDOW",
+	"ARC_SENTRY_DSN",
+	"ARC_TENANTS_FILE",
+	"ARC_TENANT_DEFAULT",
+	"ARC_TENANT_HEADER",
+	"ARC_TENANT_RESOLVE",
+	"ARC_TENANT_TEST_ONLY",
+	"ARC_TEST_LIMIT",
+	"ARC_TLS_ACME_CACHE_DIR",
+	"ARC_TLS_ACME_DIRECTORY_URL",
+	"ARC_TLS_ACME_DOMAINS",
+	"ARC_TLS_ACME_EMAIL",
+	"ARC_TLS_ACME_HTTP_ADDR",
+	"ARC_TLS_CERT_FILE",
+	"ARC_TLS_KEY_FILE",
+	"ARC_TOKEN_HMAC_KEY",
+	"ARC_WS_ALLOWED_ORIGINS",
+	"ARC_WS_AUTH_COOKIE_NAME",
+	"ARC_WS_AUTH_QUERY_PARAM",
+	"ARC_WS_AUTH_SUBPROTOCOL",
+	"ARC_WS_BACKPRESSURE",
+	"ARC_WS_COMPRESSION",
+	"ARC_WS_COMPRESSION_CONTEXT_CONNS",
+	"ARC_WS_COMPRESSION_THRESHOLD",
+	"ARC_WS_CONVERSATION_SEND_BURST",
+	"ARC_WS_CONVERSATION_SEND_REFILL",
+	"ARC_WS_DEV_INSECURE",
+	"ARC_WS_DRAIN_RETRY_AFTER",
+	"ARC_WS_DRAIN_TIMEOUT",
+	"ARC_WS_HEARTBEAT_INTERVAL",
+	"ARC_WS_HEARTBEAT_TIMEOUT",
+	"ARC_WS_HISTORY_CACHE",
+	"ARC_WS_HISTORY_CACHE_CONVERSATIONS",
+	"ARC_WS_HISTORY_CACHE_MESSAGES",
+	"ARC_WS_HISTORY_CACHE_TTL",
+	"ARC_WS_INBOX_MAX_CONVERSATIONS",
+	"ARC_WS_MAX_CONNS_PER_IP",
+	"ARC_WS_MAX_CONNS_PER_SESSION",
+	"ARC_WS_MAX_CONNS_PER_USER",
+	"ARC_WS_MAX_FRAME_BYTES",
+	"ARC_WS_MAX_JOINED_CONVERSATIONS",
+	"ARC_WS_MAX_MESSAGE_CHARS",
+	"ARC_WS_ORDER_HOLD",
+	"ARC_WS_ORIGIN_REQUIRED",
+	"ARC_WS_PRIORITY_QUEUE",
+	"ARC_WS_RATE_EVENTS",
+	"ARC_WS_RATE_WINDOW",
+	"ARC_WS_READ_IDLE_TIMEOUT",
+	"ARC_WS_REQUIRE_AUTH",
+	"ARC_WS_REQUIRE_MEMBERSHIP",
+	"ARC_WS_RESUME_MAX_MESSAGES",
+	"ARC_WS_SEND_QUEUE",
+	"ARC_WS_SLOW_CONSUMER_DROPS",
+	"ARC_WS_TICKET_QUERY_PARAM",
+	"ARC_WS_TOUCH_INTERVAL",
+	"ARC_WS_WRITE_TIMEOUT",
+}
+
+// Known reports whether key names a setting Arc reads.
+func Known(key string) bool {
+	_, ok := slices.BinarySearch(settings, key)
+	return ok
+}
@@ -0,0 +1,15 @@
+package config
+
+import (
+	"github.com/BurntSushi/toml"
+)
+
+// parseTOML reads a TOML document of tables whose values are scalars or arrays of
+// scalars.
+func parseTOML(p *parser, data []byte) error {
+	var doc map[string]any
+	if _, err := toml.Decode(string(data), &doc); err != nil {
+		return err
+	}
+	return p.flatten(nil, doc)
+}
@@ -0,0 +1,15 @@
+package config
+
+import (
+	"gopkg.in/yaml.v3"
+)
+
+// parseYAML reads a YAML document of nested mappings whose leaves are scalars or
+// lists of scalars.
+func parseYAML(p *parser, data []byte) error {
+	var doc map[string]any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	return p.flatten(nil, doc)
+}
@@ -0,0 +1,29 @@
+package dbroute
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type primaryKey struct{}
+
+// RequirePrimary returns a context whose replica-eligible reads use the primary.
+func RequirePrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// PrimaryRequired reports whether ctx was marked by RequirePrimary.
+func PrimaryRequired(ctx context.Context) bool {
+	v, _ := ctx.Value(primaryKey{}).(bool)
+	return v
+}
+
+// Reader returns replica, or primary when replica is nil or ctx requires the primary.
+func Reader(ctx context.Context, primary, replica *pgxpool.Pool) *pgxpool.Pool {
+	if replica == nil || PrimaryRequired(ctx) {
+		return primary
+	}
+	replicaReads.Inc()
+	return replica
+}
@@ -0,0 +1,29 @@
+package dbroute
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestReader(t *testing.T) {
+	primary, replica := &pgxpool.Pool{}, &pgxpool.Pool{}
+	ctx := context.Background()
+
+	if got := Reader(ctx, primary, nil); got != primary {
+		t.Fatalf("expected primary without a replica")
+	}
+	before := replicaReads.Value()
+	if got := Reader(ctx, primary, replica); got != replica || replicaReads.Value() != before+1 {
+		t.Fatalf("expected replica for an unmarked context")
+	}
+
+	pctx := RequirePrimary(ctx)
+	if !PrimaryRequired(pctx) || PrimaryRequired(ctx) {
+		t.Fatalf("expected only the marked context to require the primary")
+	}
+	if got := Reader(pctx, primary, replica); got != primary {
+		t.Fatalf("expected primary for a RequirePrimary context")
+	}
+}
@@ -0,0 +1,8 @@
+// Package dbroute sends read-only queries to an optional Postgres read replica.
+//
+// Stores that accept a replica pool (identity, session and realtime WithReadPool)
+// route their replica-safe reads through Reader. Replicas lag the primary, so
+// callers whose decision depends on seeing the latest write (lock checks, seq
+// gap detection on resume, counts that gate a revocation) mark the context with
+// RequirePrimary. Writes and reads inside transactions always use the primary.
+package dbroute
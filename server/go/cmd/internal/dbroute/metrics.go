@@ -0,0 +1,6 @@
+package dbroute
+
+import "arc/cmd/internal/metrics"
+
+var replicaReads = metrics.NewCounter("arc_db_replica_reads_total",
+	"Queries routed to the read replica pool.")
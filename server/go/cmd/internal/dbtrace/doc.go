@@ -0,0 +1,10 @@
+// Package dbtrace instruments every Postgres query issued through a traced pool.
+//
+// A Tracer is installed as the pgx tracer of the server's pools, so all stores
+// are covered without wrapping each one. Stores name the operation on the
+// context before querying, e.g. dbtrace.WithOp(ctx, "session.get_by_id"), and
+// each query is recorded under that name in arc_db_query_duration_seconds{pool,op}
+// ("unknown" without one). Queries slower than the configured threshold are
+// logged as db.query.slow with that operation name; SQL text and arguments are
+// never logged.
+package dbtrace
@@ -0,0 +1,11 @@
+package dbtrace
+
+import "arc/cmd/internal/metrics"
+
+var (
+	queryDuration = metrics.NewHistogramVec("arc_db_query_duration_seconds",
+		"Postgres query latency in seconds by pool and store operation.",
+		[]float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5}, "pool", "op")
+	slowQueries = metrics.NewCounterVec("arc_db_slow_queries_total",
+		"Postgres queries slower than ARC_DB_SLOW_QUERY_THRESHOLD by pool and store operation.", "pool", "op")
+)
@@ -0,0 +1,116 @@
+package dbtrace
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Tracer records query latency per operation and logs slow queries. It
+// implements pgx's query, batch and copy tracers.
+type Tracer struct {
+	log  *slog.Logger
+	pool string
+	slow time.Duration
+}
+
+var (
+	_ pgx.QueryTracer    = (*Tracer)(nil)
+	_ pgx.BatchTracer    = (*Tracer)(nil)
+	_ pgx.CopyFromTracer = (*Tracer)(nil)
+)
+
+// New returns a Tracer for the pool named pool ("primary", "replica"). Queries
+// taking at least slow are logged; slow <= 0 disables the log.
+func New(log *slog.Logger, pool string, slow time.Duration) *Tracer {
+	if log == nil {
+		log = slog.Default()
+	}
+	return &Tracer{log: log, pool: pool, slow: slow}
+}
+
+type (
+	traceKey struct{}
+	opKey    struct{}
+)
+
+// unknownOp labels queries issued without WithOp.
+const unknownOp = "unknown"
+
+// WithOp names the store operation whose queries run under ctx, e.g.
+// "session.get_by_id". Stores call it on entry; the innermost name wins.
+func WithOp(ctx context.Context, op string) context.Context {
+	return context.WithValue(ctx, opKey{}, op)
+}
+
+// Op returns the operation set on ctx by WithOp, or "unknown".
+func Op(ctx context.Context) string {
+	if op, ok := ctx.Value(opKey{}).(string); ok && op != "" {
+		return op
+	}
+	return unknownOp
+}
+
+type trace struct {
+	op    string
+	start time.Time
+}
+
+func (t *Tracer) start(ctx context.Context) context.Context {
+	return context.WithValue(ctx, traceKey{}, trace{op: Op(ctx), start: time.Now()})
+}
+
+func (t *Tracer) end(ctx context.Context, err error) {
+	tr, ok := ctx.Value(traceKey{}).(trace)
+	if !ok {
+		return
+	}
+	d := time.Since(tr.start)
+	queryDuration.With(t.pool, tr.op).Observe(d.Seconds())
+	if t.slow <= 0 || d < t.slow {
+		return
+	}
+	slowQueries.With(t.pool, tr.op).Inc()
+	if err != nil {
+		t.log.Warn("db.query.slow", "pool", t.pool, "op", tr.op, "duration_ms", d.Milliseconds(),
+			"threshold_ms", t.slow.Milliseconds(), "err", err, "result", "server_error")
+		return
+	}
+	t.log.Warn("db.query.slow", "pool", t.pool, "op", tr.op, "duration_ms", d.Milliseconds(),
+		"threshold_ms", t.slow.Milliseconds(), "result", "success")
+}
+
+// TraceQueryStart implements pgx.QueryTracer.
+func (t *Tracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, _ pgx.TraceQueryStartData) context.Context {
+	return t.start(ctx)
+}
+
+// TraceQueryEnd implements pgx.QueryTracer.
+func (t *Tracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	t.end(ctx, data.Err)
+}
+
+// TraceBatchStart implements pgx.BatchTracer; a batch is timed as one operation.
+func (t *Tracer) TraceBatchStart(ctx context.Context, _ *pgx.Conn, _ pgx.TraceBatchStartData) context.Context {
+	return t.start(ctx)
+}
+
+// TraceBatchQuery implements pgx.BatchTracer.
+func (t *Tracer) TraceBatchQuery(context.Context, *pgx.Conn, pgx.TraceBatchQueryData) {}
+
+// TraceBatchEnd implements pgx.BatchTracer.
+func (t *Tracer) TraceBatchEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchEndData) {
+	t.end(ctx, data.Err)
+}
+
+// TraceCopyFromStart implements pgx.CopyFromTracer.
+func (t *Tracer) TraceCopyFromStart(ctx context.Context, _ *pgx.Conn, _ pgx.TraceCopyFromStartData) context.Context {
+	return t.start(ctx)
+}
+
+// TraceCopyFromEnd implements pgx.CopyFromTracer.
+func (t *Tracer) TraceCopyFromEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromEndData) {
+	t.end(ctx, data.Err)
+}
@@ -0,0 +1,56 @@
+package dbtrace
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func TestTracer_SlowQueryLog(t *testing.T) {
+	var buf bytes.Buffer
+	tr := New(slog.New(slog.NewJSONHandler(&buf, nil)), "test", 10*time.Millisecond)
+	op := "dbtrace_test.Store.Slow"
+
+	slowBefore := slowQueries.With("test", op).Value()
+	fast := context.WithValue(context.Background(), traceKey{}, trace{op: op, start: time.Now()})
+	tr.TraceQueryEnd(fast, nil, pgx.TraceQueryEndData{})
+	if buf.Len() != 0 {
+		t.Fatalf("expected no log for a fast query, got %s", buf.String())
+	}
+
+	slow := context.WithValue(context.Background(), traceKey{}, trace{op: op, start: time.Now().Add(-time.Second)})
+	tr.TraceQueryEnd(slow, nil, pgx.TraceQueryEndData{Err: errors.New("boom")})
+	out := buf.String()
+	for _, want := range []string{`"msg":"db.query.slow"`, `"op":"dbtrace_test.Store.Slow"`, `"pool":"test"`, `"result":"server_error"`} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %s in %s", want, out)
+		}
+	}
+	if got := slowQueries.With("test", op).Value(); got != slowBefore+1 {
+		t.Fatalf("slow queries = %v, want %v", got, slowBefore+1)
+	}
+
+	// A query whose start was not traced (tracer added mid-flight) is ignored.
+	tr.TraceQueryEnd(context.Background(), nil, pgx.TraceQueryEndData{})
+}
+
+func TestTracer_Operation(t *testing.T) {
+	tracer := New(nil, "test", 0)
+	ctx := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{})
+	if tr, ok := ctx.Value(traceKey{}).(trace); !ok || tr.op != "unknown" || tr.start.IsZero() {
+		t.Fatalf("trace without an op = %+v, %v", tr, ok)
+	}
+
+	// The innermost name wins.
+	ctx = WithOp(WithOp(context.Background(), "session.rotate_refresh"), "session.get_by_id")
+	ctx = tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{})
+	if tr, _ := ctx.Value(traceKey{}).(trace); tr.op != "session.get_by_id" {
+		t.Fatalf("op = %q, want session.get_by_id", tr.op)
+	}
+}
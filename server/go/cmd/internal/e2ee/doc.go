@@ -0,0 +1,8 @@
+// Package e2ee is the device key registry for end-to-end encrypted messages.
+//
+// Each device uploads its identity key, a signed prekey and a batch of one-time
+// prekeys with POST /me/e2ee/keys. A sender fetches a recipient's prekey bundles
+// with GET /users/{id}/e2ee/prekeys to set up sessions with all of the
+// recipient's devices; each fetch consumes one one-time prekey per device. The
+// server only stores and hands out public keys and never sees message plaintext.
+package e2ee
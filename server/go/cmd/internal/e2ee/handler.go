@@ -0,0 +1,266 @@
+package e2ee
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"arc/cmd/internal/auth/session"
+	"arc/cmd/internal/httpjson"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	keysPath    = "/me/e2ee/keys"
+	prekeysPath = "/users/{id}/e2ee/prekeys"
+
+	requestMaxBodyBytes = 64 << 10 // 64 KiB
+	maxDeviceIDLen      = 128
+	maxPublicKeyLen     = 256
+	maxOneTimePrekeys   = 100
+)
+
+// TokenValidator validates bearer access tokens.
+type TokenValidator interface {
+	ValidateAccessToken(ctx context.Context, token string, now time.Time) (session.AccessClaims, error)
+}
+
+// keyStore is the subset of PostgresStore the Handler needs.
+type keyStore interface {
+	uploadKeys(ctx context.Context, userID string, k DeviceKeys, now time.Time) (int, error)
+	deviceCounts(ctx context.Context, userID string) ([]DeviceCount, error)
+	claimBundles(ctx context.Context, requesterID, userID string) ([]Bundle, error)
+}
+
+// Handler serves device key uploads and prekey bundle fetches.
+type Handler struct {
+	log    *slog.Logger
+	store  keyStore
+	tokens TokenValidator
+	now    func() time.Time
+}
+
+type prekeyJSON struct {
+	KeyID     int64  `json:"key_id"`
+	PublicKey []byte `json:"public_key"`
+}
+
+type signedPrekeyJSON struct {
+	KeyID     int64  `json:"key_id"`
+	PublicKey []byte `json:"public_key"`
+	Signature []byte `json:"signature"`
+}
+
+// Key material is base64 in JSON ([]byte fields).
+type uploadKeysRequest struct {
+	DeviceID       string           `json:"device_id"`
+	IdentityKey    []byte           `json:"identity_key"`
+	SignedPrekey   signedPrekeyJSON `json:"signed_prekey"`
+	OneTimePrekeys []prekeyJSON     `json:"one_time_prekeys,omitempty"`
+}
+
+type deviceCountJSON struct {
+	DeviceID       string `json:"device_id"`
+	OneTimePrekeys int    `json:"one_time_prekeys"`
+}
+
+type devicesResponse struct {
+	Devices []deviceCountJSON `json:"devices"`
+}
+
+type bundleJSON struct {
+	DeviceID      string           `json:"device_id"`
+	IdentityKey   []byte           `json:"identity_key"`
+	SignedPrekey  signedPrekeyJSON `json:"signed_prekey"`
+	OneTimePrekey *prekeyJSON      `json:"one_time_prekey"`
+}
+
+type bundlesResponse struct {
+	UserID  string       `json:"user_id"`
+	Devices []bundleJSON `json:"devices"`
+}
+
+// NewHandler constructs an e2ee Handler.
+func NewHandler(log *slog.Logger, pool *pgxpool.Pool, tokens TokenValidator) (*Handler, error) {
+	if log == nil {
+		log = slog.Default()
+	}
+	if tokens == nil {
+		return nil, errors.New("e2ee: nil token validator")
+	}
+	store, err := NewPostgresStore(pool)
+	if err != nil {
+		return nil, err
+	}
+	return &Handler{log: log, store: store, tokens: tokens, now: time.Now}, nil
+}
+
+// Register wires e2ee routes onto the provided mux.
+func (h *Handler) Register(mux *http.ServeMux) {
+	if h == nil || mux == nil {
+		return
+	}
+	mux.HandleFunc(keysPath, h.handleKeys)
+	mux.HandleFunc(prekeysPath, h.handlePrekeys)
+}
+
+// handleKeys serves POST /me/e2ee/keys (upload a device's keys) and
+// GET /me/e2ee/keys (list the caller's devices and their remaining one-time
+// prekeys, so clients know when to replenish).
+func (h *Handler) handleKeys(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET, POST")
+		httpjson.WriteError(w, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+	claims, ok := h.requireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		counts, err := h.store.deviceCounts(r.Context(), claims.UserID)
+		if err != nil {
+			h.log.Error("e2ee.keys.list.fail", "user_id", claims.UserID, "err", err)
+			httpjson.WriteError(w, http.StatusInternalServerError, "internal_error", "internal error")
+			return
+		}
+		resp := devicesResponse{Devices: make([]deviceCountJSON, 0, len(counts))}
+		for _, c := range counts {
+			resp.Devices = append(resp.Devices, deviceCountJSON(c))
+		}
+		httpjson.Write(w, http.StatusOK, resp)
+		return
+	}
+
+	var req uploadKeysRequest
+	if err := httpjson.Decode(w, r, requestMaxBodyBytes, &req); err != nil {
+		httpjson.WriteError(w, http.StatusBadRequest, "invalid_json", "invalid JSON body")
+		return
+	}
+	k, err := req.deviceKeys()
+	if err != nil {
+		httpjson.WriteError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	n, err := h.store.uploadKeys(r.Context(), claims.UserID, k, h.now().UTC())
+	if err != nil {
+		h.log.Error("e2ee.keys.upload.fail", "user_id", claims.UserID, "err", err)
+		httpjson.WriteError(w, http.StatusInternalServerError, "internal_error", "internal error")
+		return
+	}
+	httpjson.Write(w, http.StatusOK, deviceCountJSON{DeviceID: k.DeviceID, OneTimePrekeys: n})
+}
+
+// handlePrekeys serves GET /users/{id}/e2ee/prekeys. Users other than the caller
+// are only visible when they share a conversation; otherwise, and when the user
+// has no devices, it returns 404.
+func (h *Handler) handlePrekeys(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		httpjson.WriteError(w, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+	claims, ok := h.requireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	userID := strings.TrimSpace(r.PathValue("id"))
+	if userID == "" {
+		httpjson.WriteError(w, http.StatusBadRequest, "invalid_request", "user id is required")
+		return
+	}
+
+	bundles, err := h.store.claimBundles(r.Context(), claims.UserID, userID)
+	if errors.Is(err, errNotFound) {
+		httpjson.WriteError(w, http.StatusNotFound, "not_found", "no device keys found")
+		return
+	}
+	if err != nil {
+		h.log.Error("e2ee.prekeys.fetch.fail", "user_id", userID, "err", err)
+		httpjson.WriteError(w, http.StatusInternalServerError, "internal_error", "internal error")
+		return
+	}
+
+	resp := bundlesResponse{UserID: userID, Devices: make([]bundleJSON, 0, len(bundles))}
+	for _, b := range bundles {
+		out := bundleJSON{
+			DeviceID:    b.DeviceID,
+			IdentityKey: b.IdentityKey,
+			SignedPrekey: signedPrekeyJSON{
+				KeyID:     b.SignedPrekey.KeyID,
+				PublicKey: b.SignedPrekey.PublicKey,
+				Signature: b.SignedPrekey.Signature,
+			},
+		}
+		if b.OneTimePrekey != nil {
+			out.OneTimePrekey = &prekeyJSON{KeyID: b.OneTimePrekey.KeyID, PublicKey: b.OneTimePrekey.PublicKey}
+		}
+		resp.Devices = append(resp.Devices, out)
+	}
+	httpjson.Write(w, http.StatusOK, resp)
+}
+
+// deviceKeys validates the request. Duplicate one-time prekey ids are rejected.
+func (req uploadKeysRequest) deviceKeys() (DeviceKeys, error) {
+	k := DeviceKeys{DeviceID: strings.TrimSpace(req.DeviceID)}
+	if k.DeviceID == "" || len(k.DeviceID) > maxDeviceIDLen {
+		return DeviceKeys{}, errors.New("invalid device_id")
+	}
+	if !validKey(req.IdentityKey) {
+		return DeviceKeys{}, errors.New("invalid identity_key")
+	}
+	k.IdentityKey = req.IdentityKey
+
+	sp := req.SignedPrekey
+	if sp.KeyID < 0 || !validKey(sp.PublicKey) || !validKey(sp.Signature) {
+		return DeviceKeys{}, errors.New("invalid signed_prekey")
+	}
+	k.SignedPrekey = SignedPrekey{Prekey: Prekey{KeyID: sp.KeyID, PublicKey: sp.PublicKey}, Signature: sp.Signature}
+
+	if len(req.OneTimePrekeys) > maxOneTimePrekeys {
+		return DeviceKeys{}, errors.New("too many one_time_prekeys")
+	}
+	seen := make(map[int64]struct{}, len(req.OneTimePrekeys))
+	for _, p := range req.OneTimePrekeys {
+		if _, dup := seen[p.KeyID]; dup || p.KeyID < 0 || !validKey(p.PublicKey) {
+			return DeviceKeys{}, errors.New("invalid one_time_prekeys")
+		}
+		seen[p.KeyID] = struct{}{}
+		k.OneTimePrekeys = append(k.OneTimePrekeys, Prekey(p))
+	}
+	return k, nil
+}
+
+func validKey(b []byte) bool {
+	return len(b) > 0 && len(b) <= maxPublicKeyLen
+}
+
+func (h *Handler) requireAuth(w http.ResponseWriter, r *http.Request) (session.AccessClaims, bool) {
+	token := bearerToken(r)
+	if token == "" {
+		httpjson.WriteError(w, http.StatusUnauthorized, "unauthorized", "missing bearer token")
+		return session.AccessClaims{}, false
+	}
+	claims, err := h.tokens.ValidateAccessToken(r.Context(), token, h.now().UTC())
+	if err != nil || strings.TrimSpace(claims.UserID) == "" {
+		httpjson.WriteError(w, http.StatusUnauthorized, "unauthorized", "invalid token")
+		return session.AccessClaims{}, false
+	}
+	return claims, true
+}
+
+func bearerToken(r *http.Request) string {
+	raw := strings.TrimSpace(r.Header.Get("Authorization"))
+	parts := strings.SplitN(raw, " ", 2)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(parts[1])
+}
@@ -0,0 +1,131 @@
+package e2ee
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"arc/cmd/internal/auth/session"
+	"arc/cmd/internal/httpjson/httpjsontest"
+)
+
+const testUserID = "01HZXUSER00000000000000000"
+
+type fakeTokens struct{}
+
+func (fakeTokens) ValidateAccessToken(_ context.Context, token string, _ time.Time) (session.AccessClaims, error) {
+	if token != "good" {
+		return session.AccessClaims{}, errors.New("invalid")
+	}
+	return session.AccessClaims{UserID: testUserID}, nil
+}
+
+type fakeKeyStore struct {
+	devices map[string]DeviceKeys
+}
+
+func (s *fakeKeyStore) uploadKeys(_ context.Context, _ string, k DeviceKeys, _ time.Time) (int, error) {
+	prev, ok := s.devices[k.DeviceID]
+	if ok && string(prev.IdentityKey) == string(k.IdentityKey) {
+		k.OneTimePrekeys = append(prev.OneTimePrekeys, k.OneTimePrekeys...)
+	}
+	s.devices[k.DeviceID] = k
+	return len(k.OneTimePrekeys), nil
+}
+
+func (s *fakeKeyStore) deviceCounts(_ context.Context, _ string) ([]DeviceCount, error) {
+	var out []DeviceCount
+	for id, k := range s.devices {
+		out = append(out, DeviceCount{DeviceID: id, OneTimePrekeys: len(k.OneTimePrekeys)})
+	}
+	return out, nil
+}
+
+func (s *fakeKeyStore) claimBundles(_ context.Context, _, userID string) ([]Bundle, error) {
+	if userID != testUserID || len(s.devices) == 0 {
+		return nil, errNotFound
+	}
+	var out []Bundle
+	for id, k := range s.devices {
+		b := Bundle{DeviceID: id, IdentityKey: k.IdentityKey, SignedPrekey: k.SignedPrekey}
+		if len(k.OneTimePrekeys) > 0 {
+			p := k.OneTimePrekeys[0]
+			b.OneTimePrekey = &p
+			k.OneTimePrekeys = k.OneTimePrekeys[1:]
+			s.devices[id] = k
+		}
+		out = append(out, b)
+	}
+	return out, nil
+}
+
+func TestHandleKeys(t *testing.T) {
+	h := &Handler{store: &fakeKeyStore{devices: make(map[string]DeviceKeys)}, tokens: fakeTokens{}, now: time.Now}
+	mux := http.NewServeMux()
+	h.Register(mux)
+
+	// "AQID" is base64 for 0x01 0x02 0x03.
+	upload := `{"device_id":"d1","identity_key":"AQID","signed_prekey":{"key_id":1,"public_key":"AQID","signature":"AQID"},` +
+		`"one_time_prekeys":[{"key_id":1,"public_key":"AQID"},{"key_id":2,"public_key":"AQID"}]}`
+	many := make([]string, maxOneTimePrekeys+1)
+	for i := range many {
+		many[i] = `{"key_id":` + strconv.Itoa(i) + `,"public_key":"AQID"}`
+	}
+
+	cases := []struct {
+		name   string
+		method string
+		path   string
+		auth   string
+		body   string
+		status int
+	}{
+		{"method", http.MethodDelete, keysPath, "good", "", http.StatusMethodNotAllowed},
+		{"no token", http.MethodPost, keysPath, "", upload, http.StatusUnauthorized},
+		{"no device", http.MethodPost, keysPath, "good", `{"identity_key":"AQID","signed_prekey":{"key_id":1,"public_key":"AQID","signature":"AQID"}}`, http.StatusBadRequest},
+		{"no identity", http.MethodPost, keysPath, "good", `{"device_id":"d1","signed_prekey":{"key_id":1,"public_key":"AQID","signature":"AQID"}}`, http.StatusBadRequest},
+		{"no signature", http.MethodPost, keysPath, "good", `{"device_id":"d1","identity_key":"AQID","signed_prekey":{"key_id":1,"public_key":"AQID"}}`, http.StatusBadRequest},
+		{"bad base64", http.MethodPost, keysPath, "good", `{"device_id":"d1","identity_key":"!!","signed_prekey":{"key_id":1,"public_key":"AQID","signature":"AQID"}}`, http.StatusBadRequest},
+		{"duplicate prekey", http.MethodPost, keysPath, "good", `{"device_id":"d1","identity_key":"AQID","signed_prekey":{"key_id":1,"public_key":"AQID","signature":"AQID"},"one_time_prekeys":[{"key_id":1,"public_key":"AQID"},{"key_id":1,"public_key":"AQID"}]}`, http.StatusBadRequest},
+		{"too many prekeys", http.MethodPost, keysPath, "good", `{"device_id":"d1","identity_key":"AQID","signed_prekey":{"key_id":1,"public_key":"AQID","signature":"AQID"},"one_time_prekeys":[` + strings.Join(many, ",") + `]}`, http.StatusBadRequest},
+		{"unknown user", http.MethodGet, "/users/someone/e2ee/prekeys", "good", "", http.StatusNotFound},
+		{"upload", http.MethodPost, keysPath, "good", upload, http.StatusOK},
+		{"list", http.MethodGet, keysPath, "good", "", http.StatusOK},
+		{"prekeys method", http.MethodPost, "/users/" + testUserID + "/e2ee/prekeys", "good", "", http.StatusMethodNotAllowed},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httpjsontest.Do(mux, tc.method, tc.path, tc.auth, tc.body)
+			if rec.Code != tc.status {
+				t.Fatalf("got %d want %d: %s", rec.Code, tc.status, rec.Body.String())
+			}
+		})
+	}
+
+	// Each fetch consumes one one-time prekey; the third gets none.
+	for i, want := range []int64{1, 2, -1} {
+		rec := httpjsontest.Do(mux, http.MethodGet, "/users/"+testUserID+"/e2ee/prekeys", "good", "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("fetch %d: got %d (%s)", i, rec.Code, rec.Body.String())
+		}
+		var resp bundlesResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || len(resp.Devices) != 1 {
+			t.Fatalf("fetch %d: %v %s", i, err, rec.Body.String())
+		}
+		d := resp.Devices[0]
+		if string(d.IdentityKey) != "\x01\x02\x03" || string(d.SignedPrekey.Signature) != "\x01\x02\x03" {
+			t.Fatalf("fetch %d: unexpected bundle %+v", i, d)
+		}
+		switch {
+		case want < 0 && d.OneTimePrekey != nil:
+			t.Fatalf("fetch %d: expected no one-time prekey, got %+v", i, d.OneTimePrekey)
+		case want >= 0 && (d.OneTimePrekey == nil || d.OneTimePrekey.KeyID != want):
+			t.Fatalf("fetch %d: one-time prekey = %+v, want key %d", i, d.OneTimePrekey, want)
+		}
+	}
+}
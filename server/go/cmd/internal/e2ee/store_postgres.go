@@ -0,0 +1,240 @@
+package e2ee
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"time"
+
+	"arc/cmd/internal/dbtrace"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var errNotFound = errors.New("not found")
+
+// Prekey is a public prekey and the id the device knows it by.
+type Prekey struct {
+	KeyID     int64
+	PublicKey []byte
+}
+
+// SignedPrekey is a device's medium-term prekey signed with its identity key.
+type SignedPrekey struct {
+	Prekey
+	Signature []byte
+}
+
+// DeviceKeys is one device's upload: its identity key, current signed prekey and
+// new one-time prekeys.
+type DeviceKeys struct {
+	DeviceID       string
+	IdentityKey    []byte
+	SignedPrekey   SignedPrekey
+	OneTimePrekeys []Prekey
+}
+
+// DeviceCount is the number of one-time prekeys a device has left.
+type DeviceCount struct {
+	DeviceID       string
+	OneTimePrekeys int
+}
+
+// Bundle is what a sender needs to start a session with one device. OneTimePrekey
+// is nil once the device has run out.
+type Bundle struct {
+	DeviceID      string
+	IdentityKey   []byte
+	SignedPrekey  SignedPrekey
+	OneTimePrekey *Prekey
+}
+
+// PostgresStore persists device keys in arc.e2ee_devices and
+// arc.e2ee_one_time_prekeys.
+type PostgresStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresStore constructs a PostgresStore.
+func NewPostgresStore(pool *pgxpool.Pool) (*PostgresStore, error) {
+	if pool == nil {
+		return nil, errors.New("e2ee: nil db pool")
+	}
+	return &PostgresStore{pool: pool}, nil
+}
+
+// uploadKeys stores k for userID and returns how many one-time prekeys the device
+// now has. A changed identity key means the device was reset, so its remaining
+// one-time prekeys are dropped. One-time prekey ids already stored are kept as is.
+func (s *PostgresStore) uploadKeys(ctx context.Context, userID string, k DeviceKeys, now time.Time) (int, error) {
+	ctx = dbtrace.WithOp(ctx, "e2ee.upload_keys")
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var identity []byte
+	err = tx.QueryRow(ctx, `
+		SELECT identity_key FROM arc.e2ee_devices WHERE user_id = $1 AND device_id = $2 FOR UPDATE
+	`, userID, k.DeviceID).Scan(&identity)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+	case err != nil:
+		return 0, err
+	case !bytes.Equal(identity, k.IdentityKey):
+		if _, err := tx.Exec(ctx, `
+			DELETE FROM arc.e2ee_one_time_prekeys WHERE user_id = $1 AND device_id = $2
+		`, userID, k.DeviceID); err != nil {
+			return 0, err
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO arc.e2ee_devices (
+		  user_id, device_id, identity_key, signed_prekey_id, signed_prekey, signed_prekey_signature, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+		ON CONFLICT (user_id, device_id) DO UPDATE
+		  SET identity_key = EXCLUDED.identity_key,
+		      signed_prekey_id = EXCLUDED.signed_prekey_id,
+		      signed_prekey = EXCLUDED.signed_prekey,
+		      signed_prekey_signature = EXCLUDED.signed_prekey_signature
+	`, userID, k.DeviceID, k.IdentityKey, k.SignedPrekey.KeyID, k.SignedPrekey.PublicKey, k.SignedPrekey.Signature, now); err != nil {
+		return 0, err
+	}
+
+	for _, p := range k.OneTimePrekeys {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO arc.e2ee_one_time_prekeys (user_id, device_id, key_id, public_key, created_at)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (user_id, device_id, key_id) DO NOTHING
+		`, userID, k.DeviceID, p.KeyID, p.PublicKey, now); err != nil {
+			return 0, err
+		}
+	}
+
+	var n int
+	if err := tx.QueryRow(ctx, `
+		SELECT count(*) FROM arc.e2ee_one_time_prekeys WHERE user_id = $1 AND device_id = $2
+	`, userID, k.DeviceID).Scan(&n); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// deviceCounts lists userID's devices with their remaining one-time prekeys.
+func (s *PostgresStore) deviceCounts(ctx context.Context, userID string) ([]DeviceCount, error) {
+	ctx = dbtrace.WithOp(ctx, "e2ee.device_counts")
+	rows, err := s.pool.Query(ctx, `
+		SELECT d.device_id, count(p.key_id)
+		FROM arc.e2ee_devices d
+		LEFT JOIN arc.e2ee_one_time_prekeys p
+		  ON p.user_id = d.user_id AND p.device_id = d.device_id
+		WHERE d.user_id = $1
+		GROUP BY d.device_id
+		ORDER BY d.device_id
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []DeviceCount{}
+	for rows.Next() {
+		var c DeviceCount
+		if err := rows.Scan(&c.DeviceID, &c.OneTimePrekeys); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// claimBundles returns a bundle per device of userID, consuming one one-time
+// prekey of each. It returns errNotFound when userID has no devices or requesterID
+// is neither userID nor a member of a conversation with them.
+func (s *PostgresStore) claimBundles(ctx context.Context, requesterID, userID string) ([]Bundle, error) {
+	ctx = dbtrace.WithOp(ctx, "e2ee.claim_bundles")
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if requesterID != userID {
+		var shares bool
+		if err := tx.QueryRow(ctx, `
+			SELECT EXISTS (
+			  SELECT 1
+			  FROM arc.conversation_members a
+			  JOIN arc.conversation_members b ON b.conversation_id = a.conversation_id
+			  WHERE a.user_id = $1 AND b.user_id = $2
+			)
+		`, requesterID, userID).Scan(&shares); err != nil {
+			return nil, err
+		}
+		if !shares {
+			return nil, errNotFound
+		}
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT device_id, identity_key, signed_prekey_id, signed_prekey, signed_prekey_signature
+		FROM arc.e2ee_devices
+		WHERE user_id = $1
+		ORDER BY device_id
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	var out []Bundle
+	for rows.Next() {
+		var b Bundle
+		if err := rows.Scan(&b.DeviceID, &b.IdentityKey, &b.SignedPrekey.KeyID, &b.SignedPrekey.PublicKey, &b.SignedPrekey.Signature); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		out = append(out, b)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(out) == 0 {
+		return nil, errNotFound
+	}
+
+	// SKIP LOCKED lets concurrent fetches each claim a different key instead of
+	// waiting on (and then missing) the same one.
+	for i := range out {
+		var p Prekey
+		err := tx.QueryRow(ctx, `
+			DELETE FROM arc.e2ee_one_time_prekeys
+			WHERE (user_id, device_id, key_id) = (
+			  SELECT user_id, device_id, key_id
+			  FROM arc.e2ee_one_time_prekeys
+			  WHERE user_id = $1 AND device_id = $2
+			  ORDER BY key_id
+			  LIMIT 1
+			  FOR UPDATE SKIP LOCKED
+			)
+			RETURNING key_id, public_key
+		`, userID, out[i].DeviceID).Scan(&p.KeyID, &p.PublicKey)
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+		case err != nil:
+			return nil, err
+		default:
+			out[i].OneTimePrekey = &p
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,19 @@
+// Package featureflags holds the named on/off switches consulted by authapi and
+// the realtime gateway.
+//
+// A flag's value comes from, in order of precedence:
+//
+//   - an override row in <schema>.feature_flags, set through
+//     PUT /admin/feature-flags/{name} and cleared with DELETE;
+//   - ARC_FEATURE_FLAGS, a comma-separated list such as
+//     "auth.open_signup=true,realtime.presence=false" (a bare name means true);
+//   - the flag's built-in default.
+//
+// Overrides are cached per process for ARC_FEATURE_FLAGS_CACHE_TTL (default 30s),
+// so other nodes pick up an admin change within that window. When the database
+// cannot be read the last loaded overrides stay in effect.
+//
+// Each tenant schema keeps its own overrides table, and a tenant's config block
+// may set ARC_FEATURE_FLAGS for that tenant; tenants on the arc schema share the
+// deployment's flags.
+package featureflags
@@ -0,0 +1,289 @@
+package featureflags
+
+import (
+	"arc/cmd/internal/config"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Flag names.
+const (
+	// AuthOpenSignup lets /auth/invites/consume create accounts without an invite
+	// token. When set it takes precedence over ARC_AUTH_INVITE_ONLY.
+	AuthOpenSignup = "auth.open_signup"
+	// AuthMFARequired sends every password login through the emailed login
+	// challenge, not only logins from unrecognized devices.
+	AuthMFARequired = "auth.mfa_required"
+	// RealtimeMessageEdits allows message.edit and message.delete.
+	RealtimeMessageEdits = "realtime.message_edits"
+	// RealtimePresence allows presence.subscribe and presence.update.
+	RealtimePresence = "realtime.presence"
+)
+
+// DefaultCacheTTL is how long overrides loaded from the database are reused.
+const DefaultCacheTTL = 30 * time.Second
+
+// Sources of a flag's current value.
+const (
+	SourceDefault  = "default"
+	SourceConfig   = "config"
+	SourceOverride = "override"
+)
+
+var (
+	// ErrUnknownFlag is returned for names that are not in Known.
+	ErrUnknownFlag = errors.New("featureflags: unknown flag")
+	// ErrNoStore is returned when overrides are changed without a database.
+	ErrNoStore = errors.New("featureflags: overrides need a database")
+)
+
+// Flag describes a known flag.
+type Flag struct {
+	Name        string
+	Description string
+	Default     bool
+}
+
+var known = []Flag{
+	{Name: AuthOpenSignup, Description: "Allow signup without an invite token."},
+	{Name: AuthMFARequired, Description: "Require the emailed login challenge on every password login."},
+	{Name: RealtimeMessageEdits, Description: "Allow editing and deleting sent messages.", Default: true},
+	{Name: RealtimePresence, Description: "Allow presence subscriptions and updates.", Default: true},
+}
+
+// Known returns the known flags ordered by name.
+func Known() []Flag {
+	out := slices.Clone(known)
+	slices.SortFunc(out, func(a, b Flag) int { return strings.Compare(a.Name, b.Name) })
+	return out
+}
+
+// Lookup returns the known flag called name.
+func Lookup(name string) (Flag, bool) {
+	for _, f := range known {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Flag{}, false
+}
+
+// Config is the static part of the flag values.
+type Config struct {
+	// Values maps flag names to the value set in ARC_FEATURE_FLAGS.
+	Values map[string]bool
+	// CacheTTL bounds how stale database overrides may be.
+	CacheTTL time.Duration
+}
+
+// LoadConfigFromEnv reads ARC_FEATURE_FLAGS and ARC_FEATURE_FLAGS_CACHE_TTL.
+func LoadConfigFromEnv() (Config, error) {
+	return LoadConfigFrom(config.Env)
+}
+
+// LoadConfigFrom is LoadConfigFromEnv reading the settings through lookup.
+func LoadConfigFrom(lookup config.Lookup) (Config, error) {
+	values, err := ParseValues(lookup.Get("ARC_FEATURE_FLAGS"))
+	if err != nil {
+		return Config{}, fmt.Errorf("ARC_FEATURE_FLAGS: %w", err)
+	}
+	cfg := Config{Values: values, CacheTTL: DefaultCacheTTL}
+	if raw := strings.TrimSpace(lookup.Get("ARC_FEATURE_FLAGS_CACHE_TTL")); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d < 0 {
+			return Config{}, fmt.Errorf("ARC_FEATURE_FLAGS_CACHE_TTL %q: want a non-negative duration", raw)
+		}
+		cfg.CacheTTL = d
+	}
+	return cfg, nil
+}
+
+// ParseValues parses "name=bool" entries separated by commas. A bare name is true.
+func ParseValues(s string) (map[string]bool, error) {
+	values := map[string]bool{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, raw, hasValue := strings.Cut(part, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := Lookup(name); !ok {
+			return nil, fmt.Errorf("%w %q", ErrUnknownFlag, name)
+		}
+		v := true
+		if hasValue {
+			var err error
+			if v, err = strconv.ParseBool(strings.TrimSpace(raw)); err != nil {
+				return nil, fmt.Errorf("%s: invalid value %q", name, raw)
+			}
+		}
+		values[name] = v
+	}
+	return values, nil
+}
+
+// State is the current value of a flag and where it came from.
+type State struct {
+	Flag
+	Enabled bool
+	Source  string
+	// UpdatedAt and UpdatedBy describe the override (zero otherwise).
+	UpdatedAt time.Time
+	UpdatedBy string
+}
+
+// Flags resolves flag values for one schema. The zero value is not usable; a nil
+// *Flags reports every flag at its default.
+type Flags struct {
+	store Store
+	ttl   time.Duration
+	now   func() time.Time
+
+	static atomic.Pointer[map[string]bool]
+
+	mu        sync.Mutex
+	overrides map[string]Override
+	loadedAt  time.Time
+	loaded    bool
+}
+
+// New returns flags backed by cfg and, when store is non-nil, database overrides.
+func New(cfg Config, store Store) *Flags {
+	f := &Flags{store: store, ttl: cfg.CacheTTL, now: time.Now}
+	f.SetStatic(cfg.Values)
+	return f
+}
+
+// SetStatic replaces the ARC_FEATURE_FLAGS values, e.g. after a config reload.
+func (f *Flags) SetStatic(values map[string]bool) {
+	m := make(map[string]bool, len(values))
+	for k, v := range values {
+		m[k] = v
+	}
+	f.static.Store(&m)
+}
+
+// Enabled reports whether the flag called name is on. Unknown names are off.
+func (f *Flags) Enabled(ctx context.Context, name string) bool {
+	return f.State(ctx, name).Enabled
+}
+
+// State returns the current value of the flag called name.
+func (f *Flags) State(ctx context.Context, name string) State {
+	def, ok := Lookup(name)
+	if !ok {
+		return State{Flag: Flag{Name: name}, Source: SourceDefault}
+	}
+	if f == nil {
+		return State{Flag: def, Enabled: def.Default, Source: SourceDefault}
+	}
+	return f.state(def, f.cachedOverrides(ctx))
+}
+
+// List returns the state of every known flag ordered by name.
+func (f *Flags) List(ctx context.Context) []State {
+	var overrides map[string]Override
+	if f != nil {
+		overrides = f.cachedOverrides(ctx)
+	}
+	flags := Known()
+	out := make([]State, 0, len(flags))
+	for _, def := range flags {
+		if f == nil {
+			out = append(out, State{Flag: def, Enabled: def.Default, Source: SourceDefault})
+			continue
+		}
+		out = append(out, f.state(def, overrides))
+	}
+	return out
+}
+
+func (f *Flags) state(def Flag, overrides map[string]Override) State {
+	if o, ok := overrides[def.Name]; ok {
+		return State{Flag: def, Enabled: o.Enabled, Source: SourceOverride, UpdatedAt: o.UpdatedAt, UpdatedBy: o.UpdatedBy}
+	}
+	if v, ok := (*f.static.Load())[def.Name]; ok {
+		return State{Flag: def, Enabled: v, Source: SourceConfig}
+	}
+	return State{Flag: def, Enabled: def.Default, Source: SourceDefault}
+}
+
+// Set stores an override for name, effective immediately on this node.
+func (f *Flags) Set(ctx context.Context, name string, enabled bool, actorID string) (State, error) {
+	def, ok := Lookup(name)
+	if !ok {
+		return State{}, ErrUnknownFlag
+	}
+	if f == nil || f.store == nil {
+		return State{}, ErrNoStore
+	}
+	o, err := f.store.Set(ctx, f.now().UTC(), name, enabled, actorID)
+	if err != nil {
+		return State{}, err
+	}
+	f.mu.Lock()
+	m := maps.Clone(f.overrides)
+	if m == nil {
+		m = map[string]Override{}
+	}
+	m[name] = o
+	f.overrides = m
+	f.mu.Unlock()
+	return f.State(ctx, def.Name), nil
+}
+
+// Clear removes the override for name, so the config value or default applies.
+func (f *Flags) Clear(ctx context.Context, name string) (State, error) {
+	def, ok := Lookup(name)
+	if !ok {
+		return State{}, ErrUnknownFlag
+	}
+	if f == nil || f.store == nil {
+		return State{}, ErrNoStore
+	}
+	if err := f.store.Delete(ctx, name); err != nil {
+		return State{}, err
+	}
+	f.mu.Lock()
+	m := maps.Clone(f.overrides)
+	delete(m, name)
+	f.overrides = m
+	f.mu.Unlock()
+	return f.State(ctx, def.Name), nil
+}
+
+// cachedOverrides returns the overrides, reloading them once the cache expires.
+// A failed reload keeps the previous overrides until the next expiry. The map is
+// replaced, never modified, so callers may read it without the lock.
+func (f *Flags) cachedOverrides(ctx context.Context) map[string]Override {
+	if f.store == nil {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := f.now()
+	if f.loaded && now.Sub(f.loadedAt) < f.ttl {
+		return f.overrides
+	}
+	f.loaded, f.loadedAt = true, now
+	list, err := f.store.List(ctx)
+	if err != nil {
+		refreshFailures.Inc()
+		return f.overrides
+	}
+	m := make(map[string]Override, len(list))
+	for _, o := range list {
+		m[o.Name] = o
+	}
+	f.overrides = m
+	return m
+}
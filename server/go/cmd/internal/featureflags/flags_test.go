@@ -0,0 +1,178 @@
+package featureflags
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type memoryStore struct {
+	rows  map[string]Override
+	lists int
+	err   error
+}
+
+func (s *memoryStore) List(context.Context) ([]Override, error) {
+	s.lists++
+	if s.err != nil {
+		return nil, s.err
+	}
+	var out []Override
+	for _, o := range s.rows {
+		out = append(out, o)
+	}
+	return out, nil
+}
+
+func (s *memoryStore) Set(_ context.Context, now time.Time, name string, enabled bool, actorID string) (Override, error) {
+	if s.err != nil {
+		return Override{}, s.err
+	}
+	o := Override{Name: name, Enabled: enabled, UpdatedAt: now, UpdatedBy: actorID}
+	s.rows[name] = o
+	return o, nil
+}
+
+func (s *memoryStore) Delete(_ context.Context, name string) error {
+	if s.err != nil {
+		return s.err
+	}
+	delete(s.rows, name)
+	return nil
+}
+
+func TestParseValues(t *testing.T) {
+	got, err := ParseValues(" auth.open_signup , realtime.presence=false,AUTH.MFA_REQUIRED=1,")
+	if err != nil {
+		t.Fatalf("ParseValues: %v", err)
+	}
+	if !got[AuthOpenSignup] || got[RealtimePresence] || !got[AuthMFARequired] || len(got) != 3 {
+		t.Fatalf("values = %v", got)
+	}
+
+	if _, err := ParseValues("auth.nope"); !errors.Is(err, ErrUnknownFlag) {
+		t.Fatalf("unknown flag err = %v", err)
+	}
+	if _, err := ParseValues("auth.open_signup=maybe"); err == nil {
+		t.Fatalf("expected error for non-boolean value")
+	}
+}
+
+func TestLoadConfigFromEnv(t *testing.T) {
+	t.Setenv("ARC_FEATURE_FLAGS", "realtime.message_edits=false")
+	t.Setenv("ARC_FEATURE_FLAGS_CACHE_TTL", "5s")
+	cfg, err := LoadConfigFromEnv()
+	if err != nil {
+		t.Fatalf("LoadConfigFromEnv: %v", err)
+	}
+	if v, ok := cfg.Values[RealtimeMessageEdits]; !ok || v || cfg.CacheTTL != 5*time.Second {
+		t.Fatalf("cfg = %+v", cfg)
+	}
+
+	t.Setenv("ARC_FEATURE_FLAGS_CACHE_TTL", "-1s")
+	if _, err := LoadConfigFromEnv(); err == nil {
+		t.Fatalf("expected error for negative TTL")
+	}
+}
+
+func TestFlags_Precedence(t *testing.T) {
+	ctx := context.Background()
+	store := &memoryStore{rows: map[string]Override{}}
+	f := New(Config{Values: map[string]bool{AuthOpenSignup: true, RealtimePresence: false}}, store)
+
+	if st := f.State(ctx, AuthOpenSignup); !st.Enabled || st.Source != SourceConfig {
+		t.Fatalf("open_signup = %+v, want config true", st)
+	}
+	if st := f.State(ctx, RealtimeMessageEdits); !st.Enabled || st.Source != SourceDefault {
+		t.Fatalf("message_edits = %+v, want default true", st)
+	}
+
+	st, err := f.Set(ctx, AuthOpenSignup, false, "admin")
+	if err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if st.Enabled || st.Source != SourceOverride || st.UpdatedBy != "admin" {
+		t.Fatalf("after Set = %+v, want override false", st)
+	}
+	if f.Enabled(ctx, AuthOpenSignup) {
+		t.Fatalf("expected override to win over config")
+	}
+
+	st, err = f.Clear(ctx, AuthOpenSignup)
+	if err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+	if !st.Enabled || st.Source != SourceConfig {
+		t.Fatalf("after Clear = %+v, want config true", st)
+	}
+
+	f.SetStatic(nil)
+	if st := f.State(ctx, AuthOpenSignup); st.Enabled || st.Source != SourceDefault {
+		t.Fatalf("after SetStatic = %+v, want default false", st)
+	}
+
+	if _, err := f.Set(ctx, "auth.nope", true, ""); !errors.Is(err, ErrUnknownFlag) {
+		t.Fatalf("Set unknown err = %v", err)
+	}
+	if f.Enabled(ctx, "auth.nope") {
+		t.Fatalf("unknown flag should be off")
+	}
+}
+
+func TestFlags_CacheAndStaleOnError(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := &memoryStore{rows: map[string]Override{
+		RealtimePresence: {Name: RealtimePresence, Enabled: false},
+	}}
+	f := New(Config{CacheTTL: time.Minute}, store)
+	f.now = func() time.Time { return now }
+
+	if f.Enabled(ctx, RealtimePresence) {
+		t.Fatalf("expected override to disable presence")
+	}
+	// Another node clears the override; this node keeps its cache until the TTL.
+	delete(store.rows, RealtimePresence)
+	if f.Enabled(ctx, RealtimePresence) || store.lists != 1 {
+		t.Fatalf("expected cached override, lists = %d", store.lists)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if !f.Enabled(ctx, RealtimePresence) || store.lists != 2 {
+		t.Fatalf("expected reload after TTL, lists = %d", store.lists)
+	}
+
+	store.rows[RealtimePresence] = Override{Name: RealtimePresence, Enabled: false}
+	now = now.Add(2 * time.Minute)
+	f.Enabled(ctx, RealtimePresence)
+	store.err = errors.New("db down")
+	now = now.Add(2 * time.Minute)
+	if f.Enabled(ctx, RealtimePresence) {
+		t.Fatalf("expected previous overrides to stay in effect on reload failure")
+	}
+	f.Enabled(ctx, RealtimePresence)
+	if store.lists != 4 {
+		t.Fatalf("expected failed reload to wait for the next expiry, lists = %d", store.lists)
+	}
+}
+
+func TestFlags_NilAndNoStore(t *testing.T) {
+	ctx := context.Background()
+
+	var nilFlags *Flags
+	if !nilFlags.Enabled(ctx, RealtimeMessageEdits) || nilFlags.Enabled(ctx, AuthMFARequired) {
+		t.Fatalf("nil flags should report defaults")
+	}
+	if got := nilFlags.List(ctx); len(got) != len(known) {
+		t.Fatalf("List = %d flags, want %d", len(got), len(known))
+	}
+
+	f := New(Config{}, nil)
+	if _, err := f.Set(ctx, AuthMFARequired, true, ""); !errors.Is(err, ErrNoStore) {
+		t.Fatalf("Set without store err = %v", err)
+	}
+	if _, err := f.Clear(ctx, AuthMFARequired); !errors.Is(err, ErrNoStore) {
+		t.Fatalf("Clear without store err = %v", err)
+	}
+}
@@ -0,0 +1,6 @@
+package featureflags
+
+import "arc/cmd/internal/metrics"
+
+var refreshFailures = metrics.NewCounter("arc_feature_flags_refresh_failures_total",
+	"Feature flag override reloads that failed; the previous overrides stayed in effect.")
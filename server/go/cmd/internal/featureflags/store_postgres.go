@@ -0,0 +1,126 @@
+package featureflags
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"arc/cmd/internal/dbtrace"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Override is a flag value set at runtime.
+type Override struct {
+	Name      string
+	Enabled   bool
+	UpdatedAt time.Time
+	// UpdatedBy is the admin user ID (empty when unknown or since deleted).
+	UpdatedBy string
+}
+
+// Store persists overrides.
+type Store interface {
+	// List returns every override.
+	List(ctx context.Context) ([]Override, error)
+	// Set creates or replaces the override for name.
+	Set(ctx context.Context, now time.Time, name string, enabled bool, actorID string) (Override, error)
+	// Delete removes the override for name; a missing row is not an error.
+	Delete(ctx context.Context, name string) error
+}
+
+// PostgresStore implements Store using <schema>.feature_flags (default arc).
+type PostgresStore struct {
+	pool   *pgxpool.Pool
+	schema string
+}
+
+// PostgresOption configures PostgresStore.
+type PostgresOption func(*PostgresStore) error
+
+var pgIdentRE = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// WithSchema sets the DB schema holding feature_flags (default: "arc").
+func WithSchema(schema string) PostgresOption {
+	return func(s *PostgresStore) error {
+		schema = strings.TrimSpace(schema)
+		if schema == "" {
+			return errors.New("featureflags: empty schema")
+		}
+		if !pgIdentRE.MatchString(schema) {
+			return errors.New("featureflags: invalid schema identifier")
+		}
+		s.schema = schema
+		return nil
+	}
+}
+
+// NewPostgresStore creates a Postgres-backed override store.
+func NewPostgresStore(pool *pgxpool.Pool, opts ...PostgresOption) (*PostgresStore, error) {
+	st := &PostgresStore{pool: pool, schema: "arc"}
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		if err := opt(st); err != nil {
+			return nil, err
+		}
+	}
+	return st, nil
+}
+
+func (s *PostgresStore) table() string {
+	return pgx.Identifier{s.schema, "feature_flags"}.Sanitize()
+}
+
+// List returns every override ordered by name.
+func (s *PostgresStore) List(ctx context.Context) ([]Override, error) {
+	ctx = dbtrace.WithOp(ctx, "featureflags.list")
+	rows, err := s.pool.Query(ctx, `
+		SELECT name, enabled, updated_at, COALESCE(updated_by, '')
+		FROM `+s.table()+`
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Override
+	for rows.Next() {
+		var o Override
+		if err := rows.Scan(&o.Name, &o.Enabled, &o.UpdatedAt, &o.UpdatedBy); err != nil {
+			return nil, err
+		}
+		out = append(out, o)
+	}
+	return out, rows.Err()
+}
+
+// Set upserts the override for name.
+func (s *PostgresStore) Set(ctx context.Context, now time.Time, name string, enabled bool, actorID string) (Override, error) {
+	ctx = dbtrace.WithOp(ctx, "featureflags.set")
+	var actor *string
+	if actorID != "" {
+		actor = &actorID
+	}
+	o := Override{Name: name}
+	err := s.pool.QueryRow(ctx, `
+		INSERT INTO `+s.table()+` (name, enabled, updated_at, updated_by)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (name) DO UPDATE
+		SET enabled = EXCLUDED.enabled, updated_at = EXCLUDED.updated_at, updated_by = EXCLUDED.updated_by
+		RETURNING enabled, updated_at, COALESCE(updated_by, '')
+	`, name, enabled, now, actor).Scan(&o.Enabled, &o.UpdatedAt, &o.UpdatedBy)
+	return o, err
+}
+
+// Delete removes the override for name.
+func (s *PostgresStore) Delete(ctx context.Context, name string) error {
+	ctx = dbtrace.WithOp(ctx, "featureflags.delete")
+	_, err := s.pool.Exec(ctx, `DELETE FROM `+s.table()+` WHERE name = $1`, name)
+	return err
+}
@@ -0,0 +1,68 @@
+package geoip
+
+import (
+	"arc/cmd/internal/config"
+	"strings"
+	"time"
+)
+
+// Config selects and tunes the GeoIP resolver.
+type Config struct {
+	// CityDBPath is a MaxMind City (or Country) database file.
+	CityDBPath string
+	// ASNDBPath is a MaxMind ASN database file.
+	ASNDBPath string
+
+	// ServiceURL is an external lookup endpoint; "{ip}" is replaced by the address.
+	// It is used only when no database file is configured.
+	ServiceURL string
+	// ServiceTimeout bounds a single external lookup.
+	ServiceTimeout time.Duration
+	// CacheSize bounds the external lookup cache (entries).
+	CacheSize int
+	// CacheTTL bounds how long external lookups are reused.
+	CacheTTL time.Duration
+}
+
+// DefaultConfig returns the lookup settings with no database or service set,
+// which resolves nothing.
+func DefaultConfig() Config {
+	return Config{
+		ServiceTimeout: 500 * time.Millisecond,
+		CacheSize:      10000,
+		CacheTTL:       time.Hour,
+	}
+}
+
+// LoadConfigFromEnv loads GeoIP configuration from environment variables.
+func LoadConfigFromEnv() Config {
+	return LoadConfigFrom(config.Env)
+}
+
+// LoadConfigFrom is LoadConfigFromEnv reading the settings through lookup.
+func LoadConfigFrom(lookup config.Lookup) Config {
+	cfg := DefaultConfig()
+	cfg.CityDBPath = strings.TrimSpace(lookup.Get("ARC_GEOIP_CITY_DB"))
+	cfg.ASNDBPath = strings.TrimSpace(lookup.Get("ARC_GEOIP_ASN_DB"))
+	cfg.ServiceURL = strings.TrimSpace(lookup.Get("ARC_GEOIP_SERVICE_URL"))
+	if v := strings.TrimSpace(lookup.Get("ARC_GEOIP_SERVICE_TIMEOUT")); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			cfg.ServiceTimeout = d
+		}
+	}
+	return cfg
+}
+
+// NewResolver builds the resolver described by cfg.
+//
+// Database files take precedence over an external service. With nothing
+// configured it returns NoopResolver.
+func NewResolver(cfg Config) (Resolver, error) {
+	if cfg.CityDBPath != "" || cfg.ASNDBPath != "" {
+		return OpenMMDB(cfg.CityDBPath, cfg.ASNDBPath)
+	}
+	if cfg.ServiceURL != "" {
+		return NewHTTPResolver(cfg.ServiceURL, cfg.ServiceTimeout, cfg.CacheSize, cfg.CacheTTL)
+	}
+	return NoopResolver{}, nil
+}
@@ -0,0 +1,11 @@
+// Package geoip resolves client IP addresses to coarse network geography.
+//
+// Resolvers are pluggable:
+// - MMDBResolver reads MaxMind DB files (GeoLite2/GeoIP2 City and ASN).
+// - HTTPResolver queries an external JSON lookup service.
+// - NoopResolver is the default and resolves nothing.
+//
+// Results are advisory: they annotate sessions and audit rows and feed risk
+// signals. The only authorization decisions made on them are the country lists
+// an operator opts into through package ipaccess.
+package geoip
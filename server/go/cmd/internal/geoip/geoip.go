@@ -0,0 +1,45 @@
+package geoip
+
+import (
+	"context"
+	"net"
+)
+
+// Location is the coarse geography of an IP address.
+type Location struct {
+	// Country is the ISO 3166-1 alpha-2 country code.
+	Country string `json:"country,omitempty"`
+	// City is the English city name.
+	City string `json:"city,omitempty"`
+	// ASN is the autonomous system number of the announcing network.
+	ASN uint32 `json:"asn,omitempty"`
+	// ASOrg is the autonomous system organization name.
+	ASOrg string `json:"as_org,omitempty"`
+}
+
+// IsZero reports whether nothing was resolved.
+func (l Location) IsZero() bool {
+	return l == Location{}
+}
+
+// Resolver resolves IP addresses to locations.
+//
+// Implementations return a zero Location and nil error when the address is unknown.
+type Resolver interface {
+	Lookup(ctx context.Context, ip net.IP) (Location, error)
+}
+
+// NoopResolver resolves nothing.
+type NoopResolver struct{}
+
+// Lookup always returns a zero Location.
+func (NoopResolver) Lookup(_ context.Context, _ net.IP) (Location, error) { return Location{}, nil }
+
+// isPublic reports whether ip can meaningfully be geolocated.
+func isPublic(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast())
+}
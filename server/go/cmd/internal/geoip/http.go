@@ -0,0 +1,134 @@
+package geoip
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrInvalidServiceURL is returned when the external service URL cannot be used.
+var ErrInvalidServiceURL = errors.New("geoip: invalid service url")
+
+// HTTPResolver resolves addresses through an external JSON service.
+//
+// The service must answer GET <url with {ip} substituted> with a JSON object:
+//
+//	{"country":"DE","city":"Berlin","asn":3320,"as_org":"Deutsche Telekom AG"}
+//
+// Results (including misses) are cached in memory to keep login latency bounded.
+type HTTPResolver struct {
+	urlTemplate string
+	client      *http.Client
+
+	mu       sync.Mutex
+	cache    map[string]cacheEntry
+	maxCache int
+	ttl      time.Duration
+}
+
+type cacheEntry struct {
+	loc Location
+	exp time.Time
+}
+
+// NewHTTPResolver creates an HTTPResolver. urlTemplate must contain "{ip}".
+func NewHTTPResolver(urlTemplate string, timeout time.Duration, cacheSize int, cacheTTL time.Duration) (*HTTPResolver, error) {
+	urlTemplate = strings.TrimSpace(urlTemplate)
+	if !strings.Contains(urlTemplate, "{ip}") {
+		return nil, ErrInvalidServiceURL
+	}
+	u, err := url.Parse(strings.ReplaceAll(urlTemplate, "{ip}", "127.0.0.1"))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, ErrInvalidServiceURL
+	}
+	if timeout <= 0 {
+		timeout = 500 * time.Millisecond
+	}
+	return &HTTPResolver{
+		urlTemplate: urlTemplate,
+		client:      &http.Client{Timeout: timeout},
+		cache:       make(map[string]cacheEntry),
+		maxCache:    cacheSize,
+		ttl:         cacheTTL,
+	}, nil
+}
+
+// Lookup resolves ip via the external service.
+func (r *HTTPResolver) Lookup(ctx context.Context, ip net.IP) (Location, error) {
+	if r == nil || !isPublic(ip) {
+		return Location{}, nil
+	}
+	key := ip.String()
+	now := time.Now()
+
+	if loc, ok := r.cached(key, now); ok {
+		return loc, nil
+	}
+
+	endpoint := strings.ReplaceAll(r.urlTemplate, "{ip}", url.PathEscape(key))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return Location{}, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return Location{}, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode == http.StatusNotFound {
+		r.store(key, Location{}, now)
+		return Location{}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Location{}, fmt.Errorf("geoip: service status %d", resp.StatusCode)
+	}
+
+	var loc Location
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&loc); err != nil {
+		return Location{}, err
+	}
+	loc.Country = strings.ToUpper(strings.TrimSpace(loc.Country))
+	r.store(key, loc, now)
+	return loc, nil
+}
+
+func (r *HTTPResolver) cached(key string, now time.Time) (Location, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, ok := r.cache[key]
+	if !ok || now.After(e.exp) {
+		return Location{}, false
+	}
+	return e.loc, true
+}
+
+func (r *HTTPResolver) store(key string, loc Location, now time.Time) {
+	if r.maxCache <= 0 || r.ttl <= 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.cache) >= r.maxCache {
+		// Cheap bounded eviction: drop expired entries, then everything if still full.
+		for k, e := range r.cache {
+			if now.After(e.exp) {
+				delete(r.cache, k)
+			}
+		}
+		if len(r.cache) >= r.maxCache {
+			r.cache = make(map[string]cacheEntry)
+		}
+	}
+	r.cache[key] = cacheEntry{loc: loc, exp: now.Add(r.ttl)}
+}
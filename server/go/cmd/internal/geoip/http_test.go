@@ -0,0 +1,57 @@
+package geoip
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHTTPResolver_LookupAndCache(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.URL.Path != "/lookup/203.0.113.7" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"country":"de","city":"Berlin","asn":3320,"as_org":"Example AS"}`))
+	}))
+	defer srv.Close()
+
+	r, err := NewHTTPResolver(srv.URL+"/lookup/{ip}", time.Second, 16, time.Minute)
+	if err != nil {
+		t.Fatalf("NewHTTPResolver: %v", err)
+	}
+
+	ip := net.ParseIP("203.0.113.7")
+	for i := 0; i < 2; i++ {
+		loc, err := r.Lookup(context.Background(), ip)
+		if err != nil {
+			t.Fatalf("Lookup: %v", err)
+		}
+		if loc.Country != "DE" || loc.City != "Berlin" || loc.ASN != 3320 {
+			t.Fatalf("unexpected location: %+v", loc)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("expected cached second lookup, got %d calls", calls)
+	}
+
+	loc, err := r.Lookup(context.Background(), net.ParseIP("198.51.100.1"))
+	if err != nil || !loc.IsZero() {
+		t.Fatalf("expected zero location for 404, got %+v err=%v", loc, err)
+	}
+}
+
+func TestNewHTTPResolver_RequiresPlaceholder(t *testing.T) {
+	if _, err := NewHTTPResolver("https://geo.example.com/lookup", time.Second, 0, 0); err == nil {
+		t.Fatalf("expected error without {ip} placeholder")
+	}
+	if _, err := NewHTTPResolver("ftp://geo.example.com/{ip}", time.Second, 0, 0); err == nil {
+		t.Fatalf("expected error for non-http scheme")
+	}
+}
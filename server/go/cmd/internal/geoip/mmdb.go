@@ -0,0 +1,97 @@
+package geoip
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// MMDBResolver resolves addresses from MaxMind DB files.
+//
+// The City and ASN databases are separate products; either may be omitted.
+type MMDBResolver struct {
+	city *maxminddb.Reader
+	asn  *maxminddb.Reader
+}
+
+// cityRecord is the part of a GeoIP2/GeoLite2 City or Country record Lookup reads.
+type cityRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+}
+
+// asnRecord is a GeoIP2/GeoLite2 ASN record.
+type asnRecord struct {
+	Number       uint32 `maxminddb:"autonomous_system_number"`
+	Organization string `maxminddb:"autonomous_system_organization"`
+}
+
+// OpenMMDB opens the given database files. Empty paths are skipped.
+func OpenMMDB(cityPath string, asnPath string) (*MMDBResolver, error) {
+	r := &MMDBResolver{}
+	if cityPath != "" {
+		db, err := maxminddb.Open(cityPath)
+		if err != nil {
+			return nil, fmt.Errorf("geoip: open city db: %w", err)
+		}
+		r.city = db
+	}
+	if asnPath != "" {
+		db, err := maxminddb.Open(asnPath)
+		if err != nil {
+			_ = r.Close()
+			return nil, fmt.Errorf("geoip: open asn db: %w", err)
+		}
+		r.asn = db
+	}
+	return r, nil
+}
+
+// Lookup resolves ip from the loaded databases.
+func (r *MMDBResolver) Lookup(_ context.Context, ip net.IP) (Location, error) {
+	if r == nil || !isPublic(ip) {
+		return Location{}, nil
+	}
+
+	var loc Location
+	if r.city != nil {
+		var rec cityRecord
+		if err := r.city.Lookup(ip, &rec); err != nil {
+			return Location{}, fmt.Errorf("geoip: city lookup: %w", err)
+		}
+		loc.Country = strings.ToUpper(rec.Country.ISOCode)
+		loc.City = rec.City.Names["en"]
+	}
+	if r.asn != nil {
+		var rec asnRecord
+		if err := r.asn.Lookup(ip, &rec); err != nil {
+			return Location{}, fmt.Errorf("geoip: asn lookup: %w", err)
+		}
+		loc.ASN = rec.Number
+		loc.ASOrg = rec.Organization
+	}
+	return loc, nil
+}
+
+// Close releases the database files.
+func (r *MMDBResolver) Close() error {
+	if r == nil {
+		return nil
+	}
+	var errs []error
+	if r.city != nil {
+		errs = append(errs, r.city.Close())
+	}
+	if r.asn != nil {
+		errs = append(errs, r.asn.Close())
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,139 @@
+package geoip
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// MaxMind DB data types and layout constants used to build test databases.
+// See https://maxmind.github.io/MaxMind-DB/ for the specification.
+const (
+	mmdbTypeString = 2
+	mmdbTypeUint16 = 5
+	mmdbTypeUint32 = 6
+	mmdbTypeMap    = 7
+
+	mmdbDataSeparator = 16
+)
+
+var mmdbMetadataMarker = []byte("\xAB\xCD\xEFMaxMind.com")
+
+// mmdbTestString encodes a UTF-8 string shorter than 285 bytes.
+func mmdbTestString(s string) []byte {
+	if len(s) < 29 {
+		return append([]byte{byte(mmdbTypeString<<5 | len(s))}, s...)
+	}
+	return append([]byte{byte(mmdbTypeString<<5 | 29), byte(len(s) - 29)}, s...)
+}
+
+func mmdbTestMap(pairs ...[]byte) []byte {
+	out := []byte{byte(mmdbTypeMap<<5 | len(pairs)/2)}
+	for _, p := range pairs {
+		out = append(out, p...)
+	}
+	return out
+}
+
+func mmdbTestUint16(v uint16) []byte {
+	return []byte{byte(mmdbTypeUint16<<5 | 2), byte(v >> 8), byte(v)}
+}
+
+func mmdbTestUint32(v uint32) []byte {
+	return []byte{byte(mmdbTypeUint32<<5 | 4), byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)}
+}
+
+// buildTestMMDB builds a one-node IPv4 tree: addresses with the top bit set
+// (128.0.0.0/1) resolve to record, everything else is a miss.
+func buildTestMMDB(record []byte) []byte {
+	const nodeCount = 1
+	// Left record: nodeCount (miss). Right record: data offset 0.
+	right := nodeCount + mmdbDataSeparator
+	buf := []byte{0, 0, nodeCount, 0, 0, byte(right)}
+	buf = append(buf, make([]byte, mmdbDataSeparator)...)
+	buf = append(buf, record...)
+	buf = append(buf, mmdbMetadataMarker...)
+	buf = append(buf, mmdbTestMap(
+		mmdbTestString("node_count"), mmdbTestUint32(nodeCount),
+		mmdbTestString("record_size"), mmdbTestUint16(24),
+		mmdbTestString("ip_version"), mmdbTestUint16(4),
+	)...)
+	return buf
+}
+
+func openTestMMDB(t *testing.T, buf []byte) *maxminddb.Reader {
+	t.Helper()
+	db, err := maxminddb.FromBytes(buf)
+	if err != nil {
+		t.Fatalf("FromBytes: %v", err)
+	}
+	return db
+}
+
+func TestMMDBResolver_Lookup(t *testing.T) {
+	rec := mmdbTestMap(
+		mmdbTestString("country"), mmdbTestMap(mmdbTestString("iso_code"), mmdbTestString("de")),
+		mmdbTestString("city"), mmdbTestMap(
+			mmdbTestString("names"), mmdbTestMap(mmdbTestString("en"), mmdbTestString("Berlin")),
+		),
+	)
+	db := openTestMMDB(t, buildTestMMDB(rec))
+
+	r := &MMDBResolver{city: db}
+	loc, err := r.Lookup(context.Background(), net.ParseIP("203.0.113.7"))
+	if err != nil {
+		t.Fatalf("Lookup: %v", err)
+	}
+	if loc.Country != "DE" || loc.City != "Berlin" {
+		t.Fatalf("unexpected location: %+v", loc)
+	}
+
+	loc, err = r.Lookup(context.Background(), net.ParseIP("8.8.8.8"))
+	if err != nil {
+		t.Fatalf("Lookup miss: %v", err)
+	}
+	if !loc.IsZero() {
+		t.Fatalf("expected miss, got %+v", loc)
+	}
+}
+
+func TestMMDBResolver_ASN(t *testing.T) {
+	rec := mmdbTestMap(
+		mmdbTestString("autonomous_system_number"), mmdbTestUint32(3320),
+		mmdbTestString("autonomous_system_organization"), mmdbTestString("Example AS"),
+	)
+	db := openTestMMDB(t, buildTestMMDB(rec))
+
+	r := &MMDBResolver{asn: db}
+	loc, err := r.Lookup(context.Background(), net.ParseIP("203.0.113.7"))
+	if err != nil {
+		t.Fatalf("Lookup: %v", err)
+	}
+	if loc.ASN != 3320 || loc.ASOrg != "Example AS" {
+		t.Fatalf("unexpected location: %+v", loc)
+	}
+}
+
+func TestMMDBResolver_SkipsPrivateAddresses(t *testing.T) {
+	r := &MMDBResolver{}
+	for _, raw := range []string{"127.0.0.1", "10.1.2.3", "::1", "fe80::1"} {
+		loc, err := r.Lookup(context.Background(), net.ParseIP(raw))
+		if err != nil || !loc.IsZero() {
+			t.Fatalf("%s: expected zero location, got %+v err=%v", raw, loc, err)
+		}
+	}
+}
+
+func TestOpenMMDB_RejectsGarbage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "garbage.mmdb")
+	if err := os.WriteFile(path, []byte("not a database"), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if _, err := OpenMMDB(path, ""); err == nil {
+		t.Fatalf("expected error for garbage input")
+	}
+}
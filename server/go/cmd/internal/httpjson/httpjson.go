@@ -0,0 +1,57 @@
+// Package httpjson holds the JSON request and response helpers shared by the
+// HTTP APIs of the optional subsystems (attachments, push, bots, moderation, e2ee).
+//
+// Errors use the same body as the auth API:
+//
+//	{"error": {"code": "invalid_request", "message": "..."}}
+package httpjson
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// Error is the error object of an ErrorResponse.
+type Error struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ErrorResponse is the body WriteError sends.
+type ErrorResponse struct {
+	Error Error `json:"error"`
+}
+
+// Write sends v as an uncacheable JSON response with status.
+func Write(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// WriteError sends an ErrorResponse with status, code and msg.
+func WriteError(w http.ResponseWriter, status int, code, msg string) {
+	Write(w, status, ErrorResponse{Error: Error{Code: code, Message: msg}})
+}
+
+// Decode reads a single JSON value of at most maxBytes from r's body into dst.
+// Unknown fields and trailing data are rejected.
+func Decode(w http.ResponseWriter, r *http.Request, maxBytes int64, dst any) error {
+	if r.Body == nil {
+		return errors.New("empty body")
+	}
+	defer func() { _ = r.Body.Close() }()
+
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil {
+		return err
+	}
+	if err := dec.Decode(&struct{}{}); err != io.EOF {
+		return errors.New("extra data after JSON object")
+	}
+	return nil
+}
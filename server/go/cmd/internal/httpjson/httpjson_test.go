@@ -0,0 +1,46 @@
+package httpjson
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWriteError(t *testing.T) {
+	rec := httptest.NewRecorder()
+	WriteError(rec, http.StatusBadRequest, "invalid_request", "bad")
+
+	if rec.Code != http.StatusBadRequest || rec.Header().Get("Cache-Control") != "no-store" {
+		t.Fatalf("status=%d cache-control=%q", rec.Code, rec.Header().Get("Cache-Control"))
+	}
+	if got, want := rec.Body.String(), `{"error":{"code":"invalid_request","message":"bad"}}`+"\n"; got != want {
+		t.Fatalf("body = %q, want %q", got, want)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	type payload struct {
+		Name string `json:"name"`
+	}
+	tests := []struct {
+		name    string
+		body    string
+		wantErr bool
+	}{
+		{name: "ok", body: `{"name":"a"}`},
+		{name: "unknown field", body: `{"name":"a","x":1}`, wantErr: true},
+		{name: "trailing data", body: `{"name":"a"}{}`, wantErr: true},
+		{name: "too large", body: `{"name":"` + strings.Repeat("a", 64) + `"}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p payload
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			err := Decode(httptest.NewRecorder(), r, 32, &p)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Decode(%s) err = %v, want error %v", tt.body, err, tt.wantErr)
+			}
+		})
+	}
+}
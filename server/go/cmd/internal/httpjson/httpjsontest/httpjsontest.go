@@ -0,0 +1,20 @@
+// Package httpjsontest sends requests to the JSON APIs in handler tests.
+package httpjsontest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+)
+
+// Do serves a method request for target with body through h and returns the
+// recorded response. A non-empty token is sent as a bearer token.
+func Do(h http.Handler, method, target, token, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
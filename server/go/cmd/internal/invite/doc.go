@@ -1,2 +1,8 @@
-// Package invite provides invite creation, validation, and consumption for invite-only onboarding.
+// Package invite owns invite tokens for invite-only onboarding: creation, validation,
+// consumption (standalone or inside a caller's signup transaction) and reporting.
+//
+// It is the only code that creates or consumes invites. An invite has
+// max_uses >= 1 and is active while it is not revoked, not expired and has uses
+// left; used_count counts consumptions, and consumed_at/consumed_by record the
+// latest one.
 package invite
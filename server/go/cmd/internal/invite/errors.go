@@ -9,4 +9,6 @@ var (
 	ErrNotFound = errors.New("invite not found")
 	// ErrNotActive indicates the invite is expired, revoked, or out of uses.
 	ErrNotActive = errors.New("invite not active")
+	// ErrConversationNotFound indicates the conversation an invite should join does not exist.
+	ErrConversationNotFound = errors.New("invite conversation not found")
 )
@@ -0,0 +1,100 @@
+package invite
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxEmailDomains bounds an invite's email domain allowlist (chk_invites_allowed_email_domains_len).
+const maxEmailDomains = 20
+
+// NormalizeEmailDomain canonicalizes an allowlist entry: lower-cased, without a
+// leading "@" or trailing ".".
+func NormalizeEmailDomain(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	s = strings.TrimPrefix(s, "@")
+	return strings.TrimSuffix(s, ".")
+}
+
+// EmailDomainAllowed reports whether email's domain is exactly one of domains
+// (normalized entries). An empty allowlist allows every address.
+func EmailDomainAllowed(email string, domains []string) bool {
+	if len(domains) == 0 {
+		return true
+	}
+	email = strings.ToLower(strings.TrimSpace(email))
+	at := strings.LastIndexByte(email, '@')
+	if at < 0 {
+		return false
+	}
+	domain := NormalizeEmailDomain(email[at+1:])
+	for _, d := range domains {
+		if d != "" && d == domain {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeConversation validates an invite's conversation payload. A role
+// without a conversation is rejected; a conversation without a role grants member.
+func normalizeConversation(convID, role *string) (*string, *string, error) {
+	convID, role = trimPtr(convID), trimPtr(role)
+	if convID == nil {
+		if role != nil {
+			return nil, nil, fmt.Errorf("%w: conversation role requires a conversation", ErrInvalidInput)
+		}
+		return nil, nil, nil
+	}
+	r := RoleMember
+	if role != nil {
+		r = strings.ToLower(*role)
+	}
+	if r != RoleMember && r != RoleAdmin {
+		return nil, nil, fmt.Errorf("%w: conversation role must be member or admin", ErrInvalidInput)
+	}
+	return convID, &r, nil
+}
+
+// normalizeEmailDomains normalizes and de-duplicates an invite's domain allowlist,
+// rejecting entries that are not plain host names.
+func normalizeEmailDomains(domains []string) ([]string, error) {
+	if len(domains) == 0 {
+		return nil, nil
+	}
+	if len(domains) > maxEmailDomains {
+		return nil, fmt.Errorf("%w: too many email domains", ErrInvalidInput)
+	}
+	out := make([]string, 0, len(domains))
+	seen := make(map[string]struct{}, len(domains))
+	for _, raw := range domains {
+		d := NormalizeEmailDomain(raw)
+		if !validEmailDomain(d) {
+			return nil, fmt.Errorf("%w: invalid email domain", ErrInvalidInput)
+		}
+		if _, ok := seen[d]; ok {
+			continue
+		}
+		seen[d] = struct{}{}
+		out = append(out, d)
+	}
+	return out, nil
+}
+
+// validEmailDomain accepts dotted host names of letters, digits and hyphens.
+func validEmailDomain(d string) bool {
+	if len(d) < 3 || len(d) > 253 || !strings.Contains(d, ".") {
+		return false
+	}
+	for _, label := range strings.Split(d, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, c := range label {
+			if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' {
+				return false
+			}
+		}
+	}
+	return true
+}
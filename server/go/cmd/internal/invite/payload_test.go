@@ -0,0 +1,63 @@
+package invite
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEmailDomainAllowed(t *testing.T) {
+	domains := []string{"example.com", "corp.example"}
+	tests := []struct {
+		email string
+		want  bool
+	}{
+		{email: "alice@example.com", want: true},
+		{email: " Bob@Corp.Example ", want: true},
+		{email: "eve@sub.example.com", want: false},
+		{email: "eve@example.com.evil", want: false},
+		{email: "no-at-sign", want: false},
+	}
+	for _, tt := range tests {
+		if got := EmailDomainAllowed(tt.email, domains); got != tt.want {
+			t.Fatalf("EmailDomainAllowed(%q) = %v, want %v", tt.email, got, tt.want)
+		}
+	}
+	if !EmailDomainAllowed("anyone@anywhere.test", nil) {
+		t.Fatal("expected an empty allowlist to allow every address")
+	}
+}
+
+func TestNormalizeEmailDomains(t *testing.T) {
+	got, err := normalizeEmailDomains([]string{"@Example.COM.", "example.com", "corp.example"})
+	if err != nil {
+		t.Fatalf("normalize: %v", err)
+	}
+	if len(got) != 2 || got[0] != "example.com" || got[1] != "corp.example" {
+		t.Fatalf("unexpected domains: %v", got)
+	}
+	for _, bad := range []string{"localhost", "-bad.example", "bad_.example", "a..example"} {
+		if _, err := normalizeEmailDomains([]string{bad}); !errors.Is(err, ErrInvalidInput) {
+			t.Fatalf("normalizeEmailDomains(%q) err = %v, want ErrInvalidInput", bad, err)
+		}
+	}
+}
+
+func TestNormalizeConversation(t *testing.T) {
+	conv := "conv"
+	admin := " Admin "
+	owner := "owner"
+
+	id, role, err := normalizeConversation(&conv, nil)
+	if err != nil || id == nil || role == nil || *role != RoleMember {
+		t.Fatalf("expected member default, got %v %v %v", id, role, err)
+	}
+	if _, role, err = normalizeConversation(&conv, &admin); err != nil || *role != RoleAdmin {
+		t.Fatalf("expected admin, got %v %v", role, err)
+	}
+	if _, _, err = normalizeConversation(&conv, &owner); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("expected owner to be rejected, got %v", err)
+	}
+	if _, _, err = normalizeConversation(nil, &admin); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("expected role without conversation to be rejected, got %v", err)
+	}
+}
@@ -5,15 +5,27 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 
 	"arc/cmd/security/token"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/oklog/ulid/v2"
 )
 
-const defaultTokenBytes = 32
+const (
+	defaultTokenBytes = 32
+	defaultTTL        = 7 * 24 * time.Hour
+	maxNoteLen        = 512
+)
+
+// Conversation roles an invite may grant (conversation_members.role); owner never is.
+const (
+	RoleMember = "member"
+	RoleAdmin  = "admin"
+)
 
 // Invite represents an invite row.
 type Invite struct {
@@ -27,15 +39,33 @@ type Invite struct {
 	Note       *string
 	ConsumedAt *time.Time
 	ConsumedBy *string
+
+	// ConversationID, when set, is joined by the user created with the invite,
+	// with ConversationRole (member or admin).
+	ConversationID   *string
+	ConversationRole *string
+
+	// AllowedEmailDomains, when non-empty, restricts consumption to emails in
+	// these domains.
+	AllowedEmailDomains []string
+}
+
+// Active reports whether the invite can still be consumed at now.
+func (inv Invite) Active(now time.Time) bool {
+	return inv.RevokedAt == nil && inv.ExpiresAt.After(now) && inv.UsedCount < inv.MaxUses
 }
 
 // CreateInput describes invite creation.
+// ConversationRole requires ConversationID and defaults to member when it is set.
 type CreateInput struct {
-	CreatedBy *string
-	TTL       time.Duration
-	MaxUses   int
-	Note      *string
-	Now       time.Time
+	CreatedBy           *string
+	TTL                 time.Duration
+	MaxUses             int
+	Note                *string
+	ConversationID      *string
+	ConversationRole    *string
+	AllowedEmailDomains []string
+	Now                 time.Time
 }
 
 // ConsumeInput describes invite consumption.
@@ -83,6 +113,7 @@ func NewService(store Store, opts ...Option) (*Service, error) {
 }
 
 // CreateInvite creates a new invite and returns the invite plus its plain token.
+// A missing conversation yields ErrConversationNotFound.
 func (s *Service) CreateInvite(ctx context.Context, in CreateInput) (Invite, string, error) {
 	if s == nil || s.store == nil {
 		return Invite{}, "", ErrInvalidInput
@@ -97,15 +128,23 @@ func (s *Service) CreateInvite(ctx context.Context, in CreateInput) (Invite, str
 	}
 	ttl := in.TTL
 	if ttl <= 0 {
-		ttl = 7 * 24 * time.Hour
+		ttl = defaultTTL
 	}
 	maxUses := in.MaxUses
 	if maxUses <= 0 {
 		maxUses = 1
 	}
 	note := trimPtr(in.Note)
-	if note != nil && len(*note) > 512 {
-		return Invite{}, "", ErrInvalidInput
+	if note != nil && len(*note) > maxNoteLen {
+		return Invite{}, "", fmt.Errorf("%w: note too long", ErrInvalidInput)
+	}
+	convID, convRole, err := normalizeConversation(in.ConversationID, in.ConversationRole)
+	if err != nil {
+		return Invite{}, "", err
+	}
+	domains, err := normalizeEmailDomains(in.AllowedEmailDomains)
+	if err != nil {
+		return Invite{}, "", err
 	}
 
 	tokenPlain, err := newOpaqueToken(s.tokenBytes)
@@ -119,20 +158,17 @@ func (s *Service) CreateInvite(ctx context.Context, in CreateInput) (Invite, str
 		return Invite{}, "", err
 	}
 
-	expiresAt := now.Add(ttl)
-	createdBy := trimPtr(in.CreatedBy)
 	inv, err := s.store.Create(ctx, CreateRecord{
-		ID:         inviteID,
-		TokenHash:  tokenHash,
-		CreatedBy:  createdBy,
-		CreatedAt:  now,
-		ExpiresAt:  expiresAt,
-		MaxUses:    maxUses,
-		UsedCount:  0,
-		RevokedAt:  nil,
-		Note:       note,
-		ConsumedAt: nil,
-		ConsumedBy: nil,
+		ID:                  inviteID,
+		TokenHash:           tokenHash,
+		CreatedBy:           trimPtr(in.CreatedBy),
+		CreatedAt:           now,
+		ExpiresAt:           now.Add(ttl),
+		MaxUses:             maxUses,
+		Note:                note,
+		ConversationID:      convID,
+		ConversationRole:    convRole,
+		AllowedEmailDomains: domains,
 	})
 	if err != nil {
 		return Invite{}, "", err
@@ -164,21 +200,11 @@ func (s *Service) ValidateInvite(ctx context.Context, tokenStr string, now time.
 		}
 		return false, Invite{}, err
 	}
-
-	if inv.RevokedAt != nil {
-		return false, inv, nil
-	}
-	if !inv.ExpiresAt.After(now) {
-		return false, inv, nil
-	}
-	if inv.MaxUses > 0 && inv.UsedCount >= inv.MaxUses {
-		return false, inv, nil
-	}
-
-	return true, inv, nil
+	return inv.Active(now), inv, nil
 }
 
-// ConsumeInvite marks an invite as used.
+// ConsumeInvite marks an invite as used by an existing user, joining the invite's
+// conversation when it has one.
 func (s *Service) ConsumeInvite(ctx context.Context, in ConsumeInput) (Invite, error) {
 	if s == nil || s.store == nil {
 		return Invite{}, ErrInvalidInput
@@ -206,6 +232,36 @@ func (s *Service) ConsumeInvite(ctx context.Context, in ConsumeInput) (Invite, e
 	})
 }
 
+// LockTx locks the invite behind tokenStr inside tx and returns it when it is active
+// at now; otherwise ErrNotFound or ErrNotActive. The lock holds until the caller
+// commits, so a signup can create its user before calling ConsumeTx.
+func (s *Service) LockTx(ctx context.Context, tx pgx.Tx, tokenStr string, now time.Time) (Invite, error) {
+	if s == nil || s.store == nil || tx == nil {
+		return Invite{}, ErrInvalidInput
+	}
+	tokenStr = strings.TrimSpace(tokenStr)
+	if tokenStr == "" {
+		return Invite{}, ErrInvalidInput
+	}
+	inv, err := s.store.LockByTokenHash(ctx, tx, token.HashRefreshTokenHex(tokenStr))
+	if err != nil {
+		return Invite{}, err
+	}
+	if !inv.Active(now) {
+		return Invite{}, ErrNotActive
+	}
+	return inv, nil
+}
+
+// ConsumeTx records userID's use of inv (locked by LockTx in the same tx) and adds
+// them to the invite's conversation, if any.
+func (s *Service) ConsumeTx(ctx context.Context, tx pgx.Tx, inv Invite, userID string, now time.Time) (Invite, error) {
+	if s == nil || s.store == nil || tx == nil || inv.ID == "" || strings.TrimSpace(userID) == "" {
+		return Invite{}, ErrInvalidInput
+	}
+	return s.store.ConsumeLocked(ctx, tx, inv, userID, now)
+}
+
 func newOpaqueToken(nBytes int) (string, error) {
 	if nBytes <= 0 {
 		nBytes = defaultTokenBytes
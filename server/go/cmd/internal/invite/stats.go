@@ -0,0 +1,171 @@
+package invite
+
+import (
+	"context"
+	"time"
+
+	"arc/cmd/internal/dbroute"
+	"arc/cmd/internal/dbtrace"
+)
+
+// WeekStats counts invite activity in one week (Monday 00:00 UTC onwards).
+//
+// Consumed counts invites by their latest consumption, so a multi-use invite used in
+// several weeks shows up once. Expired counts invites that expired in the week with
+// uses left and without being revoked.
+type WeekStats struct {
+	WeekStart time.Time
+	Created   int
+	Consumed  int
+	Expired   int
+}
+
+// InviterStats summarizes the invites one user created in the stats window.
+// Signups is the sum of their used counts.
+type InviterStats struct {
+	UserID   string
+	Username *string
+	Created  int
+	Signups  int
+}
+
+// Stats is the result of Service.Stats.
+type Stats struct {
+	Weeks       []WeekStats
+	TopInviters []InviterStats
+}
+
+// StatsInput selects the stats window: the Weeks most recent weeks up to and
+// including the one containing Now, and the TopN most active inviters in it.
+type StatsInput struct {
+	Weeks int
+	TopN  int
+	Now   time.Time
+}
+
+// WeekStart returns the Monday 00:00 UTC that starts t's week, matching Postgres
+// date_trunc('week', ...).
+func WeekStart(t time.Time) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	offset := (int(day.Weekday()) + 6) % 7 // Monday = 0
+	return day.AddDate(0, 0, -offset)
+}
+
+// Stats aggregates invite activity per week and the top inviters. Weeks without
+// activity are included with zero counts, oldest first.
+func (s *Service) Stats(ctx context.Context, in StatsInput) (Stats, error) {
+	if s == nil || s.store == nil || in.Weeks <= 0 || in.TopN <= 0 {
+		return Stats{}, ErrInvalidInput
+	}
+	if err := ctx.Err(); err != nil {
+		return Stats{}, err
+	}
+	now := in.Now
+	if now.IsZero() {
+		now = time.Now().UTC()
+	}
+	since := WeekStart(now).AddDate(0, 0, -7*(in.Weeks-1))
+
+	counts, err := s.store.WeeklyCounts(ctx, since, now)
+	if err != nil {
+		return Stats{}, err
+	}
+	byWeek := make(map[time.Time]WeekStats, len(counts))
+	for _, w := range counts {
+		byWeek[w.WeekStart.UTC()] = w
+	}
+	out := Stats{Weeks: make([]WeekStats, 0, in.Weeks)}
+	for i := range in.Weeks {
+		start := since.AddDate(0, 0, 7*i)
+		w, ok := byWeek[start]
+		if !ok {
+			w = WeekStats{}
+		}
+		w.WeekStart = start
+		out.Weeks = append(out.Weeks, w)
+	}
+
+	out.TopInviters, err = s.store.TopInviters(ctx, since, in.TopN)
+	if err != nil {
+		return Stats{}, err
+	}
+	return out, nil
+}
+
+// WeeklyCounts counts invites created, consumed and expired per week since since.
+// Each branch is a range scan on its own timestamp index.
+func (s *PostgresStore) WeeklyCounts(ctx context.Context, since, now time.Time) ([]WeekStats, error) {
+	ctx = dbtrace.WithOp(ctx, "invite.weekly_counts")
+	invites := pgIdent(s.schema, "invites")
+	rows, err := dbroute.Reader(ctx, s.pool, s.readPool).Query(ctx,
+		`WITH events AS (
+		     SELECT date_trunc('week', created_at AT TIME ZONE 'UTC') AS week, 'created' AS kind
+		       FROM `+invites+`
+		      WHERE created_at >= $1
+		     UNION ALL
+		     SELECT date_trunc('week', consumed_at AT TIME ZONE 'UTC'), 'consumed'
+		       FROM `+invites+`
+		      WHERE consumed_at >= $1
+		     UNION ALL
+		     SELECT date_trunc('week', expires_at AT TIME ZONE 'UTC'), 'expired'
+		       FROM `+invites+`
+		      WHERE expires_at >= $1 AND expires_at <= $2
+		        AND revoked_at IS NULL
+		        AND used_count < max_uses
+		 )
+		 SELECT week,
+		        count(*) FILTER (WHERE kind = 'created'),
+		        count(*) FILTER (WHERE kind = 'consumed'),
+		        count(*) FILTER (WHERE kind = 'expired')
+		   FROM events
+		  GROUP BY week`,
+		since, now,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []WeekStats
+	for rows.Next() {
+		var w WeekStats
+		if err := rows.Scan(&w.WeekStart, &w.Created, &w.Consumed, &w.Expired); err != nil {
+			return nil, err
+		}
+		out = append(out, w)
+	}
+	return out, rows.Err()
+}
+
+// TopInviters returns the users who created the most invites since since.
+func (s *PostgresStore) TopInviters(ctx context.Context, since time.Time, limit int) ([]InviterStats, error) {
+	ctx = dbtrace.WithOp(ctx, "invite.top_inviters")
+	invites := pgIdent(s.schema, "invites")
+	users := pgIdent(s.schema, "users")
+	rows, err := dbroute.Reader(ctx, s.pool, s.readPool).Query(ctx,
+		`SELECT i.created_by, u.username, count(*), COALESCE(sum(i.used_count), 0)
+		   FROM `+invites+` i
+		   LEFT JOIN `+users+` u ON u.id = i.created_by
+		  WHERE i.created_at >= $1
+		    AND i.created_by IS NOT NULL
+		  GROUP BY i.created_by, u.username
+		  ORDER BY count(*) DESC, i.created_by
+		  LIMIT $2`,
+		since, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []InviterStats{}
+	for rows.Next() {
+		var is InviterStats
+		if err := rows.Scan(&is.UserID, &is.Username, &is.Created, &is.Signups); err != nil {
+			return nil, err
+		}
+		out = append(out, is)
+	}
+	return out, rows.Err()
+}
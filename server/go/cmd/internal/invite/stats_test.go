@@ -0,0 +1,23 @@
+package invite
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWeekStart(t *testing.T) {
+	tests := []struct {
+		in   time.Time
+		want time.Time
+	}{
+		{in: time.Date(2026, 10, 16, 15, 4, 0, 0, time.UTC), want: time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)},
+		{in: time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC), want: time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)},
+		{in: time.Date(2026, 10, 18, 23, 59, 0, 0, time.UTC), want: time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)},
+		{in: time.Date(2026, 10, 19, 1, 0, 0, 0, time.FixedZone("x", 3*3600)), want: time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := WeekStart(tt.in); !got.Equal(tt.want) {
+			t.Fatalf("WeekStart(%v) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
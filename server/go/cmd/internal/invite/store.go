@@ -3,21 +3,22 @@ package invite
 import (
 	"context"
 	"time"
+
+	"github.com/jackc/pgx/v5"
 )
 
 // CreateRecord is a normalized invite insert payload.
 type CreateRecord struct {
-	ID         string
-	TokenHash  string
-	CreatedBy  *string
-	CreatedAt  time.Time
-	ExpiresAt  time.Time
-	MaxUses    int
-	UsedCount  int
-	RevokedAt  *time.Time
-	Note       *string
-	ConsumedAt *time.Time
-	ConsumedBy *string
+	ID                  string
+	TokenHash           string
+	CreatedBy           *string
+	CreatedAt           time.Time
+	ExpiresAt           time.Time
+	MaxUses             int
+	Note                *string
+	ConversationID      *string
+	ConversationRole    *string
+	AllowedEmailDomains []string
 }
 
 // ConsumeRecord describes a token consumption.
@@ -31,5 +32,16 @@ type ConsumeRecord struct {
 type Store interface {
 	Create(ctx context.Context, in CreateRecord) (Invite, error)
 	GetByTokenHash(ctx context.Context, tokenHash string) (Invite, error)
+	// Consume locks, checks and consumes an invite in its own transaction.
 	Consume(ctx context.Context, in ConsumeRecord) (Invite, error)
+
+	// LockByTokenHash selects the invite FOR UPDATE inside tx.
+	LockByTokenHash(ctx context.Context, tx pgx.Tx, tokenHash string) (Invite, error)
+	// ConsumeLocked records userID's use of a locked invite and joins its conversation.
+	// It returns ErrNotActive when the invite has no uses left.
+	ConsumeLocked(ctx context.Context, tx pgx.Tx, inv Invite, userID string, now time.Time) (Invite, error)
+
+	// WeeklyCounts and TopInviters back Service.Stats.
+	WeeklyCounts(ctx context.Context, since, now time.Time) ([]WeekStats, error)
+	TopInviters(ctx context.Context, since time.Time, limit int) ([]InviterStats, error)
 }
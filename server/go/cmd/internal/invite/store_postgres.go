@@ -6,14 +6,24 @@ import (
 	"strings"
 	"time"
 
+	"arc/cmd/internal/dbtrace"
+	"arc/cmd/internal/pgutil"
+
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// inviteColumns is the select list scanned by scanInvite.
+const inviteColumns = `id, created_by, created_at, expires_at, max_uses, used_count, revoked_at, note,
+	consumed_at, consumed_by, conversation_id, conversation_role, allowed_email_domains`
+
 // PostgresStore persists invites in PostgreSQL.
 type PostgresStore struct {
-	pool   *pgxpool.Pool
-	schema string
+	pool *pgxpool.Pool
+	// readPool serves the stats queries (nil uses pool); see WithReadPool.
+	readPool *pgxpool.Pool
+	schema   string
 }
 
 // StoreOption configures PostgresStore.
@@ -31,6 +41,16 @@ func WithSchema(schema string) StoreOption {
 	}
 }
 
+// WithReadPool routes the stats queries to a read replica unless the context
+// requires the primary. Token lookups and consumption stay on the primary pool.
+// A nil pool is ignored.
+func WithReadPool(pool *pgxpool.Pool) StoreOption {
+	return func(s *PostgresStore) error {
+		s.readPool = pool
+		return nil
+	}
+}
+
 // NewPostgresStore constructs a PostgresStore.
 func NewPostgresStore(pool *pgxpool.Pool, opts ...StoreOption) (*PostgresStore, error) {
 	st := &PostgresStore{pool: pool, schema: "arc"}
@@ -50,6 +70,7 @@ func NewPostgresStore(pool *pgxpool.Pool, opts ...StoreOption) (*PostgresStore,
 
 // Create inserts a new invite record.
 func (s *PostgresStore) Create(ctx context.Context, in CreateRecord) (Invite, error) {
+	ctx = dbtrace.WithOp(ctx, "invite.create")
 	if s == nil || s.pool == nil {
 		return Invite{}, ErrInvalidInput
 	}
@@ -62,47 +83,50 @@ func (s *PostgresStore) Create(ctx context.Context, in CreateRecord) (Invite, er
 	if in.MaxUses <= 0 {
 		return Invite{}, ErrInvalidInput
 	}
-	if in.Note != nil && len(strings.TrimSpace(*in.Note)) > 512 {
+	if in.Note != nil && len(strings.TrimSpace(*in.Note)) > maxNoteLen {
 		return Invite{}, ErrInvalidInput
 	}
 	invites := pgIdent(s.schema, "invites")
 
 	_, err := s.pool.Exec(ctx,
 		`INSERT INTO `+invites+` (
-		     id, token_hash, created_by, created_at, expires_at, max_uses, used_count, revoked_at, note, consumed_at, consumed_by
-		   ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		     id, token_hash, created_by, created_at, expires_at, max_uses, used_count, note,
+		     conversation_id, conversation_role, allowed_email_domains
+		   ) VALUES ($1, $2, $3, $4, $5, $6, 0, $7, $8, $9, $10)`,
 		in.ID,
 		in.TokenHash,
 		in.CreatedBy,
 		in.CreatedAt,
 		in.ExpiresAt,
 		in.MaxUses,
-		in.UsedCount,
-		in.RevokedAt,
 		in.Note,
-		in.ConsumedAt,
-		in.ConsumedBy,
+		in.ConversationID,
+		in.ConversationRole,
+		in.AllowedEmailDomains,
 	)
 	if err != nil {
+		if in.ConversationID != nil && isForeignKeyViolation(err) {
+			return Invite{}, ErrConversationNotFound
+		}
 		return Invite{}, err
 	}
 
 	return Invite{
-		ID:         in.ID,
-		CreatedBy:  in.CreatedBy,
-		CreatedAt:  in.CreatedAt,
-		ExpiresAt:  in.ExpiresAt,
-		MaxUses:    in.MaxUses,
-		UsedCount:  in.UsedCount,
-		RevokedAt:  in.RevokedAt,
-		Note:       in.Note,
-		ConsumedAt: in.ConsumedAt,
-		ConsumedBy: in.ConsumedBy,
+		ID:                  in.ID,
+		CreatedBy:           in.CreatedBy,
+		CreatedAt:           in.CreatedAt,
+		ExpiresAt:           in.ExpiresAt,
+		MaxUses:             in.MaxUses,
+		Note:                in.Note,
+		ConversationID:      in.ConversationID,
+		ConversationRole:    in.ConversationRole,
+		AllowedEmailDomains: in.AllowedEmailDomains,
 	}, nil
 }
 
 // GetByTokenHash fetches an invite by token hash.
 func (s *PostgresStore) GetByTokenHash(ctx context.Context, tokenHash string) (Invite, error) {
+	ctx = dbtrace.WithOp(ctx, "invite.get_by_token_hash")
 	if s == nil || s.pool == nil {
 		return Invite{}, ErrInvalidInput
 	}
@@ -115,35 +139,18 @@ func (s *PostgresStore) GetByTokenHash(ctx context.Context, tokenHash string) (I
 	}
 
 	invites := pgIdent(s.schema, "invites")
-	var out Invite
-	err := s.pool.QueryRow(ctx,
-		`SELECT id, created_by, created_at, expires_at, max_uses, used_count, revoked_at, note, consumed_at, consumed_by
+	return scanInvite(s.pool.QueryRow(ctx,
+		`SELECT `+inviteColumns+`
 		   FROM `+invites+`
 		  WHERE token_hash = $1`,
 		tokenHash,
-	).Scan(
-		&out.ID,
-		&out.CreatedBy,
-		&out.CreatedAt,
-		&out.ExpiresAt,
-		&out.MaxUses,
-		&out.UsedCount,
-		&out.RevokedAt,
-		&out.Note,
-		&out.ConsumedAt,
-		&out.ConsumedBy,
-	)
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return Invite{}, ErrNotFound
-		}
-		return Invite{}, err
-	}
-	return out, nil
+	))
 }
 
-// Consume increments used_count and marks last consumption.
+// Consume locks the invite, checks it is active and consumes it in one transaction
+// (retried on serialization failures and deadlocks, see pgutil.WithTx).
 func (s *PostgresStore) Consume(ctx context.Context, in ConsumeRecord) (Invite, error) {
+	ctx = dbtrace.WithOp(ctx, "invite.consume")
 	if s == nil || s.pool == nil {
 		return Invite{}, ErrInvalidInput
 	}
@@ -157,22 +164,79 @@ func (s *PostgresStore) Consume(ctx context.Context, in ConsumeRecord) (Invite,
 		in.Now = time.Now().UTC()
 	}
 
-	invites := pgIdent(s.schema, "invites")
 	var out Invite
-	err := s.pool.QueryRow(ctx,
+	err := pgutil.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		inv, err := s.LockByTokenHash(ctx, tx, in.TokenHash)
+		if err != nil {
+			return err
+		}
+		if !inv.Active(in.Now) {
+			return ErrNotActive
+		}
+		out, err = s.ConsumeLocked(ctx, tx, inv, *in.ConsumedBy, in.Now)
+		return err
+	})
+	if err != nil {
+		return Invite{}, err
+	}
+	return out, nil
+}
+
+// LockByTokenHash selects the invite FOR UPDATE inside tx.
+func (s *PostgresStore) LockByTokenHash(ctx context.Context, tx pgx.Tx, tokenHash string) (Invite, error) {
+	invites := pgIdent(s.schema, "invites")
+	return scanInvite(tx.QueryRow(ctx,
+		`SELECT `+inviteColumns+`
+		   FROM `+invites+`
+		  WHERE token_hash = $1
+		  FOR UPDATE`,
+		tokenHash,
+	))
+}
+
+// ConsumeLocked increments used_count, records the latest consumption and, when the
+// invite carries a conversation, adds userID to it with the invite's role. A
+// conversation deleted since creation has nulled conversation_id and is skipped.
+func (s *PostgresStore) ConsumeLocked(ctx context.Context, tx pgx.Tx, inv Invite, userID string, now time.Time) (Invite, error) {
+	invites := pgIdent(s.schema, "invites")
+	out, err := scanInvite(tx.QueryRow(ctx,
 		`UPDATE `+invites+`
 		    SET used_count = used_count + 1,
 		        consumed_at = $1,
 		        consumed_by = $2
-		  WHERE token_hash = $3
-		    AND revoked_at IS NULL
-		    AND expires_at > $1
+		  WHERE id = $3
 		    AND used_count < max_uses
-		RETURNING id, created_by, created_at, expires_at, max_uses, used_count, revoked_at, note, consumed_at, consumed_by`,
-		in.Now,
-		in.ConsumedBy,
-		in.TokenHash,
-	).Scan(
+		RETURNING `+inviteColumns,
+		now, userID, inv.ID,
+	))
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return Invite{}, ErrNotActive
+		}
+		return Invite{}, err
+	}
+
+	if out.ConversationID != nil {
+		role := RoleMember
+		if out.ConversationRole != nil {
+			role = *out.ConversationRole
+		}
+		members := pgIdent(s.schema, "conversation_members")
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO `+members+` (conversation_id, user_id, role, joined_at)
+			 VALUES ($1, $2, $3, $4)
+			 ON CONFLICT (conversation_id, user_id) DO NOTHING`,
+			*out.ConversationID, userID, role, now,
+		); err != nil {
+			return Invite{}, err
+		}
+	}
+	return out, nil
+}
+
+func scanInvite(row pgx.Row) (Invite, error) {
+	var out Invite
+	err := row.Scan(
 		&out.ID,
 		&out.CreatedBy,
 		&out.CreatedAt,
@@ -183,23 +247,22 @@ func (s *PostgresStore) Consume(ctx context.Context, in ConsumeRecord) (Invite,
 		&out.Note,
 		&out.ConsumedAt,
 		&out.ConsumedBy,
+		&out.ConversationID,
+		&out.ConversationRole,
+		&out.AllowedEmailDomains,
 	)
-	if err == nil {
-		return out, nil
-	}
-	if !errors.Is(err, pgx.ErrNoRows) {
-		return Invite{}, err
-	}
-
-	// Distinguish not-found vs not-active.
-	_, selErr := s.GetByTokenHash(ctx, in.TokenHash)
-	if selErr != nil {
-		if errors.Is(selErr, ErrNotFound) {
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
 			return Invite{}, ErrNotFound
 		}
-		return Invite{}, selErr
+		return Invite{}, err
 	}
-	return Invite{}, ErrNotActive
+	return out, nil
+}
+
+func isForeignKeyViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23503"
 }
 
 func pgIdent(schema, table string) string {
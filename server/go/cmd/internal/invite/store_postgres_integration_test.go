@@ -4,7 +4,6 @@ import (
 	"context"
 	"crypto/rand"
 	"errors"
-	"fmt"
 	"net"
 	"os"
 	"strings"
@@ -12,7 +11,8 @@ import (
 	"testing"
 	"time"
 
-	"github.com/jackc/pgx/v5"
+	"arc/cmd/internal/migrations/migrationstest"
+
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/oklog/ulid/v2"
 )
@@ -26,9 +26,7 @@ func TestInviteService_CreateValidateConsume(t *testing.T) {
 	pool := mustOpenTestPool(t)
 	defer pool.Close()
 
-	schema := mustCreateTestSchema(t, pool)
-	t.Cleanup(func() { mustDropSchema(t, pool, schema) })
-	mustApplySchema(t, pool, schema)
+	schema := migrationstest.NewSchema(t, pool)
 
 	store, err := NewPostgresStore(pool, WithSchema(schema))
 	if err != nil {
@@ -91,9 +89,7 @@ func TestInviteService_Validate_ExpiredRevoked(t *testing.T) {
 	pool := mustOpenTestPool(t)
 	defer pool.Close()
 
-	schema := mustCreateTestSchema(t, pool)
-	t.Cleanup(func() { mustDropSchema(t, pool, schema) })
-	mustApplySchema(t, pool, schema)
+	schema := migrationstest.NewSchema(t, pool)
 
 	store, err := NewPostgresStore(pool, WithSchema(schema))
 	if err != nil {
@@ -141,9 +137,7 @@ func TestInviteService_ConcurrentConsume_MaxUses(t *testing.T) {
 	pool := mustOpenTestPool(t)
 	defer pool.Close()
 
-	schema := mustCreateTestSchema(t, pool)
-	t.Cleanup(func() { mustDropSchema(t, pool, schema) })
-	mustApplySchema(t, pool, schema)
+	schema := migrationstest.NewSchema(t, pool)
 
 	store, err := NewPostgresStore(pool, WithSchema(schema))
 	if err != nil {
@@ -206,6 +200,62 @@ func TestInviteService_ConcurrentConsume_MaxUses(t *testing.T) {
 	}
 }
 
+func TestInviteService_ConsumeJoinsConversation(t *testing.T) {
+	t.Parallel()
+
+	pool := mustOpenTestPool(t)
+	defer pool.Close()
+
+	schema := migrationstest.NewSchema(t, pool)
+
+	store, err := NewPostgresStore(pool, WithSchema(schema))
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	service, err := NewService(store)
+	if err != nil {
+		t.Fatalf("new service: %v", err)
+	}
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	convID := "conv-" + strings.ToLower(newTestULID(t))
+	if _, err := pool.Exec(ctx, `INSERT INTO `+pgIdent(schema, "conversations")+` (id, kind) VALUES ($1, 'group')`, convID); err != nil {
+		t.Fatalf("insert conversation: %v", err)
+	}
+
+	missing := "conv-missing"
+	if _, _, err := service.CreateInvite(ctx, CreateInput{ConversationID: &missing, Now: now}); !errors.Is(err, ErrConversationNotFound) {
+		t.Fatalf("expected ErrConversationNotFound, got %v", err)
+	}
+
+	_, token, err := service.CreateInvite(ctx, CreateInput{ConversationID: &convID, Now: now})
+	if err != nil {
+		t.Fatalf("create invite: %v", err)
+	}
+	user := newTestULID(t)
+	mustInsertUser(t, pool, schema, user)
+	consumed, err := service.ConsumeInvite(ctx, ConsumeInput{Token: token, ConsumedBy: &user, Now: now.Add(time.Second)})
+	if err != nil {
+		t.Fatalf("consume invite: %v", err)
+	}
+	if consumed.ConsumedBy == nil || *consumed.ConsumedBy != user || consumed.ConsumedAt == nil {
+		t.Fatalf("expected consumption to be recorded, got %+v", consumed)
+	}
+
+	var role string
+	if err := pool.QueryRow(ctx,
+		`SELECT role FROM `+pgIdent(schema, "conversation_members")+` WHERE conversation_id = $1 AND user_id = $2`,
+		convID, user,
+	).Scan(&role); err != nil {
+		t.Fatalf("load membership: %v", err)
+	}
+	if role != RoleMember {
+		t.Fatalf("expected member role, got %q", role)
+	}
+}
+
 // ---- helpers ----
 
 func mustOpenTestPool(t *testing.T) *pgxpool.Pool {
@@ -271,70 +321,6 @@ func shouldSkipIntegration(err error) bool {
 	return false
 }
 
-func mustCreateTestSchema(t *testing.T, pool *pgxpool.Pool) string {
-	t.Helper()
-
-	schema := "arc_invite_it_" + strings.ToLower(newTestULID(t))
-
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	if _, err := pool.Exec(ctx, `CREATE SCHEMA `+pgx.Identifier{schema}.Sanitize()); err != nil {
-		t.Fatalf("create schema: %v", err)
-	}
-	return schema
-}
-
-func mustDropSchema(t *testing.T, pool *pgxpool.Pool, schema string) {
-	t.Helper()
-
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	_, _ = pool.Exec(ctx, `DROP SCHEMA IF EXISTS `+pgx.Identifier{schema}.Sanitize()+` CASCADE`)
-}
-
-func mustApplySchema(t *testing.T, pool *pgxpool.Pool, schema string) {
-	t.Helper()
-
-	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
-	defer cancel()
-
-	users := pgIdent(schema, "users")
-	invites := pgIdent(schema, "invites")
-
-	schemaSQL := fmt.Sprintf(`
-CREATE TABLE IF NOT EXISTS %s (
-  id TEXT PRIMARY KEY,
-  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
-);
-
-CREATE TABLE IF NOT EXISTS %s (
-  id TEXT PRIMARY KEY,
-  token_hash TEXT NOT NULL,
-  created_by TEXT NULL REFERENCES %s(id) ON DELETE SET NULL,
-  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
-  expires_at TIMESTAMPTZ NOT NULL,
-  max_uses INT NOT NULL DEFAULT 1,
-  used_count INT NOT NULL DEFAULT 0,
-  revoked_at TIMESTAMPTZ NULL,
-  note TEXT NULL,
-  consumed_at TIMESTAMPTZ NULL,
-  consumed_by TEXT NULL REFERENCES %s(id) ON DELETE SET NULL,
-  CONSTRAINT chk_invites_id_ulid_len CHECK (char_length(id) = 26),
-  CONSTRAINT chk_invites_token_hash_len CHECK (char_length(token_hash) = 64),
-  CONSTRAINT chk_invites_max_uses CHECK (max_uses >= 1),
-  CONSTRAINT chk_invites_used_count CHECK (used_count >= 0 AND used_count <= max_uses)
-);
-
-CREATE UNIQUE INDEX IF NOT EXISTS uq_invites_token_hash ON %s (token_hash);
-`, users, invites, users, users, invites)
-
-	if _, err := pool.Exec(ctx, schemaSQL); err != nil {
-		t.Fatalf("apply schema: %v", err)
-	}
-}
-
 func mustInsertUser(t *testing.T, pool *pgxpool.Pool, schema, userID string) {
 	t.Helper()
 	if strings.TrimSpace(userID) == "" {
@@ -0,0 +1,83 @@
+package ipaccess
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"arc/cmd/internal/dbtrace"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// auditTimeout bounds one audit insert; denials are written off the request path.
+const auditTimeout = 5 * time.Second
+
+// Auditor records denials in the audit log as "auth.access.ip_denied" or
+// "auth.access.country_denied", with the endpoint, reason and country in meta.
+type Auditor struct {
+	log    *slog.Logger
+	pool   *pgxpool.Pool
+	schema string
+}
+
+// NewAuditor constructs an Auditor writing to schema's audit_log.
+func NewAuditor(log *slog.Logger, pool *pgxpool.Pool, schema string) (*Auditor, error) {
+	if pool == nil {
+		return nil, errors.New("ipaccess: nil db pool")
+	}
+	if log == nil {
+		log = slog.Default()
+	}
+	if strings.TrimSpace(schema) == "" {
+		schema = "arc"
+	}
+	return &Auditor{log: log, pool: pool, schema: schema}, nil
+}
+
+// ReportDenied writes d asynchronously; failures are logged.
+func (a *Auditor) ReportDenied(_ context.Context, d Denial) {
+	action := "auth.access.ip_denied"
+	if d.Reason == ReasonCountryDenied || d.Reason == ReasonCountryNotAllowed {
+		action = "auth.access.country_denied"
+	}
+	meta := map[string]any{
+		"endpoint": d.Endpoint,
+		"reason":   d.Reason,
+	}
+	if d.Country != "" {
+		meta["country"] = d.Country
+	}
+	b, err := json.Marshal(meta)
+	if err != nil {
+		return
+	}
+	var ip any
+	if d.IP != nil {
+		ip = d.IP.String()
+	}
+	var ua any
+	if v := strings.TrimSpace(d.UserAgent); v != "" {
+		ua = v
+	}
+
+	a.log.Info(action, "endpoint", d.Endpoint, "reason", d.Reason, "country", d.Country, "result", "forbidden")
+	go func() {
+		ctx, cancel := context.WithTimeout(dbtrace.WithOp(context.Background(), "ipaccess.report_denied"), auditTimeout)
+		defer cancel()
+
+		_, err := a.pool.Exec(ctx, `
+			INSERT INTO `+pgx.Identifier{a.schema, "audit_log"}.Sanitize()+` (action, created_at, ip, user_agent, meta)
+			VALUES ($1, $2, $3, $4, $5::jsonb)
+		`, action, d.At, ip, ua, string(b))
+		if err != nil {
+			a.log.Error("ipaccess.audit.fail", "action", action, "err", err)
+		}
+	}()
+}
+
+var _ Reporter = (*Auditor)(nil)
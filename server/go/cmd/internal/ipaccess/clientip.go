@@ -0,0 +1,185 @@
+package ipaccess
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// Forwarding headers ProxyConfig.Header may select.
+const (
+	HeaderXForwardedFor = "x-forwarded-for"
+	HeaderForwarded     = "forwarded"
+)
+
+// ProxyConfig says when and how forwarding headers identify the client.
+type ProxyConfig struct {
+	// Trust enables forwarding headers; without it the connection's peer is the client.
+	Trust bool
+	// TrustedCIDRs, when set, honors the headers only if the direct peer is in one
+	// of them, and skips these proxies when walking the chain from the right.
+	// Empty trusts the headers from any peer.
+	TrustedCIDRs []netip.Prefix
+	// Header is HeaderXForwardedFor (default; X-Real-IP is the fallback) or
+	// HeaderForwarded (RFC 7239 "for=" parameters).
+	Header string
+	// Hops, when positive, is the number of proxies in front of the server: the
+	// client is the Hops-th address from the right of the chain.
+	Hops int
+}
+
+// ClientIP returns the client address of r under proxy. The chain is read left
+// (client) to right (nearest proxy): with Hops it picks the Hops-th entry from the
+// right, with TrustedCIDRs the rightmost entry outside them, and otherwise the
+// leftmost entry.
+func ClientIP(r *http.Request, proxy ProxyConfig) net.IP {
+	peer := peerIP(r)
+	if !proxy.Trust {
+		return peer
+	}
+	if len(proxy.TrustedCIDRs) > 0 && !containsIP(proxy.TrustedCIDRs, peer) {
+		return peer
+	}
+
+	var chain []net.IP
+	if proxy.Header == HeaderForwarded {
+		chain = parseForwarded(r.Header.Values("Forwarded"))
+	} else {
+		chain = parseXForwardedFor(r.Header.Values("X-Forwarded-For"))
+		if len(chain) == 0 {
+			if ip := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); ip != nil {
+				return ip
+			}
+		}
+	}
+	if len(chain) == 0 {
+		return peer
+	}
+
+	switch {
+	case proxy.Hops > 0:
+		// A shorter chain than expected means the client sent no header of its own.
+		ip := chain[max(len(chain)-proxy.Hops, 0)]
+		if ip == nil {
+			return peer
+		}
+		return ip
+	case len(proxy.TrustedCIDRs) > 0:
+		// Walk back through trusted proxies; an unparseable hop ends the walk at the
+		// last address that was verified.
+		last := peer
+		for i := len(chain) - 1; i >= 0; i-- {
+			ip := chain[i]
+			if ip == nil {
+				return last
+			}
+			if !containsIP(proxy.TrustedCIDRs, ip) {
+				return ip
+			}
+			last = ip
+		}
+		return last
+	default:
+		for _, ip := range chain {
+			if ip != nil {
+				return ip
+			}
+		}
+		return peer
+	}
+}
+
+func peerIP(r *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(strings.TrimSpace(r.RemoteAddr))
+	if err == nil {
+		if ip := net.ParseIP(host); ip != nil {
+			return ip
+		}
+	}
+	return nil
+}
+
+func containsIP(prefixes []netip.Prefix, ip net.IP) bool {
+	addr, ok := netip.AddrFromSlice(ip)
+	return ok && containsAddr(prefixes, addr.Unmap())
+}
+
+// parseXForwardedFor returns the addresses of every X-Forwarded-For header in
+// order; entries that are not addresses are nil.
+func parseXForwardedFor(values []string) []net.IP {
+	var out []net.IP
+	for _, v := range values {
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				out = append(out, net.ParseIP(part))
+			}
+		}
+	}
+	return out
+}
+
+// parseForwarded returns the "for" address of every RFC 7239 forwarded-element in
+// order. Obfuscated identifiers, "unknown" and elements without "for" are nil.
+func parseForwarded(values []string) []net.IP {
+	var out []net.IP
+	for _, v := range values {
+		for _, elem := range splitQuoted(v, ',') {
+			if strings.TrimSpace(elem) == "" {
+				continue
+			}
+			var ip net.IP
+			for _, pair := range splitQuoted(elem, ';') {
+				name, val, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if !ok || !strings.EqualFold(strings.TrimSpace(name), "for") {
+					continue
+				}
+				ip = parseForwardedNode(strings.TrimSpace(val))
+			}
+			out = append(out, ip)
+		}
+	}
+	return out
+}
+
+// parseForwardedNode parses a node such as 192.0.2.60, "192.0.2.60:8080" or
+// "[2001:db8::17]:4711".
+func parseForwardedNode(v string) net.IP {
+	v = strings.Trim(v, `"`)
+	if strings.HasPrefix(v, "[") {
+		end := strings.IndexByte(v, ']')
+		if end < 0 {
+			return nil
+		}
+		return net.ParseIP(v[1:end])
+	}
+	if host, _, err := net.SplitHostPort(v); err == nil {
+		v = host
+	}
+	return net.ParseIP(v)
+}
+
+// splitQuoted splits s at sep outside double-quoted strings.
+func splitQuoted(s string, sep byte) []string {
+	var (
+		out    []string
+		quoted bool
+		start  int
+	)
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			quoted = !quoted
+		case '\\':
+			if quoted {
+				i++
+			}
+		case sep:
+			if !quoted {
+				out = append(out, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(out, s[start:])
+}
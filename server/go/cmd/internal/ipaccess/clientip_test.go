@@ -0,0 +1,124 @@
+package ipaccess
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	trusted := prefixes(t, "10.0.0.0/8", "2001:db8:ffff::/48")
+
+	cases := []struct {
+		name    string
+		peer    string
+		headers map[string]string
+		proxy   ProxyConfig
+		want    string
+	}{
+		{
+			name:    "headers ignored without trust",
+			peer:    "10.0.0.1:1234",
+			headers: map[string]string{"X-Forwarded-For": "198.51.100.7"},
+			want:    "10.0.0.1",
+		},
+		{
+			name:    "legacy trust takes the leftmost entry",
+			peer:    "192.0.2.1:1234",
+			headers: map[string]string{"X-Forwarded-For": "garbage, 198.51.100.7, 10.0.0.2"},
+			proxy:   ProxyConfig{Trust: true},
+			want:    "198.51.100.7",
+		},
+		{
+			name:    "x-real-ip fallback",
+			peer:    "10.0.0.1:1234",
+			headers: map[string]string{"X-Real-IP": "198.51.100.7"},
+			proxy:   ProxyConfig{Trust: true, TrustedCIDRs: trusted},
+			want:    "198.51.100.7",
+		},
+		{
+			name:    "untrusted peer cannot spoof",
+			peer:    "192.0.2.1:1234",
+			headers: map[string]string{"X-Forwarded-For": "198.51.100.7"},
+			proxy:   ProxyConfig{Trust: true, TrustedCIDRs: trusted},
+			want:    "192.0.2.1",
+		},
+		{
+			name:    "rightmost untrusted entry",
+			peer:    "10.0.0.1:1234",
+			headers: map[string]string{"X-Forwarded-For": "203.0.113.5, 198.51.100.7, 10.0.0.2"},
+			proxy:   ProxyConfig{Trust: true, TrustedCIDRs: trusted},
+			want:    "198.51.100.7",
+		},
+		{
+			name:    "all hops trusted",
+			peer:    "10.0.0.1:1234",
+			headers: map[string]string{"X-Forwarded-For": "10.0.0.3, 10.0.0.2"},
+			proxy:   ProxyConfig{Trust: true, TrustedCIDRs: trusted},
+			want:    "10.0.0.3",
+		},
+		{
+			name:    "unparseable hop stops the walk",
+			peer:    "10.0.0.1:1234",
+			headers: map[string]string{"X-Forwarded-For": "198.51.100.7, garbage, 10.0.0.2"},
+			proxy:   ProxyConfig{Trust: true, TrustedCIDRs: trusted},
+			want:    "10.0.0.2",
+		},
+		{
+			name:    "hop count",
+			peer:    "10.0.0.1:1234",
+			headers: map[string]string{"X-Forwarded-For": "203.0.113.5, 198.51.100.7, 192.0.2.9"},
+			proxy:   ProxyConfig{Trust: true, Hops: 2},
+			want:    "198.51.100.7",
+		},
+		{
+			name:    "hop count longer than chain",
+			peer:    "10.0.0.1:1234",
+			headers: map[string]string{"X-Forwarded-For": "198.51.100.7"},
+			proxy:   ProxyConfig{Trust: true, Hops: 3},
+			want:    "198.51.100.7",
+		},
+		{
+			name: "forwarded header",
+			peer: "10.0.0.1:1234",
+			headers: map[string]string{
+				"Forwarded":       `for=203.0.113.5;proto=https, for="[2001:db8:cafe::17]:4711";by=10.0.0.2, for="10.0.0.2:80"`,
+				"X-Forwarded-For": "192.0.2.1",
+			},
+			proxy: ProxyConfig{Trust: true, TrustedCIDRs: trusted, Header: HeaderForwarded},
+			want:  "2001:db8:cafe::17",
+		},
+		{
+			name:    "forwarded unknown node",
+			peer:    "10.0.0.1:1234",
+			headers: map[string]string{"Forwarded": `for=unknown, for="_hidden", for=198.51.100.7`},
+			proxy:   ProxyConfig{Trust: true, Header: HeaderForwarded},
+			want:    "198.51.100.7",
+		},
+		{
+			name:    "forwarded quoted separators",
+			peer:    "10.0.0.1:1234",
+			headers: map[string]string{"Forwarded": `for=198.51.100.7;host="a,b;c", for=10.0.0.2`},
+			proxy:   ProxyConfig{Trust: true, TrustedCIDRs: trusted, Header: HeaderForwarded},
+			want:    "198.51.100.7",
+		},
+		{
+			name:    "forwarded mode ignores x-forwarded-for",
+			peer:    "10.0.0.1:1234",
+			headers: map[string]string{"X-Forwarded-For": "198.51.100.7"},
+			proxy:   ProxyConfig{Trust: true, Header: HeaderForwarded},
+			want:    "10.0.0.1",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tc.peer
+			for k, v := range tc.headers {
+				r.Header.Set(k, v)
+			}
+			if got := ClientIP(r, tc.proxy); got.String() != tc.want {
+				t.Fatalf("got %v, want %s", got, tc.want)
+			}
+		})
+	}
+}
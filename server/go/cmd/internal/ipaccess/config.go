@@ -0,0 +1,101 @@
+package ipaccess
+
+import (
+	"arc/cmd/internal/config"
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// Config holds the access lists. Empty lists impose nothing.
+type Config struct {
+	// AllowCIDRs and AllowCountries, when either is set, admit only addresses in
+	// one of the prefixes or resolving to one of the countries.
+	AllowCIDRs     []netip.Prefix
+	AllowCountries []string
+
+	// DenyCIDRs and DenyCountries refuse matching addresses.
+	DenyCIDRs     []netip.Prefix
+	DenyCountries []string
+
+	// BypassCIDRs are always allowed, before any other list is consulted.
+	BypassCIDRs []netip.Prefix
+}
+
+// Enabled reports whether any list restricts access.
+func (c Config) Enabled() bool {
+	return len(c.AllowCIDRs) > 0 || len(c.AllowCountries) > 0 || len(c.DenyCIDRs) > 0 || len(c.DenyCountries) > 0
+}
+
+// LoadConfigFromEnv reads the comma-separated lists:
+//   - ARC_ACCESS_ALLOW_CIDRS, ARC_ACCESS_DENY_CIDRS, ARC_ACCESS_BYPASS_CIDRS
+//     (prefixes such as "10.0.0.0/8" or single addresses)
+//   - ARC_ACCESS_ALLOW_COUNTRIES, ARC_ACCESS_DENY_COUNTRIES
+//     (ISO 3166-1 alpha-2 codes, case-insensitive)
+//
+// A malformed entry is an error so a typo cannot silently open access.
+func LoadConfigFromEnv() (Config, error) {
+	return LoadConfigFrom(config.Env)
+}
+
+// LoadConfigFrom is LoadConfigFromEnv reading the settings through lookup.
+func LoadConfigFrom(lookup config.Lookup) (Config, error) {
+	var (
+		cfg Config
+		err error
+	)
+	if cfg.AllowCIDRs, err = parsePrefixes(lookup, "ARC_ACCESS_ALLOW_CIDRS"); err != nil {
+		return Config{}, err
+	}
+	if cfg.DenyCIDRs, err = parsePrefixes(lookup, "ARC_ACCESS_DENY_CIDRS"); err != nil {
+		return Config{}, err
+	}
+	if cfg.BypassCIDRs, err = parsePrefixes(lookup, "ARC_ACCESS_BYPASS_CIDRS"); err != nil {
+		return Config{}, err
+	}
+	if cfg.AllowCountries, err = parseCountries(lookup, "ARC_ACCESS_ALLOW_COUNTRIES"); err != nil {
+		return Config{}, err
+	}
+	if cfg.DenyCountries, err = parseCountries(lookup, "ARC_ACCESS_DENY_COUNTRIES"); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+func parsePrefixes(lookup config.Lookup, key string) ([]netip.Prefix, error) {
+	var out []netip.Prefix
+	for _, part := range splitCSV(lookup.Get(key)) {
+		if p, err := netip.ParsePrefix(part); err == nil {
+			out = append(out, p.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(part)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid prefix %q", key, part)
+		}
+		out = append(out, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+	}
+	return out, nil
+}
+
+func parseCountries(lookup config.Lookup, key string) ([]string, error) {
+	var out []string
+	for _, part := range splitCSV(lookup.Get(key)) {
+		code := strings.ToUpper(part)
+		if len(code) != 2 || code[0] < 'A' || code[0] > 'Z' || code[1] < 'A' || code[1] > 'Z' {
+			return nil, fmt.Errorf("%s: invalid country code %q", key, part)
+		}
+		out = append(out, code)
+	}
+	return out, nil
+}
+
+func splitCSV(v string) []string {
+	var out []string
+	for _, part := range strings.Split(v, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
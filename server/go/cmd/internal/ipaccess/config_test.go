@@ -0,0 +1,44 @@
+package ipaccess
+
+import "testing"
+
+func TestLoadConfigFromEnv(t *testing.T) {
+	t.Setenv("ARC_ACCESS_ALLOW_CIDRS", "10.0.0.0/8, 192.0.2.7")
+	t.Setenv("ARC_ACCESS_DENY_CIDRS", "")
+	t.Setenv("ARC_ACCESS_BYPASS_CIDRS", "2001:db8::/32")
+	t.Setenv("ARC_ACCESS_ALLOW_COUNTRIES", "de, fr")
+	t.Setenv("ARC_ACCESS_DENY_COUNTRIES", "")
+
+	cfg, err := LoadConfigFromEnv()
+	if err != nil {
+		t.Fatalf("LoadConfigFromEnv: %v", err)
+	}
+	if !cfg.Enabled() {
+		t.Fatalf("expected config to be enabled")
+	}
+	if len(cfg.AllowCIDRs) != 2 || cfg.AllowCIDRs[1].String() != "192.0.2.7/32" {
+		t.Fatalf("unexpected allow cidrs %v", cfg.AllowCIDRs)
+	}
+	if len(cfg.BypassCIDRs) != 1 || cfg.BypassCIDRs[0].String() != "2001:db8::/32" {
+		t.Fatalf("unexpected bypass cidrs %v", cfg.BypassCIDRs)
+	}
+	if len(cfg.AllowCountries) != 2 || cfg.AllowCountries[0] != "DE" || cfg.AllowCountries[1] != "FR" {
+		t.Fatalf("unexpected allow countries %v", cfg.AllowCountries)
+	}
+}
+
+func TestLoadConfigFromEnv_Invalid(t *testing.T) {
+	for key, val := range map[string]string{
+		"ARC_ACCESS_DENY_CIDRS":      "10.0.0.0/33",
+		"ARC_ACCESS_BYPASS_CIDRS":    "office",
+		"ARC_ACCESS_DENY_COUNTRIES":  "IRN",
+		"ARC_ACCESS_ALLOW_COUNTRIES": "d1",
+	} {
+		t.Run(key, func(t *testing.T) {
+			t.Setenv(key, val)
+			if _, err := LoadConfigFromEnv(); err == nil {
+				t.Fatalf("expected error for %s=%q", key, val)
+			}
+		})
+	}
+}
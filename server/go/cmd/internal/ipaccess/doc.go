@@ -0,0 +1,16 @@
+// Package ipaccess decides whether a client address may authenticate, from
+// operator-configured CIDR and GeoIP country allow and deny lists.
+//
+// The auth API checks the policy before login and refresh, and the realtime
+// gateway before a WebSocket upgrade. Addresses in the bypass list (e.g. admin
+// networks) are always allowed; otherwise a deny match wins, and when any allow
+// list is set the address must match one of them. Refusals are reported to a
+// Reporter, which Auditor implements by writing "auth.access.ip_denied" and
+// "auth.access.country_denied" rows to the audit log.
+//
+// Country lists inherit GeoIP's imprecision: an address the resolver does not
+// know has no country, so it fails a country allow list and passes a deny list.
+//
+// ClientIP resolves the client address both callers check, honoring forwarding
+// headers only as far as ProxyConfig trusts them.
+package ipaccess
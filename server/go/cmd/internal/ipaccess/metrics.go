@@ -0,0 +1,6 @@
+package ipaccess
+
+import "arc/cmd/internal/metrics"
+
+var deniedTotal = metrics.NewCounterVec("arc_access_denied_total",
+	"Requests refused by the IP and country access lists, by endpoint and reason.", "endpoint", "reason")
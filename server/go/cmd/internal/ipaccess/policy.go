@@ -0,0 +1,137 @@
+package ipaccess
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"slices"
+	"strings"
+	"time"
+
+	"arc/cmd/internal/geoip"
+)
+
+// Decision reasons. Allowed decisions carry ReasonBypass or no reason.
+const (
+	ReasonBypass            = "bypass"
+	ReasonIPDenied          = "ip_denied"
+	ReasonIPNotAllowed      = "ip_not_allowed"
+	ReasonCountryDenied     = "country_denied"
+	ReasonCountryNotAllowed = "country_not_allowed"
+)
+
+// Decision is the outcome of Policy.Check.
+type Decision struct {
+	Allowed bool
+	Reason  string
+	// Country is the resolved country code, when a country list had to be consulted.
+	Country string
+}
+
+// Denial describes a refused request for a Reporter.
+type Denial struct {
+	// Endpoint names the guarded step: "login", "login_challenge", "otp_request",
+	// "otp_verify", "refresh", "access_renew" or "ws".
+	Endpoint  string
+	IP        net.IP
+	Country   string
+	Reason    string
+	UserAgent string
+	At        time.Time
+}
+
+// Reporter records refused requests, e.g. in the audit log.
+type Reporter interface {
+	// ReportDenied must not block the request.
+	ReportDenied(ctx context.Context, d Denial)
+}
+
+// Policy evaluates the access lists. A nil *Policy allows everything.
+type Policy struct {
+	cfg      Config
+	geo      geoip.Resolver
+	reporter Reporter
+}
+
+// New returns the policy for cfg, or nil when cfg restricts nothing. geo resolves
+// countries (nil resolves none) and reporter (may be nil) receives denials.
+func New(cfg Config, geo geoip.Resolver, reporter Reporter) *Policy {
+	if !cfg.Enabled() {
+		return nil
+	}
+	if geo == nil {
+		geo = geoip.NoopResolver{}
+	}
+	return &Policy{cfg: cfg, geo: geo, reporter: reporter}
+}
+
+// Check decides whether ip may authenticate. Deny lists are consulted before
+// allow lists; the bypass list before both.
+func (p *Policy) Check(ctx context.Context, ip net.IP) Decision {
+	if p == nil {
+		return Decision{Allowed: true}
+	}
+	addr, ok := netip.AddrFromSlice(ip)
+	addr = addr.Unmap()
+	if ok && containsAddr(p.cfg.BypassCIDRs, addr) {
+		return Decision{Allowed: true, Reason: ReasonBypass}
+	}
+	if ok && containsAddr(p.cfg.DenyCIDRs, addr) {
+		return Decision{Reason: ReasonIPDenied}
+	}
+
+	var country string
+	if ok && (len(p.cfg.AllowCountries) > 0 || len(p.cfg.DenyCountries) > 0) {
+		// Lookup failures leave the country unknown.
+		if loc, err := p.geo.Lookup(ctx, ip); err == nil {
+			country = strings.ToUpper(loc.Country)
+		}
+	}
+	if country != "" && slices.Contains(p.cfg.DenyCountries, country) {
+		return Decision{Reason: ReasonCountryDenied, Country: country}
+	}
+
+	if len(p.cfg.AllowCIDRs) == 0 && len(p.cfg.AllowCountries) == 0 {
+		return Decision{Allowed: true, Country: country}
+	}
+	if ok && containsAddr(p.cfg.AllowCIDRs, addr) {
+		return Decision{Allowed: true, Country: country}
+	}
+	if country != "" && slices.Contains(p.cfg.AllowCountries, country) {
+		return Decision{Allowed: true, Country: country}
+	}
+	if len(p.cfg.AllowCountries) > 0 {
+		return Decision{Reason: ReasonCountryNotAllowed, Country: country}
+	}
+	return Decision{Reason: ReasonIPNotAllowed, Country: country}
+}
+
+// Allow checks ip for endpoint and reports a refusal. It returns false when the
+// caller must reject the request.
+func (p *Policy) Allow(ctx context.Context, ip net.IP, endpoint, userAgent string) bool {
+	d := p.Check(ctx, ip)
+	if d.Allowed {
+		return true
+	}
+	deniedTotal.With(endpoint, d.Reason).Inc()
+	if p.reporter != nil {
+		p.reporter.ReportDenied(ctx, Denial{
+			Endpoint:  endpoint,
+			IP:        ip,
+			Country:   d.Country,
+			Reason:    d.Reason,
+			UserAgent: userAgent,
+			At:        time.Now().UTC(),
+		})
+	}
+	return false
+}
+
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, p := range prefixes {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package ipaccess
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"testing"
+
+	"arc/cmd/internal/geoip"
+)
+
+type countryResolver map[string]string
+
+func (c countryResolver) Lookup(_ context.Context, ip net.IP) (geoip.Location, error) {
+	if ip.String() == "203.0.113.99" {
+		return geoip.Location{}, errors.New("lookup failed")
+	}
+	return geoip.Location{Country: c[ip.String()]}, nil
+}
+
+type recordingReporter struct{ got []Denial }
+
+func (r *recordingReporter) ReportDenied(_ context.Context, d Denial) { r.got = append(r.got, d) }
+
+func prefixes(t *testing.T, ss ...string) []netip.Prefix {
+	t.Helper()
+	out := make([]netip.Prefix, 0, len(ss))
+	for _, s := range ss {
+		out = append(out, netip.MustParsePrefix(s))
+	}
+	return out
+}
+
+func TestPolicy_Check(t *testing.T) {
+	geo := countryResolver{
+		"198.51.100.7": "IR",
+		"198.51.100.8": "DE",
+		"198.51.100.9": "US",
+		"10.1.2.3":     "IR",
+	}
+	ctx := context.Background()
+
+	cases := []struct {
+		name   string
+		cfg    Config
+		ip     string
+		allow  bool
+		reason string
+	}{
+		{"deny cidr", Config{DenyCIDRs: prefixes(t, "198.51.100.0/24")}, "198.51.100.8", false, ReasonIPDenied},
+		{"outside deny cidr", Config{DenyCIDRs: prefixes(t, "192.0.2.0/24")}, "198.51.100.8", true, ""},
+		{"deny country", Config{DenyCountries: []string{"IR"}}, "198.51.100.7", false, ReasonCountryDenied},
+		{"unknown country passes deny list", Config{DenyCountries: []string{"IR"}}, "203.0.113.99", true, ""},
+		{"allow country", Config{AllowCountries: []string{"DE"}}, "198.51.100.8", true, ""},
+		{"country not allowed", Config{AllowCountries: []string{"DE"}}, "198.51.100.9", false, ReasonCountryNotAllowed},
+		{"unknown country fails allow list", Config{AllowCountries: []string{"DE"}}, "203.0.113.99", false, ReasonCountryNotAllowed},
+		{"allow cidr admits other countries", Config{AllowCountries: []string{"DE"}, AllowCIDRs: prefixes(t, "198.51.100.9/32")}, "198.51.100.9", true, ""},
+		{"ip not allowed", Config{AllowCIDRs: prefixes(t, "192.0.2.0/24")}, "198.51.100.8", false, ReasonIPNotAllowed},
+		{"deny country beats allow cidr", Config{AllowCIDRs: prefixes(t, "198.51.100.0/24"), DenyCountries: []string{"IR"}}, "198.51.100.7", false, ReasonCountryDenied},
+		{"bypass beats deny", Config{DenyCountries: []string{"IR"}, BypassCIDRs: prefixes(t, "10.0.0.0/8")}, "10.1.2.3", true, ReasonBypass},
+		{"ipv4-mapped address", Config{DenyCIDRs: prefixes(t, "198.51.100.0/24")}, "::ffff:198.51.100.8", false, ReasonIPDenied},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := New(tc.cfg, geo, nil)
+			d := p.Check(ctx, net.ParseIP(tc.ip))
+			if d.Allowed != tc.allow || d.Reason != tc.reason {
+				t.Fatalf("got %+v, want allowed=%v reason=%q", d, tc.allow, tc.reason)
+			}
+		})
+	}
+}
+
+func TestPolicy_NilAndMissingIP(t *testing.T) {
+	var p *Policy
+	if !p.Allow(context.Background(), nil, "login", "") {
+		t.Fatalf("nil policy must allow")
+	}
+	if New(Config{BypassCIDRs: prefixes(t, "10.0.0.0/8")}, nil, nil) != nil {
+		t.Fatalf("a bypass list alone restricts nothing")
+	}
+
+	p = New(Config{AllowCIDRs: prefixes(t, "10.0.0.0/8")}, nil, nil)
+	if d := p.Check(context.Background(), nil); d.Allowed || d.Reason != ReasonIPNotAllowed {
+		t.Fatalf("expected a missing address to fail the allow list, got %+v", d)
+	}
+}
+
+func TestPolicy_AllowReportsDenials(t *testing.T) {
+	rep := &recordingReporter{}
+	p := New(Config{DenyCountries: []string{"IR"}}, countryResolver{"198.51.100.7": "IR"}, rep)
+
+	if p.Allow(context.Background(), net.ParseIP("198.51.100.7"), "refresh", "arc-test/1.0") {
+		t.Fatalf("expected denial")
+	}
+	if !p.Allow(context.Background(), net.ParseIP("198.51.100.8"), "refresh", "arc-test/1.0") {
+		t.Fatalf("expected other countries to pass")
+	}
+	if len(rep.got) != 1 {
+		t.Fatalf("expected one report, got %d", len(rep.got))
+	}
+	d := rep.got[0]
+	if d.Endpoint != "refresh" || d.Reason != ReasonCountryDenied || d.Country != "IR" || d.UserAgent != "arc-test/1.0" {
+		t.Fatalf("unexpected denial %+v", d)
+	}
+}
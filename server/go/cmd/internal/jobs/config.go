@@ -0,0 +1,35 @@
+package jobs
+
+import (
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Config selects which jobs run on this instance.
+type Config struct {
+	// Enabled runs scheduled jobs here; false leaves them to other instances.
+	Enabled bool
+	// Disabled names jobs that never run here.
+	Disabled []string
+}
+
+// LoadConfigFromEnv reads ARC_JOBS_ENABLED (default true) and ARC_JOBS_DISABLED.
+func LoadConfigFromEnv() Config {
+	cfg := Config{Enabled: true}
+	if v, err := strconv.ParseBool(strings.TrimSpace(os.Getenv("ARC_JOBS_ENABLED"))); err == nil {
+		cfg.Enabled = v
+	}
+	for _, name := range strings.Split(os.Getenv("ARC_JOBS_DISABLED"), ",") {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			cfg.Disabled = append(cfg.Disabled, name)
+		}
+	}
+	return cfg
+}
+
+// Allows reports whether the job called name may run on this instance.
+func (c Config) Allows(name string) bool {
+	return c.Enabled && !slices.Contains(c.Disabled, name)
+}
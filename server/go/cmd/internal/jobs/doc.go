@@ -0,0 +1,14 @@
+// Package jobs runs periodic background work on a schedule.
+//
+// A Job pairs a Schedule (a fixed interval or a cron expression) with a Run
+// function. The Scheduler starts one loop per job, adds a random delay of up to
+// Job.Jitter before each run so instances do not fire in lockstep, bounds each run
+// by Job.Timeout and records arc_job_* metrics.
+//
+// With a Locker the instances elect one leader per job: a node runs a job only
+// while it holds the job's lease, so only one instance runs each job. The
+// PostgresLocker lease is a session advisory lock kept on a dedicated pool
+// connection; when the leader stops or loses its connection, Postgres drops the
+// lock and another node takes over at its next scheduled run. Without a Locker
+// every instance runs every job.
+package jobs
@@ -0,0 +1,65 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"arc/cmd/internal/dbtrace"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// leaseUnlockTimeout bounds the unlock issued when a lease is released.
+const leaseUnlockTimeout = 5 * time.Second
+
+// PostgresLocker elects job leaders with session advisory locks keyed by
+// "arc.job.<name>". Each held lease pins one pool connection.
+type PostgresLocker struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresLocker constructs a PostgresLocker.
+func NewPostgresLocker(pool *pgxpool.Pool) (*PostgresLocker, error) {
+	if pool == nil {
+		return nil, errors.New("jobs: nil db pool")
+	}
+	return &PostgresLocker{pool: pool}, nil
+}
+
+// TryAcquire takes the advisory lock for name on a dedicated connection.
+func (l *PostgresLocker) TryAcquire(ctx context.Context, name string) (Lease, bool, error) {
+	ctx = dbtrace.WithOp(ctx, "jobs.try_acquire")
+	conn, err := l.pool.Acquire(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	key := "arc.job." + name
+	var ok bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock(hashtextextended($1, 0))`, key).Scan(&ok); err != nil || !ok {
+		conn.Release()
+		return nil, false, err
+	}
+	return &pgLease{conn: conn, key: key}, true, nil
+}
+
+// pgLease holds an advisory lock for as long as its connection lives.
+type pgLease struct {
+	conn *pgxpool.Conn
+	key  string
+}
+
+// Held pings the lease's connection; the lock lives exactly as long as the session.
+func (l *pgLease) Held(ctx context.Context) bool {
+	return l.conn.Ping(ctx) == nil
+}
+
+func (l *pgLease) Release() {
+	ctx, cancel := context.WithTimeout(dbtrace.WithOp(context.Background(), "jobs.release"), leaseUnlockTimeout)
+	defer cancel()
+	if _, err := l.conn.Exec(ctx, `SELECT pg_advisory_unlock(hashtextextended($1, 0))`, l.key); err != nil {
+		// The session may still hold the lock; close it rather than return it to the pool.
+		_ = l.conn.Conn().Close(ctx)
+	}
+	l.conn.Release()
+}
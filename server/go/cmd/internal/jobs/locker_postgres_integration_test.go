@@ -0,0 +1,72 @@
+package jobs
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oklog/ulid/v2"
+)
+
+// Integration tests are enabled when ARC_DATABASE_URL points at a reachable database.
+
+func TestPostgresLocker_OneLeaderPerJob(t *testing.T) {
+	pool := openTestPool(t)
+	ctx := context.Background()
+	locker, err := NewPostgresLocker(pool)
+	if err != nil {
+		t.Fatalf("NewPostgresLocker: %v", err)
+	}
+	name := "test." + strings.ToLower(ulid.Make().String())
+
+	lease, ok, err := locker.TryAcquire(ctx, name)
+	if err != nil || !ok {
+		t.Fatalf("first TryAcquire = %v, %v", ok, err)
+	}
+	if !lease.Held(ctx) {
+		t.Fatalf("expected the lease to be held")
+	}
+	if _, ok, err := locker.TryAcquire(ctx, name); err != nil || ok {
+		t.Fatalf("second TryAcquire = %v, %v; want refused", ok, err)
+	}
+	if other, ok, err := locker.TryAcquire(ctx, name+".other"); err != nil || !ok {
+		t.Fatalf("other job TryAcquire = %v, %v", ok, err)
+	} else {
+		other.Release()
+	}
+
+	lease.Release()
+	again, ok, err := locker.TryAcquire(ctx, name)
+	if err != nil || !ok {
+		t.Fatalf("TryAcquire after release = %v, %v", ok, err)
+	}
+	again.Release()
+}
+
+func openTestPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+
+	dbURL := strings.TrimSpace(os.Getenv("ARC_DATABASE_URL"))
+	if dbURL == "" {
+		t.Skip("ARC_DATABASE_URL is not set; skipping Postgres integration test")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	pool, err := pgxpool.New(ctx, dbURL)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		if os.Getenv("CI") == "" {
+			t.Skipf("Postgres unreachable (ARC_DATABASE_URL set): %v", err)
+		}
+		t.Fatalf("ping: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	return pool
+}
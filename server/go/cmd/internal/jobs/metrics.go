@@ -0,0 +1,15 @@
+package jobs
+
+import "arc/cmd/internal/metrics"
+
+var (
+	jobRuns = metrics.NewCounterVec("arc_job_runs_total",
+		"Background job runs by result (success, error, skipped when another instance leads).", "job", "result")
+	jobDuration = metrics.NewHistogramVec("arc_job_duration_seconds",
+		"Background job run duration in seconds.",
+		[]float64{0.01, 0.1, 0.5, 1, 5, 15, 60, 300, 900, 3600}, "job")
+	jobLastSuccess = metrics.NewGaugeVec("arc_job_last_success_timestamp_seconds",
+		"Unix time of the last successful run of each background job on this instance.", "job")
+	jobLeader = metrics.NewGaugeVec("arc_job_leader",
+		"1 while this instance holds the job's leader lease.", "job")
+)
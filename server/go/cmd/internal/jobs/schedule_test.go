@@ -0,0 +1,71 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse_Cron(t *testing.T) {
+	at := func(s string) time.Time {
+		t.Helper()
+		v, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatalf("parse %s: %v", s, err)
+		}
+		return v
+	}
+	tests := []struct {
+		spec  string
+		after string
+		want  string
+	}{
+		{"*/15 * * * *", "2026-03-04T10:07:30Z", "2026-03-04T10:15:00Z"},
+		{"0 3 * * *", "2026-03-04T03:00:00Z", "2026-03-05T03:00:00Z"},
+		{"30 2 1 * *", "2026-01-31T12:00:00Z", "2026-02-01T02:30:00Z"},
+		{"0 9 * * 1-5", "2026-03-06T10:00:00Z", "2026-03-09T09:00:00Z"}, // Friday -> Monday
+		{"0 0 * * 7", "2026-03-04T00:00:00Z", "2026-03-08T00:00:00Z"},   // 7 is Sunday
+		{"0 0 13 * 5", "2026-03-01T00:00:00Z", "2026-03-06T00:00:00Z"},  // day 13 or a Friday
+		{"5,10 0-1/1 * 2 *", "2026-03-04T00:00:00Z", "2027-02-01T00:05:00Z"},
+		{"@hourly", "2026-03-04T10:59:59Z", "2026-03-04T11:00:00Z"},
+		{"@daily", "2026-12-31T23:59:00Z", "2027-01-01T00:00:00Z"},
+	}
+	for _, tc := range tests {
+		s, err := Parse(tc.spec)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tc.spec, err)
+		}
+		if got := s.Next(at(tc.after)); !got.Equal(at(tc.want)) {
+			t.Fatalf("%q after %s = %s, want %s", tc.spec, tc.after, got.Format(time.RFC3339), tc.want)
+		}
+	}
+}
+
+func TestParse_Every(t *testing.T) {
+	s, err := Parse("@every 90s")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	if got := s.Next(now); !got.Equal(now.Add(90 * time.Second)) {
+		t.Fatalf("Next = %s", got)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, spec := range []string{
+		"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8",
+		"*/0 * * * *", "5-1 * * * *", "a * * * *", "@every 10ms", "@every soon", "@yearly",
+	} {
+		if _, err := Parse(spec); err == nil {
+			t.Fatalf("Parse(%q): expected error", spec)
+		}
+	}
+}
+
+func TestCron_NeverMatches(t *testing.T) {
+	s := MustParse("0 0 31 2 *")
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	if got := s.Next(now); got.Sub(now) < 4*365*24*time.Hour {
+		t.Fatalf("expected impossible schedule to park far ahead, got %s", got)
+	}
+}
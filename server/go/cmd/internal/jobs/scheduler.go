@@ -0,0 +1,190 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// Job is one kind of periodic work.
+type Job struct {
+	// Name identifies the job in logs, metrics and leader leases; lower-case
+	// letters, digits, '.', '_' and '-'.
+	Name     string
+	Schedule Schedule
+	// Jitter bounds a random delay added before each run.
+	Jitter time.Duration
+	// Timeout bounds one run; zero leaves it unbounded.
+	Timeout time.Duration
+	// Run does the work. It should return promptly once ctx is done.
+	Run func(ctx context.Context) error
+}
+
+// Locker elects one leader per job among the instances.
+type Locker interface {
+	// TryAcquire takes the lease for the job called name without waiting. ok is
+	// false when another instance holds it.
+	TryAcquire(ctx context.Context, name string) (lease Lease, ok bool, err error)
+}
+
+// Lease is the leadership of one job.
+type Lease interface {
+	// Held reports whether the lease is still in force.
+	Held(ctx context.Context) bool
+	// Release gives the lease up.
+	Release()
+}
+
+var jobNameRE = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+
+// Scheduler runs jobs on their schedules.
+type Scheduler struct {
+	log    *slog.Logger
+	locker Locker
+	jobs   []Job
+	now    func() time.Time
+}
+
+// New constructs a Scheduler. A nil locker runs every job on this instance.
+func New(log *slog.Logger, locker Locker) *Scheduler {
+	if log == nil {
+		log = slog.Default()
+	}
+	return &Scheduler{log: log, locker: locker, now: time.Now}
+}
+
+// Add registers j. It must be called before Run.
+func (s *Scheduler) Add(j Job) error {
+	if !jobNameRE.MatchString(j.Name) {
+		return fmt.Errorf("jobs: invalid job name %q", j.Name)
+	}
+	if j.Schedule == nil || j.Run == nil {
+		return fmt.Errorf("jobs: %s: schedule and run are required", j.Name)
+	}
+	if j.Jitter < 0 || j.Timeout < 0 {
+		return fmt.Errorf("jobs: %s: negative jitter or timeout", j.Name)
+	}
+	for _, other := range s.jobs {
+		if other.Name == j.Name {
+			return fmt.Errorf("jobs: duplicate job %q", j.Name)
+		}
+	}
+	s.jobs = append(s.jobs, j)
+	return nil
+}
+
+// Len returns the number of registered jobs.
+func (s *Scheduler) Len() int {
+	return len(s.jobs)
+}
+
+// Run runs the registered jobs until ctx is done, then waits for runs in
+// progress to return and releases held leases.
+func (s *Scheduler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, j := range s.jobs {
+		wg.Go(func() { s.loop(ctx, j) })
+	}
+	wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, j Job) {
+	var lease Lease
+	defer func() {
+		if lease != nil {
+			lease.Release()
+			jobLeader.With(j.Name).Set(0)
+		}
+	}()
+
+	for {
+		now := s.now()
+		t := time.NewTimer(j.Schedule.Next(now).Sub(now) + jitter(j.Jitter))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		if s.locker != nil {
+			var leading bool
+			lease, leading = s.lead(ctx, j.Name, lease)
+			if !leading {
+				jobRuns.With(j.Name, "skipped").Inc()
+				continue
+			}
+		}
+		_ = s.runJob(ctx, j)
+	}
+}
+
+// lead keeps or takes the lease for name. It returns the lease to keep (nil when
+// not leading).
+func (s *Scheduler) lead(ctx context.Context, name string, lease Lease) (Lease, bool) {
+	if lease != nil {
+		if lease.Held(ctx) {
+			return lease, true
+		}
+		lease.Release()
+		jobLeader.With(name).Set(0)
+		s.log.Warn("jobs.leader.lost", "job", name)
+	}
+	lease, ok, err := s.locker.TryAcquire(ctx, name)
+	if err != nil {
+		if ctx.Err() == nil {
+			s.log.Error("jobs.leader.fail", "job", name, "err", err, "result", "server_error")
+		}
+		return nil, false
+	}
+	if !ok {
+		return nil, false
+	}
+	jobLeader.With(name).Set(1)
+	s.log.Info("jobs.leader.acquired", "job", name, "result", "success")
+	return lease, true
+}
+
+// runJob runs j once, recording metrics and turning a panic into an error.
+func (s *Scheduler) runJob(ctx context.Context, j Job) (err error) {
+	runCtx := ctx
+	if j.Timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, j.Timeout)
+		defer cancel()
+	}
+
+	start := s.now()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("jobs: %s panicked: %v", j.Name, r)
+		}
+		elapsed := s.now().Sub(start)
+		jobDuration.With(j.Name).Observe(elapsed.Seconds())
+		if err != nil {
+			jobRuns.With(j.Name, "error").Inc()
+			if ctx.Err() == nil {
+				s.log.Error("jobs.run.fail", "job", j.Name, "err", err, "duration", elapsed, "result", "server_error")
+			}
+			return
+		}
+		jobRuns.With(j.Name, "success").Inc()
+		jobLastSuccess.With(j.Name).Set(float64(s.now().Unix()))
+		s.log.Debug("jobs.run", "job", j.Name, "duration", elapsed, "result", "success")
+	}()
+	return j.Run(runCtx)
+}
+
+func jitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	return rand.N(max)
+}
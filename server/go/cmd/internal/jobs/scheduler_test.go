@@ -0,0 +1,160 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// tick schedules runs every few milliseconds, below Every's floor.
+type tick time.Duration
+
+func (d tick) Next(after time.Time) time.Time { return after.Add(time.Duration(d)) }
+
+type memoryLocker struct {
+	mu     sync.Mutex
+	holder map[string]*memoryLease
+}
+
+type memoryLease struct {
+	l        *memoryLocker
+	name     string
+	revoked  atomic.Bool
+	released atomic.Bool
+}
+
+func (l *memoryLocker) TryAcquire(_ context.Context, name string) (Lease, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.holder == nil {
+		l.holder = map[string]*memoryLease{}
+	}
+	if h := l.holder[name]; h != nil && !h.released.Load() && !h.revoked.Load() {
+		return nil, false, nil
+	}
+	lease := &memoryLease{l: l, name: name}
+	l.holder[name] = lease
+	return lease, true, nil
+}
+
+func (m *memoryLease) Held(context.Context) bool { return !m.revoked.Load() }
+func (m *memoryLease) Release()                  { m.released.Store(true) }
+
+func discardLogger() *slog.Logger { return slog.New(slog.NewTextHandler(io.Discard, nil)) }
+
+func TestScheduler_Add(t *testing.T) {
+	s := New(discardLogger(), nil)
+	run := func(context.Context) error { return nil }
+	if err := s.Add(Job{Name: "retention", Schedule: Every(time.Minute), Run: run}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	bad := []Job{
+		{Name: "retention", Schedule: Every(time.Minute), Run: run},
+		{Name: "Bad Name", Schedule: Every(time.Minute), Run: run},
+		{Name: "no-run", Schedule: Every(time.Minute)},
+		{Name: "no-schedule", Run: run},
+		{Name: "neg", Schedule: Every(time.Minute), Run: run, Jitter: -time.Second},
+	}
+	for _, j := range bad {
+		if err := s.Add(j); err == nil {
+			t.Fatalf("Add(%q): expected error", j.Name)
+		}
+	}
+	if s.Len() != 1 {
+		t.Fatalf("Len = %d, want 1", s.Len())
+	}
+}
+
+func TestScheduler_OnlyLeaderRuns(t *testing.T) {
+	locker := &memoryLocker{}
+	var runsA, runsB atomic.Int64
+	a := New(discardLogger(), locker)
+	b := New(discardLogger(), locker)
+	// Runs racing the shutdown (after the leader released its lease) are not counted.
+	count := func(n *atomic.Int64) func(context.Context) error {
+		return func(ctx context.Context) error {
+			if ctx.Err() == nil {
+				n.Add(1)
+			}
+			return nil
+		}
+	}
+	_ = a.Add(Job{Name: "reaper", Schedule: tick(2 * time.Millisecond), Run: count(&runsA)})
+	_ = b.Add(Job{Name: "reaper", Schedule: tick(2 * time.Millisecond), Run: count(&runsB)})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	var wg sync.WaitGroup
+	wg.Go(func() { a.Run(ctx) })
+	wg.Go(func() { b.Run(ctx) })
+	wg.Wait()
+
+	if (runsA.Load() == 0) == (runsB.Load() == 0) {
+		t.Fatalf("expected exactly one instance to run, got a=%d b=%d", runsA.Load(), runsB.Load())
+	}
+	if h := locker.holder["reaper"]; h == nil || !h.released.Load() {
+		t.Fatalf("expected the lease to be released on shutdown")
+	}
+}
+
+func TestScheduler_LostLeaseHandsOver(t *testing.T) {
+	locker := &memoryLocker{}
+	s := New(discardLogger(), locker)
+
+	lease, ok := s.lead(context.Background(), "reaper", nil)
+	if !ok || lease == nil {
+		t.Fatalf("expected to take the free lease")
+	}
+	if _, ok := New(discardLogger(), locker).lead(context.Background(), "reaper", nil); ok {
+		t.Fatalf("expected a second instance to be refused")
+	}
+
+	lease.(*memoryLease).revoked.Store(true)
+	next, ok := s.lead(context.Background(), "reaper", lease)
+	if !ok || next == lease || !lease.(*memoryLease).released.Load() {
+		t.Fatalf("expected a lost lease to be released and re-acquired")
+	}
+}
+
+func TestScheduler_RunJobRecoversAndTimesOut(t *testing.T) {
+	s := New(discardLogger(), nil)
+
+	err := s.runJob(context.Background(), Job{Name: "panics", Run: func(context.Context) error { panic("boom") }})
+	if err == nil {
+		t.Fatalf("expected panic to surface as an error")
+	}
+
+	err = s.runJob(context.Background(), Job{Name: "slow", Timeout: 5 * time.Millisecond, Run: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want deadline exceeded", err)
+	}
+
+	before := jobRuns.With("ok", "success").Value()
+	if err := s.runJob(context.Background(), Job{Name: "ok", Run: func(context.Context) error { return nil }}); err != nil {
+		t.Fatalf("runJob: %v", err)
+	}
+	if jobRuns.With("ok", "success").Value() != before+1 || jobLastSuccess.With("ok").Value() == 0 {
+		t.Fatalf("expected success metrics to be recorded")
+	}
+}
+
+func TestConfig_Allows(t *testing.T) {
+	t.Setenv("ARC_JOBS_ENABLED", "")
+	t.Setenv("ARC_JOBS_DISABLED", " Retention ,")
+	cfg := LoadConfigFromEnv()
+	if !cfg.Enabled || cfg.Allows("retention") || !cfg.Allows("reaper") {
+		t.Fatalf("unexpected config: %+v", cfg)
+	}
+	t.Setenv("ARC_JOBS_ENABLED", "false")
+	if LoadConfigFromEnv().Allows("reaper") {
+		t.Fatalf("expected ARC_JOBS_ENABLED=false to disable every job")
+	}
+}
@@ -0,0 +1,11 @@
+// Package maintenance holds the node's maintenance-mode switch.
+//
+// While enabled, the HTTP middleware in package app answers mutating requests
+// with 503 and Retry-After, the realtime gateway closes new WebSocket connects
+// with 1013 (try again later), and health, readiness and read-only endpoints keep
+// serving. Package app sets the switch from ARC_MAINTENANCE_* settings and config
+// reloads; it can also be flipped at runtime through POST /admin/maintenance.
+//
+// The state is per process: with several nodes, toggle each node or use the
+// config file so every node picks the change up.
+package maintenance
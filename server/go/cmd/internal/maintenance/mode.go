@@ -0,0 +1,76 @@
+package maintenance
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultRetryAfter is the Retry-After hint when none is configured.
+const DefaultRetryAfter = 5 * time.Minute
+
+// Status is a snapshot of the switch.
+type Status struct {
+	Enabled    bool
+	RetryAfter time.Duration
+	Message    string
+	// Since is when the switch last changed; Source is "config" or "admin".
+	Since  time.Time
+	Source string
+}
+
+// RetryAfterSeconds is the Retry-After header value, at least one second.
+func (s Status) RetryAfterSeconds() int {
+	return int(max(s.RetryAfter, time.Second) / time.Second)
+}
+
+// Mode is the maintenance switch. The zero value is off; it is safe for
+// concurrent use.
+type Mode struct {
+	status atomic.Pointer[Status]
+}
+
+// New returns a switch set to st.
+func New(st Status) *Mode {
+	m := &Mode{}
+	m.Set(st)
+	return m
+}
+
+// Set replaces the state. A zero RetryAfter becomes DefaultRetryAfter and a zero
+// Since becomes now.
+func (m *Mode) Set(st Status) {
+	if st.RetryAfter <= 0 {
+		st.RetryAfter = DefaultRetryAfter
+	}
+	if st.Since.IsZero() {
+		st.Since = time.Now().UTC()
+	}
+	m.status.Store(&st)
+}
+
+// Status returns the current state.
+func (m *Mode) Status() Status {
+	if m == nil {
+		return Status{}
+	}
+	if st := m.status.Load(); st != nil {
+		return *st
+	}
+	return Status{}
+}
+
+// Enabled reports whether maintenance mode is on. A nil Mode is off.
+func (m *Mode) Enabled() bool {
+	return m.Status().Enabled
+}
+
+// Mutating reports whether method can change state. GET, HEAD and OPTIONS keep
+// working during maintenance.
+func Mutating(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,41 @@
+package maintenance
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestMode(t *testing.T) {
+	t.Parallel()
+
+	var nilMode *Mode
+	if nilMode.Enabled() {
+		t.Fatal("nil mode should be off")
+	}
+
+	m := New(Status{})
+	if m.Enabled() || m.Status().RetryAfter != DefaultRetryAfter || m.Status().Since.IsZero() {
+		t.Fatalf("initial status = %+v", m.Status())
+	}
+	m.Set(Status{Enabled: true, RetryAfter: 1500 * time.Millisecond, Message: "upgrading", Source: "admin"})
+	st := m.Status()
+	if !st.Enabled || st.Message != "upgrading" || st.RetryAfterSeconds() != 1 {
+		t.Fatalf("status = %+v", st)
+	}
+}
+
+func TestMutating(t *testing.T) {
+	t.Parallel()
+
+	for _, m := range []string{http.MethodGet, http.MethodHead, http.MethodOptions} {
+		if Mutating(m) {
+			t.Fatalf("%s should be read-only", m)
+		}
+	}
+	for _, m := range []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+		if !Mutating(m) {
+			t.Fatalf("%s should be mutating", m)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+// Package metrics collects Arc's Prometheus metrics and serves them on /metrics.
+//
+// It is a thin layer over the Prometheus client library
+// (github.com/prometheus/client_golang), which does the bookkeeping and the
+// exposition encoding; the package keeps the constructors short and the label
+// handling positional:
+//
+//	var loginTotal = metrics.NewCounterVec("arc_auth_login_total", "Login attempts by outcome.", "outcome")
+//
+//	loginTotal.With("success").Inc()
+//
+// Metrics are registered once, usually as package-level variables, in the Default
+// registry, which is the client library's default registry; registering a name
+// twice panics. A labeled metric is exposed once one of its label sets has been
+// used. Label values must come from a small, fixed set (routes, outcomes), never
+// from user input.
+package metrics
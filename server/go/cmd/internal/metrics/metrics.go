@@ -0,0 +1,144 @@
+package metrics
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// DefBuckets are latency buckets in seconds, from 5ms to 10s.
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// validName keeps names in the classic Prometheus charset, which every scraper accepts.
+var validName = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+// desc describes one metric family.
+type desc struct {
+	name   string
+	help   string
+	labels []string
+}
+
+func newDesc(name, help string, labels []string) desc {
+	if !validName.MatchString(name) {
+		panic(fmt.Sprintf("metrics: invalid metric name %q", name))
+	}
+	for _, l := range labels {
+		if !validName.MatchString(l) || strings.HasPrefix(l, "__") || l == "le" {
+			panic(fmt.Sprintf("metrics: invalid label name %q for %s", l, name))
+		}
+	}
+	return desc{name: name, help: help, labels: append([]string(nil), labels...)}
+}
+
+// Counter is a monotonically increasing value.
+type Counter struct {
+	c prometheus.Counter
+}
+
+// Inc adds one.
+func (c *Counter) Inc() { c.c.Inc() }
+
+// Add adds n.
+func (c *Counter) Add(n uint64) { c.c.Add(float64(n)) }
+
+// Value returns the current count.
+func (c *Counter) Value() uint64 { return uint64(read(c.c).GetCounter().GetValue()) }
+
+// Gauge is a value that can go up and down.
+type Gauge struct {
+	g prometheus.Gauge
+}
+
+// Set sets the gauge to v.
+func (g *Gauge) Set(v float64) { g.g.Set(v) }
+
+// Inc adds one.
+func (g *Gauge) Inc() { g.g.Inc() }
+
+// Dec subtracts one.
+func (g *Gauge) Dec() { g.g.Dec() }
+
+// Add adds delta, which may be negative.
+func (g *Gauge) Add(delta float64) { g.g.Add(delta) }
+
+// Value returns the current value.
+func (g *Gauge) Value() float64 { return read(g.g).GetGauge().GetValue() }
+
+// Histogram counts observations in cumulative buckets.
+type Histogram struct {
+	h prometheus.Histogram
+}
+
+// Observe records v.
+func (h *Histogram) Observe(v float64) { h.h.Observe(v) }
+
+// Count returns the number of observations.
+func (h *Histogram) Count() uint64 { return read(h.h).GetHistogram().GetSampleCount() }
+
+// read snapshots m the way a scrape would.
+func read(m prometheus.Metric) *dto.Metric {
+	var out dto.Metric
+	if err := m.Write(&out); err != nil {
+		panic(fmt.Sprintf("metrics: read %s: %v", m.Desc(), err))
+	}
+	return &out
+}
+
+// vec caches one wrapper per distinct label value tuple, so With does not
+// allocate once a child exists.
+type vec[T any] struct {
+	desc
+	mu       sync.RWMutex
+	children map[string]*T
+	newChild func(values []string) *T
+}
+
+func (v *vec[T]) with(values []string) *T {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: %s wants %d label values, got %d", v.name, len(v.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+
+	v.mu.RLock()
+	c, ok := v.children[key]
+	v.mu.RUnlock()
+	if ok {
+		return c
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if c, ok := v.children[key]; ok {
+		return c
+	}
+	c = v.newChild(values)
+	v.children[key] = c
+	return c
+}
+
+func newVec[T any](d desc, newChild func(values []string) *T) *vec[T] {
+	return &vec[T]{desc: d, children: map[string]*T{}, newChild: newChild}
+}
+
+// CounterVec is a counter partitioned by labels.
+type CounterVec struct{ *vec[Counter] }
+
+// With returns the counter for the label values, in the order the labels were declared.
+func (v *CounterVec) With(values ...string) *Counter { return v.with(values) }
+
+// GaugeVec is a gauge partitioned by labels.
+type GaugeVec struct{ *vec[Gauge] }
+
+// With returns the gauge for the label values, in the order the labels were declared.
+func (v *GaugeVec) With(values ...string) *Gauge { return v.with(values) }
+
+// HistogramVec is a histogram partitioned by labels.
+type HistogramVec struct{ *vec[Histogram] }
+
+// With returns the histogram for the label values, in the order the labels were declared.
+func (v *HistogramVec) With(values ...string) *Histogram { return v.with(values) }
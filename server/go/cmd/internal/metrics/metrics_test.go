@@ -0,0 +1,126 @@
+package metrics
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/common/model"
+)
+
+func TestRegistry_Handler(t *testing.T) {
+	t.Parallel()
+
+	r := NewRegistry()
+	logins := r.NewCounterVec("arc_test_login_total", "Login attempts.\nBy outcome.", "outcome")
+	logins.With("success").Add(2)
+	logins.With(`bad"pw`).Inc()
+	conns := r.NewGauge("arc_test_connections", "Open connections.")
+	conns.Inc()
+	conns.Inc()
+	conns.Dec()
+	lat := r.NewHistogramVec("arc_test_duration_seconds", "Latency.", []float64{1, 0.1}, "route")
+	lat.With("/a").Observe(0.05)
+	lat.With("/a").Observe(0.5)
+	lat.With("/a").Observe(3)
+	r.NewGaugeFunc("arc_test_pool_size", "Pool size.", func() float64 { return 4 })
+
+	if logins.With("success").Value() != 2 || conns.Value() != 1 || lat.With("/a").Count() != 3 {
+		t.Fatalf("unexpected values: logins=%d conns=%v lat=%d", logins.With("success").Value(), conns.Value(), lat.With("/a").Count())
+	}
+
+	rr := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rr.Code != http.StatusOK || !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Fatalf("status %d content-type %q", rr.Code, rr.Header().Get("Content-Type"))
+	}
+	parser := expfmt.NewTextParser(model.LegacyValidation)
+	families, err := parser.TextToMetricFamilies(rr.Body)
+	if err != nil {
+		t.Fatalf("parse exposition: %v", err)
+	}
+	if len(families) != 4 {
+		t.Fatalf("expected 4 families, got %d", len(families))
+	}
+
+	login := families["arc_test_login_total"]
+	if login.GetType() != dto.MetricType_COUNTER || login.GetHelp() != "Login attempts.\nBy outcome." {
+		t.Fatalf("unexpected login family: %v", login)
+	}
+	got := map[string]float64{}
+	for _, m := range login.GetMetric() {
+		got[m.GetLabel()[0].GetValue()] = m.GetCounter().GetValue()
+	}
+	if len(got) != 2 || got["success"] != 2 || got[`bad"pw`] != 1 {
+		t.Fatalf("unexpected login samples: %v", got)
+	}
+
+	if g := families["arc_test_connections"]; g.GetType() != dto.MetricType_GAUGE || g.GetMetric()[0].GetGauge().GetValue() != 1 {
+		t.Fatalf("unexpected connections family: %v", g)
+	}
+	if g := families["arc_test_pool_size"]; g.GetType() != dto.MetricType_GAUGE || g.GetMetric()[0].GetGauge().GetValue() != 4 {
+		t.Fatalf("unexpected pool size family: %v", g)
+	}
+
+	dur := families["arc_test_duration_seconds"]
+	if dur.GetType() != dto.MetricType_HISTOGRAM || len(dur.GetMetric()) != 1 {
+		t.Fatalf("unexpected duration family: %v", dur)
+	}
+	h := dur.GetMetric()[0].GetHistogram()
+	if h.GetSampleCount() != 3 || h.GetSampleSum() != 3.55 || len(h.GetBucket()) != 3 {
+		t.Fatalf("unexpected histogram: %v", h)
+	}
+	for i, want := range []struct {
+		upper float64
+		count uint64
+	}{{0.1, 1}, {1, 2}, {math.Inf(1), 3}} {
+		if b := h.GetBucket()[i]; b.GetUpperBound() != want.upper || b.GetCumulativeCount() != want.count {
+			t.Fatalf("bucket %d: got le=%v count=%d, want le=%v count=%d", i, b.GetUpperBound(), b.GetCumulativeCount(), want.upper, want.count)
+		}
+	}
+}
+
+func TestRegistry_Panics(t *testing.T) {
+	t.Parallel()
+
+	r := NewRegistry()
+	r.NewCounter("arc_test_total", "x")
+	cases := map[string]func(){
+		"duplicate":   func() { r.NewGauge("arc_test_total", "x") },
+		"bad name":    func() { r.NewCounter("arc-test", "x") },
+		"reserved le": func() { r.NewCounterVec("arc_test_le_total", "x", "le") },
+		"label arity": func() { r.NewCounterVec("arc_test_arity_total", "x", "a").With("1", "2") },
+	}
+	for name, fn := range cases {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("%s: expected panic", name)
+				}
+			}()
+			fn()
+		}()
+	}
+}
+
+func TestHandler_Default(t *testing.T) {
+	NewCounter("arc_test_default_requests_total", "Requests.").Inc()
+
+	rr := httptest.NewRecorder()
+	Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	parser := expfmt.NewTextParser(model.LegacyValidation)
+	families, err := parser.TextToMetricFamilies(rr.Body)
+	if err != nil {
+		t.Fatalf("parse exposition: %v", err)
+	}
+	if c := families["arc_test_default_requests_total"]; c.GetMetric()[0].GetCounter().GetValue() != 1 {
+		t.Fatalf("unexpected counter family: %v", c)
+	}
+	if _, ok := families["go_goroutines"]; !ok {
+		t.Fatalf("expected the Go runtime collector in the default registry")
+	}
+}
@@ -0,0 +1,127 @@
+package metrics
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Registry is a set of uniquely named metrics.
+type Registry struct {
+	reg    prometheus.Registerer
+	gather prometheus.Gatherer
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	r := prometheus.NewRegistry()
+	return &Registry{reg: r, gather: r}
+}
+
+// Default is the registry the package-level constructors and Handler use: the
+// client library's default registry, which also holds the Go runtime and process
+// collectors.
+var Default = &Registry{reg: prometheus.DefaultRegisterer, gather: prometheus.DefaultGatherer}
+
+// NewCounterVec registers a counter with the given labels.
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	d := newDesc(name, help, labels)
+	pv := prometheus.NewCounterVec(prometheus.CounterOpts{Name: d.name, Help: d.help}, d.labels)
+	r.reg.MustRegister(pv)
+	return &CounterVec{newVec(d, func(values []string) *Counter {
+		return &Counter{pv.WithLabelValues(values...)}
+	})}
+}
+
+// NewCounter registers a counter without labels.
+func (r *Registry) NewCounter(name, help string) *Counter {
+	return r.NewCounterVec(name, help).With()
+}
+
+// NewGaugeVec registers a gauge with the given labels.
+func (r *Registry) NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	d := newDesc(name, help, labels)
+	pv := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: d.name, Help: d.help}, d.labels)
+	r.reg.MustRegister(pv)
+	return &GaugeVec{newVec(d, func(values []string) *Gauge {
+		return &Gauge{pv.WithLabelValues(values...)}
+	})}
+}
+
+// NewGauge registers a gauge without labels.
+func (r *Registry) NewGauge(name, help string) *Gauge {
+	return r.NewGaugeVec(name, help).With()
+}
+
+// NewHistogramVec registers a histogram with the given upper bucket bounds (sorted,
+// without +Inf) and labels.
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	d := newDesc(name, help, labels)
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	pv := prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: d.name, Help: d.help, Buckets: buckets}, d.labels)
+	r.reg.MustRegister(pv)
+	return &HistogramVec{newVec(d, func(values []string) *Histogram {
+		return &Histogram{pv.WithLabelValues(values...).(prometheus.Histogram)}
+	})}
+}
+
+// NewHistogram registers a histogram without labels.
+func (r *Registry) NewHistogram(name, help string, buckets []float64) *Histogram {
+	return r.NewHistogramVec(name, help, buckets).With()
+}
+
+// NewGaugeFunc registers a gauge whose value fn returns at scrape time.
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) {
+	d := newDesc(name, help, nil)
+	r.reg.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{Name: d.name, Help: d.help}, fn))
+}
+
+// NewCounterFunc registers a counter whose value fn returns at scrape time; fn must
+// never decrease.
+func (r *Registry) NewCounterFunc(name, help string, fn func() float64) {
+	d := newDesc(name, help, nil)
+	r.reg.MustRegister(prometheus.NewCounterFunc(prometheus.CounterOpts{Name: d.name, Help: d.help}, fn))
+}
+
+// Handler serves the registry, negotiating the exposition format with the scraper.
+func (r *Registry) Handler() http.Handler {
+	return promhttp.HandlerFor(r.gather, promhttp.HandlerOpts{})
+}
+
+// NewCounterVec registers a counter in Default.
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return Default.NewCounterVec(name, help, labels...)
+}
+
+// NewCounter registers a counter without labels in Default.
+func NewCounter(name, help string) *Counter { return Default.NewCounter(name, help) }
+
+// NewGaugeVec registers a gauge in Default.
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	return Default.NewGaugeVec(name, help, labels...)
+}
+
+// NewGauge registers a gauge without labels in Default.
+func NewGauge(name, help string) *Gauge { return Default.NewGauge(name, help) }
+
+// NewHistogramVec registers a histogram in Default.
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	return Default.NewHistogramVec(name, help, buckets, labels...)
+}
+
+// NewHistogram registers a histogram without labels in Default.
+func NewHistogram(name, help string, buckets []float64) *Histogram {
+	return Default.NewHistogram(name, help, buckets)
+}
+
+// NewGaugeFunc registers a callback gauge in Default.
+func NewGaugeFunc(name, help string, fn func() float64) { Default.NewGaugeFunc(name, help, fn) }
+
+// NewCounterFunc registers a callback counter in Default.
+func NewCounterFunc(name, help string, fn func() float64) { Default.NewCounterFunc(name, help, fn) }
+
+// Handler serves Default with promhttp.Handler, which also counts its own scrapes.
+func Handler() http.Handler { return promhttp.Handler() }
@@ -0,0 +1,75 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const commandUsage = `usage:
+  arc migrate up
+  arc migrate down [STEPS]   (default 1)
+  arc migrate status`
+
+// RunCommand runs an operator subcommand: "up" applies pending migrations, "down"
+// reverts the latest ones and "status" prints every migration's state.
+func RunCommand(ctx context.Context, log *slog.Logger, pool *pgxpool.Pool, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(commandUsage)
+	}
+	r, err := NewRunner(log, pool)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		done, err := r.Up(ctx)
+		for _, m := range done {
+			_, _ = fmt.Fprintf(out, "applied %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(done) == 0 {
+			_, _ = fmt.Fprintln(out, "schema is up to date")
+		}
+		return nil
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return errors.New(commandUsage)
+			}
+		}
+		done, err := r.Down(ctx, steps)
+		for _, m := range done {
+			_, _ = fmt.Fprintf(out, "reverted %04d_%s\n", m.Version, m.Name)
+		}
+		return err
+
+	case "status":
+		st, err := r.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range st {
+			applied := "-"
+			if !s.AppliedAt.IsZero() {
+				applied = s.AppliedAt.UTC().Format("2006-01-02T15:04:05Z")
+			}
+			_, _ = fmt.Fprintf(out, "%04d_%-32s %-9s %s\n", s.Version, s.Name, s.State, applied)
+		}
+		return nil
+
+	default:
+		return errors.New(commandUsage)
+	}
+}
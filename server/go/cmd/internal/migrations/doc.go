@@ -0,0 +1,15 @@
+// Package migrations applies Arc's Postgres schema from SQL files embedded in the binary.
+//
+// Migrations live in sql/ as NNNN_name.up.sql with an optional NNNN_name.down.sql and are
+// applied in version order, each in its own transaction. Applied versions are recorded in
+// public.arc_schema_migrations together with a SHA-256 checksum of the up script; Up and
+// Status refuse to continue when an applied file has since been edited, so a migration
+// that shipped is never changed in place. Schema changes are new migration files.
+//
+// 0001_baseline is the schema as it stood when migrations were introduced. It is
+// idempotent, so databases previously set up from the Atlas schema file adopt it safely.
+//
+// Operators run "arc migrate up|down|status"; ARC_DB_AUTO_MIGRATE=true applies pending
+// migrations at startup (intended for development). A session advisory lock serializes
+// concurrent runners.
+package migrations
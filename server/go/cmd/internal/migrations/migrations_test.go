@@ -0,0 +1,93 @@
+package migrations
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"0002_add_index.up.sql":  {Data: []byte("CREATE INDEX ...;")},
+		"0010_later.up.sql":      {Data: []byte("SELECT 10;")},
+		"0001_baseline.up.sql":   {Data: []byte("CREATE SCHEMA arc;")},
+		"0001_baseline.down.sql": {Data: []byte("DROP SCHEMA arc;")},
+		"README.md":              {Data: []byte("ignored")},
+	}
+	migs, err := Load(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var versions []int64
+	for _, m := range migs {
+		versions = append(versions, m.Version)
+	}
+	if len(migs) != 3 || versions[0] != 1 || versions[1] != 2 || versions[2] != 10 {
+		t.Fatalf("versions = %v", versions)
+	}
+	if migs[0].Down != "DROP SCHEMA arc;" || migs[1].Down != "" || len(migs[0].Checksum) != 64 {
+		t.Fatalf("unexpected baseline %+v", migs[0])
+	}
+
+	cases := map[string]fstest.MapFS{
+		"no up":        {"0003_x.down.sql": {Data: []byte("x")}},
+		"bad name":     {"3-x.sql": {Data: []byte("x")}},
+		"two names":    {"0003_a.up.sql": {Data: []byte("x")}, "0003_b.up.sql": {Data: []byte("y")}},
+		"zero version": {"0000_x.up.sql": {Data: []byte("x")}},
+	}
+	for name, fsys := range cases {
+		if _, err := Load(fsys); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
+
+func TestEmbedded(t *testing.T) {
+	t.Parallel()
+
+	migs, err := Embedded()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migs) == 0 || migs[0].Version != 1 || migs[0].Name != "baseline" {
+		t.Fatalf("unexpected embedded migrations %+v", migs)
+	}
+	if !strings.Contains(migs[0].Up, "CREATE SCHEMA IF NOT EXISTS arc") || migs[0].Down == "" {
+		t.Fatal("baseline must create and drop the arc schema")
+	}
+}
+
+func TestStatus(t *testing.T) {
+	t.Parallel()
+
+	migs := []Migration{
+		{Version: 1, Name: "baseline", Checksum: "a"},
+		{Version: 2, Name: "edited", Checksum: "b"},
+		{Version: 3, Name: "pending", Checksum: "c"},
+	}
+	at := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	applied := map[int64]appliedRow{
+		1: {name: "baseline", checksum: "a", appliedAt: at},
+		2: {name: "edited", checksum: "changed", appliedAt: at},
+		9: {name: "from_newer_release", checksum: "z", appliedAt: at},
+	}
+
+	got := status(migs, applied)
+	want := []State{StateApplied, StateModified, StatePending, StateUnknown}
+	if len(got) != len(want) {
+		t.Fatalf("status = %+v", got)
+	}
+	for i, s := range got {
+		if s.State != want[i] {
+			t.Fatalf("row %d (%d) state = %s, want %s", i, s.Version, s.State, want[i])
+		}
+	}
+
+	r := &Runner{migrations: migs}
+	if err := r.verify(applied); err == nil || !strings.Contains(err.Error(), "0002_edited") {
+		t.Fatalf("verify = %v, want checksum mismatch for 0002", err)
+	}
+}
@@ -0,0 +1,29 @@
+package migrationstest
+
+import (
+	"strings"
+	"testing"
+
+	"arc/cmd/internal/migrations"
+)
+
+func TestRewrite(t *testing.T) {
+	t.Parallel()
+
+	migs, err := migrations.Embedded()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range migs {
+		got := Rewrite(m.Up, "arc_it_x")
+		if qualifiedRE.MatchString(got) || strings.Contains(got, "'arc'") || strings.Contains(got, "SCHEMA IF NOT EXISTS arc;") {
+			t.Fatalf("%04d_%s still references the arc schema", m.Version, m.Name)
+		}
+	}
+
+	got := Rewrite(`CREATE SCHEMA IF NOT EXISTS arc; CREATE TABLE arc.users (id TEXT); SELECT 'arc.users'::regclass, public.arc_schema_migrations;`, "arc_it_x")
+	want := `CREATE SCHEMA IF NOT EXISTS arc_it_x; CREATE TABLE arc_it_x.users (id TEXT); SELECT 'arc_it_x.users'::regclass, public.arc_schema_migrations;`
+	if got != want {
+		t.Fatalf("Rewrite = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,232 @@
+package migrations
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"arc/cmd/internal/dbtrace"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrChecksumMismatch reports an applied migration whose up script changed since.
+var ErrChecksumMismatch = errors.New("migrations: applied migration was modified")
+
+// ErrIrreversible reports a Down past a migration without a down script.
+var ErrIrreversible = errors.New("migrations: migration has no down script")
+
+// The tracking table lives outside the arc schema so reverting the baseline
+// (which drops arc) keeps the history consistent.
+const (
+	createTrackingTable = `
+		CREATE TABLE IF NOT EXISTS public.arc_schema_migrations (
+			version BIGINT PRIMARY KEY,
+			name TEXT NOT NULL,
+			checksum TEXT NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)`
+	lockKey = `hashtextextended('arc.migrations', 0)`
+)
+
+// State describes a migration relative to the database.
+type State string
+
+const (
+	StatePending  State = "pending"
+	StateApplied  State = "applied"
+	StateModified State = "modified"
+	// StateUnknown is a version recorded in the database that this binary does not
+	// ship, e.g. after rolling back to an older release.
+	StateUnknown State = "unknown"
+)
+
+// Status is one row of Runner.Status.
+type Status struct {
+	Version   int64
+	Name      string
+	State     State
+	AppliedAt time.Time
+}
+
+// Runner applies migrations to a database.
+type Runner struct {
+	log        *slog.Logger
+	pool       *pgxpool.Pool
+	migrations []Migration
+}
+
+// NewRunner returns a Runner for the embedded migrations.
+func NewRunner(log *slog.Logger, pool *pgxpool.Pool) (*Runner, error) {
+	if pool == nil {
+		return nil, errors.New("migrations: pool is nil")
+	}
+	migs, err := Embedded()
+	if err != nil {
+		return nil, err
+	}
+	if log == nil {
+		log = slog.Default()
+	}
+	return &Runner{log: log, pool: pool, migrations: migs}, nil
+}
+
+type appliedRow struct {
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+// Up applies every pending migration in order and returns those it applied.
+func (r *Runner) Up(ctx context.Context) ([]Migration, error) {
+	ctx = dbtrace.WithOp(ctx, "migrations.up")
+	var done []Migration
+	err := r.locked(ctx, func(conn *pgxpool.Conn, applied map[int64]appliedRow) error {
+		if err := r.verify(applied); err != nil {
+			return err
+		}
+		for _, m := range r.migrations {
+			if _, ok := applied[m.Version]; ok {
+				continue
+			}
+			start := time.Now()
+			err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+				if _, err := tx.Exec(ctx, m.Up); err != nil {
+					return err
+				}
+				_, err := tx.Exec(ctx,
+					`INSERT INTO public.arc_schema_migrations (version, name, checksum) VALUES ($1, $2, $3)`,
+					m.Version, m.Name, m.Checksum)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("migrations: %04d_%s up: %w", m.Version, m.Name, err)
+			}
+			r.log.Info("db.migrate.up", "version", m.Version, "name", m.Name, "duration_ms", time.Since(start).Milliseconds(), "result", "success")
+			done = append(done, m)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down reverts the latest steps applied migrations, newest first, and returns
+// those it reverted.
+func (r *Runner) Down(ctx context.Context, steps int) ([]Migration, error) {
+	ctx = dbtrace.WithOp(ctx, "migrations.down")
+	var done []Migration
+	err := r.locked(ctx, func(conn *pgxpool.Conn, applied map[int64]appliedRow) error {
+		for i := len(r.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+			m := r.migrations[i]
+			if _, ok := applied[m.Version]; !ok {
+				continue
+			}
+			if m.Down == "" {
+				return fmt.Errorf("%w: %04d_%s", ErrIrreversible, m.Version, m.Name)
+			}
+			err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+				if _, err := tx.Exec(ctx, m.Down); err != nil {
+					return err
+				}
+				_, err := tx.Exec(ctx, `DELETE FROM public.arc_schema_migrations WHERE version = $1`, m.Version)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("migrations: %04d_%s down: %w", m.Version, m.Name, err)
+			}
+			r.log.Info("db.migrate.down", "version", m.Version, "name", m.Name, "result", "success")
+			done = append(done, m)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status lists every shipped migration and any unknown applied version, by version.
+func (r *Runner) Status(ctx context.Context) ([]Status, error) {
+	var out []Status
+	err := r.locked(ctx, func(_ *pgxpool.Conn, applied map[int64]appliedRow) error {
+		out = status(r.migrations, applied)
+		return nil
+	})
+	return out, err
+}
+
+// verify fails when an applied migration's up script no longer matches.
+func (r *Runner) verify(applied map[int64]appliedRow) error {
+	for _, s := range status(r.migrations, applied) {
+		if s.State == StateModified {
+			return fmt.Errorf("%w: %04d_%s", ErrChecksumMismatch, s.Version, s.Name)
+		}
+	}
+	return nil
+}
+
+func status(migs []Migration, applied map[int64]appliedRow) []Status {
+	out := make([]Status, 0, len(migs))
+	known := make(map[int64]bool, len(migs))
+	for _, m := range migs {
+		known[m.Version] = true
+		s := Status{Version: m.Version, Name: m.Name, State: StatePending}
+		if row, ok := applied[m.Version]; ok {
+			s.AppliedAt = row.appliedAt
+			s.State = StateApplied
+			if row.checksum != m.Checksum {
+				s.State = StateModified
+			}
+		}
+		out = append(out, s)
+	}
+	for v, row := range applied {
+		if !known[v] {
+			out = append(out, Status{Version: v, Name: row.name, State: StateUnknown, AppliedAt: row.appliedAt})
+		}
+	}
+	slices.SortFunc(out, func(a, b Status) int { return cmp.Compare(a.Version, b.Version) })
+	return out
+}
+
+// locked runs fn on one connection holding the migrations advisory lock, with the
+// applied versions read after the lock was taken.
+func (r *Runner) locked(ctx context.Context, fn func(*pgxpool.Conn, map[int64]appliedRow) error) error {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock(`+lockKey+`)`); err != nil {
+		return err
+	}
+	defer func() {
+		_, _ = conn.Exec(context.Background(), `SELECT pg_advisory_unlock(`+lockKey+`)`)
+	}()
+
+	if _, err := conn.Exec(ctx, createTrackingTable); err != nil {
+		return err
+	}
+	rows, err := conn.Query(ctx, `SELECT version, name, checksum, applied_at FROM public.arc_schema_migrations`)
+	if err != nil {
+		return err
+	}
+	applied := make(map[int64]appliedRow)
+	for rows.Next() {
+		var v int64
+		var row appliedRow
+		if err := rows.Scan(&v, &row.name, &row.checksum, &row.appliedAt); err != nil {
+			rows.Close()
+			return err
+		}
+		applied[v] = row
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return fn(conn, applied)
+}
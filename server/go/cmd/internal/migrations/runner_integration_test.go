@@ -0,0 +1,73 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Integration tests are enabled when ARC_DATABASE_URL is set. They migrate that
+// database up, which is a no-op when it is already current.
+func TestRunnerUpIsIdempotent(t *testing.T) {
+	pool := mustOpenTestPool(t)
+	defer pool.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	r, err := NewRunner(nil, pool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Up(ctx); err != nil {
+		t.Fatalf("first up: %v", err)
+	}
+	again, err := r.Up(ctx)
+	if err != nil || len(again) != 0 {
+		t.Fatalf("second up applied %d migrations, err %v", len(again), err)
+	}
+
+	st, err := r.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range st {
+		if s.State != StateApplied && s.State != StateUnknown {
+			t.Fatalf("%04d_%s is %s after up", s.Version, s.Name, s.State)
+		}
+	}
+}
+
+func mustOpenTestPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+
+	raw := strings.TrimSpace(os.Getenv("ARC_DATABASE_URL"))
+	if raw == "" {
+		t.Skip("integration test skipped: ARC_DATABASE_URL is not set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pool, err := pgxpool.New(ctx, raw)
+	if err != nil {
+		t.Fatalf("connect postgres: %v", err)
+	}
+	c, err := pool.Acquire(ctx)
+	if err != nil {
+		pool.Close()
+		var opErr *net.OpError
+		if os.Getenv("CI") == "" && (errors.As(err, &opErr) || strings.Contains(strings.ToLower(err.Error()), "connection refused")) {
+			t.Skipf("integration test skipped: Postgres unreachable (ARC_DATABASE_URL set): %v", err)
+		}
+		t.Fatalf("acquire: %v", err)
+	}
+	c.Release()
+	return pool
+}
@@ -0,0 +1,2 @@
+-- Reverting the baseline removes every Arc table and all data in them.
+DROP SCHEMA IF EXISTS arc CASCADE;
@@ -0,0 +1,1421 @@
+-- Arc schema is fully schema-qualified (arc.*). Do not rely on search_path.
+
+CREATE SCHEMA IF NOT EXISTS arc;
+
+CREATE SCHEMA IF NOT EXISTS public;
+
+COMMENT ON SCHEMA public IS 'standard public schema';
+
+-- =========================
+-- Helpers
+-- =========================
+
+-- Standard updated_at trigger.
+CREATE OR REPLACE FUNCTION arc.set_updated_at()
+RETURNS TRIGGER AS $$
+BEGIN
+  NEW.updated_at = now();
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- =========================
+-- Realtime core (PR-001/002)
+-- =========================
+
+CREATE TABLE IF NOT EXISTS arc.conversations (
+    id TEXT PRIMARY KEY,
+    kind TEXT NOT NULL,
+    visibility TEXT NOT NULL DEFAULT 'private',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT chk_conversations_id_nonempty CHECK (char_length(id) > 0)
+);
+
+-- PR-010: evolve conversation kind + visibility in an idempotent way for existing databases.
+ALTER TABLE arc.conversations
+    ADD COLUMN IF NOT EXISTS visibility TEXT;
+
+UPDATE arc.conversations
+SET visibility = 'private'
+WHERE visibility IS NULL;
+
+ALTER TABLE arc.conversations
+    ALTER COLUMN visibility SET DEFAULT 'private';
+
+ALTER TABLE arc.conversations
+    ALTER COLUMN visibility SET NOT NULL;
+
+-- Drop legacy anonymous check names when upgrading from older schema versions.
+ALTER TABLE arc.conversations
+    DROP CONSTRAINT IF EXISTS conversations_kind_check;
+
+ALTER TABLE arc.conversations
+    DROP CONSTRAINT IF EXISTS chk_conversations_kind;
+
+ALTER TABLE arc.conversations
+    ADD CONSTRAINT chk_conversations_kind CHECK (kind IN ('direct', 'group', 'room'));
+
+ALTER TABLE arc.conversations
+    DROP CONSTRAINT IF EXISTS chk_conversations_visibility;
+
+ALTER TABLE arc.conversations
+    ADD CONSTRAINT chk_conversations_visibility CHECK (visibility IN ('public', 'private'));
+
+CREATE INDEX IF NOT EXISTS idx_conversations_visibility ON arc.conversations (visibility);
+
+-- next_seq is the next allocatable sequence number (starts at 1).
+CREATE TABLE IF NOT EXISTS arc.conversation_cursors (
+    conversation_id TEXT PRIMARY KEY REFERENCES arc.conversations (id) ON DELETE CASCADE,
+    next_seq BIGINT NOT NULL DEFAULT 1,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT chk_conversation_cursors_next_seq_positive CHECK (next_seq >= 1)
+);
+
+DROP TRIGGER IF EXISTS trg_conversation_cursors_updated_at ON arc.conversation_cursors;
+
+CREATE TRIGGER trg_conversation_cursors_updated_at
+BEFORE UPDATE ON arc.conversation_cursors
+FOR EACH ROW
+EXECUTE FUNCTION arc.set_updated_at();
+
+-- =========================
+-- Identity & Auth Foundation (PR-003)
+-- ADR-0003 aligned + PR-005-ready
+-- =========================
+
+
+CREATE TABLE IF NOT EXISTS arc.users (
+  id TEXT PRIMARY KEY,
+
+  username TEXT NULL,
+  username_norm TEXT NULL,
+
+  email TEXT NULL,
+  email_norm TEXT NULL,
+  email_verified_at TIMESTAMPTZ NULL,
+
+  display_name TEXT NULL,
+  bio TEXT NULL,
+
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+  CONSTRAINT chk_users_id_ulid_len CHECK (char_length(id) = 26),
+
+-- Keep raw and normalized columns consistent: either both NULL or both non-NULL.
+CONSTRAINT chk_users_username_pair CHECK (
+    (username IS NULL) = (username_norm IS NULL)
+),
+CONSTRAINT chk_users_email_pair CHECK (
+    (email IS NULL) = (email_norm IS NULL)
+),
+
+-- Prevent empty strings (if present).
+CONSTRAINT chk_users_username_nonempty CHECK (username IS NULL OR char_length(btrim(username)) > 0),
+  CONSTRAINT chk_users_username_norm_nonempty CHECK (username_norm IS NULL OR char_length(btrim(username_norm)) > 0),
+  CONSTRAINT chk_users_email_nonempty CHECK (email IS NULL OR char_length(btrim(email)) > 0),
+  CONSTRAINT chk_users_email_norm_nonempty CHECK (email_norm IS NULL OR char_length(btrim(email_norm)) > 0),
+
+  CONSTRAINT chk_users_username_len CHECK (username IS NULL OR (char_length(username) >= 3 AND char_length(username) <= 32)),
+  CONSTRAINT chk_users_username_norm_len CHECK (username_norm IS NULL OR (char_length(username_norm) >= 3 AND char_length(username_norm) <= 32)),
+
+  CONSTRAINT chk_users_email_len CHECK (email IS NULL OR (char_length(email) >= 3 AND char_length(email) <= 320)),
+  CONSTRAINT chk_users_email_norm_len CHECK (email_norm IS NULL OR (char_length(email_norm) >= 3 AND char_length(email_norm) <= 320)),
+  CONSTRAINT chk_users_email_verified_after_created CHECK (
+      email_verified_at IS NULL
+      OR email_verified_at >= created_at
+  ),
+
+  CONSTRAINT chk_users_display_name_len CHECK (display_name IS NULL OR char_length(display_name) <= 80),
+  CONSTRAINT chk_users_bio_len CHECK (bio IS NULL OR char_length(bio) <= 512)
+);
+
+ALTER TABLE arc.users
+    ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMPTZ;
+
+ALTER TABLE arc.users
+    DROP CONSTRAINT IF EXISTS chk_users_email_verified_after_created;
+
+ALTER TABLE arc.users
+    ADD CONSTRAINT chk_users_email_verified_after_created CHECK (
+        email_verified_at IS NULL
+        OR email_verified_at >= created_at
+    );
+
+DROP TRIGGER IF EXISTS trg_users_updated_at ON arc.users;
+
+CREATE TRIGGER trg_users_updated_at
+BEFORE UPDATE ON arc.users
+FOR EACH ROW
+EXECUTE FUNCTION arc.set_updated_at();
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_users_username_norm ON arc.users (username_norm);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_users_email_norm ON arc.users (email_norm);
+
+CREATE INDEX IF NOT EXISTS idx_users_created_at ON arc.users (created_at DESC);
+
+-- One credentials row per user.
+CREATE TABLE IF NOT EXISTS arc.user_credentials (
+    user_id TEXT PRIMARY KEY REFERENCES arc.users (id) ON DELETE CASCADE,
+    password_hash TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT chk_user_credentials_user_id_ulid_len CHECK (char_length(user_id) = 26),
+    CONSTRAINT chk_user_credentials_hash_len CHECK (
+        char_length(password_hash) >= 20
+        AND char_length(password_hash) <= 1024
+    )
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_credentials_user_id ON arc.user_credentials (user_id);
+
+DROP TRIGGER IF EXISTS trg_user_credentials_updated_at ON arc.user_credentials;
+
+CREATE TRIGGER trg_user_credentials_updated_at
+BEFORE UPDATE ON arc.user_credentials
+FOR EACH ROW
+EXECUTE FUNCTION arc.set_updated_at();
+
+-- =========================
+-- Sessions (PR-005)
+-- =========================
+
+-- Sessions: refresh tokens are opaque and stored hashed (HMAC-SHA256 or SHA-256 hex => 64 chars).
+-- PR-005: rotation chain + platform + DB-level invariants for correctness and safety.
+CREATE TABLE IF NOT EXISTS arc.sessions (
+  id TEXT PRIMARY KEY,
+  user_id TEXT NOT NULL REFERENCES arc.users (id) ON DELETE CASCADE,
+
+-- HMAC-SHA256 or SHA-256 hex of opaque refresh token (64 chars).
+refresh_token_hash TEXT NOT NULL,
+created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+last_used_at TIMESTAMPTZ NULL,
+expires_at TIMESTAMPTZ NOT NULL,
+revoked_at TIMESTAMPTZ NULL,
+
+-- Rotation chain: when refresh is rotated, old session points to its replacement.
+replaced_by_session_id TEXT NULL REFERENCES arc.sessions (id) ON DELETE SET NULL,
+user_agent TEXT NULL,
+ip INET NULL,
+
+-- Device/platform context.
+platform TEXT NOT NULL DEFAULT 'unknown',
+
+-- Optional: why a session was revoked (observability without changing semantics).
+-- Keep nullable and conservative; do not depend on this for core logic.
+revocation_reason TEXT NULL,
+CONSTRAINT chk_sessions_id_ulid_len CHECK (char_length(id) = 26),
+CONSTRAINT chk_sessions_user_id_ulid_len CHECK (char_length(user_id) = 26),
+CONSTRAINT chk_sessions_refresh_hash_len CHECK (
+    char_length(refresh_token_hash) = 64
+),
+
+-- IMPORTANT: keep strict. For an "expired session", set created_at in the past and
+-- expires_at after created_at (but still in the past).
+CONSTRAINT chk_sessions_expires_after_created CHECK (expires_at > created_at),
+CONSTRAINT chk_sessions_revoked_after_created CHECK (
+    revoked_at IS NULL
+    OR revoked_at >= created_at
+),
+CONSTRAINT chk_sessions_last_used_after_created CHECK (
+    last_used_at IS NULL
+    OR last_used_at >= created_at
+),
+
+-- Sanity: last_used_at should not exceed expires_at.
+CONSTRAINT chk_sessions_last_used_before_expires CHECK (
+    last_used_at IS NULL
+    OR last_used_at <= expires_at
+),
+CONSTRAINT chk_sessions_platform CHECK (
+    platform IN (
+        'web',
+        'ios',
+        'android',
+        'desktop',
+        'unknown'
+    )
+),
+
+-- Replacement cannot point to self.
+CONSTRAINT chk_sessions_replaced_not_self CHECK (
+    replaced_by_session_id IS NULL
+    OR replaced_by_session_id <> id
+),
+
+-- Rotation implies revocation: if replaced_by_session_id is set, revoked_at must be set.
+CONSTRAINT chk_sessions_replaced_requires_revoked CHECK (
+    replaced_by_session_id IS NULL
+    OR revoked_at IS NOT NULL
+),
+
+-- Keep user_agent bounded to prevent pathological payload sizes.
+CONSTRAINT chk_sessions_user_agent_len CHECK (
+    user_agent IS NULL
+    OR char_length(user_agent) <= 512
+),
+
+-- revocation_reason is optional but, when present, must be one of the known reasons.
+CONSTRAINT chk_sessions_revocation_reason CHECK (
+    revocation_reason IS NULL OR
+    revocation_reason IN ('logout','rotation','reuse_detected','admin','security')
+  )
+);
+
+-- Uniqueness on refresh token hash guarantees no two sessions share the same refresh token.
+CREATE UNIQUE INDEX IF NOT EXISTS uq_sessions_refresh_token_hash ON arc.sessions (refresh_token_hash);
+
+-- Common access patterns.
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON arc.sessions (user_id);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_expires_at ON arc.sessions (expires_at);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id_revoked_expires ON arc.sessions (
+    user_id,
+    revoked_at,
+    expires_at
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id_platform ON arc.sessions (user_id, platform);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_replaced_by ON arc.sessions (replaced_by_session_id);
+
+-- Partial index for "active sessions" reads.
+CREATE INDEX IF NOT EXISTS idx_sessions_active_by_user ON arc.sessions (user_id, expires_at DESC)
+WHERE
+    revoked_at IS NULL;
+
+-- Helpful index for rotated sessions (reuse detection and chain inspection).
+CREATE INDEX IF NOT EXISTS idx_sessions_rotated ON arc.sessions (user_id, revoked_at DESC)
+WHERE
+    replaced_by_session_id IS NOT NULL;
+
+-- Enforce replacement-chain invariants:
+-- - replacement must exist
+-- - replacement must belong to the same user
+-- - replacement must not be created before the replaced session
+CREATE OR REPLACE FUNCTION arc.sessions_validate_replacement_chain()
+RETURNS TRIGGER AS $$
+DECLARE
+  v_user_id TEXT;
+  v_created_at TIMESTAMPTZ;
+BEGIN
+  IF NEW.replaced_by_session_id IS NULL THEN
+    RETURN NEW;
+  END IF;
+
+  -- Defensive: should be covered by chk_sessions_replaced_not_self.
+  IF NEW.replaced_by_session_id = NEW.id THEN
+    RAISE EXCEPTION 'sessions.replaced_by_session_id cannot reference self';
+  END IF;
+
+  SELECT s.user_id, s.created_at
+    INTO v_user_id, v_created_at
+  FROM arc.sessions s
+  WHERE s.id = NEW.replaced_by_session_id;
+
+  IF v_user_id IS NULL THEN
+    RAISE EXCEPTION 'sessions.replaced_by_session_id references missing session: %', NEW.replaced_by_session_id;
+  END IF;
+
+  IF v_user_id <> NEW.user_id THEN
+    RAISE EXCEPTION 'sessions.replaced_by_session_id must reference a session of the same user';
+  END IF;
+
+  -- Allow equal timestamps (same transaction time) but disallow replacement being earlier.
+  IF v_created_at < NEW.created_at THEN
+    RAISE EXCEPTION 'replacement session must not be created before the replaced session';
+  END IF;
+
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_sessions_validate_replacement_chain ON arc.sessions;
+
+CREATE TRIGGER trg_sessions_validate_replacement_chain
+BEFORE INSERT OR UPDATE OF replaced_by_session_id, user_id, created_at
+ON arc.sessions
+FOR EACH ROW
+EXECUTE FUNCTION arc.sessions_validate_replacement_chain();
+
+-- =========================
+-- Messages (PR-001/002, FK to sessions after sessions exist)
+-- =========================
+
+CREATE TABLE IF NOT EXISTS arc.messages (
+    conversation_id TEXT NOT NULL REFERENCES arc.conversations (id) ON DELETE CASCADE,
+    seq BIGINT NOT NULL,
+    server_msg_id TEXT NOT NULL,
+    client_msg_id TEXT NOT NULL,
+    sender_session TEXT NOT NULL,
+    text TEXT NOT NULL,
+    server_ts TIMESTAMPTZ NOT NULL DEFAULT now(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (conversation_id, seq),
+    CONSTRAINT uq_messages_conversation_client_msg UNIQUE (
+        conversation_id,
+        client_msg_id
+    ),
+    CONSTRAINT uq_messages_server_msg_id UNIQUE (server_msg_id),
+    CONSTRAINT chk_messages_seq_positive CHECK (seq >= 1),
+    CONSTRAINT chk_messages_text_len CHECK (
+        char_length(text) > 0
+        AND char_length(text) <= 4096
+    ),
+    CONSTRAINT chk_messages_client_msg_id_nonempty CHECK (
+        char_length(client_msg_id) > 0
+    ),
+    CONSTRAINT chk_messages_server_msg_id_nonempty CHECK (
+        char_length(server_msg_id) > 0
+    ),
+    CONSTRAINT chk_messages_sender_session_nonempty CHECK (
+        char_length(sender_session) > 0
+    )
+);
+
+CREATE INDEX IF NOT EXISTS idx_messages_conversation_seq_asc ON arc.messages (conversation_id, seq ASC);
+
+CREATE INDEX IF NOT EXISTS idx_messages_conversation_seq_desc ON arc.messages (conversation_id, seq DESC);
+
+CREATE INDEX IF NOT EXISTS idx_messages_conversation_client_msg ON arc.messages (
+    conversation_id,
+    client_msg_id
+);
+
+CREATE INDEX IF NOT EXISTS idx_messages_server_msg_id ON arc.messages (server_msg_id);
+
+-- Now that sessions exist, enforce sender_session integrity for messages.
+-- Keep column name as-is to avoid breaking Go code; enforce FK on the same column.
+DO $$
+BEGIN
+  IF NOT EXISTS (
+    SELECT 1
+    FROM pg_constraint
+    WHERE conname = 'fk_messages_sender_session'
+      AND conrelid = 'arc.messages'::regclass
+  ) THEN
+    ALTER TABLE arc.messages
+      ADD CONSTRAINT fk_messages_sender_session
+      FOREIGN KEY (sender_session)
+      REFERENCES arc.sessions (id)
+      ON DELETE RESTRICT;
+
+END IF;
+
+END;
+
+$$;
+
+-- =========================
+-- Invites (invite-only by default)
+-- =========================
+
+CREATE TABLE IF NOT EXISTS arc.invites (
+    id TEXT PRIMARY KEY,
+    token_hash TEXT NOT NULL,
+    created_by TEXT NULL REFERENCES arc.users (id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    max_uses INT NOT NULL DEFAULT 1,
+    used_count INT NOT NULL DEFAULT 0,
+    revoked_at TIMESTAMPTZ NULL,
+    note TEXT NULL,
+    consumed_at TIMESTAMPTZ NULL,
+    consumed_by TEXT NULL REFERENCES arc.users (id) ON DELETE SET NULL,
+    CONSTRAINT chk_invites_id_ulid_len CHECK (char_length(id) = 26),
+    CONSTRAINT chk_invites_token_hash_len CHECK (char_length(token_hash) = 64),
+    CONSTRAINT chk_invites_expires_after_created CHECK (expires_at > created_at),
+    CONSTRAINT chk_invites_max_uses CHECK (max_uses >= 1),
+    CONSTRAINT chk_invites_used_count CHECK (used_count >= 0 AND used_count <= max_uses),
+    CONSTRAINT chk_invites_revoked_after_created CHECK (
+        revoked_at IS NULL
+        OR revoked_at >= created_at
+    ),
+    CONSTRAINT chk_invites_note_len CHECK (
+        note IS NULL
+        OR char_length(note) <= 512
+    ),
+    CONSTRAINT chk_invites_consumed_at_after_created CHECK (
+        consumed_at IS NULL
+        OR consumed_at >= created_at
+    ),
+    CONSTRAINT chk_invites_consumed_by_pair CHECK (
+        (consumed_at IS NULL) = (consumed_by IS NULL)
+    )
+);
+
+-- PR-011 readiness: evolve invites in-place for older local databases.
+ALTER TABLE arc.invites
+    ADD COLUMN IF NOT EXISTS max_uses INT;
+
+ALTER TABLE arc.invites
+    ADD COLUMN IF NOT EXISTS used_count INT;
+
+ALTER TABLE arc.invites
+    ADD COLUMN IF NOT EXISTS revoked_at TIMESTAMPTZ;
+
+ALTER TABLE arc.invites
+    ADD COLUMN IF NOT EXISTS note TEXT;
+
+UPDATE arc.invites
+SET max_uses = 1
+WHERE max_uses IS NULL;
+
+UPDATE arc.invites
+SET used_count = 0
+WHERE used_count IS NULL;
+
+ALTER TABLE arc.invites
+    ALTER COLUMN max_uses SET DEFAULT 1;
+
+ALTER TABLE arc.invites
+    ALTER COLUMN used_count SET DEFAULT 0;
+
+ALTER TABLE arc.invites
+    ALTER COLUMN max_uses SET NOT NULL;
+
+ALTER TABLE arc.invites
+    ALTER COLUMN used_count SET NOT NULL;
+
+ALTER TABLE arc.invites
+    DROP CONSTRAINT IF EXISTS chk_invites_id_ulid_len;
+
+ALTER TABLE arc.invites
+    ADD CONSTRAINT chk_invites_id_ulid_len CHECK (char_length(id) = 26);
+
+ALTER TABLE arc.invites
+    DROP CONSTRAINT IF EXISTS chk_invites_token_hash_len;
+
+ALTER TABLE arc.invites
+    ADD CONSTRAINT chk_invites_token_hash_len CHECK (char_length(token_hash) = 64);
+
+ALTER TABLE arc.invites
+    DROP CONSTRAINT IF EXISTS chk_invites_expires_after_created;
+
+ALTER TABLE arc.invites
+    ADD CONSTRAINT chk_invites_expires_after_created CHECK (expires_at > created_at);
+
+ALTER TABLE arc.invites
+    DROP CONSTRAINT IF EXISTS chk_invites_max_uses;
+
+ALTER TABLE arc.invites
+    ADD CONSTRAINT chk_invites_max_uses CHECK (max_uses >= 1);
+
+ALTER TABLE arc.invites
+    DROP CONSTRAINT IF EXISTS chk_invites_used_count;
+
+ALTER TABLE arc.invites
+    ADD CONSTRAINT chk_invites_used_count CHECK (used_count >= 0 AND used_count <= max_uses);
+
+ALTER TABLE arc.invites
+    DROP CONSTRAINT IF EXISTS chk_invites_revoked_after_created;
+
+ALTER TABLE arc.invites
+    ADD CONSTRAINT chk_invites_revoked_after_created CHECK (
+        revoked_at IS NULL
+        OR revoked_at >= created_at
+    );
+
+ALTER TABLE arc.invites
+    DROP CONSTRAINT IF EXISTS chk_invites_note_len;
+
+ALTER TABLE arc.invites
+    ADD CONSTRAINT chk_invites_note_len CHECK (
+        note IS NULL
+        OR char_length(note) <= 512
+    );
+
+ALTER TABLE arc.invites
+    DROP CONSTRAINT IF EXISTS chk_invites_consumed_at_after_created;
+
+ALTER TABLE arc.invites
+    ADD CONSTRAINT chk_invites_consumed_at_after_created CHECK (
+        consumed_at IS NULL
+        OR consumed_at >= created_at
+    );
+
+ALTER TABLE arc.invites
+    DROP CONSTRAINT IF EXISTS chk_invites_consumed_by_pair;
+
+ALTER TABLE arc.invites
+    ADD CONSTRAINT chk_invites_consumed_by_pair CHECK (
+        (consumed_at IS NULL) = (consumed_by IS NULL)
+    );
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_invites_token_hash ON arc.invites (token_hash);
+
+CREATE INDEX IF NOT EXISTS idx_invites_expires_at ON arc.invites (expires_at);
+
+CREATE INDEX IF NOT EXISTS idx_invites_consumed_at ON arc.invites (consumed_at);
+
+CREATE INDEX IF NOT EXISTS idx_invites_revoked_at ON arc.invites (revoked_at);
+
+-- =========================
+-- Email verification readiness (PR-011)
+-- =========================
+
+CREATE TABLE IF NOT EXISTS arc.email_verification_tokens (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES arc.users (id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    consumed_at TIMESTAMPTZ NULL,
+    CONSTRAINT chk_email_verification_tokens_id_ulid_len CHECK (char_length(id) = 26),
+    CONSTRAINT chk_email_verification_tokens_user_id_ulid_len CHECK (char_length(user_id) = 26),
+    CONSTRAINT chk_email_verification_tokens_hash_len CHECK (char_length(token_hash) = 64),
+    CONSTRAINT chk_email_verification_tokens_expires_after_created CHECK (expires_at > created_at),
+    CONSTRAINT chk_email_verification_tokens_consumed_after_created CHECK (
+        consumed_at IS NULL
+        OR consumed_at >= created_at
+    )
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_email_verification_tokens_hash ON arc.email_verification_tokens (token_hash);
+
+CREATE INDEX IF NOT EXISTS idx_email_verification_tokens_user_id ON arc.email_verification_tokens (user_id);
+
+CREATE INDEX IF NOT EXISTS idx_email_verification_tokens_expires_at ON arc.email_verification_tokens (expires_at);
+
+CREATE INDEX IF NOT EXISTS idx_email_verification_tokens_active ON arc.email_verification_tokens (user_id, expires_at DESC)
+WHERE
+    consumed_at IS NULL;
+
+-- =========================
+-- Membership (authoritative)
+-- =========================
+
+CREATE TABLE IF NOT EXISTS arc.conversation_members (
+    conversation_id TEXT NOT NULL REFERENCES arc.conversations (id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES arc.users (id) ON DELETE CASCADE,
+    joined_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    role TEXT NOT NULL DEFAULT 'member',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (conversation_id, user_id),
+    CONSTRAINT chk_conversation_members_role CHECK (
+        role IN ('member', 'owner', 'admin')
+    ),
+    CONSTRAINT chk_conversation_members_user_id_ulid_len CHECK (char_length(user_id) = 26)
+);
+
+ALTER TABLE arc.conversation_members
+    ADD COLUMN IF NOT EXISTS joined_at TIMESTAMPTZ;
+
+UPDATE arc.conversation_members
+SET joined_at = COALESCE(joined_at, created_at, now())
+WHERE joined_at IS NULL;
+
+ALTER TABLE arc.conversation_members
+    ALTER COLUMN joined_at SET DEFAULT now();
+
+ALTER TABLE arc.conversation_members
+    ALTER COLUMN joined_at SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_conversation_members_user_id ON arc.conversation_members (user_id);
+
+-- One private direct conversation per user pair (user_low < user_high).
+CREATE TABLE IF NOT EXISTS arc.direct_conversations (
+    conversation_id TEXT PRIMARY KEY REFERENCES arc.conversations (id) ON DELETE CASCADE,
+    user_low TEXT NOT NULL REFERENCES arc.users (id) ON DELETE CASCADE,
+    user_high TEXT NOT NULL REFERENCES arc.users (id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT uq_direct_conversations_pair UNIQUE (user_low, user_high),
+    CONSTRAINT chk_direct_conversations_pair_order CHECK (user_low < user_high)
+);
+
+CREATE INDEX IF NOT EXISTS idx_direct_conversations_user_high ON arc.direct_conversations (user_high);
+
+-- =========================
+-- Audit log (minimal security audit)
+-- =========================
+
+CREATE TABLE IF NOT EXISTS arc.audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    user_id TEXT NULL REFERENCES arc.users (id) ON DELETE SET NULL,
+    session_id TEXT NULL REFERENCES arc.sessions (id) ON DELETE SET NULL,
+    action TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ip INET NULL,
+    user_agent TEXT NULL,
+    meta JSONB NULL,
+    CONSTRAINT chk_audit_action_len CHECK (
+        char_length(action) >= 3
+        AND char_length(action) <= 120
+    ),
+    CONSTRAINT chk_audit_user_agent_len CHECK (
+        user_agent IS NULL
+        OR char_length(user_agent) <= 512
+    )
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_created_at ON arc.audit_log (created_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_user_id ON arc.audit_log (user_id);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_session_id ON arc.audit_log (session_id);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_action_created_at ON arc.audit_log (action, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_login_failed_ip_created_at ON arc.audit_log (ip, created_at DESC) WHERE action = 'auth.login.failed'
+AND ip IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_login_failed_identifier_created_at ON arc.audit_log ((meta ->> 'identifier'), created_at DESC) WHERE action = 'auth.login.failed';
+
+-- =========================
+-- Risk-based login challenges (known devices + emailed codes)
+-- =========================
+
+CREATE TABLE IF NOT EXISTS arc.user_known_devices (
+    user_id TEXT NOT NULL REFERENCES arc.users (id) ON DELETE CASCADE,
+    fingerprint TEXT NOT NULL,
+    first_seen_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_seen_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, fingerprint),
+    CONSTRAINT chk_user_known_devices_user_id_ulid_len CHECK (char_length(user_id) = 26),
+    CONSTRAINT chk_user_known_devices_fingerprint_len CHECK (char_length(fingerprint) = 64)
+);
+
+CREATE TABLE IF NOT EXISTS arc.login_challenges (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES arc.users (id) ON DELETE CASCADE,
+    fingerprint TEXT NOT NULL,
+    code_hash TEXT NOT NULL,
+    identifier TEXT NOT NULL DEFAULT '',
+    platform TEXT NOT NULL DEFAULT 'unknown',
+    remember_me BOOLEAN NOT NULL DEFAULT false,
+    attempts INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    consumed_at TIMESTAMPTZ NULL,
+    CONSTRAINT chk_login_challenges_id_ulid_len CHECK (char_length(id) = 26),
+    CONSTRAINT chk_login_challenges_user_id_ulid_len CHECK (char_length(user_id) = 26),
+    CONSTRAINT chk_login_challenges_fingerprint_len CHECK (char_length(fingerprint) = 64),
+    CONSTRAINT chk_login_challenges_code_hash_len CHECK (char_length(code_hash) = 64),
+    CONSTRAINT chk_login_challenges_attempts_nonneg CHECK (attempts >= 0),
+    CONSTRAINT chk_login_challenges_expires_after_created CHECK (expires_at > created_at),
+    CONSTRAINT chk_login_challenges_platform CHECK (
+        platform IN ('web', 'ios', 'android', 'desktop', 'unknown')
+    )
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_challenges_user_id ON arc.login_challenges (user_id);
+
+CREATE INDEX IF NOT EXISTS idx_login_challenges_expires_at ON arc.login_challenges (expires_at);
+
+-- =========================
+-- GeoIP enrichment (sessions; audit rows carry geo in meta)
+-- =========================
+
+ALTER TABLE arc.sessions
+    ADD COLUMN IF NOT EXISTS geo_country TEXT NULL;
+
+ALTER TABLE arc.sessions
+    ADD COLUMN IF NOT EXISTS geo_city TEXT NULL;
+
+ALTER TABLE arc.sessions
+    ADD COLUMN IF NOT EXISTS geo_asn BIGINT NULL;
+
+ALTER TABLE arc.sessions
+    DROP CONSTRAINT IF EXISTS chk_sessions_geo_country_len;
+
+ALTER TABLE arc.sessions
+    ADD CONSTRAINT chk_sessions_geo_country_len CHECK (
+        geo_country IS NULL
+        OR char_length(geo_country) = 2
+    );
+
+ALTER TABLE arc.sessions
+    DROP CONSTRAINT IF EXISTS chk_sessions_geo_city_len;
+
+ALTER TABLE arc.sessions
+    ADD CONSTRAINT chk_sessions_geo_city_len CHECK (
+        geo_city IS NULL
+        OR char_length(geo_city) <= 128
+    );
+
+-- =========================
+-- Security events (client-reported signals, e.g. certificate pinning failures)
+-- =========================
+
+CREATE TABLE IF NOT EXISTS arc.security_events (
+    id BIGSERIAL PRIMARY KEY,
+    kind TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ip INET NULL,
+    user_agent TEXT NULL,
+    platform TEXT NOT NULL DEFAULT 'unknown',
+    meta JSONB NULL,
+    CONSTRAINT chk_security_events_kind_len CHECK (
+        char_length(kind) >= 3
+        AND char_length(kind) <= 64
+    ),
+    CONSTRAINT chk_security_events_user_agent_len CHECK (
+        user_agent IS NULL
+        OR char_length(user_agent) <= 512
+    ),
+    CONSTRAINT chk_security_events_platform CHECK (
+        platform IN ('web', 'ios', 'android', 'desktop', 'unknown')
+    )
+);
+
+CREATE INDEX IF NOT EXISTS idx_security_events_kind_created_at ON arc.security_events (kind, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_security_events_ip_created_at ON arc.security_events (ip, created_at DESC) WHERE ip IS NOT NULL;
+
+-- =========================
+-- Administrative account lock (abuse response)
+-- =========================
+
+ALTER TABLE arc.users
+    ADD COLUMN IF NOT EXISTS locked_at TIMESTAMPTZ NULL;
+
+ALTER TABLE arc.users
+    ADD COLUMN IF NOT EXISTS locked_reason TEXT NULL;
+
+ALTER TABLE arc.users
+    DROP CONSTRAINT IF EXISTS chk_users_locked_reason_len;
+
+ALTER TABLE arc.users
+    ADD CONSTRAINT chk_users_locked_reason_len CHECK (
+        locked_reason IS NULL
+        OR char_length(locked_reason) <= 512
+    );
+
+CREATE INDEX IF NOT EXISTS idx_users_locked_at ON arc.users (locked_at) WHERE locked_at IS NOT NULL;
+
+-- =========================
+-- Refresh token key binding (proof of possession on rotation)
+-- =========================
+
+ALTER TABLE arc.sessions
+    ADD COLUMN IF NOT EXISTS binding_key BYTEA NULL;
+
+ALTER TABLE arc.sessions
+    ADD COLUMN IF NOT EXISTS binding_nonce TEXT NULL;
+
+ALTER TABLE arc.sessions
+    DROP CONSTRAINT IF EXISTS chk_sessions_binding_key_len;
+
+ALTER TABLE arc.sessions
+    ADD CONSTRAINT chk_sessions_binding_key_len CHECK (
+        binding_key IS NULL
+        OR octet_length(binding_key) = 32
+    );
+
+ALTER TABLE arc.login_challenges
+    ADD COLUMN IF NOT EXISTS binding_key BYTEA NULL;
+
+-- =========================
+-- Access token renewal without refresh rotation
+-- =========================
+
+ALTER TABLE arc.sessions
+    ADD COLUMN IF NOT EXISTS access_renewed_at TIMESTAMPTZ NULL;
+
+-- =========================
+-- Account recovery codes (single-use login challenge fallback)
+-- =========================
+
+CREATE TABLE IF NOT EXISTS arc.recovery_codes (
+    id BIGSERIAL PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES arc.users (id) ON DELETE CASCADE,
+    code_hash TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    used_at TIMESTAMPTZ NULL,
+    CONSTRAINT uq_recovery_codes_user_code UNIQUE (user_id, code_hash),
+    CONSTRAINT chk_recovery_codes_user_id_ulid_len CHECK (char_length(user_id) = 26),
+    CONSTRAINT chk_recovery_codes_code_hash_len CHECK (char_length(code_hash) = 64)
+);
+
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_user_unused ON arc.recovery_codes (user_id) WHERE used_at IS NULL;
+
+-- =========================
+-- Privacy data exports (asynchronous JSON archives)
+-- =========================
+
+CREATE TABLE IF NOT EXISTS arc.privacy_exports (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES arc.users (id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'pending',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    completed_at TIMESTAMPTZ NULL,
+    expires_at TIMESTAMPTZ NULL,
+    -- gzip-compressed JSON archive; cleared once the export expires.
+    archive BYTEA NULL,
+    error TEXT NULL,
+    CONSTRAINT chk_privacy_exports_id_ulid_len CHECK (char_length(id) = 26),
+    CONSTRAINT chk_privacy_exports_user_id_ulid_len CHECK (char_length(user_id) = 26),
+    CONSTRAINT chk_privacy_exports_status CHECK (
+        status IN ('pending', 'ready', 'failed', 'expired')
+    ),
+    CONSTRAINT chk_privacy_exports_error_len CHECK (
+        error IS NULL
+        OR char_length(error) <= 256
+    )
+);
+
+CREATE INDEX IF NOT EXISTS idx_privacy_exports_user_created ON arc.privacy_exports (user_id, created_at DESC);
+
+-- =========================
+-- Right-to-be-forgotten purge jobs
+-- =========================
+
+-- Purged users' messages keep their seq but lose their author: sender_session becomes NULL.
+ALTER TABLE arc.messages
+    ALTER COLUMN sender_session DROP NOT NULL;
+
+ALTER TABLE arc.messages
+    DROP CONSTRAINT IF EXISTS chk_messages_sender_session_nonempty;
+
+ALTER TABLE arc.messages
+    ADD CONSTRAINT chk_messages_sender_session_nonempty CHECK (
+        sender_session IS NULL
+        OR char_length(sender_session) > 0
+    );
+
+-- target_user_id intentionally has no FK: the job row must outlive the user it erased.
+CREATE TABLE IF NOT EXISTS arc.user_purge_jobs (
+    id TEXT PRIMARY KEY,
+    target_user_id TEXT NOT NULL,
+    requested_by TEXT NULL REFERENCES arc.users (id) ON DELETE SET NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    current_step TEXT NULL,
+    steps_completed INT NOT NULL DEFAULT 0,
+    steps_total INT NOT NULL,
+    messages_scrubbed BIGINT NOT NULL DEFAULT 0,
+    invites_revoked BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    started_at TIMESTAMPTZ NULL,
+    completed_at TIMESTAMPTZ NULL,
+    error TEXT NULL,
+    CONSTRAINT chk_user_purge_jobs_id_ulid_len CHECK (char_length(id) = 26),
+    CONSTRAINT chk_user_purge_jobs_status CHECK (
+        status IN ('pending', 'running', 'completed', 'failed')
+    ),
+    CONSTRAINT chk_user_purge_jobs_steps CHECK (
+        steps_completed >= 0
+        AND steps_completed <= steps_total
+    ),
+    CONSTRAINT chk_user_purge_jobs_error_len CHECK (
+        error IS NULL
+        OR char_length(error) <= 256
+    )
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_purge_jobs_target_created ON arc.user_purge_jobs (target_user_id, created_at DESC);
+
+-- =========================
+-- SCIM 2.0 provisioning (IdP externalId links)
+-- =========================
+
+CREATE TABLE IF NOT EXISTS arc.scim_users (
+    user_id TEXT PRIMARY KEY REFERENCES arc.users (id) ON DELETE CASCADE,
+    external_id TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT chk_scim_users_user_id_ulid_len CHECK (char_length(user_id) = 26),
+    CONSTRAINT chk_scim_users_external_id_len CHECK (
+        char_length(external_id) > 0
+        AND char_length(external_id) <= 256
+    )
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_scim_users_external_id ON arc.scim_users (external_id);
+
+-- =========================
+-- Per-user read cursors (unread counts)
+-- =========================
+
+-- Rows are removed together with the membership they belong to.
+CREATE TABLE IF NOT EXISTS arc.conversation_read_cursors (
+    conversation_id TEXT NOT NULL,
+    user_id TEXT NOT NULL,
+    last_read_seq BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (conversation_id, user_id),
+    CONSTRAINT fk_conversation_read_cursors_member FOREIGN KEY (conversation_id, user_id)
+        REFERENCES arc.conversation_members (conversation_id, user_id) ON DELETE CASCADE,
+    CONSTRAINT chk_conversation_read_cursors_seq_nonneg CHECK (last_read_seq >= 0)
+);
+
+-- =========================
+-- Message edits and delete tombstones
+-- =========================
+
+-- version starts at 1 and increments on every edit/delete; tombstones keep their seq.
+ALTER TABLE arc.messages
+    ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
+
+ALTER TABLE arc.messages
+    ADD COLUMN IF NOT EXISTS edited_at TIMESTAMPTZ NULL;
+
+ALTER TABLE arc.messages
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ NULL;
+
+ALTER TABLE arc.messages
+    DROP CONSTRAINT IF EXISTS chk_messages_text_len;
+
+-- Tombstones carry empty text.
+ALTER TABLE arc.messages
+    ADD CONSTRAINT chk_messages_text_len CHECK (
+        (deleted_at IS NOT NULL AND text = '')
+        OR (
+            deleted_at IS NULL
+            AND char_length(text) > 0
+            AND char_length(text) <= 4096
+        )
+    );
+
+ALTER TABLE arc.messages
+    DROP CONSTRAINT IF EXISTS chk_messages_version_positive;
+
+ALTER TABLE arc.messages
+    ADD CONSTRAINT chk_messages_version_positive CHECK (version >= 1);
+
+-- =========================
+-- Reply-to metadata
+-- =========================
+
+-- The referenced message must belong to the same conversation (enforced by the realtime store).
+ALTER TABLE arc.messages
+    ADD COLUMN IF NOT EXISTS reply_to_server_msg_id TEXT NULL;
+
+-- =========================
+-- Message attachments
+-- =========================
+
+-- One row per issued upload target. Bytes live in the configured backend (local disk or S3)
+-- under storage_key; uploaded_at is only observed for local uploads.
+CREATE TABLE IF NOT EXISTS arc.attachments (
+    id TEXT PRIMARY KEY,
+    owner_user_id TEXT NOT NULL REFERENCES arc.users (id) ON DELETE CASCADE,
+    filename TEXT NULL,
+    mime_type TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    backend TEXT NOT NULL,
+    storage_key TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    upload_expires_at TIMESTAMPTZ NOT NULL,
+    uploaded_at TIMESTAMPTZ NULL,
+    CONSTRAINT chk_attachments_id_ulid_len CHECK (char_length(id) = 26),
+    CONSTRAINT chk_attachments_size_positive CHECK (size_bytes > 0),
+    CONSTRAINT chk_attachments_backend CHECK (backend IN ('local', 's3')),
+    CONSTRAINT chk_attachments_filename_len CHECK (
+        filename IS NULL OR char_length(filename) <= 255
+    )
+);
+
+CREATE INDEX IF NOT EXISTS idx_attachments_owner_created
+    ON arc.attachments (owner_user_id, created_at DESC);
+
+-- Attachment IDs referenced by message.send (ownership is checked by the gateway).
+ALTER TABLE arc.messages
+    ADD COLUMN IF NOT EXISTS attachment_ids TEXT[] NOT NULL DEFAULT '{}';
+
+-- =========================
+-- Message entities (mentions, links)
+-- =========================
+
+-- Server-extracted entities: [{type, offset, length, user_id?, url?}], offsets in UTF-16 code units.
+ALTER TABLE arc.messages
+    ADD COLUMN IF NOT EXISTS entities JSONB NULL;
+
+-- =========================
+-- Realtime fanout (Postgres broker)
+-- =========================
+
+-- Payloads too large for NOTIFY (8000 bytes) are spilled here and announced by id.
+-- Rows are short-lived: publishers prune entries older than a few minutes.
+CREATE TABLE IF NOT EXISTS arc.broker_spill (
+    id BIGSERIAL PRIMARY KEY,
+    channel TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_broker_spill_created
+    ON arc.broker_spill (created_at);
+
+-- =========================
+-- Per-user delivery cursors (message.delivered receipts)
+-- =========================
+
+-- A user's devices share one cursor; rows are removed together with the membership.
+CREATE TABLE IF NOT EXISTS arc.conversation_delivery_cursors (
+    conversation_id TEXT NOT NULL,
+    user_id TEXT NOT NULL,
+    last_delivered_seq BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (conversation_id, user_id),
+    CONSTRAINT fk_conversation_delivery_cursors_member FOREIGN KEY (conversation_id, user_id)
+        REFERENCES arc.conversation_members (conversation_id, user_id) ON DELETE CASCADE,
+    CONSTRAINT chk_conversation_delivery_cursors_seq_nonneg CHECK (last_delivered_seq >= 0)
+);
+
+-- =========================
+-- Push notifications
+-- =========================
+
+-- A device token belongs to at most one user; re-registering moves it to the new user.
+CREATE TABLE IF NOT EXISTS arc.push_tokens (
+    platform TEXT NOT NULL,
+    token TEXT NOT NULL,
+    user_id TEXT NOT NULL REFERENCES arc.users (id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (platform, token),
+    CONSTRAINT chk_push_tokens_platform CHECK (platform IN ('apns', 'fcm')),
+    CONSTRAINT chk_push_tokens_token_len CHECK (char_length(token) BETWEEN 1 AND 4096)
+);
+
+CREATE INDEX IF NOT EXISTS idx_push_tokens_user
+    ON arc.push_tokens (user_id);
+
+-- Per-member notification preferences; rows are removed together with the membership.
+CREATE TABLE IF NOT EXISTS arc.conversation_notification_prefs (
+    conversation_id TEXT NOT NULL,
+    user_id TEXT NOT NULL,
+    muted_until TIMESTAMPTZ NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (conversation_id, user_id),
+    CONSTRAINT fk_conversation_notification_prefs_member FOREIGN KEY (conversation_id, user_id)
+        REFERENCES arc.conversation_members (conversation_id, user_id) ON DELETE CASCADE
+);
+
+-- =========================
+-- Notification levels
+-- =========================
+
+-- 'all' pushes every message; 'mentions' only messages that mention the member.
+-- muted_until silences pushes regardless of level.
+ALTER TABLE arc.conversation_notification_prefs
+    ADD COLUMN IF NOT EXISTS level TEXT NOT NULL DEFAULT 'all';
+
+ALTER TABLE arc.conversation_notification_prefs
+    DROP CONSTRAINT IF EXISTS chk_conversation_notification_prefs_level;
+
+ALTER TABLE arc.conversation_notification_prefs
+    ADD CONSTRAINT chk_conversation_notification_prefs_level CHECK (level IN ('all', 'mentions'));
+
+-- =========================
+-- Bots (slash-command webhooks)
+-- =========================
+
+-- A bot is installed in one conversation; its API token (stored as a SHA-256 hex digest)
+-- may only post there. webhook_secret signs deliveries and must stay readable.
+CREATE TABLE IF NOT EXISTS arc.bots (
+    id TEXT PRIMARY KEY,
+    conversation_id TEXT NOT NULL REFERENCES arc.conversations (id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    webhook_url TEXT NOT NULL,
+    webhook_secret TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    created_by TEXT NULL REFERENCES arc.users (id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT chk_bots_id_ulid_len CHECK (char_length(id) = 26),
+    CONSTRAINT chk_bots_name_len CHECK (char_length(name) BETWEEN 1 AND 80),
+    CONSTRAINT chk_bots_token_hash_len CHECK (char_length(token_hash) = 64)
+);
+
+CREATE INDEX IF NOT EXISTS idx_bots_conversation
+    ON arc.bots (conversation_id);
+
+-- A command maps to at most one bot per conversation.
+CREATE TABLE IF NOT EXISTS arc.bot_commands (
+    conversation_id TEXT NOT NULL,
+    command TEXT NOT NULL,
+    bot_id TEXT NOT NULL REFERENCES arc.bots (id) ON DELETE CASCADE,
+    PRIMARY KEY (conversation_id, command)
+);
+
+CREATE INDEX IF NOT EXISTS idx_bot_commands_bot
+    ON arc.bot_commands (bot_id);
+
+-- Bot replies have no sender session; sender_bot_id attributes them instead. It is kept
+-- (without a foreign key) after the bot is removed so history stays attributed.
+ALTER TABLE arc.messages
+    ADD COLUMN IF NOT EXISTS sender_bot_id TEXT NULL;
+
+-- =========================
+-- Moderation: message reports
+-- =========================
+
+-- One report per reporter and message. Reports move from open to actioned when a
+-- moderator removes the message; removals are also recorded in arc.audit_log.
+CREATE TABLE IF NOT EXISTS arc.message_reports (
+    id TEXT PRIMARY KEY,
+    conversation_id TEXT NOT NULL REFERENCES arc.conversations (id) ON DELETE CASCADE,
+    server_msg_id TEXT NOT NULL REFERENCES arc.messages (server_msg_id) ON DELETE CASCADE,
+    reporter_user_id TEXT NOT NULL REFERENCES arc.users (id) ON DELETE CASCADE,
+    reason TEXT NOT NULL,
+    note TEXT NULL,
+    status TEXT NOT NULL DEFAULT 'open',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    resolved_at TIMESTAMPTZ NULL,
+    resolved_by TEXT NULL REFERENCES arc.users (id) ON DELETE SET NULL,
+    CONSTRAINT uq_message_reports_reporter UNIQUE (server_msg_id, reporter_user_id),
+    CONSTRAINT chk_message_reports_id_ulid_len CHECK (char_length(id) = 26),
+    CONSTRAINT chk_message_reports_reason CHECK (
+        reason IN ('spam', 'harassment', 'hate', 'violence', 'sexual', 'illegal', 'other')
+    ),
+    CONSTRAINT chk_message_reports_note_len CHECK (
+        note IS NULL
+        OR char_length(note) <= 1000
+    ),
+    CONSTRAINT chk_message_reports_status CHECK (status IN ('open', 'actioned'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_message_reports_open_queue
+    ON arc.message_reports (conversation_id, created_at, id)
+    WHERE status = 'open';
+
+CREATE INDEX IF NOT EXISTS idx_message_reports_message
+    ON arc.message_reports (server_msg_id);
+
+-- =========================
+-- Message retention archives
+-- =========================
+
+-- One row per archive object (gzip NDJSON) written before the retention job deleted
+-- messages from_seq..to_seq. conversation_id has no FK: archives outlive conversations.
+CREATE TABLE IF NOT EXISTS arc.message_archives (
+    id TEXT PRIMARY KEY,
+    conversation_id TEXT NOT NULL,
+    from_seq BIGINT NOT NULL,
+    to_seq BIGINT NOT NULL,
+    message_count INTEGER NOT NULL,
+    backend TEXT NOT NULL,
+    object_key TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT chk_message_archives_range CHECK (from_seq >= 1 AND from_seq <= to_seq),
+    CONSTRAINT chk_message_archives_count_positive CHECK (message_count > 0),
+    CONSTRAINT chk_message_archives_backend CHECK (backend IN ('local', 's3'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_message_archives_conversation_seq
+    ON arc.message_archives (conversation_id, from_seq, to_seq);
+
+-- =========================
+-- Messages: hash partitioning by conversation
+-- =========================
+
+-- arc.messages is hash-partitioned on conversation_id into 16 partitions
+-- (arc.messages_p00 .. arc.messages_p15). Every store query is scoped to one
+-- conversation, so it touches a single partition. Hash partitioning beats monthly
+-- ranges here because the primary key (conversation_id, seq) cannot include server_ts.
+--
+-- Unique constraints on a partitioned table must include the partition key, so
+-- server_msg_id is unique per conversation (it is a random 128-bit id), and
+-- arc.message_reports references and deduplicates by (conversation_id, server_msg_id).
+--
+-- Migration path: on databases created before partitioning, the block below converts
+-- the plain table in place. It copies every row in one transaction while holding an
+-- exclusive lock on arc.messages, so run this schema in a maintenance window on large
+-- deployments. Later runs see a partitioned table and do nothing.
+DO $$
+DECLARE
+  parts CONSTANT INT := 16;
+  i INT;
+BEGIN
+  IF NOT EXISTS (
+    SELECT 1
+    FROM pg_class c
+    JOIN pg_namespace n ON n.oid = c.relnamespace
+    WHERE n.nspname = 'arc'
+      AND c.relname = 'messages'
+      AND c.relkind = 'r'
+  ) THEN
+    RETURN;
+  END IF;
+
+  LOCK TABLE arc.messages IN ACCESS EXCLUSIVE MODE;
+
+  ALTER TABLE arc.message_reports
+    DROP CONSTRAINT IF EXISTS message_reports_server_msg_id_fkey;
+
+  ALTER TABLE arc.messages RENAME TO messages_unpartitioned;
+
+  CREATE TABLE arc.messages (
+      conversation_id TEXT NOT NULL REFERENCES arc.conversations (id) ON DELETE CASCADE,
+      seq BIGINT NOT NULL,
+      server_msg_id TEXT NOT NULL,
+      client_msg_id TEXT NOT NULL,
+      sender_session TEXT NULL,
+      text TEXT NOT NULL,
+      server_ts TIMESTAMPTZ NOT NULL DEFAULT now(),
+      created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+      version BIGINT NOT NULL DEFAULT 1,
+      edited_at TIMESTAMPTZ NULL,
+      deleted_at TIMESTAMPTZ NULL,
+      reply_to_server_msg_id TEXT NULL,
+      attachment_ids TEXT[] NOT NULL DEFAULT '{}',
+      entities JSONB NULL,
+      sender_bot_id TEXT NULL
+  ) PARTITION BY HASH (conversation_id);
+
+  FOR i IN 0 .. parts - 1 LOOP
+    EXECUTE format(
+      'CREATE TABLE arc.%I PARTITION OF arc.messages FOR VALUES WITH (MODULUS %s, REMAINDER %s)',
+      'messages_p' || lpad(i::text, 2, '0'), parts, i
+    );
+  END LOOP;
+
+  INSERT INTO arc.messages (
+      conversation_id, seq, server_msg_id, client_msg_id, sender_session, text, server_ts, created_at,
+      version, edited_at, deleted_at, reply_to_server_msg_id, attachment_ids, entities, sender_bot_id
+  )
+  SELECT conversation_id, seq, server_msg_id, client_msg_id, sender_session, text, server_ts, created_at,
+         version, edited_at, deleted_at, reply_to_server_msg_id, attachment_ids, entities, sender_bot_id
+  FROM arc.messages_unpartitioned;
+
+  -- Dropping the old table frees its index and constraint names for the new table.
+  DROP TABLE arc.messages_unpartitioned;
+
+  ALTER TABLE arc.messages
+    ADD CONSTRAINT messages_pkey PRIMARY KEY (conversation_id, seq),
+    ADD CONSTRAINT uq_messages_conversation_client_msg UNIQUE (conversation_id, client_msg_id),
+    ADD CONSTRAINT uq_messages_conversation_server_msg UNIQUE (conversation_id, server_msg_id),
+    ADD CONSTRAINT chk_messages_seq_positive CHECK (seq >= 1),
+    ADD CONSTRAINT chk_messages_text_len CHECK (
+        (deleted_at IS NOT NULL AND text = '')
+        OR (
+            deleted_at IS NULL
+            AND char_length(text) > 0
+            AND char_length(text) <= 4096
+        )
+    ),
+    ADD CONSTRAINT chk_messages_client_msg_id_nonempty CHECK (char_length(client_msg_id) > 0),
+    ADD CONSTRAINT chk_messages_server_msg_id_nonempty CHECK (char_length(server_msg_id) > 0),
+    ADD CONSTRAINT chk_messages_sender_session_nonempty CHECK (
+        sender_session IS NULL
+        OR char_length(sender_session) > 0
+    ),
+    ADD CONSTRAINT chk_messages_version_positive CHECK (version >= 1),
+    ADD CONSTRAINT fk_messages_sender_session
+      FOREIGN KEY (sender_session)
+      REFERENCES arc.sessions (id)
+      ON DELETE RESTRICT;
+
+  CREATE INDEX idx_messages_conversation_seq_asc ON arc.messages (conversation_id, seq ASC);
+  CREATE INDEX idx_messages_conversation_seq_desc ON arc.messages (conversation_id, seq DESC);
+  CREATE INDEX idx_messages_conversation_client_msg ON arc.messages (conversation_id, client_msg_id);
+  CREATE INDEX idx_messages_server_msg_id ON arc.messages (server_msg_id);
+
+  ALTER TABLE arc.message_reports
+    DROP CONSTRAINT IF EXISTS uq_message_reports_reporter,
+    ADD CONSTRAINT uq_message_reports_reporter UNIQUE (conversation_id, server_msg_id, reporter_user_id),
+    ADD CONSTRAINT fk_message_reports_message
+      FOREIGN KEY (conversation_id, server_msg_id)
+      REFERENCES arc.messages (conversation_id, server_msg_id)
+      ON DELETE CASCADE;
+END;
+$$;
+
+-- Privacy exports and purges look messages up by author across all partitions.
+CREATE INDEX IF NOT EXISTS idx_messages_sender_session
+    ON arc.messages (sender_session);
+
+-- =========================
+-- Conversation stats
+-- =========================
+-- One row per conversation with messages, maintained in the transaction that appends
+-- (and, for retention, deletes or restores) messages, so conversation lists and unread
+-- counts never scan arc.messages.
+CREATE TABLE IF NOT EXISTS arc.conversation_stats (
+    conversation_id TEXT PRIMARY KEY REFERENCES arc.conversations (id) ON DELETE CASCADE,
+    last_seq BIGINT NOT NULL DEFAULT 0,
+    message_count BIGINT NOT NULL DEFAULT 0,
+    last_message_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT chk_conversation_stats_last_seq CHECK (last_seq >= 0),
+    CONSTRAINT chk_conversation_stats_message_count CHECK (message_count >= 0)
+);
+
+DROP TRIGGER IF EXISTS trg_conversation_stats_updated_at ON arc.conversation_stats;
+
+CREATE TRIGGER trg_conversation_stats_updated_at
+BEFORE UPDATE ON arc.conversation_stats
+FOR EACH ROW
+EXECUTE FUNCTION arc.set_updated_at();
+
+-- Backfill conversations that had messages before the table existed.
+INSERT INTO arc.conversation_stats (conversation_id, last_seq, message_count, last_message_at)
+SELECT conversation_id, max(seq), count(*), max(server_ts)
+  FROM arc.messages
+ GROUP BY conversation_id
+ON CONFLICT (conversation_id) DO NOTHING;
+
+-- =========================
+-- End-to-end encryption
+-- =========================
+-- Encrypted messages (content_type 'e2ee') carry empty text; the server stores the
+-- ciphertext and the recipient device key ids opaquely and never decrypts them.
+ALTER TABLE arc.messages
+    ADD COLUMN IF NOT EXISTS content_type TEXT NOT NULL DEFAULT 'text',
+    ADD COLUMN IF NOT EXISTS ciphertext BYTEA NULL,
+    ADD COLUMN IF NOT EXISTS key_ids TEXT[] NOT NULL DEFAULT '{}';
+
+ALTER TABLE arc.messages
+    DROP CONSTRAINT IF EXISTS chk_messages_content_type,
+    DROP CONSTRAINT IF EXISTS chk_messages_text_len;
+
+ALTER TABLE arc.messages
+    ADD CONSTRAINT chk_messages_content_type CHECK (content_type IN ('text', 'e2ee')),
+    ADD CONSTRAINT chk_messages_text_len CHECK (
+        (deleted_at IS NOT NULL AND text = '')
+        OR (
+            deleted_at IS NULL
+            AND content_type = 'e2ee'
+            AND text = ''
+            AND ciphertext IS NOT NULL
+        )
+        OR (
+            deleted_at IS NULL
+            AND content_type = 'text'
+            AND char_length(text) > 0
+            AND char_length(text) <= 4096
+        )
+    );
+
+-- One row per user device: its long-term identity key and current signed prekey.
+CREATE TABLE IF NOT EXISTS arc.e2ee_devices (
+    user_id TEXT NOT NULL REFERENCES arc.users (id) ON DELETE CASCADE,
+    device_id TEXT NOT NULL,
+    identity_key BYTEA NOT NULL,
+    signed_prekey_id BIGINT NOT NULL,
+    signed_prekey BYTEA NOT NULL,
+    signed_prekey_signature BYTEA NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, device_id),
+    CONSTRAINT chk_e2ee_devices_device_id_len CHECK (
+        char_length(device_id) > 0
+        AND char_length(device_id) <= 128
+    ),
+    CONSTRAINT chk_e2ee_devices_signed_prekey_id_nonneg CHECK (signed_prekey_id >= 0)
+);
+
+DROP TRIGGER IF EXISTS trg_e2ee_devices_updated_at ON arc.e2ee_devices;
+
+CREATE TRIGGER trg_e2ee_devices_updated_at
+BEFORE UPDATE ON arc.e2ee_devices
+FOR EACH ROW
+EXECUTE FUNCTION arc.set_updated_at();
+
+-- One-time prekeys are handed out at most once, then deleted.
+CREATE TABLE IF NOT EXISTS arc.e2ee_one_time_prekeys (
+    user_id TEXT NOT NULL,
+    device_id TEXT NOT NULL,
+    key_id BIGINT NOT NULL,
+    public_key BYTEA NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, device_id, key_id),
+    CONSTRAINT fk_e2ee_one_time_prekeys_device
+      FOREIGN KEY (user_id, device_id)
+      REFERENCES arc.e2ee_devices (user_id, device_id)
+      ON DELETE CASCADE,
+    CONSTRAINT chk_e2ee_one_time_prekeys_key_id_nonneg CHECK (key_id >= 0)
+);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS arc.idx_audit_log_login_failed_identifier_hash_created_at;
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_login_failed_identifier_created_at ON arc.audit_log ((meta ->> 'identifier'), created_at DESC) WHERE action = 'auth.login.failed';
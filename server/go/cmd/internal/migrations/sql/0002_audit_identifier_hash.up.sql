@@ -0,0 +1,9 @@
+-- Login identifiers in audit meta are redacted (emails partially), so failed-login
+-- throttling matches on meta.identifier_hash, an HMAC-SHA256 of the normalized
+-- identifier under a key derived from ARC_TOKEN_HMAC_KEY. Postgres does not have
+-- the key, so the server backfills recent failures on start
+-- (authapi.Handler.BackfillIdentifierHashes).
+
+DROP INDEX IF EXISTS arc.idx_audit_log_login_failed_identifier_created_at;
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_login_failed_identifier_hash_created_at ON arc.audit_log ((meta ->> 'identifier_hash'), created_at DESC) WHERE action = 'auth.login.failed';
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS arc.feature_flags;
@@ -0,0 +1,9 @@
+-- Runtime feature flag overrides set through /admin/feature-flags. Each tenant
+-- schema carries its own copy; rows win over ARC_FEATURE_FLAGS.
+CREATE TABLE IF NOT EXISTS arc.feature_flags (
+    name TEXT PRIMARY KEY,
+    enabled BOOLEAN NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_by TEXT REFERENCES arc.users (id) ON DELETE SET NULL,
+    CONSTRAINT chk_feature_flags_name_len CHECK (char_length(name) BETWEEN 1 AND 64)
+);
@@ -0,0 +1,21 @@
+-- Emailed invites (POST /auth/invites/send). One row per invite records where it
+-- was sent and whether the email sender accepted it; sent_by + created_at backs
+-- the per-user daily send limit.
+CREATE TABLE IF NOT EXISTS arc.invite_deliveries (
+    invite_id TEXT PRIMARY KEY REFERENCES arc.invites (id) ON DELETE CASCADE,
+    sent_by TEXT NOT NULL REFERENCES arc.users (id) ON DELETE CASCADE,
+    email TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    error TEXT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT chk_invite_deliveries_status CHECK (status IN ('pending', 'sent', 'failed')),
+    CONSTRAINT chk_invite_deliveries_email_len CHECK (char_length(email) BETWEEN 3 AND 320),
+    CONSTRAINT chk_invite_deliveries_error_len CHECK (
+        error IS NULL
+        OR char_length(error) <= 512
+    )
+);
+
+CREATE INDEX IF NOT EXISTS idx_invite_deliveries_sent_by_created_at
+    ON arc.invite_deliveries (sent_by, created_at DESC);
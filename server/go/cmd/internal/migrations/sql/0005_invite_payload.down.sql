@@ -0,0 +1,8 @@
+ALTER TABLE arc.invites
+    DROP CONSTRAINT IF EXISTS chk_invites_conversation_role;
+
+ALTER TABLE arc.invites
+    DROP COLUMN IF EXISTS conversation_role;
+
+ALTER TABLE arc.invites
+    DROP COLUMN IF EXISTS conversation_id;
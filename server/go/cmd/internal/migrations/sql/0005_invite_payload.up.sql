@@ -0,0 +1,17 @@
+-- Invites can carry a conversation the new user joins on signup, with the role
+-- to grant there. Owner is never granted by invite. Deleting the conversation
+-- drops the payload and leaves a plain invite.
+ALTER TABLE arc.invites
+    ADD COLUMN IF NOT EXISTS conversation_id TEXT NULL REFERENCES arc.conversations (id) ON DELETE SET NULL;
+
+ALTER TABLE arc.invites
+    ADD COLUMN IF NOT EXISTS conversation_role TEXT NULL;
+
+ALTER TABLE arc.invites
+    DROP CONSTRAINT IF EXISTS chk_invites_conversation_role;
+
+ALTER TABLE arc.invites
+    ADD CONSTRAINT chk_invites_conversation_role CHECK (
+        conversation_role IS NULL
+        OR conversation_role IN ('member', 'admin')
+    );
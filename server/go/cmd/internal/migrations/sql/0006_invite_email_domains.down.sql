@@ -0,0 +1,5 @@
+ALTER TABLE arc.invites
+    DROP CONSTRAINT IF EXISTS chk_invites_allowed_email_domains_len;
+
+ALTER TABLE arc.invites
+    DROP COLUMN IF EXISTS allowed_email_domains;
@@ -0,0 +1,13 @@
+-- Invites can be restricted to emails in a set of domains (exact match, lower-case),
+-- checked when the invite is consumed.
+ALTER TABLE arc.invites
+    ADD COLUMN IF NOT EXISTS allowed_email_domains TEXT[] NULL;
+
+ALTER TABLE arc.invites
+    DROP CONSTRAINT IF EXISTS chk_invites_allowed_email_domains_len;
+
+ALTER TABLE arc.invites
+    ADD CONSTRAINT chk_invites_allowed_email_domains_len CHECK (
+        allowed_email_domains IS NULL
+        OR cardinality(allowed_email_domains) <= 20
+    );
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS arc.idx_invites_created_at;
@@ -0,0 +1,3 @@
+-- GET /admin/invites/stats scans invites by creation time; consumed_at and
+-- expires_at already have indexes.
+CREATE INDEX IF NOT EXISTS idx_invites_created_at ON arc.invites (created_at);
@@ -0,0 +1,3 @@
+-- The used_count backfill is not reverted; it only counted uses already recorded.
+ALTER TABLE arc.invites
+    DROP CONSTRAINT IF EXISTS chk_invites_consumed_used;
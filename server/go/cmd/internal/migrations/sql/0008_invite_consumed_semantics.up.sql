@@ -0,0 +1,16 @@
+-- Invite handling is consolidated in one service: every use increments used_count
+-- and consumed_at/consumed_by record the latest use. Rows consumed by the retired
+-- code path without a use counted are backfilled before the invariant is enforced.
+UPDATE arc.invites
+   SET used_count = 1
+ WHERE consumed_at IS NOT NULL
+   AND used_count = 0;
+
+ALTER TABLE arc.invites
+    DROP CONSTRAINT IF EXISTS chk_invites_consumed_used;
+
+ALTER TABLE arc.invites
+    ADD CONSTRAINT chk_invites_consumed_used CHECK (
+        consumed_at IS NULL
+        OR used_count >= 1
+    );
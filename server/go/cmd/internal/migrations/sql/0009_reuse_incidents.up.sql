@@ -0,0 +1,33 @@
+-- Refresh token reuse detections (GET /admin/security/reuse-incidents). Reuse revokes
+-- every session of the user, so each row keeps the evidence: who replayed the rotated
+-- token, who had rotated it first, and the rotation chain as it stood at detection.
+CREATE TABLE IF NOT EXISTS arc.reuse_incidents (
+    id BIGSERIAL PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES arc.users (id) ON DELETE CASCADE,
+    detected_at TIMESTAMPTZ NOT NULL,
+    endpoint TEXT NOT NULL,
+    -- Session whose rotated refresh token was presented again (no FK: the evidence
+    -- outlives session cleanup).
+    reused_session_id TEXT NOT NULL,
+    presenter_ip INET NULL,
+    presenter_user_agent TEXT NULL,
+    presenter_platform TEXT NOT NULL DEFAULT 'unknown',
+    -- The first presenter: when the token was rotated and from where.
+    rotated_at TIMESTAMPTZ NULL,
+    rotated_ip INET NULL,
+    rotated_user_agent TEXT NULL,
+    -- Sessions linked by replaced_by_session_id, oldest first (see session.ReuseChainEntry).
+    session_chain JSONB NOT NULL,
+    sessions_revoked BIGINT NOT NULL DEFAULT 0,
+    CONSTRAINT chk_reuse_incidents_endpoint CHECK (endpoint IN ('refresh', 'access_renew')),
+    CONSTRAINT chk_reuse_incidents_presenter_user_agent_len CHECK (
+        presenter_user_agent IS NULL
+        OR char_length(presenter_user_agent) <= 512
+    )
+);
+
+CREATE INDEX IF NOT EXISTS idx_reuse_incidents_detected_at
+    ON arc.reuse_incidents (detected_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_reuse_incidents_user_id_detected_at
+    ON arc.reuse_incidents (user_id, detected_at DESC);
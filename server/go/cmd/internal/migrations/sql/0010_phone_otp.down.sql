@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS arc.phone_otps;
+
+DROP INDEX IF EXISTS arc.uq_users_phone;
+
+ALTER TABLE arc.users
+    DROP CONSTRAINT IF EXISTS chk_users_phone_verified_pair,
+    DROP CONSTRAINT IF EXISTS chk_users_phone_e164,
+    DROP COLUMN IF EXISTS phone_verified_at,
+    DROP COLUMN IF EXISTS phone;
@@ -0,0 +1,48 @@
+-- Phone number identity and SMS one-time codes (POST /auth/otp/request, /auth/otp/verify).
+-- A phone is set only after its owner proves possession with a code, so a non-NULL
+-- phone is always verified; phone_verified_at records when.
+ALTER TABLE arc.users
+    ADD COLUMN IF NOT EXISTS phone TEXT NULL,
+    ADD COLUMN IF NOT EXISTS phone_verified_at TIMESTAMPTZ NULL;
+
+ALTER TABLE arc.users
+    DROP CONSTRAINT IF EXISTS chk_users_phone_e164,
+    ADD CONSTRAINT chk_users_phone_e164 CHECK (phone IS NULL OR phone ~ '^\+[1-9][0-9]{6,14}$'),
+    DROP CONSTRAINT IF EXISTS chk_users_phone_verified_pair,
+    ADD CONSTRAINT chk_users_phone_verified_pair CHECK ((phone IS NULL) = (phone_verified_at IS NULL));
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_users_phone ON arc.users (phone) WHERE phone IS NOT NULL;
+
+-- One row per code sent. purpose 'login' signs the owner of phone in; 'link' attaches
+-- phone to user_id. Login codes for unknown numbers are stored with a NULL user_id so
+-- they count towards the throttles and never verify. phone_hash (SHA-256 of the E.164
+-- number) and ip back the per-phone and per-IP request limits.
+CREATE TABLE IF NOT EXISTS arc.phone_otps (
+    id TEXT PRIMARY KEY,
+    purpose TEXT NOT NULL,
+    phone TEXT NOT NULL,
+    phone_hash TEXT NOT NULL,
+    user_id TEXT NULL REFERENCES arc.users (id) ON DELETE CASCADE,
+    code_hash TEXT NOT NULL,
+    channel TEXT NOT NULL DEFAULT 'sms',
+    ip INET NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    consumed_at TIMESTAMPTZ NULL,
+    CONSTRAINT chk_phone_otps_id_ulid_len CHECK (char_length(id) = 26),
+    CONSTRAINT chk_phone_otps_purpose CHECK (purpose IN ('login', 'link')),
+    CONSTRAINT chk_phone_otps_phone_e164 CHECK (phone ~ '^\+[1-9][0-9]{6,14}$'),
+    CONSTRAINT chk_phone_otps_phone_hash_len CHECK (char_length(phone_hash) = 64),
+    CONSTRAINT chk_phone_otps_code_hash_len CHECK (char_length(code_hash) = 64),
+    CONSTRAINT chk_phone_otps_channel CHECK (channel IN ('sms', 'email')),
+    CONSTRAINT chk_phone_otps_attempts_nonneg CHECK (attempts >= 0),
+    CONSTRAINT chk_phone_otps_expires_after_created CHECK (expires_at > created_at),
+    CONSTRAINT chk_phone_otps_link_user CHECK (purpose <> 'link' OR user_id IS NOT NULL)
+);
+
+CREATE INDEX IF NOT EXISTS idx_phone_otps_phone_hash_created_at ON arc.phone_otps (phone_hash, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_phone_otps_ip_created_at ON arc.phone_otps (ip, created_at DESC) WHERE ip IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_phone_otps_expires_at ON arc.phone_otps (expires_at);
@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS trg_sessions_notify_deleted ON arc.sessions;
+
+DROP TRIGGER IF EXISTS trg_sessions_notify_revoked ON arc.sessions;
+
+DROP FUNCTION IF EXISTS arc.sessions_notify_revoked();
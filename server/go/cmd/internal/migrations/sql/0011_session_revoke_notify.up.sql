@@ -0,0 +1,35 @@
+-- Announce session revocations on the "<schema>.session_revoked" channel so realtime
+-- gateways close the affected connections at once. The payload is "<user_id> <session_id>".
+-- NOTIFY is delivered on commit, which covers every revocation path (logout, revoke-all,
+-- admin and filter revocations, reuse detection, user deletes). Refresh rotation is not
+-- announced, since the replacement session keeps the user's connections open, and neither
+-- are deletes of sessions that already ended (retention cleanup).
+CREATE OR REPLACE FUNCTION arc.sessions_notify_revoked()
+RETURNS TRIGGER AS $$
+BEGIN
+  PERFORM pg_notify(TG_TABLE_SCHEMA || '.session_revoked', OLD.user_id || ' ' || OLD.id);
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_sessions_notify_revoked ON arc.sessions;
+
+CREATE TRIGGER trg_sessions_notify_revoked
+AFTER UPDATE OF revoked_at
+ON arc.sessions
+FOR EACH ROW
+WHEN (
+    OLD.revoked_at IS NULL
+    AND NEW.revoked_at IS NOT NULL
+    AND NEW.revocation_reason IS DISTINCT FROM 'rotation'
+)
+EXECUTE FUNCTION arc.sessions_notify_revoked();
+
+DROP TRIGGER IF EXISTS trg_sessions_notify_deleted ON arc.sessions;
+
+CREATE TRIGGER trg_sessions_notify_deleted
+AFTER DELETE
+ON arc.sessions
+FOR EACH ROW
+WHEN (OLD.revoked_at IS NULL AND OLD.expires_at > now())
+EXECUTE FUNCTION arc.sessions_notify_revoked();
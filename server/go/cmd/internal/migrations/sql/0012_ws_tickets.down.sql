@@ -0,0 +1 @@
+DROP TABLE IF EXISTS arc.ws_tickets;
@@ -0,0 +1,16 @@
+-- One-time WebSocket tickets (POST /auth/ws-ticket). Browsers cannot set headers on
+-- the upgrade request, so they trade their access token for a short-lived ticket that
+-- is passed in the URL instead. Only the SHA-256 of the ticket is stored.
+CREATE TABLE IF NOT EXISTS arc.ws_tickets (
+    ticket_hash TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES arc.users (id) ON DELETE CASCADE,
+    session_id TEXT NOT NULL REFERENCES arc.sessions (id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    consumed_at TIMESTAMPTZ NULL,
+    CONSTRAINT chk_ws_tickets_hash_len CHECK (char_length(ticket_hash) = 64),
+    CONSTRAINT chk_ws_tickets_expires_after_created CHECK (expires_at > created_at)
+);
+
+-- Expired tickets are pruned when new ones are issued.
+CREATE INDEX IF NOT EXISTS idx_ws_tickets_expires_at ON arc.ws_tickets (expires_at);
@@ -0,0 +1,68 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"arc/cmd/internal/dbtrace"
+	"arc/cmd/internal/realtime"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// abuseAuditTimeout bounds one audit insert; reports are written off the session loop.
+const abuseAuditTimeout = 5 * time.Second
+
+// AbuseAuditor records realtime abuse events in arc.audit_log as
+// "abuse.duplicate.warned" and "abuse.session.suspended" so moderators can review them.
+type AbuseAuditor struct {
+	log  *slog.Logger
+	pool *pgxpool.Pool
+}
+
+// NewAbuseAuditor constructs an AbuseAuditor.
+func NewAbuseAuditor(log *slog.Logger, pool *pgxpool.Pool) (*AbuseAuditor, error) {
+	if pool == nil {
+		return nil, errors.New("moderation: nil db pool")
+	}
+	if log == nil {
+		log = slog.Default()
+	}
+	return &AbuseAuditor{log: log, pool: pool}, nil
+}
+
+// ReportAbuse writes ev asynchronously; failures are logged.
+func (a *AbuseAuditor) ReportAbuse(_ context.Context, ev realtime.AbuseEvent) {
+	action := "abuse.duplicate.warned"
+	if ev.Kind == realtime.AbuseSessionSuspended {
+		action = "abuse.session.suspended"
+	}
+	meta := map[string]any{
+		"count":            ev.Count,
+		"conversation_ids": ev.ConversationIDs,
+		"content_hash":     ev.ContentHash,
+	}
+	if ev.SuspendedUntil != nil {
+		meta["suspended_until"] = ev.SuspendedUntil.UTC()
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(dbtrace.WithOp(context.Background(), "moderation.report_abuse"), abuseAuditTimeout)
+		defer cancel()
+
+		tx, err := a.pool.Begin(ctx)
+		if err == nil {
+			defer func() { _ = tx.Rollback(ctx) }()
+			if err = insertAudit(ctx, tx, auditEntry{Action: action, UserID: ev.UserID, SessionID: ev.SessionID, Meta: meta}); err == nil {
+				err = tx.Commit(ctx)
+			}
+		}
+		if err != nil {
+			a.log.Error("moderation.abuse.audit.fail", "session_id", ev.SessionID, "action", action, "err", err)
+		}
+	}()
+}
+
+var _ realtime.AbuseReporter = (*AbuseAuditor)(nil)
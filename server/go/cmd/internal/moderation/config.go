@@ -0,0 +1,35 @@
+package moderation
+
+import (
+	"os"
+	"strings"
+)
+
+// Config controls moderation.
+type Config struct {
+	// ModeratorUserIDs may moderate every conversation, in addition to each
+	// conversation's owners and admins.
+	ModeratorUserIDs []string
+}
+
+// LoadConfigFromEnv loads moderation config from environment variables.
+func LoadConfigFromEnv() Config {
+	return Config{
+		ModeratorUserIDs: envCSV("ARC_MODERATION_MODERATOR_USER_IDS"),
+	}
+}
+
+func envCSV(key string) []string {
+	raw := strings.TrimSpace(os.Getenv(key))
+	if raw == "" {
+		return nil
+	}
+	parts := strings.Split(raw, ",")
+	out := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if v := strings.TrimSpace(p); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
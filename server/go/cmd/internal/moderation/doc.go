@@ -0,0 +1,9 @@
+// Package moderation lets members report messages and moderators remove them.
+//
+// Members file reports with POST /conversations/{id}/messages/{msg_id}/report;
+// open reports form the conversation's moderation queue. Moderators (conversation
+// owners and admins, plus the users listed in ARC_MODERATION_MODERATOR_USER_IDS)
+// read the queue and remove messages with DELETE /conversations/{id}/messages/{msg_id}.
+// Removal tombstones the message like an author delete, broadcasts message.removed,
+// marks the message's reports actioned and writes an arc.audit_log record.
+package moderation
@@ -0,0 +1,397 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"arc/cmd/internal/auth/session"
+	"arc/cmd/internal/httpjson"
+	"arc/cmd/internal/realtime"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oklog/ulid/v2"
+)
+
+const (
+	reportPath  = "/conversations/{id}/messages/{msg_id}/report"
+	messagePath = "/conversations/{id}/messages/{msg_id}"
+	reportsPath = "/conversations/{id}/reports"
+
+	requestMaxBodyBytes = 4 << 10 // 4 KiB
+	maxNoteChars        = 1000
+
+	reportsDefaultLimit = 50
+	reportsMaxLimit     = 200
+)
+
+// reasons are the accepted report reasons; they match chk_message_reports_reason.
+var reasons = []string{"spam", "harassment", "hate", "violence", "sexual", "illegal", "other"}
+
+// TokenValidator validates bearer access tokens.
+type TokenValidator interface {
+	ValidateAccessToken(ctx context.Context, token string, now time.Time) (session.AccessClaims, error)
+}
+
+// MemberRoles looks up conversation roles for authorization.
+type MemberRoles interface {
+	GetMemberRole(ctx context.Context, userID, conversationID string) (string, error)
+}
+
+// MessageRemover tombstones messages for moderators; *realtime.WSGateway implements it.
+type MessageRemover interface {
+	RemoveMessage(ctx context.Context, in realtime.RemoveMessageInput) (realtime.MessageMutationResult, error)
+}
+
+// reportStore is the subset of PostgresStore the Handler needs.
+type reportStore interface {
+	createReport(ctx context.Context, r Report) (Report, bool, error)
+	listReports(ctx context.Context, conversationID, status, afterID string, limit int) ([]Report, error)
+	resolveReports(ctx context.Context, conversationID, serverMsgID, moderatorID string, now time.Time, audit auditEntry) (int64, error)
+}
+
+// Handler serves message reports, the moderation queue and moderator removal.
+type Handler struct {
+	log        *slog.Logger
+	store      reportStore
+	tokens     TokenValidator
+	members    MemberRoles
+	remover    MessageRemover
+	moderators map[string]bool
+	now        func() time.Time
+}
+
+type reportRequest struct {
+	Reason string `json:"reason"`
+	Note   string `json:"note,omitempty"`
+}
+
+type reportResponse struct {
+	ReportID       string     `json:"report_id"`
+	ConversationID string     `json:"conversation_id"`
+	ServerMsgID    string     `json:"server_msg_id"`
+	ReporterUserID string     `json:"reporter_user_id"`
+	Reason         string     `json:"reason"`
+	Note           string     `json:"note,omitempty"`
+	Status         string     `json:"status"`
+	CreatedAt      time.Time  `json:"created_at"`
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty"`
+	ResolvedBy     string     `json:"resolved_by,omitempty"`
+}
+
+type listReportsResponse struct {
+	Reports    []reportResponse `json:"reports"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+}
+
+type removeResponse struct {
+	ConversationID  string    `json:"conversation_id"`
+	ServerMsgID     string    `json:"server_msg_id"`
+	Seq             int64     `json:"seq"`
+	Version         int64     `json:"version"`
+	DeletedAt       time.Time `json:"deleted_at"`
+	ResolvedReports int64     `json:"resolved_reports"`
+}
+
+// NewHandler constructs a moderation Handler.
+func NewHandler(log *slog.Logger, pool *pgxpool.Pool, cfg Config, tokens TokenValidator, members MemberRoles, remover MessageRemover) (*Handler, error) {
+	if tokens == nil || members == nil || remover == nil {
+		return nil, errors.New("moderation: missing token validator, member store or message remover")
+	}
+	store, err := NewPostgresStore(pool)
+	if err != nil {
+		return nil, err
+	}
+	return newHandler(log, store, cfg, tokens, members, remover), nil
+}
+
+func newHandler(log *slog.Logger, store reportStore, cfg Config, tokens TokenValidator, members MemberRoles, remover MessageRemover) *Handler {
+	if log == nil {
+		log = slog.Default()
+	}
+	moderators := make(map[string]bool, len(cfg.ModeratorUserIDs))
+	for _, id := range cfg.ModeratorUserIDs {
+		moderators[id] = true
+	}
+	return &Handler{log: log, store: store, tokens: tokens, members: members, remover: remover, moderators: moderators, now: time.Now}
+}
+
+// Register wires moderation routes onto the provided mux.
+func (h *Handler) Register(mux *http.ServeMux) {
+	if h == nil || mux == nil {
+		return
+	}
+	mux.HandleFunc(reportPath, h.handleReport)
+	mux.HandleFunc(messagePath, h.handleRemove)
+	mux.HandleFunc(reportsPath, h.handleReports)
+}
+
+// handleReport serves POST /conversations/{id}/messages/{msg_id}/report for members.
+// Reporting the same message again returns the existing report with 200.
+func (h *Handler) handleReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		httpjson.WriteError(w, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+	claims, ok := h.requireAuth(w, r)
+	if !ok {
+		return
+	}
+	convID := strings.TrimSpace(r.PathValue("id"))
+	msgID := strings.TrimSpace(r.PathValue("msg_id"))
+	if _, ok := h.memberRole(w, r, claims.UserID, convID); !ok {
+		return
+	}
+
+	var req reportRequest
+	if err := httpjson.Decode(w, r, requestMaxBodyBytes, &req); err != nil {
+		httpjson.WriteError(w, http.StatusBadRequest, "invalid_json", "invalid JSON body")
+		return
+	}
+	reason := strings.ToLower(strings.TrimSpace(req.Reason))
+	if !slices.Contains(reasons, reason) {
+		httpjson.WriteError(w, http.StatusBadRequest, "invalid_request", "reason must be one of "+strings.Join(reasons, ", "))
+		return
+	}
+	note := strings.TrimSpace(req.Note)
+	if len([]rune(note)) > maxNoteChars {
+		httpjson.WriteError(w, http.StatusBadRequest, "invalid_request", "note must be at most 1000 characters")
+		return
+	}
+
+	report, created, err := h.store.createReport(r.Context(), Report{
+		ID:             ulid.Make().String(),
+		ConversationID: convID,
+		ServerMsgID:    msgID,
+		ReporterUserID: claims.UserID,
+		Reason:         reason,
+		Note:           note,
+		CreatedAt:      h.now().UTC(),
+	})
+	if errors.Is(err, errMessageNotFound) {
+		httpjson.WriteError(w, http.StatusNotFound, "message_not_found", "message not found")
+		return
+	}
+	if err != nil {
+		h.log.Error("moderation.report.fail", "conversation_id", convID, "err", err)
+		httpjson.WriteError(w, http.StatusInternalServerError, "internal_error", "internal error")
+		return
+	}
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+		h.log.Info("moderation.report", "conversation_id", convID, "server_msg_id", msgID, "reason", reason)
+	}
+	httpjson.Write(w, status, toReportResponse(report))
+}
+
+// handleReports serves GET /conversations/{id}/reports?status=&limit=&cursor= for
+// moderators. Reports are ordered oldest first; status defaults to open.
+func (h *Handler) handleReports(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		httpjson.WriteError(w, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+	claims, ok := h.requireAuth(w, r)
+	if !ok {
+		return
+	}
+	convID := strings.TrimSpace(r.PathValue("id"))
+	if !h.requireModerator(w, r, claims.UserID, convID) {
+		return
+	}
+
+	q := r.URL.Query()
+	status := strings.TrimSpace(q.Get("status"))
+	if status == "" {
+		status = StatusOpen
+	}
+	if status != StatusOpen && status != StatusActioned {
+		httpjson.WriteError(w, http.StatusBadRequest, "invalid_request", "status must be open or actioned")
+		return
+	}
+	limit := reportsDefaultLimit
+	if raw := strings.TrimSpace(q.Get("limit")); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			httpjson.WriteError(w, http.StatusBadRequest, "invalid_request", "invalid limit")
+			return
+		}
+		limit = min(n, reportsMaxLimit)
+	}
+
+	reports, err := h.store.listReports(r.Context(), convID, status, strings.TrimSpace(q.Get("cursor")), limit+1)
+	if err != nil {
+		h.log.Error("moderation.reports.list.fail", "conversation_id", convID, "err", err)
+		httpjson.WriteError(w, http.StatusInternalServerError, "internal_error", "internal error")
+		return
+	}
+	resp := listReportsResponse{Reports: make([]reportResponse, 0, min(len(reports), limit))}
+	if len(reports) > limit {
+		reports = reports[:limit]
+		resp.NextCursor = reports[limit-1].ID
+	}
+	for _, rep := range reports {
+		resp.Reports = append(resp.Reports, toReportResponse(rep))
+	}
+	httpjson.Write(w, http.StatusOK, resp)
+}
+
+// handleRemove serves DELETE /conversations/{id}/messages/{msg_id}?reason= for
+// moderators. Removing an already deleted message still resolves its reports.
+func (h *Handler) handleRemove(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		w.Header().Set("Allow", http.MethodDelete)
+		httpjson.WriteError(w, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+	claims, ok := h.requireAuth(w, r)
+	if !ok {
+		return
+	}
+	convID := strings.TrimSpace(r.PathValue("id"))
+	msgID := strings.TrimSpace(r.PathValue("msg_id"))
+	if !h.requireModerator(w, r, claims.UserID, convID) {
+		return
+	}
+	reason := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("reason")))
+	if reason != "" && !slices.Contains(reasons, reason) {
+		httpjson.WriteError(w, http.StatusBadRequest, "invalid_request", "reason must be one of "+strings.Join(reasons, ", "))
+		return
+	}
+
+	res, err := h.remover.RemoveMessage(r.Context(), realtime.RemoveMessageInput{
+		ConversationID:  convID,
+		ServerMsgID:     msgID,
+		ModeratorUserID: claims.UserID,
+		Reason:          reason,
+	})
+	if errors.Is(err, realtime.ErrMessageNotFound) {
+		httpjson.WriteError(w, http.StatusNotFound, "message_not_found", "message not found")
+		return
+	}
+	if err != nil {
+		h.log.Error("moderation.remove.fail", "conversation_id", convID, "server_msg_id", msgID, "err", err)
+		httpjson.WriteError(w, http.StatusInternalServerError, "internal_error", "internal error")
+		return
+	}
+
+	now := h.now().UTC()
+	resolved, err := h.store.resolveReports(r.Context(), convID, msgID, claims.UserID, now, auditEntry{
+		Action: "moderation.message.remove",
+		UserID: claims.UserID,
+		Meta: map[string]any{
+			"conversation_id": convID,
+			"server_msg_id":   msgID,
+			"seq":             res.Stored.Seq,
+			"reason":          reason,
+			"changed":         res.Changed,
+		},
+	})
+	if err != nil {
+		// The message is already tombstoned; report the failure so the moderator retries.
+		h.log.Error("moderation.resolve.fail", "conversation_id", convID, "server_msg_id", msgID, "err", err)
+		httpjson.WriteError(w, http.StatusInternalServerError, "internal_error", "internal error")
+		return
+	}
+	h.log.Info("moderation.remove", "conversation_id", convID, "server_msg_id", msgID, "user_id", claims.UserID, "resolved_reports", resolved)
+
+	deletedAt := now
+	if res.Stored.DeletedAt != nil {
+		deletedAt = res.Stored.DeletedAt.UTC()
+	}
+	httpjson.Write(w, http.StatusOK, removeResponse{
+		ConversationID:  convID,
+		ServerMsgID:     msgID,
+		Seq:             res.Stored.Seq,
+		Version:         res.Stored.Version,
+		DeletedAt:       deletedAt,
+		ResolvedReports: resolved,
+	})
+}
+
+// memberRole returns userID's role in convID. Non-members get 404 so private
+// conversations are not disclosed.
+func (h *Handler) memberRole(w http.ResponseWriter, r *http.Request, userID, convID string) (string, bool) {
+	if convID == "" {
+		httpjson.WriteError(w, http.StatusBadRequest, "invalid_request", "conversation id is required")
+		return "", false
+	}
+	role, err := h.members.GetMemberRole(r.Context(), userID, convID)
+	if errors.Is(err, realtime.ErrMembershipRequired) {
+		httpjson.WriteError(w, http.StatusNotFound, "conversation_not_found", "conversation not found")
+		return "", false
+	}
+	if err != nil {
+		h.log.Error("moderation.role.fail", "conversation_id", convID, "err", err)
+		httpjson.WriteError(w, http.StatusInternalServerError, "internal_error", "internal error")
+		return "", false
+	}
+	return role, true
+}
+
+// requireModerator allows configured moderators everywhere and owners and admins
+// in their conversations.
+func (h *Handler) requireModerator(w http.ResponseWriter, r *http.Request, userID, convID string) bool {
+	if convID == "" {
+		httpjson.WriteError(w, http.StatusBadRequest, "invalid_request", "conversation id is required")
+		return false
+	}
+	if h.moderators[userID] {
+		return true
+	}
+	role, ok := h.memberRole(w, r, userID, convID)
+	if !ok {
+		return false
+	}
+	if role != realtime.MemberRoleOwner && role != realtime.MemberRoleAdmin {
+		httpjson.WriteError(w, http.StatusForbidden, "forbidden", "moderator role required")
+		return false
+	}
+	return true
+}
+
+func toReportResponse(r Report) reportResponse {
+	return reportResponse{
+		ReportID:       r.ID,
+		ConversationID: r.ConversationID,
+		ServerMsgID:    r.ServerMsgID,
+		ReporterUserID: r.ReporterUserID,
+		Reason:         r.Reason,
+		Note:           r.Note,
+		Status:         r.Status,
+		CreatedAt:      r.CreatedAt.UTC(),
+		ResolvedAt:     r.ResolvedAt,
+		ResolvedBy:     r.ResolvedBy,
+	}
+}
+
+func (h *Handler) requireAuth(w http.ResponseWriter, r *http.Request) (session.AccessClaims, bool) {
+	token := bearerToken(r)
+	if token == "" {
+		httpjson.WriteError(w, http.StatusUnauthorized, "unauthorized", "missing bearer token")
+		return session.AccessClaims{}, false
+	}
+	claims, err := h.tokens.ValidateAccessToken(r.Context(), token, h.now().UTC())
+	if err != nil || strings.TrimSpace(claims.UserID) == "" {
+		httpjson.WriteError(w, http.StatusUnauthorized, "unauthorized", "invalid token")
+		return session.AccessClaims{}, false
+	}
+	return claims, true
+}
+
+func bearerToken(r *http.Request) string {
+	raw := strings.TrimSpace(r.Header.Get("Authorization"))
+	parts := strings.SplitN(raw, " ", 2)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(parts[1])
+}
@@ -0,0 +1,204 @@
+package moderation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"arc/cmd/internal/auth/session"
+	"arc/cmd/internal/httpjson/httpjsontest"
+	"arc/cmd/internal/realtime"
+)
+
+type fakeTokens struct{}
+
+func (fakeTokens) ValidateAccessToken(_ context.Context, token string, _ time.Time) (session.AccessClaims, error) {
+	if token == "" {
+		return session.AccessClaims{}, errors.New("invalid")
+	}
+	return session.AccessClaims{UserID: token}, nil
+}
+
+type fakeRoles map[string]string
+
+func (f fakeRoles) GetMemberRole(_ context.Context, userID, conversationID string) (string, error) {
+	role, ok := f[userID+" "+conversationID]
+	if !ok {
+		return "", realtime.ErrMembershipRequired
+	}
+	return role, nil
+}
+
+type fakeReportStore struct {
+	messages map[string]bool
+	reports  []Report
+	audits   []auditEntry
+}
+
+func (s *fakeReportStore) createReport(_ context.Context, r Report) (Report, bool, error) {
+	for _, existing := range s.reports {
+		if existing.ServerMsgID == r.ServerMsgID && existing.ReporterUserID == r.ReporterUserID {
+			return existing, false, nil
+		}
+	}
+	if !s.messages[r.ConversationID+" "+r.ServerMsgID] {
+		return Report{}, false, errMessageNotFound
+	}
+	r.Status = StatusOpen
+	s.reports = append(s.reports, r)
+	return r, true, nil
+}
+
+func (s *fakeReportStore) listReports(_ context.Context, conversationID, status, afterID string, limit int) ([]Report, error) {
+	var out []Report
+	skipping := afterID != ""
+	for _, r := range s.reports {
+		if skipping {
+			skipping = r.ID != afterID
+			continue
+		}
+		if r.ConversationID == conversationID && r.Status == status && len(out) < limit {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+func (s *fakeReportStore) resolveReports(_ context.Context, conversationID, serverMsgID, moderatorID string, now time.Time, audit auditEntry) (int64, error) {
+	var n int64
+	for i := range s.reports {
+		r := &s.reports[i]
+		if r.ConversationID == conversationID && r.ServerMsgID == serverMsgID && r.Status == StatusOpen {
+			r.Status, r.ResolvedAt, r.ResolvedBy = StatusActioned, &now, moderatorID
+			n++
+		}
+	}
+	s.audits = append(s.audits, audit)
+	return n, nil
+}
+
+type fakeRemover struct {
+	got []realtime.RemoveMessageInput
+}
+
+func (f *fakeRemover) RemoveMessage(_ context.Context, in realtime.RemoveMessageInput) (realtime.MessageMutationResult, error) {
+	if in.ServerMsgID != "m1" {
+		return realtime.MessageMutationResult{}, realtime.ErrMessageNotFound
+	}
+	f.got = append(f.got, in)
+	now := time.Now().UTC()
+	return realtime.MessageMutationResult{Changed: true, Stored: realtime.StoredMessage{
+		ConversationID: in.ConversationID, ServerMsgID: in.ServerMsgID, Seq: 4, Version: 2, DeletedAt: &now,
+	}}, nil
+}
+
+func newTestHandler() (*fakeReportStore, *fakeRemover, *http.ServeMux) {
+	store := &fakeReportStore{messages: map[string]bool{"c1 m1": true, "c1 m2": true}}
+	remover := &fakeRemover{}
+	roles := fakeRoles{
+		"alice c1": realtime.MemberRoleMember,
+		"bob c1":   realtime.MemberRoleMember,
+		"owner c1": realtime.MemberRoleOwner,
+	}
+	h := newHandler(nil, store, Config{ModeratorUserIDs: []string{"staff"}}, fakeTokens{}, roles, remover)
+	mux := http.NewServeMux()
+	h.Register(mux)
+	return store, remover, mux
+}
+
+func TestHandlerReport(t *testing.T) {
+	t.Parallel()
+
+	store, _, mux := newTestHandler()
+
+	if rec := httpjsontest.Do(mux, http.MethodPost, "/conversations/c1/messages/m1/report", "", `{"reason":"spam"}`); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("anonymous: status=%d", rec.Code)
+	}
+	if rec := httpjsontest.Do(mux, http.MethodPost, "/conversations/c1/messages/m1/report", "mallory", `{"reason":"spam"}`); rec.Code != http.StatusNotFound {
+		t.Fatalf("non-member: status=%d", rec.Code)
+	}
+	if rec := httpjsontest.Do(mux, http.MethodPost, "/conversations/c1/messages/m1/report", "alice", `{"reason":"boring"}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("bad reason: status=%d", rec.Code)
+	}
+	if rec := httpjsontest.Do(mux, http.MethodPost, "/conversations/c1/messages/m9/report", "alice", `{"reason":"spam"}`); rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown message: status=%d", rec.Code)
+	}
+
+	rec := httpjsontest.Do(mux, http.MethodPost, "/conversations/c1/messages/m1/report", "alice", `{"reason":"Spam","note":" ads "}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("report: status=%d body=%s", rec.Code, rec.Body.String())
+	}
+	var got reportResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.Reason != "spam" || got.Note != "ads" || got.Status != StatusOpen || got.ReporterUserID != "alice" {
+		t.Fatalf("unexpected report %+v", got)
+	}
+
+	if rec := httpjsontest.Do(mux, http.MethodPost, "/conversations/c1/messages/m1/report", "alice", `{"reason":"other"}`); rec.Code != http.StatusOK {
+		t.Fatalf("repeat report: status=%d", rec.Code)
+	}
+	if len(store.reports) != 1 {
+		t.Fatalf("expected one stored report, got %d", len(store.reports))
+	}
+}
+
+func TestHandlerQueueAndRemove(t *testing.T) {
+	t.Parallel()
+
+	store, remover, mux := newTestHandler()
+	for _, reporter := range []string{"alice", "bob"} {
+		if rec := httpjsontest.Do(mux, http.MethodPost, "/conversations/c1/messages/m1/report", reporter, `{"reason":"harassment"}`); rec.Code != http.StatusCreated {
+			t.Fatalf("report: status=%d", rec.Code)
+		}
+	}
+
+	if rec := httpjsontest.Do(mux, http.MethodGet, "/conversations/c1/reports", "alice", ""); rec.Code != http.StatusForbidden {
+		t.Fatalf("member queue: status=%d", rec.Code)
+	}
+	rec := httpjsontest.Do(mux, http.MethodGet, "/conversations/c1/reports?limit=1", "owner", "")
+	var page listReportsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("queue: status=%d err=%v", rec.Code, err)
+	}
+	if len(page.Reports) != 1 || page.NextCursor == "" {
+		t.Fatalf("unexpected first page %+v", page)
+	}
+
+	if rec := httpjsontest.Do(mux, http.MethodDelete, "/conversations/c1/messages/m1", "alice", ""); rec.Code != http.StatusForbidden {
+		t.Fatalf("member remove: status=%d", rec.Code)
+	}
+	if rec := httpjsontest.Do(mux, http.MethodDelete, "/conversations/c1/messages/m1?reason=nope", "owner", ""); rec.Code != http.StatusBadRequest {
+		t.Fatalf("bad reason: status=%d", rec.Code)
+	}
+	if rec := httpjsontest.Do(mux, http.MethodDelete, "/conversations/c1/messages/m9", "staff", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown message: status=%d", rec.Code)
+	}
+
+	rec = httpjsontest.Do(mux, http.MethodDelete, "/conversations/c1/messages/m1?reason=harassment", "staff", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("remove: status=%d body=%s", rec.Code, rec.Body.String())
+	}
+	var removed removeResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &removed); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if removed.ResolvedReports != 2 || removed.Seq != 4 {
+		t.Fatalf("unexpected removal %+v", removed)
+	}
+	if len(remover.got) != 1 || remover.got[0].ModeratorUserID != "staff" || remover.got[0].Reason != "harassment" {
+		t.Fatalf("unexpected remover calls %+v", remover.got)
+	}
+	if len(store.audits) != 1 || store.audits[0].Action != "moderation.message.remove" || store.audits[0].UserID != "staff" {
+		t.Fatalf("unexpected audit %+v", store.audits)
+	}
+
+	rec = httpjsontest.Do(mux, http.MethodGet, "/conversations/c1/reports", "owner", "")
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil || len(page.Reports) != 0 {
+		t.Fatalf("expected empty open queue, got %+v err=%v", page, err)
+	}
+}
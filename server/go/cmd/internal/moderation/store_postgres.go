@@ -0,0 +1,196 @@
+package moderation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"arc/cmd/internal/dbtrace"
+	"arc/cmd/internal/redact"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Report statuses.
+const (
+	StatusOpen     = "open"
+	StatusActioned = "actioned"
+)
+
+var errMessageNotFound = errors.New("message not found")
+
+// Report is a member's report of a message.
+type Report struct {
+	ID             string
+	ConversationID string
+	ServerMsgID    string
+	ReporterUserID string
+	Reason         string
+	Note           string
+	Status         string
+	CreatedAt      time.Time
+	ResolvedAt     *time.Time
+	ResolvedBy     string
+}
+
+// auditEntry is written to arc.audit_log with the acting user and, if known, session.
+type auditEntry struct {
+	Action    string
+	UserID    string
+	SessionID string
+	Meta      map[string]any
+}
+
+// PostgresStore persists reports in arc.message_reports.
+type PostgresStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresStore constructs a PostgresStore.
+func NewPostgresStore(pool *pgxpool.Pool) (*PostgresStore, error) {
+	if pool == nil {
+		return nil, errors.New("moderation: nil db pool")
+	}
+	return &PostgresStore{pool: pool}, nil
+}
+
+const reportColumns = `id, conversation_id, server_msg_id, reporter_user_id, reason, COALESCE(note, ''),
+	status, created_at, resolved_at, COALESCE(resolved_by, '')`
+
+func scanReport(row pgx.Row) (Report, error) {
+	var r Report
+	err := row.Scan(&r.ID, &r.ConversationID, &r.ServerMsgID, &r.ReporterUserID, &r.Reason, &r.Note,
+		&r.Status, &r.CreatedAt, &r.ResolvedAt, &r.ResolvedBy)
+	return r, err
+}
+
+// createReport stores r for a message of r.ConversationID that is not a tombstone.
+// A repeated report by the same member returns the existing one with created false.
+func (s *PostgresStore) createReport(ctx context.Context, r Report) (Report, bool, error) {
+	ctx = dbtrace.WithOp(ctx, "moderation.create_report")
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return Report{}, false, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var note *string
+	if r.Note != "" {
+		note = &r.Note
+	}
+	out, err := scanReport(tx.QueryRow(ctx, `
+		INSERT INTO arc.message_reports (id, conversation_id, server_msg_id, reporter_user_id, reason, note, created_at)
+		SELECT $1, m.conversation_id, m.server_msg_id, $4, $5, $6, $7
+		FROM arc.messages m
+		WHERE m.conversation_id = $2 AND m.server_msg_id = $3 AND m.deleted_at IS NULL
+		ON CONFLICT (conversation_id, server_msg_id, reporter_user_id) DO NOTHING
+		RETURNING `+reportColumns,
+		r.ID, r.ConversationID, r.ServerMsgID, r.ReporterUserID, r.Reason, note, r.CreatedAt,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		existing, err := scanReport(tx.QueryRow(ctx, `
+			SELECT `+reportColumns+`
+			FROM arc.message_reports
+			WHERE conversation_id = $1 AND server_msg_id = $2 AND reporter_user_id = $3
+		`, r.ConversationID, r.ServerMsgID, r.ReporterUserID))
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Report{}, false, errMessageNotFound
+		}
+		return existing, false, err
+	}
+	if err != nil {
+		return Report{}, false, err
+	}
+	if err := insertAudit(ctx, tx, auditEntry{
+		Action: "moderation.message.report",
+		UserID: r.ReporterUserID,
+		Meta: map[string]any{
+			"report_id":       out.ID,
+			"conversation_id": out.ConversationID,
+			"server_msg_id":   out.ServerMsgID,
+			"reason":          out.Reason,
+		},
+	}); err != nil {
+		return Report{}, false, err
+	}
+	return out, true, tx.Commit(ctx)
+}
+
+// listReports returns up to limit reports of conversationID with status, oldest
+// first, starting after the report afterID ("" for the first page).
+func (s *PostgresStore) listReports(ctx context.Context, conversationID, status, afterID string, limit int) ([]Report, error) {
+	ctx = dbtrace.WithOp(ctx, "moderation.list_reports")
+	rows, err := s.pool.Query(ctx, `
+		SELECT `+reportColumns+`
+		FROM arc.message_reports
+		WHERE conversation_id = $1 AND status = $2
+		  AND ($3 = '' OR (created_at, id) > (
+		      SELECT created_at, id FROM arc.message_reports WHERE id = $3
+		  ))
+		ORDER BY created_at, id
+		LIMIT $4
+	`, conversationID, status, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Report
+	for rows.Next() {
+		r, err := scanReport(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// resolveReports marks the open reports of a removed message actioned and records
+// the removal in the audit log. It returns the number of reports resolved.
+func (s *PostgresStore) resolveReports(ctx context.Context, conversationID, serverMsgID, moderatorID string, now time.Time, audit auditEntry) (int64, error) {
+	ctx = dbtrace.WithOp(ctx, "moderation.resolve_reports")
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	ct, err := tx.Exec(ctx, `
+		UPDATE arc.message_reports
+		SET status = 'actioned', resolved_at = $3, resolved_by = $4
+		WHERE conversation_id = $1 AND server_msg_id = $2 AND status = 'open'
+	`, conversationID, serverMsgID, now, moderatorID)
+	if err != nil {
+		return 0, err
+	}
+	if audit.Meta == nil {
+		audit.Meta = map[string]any{}
+	}
+	audit.Meta["resolved_reports"] = ct.RowsAffected()
+	if err := insertAudit(ctx, tx, audit); err != nil {
+		return 0, err
+	}
+	return ct.RowsAffected(), tx.Commit(ctx)
+}
+
+func insertAudit(ctx context.Context, tx pgx.Tx, e auditEntry) error {
+	var metaVal *string
+	if len(e.Meta) > 0 {
+		if b, err := json.Marshal(redact.Meta(e.Meta)); err == nil {
+			s := string(b)
+			metaVal = &s
+		}
+	}
+	var sessionID *string
+	if e.SessionID != "" {
+		sessionID = &e.SessionID
+	}
+	_, err := tx.Exec(ctx, `
+		INSERT INTO arc.audit_log (user_id, session_id, action, created_at, meta)
+		VALUES ($1, $2, $3, now(), $4::jsonb)
+	`, e.UserID, sessionID, e.Action, metaVal)
+	return err
+}
@@ -0,0 +1,7 @@
+// Package pgutil holds transaction helpers shared by the Postgres stores.
+//
+// WithTx runs a function in a read-committed transaction and retries it when
+// Postgres aborts the transaction with a serialization failure (40001) or a
+// deadlock (40P01). Both are transient under contention: the transaction made
+// no changes and the same work usually succeeds when run again.
+package pgutil
@@ -0,0 +1,6 @@
+package pgutil
+
+import "arc/cmd/internal/metrics"
+
+var txRetries = metrics.NewCounterVec("arc_db_tx_retries_total",
+	"Transactions retried by WithTx, by SQLSTATE (40001 serialization failure, 40P01 deadlock).", "code")
@@ -0,0 +1,106 @@
+package pgutil
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	// MaxTxAttempts bounds how often WithTx runs fn, counting the first run.
+	MaxTxAttempts = 4
+
+	txBackoffBase = 10 * time.Millisecond
+	txBackoffMax  = 200 * time.Millisecond
+)
+
+// SQLSTATE codes of aborted transactions that are safe to retry.
+const (
+	codeSerializationFailure = "40001"
+	codeDeadlockDetected     = "40P01"
+)
+
+// Beginner starts transactions; *pgxpool.Pool and *pgx.Conn implement it.
+type Beginner interface {
+	BeginTx(ctx context.Context, opts pgx.TxOptions) (pgx.Tx, error)
+}
+
+// WithTx runs fn in a read-committed, read-write transaction and commits it.
+// If the transaction fails with a serialization failure or deadlock, it is
+// rolled back and fn runs again in a new one, up to MaxTxAttempts runs with
+// jittered exponential backoff between them. fn may therefore run more than
+// once: it must set its results on every run and must not have side effects
+// outside tx. Any other error, including one returned by fn, is returned as is.
+func WithTx(ctx context.Context, db Beginner, fn func(tx pgx.Tx) error) error {
+	var err error
+	for attempt := range MaxTxAttempts {
+		if attempt > 0 {
+			if err := sleep(ctx, backoff(attempt)); err != nil {
+				return err
+			}
+		}
+		err = runTx(ctx, db, fn)
+		code, retry := retryable(err)
+		if !retry {
+			return err
+		}
+		txRetries.With(code).Inc()
+	}
+	return err
+}
+
+func runTx(ctx context.Context, db Beginner, fn func(tx pgx.Tx) error) error {
+	tx, err := db.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.ReadCommitted,
+		AccessMode: pgx.ReadWrite,
+	})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// IsRetryable reports whether err aborted a transaction that may be retried.
+func IsRetryable(err error) bool {
+	_, ok := retryable(err)
+	return ok
+}
+
+func retryable(err error) (string, bool) {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return "", false
+	}
+	switch pgErr.Code {
+	case codeSerializationFailure, codeDeadlockDetected:
+		return pgErr.Code, true
+	}
+	return "", false
+}
+
+// backoff is the jittered delay before retry attempt (1-based): half the
+// exponential step plus a random share of the other half.
+func backoff(attempt int) time.Duration {
+	d := min(txBackoffBase<<(attempt-1), txBackoffMax)
+	return d/2 + rand.N(d/2+1)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,110 @@
+package pgutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type fakeTx struct {
+	pgx.Tx
+	db *fakeDB
+}
+
+func (t *fakeTx) Commit(context.Context) error {
+	t.db.commits++
+	if len(t.db.commitErrs) > 0 {
+		err := t.db.commitErrs[0]
+		t.db.commitErrs = t.db.commitErrs[1:]
+		return err
+	}
+	return nil
+}
+
+func (t *fakeTx) Rollback(context.Context) error {
+	t.db.rollbacks++
+	return nil
+}
+
+type fakeDB struct {
+	begins, commits, rollbacks int
+	commitErrs                 []error
+}
+
+func (db *fakeDB) BeginTx(context.Context, pgx.TxOptions) (pgx.Tx, error) {
+	db.begins++
+	return &fakeTx{db: db}, nil
+}
+
+func pgErr(code string) error {
+	return fmt.Errorf("exec: %w", &pgconn.PgError{Code: code})
+}
+
+func TestWithTx_RetriesTransientErrors(t *testing.T) {
+	db := &fakeDB{}
+	before := txRetries.With(codeDeadlockDetected).Value()
+	runs := 0
+	err := WithTx(context.Background(), db, func(pgx.Tx) error {
+		runs++
+		if runs == 1 {
+			return pgErr(codeDeadlockDetected)
+		}
+		return nil
+	})
+	if err != nil || runs != 2 || db.commits != 1 {
+		t.Fatalf("err=%v runs=%d commits=%d; want nil, 2, 1", err, runs, db.commits)
+	}
+	if got := txRetries.With(codeDeadlockDetected).Value(); got != before+1 {
+		t.Fatalf("retries = %v, want %v", got, before+1)
+	}
+
+	// A serialization failure reported at commit is retried as well.
+	db = &fakeDB{commitErrs: []error{pgErr(codeSerializationFailure)}}
+	if err := WithTx(context.Background(), db, func(pgx.Tx) error { return nil }); err != nil || db.begins != 2 {
+		t.Fatalf("err=%v begins=%d; want nil, 2", err, db.begins)
+	}
+}
+
+func TestWithTx_StopsOnOtherErrorsAndAfterMaxAttempts(t *testing.T) {
+	db := &fakeDB{}
+	errBoom := errors.New("boom")
+	if err := WithTx(context.Background(), db, func(pgx.Tx) error { return errBoom }); !errors.Is(err, errBoom) || db.begins != 1 {
+		t.Fatalf("err=%v begins=%d; want boom, 1", err, db.begins)
+	}
+	if db.rollbacks != 1 || db.commits != 0 {
+		t.Fatalf("rollbacks=%d commits=%d; want 1, 0", db.rollbacks, db.commits)
+	}
+
+	db = &fakeDB{}
+	err := WithTx(context.Background(), db, func(pgx.Tx) error { return pgErr(codeSerializationFailure) })
+	if !IsRetryable(err) || db.begins != MaxTxAttempts {
+		t.Fatalf("err=%v begins=%d; want retryable, %d", err, db.begins, MaxTxAttempts)
+	}
+}
+
+func TestWithTx_BackoffHonorsContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	db := &fakeDB{}
+	err := WithTx(ctx, db, func(pgx.Tx) error {
+		cancel()
+		return pgErr(codeDeadlockDetected)
+	})
+	if !errors.Is(err, context.Canceled) || db.begins != 1 {
+		t.Fatalf("err=%v begins=%d; want canceled, 1", err, db.begins)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for attempt := 1; attempt <= 8; attempt++ {
+		step := min(txBackoffBase<<(attempt-1), txBackoffMax)
+		for range 20 {
+			if d := backoff(attempt); d < step/2 || d > step {
+				t.Fatalf("backoff(%d) = %v, want within [%v, %v]", attempt, d, step/2, step)
+			}
+		}
+	}
+}
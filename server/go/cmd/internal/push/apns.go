@@ -0,0 +1,191 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// apnsTokenRefresh re-signs the provider token well inside APNs' one hour limit
+// while staying above its 20 minute minimum refresh interval.
+const apnsTokenRefresh = 40 * time.Minute
+
+// maxAPNsCollapseIDBytes is the apns-collapse-id header limit.
+const maxAPNsCollapseIDBytes = 64
+
+// APNsSender sends alerts through APNs with token-based authentication.
+type APNsSender struct {
+	client   *http.Client
+	endpoint string
+	topic    string
+	keyID    string
+	teamID   string
+	key      *ecdsa.PrivateKey
+	now      func() time.Time
+
+	mu       sync.Mutex
+	jwt      string
+	jwtIssue time.Time
+}
+
+type apnsAlert struct {
+	Title string `json:"title,omitempty"`
+	Body  string `json:"body"`
+}
+
+type apnsAPS struct {
+	Alert    apnsAlert `json:"alert"`
+	Sound    string    `json:"sound,omitempty"`
+	ThreadID string    `json:"thread-id,omitempty"`
+}
+
+type apnsErrorResponse struct {
+	Reason string `json:"reason"`
+}
+
+// NewAPNsSender loads the .p8 key from cfg.KeyFile. client must speak HTTP/2;
+// nil uses http.DefaultClient, which negotiates it over TLS.
+func NewAPNsSender(cfg APNsConfig, client *http.Client) (*APNsSender, error) {
+	if cfg.KeyID == "" || cfg.TeamID == "" || cfg.Topic == "" {
+		return nil, errors.New("push: apns requires key id, team id and topic")
+	}
+	raw, err := os.ReadFile(cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("push: read apns key: %w", err)
+	}
+	key, err := parseAPNsKey(raw)
+	if err != nil {
+		return nil, err
+	}
+	return newAPNsSender(cfg, key, client), nil
+}
+
+func newAPNsSender(cfg APNsConfig, key *ecdsa.PrivateKey, client *http.Client) *APNsSender {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	endpoint := strings.TrimRight(cfg.Endpoint, "/")
+	if endpoint == "" {
+		endpoint = APNsProductionEndpoint
+	}
+	return &APNsSender{
+		client:   client,
+		endpoint: endpoint,
+		topic:    cfg.Topic,
+		keyID:    cfg.KeyID,
+		teamID:   cfg.TeamID,
+		key:      key,
+		now:      time.Now,
+	}
+}
+
+func parseAPNsKey(raw []byte) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, errors.New("push: apns key is not PEM encoded")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("push: parse apns key: %w", err)
+	}
+	key, ok := parsed.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("push: apns key must be an ECDSA P-256 key")
+	}
+	return key, nil
+}
+
+// Send implements Sender.
+func (s *APNsSender) Send(ctx context.Context, token string, n Notification) error {
+	auth, err := s.providerToken()
+	if err != nil {
+		return err
+	}
+
+	payload := map[string]any{
+		"aps": apnsAPS{
+			Alert:    apnsAlert{Title: n.Title, Body: n.Body},
+			Sound:    "default",
+			ThreadID: n.CollapseKey,
+		},
+	}
+	for k, v := range n.Data {
+		if k != "aps" {
+			payload[k] = v
+		}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint+"/3/device/"+url.PathEscape(token), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "bearer "+auth)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("apns-topic", s.topic)
+	req.Header.Set("apns-push-type", "alert")
+	req.Header.Set("apns-priority", "10")
+	if n.CollapseKey != "" && len(n.CollapseKey) <= maxAPNsCollapseIDBytes {
+		req.Header.Set("apns-collapse-id", n.CollapseKey)
+	}
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("push: apns request: %w", err)
+	}
+	defer func() { _ = res.Body.Close() }()
+	if res.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	var apnsErr apnsErrorResponse
+	_ = json.Unmarshal(readErrorBody(res.Body), &apnsErr)
+	switch {
+	case res.StatusCode == http.StatusGone,
+		apnsErr.Reason == "BadDeviceToken",
+		apnsErr.Reason == "DeviceTokenNotForTopic",
+		apnsErr.Reason == "Unregistered":
+		return ErrInvalidToken
+	case res.StatusCode == http.StatusForbidden && apnsErr.Reason == "ExpiredProviderToken":
+		s.mu.Lock()
+		s.jwt = ""
+		s.mu.Unlock()
+	}
+	return fmt.Errorf("push: apns status %d: %s", res.StatusCode, apnsErr.Reason)
+}
+
+// providerToken returns the cached ES256 provider token, re-signing it when stale.
+func (s *APNsSender) providerToken() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if s.jwt != "" && now.Sub(s.jwtIssue) < apnsTokenRefresh {
+		return s.jwt, nil
+	}
+	jwt, err := signJWT(s.key, map[string]string{"kid": s.keyID}, map[string]any{
+		"iss": s.teamID,
+		"iat": now.Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+	s.jwt, s.jwtIssue = jwt, now
+	return jwt, nil
+}
+
+var _ Sender = (*APNsSender)(nil)
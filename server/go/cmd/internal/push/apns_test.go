@@ -0,0 +1,135 @@
+package push
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newTestAPNsKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	return key
+}
+
+// verifyES256 checks a compact JWS against key's public half.
+func verifyES256(t *testing.T, jwt string, key *ecdsa.PrivateKey) map[string]any {
+	t.Helper()
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		t.Fatalf("malformed jwt %q", jwt)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || len(sig) != 64 {
+		t.Fatalf("bad signature encoding: %v (len=%d)", err, len(sig))
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+	if !ecdsa.Verify(&key.PublicKey, digest[:], r, s) {
+		t.Fatalf("signature does not verify")
+	}
+	raw, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	var claims map[string]any
+	if err := json.Unmarshal(raw, &claims); err != nil {
+		t.Fatalf("claims: %v", err)
+	}
+	return claims
+}
+
+func TestAPNsSender_Send(t *testing.T) {
+	t.Parallel()
+
+	key := newTestAPNsKey(t)
+	var gotPath string
+	var gotHeader http.Header
+	var gotBody map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotHeader = r.URL.Path, r.Header.Clone()
+		b, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(b, &gotBody)
+	}))
+	t.Cleanup(srv.Close)
+
+	s := newAPNsSender(APNsConfig{KeyID: "KID", TeamID: "TEAM", Topic: "app.arc", Endpoint: srv.URL}, key, srv.Client())
+	err := s.Send(context.Background(), "abc123", Notification{
+		Title:       "New message",
+		Body:        "hi",
+		CollapseKey: "c1",
+		Data:        map[string]string{"conversation_id": "c1"},
+	})
+	if err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	if gotPath != "/3/device/abc123" {
+		t.Fatalf("unexpected path %q", gotPath)
+	}
+	for h, want := range map[string]string{
+		"apns-topic":       "app.arc",
+		"apns-push-type":   "alert",
+		"apns-collapse-id": "c1",
+	} {
+		if got := gotHeader.Get(h); got != want {
+			t.Fatalf("%s: got %q want %q", h, got, want)
+		}
+	}
+	claims := verifyES256(t, strings.TrimPrefix(gotHeader.Get("Authorization"), "bearer "), key)
+	if claims["iss"] != "TEAM" {
+		t.Fatalf("unexpected claims %+v", claims)
+	}
+	aps, _ := gotBody["aps"].(map[string]any)
+	if aps["thread-id"] != "c1" || gotBody["conversation_id"] != "c1" {
+		t.Fatalf("unexpected payload %+v", gotBody)
+	}
+
+	// The provider token is reused between sends.
+	first := gotHeader.Get("Authorization")
+	_ = s.Send(context.Background(), "abc123", Notification{Body: "again"})
+	if gotHeader.Get("Authorization") != first {
+		t.Fatalf("expected cached provider token")
+	}
+}
+
+func TestAPNsSender_InvalidToken(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		status  int
+		reason  string
+		invalid bool
+	}{
+		{http.StatusGone, "Unregistered", true},
+		{http.StatusBadRequest, "BadDeviceToken", true},
+		{http.StatusBadRequest, "PayloadTooLarge", false},
+		{http.StatusTooManyRequests, "TooManyRequests", false},
+	}
+	for _, tc := range cases {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(tc.status)
+			_, _ = w.Write([]byte(`{"reason":"` + tc.reason + `"}`))
+		}))
+		s := newAPNsSender(APNsConfig{KeyID: "KID", TeamID: "TEAM", Topic: "app.arc", Endpoint: srv.URL}, newTestAPNsKey(t), srv.Client())
+		err := s.Send(context.Background(), "tok", Notification{Body: "x"})
+		srv.Close()
+		if err == nil {
+			t.Fatalf("%s: expected error", tc.reason)
+		}
+		if got := errors.Is(err, ErrInvalidToken); got != tc.invalid {
+			t.Fatalf("%s: invalid=%v, want %v (%v)", tc.reason, got, tc.invalid, err)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package push
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Device token platforms.
+const (
+	PlatformAPNs = "apns"
+	PlatformFCM  = "fcm"
+)
+
+// Provider endpoints.
+const (
+	APNsProductionEndpoint = "https://api.push.apple.com"
+	APNsSandboxEndpoint    = "https://api.sandbox.push.apple.com"
+	FCMEndpoint            = "https://fcm.googleapis.com"
+)
+
+// Config controls push delivery.
+type Config struct {
+	APNs APNsConfig
+	FCM  FCMConfig
+
+	// QueueSize bounds messages waiting for dispatch; further messages are dropped.
+	QueueSize int
+	// Workers is the number of concurrent dispatch loops.
+	Workers int
+	// SendTimeout bounds a single provider request.
+	SendTimeout time.Duration
+	// ShowPreview includes message text in notifications. When false a generic
+	// body is sent so message content never leaves through the provider.
+	ShowPreview bool
+}
+
+// APNsConfig configures token-based (.p8) APNs authentication.
+type APNsConfig struct {
+	// KeyFile is the PKCS#8 .p8 signing key. Empty disables APNs.
+	KeyFile string
+	KeyID   string
+	TeamID  string
+	// Topic is the app bundle ID.
+	Topic string
+	// Endpoint is APNsProductionEndpoint or APNsSandboxEndpoint.
+	Endpoint string
+}
+
+// FCMConfig configures the FCM HTTP v1 API.
+type FCMConfig struct {
+	// CredentialsFile is a Google service account JSON key. Empty disables FCM.
+	CredentialsFile string
+	// ProjectID overrides the service account's project_id.
+	ProjectID string
+	Endpoint  string
+}
+
+// LoadConfigFromEnv loads push config from environment variables with safe defaults.
+func LoadConfigFromEnv() Config {
+	apnsEndpoint := APNsProductionEndpoint
+	if envBool("ARC_PUSH_APNS_SANDBOX", false) {
+		apnsEndpoint = APNsSandboxEndpoint
+	}
+	return Config{
+		APNs: APNsConfig{
+			KeyFile:  strings.TrimSpace(os.Getenv("ARC_PUSH_APNS_KEY_FILE")),
+			KeyID:    strings.TrimSpace(os.Getenv("ARC_PUSH_APNS_KEY_ID")),
+			TeamID:   strings.TrimSpace(os.Getenv("ARC_PUSH_APNS_TEAM_ID")),
+			Topic:    strings.TrimSpace(os.Getenv("ARC_PUSH_APNS_TOPIC")),
+			Endpoint: envString("ARC_PUSH_APNS_ENDPOINT", apnsEndpoint),
+		},
+		FCM: FCMConfig{
+			CredentialsFile: strings.TrimSpace(os.Getenv("ARC_PUSH_FCM_CREDENTIALS_FILE")),
+			ProjectID:       strings.TrimSpace(os.Getenv("ARC_PUSH_FCM_PROJECT_ID")),
+			Endpoint:        envString("ARC_PUSH_FCM_ENDPOINT", FCMEndpoint),
+		},
+		QueueSize:   envInt("ARC_PUSH_QUEUE_SIZE", 1024),
+		Workers:     envInt("ARC_PUSH_WORKERS", 4),
+		SendTimeout: envDuration("ARC_PUSH_SEND_TIMEOUT", 10*time.Second),
+		ShowPreview: envBool("ARC_PUSH_SHOW_PREVIEW", false),
+	}
+}
+
+// Enabled reports whether any push provider is configured.
+func (c Config) Enabled() bool {
+	return c.APNs.KeyFile != "" || c.FCM.CredentialsFile != ""
+}
+
+func envString(key, def string) string {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return def
+	}
+	return v
+}
+
+func envInt(key string, def int) int {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		return def
+	}
+	return n
+}
+
+func envDuration(key string, def time.Duration) time.Duration {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		return def
+	}
+	return d
+}
+
+func envBool(key string, def bool) bool {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return def
+	}
+	return b
+}
@@ -0,0 +1,231 @@
+package push
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"arc/cmd/internal/realtime"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	notificationTitle = "New message"
+	genericBody       = "You have a new message"
+	// maxPreviewRunes truncates message text in previews.
+	maxPreviewRunes = 140
+)
+
+// dispatchStore is the subset of PostgresStore the Dispatcher needs.
+type dispatchStore interface {
+	recipients(ctx context.Context, conversationID, senderUserID string, now time.Time) ([]recipient, error)
+	tokensForUsers(ctx context.Context, userIDs []string) ([]DeviceToken, error)
+	deleteToken(ctx context.Context, platform, token string) error
+}
+
+// Dispatcher queues stored messages and pushes them to members without a
+// connected session. It implements realtime.OfflineNotifier.
+//
+// Connectivity is checked against this node only; with a cross-node broker a
+// member connected to another node may still receive a push.
+type Dispatcher struct {
+	log       *slog.Logger
+	store     dispatchStore
+	senders   map[string]Sender
+	connected func(userID string) bool
+	now       func() time.Time
+
+	queue       chan realtime.OfflineMessage
+	workers     int
+	sendTimeout time.Duration
+	preview     bool
+}
+
+// NewDispatcher constructs a Dispatcher with a Sender per configured provider.
+// connected reports whether a user has a live session (typically Hub.UserConnected).
+func NewDispatcher(log *slog.Logger, pool *pgxpool.Pool, cfg Config, connected func(userID string) bool) (*Dispatcher, error) {
+	store, err := NewPostgresStore(pool)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Timeout: cfg.SendTimeout}
+	senders := make(map[string]Sender, 2)
+	if cfg.APNs.KeyFile != "" {
+		s, err := NewAPNsSender(cfg.APNs, client)
+		if err != nil {
+			return nil, err
+		}
+		senders[PlatformAPNs] = s
+	}
+	if cfg.FCM.CredentialsFile != "" {
+		s, err := NewFCMSender(cfg.FCM, client)
+		if err != nil {
+			return nil, err
+		}
+		senders[PlatformFCM] = s
+	}
+	if len(senders) == 0 {
+		return nil, errors.New("push: no provider configured")
+	}
+	return newDispatcher(log, store, senders, connected, cfg), nil
+}
+
+func newDispatcher(log *slog.Logger, store dispatchStore, senders map[string]Sender, connected func(string) bool, cfg Config) *Dispatcher {
+	if log == nil {
+		log = slog.Default()
+	}
+	if connected == nil {
+		connected = func(string) bool { return false }
+	}
+	return &Dispatcher{
+		log:         log,
+		store:       store,
+		senders:     senders,
+		connected:   connected,
+		now:         time.Now,
+		queue:       make(chan realtime.OfflineMessage, max(cfg.QueueSize, 1)),
+		workers:     max(cfg.Workers, 1),
+		sendTimeout: cfg.SendTimeout,
+		preview:     cfg.ShowPreview,
+	}
+}
+
+// NotifyOffline queues msg without blocking; it is dropped when the queue is full.
+func (d *Dispatcher) NotifyOffline(msg realtime.OfflineMessage) {
+	select {
+	case d.queue <- msg:
+	default:
+		d.log.Warn("push.queue.full", "conversation_id", msg.ConversationID, "server_msg_id", msg.ServerMsgID, "result", "dropped")
+	}
+}
+
+// Run processes queued messages until ctx is done, then finishes the messages
+// already queued. In-flight sends are not cut short by ctx; the caller bounds how
+// long it waits for Run to return.
+func (d *Dispatcher) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for range d.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					d.flush(context.WithoutCancel(ctx))
+					return
+				case msg := <-d.queue:
+					d.dispatch(context.WithoutCancel(ctx), msg)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// flush handles what is left in the queue when Run is stopped.
+func (d *Dispatcher) flush(ctx context.Context) {
+	for {
+		select {
+		case msg := <-d.queue:
+			d.dispatch(ctx, msg)
+		default:
+			return
+		}
+	}
+}
+
+// dispatch pushes msg to every device of offline, unmuted recipients. Members
+// with the mentions level are only notified when msg mentions them.
+func (d *Dispatcher) dispatch(ctx context.Context, msg realtime.OfflineMessage) {
+	recipients, err := d.store.recipients(ctx, msg.ConversationID, msg.SenderUserID, d.now().UTC())
+	if err != nil {
+		d.log.Error("push.recipients.fail", "conversation_id", msg.ConversationID, "err", err)
+		return
+	}
+
+	var offline []string
+	for _, r := range recipients {
+		if r.Level == realtime.NotificationLevelMentions && !slices.Contains(msg.MentionedUserIDs, r.UserID) {
+			continue
+		}
+		if !d.connected(r.UserID) {
+			offline = append(offline, r.UserID)
+		}
+	}
+	if len(offline) == 0 {
+		return
+	}
+
+	tokens, err := d.store.tokensForUsers(ctx, offline)
+	if err != nil {
+		d.log.Error("push.tokens.fail", "conversation_id", msg.ConversationID, "err", err)
+		return
+	}
+
+	n := d.notification(msg)
+	for _, t := range tokens {
+		sender, ok := d.senders[t.Platform]
+		if !ok {
+			continue
+		}
+		d.send(ctx, sender, t, n)
+	}
+}
+
+func (d *Dispatcher) send(ctx context.Context, sender Sender, t DeviceToken, n Notification) {
+	if d.sendTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.sendTimeout)
+		defer cancel()
+	}
+
+	err := sender.Send(ctx, t.Token, n)
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrInvalidToken):
+		if err := d.store.deleteToken(ctx, t.Platform, t.Token); err != nil {
+			d.log.Error("push.token.delete.fail", "user_id", t.UserID, "platform", t.Platform, "err", err)
+			return
+		}
+		d.log.Info("push.token.pruned", "user_id", t.UserID, "platform", t.Platform)
+	default:
+		d.log.Warn("push.send.fail", "user_id", t.UserID, "platform", t.Platform, "err", err)
+	}
+}
+
+// notification builds the alert for msg. All notifications of a conversation
+// share its ID as collapse key.
+func (d *Dispatcher) notification(msg realtime.OfflineMessage) Notification {
+	body := genericBody
+	if d.preview && msg.Text != "" {
+		body = truncateRunes(msg.Text, maxPreviewRunes)
+	}
+	return Notification{
+		Title:       notificationTitle,
+		Body:        body,
+		CollapseKey: msg.ConversationID,
+		Data: map[string]string{
+			"conversation_id": msg.ConversationID,
+			"server_msg_id":   msg.ServerMsgID,
+			"seq":             strconv.FormatInt(msg.Seq, 10),
+		},
+	}
+}
+
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	r := []rune(s)
+	return string(r[:n-1]) + "…"
+}
+
+var _ realtime.OfflineNotifier = (*Dispatcher)(nil)
@@ -0,0 +1,191 @@
+package push
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"arc/cmd/internal/realtime"
+)
+
+type fakeDispatchStore struct {
+	mu      sync.Mutex
+	members []recipient
+	tokens  []DeviceToken
+	deleted []string
+}
+
+func (s *fakeDispatchStore) recipients(_ context.Context, _, senderUserID string, _ time.Time) ([]recipient, error) {
+	var out []recipient
+	for _, r := range s.members {
+		if r.UserID != senderUserID {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+func (s *fakeDispatchStore) tokensForUsers(_ context.Context, userIDs []string) ([]DeviceToken, error) {
+	var out []DeviceToken
+	for _, t := range s.tokens {
+		for _, id := range userIDs {
+			if t.UserID == id {
+				out = append(out, t)
+			}
+		}
+	}
+	return out, nil
+}
+
+func (s *fakeDispatchStore) deleteToken(_ context.Context, _, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deleted = append(s.deleted, token)
+	return nil
+}
+
+type fakeSender struct {
+	mu      sync.Mutex
+	sent    map[string]Notification
+	invalid map[string]bool
+}
+
+func (s *fakeSender) Send(_ context.Context, token string, n Notification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.invalid[token] {
+		return ErrInvalidToken
+	}
+	if s.sent == nil {
+		s.sent = make(map[string]Notification)
+	}
+	s.sent[token] = n
+	return nil
+}
+
+func TestDispatcher_Dispatch(t *testing.T) {
+	t.Parallel()
+
+	store := &fakeDispatchStore{
+		members: []recipient{
+			{UserID: "sender", Level: realtime.NotificationLevelAll},
+			{UserID: "online", Level: realtime.NotificationLevelAll},
+			{UserID: "offline", Level: realtime.NotificationLevelAll},
+		},
+		tokens: []DeviceToken{
+			{UserID: "sender", Platform: PlatformAPNs, Token: "t-sender"},
+			{UserID: "online", Platform: PlatformAPNs, Token: "t-online"},
+			{UserID: "offline", Platform: PlatformAPNs, Token: "t-ios"},
+			{UserID: "offline", Platform: PlatformFCM, Token: "t-android"},
+			{UserID: "offline", Platform: PlatformFCM, Token: "t-dead"},
+		},
+	}
+	apns := &fakeSender{}
+	fcm := &fakeSender{invalid: map[string]bool{"t-dead": true}}
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	d := newDispatcher(log, store, map[string]Sender{PlatformAPNs: apns, PlatformFCM: fcm},
+		func(userID string) bool { return userID == "online" }, Config{QueueSize: 1, Workers: 1})
+
+	d.dispatch(context.Background(), realtime.OfflineMessage{
+		ConversationID: "c1",
+		ServerMsgID:    "m1",
+		Seq:            7,
+		SenderUserID:   "sender",
+		Text:           "secret",
+	})
+
+	if len(apns.sent) != 1 || len(fcm.sent) != 1 {
+		t.Fatalf("expected one push per provider, got apns=%v fcm=%v", apns.sent, fcm.sent)
+	}
+	n, ok := apns.sent["t-ios"]
+	if !ok {
+		t.Fatalf("expected push to offline member, got %v", apns.sent)
+	}
+	if n.CollapseKey != "c1" || n.Data["seq"] != "7" || n.Data["server_msg_id"] != "m1" {
+		t.Fatalf("unexpected notification %+v", n)
+	}
+	if n.Body == "secret" {
+		t.Fatalf("message text leaked without preview enabled")
+	}
+	if len(store.deleted) != 1 || store.deleted[0] != "t-dead" {
+		t.Fatalf("expected invalid token to be pruned, got %v", store.deleted)
+	}
+}
+
+func TestDispatcher_MentionsLevel(t *testing.T) {
+	t.Parallel()
+
+	store := &fakeDispatchStore{
+		members: []recipient{
+			{UserID: "all", Level: realtime.NotificationLevelAll},
+			{UserID: "quiet", Level: realtime.NotificationLevelMentions},
+		},
+		tokens: []DeviceToken{
+			{UserID: "all", Platform: PlatformFCM, Token: "t-all"},
+			{UserID: "quiet", Platform: PlatformFCM, Token: "t-quiet"},
+		},
+	}
+	fcm := &fakeSender{}
+	d := newDispatcher(nil, store, map[string]Sender{PlatformFCM: fcm}, nil, Config{})
+
+	d.dispatch(context.Background(), realtime.OfflineMessage{ConversationID: "c1", SenderUserID: "sender"})
+	if _, ok := fcm.sent["t-quiet"]; ok || len(fcm.sent) != 1 {
+		t.Fatalf("expected only the all-level member to be notified, got %v", fcm.sent)
+	}
+
+	d.dispatch(context.Background(), realtime.OfflineMessage{ConversationID: "c1", SenderUserID: "sender", MentionedUserIDs: []string{"quiet"}})
+	if _, ok := fcm.sent["t-quiet"]; !ok {
+		t.Fatalf("expected mentioned member to be notified, got %v", fcm.sent)
+	}
+}
+
+func TestDispatcher_NotifyOfflineDropsWhenFull(t *testing.T) {
+	t.Parallel()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	d := newDispatcher(log, &fakeDispatchStore{}, nil, nil, Config{QueueSize: 1})
+	d.NotifyOffline(realtime.OfflineMessage{ConversationID: "c1"})
+	d.NotifyOffline(realtime.OfflineMessage{ConversationID: "c2"})
+	if got := len(d.queue); got != 1 {
+		t.Fatalf("expected 1 queued message, got %d", got)
+	}
+}
+
+func TestDispatcher_Preview(t *testing.T) {
+	t.Parallel()
+
+	d := newDispatcher(nil, &fakeDispatchStore{}, nil, nil, Config{ShowPreview: true})
+	long := strings.Repeat("é", maxPreviewRunes+10)
+	n := d.notification(realtime.OfflineMessage{ConversationID: "c1", Text: long})
+	if got := []rune(n.Body); len(got) != maxPreviewRunes || got[len(got)-1] != '…' {
+		t.Fatalf("unexpected preview %q", n.Body)
+	}
+}
+
+func TestDispatcher_RunFlushesQueueOnStop(t *testing.T) {
+	t.Parallel()
+
+	store := &fakeDispatchStore{
+		members: []recipient{{UserID: "offline", Level: realtime.NotificationLevelAll}},
+		tokens:  []DeviceToken{{UserID: "offline", Platform: PlatformFCM, Token: "t1"}},
+	}
+	fcm := &fakeSender{}
+	d := newDispatcher(nil, store, map[string]Sender{PlatformFCM: fcm}, nil, Config{QueueSize: 2, Workers: 1})
+	d.NotifyOffline(realtime.OfflineMessage{ConversationID: "c1", ServerMsgID: "m1", SenderUserID: "sender"})
+	d.NotifyOffline(realtime.OfflineMessage{ConversationID: "c1", ServerMsgID: "m2", SenderUserID: "sender"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	d.Run(ctx)
+
+	if len(d.queue) != 0 {
+		t.Fatalf("expected queue to be drained, %d left", len(d.queue))
+	}
+	if n, ok := fcm.sent["t1"]; !ok || n.Data["server_msg_id"] == "" {
+		t.Fatalf("expected queued messages to be pushed, got %v", fcm.sent)
+	}
+}
@@ -0,0 +1,10 @@
+// Package push delivers notifications to members without a connected session.
+//
+// Clients register device tokens with POST /me/push-tokens. When a message is
+// stored, the realtime gateway hands it to the Dispatcher, which looks up the
+// conversation's other members, skips those connected to this node or who muted
+// the conversation, and sends through Apple Push Notification service (token-based
+// auth) or Firebase Cloud Messaging (HTTP v1 API). Notifications for the same
+// conversation share a collapse key so devices show only the latest one. Tokens
+// the provider reports as unregistered are removed.
+package push
@@ -0,0 +1,253 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	fcmScope            = "https://www.googleapis.com/auth/firebase.messaging"
+	defaultGoogleTokens = "https://oauth2.googleapis.com/token"
+
+	// fcmTokenSkew refreshes OAuth access tokens before they expire.
+	fcmTokenSkew = time.Minute
+)
+
+// FCMSender sends through the FCM HTTP v1 API, authenticating as a service account.
+type FCMSender struct {
+	client    *http.Client
+	endpoint  string
+	projectID string
+	email     string
+	tokenURI  string
+	key       *rsa.PrivateKey
+	now       func() time.Time
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+type serviceAccount struct {
+	ProjectID   string `json:"project_id"`
+	PrivateKey  string `json:"private_key"`
+	ClientEmail string `json:"client_email"`
+	TokenURI    string `json:"token_uri"`
+}
+
+type fcmRequest struct {
+	Message fcmMessage `json:"message"`
+}
+
+type fcmMessage struct {
+	Token        string            `json:"token"`
+	Notification fcmNotification   `json:"notification"`
+	Data         map[string]string `json:"data,omitempty"`
+	Android      fcmAndroid        `json:"android"`
+}
+
+type fcmNotification struct {
+	Title string `json:"title,omitempty"`
+	Body  string `json:"body"`
+}
+
+type fcmAndroid struct {
+	CollapseKey string `json:"collapse_key,omitempty"`
+	Priority    string `json:"priority"`
+}
+
+type fcmErrorResponse struct {
+	Error struct {
+		Status  string `json:"status"`
+		Message string `json:"message"`
+		Details []struct {
+			ErrorCode string `json:"errorCode"`
+		} `json:"details"`
+	} `json:"error"`
+}
+
+type oauthTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// NewFCMSender loads the service account key from cfg.CredentialsFile.
+func NewFCMSender(cfg FCMConfig, client *http.Client) (*FCMSender, error) {
+	raw, err := os.ReadFile(cfg.CredentialsFile)
+	if err != nil {
+		return nil, fmt.Errorf("push: read fcm credentials: %w", err)
+	}
+	var sa serviceAccount
+	if err := json.Unmarshal(raw, &sa); err != nil {
+		return nil, fmt.Errorf("push: parse fcm credentials: %w", err)
+	}
+	key, err := parseServiceAccountKey(sa.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.ProjectID != "" {
+		sa.ProjectID = cfg.ProjectID
+	}
+	if sa.ProjectID == "" || sa.ClientEmail == "" {
+		return nil, errors.New("push: fcm credentials require project_id and client_email")
+	}
+	return newFCMSender(cfg, sa, key, client), nil
+}
+
+func newFCMSender(cfg FCMConfig, sa serviceAccount, key *rsa.PrivateKey, client *http.Client) *FCMSender {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	endpoint := strings.TrimRight(cfg.Endpoint, "/")
+	if endpoint == "" {
+		endpoint = FCMEndpoint
+	}
+	tokenURI := sa.TokenURI
+	if tokenURI == "" {
+		tokenURI = defaultGoogleTokens
+	}
+	return &FCMSender{
+		client:    client,
+		endpoint:  endpoint,
+		projectID: sa.ProjectID,
+		email:     sa.ClientEmail,
+		tokenURI:  tokenURI,
+		key:       key,
+		now:       time.Now,
+	}
+}
+
+func parseServiceAccountKey(raw string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(raw))
+	if block == nil {
+		return nil, errors.New("push: fcm private_key is not PEM encoded")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("push: parse fcm private_key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("push: fcm private_key must be an RSA key")
+	}
+	return key, nil
+}
+
+// Send implements Sender.
+func (s *FCMSender) Send(ctx context.Context, token string, n Notification) error {
+	access, err := s.oauthToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(fcmRequest{Message: fcmMessage{
+		Token:        token,
+		Notification: fcmNotification{Title: n.Title, Body: n.Body},
+		Data:         n.Data,
+		Android:      fcmAndroid{CollapseKey: n.CollapseKey, Priority: "HIGH"},
+	}})
+	if err != nil {
+		return err
+	}
+
+	u := s.endpoint + "/v1/projects/" + url.PathEscape(s.projectID) + "/messages:send"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+access)
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("push: fcm request: %w", err)
+	}
+	defer func() { _ = res.Body.Close() }()
+	if res.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	var fcmErr fcmErrorResponse
+	_ = json.Unmarshal(readErrorBody(res.Body), &fcmErr)
+	for _, d := range fcmErr.Error.Details {
+		if d.ErrorCode == "UNREGISTERED" {
+			return ErrInvalidToken
+		}
+	}
+	if res.StatusCode == http.StatusNotFound {
+		return ErrInvalidToken
+	}
+	if res.StatusCode == http.StatusUnauthorized {
+		s.mu.Lock()
+		s.accessToken = ""
+		s.mu.Unlock()
+	}
+	return fmt.Errorf("push: fcm status %d: %s", res.StatusCode, fcmErr.Error.Status)
+}
+
+// oauthToken exchanges a signed service account assertion for an access token
+// and caches it until shortly before expiry.
+func (s *FCMSender) oauthToken(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if s.accessToken != "" && now.Before(s.expiresAt) {
+		return s.accessToken, nil
+	}
+
+	assertion, err := signJWT(s.key, map[string]string{}, map[string]any{
+		"iss":   s.email,
+		"scope": fcmScope,
+		"aud":   s.tokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.tokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("push: fcm oauth: %w", err)
+	}
+	defer func() { _ = res.Body.Close() }()
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("push: fcm oauth status %d", res.StatusCode)
+	}
+
+	var tok oauthTokenResponse
+	if err := json.NewDecoder(res.Body).Decode(&tok); err != nil {
+		return "", fmt.Errorf("push: fcm oauth decode: %w", err)
+	}
+	if tok.AccessToken == "" {
+		return "", errors.New("push: fcm oauth returned no access token")
+	}
+	s.accessToken = tok.AccessToken
+	s.expiresAt = now.Add(time.Duration(tok.ExpiresIn)*time.Second - fcmTokenSkew)
+	return s.accessToken, nil
+}
+
+var _ Sender = (*FCMSender)(nil)
@@ -0,0 +1,103 @@
+package push
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func newTestFCMServer(t *testing.T, key *rsa.PrivateKey, send http.HandlerFunc) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var oauthCalls atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		oauthCalls.Add(1)
+		_ = r.ParseForm()
+		parts := strings.Split(r.PostForm.Get("assertion"), ".")
+		if len(parts) != 3 {
+			http.Error(w, "bad assertion", http.StatusBadRequest)
+			return
+		}
+		sig, _ := base64.RawURLEncoding.DecodeString(parts[2])
+		digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], sig); err != nil {
+			http.Error(w, "bad signature", http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token":"at-1","expires_in":3600,"token_type":"Bearer"}`))
+	})
+	mux.HandleFunc("/v1/projects/proj/messages:send", send)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv, &oauthCalls
+}
+
+func newTestFCMSender(t *testing.T, srv *httptest.Server, key *rsa.PrivateKey) *FCMSender {
+	t.Helper()
+	sa := serviceAccount{ProjectID: "proj", ClientEmail: "push@proj.iam.gserviceaccount.com", TokenURI: srv.URL + "/token"}
+	return newFCMSender(FCMConfig{Endpoint: srv.URL}, sa, key, srv.Client())
+}
+
+func TestFCMSender_Send(t *testing.T) {
+	t.Parallel()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	var got fcmRequest
+	var gotAuth string
+	srv, oauthCalls := newTestFCMServer(t, key, func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		b, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(b, &got)
+		_, _ = w.Write([]byte(`{"name":"projects/proj/messages/1"}`))
+	})
+	s := newTestFCMSender(t, srv, key)
+
+	n := Notification{Title: "New message", Body: "hi", CollapseKey: "c1", Data: map[string]string{"seq": "4"}}
+	for range 2 {
+		if err := s.Send(context.Background(), "device-1", n); err != nil {
+			t.Fatalf("send: %v", err)
+		}
+	}
+
+	if gotAuth != "Bearer at-1" {
+		t.Fatalf("unexpected authorization %q", gotAuth)
+	}
+	if oauthCalls.Load() != 1 {
+		t.Fatalf("expected cached access token, got %d oauth calls", oauthCalls.Load())
+	}
+	if got.Message.Token != "device-1" || got.Message.Android.CollapseKey != "c1" || got.Message.Data["seq"] != "4" {
+		t.Fatalf("unexpected message %+v", got.Message)
+	}
+}
+
+func TestFCMSender_Unregistered(t *testing.T) {
+	t.Parallel()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	srv, _ := newTestFCMServer(t, key, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":{"code":404,"status":"NOT_FOUND","details":[{"@type":"type.googleapis.com/google.firebase.fcm.v1.FcmError","errorCode":"UNREGISTERED"}]}}`))
+	})
+
+	err = newTestFCMSender(t, srv, key).Send(context.Background(), "gone", Notification{Body: "x"})
+	if !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("expected ErrInvalidToken, got %v", err)
+	}
+}
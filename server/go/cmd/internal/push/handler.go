@@ -0,0 +1,238 @@
+package push
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"arc/cmd/internal/auth/session"
+	"arc/cmd/internal/httpjson"
+	"arc/cmd/internal/realtime"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	pushTokensPath = "/me/push-tokens"
+	prefsPath      = "/me/conversations/{id}/notifications"
+
+	requestMaxBodyBytes = 8 << 10 // 8 KiB
+	maxTokenLen         = 4096
+)
+
+// TokenValidator validates bearer access tokens.
+type TokenValidator interface {
+	ValidateAccessToken(ctx context.Context, token string, now time.Time) (session.AccessClaims, error)
+}
+
+// tokenStore is the subset of PostgresStore the Handler needs.
+type tokenStore interface {
+	registerToken(ctx context.Context, t DeviceToken, now time.Time) error
+	unregisterToken(ctx context.Context, userID, platform, token string) (bool, error)
+	updatePrefs(ctx context.Context, userID, conversationID string, u prefsUpdate, now time.Time) (realtime.NotificationPrefs, error)
+}
+
+// Handler serves device token registration and notification preferences.
+type Handler struct {
+	log    *slog.Logger
+	store  tokenStore
+	tokens TokenValidator
+	now    func() time.Time
+}
+
+type pushTokenRequest struct {
+	Platform string `json:"platform"`
+	Token    string `json:"token"`
+}
+
+type notificationPrefsRequest struct {
+	Level *string `json:"level,omitempty"`
+	// MutedUntil silences pushes for the conversation until the given time; null unmutes.
+	// It is raw so an omitted field can be told apart from null.
+	MutedUntil json.RawMessage `json:"muted_until,omitempty"`
+}
+
+type notificationPrefsResponse struct {
+	ConversationID string     `json:"conversation_id"`
+	Level          string     `json:"level"`
+	MutedUntil     *time.Time `json:"muted_until"`
+}
+
+// NewHandler constructs a push Handler.
+func NewHandler(log *slog.Logger, pool *pgxpool.Pool, tokens TokenValidator) (*Handler, error) {
+	if log == nil {
+		log = slog.Default()
+	}
+	if tokens == nil {
+		return nil, errors.New("push: nil token validator")
+	}
+	store, err := NewPostgresStore(pool)
+	if err != nil {
+		return nil, err
+	}
+	return &Handler{log: log, store: store, tokens: tokens, now: time.Now}, nil
+}
+
+// Register wires push routes onto the provided mux.
+func (h *Handler) Register(mux *http.ServeMux) {
+	if h == nil || mux == nil {
+		return
+	}
+	mux.HandleFunc(pushTokensPath, h.handlePushTokens)
+	mux.HandleFunc(prefsPath, h.handleNotificationPrefs)
+}
+
+// handlePushTokens serves POST /me/push-tokens (register or re-assign a device
+// token to the caller) and DELETE /me/push-tokens (unregister it, e.g. on sign-out).
+func (h *Handler) handlePushTokens(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		w.Header().Set("Allow", "POST, DELETE")
+		httpjson.WriteError(w, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+	claims, ok := h.requireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	var req pushTokenRequest
+	if err := httpjson.Decode(w, r, requestMaxBodyBytes, &req); err != nil {
+		httpjson.WriteError(w, http.StatusBadRequest, "invalid_json", "invalid JSON body")
+		return
+	}
+	platform := strings.ToLower(strings.TrimSpace(req.Platform))
+	if platform != PlatformAPNs && platform != PlatformFCM {
+		httpjson.WriteError(w, http.StatusBadRequest, "invalid_request", "platform must be apns or fcm")
+		return
+	}
+	token := strings.TrimSpace(req.Token)
+	if token == "" || len(token) > maxTokenLen {
+		httpjson.WriteError(w, http.StatusBadRequest, "invalid_request", "invalid token")
+		return
+	}
+
+	if r.Method == http.MethodDelete {
+		found, err := h.store.unregisterToken(r.Context(), claims.UserID, platform, token)
+		if err != nil {
+			h.log.Error("push.token.unregister.fail", "user_id", claims.UserID, "err", err)
+			httpjson.WriteError(w, http.StatusInternalServerError, "internal_error", "internal error")
+			return
+		}
+		if !found {
+			httpjson.WriteError(w, http.StatusNotFound, "not_found", "push token not found")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	t := DeviceToken{UserID: claims.UserID, Platform: platform, Token: token}
+	if err := h.store.registerToken(r.Context(), t, h.now().UTC()); err != nil {
+		h.log.Error("push.token.register.fail", "user_id", claims.UserID, "err", err)
+		httpjson.WriteError(w, http.StatusInternalServerError, "internal_error", "internal error")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleNotificationPrefs serves PATCH /me/conversations/{id}/notifications.
+// Non-members get 404 so private conversations are not disclosed.
+func (h *Handler) handleNotificationPrefs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch {
+		w.Header().Set("Allow", http.MethodPatch)
+		httpjson.WriteError(w, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+	claims, ok := h.requireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	convID := strings.TrimSpace(r.PathValue("id"))
+	if convID == "" {
+		httpjson.WriteError(w, http.StatusBadRequest, "invalid_request", "conversation id is required")
+		return
+	}
+
+	var req notificationPrefsRequest
+	if err := httpjson.Decode(w, r, requestMaxBodyBytes, &req); err != nil {
+		httpjson.WriteError(w, http.StatusBadRequest, "invalid_json", "invalid JSON body")
+		return
+	}
+	now := h.now().UTC()
+	u, err := req.update(now)
+	if err != nil {
+		httpjson.WriteError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	prefs, err := h.store.updatePrefs(r.Context(), claims.UserID, convID, u, now)
+	if errors.Is(err, errNotMember) {
+		httpjson.WriteError(w, http.StatusNotFound, "conversation_not_found", "conversation not found")
+		return
+	}
+	if err != nil {
+		h.log.Error("push.prefs.update.fail", "conversation_id", convID, "err", err)
+		httpjson.WriteError(w, http.StatusInternalServerError, "internal_error", "internal error")
+		return
+	}
+	httpjson.Write(w, http.StatusOK, notificationPrefsResponse{
+		ConversationID: convID,
+		Level:          prefs.Level,
+		MutedUntil:     prefs.MutedUntil,
+	})
+}
+
+// update validates the request. A muted_until at or before now unmutes.
+func (req notificationPrefsRequest) update(now time.Time) (prefsUpdate, error) {
+	var u prefsUpdate
+	if req.Level != nil {
+		level := strings.ToLower(strings.TrimSpace(*req.Level))
+		if level != realtime.NotificationLevelAll && level != realtime.NotificationLevelMentions {
+			return prefsUpdate{}, errors.New("level must be all or mentions")
+		}
+		u.Level = &level
+	}
+	if len(req.MutedUntil) > 0 {
+		u.SetMutedUntil = true
+		var until *time.Time
+		if err := json.Unmarshal(req.MutedUntil, &until); err != nil {
+			return prefsUpdate{}, errors.New("muted_until must be an RFC 3339 timestamp or null")
+		}
+		if until != nil && until.After(now) {
+			t := until.UTC()
+			u.MutedUntil = &t
+		}
+	}
+	if u.Level == nil && !u.SetMutedUntil {
+		return prefsUpdate{}, errors.New("level or muted_until is required")
+	}
+	return u, nil
+}
+
+func (h *Handler) requireAuth(w http.ResponseWriter, r *http.Request) (session.AccessClaims, bool) {
+	token := bearerToken(r)
+	if token == "" {
+		httpjson.WriteError(w, http.StatusUnauthorized, "unauthorized", "missing bearer token")
+		return session.AccessClaims{}, false
+	}
+	claims, err := h.tokens.ValidateAccessToken(r.Context(), token, h.now().UTC())
+	if err != nil || strings.TrimSpace(claims.UserID) == "" {
+		httpjson.WriteError(w, http.StatusUnauthorized, "unauthorized", "invalid token")
+		return session.AccessClaims{}, false
+	}
+	return claims, true
+}
+
+func bearerToken(r *http.Request) string {
+	raw := strings.TrimSpace(r.Header.Get("Authorization"))
+	parts := strings.SplitN(raw, " ", 2)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(parts[1])
+}
@@ -0,0 +1,145 @@
+package push
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"arc/cmd/internal/auth/session"
+	"arc/cmd/internal/httpjson/httpjsontest"
+	"arc/cmd/internal/realtime"
+)
+
+type fakeTokens struct{}
+
+func (fakeTokens) ValidateAccessToken(_ context.Context, token string, _ time.Time) (session.AccessClaims, error) {
+	if token != "good" {
+		return session.AccessClaims{}, errors.New("invalid")
+	}
+	return session.AccessClaims{UserID: "01HZXUSER00000000000000000"}, nil
+}
+
+type fakeTokenStore struct {
+	registered []DeviceToken
+	prefs      map[string]realtime.NotificationPrefs
+}
+
+func (s *fakeTokenStore) registerToken(_ context.Context, t DeviceToken, _ time.Time) error {
+	s.registered = append(s.registered, t)
+	return nil
+}
+
+func (s *fakeTokenStore) unregisterToken(_ context.Context, userID, platform, token string) (bool, error) {
+	for i, t := range s.registered {
+		if t.UserID == userID && t.Platform == platform && t.Token == token {
+			s.registered = append(s.registered[:i], s.registered[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *fakeTokenStore) updatePrefs(_ context.Context, _, conversationID string, u prefsUpdate, _ time.Time) (realtime.NotificationPrefs, error) {
+	if conversationID != "c1" {
+		return realtime.NotificationPrefs{}, errNotMember
+	}
+	p, ok := s.prefs[conversationID]
+	if !ok {
+		p.Level = realtime.NotificationLevelAll
+	}
+	if u.Level != nil {
+		p.Level = *u.Level
+	}
+	if u.SetMutedUntil {
+		p.MutedUntil = u.MutedUntil
+	}
+	s.prefs[conversationID] = p
+	return p, nil
+}
+
+func newTestHandler() (*http.ServeMux, *fakeTokenStore) {
+	store := &fakeTokenStore{prefs: make(map[string]realtime.NotificationPrefs)}
+	h := &Handler{store: store, tokens: fakeTokens{}, now: time.Now}
+	mux := http.NewServeMux()
+	h.Register(mux)
+	return mux, store
+}
+
+func TestHandlePushTokens(t *testing.T) {
+	mux, store := newTestHandler()
+
+	cases := []struct {
+		name   string
+		method string
+		auth   string
+		body   string
+		status int
+	}{
+		{"method", http.MethodGet, "good", "", http.StatusMethodNotAllowed},
+		{"no token", http.MethodPost, "", `{"platform":"apns","token":"abc"}`, http.StatusUnauthorized},
+		{"bad platform", http.MethodPost, "good", `{"platform":"webpush","token":"abc"}`, http.StatusBadRequest},
+		{"empty token", http.MethodPost, "good", `{"platform":"fcm","token":"  "}`, http.StatusBadRequest},
+		{"unknown field", http.MethodPost, "good", `{"platform":"fcm","token":"abc","x":1}`, http.StatusBadRequest},
+		{"register", http.MethodPost, "good", `{"platform":"APNS","token":"abc"}`, http.StatusNoContent},
+		{"unregister", http.MethodDelete, "good", `{"platform":"apns","token":"abc"}`, http.StatusNoContent},
+		{"unregister missing", http.MethodDelete, "good", `{"platform":"apns","token":"abc"}`, http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httpjsontest.Do(mux, tc.method, pushTokensPath, tc.auth, tc.body)
+			if rec.Code != tc.status {
+				t.Fatalf("got %d want %d: %s", rec.Code, tc.status, rec.Body.String())
+			}
+		})
+	}
+	if len(store.registered) != 0 {
+		t.Fatalf("expected token to be unregistered, got %+v", store.registered)
+	}
+}
+
+func TestHandleNotificationPrefs(t *testing.T) {
+	mux, store := newTestHandler()
+	const path = "/me/conversations/c1/notifications"
+
+	until := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	rec := httpjsontest.Do(mux, http.MethodPatch, path, "good", `{"muted_until":"`+until+`"}`)
+	if rec.Code != http.StatusOK || store.prefs["c1"].MutedUntil == nil {
+		t.Fatalf("mute: got %d (%s)", rec.Code, rec.Body.String())
+	}
+
+	// Omitting muted_until keeps the mute.
+	rec = httpjsontest.Do(mux, http.MethodPatch, path, "good", `{"level":"mentions"}`)
+	if p := store.prefs["c1"]; rec.Code != http.StatusOK || p.Level != realtime.NotificationLevelMentions || p.MutedUntil == nil {
+		t.Fatalf("level: got %d %+v (%s)", rec.Code, p, rec.Body.String())
+	}
+
+	rec = httpjsontest.Do(mux, http.MethodPatch, path, "good", `{"muted_until":null}`)
+	if rec.Code != http.StatusOK || store.prefs["c1"].MutedUntil != nil {
+		t.Fatalf("unmute: got %d (%s)", rec.Code, rec.Body.String())
+	}
+
+	// Times in the past unmute.
+	_ = httpjsontest.Do(mux, http.MethodPatch, path, "good", `{"muted_until":"`+until+`"}`)
+	rec = httpjsontest.Do(mux, http.MethodPatch, path, "good", `{"muted_until":"2000-01-01T00:00:00Z"}`)
+	if rec.Code != http.StatusOK || store.prefs["c1"].MutedUntil != nil {
+		t.Fatalf("past mute: got %d (%s)", rec.Code, rec.Body.String())
+	}
+
+	for name, body := range map[string]string{
+		"empty":       `{}`,
+		"bad level":   `{"level":"none"}`,
+		"bad muted":   `{"muted_until":"tomorrow"}`,
+		"extra field": `{"level":"all","x":1}`,
+	} {
+		if rec := httpjsontest.Do(mux, http.MethodPatch, path, "good", body); rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: got %d", name, rec.Code)
+		}
+	}
+
+	rec = httpjsontest.Do(mux, http.MethodPatch, "/me/conversations/other/notifications", "good", `{"level":"all"}`)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("non-member: got %d", rec.Code)
+	}
+}
@@ -0,0 +1,87 @@
+package push
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrInvalidToken is returned by a Sender when the provider reports the device
+// token as unregistered or malformed; the token should be removed.
+var ErrInvalidToken = errors.New("push: invalid device token")
+
+// maxErrorBodyBytes bounds how much of a provider error response is read.
+const maxErrorBodyBytes = 8 << 10
+
+// Notification is a provider-neutral alert.
+type Notification struct {
+	Title string
+	Body  string
+	// CollapseKey groups notifications so a newer one replaces an older one on the device.
+	CollapseKey string
+	// Data is delivered to the app alongside the alert.
+	Data map[string]string
+}
+
+// Sender delivers a notification to one device token.
+type Sender interface {
+	Send(ctx context.Context, token string, n Notification) error
+}
+
+// signJWT returns a compact JWS over claims. Only ES256 (ECDSA P-256) and RS256
+// keys are supported, which covers APNs and Google service accounts.
+func signJWT(key crypto.Signer, header map[string]string, claims any) (string, error) {
+	switch key.(type) {
+	case *ecdsa.PrivateKey:
+		header["alg"] = "ES256"
+	case *rsa.PrivateKey:
+		header["alg"] = "RS256"
+	default:
+		return "", fmt.Errorf("push: unsupported signing key %T", key)
+	}
+	header["typ"] = "JWT"
+
+	h, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	c, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	input := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c)
+	digest := sha256.Sum256([]byte(input))
+
+	var sig []byte
+	switch k := key.(type) {
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		if err != nil {
+			return "", err
+		}
+		// JWS wants the fixed-width r||s encoding, not ASN.1.
+		size := (k.Curve.Params().BitSize + 7) / 8
+		sig = make([]byte, 2*size)
+		r.FillBytes(sig[:size])
+		s.FillBytes(sig[size:])
+	case *rsa.PrivateKey:
+		sig, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+		if err != nil {
+			return "", err
+		}
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+func readErrorBody(r io.Reader) []byte {
+	b, _ := io.ReadAll(io.LimitReader(r, maxErrorBodyBytes))
+	return b
+}
@@ -0,0 +1,176 @@
+package push
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"arc/cmd/internal/dbtrace"
+	"arc/cmd/internal/realtime"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var errNotMember = errors.New("not a member")
+
+// DeviceToken is a registered device.
+type DeviceToken struct {
+	UserID   string
+	Platform string
+	Token    string
+}
+
+// recipient is a member eligible for pushes in a conversation.
+type recipient struct {
+	UserID string
+	// Level is realtime.NotificationLevelAll or realtime.NotificationLevelMentions.
+	Level string
+}
+
+// prefsUpdate changes notification preferences; unset fields keep their value.
+type prefsUpdate struct {
+	Level         *string
+	SetMutedUntil bool
+	MutedUntil    *time.Time
+}
+
+// PostgresStore persists device tokens in arc.push_tokens and notification
+// preferences in arc.conversation_notification_prefs.
+type PostgresStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresStore constructs a PostgresStore.
+func NewPostgresStore(pool *pgxpool.Pool) (*PostgresStore, error) {
+	if pool == nil {
+		return nil, errors.New("push: nil db pool")
+	}
+	return &PostgresStore{pool: pool}, nil
+}
+
+// registerToken stores (platform, token) for userID, moving it away from any
+// previous owner (e.g. after a sign-out and sign-in on the same device).
+func (s *PostgresStore) registerToken(ctx context.Context, t DeviceToken, now time.Time) error {
+	ctx = dbtrace.WithOp(ctx, "push.register_token")
+	_, err := s.pool.Exec(ctx, `
+		INSERT INTO arc.push_tokens (platform, token, user_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $4)
+		ON CONFLICT (platform, token) DO UPDATE
+		  SET user_id = EXCLUDED.user_id, updated_at = EXCLUDED.updated_at
+	`, t.Platform, t.Token, t.UserID, now)
+	return err
+}
+
+// unregisterToken removes one of userID's tokens. It reports false when the
+// token is not registered to userID.
+func (s *PostgresStore) unregisterToken(ctx context.Context, userID, platform, token string) (bool, error) {
+	ctx = dbtrace.WithOp(ctx, "push.unregister_token")
+	ct, err := s.pool.Exec(ctx, `
+		DELETE FROM arc.push_tokens WHERE platform = $1 AND token = $2 AND user_id = $3
+	`, platform, token, userID)
+	if err != nil {
+		return false, err
+	}
+	return ct.RowsAffected() == 1, nil
+}
+
+// deleteToken removes a token the provider rejected.
+func (s *PostgresStore) deleteToken(ctx context.Context, platform, token string) error {
+	ctx = dbtrace.WithOp(ctx, "push.delete_token")
+	_, err := s.pool.Exec(ctx, `
+		DELETE FROM arc.push_tokens WHERE platform = $1 AND token = $2
+	`, platform, token)
+	return err
+}
+
+// recipients lists members of conversationID other than senderUserID who have
+// not muted it at now, with their notification level.
+func (s *PostgresStore) recipients(ctx context.Context, conversationID, senderUserID string, now time.Time) ([]recipient, error) {
+	ctx = dbtrace.WithOp(ctx, "push.recipients")
+	rows, err := s.pool.Query(ctx, `
+		SELECT m.user_id, COALESCE(p.level, 'all')
+		FROM arc.conversation_members m
+		LEFT JOIN arc.conversation_notification_prefs p
+		  ON p.conversation_id = m.conversation_id AND p.user_id = m.user_id
+		WHERE m.conversation_id = $1
+		  AND m.user_id <> $2
+		  AND (p.muted_until IS NULL OR p.muted_until <= $3)
+		ORDER BY m.user_id
+	`, conversationID, senderUserID, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []recipient
+	for rows.Next() {
+		var r recipient
+		if err := rows.Scan(&r.UserID, &r.Level); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// tokensForUsers returns every device token registered to userIDs.
+func (s *PostgresStore) tokensForUsers(ctx context.Context, userIDs []string) ([]DeviceToken, error) {
+	ctx = dbtrace.WithOp(ctx, "push.tokens_for_users")
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+	rows, err := s.pool.Query(ctx, `
+		SELECT user_id, platform, token
+		FROM arc.push_tokens
+		WHERE user_id = ANY($1)
+		ORDER BY user_id, platform, token
+	`, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []DeviceToken
+	for rows.Next() {
+		var t DeviceToken
+		if err := rows.Scan(&t.UserID, &t.Platform, &t.Token); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// updatePrefs applies u to userID's preferences for conversationID and returns
+// the result. It returns errNotMember unless userID belongs to the conversation.
+func (s *PostgresStore) updatePrefs(ctx context.Context, userID, conversationID string, u prefsUpdate, now time.Time) (realtime.NotificationPrefs, error) {
+	ctx = dbtrace.WithOp(ctx, "push.update_prefs")
+	var out realtime.NotificationPrefs
+	err := s.pool.QueryRow(ctx, `
+		INSERT INTO arc.conversation_notification_prefs (conversation_id, user_id, level, muted_until, updated_at)
+		SELECT m.conversation_id, m.user_id, COALESCE($3, 'all'), CASE WHEN $4 THEN $5::timestamptz END, $6
+		FROM arc.conversation_members m
+		WHERE m.conversation_id = $1 AND m.user_id = $2
+		ON CONFLICT (conversation_id, user_id) DO UPDATE
+		  SET level = COALESCE($3, arc.conversation_notification_prefs.level),
+		      muted_until = CASE WHEN $4 THEN EXCLUDED.muted_until ELSE arc.conversation_notification_prefs.muted_until END,
+		      updated_at = EXCLUDED.updated_at
+		RETURNING level, muted_until
+	`, conversationID, userID, u.Level, u.SetMutedUntil, u.MutedUntil, now).Scan(&out.Level, &out.MutedUntil)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return realtime.NotificationPrefs{}, errNotMember
+	}
+	if err != nil {
+		return realtime.NotificationPrefs{}, err
+	}
+	if out.MutedUntil != nil {
+		if !out.MutedUntil.After(now) {
+			out.MutedUntil = nil
+		} else {
+			mu := out.MutedUntil.UTC()
+			out.MutedUntil = &mu
+		}
+	}
+	return out, nil
+}
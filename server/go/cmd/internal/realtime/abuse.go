@@ -0,0 +1,256 @@
+package realtime
+
+import (
+	"arc/cmd/internal/config"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Error envelope codes of the duplicate-content guard.
+const (
+	ErrorCodeDuplicateContent = "duplicate_content"
+	ErrorCodeSendSuspended    = "send_suspended"
+)
+
+// Abuse event kinds reported to an AbuseReporter.
+const (
+	AbuseDuplicateWarned  = "duplicate_warned"
+	AbuseSessionSuspended = "session_suspended"
+)
+
+// abuseSessionsPruneAbove is the tracked session count above which idle sessions are dropped.
+const abuseSessionsPruneAbove = 1024
+
+// AbuseEvent describes a session that repeated the same message.
+type AbuseEvent struct {
+	Kind      string
+	UserID    string
+	SessionID string
+	// Count is the number of identical sends inside the window.
+	Count           int
+	ConversationIDs []string
+	// ContentHash is the hex SHA-256 of the normalized text; the text itself is not reported.
+	ContentHash    string
+	SuspendedUntil *time.Time
+	At             time.Time
+}
+
+// AbuseReporter records abuse events for moderators, e.g. in the audit log.
+type AbuseReporter interface {
+	// ReportAbuse must not block the session loop; ctx ends with the session.
+	ReportAbuse(ctx context.Context, ev AbuseEvent)
+}
+
+// WithAbuseReporter records duplicate-content warnings and suspensions with r.
+func WithAbuseReporter(r AbuseReporter) GatewayOption {
+	return func(g *WSGateway) { g.abuseReporter = r }
+}
+
+// SendSuspendedError rejects message.send while the session is suspended.
+type SendSuspendedError struct {
+	Until time.Time
+}
+
+func (e *SendSuspendedError) Error() string {
+	return "sending suspended until " + e.Until.UTC().Format(time.RFC3339) + " after repeated identical messages"
+}
+
+// AbuseGuardConfig configures duplicate-content detection. Messages shorter than
+// MinChars runes (e.g. "ok") are never counted.
+type AbuseGuardConfig struct {
+	Window       time.Duration
+	WarnAfter    int
+	SuspendAfter int
+	SuspendFor   time.Duration
+	MinChars     int
+	Disabled     bool
+}
+
+// LoadAbuseGuardConfigFromEnv reads ARC_ABUSE_* with conservative defaults.
+func LoadAbuseGuardConfigFromEnv() AbuseGuardConfig {
+	return LoadAbuseGuardConfigFrom(config.Env)
+}
+
+// LoadAbuseGuardConfigFrom is LoadAbuseGuardConfigFromEnv reading the settings through lookup.
+func LoadAbuseGuardConfigFrom(lookup config.Lookup) AbuseGuardConfig {
+	return AbuseGuardConfig{
+		Window:       envDurationWS(lookup, "ARC_ABUSE_DUPLICATE_WINDOW", time.Minute),
+		WarnAfter:    envIntWS(lookup, "ARC_ABUSE_DUPLICATE_WARN", 3),
+		SuspendAfter: envIntWS(lookup, "ARC_ABUSE_DUPLICATE_SUSPEND", 5),
+		SuspendFor:   envDurationWS(lookup, "ARC_ABUSE_SUSPEND_DURATION", 5*time.Minute),
+		MinChars:     envIntWS(lookup, "ARC_ABUSE_DUPLICATE_MIN_CHARS", 10),
+		Disabled:     !envBoolWS(lookup, "ARC_ABUSE_GUARD", true),
+	}
+}
+
+// abuseVerdict is the guard's decision after recording a send.
+type abuseVerdict int
+
+const (
+	abuseOK abuseVerdict = iota
+	abuseWarn
+	abuseSuspend
+)
+
+// AbuseGuard detects sessions sending the same text repeatedly, in one or many
+// conversations. State is per node and keyed by session so suspensions survive
+// reconnects to the same node.
+type AbuseGuard struct {
+	cfg AbuseGuardConfig
+
+	mu       sync.Mutex
+	sessions map[string]*abuseSession
+}
+
+type abuseSession struct {
+	sends          []abuseSend
+	suspendedUntil time.Time
+}
+
+type abuseSend struct {
+	hash           [sha256.Size]byte
+	conversationID string
+	at             time.Time
+}
+
+// NewAbuseGuard returns nil when cfg disables the guard.
+func NewAbuseGuard(cfg AbuseGuardConfig) *AbuseGuard {
+	if cfg.Disabled || cfg.Window <= 0 || cfg.SuspendAfter <= 0 {
+		return nil
+	}
+	if cfg.WarnAfter <= 0 || cfg.WarnAfter >= cfg.SuspendAfter {
+		cfg.WarnAfter = cfg.SuspendAfter
+	}
+	return &AbuseGuard{cfg: cfg, sessions: make(map[string]*abuseSession)}
+}
+
+// Suspended returns the end of sessionID's suspension, if one is active at now.
+func (a *AbuseGuard) Suspended(sessionID string, now time.Time) (time.Time, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	s := a.sessions[sessionID]
+	if s == nil || !now.Before(s.suspendedUntil) {
+		return time.Time{}, false
+	}
+	return s.suspendedUntil, true
+}
+
+// Record counts a stored message and returns the verdict with the identical
+// sends inside the window (including this one).
+func (a *AbuseGuard) Record(sessionID, conversationID, text string, now time.Time) (abuseVerdict, []abuseSend) {
+	norm := strings.Join(strings.Fields(strings.ToLower(text)), " ")
+	if len([]rune(norm)) < a.cfg.MinChars {
+		return abuseOK, nil
+	}
+	hash := sha256.Sum256([]byte(norm))
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	s := a.sessions[sessionID]
+	if s == nil {
+		if len(a.sessions) >= abuseSessionsPruneAbove {
+			a.pruneLocked(now)
+		}
+		s = &abuseSession{}
+		a.sessions[sessionID] = s
+	}
+	s.sends = a.recent(s.sends, now)
+	s.sends = append(s.sends, abuseSend{hash: hash, conversationID: conversationID, at: now})
+
+	var same []abuseSend
+	for _, sd := range s.sends {
+		if sd.hash == hash {
+			same = append(same, sd)
+		}
+	}
+	switch {
+	case len(same) >= a.cfg.SuspendAfter:
+		s.suspendedUntil = now.Add(a.cfg.SuspendFor)
+		s.sends = nil
+		return abuseSuspend, same
+	case len(same) >= a.cfg.WarnAfter:
+		return abuseWarn, same
+	default:
+		return abuseOK, same
+	}
+}
+
+func (a *AbuseGuard) recent(sends []abuseSend, now time.Time) []abuseSend {
+	cut := now.Add(-a.cfg.Window)
+	dst := sends[:0]
+	for _, sd := range sends {
+		if sd.at.After(cut) {
+			dst = append(dst, sd)
+		}
+	}
+	return dst
+}
+
+// pruneLocked drops sessions with no recent sends and no active suspension.
+func (a *AbuseGuard) pruneLocked(now time.Time) {
+	for id, s := range a.sessions {
+		s.sends = a.recent(s.sends, now)
+		if len(s.sends) == 0 && !now.Before(s.suspendedUntil) {
+			delete(a.sessions, id)
+		}
+	}
+}
+
+// checkSendSuspended rejects sends from a suspended session.
+func (g *WSGateway) checkSendSuspended(client *Client, now time.Time) error {
+	if g.abuse == nil {
+		return nil
+	}
+	if until, ok := g.abuse.Suspended(client.ActorSession(), now); ok {
+		return &SendSuspendedError{Until: until}
+	}
+	return nil
+}
+
+// recordSend feeds a newly stored message to the guard, warns or suspends the
+// sender and reports the event. Encrypted messages are opaque and skipped.
+func (g *WSGateway) recordSend(ctx context.Context, client *Client, stored StoredMessage, now time.Time) {
+	if g.abuse == nil || stored.Encrypted() {
+		return
+	}
+	verdict, same := g.abuse.Record(client.ActorSession(), stored.ConversationID, stored.Text, now)
+	if verdict == abuseOK {
+		return
+	}
+
+	ev := AbuseEvent{
+		Kind:        AbuseDuplicateWarned,
+		UserID:      client.UserID,
+		SessionID:   client.ActorSession(),
+		Count:       len(same),
+		ContentHash: fmt.Sprintf("%x", same[0].hash),
+		At:          now,
+	}
+	seen := make(map[string]bool, len(same))
+	for _, sd := range same {
+		if !seen[sd.conversationID] {
+			seen[sd.conversationID] = true
+			ev.ConversationIDs = append(ev.ConversationIDs, sd.conversationID)
+		}
+	}
+
+	if verdict == abuseSuspend {
+		until := now.Add(g.abuse.cfg.SuspendFor)
+		ev.Kind = AbuseSessionSuspended
+		ev.SuspendedUntil = &until
+		g.trySendError(ctx, client, ErrorCodeSendSuspended, (&SendSuspendedError{Until: until}).Error())
+		g.log.Warn("abuse.session.suspended", "session_id", client.SessionID, "user_id", client.UserID, "count", ev.Count, "until", until)
+	} else {
+		g.trySendError(ctx, client, ErrorCodeDuplicateContent,
+			fmt.Sprintf("identical message sent %d times; continuing will suspend sending", len(same)))
+		g.log.Info("abuse.duplicate.warned", "session_id", client.SessionID, "user_id", client.UserID, "count", ev.Count)
+	}
+	if g.abuseReporter != nil {
+		g.abuseReporter.ReportAbuse(ctx, ev)
+	}
+}
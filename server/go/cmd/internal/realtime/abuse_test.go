@@ -0,0 +1,120 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	v1 "arc/shared/contracts/realtime/v1"
+)
+
+type recordingAbuse struct {
+	got []AbuseEvent
+}
+
+func (r *recordingAbuse) ReportAbuse(_ context.Context, ev AbuseEvent) {
+	r.got = append(r.got, ev)
+}
+
+func TestAbuseGuard(t *testing.T) {
+	t.Parallel()
+
+	a := NewAbuseGuard(AbuseGuardConfig{Window: time.Minute, WarnAfter: 2, SuspendAfter: 3, SuspendFor: time.Minute, MinChars: 5})
+	t0 := time.Unix(1_700_000_000, 0)
+
+	if v, _ := a.Record("s1", "c1", "ok", t0); v != abuseOK {
+		t.Fatalf("expected short messages to be ignored")
+	}
+	if v, _ := a.Record("s1", "c1", "ok", t0); v != abuseOK {
+		t.Fatalf("expected short messages to be ignored")
+	}
+
+	if v, _ := a.Record("s1", "c1", "Buy cheap coins", t0); v != abuseOK {
+		t.Fatalf("expected first send to pass")
+	}
+	if v, _ := a.Record("s2", "c1", "buy cheap coins", t0); v != abuseOK {
+		t.Fatalf("expected other sessions to be counted separately")
+	}
+	if v, _ := a.Record("s1", "c2", "buy  CHEAP coins", t0.Add(time.Second)); v != abuseWarn {
+		t.Fatalf("expected a warning for a normalized duplicate in another conversation")
+	}
+	if v, _ := a.Record("s1", "c1", "buy cheap coins", t0.Add(2*time.Minute)); v != abuseOK {
+		t.Fatalf("expected sends outside the window to be forgotten")
+	}
+
+	for i := range 2 {
+		a.Record("s1", "c3", "buy cheap coins", t0.Add(2*time.Minute+time.Duration(i)*time.Second))
+	}
+	until, ok := a.Suspended("s1", t0.Add(2*time.Minute+2*time.Second))
+	if !ok || !until.Equal(t0.Add(3*time.Minute+time.Second)) {
+		t.Fatalf("expected suspension, got %v %v", until, ok)
+	}
+	if _, ok := a.Suspended("s1", until); ok {
+		t.Fatalf("expected suspension to end")
+	}
+	if _, ok := a.Suspended("s2", t0); ok {
+		t.Fatalf("expected s2 not to be suspended")
+	}
+
+	if NewAbuseGuard(AbuseGuardConfig{Disabled: true, Window: time.Minute, SuspendAfter: 3}) != nil {
+		t.Fatalf("expected disabled guard to be nil")
+	}
+}
+
+func TestGatewayAbuseEscalation(t *testing.T) {
+	t.Parallel()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	reporter := &recordingAbuse{}
+	g := NewWSGateway(log, NewHub(log), NewInMemoryStore(), nil, nil, WithAbuseReporter(reporter))
+	g.abuse = NewAbuseGuard(AbuseGuardConfig{Window: time.Minute, WarnAfter: 2, SuspendAfter: 3, SuspendFor: time.Minute, MinChars: 1})
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+	client := NewClient("u1", "s1", 16)
+	for i, conv := range []string{"c1", "c2", "c3"} {
+		g.recordSend(ctx, client, StoredMessage{ConversationID: conv, Text: "same text"}, now.Add(time.Duration(i)*time.Second))
+	}
+
+	wantCodes := []string{ErrorCodeDuplicateContent, ErrorCodeSendSuspended}
+	for _, want := range wantCodes {
+		select {
+		case env := <-client.Send:
+			var p v1.ErrorPayload
+			if err := json.Unmarshal(env.Payload, &p); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if env.Type != v1.TypeError || p.Code != want {
+				t.Fatalf("expected %s, got %s %+v", want, env.Type, p)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("expected %s envelope", want)
+		}
+	}
+
+	if len(reporter.got) != 2 || reporter.got[0].Kind != AbuseDuplicateWarned || reporter.got[1].Kind != AbuseSessionSuspended {
+		t.Fatalf("unexpected abuse events %+v", reporter.got)
+	}
+	suspended := reporter.got[1]
+	if suspended.Count != 3 || len(suspended.ConversationIDs) != 3 || suspended.SuspendedUntil == nil || len(suspended.ContentHash) != 64 {
+		t.Fatalf("unexpected suspension event %+v", suspended)
+	}
+
+	err := g.checkSendSuspended(client, now.Add(3*time.Second))
+	var se *SendSuspendedError
+	if !errors.As(err, &se) {
+		t.Fatalf("expected SendSuspendedError, got %v", err)
+	}
+	g.trySendFailure(ctx, client, "send_failed", err)
+	var p v1.ErrorPayload
+	if err := json.Unmarshal((<-client.Send).Payload, &p); err != nil || p.Code != ErrorCodeSendSuspended {
+		t.Fatalf("expected send_suspended, got %+v err=%v", p, err)
+	}
+	if err := g.checkSendSuspended(&Client{SessionID: "s2"}, now); err != nil {
+		t.Fatalf("expected other sessions to send, got %v", err)
+	}
+}
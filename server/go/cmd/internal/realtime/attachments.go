@@ -0,0 +1,59 @@
+package realtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrAttachmentNotFound is returned when an attachment does not exist or is not owned by the sender.
+var ErrAttachmentNotFound = errors.New("realtime: attachment not found")
+
+// AttachmentVerifier checks that attachments referenced by message.send belong to the sender.
+type AttachmentVerifier interface {
+	// VerifyAttachments returns ErrAttachmentNotFound unless every id is owned by userID.
+	VerifyAttachments(ctx context.Context, userID string, ids []string) error
+}
+
+// normalizeAttachmentIDs trims and de-duplicates ids, preserving order.
+func normalizeAttachmentIDs(ids []string) ([]string, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	out := make([]string, 0, len(ids))
+	seen := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			return nil, errors.New("empty attachment id")
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		out = append(out, id)
+	}
+	if len(out) > maxMessageAttachments {
+		return nil, fmt.Errorf("too many attachments: max=%d", maxMessageAttachments)
+	}
+	return out, nil
+}
+
+// verifyAttachments checks ownership of ids before they are stored and fanned out.
+func (g *WSGateway) verifyAttachments(ctx context.Context, client *Client, ids []string) error {
+	if g.attachments == nil {
+		return errors.New("attachments not enabled")
+	}
+	if client.UserID == "" {
+		return errors.New("attachments require authentication")
+	}
+	err := g.attachments.VerifyAttachments(ctx, client.UserID, ids)
+	if errors.Is(err, ErrAttachmentNotFound) {
+		return errors.New("attachment not found")
+	}
+	if err != nil {
+		return fmt.Errorf("attachment verify: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package realtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+)
+
+type fakeAttachmentVerifier struct {
+	owned map[string]string // attachment id -> owner user id
+}
+
+func (f fakeAttachmentVerifier) VerifyAttachments(_ context.Context, userID string, ids []string) error {
+	for _, id := range ids {
+		if f.owned[id] != userID {
+			return ErrAttachmentNotFound
+		}
+	}
+	return nil
+}
+
+func TestNormalizeAttachmentIDs(t *testing.T) {
+	t.Parallel()
+
+	got, err := normalizeAttachmentIDs([]string{" a ", "b", "a"})
+	if err != nil || len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Fatalf("unexpected %v, %v", got, err)
+	}
+	if _, err := normalizeAttachmentIDs([]string{"a", " "}); err == nil {
+		t.Fatalf("expected empty id to fail")
+	}
+	many := make([]string, maxMessageAttachments+1)
+	for i := range many {
+		many[i] = fmt.Sprintf("att-%d", i)
+	}
+	if _, err := normalizeAttachmentIDs(many); err == nil {
+		t.Fatalf("expected too many attachments to fail")
+	}
+}
+
+func TestGatewayVerifyAttachments(t *testing.T) {
+	t.Parallel()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx := context.Background()
+	owner := &Client{SessionID: "s1", UserID: "u1"}
+
+	g := NewWSGateway(log, NewHub(log), NewInMemoryStore(), nil, nil)
+	if err := g.verifyAttachments(ctx, owner, []string{"att-1"}); err == nil {
+		t.Fatalf("expected attachments to be rejected when not enabled")
+	}
+
+	g = NewWSGateway(log, NewHub(log), NewInMemoryStore(), nil, nil,
+		WithAttachmentVerifier(fakeAttachmentVerifier{owned: map[string]string{"att-1": "u1"}}))
+
+	if err := g.verifyAttachments(ctx, owner, []string{"att-1"}); err != nil {
+		t.Fatalf("expected owner to pass, got %v", err)
+	}
+	if err := g.verifyAttachments(ctx, &Client{SessionID: "s2", UserID: "u2"}, []string{"att-1"}); err == nil {
+		t.Fatalf("expected another user's attachment to be rejected")
+	}
+	if err := g.verifyAttachments(ctx, &Client{SessionID: "s3"}, []string{"att-1"}); err == nil {
+		t.Fatalf("expected anonymous client to be rejected")
+	}
+	if err := g.verifyAttachments(ctx, owner, []string{"att-2"}); errors.Is(err, ErrAttachmentNotFound) || err == nil {
+		t.Fatalf("expected a client-facing error for unknown attachment, got %v", err)
+	}
+}
@@ -0,0 +1,137 @@
+package realtime
+
+import (
+	"strings"
+	"time"
+
+	v1 "arc/shared/contracts/realtime/v1"
+)
+
+// Backpressure modes applied when a client's send queue is full.
+const (
+	// BackpressureDropNewest discards the envelope being sent (default).
+	BackpressureDropNewest = "drop-newest"
+	// BackpressureDropOldest evicts the oldest queued envelope to make room,
+	// favoring fresh state over stale backlog.
+	BackpressureDropOldest = "drop-oldest"
+)
+
+const (
+	wsDefaultPriorityQueueSize = 16
+)
+
+// BackpressurePolicy controls how a client's send queue behaves under load.
+// It applies to every producer: direct replies, conversation fanout and
+// user-scoped events.
+type BackpressurePolicy struct {
+	// Mode is BackpressureDropNewest or BackpressureDropOldest.
+	Mode string
+	// DisconnectAfter closes a session after this many consecutive drops;
+	// 0 keeps slow consumers connected.
+	DisconnectAfter int
+	// PriorityQueueSize reserves a separate queue for acks and errors so they are
+	// written ahead of (and not dropped behind) bulk fanout; 0 disables the lane.
+	PriorityQueueSize int
+	// OrderHold bounds how long a message.new waits for a lower seq of its
+	// conversation before it is written anyway; 0 uses the default and a negative
+	// value never holds (superseded envelopes are still discarded).
+	OrderHold time.Duration
+}
+
+// normalizeBackpressureMode maps unknown values to BackpressureDropNewest.
+func normalizeBackpressureMode(mode string) string {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case BackpressureDropOldest, "drop_oldest":
+		return BackpressureDropOldest
+	default:
+		return BackpressureDropNewest
+	}
+}
+
+// isPriorityEnvelope reports whether typ belongs on the priority lane.
+func isPriorityEnvelope(typ string) bool {
+	switch typ {
+	case v1.TypeMessageAck, v1.TypeHelloAck, v1.TypeError, v1.TypeServerShutdown, v1.TypeTimeSync:
+		return true
+	default:
+		return false
+	}
+}
+
+// Offer queues env without blocking, applying the client's backpressure policy.
+// Seq-tagged message.new and message.ack go through their conversation's
+// ordered lane first. It reports false when env was dropped by backpressure or
+// the client is closed.
+func (c *Client) Offer(env v1.Envelope) bool {
+	if c == nil {
+		return false
+	}
+	select {
+	case <-c.done:
+		return false
+	default:
+	}
+
+	if isOrdered(env) {
+		return c.offerOrdered(env)
+	}
+	return c.enqueue(env)
+}
+
+// enqueue queues env on the priority lane or the regular queue.
+func (c *Client) enqueue(env v1.Envelope) bool {
+	if c.Priority != nil && isPriorityEnvelope(env.Type) {
+		select {
+		case c.Priority <- env:
+			c.dropStreak.Store(0)
+			return true
+		default:
+			// Priority lane full: fall back to the regular queue.
+		}
+	}
+
+	select {
+	case c.Send <- env:
+		c.dropStreak.Store(0)
+		return true
+	default:
+	}
+
+	if c.policy.Mode == BackpressureDropOldest {
+		select {
+		case <-c.Send:
+			c.recordDrop()
+		default:
+		}
+		select {
+		case c.Send <- env:
+			return true
+		default:
+		}
+	}
+
+	c.recordDrop()
+	return false
+}
+
+// Dropped returns the number of envelopes dropped for this client.
+func (c *Client) Dropped() int64 {
+	if c == nil {
+		return 0
+	}
+	return c.dropped.Load()
+}
+
+// Slow returns a channel that is closed once the client exceeded its policy's
+// DisconnectAfter consecutive drops.
+func (c *Client) Slow() <-chan struct{} {
+	return c.slow
+}
+
+func (c *Client) recordDrop() {
+	c.dropped.Add(1)
+	streak := c.dropStreak.Add(1)
+	if c.policy.DisconnectAfter > 0 && streak >= int64(c.policy.DisconnectAfter) {
+		c.slowOnce.Do(func() { close(c.slow) })
+	}
+}
@@ -0,0 +1,112 @@
+package realtime
+
+import (
+	"testing"
+	"time"
+
+	v1 "arc/shared/contracts/realtime/v1"
+)
+
+func testEnvelope(typ string) v1.Envelope {
+	return mustNewEnvelope(typ, []byte(`{}`), time.Now().UTC())
+}
+
+func TestClient_Offer_DropNewest(t *testing.T) {
+	t.Parallel()
+
+	c := NewClient("u1", "s1", 2)
+	first, second, third := testEnvelope(v1.TypeMessageNew), testEnvelope(v1.TypeMessageNew), testEnvelope(v1.TypeMessageNew)
+
+	if !c.Offer(first) || !c.Offer(second) {
+		t.Fatalf("expected queue to accept two envelopes")
+	}
+	if c.Offer(third) {
+		t.Fatalf("expected full queue to reject the newest envelope")
+	}
+	if c.Dropped() != 1 {
+		t.Fatalf("expected 1 drop, got %d", c.Dropped())
+	}
+	if got := <-c.Send; got.ID != first.ID {
+		t.Fatalf("expected oldest envelope to be kept")
+	}
+}
+
+func TestClient_Offer_DropOldest(t *testing.T) {
+	t.Parallel()
+
+	c := NewClientWithPolicy("u1", "s1", 2, BackpressurePolicy{Mode: BackpressureDropOldest})
+	first, second, third := testEnvelope(v1.TypeMessageNew), testEnvelope(v1.TypeMessageNew), testEnvelope(v1.TypeMessageNew)
+
+	c.Offer(first)
+	c.Offer(second)
+	if !c.Offer(third) {
+		t.Fatalf("expected drop-oldest to accept the newest envelope")
+	}
+	if c.Dropped() != 1 {
+		t.Fatalf("expected 1 drop, got %d", c.Dropped())
+	}
+	if a, b := <-c.Send, <-c.Send; a.ID != second.ID || b.ID != third.ID {
+		t.Fatalf("expected the two newest envelopes to remain")
+	}
+}
+
+func TestClient_Offer_PriorityLane(t *testing.T) {
+	t.Parallel()
+
+	c := NewClientWithPolicy("u1", "s1", 1, BackpressurePolicy{PriorityQueueSize: 2})
+	c.Offer(testEnvelope(v1.TypeMessageNew))
+
+	// The regular queue is full, yet acks and errors still get through.
+	if !c.Offer(testEnvelope(v1.TypeMessageAck)) || !c.Offer(testEnvelope(v1.TypeError)) {
+		t.Fatalf("expected priority envelopes to bypass the full send queue")
+	}
+	if len(c.Priority) != 2 || len(c.Send) != 1 {
+		t.Fatalf("expected 2 priority and 1 regular envelope, got %d and %d", len(c.Priority), len(c.Send))
+	}
+	if c.Offer(testEnvelope(v1.TypeMessageNew)) {
+		t.Fatalf("expected bulk envelope to be dropped")
+	}
+}
+
+func TestClient_Offer_DisconnectsSlowConsumer(t *testing.T) {
+	t.Parallel()
+
+	c := NewClientWithPolicy("u1", "s1", 1, BackpressurePolicy{DisconnectAfter: 3})
+	c.Offer(testEnvelope(v1.TypeMessageNew))
+
+	c.Offer(testEnvelope(v1.TypeMessageNew))
+	c.Offer(testEnvelope(v1.TypeMessageNew))
+	<-c.Send // The consumer catches up, resetting the streak.
+	c.Offer(testEnvelope(v1.TypeMessageNew))
+
+	select {
+	case <-c.Slow():
+		t.Fatalf("expected a successful send to reset the drop streak")
+	default:
+	}
+
+	for range 3 {
+		c.Offer(testEnvelope(v1.TypeMessageNew))
+	}
+	select {
+	case <-c.Slow():
+	default:
+		t.Fatalf("expected client to be flagged after 3 consecutive drops")
+	}
+	if c.Dropped() != 5 {
+		t.Fatalf("expected 5 drops, got %d", c.Dropped())
+	}
+}
+
+func TestClient_Offer_ClosedClient(t *testing.T) {
+	t.Parallel()
+
+	c := NewClient("u1", "s1", 4)
+	c.Close()
+	if c.Offer(testEnvelope(v1.TypeMessageNew)) {
+		t.Fatalf("expected closed client to reject envelopes")
+	}
+	if c.Dropped() != 0 {
+		t.Fatalf("closed clients should not count drops")
+	}
+}
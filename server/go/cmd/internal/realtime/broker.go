@@ -0,0 +1,281 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	v1 "arc/shared/contracts/realtime/v1"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Broker kinds accepted by ARC_BROKER.
+const (
+	BrokerNone     = "none"
+	BrokerRedis    = "redis"
+	BrokerNATS     = "nats"
+	BrokerPostgres = "postgres"
+)
+
+const (
+	defaultBrokerChannel = "arc.realtime.fanout"
+
+	// brokerOutboxSize bounds envelopes waiting to be published. Broadcast never
+	// blocks, so a stalled broker drops cross-node fanout instead of chat traffic.
+	brokerOutboxSize = 1024
+
+	brokerPublishTimeout  = 2 * time.Second
+	brokerDialTimeout     = 5 * time.Second
+	brokerMinRetryBackoff = 500 * time.Millisecond
+	brokerMaxRetryBackoff = 30 * time.Second
+
+	// brokerMaxPayloadBytes caps a single inbound broker message.
+	brokerMaxPayloadBytes = 4 << 20
+	// brokerReadBufferSize bounds a single protocol line (e.g. a NATS INFO).
+	brokerReadBufferSize = 32 << 10
+)
+
+// Broker is a pub/sub transport that relays conversation broadcasts between
+// gateway instances so clients connected to different nodes see the same events.
+//
+// Delivery is at-most-once: clients already dedupe by seq and recover gaps via
+// resume or history fetch.
+type Broker interface {
+	// Publish sends payload to every subscriber of channel, including this node.
+	Publish(ctx context.Context, channel string, payload []byte) error
+	// Subscribe calls handle for each payload published to channel. It blocks until
+	// ctx is done or the underlying connection fails.
+	Subscribe(ctx context.Context, channel string, handle func(payload []byte)) error
+	// Close releases publishing resources. Active subscriptions end with their ctx.
+	Close() error
+}
+
+// BrokerPinger is implemented by brokers that can check connectivity on demand,
+// for readiness probes. Ping must not disturb publishing or subscriptions.
+type BrokerPinger interface {
+	Ping(ctx context.Context) error
+}
+
+// BrokerConfig selects the cross-node fanout transport.
+type BrokerConfig struct {
+	// Kind is BrokerRedis, BrokerNATS or BrokerPostgres. Empty or BrokerNone keeps
+	// fanout node-local.
+	Kind string
+	// URL addresses the broker, e.g. redis://:pass@host:6379/0 or nats://host:4222.
+	// BrokerPostgres uses the server's database pool instead.
+	URL string
+	// Channel is the Redis channel / NATS subject shared by all gateway instances.
+	Channel string
+}
+
+// DefaultBrokerConfig returns the node-local config: no broker, on the default channel.
+func DefaultBrokerConfig() BrokerConfig {
+	return BrokerConfig{Channel: defaultBrokerChannel}
+}
+
+// LoadBrokerConfigFromEnv loads broker config from environment variables.
+func LoadBrokerConfigFromEnv() BrokerConfig {
+	cfg := BrokerConfig{
+		Kind:    strings.ToLower(strings.TrimSpace(os.Getenv("ARC_BROKER"))),
+		URL:     strings.TrimSpace(os.Getenv("ARC_BROKER_URL")),
+		Channel: strings.TrimSpace(os.Getenv("ARC_BROKER_CHANNEL")),
+	}
+	if cfg.Channel == "" {
+		cfg.Channel = DefaultBrokerConfig().Channel
+	}
+	return cfg
+}
+
+// Enabled reports whether a broker is configured.
+func (c BrokerConfig) Enabled() bool {
+	return c.Kind != "" && c.Kind != BrokerNone
+}
+
+// NewBroker constructs the configured broker. It returns nil when none is configured.
+// pool is only used by BrokerPostgres and may be nil otherwise.
+// Connections are established lazily, so an unreachable broker does not block startup.
+func NewBroker(cfg BrokerConfig, pool *pgxpool.Pool) (Broker, error) {
+	if !cfg.Enabled() {
+		return nil, nil
+	}
+	if strings.ContainsAny(cfg.Channel, " \t\r\n") {
+		return nil, errors.New("realtime: broker channel must not contain whitespace")
+	}
+
+	if cfg.Kind == BrokerPostgres {
+		if pool == nil {
+			return nil, errors.New("realtime: postgres broker requires ARC_DATABASE_URL")
+		}
+		if len(cfg.Channel) > pgMaxChannelLen {
+			return nil, errors.New("realtime: postgres broker channel is too long")
+		}
+		return NewPostgresBroker(pool)
+	}
+
+	if cfg.URL == "" {
+		return nil, errors.New("realtime: ARC_BROKER_URL is required")
+	}
+	switch cfg.Kind {
+	case BrokerRedis:
+		return NewRedisBroker(cfg.URL)
+	case BrokerNATS:
+		return NewNATSBroker(cfg.URL)
+	default:
+		return nil, fmt.Errorf("realtime: unknown broker %q", cfg.Kind)
+	}
+}
+
+// brokerMessage is the wire format relayed between nodes.
+type brokerMessage struct {
+	Node           string      `json:"node"`
+	ConversationID string      `json:"conversation_id"`
+	Envelope       v1.Envelope `json:"envelope"`
+}
+
+// brokerFanout is the hub's active broker attachment.
+type brokerFanout struct {
+	broker  Broker
+	channel string
+	nodeID  string
+	outbox  chan brokerMessage
+}
+
+// UseBroker relays every conversation broadcast through b so other nodes deliver
+// it to their local members, and delivers broadcasts published by other nodes.
+// It must be called at most once; when ctx is done publishing and subscribing stop
+// and b is closed.
+func (h *Hub) UseBroker(ctx context.Context, b Broker, channel string) {
+	if b == nil {
+		return
+	}
+	if channel == "" {
+		channel = defaultBrokerChannel
+	}
+
+	f := &brokerFanout{
+		broker:  b,
+		channel: channel,
+		nodeID:  NewRandomHex(8),
+		outbox:  make(chan brokerMessage, brokerOutboxSize),
+	}
+	if !h.fanout.CompareAndSwap(nil, f) {
+		return
+	}
+
+	go h.publishLoop(ctx, f)
+	go h.subscribeLoop(ctx, f)
+
+	h.log.Info("realtime.broker.start", "channel", channel, "node_id", f.nodeID)
+}
+
+// RemoteObserver is told about broadcasts other nodes relayed through the broker,
+// whether or not the conversation is joined locally.
+type RemoteObserver interface {
+	ObserveRemote(conversationID string, env v1.Envelope)
+	// RemoteGap reports that the subscription dropped, so relayed broadcasts may
+	// have been missed.
+	RemoteGap()
+}
+
+// ObserveRemote registers o to observe broadcasts relayed from other nodes.
+func (h *Hub) ObserveRemote(o RemoteObserver) {
+	if o != nil {
+		h.remote.Store(&o)
+	}
+}
+
+// relay queues env for cross-node fanout. Like Broadcast it never blocks.
+func (h *Hub) relay(conversationID string, env v1.Envelope) {
+	f := h.fanout.Load()
+	if f == nil {
+		return
+	}
+
+	select {
+	case f.outbox <- brokerMessage{Node: f.nodeID, ConversationID: conversationID, Envelope: env}:
+	default:
+		h.log.Warn("realtime.broker.drop", "conversation_id", conversationID, "type", env.Type, "reason", "outbox_full")
+	}
+}
+
+func (h *Hub) publishLoop(ctx context.Context, f *brokerFanout) {
+	for {
+		select {
+		case <-ctx.Done():
+			if err := f.broker.Close(); err != nil {
+				h.log.Warn("realtime.broker.close.fail", "err", err)
+			}
+			return
+		case msg := <-f.outbox:
+			payload, err := json.Marshal(msg)
+			if err != nil {
+				h.log.Error("realtime.broker.encode.fail", "err", err)
+				continue
+			}
+
+			pubCtx, cancel := context.WithTimeout(ctx, brokerPublishTimeout)
+			err = f.broker.Publish(pubCtx, f.channel, payload)
+			cancel()
+			if err != nil && ctx.Err() == nil {
+				h.log.Warn("realtime.broker.publish.fail", "conversation_id", msg.ConversationID, "type", msg.Envelope.Type, "err", err)
+			}
+		}
+	}
+}
+
+func (h *Hub) subscribeLoop(ctx context.Context, f *brokerFanout) {
+	backoff := brokerMinRetryBackoff
+	for {
+		started := time.Now()
+		err := f.broker.Subscribe(ctx, f.channel, func(payload []byte) {
+			h.deliverRemote(f, payload)
+		})
+		if ctx.Err() != nil {
+			return
+		}
+
+		// A subscription that stayed up for a while earns a fresh backoff.
+		if time.Since(started) > brokerMaxRetryBackoff {
+			backoff = brokerMinRetryBackoff
+		}
+		h.log.Warn("realtime.broker.subscribe.fail", "err", err, "retry_in", backoff.String())
+		if o := h.remote.Load(); o != nil {
+			(*o).RemoteGap()
+		}
+
+		t := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+		}
+		backoff = min(backoff*2, brokerMaxRetryBackoff)
+	}
+}
+
+// deliverRemote fans a message published by another node out to local members,
+// inbox subscribers and firehose streams.
+func (h *Hub) deliverRemote(f *brokerFanout, payload []byte) {
+	var msg brokerMessage
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		h.log.Warn("realtime.broker.decode.fail", "err", err)
+		return
+	}
+	if msg.Node == f.nodeID || msg.ConversationID == "" {
+		return
+	}
+
+	if o := h.remote.Load(); o != nil {
+		(*o).ObserveRemote(msg.ConversationID, msg.Envelope)
+	}
+	if conv := h.Conversation(msg.ConversationID); conv != nil {
+		conv.deliver(msg.Envelope)
+	}
+	h.observe(msg.ConversationID, msg.Envelope)
+}
@@ -0,0 +1,294 @@
+package realtime
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NATSBroker implements Broker with core NATS publish/subscribe.
+//
+// Like RedisBroker it keeps one lazily dialed publishing connection and dials a
+// dedicated connection per Subscribe call. Both answer server PINGs so idle
+// connections are not reaped.
+type NATSBroker struct {
+	addr   string
+	user   string
+	pass   string
+	token  string
+	tlsCfg *tls.Config
+
+	mu   sync.Mutex
+	conn *natsConn
+}
+
+// natsConn is one NATS client connection; writes are serialized by wmu.
+type natsConn struct {
+	net.Conn
+	rd  *bufio.Reader
+	wmu sync.Mutex
+}
+
+func (c *natsConn) write(p []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	_, err := c.Write(p)
+	return err
+}
+
+// NewNATSBroker parses a nats:// or tls:// URL of the form
+// nats://[user:password@|token@]host[:port].
+func NewNATSBroker(rawURL string) (*NATSBroker, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return nil, fmt.Errorf("realtime: invalid nats url: %w", err)
+	}
+
+	b := &NATSBroker{}
+	switch u.Scheme {
+	case "nats":
+	case "tls":
+		b.tlsCfg = &tls.Config{ServerName: u.Hostname(), MinVersion: tls.VersionTLS12}
+	default:
+		return nil, errors.New("realtime: nats url must use nats:// or tls://")
+	}
+	if u.Hostname() == "" {
+		return nil, errors.New("realtime: nats url is missing a host")
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = "4222"
+	}
+	b.addr = net.JoinHostPort(u.Hostname(), port)
+
+	if u.User != nil {
+		if pass, ok := u.User.Password(); ok {
+			b.user, b.pass = u.User.Username(), pass
+		} else {
+			b.token = u.User.Username()
+		}
+	}
+
+	return b, nil
+}
+
+// Publish implements Broker.
+func (b *NATSBroker) Publish(ctx context.Context, channel string, payload []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.conn == nil {
+		conn, err := b.dial(ctx)
+		if err != nil {
+			return err
+		}
+		b.conn = conn
+		go b.drainPublisher(conn)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = b.conn.SetWriteDeadline(deadline)
+		defer func() { _ = b.conn.SetWriteDeadline(time.Time{}) }()
+	}
+
+	msg := make([]byte, 0, len(channel)+len(payload)+32)
+	msg = append(msg, "PUB "...)
+	msg = append(msg, channel...)
+	msg = append(msg, ' ')
+	msg = strconv.AppendInt(msg, int64(len(payload)), 10)
+	msg = append(msg, '\r', '\n')
+	msg = append(msg, payload...)
+	msg = append(msg, '\r', '\n')
+
+	if err := b.conn.write(msg); err != nil {
+		_ = b.conn.Close()
+		b.conn = nil
+		return err
+	}
+	return nil
+}
+
+// drainPublisher answers PINGs on the publishing connection and forgets it once
+// it fails, so the next Publish redials.
+func (b *NATSBroker) drainPublisher(conn *natsConn) {
+	_ = readNATS(conn, nil)
+
+	b.mu.Lock()
+	if b.conn == conn {
+		b.conn = nil
+	}
+	b.mu.Unlock()
+
+	_ = conn.Close()
+}
+
+// Subscribe implements Broker.
+func (b *NATSBroker) Subscribe(ctx context.Context, channel string, handle func(payload []byte)) error {
+	conn, err := b.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	if err := conn.write([]byte("SUB " + channel + " 1\r\n")); err != nil {
+		return err
+	}
+
+	err = readNATS(conn, handle)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// Ping implements BrokerPinger. dial already completes a CONNECT/PING/PONG
+// exchange, which proves the server is up and accepts the credentials.
+func (b *NATSBroker) Ping(ctx context.Context) error {
+	conn, err := b.dial(ctx)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// Close implements Broker.
+func (b *NATSBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.conn == nil {
+		return nil
+	}
+	err := b.conn.Close()
+	b.conn = nil
+	return err
+}
+
+func (b *NATSBroker) dial(ctx context.Context) (*natsConn, error) {
+	d := net.Dialer{Timeout: brokerDialTimeout}
+	raw, err := d.DialContext(ctx, "tcp", b.addr)
+	if err != nil {
+		return nil, err
+	}
+	conn := &natsConn{Conn: raw, rd: bufio.NewReaderSize(raw, brokerReadBufferSize)}
+
+	fail := func(err error) (*natsConn, error) {
+		_ = raw.Close()
+		return nil, err
+	}
+
+	deadline := time.Now().Add(brokerDialTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	_ = raw.SetDeadline(deadline)
+
+	// The server greets with INFO before any TLS upgrade.
+	line, err := readCRLFLine(conn.rd)
+	if err != nil {
+		return fail(err)
+	}
+	if !bytes.HasPrefix(line, []byte("INFO ")) {
+		return fail(errors.New("realtime: nats: expected INFO"))
+	}
+
+	if b.tlsCfg != nil {
+		tc := tls.Client(raw, b.tlsCfg)
+		if err := tc.HandshakeContext(ctx); err != nil {
+			return fail(err)
+		}
+		conn.Conn = tc
+		conn.rd = bufio.NewReaderSize(tc, brokerReadBufferSize)
+	}
+
+	connect, err := json.Marshal(map[string]any{
+		"verbose":    false,
+		"pedantic":   false,
+		"lang":       "go",
+		"version":    "arc",
+		"protocol":   1,
+		"name":       "arc-realtime",
+		"user":       b.user,
+		"pass":       b.pass,
+		"auth_token": b.token,
+	})
+	if err != nil {
+		return fail(err)
+	}
+
+	// PING after CONNECT: the PONG confirms the server accepted the credentials.
+	if err := conn.write([]byte("CONNECT " + string(connect) + "\r\nPING\r\n")); err != nil {
+		return fail(err)
+	}
+	for {
+		line, err := readCRLFLine(conn.rd)
+		if err != nil {
+			return fail(err)
+		}
+		switch {
+		case bytes.Equal(line, []byte("PONG")):
+			_ = conn.SetDeadline(time.Time{})
+			return conn, nil
+		case bytes.HasPrefix(line, []byte("-ERR")):
+			return fail(natsError(strings.TrimSpace(string(line[4:]))))
+		}
+	}
+}
+
+// natsError is a protocol error ("-ERR '...'") sent by the server.
+type natsError string
+
+func (e natsError) Error() string { return "realtime: nats: " + string(e) }
+
+// readNATS processes server operations until the connection fails. MSG payloads
+// are passed to handle when it is non-nil.
+func readNATS(conn *natsConn, handle func(payload []byte)) error {
+	for {
+		line, err := readCRLFLine(conn.rd)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case bytes.HasPrefix(line, []byte("MSG ")):
+			// MSG <subject> <sid> [reply-to] <#bytes>
+			fields := strings.Fields(string(line[4:]))
+			if len(fields) < 3 {
+				return errors.New("realtime: nats: malformed MSG")
+			}
+			n, err := strconv.Atoi(fields[len(fields)-1])
+			if err != nil || n < 0 || n > brokerMaxPayloadBytes {
+				return errors.New("realtime: nats: bad MSG size")
+			}
+			data := make([]byte, n+2)
+			if _, err := io.ReadFull(conn.rd, data); err != nil {
+				return err
+			}
+			if handle != nil {
+				handle(data[:n])
+			}
+		case bytes.Equal(line, []byte("PING")):
+			if err := conn.write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case bytes.HasPrefix(line, []byte("-ERR")):
+			return natsError(strings.TrimSpace(string(line[4:])))
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package realtime
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"arc/cmd/internal/dbtrace"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// pgNotifyMaxPayload stays below Postgres' 8000-byte NOTIFY payload limit.
+	// Larger messages are spilled to arc.broker_spill and announced by id.
+	pgNotifyMaxPayload = 7900
+
+	// pgSpillPrefix marks a notification whose payload must be fetched by id.
+	pgSpillPrefix = "#"
+
+	// pgSpillRetention bounds how long spilled payloads stay fetchable.
+	pgSpillRetention = "5 minutes"
+
+	// pgMaxChannelLen is Postgres' identifier limit (NAMEDATALEN - 1).
+	pgMaxChannelLen = 63
+)
+
+// PostgresBroker implements Broker with Postgres LISTEN/NOTIFY, so multi-node
+// deployments need no infrastructure beyond the database.
+//
+// Payloads too large for NOTIFY are written to a spill table and the notification
+// carries only their id; subscribers fetch the payload by id.
+type PostgresBroker struct {
+	pool   *pgxpool.Pool
+	schema string
+}
+
+// NewPostgresBroker constructs a LISTEN/NOTIFY broker using tables in the "arc" schema.
+func NewPostgresBroker(pool *pgxpool.Pool) (*PostgresBroker, error) {
+	if pool == nil {
+		return nil, errors.New("realtime: nil pool")
+	}
+	return &PostgresBroker{pool: pool, schema: "arc"}, nil
+}
+
+// Publish implements Broker.
+func (b *PostgresBroker) Publish(ctx context.Context, channel string, payload []byte) error {
+	ctx = dbtrace.WithOp(ctx, "realtime.publish")
+	if b == nil || b.pool == nil {
+		return errors.New("realtime: postgres broker not initialized")
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if len(payload) <= pgNotifyMaxPayload {
+		_, err := b.pool.Exec(ctx, `SELECT pg_notify($1, $2)`, channel, string(payload))
+		return err
+	}
+
+	// Spill, prune expired spills and notify in one statement; the notification is
+	// delivered on commit, after the row is visible.
+	spill := pgIdent(b.schema, "broker_spill")
+	_, err := b.pool.Exec(ctx, `
+		WITH pruned AS (
+			DELETE FROM `+spill+` WHERE created_at < now() - $3::interval
+		), spilled AS (
+			INSERT INTO `+spill+` (channel, payload) VALUES ($1, $2) RETURNING id
+		)
+		SELECT pg_notify($1, $4 || id::text) FROM spilled
+	`, channel, string(payload), pgSpillRetention, pgSpillPrefix)
+	return err
+}
+
+// Subscribe implements Broker. It takes a dedicated connection out of the pool
+// for the lifetime of the subscription.
+func (b *PostgresBroker) Subscribe(ctx context.Context, channel string, handle func(payload []byte)) error {
+	ctx = dbtrace.WithOp(ctx, "realtime.subscribe")
+	if b == nil || b.pool == nil {
+		return errors.New("realtime: postgres broker not initialized")
+	}
+
+	pooled, err := b.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	// LISTEN state must not leak back into the pool.
+	conn := pooled.Hijack()
+	defer func() { _ = conn.Close(context.Background()) }()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+		return err
+	}
+
+	spill := pgIdent(b.schema, "broker_spill")
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		if n.Channel != channel {
+			continue
+		}
+
+		idStr, spilled := strings.CutPrefix(n.Payload, pgSpillPrefix)
+		if !spilled {
+			handle([]byte(n.Payload))
+			continue
+		}
+
+		id, err := strconv.ParseInt(idStr, 10, 64)
+		if err != nil {
+			continue
+		}
+		var payload string
+		err = conn.QueryRow(ctx, `SELECT payload FROM `+spill+` WHERE id = $1`, id).Scan(&payload)
+		if errors.Is(err, pgx.ErrNoRows) {
+			// Pruned before we got to it; fanout is at-most-once.
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		handle([]byte(payload))
+	}
+}
+
+// Close implements Broker. The pool is owned by the caller.
+func (b *PostgresBroker) Close() error { return nil }
+
+// Ping implements BrokerPinger.
+func (b *PostgresBroker) Ping(ctx context.Context) error { return b.pool.Ping(ctx) }
@@ -0,0 +1,79 @@
+package realtime
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"arc/cmd/internal/migrations/migrationstest"
+)
+
+func TestPostgresBroker_NotifyAndSpill(t *testing.T) {
+	pool := mustOpenTestPool(t)
+	t.Cleanup(pool.Close)
+
+	schema := migrationstest.NewSchema(t, pool)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	b := &PostgresBroker{pool: pool, schema: schema}
+	channel := "arc.it." + strings.ToLower(NewRandomHex(4))
+
+	got := make(chan string, 4)
+	subCtx, subCancel := context.WithCancel(ctx)
+	done := make(chan error, 1)
+	go func() {
+		done <- b.Subscribe(subCtx, channel, func(p []byte) { got <- string(p) })
+	}()
+
+	small := `{"node":"n1","conversation_id":"c1"}`
+	large := `{"text":"` + strings.Repeat("x", pgNotifyMaxPayload) + `"}`
+
+	// Publish until LISTEN is active; earlier notifications are not delivered.
+	deadline := time.Now().Add(5 * time.Second)
+	for received := false; !received; {
+		if err := b.Publish(ctx, channel, []byte(small)); err != nil {
+			t.Fatalf("publish small: %v", err)
+		}
+		select {
+		case p := <-got:
+			if p != small {
+				t.Fatalf("expected %q, got %q", small, p)
+			}
+			received = true
+		case <-time.After(50 * time.Millisecond):
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for notification")
+			}
+		}
+	}
+	// Drain duplicates from the retry loop.
+	for drained := false; !drained; {
+		select {
+		case <-got:
+		case <-time.After(100 * time.Millisecond):
+			drained = true
+		}
+	}
+
+	if err := b.Publish(ctx, channel, []byte(large)); err != nil {
+		t.Fatalf("publish large: %v", err)
+	}
+	select {
+	case p := <-got:
+		if p != large {
+			t.Fatalf("spilled payload mismatch: got %d bytes", len(p))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for spilled payload")
+	}
+
+	subCancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("subscribe did not stop on cancel")
+	}
+}
@@ -0,0 +1,303 @@
+package realtime
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RedisBroker implements Broker with Redis Pub/Sub over RESP2.
+//
+// Publishing reuses one lazily dialed connection; each Subscribe call dials its
+// own connection because a subscribed Redis connection accepts no other commands.
+type RedisBroker struct {
+	addr     string
+	username string
+	password string
+	db       int
+	tlsCfg   *tls.Config
+
+	mu   sync.Mutex
+	conn net.Conn
+	rd   *bufio.Reader
+}
+
+// NewRedisBroker parses a redis:// or rediss:// URL of the form
+// redis://[user:password@]host[:port][/db].
+func NewRedisBroker(rawURL string) (*RedisBroker, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return nil, fmt.Errorf("realtime: invalid redis url: %w", err)
+	}
+
+	b := &RedisBroker{}
+	switch u.Scheme {
+	case "redis":
+	case "rediss":
+		b.tlsCfg = &tls.Config{ServerName: u.Hostname(), MinVersion: tls.VersionTLS12}
+	default:
+		return nil, errors.New("realtime: redis url must use redis:// or rediss://")
+	}
+	if u.Hostname() == "" {
+		return nil, errors.New("realtime: redis url is missing a host")
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = "6379"
+	}
+	b.addr = net.JoinHostPort(u.Hostname(), port)
+
+	if u.User != nil {
+		b.username = u.User.Username()
+		b.password, _ = u.User.Password()
+	}
+
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		n, err := strconv.Atoi(db)
+		if err != nil || n < 0 {
+			return nil, errors.New("realtime: redis url database must be a non-negative integer")
+		}
+		b.db = n
+	}
+
+	return b, nil
+}
+
+// Publish implements Broker.
+func (b *RedisBroker) Publish(ctx context.Context, channel string, payload []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.conn == nil {
+		conn, rd, err := b.dial(ctx)
+		if err != nil {
+			return err
+		}
+		b.conn, b.rd = conn, rd
+	}
+
+	err := b.roundTrip(ctx, b.conn, b.rd, []byte("PUBLISH"), []byte(channel), payload)
+	if err != nil {
+		// Drop the connection; the next publish redials.
+		_ = b.conn.Close()
+		b.conn, b.rd = nil, nil
+	}
+	return err
+}
+
+// Subscribe implements Broker.
+func (b *RedisBroker) Subscribe(ctx context.Context, channel string, handle func(payload []byte)) error {
+	conn, rd, err := b.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	if err := writeRESPCommand(conn, []byte("SUBSCRIBE"), []byte(channel)); err != nil {
+		return err
+	}
+
+	for {
+		reply, err := readRESP(rd)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+
+		// Pushes are ["subscribe", channel, count] and ["message", channel, payload].
+		parts, ok := reply.([]any)
+		if !ok || len(parts) != 3 {
+			continue
+		}
+		kind, _ := parts[0].([]byte)
+		if string(kind) != "message" {
+			continue
+		}
+		if data, ok := parts[2].([]byte); ok {
+			handle(data)
+		}
+	}
+}
+
+// Ping implements BrokerPinger. It dials a separate connection so a stuck probe
+// never holds the publishing lock.
+func (b *RedisBroker) Ping(ctx context.Context) error {
+	conn, rd, err := b.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return b.roundTrip(ctx, conn, rd, []byte("PING"))
+}
+
+// Close implements Broker.
+func (b *RedisBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.conn == nil {
+		return nil
+	}
+	err := b.conn.Close()
+	b.conn, b.rd = nil, nil
+	return err
+}
+
+func (b *RedisBroker) dial(ctx context.Context) (net.Conn, *bufio.Reader, error) {
+	d := net.Dialer{Timeout: brokerDialTimeout}
+	conn, err := d.DialContext(ctx, "tcp", b.addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	if b.tlsCfg != nil {
+		tc := tls.Client(conn, b.tlsCfg)
+		if err := tc.HandshakeContext(ctx); err != nil {
+			_ = conn.Close()
+			return nil, nil, err
+		}
+		conn = tc
+	}
+	rd := bufio.NewReaderSize(conn, brokerReadBufferSize)
+
+	if b.password != "" {
+		args := [][]byte{[]byte("AUTH")}
+		if b.username != "" {
+			args = append(args, []byte(b.username))
+		}
+		args = append(args, []byte(b.password))
+		if err := b.roundTrip(ctx, conn, rd, args...); err != nil {
+			_ = conn.Close()
+			return nil, nil, err
+		}
+	}
+	if b.db != 0 {
+		if err := b.roundTrip(ctx, conn, rd, []byte("SELECT"), []byte(strconv.Itoa(b.db))); err != nil {
+			_ = conn.Close()
+			return nil, nil, err
+		}
+	}
+
+	return conn, rd, nil
+}
+
+// roundTrip writes one command and reads its reply, honoring ctx's deadline.
+func (b *RedisBroker) roundTrip(ctx context.Context, conn net.Conn, rd *bufio.Reader, args ...[]byte) error {
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+		defer func() { _ = conn.SetDeadline(time.Time{}) }()
+	}
+
+	if err := writeRESPCommand(conn, args...); err != nil {
+		return err
+	}
+	_, err := readRESP(rd)
+	return err
+}
+
+// redisError is an error reply ("-ERR ...") returned by the server.
+type redisError string
+
+func (e redisError) Error() string { return "realtime: redis: " + string(e) }
+
+// writeRESPCommand encodes args as a RESP array of bulk strings.
+func writeRESPCommand(w io.Writer, args ...[]byte) error {
+	buf := make([]byte, 0, 64)
+	buf = append(buf, '*')
+	buf = strconv.AppendInt(buf, int64(len(args)), 10)
+	buf = append(buf, '\r', '\n')
+	for _, a := range args {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(a)), 10)
+		buf = append(buf, '\r', '\n')
+		buf = append(buf, a...)
+		buf = append(buf, '\r', '\n')
+	}
+	_, err := w.Write(buf)
+	return err
+}
+
+// readRESP decodes one RESP2 value: simple strings and bulk strings as []byte,
+// integers as int64, arrays as []any and nil bulk/array as nil.
+// Error replies are returned as redisError.
+func readRESP(rd *bufio.Reader) (any, error) {
+	line, err := readCRLFLine(rd)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, errors.New("realtime: redis: empty reply line")
+	}
+
+	body := string(line[1:])
+	switch line[0] {
+	case '+':
+		return []byte(body), nil
+	case '-':
+		return nil, redisError(body)
+	case ':':
+		n, err := strconv.ParseInt(body, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("realtime: redis: bad integer reply: %w", err)
+		}
+		return n, nil
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < -1 || n > brokerMaxPayloadBytes {
+			return nil, errors.New("realtime: redis: bad bulk length")
+		}
+		if n == -1 {
+			return nil, nil
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(rd, data); err != nil {
+			return nil, err
+		}
+		return data[:n], nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < -1 || n > 1024 {
+			return nil, errors.New("realtime: redis: bad array length")
+		}
+		if n == -1 {
+			return nil, nil
+		}
+		out := make([]any, 0, n)
+		for range n {
+			v, err := readRESP(rd)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, v)
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("realtime: redis: unexpected reply type %q", line[0])
+	}
+}
+
+// readCRLFLine reads one protocol line without its trailing CRLF.
+func readCRLFLine(rd *bufio.Reader) ([]byte, error) {
+	line, err := rd.ReadSlice('\n')
+	if err != nil {
+		if errors.Is(err, bufio.ErrBufferFull) {
+			return nil, errors.New("realtime: broker protocol line too long")
+		}
+		return nil, err
+	}
+	return []byte(strings.TrimRight(string(line), "\r\n")), nil
+}
@@ -0,0 +1,423 @@
+package realtime
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	v1 "arc/shared/contracts/realtime/v1"
+)
+
+// memBroker is an in-process Broker shared by several hubs in tests.
+type memBroker struct {
+	mu   sync.Mutex
+	subs map[string][]chan []byte
+}
+
+func newMemBroker() *memBroker {
+	return &memBroker{subs: make(map[string][]chan []byte)}
+}
+
+func (b *memBroker) Publish(_ context.Context, channel string, payload []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, ch := range b.subs[channel] {
+		ch <- append([]byte(nil), payload...)
+	}
+	return nil
+}
+
+func (b *memBroker) Subscribe(ctx context.Context, channel string, handle func(payload []byte)) error {
+	ch := make(chan []byte, 64)
+	b.mu.Lock()
+	b.subs[channel] = append(b.subs[channel], ch)
+	b.mu.Unlock()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case p := <-ch:
+			handle(p)
+		}
+	}
+}
+
+func (b *memBroker) Close() error { return nil }
+
+func (b *memBroker) waitSubscribers(t *testing.T, channel string, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		b.mu.Lock()
+		got := len(b.subs[channel])
+		b.mu.Unlock()
+		if got >= n {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d subscribers", n)
+}
+
+func recvEnvelope(t *testing.T, c *Client) v1.Envelope {
+	t.Helper()
+	select {
+	case env := <-c.Send:
+		return env
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for envelope")
+		return v1.Envelope{}
+	}
+}
+
+func TestHub_UseBroker_FansOutAcrossNodes(t *testing.T) {
+	t.Parallel()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	broker := newMemBroker()
+	hubA, hubB := NewHub(log), NewHub(log)
+	hubA.UseBroker(ctx, broker, "test.fanout")
+	hubB.UseBroker(ctx, broker, "test.fanout")
+	broker.waitSubscribers(t, "test.fanout", 2)
+
+	clientA := NewClient("u1", "sa", 8)
+	clientB := NewClient("u2", "sb", 8)
+	hubA.GetOrCreateConversation("c1").Join(clientA)
+	hubB.GetOrCreateConversation("c1").Join(clientB)
+
+	env := mustNewEnvelope(v1.TypeSystemNew, []byte(`{"text":"hi"}`), time.Now().UTC())
+	hubA.Conversation("c1").Broadcast(env)
+
+	if got := recvEnvelope(t, clientA); got.ID != env.ID {
+		t.Fatalf("local member: expected %q, got %q", env.ID, got.ID)
+	}
+	if got := recvEnvelope(t, clientB); got.ID != env.ID || got.Type != env.Type {
+		t.Fatalf("remote member: expected %q, got %+v", env.ID, got)
+	}
+
+	// The publishing node ignores its own message instead of delivering twice.
+	select {
+	case dup := <-clientA.Send:
+		t.Fatalf("unexpected duplicate delivery %+v", dup)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+type remoteRecorder chan string
+
+func (r remoteRecorder) ObserveRemote(conversationID string, _ v1.Envelope) { r <- conversationID }
+func (r remoteRecorder) RemoteGap()                                         {}
+
+func TestHub_ObserveRemote_SeesUnjoinedConversations(t *testing.T) {
+	t.Parallel()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	broker := newMemBroker()
+	hubA, hubB := NewHub(log), NewHub(log)
+	seen := make(remoteRecorder, 1)
+	hubB.ObserveRemote(seen)
+	hubA.UseBroker(ctx, broker, "test.observe")
+	hubB.UseBroker(ctx, broker, "test.observe")
+	broker.waitSubscribers(t, "test.observe", 2)
+
+	hubA.Broadcast("c1", mustNewEnvelope(v1.TypeMessageNew, []byte(`{}`), time.Now().UTC()))
+
+	select {
+	case got := <-seen:
+		if got != "c1" {
+			t.Fatalf("expected c1, got %q", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("remote broadcast was not observed")
+	}
+}
+
+func TestNewBroker_Config(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		cfg     BrokerConfig
+		wantNil bool
+		wantErr bool
+	}{
+		{name: "disabled", cfg: BrokerConfig{}, wantNil: true},
+		{name: "none", cfg: BrokerConfig{Kind: BrokerNone}, wantNil: true},
+		{name: "redis", cfg: BrokerConfig{Kind: BrokerRedis, URL: "redis://:secret@localhost:6379/2", Channel: "c"}},
+		{name: "rediss", cfg: BrokerConfig{Kind: BrokerRedis, URL: "rediss://cache.internal", Channel: "c"}},
+		{name: "nats", cfg: BrokerConfig{Kind: BrokerNATS, URL: "nats://token@localhost:4222", Channel: "c"}},
+		{name: "missing url", cfg: BrokerConfig{Kind: BrokerRedis, Channel: "c"}, wantErr: true},
+		{name: "bad redis scheme", cfg: BrokerConfig{Kind: BrokerRedis, URL: "http://localhost", Channel: "c"}, wantErr: true},
+		{name: "bad redis db", cfg: BrokerConfig{Kind: BrokerRedis, URL: "redis://localhost/x", Channel: "c"}, wantErr: true},
+		{name: "bad nats scheme", cfg: BrokerConfig{Kind: BrokerNATS, URL: "redis://localhost", Channel: "c"}, wantErr: true},
+		{name: "channel whitespace", cfg: BrokerConfig{Kind: BrokerNATS, URL: "nats://localhost", Channel: "a b"}, wantErr: true},
+		{name: "postgres without pool", cfg: BrokerConfig{Kind: BrokerPostgres, Channel: "c"}, wantErr: true},
+		{name: "unknown kind", cfg: BrokerConfig{Kind: "kafka", URL: "kafka://localhost", Channel: "c"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := NewBroker(tt.cfg, nil)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if (b == nil) != tt.wantNil {
+				t.Fatalf("expected nil=%v, got %v", tt.wantNil, b)
+			}
+		})
+	}
+}
+
+func TestRedisBroker_PublishSubscribe(t *testing.T) {
+	t.Parallel()
+
+	addr := startFakeRedis(t, "secret")
+	b, err := NewRedisBroker("redis://:secret@" + addr)
+	if err != nil {
+		t.Fatalf("new broker: %v", err)
+	}
+	defer b.Close()
+
+	testBrokerRoundTrip(t, b)
+
+	if err := b.Ping(context.Background()); err != nil {
+		t.Fatalf("ping: %v", err)
+	}
+	bad, _ := NewRedisBroker("redis://:wrong@" + addr)
+	if err := bad.Ping(context.Background()); err == nil {
+		t.Fatalf("expected ping with a wrong password to fail")
+	}
+}
+
+func TestNATSBroker_PublishSubscribe(t *testing.T) {
+	t.Parallel()
+
+	addr := startFakeNATS(t)
+	b, err := NewNATSBroker("nats://" + addr)
+	if err != nil {
+		t.Fatalf("new broker: %v", err)
+	}
+	defer b.Close()
+
+	testBrokerRoundTrip(t, b)
+
+	if err := b.Ping(context.Background()); err != nil {
+		t.Fatalf("ping: %v", err)
+	}
+}
+
+func testBrokerRoundTrip(t *testing.T, b Broker) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	got := make(chan string, 4)
+	done := make(chan error, 1)
+	go func() {
+		done <- b.Subscribe(ctx, "arc.test", func(p []byte) { got <- string(p) })
+	}()
+
+	// Publish until the subscription is live; pub/sub drops messages sent earlier.
+	want := "payload with\r\nnewline"
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if err := b.Publish(ctx, "arc.test", []byte(want)); err != nil {
+			t.Fatalf("publish: %v", err)
+		}
+		select {
+		case p := <-got:
+			if p != want {
+				t.Fatalf("expected %q, got %q", want, p)
+			}
+			cancel()
+			select {
+			case <-done:
+			case <-time.After(2 * time.Second):
+				t.Fatalf("subscribe did not stop on cancel")
+			}
+			return
+		case <-time.After(20 * time.Millisecond):
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for message")
+		}
+	}
+}
+
+// fakePubSub tracks subscriber connections for the fake servers.
+type fakePubSub struct {
+	mu   sync.Mutex
+	subs []func(channel string, payload []byte)
+}
+
+func (f *fakePubSub) add(fn func(channel string, payload []byte)) {
+	f.mu.Lock()
+	f.subs = append(f.subs, fn)
+	f.mu.Unlock()
+}
+
+func (f *fakePubSub) publish(channel string, payload []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, fn := range f.subs {
+		fn(channel, payload)
+	}
+}
+
+func serveFake(t *testing.T, handle func(conn net.Conn)) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				handle(conn)
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func startFakeRedis(t *testing.T, password string) string {
+	ps := &fakePubSub{}
+	return serveFake(t, func(conn net.Conn) {
+		rd := bufio.NewReader(conn)
+		var wmu sync.Mutex
+		reply := func(s string) {
+			wmu.Lock()
+			defer wmu.Unlock()
+			_, _ = io.WriteString(conn, s)
+		}
+		authed := password == ""
+
+		for {
+			v, err := readRESP(rd)
+			if err != nil {
+				return
+			}
+			args, _ := v.([]any)
+			if len(args) == 0 {
+				return
+			}
+			arg := func(i int) string { b, _ := args[i].([]byte); return string(b) }
+
+			switch strings.ToUpper(arg(0)) {
+			case "AUTH":
+				if arg(len(args)-1) != password {
+					reply("-WRONGPASS invalid password\r\n")
+					continue
+				}
+				authed = true
+				reply("+OK\r\n")
+			case "SUBSCRIBE":
+				if !authed {
+					reply("-NOAUTH Authentication required.\r\n")
+					continue
+				}
+				ch := arg(1)
+				ps.add(func(channel string, payload []byte) {
+					if channel != ch {
+						return
+					}
+					reply(fmt.Sprintf("*3\r\n$7\r\nmessage\r\n$%d\r\n%s\r\n$%d\r\n%s\r\n", len(channel), channel, len(payload), payload))
+				})
+				reply(fmt.Sprintf("*3\r\n$9\r\nsubscribe\r\n$%d\r\n%s\r\n:1\r\n", len(ch), ch))
+			case "PING":
+				if !authed {
+					reply("-NOAUTH Authentication required.\r\n")
+					continue
+				}
+				reply("+PONG\r\n")
+			case "PUBLISH":
+				if !authed {
+					reply("-NOAUTH Authentication required.\r\n")
+					continue
+				}
+				ps.publish(arg(1), args[2].([]byte))
+				reply(":1\r\n")
+			default:
+				reply("-ERR unknown command\r\n")
+			}
+		}
+	})
+}
+
+func startFakeNATS(t *testing.T) string {
+	ps := &fakePubSub{}
+	return serveFake(t, func(conn net.Conn) {
+		rd := bufio.NewReader(conn)
+		var wmu sync.Mutex
+		send := func(s string) {
+			wmu.Lock()
+			defer wmu.Unlock()
+			_, _ = io.WriteString(conn, s)
+		}
+
+		send(`INFO {"server_id":"fake","max_payload":1048576}` + "\r\n")
+		for {
+			line, err := readCRLFLine(rd)
+			if err != nil {
+				return
+			}
+			fields := strings.Fields(string(line))
+			if len(fields) == 0 {
+				continue
+			}
+
+			switch fields[0] {
+			case "CONNECT":
+			case "PING":
+				send("PONG\r\n")
+			case "SUB":
+				subject, sid := fields[1], fields[2]
+				ps.add(func(channel string, payload []byte) {
+					if channel != subject {
+						return
+					}
+					send(fmt.Sprintf("MSG %s %s %d\r\n%s\r\n", channel, sid, len(payload), payload))
+				})
+			case "PUB":
+				n, _ := strconv.Atoi(fields[len(fields)-1])
+				data := make([]byte, n+2)
+				if _, err := io.ReadFull(rd, data); err != nil {
+					return
+				}
+				ps.publish(fields[1], data[:n])
+			default:
+				send("-ERR 'Unknown Protocol Operation'\r\n")
+			}
+		}
+	})
+}
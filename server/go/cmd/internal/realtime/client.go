@@ -1,37 +1,105 @@
 package realtime
 
 import (
+	"slices"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	v1 "arc/shared/contracts/realtime/v1"
+
+	"github.com/coder/websocket"
 )
 
 // Client represents one connected websocket session.
 //
 // Design notes:
 // - Send is intentionally NOT closed by the server to avoid panics from concurrent broadcasters.
+// - Producers enqueue through Offer so the backpressure policy applies uniformly.
 // - done is used to signal goroutines to stop.
 // - Close is idempotent.
 type Client struct {
 	SessionID string
 	UserID    string
 	Send      chan v1.Envelope
+	// Priority carries acks and errors ahead of Send; nil when the lane is disabled.
+	Priority chan v1.Envelope
+
+	// ConnectedAt is when the session was established.
+	ConnectedAt time.Time
 
 	done      chan struct{}
 	closeOnce sync.Once
+	// closeCode and closeReason are set by CloseWithStatus before done is closed.
+	closeCode   websocket.StatusCode
+	closeReason string
+
+	// lastActivity is the unix nano time of the last inbound envelope.
+	lastActivity atomic.Int64
+	// conversations are the ids of the joined conversations, oldest join first.
+	convsMu       sync.Mutex
+	conversations []string
+	// hello is what the client announced in its last hello (nil before the first).
+	hello atomic.Pointer[ClientHello]
+	// offered are the capabilities the session's transport supports.
+	offered v1.Capabilities
+	// actorSession is the auth session messages are attributed to when it differs
+	// from SessionID, as for the transient clients of unary gRPC calls.
+	actorSession string
+
+	// cursors is the highest message.new seq written per conversation, embedded in resume tokens.
+	cursorsMu sync.Mutex
+	cursors   map[string]int64
+
+	// lanes keep seq-tagged envelopes in seq order per conversation.
+	lanesMu sync.Mutex
+	lanes   map[string]*orderedLane
+
+	policy     BackpressurePolicy
+	dropped    atomic.Int64
+	dropStreak atomic.Int64
+	slow       chan struct{}
+	slowOnce   sync.Once
 }
 
-// NewClient constructs a Client with a bounded send queue.
+// NewClient constructs a Client with a bounded send queue and the default
+// drop-newest policy.
 func NewClient(userID, sessionID string, sendQueueSize int) *Client {
+	return NewClientWithPolicy(userID, sessionID, sendQueueSize, BackpressurePolicy{})
+}
+
+// NewClientWithPolicy constructs a Client whose send queue follows policy.
+func NewClientWithPolicy(userID, sessionID string, sendQueueSize int, policy BackpressurePolicy) *Client {
 	if sendQueueSize <= 0 {
 		sendQueueSize = 64
 	}
-	return &Client{
-		SessionID: sessionID,
-		UserID:    userID,
-		Send:      make(chan v1.Envelope, sendQueueSize),
-		done:      make(chan struct{}),
+	policy.Mode = normalizeBackpressureMode(policy.Mode)
+
+	now := time.Now().UTC()
+	c := &Client{
+		SessionID:   sessionID,
+		UserID:      userID,
+		Send:        make(chan v1.Envelope, sendQueueSize),
+		ConnectedAt: now,
+		done:        make(chan struct{}),
+		policy:      policy,
+		slow:        make(chan struct{}),
+		offered:     serverCapabilities,
+	}
+	c.lastActivity.Store(now.UnixNano())
+	if policy.PriorityQueueSize > 0 {
+		c.Priority = make(chan v1.Envelope, policy.PriorityQueueSize)
 	}
+	return c
+}
+
+// ActorSession is the auth session the client's messages and actions are
+// attributed to: SessionID unless the client stands in for another session.
+func (c *Client) ActorSession() string {
+	if c.actorSession != "" {
+		return c.actorSession
+	}
+	return c.SessionID
 }
 
 // Done returns a channel that is closed when the client is shutting down.
@@ -47,10 +115,130 @@ func (c *Client) Done() <-chan struct{} {
 // Close signals the client goroutines to stop (idempotent).
 // It does NOT close Send to keep broadcast safe under concurrency.
 func (c *Client) Close() {
+	c.CloseWithStatus(0, "")
+}
+
+// CloseWithStatus is Close, but the session's connection is closed with code and
+// reason instead of the generic policy violation (idempotent: the first call wins).
+func (c *Client) CloseWithStatus(code websocket.StatusCode, reason string) {
 	if c == nil {
 		return
 	}
 	c.closeOnce.Do(func() {
+		c.closeCode, c.closeReason = code, reason
 		close(c.done)
+		c.stopLanes()
 	})
 }
+
+// closeStatus returns the status the connection is closed with once Done is closed.
+func (c *Client) closeStatus() (websocket.StatusCode, string) {
+	if c.closeCode == 0 {
+		return websocket.StatusPolicyViolation, "connection closed by server"
+	}
+	return c.closeCode, c.closeReason
+}
+
+// Touch records inbound activity at now.
+func (c *Client) Touch(now time.Time) {
+	c.lastActivity.Store(now.UnixNano())
+}
+
+// LastActivity returns the time of the last inbound envelope, or ConnectedAt.
+func (c *Client) LastActivity() time.Time {
+	return time.Unix(0, c.lastActivity.Load()).UTC()
+}
+
+// SetConversation records conversationID as the only conversation the client joined.
+func (c *Client) SetConversation(conversationID string) {
+	c.convsMu.Lock()
+	defer c.convsMu.Unlock()
+	c.conversations = []string{conversationID}
+}
+
+// AddConversation records a conversation the client joined in addition to the others.
+func (c *Client) AddConversation(conversationID string) {
+	c.convsMu.Lock()
+	defer c.convsMu.Unlock()
+	c.conversations = append(slices.DeleteFunc(c.conversations, func(id string) bool { return id == conversationID }), conversationID)
+}
+
+// RemoveConversation records that the client left conversationID.
+func (c *Client) RemoveConversation(conversationID string) {
+	c.convsMu.Lock()
+	defer c.convsMu.Unlock()
+	c.conversations = slices.DeleteFunc(c.conversations, func(id string) bool { return id == conversationID })
+}
+
+// Conversation returns the most recently joined conversation id, or "".
+func (c *Client) Conversation() string {
+	c.convsMu.Lock()
+	defer c.convsMu.Unlock()
+	if n := len(c.conversations); n > 0 {
+		return c.conversations[n-1]
+	}
+	return ""
+}
+
+// Conversations returns the joined conversation ids, oldest join first.
+func (c *Client) Conversations() []string {
+	c.convsMu.Lock()
+	defer c.convsMu.Unlock()
+	return slices.Clone(c.conversations)
+}
+
+// ClientHello is the client build and the capabilities negotiated in hello.
+type ClientHello struct {
+	Version      string
+	Platform     string
+	Capabilities v1.Capabilities
+}
+
+// SetHello records the outcome of the client's hello.
+func (c *Client) SetHello(h ClientHello) {
+	c.hello.Store(&h)
+}
+
+// Hello returns the outcome of the client's last hello, or the zero value before
+// it said hello.
+func (c *Client) Hello() ClientHello {
+	if h := c.hello.Load(); h != nil {
+		return *h
+	}
+	return ClientHello{}
+}
+
+// QueueDepth returns the number of envelopes waiting to be written.
+func (c *Client) QueueDepth() int {
+	return len(c.Send) + len(c.Priority)
+}
+
+// AdvanceCursor records seq as delivered in conversationID if it is newer.
+// At most wsMaxResumeConvs conversations are tracked; further ones are ignored.
+func (c *Client) AdvanceCursor(conversationID string, seq int64) {
+	c.cursorsMu.Lock()
+	defer c.cursorsMu.Unlock()
+
+	cur, ok := c.cursors[conversationID]
+	if !ok && len(c.cursors) >= wsMaxResumeConvs {
+		return
+	}
+	if c.cursors == nil {
+		c.cursors = make(map[string]int64)
+	}
+	if seq > cur || !ok {
+		c.cursors[conversationID] = seq
+	}
+}
+
+// Cursors returns a copy of the delivered cursors.
+func (c *Client) Cursors() map[string]int64 {
+	c.cursorsMu.Lock()
+	defer c.cursorsMu.Unlock()
+
+	out := make(map[string]int64, len(c.cursors))
+	for id, seq := range c.cursors {
+		out[id] = seq
+	}
+	return out
+}
@@ -0,0 +1,160 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	v1 "arc/shared/contracts/realtime/v1"
+)
+
+// ErrInvalidBotMessage is returned by PostBotMessage for input that message.send
+// would also reject.
+var ErrInvalidBotMessage = errors.New("realtime: invalid bot message")
+
+// CommandMessage is a newly stored message whose text starts with "/".
+type CommandMessage struct {
+	ConversationID string
+	ServerMsgID    string
+	Seq            int64
+	SenderUserID   string
+	Text           string
+	ServerTS       time.Time
+}
+
+// CommandDispatcher routes slash commands to integrations such as bots.
+type CommandDispatcher interface {
+	// DispatchCommand must not block the session loop; implementations queue work.
+	DispatchCommand(msg CommandMessage)
+}
+
+// WithCommandDispatcher hands messages starting with "/" to d after fanout.
+func WithCommandDispatcher(d CommandDispatcher) GatewayOption {
+	return func(g *WSGateway) { g.commands = d }
+}
+
+// dispatchCommand forwards stored to the command dispatcher when it looks like
+// a slash command. Anonymous senders are skipped.
+func (g *WSGateway) dispatchCommand(client *Client, stored StoredMessage) {
+	if g.commands == nil || client.UserID == "" || !strings.HasPrefix(stored.Text, "/") {
+		return
+	}
+	g.commands.DispatchCommand(CommandMessage{
+		ConversationID: stored.ConversationID,
+		ServerMsgID:    stored.ServerMsgID,
+		Seq:            stored.Seq,
+		SenderUserID:   client.UserID,
+		Text:           stored.Text,
+		ServerTS:       stored.ServerTS,
+	})
+}
+
+// BotMessageInput is a message posted by a bot through the server API.
+type BotMessageInput struct {
+	ConversationID string
+	BotID          string
+	ClientMsgID    string
+	Text           string
+	// ReplyToServerMsgID is optional; it must reference a message of ConversationID.
+	ReplyToServerMsgID string
+}
+
+// PostBotMessage stores a message attributed to in.BotID and fans it out like
+// message.send. Callers authorize the bot for the conversation. Bot messages are
+// never dispatched as commands, so bots cannot trigger each other.
+func (g *WSGateway) PostBotMessage(ctx context.Context, in BotMessageInput) (AppendMessageResult, error) {
+	now := time.Now().UTC()
+	msg, err := g.botAppendInput(ctx, in, now)
+	if err != nil {
+		return AppendMessageResult{}, err
+	}
+	appendStart := time.Now()
+	res, err := g.store.AppendMessage(ctx, msg)
+	observeAppend(appendStart, err)
+	if err != nil {
+		return AppendMessageResult{}, err
+	}
+	if !res.Duplicated {
+		g.fanoutBotMessage(res.Stored, now)
+	}
+	return res, nil
+}
+
+// PostBotMessages stores up to MaxAppendBatch bot messages of one conversation with
+// a single AppendMessages call and fans out the new ones in seq order. Either every
+// message is valid and stored, or none is.
+func (g *WSGateway) PostBotMessages(ctx context.Context, in []BotMessageInput) ([]AppendMessageResult, error) {
+	if len(in) == 0 || len(in) > MaxAppendBatch {
+		return nil, fmt.Errorf("%w: batch must have 1-%d messages", ErrInvalidBotMessage, MaxAppendBatch)
+	}
+	now := time.Now().UTC()
+	msgs := make([]AppendMessageInput, 0, len(in))
+	for i, m := range in {
+		if m.ConversationID != in[0].ConversationID {
+			return nil, fmt.Errorf("%w: messages[%d]: all messages must target one conversation", ErrInvalidBotMessage, i)
+		}
+		msg, err := g.botAppendInput(ctx, m, now)
+		if errors.Is(err, ErrInvalidBotMessage) {
+			return nil, fmt.Errorf("%w: messages[%d]%s", ErrInvalidBotMessage, i, strings.TrimPrefix(err.Error(), ErrInvalidBotMessage.Error()))
+		}
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, msg)
+	}
+	appendStart := time.Now()
+	res, err := g.store.AppendMessages(ctx, msgs)
+	observeAppend(appendStart, err)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range res {
+		if !r.Duplicated {
+			g.fanoutBotMessage(r.Stored, now)
+		}
+	}
+	return res, nil
+}
+
+// botAppendInput validates in like message.send and builds its store input.
+func (g *WSGateway) botAppendInput(ctx context.Context, in BotMessageInput, now time.Time) (AppendMessageInput, error) {
+	convID := strings.TrimSpace(in.ConversationID)
+	botID := strings.TrimSpace(in.BotID)
+	clientMsgID := strings.TrimSpace(in.ClientMsgID)
+	if convID == "" || botID == "" {
+		return AppendMessageInput{}, fmt.Errorf("%w: missing conversation or bot", ErrInvalidBotMessage)
+	}
+	if clientMsgID == "" {
+		return AppendMessageInput{}, fmt.Errorf("%w: missing client_msg_id", ErrInvalidBotMessage)
+	}
+	text, err := g.messageText(in.Text)
+	if err != nil {
+		return AppendMessageInput{}, fmt.Errorf("%w: %v", ErrInvalidBotMessage, err)
+	}
+
+	entities, err := g.messageEntities(ctx, convID, text)
+	if err != nil {
+		return AppendMessageInput{}, err
+	}
+	return AppendMessageInput{
+		ConversationID:     convID,
+		ClientMsgID:        clientMsgID,
+		SenderBotID:        botID,
+		Text:               text,
+		Now:                now,
+		ReplyToServerMsgID: strings.TrimSpace(in.ReplyToServerMsgID),
+		Entities:           entities,
+	}, nil
+}
+
+// fanoutBotMessage broadcasts a newly stored bot message and notifies offline members.
+func (g *WSGateway) fanoutBotMessage(stored StoredMessage, now time.Time) {
+	payload, _ := json.Marshal(messagePayload(stored))
+	g.hub.Broadcast(stored.ConversationID, withSeq(mustNewEnvelope(v1.TypeMessageNew, payload, now), stored.ConversationID, stored.Seq))
+	if g.offline != nil {
+		g.offline.NotifyOffline(offlineMessage("", stored))
+	}
+}
@@ -0,0 +1,157 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	v1 "arc/shared/contracts/realtime/v1"
+)
+
+type recordingCommands struct {
+	got []CommandMessage
+}
+
+func (d *recordingCommands) DispatchCommand(msg CommandMessage) {
+	d.got = append(d.got, msg)
+}
+
+func TestGatewayDispatchCommand(t *testing.T) {
+	t.Parallel()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	d := &recordingCommands{}
+	g := NewWSGateway(log, NewHub(log), NewInMemoryStore(), nil, nil, WithCommandDispatcher(d))
+
+	g.dispatchCommand(&Client{SessionID: "s1", UserID: "u1"}, StoredMessage{ConversationID: "c1", Text: "hello"})
+	g.dispatchCommand(&Client{SessionID: "s0"}, StoredMessage{ConversationID: "c1", Text: "/remind me"})
+	if len(d.got) != 0 {
+		t.Fatalf("expected plain text and anonymous senders to be skipped, got %+v", d.got)
+	}
+
+	g.dispatchCommand(&Client{SessionID: "s1", UserID: "u1"}, StoredMessage{ConversationID: "c1", ServerMsgID: "m1", Seq: 2, Text: "/remind me"})
+	if len(d.got) != 1 || d.got[0].SenderUserID != "u1" || d.got[0].ServerMsgID != "m1" {
+		t.Fatalf("unexpected commands %+v", d.got)
+	}
+}
+
+func TestPostBotMessage(t *testing.T) {
+	t.Parallel()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	hub := NewHub(log)
+	d := &recordingCommands{}
+	n := &recordingNotifier{}
+	g := NewWSGateway(log, hub, NewInMemoryStore(), nil, nil, WithCommandDispatcher(d), WithOfflineNotifier(n))
+
+	c := NewClient("u1", "s1", 4)
+	hub.AddClient(c)
+	hub.GetOrCreateConversation("c1").Join(c)
+
+	ctx := context.Background()
+	res, err := g.PostBotMessage(ctx, BotMessageInput{ConversationID: "c1", BotID: "b1", ClientMsgID: "k1", Text: " reminder set "})
+	if err != nil {
+		t.Fatalf("PostBotMessage: %v", err)
+	}
+	if res.Stored.SenderBotID != "b1" || res.Stored.SenderSession != "" || res.Stored.Text != "reminder set" {
+		t.Fatalf("unexpected stored message %+v", res.Stored)
+	}
+
+	select {
+	case env := <-c.Send:
+		var p v1.MessageNewPayload
+		if err := json.Unmarshal(env.Payload, &p); err != nil {
+			t.Fatalf("decode payload: %v", err)
+		}
+		if env.Type != v1.TypeMessageNew || p.SenderBotID != "b1" {
+			t.Fatalf("unexpected fanout %s %+v", env.Type, p)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected message.new fanout")
+	}
+
+	if len(d.got) != 0 {
+		t.Fatalf("bot messages must not be dispatched as commands")
+	}
+	if len(n.got) != 1 || n.got[0].SenderUserID != "" {
+		t.Fatalf("unexpected offline notifications %+v", n.got)
+	}
+
+	dup, err := g.PostBotMessage(ctx, BotMessageInput{ConversationID: "c1", BotID: "b1", ClientMsgID: "k1", Text: "reminder set"})
+	if err != nil || !dup.Duplicated || dup.Stored.ServerMsgID != res.Stored.ServerMsgID {
+		t.Fatalf("expected idempotent retry, got %+v err=%v", dup, err)
+	}
+
+	for _, in := range []BotMessageInput{
+		{ConversationID: "c1", BotID: "b1", Text: "x"},
+		{ConversationID: "c1", BotID: "b1", ClientMsgID: "k2", Text: "  "},
+		{ConversationID: "c1", ClientMsgID: "k3", Text: "x"},
+	} {
+		if _, err := g.PostBotMessage(ctx, in); !errors.Is(err, ErrInvalidBotMessage) {
+			t.Fatalf("expected ErrInvalidBotMessage for %+v, got %v", in, err)
+		}
+	}
+}
+
+func TestPostBotMessages(t *testing.T) {
+	t.Parallel()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	hub := NewHub(log)
+	g := NewWSGateway(log, hub, NewInMemoryStore(), nil, nil)
+
+	c := NewClient("u1", "s1", 8)
+	hub.AddClient(c)
+	hub.GetOrCreateConversation("c1").Join(c)
+
+	ctx := context.Background()
+	res, err := g.PostBotMessages(ctx, []BotMessageInput{
+		{ConversationID: "c1", BotID: "b1", ClientMsgID: "k1", Text: "one"},
+		{ConversationID: "c1", BotID: "b1", ClientMsgID: "k2", Text: "two"},
+		{ConversationID: "c1", BotID: "b1", ClientMsgID: "k1", Text: "one again"},
+	})
+	if err != nil {
+		t.Fatalf("PostBotMessages: %v", err)
+	}
+	if len(res) != 3 || res[0].Stored.Seq != 1 || res[1].Stored.Seq != 2 || !res[2].Duplicated {
+		t.Fatalf("unexpected results %+v", res)
+	}
+
+	for _, want := range []int64{1, 2} {
+		select {
+		case env := <-c.Send:
+			var p v1.MessageNewPayload
+			if err := json.Unmarshal(env.Payload, &p); err != nil {
+				t.Fatalf("decode payload: %v", err)
+			}
+			if p.Seq != want {
+				t.Fatalf("fanout seq = %d, want %d", p.Seq, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("expected message.new fanout for seq %d", want)
+		}
+	}
+	select {
+	case env := <-c.Send:
+		t.Fatalf("duplicate must not fan out, got %s", env.Type)
+	default:
+	}
+
+	_, err = g.PostBotMessages(ctx, []BotMessageInput{
+		{ConversationID: "c1", BotID: "b1", ClientMsgID: "k3", Text: "three"},
+		{ConversationID: "c1", BotID: "b1", ClientMsgID: "k4", Text: " "},
+	})
+	if !errors.Is(err, ErrInvalidBotMessage) {
+		t.Fatalf("expected ErrInvalidBotMessage, got %v", err)
+	}
+	if _, err := g.PostBotMessages(ctx, []BotMessageInput{
+		{ConversationID: "c1", BotID: "b1", ClientMsgID: "k5", Text: "x"},
+		{ConversationID: "c2", BotID: "b1", ClientMsgID: "k6", Text: "y"},
+	}); !errors.Is(err, ErrInvalidBotMessage) {
+		t.Fatalf("expected ErrInvalidBotMessage for mixed conversations, got %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+package realtime
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"arc/client/conformance"
+	v1 "arc/shared/contracts/realtime/v1"
+
+	"github.com/coder/websocket"
+)
+
+// pipeSession connects a conformance client to runSession in-process.
+type pipeSession struct {
+	toServer chan v1.Envelope
+	toClient chan v1.Envelope
+	closed   chan struct{}
+	once     sync.Once
+}
+
+func newPipeSession() *pipeSession {
+	return &pipeSession{
+		toServer: make(chan v1.Envelope, 64),
+		toClient: make(chan v1.Envelope, 64),
+		closed:   make(chan struct{}),
+	}
+}
+
+func (p *pipeSession) close() {
+	p.once.Do(func() { close(p.closed) })
+}
+
+func pipeRead(ctx context.Context, ch chan v1.Envelope, closed chan struct{}) (v1.Envelope, error) {
+	select {
+	case env := <-ch:
+		return env, nil
+	case <-closed:
+		return v1.Envelope{}, io.EOF
+	case <-ctx.Done():
+		return v1.Envelope{}, ctx.Err()
+	}
+}
+
+func pipeWrite(ctx context.Context, ch chan v1.Envelope, closed chan struct{}, env v1.Envelope) error {
+	select {
+	case ch <- env:
+		return nil
+	case <-closed:
+		return io.ErrClosedPipe
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// pipeServerSide implements sessionConn.
+type pipeServerSide struct{ *pipeSession }
+
+func (s pipeServerSide) ReadEnvelope(ctx context.Context) (v1.Envelope, error) {
+	return pipeRead(ctx, s.toServer, s.closed)
+}
+
+func (s pipeServerSide) WriteEnvelope(ctx context.Context, env v1.Envelope) error {
+	return pipeWrite(ctx, s.toClient, s.closed, env)
+}
+
+func (s pipeServerSide) Ping(context.Context) error { return nil }
+
+func (s pipeServerSide) Close(websocket.StatusCode, string) error {
+	s.close()
+	return nil
+}
+
+// pipeClientSide implements conformance.Conn.
+type pipeClientSide struct{ *pipeSession }
+
+func (c pipeClientSide) Read(ctx context.Context) (v1.Envelope, error) {
+	return pipeRead(ctx, c.toClient, c.closed)
+}
+
+func (c pipeClientSide) Write(ctx context.Context, env v1.Envelope) error {
+	return pipeWrite(ctx, c.toServer, c.closed, env)
+}
+
+func (c pipeClientSide) Close() error {
+	c.close()
+	return nil
+}
+
+func TestWSGateway_Conformance(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	g := NewWSGateway(log, NewHub(log), NewInMemoryStore(), nil, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var sessions atomic.Int64
+	var wg sync.WaitGroup
+	dial := func(context.Context) (conformance.Conn, error) {
+		p := newPipeSession()
+		id := strconv.FormatInt(sessions.Add(1), 10)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g.runSession(ctx, pipeServerSide{p}, "u"+id, "s"+id)
+		}()
+		return pipeClientSide{p}, nil
+	}
+
+	rep := conformance.Run(ctx, conformance.Config{Dial: dial, StepTimeout: 2 * time.Second, QuietPeriod: 100 * time.Millisecond})
+	for _, r := range rep.Results {
+		if !r.Passed {
+			t.Errorf("%s: %s", r.Name, r.Error)
+		}
+	}
+	if !rep.OK() {
+		t.Fatalf("conformance: passed=%d failed=%d", rep.Passed, rep.Failed)
+	}
+	cancel()
+	wg.Wait()
+}
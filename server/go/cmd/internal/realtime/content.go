@@ -0,0 +1,257 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"arc/cmd/internal/dbtrace"
+)
+
+// Message entity types.
+const (
+	EntityMention = "mention"
+	EntityURL     = "url"
+)
+
+// MessageEntity marks a span of message text the server recognized.
+// Offset and Length count UTF-16 code units, matching client string indexing.
+type MessageEntity struct {
+	Type   string `json:"type"`
+	Offset int    `json:"offset"`
+	Length int    `json:"length"`
+	// UserID is the mentioned member (mentions only).
+	UserID string `json:"user_id,omitempty"`
+	// URL is the normalized link target (urls only).
+	URL string `json:"url,omitempty"`
+}
+
+// MentionResolver resolves @usernames to user IDs among a conversation's members.
+type MentionResolver interface {
+	// ResolveMentions maps normalized usernames to user IDs; non-members are omitted.
+	ResolveMentions(ctx context.Context, conversationID string, usernames []string) (map[string]string, error)
+}
+
+var (
+	// mentionRE requires a non-word character (or start of text) before '@' so
+	// e-mail addresses are not treated as mentions. Group 1 is the username.
+	mentionRE = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_@.])@([A-Za-z0-9_][A-Za-z0-9_.-]{1,30}[A-Za-z0-9_])`)
+	urlRE     = regexp.MustCompile(`(?i)\bhttps?://[^\s<>"'` + "`" + `]+`)
+)
+
+// entitySpan is a candidate entity in byte offsets.
+type entitySpan struct {
+	start, end int
+	entity     MessageEntity
+	username   string
+}
+
+// extractEntities resolves mentions and links in text. Mentions of users who are
+// not members are dropped. resolver may be nil, in which case only links are returned.
+func extractEntities(ctx context.Context, resolver MentionResolver, conversationID, text string) ([]MessageEntity, error) {
+	spans := parseEntitySpans(text)
+	if len(spans) == 0 {
+		return nil, nil
+	}
+
+	var usernames []string
+	seen := make(map[string]struct{})
+	for _, sp := range spans {
+		if sp.entity.Type != EntityMention {
+			continue
+		}
+		if _, ok := seen[sp.username]; !ok {
+			seen[sp.username] = struct{}{}
+			usernames = append(usernames, sp.username)
+		}
+	}
+
+	var resolved map[string]string
+	if len(usernames) > 0 && resolver != nil {
+		var err error
+		resolved, err = resolver.ResolveMentions(ctx, conversationID, usernames)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	out := make([]MessageEntity, 0, len(spans))
+	u16 := 0
+	prev := 0
+	for _, sp := range spans {
+		if sp.entity.Type == EntityMention {
+			userID, ok := resolved[sp.username]
+			if !ok {
+				continue
+			}
+			sp.entity.UserID = userID
+		}
+		u16 += utf16Len(text[prev:sp.start])
+		sp.entity.Offset = u16
+		sp.entity.Length = utf16Len(text[sp.start:sp.end])
+		u16 += sp.entity.Length
+		prev = sp.end
+		out = append(out, sp.entity)
+		if len(out) == maxMessageEntities {
+			break
+		}
+	}
+	if len(out) == 0 {
+		return nil, nil
+	}
+	return out, nil
+}
+
+// messageEntities runs the content pipeline for text sent to conversationID.
+// Mentions resolve only when the membership store supports it.
+func (g *WSGateway) messageEntities(ctx context.Context, conversationID, text string) ([]MessageEntity, error) {
+	resolver, _ := g.members.(MentionResolver)
+	entities, err := extractEntities(ctx, resolver, conversationID, text)
+	if err != nil {
+		return nil, fmt.Errorf("mention resolve: %w", err)
+	}
+	return entities, nil
+}
+
+// parseEntitySpans finds candidate mentions and links, ordered by position.
+// Mentions inside links (e.g. https://example.com/@user) are ignored.
+func parseEntitySpans(text string) []entitySpan {
+	var spans []entitySpan
+
+	for _, m := range urlRE.FindAllStringIndex(text, -1) {
+		start, end := m[0], trimURLEnd(text, m[0], m[1])
+		u, err := url.Parse(text[start:end])
+		if err != nil || u.Host == "" {
+			continue
+		}
+		spans = append(spans, entitySpan{start: start, end: end, entity: MessageEntity{Type: EntityURL, URL: u.String()}})
+	}
+	links := len(spans)
+
+	for _, m := range mentionRE.FindAllStringSubmatchIndex(text, -1) {
+		start, end := m[2]-1, m[3] // include '@'
+		overlaps := false
+		for _, l := range spans[:links] {
+			if start < l.end && end > l.start {
+				overlaps = true
+				break
+			}
+		}
+		if overlaps {
+			continue
+		}
+		spans = append(spans, entitySpan{
+			start:    start,
+			end:      end,
+			entity:   MessageEntity{Type: EntityMention},
+			username: strings.ToLower(text[m[2]:m[3]]),
+		})
+	}
+
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+	return spans
+}
+
+// trimURLEnd drops trailing punctuation that usually belongs to the sentence,
+// keeping a closing parenthesis when the link itself opened one.
+func trimURLEnd(text string, start, end int) int {
+	for end > start {
+		c := text[end-1]
+		switch c {
+		case '.', ',', ';', ':', '!', '?', '\'', '"', ']', '}':
+			end--
+			continue
+		case ')':
+			if strings.Count(text[start:end], "(") < strings.Count(text[start:end], ")") {
+				end--
+				continue
+			}
+		}
+		break
+	}
+	return end
+}
+
+func utf16Len(s string) int {
+	n := 0
+	for len(s) > 0 {
+		r, size := utf8.DecodeRuneInString(s)
+		s = s[size:]
+		n += utf16.RuneLen(r)
+	}
+	return n
+}
+
+// entitiesJSON renders entities for a JSONB column (nil when empty).
+func entitiesJSON(entities []MessageEntity) *string {
+	if len(entities) == 0 {
+		return nil
+	}
+	b, err := json.Marshal(entities)
+	if err != nil {
+		return nil
+	}
+	s := string(b)
+	return &s
+}
+
+// decodeEntities parses a JSONB entities column (NULL yields nil).
+func decodeEntities(raw []byte) ([]MessageEntity, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var out []MessageEntity
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ResolveMentions implements MentionResolver against users.username_norm.
+func (s *PostgresMembershipStore) ResolveMentions(ctx context.Context, conversationID string, usernames []string) (map[string]string, error) {
+	ctx = dbtrace.WithOp(ctx, "realtime.resolve_mentions")
+	if s == nil || s.pool == nil {
+		return nil, errors.New("realtime: nil membership store")
+	}
+	conversationID = strings.TrimSpace(conversationID)
+	if conversationID == "" || len(usernames) == 0 {
+		return nil, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	users := pgIdent(s.schema, "users")
+	members := pgIdent(s.schema, "conversation_members")
+
+	rows, err := s.pool.Query(ctx,
+		`SELECT u.username_norm, u.id
+		   FROM `+members+` m
+		   JOIN `+users+` u ON u.id = m.user_id
+		  WHERE m.conversation_id = $1 AND u.username_norm = ANY($2)`,
+		conversationID, usernames,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[string]string, len(usernames))
+	for rows.Next() {
+		var username, userID string
+		if err := rows.Scan(&username, &userID); err != nil {
+			return nil, err
+		}
+		out[username] = userID
+	}
+	return out, rows.Err()
+}
+
+var _ MentionResolver = (*PostgresMembershipStore)(nil)
@@ -0,0 +1,131 @@
+package realtime
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type fakeMentionResolver map[string]string
+
+func (f fakeMentionResolver) ResolveMentions(_ context.Context, _ string, usernames []string) (map[string]string, error) {
+	out := make(map[string]string)
+	for _, u := range usernames {
+		if id, ok := f[u]; ok {
+			out[u] = id
+		}
+	}
+	return out, nil
+}
+
+type failingMentionResolver struct{}
+
+func (failingMentionResolver) ResolveMentions(context.Context, string, []string) (map[string]string, error) {
+	return nil, errors.New("boom")
+}
+
+func TestExtractEntities_MentionsAndLinks(t *testing.T) {
+	t.Parallel()
+
+	resolver := fakeMentionResolver{"alice": "u-alice", "bob_2": "u-bob"}
+	text := "hi @Alice and @bob_2, see https://example.com/a_(b). mail x@alice.dev @ghost"
+
+	got, err := extractEntities(context.Background(), resolver, "c1", text)
+	if err != nil {
+		t.Fatalf("extract: %v", err)
+	}
+	want := []MessageEntity{
+		{Type: EntityMention, Offset: 3, Length: 6, UserID: "u-alice"},
+		{Type: EntityMention, Offset: 14, Length: 6, UserID: "u-bob"},
+		{Type: EntityURL, Offset: 26, Length: 25, URL: "https://example.com/a_(b)"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d entities, got %+v", len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("entity %d: expected %+v, got %+v", i, want[i], got[i])
+		}
+	}
+}
+
+func TestExtractEntities_UTF16Offsets(t *testing.T) {
+	t.Parallel()
+
+	// U+1F600 is two UTF-16 code units; "é" is one.
+	text := "\U0001F600 é @alice"
+	got, err := extractEntities(context.Background(), fakeMentionResolver{"alice": "u1"}, "c1", text)
+	if err != nil {
+		t.Fatalf("extract: %v", err)
+	}
+	if len(got) != 1 || got[0].Offset != 5 || got[0].Length != 6 {
+		t.Fatalf("unexpected entities %+v", got)
+	}
+}
+
+func TestExtractEntities_MentionInsideLinkIgnored(t *testing.T) {
+	t.Parallel()
+
+	got, err := extractEntities(context.Background(), fakeMentionResolver{"alice": "u1"}, "c1", "https://x.example/@alice.")
+	if err != nil {
+		t.Fatalf("extract: %v", err)
+	}
+	if len(got) != 1 || got[0].Type != EntityURL || got[0].URL != "https://x.example/@alice" {
+		t.Fatalf("unexpected entities %+v", got)
+	}
+}
+
+func TestExtractEntities_NoResolverKeepsLinks(t *testing.T) {
+	t.Parallel()
+
+	got, err := extractEntities(context.Background(), nil, "c1", "@alice http://a.example")
+	if err != nil {
+		t.Fatalf("extract: %v", err)
+	}
+	if len(got) != 1 || got[0].Type != EntityURL || got[0].Offset != 7 {
+		t.Fatalf("unexpected entities %+v", got)
+	}
+
+	if got, err := extractEntities(context.Background(), nil, "c1", "plain text"); err != nil || got != nil {
+		t.Fatalf("expected no entities, got %+v, %v", got, err)
+	}
+}
+
+func TestExtractEntities_ResolverError(t *testing.T) {
+	t.Parallel()
+
+	if _, err := extractEntities(context.Background(), failingMentionResolver{}, "c1", "@alice"); err == nil {
+		t.Fatalf("expected resolver error")
+	}
+	// No mention candidates: the resolver is not consulted.
+	if _, err := extractEntities(context.Background(), failingMentionResolver{}, "c1", "hello"); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestInMemoryStore_EntitiesFollowEdits(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	st := NewInMemoryStore()
+	res, err := st.AppendMessage(ctx, AppendMessageInput{
+		ConversationID: "c1",
+		ClientMsgID:    "cm-1",
+		SenderSession:  "s1",
+		Text:           "@alice",
+		Entities:       []MessageEntity{{Type: EntityMention, Offset: 0, Length: 6, UserID: "u1"}},
+	})
+	if err != nil || len(res.Stored.Entities) != 1 {
+		t.Fatalf("unexpected append %+v, %v", res, err)
+	}
+
+	edit, err := st.EditMessage(ctx, EditMessageInput{
+		MessageActor:   MessageActor{ActorSession: "s1"},
+		ConversationID: "c1",
+		ServerMsgID:    res.Stored.ServerMsgID,
+		Text:           "no mentions",
+	})
+	if err != nil || len(edit.Stored.Entities) != 0 {
+		t.Fatalf("expected edit to replace entities, got %+v, %v", edit, err)
+	}
+}
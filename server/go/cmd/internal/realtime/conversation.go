@@ -20,6 +20,11 @@ type Conversation struct {
 
 	mu      sync.RWMutex
 	members map[string]*Client
+
+	// observe shows broadcasts to inbox subscribers and firehose streams.
+	observe func(conversationID string, env v1.Envelope)
+	// relay forwards broadcasts to other nodes; nil keeps fanout node-local.
+	relay func(conversationID string, env v1.Envelope)
 }
 
 // NewConversation constructs a conversation.
@@ -68,13 +73,79 @@ func (c *Conversation) Leave(sessionID string) {
 	c.log.Info("conversation.member.leave", "conversation_id", c.ID, "session_id", sessionID)
 }
 
-// Broadcast fanouts an envelope to all members.
+// Remove drops sessionID from the broadcast fanout without closing its client,
+// which stays usable for other conversations.
+func (c *Conversation) Remove(sessionID string) {
+	if c == nil || sessionID == "" {
+		return
+	}
+
+	c.mu.Lock()
+	_, ok := c.members[sessionID]
+	delete(c.members, sessionID)
+	c.mu.Unlock()
+
+	if ok {
+		c.log.Info("conversation.member.leave", "conversation_id", c.ID, "session_id", sessionID)
+	}
+}
+
+// RemoveUser drops every session of userID from the broadcast fanout.
+// Unlike Leave it does not close the clients: their connections stay usable for
+// other conversations. It returns the number of sessions removed.
+func (c *Conversation) RemoveUser(userID string) int {
+	if c == nil || userID == "" {
+		return 0
+	}
+
+	removed := 0
+
+	c.mu.Lock()
+	for sid, m := range c.members {
+		if m != nil && m.UserID == userID {
+			delete(c.members, sid)
+			removed++
+		}
+	}
+	c.mu.Unlock()
+
+	if removed > 0 {
+		c.log.Info("conversation.member.removed", "conversation_id", c.ID, "user_id", userID, "sessions", removed)
+	}
+	return removed
+}
+
+func (c *Conversation) hasSession(sessionID string) bool {
+	if c == nil {
+		return false
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	_, ok := c.members[sessionID]
+	return ok
+}
+
+// Broadcast fanouts an envelope to all members, including members connected to
+// other nodes when the hub uses a Broker.
 // Non-blocking: if a member queue is full or the client is shutting down, it is dropped.
 func (c *Conversation) Broadcast(env v1.Envelope) {
 	if c == nil {
 		return
 	}
 
+	c.deliver(env)
+	if c.observe != nil {
+		c.observe(c.ID, env)
+	}
+	if c.relay != nil {
+		c.relay(c.ID, env)
+	}
+}
+
+// deliver fanouts env to members connected to this node.
+func (c *Conversation) deliver(env v1.Envelope) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
@@ -83,17 +154,8 @@ func (c *Conversation) Broadcast(env v1.Envelope) {
 			continue
 		}
 
-		select {
-		case <-m.Done():
-			// Skip clients that are shutting down.
-			continue
-		default:
-		}
-
-		select {
-		case m.Send <- env:
-		default:
-			// Drop rather than block the whole conversation.
-		}
+		// Offer skips clients that are shutting down and drops rather than
+		// blocking the whole conversation.
+		m.Offer(env)
 	}
 }
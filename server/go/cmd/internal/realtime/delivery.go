@@ -0,0 +1,186 @@
+package realtime
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"arc/cmd/internal/dbtrace"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// DeliveryCursor is a member's delivery position after MarkDelivered.
+type DeliveryCursor struct {
+	ConversationID   string
+	UserID           string
+	LastDeliveredSeq int64
+	UpdatedAt        time.Time
+	// Advanced is false when the update did not move the cursor forward.
+	Advanced bool
+}
+
+// MessageReceipt is one member's delivery state for a message. A read message
+// always counts as delivered.
+type MessageReceipt struct {
+	UserID    string
+	Delivered bool
+	Read      bool
+}
+
+// MessageReceipts lists the delivery state of a message for every member.
+type MessageReceipts struct {
+	ConversationID string
+	ServerMsgID    string
+	Seq            int64
+	Receipts       []MessageReceipt
+}
+
+// DeliveryStore persists per-user delivery positions acknowledged with
+// message.delivered.
+type DeliveryStore interface {
+	// MarkDelivered advances userID's delivery cursor in conversationID to upToSeq.
+	// Cursors never move backwards and never pass the latest message.
+	MarkDelivered(ctx context.Context, userID, conversationID string, upToSeq int64) (DeliveryCursor, error)
+	// LastDelivered returns userID's delivery cursor. ok is false when the user
+	// never acknowledged delivery in conversationID.
+	LastDelivered(ctx context.Context, userID, conversationID string) (seq int64, ok bool, err error)
+	// MessageReceipts returns per-member delivery state of serverMsgID, or
+	// ErrMessageNotFound.
+	MessageReceipts(ctx context.Context, conversationID, serverMsgID string) (MessageReceipts, error)
+}
+
+func (s *PostgresMembershipStore) MarkDelivered(ctx context.Context, userID, conversationID string, upToSeq int64) (DeliveryCursor, error) {
+	ctx = dbtrace.WithOp(ctx, "realtime.mark_delivered")
+	if s == nil || s.pool == nil {
+		return DeliveryCursor{}, errors.New("realtime: nil membership store")
+	}
+	userID = strings.TrimSpace(userID)
+	conversationID = strings.TrimSpace(conversationID)
+	if userID == "" || conversationID == "" {
+		return DeliveryCursor{}, errors.New("realtime: missing user_id or conversation_id")
+	}
+	if upToSeq < 0 {
+		return DeliveryCursor{}, errors.New("realtime: invalid up_to_seq")
+	}
+	if err := ctx.Err(); err != nil {
+		return DeliveryCursor{}, err
+	}
+
+	members := pgIdent(s.schema, "conversation_members")
+	cursors := pgIdent(s.schema, "conversation_delivery_cursors")
+	stats := pgIdent(s.schema, "conversation_stats")
+
+	out := DeliveryCursor{ConversationID: conversationID, UserID: userID}
+	var prev int64
+	err := s.pool.QueryRow(ctx,
+		`WITH latest AS (
+		     SELECT COALESCE(max(last_seq), 0) AS seq FROM `+stats+` WHERE conversation_id = $1
+		 ), prev AS (
+		     SELECT last_delivered_seq FROM `+cursors+` WHERE conversation_id = $1 AND user_id = $2
+		 ), up AS (
+		     INSERT INTO `+cursors+` (conversation_id, user_id, last_delivered_seq, updated_at)
+		     SELECT m.conversation_id, m.user_id, LEAST($3::bigint, latest.seq), now()
+		       FROM `+members+` m, latest
+		      WHERE m.conversation_id = $1 AND m.user_id = $2
+		     ON CONFLICT (conversation_id, user_id) DO UPDATE
+		       SET last_delivered_seq = GREATEST(`+cursors+`.last_delivered_seq, EXCLUDED.last_delivered_seq),
+		           updated_at = CASE
+		               WHEN EXCLUDED.last_delivered_seq > `+cursors+`.last_delivered_seq THEN EXCLUDED.updated_at
+		               ELSE `+cursors+`.updated_at
+		           END
+		     RETURNING last_delivered_seq, updated_at
+		 )
+		 SELECT up.last_delivered_seq, up.updated_at, COALESCE((SELECT last_delivered_seq FROM prev), 0)
+		   FROM up`,
+		conversationID, userID, upToSeq,
+	).Scan(&out.LastDeliveredSeq, &out.UpdatedAt, &prev)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return DeliveryCursor{}, ErrMembershipRequired
+	}
+	if err != nil {
+		return DeliveryCursor{}, err
+	}
+	out.UpdatedAt = out.UpdatedAt.UTC()
+	out.Advanced = out.LastDeliveredSeq > prev
+	return out, nil
+}
+
+func (s *PostgresMembershipStore) LastDelivered(ctx context.Context, userID, conversationID string) (int64, bool, error) {
+	ctx = dbtrace.WithOp(ctx, "realtime.last_delivered")
+	if s == nil || s.pool == nil {
+		return 0, false, errors.New("realtime: nil membership store")
+	}
+
+	var seq int64
+	err := s.pool.QueryRow(ctx,
+		`SELECT last_delivered_seq FROM `+pgIdent(s.schema, "conversation_delivery_cursors")+`
+		  WHERE conversation_id = $1 AND user_id = $2`,
+		strings.TrimSpace(conversationID), strings.TrimSpace(userID),
+	).Scan(&seq)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return seq, true, nil
+}
+
+func (s *PostgresMembershipStore) MessageReceipts(ctx context.Context, conversationID, serverMsgID string) (MessageReceipts, error) {
+	ctx = dbtrace.WithOp(ctx, "realtime.message_receipts")
+	if s == nil || s.pool == nil {
+		return MessageReceipts{}, errors.New("realtime: nil membership store")
+	}
+	conversationID = strings.TrimSpace(conversationID)
+	serverMsgID = strings.TrimSpace(serverMsgID)
+	if conversationID == "" || serverMsgID == "" {
+		return MessageReceipts{}, errors.New("realtime: missing conversation_id or server_msg_id")
+	}
+
+	out := MessageReceipts{ConversationID: conversationID, ServerMsgID: serverMsgID}
+	err := s.pool.QueryRow(ctx,
+		`SELECT seq FROM `+pgIdent(s.schema, "messages")+`
+		  WHERE conversation_id = $1 AND server_msg_id = $2`,
+		conversationID, serverMsgID,
+	).Scan(&out.Seq)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return MessageReceipts{}, ErrMessageNotFound
+	}
+	if err != nil {
+		return MessageReceipts{}, err
+	}
+
+	rows, err := s.pool.Query(ctx,
+		`SELECT m.user_id,
+		        GREATEST(COALESCE(d.last_delivered_seq, 0), COALESCE(r.last_read_seq, 0)) >= $2,
+		        COALESCE(r.last_read_seq, 0) >= $2
+		   FROM `+pgIdent(s.schema, "conversation_members")+` m
+		   LEFT JOIN `+pgIdent(s.schema, "conversation_delivery_cursors")+` d
+		     ON d.conversation_id = m.conversation_id AND d.user_id = m.user_id
+		   LEFT JOIN `+pgIdent(s.schema, "conversation_read_cursors")+` r
+		     ON r.conversation_id = m.conversation_id AND r.user_id = m.user_id
+		  WHERE m.conversation_id = $1
+		  ORDER BY m.user_id`,
+		conversationID, out.Seq,
+	)
+	if err != nil {
+		return MessageReceipts{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rc MessageReceipt
+		if err := rows.Scan(&rc.UserID, &rc.Delivered, &rc.Read); err != nil {
+			return MessageReceipts{}, err
+		}
+		out.Receipts = append(out.Receipts, rc)
+	}
+	if err := rows.Err(); err != nil {
+		return MessageReceipts{}, err
+	}
+	return out, nil
+}
+
+var _ DeliveryStore = (*PostgresMembershipStore)(nil)
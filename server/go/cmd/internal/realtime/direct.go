@@ -0,0 +1,145 @@
+package realtime
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"arc/cmd/internal/dbtrace"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+	// ErrDirectConversationSelf is returned when a user asks for a direct conversation with themselves.
+	ErrDirectConversationSelf = errors.New("realtime: direct conversation with self")
+	// ErrDirectConversationForbidden is returned for a peer the caller may not start
+	// a direct conversation with. An unknown peer is reported the same way, so the
+	// endpoint does not tell which user IDs exist.
+	ErrDirectConversationForbidden = errors.New("realtime: direct conversation not allowed")
+)
+
+// DirectConversation is the 1:1 conversation between two users.
+type DirectConversation struct {
+	ConversationID string
+	// Created is true when this call created the conversation.
+	Created bool
+}
+
+// DirectConversationStore is implemented by membership stores that can bootstrap
+// 1:1 conversations.
+type DirectConversationStore interface {
+	// FindOrCreateDirectConversation returns the private direct conversation of
+	// userID and peerID, creating it with both users as members when absent.
+	// userID is re-added to an existing conversation it left; peerID is not.
+	// It returns ErrDirectConversationForbidden when either user does not exist.
+	FindOrCreateDirectConversation(ctx context.Context, userID, peerID string) (DirectConversation, error)
+}
+
+// directPair orders two user ids the way arc.direct_conversations stores them.
+func directPair(a, b string) (low, high string) {
+	if a < b {
+		return a, b
+	}
+	return b, a
+}
+
+// FindOrCreateDirectConversation implements DirectConversationStore.
+func (s *PostgresMembershipStore) FindOrCreateDirectConversation(ctx context.Context, userID, peerID string) (DirectConversation, error) {
+	ctx = dbtrace.WithOp(ctx, "realtime.find_or_create_direct_conversation")
+	if s == nil || s.pool == nil {
+		return DirectConversation{}, errors.New("realtime: nil membership store")
+	}
+	userID = strings.TrimSpace(userID)
+	peerID = strings.TrimSpace(peerID)
+	if userID == "" || peerID == "" {
+		return DirectConversation{}, errors.New("realtime: missing user_id")
+	}
+	if userID == peerID {
+		return DirectConversation{}, ErrDirectConversationSelf
+	}
+	if err := ctx.Err(); err != nil {
+		return DirectConversation{}, err
+	}
+
+	now := time.Now().UTC()
+	low, high := directPair(userID, peerID)
+	conversations := pgIdent(s.schema, "conversations")
+	members := pgIdent(s.schema, "conversation_members")
+	direct := pgIdent(s.schema, "direct_conversations")
+
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.ReadCommitted,
+		AccessMode: pgx.ReadWrite,
+	})
+	if err != nil {
+		return DirectConversation{}, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Serialize concurrent bootstraps of the same pair; uq_direct_conversations_pair
+	// still rejects a duplicate should the lock be bypassed.
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtextextended($1, 0))`, "direct:"+low+":"+high); err != nil {
+		return DirectConversation{}, err
+	}
+
+	out := DirectConversation{}
+	err = tx.QueryRow(ctx,
+		`SELECT conversation_id FROM `+direct+` WHERE user_low = $1 AND user_high = $2`,
+		low, high,
+	).Scan(&out.ConversationID)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		out.ConversationID, err = NewConversationID(now)
+		if err != nil {
+			return DirectConversation{}, err
+		}
+		out.Created = true
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO `+conversations+` (id, kind, visibility, created_at) VALUES ($1, 'direct', $2, $3)`,
+			out.ConversationID, conversationVisibilityPrivate, now,
+		); err != nil {
+			return DirectConversation{}, err
+		}
+	case err != nil:
+		return DirectConversation{}, err
+	}
+
+	// A new conversation gets both members; an existing one only takes the caller
+	// back, so a peer who left is not pulled in again.
+	memberIDs := []string{userID}
+	if out.Created {
+		memberIDs = append(memberIDs, peerID)
+	}
+	_, err = tx.Exec(ctx,
+		`INSERT INTO `+members+` (conversation_id, user_id, role, joined_at)
+		 SELECT $1, u, $3, $4 FROM unnest($2::text[]) AS u
+		 ON CONFLICT (conversation_id, user_id) DO NOTHING`,
+		out.ConversationID, memberIDs, MemberRoleMember, now,
+	)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		return DirectConversation{}, ErrDirectConversationForbidden
+	}
+	if err != nil {
+		return DirectConversation{}, err
+	}
+
+	if out.Created {
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO `+direct+` (conversation_id, user_low, user_high, created_at) VALUES ($1, $2, $3, $4)`,
+			out.ConversationID, low, high, now,
+		); err != nil {
+			return DirectConversation{}, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return DirectConversation{}, err
+	}
+	return out, nil
+}
+
+var _ DirectConversationStore = (*PostgresMembershipStore)(nil)
@@ -0,0 +1,45 @@
+package realtime
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	v1 "arc/shared/contracts/realtime/v1"
+)
+
+// e2eeContent validates the content of an end-to-end encrypted message.send.
+// The ciphertext is never decrypted or inspected; only its size and the key ids
+// are bounded. Key ids are trimmed and de-duplicated, preserving order.
+func e2eeContent(p v1.MessageSendPayload) ([]byte, []string, error) {
+	if p.Text != "" {
+		return nil, nil, errors.New("text must be empty for e2ee messages")
+	}
+	if len(p.Ciphertext) == 0 {
+		return nil, nil, errors.New("missing ciphertext")
+	}
+	if len(p.Ciphertext) > maxE2EECiphertextBytes {
+		return nil, nil, fmt.Errorf("ciphertext too large: max=%d bytes", maxE2EECiphertextBytes)
+	}
+
+	keyIDs := make([]string, 0, len(p.KeyIDs))
+	seen := make(map[string]struct{}, len(p.KeyIDs))
+	for _, id := range p.KeyIDs {
+		id = strings.TrimSpace(id)
+		if id == "" || len(id) > maxE2EEKeyIDBytes {
+			return nil, nil, errors.New("invalid key_ids")
+		}
+		if _, dup := seen[id]; dup {
+			continue
+		}
+		seen[id] = struct{}{}
+		keyIDs = append(keyIDs, id)
+	}
+	if len(keyIDs) == 0 {
+		return nil, nil, errors.New("missing key_ids")
+	}
+	if len(keyIDs) > maxE2EEKeyIDs {
+		return nil, nil, fmt.Errorf("too many key_ids: max=%d", maxE2EEKeyIDs)
+	}
+	return p.Ciphertext, keyIDs, nil
+}
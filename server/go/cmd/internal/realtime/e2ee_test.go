@@ -0,0 +1,99 @@
+package realtime
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"arc/shared/contracts/realtime/realtimepb"
+	v1 "arc/shared/contracts/realtime/v1"
+)
+
+func TestE2EEContent(t *testing.T) {
+	t.Parallel()
+
+	ct := []byte{0x01, 0x02}
+	ciphertext, keyIDs, err := e2eeContent(v1.MessageSendPayload{Ciphertext: ct, KeyIDs: []string{" d1 ", "d2", "d1"}})
+	if err != nil || !bytes.Equal(ciphertext, ct) || strings.Join(keyIDs, ",") != "d1,d2" {
+		t.Fatalf("got %v %v %v", ciphertext, keyIDs, err)
+	}
+
+	manyKeys := make([]string, maxE2EEKeyIDs+1)
+	for i := range manyKeys {
+		manyKeys[i] = strings.Repeat("k", i+1)
+	}
+	cases := []struct {
+		name string
+		p    v1.MessageSendPayload
+	}{
+		{"text", v1.MessageSendPayload{Text: "hi", Ciphertext: ct, KeyIDs: []string{"d1"}}},
+		{"no ciphertext", v1.MessageSendPayload{KeyIDs: []string{"d1"}}},
+		{"large ciphertext", v1.MessageSendPayload{Ciphertext: make([]byte, maxE2EECiphertextBytes+1), KeyIDs: []string{"d1"}}},
+		{"no key ids", v1.MessageSendPayload{Ciphertext: ct}},
+		{"blank key id", v1.MessageSendPayload{Ciphertext: ct, KeyIDs: []string{"d1", " "}}},
+		{"long key id", v1.MessageSendPayload{Ciphertext: ct, KeyIDs: []string{strings.Repeat("k", maxE2EEKeyIDBytes+1)}}},
+		{"too many key ids", v1.MessageSendPayload{Ciphertext: ct, KeyIDs: manyKeys}},
+	}
+	for _, tc := range cases {
+		if _, _, err := e2eeContent(tc.p); err == nil {
+			t.Fatalf("%s: expected error", tc.name)
+		}
+	}
+}
+
+func TestHandleGRPC_EncryptedMessageSkipsFilters(t *testing.T) {
+	t.Parallel()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	rejectAll := MessageFilterFunc(func(context.Context, FilterInput) error {
+		return &RejectionError{Code: RejectBannedWord, Message: "rejected"}
+	})
+	g := NewWSGateway(log, NewHub(log), NewInMemoryStore(), nil, nil, WithMessageFilters(rejectAll))
+	client, _ := newGRPCClient(t, startGRPCTestServer(t, g))
+	ctx := grpcTestContext(t)
+
+	send := func(p v1.MessageSendPayload) (*realtimepb.Envelope, error) {
+		t.Helper()
+		payload, _ := json.Marshal(p)
+		return client.SendMessage(ctx, toGRPCEnvelope(t, mustNewEnvelope(v1.TypeMessageSend, payload, time.Now().UTC())))
+	}
+	for _, p := range []v1.MessageSendPayload{
+		{ConversationID: "c1", ClientMsgID: "m1", Text: "hello"},
+		{ConversationID: "c1", ClientMsgID: "m2", ContentType: "pgp", Text: "hello"},
+	} {
+		if _, err := send(p); err == nil {
+			t.Fatalf("%s: expected send to fail", p.ClientMsgID)
+		}
+	}
+
+	ciphertext := []byte("opaque\x00bytes")
+	env := fromGRPCEnvelope(t)(send(v1.MessageSendPayload{
+		ConversationID: "c1",
+		ClientMsgID:    "m3",
+		ContentType:    v1.ContentTypeE2EE,
+		Ciphertext:     ciphertext,
+		KeyIDs:         []string{"dev-a", "dev-b"},
+	}))
+	if env.Type != v1.TypeMessageAck {
+		t.Fatalf("expected message.ack, got %+v", env)
+	}
+
+	fetchPayload, _ := json.Marshal(v1.ConversationHistoryFetchPayload{ConversationID: "c1"})
+	chunk := fromGRPCEnvelope(t)(client.FetchHistory(ctx, toGRPCEnvelope(t, mustNewEnvelope(v1.TypeConversationHistoryFetch, fetchPayload, time.Now().UTC()))))
+	var p v1.ConversationHistoryChunkPayload
+	if err := json.Unmarshal(chunk.Payload, &p); err != nil {
+		t.Fatalf("decode chunk: %v", err)
+	}
+	if len(p.Messages) != 1 {
+		t.Fatalf("unexpected history %+v", p)
+	}
+	m := p.Messages[0]
+	if m.ContentType != v1.ContentTypeE2EE || m.Text != "" || !bytes.Equal(m.Ciphertext, ciphertext) || strings.Join(m.KeyIDs, ",") != "dev-a,dev-b" {
+		t.Fatalf("unexpected encrypted message %+v", m)
+	}
+}
@@ -0,0 +1,208 @@
+package realtime
+
+import (
+	"arc/cmd/internal/config"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrorCodeMessageRejected is the error envelope code for messages a MessageFilter
+// rejected; the payload's reason carries the RejectionError code.
+const ErrorCodeMessageRejected = "message_rejected"
+
+// Rejection reasons of the built-in filters.
+const (
+	RejectTooLong         = "too_long"
+	RejectTooManyLinks    = "too_many_links"
+	RejectTooManyMentions = "too_many_mentions"
+	RejectBannedWord      = "banned_word"
+)
+
+// RejectionError is returned by a MessageFilter to refuse a message. Code is a
+// stable machine-readable reason; Message is shown to the sender.
+type RejectionError struct {
+	Code    string
+	Message string
+	// MaxChars and ActualChars accompany RejectTooLong.
+	MaxChars    int
+	ActualChars int
+}
+
+func (e *RejectionError) Error() string {
+	return "message rejected: " + e.Message
+}
+
+// FilterInput is the candidate message a MessageFilter inspects, after entity
+// extraction and before it is stored.
+type FilterInput struct {
+	ConversationID string
+	SenderUserID   string
+	Text           string
+	Entities       []MessageEntity
+	AttachmentIDs  []string
+	// Edit is true when Text replaces an existing message.
+	Edit bool
+}
+
+// MessageFilter accepts or rejects outbound messages on message.send and
+// message.edit. Returning a *RejectionError surfaces its code to the sender;
+// any other error is reported as a send or edit failure.
+type MessageFilter interface {
+	FilterMessage(ctx context.Context, in FilterInput) error
+}
+
+// MessageFilterFunc adapts a function to MessageFilter.
+type MessageFilterFunc func(ctx context.Context, in FilterInput) error
+
+// FilterMessage calls f.
+func (f MessageFilterFunc) FilterMessage(ctx context.Context, in FilterInput) error {
+	return f(ctx, in)
+}
+
+// WithMessageFilters appends filters to the chain. Filters run in order and the
+// chain stops at the first rejection.
+func WithMessageFilters(filters ...MessageFilter) GatewayOption {
+	return func(g *WSGateway) { g.filters = append(g.filters, filters...) }
+}
+
+// filterMessage runs the filter chain.
+func (g *WSGateway) filterMessage(ctx context.Context, in FilterInput) error {
+	for _, f := range g.filters {
+		if err := f.FilterMessage(ctx, in); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FilterConfig is the deployment's message policy. Zero values disable a rule.
+type FilterConfig struct {
+	// MaxChars is stricter than the gateway limit (ARC_WS_MAX_MESSAGE_CHARS) when set.
+	MaxChars    int
+	MaxLinks    int
+	MaxMentions int
+	// BannedWords are matched case-insensitively against whole words; entries
+	// with spaces match whole phrases.
+	BannedWords []string
+}
+
+// LoadFilterConfigFromEnv reads ARC_MESSAGE_FILTER_MAX_CHARS, _MAX_LINKS,
+// _MAX_MENTIONS and _BANNED_WORDS (comma-separated, or a file with one entry
+// per line via _BANNED_WORDS_FILE).
+func LoadFilterConfigFromEnv() (FilterConfig, error) {
+	return LoadFilterConfigFrom(config.Env)
+}
+
+// LoadFilterConfigFrom is LoadFilterConfigFromEnv reading the settings through lookup.
+func LoadFilterConfigFrom(lookup config.Lookup) (FilterConfig, error) {
+	cfg := FilterConfig{
+		MaxChars:    envIntWS(lookup, "ARC_MESSAGE_FILTER_MAX_CHARS", 0),
+		MaxLinks:    envIntWS(lookup, "ARC_MESSAGE_FILTER_MAX_LINKS", 0),
+		MaxMentions: envIntWS(lookup, "ARC_MESSAGE_FILTER_MAX_MENTIONS", 0),
+		BannedWords: envCSVWS(lookup, "ARC_MESSAGE_FILTER_BANNED_WORDS", ""),
+	}
+	if path := strings.TrimSpace(lookup.Get("ARC_MESSAGE_FILTER_BANNED_WORDS_FILE")); path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return FilterConfig{}, fmt.Errorf("realtime: banned words file: %w", err)
+		}
+		for _, line := range strings.Split(string(b), "\n") {
+			if w := strings.TrimSpace(line); w != "" && !strings.HasPrefix(w, "#") {
+				cfg.BannedWords = append(cfg.BannedWords, w)
+			}
+		}
+	}
+	return cfg, nil
+}
+
+// Filters returns the built-in filters enabled by cfg.
+func (cfg FilterConfig) Filters() []MessageFilter {
+	var out []MessageFilter
+	if cfg.MaxChars > 0 {
+		out = append(out, MaxCharsFilter(cfg.MaxChars))
+	}
+	if cfg.MaxLinks > 0 {
+		out = append(out, MaxLinksFilter(cfg.MaxLinks))
+	}
+	if cfg.MaxMentions > 0 {
+		out = append(out, MaxMentionsFilter(cfg.MaxMentions))
+	}
+	if f := BannedWordsFilter(cfg.BannedWords); f != nil {
+		out = append(out, f)
+	}
+	return out
+}
+
+// MaxCharsFilter rejects messages longer than n runes.
+func MaxCharsFilter(n int) MessageFilter {
+	return MessageFilterFunc(func(_ context.Context, in FilterInput) error {
+		if actual := utf8.RuneCountInString(in.Text); actual > n {
+			return &RejectionError{Code: RejectTooLong, Message: fmt.Sprintf("message exceeds %d characters", n), MaxChars: n, ActualChars: actual}
+		}
+		return nil
+	})
+}
+
+// MaxLinksFilter rejects messages with more than n links.
+func MaxLinksFilter(n int) MessageFilter {
+	return MessageFilterFunc(func(_ context.Context, in FilterInput) error {
+		if countEntities(in.Entities, EntityURL) > n {
+			return &RejectionError{Code: RejectTooManyLinks, Message: fmt.Sprintf("message has more than %d links", n)}
+		}
+		return nil
+	})
+}
+
+// MaxMentionsFilter rejects messages mentioning more than n members.
+func MaxMentionsFilter(n int) MessageFilter {
+	return MessageFilterFunc(func(_ context.Context, in FilterInput) error {
+		if countEntities(in.Entities, EntityMention) > n {
+			return &RejectionError{Code: RejectTooManyMentions, Message: fmt.Sprintf("message mentions more than %d members", n)}
+		}
+		return nil
+	})
+}
+
+// BannedWordsFilter rejects messages containing any of words. It returns nil
+// when words is empty. The matched word is not echoed to the sender.
+func BannedWordsFilter(words []string) MessageFilter {
+	banned := make([]string, 0, len(words))
+	for _, w := range words {
+		if n := normalizeWords(w); n != "" {
+			banned = append(banned, " "+n+" ")
+		}
+	}
+	if len(banned) == 0 {
+		return nil
+	}
+	return MessageFilterFunc(func(_ context.Context, in FilterInput) error {
+		text := " " + normalizeWords(in.Text) + " "
+		for _, b := range banned {
+			if strings.Contains(text, b) {
+				return &RejectionError{Code: RejectBannedWord, Message: "message contains a blocked word"}
+			}
+		}
+		return nil
+	})
+}
+
+// normalizeWords lower-cases s and joins its letter/digit runs with single spaces.
+func normalizeWords(s string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+func countEntities(entities []MessageEntity, typ string) int {
+	n := 0
+	for _, e := range entities {
+		if e.Type == typ {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,118 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	v1 "arc/shared/contracts/realtime/v1"
+)
+
+func TestBuiltinFilters(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	links := []MessageEntity{{Type: EntityURL}, {Type: EntityURL}, {Type: EntityMention, UserID: "u2"}}
+	cases := []struct {
+		name   string
+		filter MessageFilter
+		in     FilterInput
+		reason string
+	}{
+		{name: "chars ok", filter: MaxCharsFilter(5), in: FilterInput{Text: "héllo"}},
+		{name: "chars over", filter: MaxCharsFilter(4), in: FilterInput{Text: "héllo"}, reason: RejectTooLong},
+		{name: "links ok", filter: MaxLinksFilter(2), in: FilterInput{Entities: links}},
+		{name: "links over", filter: MaxLinksFilter(1), in: FilterInput{Entities: links}, reason: RejectTooManyLinks},
+		{name: "mentions over", filter: MaxMentionsFilter(0), in: FilterInput{Entities: links}, reason: RejectTooManyMentions},
+		{name: "banned word", filter: BannedWordsFilter([]string{"Darn"}), in: FilterInput{Text: "well, DARN!"}, reason: RejectBannedWord},
+		{name: "banned substring ignored", filter: BannedWordsFilter([]string{"darn"}), in: FilterInput{Text: "darning socks"}},
+		{name: "banned phrase", filter: BannedWordsFilter([]string{"free  money"}), in: FilterInput{Text: "get FREE-money now"}, reason: RejectBannedWord},
+	}
+	for _, tc := range cases {
+		err := tc.filter.FilterMessage(ctx, tc.in)
+		var rej *RejectionError
+		switch {
+		case tc.reason == "" && err != nil:
+			t.Fatalf("%s: unexpected rejection %v", tc.name, err)
+		case tc.reason != "" && (!errors.As(err, &rej) || rej.Code != tc.reason):
+			t.Fatalf("%s: expected %s, got %v", tc.name, tc.reason, err)
+		}
+	}
+
+	if BannedWordsFilter([]string{" ", "!!"}) != nil {
+		t.Fatalf("expected no filter for empty word list")
+	}
+}
+
+func TestLoadFilterConfigFromEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "banned.txt")
+	if err := os.WriteFile(path, []byte("# comment\nspam\n\nscam link\n"), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	t.Setenv("ARC_MESSAGE_FILTER_MAX_LINKS", "3")
+	t.Setenv("ARC_MESSAGE_FILTER_BANNED_WORDS", "foo, bar")
+	t.Setenv("ARC_MESSAGE_FILTER_BANNED_WORDS_FILE", path)
+
+	cfg, err := LoadFilterConfigFromEnv()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.MaxLinks != 3 || cfg.MaxMentions != 0 || len(cfg.BannedWords) != 4 || cfg.BannedWords[3] != "scam link" {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+	if n := len(cfg.Filters()); n != 2 {
+		t.Fatalf("expected 2 filters, got %d", n)
+	}
+
+	t.Setenv("ARC_MESSAGE_FILTER_BANNED_WORDS_FILE", filepath.Join(t.TempDir(), "missing.txt"))
+	if _, err := LoadFilterConfigFromEnv(); err == nil {
+		t.Fatalf("expected error for a missing banned words file")
+	}
+}
+
+func TestGatewayFilterRejectionEnvelope(t *testing.T) {
+	t.Parallel()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	var seen []FilterInput
+	record := MessageFilterFunc(func(_ context.Context, in FilterInput) error {
+		seen = append(seen, in)
+		return nil
+	})
+	g := NewWSGateway(log, NewHub(log), NewInMemoryStore(), nil, nil,
+		WithMessageFilters(record, MaxLinksFilter(0)))
+
+	err := g.filterMessage(context.Background(), FilterInput{Text: "see example.com", Entities: []MessageEntity{{Type: EntityURL}}})
+	if len(seen) != 1 {
+		t.Fatalf("expected filters to run in order")
+	}
+
+	c := NewClient("u1", "s1", 4)
+	g.trySendFailure(context.Background(), c, "send_failed", err)
+	g.trySendFailure(context.Background(), c, "send_failed", errors.New("empty text"))
+
+	want := []v1.ErrorPayload{
+		{Code: ErrorCodeMessageRejected, Reason: RejectTooManyLinks, Message: "message has more than 0 links"},
+		{Code: "send_failed", Message: "empty text"},
+	}
+	for _, w := range want {
+		select {
+		case env := <-c.Send:
+			var p v1.ErrorPayload
+			if err := json.Unmarshal(env.Payload, &p); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if env.Type != v1.TypeError || p != w {
+				t.Fatalf("unexpected error envelope %s %+v", env.Type, p)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("expected error envelope")
+		}
+	}
+}
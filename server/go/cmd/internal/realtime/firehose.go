@@ -0,0 +1,92 @@
+package realtime
+
+import (
+	"sync"
+
+	v1 "arc/shared/contracts/realtime/v1"
+)
+
+// firehoseEvent is a conversation broadcast queued for a firehose stream.
+type firehoseEvent struct {
+	conversationID string
+	env            v1.Envelope
+}
+
+// firehoseSub is one firehose stream's view of the hub. A full queue ends the
+// subscription instead of dropping events: the stream closes and the consumer
+// resumes from its last checkpoint.
+type firehoseSub struct {
+	accept   func(conversationID string) bool
+	events   chan firehoseEvent
+	overflow chan struct{}
+	once     sync.Once
+}
+
+// firehoseSubs are the firehose streams connected to this node.
+type firehoseSubs struct {
+	mu   sync.RWMutex
+	subs map[*firehoseSub]struct{}
+}
+
+// isFirehoseEvent reports whether a broadcast of typ is streamed to integrations.
+func isFirehoseEvent(typ string) bool {
+	switch typ {
+	case v1.TypeMessageNew, v1.TypeMessageEdited, v1.TypeMessageDeleted, v1.TypeMessageRemoved,
+		v1.TypeMemberAdded, v1.TypeMemberRemoved:
+		return true
+	default:
+		return false
+	}
+}
+
+// subscribeFirehose queues message and membership broadcasts of the conversations
+// accept allows, up to queueSize events. Call the returned cancel when the stream ends.
+func (h *Hub) subscribeFirehose(accept func(conversationID string) bool, queueSize int) (*firehoseSub, func()) {
+	sub := &firehoseSub{
+		accept:   accept,
+		events:   make(chan firehoseEvent, queueSize),
+		overflow: make(chan struct{}),
+	}
+
+	h.firehose.mu.Lock()
+	if h.firehose.subs == nil {
+		h.firehose.subs = make(map[*firehoseSub]struct{})
+	}
+	h.firehose.subs[sub] = struct{}{}
+	h.firehose.mu.Unlock()
+
+	return sub, func() {
+		h.firehose.mu.Lock()
+		delete(h.firehose.subs, sub)
+		h.firehose.mu.Unlock()
+	}
+}
+
+// publishFirehose offers a conversation broadcast to the firehose streams. Like
+// Broadcast it never blocks.
+func (h *Hub) publishFirehose(conversationID string, env v1.Envelope) {
+	if !isFirehoseEvent(env.Type) {
+		return
+	}
+
+	h.firehose.mu.RLock()
+	defer h.firehose.mu.RUnlock()
+
+	for sub := range h.firehose.subs {
+		if !sub.accept(conversationID) {
+			continue
+		}
+		select {
+		case sub.events <- firehoseEvent{conversationID: conversationID, env: env}:
+		default:
+			sub.once.Do(func() { close(sub.overflow) })
+		}
+	}
+}
+
+// observe shows a conversation broadcast to the node-wide subscribers: inboxes
+// and firehose streams.
+func (h *Hub) observe(conversationID string, env v1.Envelope) {
+	h.deliverInbox(conversationID, env)
+	h.publishFirehose(conversationID, env)
+}
@@ -0,0 +1,381 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"arc/shared/contracts/realtime/realtimepb"
+	v1 "arc/shared/contracts/realtime/v1"
+
+	"github.com/coder/websocket"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// GRPCPathPrefix is the route prefix of the Realtime gRPC service.
+var GRPCPathPrefix = "/" + realtimepb.Realtime_ServiceDesc.ServiceName + "/"
+
+// errPeerClosed is returned by reads after the client half-closed its stream.
+var errPeerClosed = errors.New("realtime: peer closed")
+
+// grpcRequestKey carries the *grpcRequest of a call in its context.
+type grpcRequestKey struct{}
+
+// grpcRequest is the HTTP request behind a gRPC call, for the checks that look
+// past its metadata (client IP, cookies) and for write deadlines.
+type grpcRequest struct {
+	r  *http.Request
+	rc *http.ResponseController
+}
+
+// newGRPCServer returns the server of the Realtime service. It is only used
+// through ServeHTTP, so HTTP/2 and TLS stay the HTTP server's concern.
+func (g *WSGateway) newGRPCServer() *grpc.Server {
+	s := grpc.NewServer(grpc.MaxRecvMsgSize(g.maxFrameBytes))
+	realtimepb.RegisterRealtimeServer(s, grpcService{g: g})
+	return s
+}
+
+// HandleGRPC serves the arc.realtime.v1.Realtime gRPC service defined in
+// shared/contracts/realtime/realtimepb/realtime.proto.
+//
+// Stream runs the same session loop as HandleWS; SendMessage and FetchHistory are
+// one-shot calls that need no hello or join. Calls authenticate with the same
+// bearer token as the WebSocket ("authorization" metadata), except Firehose,
+// which takes a machine token. Origin checks do not apply: gRPC clients are
+// native apps and services, not browsers.
+func (g *WSGateway) HandleGRPC(w http.ResponseWriter, r *http.Request) {
+	rc := http.NewResponseController(w)
+	if r.URL.Path == realtimepb.Realtime_Stream_FullMethodName || r.URL.Path == realtimepb.Realtime_Firehose_FullMethodName {
+		// Streams outlive the server's request timeouts, like hijacked WebSockets.
+		_ = rc.SetReadDeadline(time.Time{})
+		_ = rc.SetWriteDeadline(time.Time{})
+	}
+	ctx := context.WithValue(r.Context(), grpcRequestKey{}, &grpcRequest{r: r, rc: rc})
+	g.grpc.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// grpcCaller is the authenticated caller of a gRPC call; both IDs are empty
+// without auth.
+type grpcCaller struct {
+	userID    string
+	sessionID string
+}
+
+// admitGRPC runs the checks every call passes before its handler: draining,
+// the access policy, maintenance mode and, except for Firehose, authentication.
+func (g *WSGateway) admitGRPC(ctx context.Context, method string) (*grpcRequest, grpcCaller, error) {
+	req, ok := ctx.Value(grpcRequestKey{}).(*grpcRequest)
+	if !ok {
+		return nil, grpcCaller{}, status.Error(codes.Internal, "grpc served outside HandleGRPC")
+	}
+	if g.draining.Load() && (method == realtimepb.Realtime_Stream_FullMethodName || method == realtimepb.Realtime_Firehose_FullMethodName) {
+		return nil, grpcCaller{}, status.Error(codes.Unavailable, "server shutting down")
+	}
+	if !g.accessAllowed(req.r, "grpc") {
+		return nil, grpcCaller{}, status.Error(codes.PermissionDenied, "forbidden")
+	}
+	if g.rejectGRPCMaintenance(method) {
+		return nil, grpcCaller{}, status.Error(codes.Unavailable, wsMaintenanceReason)
+	}
+	if method == realtimepb.Realtime_Firehose_FullMethodName || !g.requireAuth {
+		return req, grpcCaller{}, nil
+	}
+
+	if g.auth == nil {
+		return nil, grpcCaller{}, status.Error(codes.Internal, "auth not configured")
+	}
+	token, err := g.accessTokenFromRequest(req.r)
+	if err != nil {
+		return nil, grpcCaller{}, status.Error(codes.Unauthenticated, "unauthorized")
+	}
+	claims, err := g.auth.ValidateAccessToken(ctx, token, time.Now().UTC())
+	if err != nil {
+		return nil, grpcCaller{}, status.Error(codes.Unauthenticated, "unauthorized")
+	}
+	_ = g.auth.TouchSession(ctx, time.Now().UTC(), claims.SessionID)
+	return req, grpcCaller{userID: claims.UserID, sessionID: claims.SessionID}, nil
+}
+
+// grpcService implements realtimepb.RealtimeServer on the gateway.
+type grpcService struct {
+	realtimepb.UnimplementedRealtimeServer
+	g *WSGateway
+}
+
+func (s grpcService) Stream(stream realtimepb.Realtime_StreamServer) error {
+	g := s.g
+	req, caller, err := g.admitGRPC(stream.Context(), realtimepb.Realtime_Stream_FullMethodName)
+	if err != nil {
+		return err
+	}
+	release, limit := g.acquireConn(req.r, caller.userID, caller.sessionID)
+	if limit != "" {
+		return status.Error(codes.ResourceExhausted, "too many connections per "+limit)
+	}
+	defer release()
+
+	sessionID := caller.sessionID
+	if sessionID == "" {
+		sessionID, err = NewSessionID(time.Now().UTC())
+		if err != nil {
+			g.log.Error("grpc.session_id.fail", "err", err)
+			return status.Error(codes.Internal, "internal error")
+		}
+	}
+
+	// Send response headers right away so the client sees the stream open.
+	_ = stream.SendHeader(nil)
+
+	conn := newGRPCSessionConn(stream, req.rc, g.maxFrameBytes)
+	g.runSession(stream.Context(), conn, caller.userID, sessionID)
+	if conn.code == codes.OK {
+		return nil
+	}
+	return status.Error(conn.code, conn.message)
+}
+
+func (s grpcService) SendMessage(ctx context.Context, env *realtimepb.Envelope) (*realtimepb.Envelope, error) {
+	return s.unary(ctx, realtimepb.Realtime_SendMessage_FullMethodName, env, v1.TypeMessageSend, v1.TypeMessageAck)
+}
+
+func (s grpcService) FetchHistory(ctx context.Context, env *realtimepb.Envelope) (*realtimepb.Envelope, error) {
+	return s.unary(ctx, realtimepb.Realtime_FetchHistory_FullMethodName, env, v1.TypeConversationHistoryFetch, v1.TypeConversationHistoryChunk)
+}
+
+func (s grpcService) Firehose(env *realtimepb.Envelope, stream realtimepb.Realtime_FirehoseServer) error {
+	req, _, err := s.g.admitGRPC(stream.Context(), realtimepb.Realtime_Firehose_FullMethodName)
+	if err != nil {
+		return err
+	}
+	return s.g.serveGRPCFirehose(req, env, stream)
+}
+
+func (s grpcService) unary(ctx context.Context, method string, in *realtimepb.Envelope, reqType, replyType string) (*realtimepb.Envelope, error) {
+	_, caller, err := s.g.admitGRPC(ctx, method)
+	if err != nil {
+		return nil, err
+	}
+	env, err := realtimepb.ToV1(in)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	reply, err := s.g.grpcUnary(ctx, caller.userID, caller.sessionID, env, reqType, replyType)
+	if err != nil {
+		return nil, err
+	}
+	out, err := realtimepb.FromV1(reply)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return out, nil
+}
+
+// grpcUnary runs one request envelope through the WebSocket handlers on a
+// transient client joined to the request's conversation and returns the first
+// queued envelope of replyType. Messages are attributed to sessionID, the
+// caller's auth session (empty without auth). Errors are gRPC status errors.
+func (g *WSGateway) grpcUnary(ctx context.Context, userID, sessionID string, env v1.Envelope, reqType, replyType string) (v1.Envelope, error) {
+	if err := env.Validate(); err != nil {
+		return v1.Envelope{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if env.Type != reqType {
+		return v1.Envelope{}, status.Errorf(codes.InvalidArgument, "expected type %s", reqType)
+	}
+
+	var p struct {
+		ConversationID string `json:"conversation_id"`
+	}
+	if err := json.Unmarshal(env.Payload, &p); err != nil {
+		return v1.Envelope{}, status.Errorf(codes.InvalidArgument, "invalid payload: %v", err)
+	}
+
+	now := time.Now().UTC()
+	// Conversation membership is keyed by the client's session ID, so the
+	// token's session must not be the key: leaving would evict the caller's live
+	// WebSocket. The client gets its own key and acts for the token's session.
+	key, err := NewSessionID(now)
+	if err != nil {
+		return v1.Envelope{}, status.Error(codes.Internal, "internal error")
+	}
+	// The reply is read right away, so nothing may be held for ordering.
+	client := NewClientWithPolicy(userID, key, g.sendQueueSize, BackpressurePolicy{OrderHold: -1})
+	client.actorSession = sessionID
+
+	joinPayload, _ := json.Marshal(v1.ConversationJoinPayload{ConversationID: p.ConversationID})
+	conv, err := g.onJoin(ctx, client, &joinedConversations{}, mustNewEnvelope(v1.TypeConversationJoin, joinPayload, now))
+	if err != nil {
+		return v1.Envelope{}, status.Error(grpcCodeForJoinErr(err), err.Error())
+	}
+	defer conv.Leave(key)
+
+	switch reqType {
+	case v1.TypeMessageSend:
+		err = g.onMessageSend(ctx, client, conv, env, now)
+	case v1.TypeConversationHistoryFetch:
+		err = g.onHistoryFetch(ctx, client, conv, env)
+	default:
+		return v1.Envelope{}, status.Errorf(codes.Unimplemented, "unsupported type: %s", reqType)
+	}
+	if err != nil {
+		return v1.Envelope{}, status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	// Handlers enqueue synchronously; skip the join echo and any fanout.
+	for {
+		select {
+		case out := <-client.Send:
+			if out.Type == replyType {
+				return out, nil
+			}
+		default:
+			return v1.Envelope{}, status.Errorf(codes.Internal, "no %s produced", replyType)
+		}
+	}
+}
+
+func grpcCodeForJoinErr(err error) codes.Code {
+	switch {
+	case errors.Is(err, errMissingConversationID):
+		return codes.InvalidArgument
+	case errors.Is(err, errConversationNotFound):
+		return codes.NotFound
+	case errors.Is(err, errUnauthorized), errors.Is(err, errNotMember):
+		return codes.PermissionDenied
+	default:
+		return codes.Internal
+	}
+}
+
+// grpcSessionConn adapts a gRPC bidi stream to sessionConn. A reader goroutine
+// receives request messages so reads can honor the session's idle timeout.
+type grpcSessionConn struct {
+	stream realtimepb.Realtime_StreamServer
+	rc     *http.ResponseController
+
+	reads     chan grpcRead
+	done      chan struct{}
+	readLimit atomic.Int64
+
+	closeOnce sync.Once
+	code      codes.Code
+	message   string
+}
+
+type grpcRead struct {
+	env v1.Envelope
+	err error
+}
+
+func newGRPCSessionConn(stream realtimepb.Realtime_StreamServer, rc *http.ResponseController, maxFrameBytes int) *grpcSessionConn {
+	c := &grpcSessionConn{
+		stream: stream,
+		rc:     rc,
+		reads:  make(chan grpcRead),
+		done:   make(chan struct{}),
+	}
+	c.readLimit.Store(int64(maxFrameBytes))
+	go c.readLoop()
+	return c
+}
+
+func (c *grpcSessionConn) readLoop() {
+	for {
+		var res grpcRead
+		msg, err := c.stream.Recv()
+		// The server's limit applies while receiving; a limit negotiated by
+		// hello is checked on the decoded message.
+		if err == nil {
+			if n := proto.Size(msg); n > int(c.readLimit.Load()) {
+				err = fmt.Errorf("message of %d bytes exceeds limit %d", n, c.readLimit.Load())
+			}
+		}
+		switch {
+		case err == nil:
+			// A payload that does not match its type is reported as bad_frame;
+			// the stream stays usable.
+			res.env, res.err = realtimepb.ToV1(msg)
+		case errors.Is(err, io.EOF):
+			// Client half-close ends the session like a WebSocket close frame.
+			res.err = errPeerClosed
+		default:
+			// Receive errors are fatal; %v keeps them from reading as bad_frame.
+			res.err = fmt.Errorf("grpc: receive: %v", err)
+		}
+
+		select {
+		case c.reads <- res:
+		case <-c.done:
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (c *grpcSessionConn) ReadEnvelope(ctx context.Context) (v1.Envelope, error) {
+	select {
+	case res := <-c.reads:
+		return res.env, res.err
+	case <-ctx.Done():
+		return v1.Envelope{}, ctx.Err()
+	case <-c.done:
+		return v1.Envelope{}, net.ErrClosed
+	}
+}
+
+func (c *grpcSessionConn) WriteEnvelope(ctx context.Context, env v1.Envelope) error {
+	msg, err := realtimepb.FromV1(env)
+	if err != nil {
+		return err
+	}
+	if dl, ok := ctx.Deadline(); ok {
+		_ = c.rc.SetWriteDeadline(dl)
+	}
+	return c.stream.Send(msg)
+}
+
+// Ping is a no-op: HTTP/2 connection keepalive is the server's concern.
+func (c *grpcSessionConn) Ping(context.Context) error { return nil }
+
+// SetReadLimit changes the limit for messages not yet handed to the session.
+func (c *grpcSessionConn) SetReadLimit(n int) { c.readLimit.Store(int64(n)) }
+
+// Close records the status the Stream call returns once runSession does.
+func (c *grpcSessionConn) Close(code websocket.StatusCode, reason string) error {
+	c.closeOnce.Do(func() {
+		c.code = grpcCodeForClose(code)
+		if c.code != codes.OK {
+			c.message = reason
+		}
+		close(c.done)
+	})
+	return nil
+}
+
+func grpcCodeForClose(code websocket.StatusCode) codes.Code {
+	switch code {
+	case websocket.StatusNormalClosure:
+		return codes.OK
+	case websocket.StatusPolicyViolation:
+		// Rate limiting and slow consumers.
+		return codes.ResourceExhausted
+	case websocket.StatusInternalError:
+		return codes.Internal
+	case StatusSessionRevoked:
+		return codes.Unauthenticated
+	default:
+		return codes.Unavailable
+	}
+}
@@ -0,0 +1,63 @@
+package realtime
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"arc/cmd/internal/auth/session"
+	"arc/cmd/internal/migrations/migrationstest"
+	v1 "arc/shared/contracts/realtime/v1"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// TestHandleGRPC_SendMessage_Postgres sends through the unary RPC into the
+// Postgres store, whose sender_session must reference the caller's auth session.
+func TestHandleGRPC_SendMessage_Postgres(t *testing.T) {
+	t.Setenv("ARC_WS_REQUIRE_AUTH", "true")
+	t.Setenv("ARC_WS_REQUIRE_MEMBERSHIP", "false")
+
+	pool := mustOpenTestPool(t)
+	defer pool.Close()
+
+	schema := migrationstest.NewSchema(t, pool)
+	mustCreateTestSessions(t, pool, schema, "user-grpc", "session-grpc")
+	store := mustNewStore(t, pool, schema)
+
+	now := time.Now().UTC()
+	row := session.Row{
+		ID:        testID("session-grpc"),
+		UserID:    testID("user-grpc"),
+		CreatedAt: now,
+		ExpiresAt: now.Add(time.Hour),
+		Platform:  session.PlatformWeb,
+	}
+	authSvc, tokens := newWSAuthService(t, row, 15*time.Minute)
+	token, _, err := tokens.Issue(row.UserID, row.ID, now)
+	if err != nil {
+		t.Fatalf("issue token: %v", err)
+	}
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	g := NewWSGateway(log, NewHub(log), store, authSvc, nil)
+	client, _ := newGRPCClient(t, startGRPCTestServer(t, g))
+
+	convID := "it-grpc-" + NewRandomHex(8)
+	payload, _ := json.Marshal(v1.MessageSendPayload{ConversationID: convID, ClientMsgID: "m1", Text: "hello"})
+	ctx := metadata.AppendToOutgoingContext(grpcTestContext(t), "authorization", "Bearer "+token)
+	ack := fromGRPCEnvelope(t)(client.SendMessage(ctx, toGRPCEnvelope(t, mustNewEnvelope(v1.TypeMessageSend, payload, now))))
+	if ack.Type != v1.TypeMessageAck {
+		t.Fatalf("expected message.ack, got %+v", ack)
+	}
+
+	hist, err := store.FetchHistory(grpcTestContext(t), FetchHistoryInput{ConversationID: convID, Limit: 10})
+	if err != nil {
+		t.Fatalf("FetchHistory: %v", err)
+	}
+	if len(hist.Messages) != 1 || hist.Messages[0].SenderSession != row.ID {
+		t.Fatalf("expected one message from %s, got %+v", row.ID, hist.Messages)
+	}
+}
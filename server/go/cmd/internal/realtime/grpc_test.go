@@ -0,0 +1,254 @@
+package realtime
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"arc/shared/contracts/realtime/realtimepb"
+	v1 "arc/shared/contracts/realtime/v1"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+func newGRPCTestServer(t *testing.T) (*httptest.Server, *WSGateway) {
+	t.Helper()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	g := NewWSGateway(log, NewHub(log), NewInMemoryStore(), nil, nil)
+	return startGRPCTestServer(t, g), g
+}
+
+func startGRPCTestServer(t *testing.T, g *WSGateway) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(g.HandleGRPC))
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// newGRPCClient dials srv over TLS with the generated client.
+func newGRPCClient(t *testing.T, srv *httptest.Server) (realtimepb.RealtimeClient, *grpc.ClientConn) {
+	t.Helper()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+	conn, err := grpc.NewClient(strings.TrimPrefix(srv.URL, "https://"),
+		grpc.WithTransportCredentials(credentials.NewClientTLSFromCert(roots, "")))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return realtimepb.NewRealtimeClient(conn), conn
+}
+
+func grpcTestContext(t *testing.T) context.Context {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func toGRPCEnvelope(t *testing.T, env v1.Envelope) *realtimepb.Envelope {
+	t.Helper()
+	out, err := realtimepb.FromV1(env)
+	if err != nil {
+		t.Fatalf("FromV1: %v", err)
+	}
+	return out
+}
+
+// fromGRPCEnvelope converts the result of a call, failing t on an error:
+// fromGRPCEnvelope(t)(stream.Recv()).
+func fromGRPCEnvelope(t *testing.T) func(*realtimepb.Envelope, error) v1.Envelope {
+	return func(env *realtimepb.Envelope, err error) v1.Envelope {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("call: %v", err)
+		}
+		out, err := realtimepb.ToV1(env)
+		if err != nil {
+			t.Fatalf("ToV1: %v", err)
+		}
+		return out
+	}
+}
+
+func wantGRPCCode(t *testing.T, err error, want codes.Code) {
+	t.Helper()
+	if got := status.Code(err); got != want {
+		t.Fatalf("expected %s, got %s (%v)", want, got, err)
+	}
+}
+
+func TestHandleGRPC_Stream(t *testing.T) {
+	t.Parallel()
+
+	srv, _ := newGRPCTestServer(t)
+	client, _ := newGRPCClient(t, srv)
+	stream, err := client.Stream(grpcTestContext(t))
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+
+	if err := stream.Send(toGRPCEnvelope(t, mustNewEnvelope(v1.TypeHello, json.RawMessage(`{}`), time.Now().UTC()))); err != nil {
+		t.Fatalf("send hello: %v", err)
+	}
+	if got := fromGRPCEnvelope(t)(stream.Recv()); got.Type != v1.TypeHelloAck {
+		t.Fatalf("expected hello.ack, got %+v", got)
+	}
+
+	// A payload that does not match the type is reported in-band and the stream stays open.
+	mismatched := &realtimepb.Envelope{V: v1.Version, Type: v1.TypeConversationJoin, Payload: &realtimepb.Envelope_Hello{Hello: &realtimepb.HelloPayload{}}}
+	if err := stream.Send(mismatched); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	got := fromGRPCEnvelope(t)(stream.Recv())
+	var e v1.ErrorPayload
+	if err := json.Unmarshal(got.Payload, &e); err != nil || got.Type != v1.TypeError || e.Code != "bad_frame" {
+		t.Fatalf("expected bad_frame, got %+v", got)
+	}
+
+	joinPayload, _ := json.Marshal(v1.ConversationJoinPayload{ConversationID: "c1"})
+	if err := stream.Send(toGRPCEnvelope(t, mustNewEnvelope(v1.TypeConversationJoin, joinPayload, time.Now().UTC()))); err != nil {
+		t.Fatalf("send join: %v", err)
+	}
+	if got := fromGRPCEnvelope(t)(stream.Recv()); got.Type != v1.TypeConversationJoin {
+		t.Fatalf("expected join echo, got %+v", got)
+	}
+
+	// Half-closing the request ends the session cleanly.
+	if err := stream.CloseSend(); err != nil {
+		t.Fatalf("close send: %v", err)
+	}
+	if _, err := stream.Recv(); !errors.Is(err, io.EOF) {
+		t.Fatalf("expected a clean end, got %v", err)
+	}
+}
+
+func TestHandleGRPC_SendMessageAndFetchHistory(t *testing.T) {
+	t.Parallel()
+
+	srv, _ := newGRPCTestServer(t)
+	client, _ := newGRPCClient(t, srv)
+	ctx := grpcTestContext(t)
+
+	sendPayload, _ := json.Marshal(v1.MessageSendPayload{ConversationID: "c1", ClientMsgID: "m1", Text: "hello"})
+	ack := fromGRPCEnvelope(t)(client.SendMessage(ctx, toGRPCEnvelope(t, mustNewEnvelope(v1.TypeMessageSend, sendPayload, time.Now().UTC()))))
+	if ack.Type != v1.TypeMessageAck {
+		t.Fatalf("expected message.ack, got %+v", ack)
+	}
+
+	fetchPayload, _ := json.Marshal(v1.ConversationHistoryFetchPayload{ConversationID: "c1"})
+	chunk := fromGRPCEnvelope(t)(client.FetchHistory(ctx, toGRPCEnvelope(t, mustNewEnvelope(v1.TypeConversationHistoryFetch, fetchPayload, time.Now().UTC()))))
+	if chunk.Type != v1.TypeConversationHistoryChunk {
+		t.Fatalf("expected history chunk, got %+v", chunk)
+	}
+	var p v1.ConversationHistoryChunkPayload
+	if err := json.Unmarshal(chunk.Payload, &p); err != nil {
+		t.Fatalf("decode chunk: %v", err)
+	}
+	if len(p.Messages) != 1 || p.Messages[0].Text != "hello" {
+		t.Fatalf("unexpected history %+v", p)
+	}
+}
+
+func TestHandleGRPC_Errors(t *testing.T) {
+	t.Parallel()
+
+	srv, _ := newGRPCTestServer(t)
+	client, conn := newGRPCClient(t, srv)
+	ctx := grpcTestContext(t)
+
+	tooLong, _ := json.Marshal(v1.MessageSendPayload{ConversationID: "c1", ClientMsgID: "m1", Text: strings.Repeat("x", defaultMaxFrameBytes)})
+	tests := []struct {
+		name string
+		call func() error
+		want codes.Code
+	}{
+		{name: "unknown method", want: codes.Unimplemented, call: func() error {
+			return conn.Invoke(ctx, GRPCPathPrefix+"Nope", &realtimepb.Envelope{}, &realtimepb.Envelope{})
+		}},
+		{name: "wrong type", want: codes.InvalidArgument, call: func() error {
+			_, err := client.SendMessage(ctx, toGRPCEnvelope(t, mustNewEnvelope(v1.TypeHello, nil, time.Now().UTC())))
+			return err
+		}},
+		{name: "empty envelope", want: codes.InvalidArgument, call: func() error {
+			_, err := client.FetchHistory(ctx, &realtimepb.Envelope{})
+			return err
+		}},
+		{name: "mismatched payload", want: codes.InvalidArgument, call: func() error {
+			_, err := client.SendMessage(ctx, &realtimepb.Envelope{V: v1.Version, Type: v1.TypeMessageSend, Payload: &realtimepb.Envelope_Hello{Hello: &realtimepb.HelloPayload{}}})
+			return err
+		}},
+		{name: "too large", want: codes.ResourceExhausted, call: func() error {
+			_, err := client.SendMessage(ctx, toGRPCEnvelope(t, mustNewEnvelope(v1.TypeMessageSend, tooLong, time.Now().UTC())))
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wantGRPCCode(t, tt.call(), tt.want)
+		})
+	}
+}
+
+func TestHandleGRPC_StreamNegotiatedFrameLimit(t *testing.T) {
+	t.Parallel()
+
+	srv, g := newGRPCTestServer(t)
+	client, _ := newGRPCClient(t, srv)
+	stream, err := client.Stream(grpcTestContext(t))
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+
+	if err := stream.Send(toGRPCEnvelope(t, mustNewEnvelope(v1.TypeHello, json.RawMessage(`{"max_frame_bytes":5000}`), time.Now().UTC()))); err != nil {
+		t.Fatalf("send hello: %v", err)
+	}
+	got := fromGRPCEnvelope(t)(stream.Recv())
+	var ack v1.HelloAckPayload
+	if err := json.Unmarshal(got.Payload, &ack); err != nil || got.Type != v1.TypeHelloAck {
+		t.Fatalf("expected hello.ack, got %+v (%v)", got, err)
+	}
+	if ack.MaxFrameBytes != 5000 || ack.HeartbeatIntervalMS != g.heartbeatEvery.Milliseconds() {
+		t.Fatalf("unexpected limits %+v", ack)
+	}
+
+	// A message over the negotiated limit but under the server's default ends the stream.
+	payload, _ := json.Marshal(v1.MessageSendPayload{ConversationID: "c1", ClientMsgID: "m1", Text: strings.Repeat("x", 8<<10)})
+	if err := stream.Send(toGRPCEnvelope(t, mustNewEnvelope(v1.TypeMessageSend, payload, time.Now().UTC()))); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	_, err = stream.Recv()
+	wantGRPCCode(t, err, codes.Unavailable)
+}
+
+func TestNegotiateFrameLimit(t *testing.T) {
+	g := &WSGateway{maxFrameBytes: defaultMaxFrameBytes}
+	for requested, want := range map[int]int{
+		0:                        defaultMaxFrameBytes,
+		-1:                       defaultMaxFrameBytes,
+		1:                        minMaxFrameBytes,
+		8 << 10:                  8 << 10,
+		defaultMaxFrameBytes * 2: defaultMaxFrameBytes,
+	} {
+		if got := g.negotiateFrameLimit(requested); got != want {
+			t.Fatalf("negotiateFrameLimit(%d) = %d, want %d", requested, got, want)
+		}
+	}
+}
@@ -0,0 +1,331 @@
+package realtime
+
+import (
+	"cmp"
+	"container/list"
+	"context"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"arc/cmd/internal/config"
+	"arc/cmd/internal/dbroute"
+	v1 "arc/shared/contracts/realtime/v1"
+)
+
+// HistoryCacheConfig bounds the recent-message cache in front of the message store.
+type HistoryCacheConfig struct {
+	// Conversations is the number of conversations kept (least recently used are evicted).
+	Conversations int
+	// Messages is the number of newest messages kept per conversation (at most 200).
+	Messages int
+	// TTL bounds how long an entry is trusted. It limits staleness from writes this
+	// node cannot observe: missed cross-node broadcasts, retention and purges.
+	TTL time.Duration
+	// Disabled serves every read from the store.
+	Disabled bool
+}
+
+// LoadHistoryCacheConfigFromEnv reads ARC_WS_HISTORY_CACHE_*.
+func LoadHistoryCacheConfigFromEnv() HistoryCacheConfig {
+	return LoadHistoryCacheConfigFrom(config.Env)
+}
+
+// LoadHistoryCacheConfigFrom is LoadHistoryCacheConfigFromEnv reading the settings through lookup.
+func LoadHistoryCacheConfigFrom(lookup config.Lookup) HistoryCacheConfig {
+	return HistoryCacheConfig{
+		Conversations: envIntWS(lookup, "ARC_WS_HISTORY_CACHE_CONVERSATIONS", 1024),
+		Messages:      envIntWS(lookup, "ARC_WS_HISTORY_CACHE_MESSAGES", 100),
+		TTL:           envDurationWS(lookup, "ARC_WS_HISTORY_CACHE_TTL", time.Minute),
+		Disabled:      !envBoolWS(lookup, "ARC_WS_HISTORY_CACHE", true),
+	}
+}
+
+// HistoryCache is a MessageStore that keeps the newest messages of recently read
+// conversations in memory, so recent scrollback and resume replay skip the store.
+//
+// An entry holds every message of its conversation from its oldest seq up to the
+// latest one. Writes through the cache keep entries current; writes it does not see
+// (other nodes, reported through the Hub's RemoteObserver) drop the entry. A read the
+// entry cannot fully answer falls through to the store.
+type HistoryCache struct {
+	MessageStore
+
+	cfg HistoryCacheConfig
+	now func() time.Time
+
+	mu      sync.Mutex
+	lru     *list.List // of *historyEntry, most recent first
+	entries map[string]*list.Element
+	// filling tracks conversations being loaded; writes meanwhile mark them stale.
+	filling map[string]*historyFill
+}
+
+type historyEntry struct {
+	conversationID string
+	msgs           []StoredMessage // ascending seq, contiguous up to the latest message
+	hasOlder       bool            // messages older than msgs[0] exist in the store
+	loadedAt       time.Time
+}
+
+type historyFill struct {
+	stale bool
+}
+
+// NewHistoryCache wraps store. It returns nil when cfg is disabled.
+func NewHistoryCache(store MessageStore, cfg HistoryCacheConfig) *HistoryCache {
+	if cfg.Disabled || store == nil || cfg.Conversations <= 0 || cfg.Messages <= 0 {
+		return nil
+	}
+	cfg.Messages = min(cfg.Messages, wsMaxHistoryLimit)
+	return &HistoryCache{
+		MessageStore: store,
+		cfg:          cfg,
+		now:          time.Now,
+		lru:          list.New(),
+		entries:      make(map[string]*list.Element),
+		filling:      make(map[string]*historyFill),
+	}
+}
+
+// FetchHistory serves the window from the cache when the cached messages answer it
+// completely, loading the conversation's newest messages on a miss.
+func (c *HistoryCache) FetchHistory(ctx context.Context, in FetchHistoryInput) (FetchHistoryResult, error) {
+	if out, ok := c.serve(in); ok {
+		return out, nil
+	}
+	if c.fill(ctx, in.ConversationID) {
+		if out, ok := c.serve(in); ok {
+			return out, nil
+		}
+	}
+	return c.MessageStore.FetchHistory(ctx, in)
+}
+
+// AppendMessage implements MessageStore and extends the cached entry.
+func (c *HistoryCache) AppendMessage(ctx context.Context, in AppendMessageInput) (AppendMessageResult, error) {
+	res, err := c.MessageStore.AppendMessage(ctx, in)
+	if err == nil && !res.Duplicated {
+		c.appended(res.Stored)
+	}
+	return res, err
+}
+
+// AppendMessages implements MessageStore and extends the cached entry.
+func (c *HistoryCache) AppendMessages(ctx context.Context, in []AppendMessageInput) ([]AppendMessageResult, error) {
+	res, err := c.MessageStore.AppendMessages(ctx, in)
+	if err == nil {
+		for _, r := range res {
+			if !r.Duplicated {
+				c.appended(r.Stored)
+			}
+		}
+	}
+	return res, err
+}
+
+// EditMessage implements MessageStore and updates the cached copy.
+func (c *HistoryCache) EditMessage(ctx context.Context, in EditMessageInput) (MessageMutationResult, error) {
+	res, err := c.MessageStore.EditMessage(ctx, in)
+	if err == nil && res.Changed {
+		c.replaced(res.Stored)
+	}
+	return res, err
+}
+
+// DeleteMessage implements MessageStore and tombstones the cached copy.
+func (c *HistoryCache) DeleteMessage(ctx context.Context, in DeleteMessageInput) (MessageMutationResult, error) {
+	res, err := c.MessageStore.DeleteMessage(ctx, in)
+	if err == nil && res.Changed {
+		c.replaced(res.Stored)
+	}
+	return res, err
+}
+
+// ObserveRemote implements RemoteObserver: message events from other nodes drop the entry.
+func (c *HistoryCache) ObserveRemote(conversationID string, env v1.Envelope) {
+	switch env.Type {
+	case v1.TypeMessageNew, v1.TypeMessageEdited, v1.TypeMessageDeleted, v1.TypeMessageRemoved:
+		c.Invalidate(conversationID)
+	}
+}
+
+// RemoteGap implements RemoteObserver: broadcasts may have been missed, so all entries are dropped.
+func (c *HistoryCache) RemoteGap() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lru.Init()
+	clear(c.entries)
+	for _, f := range c.filling {
+		f.stale = true
+	}
+}
+
+// Invalidate drops the cached entry of conversationID.
+func (c *HistoryCache) Invalidate(conversationID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.dropLocked(conversationID)
+}
+
+func (c *HistoryCache) dropLocked(conversationID string) {
+	if el, ok := c.entries[conversationID]; ok {
+		c.lru.Remove(el)
+		delete(c.entries, conversationID)
+	}
+	if f, ok := c.filling[conversationID]; ok {
+		f.stale = true
+	}
+}
+
+// entryLocked returns the live entry of conversationID, dropping it once expired.
+func (c *HistoryCache) entryLocked(conversationID string) *historyEntry {
+	el, ok := c.entries[conversationID]
+	if !ok {
+		return nil
+	}
+	e := el.Value.(*historyEntry)
+	if c.cfg.TTL > 0 && c.now().Sub(e.loadedAt) > c.cfg.TTL {
+		c.lru.Remove(el)
+		delete(c.entries, conversationID)
+		return nil
+	}
+	c.lru.MoveToFront(el)
+	return e
+}
+
+// fill loads the newest messages of conversationID. It reports whether an entry was stored.
+func (c *HistoryCache) fill(ctx context.Context, conversationID string) bool {
+	if conversationID == "" {
+		return false
+	}
+	c.mu.Lock()
+	if _, busy := c.filling[conversationID]; busy {
+		c.mu.Unlock()
+		return false
+	}
+	f := &historyFill{}
+	c.filling[conversationID] = f
+	c.mu.Unlock()
+
+	before := int64(math.MaxInt64)
+	// The cache is kept current by appends, so it must start from the primary.
+	out, err := c.MessageStore.FetchHistory(dbroute.RequirePrimary(ctx), FetchHistoryInput{
+		ConversationID: conversationID,
+		BeforeSeq:      &before,
+		Limit:          c.cfg.Messages,
+	})
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.filling, conversationID)
+	if err != nil || f.stale {
+		return false
+	}
+	if el, ok := c.entries[conversationID]; ok {
+		c.lru.Remove(el)
+	}
+	e := &historyEntry{
+		conversationID: conversationID,
+		msgs:           slices.Clone(out.Messages),
+		hasOlder:       out.HasMore,
+		loadedAt:       c.now(),
+	}
+	c.entries[conversationID] = c.lru.PushFront(e)
+	for c.lru.Len() > c.cfg.Conversations {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*historyEntry).conversationID)
+	}
+	return true
+}
+
+// serve answers in from the cache if the entry covers the whole window.
+func (c *HistoryCache) serve(in FetchHistoryInput) (FetchHistoryResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := c.entryLocked(in.ConversationID)
+	if e == nil {
+		return FetchHistoryResult{}, false
+	}
+
+	limit := in.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	limit = min(limit, wsMaxHistoryLimit)
+
+	// Messages older than the entry may fall inside the window unless the entry
+	// starts at the conversation's first message or AfterSeq excludes them.
+	partial := e.hasOlder && (in.AfterSeq == nil || *in.AfterSeq < e.msgs[0].Seq-1)
+
+	var window []StoredMessage
+	for _, m := range e.msgs {
+		if (in.AfterSeq == nil || m.Seq > *in.AfterSeq) && (in.BeforeSeq == nil || m.Seq < *in.BeforeSeq) {
+			window = append(window, m)
+		}
+	}
+
+	switch {
+	case in.BeforeSeq != nil && len(window) >= limit:
+		// The newest limit messages below BeforeSeq are all cached.
+		return FetchHistoryResult{
+			Messages: window[len(window)-limit:],
+			HasMore:  len(window) > limit || partial,
+		}, true
+	case partial:
+		return FetchHistoryResult{}, false
+	case len(window) > limit:
+		return FetchHistoryResult{Messages: window[:limit], HasMore: true}, true
+	default:
+		return FetchHistoryResult{Messages: window}, true
+	}
+}
+
+// appended adds a newly stored message to its entry. An out-of-order seq means the
+// entry missed a write, so it is dropped.
+func (c *HistoryCache) appended(m StoredMessage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if f, ok := c.filling[m.ConversationID]; ok {
+		f.stale = true
+	}
+	el, ok := c.entries[m.ConversationID]
+	if !ok {
+		return
+	}
+	e := el.Value.(*historyEntry)
+	last := int64(0)
+	if n := len(e.msgs); n > 0 {
+		last = e.msgs[n-1].Seq
+	}
+	if m.Seq != last+1 {
+		c.dropLocked(m.ConversationID)
+		return
+	}
+	e.msgs = append(e.msgs, m)
+	if len(e.msgs) > c.cfg.Messages {
+		e.msgs = slices.Delete(e.msgs, 0, len(e.msgs)-c.cfg.Messages)
+		e.hasOlder = true
+	}
+}
+
+// replaced swaps in an edited or deleted message if it is cached.
+func (c *HistoryCache) replaced(m StoredMessage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if f, ok := c.filling[m.ConversationID]; ok {
+		f.stale = true
+	}
+	el, ok := c.entries[m.ConversationID]
+	if !ok {
+		return
+	}
+	e := el.Value.(*historyEntry)
+	if i, found := slices.BinarySearchFunc(e.msgs, m.Seq, func(x StoredMessage, seq int64) int {
+		return cmp.Compare(x.Seq, seq)
+	}); found {
+		e.msgs[i] = m
+	}
+}
@@ -0,0 +1,174 @@
+package realtime
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	v1 "arc/shared/contracts/realtime/v1"
+)
+
+// countingStore counts the history reads that reach the store.
+type countingStore struct {
+	*InMemoryStore
+	fetches int
+}
+
+func (s *countingStore) FetchHistory(ctx context.Context, in FetchHistoryInput) (FetchHistoryResult, error) {
+	s.fetches++
+	return s.InMemoryStore.FetchHistory(ctx, in)
+}
+
+func seedMessages(t *testing.T, store MessageStore, conversationID string, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		id := strconv.Itoa(i)
+		if _, err := store.AppendMessage(context.Background(), AppendMessageInput{
+			ConversationID: conversationID, ClientMsgID: "k" + id, SenderSession: "s1", Text: "m" + id,
+		}); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+	}
+}
+
+func int64p(v int64) *int64 { return &v }
+
+func TestHistoryCache_MatchesStore(t *testing.T) {
+	t.Parallel()
+
+	inner := &countingStore{InMemoryStore: NewInMemoryStore()}
+	seedMessages(t, inner, "c1", 30)
+	cache := NewHistoryCache(inner, HistoryCacheConfig{Conversations: 4, Messages: 10})
+	ctx := context.Background()
+
+	windows := []FetchHistoryInput{
+		{ConversationID: "c1", BeforeSeq: int64p(31), Limit: 5},
+		{ConversationID: "c1", BeforeSeq: int64p(31), Limit: 10},
+		{ConversationID: "c1", BeforeSeq: int64p(25), Limit: 3},
+		{ConversationID: "c1", AfterSeq: int64p(25), Limit: 10},
+		{ConversationID: "c1", AfterSeq: int64p(20), Limit: 4},
+		{ConversationID: "c1", AfterSeq: int64p(20), BeforeSeq: int64p(24), Limit: 10},
+		{ConversationID: "c1", BeforeSeq: int64p(31), Limit: 20},
+		{ConversationID: "c1", AfterSeq: int64p(2), Limit: 5},
+		{ConversationID: "c1", Limit: 5},
+		{ConversationID: "c-empty", Limit: 5},
+	}
+	for _, in := range windows {
+		got, err := cache.FetchHistory(ctx, in)
+		if err != nil {
+			t.Fatalf("cache fetch %+v: %v", in, err)
+		}
+		want, err := inner.InMemoryStore.FetchHistory(ctx, in)
+		if err != nil {
+			t.Fatalf("store fetch %+v: %v", in, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("window %+v: cache=%+v store=%+v", in, got, want)
+		}
+	}
+
+	// The first six windows fall inside the newest ten messages: one fill for c1.
+	inner.fetches = 0
+	for _, in := range windows[:6] {
+		if _, err := cache.FetchHistory(ctx, in); err != nil {
+			t.Fatalf("fetch: %v", err)
+		}
+	}
+	if inner.fetches != 0 {
+		t.Fatalf("expected recent windows to be cached, store saw %d reads", inner.fetches)
+	}
+}
+
+func TestHistoryCache_WritesKeepEntryCurrent(t *testing.T) {
+	t.Parallel()
+
+	inner := &countingStore{InMemoryStore: NewInMemoryStore()}
+	seedMessages(t, inner, "c1", 3)
+	cache := NewHistoryCache(inner, HistoryCacheConfig{Conversations: 4, Messages: 3})
+	ctx := context.Background()
+	latest := FetchHistoryInput{ConversationID: "c1", BeforeSeq: int64p(1 << 62), Limit: 3}
+
+	if _, err := cache.FetchHistory(ctx, latest); err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	res, err := cache.AppendMessage(ctx, AppendMessageInput{ConversationID: "c1", ClientMsgID: "k3", SenderSession: "s1", Text: "new"})
+	if err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	if _, err := cache.EditMessage(ctx, EditMessageInput{
+		ConversationID: "c1", ServerMsgID: res.Stored.ServerMsgID, Text: "edited",
+		MessageActor: MessageActor{ActorSession: "s1"},
+	}); err != nil {
+		t.Fatalf("edit: %v", err)
+	}
+
+	inner.fetches = 0
+	out, err := cache.FetchHistory(ctx, latest)
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if inner.fetches != 0 {
+		t.Fatalf("expected append and edit to keep the entry cached")
+	}
+	if len(out.Messages) != 3 || out.Messages[0].Seq != 2 || out.Messages[2].Text != "edited" || !out.HasMore {
+		t.Fatalf("unexpected cached window %+v", out)
+	}
+
+	// A message appended on another node reaches this one only as a relayed broadcast.
+	if _, err := inner.AppendMessage(ctx, AppendMessageInput{ConversationID: "c1", ClientMsgID: "remote", SenderSession: "s2", Text: "remote"}); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	cache.ObserveRemote("c1", v1.Envelope{Type: v1.TypeMessageNew})
+	out, err = cache.FetchHistory(ctx, latest)
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if inner.fetches != 1 || out.Messages[2].Seq != 5 {
+		t.Fatalf("expected remote message to invalidate the entry, fetches=%d window=%+v", inner.fetches, out)
+	}
+}
+
+func TestHistoryCache_EvictsAndExpires(t *testing.T) {
+	t.Parallel()
+
+	inner := &countingStore{InMemoryStore: NewInMemoryStore()}
+	for _, id := range []string{"c1", "c2", "c3"} {
+		seedMessages(t, inner, id, 2)
+	}
+	cache := NewHistoryCache(inner, HistoryCacheConfig{Conversations: 2, Messages: 10, TTL: time.Minute})
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	ctx := context.Background()
+	fetch := func(id string) {
+		t.Helper()
+		if _, err := cache.FetchHistory(ctx, FetchHistoryInput{ConversationID: id, Limit: 10}); err != nil {
+			t.Fatalf("fetch: %v", err)
+		}
+	}
+
+	fetch("c1")
+	fetch("c2")
+	fetch("c1")
+	fetch("c3") // evicts c2, the least recently read
+	inner.fetches = 0
+	fetch("c1")
+	if inner.fetches != 0 {
+		t.Fatalf("expected c1 to stay cached")
+	}
+	fetch("c2")
+	if inner.fetches != 1 {
+		t.Fatalf("expected c2 to be evicted")
+	}
+
+	now = now.Add(2 * time.Minute)
+	fetch("c2")
+	if inner.fetches != 2 {
+		t.Fatalf("expected expired entry to be reloaded")
+	}
+
+	if NewHistoryCache(inner, HistoryCacheConfig{Conversations: 2, Messages: 10, Disabled: true}) != nil {
+		t.Fatalf("expected disabled cache to be nil")
+	}
+}
@@ -2,8 +2,13 @@ package realtime
 
 import (
 	"log/slog"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
+
+	v1 "arc/shared/contracts/realtime/v1"
 )
 
 // Hub owns in-memory conversations and provides stable conversation handles.
@@ -13,6 +18,17 @@ type Hub struct {
 
 	mu            sync.RWMutex
 	conversations map[string]*Conversation
+	// users indexes connected authenticated clients by user id, then session id.
+	users map[string]map[string]*Client
+
+	presence *presenceTracker
+	inbox    *inboxTracker
+	firehose firehoseSubs
+
+	// fanout is set by UseBroker to relay broadcasts across nodes.
+	fanout atomic.Pointer[brokerFanout]
+	// remote is set by ObserveRemote to see broadcasts relayed from other nodes.
+	remote atomic.Pointer[RemoteObserver]
 }
 
 // NewHub constructs a Hub instance.
@@ -20,7 +36,165 @@ func NewHub(log *slog.Logger) *Hub {
 	return &Hub{
 		log:           log,
 		conversations: make(map[string]*Conversation),
+		users:         make(map[string]map[string]*Client),
+		presence:      newPresenceTracker(),
+		inbox:         newInboxTracker(),
+	}
+}
+
+// AddClient registers an authenticated client so user-scoped events reach all of
+// its devices, and marks the session online.
+func (h *Hub) AddClient(client *Client) {
+	if client == nil || client.UserID == "" || client.SessionID == "" {
+		return
+	}
+
+	h.mu.Lock()
+	sessions := h.users[client.UserID]
+	if sessions == nil {
+		sessions = make(map[string]*Client)
+		h.users[client.UserID] = sessions
+	}
+	sessions[client.SessionID] = client
+	h.mu.Unlock()
+
+	h.SetPresence(client, PresenceOnline)
+}
+
+// RemoveClient unregisters a client added with AddClient, drops its presence
+// subscriptions and records last seen when it was the user's final session.
+func (h *Hub) RemoveClient(client *Client) {
+	if client == nil || client.UserID == "" {
+		return
+	}
+
+	h.mu.Lock()
+	sessions := h.users[client.UserID]
+	if sessions[client.SessionID] != client {
+		h.mu.Unlock()
+		return
+	}
+	delete(sessions, client.SessionID)
+	if len(sessions) == 0 {
+		delete(h.users, client.UserID)
+	}
+	h.mu.Unlock()
+
+	h.presence.dropSubscriber(client.SessionID)
+	h.inbox.unsubscribe(client.SessionID)
+	h.setPresence(client.UserID, client.SessionID, "")
+}
+
+// SendToUser delivers env to every connected session of userID, skipping sessions
+// currently joined to except (they already received it via Broadcast).
+// Like Broadcast it never blocks.
+func (h *Hub) SendToUser(userID string, env v1.Envelope, except *Conversation) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for sid, cl := range h.users[userID] {
+		if except.hasSession(sid) {
+			continue
+		}
+		cl.Offer(env)
+	}
+}
+
+// Broadcast fans env out to conversationID on this node and, with a Broker, on
+// other nodes, even when no local client joined the conversation.
+func (h *Hub) Broadcast(conversationID string, env v1.Envelope) {
+	if c := h.Conversation(conversationID); c != nil {
+		c.Broadcast(env)
+		return
+	}
+	h.observe(conversationID, env)
+	h.relay(conversationID, env)
+}
+
+// ConnectionInfo describes one session connected to this node.
+type ConnectionInfo struct {
+	UserID         string
+	SessionID      string
+	ConversationID string
+	QueueDepth     int
+	Dropped        int64
+	ConnectedAt    time.Time
+	LastActivityAt time.Time
+	Hello          ClientHello
+	Conversations  []string
+}
+
+// Connections snapshots the authenticated sessions connected to this node,
+// ordered by user and session id. ConversationID is the latest of Conversations.
+func (h *Hub) Connections() []ConnectionInfo {
+	h.mu.RLock()
+	out := make([]ConnectionInfo, 0, len(h.users))
+	for _, sessions := range h.users {
+		for _, cl := range sessions {
+			out = append(out, ConnectionInfo{
+				UserID:         cl.UserID,
+				SessionID:      cl.SessionID,
+				ConversationID: cl.Conversation(),
+				QueueDepth:     cl.QueueDepth(),
+				Dropped:        cl.Dropped(),
+				ConnectedAt:    cl.ConnectedAt,
+				LastActivityAt: cl.LastActivity(),
+				Hello:          cl.Hello(),
+				Conversations:  cl.Conversations(),
+			})
+		}
+	}
+	h.mu.RUnlock()
+
+	slices.SortFunc(out, func(a, b ConnectionInfo) int {
+		if c := strings.Compare(a.UserID, b.UserID); c != 0 {
+			return c
+		}
+		return strings.Compare(a.SessionID, b.SessionID)
+	})
+	return out
+}
+
+// CloseSession closes the connection of sessionID on this node. It reports whether
+// the session was connected.
+func (h *Hub) CloseSession(sessionID string) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for _, sessions := range h.users {
+		if cl, ok := sessions[sessionID]; ok {
+			cl.Close()
+			return true
+		}
 	}
+	return false
+}
+
+// clients snapshots the authenticated clients on this node; with a userID only
+// that user's.
+func (h *Hub) clients(userID string) []*Client {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var out []*Client
+	for uid, sessions := range h.users {
+		if userID != "" && uid != userID {
+			continue
+		}
+		for _, cl := range sessions {
+			out = append(out, cl)
+		}
+	}
+	return out
+}
+
+// UserConnected reports whether userID has at least one session connected to
+// this node.
+func (h *Hub) UserConnected(userID string) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return len(h.users[userID]) > 0
 }
 
 // GetOrCreateConversation returns a stable in-memory conversation handle.
@@ -41,10 +215,52 @@ func (h *Hub) GetOrCreateConversationWithKind(conversationID, kind string) *Conv
 	}
 
 	c := NewConversation(h.log, conversationID, kind)
+	c.observe = h.observe
+	c.relay = h.relay
 	h.conversations[conversationID] = c
 	return c
 }
 
+// Conversation returns the in-memory handle for conversationID without creating one.
+// It returns nil when no client has joined the conversation on this node.
+func (h *Hub) Conversation(conversationID string) *Conversation {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return h.conversations[conversationID]
+}
+
+// SetPresence records client's status (online or away) and notifies subscribers
+// when the user's aggregate status changes.
+func (h *Hub) SetPresence(client *Client, status string) {
+	if client == nil || client.UserID == "" || client.SessionID == "" {
+		return
+	}
+	h.setPresence(client.UserID, client.SessionID, status)
+}
+
+// SubscribePresence registers client for presence changes of userID and returns
+// the current state. showLastSeen controls whether pushed updates carry last seen.
+func (h *Hub) SubscribePresence(client *Client, userID string, showLastSeen bool) PresenceState {
+	return h.presence.subscribe(userID, presenceSub{client: client, showLastSeen: showLastSeen})
+}
+
+// Presence returns the aggregated presence of userID.
+func (h *Hub) Presence(userID string) PresenceState {
+	return h.presence.state(userID)
+}
+
+func (h *Hub) setPresence(userID, sessionID, status string) {
+	st, subs, changed := h.presence.set(userID, sessionID, status, time.Now().UTC())
+	if !changed {
+		return
+	}
+	for _, sub := range subs {
+		env := presenceEnvelope(st, sub.showLastSeen)
+		sub.client.Offer(env)
+	}
+}
+
 func normalizeConversationKind(kind string) string {
 	switch strings.ToLower(strings.TrimSpace(kind)) {
 	case "direct", "group", "room":
@@ -0,0 +1,78 @@
+package realtime
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	v1 "arc/shared/contracts/realtime/v1"
+)
+
+func TestHub_SendToUser_SkipsSessionsInConversation(t *testing.T) {
+	t.Parallel()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	hub := NewHub(log)
+	conv := hub.GetOrCreateConversationWithKind("c1", "group")
+
+	phone := NewClient("alice", "s-phone", 4)
+	laptop := NewClient("alice", "s-laptop", 4)
+	other := NewClient("bob", "s-bob", 4)
+	for _, c := range []*Client{phone, laptop, other} {
+		hub.AddClient(c)
+	}
+	conv.Join(phone)
+
+	env := v1.Envelope{V: v1.Version, Type: v1.TypeReadState}
+	conv.Broadcast(env)
+	hub.SendToUser("alice", env, conv)
+
+	if len(phone.Send) != 1 || len(laptop.Send) != 1 {
+		t.Fatalf("expected each alice device to receive exactly once, got phone=%d laptop=%d", len(phone.Send), len(laptop.Send))
+	}
+	if len(other.Send) != 0 {
+		t.Fatalf("expected other users to receive nothing")
+	}
+
+	hub.RemoveClient(laptop)
+	hub.SendToUser("alice", env, nil)
+	if len(laptop.Send) != 1 {
+		t.Fatalf("expected removed client to receive nothing more")
+	}
+}
+
+func TestHub_Connections_AndCloseSession(t *testing.T) {
+	t.Parallel()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	hub := NewHub(log)
+
+	phone := NewClient("alice", "s-phone", 4)
+	bob := NewClient("bob", "s-bob", 4)
+	anonymous := NewClient("", "s-anon", 4)
+	for _, c := range []*Client{bob, phone, anonymous} {
+		hub.AddClient(c)
+	}
+	phone.SetConversation("c1")
+	phone.Offer(v1.Envelope{V: v1.Version, Type: v1.TypeReadState})
+
+	got := hub.Connections()
+	if len(got) != 2 || got[0].SessionID != "s-phone" || got[1].SessionID != "s-bob" {
+		t.Fatalf("unexpected connections %+v", got)
+	}
+	if got[0].ConversationID != "c1" || got[0].QueueDepth != 1 || got[0].LastActivityAt.IsZero() {
+		t.Fatalf("unexpected alice connection %+v", got[0])
+	}
+
+	if hub.CloseSession("s-missing") {
+		t.Fatalf("expected unknown session to report false")
+	}
+	if !hub.CloseSession("s-bob") {
+		t.Fatalf("expected bob's session to close")
+	}
+	select {
+	case <-bob.Done():
+	default:
+		t.Fatalf("expected closed client")
+	}
+}
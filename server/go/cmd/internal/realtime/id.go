@@ -23,3 +23,8 @@ func NewEnvelopeID(now time.Time) (string, error) {
 func NewServerMsgID(now time.Time) (string, error) {
 	return ids.NewULID(now)
 }
+
+// NewConversationID returns a ULID used as the id of conversations the server creates.
+func NewConversationID(now time.Time) (string, error) {
+	return ids.NewULID(now)
+}
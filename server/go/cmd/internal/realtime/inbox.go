@@ -0,0 +1,226 @@
+package realtime
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	v1 "arc/shared/contracts/realtime/v1"
+)
+
+// inboxPreviewRunes bounds the text preview carried by inbox.message.
+const inboxPreviewRunes = 140
+
+// inboxSub is one session's inbox subscription.
+type inboxSub struct {
+	client *Client
+	convs  map[string]struct{}
+}
+
+// inboxTracker records which conversations each inbox subscription follows.
+//
+// State is node-local: with a Broker every node sees every conversation
+// broadcast, so each node serves the subscriptions of its own sessions.
+type inboxTracker struct {
+	mu    sync.Mutex
+	subs  map[string]*inboxSub            // session id -> subscription
+	convs map[string]map[string]*inboxSub // conversation id -> session id -> subscription
+}
+
+func newInboxTracker() *inboxTracker {
+	return &inboxTracker{
+		subs:  make(map[string]*inboxSub),
+		convs: make(map[string]map[string]*inboxSub),
+	}
+}
+
+// subscribe makes client follow conversationIDs, replacing an earlier
+// subscription of the same session.
+func (t *inboxTracker) subscribe(client *Client, conversationIDs []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.unsubscribeLocked(client.SessionID)
+	sub := &inboxSub{client: client, convs: make(map[string]struct{}, len(conversationIDs))}
+	t.subs[client.SessionID] = sub
+	for _, id := range conversationIDs {
+		t.followLocked(sub, id)
+	}
+	wsInboxSubscriptions.Set(float64(len(t.subs)))
+}
+
+// unsubscribe drops the subscription of sessionID and reports whether it existed.
+func (t *inboxTracker) unsubscribe(sessionID string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	ok := t.unsubscribeLocked(sessionID)
+	wsInboxSubscriptions.Set(float64(len(t.subs)))
+	return ok
+}
+
+func (t *inboxTracker) unsubscribeLocked(sessionID string) bool {
+	sub := t.subs[sessionID]
+	if sub == nil {
+		return false
+	}
+	delete(t.subs, sessionID)
+	for id := range sub.convs {
+		t.unfollowLocked(sub, id)
+	}
+	return true
+}
+
+// follow adds conversationID to every inbox subscription of userID.
+func (t *inboxTracker) follow(userID, conversationID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, sub := range t.subs {
+		if sub.client.UserID == userID {
+			t.followLocked(sub, conversationID)
+		}
+	}
+}
+
+// unfollow removes conversationID from every inbox subscription of userID.
+func (t *inboxTracker) unfollow(userID, conversationID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, sub := range t.convs[conversationID] {
+		if sub.client.UserID == userID {
+			t.unfollowLocked(sub, conversationID)
+		}
+	}
+}
+
+func (t *inboxTracker) followLocked(sub *inboxSub, conversationID string) {
+	if conversationID == "" {
+		return
+	}
+	sub.convs[conversationID] = struct{}{}
+	subs := t.convs[conversationID]
+	if subs == nil {
+		subs = make(map[string]*inboxSub)
+		t.convs[conversationID] = subs
+	}
+	subs[sub.client.SessionID] = sub
+}
+
+func (t *inboxTracker) unfollowLocked(sub *inboxSub, conversationID string) {
+	delete(sub.convs, conversationID)
+	subs := t.convs[conversationID]
+	delete(subs, sub.client.SessionID)
+	if len(subs) == 0 {
+		delete(t.convs, conversationID)
+	}
+}
+
+// subscribers snapshots the clients whose inbox follows conversationID.
+func (t *inboxTracker) subscribers(conversationID string) []*Client {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	subs := t.convs[conversationID]
+	if len(subs) == 0 {
+		return nil
+	}
+	out := make([]*Client, 0, len(subs))
+	for _, sub := range subs {
+		out = append(out, sub.client)
+	}
+	return out
+}
+
+// SubscribeInbox makes client receive message previews and membership events of
+// conversationIDs without joining them. A later call replaces the set.
+func (h *Hub) SubscribeInbox(client *Client, conversationIDs []string) {
+	if client == nil || client.SessionID == "" {
+		return
+	}
+	h.inbox.subscribe(client, conversationIDs)
+}
+
+// UnsubscribeInbox ends the inbox subscription of sessionID and reports whether
+// there was one.
+func (h *Hub) UnsubscribeInbox(sessionID string) bool {
+	return h.inbox.unsubscribe(sessionID)
+}
+
+// deliverInbox offers a conversation broadcast to the inbox subscribers following
+// conversationID, skipping sessions joined to it (they receive the full event).
+// message.new is reduced to an inbox.message preview; member.added and
+// member.removed are forwarded and make the affected user's inboxes start or
+// stop following the conversation. Other types are not inbox events.
+func (h *Hub) deliverInbox(conversationID string, env v1.Envelope) {
+	var removed string
+	switch env.Type {
+	case v1.TypeMessageNew:
+	case v1.TypeMemberAdded:
+		var p v1.MemberAddedPayload
+		if err := json.Unmarshal(env.Payload, &p); err == nil {
+			h.inbox.follow(p.UserID, conversationID)
+		}
+	case v1.TypeMemberRemoved:
+		var p v1.MemberRemovedPayload
+		if err := json.Unmarshal(env.Payload, &p); err == nil {
+			removed = p.UserID
+		}
+	default:
+		return
+	}
+
+	subs := h.inbox.subscribers(conversationID)
+	if removed != "" {
+		h.inbox.unfollow(removed, conversationID)
+	}
+	if len(subs) == 0 {
+		return
+	}
+
+	out := env
+	if env.Type == v1.TypeMessageNew {
+		var ok bool
+		if out, ok = inboxPreview(env); !ok {
+			return
+		}
+	}
+	conv := h.Conversation(conversationID)
+	for _, cl := range subs {
+		if conv.hasSession(cl.SessionID) {
+			continue
+		}
+		cl.Offer(out)
+	}
+}
+
+// inboxPreview builds the inbox.message for a message.new envelope.
+func inboxPreview(env v1.Envelope) (v1.Envelope, bool) {
+	var m v1.MessageNewPayload
+	if err := json.Unmarshal(env.Payload, &m); err != nil {
+		return v1.Envelope{}, false
+	}
+	p := v1.InboxMessagePayload{
+		ConversationID:  m.ConversationID,
+		ServerMsgID:     m.ServerMsgID,
+		Seq:             m.Seq,
+		Sender:          m.Sender,
+		SenderBotID:     m.SenderBotID,
+		Preview:         truncateRunes(m.Text, inboxPreviewRunes),
+		ContentType:     m.ContentType,
+		AttachmentCount: len(m.AttachmentIDs),
+		ServerTS:        m.ServerTS,
+	}
+	b, _ := json.Marshal(p)
+	return mustNewEnvelope(v1.TypeInboxMessage, b, time.Now().UTC()), true
+}
+
+// truncateRunes returns the first n runes of s.
+func truncateRunes(s string, n int) string {
+	i := 0
+	for pos := range s {
+		if i == n {
+			return s[:pos]
+		}
+		i++
+	}
+	return s
+}
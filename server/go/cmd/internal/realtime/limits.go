@@ -5,11 +5,29 @@ import "time"
 // Security/performance limits.
 // Keep these aligned with docs/spec/realtime-v1.md (and PR policies).
 const (
-	// Max bytes per websocket frame read (hard limit).
-	maxFrameBytes = 64 << 10 // 64 KiB
+	// Default max bytes per websocket frame read (ARC_WS_MAX_FRAME_BYTES).
+	defaultMaxFrameBytes = 64 << 10 // 64 KiB
+	// Floor for ARC_WS_MAX_FRAME_BYTES so control envelopes always fit.
+	minMaxFrameBytes = 4 << 10 // 4 KiB
 
-	// Max message text length (runes).
-	maxMessageChars = 4000
+	// Default max message text length in runes (ARC_WS_MAX_MESSAGE_CHARS).
+	defaultMaxMessageChars = 4000
+	// Ceiling for ARC_WS_MAX_MESSAGE_CHARS: chk_messages_text_len allows 4096
+	// characters in arc.messages.text.
+	maxStoredMessageChars = 4096
+
+	// Max attachments referenced by one message.
+	maxMessageAttachments = 10
+
+	// Max entities (mentions, links) recorded per message.
+	maxMessageEntities = 100
+
+	// Max ciphertext bytes of one end-to-end encrypted message.
+	maxE2EECiphertextBytes = 32 << 10 // 32 KiB
+
+	// Max device key ids one encrypted message references, and their max length.
+	maxE2EEKeyIDs     = 100
+	maxE2EEKeyIDBytes = 128
 )
 
 const (
@@ -20,4 +38,8 @@ const (
 	// Per-connection rate limits (events per window).
 	rateLimitEvents = 120
 	rateLimitWindow = 10 * time.Second
+
+	// Per-(session, conversation) message.send token bucket: burst size and refill interval.
+	conversationSendBurst  = 10
+	conversationSendRefill = time.Second
 )
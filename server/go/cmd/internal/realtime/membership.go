@@ -6,7 +6,10 @@ import (
 	"strings"
 	"time"
 
+	"arc/cmd/internal/dbtrace"
+
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -15,6 +18,13 @@ const (
 	conversationVisibilityPrivate = "private"
 )
 
+// Conversation member roles stored in conversation_members.role.
+const (
+	MemberRoleOwner  = "owner"
+	MemberRoleAdmin  = "admin"
+	MemberRoleMember = "member"
+)
+
 var (
 	// ErrConversationNotFound is returned when a conversation id does not exist.
 	ErrConversationNotFound = errors.New("realtime: conversation not found")
@@ -22,6 +32,12 @@ var (
 	ErrMembershipRequired = errors.New("realtime: membership required")
 	// ErrConversationNotPrivate is returned when AddMember is called for a non-private conversation.
 	ErrConversationNotPrivate = errors.New("realtime: conversation is not private")
+	// ErrAlreadyMember is returned when AddMemberWithRole targets an existing member.
+	ErrAlreadyMember = errors.New("realtime: already a member")
+	// ErrMemberUserNotFound is returned when the user being added does not exist.
+	ErrMemberUserNotFound = errors.New("realtime: user not found")
+	// ErrInvalidMemberRole is returned for roles outside owner/admin/member.
+	ErrInvalidMemberRole = errors.New("realtime: invalid member role")
 )
 
 // ConversationInfo represents the ACL-relevant metadata of a conversation.
@@ -43,6 +59,31 @@ type MembershipStore interface {
 	AddMember(ctx context.Context, userID, conversationID string) error
 }
 
+// MembershipManager is implemented by membership stores that support role-aware
+// member management (HTTP membership endpoints).
+type MembershipManager interface {
+	MembershipStore
+	// GetMemberRole returns the role of userID in conversationID, or ErrMembershipRequired.
+	GetMemberRole(ctx context.Context, userID, conversationID string) (string, error)
+	// AddMemberWithRole adds userID with role to conversationID regardless of visibility.
+	// It returns ErrAlreadyMember when the user is already a member.
+	AddMemberWithRole(ctx context.Context, userID, conversationID, role string) error
+	// RemoveMember deletes the membership row. It returns ErrMembershipRequired when absent.
+	RemoveMember(ctx context.Context, userID, conversationID string) error
+}
+
+// NormalizeMemberRole lower-cases role and validates it; empty defaults to member.
+func NormalizeMemberRole(role string) (string, error) {
+	switch r := strings.ToLower(strings.TrimSpace(role)); r {
+	case "":
+		return MemberRoleMember, nil
+	case MemberRoleOwner, MemberRoleAdmin, MemberRoleMember:
+		return r, nil
+	default:
+		return "", ErrInvalidMemberRole
+	}
+}
+
 // PostgresMembershipStore checks membership via arc.conversation_members.
 type PostgresMembershipStore struct {
 	pool   *pgxpool.Pool
@@ -89,6 +130,7 @@ func NewPostgresMembershipStore(pool *pgxpool.Pool, opts ...MembershipOption) (*
 
 // GetConversation fetches ACL metadata for a conversation.
 func (s *PostgresMembershipStore) GetConversation(ctx context.Context, conversationID string) (ConversationInfo, error) {
+	ctx = dbtrace.WithOp(ctx, "realtime.get_conversation")
 	if s == nil || s.pool == nil {
 		return ConversationInfo{}, errors.New("realtime: nil membership store")
 	}
@@ -129,6 +171,7 @@ func (s *PostgresMembershipStore) GetConversation(ctx context.Context, conversat
 
 // IsMember checks if userID is a member of conversationID.
 func (s *PostgresMembershipStore) IsMember(ctx context.Context, userID, conversationID string) (bool, error) {
+	ctx = dbtrace.WithOp(ctx, "realtime.is_member")
 	if s == nil || s.pool == nil {
 		return false, errors.New("realtime: nil membership store")
 	}
@@ -159,6 +202,7 @@ func (s *PostgresMembershipStore) IsMember(ctx context.Context, userID, conversa
 
 // EnsureMember checks membership and returns ErrMembershipRequired when absent.
 func (s *PostgresMembershipStore) EnsureMember(ctx context.Context, userID, conversationID string) error {
+	ctx = dbtrace.WithOp(ctx, "realtime.ensure_member")
 	if s == nil || s.pool == nil {
 		return errors.New("realtime: nil membership store")
 	}
@@ -199,6 +243,7 @@ func (s *PostgresMembershipStore) EnsureMember(ctx context.Context, userID, conv
 
 // AddMember adds a user to a private conversation (idempotent).
 func (s *PostgresMembershipStore) AddMember(ctx context.Context, userID, conversationID string) error {
+	ctx = dbtrace.WithOp(ctx, "realtime.add_member")
 	if s == nil || s.pool == nil {
 		return errors.New("realtime: nil membership store")
 	}
@@ -255,4 +300,129 @@ func (s *PostgresMembershipStore) AddMember(ctx context.Context, userID, convers
 	return tx.Commit(ctx)
 }
 
-var _ MembershipStore = (*PostgresMembershipStore)(nil)
+// GetMemberRole returns the stored role of userID in conversationID.
+func (s *PostgresMembershipStore) GetMemberRole(ctx context.Context, userID, conversationID string) (string, error) {
+	ctx = dbtrace.WithOp(ctx, "realtime.get_member_role")
+	if s == nil || s.pool == nil {
+		return "", errors.New("realtime: nil membership store")
+	}
+	userID = strings.TrimSpace(userID)
+	conversationID = strings.TrimSpace(conversationID)
+	if userID == "" || conversationID == "" {
+		return "", errors.New("realtime: missing user_id or conversation_id")
+	}
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	conversations := pgIdent(s.schema, "conversations")
+	members := pgIdent(s.schema, "conversation_members")
+
+	var role *string
+	err := s.pool.QueryRow(ctx,
+		`SELECT m.role
+		   FROM `+conversations+` c
+		   LEFT JOIN `+members+` m
+		     ON m.conversation_id = c.id AND m.user_id = $2
+		  WHERE c.id = $1`,
+		conversationID, userID,
+	).Scan(&role)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", ErrConversationNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	if role == nil {
+		return "", ErrMembershipRequired
+	}
+	return *role, nil
+}
+
+// AddMemberWithRole inserts a membership row with an explicit role.
+func (s *PostgresMembershipStore) AddMemberWithRole(ctx context.Context, userID, conversationID, role string) error {
+	ctx = dbtrace.WithOp(ctx, "realtime.add_member_with_role")
+	if s == nil || s.pool == nil {
+		return errors.New("realtime: nil membership store")
+	}
+	userID = strings.TrimSpace(userID)
+	conversationID = strings.TrimSpace(conversationID)
+	if userID == "" || conversationID == "" {
+		return errors.New("realtime: missing user_id or conversation_id")
+	}
+	role, err := NormalizeMemberRole(role)
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	conversations := pgIdent(s.schema, "conversations")
+	members := pgIdent(s.schema, "conversation_members")
+
+	var inserted bool
+	err = s.pool.QueryRow(ctx,
+		`WITH conv AS (
+		     SELECT id FROM `+conversations+` WHERE id = $1
+		 ), ins AS (
+		     INSERT INTO `+members+` (conversation_id, user_id, role, joined_at)
+		     SELECT id, $2, $3, $4 FROM conv
+		     ON CONFLICT (conversation_id, user_id) DO NOTHING
+		     RETURNING 1
+		 )
+		 SELECT EXISTS (SELECT 1 FROM ins)
+		   FROM conv`,
+		conversationID, userID, role, now,
+	).Scan(&inserted)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrConversationNotFound
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		return ErrMemberUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if !inserted {
+		return ErrAlreadyMember
+	}
+	return nil
+}
+
+// RemoveMember deletes userID's membership in conversationID.
+func (s *PostgresMembershipStore) RemoveMember(ctx context.Context, userID, conversationID string) error {
+	ctx = dbtrace.WithOp(ctx, "realtime.remove_member")
+	if s == nil || s.pool == nil {
+		return errors.New("realtime: nil membership store")
+	}
+	userID = strings.TrimSpace(userID)
+	conversationID = strings.TrimSpace(conversationID)
+	if userID == "" || conversationID == "" {
+		return errors.New("realtime: missing user_id or conversation_id")
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	members := pgIdent(s.schema, "conversation_members")
+
+	tag, err := s.pool.Exec(ctx,
+		`DELETE FROM `+members+` WHERE conversation_id = $1 AND user_id = $2`,
+		conversationID, userID,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrMembershipRequired
+	}
+	return nil
+}
+
+var (
+	_ MembershipStore   = (*PostgresMembershipStore)(nil)
+	_ MembershipManager = (*PostgresMembershipStore)(nil)
+)
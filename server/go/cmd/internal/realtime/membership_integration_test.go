@@ -7,6 +7,8 @@ import (
 	"testing"
 	"time"
 
+	"arc/cmd/internal/migrations/migrationstest"
+
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -16,10 +18,7 @@ func TestPostgresMembershipStore_PublicConversation_RequiresExplicitMembership(t
 	pool := mustOpenTestPool(t)
 	defer pool.Close()
 
-	schema := mustCreateTestSchema(t, pool)
-	t.Cleanup(func() { mustDropSchema(t, pool, schema) })
-
-	mustApplyMembershipSchemaRT(t, pool, schema)
+	schema := migrationstest.NewSchema(t, pool)
 
 	store, err := NewPostgresMembershipStore(pool, WithMembershipSchema(schema))
 	if err != nil {
@@ -62,10 +61,7 @@ func TestPostgresMembershipStore_PrivateConversation_AddAndEnsureMember(t *testi
 	pool := mustOpenTestPool(t)
 	defer pool.Close()
 
-	schema := mustCreateTestSchema(t, pool)
-	t.Cleanup(func() { mustDropSchema(t, pool, schema) })
-
-	mustApplyMembershipSchemaRT(t, pool, schema)
+	schema := migrationstest.NewSchema(t, pool)
 
 	store, err := NewPostgresMembershipStore(pool, WithMembershipSchema(schema))
 	if err != nil {
@@ -73,7 +69,7 @@ func TestPostgresMembershipStore_PrivateConversation_AddAndEnsureMember(t *testi
 	}
 
 	const (
-		userID = "01HYYYYYYYYYYYYYYYYYYYYYYYY"
+		userID = "01HYYYYYYYYYYYYYYYYYYYYYYY"
 		convID = "conv-private-membership-1"
 	)
 	mustInsertMembershipUserRT(t, pool, schema, userID)
@@ -111,43 +107,421 @@ func TestPostgresMembershipStore_PrivateConversation_AddAndEnsureMember(t *testi
 	}
 }
 
-func mustApplyMembershipSchemaRT(t *testing.T, pool *pgxpool.Pool, schema string) {
-	t.Helper()
+func TestPostgresMembershipStore_RolesAddRemove(t *testing.T) {
+	t.Parallel()
+
+	pool := mustOpenTestPool(t)
+	defer pool.Close()
+
+	schema := migrationstest.NewSchema(t, pool)
+
+	store, err := NewPostgresMembershipStore(pool, WithMembershipSchema(schema))
+	if err != nil {
+		t.Fatalf("new membership store: %v", err)
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	const (
+		ownerID  = "01HXXXXXXXXXXXXXXXXXXXXXX1"
+		memberID = "01HXXXXXXXXXXXXXXXXXXXXXX2"
+		ghostID  = "01HXXXXXXXXXXXXXXXXXXXXXX3"
+		convID   = "conv-roles-1"
+	)
+	mustInsertMembershipUserRT(t, pool, schema, ownerID)
+	mustInsertMembershipUserRT(t, pool, schema, memberID)
+	mustInsertMembershipConversationRT(t, pool, schema, convID, "room", conversationVisibilityPublic)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := store.AddMemberWithRole(ctx, ownerID, convID, MemberRoleOwner); err != nil {
+		t.Fatalf("add owner: %v", err)
+	}
+	if err := store.AddMemberWithRole(ctx, memberID, convID, ""); err != nil {
+		t.Fatalf("add member: %v", err)
+	}
+	if err := store.AddMemberWithRole(ctx, memberID, convID, MemberRoleAdmin); !errors.Is(err, ErrAlreadyMember) {
+		t.Fatalf("expected ErrAlreadyMember, got %v", err)
+	}
+	if err := store.AddMemberWithRole(ctx, ghostID, convID, MemberRoleMember); !errors.Is(err, ErrMemberUserNotFound) {
+		t.Fatalf("expected ErrMemberUserNotFound, got %v", err)
+	}
+	if err := store.AddMemberWithRole(ctx, memberID, "conv-missing", MemberRoleMember); !errors.Is(err, ErrConversationNotFound) {
+		t.Fatalf("expected ErrConversationNotFound, got %v", err)
+	}
+
+	role, err := store.GetMemberRole(ctx, ownerID, convID)
+	if err != nil || role != MemberRoleOwner {
+		t.Fatalf("owner role: got %q, %v", role, err)
+	}
+	role, err = store.GetMemberRole(ctx, memberID, convID)
+	if err != nil || role != MemberRoleMember {
+		t.Fatalf("member role: got %q, %v", role, err)
+	}
+
+	if err := store.RemoveMember(ctx, memberID, convID); err != nil {
+		t.Fatalf("remove member: %v", err)
+	}
+	if err := store.RemoveMember(ctx, memberID, convID); !errors.Is(err, ErrMembershipRequired) {
+		t.Fatalf("expected ErrMembershipRequired on second remove, got %v", err)
+	}
+	if _, err := store.GetMemberRole(ctx, memberID, convID); !errors.Is(err, ErrMembershipRequired) {
+		t.Fatalf("expected ErrMembershipRequired after remove, got %v", err)
+	}
+}
+
+func TestPostgresMembershipStore_ListUserConversations_UnreadAndPaging(t *testing.T) {
+	t.Parallel()
+
+	pool := mustOpenTestPool(t)
+	defer pool.Close()
+
+	schema := migrationstest.NewSchema(t, pool)
+
+	members, err := NewPostgresMembershipStore(pool, WithMembershipSchema(schema))
+	if err != nil {
+		t.Fatalf("new membership store: %v", err)
+	}
+	msgs := mustNewStore(t, pool, schema)
+
+	const userID = "01HWWWWWWWWWWWWWWWWWWWWWW1"
+	mustInsertMembershipUserRT(t, pool, schema, userID)
+	mustCreateTestSessions(t, pool, schema, userID, "sess-1")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	convIDs := []string{"conv-list-a", "conv-list-b", "conv-list-c"}
+	for i, convID := range convIDs {
+		mustInsertMembershipConversationRT(t, pool, schema, convID, "group", conversationVisibilityPrivate)
+		if err := members.AddMemberWithRole(ctx, userID, convID, MemberRoleMember); err != nil {
+			t.Fatalf("add member: %v", err)
+		}
+		// conv-list-c is newest; each conversation gets i+1 messages.
+		for j := 0; j <= i; j++ {
+			if _, err := msgs.AppendMessage(ctx, AppendMessageInput{
+				ConversationID: convID,
+				ClientMsgID:    fmt.Sprintf("c-%d-%d", i, j),
+				SenderSession:  testID("sess-1"),
+				Text:           fmt.Sprintf("hello %d", j),
+				Now:            base.Add(time.Duration(i)*time.Hour + time.Duration(j)*time.Minute),
+			}); err != nil {
+				t.Fatalf("append: %v", err)
+			}
+		}
+	}
+
+	cur, err := members.MarkRead(ctx, userID, "conv-list-c", 2)
+	if err != nil {
+		t.Fatalf("mark read: %v", err)
+	}
+	if !cur.Advanced || cur.LastReadSeq != 2 || cur.LastSeq != 3 || cur.UnreadCount() != 1 {
+		t.Fatalf("unexpected cursor after mark read: %+v", cur)
+	}
+	cur, err = members.MarkRead(ctx, userID, "conv-list-c", 1)
+	if err != nil {
+		t.Fatalf("mark read backwards: %v", err)
+	}
+	if cur.Advanced || cur.LastReadSeq != 2 {
+		t.Fatalf("expected cursor to stay at 2, got %+v", cur)
+	}
+	cur, err = members.MarkRead(ctx, userID, "conv-list-a", 99)
+	if err != nil {
+		t.Fatalf("mark read past end: %v", err)
+	}
+	if cur.LastReadSeq != 1 || cur.UnreadCount() != 0 {
+		t.Fatalf("expected cursor clamped to latest seq, got %+v", cur)
+	}
+	if _, err := members.MarkRead(ctx, "01HWWWWWWWWWWWWWWWWWWWWWW2", "conv-list-a", 1); !errors.Is(err, ErrMembershipRequired) {
+		t.Fatalf("expected ErrMembershipRequired for non-member, got %v", err)
+	}
+
+	if _, err := pool.Exec(ctx,
+		`INSERT INTO `+pgIdent(schema, "conversation_notification_prefs")+` (conversation_id, user_id, level, muted_until)
+		 VALUES ('conv-list-c', $1, 'mentions', now() + interval '1 hour'), ('conv-list-b', $1, 'all', now() - interval '1 hour')`,
+		userID,
+	); err != nil {
+		t.Fatalf("insert notification prefs: %v", err)
+	}
+
+	page1, err := members.ListUserConversations(ctx, ListUserConversationsInput{UserID: userID, Limit: 2})
+	if err != nil {
+		t.Fatalf("list page 1: %v", err)
+	}
+	if len(page1.Conversations) != 2 || page1.Next == nil {
+		t.Fatalf("expected 2 conversations and a next cursor, got %d next=%v", len(page1.Conversations), page1.Next)
+	}
+	c := page1.Conversations[0]
+	if c.ConversationID != "conv-list-c" || c.LastSeq != 3 || c.LastReadSeq != 2 || c.UnreadCount != 1 {
+		t.Fatalf("unexpected first row: %+v", c)
+	}
+	if c.LatestMessage == nil || c.LatestMessage.Text != "hello 2" {
+		t.Fatalf("unexpected latest message: %+v", c.LatestMessage)
+	}
+	if c.Notifications.Level != NotificationLevelMentions || c.Notifications.MutedUntil == nil {
+		t.Fatalf("unexpected notification prefs: %+v", c.Notifications)
+	}
+	if b := page1.Conversations[1]; b.ConversationID != "conv-list-b" || b.UnreadCount != 2 {
+		t.Fatalf("unexpected second row: %+v", b)
+	}
+	if b := page1.Conversations[1].Notifications; b.Level != NotificationLevelAll || b.MutedUntil != nil {
+		t.Fatalf("expected expired mute to be hidden, got %+v", b)
+	}
+
+	page2, err := members.ListUserConversations(ctx, ListUserConversationsInput{UserID: userID, Limit: 2, After: page1.Next})
+	if err != nil {
+		t.Fatalf("list page 2: %v", err)
+	}
+	if len(page2.Conversations) != 1 || page2.Next != nil {
+		t.Fatalf("expected last page with 1 conversation, got %d next=%v", len(page2.Conversations), page2.Next)
+	}
+	if a := page2.Conversations[0]; a.ConversationID != "conv-list-a" || a.LastReadSeq != 1 || a.UnreadCount != 0 {
+		t.Fatalf("unexpected page 2 row: %+v", a)
+	}
+}
+
+func TestPostgresMembershipStore_DeliveryReceipts(t *testing.T) {
+	t.Parallel()
+
+	pool := mustOpenTestPool(t)
+	defer pool.Close()
+
+	schema := migrationstest.NewSchema(t, pool)
+
+	members, err := NewPostgresMembershipStore(pool, WithMembershipSchema(schema))
+	if err != nil {
+		t.Fatalf("new membership store: %v", err)
+	}
+	msgs := mustNewStore(t, pool, schema)
+
+	const (
+		alice  = "01HWWWWWWWWWWWWWWWWWWWWWA1"
+		bob    = "01HWWWWWWWWWWWWWWWWWWWWWB1"
+		carol  = "01HWWWWWWWWWWWWWWWWWWWWWC1"
+		convID = "conv-receipts"
+	)
+	mustInsertMembershipConversationRT(t, pool, schema, convID, "group", conversationVisibilityPrivate)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	for _, userID := range []string{alice, bob, carol} {
+		mustInsertMembershipUserRT(t, pool, schema, userID)
+		if err := members.AddMemberWithRole(ctx, userID, convID, MemberRoleMember); err != nil {
+			t.Fatalf("add member: %v", err)
+		}
+	}
+	mustCreateTestSessions(t, pool, schema, alice, "sess-1")
+
+	var second StoredMessage
+	for i := 1; i <= 3; i++ {
+		res, err := msgs.AppendMessage(ctx, AppendMessageInput{
+			ConversationID: convID,
+			ClientMsgID:    fmt.Sprintf("c-%d", i),
+			SenderSession:  testID("sess-1"),
+			Text:           fmt.Sprintf("hello %d", i),
+		})
+		if err != nil {
+			t.Fatalf("append: %v", err)
+		}
+		if i == 2 {
+			second = res.Stored
+		}
+	}
+
+	if _, ok, err := members.LastDelivered(ctx, bob, convID); err != nil || ok {
+		t.Fatalf("expected no cursor before ack, got ok=%v err=%v", ok, err)
+	}
+
+	cur, err := members.MarkDelivered(ctx, bob, convID, 2)
+	if err != nil {
+		t.Fatalf("mark delivered: %v", err)
+	}
+	if !cur.Advanced || cur.LastDeliveredSeq != 2 {
+		t.Fatalf("unexpected cursor: %+v", cur)
+	}
+	if cur, err = members.MarkDelivered(ctx, bob, convID, 1); err != nil || cur.Advanced || cur.LastDeliveredSeq != 2 {
+		t.Fatalf("expected cursor to stay at 2, got %+v, %v", cur, err)
+	}
+	if cur, err = members.MarkDelivered(ctx, alice, convID, 99); err != nil || cur.LastDeliveredSeq != 3 {
+		t.Fatalf("expected cursor clamped to latest seq, got %+v, %v", cur, err)
+	}
+	if _, err := members.MarkDelivered(ctx, "01HWWWWWWWWWWWWWWWWWWWWWZ9", convID, 1); !errors.Is(err, ErrMembershipRequired) {
+		t.Fatalf("expected ErrMembershipRequired for non-member, got %v", err)
+	}
+	if seq, ok, err := members.LastDelivered(ctx, bob, convID); err != nil || !ok || seq != 2 {
+		t.Fatalf("unexpected last delivered: %d, %v, %v", seq, ok, err)
+	}
+
+	// Carol read without acking delivery: reading implies delivery.
+	if _, err := members.MarkRead(ctx, carol, convID, 2); err != nil {
+		t.Fatalf("mark read: %v", err)
+	}
+
+	out, err := members.MessageReceipts(ctx, convID, second.ServerMsgID)
+	if err != nil {
+		t.Fatalf("receipts: %v", err)
+	}
+	if out.Seq != 2 || len(out.Receipts) != 3 {
+		t.Fatalf("unexpected receipts: %+v", out)
+	}
+	want := map[string]MessageReceipt{
+		alice: {UserID: alice, Delivered: true},
+		bob:   {UserID: bob, Delivered: true},
+		carol: {UserID: carol, Delivered: true, Read: true},
+	}
+	for _, rc := range out.Receipts {
+		if rc != want[rc.UserID] {
+			t.Fatalf("unexpected receipt %+v", rc)
+		}
+	}
+
+	if _, err := members.MessageReceipts(ctx, convID, "missing"); !errors.Is(err, ErrMessageNotFound) {
+		t.Fatalf("expected ErrMessageNotFound, got %v", err)
+	}
+}
+
+func TestPostgresMembershipStore_SharesConversation(t *testing.T) {
+	t.Parallel()
+
+	pool := mustOpenTestPool(t)
+	defer pool.Close()
+
+	schema := migrationstest.NewSchema(t, pool)
+
+	store, err := NewPostgresMembershipStore(pool, WithMembershipSchema(schema))
+	if err != nil {
+		t.Fatalf("new membership store: %v", err)
+	}
+
+	const (
+		userA = "01HVVVVVVVVVVVVVVVVVVVVVV1"
+		userB = "01HVVVVVVVVVVVVVVVVVVVVVV2"
+		userC = "01HVVVVVVVVVVVVVVVVVVVVVV3"
+	)
+	for _, id := range []string{userA, userB, userC} {
+		mustInsertMembershipUserRT(t, pool, schema, id)
+	}
+	mustInsertMembershipConversationRT(t, pool, schema, "conv-shared-1", "group", conversationVisibilityPrivate)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	for _, id := range []string{userA, userB} {
+		if err := store.AddMember(ctx, id, "conv-shared-1"); err != nil {
+			t.Fatalf("add member: %v", err)
+		}
+	}
+
+	if ok, err := store.SharesConversation(ctx, userA, userB); err != nil || !ok {
+		t.Fatalf("expected A and B to share a conversation, got %v, %v", ok, err)
+	}
+	if ok, err := store.SharesConversation(ctx, userA, userC); err != nil || ok {
+		t.Fatalf("expected A and C not to share a conversation, got %v, %v", ok, err)
+	}
+}
+
+func TestPostgresMembershipStore_ResolveMentions(t *testing.T) {
+	t.Parallel()
+
+	pool := mustOpenTestPool(t)
+	defer pool.Close()
+
+	schema := migrationstest.NewSchema(t, pool)
+
+	store, err := NewPostgresMembershipStore(pool, WithMembershipSchema(schema))
+	if err != nil {
+		t.Fatalf("new membership store: %v", err)
+	}
+
+	const (
+		userA = "01HVVVVVVVVVVVVVVVVVVVVVW1"
+		userB = "01HVVVVVVVVVVVVVVVVVVVVVW2"
+	)
+	for _, id := range []string{userA, userB} {
+		mustInsertMembershipUserRT(t, pool, schema, id)
+	}
+	mustInsertMembershipConversationRT(t, pool, schema, "conv-mention-1", "group", conversationVisibilityPrivate)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	users := pgIdent(schema, "users")
-	conversations := pgIdent(schema, "conversations")
-	members := pgIdent(schema, "conversation_members")
+	for id, name := range map[string]string{userA: "alice", userB: "bob"} {
+		if _, err := pool.Exec(ctx, `UPDATE `+users+` SET username = $2, username_norm = $2 WHERE id = $1`, id, name); err != nil {
+			t.Fatalf("set username: %v", err)
+		}
+	}
+	if err := store.AddMember(ctx, userA, "conv-mention-1"); err != nil {
+		t.Fatalf("add member: %v", err)
+	}
+
+	got, err := store.ResolveMentions(ctx, "conv-mention-1", []string{"alice", "bob", "nobody"})
+	if err != nil {
+		t.Fatalf("resolve mentions: %v", err)
+	}
+	if len(got) != 1 || got["alice"] != userA {
+		t.Fatalf("expected only the member alice to resolve, got %v", got)
+	}
+}
+
+func TestPostgresMembershipStore_FindOrCreateDirectConversation(t *testing.T) {
+	t.Parallel()
 
-	schemaSQL := fmt.Sprintf(`
-CREATE TABLE IF NOT EXISTS %s (
-  id TEXT PRIMARY KEY
-);
-
-CREATE TABLE IF NOT EXISTS %s (
-  id TEXT PRIMARY KEY,
-  kind TEXT NOT NULL CHECK (kind IN ('direct', 'group', 'room')),
-  visibility TEXT NOT NULL DEFAULT 'private' CHECK (visibility IN ('public', 'private')),
-  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
-);
-
-CREATE TABLE IF NOT EXISTS %s (
-  conversation_id TEXT NOT NULL REFERENCES %s(id) ON DELETE CASCADE,
-  user_id TEXT NOT NULL REFERENCES %s(id) ON DELETE CASCADE,
-  joined_at TIMESTAMPTZ NOT NULL DEFAULT now(),
-  role TEXT NOT NULL DEFAULT 'member',
-  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
-  PRIMARY KEY (conversation_id, user_id)
-);
-
-CREATE INDEX IF NOT EXISTS idx_conversation_members_user_id
-  ON %s (user_id);
-`, users, conversations, members, conversations, users, members)
-
-	if _, err := pool.Exec(ctx, schemaSQL); err != nil {
-		t.Fatalf("apply membership schema: %v", err)
+	pool := mustOpenTestPool(t)
+	defer pool.Close()
+
+	schema := migrationstest.NewSchema(t, pool)
+
+	store, err := NewPostgresMembershipStore(pool, WithMembershipSchema(schema))
+	if err != nil {
+		t.Fatalf("new membership store: %v", err)
+	}
+
+	const (
+		userA   = "01HVVVVVVVVVVVVVVVVVVVVVX1"
+		userB   = "01HVVVVVVVVVVVVVVVVVVVVVX2"
+		missing = "01HVVVVVVVVVVVVVVVVVVVVVX9"
+	)
+	for _, id := range []string{userA, userB} {
+		mustInsertMembershipUserRT(t, pool, schema, id)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	first, err := store.FindOrCreateDirectConversation(ctx, userB, userA)
+	if err != nil || !first.Created {
+		t.Fatalf("expected a new conversation, got %+v, %v", first, err)
+	}
+	info, err := store.GetConversation(ctx, first.ConversationID)
+	if err != nil || info.Kind != "direct" || info.Visibility != conversationVisibilityPrivate {
+		t.Fatalf("unexpected conversation %+v, %v", info, err)
+	}
+
+	// Either side gets the same conversation back. A caller who left is re-added;
+	// a peer who left is not.
+	for _, id := range []string{userA, userB} {
+		if err := store.RemoveMember(ctx, id, first.ConversationID); err != nil {
+			t.Fatalf("remove member %s: %v", id, err)
+		}
+	}
+	again, err := store.FindOrCreateDirectConversation(ctx, userA, userB)
+	if err != nil || again.Created || again.ConversationID != first.ConversationID {
+		t.Fatalf("expected the existing conversation %s, got %+v, %v", first.ConversationID, again, err)
+	}
+	if err := store.EnsureMember(ctx, userA, first.ConversationID); err != nil {
+		t.Fatalf("ensure member %s: %v", userA, err)
+	}
+	if err := store.EnsureMember(ctx, userB, first.ConversationID); !errors.Is(err, ErrMembershipRequired) {
+		t.Fatalf("expected the peer to stay out, got %v", err)
+	}
+
+	if _, err := store.FindOrCreateDirectConversation(ctx, userA, userA); !errors.Is(err, ErrDirectConversationSelf) {
+		t.Fatalf("expected ErrDirectConversationSelf, got %v", err)
+	}
+	if _, err := store.FindOrCreateDirectConversation(ctx, userA, missing); !errors.Is(err, ErrDirectConversationForbidden) {
+		t.Fatalf("expected ErrDirectConversationForbidden, got %v", err)
 	}
 }
 
@@ -0,0 +1,42 @@
+package realtime
+
+import (
+	"time"
+
+	"arc/cmd/internal/metrics"
+)
+
+var (
+	wsConnections = metrics.NewGauge("arc_ws_connections",
+		"Open realtime sessions (WebSocket and gRPC streams).")
+	wsConnectionsTotal = metrics.NewCounter("arc_ws_connections_total",
+		"Realtime sessions opened since start.")
+	wsMaintenanceRejected = metrics.NewCounter("arc_ws_maintenance_rejected_total",
+		"WebSocket connects closed with 1013 during maintenance mode.")
+	wsSessionRevokedClosed = metrics.NewCounter("arc_ws_session_revoked_closed_total",
+		"Realtime sessions closed with 4001 because their auth session was revoked.")
+	wsConnLimitRejected = metrics.NewCounterVec("arc_ws_connection_limit_rejected_total",
+		"Realtime sessions refused because a per-user, per-session or per-IP connection limit was reached.", "limit")
+	wsHellos = metrics.NewCounterVec("arc_ws_hello_total",
+		"Realtime hellos by announced client platform.", "platform")
+	wsInboxSubscriptions = metrics.NewGauge("arc_ws_inbox_subscriptions",
+		"Realtime sessions subscribed to their user's inbox.")
+	wsFirehoseStreams = metrics.NewGauge("arc_firehose_streams",
+		"Open integration event streams (gRPC Firehose).")
+	wsFeatureDisabled = metrics.NewCounterVec("arc_ws_feature_disabled_total",
+		"Realtime requests refused because their feature flag is off.", "flag")
+	wsSendQueueDepth = metrics.NewHistogram("arc_ws_send_queue_depth",
+		"Envelopes still queued for a session when one is written.",
+		[]float64{0, 1, 2, 4, 8, 16, 32, 64, 128, 256})
+	messageAppendDuration = metrics.NewHistogramVec("arc_message_append_duration_seconds",
+		"Message store append latency by result.", metrics.DefBuckets, "result")
+)
+
+// observeAppend records the latency of a message store append started at start.
+func observeAppend(start time.Time, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	messageAppendDuration.With(result).Observe(time.Since(start).Seconds())
+}
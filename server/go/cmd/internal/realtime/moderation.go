@@ -0,0 +1,52 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	v1 "arc/shared/contracts/realtime/v1"
+)
+
+// RemoveMessageInput is a moderator removal of any member's message.
+type RemoveMessageInput struct {
+	ConversationID  string
+	ServerMsgID     string
+	ModeratorUserID string
+	// Reason is echoed in message.removed ("" if none).
+	Reason string
+}
+
+// RemoveMessage tombstones a message regardless of its author and broadcasts
+// message.removed, also to other nodes. Callers authorize the moderator.
+// Removing a tombstone is a no-op and is not broadcast again.
+func (g *WSGateway) RemoveMessage(ctx context.Context, in RemoveMessageInput) (MessageMutationResult, error) {
+	now := time.Now().UTC()
+	res, err := g.store.DeleteMessage(ctx, DeleteMessageInput{
+		MessageActor:   MessageActor{ActorUserID: strings.TrimSpace(in.ModeratorUserID)},
+		ConversationID: strings.TrimSpace(in.ConversationID),
+		ServerMsgID:    strings.TrimSpace(in.ServerMsgID),
+		Now:            now,
+		Moderator:      true,
+	})
+	if err != nil || !res.Changed {
+		return res, err
+	}
+
+	m := res.Stored
+	deletedAt := now
+	if m.DeletedAt != nil {
+		deletedAt = *m.DeletedAt
+	}
+	payload, _ := json.Marshal(v1.MessageRemovedPayload{
+		ConversationID: m.ConversationID,
+		ServerMsgID:    m.ServerMsgID,
+		Seq:            m.Seq,
+		Version:        m.Version,
+		DeletedAt:      deletedAt,
+		Reason:         in.Reason,
+	})
+	g.hub.Broadcast(m.ConversationID, mustNewEnvelope(v1.TypeMessageRemoved, payload, now))
+	return res, nil
+}
@@ -0,0 +1,81 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	v1 "arc/shared/contracts/realtime/v1"
+)
+
+func TestRemoveMessage(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	hub := NewHub(log)
+	st := NewInMemoryStore()
+	g := NewWSGateway(log, hub, st, nil, nil)
+
+	appended, err := st.AppendMessage(ctx, AppendMessageInput{
+		ConversationID: "c1",
+		ClientMsgID:    "k1",
+		SenderSession:  "s1",
+		Text:           "buy now",
+		Now:            time.Now().UTC(),
+	})
+	if err != nil {
+		t.Fatalf("append: %v", err)
+	}
+
+	c := NewClient("u2", "s2", 4)
+	hub.AddClient(c)
+	hub.GetOrCreateConversation("c1").Join(c)
+
+	if _, err := st.DeleteMessage(ctx, DeleteMessageInput{
+		MessageActor:   MessageActor{ActorSession: "s2"},
+		ConversationID: "c1",
+		ServerMsgID:    appended.Stored.ServerMsgID,
+	}); !errors.Is(err, ErrMessageNotAuthor) {
+		t.Fatalf("expected ErrMessageNotAuthor without Moderator, got %v", err)
+	}
+
+	res, err := g.RemoveMessage(ctx, RemoveMessageInput{
+		ConversationID:  "c1",
+		ServerMsgID:     appended.Stored.ServerMsgID,
+		ModeratorUserID: "mod",
+		Reason:          "spam",
+	})
+	if err != nil || !res.Changed || !res.Stored.Deleted() || res.Stored.Text != "" {
+		t.Fatalf("unexpected removal %+v err=%v", res, err)
+	}
+
+	select {
+	case env := <-c.Send:
+		var p v1.MessageRemovedPayload
+		if err := json.Unmarshal(env.Payload, &p); err != nil {
+			t.Fatalf("decode payload: %v", err)
+		}
+		if env.Type != v1.TypeMessageRemoved || p.ServerMsgID != appended.Stored.ServerMsgID || p.Reason != "spam" || p.Version != 2 {
+			t.Fatalf("unexpected fanout %s %+v", env.Type, p)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected message.removed fanout")
+	}
+
+	again, err := g.RemoveMessage(ctx, RemoveMessageInput{ConversationID: "c1", ServerMsgID: appended.Stored.ServerMsgID, ModeratorUserID: "mod"})
+	if err != nil || again.Changed {
+		t.Fatalf("expected no-op for a tombstone, got %+v err=%v", again, err)
+	}
+	if len(c.Send) != 0 {
+		t.Fatalf("expected no second broadcast")
+	}
+
+	if _, err := g.RemoveMessage(ctx, RemoveMessageInput{ConversationID: "c1", ServerMsgID: "missing"}); !errors.Is(err, ErrMessageNotFound) {
+		t.Fatalf("expected ErrMessageNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package realtime
+
+import "time"
+
+// Notification levels.
+const (
+	NotificationLevelAll      = "all"
+	NotificationLevelMentions = "mentions"
+)
+
+// NotificationPrefs are a member's push preferences for one conversation.
+type NotificationPrefs struct {
+	// Level is NotificationLevelAll or NotificationLevelMentions.
+	Level string
+	// MutedUntil silences all pushes until the given time; nil when not muted.
+	MutedUntil *time.Time
+}
+
+// OfflineMessage describes a newly stored message for members without a
+// connected session.
+type OfflineMessage struct {
+	ConversationID string
+	ServerMsgID    string
+	Seq            int64
+	SenderUserID   string
+	Text           string
+	// MentionedUserIDs lists users referenced by mention entities.
+	MentionedUserIDs []string
+	ServerTS         time.Time
+}
+
+// OfflineNotifier is told about every stored message so it can reach members
+// that are not connected (e.g. through push notifications).
+type OfflineNotifier interface {
+	// NotifyOffline must not block the session loop; implementations queue work.
+	NotifyOffline(msg OfflineMessage)
+}
+
+// WithOfflineNotifier notifies n after each new message is fanned out.
+func WithOfflineNotifier(n OfflineNotifier) GatewayOption {
+	return func(g *WSGateway) { g.offline = n }
+}
+
+// notifyOffline hands a stored message to the offline notifier, if any.
+// Anonymous senders are skipped: pushes are only sent within member-checked
+// conversations.
+func (g *WSGateway) notifyOffline(client *Client, stored StoredMessage) {
+	if g.offline == nil || client.UserID == "" {
+		return
+	}
+	g.offline.NotifyOffline(offlineMessage(client.UserID, stored))
+}
+
+// offlineMessage describes stored for an OfflineNotifier. senderUserID is empty
+// for bot messages.
+func offlineMessage(senderUserID string, stored StoredMessage) OfflineMessage {
+	var mentioned []string
+	for _, e := range stored.Entities {
+		if e.Type == EntityMention && e.UserID != "" {
+			mentioned = append(mentioned, e.UserID)
+		}
+	}
+	return OfflineMessage{
+		ConversationID:   stored.ConversationID,
+		ServerMsgID:      stored.ServerMsgID,
+		Seq:              stored.Seq,
+		SenderUserID:     senderUserID,
+		Text:             stored.Text,
+		MentionedUserIDs: mentioned,
+		ServerTS:         stored.ServerTS,
+	}
+}
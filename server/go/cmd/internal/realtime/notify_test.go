@@ -0,0 +1,68 @@
+package realtime
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+)
+
+type recordingNotifier struct {
+	got []OfflineMessage
+}
+
+func (n *recordingNotifier) NotifyOffline(msg OfflineMessage) {
+	n.got = append(n.got, msg)
+}
+
+func TestGatewayNotifyOffline(t *testing.T) {
+	t.Parallel()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	n := &recordingNotifier{}
+	g := NewWSGateway(log, NewHub(log), NewInMemoryStore(), nil, nil, WithOfflineNotifier(n))
+
+	stored := StoredMessage{
+		ConversationID: "c1",
+		ServerMsgID:    "m1",
+		Seq:            3,
+		Text:           "hi @bob see example.com",
+		Entities: []MessageEntity{
+			{Type: EntityMention, Offset: 3, Length: 4, UserID: "u2"},
+			{Type: EntityURL, Offset: 12, Length: 11, URL: "https://example.com"},
+		},
+	}
+
+	g.notifyOffline(&Client{SessionID: "s0"}, stored)
+	if len(n.got) != 0 {
+		t.Fatalf("expected anonymous senders to be skipped")
+	}
+
+	g.notifyOffline(&Client{SessionID: "s1", UserID: "u1"}, stored)
+	if len(n.got) != 1 {
+		t.Fatalf("expected one notification, got %d", len(n.got))
+	}
+	msg := n.got[0]
+	if msg.SenderUserID != "u1" || msg.Seq != 3 || len(msg.MentionedUserIDs) != 1 || msg.MentionedUserIDs[0] != "u2" {
+		t.Fatalf("unexpected offline message %+v", msg)
+	}
+}
+
+func TestHubUserConnected(t *testing.T) {
+	t.Parallel()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	h := NewHub(log)
+	c := NewClient("u1", "s1", 1)
+
+	if h.UserConnected("u1") {
+		t.Fatalf("expected u1 to be offline")
+	}
+	h.AddClient(c)
+	if !h.UserConnected("u1") {
+		t.Fatalf("expected u1 to be connected")
+	}
+	h.RemoveClient(c)
+	if h.UserConnected("u1") {
+		t.Fatalf("expected u1 to be offline after disconnect")
+	}
+}
@@ -0,0 +1,242 @@
+package realtime
+
+import (
+	"slices"
+	"time"
+
+	v1 "arc/shared/contracts/realtime/v1"
+)
+
+// wsDefaultOrderHold bounds how long a message.new waits for a lower seq.
+const wsDefaultOrderHold = 500 * time.Millisecond
+
+// orderedLane keeps the seq-tagged envelopes of one conversation in seq order on
+// a client's queues, so the connection never observes a seq regression.
+//
+// message.new is released when it directly follows the last released seq; a gap
+// holds it until the missing seqs arrive or the hold expires. Envelopes at or
+// below the last released seq were superseded and are discarded. message.ack
+// never overtakes a lower message.new that is held or still queued.
+type orderedLane struct {
+	// first and last are the lowest and highest message.new seq released (0 before any).
+	first int64
+	last  int64
+	// written is the highest message.new seq written to the connection.
+	written int64
+	// sealed holds every envelope until unsealed, e.g. while a join replays missed messages.
+	sealed bool
+	// held are envelopes waiting for a lower seq, ordered by seq.
+	held  []v1.Envelope
+	timer *time.Timer
+}
+
+// isOrdered reports whether env is routed through its conversation's lane.
+func isOrdered(env v1.Envelope) bool {
+	return env.ConvID != "" && env.Seq > 0 && (env.Type == v1.TypeMessageNew || env.Type == v1.TypeMessageAck)
+}
+
+// withSeq tags env with the conversation and seq of the message it carries.
+func withSeq(env v1.Envelope, conversationID string, seq int64) v1.Envelope {
+	env.ConvID = conversationID
+	env.Seq = seq
+	return env
+}
+
+func (c *Client) lane(conversationID string) *orderedLane {
+	l := c.lanes[conversationID]
+	if l == nil {
+		if c.lanes == nil {
+			c.lanes = make(map[string]*orderedLane)
+		}
+		l = &orderedLane{}
+		c.lanes[conversationID] = l
+	}
+	return l
+}
+
+// offerOrdered queues a seq-tagged envelope through its conversation's lane.
+// Held and superseded envelopes count as accepted; only backpressure reports false.
+func (c *Client) offerOrdered(env v1.Envelope) bool {
+	c.lanesMu.Lock()
+	defer c.lanesMu.Unlock()
+
+	l := c.lane(env.ConvID)
+	if c.policy.OrderHold >= 0 && (l.sealed || l.blocks(env)) {
+		c.hold(env.ConvID, l, env)
+		return true
+	}
+	ok := c.release(l, env)
+	c.releaseHeld(l, false)
+	return ok
+}
+
+// blocks reports whether env must wait for a lower seq: a gap after the last
+// released message.new, or (for acks) a lower message.new held already.
+func (l *orderedLane) blocks(env v1.Envelope) bool {
+	if l.last > 0 && env.Seq > l.last+1 {
+		return true
+	}
+	return env.Type != v1.TypeMessageNew && len(l.held) > 0 && l.held[0].Seq < env.Seq
+}
+
+// release queues env, discarding a message.new the lane already passed.
+// Callers hold c.lanesMu.
+func (c *Client) release(l *orderedLane, env v1.Envelope) bool {
+	if env.Type != v1.TypeMessageNew {
+		if l.written < l.last {
+			// Earlier message.new are still queued: stay behind them unless the queue is full.
+			select {
+			case c.Send <- env:
+				c.dropStreak.Store(0)
+				return true
+			default:
+			}
+		}
+		return c.enqueue(env)
+	}
+
+	if env.Seq <= l.last {
+		return true
+	}
+	if l.first == 0 {
+		l.first = env.Seq
+	}
+	l.last = env.Seq
+	return c.enqueue(env)
+}
+
+// releaseHeld queues held envelopes that no longer wait for a lower seq; force
+// flushes them all in seq order, skipping gaps.
+func (c *Client) releaseHeld(l *orderedLane, force bool) {
+	for len(l.held) > 0 && !l.sealed {
+		next := l.held[0]
+		if !force && l.blocks(next) {
+			break
+		}
+		l.held = l.held[1:]
+		c.release(l, next)
+	}
+	if len(l.held) == 0 && !l.sealed && l.timer != nil {
+		l.timer.Stop()
+		l.timer = nil
+	}
+}
+
+// hold parks env in seq order (after envelopes of the same seq) and arms the
+// lane's hold timer.
+func (c *Client) hold(conversationID string, l *orderedLane, env v1.Envelope) {
+	i, _ := slices.BinarySearchFunc(l.held, env.Seq+1, func(e v1.Envelope, seq int64) int {
+		if e.Seq < seq {
+			return -1
+		}
+		return 1
+	})
+	l.held = slices.Insert(l.held, i, env)
+	if l.timer == nil {
+		l.timer = time.AfterFunc(c.orderHold(), func() { c.flushLane(conversationID) })
+	}
+}
+
+// flushLane releases everything a lane holds once its hold expired; missing
+// seqs are given up on and clients fill the gap from history.
+func (c *Client) flushLane(conversationID string) {
+	c.lanesMu.Lock()
+	defer c.lanesMu.Unlock()
+
+	l := c.lanes[conversationID]
+	if l == nil {
+		return
+	}
+	l.timer = nil
+	l.sealed = false
+	c.releaseHeld(l, true)
+}
+
+func (c *Client) orderHold() time.Duration {
+	if c.policy.OrderHold > 0 {
+		return c.policy.OrderHold
+	}
+	return wsDefaultOrderHold
+}
+
+// SealLane holds conversationID's seq-tagged envelopes until UnsealLane, so
+// messages replayed after a join or resume are not overtaken by live fanout.
+// It is a no-op when the policy disables ordering holds.
+func (c *Client) SealLane(conversationID string) {
+	if c.policy.OrderHold < 0 {
+		return
+	}
+	c.lanesMu.Lock()
+	defer c.lanesMu.Unlock()
+
+	l := c.lane(conversationID)
+	l.sealed = true
+	if l.timer == nil {
+		l.timer = time.AfterFunc(c.orderHold(), func() { c.flushLane(conversationID) })
+	}
+}
+
+// UnsealLane releases a sealed lane. afterSeq, when positive, marks the seqs
+// up to it as already known to the client, so replays continue from there.
+func (c *Client) UnsealLane(conversationID string, afterSeq int64) {
+	c.lanesMu.Lock()
+	defer c.lanesMu.Unlock()
+
+	l := c.lane(conversationID)
+	if afterSeq > l.last {
+		if l.first == 0 {
+			l.first = afterSeq + 1
+		}
+		l.last = afterSeq
+	}
+	l.sealed = false
+	c.releaseHeld(l, false)
+}
+
+// LaneStart returns the lowest message.new seq released for conversationID on
+// this connection, or 0.
+func (c *Client) LaneStart(conversationID string) int64 {
+	c.lanesMu.Lock()
+	defer c.lanesMu.Unlock()
+
+	if l := c.lanes[conversationID]; l != nil {
+		return l.first
+	}
+	return 0
+}
+
+// markWritten records that a seq-tagged message.new reached the connection.
+func (c *Client) markWritten(env v1.Envelope) {
+	c.lanesMu.Lock()
+	defer c.lanesMu.Unlock()
+
+	if l := c.lanes[env.ConvID]; l != nil && env.Seq > l.written {
+		l.written = env.Seq
+	}
+}
+
+// dropLane forgets conversationID's lane after the client left it; held
+// envelopes are discarded.
+func (c *Client) dropLane(conversationID string) {
+	c.lanesMu.Lock()
+	defer c.lanesMu.Unlock()
+
+	if l := c.lanes[conversationID]; l != nil && l.timer != nil {
+		l.timer.Stop()
+	}
+	delete(c.lanes, conversationID)
+}
+
+// stopLanes cancels pending hold timers; held envelopes are discarded.
+func (c *Client) stopLanes() {
+	c.lanesMu.Lock()
+	defer c.lanesMu.Unlock()
+
+	for _, l := range c.lanes {
+		if l.timer != nil {
+			l.timer.Stop()
+			l.timer = nil
+		}
+		l.held = nil
+	}
+}
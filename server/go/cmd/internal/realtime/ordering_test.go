@@ -0,0 +1,126 @@
+package realtime
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	v1 "arc/shared/contracts/realtime/v1"
+)
+
+func seqEnvelope(typ string, seq int64) v1.Envelope {
+	return withSeq(testEnvelope(typ), "c1", seq)
+}
+
+func queuedSeqs(t *testing.T, ch chan v1.Envelope) []int64 {
+	t.Helper()
+	var out []int64
+	for {
+		select {
+		case env := <-ch:
+			out = append(out, env.Seq)
+		default:
+			return out
+		}
+	}
+}
+
+func TestClient_Offer_ReordersWithinConversation(t *testing.T) {
+	t.Parallel()
+
+	c := NewClientWithPolicy("u1", "s1", 8, BackpressurePolicy{OrderHold: time.Minute})
+	for _, seq := range []int64{1, 3, 4, 2} {
+		if !c.Offer(seqEnvelope(v1.TypeMessageNew, seq)) {
+			t.Fatalf("offer seq %d rejected", seq)
+		}
+	}
+	if got := queuedSeqs(t, c.Send); !slices.Equal(got, []int64{1, 2, 3, 4}) {
+		t.Fatalf("queued seqs = %v, want [1 2 3 4]", got)
+	}
+}
+
+func TestClient_Offer_DiscardsSupersededSeq(t *testing.T) {
+	t.Parallel()
+
+	c := NewClientWithPolicy("u1", "s1", 8, BackpressurePolicy{OrderHold: time.Minute})
+	c.Offer(seqEnvelope(v1.TypeMessageNew, 5))
+	c.Offer(seqEnvelope(v1.TypeMessageNew, 6))
+	if !c.Offer(seqEnvelope(v1.TypeMessageNew, 5)) {
+		t.Fatalf("superseded envelope must not count as a drop")
+	}
+	if got := queuedSeqs(t, c.Send); !slices.Equal(got, []int64{5, 6}) {
+		t.Fatalf("queued seqs = %v, want [5 6]", got)
+	}
+	if c.Dropped() != 0 {
+		t.Fatalf("dropped = %d, want 0", c.Dropped())
+	}
+}
+
+func TestClient_Offer_FlushesGapAfterHold(t *testing.T) {
+	t.Parallel()
+
+	c := NewClientWithPolicy("u1", "s1", 8, BackpressurePolicy{OrderHold: 20 * time.Millisecond})
+	c.Offer(seqEnvelope(v1.TypeMessageNew, 1))
+	c.Offer(seqEnvelope(v1.TypeMessageNew, 3))
+	if got := queuedSeqs(t, c.Send); !slices.Equal(got, []int64{1}) {
+		t.Fatalf("queued seqs = %v, want [1] while 3 is held", got)
+	}
+
+	select {
+	case env := <-c.Send:
+		if env.Seq != 3 {
+			t.Fatalf("flushed seq = %d, want 3", env.Seq)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("held envelope was not flushed")
+	}
+
+	// The missing seq arriving late would be a regression.
+	c.Offer(seqEnvelope(v1.TypeMessageNew, 2))
+	if got := queuedSeqs(t, c.Send); len(got) != 0 {
+		t.Fatalf("queued seqs = %v, want none", got)
+	}
+}
+
+func TestClient_Offer_AckStaysBehindQueuedMessages(t *testing.T) {
+	t.Parallel()
+
+	c := NewClientWithPolicy("u1", "s1", 8, BackpressurePolicy{PriorityQueueSize: 4, OrderHold: time.Minute})
+	c.Offer(seqEnvelope(v1.TypeMessageNew, 1))
+	c.Offer(seqEnvelope(v1.TypeMessageAck, 2))
+	if len(c.Priority) != 0 {
+		t.Fatalf("ack overtook a queued message.new on the priority lane")
+	}
+
+	// Once written, acks use the priority lane again.
+	for _, env := range []v1.Envelope{<-c.Send, <-c.Send} {
+		trackDelivered(c, env)
+	}
+	c.Offer(seqEnvelope(v1.TypeMessageNew, 2))
+	trackDelivered(c, <-c.Send)
+	c.Offer(seqEnvelope(v1.TypeMessageAck, 3))
+	if len(c.Priority) != 1 {
+		t.Fatalf("priority depth = %d, want 1", len(c.Priority))
+	}
+}
+
+func TestClient_SealLane_ReplaysBeforeLiveFanout(t *testing.T) {
+	t.Parallel()
+
+	c := NewClientWithPolicy("u1", "s1", 8, BackpressurePolicy{OrderHold: time.Minute})
+	c.SealLane("c1")
+	c.Offer(seqEnvelope(v1.TypeMessageNew, 5))
+	if got := queuedSeqs(t, c.Send); len(got) != 0 {
+		t.Fatalf("queued seqs = %v while sealed, want none", got)
+	}
+
+	c.UnsealLane("c1", 2)
+	c.Offer(seqEnvelope(v1.TypeMessageNew, 3))
+	c.Offer(seqEnvelope(v1.TypeMessageNew, 4))
+	if got := queuedSeqs(t, c.Send); !slices.Equal(got, []int64{3, 4, 5}) {
+		t.Fatalf("queued seqs = %v, want [3 4 5]", got)
+	}
+	if got := c.LaneStart("c1"); got != 3 {
+		t.Fatalf("lane start = %d, want 3", got)
+	}
+}
@@ -0,0 +1,203 @@
+package realtime
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"arc/cmd/internal/dbtrace"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Presence statuses.
+const (
+	PresenceOnline  = "online"
+	PresenceAway    = "away"
+	PresenceOffline = "offline"
+)
+
+// Last-seen visibility policies (ARC_PRESENCE_LAST_SEEN).
+const (
+	PresenceLastSeenEveryone = "everyone"
+	PresenceLastSeenMembers  = "members"
+	PresenceLastSeenNobody   = "nobody"
+)
+
+// PresenceState is the aggregated presence of one user across all sessions.
+type PresenceState struct {
+	UserID string
+	Status string
+	// LastSeenAt is the last disconnect of an offline user, or zero when unknown
+	// (never connected to this node) or while online.
+	LastSeenAt time.Time
+}
+
+// PresenceAudience decides whether two users share a conversation, which gates
+// last-seen visibility under the "members" policy.
+type PresenceAudience interface {
+	SharesConversation(ctx context.Context, userA, userB string) (bool, error)
+}
+
+type userPresence struct {
+	sessions map[string]string // session id -> online|away
+	lastSeen time.Time
+}
+
+type presenceSub struct {
+	client       *Client
+	showLastSeen bool
+}
+
+// presenceTracker aggregates per-session presence into per-user state and
+// tracks which sessions subscribed to which users.
+//
+// State is node-local and in-memory: last seen is lost on restart.
+type presenceTracker struct {
+	mu    sync.Mutex
+	users map[string]*userPresence
+	subs  map[string]map[string]presenceSub // target user id -> subscriber session id -> sub
+}
+
+func newPresenceTracker() *presenceTracker {
+	return &presenceTracker{
+		users: make(map[string]*userPresence),
+		subs:  make(map[string]map[string]presenceSub),
+	}
+}
+
+func (p *presenceTracker) stateLocked(userID string) PresenceState {
+	st := PresenceState{UserID: userID, Status: PresenceOffline}
+	u := p.users[userID]
+	if u == nil {
+		return st
+	}
+	if len(u.sessions) == 0 {
+		st.LastSeenAt = u.lastSeen
+		return st
+	}
+	st.Status = PresenceAway
+	for _, s := range u.sessions {
+		if s == PresenceOnline {
+			st.Status = PresenceOnline
+			break
+		}
+	}
+	return st
+}
+
+func (p *presenceTracker) state(userID string) PresenceState {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stateLocked(userID)
+}
+
+// set records sessionID's status ("" removes the session) and returns the new
+// aggregate state plus the subscribers to notify when the aggregate changed.
+func (p *presenceTracker) set(userID, sessionID, status string, now time.Time) (PresenceState, []presenceSub, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	before := p.stateLocked(userID)
+
+	u := p.users[userID]
+	if u == nil {
+		u = &userPresence{sessions: make(map[string]string)}
+		p.users[userID] = u
+	}
+	if status == "" {
+		delete(u.sessions, sessionID)
+		if len(u.sessions) == 0 {
+			u.lastSeen = now
+		}
+	} else {
+		u.sessions[sessionID] = status
+	}
+
+	after := p.stateLocked(userID)
+	if after.Status == before.Status {
+		return after, nil, false
+	}
+
+	subs := make([]presenceSub, 0, len(p.subs[userID]))
+	for _, s := range p.subs[userID] {
+		subs = append(subs, s)
+	}
+	return after, subs, true
+}
+
+func (p *presenceTracker) subscribe(target string, sub presenceSub) PresenceState {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	m := p.subs[target]
+	if m == nil {
+		m = make(map[string]presenceSub)
+		p.subs[target] = m
+	}
+	m[sub.client.SessionID] = sub
+	return p.stateLocked(target)
+}
+
+// dropSubscriber removes every subscription held by sessionID.
+func (p *presenceTracker) dropSubscriber(sessionID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for target, m := range p.subs {
+		delete(m, sessionID)
+		if len(m) == 0 {
+			delete(p.subs, target)
+		}
+	}
+}
+
+func normalizePresenceLastSeen(v string) string {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case PresenceLastSeenEveryone:
+		return PresenceLastSeenEveryone
+	case PresenceLastSeenNobody:
+		return PresenceLastSeenNobody
+	default:
+		return PresenceLastSeenMembers
+	}
+}
+
+// SharesConversation reports whether userA and userB are members of at least one common conversation.
+func (s *PostgresMembershipStore) SharesConversation(ctx context.Context, userA, userB string) (bool, error) {
+	ctx = dbtrace.WithOp(ctx, "realtime.shares_conversation")
+	if s == nil || s.pool == nil {
+		return false, errors.New("realtime: nil membership store")
+	}
+	userA = strings.TrimSpace(userA)
+	userB = strings.TrimSpace(userB)
+	if userA == "" || userB == "" {
+		return false, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	members := pgIdent(s.schema, "conversation_members")
+
+	var one int
+	err := s.pool.QueryRow(ctx,
+		`SELECT 1
+		   FROM `+members+` a
+		   JOIN `+members+` b ON b.conversation_id = a.conversation_id
+		  WHERE a.user_id = $1 AND b.user_id = $2
+		  LIMIT 1`,
+		userA, userB,
+	).Scan(&one)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+var _ PresenceAudience = (*PostgresMembershipStore)(nil)
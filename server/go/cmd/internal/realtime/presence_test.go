@@ -0,0 +1,150 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"testing"
+
+	v1 "arc/shared/contracts/realtime/v1"
+)
+
+func TestHub_Presence_AggregatesSessions(t *testing.T) {
+	t.Parallel()
+
+	hub := NewHub(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	if st := hub.Presence("alice"); st.Status != PresenceOffline || !st.LastSeenAt.IsZero() {
+		t.Fatalf("unknown user: got %+v", st)
+	}
+
+	phone := NewClient("alice", "s-phone", 8)
+	laptop := NewClient("alice", "s-laptop", 8)
+	hub.AddClient(phone)
+	hub.AddClient(laptop)
+	if st := hub.Presence("alice"); st.Status != PresenceOnline {
+		t.Fatalf("expected online, got %q", st.Status)
+	}
+
+	hub.SetPresence(phone, PresenceAway)
+	if st := hub.Presence("alice"); st.Status != PresenceOnline {
+		t.Fatalf("one online session keeps the user online, got %q", st.Status)
+	}
+	hub.SetPresence(laptop, PresenceAway)
+	if st := hub.Presence("alice"); st.Status != PresenceAway {
+		t.Fatalf("expected away, got %q", st.Status)
+	}
+
+	hub.RemoveClient(phone)
+	hub.RemoveClient(laptop)
+	st := hub.Presence("alice")
+	if st.Status != PresenceOffline || st.LastSeenAt.IsZero() {
+		t.Fatalf("expected offline with last seen, got %+v", st)
+	}
+}
+
+func TestHub_Presence_NotifiesSubscribersOnChange(t *testing.T) {
+	t.Parallel()
+
+	hub := NewHub(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	watcher := NewClient("bob", "s-bob", 8)
+	hidden := NewClient("carol", "s-carol", 8)
+	hub.AddClient(watcher)
+	hub.AddClient(hidden)
+
+	if st := hub.SubscribePresence(watcher, "alice", true); st.Status != PresenceOffline {
+		t.Fatalf("expected offline before connect, got %q", st.Status)
+	}
+	hub.SubscribePresence(hidden, "alice", false)
+
+	alice := NewClient("alice", "s-alice", 8)
+	hub.AddClient(alice)
+	hub.SetPresence(alice, PresenceOnline) // no change, no notification
+	hub.RemoveClient(alice)
+
+	got := drainPresence(t, watcher)
+	if len(got) != 2 || got[0].Status != PresenceOnline || got[1].Status != PresenceOffline {
+		t.Fatalf("unexpected updates: %+v", got)
+	}
+	if got[1].LastSeenAt == nil {
+		t.Fatalf("expected last_seen_at for permitted subscriber")
+	}
+
+	hiddenGot := drainPresence(t, hidden)
+	if len(hiddenGot) != 2 || hiddenGot[1].LastSeenAt != nil {
+		t.Fatalf("expected last_seen_at to be withheld, got %+v", hiddenGot)
+	}
+
+	// Subscriptions die with the subscriber's session.
+	hub.RemoveClient(watcher)
+	hub.AddClient(alice)
+	if n := len(watcher.Send); n != 0 {
+		t.Fatalf("expected no updates after unsubscribe, got %d", n)
+	}
+}
+
+func TestWSGateway_CanSeeLastSeen_Policies(t *testing.T) {
+	t.Parallel()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	g := NewWSGateway(log, NewHub(log), NewInMemoryStore(), nil, nil)
+	ctx := context.Background()
+
+	g.presenceLastSeen = PresenceLastSeenNobody
+	if !g.canSeeLastSeen(ctx, "u1", "u1") {
+		t.Fatalf("users always see their own last seen")
+	}
+	if g.canSeeLastSeen(ctx, "u1", "u2") {
+		t.Fatalf("nobody policy must hide last seen")
+	}
+
+	g.presenceLastSeen = PresenceLastSeenEveryone
+	if !g.canSeeLastSeen(ctx, "u1", "u2") {
+		t.Fatalf("everyone policy must show last seen")
+	}
+
+	g.presenceLastSeen = PresenceLastSeenMembers
+	if g.canSeeLastSeen(ctx, "u1", "u2") {
+		t.Fatalf("members policy without a membership store must hide last seen")
+	}
+}
+
+func TestNormalizePresenceLastSeen(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"":          PresenceLastSeenMembers,
+		"members":   PresenceLastSeenMembers,
+		"EVERYONE":  PresenceLastSeenEveryone,
+		" nobody ":  PresenceLastSeenNobody,
+		"something": PresenceLastSeenMembers,
+	}
+	for in, want := range cases {
+		if got := normalizePresenceLastSeen(in); got != want {
+			t.Fatalf("normalizePresenceLastSeen(%q)=%q want %q", in, got, want)
+		}
+	}
+}
+
+func drainPresence(t *testing.T, c *Client) []v1.PresenceUpdatePayload {
+	t.Helper()
+
+	var out []v1.PresenceUpdatePayload
+	for {
+		select {
+		case env := <-c.Send:
+			if env.Type != v1.TypePresenceUpdate {
+				t.Fatalf("unexpected envelope type %q", env.Type)
+			}
+			var p v1.PresenceUpdatePayload
+			if err := json.Unmarshal(env.Payload, &p); err != nil {
+				t.Fatalf("decode presence: %v", err)
+			}
+			out = append(out, p)
+		default:
+			return out
+		}
+	}
+}
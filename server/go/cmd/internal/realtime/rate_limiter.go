@@ -28,6 +28,19 @@ func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
 	}
 }
 
+// SetLimit changes the limit and window; invalid inputs keep the current values.
+// Events already counted stay in the window.
+func (r *RateLimiter) SetLimit(limit int, window time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if limit > 0 {
+		r.limit = limit
+	}
+	if window > 0 {
+		r.window = window
+	}
+}
+
 // Allow reports whether an event at time "now" should be permitted.
 func (r *RateLimiter) Allow(now time.Time) bool {
 	r.mu.Lock()
@@ -48,3 +61,94 @@ func (r *RateLimiter) Allow(now time.Time) bool {
 	r.events = append(r.events, now)
 	return true
 }
+
+// ConversationRateLimiter holds token buckets per conversation for one session's
+// message.send. It complements the connection-wide RateLimiter so a client cannot
+// spend its whole budget flooding a single conversation.
+type ConversationRateLimiter struct {
+	mu      sync.Mutex
+	burst   float64
+	refill  time.Duration
+	buckets map[string]*tokenBucket
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// conversationBucketsPrune is the bucket count above which idle, full buckets are dropped.
+const conversationBucketsPrune = 64
+
+// NewConversationRateLimiter allows bursts of burst sends per conversation,
+// refilling one token every refill. Invalid inputs fall back to the defaults.
+func NewConversationRateLimiter(burst int, refill time.Duration) *ConversationRateLimiter {
+	if burst <= 0 {
+		burst = conversationSendBurst
+	}
+	if refill <= 0 {
+		refill = conversationSendRefill
+	}
+	return &ConversationRateLimiter{
+		burst:   float64(burst),
+		refill:  refill,
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// Allow takes a token for conversationID at now. When the bucket is empty it
+// returns false and how long until the next token.
+func (r *ConversationRateLimiter) Allow(conversationID string, now time.Time) (bool, time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b := r.buckets[conversationID]
+	if b == nil {
+		if len(r.buckets) >= conversationBucketsPrune {
+			r.pruneLocked(now)
+		}
+		b = &tokenBucket{tokens: r.burst, last: now}
+		r.buckets[conversationID] = b
+	}
+	r.refillLocked(b, now)
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) * float64(r.refill))
+		return false, wait
+	}
+	b.tokens--
+	return true, 0
+}
+
+// SetLimit changes the burst and refill interval; invalid inputs keep the current
+// values. Buckets keep their tokens, capped at the new burst.
+func (r *ConversationRateLimiter) SetLimit(burst int, refill time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if burst > 0 {
+		r.burst = float64(burst)
+		for _, b := range r.buckets {
+			b.tokens = min(b.tokens, r.burst)
+		}
+	}
+	if refill > 0 {
+		r.refill = refill
+	}
+}
+
+func (r *ConversationRateLimiter) refillLocked(b *tokenBucket, now time.Time) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = min(r.burst, b.tokens+float64(elapsed)/float64(r.refill))
+		b.last = now
+	}
+}
+
+// pruneLocked drops buckets that have refilled completely; they behave like new ones.
+func (r *ConversationRateLimiter) pruneLocked(now time.Time) {
+	for id, b := range r.buckets {
+		r.refillLocked(b, now)
+		if b.tokens >= r.burst {
+			delete(r.buckets, id)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package realtime
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestConversationRateLimiter(t *testing.T) {
+	t.Parallel()
+
+	rl := NewConversationRateLimiter(2, time.Second)
+	t0 := time.Unix(1_700_000_000, 0)
+
+	for i := range 2 {
+		if ok, _ := rl.Allow("c1", t0); !ok {
+			t.Fatalf("send %d: expected burst to be allowed", i)
+		}
+	}
+	ok, wait := rl.Allow("c1", t0)
+	if ok || wait != time.Second {
+		t.Fatalf("expected c1 to be limited for 1s, got ok=%v wait=%s", ok, wait)
+	}
+	if ok, _ := rl.Allow("c2", t0); !ok {
+		t.Fatalf("expected other conversations to keep their own budget")
+	}
+
+	if ok, wait := rl.Allow("c1", t0.Add(500*time.Millisecond)); ok || wait != 500*time.Millisecond {
+		t.Fatalf("expected half a token after 500ms, got ok=%v wait=%s", ok, wait)
+	}
+	if ok, _ := rl.Allow("c1", t0.Add(time.Second)); !ok {
+		t.Fatalf("expected a refilled token after 1s")
+	}
+	if ok, _ := rl.Allow("c1", t0.Add(time.Hour)); !ok {
+		t.Fatalf("expected the bucket to refill")
+	}
+}
+
+func TestConversationRateLimiterPrunesIdleBuckets(t *testing.T) {
+	t.Parallel()
+
+	rl := NewConversationRateLimiter(1, time.Second)
+	t0 := time.Unix(1_700_000_000, 0)
+	for i := range conversationBucketsPrune {
+		rl.Allow(fmt.Sprintf("c%d", i), t0)
+	}
+	rl.Allow("busy", t0.Add(10*time.Second))
+	if n := len(rl.buckets); n != 1 {
+		t.Fatalf("expected refilled buckets to be pruned, got %d", n)
+	}
+}
+
+func TestRateLimitersSetLimit(t *testing.T) {
+	t.Parallel()
+
+	t0 := time.Unix(1_700_000_000, 0)
+	rl := NewRateLimiter(1, time.Minute)
+	if !rl.Allow(t0) || rl.Allow(t0) {
+		t.Fatalf("expected a budget of one event")
+	}
+	rl.SetLimit(3, time.Minute)
+	if !rl.Allow(t0) || !rl.Allow(t0) || rl.Allow(t0) {
+		t.Fatalf("expected the raised limit to apply to the current window")
+	}
+
+	crl := NewConversationRateLimiter(5, time.Second)
+	crl.Allow("c1", t0)
+	crl.SetLimit(1, time.Minute)
+	if ok, _ := crl.Allow("c1", t0); !ok {
+		t.Fatalf("expected the remaining token to survive the new burst")
+	}
+	if ok, wait := crl.Allow("c1", t0); ok || wait != time.Minute {
+		t.Fatalf("expected the new refill, got ok=%v wait=%s", ok, wait)
+	}
+}
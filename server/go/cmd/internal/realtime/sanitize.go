@@ -0,0 +1,97 @@
+package realtime
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Message text sanitization policies (ARC_MESSAGE_SANITIZE).
+const (
+	// SanitizeStrip replaces invalid UTF-8 with U+FFFD and removes control
+	// characters other than newline and tab (default).
+	SanitizeStrip = "strip"
+	// SanitizeReject refuses text that strip would change, with reason invalid_text.
+	SanitizeReject = "reject"
+	// SanitizeOff stores text as sent, except that invalid UTF-8 becomes U+FFFD
+	// and NUL is removed: Postgres TEXT cannot hold either.
+	SanitizeOff = "off"
+)
+
+// RejectInvalidText is the rejection reason for text refused by SanitizeReject.
+const RejectInvalidText = "invalid_text"
+
+// normalizeSanitizePolicy maps unknown values to SanitizeStrip.
+func normalizeSanitizePolicy(policy string) string {
+	switch strings.ToLower(strings.TrimSpace(policy)) {
+	case SanitizeReject:
+		return SanitizeReject
+	case SanitizeOff:
+		return SanitizeOff
+	default:
+		return SanitizeStrip
+	}
+}
+
+// sanitizeText applies policy to message text. CRLF and lone CR become LF.
+func sanitizeText(policy, text string) (string, error) {
+	if policy == SanitizeOff {
+		return strings.ReplaceAll(strings.ToValidUTF8(text, string(utf8.RuneError)), "\x00", ""), nil
+	}
+	clean := stripControl(text)
+	if policy == SanitizeReject && clean != strings.ReplaceAll(text, "\r\n", "\n") {
+		return "", &RejectionError{Code: RejectInvalidText, Message: "message contains invalid UTF-8 or control characters"}
+	}
+	return clean, nil
+}
+
+func stripControl(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	var b strings.Builder
+	b.Grow(len(text))
+	for len(text) > 0 {
+		r, size := utf8.DecodeRuneInString(text)
+		text = text[size:]
+		switch {
+		case r == utf8.RuneError && size == 1:
+			b.WriteRune(utf8.RuneError)
+		case r == '\r':
+			b.WriteByte('\n')
+		case r == '\n', r == '\t':
+			b.WriteRune(r)
+		case unicode.IsControl(r):
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// tooLongError rejects text of actual runes against a limit of max.
+func tooLongError(max, actual int) *RejectionError {
+	return &RejectionError{
+		Code:        RejectTooLong,
+		Message:     fmt.Sprintf("message too long: max=%d chars", max),
+		MaxChars:    max,
+		ActualChars: actual,
+	}
+}
+
+// messageText sanitizes and bounds-checks the text of message.send, message.edit
+// and bot messages.
+func (g *WSGateway) messageText(raw string) (string, error) {
+	text, err := sanitizeText(g.sanitizePolicy, raw)
+	if err != nil {
+		return "", err
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", errors.New("empty text")
+	}
+	if n := utf8.RuneCountInString(text); n > g.maxMessageChars {
+		return "", tooLongError(g.maxMessageChars, n)
+	}
+	return text, nil
+}
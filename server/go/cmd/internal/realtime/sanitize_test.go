@@ -0,0 +1,75 @@
+package realtime
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSanitizeText(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name, policy, in, want, reason string
+	}{
+		{"clean", SanitizeStrip, "hi\tthere\nbye", "hi\tthere\nbye", ""},
+		{"line endings", SanitizeStrip, "a\r\nb\rc", "a\nb\nc", ""},
+		{"control", SanitizeStrip, "a\x00b\x1bc\u0085d", "abcd", ""},
+		{"invalid utf8", SanitizeStrip, "a\xffb", "a�b", ""},
+		{"reject clean crlf", SanitizeReject, "a\r\nb", "a\nb", ""},
+		{"reject control", SanitizeReject, "a\x07b", "", RejectInvalidText},
+		{"reject invalid utf8", SanitizeReject, "a\xffb", "", RejectInvalidText},
+		{"off", SanitizeOff, "a\x07\r\nb", "a\x07\r\nb", ""},
+		{"off keeps storable", SanitizeOff, "a\x00\xffb", "a\ufffdb", ""},
+	}
+	for _, tc := range cases {
+		got, err := sanitizeText(tc.policy, tc.in)
+		if tc.reason != "" {
+			var rej *RejectionError
+			if !errors.As(err, &rej) || rej.Code != tc.reason {
+				t.Fatalf("%s: err = %v, want reason %s", tc.name, err, tc.reason)
+			}
+			continue
+		}
+		if err != nil || got != tc.want {
+			t.Fatalf("%s: got %q, %v; want %q", tc.name, got, err, tc.want)
+		}
+	}
+}
+
+func TestWSGateway_MessageText_Limits(t *testing.T) {
+	t.Setenv("ARC_WS_MAX_MESSAGE_CHARS", "5")
+	t.Setenv("ARC_WS_MAX_FRAME_BYTES", "100")
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	g := NewWSGateway(log, NewHub(log), NewInMemoryStore(), nil, nil)
+	if g.maxFrameBytes != minMaxFrameBytes {
+		t.Fatalf("max frame bytes = %d, want floor %d", g.maxFrameBytes, minMaxFrameBytes)
+	}
+
+	if got, err := g.messageText("  héllo\x00 "); err != nil || got != "héllo" {
+		t.Fatalf("got %q, %v; want héllo", got, err)
+	}
+	if _, err := g.messageText(" \x01 "); err == nil {
+		t.Fatalf("expected empty text error")
+	}
+
+	_, err := g.messageText(strings.Repeat("é", 7))
+	var rej *RejectionError
+	if !errors.As(err, &rej) || rej.Code != RejectTooLong || rej.MaxChars != 5 || rej.ActualChars != 7 {
+		t.Fatalf("err = %#v, want too_long max=5 actual=7", err)
+	}
+}
+
+func TestWSGateway_MaxMessageCharsCeiling(t *testing.T) {
+	t.Setenv("ARC_WS_MAX_MESSAGE_CHARS", "10000")
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	g := NewWSGateway(log, NewHub(log), NewInMemoryStore(), nil, nil)
+	defer g.stopReloading()
+	if g.maxMessageChars != maxStoredMessageChars {
+		t.Fatalf("max message chars = %d, want ceiling %d", g.maxMessageChars, maxStoredMessageChars)
+	}
+}
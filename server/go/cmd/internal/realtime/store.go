@@ -2,7 +2,23 @@ package realtime
 
 import (
 	"context"
+	"errors"
 	"time"
+
+	v1 "arc/shared/contracts/realtime/v1"
+)
+
+var (
+	// ErrMessageNotFound is returned when a message id does not exist in the conversation.
+	ErrMessageNotFound = errors.New("realtime: message not found")
+	// ErrMessageNotAuthor is returned when someone other than the sender edits or deletes a message.
+	ErrMessageNotAuthor = errors.New("realtime: not the message author")
+	// ErrMessageDeleted is returned when editing a tombstoned message.
+	ErrMessageDeleted = errors.New("realtime: message deleted")
+	// ErrReplyTargetNotFound is returned when a reply references a message outside the conversation.
+	ErrReplyTargetNotFound = errors.New("realtime: reply target not found")
+	// ErrMessageEncrypted is returned when editing an end-to-end encrypted message.
+	ErrMessageEncrypted = errors.New("realtime: message is end-to-end encrypted")
 )
 
 // StoredMessage is the canonical persisted message representation.
@@ -12,8 +28,38 @@ type StoredMessage struct {
 	ServerMsgID    string
 	Seq            int64
 	SenderSession  string
-	Text           string
-	ServerTS       time.Time
+	// SenderBotID is set instead of SenderSession for messages posted by a bot.
+	SenderBotID string
+	Text        string
+	ServerTS    time.Time
+	// Version starts at 1 and increments on every edit or delete.
+	Version   int64
+	EditedAt  *time.Time
+	DeletedAt *time.Time
+	// ReplyToServerMsgID references the replied-to message in the same conversation ("" if none).
+	ReplyToServerMsgID string
+	// AttachmentIDs are verified against the sender before the message is stored.
+	AttachmentIDs []string
+	// Entities are the mentions and links extracted from Text.
+	Entities []MessageEntity
+	// ContentType is v1.ContentTypeE2EE for end-to-end encrypted messages, which
+	// carry Ciphertext and KeyIDs and have empty Text; text messages leave it "" or
+	// v1.ContentTypeText.
+	ContentType string
+	Ciphertext  []byte
+	KeyIDs      []string
+}
+
+// Encrypted reports whether the message is end-to-end encrypted. The server never
+// inspects its content: filters, entities, the abuse guard and bots skip it.
+func (m StoredMessage) Encrypted() bool {
+	return m.ContentType == v1.ContentTypeE2EE
+}
+
+// Deleted reports whether the message is a tombstone. Tombstones keep their seq
+// but have empty Text.
+func (m StoredMessage) Deleted() bool {
+	return m.DeletedAt != nil
 }
 
 // MessageStore persists and queries messages.
@@ -22,19 +68,102 @@ type StoredMessage struct {
 //   - Idempotency per (conversation_id, client_msg_id)
 //   - Monotonic seq per conversation (no gaps for duplicates)
 //   - History query ordered by seq ASC
+//   - Edits and deletes keep seq; deletes leave a tombstone in history
 type MessageStore interface {
 	AppendMessage(ctx context.Context, in AppendMessageInput) (AppendMessageResult, error)
+	// AppendMessages appends up to MaxAppendBatch messages of one conversation
+	// atomically, allocating consecutive seqs. Results follow the input order; inputs
+	// whose client_msg_id is already stored, or repeats one earlier in the batch, are
+	// Duplicated. If any input is invalid, nothing is stored.
+	AppendMessages(ctx context.Context, in []AppendMessageInput) ([]AppendMessageResult, error)
 	FetchHistory(ctx context.Context, in FetchHistoryInput) (FetchHistoryResult, error)
+	EditMessage(ctx context.Context, in EditMessageInput) (MessageMutationResult, error)
+	DeleteMessage(ctx context.Context, in DeleteMessageInput) (MessageMutationResult, error)
 	Close() error
 }
 
+// MaxAppendBatch bounds the inputs of one AppendMessages call.
+const MaxAppendBatch = 100
+
+// ErrAppendBatch is returned by AppendMessages for an empty, oversized or mixed-conversation batch.
+var ErrAppendBatch = errors.New("realtime: invalid append batch")
+
+// AuthoredMessageLister lists the messages a user sent from any of their sessions.
+//
+// It backs privacy exports; implementations stream rows instead of buffering them.
+type AuthoredMessageLister interface {
+	ListMessagesByAuthor(ctx context.Context, userID string, fn func(StoredMessage) error) error
+}
+
+// ScrubbedMessageText replaces the text of messages whose author has been erased.
+const ScrubbedMessageText = "[deleted]"
+
+// MessageAuthorScrubber erases a user's authorship from stored messages.
+//
+// Scrubbed messages keep their place (conversation, seq) but lose their sender
+// session and text, so deleting the user no longer conflicts with message rows.
+type MessageAuthorScrubber interface {
+	ScrubMessagesByAuthor(ctx context.Context, userID string, limit int) (int64, error)
+}
+
+// ConversationStats summarizes a conversation's messages.
+type ConversationStats struct {
+	ConversationID string
+	// LastSeq is the seq of the latest message.
+	LastSeq int64
+	// MessageCount is the number of stored messages, tombstones included.
+	MessageCount  int64
+	LastMessageAt time.Time
+}
+
+// ConversationStatsReader reads per-conversation stats without scanning messages.
+//
+// Stats are maintained in the same transaction as appends, so unread counts
+// (LastSeq minus a read cursor) and conversation lists cost one row per conversation.
+type ConversationStatsReader interface {
+	// ConversationStats returns the stats of those conversationIDs that have messages.
+	ConversationStats(ctx context.Context, conversationIDs []string) (map[string]ConversationStats, error)
+}
+
 // AppendMessageInput describes a message append request.
 type AppendMessageInput struct {
 	ConversationID string
 	ClientMsgID    string
-	SenderSession  string
-	Text           string
-	Now            time.Time
+	// Exactly one of SenderSession and SenderBotID identifies the author.
+	SenderSession string
+	SenderBotID   string
+	Text          string
+	Now           time.Time
+	// ReplyToServerMsgID is optional; it must reference a message of ConversationID.
+	ReplyToServerMsgID string
+	AttachmentIDs      []string
+	Entities           []MessageEntity
+	// ContentType, Ciphertext and KeyIDs describe end-to-end encrypted messages
+	// (see StoredMessage); Text is empty then.
+	ContentType string
+	Ciphertext  []byte
+	KeyIDs      []string
+}
+
+func (in AppendMessageInput) valid() bool {
+	return in.ConversationID != "" && in.ClientMsgID != "" && (in.SenderSession == "") != (in.SenderBotID == "")
+}
+
+// checkAppendBatch validates a batch and returns its conversation id.
+func checkAppendBatch(in []AppendMessageInput) (string, error) {
+	if len(in) == 0 || len(in) > MaxAppendBatch {
+		return "", ErrAppendBatch
+	}
+	convID := in[0].ConversationID
+	for _, m := range in {
+		if !m.valid() {
+			return "", errors.New("invalid input")
+		}
+		if m.ConversationID != convID {
+			return "", ErrAppendBatch
+		}
+	}
+	return convID, nil
 }
 
 // AppendMessageResult is the append operation result.
@@ -43,10 +172,50 @@ type AppendMessageResult struct {
 	Duplicated bool
 }
 
+// MessageActor identifies who edits or deletes a message. The actor is the author
+// when the message was sent from ActorSession, or from any session of ActorUserID.
+type MessageActor struct {
+	ActorSession string
+	ActorUserID  string
+}
+
+// EditMessageInput describes a message edit request.
+type EditMessageInput struct {
+	MessageActor
+	ConversationID string
+	ServerMsgID    string
+	Text           string
+	// Entities replace the message's entities along with its text.
+	Entities []MessageEntity
+	Now      time.Time
+}
+
+// DeleteMessageInput describes a message delete request.
+type DeleteMessageInput struct {
+	MessageActor
+	ConversationID string
+	ServerMsgID    string
+	Now            time.Time
+	// Moderator skips the authorship check. Callers authorize the actor as a moderator.
+	Moderator bool
+}
+
+// MessageMutationResult is the edit/delete result. Changed is false for no-op
+// retries (same text, or an already deleted message).
+type MessageMutationResult struct {
+	Stored  StoredMessage
+	Changed bool
+}
+
 // FetchHistoryInput describes a history query request.
+//
+// With only AfterSeq set (or neither), messages are read forward from the oldest match.
+// With BeforeSeq set, the newest Limit messages below BeforeSeq (and above AfterSeq, if
+// set) are returned; HasMore then reports older messages. Results are always ascending.
 type FetchHistoryInput struct {
 	ConversationID string
 	AfterSeq       *int64
+	BeforeSeq      *int64
 	Limit          int
 }
 
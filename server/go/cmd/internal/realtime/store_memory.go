@@ -3,6 +3,7 @@ package realtime
 import (
 	"context"
 	"errors"
+	"slices"
 	"sort"
 	"sync"
 	"time"
@@ -14,8 +15,10 @@ const (
 
 // InMemoryStore is a dev-only fallback when DB is not configured.
 // It supports:
-//   - AppendMessage: idempotent + seq allocation
+//   - AppendMessage/AppendMessages: idempotent + seq allocation
 //   - FetchHistory: paging by after_seq (for CI/smoke determinism)
+//   - EditMessage/DeleteMessage: authorship is checked by session only
+//     (there is no session -> user mapping in memory)
 type InMemoryStore struct {
 	mu    sync.Mutex
 	convs map[string]*memConv
@@ -39,7 +42,7 @@ func (s *InMemoryStore) Close() error { return nil }
 
 // AppendMessage persists a message with idempotency and monotonic sequence allocation.
 func (s *InMemoryStore) AppendMessage(ctx context.Context, in AppendMessageInput) (AppendMessageResult, error) {
-	if in.ConversationID == "" || in.ClientMsgID == "" || in.SenderSession == "" {
+	if !in.valid() {
 		return AppendMessageResult{}, errors.New("invalid input")
 	}
 	if err := ctx.Err(); err != nil {
@@ -54,19 +57,69 @@ func (s *InMemoryStore) AppendMessage(ctx context.Context, in AppendMessageInput
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	c := s.convs[in.ConversationID]
+	c := s.conv(in.ConversationID)
+	if existing, ok := c.dedupe[in.ClientMsgID]; ok {
+		return AppendMessageResult{Stored: existing, Duplicated: true}, nil
+	}
+
+	if in.ReplyToServerMsgID != "" && !c.hasServerMsgID(in.ReplyToServerMsgID) {
+		return AppendMessageResult{}, ErrReplyTargetNotFound
+	}
+
+	return AppendMessageResult{Stored: c.append(in, now), Duplicated: false}, nil
+}
+
+// AppendMessages appends a batch of one conversation under a single lock.
+func (s *InMemoryStore) AppendMessages(ctx context.Context, in []AppendMessageInput) ([]AppendMessageResult, error) {
+	convID, err := checkAppendBatch(in)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := s.conv(convID)
+	for _, m := range in {
+		if m.ReplyToServerMsgID != "" && !c.hasServerMsgID(m.ReplyToServerMsgID) {
+			return nil, ErrReplyTargetNotFound
+		}
+	}
+
+	out := make([]AppendMessageResult, len(in))
+	for i, m := range in {
+		if existing, ok := c.dedupe[m.ClientMsgID]; ok {
+			out[i] = AppendMessageResult{Stored: existing, Duplicated: true}
+			continue
+		}
+		ts := m.Now
+		if ts.IsZero() {
+			ts = now
+		}
+		out[i] = AppendMessageResult{Stored: c.append(m, ts)}
+	}
+	return out, nil
+}
+
+// conv returns the conversation state, creating it on first use. Callers hold s.mu.
+func (s *InMemoryStore) conv(conversationID string) *memConv {
+	c := s.convs[conversationID]
 	if c == nil {
 		c = &memConv{
 			dedupe: make(map[string]StoredMessage),
 			msgs:   make([]StoredMessage, 0, 256),
 		}
-		s.convs[in.ConversationID] = c
-	}
-
-	if existing, ok := c.dedupe[in.ClientMsgID]; ok {
-		return AppendMessageResult{Stored: existing, Duplicated: true}, nil
+		s.convs[conversationID] = c
 	}
+	return c
+}
 
+// append stores in with the next seq.
+func (c *memConv) append(in AppendMessageInput, now time.Time) StoredMessage {
 	c.seq++
 	msg := StoredMessage{
 		ConversationID: in.ConversationID,
@@ -74,8 +127,18 @@ func (s *InMemoryStore) AppendMessage(ctx context.Context, in AppendMessageInput
 		ServerMsgID:    NewRandomHex(16),
 		Seq:            c.seq,
 		SenderSession:  in.SenderSession,
+		SenderBotID:    in.SenderBotID,
 		Text:           in.Text,
 		ServerTS:       now,
+		Version:        1,
+
+		ReplyToServerMsgID: in.ReplyToServerMsgID,
+		AttachmentIDs:      slices.Clone(in.AttachmentIDs),
+		Entities:           slices.Clone(in.Entities),
+
+		ContentType: in.ContentType,
+		Ciphertext:  slices.Clone(in.Ciphertext),
+		KeyIDs:      slices.Clone(in.KeyIDs),
 	}
 	c.dedupe[in.ClientMsgID] = msg
 	c.msgs = append(c.msgs, msg)
@@ -84,14 +147,13 @@ func (s *InMemoryStore) AppendMessage(ctx context.Context, in AppendMessageInput
 	if len(c.msgs) > memMaxMessagesPerConversation {
 		c.msgs = c.msgs[len(c.msgs)-memMaxMessagesPerConversation:]
 	}
-
-	return AppendMessageResult{Stored: msg, Duplicated: false}, nil
+	return msg
 }
 
 // FetchHistory returns messages ordered by seq ASC with paging via after_seq.
 func (s *InMemoryStore) FetchHistory(ctx context.Context, in FetchHistoryInput) (FetchHistoryResult, error) {
 	if in.ConversationID == "" {
-		return FetchHistoryResult{}, errors.New("missing conversation_id")
+		return FetchHistoryResult{}, errMissingConversationID
 	}
 	if err := ctx.Err(); err != nil {
 		return FetchHistoryResult{}, err
@@ -104,7 +166,6 @@ func (s *InMemoryStore) FetchHistory(ctx context.Context, in FetchHistoryInput)
 	if limit > 200 {
 		limit = 200
 	}
-	fetch := limit + 1
 
 	s.mu.Lock()
 	c := s.convs[in.ConversationID]
@@ -121,25 +182,143 @@ func (s *InMemoryStore) FetchHistory(ctx context.Context, in FetchHistoryInput)
 	// Ensure ordering defensively.
 	sort.Slice(snap, func(i, j int) bool { return snap[i].Seq < snap[j].Seq })
 
-	start := 0
+	// [lo, hi) is the window allowed by AfterSeq/BeforeSeq.
+	lo, hi := 0, len(snap)
 	if in.AfterSeq != nil {
 		after := *in.AfterSeq
-		start = sort.Search(len(snap), func(i int) bool { return snap[i].Seq > after })
-		if start >= len(snap) {
-			return FetchHistoryResult{Messages: nil, HasMore: false}, nil
+		lo = sort.Search(len(snap), func(i int) bool { return snap[i].Seq > after })
+	}
+	if in.BeforeSeq != nil {
+		before := *in.BeforeSeq
+		hi = sort.Search(len(snap), func(i int) bool { return snap[i].Seq >= before })
+	}
+	if lo >= hi {
+		return FetchHistoryResult{Messages: nil, HasMore: false}, nil
+	}
+
+	hasMore := hi-lo > limit
+	if !hasMore {
+		return FetchHistoryResult{Messages: snap[lo:hi], HasMore: false}, nil
+	}
+	if in.BeforeSeq != nil {
+		return FetchHistoryResult{Messages: snap[hi-limit : hi], HasMore: true}, nil
+	}
+	return FetchHistoryResult{Messages: snap[lo : lo+limit], HasMore: true}, nil
+}
+
+// ConversationStats derives stats from the stored messages.
+func (s *InMemoryStore) ConversationStats(ctx context.Context, conversationIDs []string) (map[string]ConversationStats, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make(map[string]ConversationStats, len(conversationIDs))
+	for _, id := range conversationIDs {
+		c := s.convs[id]
+		if c == nil || len(c.msgs) == 0 {
+			continue
+		}
+		st := ConversationStats{ConversationID: id, MessageCount: int64(len(c.msgs))}
+		for _, m := range c.msgs {
+			st.LastSeq = max(st.LastSeq, m.Seq)
+			if m.ServerTS.After(st.LastMessageAt) {
+				st.LastMessageAt = m.ServerTS
+			}
 		}
+		out[id] = st
 	}
+	return out, nil
+}
 
-	end := start + fetch
-	if end > len(snap) {
-		end = len(snap)
+// EditMessage replaces the text of a message sent from in.ActorSession.
+func (s *InMemoryStore) EditMessage(ctx context.Context, in EditMessageInput) (MessageMutationResult, error) {
+	if in.ConversationID == "" || in.ServerMsgID == "" || in.Text == "" {
+		return MessageMutationResult{}, errors.New("invalid input")
+	}
+	now := in.Now
+	if now.IsZero() {
+		now = time.Now().UTC()
 	}
-	out := snap[start:end]
+	return s.mutate(ctx, in.ConversationID, in.ServerMsgID, in.MessageActor, false, func(m *StoredMessage) (bool, error) {
+		if m.Deleted() {
+			return false, ErrMessageDeleted
+		}
+		if m.Encrypted() {
+			return false, ErrMessageEncrypted
+		}
+		if m.Text == in.Text {
+			return false, nil
+		}
+		m.Text = in.Text
+		m.Entities = slices.Clone(in.Entities)
+		m.Version++
+		m.EditedAt = &now
+		return true, nil
+	})
+}
 
-	hasMore := len(out) > limit
-	if hasMore {
-		out = out[:limit]
+// DeleteMessage tombstones a message sent from in.ActorSession, or any message for moderators.
+func (s *InMemoryStore) DeleteMessage(ctx context.Context, in DeleteMessageInput) (MessageMutationResult, error) {
+	if in.ConversationID == "" || in.ServerMsgID == "" {
+		return MessageMutationResult{}, errors.New("invalid input")
+	}
+	now := in.Now
+	if now.IsZero() {
+		now = time.Now().UTC()
+	}
+	return s.mutate(ctx, in.ConversationID, in.ServerMsgID, in.MessageActor, in.Moderator, func(m *StoredMessage) (bool, error) {
+		if m.Deleted() {
+			return false, nil
+		}
+		m.Text = ""
+		m.AttachmentIDs = nil
+		m.Entities = nil
+		m.Ciphertext = nil
+		m.KeyIDs = nil
+		m.Version++
+		m.DeletedAt = &now
+		return true, nil
+	})
+}
+
+func (s *InMemoryStore) mutate(ctx context.Context, conversationID, serverMsgID string, actor MessageActor, moderator bool, fn func(*StoredMessage) (bool, error)) (MessageMutationResult, error) {
+	if err := ctx.Err(); err != nil {
+		return MessageMutationResult{}, err
 	}
 
-	return FetchHistoryResult{Messages: out, HasMore: hasMore}, nil
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := s.convs[conversationID]
+	if c == nil {
+		return MessageMutationResult{}, ErrMessageNotFound
+	}
+	for i := range c.msgs {
+		m := &c.msgs[i]
+		if m.ServerMsgID != serverMsgID {
+			continue
+		}
+		if !moderator && (actor.ActorSession == "" || m.SenderSession != actor.ActorSession) {
+			return MessageMutationResult{}, ErrMessageNotAuthor
+		}
+		changed, err := fn(m)
+		if err != nil {
+			return MessageMutationResult{}, err
+		}
+		c.dedupe[m.ClientMsgID] = *m
+		return MessageMutationResult{Stored: *m, Changed: changed}, nil
+	}
+	return MessageMutationResult{}, ErrMessageNotFound
+}
+
+func (c *memConv) hasServerMsgID(serverMsgID string) bool {
+	for _, m := range c.msgs {
+		if m.ServerMsgID == serverMsgID {
+			return true
+		}
+	}
+	return false
 }
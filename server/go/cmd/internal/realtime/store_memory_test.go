@@ -0,0 +1,250 @@
+package realtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestInMemoryStore_EditAndDelete(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	st := NewInMemoryStore()
+
+	var ids []string
+	for _, text := range []string{"a", "b"} {
+		res, err := st.AppendMessage(ctx, AppendMessageInput{
+			ConversationID: "c1",
+			ClientMsgID:    "cm-" + text,
+			SenderSession:  "s1",
+			Text:           text,
+			Now:            time.Now().UTC(),
+		})
+		if err != nil {
+			t.Fatalf("append: %v", err)
+		}
+		ids = append(ids, res.Stored.ServerMsgID)
+	}
+
+	if _, err := st.EditMessage(ctx, EditMessageInput{
+		MessageActor:   MessageActor{ActorSession: "s2"},
+		ConversationID: "c1",
+		ServerMsgID:    ids[0],
+		Text:           "x",
+	}); !errors.Is(err, ErrMessageNotAuthor) {
+		t.Fatalf("expected ErrMessageNotAuthor, got %v", err)
+	}
+
+	res, err := st.EditMessage(ctx, EditMessageInput{
+		MessageActor:   MessageActor{ActorSession: "s1"},
+		ConversationID: "c1",
+		ServerMsgID:    ids[0],
+		Text:           "a2",
+	})
+	if err != nil || !res.Changed || res.Stored.Version != 2 || res.Stored.EditedAt == nil {
+		t.Fatalf("unexpected edit: %+v, %v", res, err)
+	}
+
+	res, err = st.DeleteMessage(ctx, DeleteMessageInput{
+		MessageActor:   MessageActor{ActorSession: "s1"},
+		ConversationID: "c1",
+		ServerMsgID:    ids[0],
+	})
+	if err != nil || !res.Changed || !res.Stored.Deleted() || res.Stored.Text != "" {
+		t.Fatalf("unexpected delete: %+v, %v", res, err)
+	}
+
+	hist, err := st.FetchHistory(ctx, FetchHistoryInput{ConversationID: "c1"})
+	if err != nil {
+		t.Fatalf("history: %v", err)
+	}
+	if len(hist.Messages) != 2 || !hist.Messages[0].Deleted() || hist.Messages[0].Seq != 1 {
+		t.Fatalf("expected tombstone at seq 1 in history, got %+v", hist.Messages)
+	}
+
+	// A retried append of the deleted message must not resurrect its text.
+	dup, err := st.AppendMessage(ctx, AppendMessageInput{ConversationID: "c1", ClientMsgID: "cm-a", SenderSession: "s1", Text: "a"})
+	if err != nil || !dup.Duplicated || !dup.Stored.Deleted() {
+		t.Fatalf("expected duplicate to return tombstone, got %+v, %v", dup, err)
+	}
+}
+
+func TestInMemoryStore_ReplyTo(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	st := NewInMemoryStore()
+
+	parent, err := st.AppendMessage(ctx, AppendMessageInput{ConversationID: "c1", ClientMsgID: "cm-1", SenderSession: "s1", Text: "a"})
+	if err != nil {
+		t.Fatalf("append parent: %v", err)
+	}
+
+	if _, err := st.AppendMessage(ctx, AppendMessageInput{
+		ConversationID:     "c2",
+		ClientMsgID:        "cm-2",
+		SenderSession:      "s1",
+		Text:               "b",
+		ReplyToServerMsgID: parent.Stored.ServerMsgID,
+	}); !errors.Is(err, ErrReplyTargetNotFound) {
+		t.Fatalf("expected ErrReplyTargetNotFound across conversations, got %v", err)
+	}
+
+	reply, err := st.AppendMessage(ctx, AppendMessageInput{
+		ConversationID:     "c1",
+		ClientMsgID:        "cm-3",
+		SenderSession:      "s2",
+		Text:               "c",
+		ReplyToServerMsgID: parent.Stored.ServerMsgID,
+	})
+	if err != nil || reply.Stored.ReplyToServerMsgID != parent.Stored.ServerMsgID {
+		t.Fatalf("unexpected reply: %+v, %v", reply, err)
+	}
+
+	hist, err := st.FetchHistory(ctx, FetchHistoryInput{ConversationID: "c1"})
+	if err != nil {
+		t.Fatalf("history: %v", err)
+	}
+	if len(hist.Messages) != 2 || hist.Messages[1].ReplyToServerMsgID != parent.Stored.ServerMsgID {
+		t.Fatalf("expected reply metadata in history, got %+v", hist.Messages)
+	}
+}
+
+func TestInMemoryStore_FetchHistory_Directions(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	st := NewInMemoryStore()
+	for i := 1; i <= 5; i++ {
+		if _, err := st.AppendMessage(ctx, AppendMessageInput{
+			ConversationID: "c1",
+			ClientMsgID:    fmt.Sprintf("cm-%d", i),
+			SenderSession:  "s1",
+			Text:           "m",
+		}); err != nil {
+			t.Fatalf("append %d: %v", i, err)
+		}
+	}
+
+	seq := func(v int64) *int64 { return &v }
+	cases := []struct {
+		name    string
+		after   *int64
+		before  *int64
+		limit   int
+		want    []int64
+		hasMore bool
+	}{
+		{"forward from start", nil, nil, 2, []int64{1, 2}, true},
+		{"forward after", seq(3), nil, 2, []int64{4, 5}, false},
+		{"forward past end", seq(5), nil, 2, nil, false},
+		{"backward latest", nil, seq(6), 2, []int64{4, 5}, true},
+		{"backward page", nil, seq(4), 2, []int64{2, 3}, true},
+		{"backward to start", nil, seq(3), 2, []int64{1, 2}, false},
+		{"backward before first", nil, seq(1), 2, nil, false},
+		{"bounded window backward", seq(1), seq(5), 2, []int64{3, 4}, true},
+		{"bounded window fits", seq(1), seq(5), 3, []int64{2, 3, 4}, false},
+		{"empty window", seq(3), seq(4), 2, nil, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := st.FetchHistory(ctx, FetchHistoryInput{
+				ConversationID: "c1",
+				AfterSeq:       tc.after,
+				BeforeSeq:      tc.before,
+				Limit:          tc.limit,
+			})
+			if err != nil {
+				t.Fatalf("fetch: %v", err)
+			}
+			if len(out.Messages) != len(tc.want) || out.HasMore != tc.hasMore {
+				t.Fatalf("expected %v has_more=%v, got %d msgs has_more=%v", tc.want, tc.hasMore, len(out.Messages), out.HasMore)
+			}
+			for i, m := range out.Messages {
+				if m.Seq != tc.want[i] {
+					t.Fatalf("expected seqs %v, got seq %d at %d", tc.want, m.Seq, i)
+				}
+			}
+		})
+	}
+}
+
+func TestInMemoryStore_AppendMessages(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	st := NewInMemoryStore()
+
+	first, err := st.AppendMessage(ctx, AppendMessageInput{ConversationID: "c1", ClientMsgID: "k0", SenderSession: "s1", Text: "before"})
+	if err != nil {
+		t.Fatalf("append: %v", err)
+	}
+
+	res, err := st.AppendMessages(ctx, []AppendMessageInput{
+		{ConversationID: "c1", ClientMsgID: "k1", SenderBotID: "b1", Text: "one", ReplyToServerMsgID: first.Stored.ServerMsgID},
+		{ConversationID: "c1", ClientMsgID: "k0", SenderBotID: "b1", Text: "already stored"},
+		{ConversationID: "c1", ClientMsgID: "k2", SenderBotID: "b1", Text: "two"},
+		{ConversationID: "c1", ClientMsgID: "k1", SenderBotID: "b1", Text: "repeat"},
+	})
+	if err != nil {
+		t.Fatalf("append batch: %v", err)
+	}
+	if res[0].Stored.Seq != 2 || res[2].Stored.Seq != 3 || res[0].Duplicated || res[2].Duplicated {
+		t.Fatalf("unexpected results %+v", res)
+	}
+	if !res[1].Duplicated || res[1].Stored.ServerMsgID != first.Stored.ServerMsgID {
+		t.Fatalf("expected stored duplicate, got %+v", res[1])
+	}
+	if !res[3].Duplicated || res[3].Stored.ServerMsgID != res[0].Stored.ServerMsgID {
+		t.Fatalf("expected in-batch duplicate, got %+v", res[3])
+	}
+
+	_, err = st.AppendMessages(ctx, []AppendMessageInput{
+		{ConversationID: "c1", ClientMsgID: "k3", SenderBotID: "b1", Text: "three"},
+		{ConversationID: "c1", ClientMsgID: "k4", SenderBotID: "b1", Text: "four", ReplyToServerMsgID: "missing"},
+	})
+	if !errors.Is(err, ErrReplyTargetNotFound) {
+		t.Fatalf("expected ErrReplyTargetNotFound, got %v", err)
+	}
+	hist, err := st.FetchHistory(ctx, FetchHistoryInput{ConversationID: "c1"})
+	if err != nil || len(hist.Messages) != 3 {
+		t.Fatalf("expected rejected batch to store nothing, got %d messages err=%v", len(hist.Messages), err)
+	}
+
+	for _, batch := range [][]AppendMessageInput{
+		nil,
+		{{ConversationID: "c1", ClientMsgID: "k5", SenderBotID: "b1", Text: "x"}, {ConversationID: "c2", ClientMsgID: "k6", SenderBotID: "b1", Text: "y"}},
+		make([]AppendMessageInput, MaxAppendBatch+1),
+	} {
+		if _, err := st.AppendMessages(ctx, batch); err == nil {
+			t.Fatalf("expected error for batch of %d", len(batch))
+		}
+	}
+}
+
+func TestInMemoryStore_ConversationStats(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	s := NewInMemoryStore()
+	for _, id := range []string{"a", "b"} {
+		if _, err := s.AppendMessage(ctx, AppendMessageInput{ConversationID: "c1", ClientMsgID: id, SenderSession: "s1", Text: id}); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+	}
+	last, err := s.AppendMessage(ctx, AppendMessageInput{ConversationID: "c1", ClientMsgID: "a", SenderSession: "s1", Text: "a"})
+	if err != nil || !last.Duplicated {
+		t.Fatalf("expected duplicate, got %+v err=%v", last, err)
+	}
+
+	stats, err := s.ConversationStats(ctx, []string{"c1", "c-empty"})
+	if err != nil {
+		t.Fatalf("stats: %v", err)
+	}
+	if st, ok := stats["c1"]; len(stats) != 1 || !ok || st.LastSeq != 2 || st.MessageCount != 2 || st.LastMessageAt.IsZero() {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
@@ -6,9 +6,15 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
+	"arc/cmd/internal/dbroute"
+	"arc/cmd/internal/dbtrace"
+	"arc/cmd/internal/pgutil"
+	v1 "arc/shared/contracts/realtime/v1"
+
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -23,9 +29,18 @@ import (
 // - Uses per-conversation transactional advisory locks to guarantee:
 //   - No sequence gaps caused by duplicates
 //   - Strict monotonic ordering under concurrency
+//
+// Partitioning:
+//   - messages is hash-partitioned by conversation_id (see cmd/internal/migrations/sql).
+//     Queries filter on conversation_id so they prune to one partition; server_msg_id
+//     is only unique within a conversation and is always looked up together with it.
+//   - Author lookups (ListMessagesByAuthor, ScrubMessagesByAuthor) span all partitions
+//     through the sender_session index.
 type PostgresStore struct {
-	pool   *pgxpool.Pool
-	schema string
+	pool *pgxpool.Pool
+	// readPool serves replica-safe reads (nil uses pool); see WithReadPool.
+	readPool *pgxpool.Pool
+	schema   string
 }
 
 // PostgresOption configures PostgresStore behavior.
@@ -47,6 +62,17 @@ func WithSchema(schema string) PostgresOption {
 	}
 }
 
+// WithReadPool routes FetchHistory, ConversationStats and ListMessagesByAuthor to a
+// read replica unless the context requires the primary (dbroute.RequirePrimary).
+// Resume and redelivery replays require it, since a lagging replica would look
+// like a complete, gap-free history. A nil pool is ignored.
+func WithReadPool(pool *pgxpool.Pool) PostgresOption {
+	return func(s *PostgresStore) error {
+		s.readPool = pool
+		return nil
+	}
+}
+
 // NewPostgresStore constructs a Postgres-backed MessageStore.
 func NewPostgresStore(pool *pgxpool.Pool, opts ...PostgresOption) (*PostgresStore, error) {
 	st := &PostgresStore{
@@ -71,11 +97,13 @@ func NewPostgresStore(pool *pgxpool.Pool, opts ...PostgresOption) (*PostgresStor
 func (s *PostgresStore) Close() error { return nil }
 
 // AppendMessage appends a message with idempotency and monotonic sequence allocation.
+// The transaction is retried on serialization failures and deadlocks (pgutil.WithTx).
 func (s *PostgresStore) AppendMessage(ctx context.Context, in AppendMessageInput) (AppendMessageResult, error) {
+	ctx = dbtrace.WithOp(ctx, "realtime.append_message")
 	if s == nil || s.pool == nil {
 		return AppendMessageResult{}, errors.New("realtime: nil store")
 	}
-	if in.ConversationID == "" || in.ClientMsgID == "" || in.SenderSession == "" {
+	if !in.valid() {
 		return AppendMessageResult{}, errors.New("invalid input")
 	}
 	if err := ctx.Err(); err != nil {
@@ -87,12 +115,137 @@ func (s *PostgresStore) AppendMessage(ctx context.Context, in AppendMessageInput
 		now = time.Now().UTC()
 	}
 
+	conversations := pgIdent(s.schema, "conversations")
+	cursors := pgIdent(s.schema, "conversation_cursors")
+	messages := pgIdent(s.schema, "messages")
+
+	var res AppendMessageResult
+	err := pgutil.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		// Serialize all writes per conversation to guarantee:
+		// - No seq waste for duplicates
+		// - Strict monotonic ordering without races
+		//
+		// hashtextextended reduces collision risk vs hashtext (still a hash, but better).
+		if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtextextended($1, 0))`, in.ConversationID); err != nil {
+			return fmt.Errorf("advisory lock: %w", err)
+		}
+
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO `+conversations+` (id, kind, visibility) VALUES ($1, 'direct', 'private')
+			 ON CONFLICT (id) DO NOTHING`,
+			in.ConversationID,
+		); err != nil {
+			return err
+		}
+
+		existing, err := readMessageByClientMsgID(ctx, tx, messages, in.ConversationID, in.ClientMsgID)
+		if err == nil {
+			res = AppendMessageResult{Stored: existing, Duplicated: true}
+			return nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+
+		if in.ReplyToServerMsgID != "" {
+			var one int
+			err := tx.QueryRow(ctx,
+				`SELECT 1 FROM `+messages+` WHERE conversation_id = $1 AND server_msg_id = $2`,
+				in.ConversationID, in.ReplyToServerMsgID,
+			).Scan(&one)
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrReplyTargetNotFound
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		// Cursor row ensures monotonic seq allocation.
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO `+cursors+` (conversation_id, next_seq)
+			 VALUES ($1, 1)
+			 ON CONFLICT (conversation_id) DO NOTHING`,
+			in.ConversationID,
+		); err != nil {
+			return err
+		}
+
+		var seq int64
+		if err := tx.QueryRow(ctx,
+			`UPDATE `+cursors+`
+			    SET next_seq = next_seq + 1,
+			        updated_at = now()
+			  WHERE conversation_id = $1
+			RETURNING (next_seq - 1)`,
+			in.ConversationID,
+		).Scan(&seq); err != nil {
+			return err
+		}
+
+		serverMsgID := NewRandomHex(16)
+
+		if _, err := tx.Exec(ctx, insertMessageSQL(messages),
+			in.ConversationID, seq, serverMsgID, in.ClientMsgID, in.SenderSession, in.SenderBotID, in.Text, now,
+			in.ReplyToServerMsgID, in.AttachmentIDs, entitiesJSON(in.Entities), in.ContentType, in.Ciphertext, in.KeyIDs,
+		); err != nil {
+			return fmt.Errorf("insert message: %w", err)
+		}
+		if err := bumpConversationStats(ctx, tx, pgIdent(s.schema, "conversation_stats"), in.ConversationID, seq, 1, now); err != nil {
+			return err
+		}
+
+		res = AppendMessageResult{Stored: StoredMessage{
+			ConversationID: in.ConversationID,
+			ClientMsgID:    in.ClientMsgID,
+			ServerMsgID:    serverMsgID,
+			Seq:            seq,
+			SenderSession:  in.SenderSession,
+			SenderBotID:    in.SenderBotID,
+			Text:           in.Text,
+			ServerTS:       now,
+			Version:        1,
+
+			ReplyToServerMsgID: in.ReplyToServerMsgID,
+			AttachmentIDs:      in.AttachmentIDs,
+			Entities:           in.Entities,
+
+			ContentType: contentTypeOrText(in.ContentType),
+			Ciphertext:  in.Ciphertext,
+			KeyIDs:      in.KeyIDs,
+		}}
+		return nil
+	})
+	if err != nil {
+		return AppendMessageResult{}, err
+	}
+	return res, nil
+}
+
+// AppendMessages appends a batch of one conversation in one transaction under the
+// conversation's advisory lock. Existing client_msg_ids and reply targets are read with
+// one query each, seqs are reserved with a single cursor update, and the rows are
+// inserted in one round trip.
+func (s *PostgresStore) AppendMessages(ctx context.Context, in []AppendMessageInput) ([]AppendMessageResult, error) {
+	ctx = dbtrace.WithOp(ctx, "realtime.append_messages")
+	if s == nil || s.pool == nil {
+		return nil, errors.New("realtime: nil store")
+	}
+	convID, err := checkAppendBatch(in)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+
 	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{
 		IsoLevel:   pgx.ReadCommitted,
 		AccessMode: pgx.ReadWrite,
 	})
 	if err != nil {
-		return AppendMessageResult{}, err
+		return nil, err
 	}
 	defer func() { _ = tx.Rollback(ctx) }()
 
@@ -100,90 +253,252 @@ func (s *PostgresStore) AppendMessage(ctx context.Context, in AppendMessageInput
 	cursors := pgIdent(s.schema, "conversation_cursors")
 	messages := pgIdent(s.schema, "messages")
 
-	// Serialize all writes per conversation to guarantee:
-	// - No seq waste for duplicates
-	// - Strict monotonic ordering without races
-	//
-	// hashtextextended reduces collision risk vs hashtext (still a hash, but better).
-	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtextextended($1, 0))`, in.ConversationID); err != nil {
-		return AppendMessageResult{}, fmt.Errorf("advisory lock: %w", err)
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtextextended($1, 0))`, convID); err != nil {
+		return nil, fmt.Errorf("advisory lock: %w", err)
 	}
-
 	if _, err := tx.Exec(ctx,
 		`INSERT INTO `+conversations+` (id, kind, visibility) VALUES ($1, 'direct', 'private')
 		 ON CONFLICT (id) DO NOTHING`,
-		in.ConversationID,
+		convID,
 	); err != nil {
-		return AppendMessageResult{}, err
+		return nil, err
 	}
 
-	existing, err := readMessageByClientMsgID(ctx, tx, messages, in.ConversationID, in.ClientMsgID)
-	if err == nil {
-		if err := tx.Commit(ctx); err != nil {
-			return AppendMessageResult{}, err
+	clientIDs := make([]string, 0, len(in))
+	var replyIDs []string
+	for _, m := range in {
+		clientIDs = append(clientIDs, m.ClientMsgID)
+		if m.ReplyToServerMsgID != "" {
+			replyIDs = append(replyIDs, m.ReplyToServerMsgID)
 		}
-		return AppendMessageResult{Stored: existing, Duplicated: true}, nil
 	}
-	if !errors.Is(err, pgx.ErrNoRows) {
-		return AppendMessageResult{}, err
+
+	existing := make(map[string]StoredMessage)
+	rows, err := tx.Query(ctx,
+		`SELECT `+storedMessageColumns+`
+		   FROM `+messages+`
+		  WHERE conversation_id = $1 AND client_msg_id = ANY($2::text[])`,
+		convID, clientIDs,
+	)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		m, err := scanStoredMessage(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		existing[m.ClientMsgID] = m
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
-	// Cursor row ensures monotonic seq allocation.
-	if _, err := tx.Exec(ctx,
-		`INSERT INTO `+cursors+` (conversation_id, next_seq)
-		 VALUES ($1, 1)
-		 ON CONFLICT (conversation_id) DO NOTHING`,
-		in.ConversationID,
-	); err != nil {
-		return AppendMessageResult{}, err
+	if len(replyIDs) > 0 {
+		var found int
+		if err := tx.QueryRow(ctx,
+			`SELECT count(DISTINCT server_msg_id)
+			   FROM `+messages+`
+			  WHERE conversation_id = $1 AND server_msg_id = ANY($2::text[])`,
+			convID, replyIDs,
+		).Scan(&found); err != nil {
+			return nil, err
+		}
+		if found != len(uniqueStrings(replyIDs)) {
+			return nil, ErrReplyTargetNotFound
+		}
 	}
 
-	var seq int64
-	if err := tx.QueryRow(ctx,
-		`UPDATE `+cursors+`
-		    SET next_seq = next_seq + 1,
-		        updated_at = now()
-		  WHERE conversation_id = $1
-		RETURNING (next_seq - 1)`,
-		in.ConversationID,
-	).Scan(&seq); err != nil {
-		return AppendMessageResult{}, err
+	// Fresh inputs get consecutive seqs; repeats within the batch resolve to the first.
+	out := make([]AppendMessageResult, len(in))
+	fresh := make([]int, 0, len(in))
+	firstByClientID := make(map[string]int, len(in))
+	repeats := make(map[int]int)
+	for i, m := range in {
+		if prev, ok := existing[m.ClientMsgID]; ok {
+			out[i] = AppendMessageResult{Stored: prev, Duplicated: true}
+			continue
+		}
+		if j, ok := firstByClientID[m.ClientMsgID]; ok {
+			repeats[i] = j
+			continue
+		}
+		firstByClientID[m.ClientMsgID] = i
+		fresh = append(fresh, i)
 	}
 
-	serverMsgID := NewRandomHex(16)
+	if len(fresh) > 0 {
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO `+cursors+` (conversation_id, next_seq)
+			 VALUES ($1, 1)
+			 ON CONFLICT (conversation_id) DO NOTHING`,
+			convID,
+		); err != nil {
+			return nil, err
+		}
+		var firstSeq int64
+		if err := tx.QueryRow(ctx,
+			`UPDATE `+cursors+`
+			    SET next_seq = next_seq + $2,
+			        updated_at = now()
+			  WHERE conversation_id = $1
+			RETURNING (next_seq - $2)`,
+			convID, int64(len(fresh)),
+		).Scan(&firstSeq); err != nil {
+			return nil, err
+		}
 
-	if _, err := tx.Exec(ctx,
-		`INSERT INTO `+messages+` (
-		     conversation_id, seq, server_msg_id, client_msg_id, sender_session, text, server_ts
-		   ) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
-		in.ConversationID, seq, serverMsgID, in.ClientMsgID, in.SenderSession, in.Text, now,
-	); err != nil {
-		return AppendMessageResult{}, fmt.Errorf("insert message: %w", err)
+		batch := &pgx.Batch{}
+		for k, i := range fresh {
+			m := in[i]
+			ts := m.Now
+			if ts.IsZero() {
+				ts = now
+			}
+			stored := StoredMessage{
+				ConversationID: convID,
+				ClientMsgID:    m.ClientMsgID,
+				ServerMsgID:    NewRandomHex(16),
+				Seq:            firstSeq + int64(k),
+				SenderSession:  m.SenderSession,
+				SenderBotID:    m.SenderBotID,
+				Text:           m.Text,
+				ServerTS:       ts,
+				Version:        1,
+
+				ReplyToServerMsgID: m.ReplyToServerMsgID,
+				AttachmentIDs:      m.AttachmentIDs,
+				Entities:           m.Entities,
+
+				ContentType: contentTypeOrText(m.ContentType),
+				Ciphertext:  m.Ciphertext,
+				KeyIDs:      m.KeyIDs,
+			}
+			batch.Queue(insertMessageSQL(messages),
+				convID, stored.Seq, stored.ServerMsgID, m.ClientMsgID, m.SenderSession, m.SenderBotID, m.Text, ts,
+				m.ReplyToServerMsgID, m.AttachmentIDs, entitiesJSON(m.Entities), m.ContentType, m.Ciphertext, m.KeyIDs,
+			)
+			out[i] = AppendMessageResult{Stored: stored}
+		}
+		br := tx.SendBatch(ctx, batch)
+		for range fresh {
+			if _, err := br.Exec(); err != nil {
+				_ = br.Close()
+				return nil, fmt.Errorf("insert message: %w", err)
+			}
+		}
+		if err := br.Close(); err != nil {
+			return nil, err
+		}
+
+		last := out[fresh[len(fresh)-1]].Stored
+		var lastAt time.Time
+		for _, i := range fresh {
+			if ts := out[i].Stored.ServerTS; ts.After(lastAt) {
+				lastAt = ts
+			}
+		}
+		if err := bumpConversationStats(ctx, tx, pgIdent(s.schema, "conversation_stats"), convID, last.Seq, int64(len(fresh)), lastAt); err != nil {
+			return nil, err
+		}
 	}
 
-	out := StoredMessage{
-		ConversationID: in.ConversationID,
-		ClientMsgID:    in.ClientMsgID,
-		ServerMsgID:    serverMsgID,
-		Seq:            seq,
-		SenderSession:  in.SenderSession,
-		Text:           in.Text,
-		ServerTS:       now,
+	for i, j := range repeats {
+		out[i] = AppendMessageResult{Stored: out[j].Stored, Duplicated: true}
 	}
 
 	if err := tx.Commit(ctx); err != nil {
-		return AppendMessageResult{}, err
+		return nil, err
+	}
+	return out, nil
+}
+
+// bumpConversationStats records n messages appended up to lastSeq in the caller's transaction.
+func bumpConversationStats(ctx context.Context, tx pgx.Tx, statsTable, conversationID string, lastSeq, n int64, lastAt time.Time) error {
+	_, err := tx.Exec(ctx,
+		`INSERT INTO `+statsTable+` (conversation_id, last_seq, message_count, last_message_at)
+		 VALUES ($1, $2, $3, $4)
+		 ON CONFLICT (conversation_id) DO UPDATE
+		   SET last_seq = GREATEST(`+statsTable+`.last_seq, EXCLUDED.last_seq),
+		       message_count = `+statsTable+`.message_count + EXCLUDED.message_count,
+		       last_message_at = GREATEST(`+statsTable+`.last_message_at, EXCLUDED.last_message_at),
+		       updated_at = now()`,
+		conversationID, lastSeq, n, lastAt,
+	)
+	if err != nil {
+		return fmt.Errorf("conversation stats: %w", err)
 	}
-	return AppendMessageResult{Stored: out, Duplicated: false}, nil
+	return nil
+}
+
+// ConversationStats reads the stats rows of conversationIDs.
+func (s *PostgresStore) ConversationStats(ctx context.Context, conversationIDs []string) (map[string]ConversationStats, error) {
+	ctx = dbtrace.WithOp(ctx, "realtime.conversation_stats")
+	if s == nil || s.pool == nil {
+		return nil, errors.New("realtime: nil store")
+	}
+	out := make(map[string]ConversationStats, len(conversationIDs))
+	if len(conversationIDs) == 0 {
+		return out, nil
+	}
+
+	rows, err := dbroute.Reader(ctx, s.pool, s.readPool).Query(ctx,
+		`SELECT conversation_id, last_seq, message_count, last_message_at
+		   FROM `+pgIdent(s.schema, "conversation_stats")+`
+		  WHERE conversation_id = ANY($1::text[]) AND last_message_at IS NOT NULL`,
+		conversationIDs,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var st ConversationStats
+		if err := rows.Scan(&st.ConversationID, &st.LastSeq, &st.MessageCount, &st.LastMessageAt); err != nil {
+			return nil, err
+		}
+		st.LastMessageAt = st.LastMessageAt.UTC()
+		out[st.ConversationID] = st
+	}
+	return out, rows.Err()
+}
+
+func uniqueStrings(in []string) []string {
+	out := slices.Clone(in)
+	slices.Sort(out)
+	return slices.Compact(out)
+}
+
+// insertMessageSQL inserts one message row; arguments are conversation_id, seq,
+// server_msg_id, client_msg_id, sender_session, sender_bot_id, text, server_ts,
+// reply_to_server_msg_id, attachment_ids, entities, content_type, ciphertext and key_ids.
+func insertMessageSQL(messagesTable string) string {
+	return `INSERT INTO ` + messagesTable + ` (
+		     conversation_id, seq, server_msg_id, client_msg_id, sender_session, sender_bot_id, text, server_ts,
+		     reply_to_server_msg_id, attachment_ids, entities, content_type, ciphertext, key_ids
+		   ) VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), $7, $8, NULLIF($9, ''), COALESCE($10::text[], '{}'), $11::jsonb,
+		     COALESCE(NULLIF($12, ''), 'text'), $13, COALESCE($14::text[], '{}'))`
+}
+
+// contentTypeOrText returns the content_type stored for contentType.
+func contentTypeOrText(contentType string) string {
+	if contentType == "" {
+		return v1.ContentTypeText
+	}
+	return contentType
 }
 
 // FetchHistory returns messages ordered by seq ASC, with optional paging by AfterSeq.
 func (s *PostgresStore) FetchHistory(ctx context.Context, in FetchHistoryInput) (FetchHistoryResult, error) {
+	ctx = dbtrace.WithOp(ctx, "realtime.fetch_history")
 	if s == nil || s.pool == nil {
 		return FetchHistoryResult{}, errors.New("realtime: nil store")
 	}
 	if in.ConversationID == "" {
-		return FetchHistoryResult{}, errors.New("missing conversation_id")
+		return FetchHistoryResult{}, errMissingConversationID
 	}
 	if err := ctx.Err(); err != nil {
 		return FetchHistoryResult{}, err
@@ -200,36 +515,78 @@ func (s *PostgresStore) FetchHistory(ctx context.Context, in FetchHistoryInput)
 
 	messages := pgIdent(s.schema, "messages")
 
-	var (
-		rows pgx.Rows
-		err  error
-	)
-
-	if in.AfterSeq == nil {
-		rows, err = s.pool.Query(ctx,
-			`SELECT conversation_id, client_msg_id, server_msg_id, seq, sender_session, text, server_ts
-			   FROM `+messages+`
-			  WHERE conversation_id = $1
-			  ORDER BY seq ASC
-			  LIMIT $2`,
-			in.ConversationID, fetch,
-		)
-	} else {
-		rows, err = s.pool.Query(ctx,
-			`SELECT conversation_id, client_msg_id, server_msg_id, seq, sender_session, text, server_ts
-			   FROM `+messages+`
-			  WHERE conversation_id = $1 AND seq > $2
-			  ORDER BY seq ASC
-			  LIMIT $3`,
-			in.ConversationID, *in.AfterSeq, fetch,
-		)
+	// Backward pages read newest-first below BeforeSeq and are reversed below.
+	backward := in.BeforeSeq != nil
+	order := "ASC"
+	if backward {
+		order = "DESC"
 	}
+
+	rows, err := dbroute.Reader(ctx, s.pool, s.readPool).Query(ctx,
+		`SELECT `+storedMessageColumns+`
+		   FROM `+messages+`
+		  WHERE conversation_id = $1
+		    AND ($2::bigint IS NULL OR seq > $2)
+		    AND ($3::bigint IS NULL OR seq < $3)
+		  ORDER BY seq `+order+`
+		  LIMIT $4`,
+		in.ConversationID, in.AfterSeq, in.BeforeSeq, fetch,
+	)
 	if err != nil {
 		return FetchHistoryResult{}, err
 	}
 	defer rows.Close()
 
 	msgs := make([]StoredMessage, 0, fetch)
+	for rows.Next() {
+		m, err := scanStoredMessage(rows)
+		if err != nil {
+			return FetchHistoryResult{}, err
+		}
+		msgs = append(msgs, m)
+	}
+	if err := rows.Err(); err != nil {
+		return FetchHistoryResult{}, err
+	}
+
+	hasMore := len(msgs) > limit
+	if hasMore {
+		msgs = msgs[:limit]
+	}
+	if backward {
+		slices.Reverse(msgs)
+	}
+
+	return FetchHistoryResult{Messages: msgs, HasMore: hasMore}, nil
+}
+
+// ListMessagesByAuthor streams every message sent from a session of userID, oldest first.
+func (s *PostgresStore) ListMessagesByAuthor(ctx context.Context, userID string, fn func(StoredMessage) error) error {
+	ctx = dbtrace.WithOp(ctx, "realtime.list_messages_by_author")
+	if s == nil || s.pool == nil {
+		return errors.New("realtime: nil store")
+	}
+	if userID == "" || fn == nil {
+		return errors.New("invalid input")
+	}
+
+	messages := pgIdent(s.schema, "messages")
+	sessions := pgIdent(s.schema, "sessions")
+
+	rows, err := dbroute.Reader(ctx, s.pool, s.readPool).Query(ctx,
+		`SELECT m.conversation_id, m.client_msg_id, m.server_msg_id, m.seq, m.sender_session, m.text, m.server_ts
+		   FROM `+messages+` m
+		   JOIN `+sessions+` s ON s.id = m.sender_session
+		  WHERE s.user_id = $1
+		    AND m.deleted_at IS NULL
+		  ORDER BY m.server_ts ASC, m.server_msg_id ASC`,
+		userID,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
 	for rows.Next() {
 		var m StoredMessage
 		if err := rows.Scan(
@@ -241,31 +598,240 @@ func (s *PostgresStore) FetchHistory(ctx context.Context, in FetchHistoryInput)
 			&m.Text,
 			&m.ServerTS,
 		); err != nil {
-			return FetchHistoryResult{}, err
+			return err
+		}
+		if err := fn(m); err != nil {
+			return err
 		}
-		msgs = append(msgs, m)
 	}
-	if err := rows.Err(); err != nil {
-		return FetchHistoryResult{}, err
+	return rows.Err()
+}
+
+// ScrubMessagesByAuthor detaches up to limit messages from the sessions of userID and
+// replaces their text with ScrubbedMessageText. Seq numbers are kept so conversation
+// history stays gap-free. It returns how many messages were scrubbed; callers loop
+// until it returns 0.
+func (s *PostgresStore) ScrubMessagesByAuthor(ctx context.Context, userID string, limit int) (int64, error) {
+	ctx = dbtrace.WithOp(ctx, "realtime.scrub_messages_by_author")
+	if s == nil || s.pool == nil {
+		return 0, errors.New("realtime: nil store")
+	}
+	if userID == "" || limit <= 0 {
+		return 0, errors.New("invalid input")
 	}
 
-	hasMore := len(msgs) > limit
-	if hasMore {
-		msgs = msgs[:limit]
+	messages := pgIdent(s.schema, "messages")
+	sessions := pgIdent(s.schema, "sessions")
+
+	ct, err := s.pool.Exec(ctx,
+		`UPDATE `+messages+` m
+		    SET sender_session = NULL,
+		        text = $3,
+		        attachment_ids = '{}',
+		        entities = NULL,
+		        content_type = 'text',
+		        ciphertext = NULL,
+		        key_ids = '{}'
+		  WHERE (m.conversation_id, m.seq) IN (
+		        SELECT mm.conversation_id, mm.seq
+		          FROM `+messages+` mm
+		          JOIN `+sessions+` s ON s.id = mm.sender_session
+		         WHERE s.user_id = $1
+		         LIMIT $2
+		  )`,
+		userID, limit, ScrubbedMessageText,
+	)
+	if err != nil {
+		return 0, err
 	}
+	return ct.RowsAffected(), nil
+}
 
-	return FetchHistoryResult{Messages: msgs, HasMore: hasMore}, nil
+// EditMessage replaces the text of a message authored by in.MessageActor.
+func (s *PostgresStore) EditMessage(ctx context.Context, in EditMessageInput) (MessageMutationResult, error) {
+	ctx = dbtrace.WithOp(ctx, "realtime.edit_message")
+	if s == nil || s.pool == nil {
+		return MessageMutationResult{}, errors.New("realtime: nil store")
+	}
+	if in.ConversationID == "" || in.ServerMsgID == "" || in.Text == "" {
+		return MessageMutationResult{}, errors.New("invalid input")
+	}
+	now := in.Now
+	if now.IsZero() {
+		now = time.Now().UTC()
+	}
+
+	messages := pgIdent(s.schema, "messages")
+	return s.mutateMessage(ctx, in.ConversationID, in.ServerMsgID, in.MessageActor, false, func(tx pgx.Tx, m StoredMessage) (StoredMessage, bool, error) {
+		if m.Deleted() {
+			return m, false, ErrMessageDeleted
+		}
+		if m.Encrypted() {
+			return m, false, ErrMessageEncrypted
+		}
+		if m.Text == in.Text {
+			return m, false, nil
+		}
+		out, err := scanStoredMessage(tx.QueryRow(ctx,
+			`UPDATE `+messages+`
+			    SET text = $3, entities = $5::jsonb, version = version + 1, edited_at = $4
+			  WHERE conversation_id = $1 AND server_msg_id = $2
+			RETURNING `+storedMessageColumns,
+			in.ConversationID, in.ServerMsgID, in.Text, now, entitiesJSON(in.Entities),
+		))
+		return out, true, err
+	})
+}
+
+// DeleteMessage tombstones a message authored by in.MessageActor (or any message when
+// in.Moderator). The row keeps its seq so history stays gap-free; text is cleared.
+// Deleting a tombstone is a no-op.
+func (s *PostgresStore) DeleteMessage(ctx context.Context, in DeleteMessageInput) (MessageMutationResult, error) {
+	ctx = dbtrace.WithOp(ctx, "realtime.delete_message")
+	if s == nil || s.pool == nil {
+		return MessageMutationResult{}, errors.New("realtime: nil store")
+	}
+	if in.ConversationID == "" || in.ServerMsgID == "" {
+		return MessageMutationResult{}, errors.New("invalid input")
+	}
+	now := in.Now
+	if now.IsZero() {
+		now = time.Now().UTC()
+	}
+
+	messages := pgIdent(s.schema, "messages")
+	return s.mutateMessage(ctx, in.ConversationID, in.ServerMsgID, in.MessageActor, in.Moderator, func(tx pgx.Tx, m StoredMessage) (StoredMessage, bool, error) {
+		if m.Deleted() {
+			return m, false, nil
+		}
+		out, err := scanStoredMessage(tx.QueryRow(ctx,
+			`UPDATE `+messages+`
+			    SET text = '', attachment_ids = '{}', entities = NULL, ciphertext = NULL, key_ids = '{}',
+			        version = version + 1, deleted_at = $3
+			  WHERE conversation_id = $1 AND server_msg_id = $2
+			RETURNING `+storedMessageColumns,
+			in.ConversationID, in.ServerMsgID, now,
+		))
+		return out, true, err
+	})
+}
+
+// mutateMessage locks the message row, checks authorship (unless moderator) and
+// applies fn in one transaction.
+func (s *PostgresStore) mutateMessage(
+	ctx context.Context,
+	conversationID, serverMsgID string,
+	actor MessageActor,
+	moderator bool,
+	fn func(tx pgx.Tx, m StoredMessage) (StoredMessage, bool, error),
+) (MessageMutationResult, error) {
+	if err := ctx.Err(); err != nil {
+		return MessageMutationResult{}, err
+	}
+
+	messages := pgIdent(s.schema, "messages")
+	sessions := pgIdent(s.schema, "sessions")
+
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.ReadCommitted,
+		AccessMode: pgx.ReadWrite,
+	})
+	if err != nil {
+		return MessageMutationResult{}, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var (
+		m        StoredMessage
+		entities []byte
+		isAuthor bool
+	)
+	err = tx.QueryRow(ctx,
+		`SELECT m.conversation_id, m.client_msg_id, m.server_msg_id, m.seq, COALESCE(m.sender_session, ''),
+		        COALESCE(m.sender_bot_id, ''), m.text, m.server_ts, m.version, m.edited_at, m.deleted_at, COALESCE(m.reply_to_server_msg_id, ''),
+		        m.attachment_ids, m.entities, m.content_type, m.ciphertext, m.key_ids,
+		        m.sender_session IS NOT NULL AND (
+		            m.sender_session = $3
+		            OR ($4 <> '' AND EXISTS (
+		                SELECT 1 FROM `+sessions+` s WHERE s.id = m.sender_session AND s.user_id = $4
+		            ))
+		        )
+		   FROM `+messages+` m
+		  WHERE m.conversation_id = $1 AND m.server_msg_id = $2
+		  FOR UPDATE OF m`,
+		conversationID, serverMsgID, actor.ActorSession, actor.ActorUserID,
+	).Scan(
+		&m.ConversationID, &m.ClientMsgID, &m.ServerMsgID, &m.Seq, &m.SenderSession,
+		&m.SenderBotID, &m.Text, &m.ServerTS, &m.Version, &m.EditedAt, &m.DeletedAt, &m.ReplyToServerMsgID,
+		&m.AttachmentIDs, &entities, &m.ContentType, &m.Ciphertext, &m.KeyIDs,
+		&isAuthor,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return MessageMutationResult{}, ErrMessageNotFound
+	}
+	if err != nil {
+		return MessageMutationResult{}, err
+	}
+	if !isAuthor && !moderator {
+		return MessageMutationResult{}, ErrMessageNotAuthor
+	}
+	if m.Entities, err = decodeEntities(entities); err != nil {
+		return MessageMutationResult{}, err
+	}
+
+	out, changed, err := fn(tx, m)
+	if err != nil {
+		return MessageMutationResult{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return MessageMutationResult{}, err
+	}
+	return MessageMutationResult{Stored: out, Changed: changed}, nil
+}
+
+// storedMessageColumns matches scanStoredMessage.
+const storedMessageColumns = `conversation_id, client_msg_id, server_msg_id, seq, COALESCE(sender_session, ''), COALESCE(sender_bot_id, ''), text, server_ts, version, edited_at, deleted_at, COALESCE(reply_to_server_msg_id, ''), attachment_ids, entities, content_type, ciphertext, key_ids`
+
+func scanStoredMessage(row pgx.Row) (StoredMessage, error) {
+	var (
+		m        StoredMessage
+		entities []byte
+	)
+	err := row.Scan(
+		&m.ConversationID,
+		&m.ClientMsgID,
+		&m.ServerMsgID,
+		&m.Seq,
+		&m.SenderSession,
+		&m.SenderBotID,
+		&m.Text,
+		&m.ServerTS,
+		&m.Version,
+		&m.EditedAt,
+		&m.DeletedAt,
+		&m.ReplyToServerMsgID,
+		&m.AttachmentIDs,
+		&entities,
+		&m.ContentType,
+		&m.Ciphertext,
+		&m.KeyIDs,
+	)
+	if err != nil {
+		return StoredMessage{}, err
+	}
+	if m.Entities, err = decodeEntities(entities); err != nil {
+		return StoredMessage{}, err
+	}
+	return m, nil
 }
 
 func readMessageByClientMsgID(ctx context.Context, tx pgx.Tx, messagesTable string, conversationID, clientMsgID string) (StoredMessage, error) {
-	var m StoredMessage
-	err := tx.QueryRow(ctx,
-		`SELECT conversation_id, client_msg_id, server_msg_id, seq, sender_session, text, server_ts
+	return scanStoredMessage(tx.QueryRow(ctx,
+		`SELECT `+storedMessageColumns+`
 		   FROM `+messagesTable+`
 		  WHERE conversation_id = $1 AND client_msg_id = $2`,
 		conversationID, clientMsgID,
-	).Scan(&m.ConversationID, &m.ClientMsgID, &m.ServerMsgID, &m.Seq, &m.SenderSession, &m.Text, &m.ServerTS)
-	return m, err
+	))
 }
 
 var pgIdentRE = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
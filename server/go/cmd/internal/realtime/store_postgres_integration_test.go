@@ -1,17 +1,23 @@
 package realtime
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"math"
 	"net"
 	"os"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"arc/cmd/internal/migrations/migrationstest"
+	v1 "arc/shared/contracts/realtime/v1"
+
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -25,12 +31,10 @@ func TestPostgresStore_Append_Dedupe_NoSeqWaste(t *testing.T) {
 	pool := mustOpenTestPool(t)
 	defer pool.Close()
 
-	schema := mustCreateTestSchema(t, pool)
-	t.Cleanup(func() { mustDropSchema(t, pool, schema) })
-
-	mustApplySchema(t, pool, schema)
+	schema := migrationstest.NewSchema(t, pool)
 
 	store := mustNewStore(t, pool, schema)
+	mustCreateTestSessions(t, pool, schema, "user-a", "session-a")
 
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 	defer cancel()
@@ -43,7 +47,7 @@ func TestPostgresStore_Append_Dedupe_NoSeqWaste(t *testing.T) {
 	first, err := store.AppendMessage(ctx, AppendMessageInput{
 		ConversationID: convID,
 		ClientMsgID:    clientMsgID,
-		SenderSession:  "session-a",
+		SenderSession:  testID("session-a"),
 		Text:           "hello",
 		Now:            now,
 	})
@@ -63,7 +67,7 @@ func TestPostgresStore_Append_Dedupe_NoSeqWaste(t *testing.T) {
 	second, err := store.AppendMessage(ctx, AppendMessageInput{
 		ConversationID: convID,
 		ClientMsgID:    clientMsgID, // duplicate on purpose
-		SenderSession:  "session-a",
+		SenderSession:  testID("session-a"),
 		Text:           "hello",
 		Now:            now.Add(1 * time.Second),
 	})
@@ -86,18 +90,89 @@ func TestPostgresStore_Append_Dedupe_NoSeqWaste(t *testing.T) {
 	}
 }
 
-func TestPostgresStore_History_Order_AfterSeq_HasMore(t *testing.T) {
+func TestPostgresStore_AppendMessages_Batch(t *testing.T) {
 	t.Parallel()
 
 	pool := mustOpenTestPool(t)
 	defer pool.Close()
 
-	schema := mustCreateTestSchema(t, pool)
-	t.Cleanup(func() { mustDropSchema(t, pool, schema) })
+	schema := migrationstest.NewSchema(t, pool)
+
+	store := mustNewStore(t, pool, schema)
+	mustCreateTestSessions(t, pool, schema, "user-a", "session-a")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	convID := "it-batch-" + NewRandomHex(8)
+
+	first, err := store.AppendMessage(ctx, AppendMessageInput{
+		ConversationID: convID, ClientMsgID: "k0", SenderSession: testID("session-a"), Text: "before",
+	})
+	if err != nil {
+		t.Fatalf("append: %v", err)
+	}
+
+	res, err := store.AppendMessages(ctx, []AppendMessageInput{
+		{ConversationID: convID, ClientMsgID: "k1", SenderBotID: "bot-1", Text: "one", ReplyToServerMsgID: first.Stored.ServerMsgID},
+		{ConversationID: convID, ClientMsgID: "k0", SenderBotID: "bot-1", Text: "already stored"},
+		{ConversationID: convID, ClientMsgID: "k2", SenderBotID: "bot-1", Text: "two"},
+		{ConversationID: convID, ClientMsgID: "k1", SenderBotID: "bot-1", Text: "repeat"},
+	})
+	if err != nil {
+		t.Fatalf("append batch: %v", err)
+	}
+	if len(res) != 4 || res[0].Stored.Seq != 2 || res[2].Stored.Seq != 3 {
+		t.Fatalf("unexpected seqs: %+v", res)
+	}
+	if !res[1].Duplicated || res[1].Stored.ServerMsgID != first.Stored.ServerMsgID {
+		t.Fatalf("expected stored duplicate, got %+v", res[1])
+	}
+	if !res[3].Duplicated || res[3].Stored.ServerMsgID != res[0].Stored.ServerMsgID {
+		t.Fatalf("expected in-batch duplicate, got %+v", res[3])
+	}
+	if cnt := mustCountMessages(t, pool, schema, convID); cnt != 3 {
+		t.Fatalf("expected 3 message rows, got %d", cnt)
+	}
+
+	// A missing reply target rejects the whole batch.
+	_, err = store.AppendMessages(ctx, []AppendMessageInput{
+		{ConversationID: convID, ClientMsgID: "k3", SenderBotID: "bot-1", Text: "three"},
+		{ConversationID: convID, ClientMsgID: "k4", SenderBotID: "bot-1", Text: "four", ReplyToServerMsgID: "missing"},
+	})
+	if !errors.Is(err, ErrReplyTargetNotFound) {
+		t.Fatalf("expected ErrReplyTargetNotFound, got %v", err)
+	}
+	if cnt := mustCountMessages(t, pool, schema, convID); cnt != 3 {
+		t.Fatalf("expected rejected batch to store nothing, got %d rows", cnt)
+	}
+
+	next, err := store.AppendMessage(ctx, AppendMessageInput{
+		ConversationID: convID, ClientMsgID: "k5", SenderSession: testID("session-a"), Text: "after",
+	})
+	if err != nil || next.Stored.Seq != 4 {
+		t.Fatalf("expected seq 4 after batch, got %+v err=%v", next.Stored, err)
+	}
+
+	stats, err := store.ConversationStats(ctx, []string{convID, "conv-without-messages"})
+	if err != nil {
+		t.Fatalf("conversation stats: %v", err)
+	}
+	if st := stats[convID]; len(stats) != 1 || st.LastSeq != 4 || st.MessageCount != 4 || !st.LastMessageAt.Equal(next.Stored.ServerTS.Truncate(time.Microsecond)) {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+func TestPostgresStore_History_Order_AfterSeq_HasMore(t *testing.T) {
+	t.Parallel()
 
-	mustApplySchema(t, pool, schema)
+	pool := mustOpenTestPool(t)
+	defer pool.Close()
+
+	schema := migrationstest.NewSchema(t, pool)
 
 	store := mustNewStore(t, pool, schema)
+	mustCreateTestSessions(t, pool, schema, "user-a", "session-a")
 
 	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
 	defer cancel()
@@ -109,7 +184,7 @@ func TestPostgresStore_History_Order_AfterSeq_HasMore(t *testing.T) {
 		_, err := store.AppendMessage(ctx, AppendMessageInput{
 			ConversationID: convID,
 			ClientMsgID:    fmt.Sprintf("cmsg-%d-%s", i, NewRandomHex(4)),
-			SenderSession:  "session-a",
+			SenderSession:  testID("session-a"),
 			Text:           fmt.Sprintf("m%d", i),
 			Now:            time.Now().UTC(),
 		})
@@ -157,19 +232,432 @@ func TestPostgresStore_History_Order_AfterSeq_HasMore(t *testing.T) {
 	}
 }
 
-func TestPostgresStore_ConcurrentAppend_StrictSeq_NoGaps(t *testing.T) {
+func TestPostgresStore_History_BeforeSeq_Backward(t *testing.T) {
+	t.Parallel()
+
+	pool := mustOpenTestPool(t)
+	defer pool.Close()
+
+	schema := migrationstest.NewSchema(t, pool)
+
+	store := mustNewStore(t, pool, schema)
+	mustCreateTestSessions(t, pool, schema, "user-a", "session-a")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	convID := "it-history-back-" + NewRandomHex(8)
+	for i := 0; i < 5; i++ {
+		_, err := store.AppendMessage(ctx, AppendMessageInput{
+			ConversationID: convID,
+			ClientMsgID:    fmt.Sprintf("cmsg-%d-%s", i, NewRandomHex(4)),
+			SenderSession:  testID("session-a"),
+			Text:           fmt.Sprintf("m%d", i),
+			Now:            time.Now().UTC(),
+		})
+		if err != nil {
+			t.Fatalf("append %d: %v", i, err)
+		}
+	}
+
+	seq := func(v int64) *int64 { return &v }
+	cases := []struct {
+		name    string
+		after   *int64
+		before  *int64
+		want    []int64
+		hasMore bool
+	}{
+		{"backward latest", nil, seq(6), []int64{4, 5}, true},
+		{"backward page", nil, seq(4), []int64{2, 3}, true},
+		{"backward to start", nil, seq(3), []int64{1, 2}, false},
+		{"bounded window", seq(1), seq(5), []int64{3, 4}, true},
+		{"forward after", seq(3), nil, []int64{4, 5}, false},
+	}
+	for _, tc := range cases {
+		out, err := store.FetchHistory(ctx, FetchHistoryInput{
+			ConversationID: convID,
+			AfterSeq:       tc.after,
+			BeforeSeq:      tc.before,
+			Limit:          2,
+		})
+		if err != nil {
+			t.Fatalf("%s: fetch history: %v", tc.name, err)
+		}
+		if len(out.Messages) != len(tc.want) || out.HasMore != tc.hasMore {
+			t.Fatalf("%s: expected %v has_more=%v, got %d msgs has_more=%v", tc.name, tc.want, tc.hasMore, len(out.Messages), out.HasMore)
+		}
+		for i, m := range out.Messages {
+			if m.Seq != tc.want[i] {
+				t.Fatalf("%s: expected seqs %v, got seq %d at %d", tc.name, tc.want, m.Seq, i)
+			}
+		}
+	}
+}
+
+func TestPostgresStore_ListMessagesByAuthor(t *testing.T) {
+	t.Parallel()
+
+	pool := mustOpenTestPool(t)
+	defer pool.Close()
+
+	schema := migrationstest.NewSchema(t, pool)
+
+	store := mustNewStore(t, pool, schema)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	// Authorship is resolved through sender_session -> user_id.
+	mustCreateTestSessions(t, pool, schema, "user-a", "s-a1", "s-a2")
+	mustCreateTestSessions(t, pool, schema, "user-b", "s-b1")
+
+	convID := "it-author-" + NewRandomHex(8)
+	base := time.Now().UTC()
+	for i, sender := range []string{testID("s-a1"), testID("s-b1"), testID("s-a2")} {
+		if _, err := store.AppendMessage(ctx, AppendMessageInput{
+			ConversationID: convID,
+			ClientMsgID:    fmt.Sprintf("cmsg-%d-%s", i, NewRandomHex(4)),
+			SenderSession:  sender,
+			Text:           fmt.Sprintf("m%d", i),
+			Now:            base.Add(time.Duration(i) * time.Second),
+		}); err != nil {
+			t.Fatalf("append %d: %v", i, err)
+		}
+	}
+
+	var texts []string
+	if err := store.ListMessagesByAuthor(ctx, testID("user-a"), func(m StoredMessage) error {
+		texts = append(texts, m.Text)
+		return nil
+	}); err != nil {
+		t.Fatalf("list by author: %v", err)
+	}
+	if strings.Join(texts, ",") != "m0,m2" {
+		t.Fatalf("expected messages of user-a in order, got %v", texts)
+	}
+}
+
+func TestPostgresStore_ScrubMessagesByAuthor(t *testing.T) {
 	t.Parallel()
 
 	pool := mustOpenTestPool(t)
 	defer pool.Close()
 
-	schema := mustCreateTestSchema(t, pool)
-	t.Cleanup(func() { mustDropSchema(t, pool, schema) })
+	schema := migrationstest.NewSchema(t, pool)
+
+	store := mustNewStore(t, pool, schema)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	mustCreateTestSessions(t, pool, schema, "user-a", "s-a1")
+	mustCreateTestSessions(t, pool, schema, "user-b", "s-b1")
 
-	mustApplySchema(t, pool, schema)
+	convID := "it-scrub-" + NewRandomHex(8)
+	for i, sender := range []string{testID("s-a1"), testID("s-b1"), testID("s-a1"), testID("s-a1")} {
+		if _, err := store.AppendMessage(ctx, AppendMessageInput{
+			ConversationID: convID,
+			ClientMsgID:    fmt.Sprintf("cmsg-%d-%s", i, NewRandomHex(4)),
+			SenderSession:  sender,
+			Text:           fmt.Sprintf("m%d", i),
+			Now:            time.Now().UTC(),
+		}); err != nil {
+			t.Fatalf("append %d: %v", i, err)
+		}
+	}
+
+	var total int64
+	for {
+		n, err := store.ScrubMessagesByAuthor(ctx, testID("user-a"), 2)
+		if err != nil {
+			t.Fatalf("scrub: %v", err)
+		}
+		if n == 0 {
+			break
+		}
+		total += n
+	}
+	if total != 3 {
+		t.Fatalf("expected 3 scrubbed messages, got %d", total)
+	}
+
+	hist, err := store.FetchHistory(ctx, FetchHistoryInput{ConversationID: convID, Limit: 10})
+	if err != nil {
+		t.Fatalf("fetch history: %v", err)
+	}
+	if len(hist.Messages) != 4 {
+		t.Fatalf("expected history to keep 4 messages, got %d", len(hist.Messages))
+	}
+	for _, m := range hist.Messages {
+		scrubbed := m.SenderSession == "" && m.Text == ScrubbedMessageText
+		if m.Text == "m1" {
+			if scrubbed || m.SenderSession != testID("s-b1") {
+				t.Fatalf("expected other author's message untouched, got %+v", m)
+			}
+			continue
+		}
+		if !scrubbed {
+			t.Fatalf("expected message to be scrubbed, got %+v", m)
+		}
+	}
+}
+
+func TestPostgresStore_EditAndDelete_Tombstone(t *testing.T) {
+	t.Parallel()
+
+	pool := mustOpenTestPool(t)
+	defer pool.Close()
+
+	schema := migrationstest.NewSchema(t, pool)
 
 	store := mustNewStore(t, pool, schema)
 
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	mustCreateTestSessions(t, pool, schema, "user-a", "s-a1", "s-a2")
+	mustCreateTestSessions(t, pool, schema, "user-b", "s-b1")
+
+	convID := "it-edit-" + NewRandomHex(8)
+	var ids []string
+	for i := 0; i < 3; i++ {
+		res, err := store.AppendMessage(ctx, AppendMessageInput{
+			ConversationID: convID,
+			ClientMsgID:    fmt.Sprintf("cmsg-%d", i),
+			SenderSession:  testID("s-a1"),
+			Text:           fmt.Sprintf("m%d", i),
+			Now:            time.Now().UTC(),
+		})
+		if err != nil {
+			t.Fatalf("append %d: %v", i, err)
+		}
+		if res.Stored.Version != 1 {
+			t.Fatalf("expected version 1 on append, got %d", res.Stored.Version)
+		}
+		ids = append(ids, res.Stored.ServerMsgID)
+	}
+
+	// Another device of the same user may edit.
+	edit := EditMessageInput{
+		MessageActor:   MessageActor{ActorSession: testID("s-a2"), ActorUserID: testID("user-a")},
+		ConversationID: convID,
+		ServerMsgID:    ids[1],
+		Text:           "m1 (edited)",
+	}
+	res, err := store.EditMessage(ctx, edit)
+	if err != nil {
+		t.Fatalf("edit: %v", err)
+	}
+	if !res.Changed || res.Stored.Version != 2 || res.Stored.EditedAt == nil || res.Stored.Seq != 2 {
+		t.Fatalf("unexpected edit result: %+v", res)
+	}
+	if res, err = store.EditMessage(ctx, edit); err != nil || res.Changed {
+		t.Fatalf("expected idempotent edit retry, got %+v, %v", res, err)
+	}
+
+	if _, err := store.EditMessage(ctx, EditMessageInput{
+		MessageActor:   MessageActor{ActorSession: testID("s-b1"), ActorUserID: testID("user-b")},
+		ConversationID: convID,
+		ServerMsgID:    ids[1],
+		Text:           "hijack",
+	}); !errors.Is(err, ErrMessageNotAuthor) {
+		t.Fatalf("expected ErrMessageNotAuthor, got %v", err)
+	}
+	if _, err := store.DeleteMessage(ctx, DeleteMessageInput{
+		MessageActor:   MessageActor{ActorSession: testID("s-a1")},
+		ConversationID: convID,
+		ServerMsgID:    "missing",
+	}); !errors.Is(err, ErrMessageNotFound) {
+		t.Fatalf("expected ErrMessageNotFound, got %v", err)
+	}
+
+	del := DeleteMessageInput{
+		MessageActor:   MessageActor{ActorSession: testID("s-a1")},
+		ConversationID: convID,
+		ServerMsgID:    ids[1],
+	}
+	res, err = store.DeleteMessage(ctx, del)
+	if err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if !res.Changed || !res.Stored.Deleted() || res.Stored.Text != "" || res.Stored.Version != 3 {
+		t.Fatalf("unexpected delete result: %+v", res)
+	}
+	if res, err = store.DeleteMessage(ctx, del); err != nil || res.Changed {
+		t.Fatalf("expected idempotent delete retry, got %+v, %v", res, err)
+	}
+	if _, err := store.EditMessage(ctx, EditMessageInput{
+		MessageActor:   MessageActor{ActorSession: testID("s-a1")},
+		ConversationID: convID,
+		ServerMsgID:    ids[1],
+		Text:           "resurrect",
+	}); !errors.Is(err, ErrMessageDeleted) {
+		t.Fatalf("expected ErrMessageDeleted, got %v", err)
+	}
+
+	hist, err := store.FetchHistory(ctx, FetchHistoryInput{ConversationID: convID, Limit: 10})
+	if err != nil {
+		t.Fatalf("fetch history: %v", err)
+	}
+	if len(hist.Messages) != 3 {
+		t.Fatalf("expected tombstone to stay in history, got %d messages", len(hist.Messages))
+	}
+	for i, m := range hist.Messages {
+		if m.Seq != int64(i+1) {
+			t.Fatalf("expected gap-free seq, got %d at %d", m.Seq, i)
+		}
+	}
+	if tomb := hist.Messages[1]; !tomb.Deleted() || tomb.Text != "" {
+		t.Fatalf("expected tombstone at seq 2, got %+v", tomb)
+	}
+}
+
+func TestPostgresStore_EncryptedMessage_Opaque(t *testing.T) {
+	t.Parallel()
+
+	pool := mustOpenTestPool(t)
+	defer pool.Close()
+
+	schema := migrationstest.NewSchema(t, pool)
+
+	store := mustNewStore(t, pool, schema)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	mustCreateTestSessions(t, pool, schema, "user-a", "s-a1")
+
+	convID := "it-e2ee-" + NewRandomHex(8)
+	ciphertext := []byte{0x00, 0xff, 0x10, 0x80}
+	res, err := store.AppendMessage(ctx, AppendMessageInput{
+		ConversationID: convID,
+		ClientMsgID:    "cmsg-e2ee",
+		SenderSession:  testID("s-a1"),
+		Now:            time.Now().UTC(),
+		ContentType:    v1.ContentTypeE2EE,
+		Ciphertext:     ciphertext,
+		KeyIDs:         []string{"dev-1", "dev-2"},
+	})
+	if err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	if !res.Stored.Encrypted() {
+		t.Fatalf("expected encrypted message, got %+v", res.Stored)
+	}
+
+	hist, err := store.FetchHistory(ctx, FetchHistoryInput{ConversationID: convID, Limit: 10})
+	if err != nil || len(hist.Messages) != 1 {
+		t.Fatalf("fetch history: %+v, %v", hist, err)
+	}
+	got := hist.Messages[0]
+	if !got.Encrypted() || got.Text != "" || !bytes.Equal(got.Ciphertext, ciphertext) || !slices.Equal(got.KeyIDs, []string{"dev-1", "dev-2"}) {
+		t.Fatalf("unexpected stored message: %+v", got)
+	}
+
+	if _, err := store.EditMessage(ctx, EditMessageInput{
+		MessageActor:   MessageActor{ActorSession: testID("s-a1")},
+		ConversationID: convID,
+		ServerMsgID:    got.ServerMsgID,
+		Text:           "plain",
+	}); !errors.Is(err, ErrMessageEncrypted) {
+		t.Fatalf("expected ErrMessageEncrypted, got %v", err)
+	}
+
+	del, err := store.DeleteMessage(ctx, DeleteMessageInput{
+		MessageActor:   MessageActor{ActorSession: testID("s-a1")},
+		ConversationID: convID,
+		ServerMsgID:    got.ServerMsgID,
+	})
+	if err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if !del.Stored.Deleted() || del.Stored.Ciphertext != nil || len(del.Stored.KeyIDs) != 0 {
+		t.Fatalf("expected tombstone without ciphertext, got %+v", del.Stored)
+	}
+}
+
+func TestPostgresStore_ReplyTo(t *testing.T) {
+	t.Parallel()
+
+	pool := mustOpenTestPool(t)
+	defer pool.Close()
+
+	schema := migrationstest.NewSchema(t, pool)
+
+	store := mustNewStore(t, pool, schema)
+	mustCreateTestSessions(t, pool, schema, "user-a", "session-a", "session-b")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	convA := "it-reply-a-" + NewRandomHex(8)
+	convB := "it-reply-b-" + NewRandomHex(8)
+
+	parent, err := store.AppendMessage(ctx, AppendMessageInput{
+		ConversationID: convA,
+		ClientMsgID:    "cmsg-parent-" + NewRandomHex(4),
+		SenderSession:  testID("session-a"),
+		Text:           "parent",
+		Now:            time.Now().UTC(),
+	})
+	if err != nil {
+		t.Fatalf("append parent: %v", err)
+	}
+
+	_, err = store.AppendMessage(ctx, AppendMessageInput{
+		ConversationID:     convB,
+		ClientMsgID:        "cmsg-cross-" + NewRandomHex(4),
+		SenderSession:      testID("session-a"),
+		Text:               "cross",
+		Now:                time.Now().UTC(),
+		ReplyToServerMsgID: parent.Stored.ServerMsgID,
+	})
+	if !errors.Is(err, ErrReplyTargetNotFound) {
+		t.Fatalf("expected ErrReplyTargetNotFound across conversations, got %v", err)
+	}
+
+	reply, err := store.AppendMessage(ctx, AppendMessageInput{
+		ConversationID:     convA,
+		ClientMsgID:        "cmsg-reply-" + NewRandomHex(4),
+		SenderSession:      testID("session-b"),
+		Text:               "reply",
+		Now:                time.Now().UTC(),
+		ReplyToServerMsgID: parent.Stored.ServerMsgID,
+	})
+	if err != nil {
+		t.Fatalf("append reply: %v", err)
+	}
+	if reply.Stored.ReplyToServerMsgID != parent.Stored.ServerMsgID {
+		t.Fatalf("expected reply_to=%s got=%q", parent.Stored.ServerMsgID, reply.Stored.ReplyToServerMsgID)
+	}
+
+	out, err := store.FetchHistory(ctx, FetchHistoryInput{ConversationID: convA, Limit: 50})
+	if err != nil {
+		t.Fatalf("fetch history: %v", err)
+	}
+	if len(out.Messages) != 2 {
+		t.Fatalf("expected 2 msgs got %d", len(out.Messages))
+	}
+	if out.Messages[0].ReplyToServerMsgID != "" {
+		t.Fatalf("expected no reply_to on parent, got %q", out.Messages[0].ReplyToServerMsgID)
+	}
+	if out.Messages[1].ReplyToServerMsgID != parent.Stored.ServerMsgID {
+		t.Fatalf("expected reply_to in history, got %q", out.Messages[1].ReplyToServerMsgID)
+	}
+}
+
+func TestPostgresStore_ConcurrentAppend_StrictSeq_NoGaps(t *testing.T) {
+	t.Parallel()
+
+	pool := mustOpenTestPool(t)
+	defer pool.Close()
+
+	schema := migrationstest.NewSchema(t, pool)
+
+	store := mustNewStore(t, pool, schema)
+	mustCreateTestSessions(t, pool, schema, "user-a", "session-a")
+
 	ctx, cancel := context.WithTimeout(context.Background(), 45*time.Second)
 	defer cancel()
 
@@ -190,7 +678,7 @@ func TestPostgresStore_ConcurrentAppend_StrictSeq_NoGaps(t *testing.T) {
 			_, err := store.AppendMessage(ctx, AppendMessageInput{
 				ConversationID: convID,
 				ClientMsgID:    fmt.Sprintf("cmsg-%d-%s", i, NewRandomHex(5)),
-				SenderSession:  "session-a",
+				SenderSession:  testID("session-a"),
 				Text:           fmt.Sprintf("m%d", i),
 				Now:            time.Now().UTC(),
 			})
@@ -246,7 +734,45 @@ func TestPostgresStore_ConcurrentAppend_StrictSeq_NoGaps(t *testing.T) {
 
 // ---- test helpers ----
 
-func mustNewStore(t *testing.T, pool *pgxpool.Pool, schema string) *PostgresStore {
+// BenchmarkPostgresStore_FetchHistory measures a history page read. The
+// cache_statement pool runs it as a named prepared statement; exec and
+// simple_protocol are the unprepared modes used behind transaction-pooling proxies.
+func BenchmarkPostgresStore_FetchHistory(b *testing.B) {
+	for _, mode := range []pgx.QueryExecMode{pgx.QueryExecModeCacheStatement, pgx.QueryExecModeExec, pgx.QueryExecModeSimpleProtocol} {
+		b.Run(mode.String(), func(b *testing.B) {
+			pool := mustOpenTestPool(b, func(c *pgxpool.Config) { c.ConnConfig.DefaultQueryExecMode = mode })
+			defer pool.Close()
+
+			schema := migrationstest.NewSchema(b, pool)
+			mustCreateTestSessions(b, pool, schema, "user-a", "session-a")
+			store := mustNewStore(b, pool, schema)
+
+			ctx := context.Background()
+			convID := "bench-history-" + NewRandomHex(8)
+			for i := range 100 {
+				if _, err := store.AppendMessage(ctx, AppendMessageInput{
+					ConversationID: convID,
+					ClientMsgID:    fmt.Sprintf("cmsg-%d", i),
+					SenderSession:  testID("session-a"),
+					Text:           fmt.Sprintf("m%d", i),
+					Now:            time.Now().UTC(),
+				}); err != nil {
+					b.Fatalf("append %d: %v", i, err)
+				}
+			}
+
+			before := int64(math.MaxInt64)
+			b.ResetTimer()
+			for b.Loop() {
+				if _, err := store.FetchHistory(ctx, FetchHistoryInput{ConversationID: convID, BeforeSeq: &before, Limit: 50}); err != nil {
+					b.Fatalf("FetchHistory: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func mustNewStore(t testing.TB, pool *pgxpool.Pool, schema string) *PostgresStore {
 	t.Helper()
 
 	st, err := NewPostgresStore(pool, WithSchema(schema))
@@ -256,7 +782,7 @@ func mustNewStore(t *testing.T, pool *pgxpool.Pool, schema string) *PostgresStor
 	return st
 }
 
-func mustOpenTestPool(t *testing.T) *pgxpool.Pool {
+func mustOpenTestPool(t testing.TB, tune ...func(*pgxpool.Config)) *pgxpool.Pool {
 	t.Helper()
 
 	raw := strings.TrimSpace(os.Getenv("ARC_DATABASE_URL"))
@@ -271,6 +797,9 @@ func mustOpenTestPool(t *testing.T) *pgxpool.Pool {
 	if err != nil {
 		t.Fatalf("parse ARC_DATABASE_URL: %v", err)
 	}
+	for _, fn := range tune {
+		fn(cfg)
+	}
 
 	pool, err := pgxpool.NewWithConfig(ctx, cfg)
 	if err != nil {
@@ -294,83 +823,31 @@ func mustOpenTestPool(t *testing.T) *pgxpool.Pool {
 	return pool
 }
 
-func mustCreateTestSchema(t *testing.T, pool *pgxpool.Pool) string {
-	t.Helper()
-
-	schema := "arc_it_" + strings.ToLower(NewRandomHex(8))
-
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	if _, err := pool.Exec(ctx, `CREATE SCHEMA `+pgx.Identifier{schema}.Sanitize()); err != nil {
-		t.Fatalf("create schema: %v", err)
-	}
-	return schema
+// testID pads name to the 26 characters the users and sessions tables require of
+// their ULID ids, keeping fixtures readable.
+func testID(name string) string {
+	return name + strings.Repeat("0", 26-len(name))
 }
 
-func mustDropSchema(t *testing.T, pool *pgxpool.Pool, schema string) {
+// mustCreateTestSessions inserts user and one active session per name in sessions,
+// all under their testID, for messages to reference through sender_session.
+func mustCreateTestSessions(t testing.TB, pool *pgxpool.Pool, schema, user string, sessions ...string) {
 	t.Helper()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	_, _ = pool.Exec(ctx, `DROP SCHEMA IF EXISTS `+pgx.Identifier{schema}.Sanitize()+` CASCADE`)
-}
-
-func mustApplySchema(t *testing.T, pool *pgxpool.Pool, schema string) {
-	t.Helper()
-
-	ctx, cancel := context.WithTimeout(context.Background(), 12*time.Second)
-	defer cancel()
-
-	conversations := pgIdent(schema, "conversations")
-	cursors := pgIdent(schema, "conversation_cursors")
-	messages := pgIdent(schema, "messages")
-
-	// Minimal schema required by PostgresStore.
-	// Must remain semantically aligned with infra/db/atlas/schema.sql.
-	schemaSQL := fmt.Sprintf(`
-CREATE TABLE IF NOT EXISTS %s (
-  id         TEXT PRIMARY KEY,
-  kind       TEXT NOT NULL CHECK (kind IN ('direct', 'group', 'room')),
-  visibility TEXT NOT NULL DEFAULT 'private' CHECK (visibility IN ('public', 'private')),
-  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
-);
-
-CREATE TABLE IF NOT EXISTS %s (
-  conversation_id TEXT PRIMARY KEY REFERENCES %s(id) ON DELETE CASCADE,
-  next_seq        BIGINT NOT NULL DEFAULT 1,
-  updated_at      TIMESTAMPTZ NOT NULL DEFAULT now()
-);
-
-CREATE TABLE IF NOT EXISTS %s (
-  conversation_id TEXT NOT NULL REFERENCES %s(id) ON DELETE CASCADE,
-  seq             BIGINT NOT NULL,
-  server_msg_id   TEXT NOT NULL,
-  client_msg_id   TEXT NOT NULL,
-  sender_session  TEXT NOT NULL,
-  text            TEXT NOT NULL,
-  server_ts       TIMESTAMPTZ NOT NULL DEFAULT now(),
-  created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
-
-  PRIMARY KEY (conversation_id, seq),
-  CONSTRAINT uq_messages_conversation_client_msg UNIQUE (conversation_id, client_msg_id),
-  CONSTRAINT uq_messages_server_msg_id UNIQUE (server_msg_id),
-  CONSTRAINT chk_messages_text_len CHECK (char_length(text) > 0 AND char_length(text) <= 4096)
-);
-
-CREATE INDEX IF NOT EXISTS idx_messages_conversation_seq_asc
-  ON %s (conversation_id, seq ASC);
-
-CREATE INDEX IF NOT EXISTS idx_messages_conversation_seq_desc
-  ON %s (conversation_id, seq DESC);
-
-CREATE INDEX IF NOT EXISTS idx_messages_conversation_client_msg
-  ON %s (conversation_id, client_msg_id);
-`, conversations, cursors, conversations, messages, conversations, messages, messages, messages)
-
-	if _, err := pool.Exec(ctx, schemaSQL); err != nil {
-		t.Fatalf("apply schema: %v", err)
+	if _, err := pool.Exec(ctx, `INSERT INTO `+pgIdent(schema, "users")+` (id) VALUES ($1) ON CONFLICT DO NOTHING`, testID(user)); err != nil {
+		t.Fatalf("insert user %s: %v", user, err)
+	}
+	for _, name := range sessions {
+		id := testID(name)
+		if _, err := pool.Exec(ctx, `
+			INSERT INTO `+pgIdent(schema, "sessions")+` (id, user_id, refresh_token_hash, expires_at)
+			VALUES ($1, $2, encode(sha256(convert_to($1, 'UTF8')), 'hex'), now() + interval '1 hour')
+		`, id, testID(user)); err != nil {
+			t.Fatalf("insert session %s: %v", name, err)
+		}
 	}
 }
 
@@ -0,0 +1,351 @@
+package realtime
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"arc/cmd/internal/dbtrace"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrInvalidConversationCursor is returned when a /me/conversations page cursor cannot be decoded.
+var ErrInvalidConversationCursor = errors.New("realtime: invalid conversation cursor")
+
+// UserConversation is one row of a user's conversation list.
+type UserConversation struct {
+	ConversationID string
+	Kind           string
+	Visibility     string
+	Role           string
+	LastSeq        int64
+	LastReadSeq    int64
+	UnreadCount    int64
+	LatestMessage  *StoredMessage
+	Notifications  NotificationPrefs
+	// LastActivityAt is the latest message timestamp, or the join time for empty conversations.
+	LastActivityAt time.Time
+}
+
+// ConversationPageCursor is the keyset position after which the next page starts.
+// Pages are ordered by LastActivityAt DESC, ConversationID DESC.
+type ConversationPageCursor struct {
+	LastActivityAt time.Time `json:"t"`
+	ConversationID string    `json:"id"`
+}
+
+// ListUserConversationsInput selects one page of a user's conversations.
+type ListUserConversationsInput struct {
+	UserID string
+	After  *ConversationPageCursor
+	Limit  int
+}
+
+// ListUserConversationsOutput is one page of a user's conversations.
+type ListUserConversationsOutput struct {
+	Conversations []UserConversation
+	// Next is nil on the last page.
+	Next *ConversationPageCursor
+}
+
+// UserConversationLister is implemented by membership stores that can list a user's
+// conversations with read state.
+type UserConversationLister interface {
+	ListUserConversations(ctx context.Context, in ListUserConversationsInput) (ListUserConversationsOutput, error)
+}
+
+// UserConversationIDLister is implemented by membership stores that can list the
+// ids of a user's conversations, for inbox subscriptions.
+type UserConversationIDLister interface {
+	// UserConversationIDs returns up to limit conversation ids of userID, most
+	// recently active first.
+	UserConversationIDs(ctx context.Context, userID string, limit int) ([]string, error)
+}
+
+// ReadCursor is a member's read position after MarkRead.
+type ReadCursor struct {
+	ConversationID string
+	UserID         string
+	LastReadSeq    int64
+	// LastSeq is the latest message seq in the conversation at update time.
+	LastSeq   int64
+	UpdatedAt time.Time
+	// Advanced is false when the update did not move the cursor forward.
+	Advanced bool
+}
+
+// UnreadCount returns the number of messages after the cursor.
+func (c ReadCursor) UnreadCount() int64 {
+	return max(c.LastSeq-c.LastReadSeq, 0)
+}
+
+// ReadCursorStore persists per-user read positions.
+type ReadCursorStore interface {
+	// MarkRead advances userID's read cursor in conversationID to upToSeq.
+	// Cursors never move backwards and never pass the latest message.
+	MarkRead(ctx context.Context, userID, conversationID string, upToSeq int64) (ReadCursor, error)
+}
+
+// EncodeConversationCursor renders c as an opaque URL-safe token.
+func EncodeConversationCursor(c ConversationPageCursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeConversationCursor parses a token produced by EncodeConversationCursor.
+func DecodeConversationCursor(raw string) (ConversationPageCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(raw))
+	if err != nil {
+		return ConversationPageCursor{}, ErrInvalidConversationCursor
+	}
+	var c ConversationPageCursor
+	if err := json.Unmarshal(b, &c); err != nil || c.ConversationID == "" || c.LastActivityAt.IsZero() {
+		return ConversationPageCursor{}, ErrInvalidConversationCursor
+	}
+	return c, nil
+}
+
+// ListUserConversations returns a keyset-paginated page of the conversations userID belongs to.
+func (s *PostgresMembershipStore) ListUserConversations(ctx context.Context, in ListUserConversationsInput) (ListUserConversationsOutput, error) {
+	ctx = dbtrace.WithOp(ctx, "realtime.list_user_conversations")
+	if s == nil || s.pool == nil {
+		return ListUserConversationsOutput{}, errors.New("realtime: nil membership store")
+	}
+	userID := strings.TrimSpace(in.UserID)
+	if userID == "" {
+		return ListUserConversationsOutput{}, errors.New("realtime: missing user_id")
+	}
+	if in.Limit <= 0 {
+		return ListUserConversationsOutput{}, errors.New("realtime: invalid limit")
+	}
+	if err := ctx.Err(); err != nil {
+		return ListUserConversationsOutput{}, err
+	}
+
+	var (
+		afterTS *time.Time
+		afterID *string
+	)
+	if in.After != nil {
+		ts := in.After.LastActivityAt.UTC()
+		afterTS = &ts
+		afterID = &in.After.ConversationID
+	}
+
+	conversations := pgIdent(s.schema, "conversations")
+	members := pgIdent(s.schema, "conversation_members")
+	readCursors := pgIdent(s.schema, "conversation_read_cursors")
+	prefs := pgIdent(s.schema, "conversation_notification_prefs")
+	stats := pgIdent(s.schema, "conversation_stats")
+	messages := pgIdent(s.schema, "messages")
+
+	// Activity, last seq and the latest message come from conversation_stats; the
+	// latest message row is a primary-key lookup by its seq.
+	rows, err := s.pool.Query(ctx,
+		`SELECT c.id, c.kind, c.visibility, m.role,
+		        COALESCE(r.last_read_seq, 0),
+		        COALESCE(np.level, 'all'),
+		        CASE WHEN np.muted_until > now() THEN np.muted_until END,
+		        COALESCE(cs.last_seq, 0),
+		        lm.seq, lm.server_msg_id, lm.client_msg_id, COALESCE(lm.sender_session, ''), lm.text, lm.server_ts,
+		        lm.version, lm.edited_at, lm.deleted_at,
+		        COALESCE(cs.last_message_at, m.joined_at) AS activity_at
+		   FROM `+members+` m
+		   JOIN `+conversations+` c ON c.id = m.conversation_id
+		   LEFT JOIN `+stats+` cs ON cs.conversation_id = m.conversation_id
+		   LEFT JOIN `+messages+` lm
+		     ON lm.conversation_id = m.conversation_id AND lm.seq = cs.last_seq
+		   LEFT JOIN `+readCursors+` r
+		     ON r.conversation_id = m.conversation_id AND r.user_id = m.user_id
+		   LEFT JOIN `+prefs+` np
+		     ON np.conversation_id = m.conversation_id AND np.user_id = m.user_id
+		  WHERE m.user_id = $1
+		    AND ($2::timestamptz IS NULL
+		         OR (COALESCE(cs.last_message_at, m.joined_at), c.id) < ($2::timestamptz, $3::text))
+		  ORDER BY activity_at DESC, c.id DESC
+		  LIMIT $4`,
+		userID, afterTS, afterID, in.Limit+1,
+	)
+	if err != nil {
+		return ListUserConversationsOutput{}, err
+	}
+	defer rows.Close()
+
+	out := ListUserConversationsOutput{Conversations: make([]UserConversation, 0, in.Limit)}
+	for rows.Next() {
+		var (
+			uc          UserConversation
+			seq         *int64
+			serverMsgID *string
+			clientMsgID *string
+			sender      string
+			text        *string
+			serverTS    *time.Time
+			version     *int64
+			editedAt    *time.Time
+			deletedAt   *time.Time
+		)
+		if err := rows.Scan(
+			&uc.ConversationID, &uc.Kind, &uc.Visibility, &uc.Role,
+			&uc.LastReadSeq,
+			&uc.Notifications.Level, &uc.Notifications.MutedUntil,
+			&uc.LastSeq,
+			&seq, &serverMsgID, &clientMsgID, &sender, &text, &serverTS,
+			&version, &editedAt, &deletedAt,
+			&uc.LastActivityAt,
+		); err != nil {
+			return ListUserConversationsOutput{}, err
+		}
+		uc.Kind = normalizeConversationKind(uc.Kind)
+		// Retention may have removed the latest message while its seq still counts.
+		if seq != nil {
+			uc.LatestMessage = &StoredMessage{
+				ConversationID: uc.ConversationID,
+				ClientMsgID:    derefString(clientMsgID),
+				ServerMsgID:    derefString(serverMsgID),
+				Seq:            *seq,
+				SenderSession:  sender,
+				Text:           derefString(text),
+				ServerTS:       serverTS.UTC(),
+				Version:        *version,
+				EditedAt:       editedAt,
+				DeletedAt:      deletedAt,
+			}
+		}
+		uc.UnreadCount = max(uc.LastSeq-uc.LastReadSeq, 0)
+		if uc.Notifications.MutedUntil != nil {
+			mu := uc.Notifications.MutedUntil.UTC()
+			uc.Notifications.MutedUntil = &mu
+		}
+		uc.LastActivityAt = uc.LastActivityAt.UTC()
+		out.Conversations = append(out.Conversations, uc)
+	}
+	if err := rows.Err(); err != nil {
+		return ListUserConversationsOutput{}, err
+	}
+
+	if len(out.Conversations) > in.Limit {
+		out.Conversations = out.Conversations[:in.Limit]
+		last := out.Conversations[in.Limit-1]
+		out.Next = &ConversationPageCursor{
+			LastActivityAt: last.LastActivityAt,
+			ConversationID: last.ConversationID,
+		}
+	}
+	return out, nil
+}
+
+// UserConversationIDs returns up to limit ids of the conversations userID belongs
+// to, in /me/conversations order.
+func (s *PostgresMembershipStore) UserConversationIDs(ctx context.Context, userID string, limit int) ([]string, error) {
+	ctx = dbtrace.WithOp(ctx, "realtime.user_conversation_i_ds")
+	if s == nil || s.pool == nil {
+		return nil, errors.New("realtime: nil membership store")
+	}
+	userID = strings.TrimSpace(userID)
+	if userID == "" {
+		return nil, errors.New("realtime: missing user_id")
+	}
+	if limit <= 0 {
+		return nil, errors.New("realtime: invalid limit")
+	}
+
+	members := pgIdent(s.schema, "conversation_members")
+	stats := pgIdent(s.schema, "conversation_stats")
+	rows, err := s.pool.Query(ctx,
+		`SELECT m.conversation_id
+		   FROM `+members+` m
+		   LEFT JOIN `+stats+` cs ON cs.conversation_id = m.conversation_id
+		  WHERE m.user_id = $1
+		  ORDER BY COALESCE(cs.last_message_at, m.joined_at) DESC, m.conversation_id DESC
+		  LIMIT $2`,
+		userID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// MarkRead advances the read cursor of a member. Non-members get ErrMembershipRequired.
+func (s *PostgresMembershipStore) MarkRead(ctx context.Context, userID, conversationID string, upToSeq int64) (ReadCursor, error) {
+	ctx = dbtrace.WithOp(ctx, "realtime.mark_read")
+	if s == nil || s.pool == nil {
+		return ReadCursor{}, errors.New("realtime: nil membership store")
+	}
+	userID = strings.TrimSpace(userID)
+	conversationID = strings.TrimSpace(conversationID)
+	if userID == "" || conversationID == "" {
+		return ReadCursor{}, errors.New("realtime: missing user_id or conversation_id")
+	}
+	if upToSeq < 0 {
+		return ReadCursor{}, errors.New("realtime: invalid up_to_seq")
+	}
+	if err := ctx.Err(); err != nil {
+		return ReadCursor{}, err
+	}
+
+	members := pgIdent(s.schema, "conversation_members")
+	readCursors := pgIdent(s.schema, "conversation_read_cursors")
+	stats := pgIdent(s.schema, "conversation_stats")
+
+	out := ReadCursor{ConversationID: conversationID, UserID: userID}
+	var prev int64
+	err := s.pool.QueryRow(ctx,
+		`WITH latest AS (
+		     SELECT COALESCE(max(last_seq), 0) AS seq FROM `+stats+` WHERE conversation_id = $1
+		 ), prev AS (
+		     SELECT last_read_seq FROM `+readCursors+` WHERE conversation_id = $1 AND user_id = $2
+		 ), up AS (
+		     INSERT INTO `+readCursors+` (conversation_id, user_id, last_read_seq, updated_at)
+		     SELECT m.conversation_id, m.user_id, LEAST($3::bigint, latest.seq), now()
+		       FROM `+members+` m, latest
+		      WHERE m.conversation_id = $1 AND m.user_id = $2
+		     ON CONFLICT (conversation_id, user_id) DO UPDATE
+		       SET last_read_seq = GREATEST(`+readCursors+`.last_read_seq, EXCLUDED.last_read_seq),
+		           updated_at = CASE
+		               WHEN EXCLUDED.last_read_seq > `+readCursors+`.last_read_seq THEN EXCLUDED.updated_at
+		               ELSE `+readCursors+`.updated_at
+		           END
+		     RETURNING last_read_seq, updated_at
+		 )
+		 SELECT up.last_read_seq, up.updated_at, latest.seq, COALESCE((SELECT last_read_seq FROM prev), 0)
+		   FROM up, latest`,
+		conversationID, userID, upToSeq,
+	).Scan(&out.LastReadSeq, &out.UpdatedAt, &out.LastSeq, &prev)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ReadCursor{}, ErrMembershipRequired
+	}
+	if err != nil {
+		return ReadCursor{}, err
+	}
+	out.UpdatedAt = out.UpdatedAt.UTC()
+	out.Advanced = out.LastReadSeq > prev
+	return out, nil
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+var (
+	_ UserConversationLister = (*PostgresMembershipStore)(nil)
+	_ ReadCursorStore        = (*PostgresMembershipStore)(nil)
+)
@@ -0,0 +1,33 @@
+package realtime
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestConversationCursor_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	in := ConversationPageCursor{
+		LastActivityAt: time.Date(2026, 1, 2, 3, 4, 5, 6000, time.UTC),
+		ConversationID: "conv-1",
+	}
+	got, err := DecodeConversationCursor(EncodeConversationCursor(in))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !got.LastActivityAt.Equal(in.LastActivityAt) || got.ConversationID != in.ConversationID {
+		t.Fatalf("round trip mismatch: got %+v want %+v", got, in)
+	}
+}
+
+func TestDecodeConversationCursor_RejectsGarbage(t *testing.T) {
+	t.Parallel()
+
+	for _, raw := range []string{"", "!!!", "e30", EncodeConversationCursor(ConversationPageCursor{ConversationID: "c"})} {
+		if _, err := DecodeConversationCursor(raw); !errors.Is(err, ErrInvalidConversationCursor) {
+			t.Fatalf("DecodeConversationCursor(%q): expected ErrInvalidConversationCursor, got %v", raw, err)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package realtime
+
+import (
+	"net/http"
+
+	"arc/cmd/internal/ipaccess"
+)
+
+// WithAccessPolicy refuses WebSocket upgrades and gRPC calls from clients p denies.
+// The client address is resolved under proxy, as in the auth API.
+func WithAccessPolicy(p *ipaccess.Policy, proxy ipaccess.ProxyConfig) GatewayOption {
+	return func(g *WSGateway) {
+		g.access = p
+		g.accessProxy = proxy
+	}
+}
+
+// accessAllowed checks the access lists for endpoint ("ws" or "grpc").
+func (g *WSGateway) accessAllowed(r *http.Request, endpoint string) bool {
+	if g.access == nil {
+		return true
+	}
+	return g.access.Allow(r.Context(), ipaccess.ClientIP(r, g.accessProxy), endpoint, r.UserAgent())
+}
@@ -0,0 +1,106 @@
+package realtime
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+type adminConnectionResponse struct {
+	UserID         string    `json:"user_id"`
+	SessionID      string    `json:"session_id"`
+	ConversationID string    `json:"conversation_id,omitempty"`
+	QueueDepth     int       `json:"queue_depth"`
+	Dropped        int64     `json:"dropped"`
+	ConnectedAt    time.Time `json:"connected_at"`
+	LastActivityAt time.Time `json:"last_activity_at"`
+	ClientVersion  string    `json:"client_version,omitempty"`
+	Platform       string    `json:"platform,omitempty"`
+	Capabilities   uint64    `json:"capabilities"`
+	Conversations  []string  `json:"conversation_ids"`
+}
+
+type adminConnectionsResponse struct {
+	Connections []adminConnectionResponse `json:"connections"`
+}
+
+// HandleAdminConnections serves GET /admin/ws/connections?user_id=&conversation_id=.
+//
+// It lists the authenticated sessions connected to this node; with several nodes
+// each one answers for its own connections.
+func (g *WSGateway) HandleAdminConnections(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeErrorHTTP(w, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+	if _, ok := g.requireAdminHTTP(w, r); !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	userID := strings.TrimSpace(q.Get("user_id"))
+	convID := strings.TrimSpace(q.Get("conversation_id"))
+
+	resp := adminConnectionsResponse{Connections: []adminConnectionResponse{}}
+	for _, c := range g.hub.Connections() {
+		if (userID != "" && c.UserID != userID) || (convID != "" && !slices.Contains(c.Conversations, convID)) {
+			continue
+		}
+		resp.Connections = append(resp.Connections, adminConnectionResponse{
+			UserID:         c.UserID,
+			SessionID:      c.SessionID,
+			ConversationID: c.ConversationID,
+			QueueDepth:     c.QueueDepth,
+			Dropped:        c.Dropped,
+			ConnectedAt:    c.ConnectedAt,
+			LastActivityAt: c.LastActivityAt,
+			ClientVersion:  c.Hello.Version,
+			Platform:       c.Hello.Platform,
+			Capabilities:   uint64(c.Hello.Capabilities),
+			Conversations:  c.Conversations,
+		})
+	}
+	writeJSONHTTP(w, http.StatusOK, resp)
+}
+
+// HandleAdminConnectionClose serves POST /admin/ws/connections/{session_id}/close.
+// The session's connection on this node is closed; the session itself stays valid
+// and the client may reconnect.
+func (g *WSGateway) HandleAdminConnectionClose(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeErrorHTTP(w, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+	adminID, ok := g.requireAdminHTTP(w, r)
+	if !ok {
+		return
+	}
+
+	sessionID := strings.TrimSpace(r.PathValue("session_id"))
+	if sessionID == "" || !g.hub.CloseSession(sessionID) {
+		writeErrorHTTP(w, http.StatusNotFound, "not_found", "connection not found")
+		return
+	}
+	g.log.Info("ws.admin.connection.close", "admin_user_id", adminID, "session_id", sessionID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// requireAdminHTTP authenticates the caller against ARC_AUTH_ADMIN_USER_IDS.
+func (g *WSGateway) requireAdminHTTP(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if g.auth == nil {
+		writeErrorHTTP(w, http.StatusServiceUnavailable, "auth_unavailable", "auth not configured")
+		return "", false
+	}
+	userID, ok := g.authenticateHTTP(w, r)
+	if !ok {
+		return "", false
+	}
+	if !slices.Contains(g.adminUserIDs, userID) {
+		writeErrorHTTP(w, http.StatusForbidden, "forbidden", "admin privileges required")
+		return "", false
+	}
+	return userID, true
+}
@@ -0,0 +1,42 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	v1 "arc/shared/contracts/realtime/v1"
+)
+
+// wsClockSkewWarn is the skew above which a session's clock is logged as off.
+const wsClockSkewWarn = 5 * time.Minute
+
+// clockSkewMS returns env's ts minus received in milliseconds, or nil when the
+// client sent no ts.
+func clockSkewMS(env v1.Envelope, received time.Time) *int64 {
+	if env.TS.IsZero() {
+		return nil
+	}
+	ms := env.TS.Sub(received).Milliseconds()
+	return &ms
+}
+
+// onTimeSync answers time.sync with the time the request was received, so clients
+// can correct their clock for display ordering.
+func (g *WSGateway) onTimeSync(ctx context.Context, client *Client, env v1.Envelope, received time.Time) error {
+	p := v1.TimeSyncPayload{ServerTS: received}
+	if skew := clockSkewMS(env, received); skew != nil {
+		ts := env.TS.UTC()
+		p.ClientTS = &ts
+		p.ClockSkewMS = skew
+		if d := time.Duration(*skew) * time.Millisecond; d > wsClockSkewWarn || d < -wsClockSkewWarn {
+			g.log.Info("ws.clock_skew", "session_id", client.SessionID, "skew_ms", *skew)
+		}
+	}
+	payload, _ := json.Marshal(p)
+	if !g.enqueue(ctx, client, mustNewEnvelope(v1.TypeTimeSync, payload, time.Now().UTC())) {
+		return errors.New("backpressure: time.sync")
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	v1 "arc/shared/contracts/realtime/v1"
+)
+
+func TestClockSkewMS(t *testing.T) {
+	t.Parallel()
+
+	received := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	if got := clockSkewMS(v1.Envelope{}, received); got != nil {
+		t.Fatalf("skew without ts = %d, want nil", *got)
+	}
+	if got := clockSkewMS(v1.Envelope{TS: received.Add(1500 * time.Millisecond)}, received); got == nil || *got != 1500 {
+		t.Fatalf("ahead skew = %v, want 1500", got)
+	}
+	if got := clockSkewMS(v1.Envelope{TS: received.Add(-2 * time.Second)}, received); got == nil || *got != -2000 {
+		t.Fatalf("behind skew = %v, want -2000", got)
+	}
+}
+
+func TestWSGateway_TimeSync_ReportsReceiveTimeAndSkew(t *testing.T) {
+	t.Parallel()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	g := NewWSGateway(log, NewHub(log), NewInMemoryStore(), nil, nil)
+	client := NewClient("u1", "s1", 8)
+
+	received := time.Now().UTC()
+	clientTS := received.Add(-3 * time.Second)
+	env := v1.Envelope{V: v1.Version, Type: v1.TypeTimeSync, ID: "e1", TS: clientTS}
+	if err := g.onTimeSync(context.Background(), client, env, received); err != nil {
+		t.Fatalf("time.sync: %v", err)
+	}
+
+	got := drainEnvelopes(client)
+	if len(got) != 1 || got[0].Type != v1.TypeTimeSync {
+		t.Fatalf("got %+v, want one time.sync", got)
+	}
+	var p v1.TimeSyncPayload
+	if err := json.Unmarshal(got[0].Payload, &p); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if !p.ServerTS.Equal(received) {
+		t.Fatalf("server_ts = %v, want %v", p.ServerTS, received)
+	}
+	if p.ClientTS == nil || !p.ClientTS.Equal(clientTS) {
+		t.Fatalf("client_ts = %v, want %v", p.ClientTS, clientTS)
+	}
+	if p.ClockSkewMS == nil || *p.ClockSkewMS != -3000 {
+		t.Fatalf("clock_skew_ms = %v, want -3000", p.ClockSkewMS)
+	}
+}
@@ -0,0 +1,52 @@
+package realtime
+
+import (
+	"strings"
+
+	"github.com/coder/websocket"
+)
+
+// ARC_WS_COMPRESSION values.
+const (
+	wsCompressionOff               = "off"
+	wsCompressionNoContextTakeover = "no-context-takeover"
+	wsCompressionContextTakeover   = "context-takeover"
+
+	// wsDefaultCompressionThreshold keeps small events (acks, presence, typing)
+	// uncompressed; deflate overhead outweighs the savings below ~512 bytes.
+	wsDefaultCompressionThreshold = 512
+
+	// wsDefaultCompressionContextConns bounds connections holding a sliding window.
+	wsDefaultCompressionContextConns = 1000
+)
+
+// parseCompressionMode maps ARC_WS_COMPRESSION to a websocket compression mode.
+// "on" is an alias for no-context-takeover; unknown values disable compression.
+func parseCompressionMode(v string) websocket.CompressionMode {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "on", "true", wsCompressionNoContextTakeover, "no_context_takeover":
+		return websocket.CompressionNoContextTakeover
+	case wsCompressionContextTakeover, "context_takeover":
+		return websocket.CompressionContextTakeover
+	default:
+		return websocket.CompressionDisabled
+	}
+}
+
+// acceptCompression picks the compression mode for a new connection and returns
+// a release func to call when it closes.
+//
+// Context takeover keeps a deflate sliding window per connection and direction
+// (tens of KiB each), so only ARC_WS_COMPRESSION_CONTEXT_CONNS connections get it; the
+// rest fall back to no-context-takeover, which compresses each message alone.
+func (g *WSGateway) acceptCompression() (websocket.CompressionMode, func()) {
+	if g.compression != websocket.CompressionContextTakeover {
+		return g.compression, func() {}
+	}
+
+	if n := g.compressionContextInUse.Add(1); g.compressionContextConns > 0 && n > int64(g.compressionContextConns) {
+		g.compressionContextInUse.Add(-1)
+		return websocket.CompressionNoContextTakeover, func() {}
+	}
+	return websocket.CompressionContextTakeover, func() { g.compressionContextInUse.Add(-1) }
+}
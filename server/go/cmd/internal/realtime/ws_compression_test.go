@@ -0,0 +1,53 @@
+package realtime
+
+import (
+	"testing"
+
+	"github.com/coder/websocket"
+)
+
+func TestParseCompressionMode(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]websocket.CompressionMode{
+		"":                    websocket.CompressionDisabled,
+		"off":                 websocket.CompressionDisabled,
+		"bogus":               websocket.CompressionDisabled,
+		"on":                  websocket.CompressionNoContextTakeover,
+		"no-context-takeover": websocket.CompressionNoContextTakeover,
+		" Context-Takeover ":  websocket.CompressionContextTakeover,
+	}
+	for in, want := range tests {
+		if got := parseCompressionMode(in); got != want {
+			t.Fatalf("%q: expected %v, got %v", in, want, got)
+		}
+	}
+}
+
+func TestWSGateway_AcceptCompression_LimitsContextTakeover(t *testing.T) {
+	t.Parallel()
+
+	g := &WSGateway{compression: websocket.CompressionContextTakeover, compressionContextConns: 2}
+
+	m1, r1 := g.acceptCompression()
+	m2, r2 := g.acceptCompression()
+	m3, r3 := g.acceptCompression()
+	if m1 != websocket.CompressionContextTakeover || m2 != websocket.CompressionContextTakeover {
+		t.Fatalf("expected first two connections to get context takeover")
+	}
+	if m3 != websocket.CompressionNoContextTakeover {
+		t.Fatalf("expected third connection to fall back to no-context-takeover, got %v", m3)
+	}
+
+	r3()
+	r1()
+	if m, _ := g.acceptCompression(); m != websocket.CompressionContextTakeover {
+		t.Fatalf("expected a released slot to be reused, got %v", m)
+	}
+	r2()
+
+	g = &WSGateway{compression: websocket.CompressionDisabled}
+	if m, _ := g.acceptCompression(); m != websocket.CompressionDisabled {
+		t.Fatalf("expected disabled compression to stay disabled")
+	}
+}
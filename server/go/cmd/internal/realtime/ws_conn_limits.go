@@ -0,0 +1,131 @@
+package realtime
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"arc/cmd/internal/config"
+	"arc/cmd/internal/ipaccess"
+)
+
+const (
+	wsDefaultMaxConnsPerUser    = 20
+	wsDefaultMaxConnsPerSession = 5
+	wsDefaultMaxConnsPerIP      = 200
+
+	// wsConnLimitRetryAfter is the Retry-After hint sent with a 429.
+	wsConnLimitRetryAfter = 30 * time.Second
+)
+
+// Limits a connection can exceed, as reported in rejections and metrics.
+const (
+	connLimitUser    = "user"
+	connLimitSession = "session"
+	connLimitIP      = "ip"
+)
+
+// connLimiter counts the open realtime sessions of this node per user, auth
+// session and client IP. An empty key is not counted.
+type connLimiter struct {
+	mu       sync.Mutex
+	users    map[string]int
+	sessions map[string]int
+	ips      map[string]int
+}
+
+// acquire reserves a slot for a connection, or returns the name of the first
+// limit it would exceed. A limit of 0 is unlimited.
+func (l *connLimiter) acquire(userID, sessionID, ip string, t *gatewayTunables) (release func(), limit string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	switch {
+	case exceedsConnLimit(l.users, userID, t.maxConnsPerUser):
+		return nil, connLimitUser
+	case exceedsConnLimit(l.sessions, sessionID, t.maxConnsPerSess):
+		return nil, connLimitSession
+	case exceedsConnLimit(l.ips, ip, t.maxConnsPerIP):
+		return nil, connLimitIP
+	}
+
+	if l.users == nil {
+		l.users = make(map[string]int)
+		l.sessions = make(map[string]int)
+		l.ips = make(map[string]int)
+	}
+	incConnCount(l.users, userID)
+	incConnCount(l.sessions, sessionID)
+	incConnCount(l.ips, ip)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			decConnCount(l.users, userID)
+			decConnCount(l.sessions, sessionID)
+			decConnCount(l.ips, ip)
+		})
+	}, ""
+}
+
+func exceedsConnLimit(counts map[string]int, key string, limit int) bool {
+	return key != "" && limit > 0 && counts[key] >= limit
+}
+
+func incConnCount(counts map[string]int, key string) {
+	if key != "" {
+		counts[key]++
+	}
+}
+
+func decConnCount(counts map[string]int, key string) {
+	if key == "" {
+		return
+	}
+	if counts[key] <= 1 {
+		delete(counts, key)
+		return
+	}
+	counts[key]--
+}
+
+// acquireConn applies the connection limits to a new session. Anonymous sessions
+// are only limited per IP.
+func (g *WSGateway) acquireConn(r *http.Request, userID, sessionID string) (release func(), limit string) {
+	var ip string
+	if addr := ipaccess.ClientIP(r, g.accessProxy); addr != nil {
+		ip = addr.String()
+	}
+	if userID == "" {
+		sessionID = ""
+	}
+	release, limit = g.connLimits.acquire(userID, sessionID, ip, g.tunables.Load())
+	if limit != "" {
+		wsConnLimitRejected.With(limit).Inc()
+		g.log.Info("ws.reject.conn_limit", "limit", limit, "user_id", userID, "ip", ip)
+	}
+	return release, limit
+}
+
+// rejectConnLimit answers 429 with a Retry-After hint naming the exceeded limit.
+func rejectConnLimit(w http.ResponseWriter, limit string) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(wsConnLimitRetryAfter/time.Second)))
+	http.Error(w, "too many connections per "+limit, http.StatusTooManyRequests)
+}
+
+// envLimitWS reads a non-negative limit; 0 disables it.
+func envLimitWS(lookup config.Lookup, key string, def int) int {
+	v := strings.TrimSpace(lookup.Get(key))
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return def
+	}
+	return n
+}
@@ -0,0 +1,126 @@
+package realtime
+
+import (
+	"arc/cmd/internal/config"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+)
+
+func TestConnLimiter_Acquire(t *testing.T) {
+	var l connLimiter
+	tun := &gatewayTunables{maxConnsPerUser: 3, maxConnsPerSess: 2, maxConnsPerIP: 3}
+
+	r1, limit := l.acquire("u1", "s1", "192.0.2.1", tun)
+	if limit != "" {
+		t.Fatalf("first connection rejected: %s", limit)
+	}
+	if _, limit := l.acquire("u1", "s1", "192.0.2.1", tun); limit != "" {
+		t.Fatalf("second connection rejected: %s", limit)
+	}
+	if _, limit := l.acquire("u1", "s1", "192.0.2.1", tun); limit != connLimitSession {
+		t.Fatalf("expected session limit, got %q", limit)
+	}
+	if _, limit := l.acquire("u1", "s2", "192.0.2.1", tun); limit != "" {
+		t.Fatalf("other session rejected: %s", limit)
+	}
+	if _, limit := l.acquire("u1", "s3", "192.0.2.2", tun); limit != connLimitUser {
+		t.Fatalf("expected user limit, got %q", limit)
+	}
+	// Anonymous connections only count against the IP.
+	if _, limit := l.acquire("", "", "192.0.2.1", tun); limit != connLimitIP {
+		t.Fatalf("expected ip limit, got %q", limit)
+	}
+
+	r1()
+	r1()
+	if got := l.users["u1"]; got != 2 {
+		t.Fatalf("user count after double release = %d, want 2", got)
+	}
+	if _, limit := l.acquire("", "", "192.0.2.1", tun); limit != "" {
+		t.Fatalf("released slot not reused: %s", limit)
+	}
+
+	// 0 disables a limit.
+	unlimited := &gatewayTunables{}
+	for range 10 {
+		if _, limit := l.acquire("u1", "s1", "192.0.2.1", unlimited); limit != "" {
+			t.Fatalf("unlimited connection rejected: %s", limit)
+		}
+	}
+}
+
+func TestConnLimiter_ReleaseDropsKeys(t *testing.T) {
+	var l connLimiter
+	release, _ := l.acquire("u1", "s1", "192.0.2.1", &gatewayTunables{})
+	release()
+	if len(l.users) != 0 || len(l.sessions) != 0 || len(l.ips) != 0 {
+		t.Fatalf("expected empty counts, got %v %v %v", l.users, l.sessions, l.ips)
+	}
+}
+
+func TestHandleWS_ConnLimitPerIP(t *testing.T) {
+	t.Setenv("ARC_WS_REQUIRE_AUTH", "false")
+	t.Setenv("ARC_WS_ORIGIN_REQUIRED", "false")
+	t.Setenv("ARC_WS_MAX_CONNS_PER_IP", "1")
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	g := NewWSGateway(log, NewHub(log), NewInMemoryStore(), nil, nil)
+	defer g.stopReloading()
+
+	if _, limit := g.connLimits.acquire("", "", "192.0.2.1", g.tunables.Load()); limit != "" {
+		t.Fatalf("unexpected limit %q", limit)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/ws", nil)
+	req.RemoteAddr = "192.0.2.1:4000"
+	rec := httptest.NewRecorder()
+	g.HandleWS(rec, req)
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "30" {
+		t.Fatalf("expected 429 with Retry-After, got %d %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if !strings.Contains(rec.Body.String(), "per ip") {
+		t.Fatalf("expected the limit in the body, got %q", rec.Body.String())
+	}
+
+	// Raising the cap applies to the next upgrade.
+	t.Setenv("ARC_WS_MAX_CONNS_PER_IP", "0")
+	g.reloadTunables([]string{"ARC_WS_MAX_CONNS_PER_IP"})
+	rec = httptest.NewRecorder()
+	g.HandleWS(rec, req)
+	if rec.Code == http.StatusTooManyRequests {
+		t.Fatalf("expected the limit to be lifted after reload")
+	}
+}
+
+func TestHandleGRPC_StreamConnLimit(t *testing.T) {
+	t.Setenv("ARC_WS_MAX_CONNS_PER_IP", "1")
+	srv, g := newGRPCTestServer(t)
+
+	if _, limit := g.connLimits.acquire("", "", "127.0.0.1", g.tunables.Load()); limit != "" {
+		t.Fatalf("unexpected limit %q", limit)
+	}
+	client, _ := newGRPCClient(t, srv)
+	stream, err := client.Stream(grpcTestContext(t))
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	_, err = stream.Recv()
+	wantGRPCCode(t, err, codes.ResourceExhausted)
+}
+
+func TestEnvLimitWS(t *testing.T) {
+	t.Setenv("ARC_TEST_LIMIT", "0")
+	if n := envLimitWS(config.Env, "ARC_TEST_LIMIT", 5); n != 0 {
+		t.Fatalf("0 = %d, want 0", n)
+	}
+	t.Setenv("ARC_TEST_LIMIT", "-1")
+	if n := envLimitWS(config.Env, "ARC_TEST_LIMIT", 5); n != 5 {
+		t.Fatalf("-1 = %d, want default", n)
+	}
+}
@@ -0,0 +1,130 @@
+package realtime
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	meConversationsDefaultLimit = 50
+	meConversationsMaxLimit     = 200
+)
+
+type latestMessageResponse struct {
+	ServerMsgID string    `json:"server_msg_id"`
+	Seq         int64     `json:"seq"`
+	Sender      string    `json:"sender"`
+	Text        string    `json:"text"`
+	ServerTS    time.Time `json:"server_ts"`
+	Edited      bool      `json:"edited,omitempty"`
+	Deleted     bool      `json:"deleted,omitempty"`
+}
+
+type notificationPrefsResponse struct {
+	Level      string     `json:"level"`
+	MutedUntil *time.Time `json:"muted_until"`
+}
+
+type userConversationResponse struct {
+	ConversationID string                    `json:"conversation_id"`
+	Kind           string                    `json:"kind"`
+	Visibility     string                    `json:"visibility"`
+	Role           string                    `json:"role"`
+	LastSeq        int64                     `json:"last_seq"`
+	LastReadSeq    int64                     `json:"last_read_seq"`
+	UnreadCount    int64                     `json:"unread_count"`
+	LatestMessage  *latestMessageResponse    `json:"latest_message,omitempty"`
+	Notifications  notificationPrefsResponse `json:"notifications"`
+	LastActivityAt time.Time                 `json:"last_activity_at"`
+}
+
+type userConversationsResponse struct {
+	Conversations []userConversationResponse `json:"conversations"`
+	NextCursor    string                     `json:"next_cursor,omitempty"`
+}
+
+// HandleMyConversations serves GET /me/conversations?limit=&cursor=.
+//
+// Conversations are ordered by latest activity, newest first. next_cursor is
+// returned while more pages remain and is passed back verbatim as cursor.
+func (g *WSGateway) HandleMyConversations(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeErrorHTTP(w, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+	lister, ok := g.members.(UserConversationLister)
+	if g.auth == nil || !ok {
+		writeErrorHTTP(w, http.StatusServiceUnavailable, "conversations_unavailable", "conversation listing not configured")
+		return
+	}
+	userID, ok := g.authenticateHTTP(w, r)
+	if !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	limit := meConversationsDefaultLimit
+	if raw := strings.TrimSpace(q.Get("limit")); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			writeErrorHTTP(w, http.StatusBadRequest, "invalid_request", "invalid limit")
+			return
+		}
+		limit = min(n, meConversationsMaxLimit)
+	}
+
+	in := ListUserConversationsInput{UserID: userID, Limit: limit}
+	if raw := strings.TrimSpace(q.Get("cursor")); raw != "" {
+		c, err := DecodeConversationCursor(raw)
+		if err != nil {
+			writeErrorHTTP(w, http.StatusBadRequest, "invalid_cursor", "invalid cursor")
+			return
+		}
+		in.After = &c
+	}
+
+	out, err := lister.ListUserConversations(r.Context(), in)
+	if err != nil {
+		g.log.Error("me.conversations.list.fail", "err", err)
+		writeErrorHTTP(w, http.StatusInternalServerError, "internal_error", "internal error")
+		return
+	}
+
+	resp := userConversationsResponse{Conversations: make([]userConversationResponse, 0, len(out.Conversations))}
+	for _, c := range out.Conversations {
+		item := userConversationResponse{
+			ConversationID: c.ConversationID,
+			Kind:           c.Kind,
+			Visibility:     c.Visibility,
+			Role:           c.Role,
+			LastSeq:        c.LastSeq,
+			LastReadSeq:    c.LastReadSeq,
+			UnreadCount:    c.UnreadCount,
+			LastActivityAt: c.LastActivityAt,
+			Notifications: notificationPrefsResponse{
+				Level:      c.Notifications.Level,
+				MutedUntil: c.Notifications.MutedUntil,
+			},
+		}
+		if m := c.LatestMessage; m != nil {
+			item.LatestMessage = &latestMessageResponse{
+				ServerMsgID: m.ServerMsgID,
+				Seq:         m.Seq,
+				Sender:      m.SenderSession,
+				Text:        m.Text,
+				ServerTS:    m.ServerTS,
+				Edited:      m.EditedAt != nil,
+				Deleted:     m.Deleted(),
+			}
+		}
+		resp.Conversations = append(resp.Conversations, item)
+	}
+	if out.Next != nil {
+		resp.NextCursor = EncodeConversationCursor(*out.Next)
+	}
+
+	writeJSONHTTP(w, http.StatusOK, resp)
+}
@@ -0,0 +1,190 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"arc/cmd/internal/dbroute"
+	v1 "arc/shared/contracts/realtime/v1"
+)
+
+type messageReceiptResponse struct {
+	UserID    string `json:"user_id"`
+	Delivered bool   `json:"delivered"`
+	Read      bool   `json:"read"`
+}
+
+type messageReceiptsResponse struct {
+	ConversationID string                   `json:"conversation_id"`
+	ServerMsgID    string                   `json:"server_msg_id"`
+	Seq            int64                    `json:"seq"`
+	DeliveredCount int                      `json:"delivered_count"`
+	ReadCount      int                      `json:"read_count"`
+	Receipts       []messageReceiptResponse `json:"receipts"`
+}
+
+// onMessageDelivered advances the caller's delivery cursor. It sends no reply;
+// delivery state is exposed through HandleMessageReceipts.
+func (g *WSGateway) onMessageDelivered(ctx context.Context, client *Client, env v1.Envelope) error {
+	if err := g.requireAuthenticatedClient(client); err != nil {
+		return err
+	}
+	if client.UserID == "" {
+		return errUnauthorized
+	}
+	deliveries, ok := g.members.(DeliveryStore)
+	if !ok {
+		return errors.New("delivery receipts not configured")
+	}
+
+	var p v1.MessageDeliveredPayload
+	if err := json.Unmarshal(env.Payload, &p); err != nil {
+		return fmt.Errorf("invalid payload: %w", err)
+	}
+
+	convID := strings.TrimSpace(p.ConversationID)
+	if convID == "" {
+		return errMissingConversationID
+	}
+	if p.UpToSeq < 0 {
+		return errors.New("invalid up_to_seq")
+	}
+
+	_, err := deliveries.MarkDelivered(ctx, client.UserID, convID, p.UpToSeq)
+	if errors.Is(err, ErrMembershipRequired) {
+		return errNotMember
+	}
+	return err
+}
+
+// redeliver replays messages after the user's delivery cursor once they join
+// convID, retrying fanout their devices missed while disconnected.
+//
+// Only users who acknowledge delivery have a cursor, so clients without
+// message.delivered support are unaffected. Cursors more than g.resumeWindow
+// messages behind are not replayed; clients fetch history instead. Lookup
+// failures are logged and skipped; only backpressure is returned.
+func (g *WSGateway) redeliver(ctx context.Context, client *Client, convID string) error {
+	// onJoin sealed the lane: replays are released ahead of the live fanout it held.
+	var replayAfter int64
+	defer func() { client.UnsealLane(convID, replayAfter) }()
+
+	if client.UserID == "" {
+		return nil
+	}
+	deliveries, ok := g.members.(DeliveryStore)
+	if !ok {
+		return nil
+	}
+
+	after, ok, err := deliveries.LastDelivered(ctx, client.UserID, convID)
+	if err != nil {
+		g.log.Error("ws.redeliver.cursor.fail", "session_id", client.SessionID, "conversation_id", convID, "err", err)
+		return nil
+	}
+	if !ok {
+		return nil
+	}
+
+	out, err := g.store.FetchHistory(dbroute.RequirePrimary(ctx), FetchHistoryInput{
+		ConversationID: convID,
+		AfterSeq:       &after,
+		Limit:          g.resumeWindow,
+	})
+	if err != nil {
+		g.log.Error("ws.redeliver.fetch.fail", "session_id", client.SessionID, "conversation_id", convID, "err", err)
+		return nil
+	}
+	if out.HasMore {
+		g.log.Info("ws.redeliver.skip", "session_id", client.SessionID, "conversation_id", convID, "after_seq", after)
+		return nil
+	}
+
+	replayAfter = after
+	now := time.Now().UTC()
+	for _, m := range out.Messages {
+		payload, _ := json.Marshal(messagePayload(m))
+		if !g.enqueue(ctx, client, withSeq(mustNewEnvelope(v1.TypeMessageNew, payload, now), m.ConversationID, m.Seq)) {
+			return errors.New("backpressure: redelivery")
+		}
+	}
+	return nil
+}
+
+// HandleMessageReceipts serves GET /conversations/{id}/messages/{msg_id}/receipts.
+//
+// msg_id is the server_msg_id. Any member may read receipts; non-members get 404
+// so private conversations are not disclosed. Delivery is tracked per user: a
+// message counts as delivered once any of the user's devices acknowledged it.
+func (g *WSGateway) HandleMessageReceipts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeErrorHTTP(w, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+	deliveries, ok := g.members.(DeliveryStore)
+	if g.auth == nil || !ok {
+		writeErrorHTTP(w, http.StatusServiceUnavailable, "receipts_unavailable", "delivery receipts not configured")
+		return
+	}
+	userID, ok := g.authenticateHTTP(w, r)
+	if !ok {
+		return
+	}
+
+	convID := strings.TrimSpace(r.PathValue("id"))
+	msgID := strings.TrimSpace(r.PathValue("msg_id"))
+	if convID == "" || msgID == "" {
+		writeErrorHTTP(w, http.StatusBadRequest, "invalid_request", "conversation id and message id are required")
+		return
+	}
+
+	switch err := g.members.EnsureMember(r.Context(), userID, convID); {
+	case err == nil:
+	case errors.Is(err, ErrMembershipRequired), errors.Is(err, ErrConversationNotFound):
+		writeErrorHTTP(w, http.StatusNotFound, "conversation_not_found", "conversation not found")
+		return
+	default:
+		g.log.Error("message.receipts.member.fail", "conversation_id", convID, "err", err)
+		writeErrorHTTP(w, http.StatusInternalServerError, "internal_error", "internal error")
+		return
+	}
+
+	out, err := deliveries.MessageReceipts(r.Context(), convID, msgID)
+	if errors.Is(err, ErrMessageNotFound) {
+		writeErrorHTTP(w, http.StatusNotFound, "message_not_found", "message not found")
+		return
+	}
+	if err != nil {
+		g.log.Error("message.receipts.fail", "conversation_id", convID, "err", err)
+		writeErrorHTTP(w, http.StatusInternalServerError, "internal_error", "internal error")
+		return
+	}
+
+	resp := messageReceiptsResponse{
+		ConversationID: out.ConversationID,
+		ServerMsgID:    out.ServerMsgID,
+		Seq:            out.Seq,
+		Receipts:       make([]messageReceiptResponse, 0, len(out.Receipts)),
+	}
+	for _, rc := range out.Receipts {
+		if rc.Delivered {
+			resp.DeliveredCount++
+		}
+		if rc.Read {
+			resp.ReadCount++
+		}
+		resp.Receipts = append(resp.Receipts, messageReceiptResponse{
+			UserID:    rc.UserID,
+			Delivered: rc.Delivered,
+			Read:      rc.Read,
+		})
+	}
+
+	writeJSONHTTP(w, http.StatusOK, resp)
+}
@@ -0,0 +1,186 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	v1 "arc/shared/contracts/realtime/v1"
+)
+
+// fakeDeliveryStore is a MembershipStore with delivery cursors where every user
+// is a member of every conversation.
+type fakeDeliveryStore struct {
+	mu      sync.Mutex
+	cursors map[string]int64
+}
+
+func newFakeDeliveryStore() *fakeDeliveryStore {
+	return &fakeDeliveryStore{cursors: make(map[string]int64)}
+}
+
+func (s *fakeDeliveryStore) GetConversation(_ context.Context, conversationID string) (ConversationInfo, error) {
+	return ConversationInfo{ID: conversationID, Kind: "group", Visibility: conversationVisibilityPrivate}, nil
+}
+
+func (s *fakeDeliveryStore) IsMember(context.Context, string, string) (bool, error) { return true, nil }
+
+func (s *fakeDeliveryStore) EnsureMember(context.Context, string, string) error { return nil }
+
+func (s *fakeDeliveryStore) AddMember(context.Context, string, string) error { return nil }
+
+func (s *fakeDeliveryStore) MarkDelivered(_ context.Context, userID, conversationID string, upToSeq int64) (DeliveryCursor, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := conversationID + "/" + userID
+	prev := s.cursors[key]
+	s.cursors[key] = max(prev, upToSeq)
+	return DeliveryCursor{
+		ConversationID:   conversationID,
+		UserID:           userID,
+		LastDeliveredSeq: s.cursors[key],
+		Advanced:         upToSeq > prev,
+	}, nil
+}
+
+func (s *fakeDeliveryStore) LastDelivered(_ context.Context, userID, conversationID string) (int64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	seq, ok := s.cursors[conversationID+"/"+userID]
+	return seq, ok, nil
+}
+
+func (s *fakeDeliveryStore) MessageReceipts(context.Context, string, string) (MessageReceipts, error) {
+	return MessageReceipts{}, ErrMessageNotFound
+}
+
+var (
+	_ MembershipStore = (*fakeDeliveryStore)(nil)
+	_ DeliveryStore   = (*fakeDeliveryStore)(nil)
+)
+
+func newDeliveredEnvelope(t *testing.T, convID string, upToSeq int64) v1.Envelope {
+	t.Helper()
+	p, err := json.Marshal(v1.MessageDeliveredPayload{ConversationID: convID, UpToSeq: upToSeq})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return mustNewEnvelope(v1.TypeMessageDelivered, p, time.Now().UTC())
+}
+
+func TestWSGateway_MessageDelivered(t *testing.T) {
+	t.Parallel()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	deliveries := newFakeDeliveryStore()
+	g := NewWSGateway(log, NewHub(log), NewInMemoryStore(), nil, deliveries)
+	ctx := context.Background()
+	client := NewClient("u1", "s1", 8)
+
+	if err := g.onMessageDelivered(ctx, client, newDeliveredEnvelope(t, "c1", 3)); err != nil {
+		t.Fatalf("delivered: %v", err)
+	}
+	if seq, ok, _ := deliveries.LastDelivered(ctx, "u1", "c1"); !ok || seq != 3 {
+		t.Fatalf("expected cursor 3, got %d (ok=%v)", seq, ok)
+	}
+	if got := drainEnvelopes(client); len(got) != 0 {
+		t.Fatalf("expected no reply, got %+v", got)
+	}
+
+	for name, env := range map[string]v1.Envelope{
+		"missing conversation": newDeliveredEnvelope(t, " ", 1),
+		"negative seq":         newDeliveredEnvelope(t, "c1", -1),
+	} {
+		if err := g.onMessageDelivered(ctx, client, env); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+	if err := g.onMessageDelivered(ctx, NewClient("", "s2", 8), newDeliveredEnvelope(t, "c1", 1)); err == nil {
+		t.Fatalf("expected anonymous client to be rejected")
+	}
+
+	plain := NewWSGateway(log, NewHub(log), NewInMemoryStore(), nil, nil)
+	if err := plain.onMessageDelivered(ctx, client, newDeliveredEnvelope(t, "c1", 1)); err == nil {
+		t.Fatalf("expected error without a delivery store")
+	}
+}
+
+func TestWSGateway_Redeliver(t *testing.T) {
+	t.Parallel()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	store := NewInMemoryStore()
+	deliveries := newFakeDeliveryStore()
+	g := NewWSGateway(log, NewHub(log), store, nil, deliveries)
+	g.resumeWindow = 3
+	ctx := context.Background()
+
+	for i := 1; i <= 4; i++ {
+		if _, err := store.AppendMessage(ctx, AppendMessageInput{ConversationID: "c1", ClientMsgID: fmt.Sprint(i), SenderSession: "s0", Text: "m"}); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+	}
+
+	// Users who never acked delivery get nothing replayed.
+	client := NewClient("u1", "s1", 16)
+	if err := g.redeliver(ctx, client, "c1"); err != nil {
+		t.Fatalf("redeliver: %v", err)
+	}
+	if got := drainEnvelopes(client); len(got) != 0 {
+		t.Fatalf("expected no redelivery without a cursor, got %d envelopes", len(got))
+	}
+
+	// Cursors beyond the window are skipped.
+	_, _ = deliveries.MarkDelivered(ctx, "u1", "c1", 0)
+	if err := g.redeliver(ctx, client, "c1"); err != nil {
+		t.Fatalf("redeliver: %v", err)
+	}
+	if got := drainEnvelopes(client); len(got) != 0 {
+		t.Fatalf("expected no redelivery beyond the window, got %d envelopes", len(got))
+	}
+
+	_, _ = deliveries.MarkDelivered(ctx, "u1", "c1", 2)
+	if err := g.redeliver(ctx, client, "c1"); err != nil {
+		t.Fatalf("redeliver: %v", err)
+	}
+	got := drainEnvelopes(client)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 redelivered messages, got %d", len(got))
+	}
+	for i, env := range got {
+		var p v1.MessageNewPayload
+		_ = json.Unmarshal(env.Payload, &p)
+		if env.Type != v1.TypeMessageNew || p.Seq != int64(i+3) {
+			t.Fatalf("envelope %d: unexpected %s seq=%d", i, env.Type, p.Seq)
+		}
+	}
+}
+
+func TestHandleMessageReceipts_Unavailable(t *testing.T) {
+	t.Parallel()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	g := NewWSGateway(log, NewHub(log), NewInMemoryStore(), nil, newFakeDeliveryStore())
+
+	req := httptest.NewRequest(http.MethodGet, "/conversations/c1/messages/m1/receipts", nil)
+	req.SetPathValue("id", "c1")
+	req.SetPathValue("msg_id", "m1")
+	rec := httptest.NewRecorder()
+	g.HandleMessageReceipts(rec, req)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 without auth, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	g.HandleMessageReceipts(rec, httptest.NewRequest(http.MethodPost, "/conversations/c1/messages/m1/receipts", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,78 @@
+package realtime
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+type directConversationRequest struct {
+	UserID string `json:"user_id"`
+}
+
+type directConversationResponse struct {
+	ConversationID string `json:"conversation_id"`
+	Kind           string `json:"kind"`
+	UserID         string `json:"user_id"`
+	Created        bool   `json:"created"`
+}
+
+// HandleDirectConversations serves POST /conversations/direct.
+//
+// It returns the caller's private 1:1 conversation with user_id, creating it on
+// first use (201) and returning the existing one afterwards (200). The caller can
+// join the returned conversation_id over the WebSocket right away.
+func (g *WSGateway) HandleDirectConversations(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeErrorHTTP(w, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+	store, ok := g.members.(DirectConversationStore)
+	if g.auth == nil || !ok {
+		writeErrorHTTP(w, http.StatusServiceUnavailable, "conversations_unavailable", "direct conversations not configured")
+		return
+	}
+	userID, ok := g.authenticateHTTP(w, r)
+	if !ok {
+		return
+	}
+
+	var req directConversationRequest
+	if err := decodeJSONHTTP(w, r, membersMaxBodyBytes, &req); err != nil {
+		writeErrorHTTP(w, http.StatusBadRequest, "invalid_json", "invalid JSON body")
+		return
+	}
+	peerID := strings.TrimSpace(req.UserID)
+	if peerID == "" {
+		writeErrorHTTP(w, http.StatusBadRequest, "invalid_request", "user_id is required")
+		return
+	}
+
+	dc, err := store.FindOrCreateDirectConversation(r.Context(), userID, peerID)
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrDirectConversationSelf):
+		writeErrorHTTP(w, http.StatusBadRequest, "invalid_request", "cannot start a direct conversation with yourself")
+		return
+	case errors.Is(err, ErrDirectConversationForbidden):
+		writeErrorHTTP(w, http.StatusForbidden, "forbidden", "cannot start a direct conversation with this user")
+		return
+	default:
+		g.log.Error("conversation.direct.fail", "user_id", userID, "err", err)
+		writeErrorHTTP(w, http.StatusInternalServerError, "internal_error", "internal error")
+		return
+	}
+
+	status := http.StatusOK
+	if dc.Created {
+		status = http.StatusCreated
+		g.log.Info("conversation.direct.created", "conversation_id", dc.ConversationID, "user_id", userID)
+	}
+	writeJSONHTTP(w, status, directConversationResponse{
+		ConversationID: dc.ConversationID,
+		Kind:           "direct",
+		UserID:         peerID,
+		Created:        dc.Created,
+	})
+}
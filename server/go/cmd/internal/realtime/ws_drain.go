@@ -0,0 +1,110 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	v1 "arc/shared/contracts/realtime/v1"
+
+	"github.com/coder/websocket"
+)
+
+const (
+	wsDefaultDrainTimeout    = 10 * time.Second
+	wsDefaultDrainRetryAfter = 5 * time.Second
+	wsDrainPollInterval      = 25 * time.Millisecond
+)
+
+// Drain gracefully ends every session on this node: new upgrades and streams are
+// refused with 503, connected clients receive server.shutdown with a jittered
+// reconnect hint, and each connection is closed with 1001 (going away) once its
+// queue is flushed or ARC_WS_DRAIN_TIMEOUT elapsed.
+//
+// Drain returns when all sessions ended, or with ctx's error when ctx is done first.
+// It is idempotent.
+func (g *WSGateway) Drain(ctx context.Context) error {
+	g.drainOnce.Do(func() {
+		g.draining.Store(true)
+		close(g.drainCh)
+		g.stopReloading()
+		g.log.Info("ws.drain.start", "sessions", g.activeSessions.Load(), "timeout", g.drainTimeout.String())
+	})
+
+	t := time.NewTicker(wsDrainPollInterval)
+	defer t.Stop()
+	for g.activeSessions.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			g.log.Warn("ws.drain.incomplete", "sessions", g.activeSessions.Load(), "err", ctx.Err())
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+	g.log.Info("ws.drain.done")
+	return nil
+}
+
+// DrainTimeout is the longest a session is kept open after Drain starts.
+func (g *WSGateway) DrainTimeout() time.Duration {
+	return g.drainTimeout
+}
+
+// Draining reports whether Drain was called.
+func (g *WSGateway) Draining() bool {
+	return g.draining.Load()
+}
+
+// rejectDraining answers 503 with a Retry-After hint while the node drains.
+func (g *WSGateway) rejectDraining(w http.ResponseWriter) bool {
+	if !g.draining.Load() {
+		return false
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(int(max(g.drainRetryAfter, time.Second)/time.Second)))
+	http.Error(w, "server shutting down", http.StatusServiceUnavailable)
+	return true
+}
+
+// drainSession announces the shutdown to client and closes the connection once the
+// client's queues are empty or the drain deadline passed.
+func (g *WSGateway) drainSession(ctx context.Context, client *Client, shutdown func(websocket.StatusCode, string)) {
+	var retryAfter time.Duration
+	if g.drainRetryAfter > 0 {
+		retryAfter = rand.N(g.drainRetryAfter) + 1
+	}
+	token, _ := g.issueResumeToken(client, time.Now().UTC())
+	payload, _ := json.Marshal(v1.ServerShutdownPayload{
+		RetryAfterMS: retryAfter.Milliseconds(),
+		DeadlineMS:   g.drainTimeout.Milliseconds(),
+		ResumeToken:  token,
+	})
+	g.enqueue(ctx, client, mustNewEnvelope(v1.TypeServerShutdown, payload, time.Now().UTC()))
+
+	deadline := time.NewTimer(g.drainTimeout)
+	defer deadline.Stop()
+	poll := time.NewTicker(wsDrainPollInterval)
+	defer poll.Stop()
+	for client.QueueDepth() > 0 {
+		select {
+		case <-ctx.Done():
+			return
+		case <-client.Done():
+			return
+		case <-deadline.C:
+			g.log.Info("ws.drain.deadline", "session_id", client.SessionID, "queued", client.QueueDepth())
+			shutdown(websocket.StatusGoingAway, "server shutting down")
+			return
+		case <-poll.C:
+		}
+	}
+	// Give the writer a moment to finish the envelope it dequeued last.
+	select {
+	case <-ctx.Done():
+		return
+	case <-time.After(wsDrainPollInterval):
+	}
+	shutdown(websocket.StatusGoingAway, "server shutting down")
+}
@@ -0,0 +1,111 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	v1 "arc/shared/contracts/realtime/v1"
+
+	"github.com/coder/websocket"
+)
+
+// fakeSessionConn blocks reads until closed and records writes and the close code.
+type fakeSessionConn struct {
+	mu      sync.Mutex
+	written []v1.Envelope
+	code    websocket.StatusCode
+	closed  chan struct{}
+	once    sync.Once
+}
+
+func newFakeSessionConn() *fakeSessionConn {
+	return &fakeSessionConn{closed: make(chan struct{})}
+}
+
+func (c *fakeSessionConn) ReadEnvelope(ctx context.Context) (v1.Envelope, error) {
+	select {
+	case <-ctx.Done():
+		return v1.Envelope{}, ctx.Err()
+	case <-c.closed:
+		return v1.Envelope{}, io.EOF
+	}
+}
+
+func (c *fakeSessionConn) WriteEnvelope(_ context.Context, env v1.Envelope) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.written = append(c.written, env)
+	return nil
+}
+
+func (c *fakeSessionConn) Ping(context.Context) error { return nil }
+
+func (c *fakeSessionConn) Close(code websocket.StatusCode, _ string) error {
+	c.once.Do(func() {
+		c.mu.Lock()
+		c.code = code
+		c.mu.Unlock()
+		close(c.closed)
+	})
+	return nil
+}
+
+func TestWSGateway_Drain_NotifiesFlushesAndCloses(t *testing.T) {
+	t.Setenv("ARC_WS_DRAIN_TIMEOUT", "2s")
+	t.Setenv("ARC_WS_DRAIN_RETRY_AFTER", "3s")
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	hub := NewHub(log)
+	g := NewWSGateway(log, hub, NewInMemoryStore(), nil, nil)
+
+	conn := newFakeSessionConn()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		g.runSession(context.Background(), conn, "u1", "s1")
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(hub.Connections()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("session did not register")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := g.Drain(ctx); err != nil {
+		t.Fatalf("drain: %v", err)
+	}
+	<-done
+
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	if conn.code != websocket.StatusGoingAway {
+		t.Fatalf("expected going away close, got %v", conn.code)
+	}
+	if len(conn.written) == 0 || conn.written[len(conn.written)-1].Type != v1.TypeServerShutdown {
+		t.Fatalf("expected server.shutdown to be written, got %+v", conn.written)
+	}
+	var p v1.ServerShutdownPayload
+	if err := json.Unmarshal(conn.written[len(conn.written)-1].Payload, &p); err != nil {
+		t.Fatalf("decode payload: %v", err)
+	}
+	if p.RetryAfterMS <= 0 || p.RetryAfterMS > 3000 || p.DeadlineMS != 2000 {
+		t.Fatalf("unexpected shutdown payload %+v", p)
+	}
+
+	rec := httptest.NewRecorder()
+	g.HandleWS(rec, httptest.NewRequest(http.MethodGet, "/ws", nil))
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") != "3" {
+		t.Fatalf("expected 503 with Retry-After while draining, got %d %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+}
@@ -0,0 +1,24 @@
+package realtime
+
+import (
+	"context"
+
+	"arc/cmd/internal/featureflags"
+)
+
+// WithFeatureFlags gates message edits and deletes (realtime.message_edits) and
+// presence (realtime.presence) on f. Requests for a disabled feature are answered
+// with a feature_disabled error; the connection stays open.
+func WithFeatureFlags(f *featureflags.Flags) GatewayOption {
+	return func(g *WSGateway) { g.flags = f }
+}
+
+// rejectDisabledFeature sends feature_disabled and reports true when flag is off.
+func (g *WSGateway) rejectDisabledFeature(ctx context.Context, client *Client, flag string) bool {
+	if g.flags.Enabled(ctx, flag) {
+		return false
+	}
+	wsFeatureDisabled.With(flag).Inc()
+	g.trySendError(ctx, client, "feature_disabled", flag+" is disabled")
+	return true
+}
@@ -0,0 +1,56 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"arc/cmd/internal/featureflags"
+	v1 "arc/shared/contracts/realtime/v1"
+
+	"github.com/coder/websocket"
+)
+
+func TestWSGateway_DisabledFeatureIsRefused(t *testing.T) {
+	t.Setenv("ARC_WS_REQUIRE_AUTH", "false")
+	t.Setenv("ARC_WS_ORIGIN_REQUIRED", "false")
+
+	flags := featureflags.New(featureflags.Config{Values: map[string]bool{featureflags.RealtimePresence: false}}, nil)
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	g := NewWSGateway(log, NewHub(log), NewInMemoryStore(), nil, nil, WithFeatureFlags(flags))
+	srv := httptest.NewServer(g)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, _, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(srv.URL, "http"), &websocket.DialOptions{Subprotocols: []string{wsSubprotocolV1}})
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer func() { _ = conn.Close(websocket.StatusNormalClosure, "") }()
+
+	payload, _ := json.Marshal(v1.PresenceSubscribePayload{UserIDs: []string{"u1"}})
+	frame, _ := json.Marshal(mustNewEnvelope(v1.TypePresenceSubscribe, payload, time.Now().UTC()))
+	if err := conn.Write(ctx, websocket.MessageText, frame); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	_, data, err := conn.Read(ctx)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	var env v1.Envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		t.Fatalf("decode envelope: %v", err)
+	}
+	var p v1.ErrorPayload
+	_ = json.Unmarshal(env.Payload, &p)
+	if env.Type != v1.TypeError || p.Code != "feature_disabled" {
+		t.Fatalf("got %s %+v, want feature_disabled error", env.Type, p)
+	}
+}
@@ -0,0 +1,309 @@
+package realtime
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"arc/cmd/internal/config"
+	"arc/cmd/internal/dbroute"
+	"arc/cmd/security/token"
+	"arc/shared/contracts/realtime/realtimepb"
+	v1 "arc/shared/contracts/realtime/v1"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	firehoseDefaultQueueSize  = 4096
+	firehoseDefaultCheckpoint = 5 * time.Second
+)
+
+// errFirehoseCursor rejects a firehose resume token that cannot be decoded.
+var errFirehoseCursor = errors.New("invalid resume_token")
+
+// firehoseConfig controls the integration event stream (gRPC Firehose).
+type firehoseConfig struct {
+	// tokenHashes are lowercase SHA-256 hex digests of accepted machine bearer
+	// tokens (ARC_FIREHOSE_TOKEN_SHA256). Empty disables the firehose.
+	tokenHashes []string
+	// convs are the streamed conversations (ARC_FIREHOSE_CONVERSATIONS); "*"
+	// streams every conversation.
+	convs    map[string]struct{}
+	allConvs bool
+
+	queueSize  int
+	checkpoint time.Duration
+}
+
+func loadFirehoseConfig(lookup config.Lookup) firehoseConfig {
+	cfg := firehoseConfig{
+		tokenHashes: parseFirehoseTokenHashes(lookup.Get("ARC_FIREHOSE_TOKEN_SHA256")),
+		convs:       make(map[string]struct{}),
+		queueSize:   envIntWS(lookup, "ARC_FIREHOSE_QUEUE_SIZE", firehoseDefaultQueueSize),
+		checkpoint:  envDurationWS(lookup, "ARC_FIREHOSE_CHECKPOINT_INTERVAL", firehoseDefaultCheckpoint),
+	}
+	for _, id := range envCSVWS(lookup, "ARC_FIREHOSE_CONVERSATIONS", "") {
+		if id == "*" {
+			cfg.allConvs = true
+			continue
+		}
+		cfg.convs[id] = struct{}{}
+	}
+	return cfg
+}
+
+// enabled reports whether a machine token and at least one conversation are configured.
+func (c firehoseConfig) enabled() bool {
+	return len(c.tokenHashes) > 0 && (c.allConvs || len(c.convs) > 0)
+}
+
+// streams reports whether conversationID is configured for the firehose.
+func (c firehoseConfig) streams(conversationID string) bool {
+	if c.allConvs {
+		return true
+	}
+	_, ok := c.convs[conversationID]
+	return ok
+}
+
+// authorized checks the machine bearer token against the configured digests.
+// Every digest is compared so timing does not reveal which credential matched.
+func (c firehoseConfig) authorized(r *http.Request) bool {
+	raw := bearerToken(r)
+	if raw == "" {
+		return false
+	}
+	sum := token.HashSHA256Hex(raw)
+	match := 0
+	for _, want := range c.tokenHashes {
+		match |= subtle.ConstantTimeCompare([]byte(sum), []byte(want))
+	}
+	return match == 1
+}
+
+// parseFirehoseTokenHashes keeps well-formed SHA-256 hex digests and drops everything else.
+func parseFirehoseTokenHashes(raw string) []string {
+	var out []string
+	for _, p := range strings.Split(raw, ",") {
+		v := strings.ToLower(strings.TrimSpace(p))
+		if len(v) != 64 {
+			continue
+		}
+		if _, err := hex.DecodeString(v); err != nil {
+			continue
+		}
+		out = append(out, v)
+	}
+	return out
+}
+
+// encodeFirehoseCursors returns the resume token for cursors: the last message
+// seq streamed per conversation. Tokens are not signed; they only select what
+// the machine credential may already read.
+func encodeFirehoseCursors(cursors map[string]int64) string {
+	b, _ := json.Marshal(cursors)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeFirehoseCursors(raw string) (map[string]int64, error) {
+	cursors := make(map[string]int64)
+	if strings.TrimSpace(raw) == "" {
+		return cursors, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(raw))
+	if err != nil {
+		return nil, errFirehoseCursor
+	}
+	if err := json.Unmarshal(b, &cursors); err != nil {
+		return nil, errFirehoseCursor
+	}
+	for id, seq := range cursors {
+		if id == "" || seq < 0 {
+			return nil, errFirehoseCursor
+		}
+	}
+	return cursors, nil
+}
+
+// firehoseStream writes one Firehose call's events.
+type firehoseStream struct {
+	g      *WSGateway
+	stream realtimepb.Realtime_FirehoseServer
+	rc     *http.ResponseController
+
+	// cursors is the last message seq written per conversation; replayed is the
+	// last seq replayed from the store, so live copies of it are skipped.
+	cursors  map[string]int64
+	replayed map[string]int64
+	dirty    bool
+}
+
+// serveGRPCFirehose streams message and membership events of the configured
+// conversations to an integration authenticated with a machine token.
+//
+// A resume token replays the messages stored after its cursors before live
+// events; membership events and edits missed while disconnected are not
+// replayed. A consumer that falls ARC_FIREHOSE_QUEUE_SIZE events behind is
+// disconnected with RESOURCE_EXHAUSTED and resumes from its last checkpoint.
+func (g *WSGateway) serveGRPCFirehose(req *grpcRequest, in *realtimepb.Envelope, stream realtimepb.Realtime_FirehoseServer) error {
+	if !g.firehose.enabled() {
+		return status.Error(codes.Unimplemented, "firehose not configured")
+	}
+	if !g.firehose.authorized(req.r) {
+		return status.Error(codes.Unauthenticated, "unauthorized")
+	}
+
+	env, err := realtimepb.ToV1(in)
+	if err == nil {
+		err = env.Validate()
+	}
+	if err == nil && env.Type != v1.TypeFirehoseSubscribe {
+		err = errors.New("expected type " + v1.TypeFirehoseSubscribe)
+	}
+	var p v1.FirehoseSubscribePayload
+	if err == nil && len(env.Payload) > 0 {
+		err = json.Unmarshal(env.Payload, &p)
+	}
+	var cursors map[string]int64
+	if err == nil {
+		cursors, err = decodeFirehoseCursors(p.ResumeToken)
+	}
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	maps.DeleteFunc(cursors, func(id string, _ int64) bool { return !g.firehose.streams(id) })
+
+	g.activeSessions.Add(1)
+	defer g.activeSessions.Add(-1)
+	wsFirehoseStreams.Inc()
+	defer wsFirehoseStreams.Dec()
+
+	// Live events queue up while the replay is written.
+	sub, cancel := g.hub.subscribeFirehose(g.firehose.streams, g.firehose.queueSize)
+	defer cancel()
+
+	_ = stream.SendHeader(nil)
+
+	s := &firehoseStream{g: g, stream: stream, rc: req.rc, cursors: cursors, replayed: make(map[string]int64)}
+	code, message := s.run(stream.Context(), sub)
+	if code == codes.OK {
+		return nil
+	}
+	g.log.Info("grpc.firehose.end", "code", code, "reason", message)
+	return status.Error(code, message)
+}
+
+func (s *firehoseStream) run(ctx context.Context, sub *firehoseSub) (codes.Code, string) {
+	for _, id := range slices.Sorted(maps.Keys(s.cursors)) {
+		if err := s.replay(ctx, id); err != nil {
+			return codes.Unavailable, err.Error()
+		}
+	}
+
+	t := time.NewTicker(s.g.firehose.checkpoint)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return codes.OK, ""
+		case <-s.g.drainCh:
+			_ = s.writeCheckpoint()
+			return codes.Unavailable, "server shutting down"
+		case <-sub.overflow:
+			return codes.ResourceExhausted, "firehose consumer too slow"
+		case ev := <-sub.events:
+			if err := s.writeEvent(ev); err != nil {
+				return codes.Unavailable, "write: " + err.Error()
+			}
+		case <-t.C:
+			if !s.dirty {
+				continue
+			}
+			if err := s.writeCheckpoint(); err != nil {
+				return codes.Unavailable, "write: " + err.Error()
+			}
+		}
+	}
+}
+
+// replay writes the stored messages of conversationID after its cursor, then a checkpoint.
+func (s *firehoseStream) replay(ctx context.Context, conversationID string) error {
+	for {
+		after := s.cursors[conversationID]
+		out, err := s.g.store.FetchHistory(dbroute.RequirePrimary(ctx), FetchHistoryInput{
+			ConversationID: conversationID,
+			AfterSeq:       &after,
+			Limit:          wsMaxHistoryLimit,
+		})
+		if err != nil {
+			s.g.log.Error("grpc.firehose.replay.fail", "conversation_id", conversationID, "err", err)
+			return errors.New("history unavailable")
+		}
+		now := time.Now().UTC()
+		for _, m := range out.Messages {
+			payload, _ := json.Marshal(messagePayload(m))
+			if err := s.write(withSeq(mustNewEnvelope(v1.TypeMessageNew, payload, now), m.ConversationID, m.Seq)); err != nil {
+				return err
+			}
+			s.cursors[conversationID] = m.Seq
+			s.replayed[conversationID] = m.Seq
+		}
+		if !out.HasMore || len(out.Messages) == 0 {
+			break
+		}
+	}
+	return s.writeCheckpoint()
+}
+
+// writeEvent writes a live broadcast, skipping messages the replay already wrote.
+func (s *firehoseStream) writeEvent(ev firehoseEvent) error {
+	if ev.env.Type != v1.TypeMessageNew {
+		return s.write(ev.env)
+	}
+	seq := ev.env.Seq
+	if seq == 0 {
+		// Untagged envelopes are relayed by nodes that predate seq tags.
+		var p struct {
+			Seq int64 `json:"seq"`
+		}
+		_ = json.Unmarshal(ev.env.Payload, &p)
+		seq = p.Seq
+	}
+	if seq <= s.replayed[ev.conversationID] {
+		return nil
+	}
+	if err := s.write(ev.env); err != nil {
+		return err
+	}
+	s.cursors[ev.conversationID] = max(s.cursors[ev.conversationID], seq)
+	s.dirty = true
+	return nil
+}
+
+func (s *firehoseStream) writeCheckpoint() error {
+	payload, _ := json.Marshal(v1.FirehoseCheckpointPayload{ResumeToken: encodeFirehoseCursors(s.cursors)})
+	if err := s.write(mustNewEnvelope(v1.TypeFirehoseCheckpoint, payload, time.Now().UTC())); err != nil {
+		return err
+	}
+	s.dirty = false
+	return nil
+}
+
+func (s *firehoseStream) write(env v1.Envelope) error {
+	msg, err := realtimepb.FromV1(env)
+	if err != nil {
+		return err
+	}
+	_ = s.rc.SetWriteDeadline(time.Now().Add(s.g.writeTimeout))
+	return s.stream.Send(msg)
+}
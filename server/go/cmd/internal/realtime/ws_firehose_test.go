@@ -0,0 +1,147 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"arc/cmd/security/token"
+	"arc/shared/contracts/realtime/realtimepb"
+	v1 "arc/shared/contracts/realtime/v1"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+const firehoseTestToken = "firehose-secret"
+
+func newFirehoseTestServer(t *testing.T) (*httptest.Server, *WSGateway) {
+	t.Helper()
+	t.Setenv("ARC_FIREHOSE_TOKEN_SHA256", token.HashSHA256Hex(firehoseTestToken))
+	t.Setenv("ARC_FIREHOSE_CONVERSATIONS", "c1,c2")
+	t.Setenv("ARC_FIREHOSE_CHECKPOINT_INTERVAL", "50ms")
+	return newGRPCTestServer(t)
+}
+
+func firehoseCall(t *testing.T, srv *httptest.Server, bearer, resumeToken string) realtimepb.Realtime_FirehoseClient {
+	t.Helper()
+	payload, _ := json.Marshal(v1.FirehoseSubscribePayload{ResumeToken: resumeToken})
+	client, _ := newGRPCClient(t, srv)
+	ctx := metadata.AppendToOutgoingContext(grpcTestContext(t), "authorization", "Bearer "+bearer)
+	stream, err := client.Firehose(ctx, toGRPCEnvelope(t, mustNewEnvelope(v1.TypeFirehoseSubscribe, payload, time.Now().UTC())))
+	if err != nil {
+		t.Fatalf("call firehose: %v", err)
+	}
+	return stream
+}
+
+func appendFirehoseTestMessages(t *testing.T, g *WSGateway, convID string, n int) {
+	t.Helper()
+	for range n {
+		if _, err := g.store.AppendMessage(context.Background(), AppendMessageInput{
+			ConversationID: convID,
+			ClientMsgID:    NewRandomHex(8),
+			SenderSession:  "s1",
+			Text:           "hi",
+			Now:            time.Now().UTC(),
+		}); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+	}
+}
+
+func TestHandleGRPC_FirehoseReplayAndLive(t *testing.T) {
+	srv, g := newFirehoseTestServer(t)
+	appendFirehoseTestMessages(t, g, "c1", 3)
+
+	res := firehoseCall(t, srv, firehoseTestToken, encodeFirehoseCursors(map[string]int64{"c1": 1, "c9": 4}))
+
+	// Messages after the cursor are replayed, then checkpointed; c9 is not configured.
+	for _, want := range []int64{2, 3} {
+		if got := fromGRPCEnvelope(t)(res.Recv()); got.Type != v1.TypeMessageNew || got.Seq != want {
+			t.Fatalf("expected replayed seq %d, got %+v", want, got)
+		}
+	}
+	var cp v1.FirehoseCheckpointPayload
+	got := fromGRPCEnvelope(t)(res.Recv())
+	_ = json.Unmarshal(got.Payload, &cp)
+	if cursors, err := decodeFirehoseCursors(cp.ResumeToken); err != nil || got.Type != v1.TypeFirehoseCheckpoint ||
+		len(cursors) != 1 || cursors["c1"] != 3 {
+		t.Fatalf("unexpected checkpoint %+v %v", got, cursors)
+	}
+
+	// Live: a replayed message is skipped, unconfigured conversations and other
+	// types are filtered, member events pass.
+	replayed, _ := json.Marshal(v1.MessageNewPayload{ConversationID: "c1", Seq: 3})
+	g.hub.Broadcast("c1", withSeq(mustNewEnvelope(v1.TypeMessageNew, replayed, time.Now().UTC()), "c1", 3))
+	g.hub.Broadcast("c3", withSeq(mustNewEnvelope(v1.TypeMessageNew, replayed, time.Now().UTC()), "c3", 1))
+	g.hub.Broadcast("c2", mustNewEnvelope(v1.TypeReadState, json.RawMessage(`{}`), time.Now().UTC()))
+	g.broadcastMemberAdded("c2", "u2", MemberRoleMember, "u1")
+	live, _ := json.Marshal(v1.MessageNewPayload{ConversationID: "c1", Seq: 4})
+	g.hub.Broadcast("c1", withSeq(mustNewEnvelope(v1.TypeMessageNew, live, time.Now().UTC()), "c1", 4))
+
+	if got := fromGRPCEnvelope(t)(res.Recv()); got.Type != v1.TypeMemberAdded {
+		t.Fatalf("expected member.added, got %+v", got)
+	}
+	if got := fromGRPCEnvelope(t)(res.Recv()); got.Type != v1.TypeMessageNew || got.Seq != 4 {
+		t.Fatalf("expected live seq 4, got %+v", got)
+	}
+	got = fromGRPCEnvelope(t)(res.Recv())
+	_ = json.Unmarshal(got.Payload, &cp)
+	if cursors, _ := decodeFirehoseCursors(cp.ResumeToken); got.Type != v1.TypeFirehoseCheckpoint || cursors["c1"] != 4 {
+		t.Fatalf("unexpected checkpoint %+v %v", got, cursors)
+	}
+}
+
+func TestHandleGRPC_FirehoseErrors(t *testing.T) {
+	srv, _ := newFirehoseTestServer(t)
+
+	tests := []struct {
+		name   string
+		bearer string
+		resume string
+		code   codes.Code
+	}{
+		{name: "bad token", bearer: "nope", code: codes.Unauthenticated},
+		{name: "bad resume token", bearer: firehoseTestToken, resume: "!!", code: codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := firehoseCall(t, srv, tt.bearer, tt.resume).Recv()
+			wantGRPCCode(t, err, tt.code)
+		})
+	}
+}
+
+func TestHandleGRPC_FirehoseNotConfigured(t *testing.T) {
+	srv, _ := newGRPCTestServer(t)
+
+	_, err := firehoseCall(t, srv, firehoseTestToken, "").Recv()
+	wantGRPCCode(t, err, codes.Unimplemented)
+}
+
+func TestHub_FirehoseOverflow(t *testing.T) {
+	t.Parallel()
+
+	h := NewHub(nil)
+	sub, cancel := h.subscribeFirehose(func(id string) bool { return id == "c1" }, 1)
+	defer cancel()
+
+	env := mustNewEnvelope(v1.TypeMemberAdded, json.RawMessage(`{}`), time.Now().UTC())
+	h.publishFirehose("c2", env)
+	h.publishFirehose("c1", env)
+	select {
+	case <-sub.overflow:
+		t.Fatalf("overflow before the queue filled")
+	default:
+	}
+	h.publishFirehose("c1", env)
+	h.publishFirehose("c1", env)
+	select {
+	case <-sub.overflow:
+	default:
+		t.Fatalf("expected overflow")
+	}
+}
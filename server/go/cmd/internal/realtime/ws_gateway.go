@@ -14,17 +14,27 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"arc/shared/contracts/realtime/realtimepb"
 	v1 "arc/shared/contracts/realtime/v1"
+	v2 "arc/shared/contracts/realtime/v2"
 
 	"arc/cmd/internal/auth/session"
+	"arc/cmd/internal/config"
+	"arc/cmd/internal/featureflags"
+	"arc/cmd/internal/ipaccess"
+	"arc/cmd/internal/maintenance"
 
 	"github.com/coder/websocket"
+	"google.golang.org/grpc"
 )
 
 const (
 	wsSubprotocolV1 = "arc.realtime.v1"
+	// wsSubprotocolV2 carries the same envelopes as MessagePack binary frames.
+	wsSubprotocolV2 = v2.Subprotocol
 
 	wsDefaultSendQueueSize = 256
 	wsMinSendQueueSize     = 32
@@ -39,11 +49,24 @@ const (
 	wsMaxPingFailures = 3
 	wsMaxAccessToken  = 8 << 10 // 8 KiB
 
+	wsDefaultTicketQueryParam = "ticket"
+	// wsTokenSubprotocolPrefix marks an access token offered in Sec-WebSocket-Protocol.
+	wsTokenSubprotocolPrefix = "arc.token."
+
 	// Secure-by-default for dev.
 	wsDefaultOriginRequired = true
 	wsDefaultAllowedOrigins = "http://localhost,http://127.0.0.1"
 )
 
+// Request errors shared by the envelope handlers; their text is the message
+// clients receive.
+var (
+	errUnauthorized          = errors.New("unauthorized")
+	errMissingConversationID = errors.New("missing conversation_id")
+	errConversationNotFound  = errors.New("conversation not found")
+	errNotMember             = errors.New("not a member of conversation_id")
+)
+
 // WSGateway is Arc's realtime websocket gateway.
 // It enforces origin policy, subprotocol selection, heartbeats, rate limits,
 // and routes validated envelopes to Hub and MessageStore.
@@ -53,30 +76,102 @@ type WSGateway struct {
 	store MessageStore
 
 	auth           *session.Service
+	resumeTokens   resumeTokenService
+	revocations    sessionRevocations
+	touches        sessionToucher
 	requireAuth    bool
 	authQueryParam string
 	authCookieName string
+	ticketParam    string
+	authSubproto   bool
 	members        MembershipStore
 	requireMember  bool
-
-	devInsecure    bool
-	originRequired bool
-	allowedOrigins []string
+	attachments    AttachmentVerifier
+	offline        OfflineNotifier
+	commands       CommandDispatcher
+	filters        []MessageFilter
+	abuse          *AbuseGuard
+	abuseReporter  AbuseReporter
+	firehose       firehoseConfig
+	// adminUserIDs may call the /admin/ws endpoints (shared with the auth API).
+	adminUserIDs []string
+
+	presenceLastSeen string
+	resumeWindow     int
+	maxJoined        int
+	maxInboxConvs    int
+
+	maxFrameBytes   int
+	maxMessageChars int
+	sanitizePolicy  string
+
+	// grpc serves HandleGRPC.
+	grpc *grpc.Server
+
+	devInsecure bool
+	// tunables are reloaded through lookup by config.Subscribe.
+	tunables      atomic.Pointer[gatewayTunables]
+	stopReloading func()
+	// lookup reads every ARC_* setting (default the process environment); see WithConfigLookup.
+	lookup config.Lookup
 
 	writeTimeout    time.Duration
 	readIdleTimeout time.Duration
 	sendQueueSize   int
+	backpressure    BackpressurePolicy
+
+	compression             websocket.CompressionMode
+	compressionThreshold    int
+	compressionContextConns int
+	compressionContextInUse atomic.Int64
 
 	heartbeatEvery   time.Duration
 	heartbeatTimeout time.Duration
+	// touchBatch holds sessions to touch on the next RunSessionTouches tick.
+	touchInterval time.Duration
+	touchBatch    touchBatch
+
+	// Drain state: drainCh is closed once Drain starts.
+	drainTimeout    time.Duration
+	drainRetryAfter time.Duration
+	draining        atomic.Bool
+	drainOnce       sync.Once
+	drainCh         chan struct{}
+	activeSessions  atomic.Int64
+
+	// connLimits counts open sessions against the per-user, per-session and per-IP caps.
+	connLimits connLimiter
+
+	// maintenance refuses new sessions while enabled (nil never does).
+	maintenance *maintenance.Mode
+	// flags gates the realtime.* features (nil uses the defaults).
+	flags *featureflags.Flags
+	// access holds the IP and country lists checked before a session opens (nil allows all).
+	access      *ipaccess.Policy
+	accessProxy ipaccess.ProxyConfig
+}
 
-	rateEvents int
-	rateWindow time.Duration
+// GatewayOption configures optional gateway dependencies.
+type GatewayOption func(*WSGateway)
+
+// WithAttachmentVerifier enables attachment_ids on message.send.
+func WithAttachmentVerifier(v AttachmentVerifier) GatewayOption {
+	return func(g *WSGateway) { g.attachments = v }
+}
+
+// WithConfigLookup reads the gateway's settings through lookup instead of the
+// process environment, at construction and on every config reload.
+func WithConfigLookup(lookup config.Lookup) GatewayOption {
+	return func(g *WSGateway) {
+		if lookup != nil {
+			g.lookup = lookup
+		}
+	}
 }
 
 // NewWSGateway constructs a gateway with secure defaults.
 // When hub/store are nil, it falls back to in-memory implementations for dev.
-func NewWSGateway(log *slog.Logger, hub *Hub, store MessageStore, auth *session.Service, members MembershipStore) *WSGateway {
+func NewWSGateway(log *slog.Logger, hub *Hub, store MessageStore, auth *session.Service, members MembershipStore, opts ...GatewayOption) *WSGateway {
 	if log == nil {
 		log = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
 	}
@@ -87,36 +182,95 @@ func NewWSGateway(log *slog.Logger, hub *Hub, store MessageStore, auth *session.
 		store = NewInMemoryStore()
 	}
 
-	g := &WSGateway{log: log, hub: hub, store: store, auth: auth, members: members}
+	g := &WSGateway{log: log, hub: hub, store: store, auth: auth, members: members, drainCh: make(chan struct{}), lookup: config.Env}
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	// Recent history is served from memory; the in-memory store needs no cache.
+	if _, inMemory := store.(*InMemoryStore); !inMemory {
+		if c := NewHistoryCache(store, LoadHistoryCacheConfigFrom(g.lookup)); c != nil {
+			g.store = c
+			hub.ObserveRemote(c)
+		}
+	}
 
 	// Dev-only escape hatch.
-	g.devInsecure = envBoolWS("ARC_WS_DEV_INSECURE", false)
-	g.requireAuth = envBoolWS("ARC_WS_REQUIRE_AUTH", auth != nil)
-	g.authQueryParam = envTokenNameWS("ARC_WS_AUTH_QUERY_PARAM")
-	g.authCookieName = envTokenNameWS("ARC_WS_AUTH_COOKIE_NAME")
-	g.requireMember = envBoolWS("ARC_WS_REQUIRE_MEMBERSHIP", members != nil)
+	g.devInsecure = envBoolWS(g.lookup, "ARC_WS_DEV_INSECURE", false)
+	g.requireAuth = envBoolWS(g.lookup, "ARC_WS_REQUIRE_AUTH", auth != nil)
+	if auth != nil {
+		g.resumeTokens = auth
+		g.revocations = auth
+		g.touches = auth
+	}
+	g.authQueryParam = envTokenNameWS(g.lookup, "ARC_WS_AUTH_QUERY_PARAM")
+	g.authCookieName = envTokenNameWS(g.lookup, "ARC_WS_AUTH_COOKIE_NAME")
+	g.authSubproto = envBoolWS(g.lookup, "ARC_WS_AUTH_SUBPROTOCOL", true)
+	g.ticketParam = envTokenNameWS(g.lookup, "ARC_WS_TICKET_QUERY_PARAM")
+	if g.ticketParam == "" {
+		g.ticketParam = wsDefaultTicketQueryParam
+	}
+	g.requireMember = envBoolWS(g.lookup, "ARC_WS_REQUIRE_MEMBERSHIP", members != nil)
 	if g.requireMember {
 		// Membership checks require authenticated user IDs.
 		g.requireAuth = true
 	}
 
-	g.originRequired = envBoolWS("ARC_WS_ORIGIN_REQUIRED", wsDefaultOriginRequired)
-	g.allowedOrigins = envCSVWS("ARC_WS_ALLOWED_ORIGINS", wsDefaultAllowedOrigins)
+	g.adminUserIDs = envCSVWS(g.lookup, "ARC_AUTH_ADMIN_USER_IDS", "")
+
+	g.presenceLastSeen = normalizePresenceLastSeen(g.lookup.Get("ARC_PRESENCE_LAST_SEEN"))
+
+	// The replay window is bounded by the store's maximum history page.
+	g.resumeWindow = envIntWS(g.lookup, "ARC_WS_RESUME_MAX_MESSAGES", wsDefaultResumeWindow)
+	if g.resumeWindow > wsMaxHistoryLimit {
+		g.resumeWindow = wsMaxHistoryLimit
+	}
+
+	g.maxJoined = envIntWS(g.lookup, "ARC_WS_MAX_JOINED_CONVERSATIONS", wsDefaultMaxJoinedConversations)
+	g.maxInboxConvs = envIntWS(g.lookup, "ARC_WS_INBOX_MAX_CONVERSATIONS", wsDefaultMaxInboxConversations)
+	g.firehose = loadFirehoseConfig(g.lookup)
+
+	g.maxFrameBytes = envIntWS(g.lookup, "ARC_WS_MAX_FRAME_BYTES", defaultMaxFrameBytes)
+	if g.maxFrameBytes < minMaxFrameBytes {
+		g.maxFrameBytes = minMaxFrameBytes
+	}
+	g.maxMessageChars = envIntWS(g.lookup, "ARC_WS_MAX_MESSAGE_CHARS", defaultMaxMessageChars)
+	if g.maxMessageChars > maxStoredMessageChars {
+		g.maxMessageChars = maxStoredMessageChars
+	}
+	g.sanitizePolicy = normalizeSanitizePolicy(g.lookup.Get("ARC_MESSAGE_SANITIZE"))
 
-	g.writeTimeout = envDurationWS("ARC_WS_WRITE_TIMEOUT", wsDefaultWriteTimeout)
-	g.readIdleTimeout = envDurationWS("ARC_WS_READ_IDLE_TIMEOUT", wsDefaultReadIdle)
+	g.tunables.Store(loadGatewayTunables(g.lookup))
+	g.stopReloading = config.Subscribe(g.reloadTunables, gatewayTunableKeys...)
 
-	g.sendQueueSize = envIntWS("ARC_WS_SEND_QUEUE", wsDefaultSendQueueSize)
+	g.writeTimeout = envDurationWS(g.lookup, "ARC_WS_WRITE_TIMEOUT", wsDefaultWriteTimeout)
+	g.readIdleTimeout = envDurationWS(g.lookup, "ARC_WS_READ_IDLE_TIMEOUT", wsDefaultReadIdle)
+
+	g.sendQueueSize = envIntWS(g.lookup, "ARC_WS_SEND_QUEUE", wsDefaultSendQueueSize)
 	if g.sendQueueSize < wsMinSendQueueSize {
 		g.sendQueueSize = wsMinSendQueueSize
 	}
+	g.backpressure = BackpressurePolicy{
+		Mode:              normalizeBackpressureMode(g.lookup.Get("ARC_WS_BACKPRESSURE")),
+		DisconnectAfter:   envIntWS(g.lookup, "ARC_WS_SLOW_CONSUMER_DROPS", 0),
+		PriorityQueueSize: envIntWS(g.lookup, "ARC_WS_PRIORITY_QUEUE", wsDefaultPriorityQueueSize),
+		OrderHold:         envDurationWS(g.lookup, "ARC_WS_ORDER_HOLD", wsDefaultOrderHold),
+	}
+
+	g.compression = parseCompressionMode(g.lookup.Get("ARC_WS_COMPRESSION"))
+	g.compressionThreshold = envIntWS(g.lookup, "ARC_WS_COMPRESSION_THRESHOLD", wsDefaultCompressionThreshold)
+	g.compressionContextConns = envIntWS(g.lookup, "ARC_WS_COMPRESSION_CONTEXT_CONNS", wsDefaultCompressionContextConns)
 
-	g.heartbeatEvery = envDurationWS("ARC_WS_HEARTBEAT_INTERVAL", heartbeatInterval)
-	g.heartbeatTimeout = envDurationWS("ARC_WS_HEARTBEAT_TIMEOUT", heartbeatTimeout)
+	g.heartbeatEvery = envDurationWS(g.lookup, "ARC_WS_HEARTBEAT_INTERVAL", heartbeatInterval)
+	g.heartbeatTimeout = envDurationWS(g.lookup, "ARC_WS_HEARTBEAT_TIMEOUT", heartbeatTimeout)
+	g.touchInterval = envDurationWS(g.lookup, "ARC_WS_TOUCH_INTERVAL", wsDefaultTouchInterval)
 
-	g.rateEvents = envIntWS("ARC_WS_RATE_EVENTS", rateLimitEvents)
-	g.rateWindow = envDurationWS("ARC_WS_RATE_WINDOW", rateLimitWindow)
+	g.abuse = NewAbuseGuard(LoadAbuseGuardConfigFrom(g.lookup))
 
+	g.drainTimeout = envDurationWS(g.lookup, "ARC_WS_DRAIN_TIMEOUT", wsDefaultDrainTimeout)
+	g.drainRetryAfter = envDurationWS(g.lookup, "ARC_WS_DRAIN_RETRY_AFTER", wsDefaultDrainRetryAfter)
+
+	g.grpc = g.newGRPCServer()
 	return g
 }
 
@@ -127,11 +281,22 @@ func (g *WSGateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 // HandleWS upgrades the request to WebSocket and runs the realtime loop.
 func (g *WSGateway) HandleWS(w http.ResponseWriter, r *http.Request) {
+	if g.rejectDraining(w) {
+		return
+	}
 	if err := g.enforceOrigin(r); err != nil {
 		g.log.Info("ws.reject.origin", "err", err, "origin", r.Header.Get("Origin"), "remote", r.RemoteAddr)
 		http.Error(w, "forbidden", http.StatusForbidden)
 		return
 	}
+	if !g.accessAllowed(r, "ws") {
+		g.log.Info("ws.reject.access", "remote", r.RemoteAddr)
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+	if g.rejectMaintenance(w, r) {
+		return
+	}
 
 	var (
 		userID    string
@@ -143,13 +308,11 @@ func (g *WSGateway) HandleWS(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "auth not configured", http.StatusInternalServerError)
 			return
 		}
-		token, err := g.accessTokenFromRequest(r)
-		if err != nil {
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
-			return
-		}
-		claims, err := g.auth.ValidateAccessToken(r.Context(), token, time.Now().UTC())
+		claims, err := g.authenticateUpgrade(r, time.Now().UTC())
 		if err != nil {
+			if reason := session.InvalidTokenReason(err); reason != "" {
+				g.log.Info("ws.reject.token", "reason", reason, "remote", r.RemoteAddr)
+			}
 			http.Error(w, "unauthorized", http.StatusUnauthorized)
 			return
 		}
@@ -159,12 +322,29 @@ func (g *WSGateway) HandleWS(w http.ResponseWriter, r *http.Request) {
 		_ = g.auth.TouchSession(r.Context(), time.Now().UTC(), sessionID)
 	}
 
+	release, limit := g.acquireConn(r, userID, sessionID)
+	if limit != "" {
+		rejectConnLimit(w, limit)
+		return
+	}
+	defer release()
+
 	// English comment:
 	// Origin enforcement is fully handled by enforceOrigin() as the single source of truth.
 	// We intentionally do NOT use AcceptOptions.OriginPatterns to avoid library-specific semantics mismatch.
+	// A token offered as a subprotocol is never selected or echoed back.
+	stripTokenSubprotocols(r.Header)
+
+	compression, releaseCompression := g.acceptCompression()
+	defer releaseCompression()
+
 	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
-		Subprotocols:       []string{wsSubprotocolV1},
+		Subprotocols:       []string{wsSubprotocolV1, wsSubprotocolV2},
 		InsecureSkipVerify: g.devInsecure,
+		// permessage-deflate is only used when the client offers it; frames below
+		// the threshold are sent uncompressed.
+		CompressionMode:      compression,
+		CompressionThreshold: g.compressionThreshold,
 	})
 	if err != nil {
 		g.log.Error("ws.accept.fail", "err", err)
@@ -172,13 +352,15 @@ func (g *WSGateway) HandleWS(w http.ResponseWriter, r *http.Request) {
 	}
 	defer func() { _ = conn.Close(websocket.StatusNormalClosure, "bye") }()
 
-	if sp := conn.Subprotocol(); sp != wsSubprotocolV1 {
-		g.log.Info("ws.reject.subprotocol", "got", sp, "want", wsSubprotocolV1)
+	sp := conn.Subprotocol()
+	if sp != wsSubprotocolV1 && sp != wsSubprotocolV2 {
+		g.log.Info("ws.reject.subprotocol", "got", sp, "want", wsSubprotocolV1+","+wsSubprotocolV2)
 		_ = conn.Close(websocket.StatusProtocolError, "subprotocol required")
 		return
 	}
+	binary := sp == wsSubprotocolV2
 
-	conn.SetReadLimit(maxFrameBytes)
+	conn.SetReadLimit(int64(g.maxFrameBytes))
 
 	now := time.Now().UTC()
 	if sessionID == "" {
@@ -192,31 +374,101 @@ func (g *WSGateway) HandleWS(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	client := NewClient(userID, sessionID, g.sendQueueSize)
+	g.runSession(r.Context(), &wsSessionConn{conn: conn, binary: binary}, userID, sessionID)
+}
+
+// sessionConn is the transport of one realtime session. The WebSocket gateway and
+// the gRPC Stream RPC share runSession through it.
+type sessionConn interface {
+	ReadEnvelope(ctx context.Context) (v1.Envelope, error)
+	WriteEnvelope(ctx context.Context, env v1.Envelope) error
+	Ping(ctx context.Context) error
+	Close(code websocket.StatusCode, reason string) error
+}
+
+// readLimiter is implemented by session transports whose read limit can change
+// after hello negotiates a smaller frame size.
+type readLimiter interface {
+	SetReadLimit(n int)
+}
+
+// wsSessionConn adapts a WebSocket connection; binary selects v2 framing.
+type wsSessionConn struct {
+	conn   *websocket.Conn
+	binary bool
+}
+
+func (c *wsSessionConn) ReadEnvelope(ctx context.Context) (v1.Envelope, error) {
+	return readEnvelope(ctx, c.conn, c.binary)
+}
+
+func (c *wsSessionConn) WriteEnvelope(ctx context.Context, env v1.Envelope) error {
+	return writeEnvelope(ctx, c.conn, env, c.binary)
+}
 
-	ctx, cancel := context.WithCancel(r.Context())
+func (c *wsSessionConn) Ping(ctx context.Context) error { return c.conn.Ping(ctx) }
+
+func (c *wsSessionConn) SetReadLimit(n int) { c.conn.SetReadLimit(int64(n)) }
+
+func (c *wsSessionConn) Close(code websocket.StatusCode, reason string) error {
+	return c.conn.Close(code, reason)
+}
+
+// runSession registers the client, runs the writer, heartbeat and read loops for
+// an authenticated (or anonymous, when auth is not required) session and blocks
+// until the session ends.
+func (g *WSGateway) runSession(parent context.Context, conn sessionConn, userID, sessionID string) {
+	g.activeSessions.Add(1)
+	defer g.activeSessions.Add(-1)
+
+	wsConnections.Inc()
+	wsConnectionsTotal.Inc()
+	defer wsConnections.Dec()
+
+	client := NewClientWithPolicy(userID, sessionID, g.sendQueueSize, g.backpressure)
+	if _, ws := conn.(*wsSessionConn); ws && g.compression != websocket.CompressionDisabled {
+		client.offered |= v1.CapCompression
+	}
+	g.hub.AddClient(client)
+
+	ctx, cancel := context.WithCancel(parent)
 	defer cancel()
 
 	var (
 		closeOnce sync.Once
-		joined    *Conversation
+		joined    joinedConversations
 	)
 
 	// shutdown is idempotent. It does NOT close client.Send.
 	// Broadcast safety: membership removal happens before client.Close.
 	shutdown := func(code websocket.StatusCode, reason string) {
 		closeOnce.Do(func() {
-			if joined != nil {
-				joined.Leave(sessionID)
-				joined = nil
+			for _, conv := range joined.takeAll() {
+				conv.Leave(sessionID)
 			}
+			g.hub.RemoveClient(client)
 			client.Close()
 			_ = conn.Close(code, reason)
 			cancel()
 		})
 	}
 
-	rl := NewRateLimiter(g.rateEvents, g.rateWindow)
+	tun := g.tunables.Load()
+	rl := NewRateLimiter(tun.rateEvents, tun.rateWindow)
+	convRL := NewConversationRateLimiter(tun.convSendBurst, tun.convSendRefill)
+
+	// A client closed from outside the session (Hub.CloseSession, a revoked auth
+	// session) ends the connection with the client's close status;
+	// Drain flushes it and closes it as going away.
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-client.Done():
+			shutdown(client.closeStatus())
+		case <-g.drainCh:
+			g.drainSession(ctx, client, shutdown)
+		}
+	}()
 
 	// Writer loop
 	writerDone := make(chan struct{})
@@ -224,22 +476,38 @@ func (g *WSGateway) HandleWS(w http.ResponseWriter, r *http.Request) {
 		defer close(writerDone)
 
 		for {
+			var env v1.Envelope
 			select {
-			case <-ctx.Done():
-				return
-			case <-client.Done():
-				return
-			case env := <-client.Send:
-				if err := writeEnvelope(ctx, conn, env, g.writeTimeout); err != nil {
-					g.log.Info("ws.write.fail",
-						"session_id", sessionID,
-						"close_status", websocket.CloseStatus(err),
-						"err", err,
-					)
-					shutdown(websocket.StatusAbnormalClosure, "write failed")
+			case env = <-client.Priority:
+			default:
+				select {
+				case <-ctx.Done():
+					return
+				case <-client.Done():
+					return
+				case <-client.Slow():
+					g.log.Info("ws.slow_consumer", "session_id", sessionID, "drops", client.Dropped())
+					shutdown(websocket.StatusPolicyViolation, "slow consumer")
 					return
+				case env = <-client.Priority:
+				case env = <-client.Send:
 				}
 			}
+
+			wsSendQueueDepth.Observe(float64(client.QueueDepth()))
+			wctx, wcancel := context.WithTimeout(ctx, g.writeTimeout)
+			err := conn.WriteEnvelope(wctx, env)
+			wcancel()
+			if err != nil {
+				g.log.Info("ws.write.fail",
+					"session_id", sessionID,
+					"close_status", websocket.CloseStatus(err),
+					"err", err,
+				)
+				shutdown(websocket.StatusAbnormalClosure, "write failed")
+				return
+			}
+			trackDelivered(client, env)
 		}
 	}()
 
@@ -273,6 +541,7 @@ func (g *WSGateway) HandleWS(w http.ResponseWriter, r *http.Request) {
 					continue
 				}
 				failures = 0
+				g.markActive(userID, sessionID)
 			}
 		}
 	}()
@@ -280,7 +549,7 @@ func (g *WSGateway) HandleWS(w http.ResponseWriter, r *http.Request) {
 readLoop:
 	for {
 		readCtx, readCancel := context.WithTimeout(ctx, g.readIdleTimeout)
-		env, err := readEnvelope(readCtx, conn)
+		env, err := conn.ReadEnvelope(readCtx)
 		readCancel()
 
 		if err != nil {
@@ -297,6 +566,9 @@ readLoop:
 			case readErrBadJSON:
 				g.trySendError(ctx, client, "bad_json", "invalid JSON")
 				continue readLoop
+			case readErrBadFrame:
+				g.trySendError(ctx, client, "bad_frame", "invalid binary frame")
+				continue readLoop
 			default:
 				g.log.Info("ws.read.fail", "session_id", sessionID, "err", err)
 				shutdown(websocket.StatusAbnormalClosure, "read failed")
@@ -305,6 +577,12 @@ readLoop:
 		}
 
 		now := time.Now().UTC()
+		client.Touch(now)
+		if t := g.tunables.Load(); t != tun {
+			tun = t
+			rl.SetLimit(t.rateEvents, t.rateWindow)
+			convRL.SetLimit(t.convSendBurst, t.convSendRefill)
+		}
 		if !rl.Allow(now) {
 			g.trySendError(ctx, client, "rate_limited", "too many events")
 			shutdown(websocket.StatusPolicyViolation, "rate limited")
@@ -318,45 +596,144 @@ readLoop:
 
 		switch env.Type {
 		case v1.TypeHello:
-			if err := g.onHello(ctx, client); err != nil {
+			frameLimit, err := g.onHello(ctx, client, env)
+			if err != nil {
 				g.trySendError(ctx, client, "hello_failed", err.Error())
 				shutdown(websocket.StatusPolicyViolation, "hello failed")
 				break readLoop
 			}
+			if lim, ok := conn.(readLimiter); ok {
+				lim.SetReadLimit(frameLimit)
+			}
 
 		case v1.TypeConversationJoin:
-			conv, err := g.onJoin(ctx, client, env)
+			conv, err := g.onJoin(ctx, client, &joined, env)
 			if err != nil {
 				g.trySendError(ctx, client, "join_failed", err.Error())
 				continue readLoop
 			}
+			g.recordJoin(client, &joined, conv)
 
-			// Ensure membership stability: leave old conversation before switching.
-			if joined != nil && joined.ID != conv.ID {
-				joined.Leave(sessionID)
+			if err := g.redeliver(ctx, client, conv.ID); err != nil {
+				g.trySendError(ctx, client, "redelivery_failed", err.Error())
+				continue readLoop
+			}
+
+		case v1.TypeConversationLeave:
+			if err := g.onLeave(ctx, client, &joined, env); err != nil {
+				g.trySendError(ctx, client, "leave_failed", err.Error())
+				continue readLoop
 			}
-			joined = conv
 
 		case v1.TypeMessageSend:
-			if joined == nil {
+			conv := joined.route(payloadConversationID(env))
+			if conv == nil {
 				g.trySendError(ctx, client, "not_joined", "join first")
 				continue readLoop
 			}
-			if err := g.onMessageSend(ctx, client, joined, env, now); err != nil {
-				g.trySendError(ctx, client, "send_failed", err.Error())
+			if ok, wait := convRL.Allow(conv.ID, now); !ok {
+				g.trySendError(ctx, client, "rate_limited_conversation",
+					fmt.Sprintf("too many messages in conversation; retry in %s", wait.Round(time.Millisecond)))
+				continue readLoop
+			}
+			if err := g.onMessageSend(ctx, client, conv, env, now); err != nil {
+				g.trySendFailure(ctx, client, "send_failed", err)
+				continue readLoop
+			}
+
+		case v1.TypeMessageEdit:
+			if g.rejectDisabledFeature(ctx, client, featureflags.RealtimeMessageEdits) {
+				continue readLoop
+			}
+			conv := joined.route(payloadConversationID(env))
+			if conv == nil {
+				g.trySendError(ctx, client, "not_joined", "join first")
+				continue readLoop
+			}
+			if err := g.onMessageEdit(ctx, client, conv, env, now); err != nil {
+				g.trySendFailure(ctx, client, "edit_failed", err)
+				continue readLoop
+			}
+
+		case v1.TypeMessageDelete:
+			if g.rejectDisabledFeature(ctx, client, featureflags.RealtimeMessageEdits) {
+				continue readLoop
+			}
+			conv := joined.route(payloadConversationID(env))
+			if conv == nil {
+				g.trySendError(ctx, client, "not_joined", "join first")
+				continue readLoop
+			}
+			if err := g.onMessageDelete(ctx, client, conv, env, now); err != nil {
+				g.trySendError(ctx, client, "delete_failed", err.Error())
 				continue readLoop
 			}
 
 		case v1.TypeConversationHistoryFetch:
-			if joined == nil {
+			conv := joined.route(payloadConversationID(env))
+			if conv == nil {
 				g.trySendError(ctx, client, "not_joined", "join first")
 				continue readLoop
 			}
-			if err := g.onHistoryFetch(ctx, client, joined, env); err != nil {
+			if err := g.onHistoryFetch(ctx, client, conv, env); err != nil {
 				g.trySendError(ctx, client, "history_failed", err.Error())
 				continue readLoop
 			}
 
+		case v1.TypeReadUpdate, v1.TypeMessageRead:
+			if err := g.onReadUpdate(ctx, client, env); err != nil {
+				g.trySendError(ctx, client, "read_failed", err.Error())
+				continue readLoop
+			}
+
+		case v1.TypeMessageDelivered:
+			if err := g.onMessageDelivered(ctx, client, env); err != nil {
+				g.trySendError(ctx, client, "delivered_failed", err.Error())
+				continue readLoop
+			}
+
+		case v1.TypePresenceSubscribe:
+			if g.rejectDisabledFeature(ctx, client, featureflags.RealtimePresence) {
+				continue readLoop
+			}
+			if err := g.onPresenceSubscribe(ctx, client, env); err != nil {
+				g.trySendError(ctx, client, "presence_failed", err.Error())
+				continue readLoop
+			}
+
+		case v1.TypePresenceUpdate:
+			if g.rejectDisabledFeature(ctx, client, featureflags.RealtimePresence) {
+				continue readLoop
+			}
+			if err := g.onPresenceUpdate(ctx, client, env); err != nil {
+				g.trySendError(ctx, client, "presence_failed", err.Error())
+				continue readLoop
+			}
+
+		case v1.TypeInboxSubscribe:
+			if err := g.onInboxSubscribe(ctx, client); err != nil {
+				g.trySendError(ctx, client, "inbox_failed", err.Error())
+				continue readLoop
+			}
+
+		case v1.TypeInboxUnsubscribe:
+			if err := g.onInboxUnsubscribe(ctx, client); err != nil {
+				g.trySendError(ctx, client, "inbox_failed", err.Error())
+				continue readLoop
+			}
+
+		case v1.TypeResume:
+			if err := g.onResume(ctx, client, env); err != nil {
+				g.trySendError(ctx, client, "resume_failed", err.Error())
+				continue readLoop
+			}
+
+		case v1.TypeTimeSync:
+			if err := g.onTimeSync(ctx, client, env, now); err != nil {
+				g.trySendError(ctx, client, "time_sync_failed", err.Error())
+				continue readLoop
+			}
+
 		default:
 			g.trySendError(ctx, client, "unsupported", fmt.Sprintf("unsupported type: %s", env.Type))
 		}
@@ -365,6 +742,10 @@ readLoop:
 	shutdown(websocket.StatusNormalClosure, "bye")
 	<-writerDone
 
+	if drops := client.Dropped(); drops > 0 {
+		g.log.Info("ws.backpressure.drops", "session_id", sessionID, "user_id", userID, "drops", drops, "policy", g.backpressure.Mode)
+	}
+
 	select {
 	case <-heartbeatDone:
 	case <-time.After(wsCloseGrace):
@@ -373,17 +754,71 @@ readLoop:
 
 // ---- handlers ----
 
-func (g *WSGateway) onHello(ctx context.Context, client *Client) error {
-	ackPayload, _ := json.Marshal(v1.HelloAckPayload{SessionID: client.SessionID})
-	ack := mustNewEnvelope(v1.TypeHelloAck, ackPayload, time.Now().UTC())
+// onHello answers hello and returns the frame limit negotiated for the connection.
+func (g *WSGateway) onHello(ctx context.Context, client *Client, env v1.Envelope) (int, error) {
+	now := time.Now().UTC()
 
-	if !g.enqueue(ctx, client, ack) {
-		return errors.New("backpressure: hello.ack")
+	var p v1.HelloPayload
+	if len(env.Payload) > 0 {
+		if err := json.Unmarshal(env.Payload, &p); err != nil {
+			return 0, fmt.Errorf("invalid payload: %w", err)
+		}
 	}
-	return nil
+	// A stale or foreign resume token is not fatal: the client still gets a fresh one
+	// and can resume with explicit cursors.
+	if p.ResumeToken != "" {
+		cursors, err := g.resumeTokenCursors(ctx, client, p.ResumeToken, now)
+		if err != nil {
+			g.log.Info("ws.resume_token.reject", "session_id", client.SessionID, "err", err)
+		}
+		for id, seq := range cursors {
+			client.AdvanceCursor(id, seq)
+		}
+	}
+
+	hello := negotiateHello(p, client.offered)
+	client.SetHello(hello)
+	platform := hello.Platform
+	if platform == "" {
+		platform = "unknown"
+	}
+	wsHellos.With(platform).Inc()
+
+	frameLimit := g.negotiateFrameLimit(p.MaxFrameBytes)
+	ack := v1.HelloAckPayload{
+		SessionID:           client.SessionID,
+		MaxMessageChars:     g.maxMessageChars,
+		MaxFrameBytes:       frameLimit,
+		HeartbeatIntervalMS: g.heartbeatEvery.Milliseconds(),
+		ProtocolVersion:     v1.Version,
+		Capabilities:        hello.Capabilities,
+	}
+	// Clients that cannot resume get no resume token to carry around.
+	if hello.Capabilities.Has(v1.CapResume) {
+		if token, exp := g.issueResumeToken(client, now); token != "" {
+			ack.ResumeToken = token
+			ack.ResumeTokenExpiresAt = &exp
+		}
+	}
+	ackPayload, _ := json.Marshal(ack)
+	ackEnv := mustNewEnvelope(v1.TypeHelloAck, ackPayload, now)
+
+	if !g.enqueue(ctx, client, ackEnv) {
+		return 0, errors.New("backpressure: hello.ack")
+	}
+	return frameLimit, nil
+}
+
+// negotiateFrameLimit applies a client's requested frame limit: it may lower
+// ARC_WS_MAX_FRAME_BYTES down to the floor, never raise it. 0 keeps the default.
+func (g *WSGateway) negotiateFrameLimit(requested int) int {
+	if requested <= 0 {
+		return g.maxFrameBytes
+	}
+	return min(max(requested, minMaxFrameBytes), g.maxFrameBytes)
 }
 
-func (g *WSGateway) onJoin(ctx context.Context, client *Client, env v1.Envelope) (*Conversation, error) {
+func (g *WSGateway) onJoin(ctx context.Context, client *Client, joined *joinedConversations, env v1.Envelope) (*Conversation, error) {
 	if err := g.requireAuthenticatedClient(client); err != nil {
 		return nil, err
 	}
@@ -395,14 +830,17 @@ func (g *WSGateway) onJoin(ctx context.Context, client *Client, env v1.Envelope)
 
 	convID := strings.TrimSpace(p.ConversationID)
 	if convID == "" {
-		return nil, errors.New("missing conversation_id")
+		return nil, errMissingConversationID
+	}
+	if err := g.admitJoin(client, joined, convID); err != nil {
+		return nil, err
 	}
 
 	kind := normalizeConversationKind(p.Kind)
 
 	if g.requireMember {
 		if client.UserID == "" {
-			return nil, errors.New("unauthorized")
+			return nil, errUnauthorized
 		}
 		if g.members == nil {
 			return nil, errors.New("membership store not configured")
@@ -410,7 +848,7 @@ func (g *WSGateway) onJoin(ctx context.Context, client *Client, env v1.Envelope)
 		info, err := g.members.GetConversation(ctx, convID)
 		if err != nil {
 			if errors.Is(err, ErrConversationNotFound) {
-				return nil, errors.New("conversation not found")
+				return nil, errConversationNotFound
 			}
 			return nil, err
 		}
@@ -424,6 +862,8 @@ func (g *WSGateway) onJoin(ctx context.Context, client *Client, env v1.Envelope)
 	}
 
 	conv := g.hub.GetOrCreateConversationWithKind(convID, kind)
+	// Live fanout waits until redeliver replayed what the user missed.
+	client.SealLane(conv.ID)
 	conv.Join(client)
 
 	echoPayload, _ := json.Marshal(v1.ConversationJoinPayload{
@@ -433,6 +873,7 @@ func (g *WSGateway) onJoin(ctx context.Context, client *Client, env v1.Envelope)
 	echo := mustNewEnvelope(v1.TypeConversationJoin, echoPayload, time.Now().UTC())
 
 	if !g.enqueue(ctx, client, echo) {
+		client.UnsealLane(conv.ID, 0)
 		conv.Leave(client.SessionID)
 		return nil, errors.New("backpressure: join echo")
 	}
@@ -460,22 +901,64 @@ func (g *WSGateway) onMessageSend(ctx context.Context, client *Client, conv *Con
 	if err := g.ensureConversationMember(ctx, client.UserID, conv.ID); err != nil {
 		return err
 	}
-
-	text := strings.TrimSpace(p.Text)
-	if text == "" {
-		return errors.New("empty text")
-	}
-	if len([]rune(text)) > maxMessageChars {
-		return fmt.Errorf("message too long: max=%d chars", maxMessageChars)
+	if err := g.checkSendSuspended(client, now); err != nil {
+		return err
 	}
 
-	res, err := g.store.AppendMessage(ctx, AppendMessageInput{
+	attachmentIDs, err := normalizeAttachmentIDs(p.AttachmentIDs)
+	if err != nil {
+		return err
+	}
+	in := AppendMessageInput{
 		ConversationID: p.ConversationID,
 		ClientMsgID:    p.ClientMsgID,
-		SenderSession:  client.SessionID,
-		Text:           text,
+		SenderSession:  client.ActorSession(),
 		Now:            now,
-	})
+
+		ReplyToServerMsgID: strings.TrimSpace(p.ReplyToServerMsgID),
+		AttachmentIDs:      attachmentIDs,
+	}
+	switch p.ContentType {
+	case "", v1.ContentTypeText:
+		text, err := g.messageText(p.Text)
+		if err != nil {
+			return err
+		}
+		entities, err := g.messageEntities(ctx, conv.ID, text)
+		if err != nil {
+			return err
+		}
+		if err := g.filterMessage(ctx, FilterInput{
+			ConversationID: conv.ID,
+			SenderUserID:   client.UserID,
+			Text:           text,
+			Entities:       entities,
+			AttachmentIDs:  attachmentIDs,
+		}); err != nil {
+			return err
+		}
+		in.Text, in.Entities = text, entities
+	case v1.ContentTypeE2EE:
+		// Encrypted content is stored as sent: no entities, filters or sanitization.
+		if in.Ciphertext, in.KeyIDs, err = e2eeContent(p); err != nil {
+			return err
+		}
+		in.ContentType = v1.ContentTypeE2EE
+	default:
+		return errors.New("unsupported content_type")
+	}
+	if len(attachmentIDs) > 0 {
+		if err := g.verifyAttachments(ctx, client, attachmentIDs); err != nil {
+			return err
+		}
+	}
+
+	appendStart := time.Now()
+	res, err := g.store.AppendMessage(ctx, in)
+	observeAppend(appendStart, err)
+	if errors.Is(err, ErrReplyTargetNotFound) {
+		return errors.New("reply_to_server_msg_id not found in conversation")
+	}
 	if err != nil {
 		return fmt.Errorf("store append: %w", err)
 	}
@@ -487,8 +970,9 @@ func (g *WSGateway) onMessageSend(ctx context.Context, client *Client, conv *Con
 		ClientMsgID:    stored.ClientMsgID,
 		ServerMsgID:    stored.ServerMsgID,
 		Seq:            stored.Seq,
+		ClockSkewMS:    clockSkewMS(env, now),
 	})
-	ack := mustNewEnvelope(v1.TypeMessageAck, ackPayload, now)
+	ack := withSeq(mustNewEnvelope(v1.TypeMessageAck, ackPayload, now), stored.ConversationID, stored.Seq)
 
 	if !g.enqueue(ctx, client, ack) {
 		return errors.New("backpressure: ack")
@@ -498,20 +982,159 @@ func (g *WSGateway) onMessageSend(ctx context.Context, client *Client, conv *Con
 		return nil
 	}
 
-	newPayload, _ := json.Marshal(v1.MessageNewPayload{
-		ConversationID: stored.ConversationID,
-		ClientMsgID:    stored.ClientMsgID,
-		ServerMsgID:    stored.ServerMsgID,
-		Seq:            stored.Seq,
-		Sender:         stored.SenderSession,
-		Text:           stored.Text,
-		ServerTS:       stored.ServerTS,
-	})
-	newEnv := mustNewEnvelope(v1.TypeMessageNew, newPayload, now)
+	newPayload, _ := json.Marshal(messagePayload(stored))
+	newEnv := withSeq(mustNewEnvelope(v1.TypeMessageNew, newPayload, now), stored.ConversationID, stored.Seq)
 	conv.Broadcast(newEnv)
+	g.notifyOffline(client, stored)
+	g.dispatchCommand(client, stored)
+	g.recordSend(ctx, client, stored, now)
 	return nil
 }
 
+func (g *WSGateway) onMessageEdit(ctx context.Context, client *Client, conv *Conversation, env v1.Envelope, now time.Time) error {
+	if err := g.requireAuthenticatedClient(client); err != nil {
+		return err
+	}
+
+	var p v1.MessageEditPayload
+	if err := json.Unmarshal(env.Payload, &p); err != nil {
+		return fmt.Errorf("invalid payload: %w", err)
+	}
+	if strings.TrimSpace(p.ConversationID) == "" || p.ConversationID != conv.ID {
+		return errors.New("invalid conversation_id")
+	}
+	if strings.TrimSpace(p.ServerMsgID) == "" {
+		return errors.New("missing server_msg_id")
+	}
+	if err := g.ensureConversationMember(ctx, client.UserID, conv.ID); err != nil {
+		return err
+	}
+
+	text, err := g.messageText(p.Text)
+	if err != nil {
+		return err
+	}
+
+	entities, err := g.messageEntities(ctx, conv.ID, text)
+	if err != nil {
+		return err
+	}
+	if err := g.filterMessage(ctx, FilterInput{
+		ConversationID: conv.ID,
+		SenderUserID:   client.UserID,
+		Text:           text,
+		Entities:       entities,
+		Edit:           true,
+	}); err != nil {
+		return err
+	}
+
+	res, err := g.store.EditMessage(ctx, EditMessageInput{
+		MessageActor:   MessageActor{ActorSession: client.ActorSession(), ActorUserID: client.UserID},
+		ConversationID: conv.ID,
+		ServerMsgID:    p.ServerMsgID,
+		Text:           text,
+		Entities:       entities,
+		Now:            now,
+	})
+	if err != nil {
+		return messageMutationError(err)
+	}
+
+	m := res.Stored
+	editedAt := m.ServerTS
+	if m.EditedAt != nil {
+		editedAt = *m.EditedAt
+	}
+	payload, _ := json.Marshal(v1.MessageEditedPayload{
+		ConversationID: m.ConversationID,
+		ServerMsgID:    m.ServerMsgID,
+		Seq:            m.Seq,
+		Text:           m.Text,
+		Version:        m.Version,
+		EditedAt:       editedAt,
+		Entities:       entityPayloads(m.Entities),
+	})
+	edited := mustNewEnvelope(v1.TypeMessageEdited, payload, now)
+	if !res.Changed {
+		// Retry of an applied edit: confirm to the caller only.
+		if !g.enqueue(ctx, client, edited) {
+			return errors.New("backpressure: message.edited")
+		}
+		return nil
+	}
+	conv.Broadcast(edited)
+	return nil
+}
+
+func (g *WSGateway) onMessageDelete(ctx context.Context, client *Client, conv *Conversation, env v1.Envelope, now time.Time) error {
+	if err := g.requireAuthenticatedClient(client); err != nil {
+		return err
+	}
+
+	var p v1.MessageDeletePayload
+	if err := json.Unmarshal(env.Payload, &p); err != nil {
+		return fmt.Errorf("invalid payload: %w", err)
+	}
+	if strings.TrimSpace(p.ConversationID) == "" || p.ConversationID != conv.ID {
+		return errors.New("invalid conversation_id")
+	}
+	if strings.TrimSpace(p.ServerMsgID) == "" {
+		return errors.New("missing server_msg_id")
+	}
+	if err := g.ensureConversationMember(ctx, client.UserID, conv.ID); err != nil {
+		return err
+	}
+
+	res, err := g.store.DeleteMessage(ctx, DeleteMessageInput{
+		MessageActor:   MessageActor{ActorSession: client.ActorSession(), ActorUserID: client.UserID},
+		ConversationID: conv.ID,
+		ServerMsgID:    p.ServerMsgID,
+		Now:            now,
+	})
+	if err != nil {
+		return messageMutationError(err)
+	}
+
+	m := res.Stored
+	deletedAt := now
+	if m.DeletedAt != nil {
+		deletedAt = *m.DeletedAt
+	}
+	payload, _ := json.Marshal(v1.MessageDeletedPayload{
+		ConversationID: m.ConversationID,
+		ServerMsgID:    m.ServerMsgID,
+		Seq:            m.Seq,
+		Version:        m.Version,
+		DeletedAt:      deletedAt,
+	})
+	deleted := mustNewEnvelope(v1.TypeMessageDeleted, payload, now)
+	if !res.Changed {
+		if !g.enqueue(ctx, client, deleted) {
+			return errors.New("backpressure: message.deleted")
+		}
+		return nil
+	}
+	conv.Broadcast(deleted)
+	return nil
+}
+
+// messageMutationError maps store errors to client-facing messages.
+func messageMutationError(err error) error {
+	switch {
+	case errors.Is(err, ErrMessageNotFound):
+		return errors.New("message not found")
+	case errors.Is(err, ErrMessageNotAuthor):
+		return errors.New("not the message author")
+	case errors.Is(err, ErrMessageDeleted):
+		return errors.New("message deleted")
+	case errors.Is(err, ErrMessageEncrypted):
+		return errors.New("encrypted messages cannot be edited")
+	default:
+		return fmt.Errorf("store: %w", err)
+	}
+}
+
 func (g *WSGateway) onHistoryFetch(ctx context.Context, client *Client, conv *Conversation, env v1.Envelope) error {
 	if err := g.requireAuthenticatedClient(client); err != nil {
 		return err
@@ -524,15 +1147,22 @@ func (g *WSGateway) onHistoryFetch(ctx context.Context, client *Client, conv *Co
 
 	convID := strings.TrimSpace(p.ConversationID)
 	if convID == "" {
-		return errors.New("missing conversation_id")
+		return errMissingConversationID
 	}
 	if convID != conv.ID {
-		return errors.New("not a member of conversation_id")
+		return errNotMember
 	}
 	if err := g.ensureConversationMember(ctx, client.UserID, convID); err != nil {
 		return err
 	}
 
+	if p.AfterSeq != nil && *p.AfterSeq < 0 {
+		return errors.New("invalid after_seq")
+	}
+	if p.BeforeSeq != nil && *p.BeforeSeq < 1 {
+		return errors.New("invalid before_seq")
+	}
+
 	limit := p.Limit
 	if limit <= 0 {
 		limit = wsDefaultHistoryLimit
@@ -544,6 +1174,7 @@ func (g *WSGateway) onHistoryFetch(ctx context.Context, client *Client, conv *Co
 	out, err := g.store.FetchHistory(ctx, FetchHistoryInput{
 		ConversationID: convID,
 		AfterSeq:       p.AfterSeq,
+		BeforeSeq:      p.BeforeSeq,
 		Limit:          limit,
 	})
 	if err != nil {
@@ -552,15 +1183,7 @@ func (g *WSGateway) onHistoryFetch(ctx context.Context, client *Client, conv *Co
 
 	msgs := make([]v1.MessageNewPayload, 0, len(out.Messages))
 	for _, m := range out.Messages {
-		msgs = append(msgs, v1.MessageNewPayload{
-			ConversationID: m.ConversationID,
-			ClientMsgID:    m.ClientMsgID,
-			ServerMsgID:    m.ServerMsgID,
-			Seq:            m.Seq,
-			Sender:         m.SenderSession,
-			Text:           m.Text,
-			ServerTS:       m.ServerTS,
-		})
+		msgs = append(msgs, messagePayload(m))
 	}
 
 	chunkPayload, _ := json.Marshal(v1.ConversationHistoryChunkPayload{
@@ -576,6 +1199,109 @@ func (g *WSGateway) onHistoryFetch(ctx context.Context, client *Client, conv *Co
 	return nil
 }
 
+// onReadUpdate advances the caller's read cursor and fans out read.state to the
+// conversation (receipts) and to the caller's other devices (unread sync).
+func (g *WSGateway) onReadUpdate(ctx context.Context, client *Client, env v1.Envelope) error {
+	if err := g.requireAuthenticatedClient(client); err != nil {
+		return err
+	}
+	if client.UserID == "" {
+		return errUnauthorized
+	}
+	cursors, ok := g.members.(ReadCursorStore)
+	if !ok {
+		return errors.New("read cursors not configured")
+	}
+
+	var p v1.ReadUpdatePayload
+	if err := json.Unmarshal(env.Payload, &p); err != nil {
+		return fmt.Errorf("invalid payload: %w", err)
+	}
+
+	convID := strings.TrimSpace(p.ConversationID)
+	if convID == "" {
+		return errMissingConversationID
+	}
+	if p.UpToSeq < 0 {
+		return errors.New("invalid up_to_seq")
+	}
+
+	cur, err := cursors.MarkRead(ctx, client.UserID, convID, p.UpToSeq)
+	if errors.Is(err, ErrMembershipRequired) {
+		return errNotMember
+	}
+	if err != nil {
+		return err
+	}
+
+	statePayload, _ := json.Marshal(v1.ReadStatePayload{
+		ConversationID: cur.ConversationID,
+		UserID:         cur.UserID,
+		LastReadSeq:    cur.LastReadSeq,
+		LastSeq:        cur.LastSeq,
+		UnreadCount:    cur.UnreadCount(),
+		UpdatedAt:      cur.UpdatedAt,
+	})
+	state := mustNewEnvelope(v1.TypeReadState, statePayload, time.Now().UTC())
+
+	if !cur.Advanced {
+		// Nothing changed for others; still confirm the current cursor to the caller.
+		if !g.enqueue(ctx, client, state) {
+			return errors.New("backpressure: read.state")
+		}
+		return nil
+	}
+
+	conv := g.hub.Conversation(convID)
+	conv.Broadcast(state)
+	g.hub.SendToUser(client.UserID, state, conv)
+	return nil
+}
+
+// messagePayload renders a stored message for message.new and history chunks.
+func messagePayload(m StoredMessage) v1.MessageNewPayload {
+	p := v1.MessageNewPayload{
+		ConversationID: m.ConversationID,
+		ClientMsgID:    m.ClientMsgID,
+		ServerMsgID:    m.ServerMsgID,
+		Seq:            m.Seq,
+		Sender:         m.SenderSession,
+		SenderBotID:    m.SenderBotID,
+		Text:           m.Text,
+		ServerTS:       m.ServerTS,
+		Version:        m.Version,
+		EditedAt:       m.EditedAt,
+		Deleted:        m.Deleted(),
+
+		ReplyToServerMsgID: m.ReplyToServerMsgID,
+		AttachmentIDs:      m.AttachmentIDs,
+		Entities:           entityPayloads(m.Entities),
+	}
+	if m.Encrypted() {
+		p.ContentType = v1.ContentTypeE2EE
+		p.Ciphertext = m.Ciphertext
+		p.KeyIDs = m.KeyIDs
+	}
+	return p
+}
+
+func entityPayloads(entities []MessageEntity) []v1.MessageEntity {
+	if len(entities) == 0 {
+		return nil
+	}
+	out := make([]v1.MessageEntity, 0, len(entities))
+	for _, e := range entities {
+		out = append(out, v1.MessageEntity{
+			Type:   e.Type,
+			Offset: e.Offset,
+			Length: e.Length,
+			UserID: e.UserID,
+			URL:    e.URL,
+		})
+	}
+	return out
+}
+
 // ---- send helpers ----
 
 func (g *WSGateway) trySendError(ctx context.Context, client *Client, code, msg string) {
@@ -584,17 +1310,35 @@ func (g *WSGateway) trySendError(ctx context.Context, client *Client, code, msg
 	_ = g.enqueue(ctx, client, env)
 }
 
+// trySendFailure reports err under code, as message_rejected with the rejection
+// reason when a MessageFilter refused the message, or as send_suspended.
+func (g *WSGateway) trySendFailure(ctx context.Context, client *Client, code string, err error) {
+	var suspended *SendSuspendedError
+	if errors.As(err, &suspended) {
+		g.trySendError(ctx, client, ErrorCodeSendSuspended, suspended.Error())
+		return
+	}
+	var rej *RejectionError
+	if !errors.As(err, &rej) {
+		g.trySendError(ctx, client, code, err.Error())
+		return
+	}
+	p, _ := json.Marshal(v1.ErrorPayload{
+		Code:        ErrorCodeMessageRejected,
+		Reason:      rej.Code,
+		Message:     rej.Message,
+		MaxChars:    rej.MaxChars,
+		ActualChars: rej.ActualChars,
+	})
+	env := mustNewEnvelope(v1.TypeError, p, time.Now().UTC())
+	_ = g.enqueue(ctx, client, env)
+}
+
 func (g *WSGateway) enqueue(ctx context.Context, client *Client, env v1.Envelope) bool {
-	select {
-	case <-ctx.Done():
-		return false
-	case <-client.Done():
-		return false
-	case client.Send <- env:
-		return true
-	default:
+	if ctx.Err() != nil {
 		return false
 	}
+	return client.Offer(env)
 }
 
 // ---- envelope IO ----
@@ -614,11 +1358,18 @@ func mustNewEnvelope(typ string, payload json.RawMessage, ts time.Time) v1.Envel
 	}
 }
 
-func readEnvelope(ctx context.Context, conn *websocket.Conn) (v1.Envelope, error) {
+// readEnvelope reads one envelope: JSON for v1, MessagePack binary frames for v2.
+func readEnvelope(ctx context.Context, conn *websocket.Conn, binary bool) (v1.Envelope, error) {
 	mt, data, err := conn.Read(ctx)
 	if err != nil {
 		return v1.Envelope{}, err
 	}
+	if binary {
+		if mt != websocket.MessageBinary {
+			return v1.Envelope{}, fmt.Errorf("%w: expected binary message", v2.ErrMalformed)
+		}
+		return v2.Unmarshal(data)
+	}
 	if mt != websocket.MessageText && mt != websocket.MessageBinary {
 		return v1.Envelope{}, fmt.Errorf("unsupported message type: %v", mt)
 	}
@@ -629,9 +1380,14 @@ func readEnvelope(ctx context.Context, conn *websocket.Conn) (v1.Envelope, error
 	return env, nil
 }
 
-func writeEnvelope(parent context.Context, conn *websocket.Conn, env v1.Envelope, timeout time.Duration) error {
-	ctx, cancel := context.WithTimeout(parent, timeout)
-	defer cancel()
+func writeEnvelope(ctx context.Context, conn *websocket.Conn, env v1.Envelope, binary bool) error {
+	if binary {
+		b, err := v2.Marshal(env)
+		if err != nil {
+			return err
+		}
+		return conn.Write(ctx, websocket.MessageBinary, b)
+	}
 
 	b, err := json.Marshal(env)
 	if err != nil {
@@ -650,10 +1406,11 @@ const (
 	readErrCtxDone
 	readErrConnClosed
 	readErrBadJSON
+	readErrBadFrame
 )
 
 func classifyReadErr(err error) readErrKind {
-	if websocket.CloseStatus(err) != -1 {
+	if websocket.CloseStatus(err) != -1 || errors.Is(err, errPeerClosed) {
 		return readErrClose
 	}
 	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
@@ -662,6 +1419,9 @@ func classifyReadErr(err error) readErrKind {
 	if errors.Is(err, net.ErrClosed) || errors.Is(err, io.EOF) {
 		return readErrConnClosed
 	}
+	if errors.Is(err, v2.ErrMalformed) || errors.Is(err, realtimepb.ErrMalformed) {
+		return readErrBadFrame
+	}
 
 	s := err.Error()
 	if strings.Contains(s, "unexpected end of JSON input") || strings.Contains(s, "invalid character") {
@@ -673,21 +1433,22 @@ func classifyReadErr(err error) readErrKind {
 // ---- origin policy ----
 
 func (g *WSGateway) enforceOrigin(r *http.Request) error {
+	tun := g.tunables.Load()
 	origin := strings.TrimSpace(r.Header.Get("Origin"))
 	if origin == "" {
-		if g.originRequired {
+		if tun.originRequired {
 			return errors.New("missing origin")
 		}
 		return nil
 	}
 
-	if len(g.allowedOrigins) == 0 {
+	if len(tun.allowedOrigins) == 0 {
 		return errors.New("origin not allowed (no allowlist)")
 	}
 
 	originHost := originHostOnly(origin)
 
-	for _, a := range g.allowedOrigins {
+	for _, a := range tun.allowedOrigins {
 		a = strings.TrimSpace(a)
 		if a == "" {
 			continue
@@ -711,7 +1472,7 @@ func (g *WSGateway) requireAuthenticatedClient(client *Client) error {
 		return nil
 	}
 	if client == nil || strings.TrimSpace(client.UserID) == "" {
-		return errors.New("unauthorized")
+		return errUnauthorized
 	}
 	return nil
 }
@@ -721,7 +1482,7 @@ func (g *WSGateway) ensureConversationMember(ctx context.Context, userID, conver
 		return nil
 	}
 	if strings.TrimSpace(userID) == "" {
-		return errors.New("unauthorized")
+		return errUnauthorized
 	}
 	if g.members == nil {
 		return errors.New("membership store not configured")
@@ -731,12 +1492,25 @@ func (g *WSGateway) ensureConversationMember(ctx context.Context, userID, conver
 	case err == nil:
 		return nil
 	case errors.Is(err, ErrMembershipRequired), errors.Is(err, ErrConversationNotFound):
-		return errors.New("not a member of conversation_id")
+		return errNotMember
 	default:
 		return err
 	}
 }
 
+// authenticateUpgrade validates the credentials of an upgrade request: a one-time
+// ticket in the ticket query parameter or, without one, an access token.
+func (g *WSGateway) authenticateUpgrade(r *http.Request, now time.Time) (session.AccessClaims, error) {
+	if ticket := strings.TrimSpace(r.URL.Query().Get(g.ticketParam)); ticket != "" {
+		return g.auth.RedeemWSTicket(r.Context(), now, ticket)
+	}
+	token, err := g.accessTokenFromRequest(r)
+	if err != nil {
+		return session.AccessClaims{}, err
+	}
+	return g.auth.ValidateAccessToken(r.Context(), token, now)
+}
+
 func (g *WSGateway) accessTokenFromRequest(r *http.Request) (string, error) {
 	if r == nil {
 		return "", errors.New("missing request")
@@ -746,6 +1520,12 @@ func (g *WSGateway) accessTokenFromRequest(r *http.Request) (string, error) {
 		return t, nil
 	}
 
+	if g.authSubproto {
+		if t, err := normalizeAccessTokenWS(subprotocolToken(r.Header)); err == nil {
+			return t, nil
+		}
+	}
+
 	if g.authCookieName != "" {
 		c, err := r.Cookie(g.authCookieName)
 		if err == nil {
@@ -764,6 +1544,47 @@ func (g *WSGateway) accessTokenFromRequest(r *http.Request) (string, error) {
 	return "", errors.New("missing access token")
 }
 
+// subprotocolToken returns the token of the first "arc.token.<token>" entry of
+// Sec-WebSocket-Protocol, for browsers that cannot set Authorization on the upgrade.
+func subprotocolToken(h http.Header) string {
+	for _, v := range h.Values("Sec-WebSocket-Protocol") {
+		for _, p := range strings.Split(v, ",") {
+			if t, ok := cutTokenSubprotocol(strings.TrimSpace(p)); ok {
+				return t
+			}
+		}
+	}
+	return ""
+}
+
+// stripTokenSubprotocols removes the "arc.token.<token>" offers from Sec-WebSocket-Protocol.
+func stripTokenSubprotocols(h http.Header) {
+	values := h.Values("Sec-WebSocket-Protocol")
+	if len(values) == 0 {
+		return
+	}
+	var kept []string
+	for _, v := range values {
+		for _, p := range strings.Split(v, ",") {
+			p = strings.TrimSpace(p)
+			if _, ok := cutTokenSubprotocol(p); !ok && p != "" {
+				kept = append(kept, p)
+			}
+		}
+	}
+	h.Del("Sec-WebSocket-Protocol")
+	if len(kept) > 0 {
+		h.Set("Sec-WebSocket-Protocol", strings.Join(kept, ", "))
+	}
+}
+
+func cutTokenSubprotocol(p string) (string, bool) {
+	if len(p) < len(wsTokenSubprotocolPrefix) || !strings.EqualFold(p[:len(wsTokenSubprotocolPrefix)], wsTokenSubprotocolPrefix) {
+		return "", false
+	}
+	return p[len(wsTokenSubprotocolPrefix):], true
+}
+
 func normalizeAccessTokenWS(raw string) (string, error) {
 	t := strings.TrimSpace(raw)
 	if t == "" {
@@ -804,8 +1625,8 @@ func originHostOnly(s string) string {
 
 // ---- env helpers ----
 
-func envBoolWS(key string, def bool) bool {
-	v := strings.TrimSpace(os.Getenv(key))
+func envBoolWS(lookup config.Lookup, key string, def bool) bool {
+	v := strings.TrimSpace(lookup.Get(key))
 	if v == "" {
 		return def
 	}
@@ -816,8 +1637,8 @@ func envBoolWS(key string, def bool) bool {
 	return b
 }
 
-func envIntWS(key string, def int) int {
-	v := strings.TrimSpace(os.Getenv(key))
+func envIntWS(lookup config.Lookup, key string, def int) int {
+	v := strings.TrimSpace(lookup.Get(key))
 	if v == "" {
 		return def
 	}
@@ -828,8 +1649,8 @@ func envIntWS(key string, def int) int {
 	return n
 }
 
-func envDurationWS(key string, def time.Duration) time.Duration {
-	v := strings.TrimSpace(os.Getenv(key))
+func envDurationWS(lookup config.Lookup, key string, def time.Duration) time.Duration {
+	v := strings.TrimSpace(lookup.Get(key))
 	if v == "" {
 		return def
 	}
@@ -840,8 +1661,8 @@ func envDurationWS(key string, def time.Duration) time.Duration {
 	return d
 }
 
-func envCSVWS(key string, def string) []string {
-	raw := strings.TrimSpace(os.Getenv(key))
+func envCSVWS(lookup config.Lookup, key string, def string) []string {
+	raw := strings.TrimSpace(lookup.Get(key))
 	if raw == "" {
 		raw = def
 	}
@@ -861,8 +1682,8 @@ func envCSVWS(key string, def string) []string {
 	return out
 }
 
-func envTokenNameWS(key string) string {
-	v := strings.TrimSpace(os.Getenv(key))
+func envTokenNameWS(lookup config.Lookup, key string) string {
+	v := strings.TrimSpace(lookup.Get(key))
 	if v == "" {
 		return ""
 	}
@@ -340,6 +340,72 @@ func TestWSGateway_RequireAuth_CookieFallback(t *testing.T) {
 	_ = conn.Close(websocket.StatusNormalClosure, "bye")
 }
 
+func TestWSGateway_RequireAuth_SubprotocolToken(t *testing.T) {
+	t.Setenv("ARC_WS_DEV_INSECURE", "false")
+	t.Setenv("ARC_WS_REQUIRE_AUTH", "true")
+	t.Setenv("ARC_WS_REQUIRE_MEMBERSHIP", "false")
+	t.Setenv("ARC_WS_ORIGIN_REQUIRED", "false")
+
+	now := time.Now().UTC()
+	row := session.Row{
+		ID:        "sess-auth-10",
+		UserID:    "user-auth-10",
+		CreatedAt: now,
+		ExpiresAt: now.Add(1 * time.Hour),
+		Platform:  session.PlatformWeb,
+	}
+
+	authSvc, tokens := newWSAuthService(t, row, 15*time.Minute)
+	accessToken, _, err := tokens.Issue(row.UserID, row.ID, now)
+	if err != nil {
+		t.Fatalf("issue token: %v", err)
+	}
+
+	gw := newWSAuthGateway(t, authSvc)
+	ts := startWSTestServer(t, gw)
+	defer ts.Close()
+
+	conn, resp, err := dialWS(t, ts.URL, wsDialInput{TokenProtocol: accessToken})
+	if resp != nil && resp.Body != nil {
+		_ = resp.Body.Close()
+	}
+	if err != nil {
+		t.Fatalf("subprotocol authorized dial failed: %v", err)
+	}
+	// The token offer is stripped; only the protocol version is echoed.
+	if got := conn.Subprotocol(); got != wsSubprotocolV1 {
+		t.Fatalf("expected %q to be selected, got %q", wsSubprotocolV1, got)
+	}
+	_ = conn.Close(websocket.StatusNormalClosure, "bye")
+
+	t.Setenv("ARC_WS_AUTH_SUBPROTOCOL", "false")
+	disabled := startWSTestServer(t, newWSAuthGateway(t, authSvc))
+	defer disabled.Close()
+	_, resp, err = dialWS(t, disabled.URL, wsDialInput{TokenProtocol: accessToken})
+	if resp != nil && resp.Body != nil {
+		_ = resp.Body.Close()
+	}
+	if err == nil || resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 with subprotocol tokens disabled, got %v", err)
+	}
+}
+
+func TestStripTokenSubprotocols(t *testing.T) {
+	h := http.Header{}
+	h.Add("Sec-WebSocket-Protocol", "arc.realtime.v2, ARC.TOKEN.v4.public.abc")
+	h.Add("Sec-WebSocket-Protocol", "arc.realtime.v1")
+	if got := subprotocolToken(h); got != "v4.public.abc" {
+		t.Fatalf("unexpected token %q", got)
+	}
+	stripTokenSubprotocols(h)
+	if got := h.Values("Sec-WebSocket-Protocol"); len(got) != 1 || got[0] != "arc.realtime.v2, arc.realtime.v1" {
+		t.Fatalf("unexpected protocols %q", got)
+	}
+	if subprotocolToken(h) != "" {
+		t.Fatalf("expected no token after stripping")
+	}
+}
+
 func TestWSGateway_RequireAuth_RejectsOversizedToken(t *testing.T) {
 	t.Setenv("ARC_WS_DEV_INSECURE", "false")
 	t.Setenv("ARC_WS_REQUIRE_AUTH", "true")
@@ -397,6 +463,8 @@ type wsDialInput struct {
 	QueryValue  string
 	CookieName  string
 	CookieValue string
+	// TokenProtocol is offered as an "arc.token.<token>" subprotocol after arc.realtime.v1.
+	TokenProtocol string
 }
 
 func dialWS(t *testing.T, baseHTTPURL string, in wsDialInput) (*websocket.Conn, *http.Response, error) {
@@ -428,8 +496,12 @@ func dialWS(t *testing.T, baseHTTPURL string, in wsDialInput) (*websocket.Conn,
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	protocols := []string{wsSubprotocolV1}
+	if in.TokenProtocol != "" {
+		protocols = append(protocols, wsTokenSubprotocolPrefix+in.TokenProtocol)
+	}
 	return websocket.Dial(ctx, u.String(), &websocket.DialOptions{
-		Subprotocols: []string{wsSubprotocolV1},
+		Subprotocols: protocols,
 		HTTPHeader:   h,
 	})
 }
@@ -527,6 +599,8 @@ func (s *wsAuthStore) MarkRotated(context.Context, time.Time, string, string) er
 
 func (s *wsAuthStore) Touch(context.Context, time.Time, string) error { return nil }
 
+func (s *wsAuthStore) TouchBatch(context.Context, time.Time, []string) (int64, error) { return 0, nil }
+
 func (s *wsAuthStore) Revoke(context.Context, time.Time, string, string) error {
 	return errors.New("not implemented")
 }
@@ -535,4 +609,20 @@ func (s *wsAuthStore) RevokeAll(context.Context, time.Time, string, string) erro
 	return errors.New("not implemented")
 }
 
+func (s *wsAuthStore) SetBindingNonce(context.Context, time.Time, string, string) error {
+	return errors.New("not implemented")
+}
+
+func (s *wsAuthStore) ListActiveByUser(context.Context, time.Time, string) ([]session.Info, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (s *wsAuthStore) CountByFilter(context.Context, time.Time, session.Filter) (int64, error) {
+	return 0, errors.New("not implemented")
+}
+
+func (s *wsAuthStore) RevokeBatchByFilter(context.Context, time.Time, session.Filter, string, int) (int64, error) {
+	return 0, errors.New("not implemented")
+}
+
 var _ session.Store = (*wsAuthStore)(nil)
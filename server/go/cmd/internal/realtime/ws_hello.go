@@ -0,0 +1,69 @@
+package realtime
+
+import (
+	"strings"
+
+	v1 "arc/shared/contracts/realtime/v1"
+)
+
+const (
+	// serverCapabilities are the hello capabilities every transport supports.
+	// CapReactions is not served yet.
+	serverCapabilities = v1.CapResume | v1.CapMultiConversation
+
+	// optInCapabilities change behavior older clients rely on, so they are only
+	// enabled when announced.
+	optInCapabilities = v1.CapMultiConversation
+
+	// maxClientVersionLen bounds the client_version kept from hello.
+	maxClientVersionLen = 64
+
+	// clientPlatformOther replaces platforms outside knownClientPlatforms.
+	clientPlatformOther = "other"
+)
+
+// knownClientPlatforms are the hello platforms kept as sent; they match the auth
+// session platforms.
+var knownClientPlatforms = []string{"web", "ios", "android", "desktop"}
+
+// negotiateHello derives the connection's hello outcome from p: the client's
+// build and the capabilities both sides support. A client that announces no
+// capabilities gets every one the connection offers except the opt-in ones.
+func negotiateHello(p v1.HelloPayload, offered v1.Capabilities) ClientHello {
+	caps := offered &^ optInCapabilities
+	if p.Capabilities != 0 {
+		caps = offered & p.Capabilities
+	}
+	return ClientHello{
+		Version:      normalizeClientVersion(p.ClientVersion),
+		Platform:     normalizeClientPlatform(p.Platform),
+		Capabilities: caps,
+	}
+}
+
+// normalizeClientVersion keeps printable ASCII and at most maxClientVersionLen bytes.
+func normalizeClientVersion(v string) string {
+	v = strings.TrimSpace(v)
+	for i := 0; i < len(v); i++ {
+		if v[i] < 0x20 || v[i] > 0x7e {
+			return ""
+		}
+	}
+	if len(v) > maxClientVersionLen {
+		v = v[:maxClientVersionLen]
+	}
+	return v
+}
+
+func normalizeClientPlatform(p string) string {
+	p = strings.ToLower(strings.TrimSpace(p))
+	if p == "" {
+		return ""
+	}
+	for _, known := range knownClientPlatforms {
+		if p == known {
+			return p
+		}
+	}
+	return clientPlatformOther
+}
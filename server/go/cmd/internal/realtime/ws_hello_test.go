@@ -0,0 +1,69 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "arc/shared/contracts/realtime/v1"
+)
+
+func TestNegotiateHello(t *testing.T) {
+	offered := v1.CapResume | v1.CapCompression
+
+	h := negotiateHello(v1.HelloPayload{ClientVersion: " 2.4.1 (77) ", Platform: "iOS", Capabilities: v1.CapCompression | v1.CapReactions}, offered)
+	if h.Version != "2.4.1 (77)" || h.Platform != "ios" || h.Capabilities != v1.CapCompression {
+		t.Fatalf("unexpected hello %+v", h)
+	}
+
+	// Announcing nothing keeps every offered capability.
+	if h := negotiateHello(v1.HelloPayload{}, offered); h.Capabilities != offered || h.Platform != "" {
+		t.Fatalf("unexpected legacy hello %+v", h)
+	}
+
+	h = negotiateHello(v1.HelloPayload{ClientVersion: "bad\x00version", Platform: "smart-fridge"}, offered)
+	if h.Version != "" || h.Platform != clientPlatformOther {
+		t.Fatalf("unexpected normalization %+v", h)
+	}
+	if v := normalizeClientVersion(strings.Repeat("9", 100)); len(v) != maxClientVersionLen {
+		t.Fatalf("version not truncated: %d bytes", len(v))
+	}
+}
+
+func TestOnHello_Capabilities(t *testing.T) {
+	t.Parallel()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	g := NewWSGateway(log, NewHub(log), NewInMemoryStore(), nil, nil)
+	defer g.stopReloading()
+	g.resumeTokens = fakeResumeTokens{}
+
+	hello := func(p v1.HelloPayload) (*Client, v1.HelloAckPayload) {
+		t.Helper()
+		client := NewClient("u1", "s1", 8)
+		payload, _ := json.Marshal(p)
+		if _, err := g.onHello(context.Background(), client, mustNewEnvelope(v1.TypeHello, payload, time.Now().UTC())); err != nil {
+			t.Fatalf("hello: %v", err)
+		}
+		var ack v1.HelloAckPayload
+		_ = json.Unmarshal(drainEnvelopes(client)[0].Payload, &ack)
+		return client, ack
+	}
+
+	client, ack := hello(v1.HelloPayload{ClientVersion: "1.0.0", Platform: "web", Capabilities: v1.CapResume | v1.CapReactions})
+	if ack.ProtocolVersion != v1.Version || ack.Capabilities != v1.CapResume || ack.ResumeToken == "" {
+		t.Fatalf("unexpected ack %+v", ack)
+	}
+	if got := client.Hello(); got.Version != "1.0.0" || got.Platform != "web" || got.Capabilities != v1.CapResume {
+		t.Fatalf("unexpected recorded hello %+v", got)
+	}
+
+	// A client that cannot resume gets no resume token.
+	if _, ack := hello(v1.HelloPayload{Capabilities: v1.CapReactions}); ack.Capabilities != 0 || ack.ResumeToken != "" {
+		t.Fatalf("unexpected ack without resume %+v", ack)
+	}
+}
@@ -0,0 +1,58 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	v1 "arc/shared/contracts/realtime/v1"
+)
+
+// wsDefaultMaxInboxConversations bounds the conversations one inbox subscription
+// follows (ARC_WS_INBOX_MAX_CONVERSATIONS).
+const wsDefaultMaxInboxConversations = 500
+
+// onInboxSubscribe handles inbox.subscribe: the session receives previews of new
+// messages and membership events of the user's most recently active
+// conversations without joining them. Subscribing again reloads the set.
+func (g *WSGateway) onInboxSubscribe(ctx context.Context, client *Client) error {
+	if err := g.requireAuthenticatedClient(client); err != nil {
+		return err
+	}
+	if client.UserID == "" {
+		return errUnauthorized
+	}
+	lister, ok := g.members.(UserConversationIDLister)
+	if !ok {
+		return errors.New("inbox not configured")
+	}
+
+	ids, err := lister.UserConversationIDs(ctx, client.UserID, g.maxInboxConvs+1)
+	if err != nil {
+		g.log.Error("ws.inbox.list.fail", "user_id", client.UserID, "err", err)
+		return errors.New("inbox unavailable")
+	}
+	truncated := len(ids) > g.maxInboxConvs
+	if truncated {
+		ids = ids[:g.maxInboxConvs]
+	}
+	g.hub.SubscribeInbox(client, ids)
+
+	echoPayload, _ := json.Marshal(v1.InboxSubscribePayload{Conversations: len(ids), Truncated: truncated})
+	if !g.enqueue(ctx, client, mustNewEnvelope(v1.TypeInboxSubscribe, echoPayload, time.Now().UTC())) {
+		return errors.New("backpressure: inbox.subscribe echo")
+	}
+	return nil
+}
+
+// onInboxUnsubscribe handles inbox.unsubscribe.
+func (g *WSGateway) onInboxUnsubscribe(ctx context.Context, client *Client) error {
+	if !g.hub.UnsubscribeInbox(client.SessionID) {
+		return errors.New("inbox not subscribed")
+	}
+	if !g.enqueue(ctx, client, mustNewEnvelope(v1.TypeInboxUnsubscribe, json.RawMessage(`{}`), time.Now().UTC())) {
+		return errors.New("backpressure: inbox.unsubscribe echo")
+	}
+	return nil
+}
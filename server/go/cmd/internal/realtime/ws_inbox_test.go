@@ -0,0 +1,159 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	v1 "arc/shared/contracts/realtime/v1"
+)
+
+// inboxTestMembers lists fixed conversations per user; other membership calls
+// are not expected with ARC_WS_REQUIRE_MEMBERSHIP=false.
+type inboxTestMembers struct {
+	MembershipStore
+	convs map[string][]string
+}
+
+func (m inboxTestMembers) UserConversationIDs(_ context.Context, userID string, limit int) ([]string, error) {
+	ids := m.convs[userID]
+	return ids[:min(limit, len(ids))], nil
+}
+
+func newInboxTestGateway(t *testing.T, convs map[string][]string) (*WSGateway, context.Context, *sync.WaitGroup) {
+	t.Helper()
+	t.Setenv("ARC_WS_REQUIRE_MEMBERSHIP", "false")
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	g := NewWSGateway(log, NewHub(log), NewInMemoryStore(), nil, inboxTestMembers{convs: convs})
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	t.Cleanup(func() {
+		cancel()
+		wg.Wait()
+		g.stopReloading()
+	})
+	return g, ctx, wg
+}
+
+func TestWSGateway_Inbox_PreviewsAndMembership(t *testing.T) {
+	g, ctx, wg := newInboxTestGateway(t, map[string][]string{"alice": {"c1", "c2"}})
+
+	alice := startJoinedTestSession(t, ctx, wg, g, "alice", "s-alice", 0)
+	alice.send(v1.TypeInboxSubscribe, struct{}{})
+	var sub v1.InboxSubscribePayload
+	_ = json.Unmarshal(alice.expect(v1.TypeInboxSubscribe).Payload, &sub)
+	if sub.Conversations != 2 || sub.Truncated {
+		t.Fatalf("unexpected subscribe echo %+v", sub)
+	}
+
+	bob := startJoinedTestSession(t, ctx, wg, g, "bob", "s-bob", v1.CapMultiConversation)
+	bob.join("c1")
+	bob.join("c2")
+	bob.sendMessage("c1", "m1")
+	bob.expect(v1.TypeMessageAck)
+	bob.expect(v1.TypeMessageNew)
+
+	var preview v1.InboxMessagePayload
+	_ = json.Unmarshal(alice.expect(v1.TypeInboxMessage).Payload, &preview)
+	if preview.ConversationID != "c1" || preview.Seq != 1 || preview.Sender != "s-bob" || preview.Preview != "hi m1" {
+		t.Fatalf("unexpected preview %+v", preview)
+	}
+
+	// A joined conversation delivers message.new instead of a preview.
+	alice.join("c2")
+	bob.sendMessage("c2", "m2")
+	bob.expect(v1.TypeMessageAck)
+	bob.expect(v1.TypeMessageNew)
+	alice.expect(v1.TypeMessageNew)
+	alice.quiet()
+
+	// Being added starts following the conversation; being removed stops it.
+	g.broadcastMemberAdded("c3", "alice", MemberRoleMember, "bob")
+	alice.expect(v1.TypeMemberAdded)
+	bob.join("c3")
+	bob.sendMessage("c3", "m3")
+	bob.expect(v1.TypeMessageAck)
+	bob.expect(v1.TypeMessageNew)
+	alice.expect(v1.TypeInboxMessage)
+
+	g.broadcastMemberRemoved("c1", "alice", "bob")
+	bob.expect(v1.TypeMemberRemoved)
+	alice.expect(v1.TypeMemberRemoved)
+	bob.sendMessage("c1", "m4")
+	bob.expect(v1.TypeMessageAck)
+	bob.expect(v1.TypeMessageNew)
+	alice.quiet()
+
+	alice.send(v1.TypeInboxUnsubscribe, struct{}{})
+	alice.expect(v1.TypeInboxUnsubscribe)
+	bob.sendMessage("c3", "m5")
+	bob.expect(v1.TypeMessageAck)
+	bob.expect(v1.TypeMessageNew)
+	alice.quiet()
+
+	alice.send(v1.TypeInboxUnsubscribe, struct{}{})
+	var errp v1.ErrorPayload
+	_ = json.Unmarshal(alice.expect(v1.TypeError).Payload, &errp)
+	if errp.Code != "inbox_failed" {
+		t.Fatalf("expected inbox_failed, got %+v", errp)
+	}
+}
+
+func TestWSGateway_Inbox_Truncated(t *testing.T) {
+	t.Setenv("ARC_WS_INBOX_MAX_CONVERSATIONS", "2")
+	g, ctx, wg := newInboxTestGateway(t, map[string][]string{"alice": {"c1", "c2", "c3"}})
+
+	alice := startJoinedTestSession(t, ctx, wg, g, "alice", "s-alice", 0)
+	alice.send(v1.TypeInboxSubscribe, struct{}{})
+	var sub v1.InboxSubscribePayload
+	_ = json.Unmarshal(alice.expect(v1.TypeInboxSubscribe).Payload, &sub)
+	if sub.Conversations != 2 || !sub.Truncated {
+		t.Fatalf("unexpected subscribe echo %+v", sub)
+	}
+}
+
+func TestWSGateway_Inbox_RequiresLister(t *testing.T) {
+	g, ctx, wg := newJoinedTestGateway(t)
+
+	alice := startJoinedTestSession(t, ctx, wg, g, "alice", "s-alice", 0)
+	alice.send(v1.TypeInboxSubscribe, struct{}{})
+	var errp v1.ErrorPayload
+	_ = json.Unmarshal(alice.expect(v1.TypeError).Payload, &errp)
+	if errp.Code != "inbox_failed" || errp.Message != "inbox not configured" {
+		t.Fatalf("expected inbox_failed, got %+v", errp)
+	}
+}
+
+func TestHub_Inbox_RemoteBroadcast(t *testing.T) {
+	t.Parallel()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	broker := newMemBroker()
+	hubA, hubB := NewHub(log), NewHub(log)
+	hubA.UseBroker(ctx, broker, "test.inbox")
+	hubB.UseBroker(ctx, broker, "test.inbox")
+	broker.waitSubscribers(t, "test.inbox", 2)
+
+	client := NewClient("u2", "sb", 8)
+	hubB.SubscribeInbox(client, []string{"c1"})
+
+	p, _ := json.Marshal(v1.MessageNewPayload{ConversationID: "c1", Seq: 7, Sender: "u1", Text: strings.Repeat("é", inboxPreviewRunes+10)})
+	hubA.Broadcast("c1", mustNewEnvelope(v1.TypeMessageNew, p, time.Now().UTC()))
+
+	got := recvEnvelope(t, client)
+	var preview v1.InboxMessagePayload
+	if err := json.Unmarshal(got.Payload, &preview); err != nil || got.Type != v1.TypeInboxMessage {
+		t.Fatalf("unexpected envelope %+v", got)
+	}
+	if preview.Seq != 7 || preview.Preview != strings.Repeat("é", inboxPreviewRunes) {
+		t.Fatalf("unexpected preview %+v", preview)
+	}
+}
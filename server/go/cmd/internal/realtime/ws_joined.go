@@ -0,0 +1,168 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "arc/shared/contracts/realtime/v1"
+)
+
+// wsDefaultMaxJoinedConversations bounds the conversations one connection with
+// the multi_conversation capability stays joined to (ARC_WS_MAX_JOINED_CONVERSATIONS).
+const wsDefaultMaxJoinedConversations = 50
+
+// errTooManyJoined rejects a join over the per-connection limit.
+var errTooManyJoined = errors.New("too many joined conversations")
+
+// joinedConversations are the conversations a session receives the fanout of,
+// oldest join first. The read loop changes it; shutdown may empty it concurrently.
+type joinedConversations struct {
+	mu    sync.Mutex
+	convs []*Conversation
+}
+
+func (j *joinedConversations) len() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return len(j.convs)
+}
+
+func (j *joinedConversations) has(conversationID string) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return slices.ContainsFunc(j.convs, func(c *Conversation) bool { return c.ID == conversationID })
+}
+
+// route returns the joined conversation with conversationID. An id that is not
+// joined routes to the latest join, whose handler rejects the mismatched
+// conversation_id; nil means nothing is joined.
+func (j *joinedConversations) route(conversationID string) *Conversation {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if len(j.convs) == 0 {
+		return nil
+	}
+	for _, c := range j.convs {
+		if c.ID == conversationID {
+			return c
+		}
+	}
+	return j.convs[len(j.convs)-1]
+}
+
+// add records conv as the latest join. Unless multi is set it replaces the
+// other joins, which are returned.
+func (j *joinedConversations) add(conv *Conversation, multi bool) []*Conversation {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	var replaced []*Conversation
+	kept := j.convs[:0]
+	for _, c := range j.convs {
+		switch {
+		case c.ID == conv.ID:
+		case multi:
+			kept = append(kept, c)
+		default:
+			replaced = append(replaced, c)
+		}
+	}
+	j.convs = append(kept, conv)
+	return replaced
+}
+
+// remove forgets conversationID and returns its conversation, or nil.
+func (j *joinedConversations) remove(conversationID string) *Conversation {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for i, c := range j.convs {
+		if c.ID == conversationID {
+			j.convs = slices.Delete(j.convs, i, i+1)
+			return c
+		}
+	}
+	return nil
+}
+
+// takeAll empties the set and returns what it held.
+func (j *joinedConversations) takeAll() []*Conversation {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	out := j.convs
+	j.convs = nil
+	return out
+}
+
+// multiConversation reports whether client stays joined to several conversations.
+// Other clients switch conversations on join, as before the capability existed.
+func multiConversation(client *Client) bool {
+	return client.Hello().Capabilities.Has(v1.CapMultiConversation)
+}
+
+// admitJoin checks the per-connection limit before client joins conversationID.
+// Rejoining a joined conversation is always allowed.
+func (g *WSGateway) admitJoin(client *Client, joined *joinedConversations, conversationID string) error {
+	if !multiConversation(client) || g.maxJoined <= 0 || joined.has(conversationID) {
+		return nil
+	}
+	if joined.len() >= g.maxJoined {
+		return fmt.Errorf("%w (max %d)", errTooManyJoined, g.maxJoined)
+	}
+	return nil
+}
+
+// recordJoin adds conv to the session's joins. Without multi-conversation
+// support the previous conversation stops fanning out to the client.
+func (g *WSGateway) recordJoin(client *Client, joined *joinedConversations, conv *Conversation) {
+	for _, prev := range joined.add(conv, multiConversation(client)) {
+		g.detach(client, prev)
+	}
+	client.AddConversation(conv.ID)
+}
+
+// detach removes client from conv's fanout and forgets its ordering lane.
+func (g *WSGateway) detach(client *Client, conv *Conversation) {
+	conv.Remove(client.SessionID)
+	client.RemoveConversation(conv.ID)
+	client.dropLane(conv.ID)
+}
+
+// onLeave handles conversation.leave: the conversation stops fanning out to the
+// session, which stays connected and joined to its other conversations.
+func (g *WSGateway) onLeave(ctx context.Context, client *Client, joined *joinedConversations, env v1.Envelope) error {
+	var p v1.ConversationLeavePayload
+	if err := json.Unmarshal(env.Payload, &p); err != nil {
+		return fmt.Errorf("invalid payload: %w", err)
+	}
+	convID := strings.TrimSpace(p.ConversationID)
+	if convID == "" {
+		return errMissingConversationID
+	}
+
+	conv := joined.remove(convID)
+	if conv == nil {
+		return errors.New("conversation_id is not joined")
+	}
+	g.detach(client, conv)
+
+	echoPayload, _ := json.Marshal(v1.ConversationLeavePayload{ConversationID: convID})
+	if !g.enqueue(ctx, client, mustNewEnvelope(v1.TypeConversationLeave, echoPayload, time.Now().UTC())) {
+		return errors.New("backpressure: leave echo")
+	}
+	return nil
+}
+
+// payloadConversationID returns the conversation_id of a client request.
+func payloadConversationID(env v1.Envelope) string {
+	var p struct {
+		ConversationID string `json:"conversation_id"`
+	}
+	_ = json.Unmarshal(env.Payload, &p)
+	return strings.TrimSpace(p.ConversationID)
+}
@@ -0,0 +1,182 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	v1 "arc/shared/contracts/realtime/v1"
+)
+
+// joinedTestSession runs one in-process session for the joined-conversation tests.
+type joinedTestSession struct {
+	t *testing.T
+	p *pipeSession
+}
+
+func startJoinedTestSession(t *testing.T, ctx context.Context, wg *sync.WaitGroup, g *WSGateway, userID, sessionID string, caps v1.Capabilities) *joinedTestSession {
+	t.Helper()
+	s := &joinedTestSession{t: t, p: newPipeSession()}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		g.runSession(ctx, pipeServerSide{s.p}, userID, sessionID)
+	}()
+	s.send(v1.TypeHello, v1.HelloPayload{Capabilities: caps})
+	s.expect(v1.TypeHelloAck)
+	return s
+}
+
+func (s *joinedTestSession) send(typ string, payload any) {
+	s.t.Helper()
+	b, _ := json.Marshal(payload)
+	s.p.toServer <- mustNewEnvelope(typ, b, time.Now().UTC())
+}
+
+// expect returns the next envelope, which must have type typ.
+func (s *joinedTestSession) expect(typ string) v1.Envelope {
+	s.t.Helper()
+	select {
+	case env := <-s.p.toClient:
+		if env.Type != typ {
+			s.t.Fatalf("expected %s, got %s %s", typ, env.Type, env.Payload)
+		}
+		return env
+	case <-s.p.closed:
+		s.t.Fatalf("expected %s, session closed", typ)
+	case <-time.After(2 * time.Second):
+		s.t.Fatalf("timed out waiting for %s", typ)
+	}
+	return v1.Envelope{}
+}
+
+// quiet fails if an envelope arrives within a short period.
+func (s *joinedTestSession) quiet() {
+	s.t.Helper()
+	select {
+	case env := <-s.p.toClient:
+		s.t.Fatalf("unexpected %s %s", env.Type, env.Payload)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func (s *joinedTestSession) join(convID string) {
+	s.t.Helper()
+	s.send(v1.TypeConversationJoin, v1.ConversationJoinPayload{ConversationID: convID})
+	s.expect(v1.TypeConversationJoin)
+}
+
+func (s *joinedTestSession) sendMessage(convID, clientMsgID string) {
+	s.t.Helper()
+	s.send(v1.TypeMessageSend, v1.MessageSendPayload{ConversationID: convID, ClientMsgID: clientMsgID, Text: "hi " + clientMsgID})
+}
+
+func newJoinedTestGateway(t *testing.T) (*WSGateway, context.Context, *sync.WaitGroup) {
+	t.Helper()
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	g := NewWSGateway(log, NewHub(log), NewInMemoryStore(), nil, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	t.Cleanup(func() {
+		cancel()
+		wg.Wait()
+		g.stopReloading()
+	})
+	return g, ctx, wg
+}
+
+func TestWSGateway_MultiConversation_JoinSendLeave(t *testing.T) {
+	g, ctx, wg := newJoinedTestGateway(t)
+
+	alice := startJoinedTestSession(t, ctx, wg, g, "alice", "s-alice", v1.CapMultiConversation)
+	alice.join("c1")
+	alice.join("c2")
+
+	bob := startJoinedTestSession(t, ctx, wg, g, "bob", "s-bob", 0)
+	bob.join("c1")
+	bob.sendMessage("c1", "m1")
+	bob.expect(v1.TypeMessageAck)
+	bob.expect(v1.TypeMessageNew)
+
+	// Alice receives both conversations on one connection and can send to either.
+	if env := alice.expect(v1.TypeMessageNew); env.ConvID != "c1" {
+		t.Fatalf("expected c1 fanout, got %+v", env)
+	}
+	alice.sendMessage("c2", "m2")
+	alice.expect(v1.TypeMessageAck)
+	if env := alice.expect(v1.TypeMessageNew); env.ConvID != "c2" {
+		t.Fatalf("expected c2 fanout, got %+v", env)
+	}
+	bob.quiet()
+
+	if got := g.hub.Connections(); len(got) != 2 || strings.Join(got[0].Conversations, ",") != "c1,c2" {
+		t.Fatalf("unexpected connections %+v", got)
+	}
+
+	alice.send(v1.TypeConversationLeave, v1.ConversationLeavePayload{ConversationID: "c1"})
+	var echo v1.ConversationLeavePayload
+	_ = json.Unmarshal(alice.expect(v1.TypeConversationLeave).Payload, &echo)
+	if echo.ConversationID != "c1" {
+		t.Fatalf("unexpected leave echo %+v", echo)
+	}
+
+	// c1 no longer fans out to alice, and sends to it are refused.
+	bob.sendMessage("c1", "m3")
+	bob.expect(v1.TypeMessageAck)
+	bob.expect(v1.TypeMessageNew)
+	alice.quiet()
+	alice.sendMessage("c1", "m4")
+	alice.expect(v1.TypeError)
+
+	alice.send(v1.TypeConversationLeave, v1.ConversationLeavePayload{ConversationID: "c1"})
+	var errp v1.ErrorPayload
+	_ = json.Unmarshal(alice.expect(v1.TypeError).Payload, &errp)
+	if errp.Code != "leave_failed" {
+		t.Fatalf("expected leave_failed, got %+v", errp)
+	}
+}
+
+func TestWSGateway_MultiConversation_Limit(t *testing.T) {
+	t.Setenv("ARC_WS_MAX_JOINED_CONVERSATIONS", "2")
+	g, ctx, wg := newJoinedTestGateway(t)
+
+	s := startJoinedTestSession(t, ctx, wg, g, "alice", "s-alice", v1.CapMultiConversation)
+	s.join("c1")
+	s.join("c2")
+	s.join("c2")
+
+	s.send(v1.TypeConversationJoin, v1.ConversationJoinPayload{ConversationID: "c3"})
+	var errp v1.ErrorPayload
+	_ = json.Unmarshal(s.expect(v1.TypeError).Payload, &errp)
+	if errp.Code != "join_failed" || !strings.Contains(errp.Message, "too many joined conversations") {
+		t.Fatalf("expected join_failed, got %+v", errp)
+	}
+}
+
+func TestWSGateway_SingleConversation_JoinSwitches(t *testing.T) {
+	g, ctx, wg := newJoinedTestGateway(t)
+
+	alice := startJoinedTestSession(t, ctx, wg, g, "alice", "s-alice", 0)
+	alice.join("c1")
+	alice.join("c2")
+
+	// The switch keeps the connection open and stops the c1 fanout.
+	bob := startJoinedTestSession(t, ctx, wg, g, "bob", "s-bob", 0)
+	bob.join("c1")
+	bob.sendMessage("c1", "m1")
+	bob.expect(v1.TypeMessageAck)
+	bob.expect(v1.TypeMessageNew)
+	alice.quiet()
+
+	alice.sendMessage("c2", "m2")
+	alice.expect(v1.TypeMessageAck)
+	alice.expect(v1.TypeMessageNew)
+	if got := g.hub.Conversation("c1").hasSession("s-alice"); got {
+		t.Fatalf("expected alice to have left c1")
+	}
+}
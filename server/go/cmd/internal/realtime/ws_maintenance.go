@@ -0,0 +1,47 @@
+package realtime
+
+import (
+	"net/http"
+
+	"arc/cmd/internal/maintenance"
+	"arc/shared/contracts/realtime/realtimepb"
+
+	"github.com/coder/websocket"
+)
+
+// wsMaintenanceReason is the close reason sent with 1013 during maintenance.
+const wsMaintenanceReason = "maintenance"
+
+// WithMaintenance refuses new sessions while m is enabled: WebSocket upgrades are
+// accepted and immediately closed with 1013 (try again later), and gRPC Stream and
+// SendMessage calls fail with UNAVAILABLE. Sessions already open keep running.
+func WithMaintenance(m *maintenance.Mode) GatewayOption {
+	return func(g *WSGateway) { g.maintenance = m }
+}
+
+// rejectMaintenance closes a new WebSocket with 1013 while maintenance mode is on.
+// The upgrade completes first so browsers see the close code rather than a bare
+// HTTP error.
+func (g *WSGateway) rejectMaintenance(w http.ResponseWriter, r *http.Request) bool {
+	if !g.maintenance.Enabled() {
+		return false
+	}
+	wsMaintenanceRejected.Inc()
+	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
+		Subprotocols:       []string{wsSubprotocolV1, wsSubprotocolV2},
+		InsecureSkipVerify: g.devInsecure,
+	})
+	if err != nil {
+		g.log.Info("ws.reject.maintenance", "err", err, "remote", r.RemoteAddr)
+		return true
+	}
+	g.log.Info("ws.reject.maintenance", "remote", r.RemoteAddr)
+	_ = conn.Close(websocket.StatusTryAgainLater, wsMaintenanceReason)
+	return true
+}
+
+// rejectGRPCMaintenance reports whether maintenance mode fails calls of method;
+// only FetchHistory is read-only.
+func (g *WSGateway) rejectGRPCMaintenance(method string) bool {
+	return g.maintenance.Enabled() && method != realtimepb.Realtime_FetchHistory_FullMethodName
+}
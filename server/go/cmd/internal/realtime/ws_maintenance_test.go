@@ -0,0 +1,46 @@
+package realtime
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"arc/cmd/internal/maintenance"
+
+	"github.com/coder/websocket"
+)
+
+func TestWSGateway_MaintenanceClosesNewConnects(t *testing.T) {
+	t.Setenv("ARC_WS_REQUIRE_AUTH", "false")
+	t.Setenv("ARC_WS_ORIGIN_REQUIRED", "false")
+
+	mode := maintenance.New(maintenance.Status{Enabled: true})
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	g := NewWSGateway(log, NewHub(log), NewInMemoryStore(), nil, nil, WithMaintenance(mode))
+	srv := httptest.NewServer(g)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+	conn, _, err := websocket.Dial(ctx, url, &websocket.DialOptions{Subprotocols: []string{wsSubprotocolV1}})
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	_, _, err = conn.Read(ctx)
+	if code := websocket.CloseStatus(err); code != websocket.StatusTryAgainLater {
+		t.Fatalf("close status = %v (%v), want 1013", code, err)
+	}
+
+	mode.Set(maintenance.Status{Enabled: false})
+	conn, _, err = websocket.Dial(ctx, url, &websocket.DialOptions{Subprotocols: []string{wsSubprotocolV1}})
+	if err != nil {
+		t.Fatalf("dial after maintenance: %v", err)
+	}
+	_ = conn.Close(websocket.StatusNormalClosure, "")
+}
@@ -0,0 +1,311 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	v1 "arc/shared/contracts/realtime/v1"
+)
+
+const membersMaxBodyBytes = 4 << 10 // 4 KiB
+
+type addMemberRequest struct {
+	UserID string `json:"user_id"`
+	Role   string `json:"role,omitempty"`
+}
+
+type memberResponse struct {
+	ConversationID string `json:"conversation_id"`
+	UserID         string `json:"user_id"`
+	Role           string `json:"role"`
+}
+
+type httpAPIError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type httpErrorResponse struct {
+	Error httpAPIError `json:"error"`
+}
+
+// HandleMembers serves POST /conversations/{id}/members.
+//
+// Owners and admins may add members; only the owner may add admins. The owner role
+// is never granted here. Connected clients of the conversation receive member.added.
+func (g *WSGateway) HandleMembers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeErrorHTTP(w, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+	members, ok := g.memberManager()
+	if !ok {
+		writeErrorHTTP(w, http.StatusServiceUnavailable, "membership_unavailable", "membership management not configured")
+		return
+	}
+	actorID, ok := g.authenticateHTTP(w, r)
+	if !ok {
+		return
+	}
+
+	var req addMemberRequest
+	if err := decodeJSONHTTP(w, r, membersMaxBodyBytes, &req); err != nil {
+		writeErrorHTTP(w, http.StatusBadRequest, "invalid_json", "invalid JSON body")
+		return
+	}
+	targetID := strings.TrimSpace(req.UserID)
+	if targetID == "" {
+		writeErrorHTTP(w, http.StatusBadRequest, "invalid_request", "user_id is required")
+		return
+	}
+	role, err := NormalizeMemberRole(req.Role)
+	if err != nil || role == MemberRoleOwner {
+		writeErrorHTTP(w, http.StatusBadRequest, "invalid_role", "role must be member or admin")
+		return
+	}
+
+	convID := strings.TrimSpace(r.PathValue("id"))
+	actorRole, ok := g.actorMemberRole(r.Context(), w, members, actorID, convID)
+	if !ok {
+		return
+	}
+	if !canAddMember(actorRole, role) {
+		writeErrorHTTP(w, http.StatusForbidden, "forbidden", "insufficient conversation role")
+		return
+	}
+
+	switch err := members.AddMemberWithRole(r.Context(), targetID, convID, role); {
+	case err == nil:
+	case errors.Is(err, ErrAlreadyMember):
+		writeErrorHTTP(w, http.StatusConflict, "already_member", "user is already a member")
+		return
+	case errors.Is(err, ErrMemberUserNotFound):
+		writeErrorHTTP(w, http.StatusNotFound, "user_not_found", "user not found")
+		return
+	case errors.Is(err, ErrConversationNotFound):
+		writeErrorHTTP(w, http.StatusNotFound, "conversation_not_found", "conversation not found")
+		return
+	default:
+		g.log.Error("conversation.member.add.fail", "conversation_id", convID, "err", err)
+		writeErrorHTTP(w, http.StatusInternalServerError, "internal_error", "internal error")
+		return
+	}
+
+	g.broadcastMemberAdded(convID, targetID, role, actorID)
+
+	writeJSONHTTP(w, http.StatusCreated, memberResponse{
+		ConversationID: convID,
+		UserID:         targetID,
+		Role:           role,
+	})
+}
+
+// HandleMember serves DELETE /conversations/{id}/members/{user_id}.
+//
+// Any member may remove themselves, except the owner. Otherwise the owner may
+// remove admins and members, and admins may remove members. Connected clients
+// receive member.removed, after which the removed user's sessions stop
+// receiving broadcasts for the conversation.
+func (g *WSGateway) HandleMember(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		w.Header().Set("Allow", http.MethodDelete)
+		writeErrorHTTP(w, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+	members, ok := g.memberManager()
+	if !ok {
+		writeErrorHTTP(w, http.StatusServiceUnavailable, "membership_unavailable", "membership management not configured")
+		return
+	}
+	actorID, ok := g.authenticateHTTP(w, r)
+	if !ok {
+		return
+	}
+
+	convID := strings.TrimSpace(r.PathValue("id"))
+	targetID := strings.TrimSpace(r.PathValue("user_id"))
+	if targetID == "" {
+		writeErrorHTTP(w, http.StatusBadRequest, "invalid_request", "user_id is required")
+		return
+	}
+
+	actorRole, ok := g.actorMemberRole(r.Context(), w, members, actorID, convID)
+	if !ok {
+		return
+	}
+
+	targetRole := actorRole
+	if targetID != actorID {
+		role, err := members.GetMemberRole(r.Context(), targetID, convID)
+		switch {
+		case err == nil:
+			targetRole = role
+		case errors.Is(err, ErrMembershipRequired):
+			writeErrorHTTP(w, http.StatusNotFound, "not_member", "user is not a member")
+			return
+		default:
+			g.log.Error("conversation.member.role.fail", "conversation_id", convID, "err", err)
+			writeErrorHTTP(w, http.StatusInternalServerError, "internal_error", "internal error")
+			return
+		}
+	}
+
+	if targetID == actorID && actorRole == MemberRoleOwner {
+		writeErrorHTTP(w, http.StatusConflict, "owner_cannot_leave", "the owner cannot leave the conversation")
+		return
+	}
+	if !canRemoveMember(actorRole, targetRole, targetID == actorID) {
+		writeErrorHTTP(w, http.StatusForbidden, "forbidden", "insufficient conversation role")
+		return
+	}
+
+	switch err := members.RemoveMember(r.Context(), targetID, convID); {
+	case err == nil:
+	case errors.Is(err, ErrMembershipRequired):
+		writeErrorHTTP(w, http.StatusNotFound, "not_member", "user is not a member")
+		return
+	default:
+		g.log.Error("conversation.member.remove.fail", "conversation_id", convID, "err", err)
+		writeErrorHTTP(w, http.StatusInternalServerError, "internal_error", "internal error")
+		return
+	}
+
+	g.broadcastMemberRemoved(convID, targetID, actorID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// canAddMember reports whether a member with actorRole may grant role.
+func canAddMember(actorRole, role string) bool {
+	switch role {
+	case MemberRoleMember:
+		return actorRole == MemberRoleOwner || actorRole == MemberRoleAdmin
+	case MemberRoleAdmin:
+		return actorRole == MemberRoleOwner
+	default:
+		return false
+	}
+}
+
+// canRemoveMember reports whether a member with actorRole may remove a member with targetRole.
+func canRemoveMember(actorRole, targetRole string, self bool) bool {
+	if targetRole == MemberRoleOwner {
+		return false
+	}
+	if self {
+		return true
+	}
+	switch targetRole {
+	case MemberRoleMember:
+		return actorRole == MemberRoleOwner || actorRole == MemberRoleAdmin
+	case MemberRoleAdmin:
+		return actorRole == MemberRoleOwner
+	default:
+		return false
+	}
+}
+
+func (g *WSGateway) memberManager() (MembershipManager, bool) {
+	if g.auth == nil || g.members == nil {
+		return nil, false
+	}
+	m, ok := g.members.(MembershipManager)
+	return m, ok
+}
+
+// authenticateHTTP validates the bearer access token and returns the user id.
+func (g *WSGateway) authenticateHTTP(w http.ResponseWriter, r *http.Request) (string, bool) {
+	token, err := normalizeAccessTokenWS(bearerToken(r))
+	if err != nil {
+		writeErrorHTTP(w, http.StatusUnauthorized, "unauthorized", "missing access token")
+		return "", false
+	}
+	claims, err := g.auth.ValidateAccessToken(r.Context(), token, time.Now().UTC())
+	if err != nil || strings.TrimSpace(claims.UserID) == "" {
+		writeErrorHTTP(w, http.StatusUnauthorized, "unauthorized", "invalid access token")
+		return "", false
+	}
+	return claims.UserID, true
+}
+
+// actorMemberRole resolves the caller's role. Non-members get 404 so private
+// conversations are not disclosed.
+func (g *WSGateway) actorMemberRole(ctx context.Context, w http.ResponseWriter, members MembershipManager, actorID, convID string) (string, bool) {
+	if convID == "" {
+		writeErrorHTTP(w, http.StatusBadRequest, "invalid_request", "conversation id is required")
+		return "", false
+	}
+	role, err := members.GetMemberRole(ctx, actorID, convID)
+	switch {
+	case err == nil:
+		return role, true
+	case errors.Is(err, ErrMembershipRequired), errors.Is(err, ErrConversationNotFound):
+		writeErrorHTTP(w, http.StatusNotFound, "conversation_not_found", "conversation not found")
+		return "", false
+	default:
+		g.log.Error("conversation.member.role.fail", "conversation_id", convID, "err", err)
+		writeErrorHTTP(w, http.StatusInternalServerError, "internal_error", "internal error")
+		return "", false
+	}
+}
+
+// broadcastMemberAdded notifies the conversation and inbox subscribers, including
+// the added user's, even when nobody joined the conversation on this node.
+func (g *WSGateway) broadcastMemberAdded(convID, userID, role, addedBy string) {
+	p, _ := json.Marshal(v1.MemberAddedPayload{
+		ConversationID: convID,
+		UserID:         userID,
+		Role:           role,
+		AddedBy:        addedBy,
+	})
+	g.hub.Broadcast(convID, mustNewEnvelope(v1.TypeMemberAdded, p, time.Now().UTC()))
+}
+
+// broadcastMemberRemoved notifies the conversation and inbox subscribers
+// (including the removed user) and then drops the removed user's sessions from
+// the fanout.
+func (g *WSGateway) broadcastMemberRemoved(convID, userID, removedBy string) {
+	p, _ := json.Marshal(v1.MemberRemovedPayload{
+		ConversationID: convID,
+		UserID:         userID,
+		RemovedBy:      removedBy,
+	})
+	g.hub.Broadcast(convID, mustNewEnvelope(v1.TypeMemberRemoved, p, time.Now().UTC()))
+	g.hub.Conversation(convID).RemoveUser(userID)
+}
+
+// ---- HTTP JSON helpers ----
+
+func writeJSONHTTP(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeErrorHTTP(w http.ResponseWriter, status int, code, msg string) {
+	writeJSONHTTP(w, status, httpErrorResponse{Error: httpAPIError{Code: code, Message: msg}})
+}
+
+func decodeJSONHTTP(w http.ResponseWriter, r *http.Request, maxBytes int64, dst any) error {
+	if r.Body == nil {
+		return errors.New("empty body")
+	}
+	defer func() { _ = r.Body.Close() }()
+
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil {
+		return err
+	}
+	if err := dec.Decode(&struct{}{}); err != io.EOF {
+		return errors.New("extra data after JSON object")
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package realtime
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	v1 "arc/shared/contracts/realtime/v1"
+)
+
+func TestCanAddMember(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		actor, role string
+		want        bool
+	}{
+		{MemberRoleOwner, MemberRoleMember, true},
+		{MemberRoleOwner, MemberRoleAdmin, true},
+		{MemberRoleOwner, MemberRoleOwner, false},
+		{MemberRoleAdmin, MemberRoleMember, true},
+		{MemberRoleAdmin, MemberRoleAdmin, false},
+		{MemberRoleMember, MemberRoleMember, false},
+	}
+	for _, tc := range cases {
+		if got := canAddMember(tc.actor, tc.role); got != tc.want {
+			t.Fatalf("canAddMember(%q, %q)=%v want %v", tc.actor, tc.role, got, tc.want)
+		}
+	}
+}
+
+func TestCanRemoveMember(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		actor, target string
+		self          bool
+		want          bool
+	}{
+		{MemberRoleMember, MemberRoleMember, true, true},
+		{MemberRoleAdmin, MemberRoleAdmin, true, true},
+		{MemberRoleOwner, MemberRoleOwner, true, false},
+		{MemberRoleOwner, MemberRoleAdmin, false, true},
+		{MemberRoleOwner, MemberRoleMember, false, true},
+		{MemberRoleAdmin, MemberRoleMember, false, true},
+		{MemberRoleAdmin, MemberRoleAdmin, false, false},
+		{MemberRoleAdmin, MemberRoleOwner, false, false},
+		{MemberRoleMember, MemberRoleMember, false, false},
+	}
+	for _, tc := range cases {
+		if got := canRemoveMember(tc.actor, tc.target, tc.self); got != tc.want {
+			t.Fatalf("canRemoveMember(%q, %q, %v)=%v want %v", tc.actor, tc.target, tc.self, got, tc.want)
+		}
+	}
+}
+
+func TestNormalizeMemberRole(t *testing.T) {
+	t.Parallel()
+
+	if r, err := NormalizeMemberRole(""); err != nil || r != MemberRoleMember {
+		t.Fatalf("empty role: got %q, %v", r, err)
+	}
+	if r, err := NormalizeMemberRole(" Admin "); err != nil || r != MemberRoleAdmin {
+		t.Fatalf("admin role: got %q, %v", r, err)
+	}
+	if _, err := NormalizeMemberRole("superuser"); err != ErrInvalidMemberRole {
+		t.Fatalf("expected ErrInvalidMemberRole, got %v", err)
+	}
+}
+
+func TestConversation_RemoveUser_StopsFanoutWithoutClosing(t *testing.T) {
+	t.Parallel()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	hub := NewHub(log)
+	if hub.Conversation("c1") != nil {
+		t.Fatalf("expected no conversation before join")
+	}
+	conv := hub.GetOrCreateConversationWithKind("c1", "group")
+	if hub.Conversation("c1") != conv {
+		t.Fatalf("expected lookup to return the joined conversation")
+	}
+
+	alice := NewClient("alice", "s-alice", 4)
+	bob1 := NewClient("bob", "s-bob-1", 4)
+	bob2 := NewClient("bob", "s-bob-2", 4)
+	conv.Join(alice)
+	conv.Join(bob1)
+	conv.Join(bob2)
+
+	if n := conv.RemoveUser("bob"); n != 2 {
+		t.Fatalf("expected 2 sessions removed, got %d", n)
+	}
+
+	conv.Broadcast(v1.Envelope{V: v1.Version, Type: v1.TypeSystemNew})
+	if len(alice.Send) != 1 {
+		t.Fatalf("expected alice to receive broadcast")
+	}
+	if len(bob1.Send) != 0 || len(bob2.Send) != 0 {
+		t.Fatalf("expected removed user to receive nothing")
+	}
+	select {
+	case <-bob1.Done():
+		t.Fatalf("RemoveUser must not close clients")
+	default:
+	}
+}
+
+func TestWSGateway_Members_RequireManagerAndAuth(t *testing.T) {
+	t.Parallel()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	gw := NewWSGateway(log, NewHub(log), NewInMemoryStore(), nil, nil)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/conversations/{id}/members", gw.HandleMembers)
+	mux.HandleFunc("/conversations/{id}/members/{user_id}", gw.HandleMember)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/conversations/c1/members", strings.NewReader(`{"user_id":"u1"}`)))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("POST: expected 503, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/conversations/c1/members/u1", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("DELETE: expected 503, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/conversations/c1/members", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("GET: expected 405, got %d", rec.Code)
+	}
+}
+
+func TestWSGateway_DirectConversations_RequireStoreAndPost(t *testing.T) {
+	t.Parallel()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	gw := NewWSGateway(log, NewHub(log), NewInMemoryStore(), nil, nil)
+
+	rec := httptest.NewRecorder()
+	gw.HandleDirectConversations(rec, httptest.NewRequest(http.MethodPost, "/conversations/direct", strings.NewReader(`{"user_id":"u2"}`)))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("POST: expected 503, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	gw.HandleDirectConversations(rec, httptest.NewRequest(http.MethodGet, "/conversations/direct", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("GET: expected 405, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,145 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	v1 "arc/shared/contracts/realtime/v1"
+)
+
+const presenceMaxSubscribe = 200
+
+type presenceResponse struct {
+	UserID     string     `json:"user_id"`
+	Status     string     `json:"status"`
+	LastSeenAt *time.Time `json:"last_seen_at,omitempty"`
+}
+
+func presencePayload(st PresenceState, showLastSeen bool) v1.PresenceUpdatePayload {
+	p := v1.PresenceUpdatePayload{UserID: st.UserID, Status: st.Status}
+	if showLastSeen && st.Status == PresenceOffline && !st.LastSeenAt.IsZero() {
+		ts := st.LastSeenAt
+		p.LastSeenAt = &ts
+	}
+	return p
+}
+
+func presenceEnvelope(st PresenceState, showLastSeen bool) v1.Envelope {
+	b, _ := json.Marshal(presencePayload(st, showLastSeen))
+	return mustNewEnvelope(v1.TypePresenceUpdate, b, time.Now().UTC())
+}
+
+// canSeeLastSeen applies the ARC_PRESENCE_LAST_SEEN policy for viewerID looking at targetID.
+func (g *WSGateway) canSeeLastSeen(ctx context.Context, viewerID, targetID string) bool {
+	if viewerID != "" && viewerID == targetID {
+		return true
+	}
+	switch g.presenceLastSeen {
+	case PresenceLastSeenEveryone:
+		return true
+	case PresenceLastSeenNobody:
+		return false
+	}
+	audience, ok := g.members.(PresenceAudience)
+	if !ok || viewerID == "" {
+		return false
+	}
+	shared, err := audience.SharesConversation(ctx, viewerID, targetID)
+	if err != nil {
+		g.log.Info("presence.audience.fail", "err", err)
+		return false
+	}
+	return shared
+}
+
+func (g *WSGateway) onPresenceSubscribe(ctx context.Context, client *Client, env v1.Envelope) error {
+	if err := g.requireAuthenticatedClient(client); err != nil {
+		return err
+	}
+
+	var p v1.PresenceSubscribePayload
+	if err := json.Unmarshal(env.Payload, &p); err != nil {
+		return fmt.Errorf("invalid payload: %w", err)
+	}
+	if len(p.UserIDs) == 0 {
+		return errors.New("missing user_ids")
+	}
+	if len(p.UserIDs) > presenceMaxSubscribe {
+		return fmt.Errorf("too many user_ids: max=%d", presenceMaxSubscribe)
+	}
+
+	seen := make(map[string]struct{}, len(p.UserIDs))
+	for _, raw := range p.UserIDs {
+		userID := strings.TrimSpace(raw)
+		if userID == "" {
+			continue
+		}
+		if _, dup := seen[userID]; dup {
+			continue
+		}
+		seen[userID] = struct{}{}
+
+		showLastSeen := g.canSeeLastSeen(ctx, client.UserID, userID)
+		st := g.hub.SubscribePresence(client, userID, showLastSeen)
+		if !g.enqueue(ctx, client, presenceEnvelope(st, showLastSeen)) {
+			return errors.New("backpressure: presence.update")
+		}
+	}
+	return nil
+}
+
+func (g *WSGateway) onPresenceUpdate(ctx context.Context, client *Client, env v1.Envelope) error {
+	if err := g.requireAuthenticatedClient(client); err != nil {
+		return err
+	}
+	if client.UserID == "" {
+		return errUnauthorized
+	}
+
+	var p v1.PresenceUpdatePayload
+	if err := json.Unmarshal(env.Payload, &p); err != nil {
+		return fmt.Errorf("invalid payload: %w", err)
+	}
+	switch status := strings.ToLower(strings.TrimSpace(p.Status)); status {
+	case PresenceOnline, PresenceAway:
+		g.hub.SetPresence(client, status)
+		return nil
+	default:
+		return errors.New("invalid status")
+	}
+}
+
+// HandleUserPresence serves GET /users/{id}/presence for REST clients.
+func (g *WSGateway) HandleUserPresence(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeErrorHTTP(w, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+	if g.auth == nil {
+		writeErrorHTTP(w, http.StatusServiceUnavailable, "presence_unavailable", "auth not configured")
+		return
+	}
+	viewerID, ok := g.authenticateHTTP(w, r)
+	if !ok {
+		return
+	}
+	targetID := strings.TrimSpace(r.PathValue("id"))
+	if targetID == "" {
+		writeErrorHTTP(w, http.StatusBadRequest, "invalid_request", "user id is required")
+		return
+	}
+
+	st := g.hub.Presence(targetID)
+	p := presencePayload(st, g.canSeeLastSeen(r.Context(), viewerID, targetID))
+	writeJSONHTTP(w, http.StatusOK, presenceResponse{
+		UserID:     targetID,
+		Status:     p.Status,
+		LastSeenAt: p.LastSeenAt,
+	})
+}
@@ -0,0 +1,71 @@
+package realtime
+
+import (
+	"arc/cmd/internal/config"
+	"strings"
+	"time"
+)
+
+// gatewayTunableKeys are the settings a config reload applies without a restart.
+var gatewayTunableKeys = []string{
+	"ARC_WS_ORIGIN_REQUIRED",
+	"ARC_WS_ALLOWED_ORIGINS",
+	"ARC_WS_RATE_EVENTS",
+	"ARC_WS_RATE_WINDOW",
+	"ARC_WS_CONVERSATION_SEND_BURST",
+	"ARC_WS_CONVERSATION_SEND_REFILL",
+	"ARC_WS_MAX_CONNS_PER_USER",
+	"ARC_WS_MAX_CONNS_PER_SESSION",
+	"ARC_WS_MAX_CONNS_PER_IP",
+}
+
+// gatewayTunables are the gateway settings that may change at runtime. A reload
+// swaps in a new value; it is never modified in place.
+type gatewayTunables struct {
+	originRequired bool
+	allowedOrigins []string
+
+	rateEvents int
+	rateWindow time.Duration
+
+	convSendBurst  int
+	convSendRefill time.Duration
+
+	maxConnsPerUser int
+	maxConnsPerSess int
+	maxConnsPerIP   int
+}
+
+func loadGatewayTunables(lookup config.Lookup) *gatewayTunables {
+	return &gatewayTunables{
+		originRequired: envBoolWS(lookup, "ARC_WS_ORIGIN_REQUIRED", wsDefaultOriginRequired),
+		allowedOrigins: envCSVWS(lookup, "ARC_WS_ALLOWED_ORIGINS", wsDefaultAllowedOrigins),
+		rateEvents:     envIntWS(lookup, "ARC_WS_RATE_EVENTS", rateLimitEvents),
+		rateWindow:     envDurationWS(lookup, "ARC_WS_RATE_WINDOW", rateLimitWindow),
+		convSendBurst:  envIntWS(lookup, "ARC_WS_CONVERSATION_SEND_BURST", conversationSendBurst),
+		convSendRefill: envDurationWS(lookup, "ARC_WS_CONVERSATION_SEND_REFILL", conversationSendRefill),
+
+		maxConnsPerUser: envLimitWS(lookup, "ARC_WS_MAX_CONNS_PER_USER", wsDefaultMaxConnsPerUser),
+		maxConnsPerSess: envLimitWS(lookup, "ARC_WS_MAX_CONNS_PER_SESSION", wsDefaultMaxConnsPerSession),
+		maxConnsPerIP:   envLimitWS(lookup, "ARC_WS_MAX_CONNS_PER_IP", wsDefaultMaxConnsPerIP),
+	}
+}
+
+// reloadTunables re-reads the tunables. New upgrades see the new origin policy at
+// once; open sessions switch to the new rate limits on their next frame. New
+// connection limits apply to later upgrades and never close open sessions.
+func (g *WSGateway) reloadTunables(changed []string) {
+	t := loadGatewayTunables(g.lookup)
+	g.tunables.Store(t)
+	g.log.Info("ws.config.reloaded",
+		"changed", strings.Join(changed, ","),
+		"allowed_origins", strings.Join(t.allowedOrigins, ","),
+		"rate_events", t.rateEvents,
+		"rate_window", t.rateWindow.String(),
+		"conversation_send_burst", t.convSendBurst,
+		"conversation_send_refill", t.convSendRefill.String(),
+		"max_conns_per_user", t.maxConnsPerUser,
+		"max_conns_per_session", t.maxConnsPerSess,
+		"max_conns_per_ip", t.maxConnsPerIP,
+	)
+}
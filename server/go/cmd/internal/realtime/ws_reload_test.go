@@ -0,0 +1,90 @@
+package realtime
+
+import (
+	"arc/cmd/internal/config"
+	"arc/cmd/internal/tenant"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReloadTunables_OriginPolicy(t *testing.T) {
+	t.Setenv("ARC_WS_ORIGIN_REQUIRED", "true")
+	t.Setenv("ARC_WS_ALLOWED_ORIGINS", "https://a.example.com")
+	t.Setenv("ARC_WS_RATE_EVENTS", "10")
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	g := NewWSGateway(log, NewHub(log), NewInMemoryStore(), nil, nil)
+	defer g.stopReloading()
+
+	req := httptest.NewRequest("GET", "/ws", nil)
+	req.Header.Set("Origin", "https://b.example.com")
+	if err := g.enforceOrigin(req); err == nil {
+		t.Fatalf("expected b.example.com to be rejected before reload")
+	}
+
+	t.Setenv("ARC_WS_ALLOWED_ORIGINS", "https://a.example.com,https://b.example.com")
+	t.Setenv("ARC_WS_RATE_EVENTS", "25")
+	g.reloadTunables([]string{"ARC_WS_ALLOWED_ORIGINS", "ARC_WS_RATE_EVENTS"})
+
+	if err := g.enforceOrigin(req); err != nil {
+		t.Fatalf("expected b.example.com after reload: %v", err)
+	}
+	if n := g.tunables.Load().rateEvents; n != 25 {
+		t.Fatalf("rate events = %d, want 25", n)
+	}
+}
+
+// TestReloadTunables_TenantOverrides reloads the config file under two tenants'
+// gateways: each keeps its own overrides and picks up the base settings it does
+// not override.
+func TestReloadTunables_TenantOverrides(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "arc.yaml")
+	write := func(src string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(src), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("ws:\n  rate_events: 10\n  max_conns_per_ip: 50\n")
+	for _, k := range []string{"ARC_WS_RATE_EVENTS", "ARC_WS_MAX_CONNS_PER_IP"} {
+		t.Setenv(k, "")
+		_ = os.Unsetenv(k)
+	}
+	l, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	newGateway := func(tn tenant.Tenant) *WSGateway {
+		g := NewWSGateway(log, NewHub(log), NewInMemoryStore(), nil, nil, WithConfigLookup(tn.Lookup()))
+		t.Cleanup(g.stopReloading)
+		return g
+	}
+	acme := newGateway(tenant.Tenant{ID: "acme", Config: map[string]string{"ARC_WS_RATE_EVENTS": "40"}})
+	globex := newGateway(tenant.Tenant{ID: "globex", Config: map[string]string{"ARC_WS_MAX_CONNS_PER_IP": "3"}})
+
+	write("ws:\n  rate_events: 20\n  max_conns_per_ip: 60\n")
+	if _, err := l.Reload(); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+
+	for _, tt := range []struct {
+		name              string
+		g                 *WSGateway
+		rateEvents, perIP int
+	}{
+		{"acme", acme, 40, 60},
+		{"globex", globex, 20, 3},
+	} {
+		got := tt.g.tunables.Load()
+		if got.rateEvents != tt.rateEvents || got.maxConnsPerIP != tt.perIP {
+			t.Fatalf("%s: rate events = %d, max conns per ip = %d; want %d, %d",
+				tt.name, got.rateEvents, got.maxConnsPerIP, tt.rateEvents, tt.perIP)
+		}
+	}
+}
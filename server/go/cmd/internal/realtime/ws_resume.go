@@ -0,0 +1,152 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"arc/cmd/internal/dbroute"
+	v1 "arc/shared/contracts/realtime/v1"
+)
+
+const (
+	wsDefaultResumeWindow = 200
+	wsMaxResumeConvs      = 50
+
+	resumeReasonWindowExceeded = "window_exceeded"
+	resumeReasonForbidden      = "forbidden"
+	resumeReasonInvalid        = "invalid"
+	resumeReasonUnavailable    = "unavailable"
+	resumeReasonOutOfOrder     = "out_of_order"
+)
+
+// onResume replays message.new events missed since each conversation's last_seq.
+//
+// Conversations with more than g.resumeWindow missed messages are not replayed;
+// the client receives resume.failed and refetches history instead. Replay reflects
+// the current stored state (edited text, tombstones), not the original event stream.
+func (g *WSGateway) onResume(ctx context.Context, client *Client, env v1.Envelope) error {
+	if err := g.requireAuthenticatedClient(client); err != nil {
+		return err
+	}
+
+	var p v1.ResumePayload
+	if err := json.Unmarshal(env.Payload, &p); err != nil {
+		return fmt.Errorf("invalid payload: %w", err)
+	}
+	convs := p.Conversations
+	if p.ResumeToken != "" {
+		cursors, err := g.resumeTokenCursors(ctx, client, p.ResumeToken, time.Now().UTC())
+		if err != nil {
+			return err
+		}
+		convs = mergeResumeCursors(convs, cursors)
+	}
+	if len(convs) == 0 {
+		return errors.New("missing conversations")
+	}
+	if len(convs) > wsMaxResumeConvs {
+		return fmt.Errorf("too many conversations: max=%d", wsMaxResumeConvs)
+	}
+
+	for _, rc := range convs {
+		if err := g.resumeConversation(ctx, client, rc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeResumeCursors appends token cursors for conversations not listed explicitly;
+// explicit positions win. Token conversations are appended in id order.
+func mergeResumeCursors(convs []v1.ResumeConversation, cursors map[string]int64) []v1.ResumeConversation {
+	listed := make(map[string]struct{}, len(convs))
+	for _, rc := range convs {
+		listed[strings.TrimSpace(rc.ConversationID)] = struct{}{}
+	}
+	out := convs
+	for _, id := range slices.Sorted(maps.Keys(cursors)) {
+		if _, ok := listed[id]; ok {
+			continue
+		}
+		out = append(out, v1.ResumeConversation{ConversationID: id, LastSeq: cursors[id]})
+	}
+	return out
+}
+
+// resumeConversation replays one conversation. Per-conversation problems are reported
+// with resume.failed; only backpressure aborts the whole resume.
+func (g *WSGateway) resumeConversation(ctx context.Context, client *Client, rc v1.ResumeConversation) error {
+	convID := strings.TrimSpace(rc.ConversationID)
+	if convID == "" || rc.LastSeq < 0 {
+		return g.resumeFailed(ctx, client, convID, resumeReasonInvalid, "invalid conversation_id or last_seq")
+	}
+	if err := g.ensureConversationMember(ctx, client.UserID, convID); err != nil {
+		return g.resumeFailed(ctx, client, convID, resumeReasonForbidden, err.Error())
+	}
+
+	// Replaying below what this connection already received live would regress seq.
+	if start := client.LaneStart(convID); start > rc.LastSeq+1 {
+		return g.resumeFailed(ctx, client, convID, resumeReasonOutOfOrder,
+			fmt.Sprintf("messages from seq %d were already delivered on this connection", start))
+	}
+	// Live fanout is held until the replay is queued ahead of it.
+	client.SealLane(convID)
+	after := rc.LastSeq
+	// A lagging replica would return a short window that looks gap-free.
+	out, err := g.store.FetchHistory(dbroute.RequirePrimary(ctx), FetchHistoryInput{
+		ConversationID: convID,
+		AfterSeq:       &after,
+		Limit:          g.resumeWindow,
+	})
+	if err != nil || out.HasMore {
+		client.UnsealLane(convID, 0)
+	} else {
+		client.UnsealLane(convID, rc.LastSeq)
+	}
+	if err != nil {
+		g.log.Error("ws.resume.fetch.fail", "session_id", client.SessionID, "conversation_id", convID, "err", err)
+		return g.resumeFailed(ctx, client, convID, resumeReasonUnavailable, "history unavailable")
+	}
+	if out.HasMore {
+		return g.resumeFailed(ctx, client, convID, resumeReasonWindowExceeded,
+			fmt.Sprintf("more than %d missed messages", g.resumeWindow))
+	}
+
+	lastSeq := rc.LastSeq
+	now := time.Now().UTC()
+	for _, m := range out.Messages {
+		payload, _ := json.Marshal(messagePayload(m))
+		if !g.enqueue(ctx, client, withSeq(mustNewEnvelope(v1.TypeMessageNew, payload, now), m.ConversationID, m.Seq)) {
+			return errors.New("backpressure: resume replay")
+		}
+		lastSeq = m.Seq
+	}
+
+	okPayload, _ := json.Marshal(v1.ResumeOKPayload{
+		ConversationID: convID,
+		Replayed:       len(out.Messages),
+		LastSeq:        lastSeq,
+	})
+	if !g.enqueue(ctx, client, mustNewEnvelope(v1.TypeResumeOK, okPayload, now)) {
+		return errors.New("backpressure: resume.ok")
+	}
+	return nil
+}
+
+func (g *WSGateway) resumeFailed(ctx context.Context, client *Client, convID, reason, msg string) error {
+	payload, _ := json.Marshal(v1.ResumeFailedPayload{
+		ConversationID: convID,
+		Reason:         reason,
+		Message:        msg,
+	})
+	if !g.enqueue(ctx, client, mustNewEnvelope(v1.TypeResumeFailed, payload, time.Now().UTC())) {
+		return errors.New("backpressure: resume.failed")
+	}
+	return nil
+}
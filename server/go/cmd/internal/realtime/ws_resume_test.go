@@ -0,0 +1,147 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	v1 "arc/shared/contracts/realtime/v1"
+)
+
+func newResumeEnvelope(t *testing.T, convs ...v1.ResumeConversation) v1.Envelope {
+	t.Helper()
+	p, err := json.Marshal(v1.ResumePayload{Conversations: convs})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return mustNewEnvelope(v1.TypeResume, p, time.Now().UTC())
+}
+
+func drainEnvelopes(c *Client) []v1.Envelope {
+	var out []v1.Envelope
+	for {
+		select {
+		case env := <-c.Send:
+			out = append(out, env)
+		default:
+			return out
+		}
+	}
+}
+
+func TestWSGateway_Resume_ReplaysMissedMessages(t *testing.T) {
+	t.Parallel()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	store := NewInMemoryStore()
+	g := NewWSGateway(log, NewHub(log), store, nil, nil)
+	ctx := context.Background()
+
+	for _, id := range []string{"a", "b", "c"} {
+		if _, err := store.AppendMessage(ctx, AppendMessageInput{ConversationID: "c1", ClientMsgID: id, SenderSession: "s0", Text: id}); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+	}
+
+	client := NewClient("u1", "s1", 64)
+	env := newResumeEnvelope(t,
+		v1.ResumeConversation{ConversationID: "c1", LastSeq: 1},
+		v1.ResumeConversation{ConversationID: "c-empty", LastSeq: 0},
+		v1.ResumeConversation{ConversationID: " ", LastSeq: 0},
+	)
+	if err := g.onResume(ctx, client, env); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+
+	got := drainEnvelopes(client)
+	wantTypes := []string{v1.TypeMessageNew, v1.TypeMessageNew, v1.TypeResumeOK, v1.TypeResumeOK, v1.TypeResumeFailed}
+	if len(got) != len(wantTypes) {
+		t.Fatalf("expected %d envelopes, got %d", len(wantTypes), len(got))
+	}
+	for i, want := range wantTypes {
+		if got[i].Type != want {
+			t.Fatalf("envelope %d: expected %q, got %q", i, want, got[i].Type)
+		}
+	}
+
+	var first v1.MessageNewPayload
+	_ = json.Unmarshal(got[0].Payload, &first)
+	if first.Seq != 2 {
+		t.Fatalf("expected replay to start at seq 2, got %d", first.Seq)
+	}
+	var ok v1.ResumeOKPayload
+	_ = json.Unmarshal(got[2].Payload, &ok)
+	if ok.ConversationID != "c1" || ok.Replayed != 2 || ok.LastSeq != 3 {
+		t.Fatalf("unexpected resume.ok %+v", ok)
+	}
+	_ = json.Unmarshal(got[3].Payload, &ok)
+	if ok.ConversationID != "c-empty" || ok.Replayed != 0 || ok.LastSeq != 0 {
+		t.Fatalf("unexpected resume.ok %+v", ok)
+	}
+	var failed v1.ResumeFailedPayload
+	_ = json.Unmarshal(got[4].Payload, &failed)
+	if failed.Reason != resumeReasonInvalid {
+		t.Fatalf("expected invalid reason, got %+v", failed)
+	}
+}
+
+func TestWSGateway_Resume_WindowExceeded(t *testing.T) {
+	t.Parallel()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	store := NewInMemoryStore()
+	g := NewWSGateway(log, NewHub(log), store, nil, nil)
+	g.resumeWindow = 2
+	ctx := context.Background()
+
+	for _, id := range []string{"a", "b", "c"} {
+		if _, err := store.AppendMessage(ctx, AppendMessageInput{ConversationID: "c1", ClientMsgID: id, SenderSession: "s0", Text: id}); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+	}
+
+	client := NewClient("u1", "s1", 64)
+	if err := g.onResume(ctx, client, newResumeEnvelope(t, v1.ResumeConversation{ConversationID: "c1", LastSeq: 0})); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	got := drainEnvelopes(client)
+	if len(got) != 1 || got[0].Type != v1.TypeResumeFailed {
+		t.Fatalf("expected a single resume.failed, got %+v", got)
+	}
+	var failed v1.ResumeFailedPayload
+	_ = json.Unmarshal(got[0].Payload, &failed)
+	if failed.ConversationID != "c1" || failed.Reason != resumeReasonWindowExceeded {
+		t.Fatalf("unexpected resume.failed %+v", failed)
+	}
+
+	// Exactly the window still replays.
+	client = NewClient("u1", "s2", 64)
+	if err := g.onResume(ctx, client, newResumeEnvelope(t, v1.ResumeConversation{ConversationID: "c1", LastSeq: 1})); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	if got := drainEnvelopes(client); len(got) != 3 || got[2].Type != v1.TypeResumeOK {
+		t.Fatalf("expected 2 replays and resume.ok, got %d envelopes", len(got))
+	}
+}
+
+func TestWSGateway_Resume_RejectsEmptyAndOversized(t *testing.T) {
+	t.Parallel()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	g := NewWSGateway(log, NewHub(log), NewInMemoryStore(), nil, nil)
+	client := NewClient("u1", "s1", 64)
+
+	if err := g.onResume(context.Background(), client, newResumeEnvelope(t)); err == nil {
+		t.Fatalf("expected empty resume to fail")
+	}
+	many := make([]v1.ResumeConversation, wsMaxResumeConvs+1)
+	for i := range many {
+		many[i] = v1.ResumeConversation{ConversationID: "c", LastSeq: 0}
+	}
+	if err := g.onResume(context.Background(), client, newResumeEnvelope(t, many...)); err == nil {
+		t.Fatalf("expected oversized resume to fail")
+	}
+}
@@ -0,0 +1,77 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"arc/cmd/internal/auth/session"
+	v1 "arc/shared/contracts/realtime/v1"
+)
+
+var (
+	errResumeTokenUnavailable = errors.New("resume tokens not configured")
+	errResumeTokenInvalid     = errors.New("invalid resume_token")
+)
+
+// resumeTokenService issues and validates the signed resume tokens handed to clients.
+//
+// A resume token embeds the session and its per-conversation cursors, so a client
+// reconnecting to any node can resume without server-side connection affinity.
+// *session.Service implements it with the session keyring.
+type resumeTokenService interface {
+	IssueResumeToken(userID, sessionID string, cursors map[string]int64, now time.Time) (string, time.Time, error)
+	ValidateResumeToken(ctx context.Context, token string, now time.Time) (session.ResumeClaims, error)
+}
+
+// issueResumeToken signs client's current cursors; "" when tokens are unavailable.
+func (g *WSGateway) issueResumeToken(client *Client, now time.Time) (string, time.Time) {
+	if g.resumeTokens == nil || client.UserID == "" {
+		return "", time.Time{}
+	}
+	token, exp, err := g.resumeTokens.IssueResumeToken(client.UserID, client.SessionID, client.Cursors(), now)
+	if err != nil {
+		g.log.Warn("ws.resume_token.issue.fail", "session_id", client.SessionID, "err", err)
+		return "", time.Time{}
+	}
+	return token, exp
+}
+
+// resumeTokenCursors validates token for client and returns its cursors.
+// Tokens issued to another user are rejected; the session may differ, since a
+// reconnect usually follows an access token refresh.
+func (g *WSGateway) resumeTokenCursors(ctx context.Context, client *Client, token string, now time.Time) (map[string]int64, error) {
+	if g.resumeTokens == nil {
+		return nil, errResumeTokenUnavailable
+	}
+	claims, err := g.resumeTokens.ValidateResumeToken(ctx, token, now)
+	if err != nil {
+		return nil, errResumeTokenInvalid
+	}
+	if claims.UserID != client.UserID {
+		return nil, errResumeTokenInvalid
+	}
+	return claims.Cursors, nil
+}
+
+// trackDelivered advances client's cursor after a message.new was written.
+func trackDelivered(client *Client, env v1.Envelope) {
+	if env.Type != v1.TypeMessageNew {
+		return
+	}
+	if isOrdered(env) {
+		client.markWritten(env)
+		client.AdvanceCursor(env.ConvID, env.Seq)
+		return
+	}
+	// Untagged envelopes are relayed by nodes that predate seq tags.
+	var p struct {
+		ConversationID string `json:"conversation_id"`
+		Seq            int64  `json:"seq"`
+	}
+	if err := json.Unmarshal(env.Payload, &p); err != nil || p.ConversationID == "" {
+		return
+	}
+	client.AdvanceCursor(p.ConversationID, p.Seq)
+}
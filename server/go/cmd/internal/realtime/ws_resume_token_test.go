@@ -0,0 +1,110 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"arc/cmd/internal/auth/session"
+	v1 "arc/shared/contracts/realtime/v1"
+)
+
+// fakeResumeTokens encodes claims as JSON instead of signing them.
+type fakeResumeTokens struct{}
+
+func (fakeResumeTokens) IssueResumeToken(userID, sessionID string, cursors map[string]int64, now time.Time) (string, time.Time, error) {
+	b, _ := json.Marshal(session.ResumeClaims{UserID: userID, SessionID: sessionID, Cursors: cursors})
+	return string(b), now.Add(time.Minute), nil
+}
+
+func (fakeResumeTokens) ValidateResumeToken(_ context.Context, token string, _ time.Time) (session.ResumeClaims, error) {
+	var c session.ResumeClaims
+	if err := json.Unmarshal([]byte(token), &c); err != nil {
+		return session.ResumeClaims{}, session.ErrInvalidToken
+	}
+	return c, nil
+}
+
+func TestWSGateway_ResumeToken_HelloCarriesCursorsAcrossNodes(t *testing.T) {
+	t.Parallel()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	store := NewInMemoryStore()
+	ctx := context.Background()
+	for _, id := range []string{"a", "b", "c"} {
+		if _, err := store.AppendMessage(ctx, AppendMessageInput{ConversationID: "c1", ClientMsgID: id, SenderSession: "s0", Text: id}); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+	}
+
+	// Node A delivered up to seq 1 and handed out a token on shutdown.
+	nodeA := NewWSGateway(log, NewHub(log), store, nil, nil)
+	nodeA.resumeTokens = fakeResumeTokens{}
+	old := NewClient("u1", "s1", 64)
+	stored, _ := store.FetchHistory(ctx, FetchHistoryInput{ConversationID: "c1", Limit: 1, AfterSeq: int64p(0)})
+	payload, _ := json.Marshal(messagePayload(stored.Messages[0]))
+	trackDelivered(old, mustNewEnvelope(v1.TypeMessageNew, payload, time.Now().UTC()))
+	token, _ := nodeA.issueResumeToken(old, time.Now().UTC())
+	if token == "" {
+		t.Fatalf("expected a resume token")
+	}
+
+	// Node B resumes from the token alone, after the session was refreshed.
+	nodeB := NewWSGateway(log, NewHub(log), store, nil, nil)
+	nodeB.resumeTokens = fakeResumeTokens{}
+	client := NewClient("u1", "s2", 64)
+	hello, _ := json.Marshal(v1.HelloPayload{ResumeToken: token})
+	if _, err := nodeB.onHello(ctx, client, mustNewEnvelope(v1.TypeHello, hello, time.Now().UTC())); err != nil {
+		t.Fatalf("hello: %v", err)
+	}
+	var ack v1.HelloAckPayload
+	_ = json.Unmarshal(drainEnvelopes(client)[0].Payload, &ack)
+	if ack.ResumeToken == "" || ack.ResumeTokenExpiresAt == nil {
+		t.Fatalf("expected hello.ack to carry a resume token, got %+v", ack)
+	}
+	if got := client.Cursors(); got["c1"] != 1 {
+		t.Fatalf("expected cursors seeded from token, got %+v", got)
+	}
+
+	resume, _ := json.Marshal(v1.ResumePayload{ResumeToken: ack.ResumeToken})
+	if err := nodeB.onResume(ctx, client, mustNewEnvelope(v1.TypeResume, resume, time.Now().UTC())); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	got := drainEnvelopes(client)
+	if len(got) != 3 || got[2].Type != v1.TypeResumeOK {
+		t.Fatalf("expected two replays and resume.ok, got %+v", got)
+	}
+	var ok v1.ResumeOKPayload
+	_ = json.Unmarshal(got[2].Payload, &ok)
+	if ok.ConversationID != "c1" || ok.Replayed != 2 || ok.LastSeq != 3 {
+		t.Fatalf("unexpected resume.ok %+v", ok)
+	}
+
+	// A token issued to another user is rejected.
+	other := NewClient("u2", "s3", 64)
+	if err := nodeB.onResume(ctx, other, mustNewEnvelope(v1.TypeResume, resume, time.Now().UTC())); err == nil || !strings.Contains(err.Error(), "resume_token") {
+		t.Fatalf("expected foreign token to be rejected, got %v", err)
+	}
+}
+
+func TestMergeResumeCursors_ExplicitPositionsWin(t *testing.T) {
+	t.Parallel()
+
+	got := mergeResumeCursors(
+		[]v1.ResumeConversation{{ConversationID: "c2", LastSeq: 7}},
+		map[string]int64{"c3": 1, "c2": 2, "c1": 4},
+	)
+	want := []v1.ResumeConversation{{ConversationID: "c2", LastSeq: 7}, {ConversationID: "c1", LastSeq: 4}, {ConversationID: "c3", LastSeq: 1}}
+	if len(got) != len(want) {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected %+v, got %+v", want, got)
+		}
+	}
+}
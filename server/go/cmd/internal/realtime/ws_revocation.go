@@ -0,0 +1,98 @@
+package realtime
+
+import (
+	"context"
+	"time"
+
+	"arc/cmd/internal/auth/session"
+
+	"github.com/coder/websocket"
+)
+
+// StatusSessionRevoked closes connections whose auth session was revoked (logout,
+// revoke-all, admin revocation, reuse detection, user deletion). Clients must
+// authenticate again before reconnecting.
+const (
+	StatusSessionRevoked websocket.StatusCode = 4001
+	reasonSessionRevoked                      = "session_revoked"
+)
+
+// revocationCheckTimeout bounds one session lookup.
+const revocationCheckTimeout = 5 * time.Second
+
+// sessionRevocations tells the gateway which auth sessions ended.
+// *session.Service implements it.
+type sessionRevocations interface {
+	RevokedChannel() string
+	SessionEnded(ctx context.Context, now time.Time, sessionID string) (bool, error)
+}
+
+// WatchSessionRevocations closes the connections of revoked auth sessions as soon
+// as the revocation commits. b must be a Postgres broker: revocations are announced
+// by a trigger on the sessions table (migration 0011), whatever ARC_BROKER is.
+//
+// After the subscription fails, connected sessions are checked again so
+// revocations missed in between still close their connections. It blocks until ctx
+// is done.
+func (g *WSGateway) WatchSessionRevocations(ctx context.Context, b Broker) {
+	if b == nil || g.revocations == nil {
+		return
+	}
+	channel := g.revocations.RevokedChannel()
+	g.log.Info("ws.revocations.start", "channel", channel)
+
+	backoff := brokerMinRetryBackoff
+	for {
+		started := time.Now()
+		err := b.Subscribe(ctx, channel, func(payload []byte) {
+			userID, sessionID, ok := session.ParseRevokedNotification(string(payload))
+			if !ok {
+				g.log.Warn("ws.revocations.decode.fail", "payload_len", len(payload))
+				return
+			}
+			g.closeRevoked(ctx, userID, sessionID)
+		})
+		if ctx.Err() != nil {
+			return
+		}
+
+		if time.Since(started) > brokerMaxRetryBackoff {
+			backoff = brokerMinRetryBackoff
+		}
+		g.log.Warn("ws.revocations.subscribe.fail", "err", err, "retry_in", backoff.String())
+
+		t := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+		}
+		backoff = min(backoff*2, brokerMaxRetryBackoff)
+		g.closeRevoked(ctx, "", "")
+	}
+}
+
+// closeRevoked closes userID's connections authenticated by sessionID, or by a
+// session it replaced through refresh rotation, with StatusSessionRevoked. Without
+// a userID every connected session is checked.
+func (g *WSGateway) closeRevoked(ctx context.Context, userID, sessionID string) {
+	now := time.Now().UTC()
+	for _, cl := range g.hub.clients(userID) {
+		if cl.SessionID != sessionID {
+			cctx, cancel := context.WithTimeout(ctx, revocationCheckTimeout)
+			ended, err := g.revocations.SessionEnded(cctx, now, cl.SessionID)
+			cancel()
+			if err != nil {
+				g.log.Warn("ws.revocations.check.fail", "session_id", cl.SessionID, "err", err)
+				continue
+			}
+			if !ended {
+				continue
+			}
+		}
+		cl.CloseWithStatus(StatusSessionRevoked, reasonSessionRevoked)
+		wsSessionRevokedClosed.Inc()
+		g.log.Info("ws.session_revoked", "user_id", cl.UserID, "session_id", cl.SessionID, "result", "closed")
+	}
+}
@@ -0,0 +1,85 @@
+package realtime
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+// fakeRevocations reports the sessions in ended as ended.
+type fakeRevocations struct {
+	ended map[string]bool
+}
+
+func (f fakeRevocations) RevokedChannel() string { return "test.session_revoked" }
+
+func (f fakeRevocations) SessionEnded(_ context.Context, _ time.Time, sessionID string) (bool, error) {
+	return f.ended[sessionID], nil
+}
+
+func TestWSGateway_WatchSessionRevocations_ClosesRevokedSessions(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	hub := NewHub(log)
+	g := NewWSGateway(log, hub, NewInMemoryStore(), nil, nil)
+	// s0 was rotated into the revoked s1; s2 is another live device.
+	g.revocations = fakeRevocations{ended: map[string]bool{"s0": true, "s1": true}}
+
+	conns := map[string]*fakeSessionConn{}
+	for _, sid := range []string{"s0", "s1", "s2"} {
+		conn := newFakeSessionConn()
+		conns[sid] = conn
+		go g.runSession(context.Background(), conn, "u1", sid)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for len(hub.Connections()) < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("sessions did not register")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	broker := newMemBroker()
+	go g.WatchSessionRevocations(ctx, broker)
+	broker.waitSubscribers(t, "test.session_revoked", 1)
+
+	_ = broker.Publish(ctx, "test.session_revoked", []byte("malformed"))
+	_ = broker.Publish(ctx, "test.session_revoked", []byte("u1 s1"))
+
+	for _, sid := range []string{"s0", "s1"} {
+		select {
+		case <-conns[sid].closed:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("%s: connection not closed", sid)
+		}
+		conns[sid].mu.Lock()
+		code := conns[sid].code
+		conns[sid].mu.Unlock()
+		if code != StatusSessionRevoked {
+			t.Fatalf("%s: expected close %d, got %v", sid, StatusSessionRevoked, code)
+		}
+	}
+	select {
+	case <-conns["s2"].closed:
+		t.Fatalf("live session was closed")
+	case <-time.After(50 * time.Millisecond):
+	}
+	_ = conns["s2"].Close(0, "")
+}
+
+func TestClient_CloseWithStatus_FirstCallWins(t *testing.T) {
+	c := NewClient("u1", "s1", 1)
+	if code, _ := c.closeStatus(); code != websocket.StatusPolicyViolation {
+		t.Fatalf("expected default policy violation, got %v", code)
+	}
+	c.CloseWithStatus(StatusSessionRevoked, reasonSessionRevoked)
+	c.Close()
+	if code, reason := c.closeStatus(); code != StatusSessionRevoked || reason != reasonSessionRevoked {
+		t.Fatalf("unexpected close status %v %q", code, reason)
+	}
+}
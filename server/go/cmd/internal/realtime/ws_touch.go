@@ -0,0 +1,94 @@
+package realtime
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	wsDefaultTouchInterval = time.Minute
+	// wsTouchFlushTimeout bounds one flush, including the final one on shutdown.
+	wsTouchFlushTimeout = 10 * time.Second
+)
+
+// sessionToucher records realtime activity on auth sessions.
+// *session.Service implements it.
+type sessionToucher interface {
+	TouchSessions(ctx context.Context, now time.Time, sessionIDs []string) (int64, error)
+}
+
+// touchBatch collects the auth sessions whose heartbeat succeeded since the last flush.
+type touchBatch struct {
+	mu      sync.Mutex
+	pending map[string]struct{}
+}
+
+func (b *touchBatch) add(sessionID string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.pending == nil {
+		b.pending = make(map[string]struct{})
+	}
+	b.pending[sessionID] = struct{}{}
+}
+
+// take returns the pending sessions and starts a new batch.
+func (b *touchBatch) take() []string {
+	b.mu.Lock()
+	pending := b.pending
+	b.pending = nil
+	b.mu.Unlock()
+
+	out := make([]string, 0, len(pending))
+	for sid := range pending {
+		out = append(out, sid)
+	}
+	return out
+}
+
+// markActive queues an authenticated session for the next touch flush.
+func (g *WSGateway) markActive(userID, sessionID string) {
+	if g.touches == nil || g.touchInterval <= 0 || userID == "" {
+		return
+	}
+	g.touchBatch.add(sessionID)
+}
+
+// RunSessionTouches updates last_used_at of every authenticated session whose
+// heartbeat succeeded, with one batched UPDATE every ARC_WS_TOUCH_INTERVAL instead
+// of a write per ping. Pending touches are flushed once more when ctx is done.
+// It blocks until then.
+func (g *WSGateway) RunSessionTouches(ctx context.Context) {
+	if g.touches == nil || g.touchInterval <= 0 {
+		return
+	}
+
+	t := time.NewTicker(g.touchInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			g.flushTouches(context.WithoutCancel(ctx))
+			return
+		case <-t.C:
+			g.flushTouches(ctx)
+		}
+	}
+}
+
+func (g *WSGateway) flushTouches(ctx context.Context) {
+	ids := g.touchBatch.take()
+	if len(ids) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, wsTouchFlushTimeout)
+	defer cancel()
+	touched, err := g.touches.TouchSessions(ctx, time.Now().UTC(), ids)
+	if err != nil {
+		g.log.Warn("ws.touch.flush.fail", "sessions", len(ids), "touched", touched, "err", err)
+		return
+	}
+	g.log.Debug("ws.touch.flush", "sessions", len(ids), "touched", touched, "result", "ok")
+}
@@ -0,0 +1,80 @@
+package realtime
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingToucher records every TouchSessions batch.
+type recordingToucher struct {
+	mu      sync.Mutex
+	batches [][]string
+}
+
+func (r *recordingToucher) TouchSessions(_ context.Context, _ time.Time, ids []string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batches = append(r.batches, slices.Sorted(slices.Values(ids)))
+	return int64(len(ids)), nil
+}
+
+func (r *recordingToucher) snapshot() [][]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.batches)
+}
+
+func TestWSGateway_RunSessionTouches_BatchesHeartbeats(t *testing.T) {
+	t.Setenv("ARC_WS_HEARTBEAT_INTERVAL", "5ms")
+	t.Setenv("ARC_WS_TOUCH_INTERVAL", "30ms")
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	g := NewWSGateway(log, NewHub(log), NewInMemoryStore(), nil, nil)
+	toucher := &recordingToucher{}
+	g.touches = toucher
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// Anonymous sessions have no auth session to touch.
+	for _, c := range [][2]string{{"u1", "s1"}, {"u2", "s2"}, {"", "anon"}} {
+		go g.runSession(ctx, newFakeSessionConn(), c[0], c[1])
+	}
+
+	done := make(chan struct{})
+	touchCtx, stopTouches := context.WithCancel(context.Background())
+	go func() {
+		defer close(done)
+		g.RunSessionTouches(touchCtx)
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		batches := toucher.snapshot()
+		if len(batches) > 0 && slices.Equal(batches[len(batches)-1], []string{"s1", "s2"}) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected a batch with s1 and s2, got %v", batches)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	for _, b := range toucher.snapshot() {
+		if slices.Contains(b, "anon") || len(b) > 2 {
+			t.Fatalf("unexpected batch %v", b)
+		}
+	}
+
+	// Pending touches are flushed on shutdown.
+	g.markActive("u3", "s3")
+	stopTouches()
+	<-done
+	batches := toucher.snapshot()
+	if last := batches[len(batches)-1]; !slices.Contains(last, "s3") {
+		t.Fatalf("expected final flush to include s3, got %v", last)
+	}
+}
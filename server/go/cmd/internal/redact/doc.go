@@ -0,0 +1,13 @@
+// Package redact masks sensitive values before they reach logs or audit rows.
+//
+// A Redactor replaces values of secret-looking attribute names (tokens,
+// passwords, cookies, keys) entirely, masks bearer and PASETO tokens and token
+// query parameters inside strings, shortens email addresses to their first
+// character and domain and phone numbers to their first and last digits, and
+// truncates IP addresses to a configurable prefix.
+//
+// NewHandler applies it to every record of a slog handler; Meta applies it to
+// audit_log meta before it is stored. The process-wide Redactor is configured
+// from ARC_REDACT_* variables at startup (see LoadConfigFromEnv) and replaced
+// with SetDefault.
+package redact
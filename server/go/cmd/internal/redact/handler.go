@@ -0,0 +1,99 @@
+package redact
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/netip"
+)
+
+// Handler is a slog.Handler that redacts every attribute, including ones added
+// with WithAttrs, before passing records to the next handler.
+type Handler struct {
+	next slog.Handler
+	r    *Redactor
+}
+
+// NewHandler wraps next; a nil r uses the process-wide Redactor at log time, so
+// SetDefault also applies to loggers built earlier.
+func NewHandler(next slog.Handler, r *Redactor) *Handler {
+	return &Handler{next: next, r: r}
+}
+
+func (h *Handler) redactor() *Redactor {
+	if h.r != nil {
+		return h.r
+	}
+	return Default()
+}
+
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *Handler) Handle(ctx context.Context, rec slog.Record) error {
+	r := h.redactor()
+	out := slog.NewRecord(rec.Time, rec.Level, rec.Message, rec.PC)
+	rec.Attrs(func(a slog.Attr) bool {
+		out.AddAttrs(r.Attr(a))
+		return true
+	})
+	return h.next.Handle(ctx, out)
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	r := h.redactor()
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = r.Attr(a)
+	}
+	return &Handler{next: h.next.WithAttrs(redacted), r: h.r}
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{next: h.next.WithGroup(name), r: h.r}
+}
+
+// Attr redacts a single log attribute, resolving LogValuers and recursing into
+// groups.
+func (r *Redactor) Attr(a slog.Attr) slog.Attr {
+	v := a.Value.Resolve()
+	if r.SensitiveKey(a.Key) && !isEmptyValue(v) {
+		return slog.String(a.Key, Masked)
+	}
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, r.String(v.String()))
+	case slog.KindGroup:
+		group := v.Group()
+		out := make([]slog.Attr, len(group))
+		for i, ga := range group {
+			out[i] = r.Attr(ga)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(out...)}
+	case slog.KindAny:
+		switch x := v.Any().(type) {
+		case error:
+			return slog.String(a.Key, r.String(x.Error()))
+		case net.IP:
+			return slog.String(a.Key, r.IP(x))
+		case netip.Addr:
+			return slog.String(a.Key, r.addr(x.Unmap()))
+		case map[string]any:
+			return slog.Any(a.Key, r.Meta(x))
+		case []string:
+			return slog.Any(a.Key, r.value(x))
+		}
+	}
+	return slog.Attr{Key: a.Key, Value: v}
+}
+
+func isEmptyValue(v slog.Value) bool {
+	switch v.Kind() {
+	case slog.KindString:
+		return v.String() == ""
+	case slog.KindAny:
+		return v.Any() == nil
+	}
+	return false
+}
@@ -0,0 +1,38 @@
+package redact
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	log := slog.New(NewHandler(slog.NewJSONHandler(&buf, nil), New(DefaultConfig()))).
+		With("refresh_token", "rt-123", "user", "dave@example.com")
+
+	log.Info("auth.login",
+		"remote", "192.0.2.44:5555",
+		"ip", net.ParseIP("192.0.2.44"),
+		"err", errors.New("Authorization: Bearer abc.def"),
+		slog.Group("req", "cookie", "arc_refresh_token=xyz", "path", "/x"),
+		"session_id", "01SESSION",
+	)
+
+	out := buf.String()
+	for _, leak := range []string{"rt-123", "dave@", "192.0.2.44", "abc.def", "xyz"} {
+		if strings.Contains(out, leak) {
+			t.Fatalf("%q leaked:\n%s", leak, out)
+		}
+	}
+	for _, want := range []string{`"user":"d***@example.com"`, `"remote":"192.0.2.0/24"`, `"ip":"192.0.2.0/24"`, `"session_id":"01SESSION"`, `"path":"/x"`} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %s:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package redact
+
+import (
+	"net"
+	"net/netip"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// Masked replaces values that must not be logged at all.
+const Masked = "[REDACTED]"
+
+// Config tunes a Redactor. Tokens and secrets are always masked.
+type Config struct {
+	// Emails partially masks email addresses ("a***@example.com").
+	Emails bool
+	// Phones partially masks E.164 phone numbers ("+44***89").
+	Phones bool
+	// IPv4PrefixBits and IPv6PrefixBits truncate IP addresses to a network
+	// prefix; 32 and 128 keep addresses intact, 0 masks them entirely.
+	IPv4PrefixBits int
+	IPv6PrefixBits int
+	// Keys are extra attribute names whose values are masked entirely.
+	Keys []string
+}
+
+// DefaultConfig masks emails and phone numbers and keeps the /24 (IPv4) or /48 (IPv6) network.
+func DefaultConfig() Config {
+	return Config{Emails: true, Phones: true, IPv4PrefixBits: 24, IPv6PrefixBits: 48}
+}
+
+// LoadConfigFromEnv loads redaction settings from environment variables.
+func LoadConfigFromEnv() Config {
+	cfg := DefaultConfig()
+	if v, err := strconv.ParseBool(strings.TrimSpace(os.Getenv("ARC_REDACT_EMAILS"))); err == nil {
+		cfg.Emails = v
+	}
+	if v, err := strconv.ParseBool(strings.TrimSpace(os.Getenv("ARC_REDACT_PHONES"))); err == nil {
+		cfg.Phones = v
+	}
+	cfg.IPv4PrefixBits = envBits("ARC_REDACT_IPV4_PREFIX", cfg.IPv4PrefixBits, 32)
+	cfg.IPv6PrefixBits = envBits("ARC_REDACT_IPV6_PREFIX", cfg.IPv6PrefixBits, 128)
+	for _, k := range strings.Split(os.Getenv("ARC_REDACT_KEYS"), ",") {
+		if k = strings.ToLower(strings.TrimSpace(k)); k != "" {
+			cfg.Keys = append(cfg.Keys, k)
+		}
+	}
+	return cfg
+}
+
+func envBits(key string, def, maxBits int) int {
+	n, err := strconv.Atoi(strings.TrimSpace(os.Getenv(key)))
+	if err != nil || n < 0 || n > maxBits {
+		return def
+	}
+	return n
+}
+
+// Redactor masks sensitive values. It is safe for concurrent use.
+type Redactor struct {
+	cfg  Config
+	keys map[string]bool
+}
+
+// New builds a Redactor from cfg.
+func New(cfg Config) *Redactor {
+	r := &Redactor{cfg: cfg, keys: make(map[string]bool, len(cfg.Keys))}
+	for _, k := range cfg.Keys {
+		r.keys[strings.ToLower(k)] = true
+	}
+	return r
+}
+
+var std atomic.Pointer[Redactor]
+
+func init() {
+	std.Store(New(DefaultConfig()))
+}
+
+// Default returns the process-wide Redactor.
+func Default() *Redactor { return std.Load() }
+
+// SetDefault replaces the process-wide Redactor.
+func SetDefault(r *Redactor) {
+	if r != nil {
+		std.Store(r)
+	}
+}
+
+// SensitiveKey reports whether values under the attribute or meta key name are
+// masked entirely.
+func (r *Redactor) SensitiveKey(name string) bool {
+	k := strings.ToLower(name)
+	if r.keys[k] {
+		return true
+	}
+	if strings.HasSuffix(k, "_id") || strings.HasSuffix(k, "_hash") {
+		return false
+	}
+	switch k {
+	case "authorization", "cookie", "set-cookie", "set_cookie", "code", "otp", "totp", "recovery_code":
+		return true
+	}
+	if strings.HasSuffix(k, "token") || strings.HasSuffix(k, "tokens") {
+		return true
+	}
+	for _, s := range []string{"password", "passwd", "secret", "api_key", "private_key"} {
+		if strings.Contains(k, s) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	bearerRE = regexp.MustCompile(`(?i)\b(bearer|basic)\s+[A-Za-z0-9._~+/=-]+`)
+	pasetoRE = regexp.MustCompile(`\bv[1-4]\.(?:local|public)\.[A-Za-z0-9_-]+(?:\.[A-Za-z0-9_-]+)?`)
+	jwtRE    = regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	queryRE  = regexp.MustCompile(`(?i)([?&;](?:access_token|refresh_token|token|ticket|code|password)=)[^&;\s"]+`)
+	emailRE  = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`)
+	phoneRE  = regexp.MustCompile(`(?:^|[^\w+])\+[1-9][0-9]{6,14}\b`)
+)
+
+// String masks tokens, emails and phone numbers inside s. A string that is a client IP address
+// (or IP:port) is truncated like IP; loopback and unspecified addresses, such as
+// listen addresses, are kept.
+func (r *Redactor) String(s string) string {
+	if s == "" {
+		return s
+	}
+	if ip, ok := parseAddr(s); ok {
+		if ip.IsLoopback() || ip.IsUnspecified() {
+			return s
+		}
+		return r.addr(ip)
+	}
+	if strings.Contains(s, "earer ") || strings.Contains(s, "asic ") || strings.Contains(s, "EARER ") || strings.Contains(s, "ASIC ") {
+		s = bearerRE.ReplaceAllString(s, "${1} "+Masked)
+	}
+	if strings.Contains(s, ".public.") || strings.Contains(s, ".local.") {
+		s = pasetoRE.ReplaceAllString(s, Masked)
+	}
+	if strings.Contains(s, "eyJ") {
+		s = jwtRE.ReplaceAllString(s, Masked)
+	}
+	if strings.Contains(s, "=") {
+		s = queryRE.ReplaceAllString(s, "${1}"+Masked)
+	}
+	if r.cfg.Emails && strings.Contains(s, "@") {
+		s = emailRE.ReplaceAllStringFunc(s, maskEmail)
+	}
+	if r.cfg.Phones && strings.Contains(s, "+") {
+		s = phoneRE.ReplaceAllStringFunc(s, maskPhone)
+	}
+	return s
+}
+
+// maskEmail keeps the first character of the local part and the domain.
+func maskEmail(email string) string {
+	at := strings.LastIndexByte(email, '@')
+	if at <= 0 {
+		return email
+	}
+	return email[:1] + "***" + email[at:]
+}
+
+// maskPhone keeps the plus sign, the first two and the last two digits. The match
+// may start with the character before the plus sign.
+func maskPhone(match string) string {
+	i := strings.IndexByte(match, '+')
+	phone := match[i:]
+	return match[:i] + phone[:3] + "***" + phone[len(phone)-2:]
+}
+
+// IP returns ip truncated to the configured prefix, e.g. "203.0.113.0/24".
+func (r *Redactor) IP(ip net.IP) string {
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return ""
+	}
+	return r.addr(addr.Unmap())
+}
+
+func (r *Redactor) addr(a netip.Addr) string {
+	bits := r.cfg.IPv6PrefixBits
+	if a.Is4() {
+		bits = r.cfg.IPv4PrefixBits
+	}
+	if bits >= a.BitLen() {
+		return a.String()
+	}
+	if bits <= 0 {
+		return Masked
+	}
+	p, err := a.Prefix(bits)
+	if err != nil {
+		return Masked
+	}
+	return p.String()
+}
+
+// parseAddr accepts "ip", "ip:port" and "[ipv6]:port".
+func parseAddr(s string) (netip.Addr, bool) {
+	if len(s) > 47 || !strings.ContainsAny(s, ".:") {
+		return netip.Addr{}, false
+	}
+	if a, err := netip.ParseAddr(s); err == nil {
+		return a.Unmap(), true
+	}
+	if ap, err := netip.ParseAddrPort(s); err == nil {
+		return ap.Addr().Unmap(), true
+	}
+	return netip.Addr{}, false
+}
+
+// Meta returns a copy of audit meta with sensitive values masked, recursing into
+// nested maps and slices. Callers keep using meta unchanged.
+func (r *Redactor) Meta(meta map[string]any) map[string]any {
+	if meta == nil {
+		return nil
+	}
+	out := make(map[string]any, len(meta))
+	for k, v := range meta {
+		if r.SensitiveKey(k) && !isEmpty(v) {
+			out[k] = Masked
+			continue
+		}
+		out[k] = r.value(v)
+	}
+	return out
+}
+
+func (r *Redactor) value(v any) any {
+	switch x := v.(type) {
+	case string:
+		return r.String(x)
+	case map[string]any:
+		return r.Meta(x)
+	case []any:
+		out := make([]any, len(x))
+		for i, e := range x {
+			out[i] = r.value(e)
+		}
+		return out
+	case []string:
+		out := make([]string, len(x))
+		for i, e := range x {
+			out[i] = r.String(e)
+		}
+		return out
+	case net.IP:
+		return r.IP(x)
+	case netip.Addr:
+		return r.addr(x.Unmap())
+	case error:
+		return r.String(x.Error())
+	default:
+		return v
+	}
+}
+
+func isEmpty(v any) bool {
+	if v == nil {
+		return true
+	}
+	s, ok := v.(string)
+	return ok && s == ""
+}
+
+// Meta redacts audit meta with the process-wide Redactor.
+func Meta(meta map[string]any) map[string]any { return Default().Meta(meta) }
@@ -0,0 +1,84 @@
+package redact
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestRedactor_String(t *testing.T) {
+	t.Parallel()
+
+	r := New(DefaultConfig())
+	cases := []struct{ in, want string }{
+		{"hello", "hello"},
+		{"Bearer v4.public.eyJzdWIiOiJ4In0.sig", "Bearer [REDACTED]"},
+		{"token v4.local.abcDEF_123 rejected", "token [REDACTED] rejected"},
+		{"jwt eyJhbGciOi.eyJzdWIi.c2ln", "jwt [REDACTED]"},
+		{"/ws?access_token=abc123&x=1", "/ws?access_token=[REDACTED]&x=1"},
+		{"duplicate key: alice@example.com", "duplicate key: a***@example.com"},
+		{"+442071838750", "+44***50"},
+		{"sms to +14155550100 failed", "sms to +14***00 failed"},
+		{"1+1234567", "1+1234567"},
+		{"203.0.113.77", "203.0.113.0/24"},
+		{"203.0.113.77:51234", "203.0.113.0/24"},
+		{"[2001:db8:1:2::5]:443", "2001:db8:1::/48"},
+		{"0.0.0.0:8080", "0.0.0.0:8080"},
+		{"127.0.0.1:6060", "127.0.0.1:6060"},
+		{"1.25", "1.25"},
+	}
+	for _, tc := range cases {
+		if got := r.String(tc.in); got != tc.want {
+			t.Errorf("String(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+
+	keep := New(Config{Emails: false, IPv4PrefixBits: 32, IPv6PrefixBits: 128})
+	if got := keep.String("203.0.113.77 alice@example.com +14155550100"); got != "203.0.113.77 alice@example.com +14155550100" {
+		t.Fatalf("disabled masking changed %q", got)
+	}
+	if got := New(Config{}).IP(net.ParseIP("203.0.113.77")); got != Masked {
+		t.Fatalf("zero prefix = %q, want fully masked", got)
+	}
+}
+
+func TestRedactor_Meta(t *testing.T) {
+	t.Parallel()
+
+	r := New(Config{Emails: true, IPv4PrefixBits: 24, IPv6PrefixBits: 48, Keys: []string{"note"}})
+	meta := map[string]any{
+		"identifier":      "bob@example.org",
+		"identifier_hash": "ab12",
+		"invite_token":    "secret-value",
+		"target_user_id":  "01USER",
+		"token_ttl":       "15m",
+		"note":            "free text",
+		"password":        "",
+		"count":           3,
+		"filter":          map[string]any{"ip": "198.51.100.9", "err": errors.New("mail carol@example.net failed")},
+		"ips":             []any{"198.51.100.9"},
+	}
+	got := r.Meta(meta)
+	want := map[string]any{
+		"identifier":      "b***@example.org",
+		"identifier_hash": "ab12",
+		"invite_token":    Masked,
+		"target_user_id":  "01USER",
+		"token_ttl":       "15m",
+		"note":            Masked,
+		"password":        "",
+		"count":           3,
+		"filter":          map[string]any{"ip": "198.51.100.0/24", "err": "mail c***@example.net failed"},
+		"ips":             []any{"198.51.100.0/24"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Meta =\n%#v\nwant\n%#v", got, want)
+	}
+	if meta["invite_token"] != "secret-value" {
+		t.Fatal("Meta modified its input")
+	}
+	if r.Meta(nil) != nil {
+		t.Fatal("Meta(nil) should stay nil")
+	}
+}
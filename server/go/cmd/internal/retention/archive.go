@@ -0,0 +1,164 @@
+package retention
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"arc/cmd/internal/attachments"
+)
+
+// s3RequestTTL bounds the pre-signed URLs the S3 archive issues to itself.
+const s3RequestTTL = 5 * time.Minute
+
+// maxArchiveBytes bounds a single archive object read back by Get.
+const maxArchiveBytes = 256 << 20
+
+// ErrArchiveNotFound is returned by Get for a missing object.
+var ErrArchiveNotFound = errors.New("retention: archive object not found")
+
+// ArchiveStore stores archive objects by key.
+type ArchiveStore interface {
+	Put(ctx context.Context, key string, body []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+}
+
+// NewArchiveStore constructs the configured archive store.
+func NewArchiveStore(cfg Config) (ArchiveStore, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	switch cfg.Backend {
+	case BackendS3:
+		return NewS3Archive(cfg.S3, nil)
+	default:
+		return NewLocalArchive(cfg.LocalDir)
+	}
+}
+
+// LocalArchive stores archive objects as files below a directory.
+type LocalArchive struct {
+	dir string
+}
+
+// NewLocalArchive constructs a LocalArchive rooted at dir.
+func NewLocalArchive(dir string) (*LocalArchive, error) {
+	if dir == "" {
+		return nil, errors.New("retention: empty archive dir")
+	}
+	return &LocalArchive{dir: dir}, nil
+}
+
+// Put implements ArchiveStore. The object becomes visible only once fully written.
+func (a *LocalArchive) Put(_ context.Context, key string, body []byte) error {
+	path, err := a.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, body, 0o640); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Get implements ArchiveStore.
+func (a *LocalArchive) Get(_ context.Context, key string) ([]byte, error) {
+	path, err := a.path(key)
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrArchiveNotFound
+	}
+	return b, err
+}
+
+func (a *LocalArchive) path(key string) (string, error) {
+	if key == "" || strings.Contains(key, "..") || strings.HasPrefix(key, "/") {
+		return "", errors.New("retention: invalid archive key")
+	}
+	return filepath.Join(a.dir, filepath.FromSlash(key)), nil
+}
+
+// S3Archive stores archive objects in an S3-compatible bucket using pre-signed requests.
+type S3Archive struct {
+	signer *attachments.S3Backend
+	client *http.Client
+}
+
+// NewS3Archive constructs an S3Archive. A nil client uses a client with a 30s timeout.
+func NewS3Archive(cfg attachments.S3Config, client *http.Client) (*S3Archive, error) {
+	signer, err := attachments.NewS3Backend(cfg, nil)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &S3Archive{signer: signer, client: client}, nil
+}
+
+// Put implements ArchiveStore.
+func (a *S3Archive) Put(ctx context.Context, key string, body []byte) error {
+	headers := map[string]string{"Content-Type": "application/gzip"}
+	resp, err := a.do(ctx, http.MethodPut, key, headers, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("retention: archive put: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Get implements ArchiveStore.
+func (a *S3Archive) Get(ctx context.Context, key string) ([]byte, error) {
+	resp, err := a.do(ctx, http.MethodGet, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrArchiveNotFound
+	case resp.StatusCode/100 != 2:
+		return nil, fmt.Errorf("retention: archive get: status %d", resp.StatusCode)
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxArchiveBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) > maxArchiveBytes {
+		return nil, errors.New("retention: archive object too large")
+	}
+	return b, nil
+}
+
+func (a *S3Archive) do(ctx context.Context, method, key string, headers map[string]string, body io.Reader) (*http.Response, error) {
+	u, err := a.signer.PresignObject(method, key, headers, s3RequestTTL)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	return a.client.Do(req)
+}
@@ -0,0 +1,122 @@
+package retention
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"arc/cmd/internal/attachments"
+)
+
+func TestArchiveEncodingRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	deleted := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	in := []ArchivedMessage{
+		{ConversationID: "c1", Seq: 1, ServerMsgID: "m1", ClientMsgID: "k1", SenderSession: "s1", Text: "hi", ServerTS: deleted, Version: 1,
+			AttachmentIDs: []string{"a1"}, Entities: []byte(`[{"type":"link","offset":0,"length":2,"url":"https://x"}]`)},
+		{ConversationID: "c1", Seq: 2, ServerMsgID: "m2", ClientMsgID: "k2", SenderBotID: "b1", ServerTS: deleted, Version: 2, DeletedAt: &deleted},
+	}
+	body, err := encodeArchive(in)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	out, err := decodeArchive(body)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(out) != 2 || out[0].Text != "hi" || string(out[0].Entities) != string(in[0].Entities) ||
+		out[1].SenderBotID != "b1" || out[1].DeletedAt == nil || !out[1].DeletedAt.Equal(deleted) {
+		t.Fatalf("round trip = %+v", out)
+	}
+	if _, err := decodeArchive([]byte("not gzip")); err == nil {
+		t.Fatalf("expected error for invalid archive")
+	}
+}
+
+func TestLocalArchive(t *testing.T) {
+	t.Parallel()
+
+	a, err := NewLocalArchive(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalArchive: %v", err)
+	}
+	ctx := context.Background()
+	if err := a.Put(ctx, "messages/c1/1-2.ndjson.gz", []byte("data")); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	b, err := a.Get(ctx, "messages/c1/1-2.ndjson.gz")
+	if err != nil || string(b) != "data" {
+		t.Fatalf("Get = %q, %v", b, err)
+	}
+	if _, err := a.Get(ctx, "messages/c1/missing"); !errors.Is(err, ErrArchiveNotFound) {
+		t.Fatalf("Get missing err = %v", err)
+	}
+	if err := a.Put(ctx, "../escape", []byte("x")); err == nil {
+		t.Fatalf("expected invalid key error")
+	}
+}
+
+func TestS3ArchivePutGet(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu      sync.Mutex
+		objects = map[string][]byte{}
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("X-Amz-Signature") == "" {
+			http.Error(w, "unsigned", http.StatusForbidden)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodPut:
+			b, _ := io.ReadAll(r.Body)
+			objects[r.URL.Path] = b
+		case http.MethodGet:
+			b, ok := objects[r.URL.Path]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = w.Write(b)
+		}
+	}))
+	defer srv.Close()
+
+	a, err := NewS3Archive(attachments.S3Config{
+		Endpoint:        srv.URL,
+		Region:          "us-east-1",
+		Bucket:          "arc-archive",
+		AccessKeyID:     "AKID",
+		SecretAccessKey: "secret",
+		PathStyle:       true,
+	}, srv.Client())
+	if err != nil {
+		t.Fatalf("NewS3Archive: %v", err)
+	}
+	ctx := context.Background()
+	if err := a.Put(ctx, "messages/c1/1-2.ndjson.gz", []byte("data")); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	mu.Lock()
+	_, stored := objects["/arc-archive/messages/c1/1-2.ndjson.gz"]
+	mu.Unlock()
+	if !stored {
+		t.Fatalf("object not stored at bucket path")
+	}
+	b, err := a.Get(ctx, "messages/c1/1-2.ndjson.gz")
+	if err != nil || string(b) != "data" {
+		t.Fatalf("Get = %q, %v", b, err)
+	}
+	if _, err := a.Get(ctx, "messages/c1/missing"); !errors.Is(err, ErrArchiveNotFound) {
+		t.Fatalf("Get missing err = %v", err)
+	}
+}
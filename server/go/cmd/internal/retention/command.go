@@ -0,0 +1,63 @@
+package retention
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const commandUsage = `usage:
+  arc retention run
+  arc retention restore -conversation ID [-from SEQ] -to SEQ`
+
+// RunCommand runs an operator subcommand: "run" performs a single archival pass and
+// "restore" reinserts an archived seq range of one conversation.
+func RunCommand(ctx context.Context, log *slog.Logger, pool *pgxpool.Pool, cfg Config, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(commandUsage)
+	}
+	job, err := NewJob(log, pool, cfg)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "run":
+		if !cfg.Enabled() {
+			return errors.New("retention: no policy configured (set ARC_RETENTION_{DIRECT,GROUP,ROOM}_MAX_AGE or _MAX_MESSAGES)")
+		}
+		st, err := job.RunOnce(ctx)
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(out, "archived %d messages from %d conversations into %d archives\n", st.Messages, st.Conversations, st.Archives)
+		return nil
+
+	case "restore":
+		fs := flag.NewFlagSet("restore", flag.ContinueOnError)
+		fs.SetOutput(out)
+		convID := fs.String("conversation", "", "conversation id")
+		from := fs.Int64("from", 1, "first seq to restore")
+		to := fs.Int64("to", 0, "last seq to restore")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if *convID == "" || *to == 0 {
+			return errors.New(commandUsage)
+		}
+		n, err := job.Restore(ctx, *convID, *from, *to)
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(out, "restored %d messages into %s\n", n, *convID)
+		return nil
+
+	default:
+		return errors.New(commandUsage)
+	}
+}
@@ -0,0 +1,160 @@
+package retention
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"arc/cmd/internal/attachments"
+	"arc/cmd/internal/jobs"
+)
+
+// Archive backends.
+const (
+	BackendLocal = "local"
+	BackendS3    = "s3"
+)
+
+// Kinds are the conversation kinds a policy can be set for.
+var Kinds = []string{"direct", "group", "room"}
+
+// Policy caps the messages kept in each conversation of one kind. Zero fields are unlimited.
+type Policy struct {
+	// MaxAge archives messages whose server timestamp is older than now-MaxAge.
+	MaxAge time.Duration
+	// MaxMessages archives all but the newest MaxMessages messages (by seq).
+	MaxMessages int64
+}
+
+// Enabled reports whether the policy caps anything.
+func (p Policy) Enabled() bool {
+	return p.MaxAge > 0 || p.MaxMessages > 0
+}
+
+// Config controls message retention.
+type Config struct {
+	// Policies is keyed by conversation kind; kinds without a policy keep everything.
+	Policies map[string]Policy
+
+	// Interval is the time between job runs.
+	Interval time.Duration
+	// Schedule is a jobs.Parse spec ("0 3 * * *") that replaces Interval when set.
+	Schedule string
+	// BatchSize bounds the messages written to one archive object.
+	BatchSize int
+
+	// Backend is BackendLocal or BackendS3.
+	Backend string
+	// LocalDir stores archives for the local backend.
+	LocalDir string
+	// Prefix is prepended to every archive object key.
+	Prefix string
+	S3     attachments.S3Config
+}
+
+// LoadConfigFromEnv loads retention config from environment variables.
+// Policies are read from ARC_RETENTION_{KIND}_MAX_AGE and ARC_RETENTION_{KIND}_MAX_MESSAGES.
+func LoadConfigFromEnv() Config {
+	cfg := Config{
+		Policies:  map[string]Policy{},
+		Interval:  envDuration("ARC_RETENTION_INTERVAL", time.Hour),
+		Schedule:  envString("ARC_RETENTION_SCHEDULE", ""),
+		BatchSize: envInt("ARC_RETENTION_BATCH_SIZE", 1000),
+		Backend:   strings.ToLower(envString("ARC_RETENTION_ARCHIVE_BACKEND", BackendLocal)),
+		LocalDir:  envString("ARC_RETENTION_ARCHIVE_LOCAL_DIR", "./data/archives"),
+		Prefix:    strings.Trim(envString("ARC_RETENTION_ARCHIVE_PREFIX", "messages"), "/"),
+		S3: attachments.S3Config{
+			Endpoint:        strings.TrimRight(envString("ARC_RETENTION_ARCHIVE_S3_ENDPOINT", ""), "/"),
+			Region:          envString("ARC_RETENTION_ARCHIVE_S3_REGION", "us-east-1"),
+			Bucket:          envString("ARC_RETENTION_ARCHIVE_S3_BUCKET", ""),
+			AccessKeyID:     envString("ARC_RETENTION_ARCHIVE_S3_ACCESS_KEY_ID", ""),
+			SecretAccessKey: envString("ARC_RETENTION_ARCHIVE_S3_SECRET_ACCESS_KEY", ""),
+			PathStyle:       envBool("ARC_RETENTION_ARCHIVE_S3_PATH_STYLE", false),
+		},
+	}
+	for _, kind := range Kinds {
+		prefix := "ARC_RETENTION_" + strings.ToUpper(kind)
+		p := Policy{
+			MaxAge:      envDuration(prefix+"_MAX_AGE", 0),
+			MaxMessages: int64(envInt(prefix+"_MAX_MESSAGES", 0)),
+		}
+		if p.Enabled() {
+			cfg.Policies[kind] = p
+		}
+	}
+	return cfg
+}
+
+// Enabled reports whether any kind has a policy.
+func (c Config) Enabled() bool {
+	return len(c.Policies) > 0
+}
+
+// Validate checks the schedule and archive backend settings.
+func (c Config) Validate() error {
+	if c.Schedule != "" {
+		if _, err := jobs.Parse(c.Schedule); err != nil {
+			return fmt.Errorf("retention: ARC_RETENTION_SCHEDULE: %w", err)
+		}
+	}
+	switch c.Backend {
+	case BackendLocal:
+		if c.LocalDir == "" {
+			return errors.New("retention: local archive backend requires ARC_RETENTION_ARCHIVE_LOCAL_DIR")
+		}
+	case BackendS3:
+		if c.S3.Endpoint == "" || c.S3.Bucket == "" {
+			return errors.New("retention: s3 archive backend requires endpoint and bucket")
+		}
+	default:
+		return errors.New("retention: unknown archive backend " + c.Backend)
+	}
+	return nil
+}
+
+func envString(key, def string) string {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return def
+	}
+	return v
+}
+
+func envInt(key string, def int) int {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		return def
+	}
+	return n
+}
+
+func envDuration(key string, def time.Duration) time.Duration {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		return def
+	}
+	return d
+}
+
+func envBool(key string, def bool) bool {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return def
+	}
+	return b
+}
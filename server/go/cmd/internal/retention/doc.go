@@ -0,0 +1,9 @@
+// Package retention archives and deletes old messages according to per-kind policies.
+//
+// Each conversation kind (direct, group, room) may cap message age and the number of
+// messages kept per conversation. A scheduled Job finds messages beyond either cap,
+// writes them as gzip-compressed NDJSON to the configured archive store (local disk or
+// an S3-compatible bucket), records the object in arc.message_archives and only then
+// deletes the rows. Restore (exposed as "arc retention restore") reads the archives of
+// a conversation back into arc.messages.
+package retention
@@ -0,0 +1,239 @@
+package retention
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+
+	"arc/cmd/internal/jobs"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oklog/ulid/v2"
+)
+
+// candidatePageSize bounds the conversations listed per query.
+const candidatePageSize = 100
+
+// jobStore is the subset of PostgresStore the Job needs.
+type jobStore interface {
+	tryLock(ctx context.Context) (release func(), ok bool, err error)
+	candidates(ctx context.Context, kind string, p Policy, now time.Time, afterID string, limit int) ([]candidate, error)
+	loadMessages(ctx context.Context, conversationID string, upToSeq int64, limit int) ([]ArchivedMessage, error)
+	commitArchive(ctx context.Context, a Archive, msgs []ArchivedMessage) (int64, error)
+	archives(ctx context.Context, conversationID string, fromSeq, toSeq int64) ([]Archive, error)
+	restoreMessages(ctx context.Context, conversationID string, msgs []ArchivedMessage) (int64, error)
+}
+
+// Stats summarizes one job run.
+type Stats struct {
+	Conversations int
+	Archives      int
+	Messages      int64
+}
+
+// Job archives and deletes messages beyond the configured policies.
+type Job struct {
+	log     *slog.Logger
+	store   jobStore
+	archive ArchiveStore
+	cfg     Config
+	now     func() time.Time
+}
+
+// NewJob constructs a Job writing to the configured archive store.
+func NewJob(log *slog.Logger, pool *pgxpool.Pool, cfg Config) (*Job, error) {
+	store, err := NewPostgresStore(pool)
+	if err != nil {
+		return nil, err
+	}
+	archive, err := NewArchiveStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return newJob(log, store, archive, cfg), nil
+}
+
+func newJob(log *slog.Logger, store jobStore, archive ArchiveStore, cfg Config) *Job {
+	if log == nil {
+		log = slog.Default()
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 1000
+	}
+	return &Job{log: log, store: store, archive: archive, cfg: cfg, now: time.Now}
+}
+
+// JobName is the retention job's name in the scheduler.
+const JobName = "retention"
+
+// Scheduled returns the job for a jobs.Scheduler, run on cfg.Schedule or every
+// cfg.Interval (at least a minute).
+func (j *Job) Scheduled() (jobs.Job, error) {
+	sched := jobs.Every(max(j.cfg.Interval, time.Minute))
+	if j.cfg.Schedule != "" {
+		var err error
+		if sched, err = jobs.Parse(j.cfg.Schedule); err != nil {
+			return jobs.Job{}, err
+		}
+	}
+	return jobs.Job{
+		Name:     JobName,
+		Schedule: sched,
+		Jitter:   time.Minute,
+		Run: func(ctx context.Context) error {
+			_, err := j.RunOnce(ctx)
+			return err
+		},
+	}, nil
+}
+
+// RunOnce archives every conversation currently beyond its kind's policy. It does
+// nothing while another node runs the job.
+func (j *Job) RunOnce(ctx context.Context) (Stats, error) {
+	var st Stats
+	release, ok, err := j.store.tryLock(ctx)
+	if err != nil || !ok {
+		return st, err
+	}
+	defer release()
+
+	now := j.now().UTC()
+	for _, kind := range Kinds {
+		p, ok := j.cfg.Policies[kind]
+		if !ok || !p.Enabled() {
+			continue
+		}
+		after := ""
+		for {
+			cands, err := j.store.candidates(ctx, kind, p, now, after, candidatePageSize)
+			if err != nil {
+				return st, err
+			}
+			for _, c := range cands {
+				archives, n, err := j.archiveConversation(ctx, c)
+				st.Archives += archives
+				st.Messages += n
+				if err != nil {
+					return st, fmt.Errorf("retention: conversation %s: %w", c.ConversationID, err)
+				}
+				st.Conversations++
+			}
+			if len(cands) < candidatePageSize {
+				break
+			}
+			after = cands[len(cands)-1].ConversationID
+		}
+	}
+	if st.Archives > 0 {
+		j.log.Info("retention.run.done",
+			"conversations", st.Conversations,
+			"archives", st.Archives,
+			"messages", st.Messages,
+		)
+	}
+	return st, nil
+}
+
+// archiveConversation archives messages up to c.UpToSeq in batches. Each batch is
+// written to the archive store before its rows are deleted.
+func (j *Job) archiveConversation(ctx context.Context, c candidate) (int, int64, error) {
+	var (
+		archives int
+		deleted  int64
+	)
+	for {
+		msgs, err := j.store.loadMessages(ctx, c.ConversationID, c.UpToSeq, j.cfg.BatchSize)
+		if err != nil || len(msgs) == 0 {
+			return archives, deleted, err
+		}
+		body, err := encodeArchive(msgs)
+		if err != nil {
+			return archives, deleted, err
+		}
+		a := Archive{
+			ID:             ulid.Make().String(),
+			ConversationID: c.ConversationID,
+			FromSeq:        msgs[0].Seq,
+			ToSeq:          msgs[len(msgs)-1].Seq,
+			MessageCount:   len(msgs),
+			Backend:        j.cfg.Backend,
+			CreatedAt:      j.now().UTC(),
+		}
+		a.ObjectKey = j.objectKey(a)
+		if err := j.archive.Put(ctx, a.ObjectKey, body); err != nil {
+			return archives, deleted, err
+		}
+		n, err := j.store.commitArchive(ctx, a, msgs)
+		if err != nil {
+			return archives, deleted, err
+		}
+		archives++
+		deleted += n
+		// A short batch was the last one; rows kept because they changed are retried next run.
+		if len(msgs) < j.cfg.BatchSize || n == 0 {
+			return archives, deleted, nil
+		}
+	}
+}
+
+// objectKey is {prefix}/{conversation_id}/{from_seq}-{to_seq}-{archive_id}.ndjson.gz,
+// with zero-padded seqs so keys sort by range.
+func (j *Job) objectKey(a Archive) string {
+	key := fmt.Sprintf("%s/%020d-%020d-%s.ndjson.gz", url.PathEscape(a.ConversationID), a.FromSeq, a.ToSeq, a.ID)
+	if j.cfg.Prefix != "" {
+		key = j.cfg.Prefix + "/" + key
+	}
+	return key
+}
+
+// Restore reinserts the archived messages of conversationID with seq in fromSeq..toSeq.
+// Messages still (or again) present are skipped, as are archive copies older than the
+// newest archive of the same message. It returns the number of messages inserted.
+//
+// Restored messages are subject to the retention policy again; relax the policy for
+// the conversation's kind first if they must stay.
+func (j *Job) Restore(ctx context.Context, conversationID string, fromSeq, toSeq int64) (int64, error) {
+	if conversationID == "" || fromSeq < 1 || toSeq < fromSeq {
+		return 0, errors.New("retention: invalid restore range")
+	}
+	archives, err := j.store.archives(ctx, conversationID, fromSeq, toSeq)
+	if err != nil {
+		return 0, err
+	}
+	var restored int64
+	for _, a := range archives {
+		body, err := j.archive.Get(ctx, a.ObjectKey)
+		if err != nil {
+			return restored, fmt.Errorf("retention: archive %s: %w", a.ID, err)
+		}
+		msgs, err := decodeArchive(body)
+		if err != nil {
+			return restored, fmt.Errorf("retention: archive %s: %w", a.ID, err)
+		}
+		in := msgs[:0]
+		for _, m := range msgs {
+			if m.ConversationID == conversationID && m.Seq >= fromSeq && m.Seq <= toSeq {
+				in = append(in, m)
+			}
+		}
+		if len(in) == 0 {
+			continue
+		}
+		n, err := j.store.restoreMessages(ctx, conversationID, in)
+		if err != nil {
+			return restored, err
+		}
+		restored += n
+	}
+	j.log.Info("retention.restore.done",
+		"conversation_id", conversationID,
+		"from_seq", fromSeq,
+		"to_seq", toSeq,
+		"archives", len(archives),
+		"restored", restored,
+	)
+	return restored, nil
+}
@@ -0,0 +1,279 @@
+package retention
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeStore keeps messages per conversation and evaluates policies in memory.
+type fakeStore struct {
+	mu       sync.Mutex
+	kinds    map[string]string
+	messages map[string][]ArchivedMessage
+	recorded []Archive
+	locked   bool
+}
+
+func (s *fakeStore) tryLock(context.Context) (func(), bool, error) {
+	if s.locked {
+		return nil, false, nil
+	}
+	return func() {}, true, nil
+}
+
+func (s *fakeStore) candidates(_ context.Context, kind string, p Policy, now time.Time, afterID string, limit int) ([]candidate, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var ids []string
+	for id, k := range s.kinds {
+		if k == kind && id > afterID {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	var out []candidate
+	for _, id := range ids {
+		msgs := s.messages[id]
+		if len(msgs) == 0 {
+			continue
+		}
+		var upTo int64
+		for _, m := range msgs {
+			if p.MaxAge > 0 && m.ServerTS.Before(now.Add(-p.MaxAge)) {
+				upTo = max(upTo, m.Seq)
+			}
+		}
+		if p.MaxMessages > 0 {
+			upTo = max(upTo, msgs[len(msgs)-1].Seq-p.MaxMessages)
+		}
+		if upTo >= msgs[0].Seq && len(out) < limit {
+			out = append(out, candidate{ConversationID: id, UpToSeq: upTo})
+		}
+	}
+	return out, nil
+}
+
+func (s *fakeStore) loadMessages(_ context.Context, conversationID string, upToSeq int64, limit int) ([]ArchivedMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []ArchivedMessage
+	for _, m := range s.messages[conversationID] {
+		if m.Seq <= upToSeq && len(out) < limit {
+			out = append(out, m)
+		}
+	}
+	return out, nil
+}
+
+func (s *fakeStore) commitArchive(_ context.Context, a Archive, msgs []ArchivedMessage) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.recorded = append(s.recorded, a)
+	kept := s.messages[a.ConversationID][:0]
+	var n int64
+	for _, m := range s.messages[a.ConversationID] {
+		if slices.ContainsFunc(msgs, func(x ArchivedMessage) bool { return x.Seq == m.Seq && x.Version == m.Version }) {
+			n++
+			continue
+		}
+		kept = append(kept, m)
+	}
+	s.messages[a.ConversationID] = kept
+	return n, nil
+}
+
+func (s *fakeStore) archives(_ context.Context, conversationID string, fromSeq, toSeq int64) ([]Archive, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Archive
+	for i := len(s.recorded) - 1; i >= 0; i-- {
+		a := s.recorded[i]
+		if a.ConversationID == conversationID && a.FromSeq <= toSeq && a.ToSeq >= fromSeq {
+			out = append(out, a)
+		}
+	}
+	return out, nil
+}
+
+func (s *fakeStore) restoreMessages(_ context.Context, conversationID string, msgs []ArchivedMessage) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int64
+	for _, m := range msgs {
+		if slices.ContainsFunc(s.messages[conversationID], func(x ArchivedMessage) bool { return x.Seq == m.Seq }) {
+			continue
+		}
+		s.messages[conversationID] = append(s.messages[conversationID], m)
+		n++
+	}
+	slices.SortFunc(s.messages[conversationID], func(a, b ArchivedMessage) int { return int(a.Seq - b.Seq) })
+	return n, nil
+}
+
+type memArchive struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (a *memArchive) Put(_ context.Context, key string, body []byte) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.objects[key] = body
+	return nil
+}
+
+func (a *memArchive) Get(_ context.Context, key string) ([]byte, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	b, ok := a.objects[key]
+	if !ok {
+		return nil, ErrArchiveNotFound
+	}
+	return b, nil
+}
+
+func seedMessages(conversationID string, n int, start time.Time, step time.Duration) []ArchivedMessage {
+	out := make([]ArchivedMessage, 0, n)
+	for i := 1; i <= n; i++ {
+		out = append(out, ArchivedMessage{
+			ConversationID: conversationID,
+			Seq:            int64(i),
+			ServerMsgID:    conversationID + "-m" + strconv.Itoa(i),
+			ClientMsgID:    conversationID + "-c" + strconv.Itoa(i),
+			SenderSession:  "s1",
+			Text:           "hello",
+			ServerTS:       start.Add(time.Duration(i) * step),
+			Version:        1,
+		})
+	}
+	return out
+}
+
+func newTestJob(store *fakeStore, archive *memArchive, cfg Config) *Job {
+	cfg.Backend = BackendLocal
+	return newJob(slog.New(slog.NewTextHandler(io.Discard, nil)), store, archive, cfg)
+}
+
+func TestJobArchivesByAgeAndCount(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	store := &fakeStore{
+		kinds: map[string]string{"d1": "direct", "g1": "group", "r1": "room"},
+		messages: map[string][]ArchivedMessage{
+			// d1: messages 1..5 are older than 24h, 6..10 are recent.
+			"d1": seedMessages("d1", 10, now.Add(-30*time.Hour), time.Hour),
+			"g1": seedMessages("g1", 10, now.Add(-time.Hour), time.Minute),
+			"r1": seedMessages("r1", 10, now.Add(-time.Hour), time.Minute),
+		},
+	}
+	archive := &memArchive{objects: map[string][]byte{}}
+	j := newTestJob(store, archive, Config{
+		Policies: map[string]Policy{
+			"direct": {MaxAge: 24 * time.Hour},
+			"group":  {MaxMessages: 3},
+		},
+		BatchSize: 4,
+		Prefix:    "messages",
+	})
+	j.now = func() time.Time { return now }
+
+	st, err := j.RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+	if st.Conversations != 2 || st.Messages != 5+7 || st.Archives != 2+2 {
+		t.Fatalf("stats = %+v", st)
+	}
+	if got := store.messages["d1"][0].Seq; got != 6 {
+		t.Fatalf("d1 oldest kept seq = %d, want 6", got)
+	}
+	if got := len(store.messages["g1"]); got != 3 {
+		t.Fatalf("g1 kept %d messages, want 3", got)
+	}
+	if got := len(store.messages["r1"]); got != 10 {
+		t.Fatalf("r1 (no policy) kept %d messages, want 10", got)
+	}
+
+	first := store.recorded[0]
+	if first.FromSeq != 1 || first.ToSeq != 4 || first.MessageCount != 4 {
+		t.Fatalf("first archive = %+v", first)
+	}
+	if first.ObjectKey != "messages/d1/00000000000000000001-00000000000000000004-"+first.ID+".ndjson.gz" {
+		t.Fatalf("object key = %q", first.ObjectKey)
+	}
+	msgs, err := decodeArchive(archive.objects[first.ObjectKey])
+	if err != nil || len(msgs) != 4 || msgs[3].Seq != 4 {
+		t.Fatalf("archive content = %+v err=%v", msgs, err)
+	}
+
+	// A second run finds nothing left to archive.
+	st, err = j.RunOnce(context.Background())
+	if err != nil || st.Archives != 0 {
+		t.Fatalf("second run = %+v err=%v", st, err)
+	}
+}
+
+func TestJobSkipsWhenLocked(t *testing.T) {
+	t.Parallel()
+
+	store := &fakeStore{
+		kinds:    map[string]string{"g1": "group"},
+		messages: map[string][]ArchivedMessage{"g1": seedMessages("g1", 5, time.Now().Add(-time.Hour), time.Second)},
+		locked:   true,
+	}
+	j := newTestJob(store, &memArchive{objects: map[string][]byte{}}, Config{
+		Policies: map[string]Policy{"group": {MaxMessages: 1}},
+	})
+	st, err := j.RunOnce(context.Background())
+	if err != nil || st.Archives != 0 || len(store.messages["g1"]) != 5 {
+		t.Fatalf("locked run = %+v err=%v", st, err)
+	}
+}
+
+func TestJobRestoreRange(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now().UTC()
+	store := &fakeStore{
+		kinds:    map[string]string{"g1": "group"},
+		messages: map[string][]ArchivedMessage{"g1": seedMessages("g1", 10, now.Add(-time.Hour), time.Minute)},
+	}
+	archive := &memArchive{objects: map[string][]byte{}}
+	j := newTestJob(store, archive, Config{
+		Policies:  map[string]Policy{"group": {MaxMessages: 2}},
+		BatchSize: 3,
+	})
+	if _, err := j.RunOnce(context.Background()); err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+	if got := len(store.messages["g1"]); got != 2 {
+		t.Fatalf("kept %d messages, want 2", got)
+	}
+
+	n, err := j.Restore(context.Background(), "g1", 3, 5)
+	if err != nil || n != 3 {
+		t.Fatalf("Restore = %d, %v", n, err)
+	}
+	var seqs []int64
+	for _, m := range store.messages["g1"] {
+		seqs = append(seqs, m.Seq)
+	}
+	if !slices.Equal(seqs, []int64{3, 4, 5, 9, 10}) {
+		t.Fatalf("seqs after restore = %v", seqs)
+	}
+
+	// Restoring again inserts nothing.
+	if n, err := j.Restore(context.Background(), "g1", 3, 5); err != nil || n != 0 {
+		t.Fatalf("second Restore = %d, %v", n, err)
+	}
+	if _, err := j.Restore(context.Background(), "g1", 5, 3); err == nil {
+		t.Fatalf("expected invalid range error")
+	}
+}
@@ -0,0 +1,76 @@
+package retention
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+)
+
+// ArchivedMessage is one line of an archive object. It mirrors an arc.messages row.
+type ArchivedMessage struct {
+	ConversationID     string          `json:"conversation_id"`
+	Seq                int64           `json:"seq"`
+	ServerMsgID        string          `json:"server_msg_id"`
+	ClientMsgID        string          `json:"client_msg_id"`
+	SenderSession      string          `json:"sender_session,omitempty"`
+	SenderBotID        string          `json:"sender_bot_id,omitempty"`
+	Text               string          `json:"text"`
+	ServerTS           time.Time       `json:"server_ts"`
+	Version            int64           `json:"version"`
+	EditedAt           *time.Time      `json:"edited_at,omitempty"`
+	DeletedAt          *time.Time      `json:"deleted_at,omitempty"`
+	ReplyToServerMsgID string          `json:"reply_to_server_msg_id,omitempty"`
+	AttachmentIDs      []string        `json:"attachment_ids,omitempty"`
+	Entities           json.RawMessage `json:"entities,omitempty"`
+	ContentType        string          `json:"content_type,omitempty"`
+	Ciphertext         []byte          `json:"ciphertext,omitempty"`
+	KeyIDs             []string        `json:"key_ids,omitempty"`
+}
+
+// encodeArchive writes msgs as gzip-compressed NDJSON, one message per line.
+func encodeArchive(msgs []ArchivedMessage) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	enc := json.NewEncoder(zw)
+	for _, m := range msgs {
+		if err := enc.Encode(m); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeArchive reads an object written by encodeArchive.
+func decodeArchive(body []byte) ([]ArchivedMessage, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = zr.Close() }()
+
+	var out []ArchivedMessage
+	r := bufio.NewReader(zr)
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			var m ArchivedMessage
+			if err := json.Unmarshal(line, &m); err != nil {
+				return nil, err
+			}
+			out = append(out, m)
+		}
+		if errors.Is(err, io.EOF) {
+			return out, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
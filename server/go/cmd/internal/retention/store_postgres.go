@@ -0,0 +1,289 @@
+package retention
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"arc/cmd/internal/dbtrace"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrConversationNotFound is returned when restoring into a conversation that no longer exists.
+var ErrConversationNotFound = errors.New("retention: conversation not found")
+
+// Archive describes one archive object: messages FromSeq..ToSeq of a conversation.
+type Archive struct {
+	ID             string
+	ConversationID string
+	FromSeq        int64
+	ToSeq          int64
+	MessageCount   int
+	Backend        string
+	ObjectKey      string
+	CreatedAt      time.Time
+}
+
+// candidate is a conversation with messages up to UpToSeq beyond its kind's policy.
+type candidate struct {
+	ConversationID string
+	UpToSeq        int64
+}
+
+// PostgresStore reads and deletes messages for archival and records archives in
+// arc.message_archives.
+type PostgresStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresStore constructs a PostgresStore.
+func NewPostgresStore(pool *pgxpool.Pool) (*PostgresStore, error) {
+	if pool == nil {
+		return nil, errors.New("retention: nil db pool")
+	}
+	return &PostgresStore{pool: pool}, nil
+}
+
+// tryLock takes the cluster-wide retention lock so only one node runs the job at a
+// time. ok is false if another node holds it; release must be called when ok.
+func (s *PostgresStore) tryLock(ctx context.Context) (release func(), ok bool, err error) {
+	ctx = dbtrace.WithOp(ctx, "retention.try_lock")
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock(hashtextextended('arc.retention', 0))`).Scan(&ok); err != nil || !ok {
+		conn.Release()
+		return nil, false, err
+	}
+	return func() {
+		_, _ = conn.Exec(context.Background(), `SELECT pg_advisory_unlock(hashtextextended('arc.retention', 0))`)
+		conn.Release()
+	}, true, nil
+}
+
+// candidates lists conversations of kind (ordered by id, after afterID) holding
+// messages older than p.MaxAge or beyond the newest p.MaxMessages.
+func (s *PostgresStore) candidates(ctx context.Context, kind string, p Policy, now time.Time, afterID string, limit int) ([]candidate, error) {
+	ctx = dbtrace.WithOp(ctx, "retention.candidates")
+	var cutoff *time.Time
+	if p.MaxAge > 0 {
+		t := now.Add(-p.MaxAge)
+		cutoff = &t
+	}
+	var keep *int64
+	if p.MaxMessages > 0 {
+		keep = &p.MaxMessages
+	}
+
+	rows, err := s.pool.Query(ctx,
+		`SELECT t.id, t.up_to
+		   FROM (
+		        SELECT c.id,
+		               GREATEST(
+		                   (SELECT max(m.seq) FROM arc.messages m
+		                     WHERE m.conversation_id = c.id AND m.server_ts < $2::timestamptz),
+		                   (SELECT max(m.seq) FROM arc.messages m
+		                     WHERE m.conversation_id = c.id) - $3::bigint
+		               ) AS up_to
+		          FROM arc.conversations c
+		         WHERE c.kind = $1
+		           AND c.id > $4
+		   ) t
+		  WHERE t.up_to IS NOT NULL
+		    AND EXISTS (SELECT 1 FROM arc.messages m WHERE m.conversation_id = t.id AND m.seq <= t.up_to)
+		  ORDER BY t.id
+		  LIMIT $5`,
+		kind, cutoff, keep, afterID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []candidate
+	for rows.Next() {
+		var c candidate
+		if err := rows.Scan(&c.ConversationID, &c.UpToSeq); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+const archivedMessageColumns = `conversation_id, seq, server_msg_id, client_msg_id, COALESCE(sender_session, ''),
+	COALESCE(sender_bot_id, ''), text, server_ts, version, edited_at, deleted_at,
+	COALESCE(reply_to_server_msg_id, ''), attachment_ids, entities, content_type, ciphertext, key_ids`
+
+// loadMessages returns up to limit of the oldest messages of conversationID with seq <= upToSeq.
+func (s *PostgresStore) loadMessages(ctx context.Context, conversationID string, upToSeq int64, limit int) ([]ArchivedMessage, error) {
+	ctx = dbtrace.WithOp(ctx, "retention.load_messages")
+	rows, err := s.pool.Query(ctx,
+		`SELECT `+archivedMessageColumns+`
+		   FROM arc.messages
+		  WHERE conversation_id = $1 AND seq <= $2
+		  ORDER BY seq ASC
+		  LIMIT $3`,
+		conversationID, upToSeq, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []ArchivedMessage
+	for rows.Next() {
+		var m ArchivedMessage
+		if err := rows.Scan(
+			&m.ConversationID, &m.Seq, &m.ServerMsgID, &m.ClientMsgID, &m.SenderSession,
+			&m.SenderBotID, &m.Text, &m.ServerTS, &m.Version, &m.EditedAt, &m.DeletedAt,
+			&m.ReplyToServerMsgID, &m.AttachmentIDs, &m.Entities, &m.ContentType, &m.Ciphertext, &m.KeyIDs,
+		); err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+// commitArchive records a and deletes the archived messages. Messages edited or deleted
+// since they were read (their version changed) are kept and archived again on a later run.
+func (s *PostgresStore) commitArchive(ctx context.Context, a Archive, msgs []ArchivedMessage) (int64, error) {
+	ctx = dbtrace.WithOp(ctx, "retention.commit_archive")
+	seqs := make([]int64, len(msgs))
+	versions := make([]int64, len(msgs))
+	for i, m := range msgs {
+		seqs[i], versions[i] = m.Seq, m.Version
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Same per-conversation lock the realtime store takes for appends and mutations.
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtextextended($1, 0))`, a.ConversationID); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(ctx,
+		`INSERT INTO arc.message_archives (id, conversation_id, from_seq, to_seq, message_count, backend, object_key, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		a.ID, a.ConversationID, a.FromSeq, a.ToSeq, a.MessageCount, a.Backend, a.ObjectKey, a.CreatedAt,
+	); err != nil {
+		return 0, err
+	}
+	ct, err := tx.Exec(ctx,
+		`DELETE FROM arc.messages
+		  WHERE conversation_id = $1
+		    AND (seq, version) IN (SELECT * FROM unnest($2::bigint[], $3::bigint[]))`,
+		a.ConversationID, seqs, versions,
+	)
+	if err != nil {
+		return 0, err
+	}
+	if err := adjustMessageCount(ctx, tx, a.ConversationID, -ct.RowsAffected()); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return ct.RowsAffected(), nil
+}
+
+// archives lists the archives of conversationID overlapping fromSeq..toSeq, newest first.
+func (s *PostgresStore) archives(ctx context.Context, conversationID string, fromSeq, toSeq int64) ([]Archive, error) {
+	ctx = dbtrace.WithOp(ctx, "retention.archives")
+	rows, err := s.pool.Query(ctx,
+		`SELECT id, conversation_id, from_seq, to_seq, message_count, backend, object_key, created_at
+		   FROM arc.message_archives
+		  WHERE conversation_id = $1 AND from_seq <= $3 AND to_seq >= $2
+		  ORDER BY created_at DESC, id DESC`,
+		conversationID, fromSeq, toSeq,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Archive
+	for rows.Next() {
+		var a Archive
+		if err := rows.Scan(&a.ID, &a.ConversationID, &a.FromSeq, &a.ToSeq, &a.MessageCount, &a.Backend, &a.ObjectKey, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+// restoreMessages inserts msgs into conversationID, skipping seqs (or client message ids)
+// already present. Senders whose session no longer exists are restored without one.
+func (s *PostgresStore) restoreMessages(ctx context.Context, conversationID string, msgs []ArchivedMessage) (int64, error) {
+	ctx = dbtrace.WithOp(ctx, "retention.restore_messages")
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtextextended($1, 0))`, conversationID); err != nil {
+		return 0, err
+	}
+	var one int
+	err = tx.QueryRow(ctx, `SELECT 1 FROM arc.conversations WHERE id = $1`, conversationID).Scan(&one)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, ErrConversationNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var restored int64
+	for _, m := range msgs {
+		ct, err := tx.Exec(ctx,
+			`INSERT INTO arc.messages (
+			     conversation_id, seq, server_msg_id, client_msg_id, sender_session, sender_bot_id, text, server_ts,
+			     version, edited_at, deleted_at, reply_to_server_msg_id, attachment_ids, entities,
+			     content_type, ciphertext, key_ids
+			   ) VALUES (
+			     $1, $2, $3, $4, (SELECT id FROM arc.sessions WHERE id = NULLIF($5, '')), NULLIF($6, ''), $7, $8,
+			     $9, $10, $11, NULLIF($12, ''), COALESCE($13::text[], '{}'), $14::jsonb,
+			     COALESCE(NULLIF($15, ''), 'text'), $16, COALESCE($17::text[], '{}')
+			   )
+			 ON CONFLICT DO NOTHING`,
+			conversationID, m.Seq, m.ServerMsgID, m.ClientMsgID, m.SenderSession, m.SenderBotID, m.Text, m.ServerTS,
+			m.Version, m.EditedAt, m.DeletedAt, m.ReplyToServerMsgID, m.AttachmentIDs, []byte(m.Entities),
+			m.ContentType, m.Ciphertext, m.KeyIDs,
+		)
+		if err != nil {
+			return 0, err
+		}
+		restored += ct.RowsAffected()
+	}
+	if err := adjustMessageCount(ctx, tx, conversationID, restored); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return restored, nil
+}
+
+// adjustMessageCount keeps arc.conversation_stats in step with archived and restored
+// rows. The last seq is untouched: archived messages still count towards unreads.
+func adjustMessageCount(ctx context.Context, tx pgx.Tx, conversationID string, delta int64) error {
+	if delta == 0 {
+		return nil
+	}
+	_, err := tx.Exec(ctx,
+		`UPDATE arc.conversation_stats
+		    SET message_count = GREATEST(message_count + $2, 0)
+		  WHERE conversation_id = $1`,
+		conversationID, delta,
+	)
+	return err
+}
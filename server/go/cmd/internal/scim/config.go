@@ -0,0 +1,71 @@
+package scim
+
+import (
+	"encoding/hex"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Config controls the SCIM endpoints.
+type Config struct {
+	// TokenHashes are lowercase SHA-256 hex digests of accepted machine bearer tokens.
+	// Storing digests keeps the plain tokens out of the environment and process listings.
+	// Empty disables SCIM.
+	TokenHashes []string
+
+	MaxBodyBytes int64
+	// DefaultPageSize and MaxPageSize bound list responses ("count" parameter).
+	DefaultPageSize int
+	MaxPageSize     int
+}
+
+// LoadConfigFromEnv loads SCIM config from environment variables with safe defaults.
+func LoadConfigFromEnv() Config {
+	cfg := Config{
+		TokenHashes:     parseTokenHashes(os.Getenv("ARC_SCIM_TOKEN_SHA256")),
+		MaxBodyBytes:    1 << 20,
+		DefaultPageSize: 100,
+		MaxPageSize:     envInt("ARC_SCIM_MAX_PAGE_SIZE", 200),
+	}
+	if cfg.MaxPageSize <= 0 {
+		cfg.MaxPageSize = 200
+	}
+	if cfg.DefaultPageSize > cfg.MaxPageSize {
+		cfg.DefaultPageSize = cfg.MaxPageSize
+	}
+	return cfg
+}
+
+// Enabled reports whether at least one machine credential is configured.
+func (c Config) Enabled() bool {
+	return len(c.TokenHashes) > 0
+}
+
+// parseTokenHashes keeps well-formed SHA-256 hex digests and drops everything else.
+func parseTokenHashes(raw string) []string {
+	var out []string
+	for _, p := range strings.Split(raw, ",") {
+		v := strings.ToLower(strings.TrimSpace(p))
+		if len(v) != 64 {
+			continue
+		}
+		if _, err := hex.DecodeString(v); err != nil {
+			continue
+		}
+		out = append(out, v)
+	}
+	return out
+}
+
+func envInt(key string, def int) int {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		return def
+	}
+	return n
+}
@@ -0,0 +1,5 @@
+// Package scim serves SCIM 2.0 user provisioning (RFC 7643/7644) on top of the identity store.
+//
+// Identity providers authenticate with machine bearer tokens configured by SHA-256 digest,
+// create and update Arc accounts, and deprovision them by deactivation (lock + session revocation).
+package scim
@@ -0,0 +1,146 @@
+package scim
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"arc/cmd/identity"
+)
+
+var errInvalidFilter = errors.New("unsupported filter")
+
+// filterClause is one `attr eq value` comparison of a list filter.
+type filterClause struct {
+	Attr  string // canonical lower-case attribute path
+	Value string
+}
+
+// filterAttrs maps supported filter attributes to their canonical form.
+var filterAttrs = map[string]string{
+	"id":           "id",
+	"username":     "username",
+	"externalid":   "externalid",
+	"emails":       "emails",
+	"emails.value": "emails",
+	"active":       "active",
+}
+
+// parseFilter parses the subset of RFC 7644 filters IdPs use for provisioning:
+// `eq` comparisons on supported attributes, optionally joined with `and`.
+// An empty filter returns no clauses.
+func parseFilter(raw string) ([]filterClause, error) {
+	s := strings.TrimSpace(raw)
+	if s == "" {
+		return nil, nil
+	}
+
+	var out []filterClause
+	for {
+		attr, rest, ok := cutToken(s)
+		if !ok {
+			return nil, errInvalidFilter
+		}
+		canonical, ok := filterAttrs[strings.ToLower(attr)]
+		if !ok {
+			return nil, errInvalidFilter
+		}
+		op, rest, ok := cutToken(rest)
+		if !ok || !strings.EqualFold(op, "eq") {
+			return nil, errInvalidFilter
+		}
+		value, rest, err := cutValue(rest)
+		if err != nil {
+			return nil, err
+		}
+		if canonical == "active" && value != "true" && value != "false" {
+			return nil, errInvalidFilter
+		}
+		out = append(out, filterClause{Attr: canonical, Value: value})
+
+		rest = strings.TrimSpace(rest)
+		if rest == "" {
+			return out, nil
+		}
+		conj, next, ok := cutToken(rest)
+		if !ok || !strings.EqualFold(conj, "and") {
+			return nil, errInvalidFilter
+		}
+		s = next
+	}
+}
+
+// cutToken splits off the next whitespace-delimited token.
+func cutToken(s string) (token string, rest string, ok bool) {
+	s = strings.TrimLeft(s, " ")
+	if s == "" {
+		return "", "", false
+	}
+	i := strings.IndexByte(s, ' ')
+	if i < 0 {
+		return s, "", true
+	}
+	return s[:i], s[i+1:], true
+}
+
+// cutValue splits off a comparison value: a JSON-style quoted string or a bare true/false.
+func cutValue(s string) (value string, rest string, err error) {
+	s = strings.TrimLeft(s, " ")
+	if strings.HasPrefix(s, `"`) {
+		for i := 1; i < len(s); i++ {
+			switch s[i] {
+			case '\\':
+				i++
+			case '"':
+				v, err := strconv.Unquote(s[:i+1])
+				if err != nil {
+					return "", "", errInvalidFilter
+				}
+				return v, s[i+1:], nil
+			}
+		}
+		return "", "", errInvalidFilter
+	}
+	tok, rest, ok := cutToken(s)
+	if !ok {
+		return "", "", errInvalidFilter
+	}
+	switch strings.ToLower(tok) {
+	case "true", "false":
+		return strings.ToLower(tok), rest, nil
+	default:
+		return "", "", errInvalidFilter
+	}
+}
+
+// filterSQL renders clauses as a WHERE fragment over `u` (users) and `x` (scim_users).
+// Arguments are numbered from firstArg; values never reach the SQL text.
+func filterSQL(clauses []filterClause, firstArg int) (string, []any) {
+	if len(clauses) == 0 {
+		return "TRUE", nil
+	}
+	parts := make([]string, 0, len(clauses))
+	args := make([]any, 0, len(clauses))
+	for _, c := range clauses {
+		n := "$" + strconv.Itoa(firstArg+len(args))
+		switch c.Attr {
+		case "id":
+			parts = append(parts, "u.id = "+n)
+			args = append(args, c.Value)
+		case "username":
+			// userName falls back to the email for email-only accounts (see toResource).
+			parts = append(parts, "(u.username_norm = "+n+" OR (u.username_norm IS NULL AND u.email_norm = "+n+"))")
+			args = append(args, identity.NormalizeUsername(c.Value))
+		case "externalid":
+			parts = append(parts, "x.external_id = "+n)
+			args = append(args, c.Value)
+		case "emails":
+			parts = append(parts, "u.email_norm = "+n)
+			args = append(args, identity.NormalizeEmail(c.Value))
+		case "active":
+			parts = append(parts, "(u.locked_at IS NULL) = "+n)
+			args = append(args, c.Value == "true")
+		}
+	}
+	return strings.Join(parts, " AND "), args
+}
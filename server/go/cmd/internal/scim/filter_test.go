@@ -0,0 +1,68 @@
+package scim
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseFilter(t *testing.T) {
+	got, err := parseFilter(`userName eq "Ada@Example.com" and active eq TRUE`)
+	if err != nil {
+		t.Fatalf("parseFilter: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected 2 clauses, got %d", len(got))
+	}
+	if got[0] != (filterClause{Attr: "username", Value: "Ada@Example.com"}) {
+		t.Fatalf("unexpected first clause: %+v", got[0])
+	}
+	if got[1] != (filterClause{Attr: "active", Value: "true"}) {
+		t.Fatalf("unexpected second clause: %+v", got[1])
+	}
+
+	got, err = parseFilter(`emails.value eq "a \"quoted\" b"`)
+	if err != nil || len(got) != 1 || got[0].Attr != "emails" || got[0].Value != `a "quoted" b` {
+		t.Fatalf("unexpected escaped value: %+v err=%v", got, err)
+	}
+
+	if got, err := parseFilter("  "); err != nil || got != nil {
+		t.Fatalf("expected empty filter to yield no clauses, got %+v err=%v", got, err)
+	}
+}
+
+func TestParseFilter_RejectsUnsupported(t *testing.T) {
+	for _, in := range []string{
+		`userName sw "a"`,
+		`name.givenName eq "a"`,
+		`userName eq "a" or userName eq "b"`,
+		`userName eq "unterminated`,
+		`active eq "maybe"`,
+		`userName eq bare`,
+		`userName eq "a" and`,
+	} {
+		if _, err := parseFilter(in); err == nil {
+			t.Fatalf("expected %q to be rejected", in)
+		}
+	}
+}
+
+func TestFilterSQL_ParameterizesValues(t *testing.T) {
+	where, args := filterSQL([]filterClause{
+		{Attr: "username", Value: "Ada"},
+		{Attr: "externalid", Value: "ext'; DROP TABLE arc.users; --"},
+		{Attr: "active", Value: "false"},
+	}, 1)
+	if strings.Contains(where, "DROP") {
+		t.Fatalf("values must not be interpolated: %s", where)
+	}
+	if !strings.Contains(where, "$1") || !strings.Contains(where, "$2") || !strings.Contains(where, "$3") {
+		t.Fatalf("expected numbered placeholders, got %s", where)
+	}
+	if len(args) != 3 || args[0] != "ada" || args[2] != false {
+		t.Fatalf("unexpected args: %#v", args)
+	}
+
+	if where, args := filterSQL(nil, 1); where != "TRUE" || args != nil {
+		t.Fatalf("expected match-all for no clauses, got %q %v", where, args)
+	}
+}
@@ -0,0 +1,381 @@
+package scim
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"arc/cmd/identity"
+	"arc/cmd/security/token"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	usersPath = "/scim/v2/Users"
+
+	// deactivateReason is recorded as locked_reason for accounts deprovisioned by the IdP.
+	deactivateReason = "deprovisioned via SCIM"
+)
+
+// SessionRevoker revokes all sessions of a deprovisioned user.
+type SessionRevoker interface {
+	RevokeAllSessions(ctx context.Context, now time.Time, userID string, reason string) error
+}
+
+// Handler serves the SCIM Users endpoints.
+type Handler struct {
+	log *slog.Logger
+	cfg Config
+
+	pool     *pgxpool.Pool
+	identity *identity.PostgresStore
+	sessions SessionRevoker
+}
+
+// NewHandler constructs a SCIM Handler. It requires a database pool and session revoker.
+func NewHandler(log *slog.Logger, pool *pgxpool.Pool, cfg Config, sessions SessionRevoker) (*Handler, error) {
+	if log == nil {
+		log = slog.Default()
+	}
+	if pool == nil {
+		return nil, errors.New("scim: nil db pool")
+	}
+	if sessions == nil {
+		return nil, errors.New("scim: nil session revoker")
+	}
+	idStore, err := identity.NewPostgresStore(pool)
+	if err != nil {
+		return nil, err
+	}
+	return &Handler{log: log, cfg: cfg, pool: pool, identity: idStore, sessions: sessions}, nil
+}
+
+// Register wires SCIM routes onto the provided mux.
+func (h *Handler) Register(mux *http.ServeMux) {
+	if h == nil || mux == nil {
+		return
+	}
+	mux.HandleFunc(usersPath, h.handleUsers)
+	mux.HandleFunc(usersPath+"/{id}", h.handleUser)
+}
+
+func (h *Handler) handleUsers(w http.ResponseWriter, r *http.Request) {
+	if !h.authenticate(w, r) {
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		h.listUsers(w, r)
+	case http.MethodPost:
+		h.createUser(w, r)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) handleUser(w http.ResponseWriter, r *http.Request) {
+	if !h.authenticate(w, r) {
+		return
+	}
+	userID := strings.TrimSpace(r.PathValue("id"))
+	if userID == "" || len(userID) > 64 {
+		writeError(w, http.StatusNotFound, "", "user not found")
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		h.getUser(w, r, userID)
+	case http.MethodPatch:
+		h.patchUser(w, r, userID)
+	case http.MethodDelete:
+		h.deactivateUser(w, r, userID)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// authenticate checks the machine bearer token against the configured digests.
+// Every digest is compared so timing does not reveal which credential matched.
+func (h *Handler) authenticate(w http.ResponseWriter, r *http.Request) bool {
+	raw := strings.TrimSpace(r.Header.Get("Authorization"))
+	const prefix = "bearer "
+	if len(raw) <= len(prefix) || !strings.EqualFold(raw[:len(prefix)], prefix) {
+		writeError(w, http.StatusUnauthorized, "", "missing bearer token")
+		return false
+	}
+	sum := token.HashSHA256Hex(strings.TrimSpace(raw[len(prefix):]))
+
+	match := 0
+	for _, want := range h.cfg.TokenHashes {
+		match |= subtle.ConstantTimeCompare([]byte(sum), []byte(want))
+	}
+	if match != 1 {
+		writeError(w, http.StatusUnauthorized, "", "invalid bearer token")
+		return false
+	}
+	return true
+}
+
+func (h *Handler) listUsers(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	clauses, err := parseFilter(q.Get("filter"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalidFilter", err.Error())
+		return
+	}
+	startIndex := queryInt(q.Get("startIndex"), 1)
+	if startIndex < 1 {
+		startIndex = 1
+	}
+	count := queryInt(q.Get("count"), h.cfg.DefaultPageSize)
+	if count < 0 {
+		count = 0
+	}
+	if count > h.cfg.MaxPageSize {
+		count = h.cfg.MaxPageSize
+	}
+
+	rows, total, err := listUserRows(r.Context(), h.pool, clauses, startIndex-1, count)
+	if err != nil {
+		h.log.Error("scim.users.list.fail", "err", err)
+		writeError(w, http.StatusInternalServerError, "", "internal error")
+		return
+	}
+
+	out := listResponse{
+		Schemas:      []string{schemaListResponse},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(rows),
+		Resources:    make([]userResource, 0, len(rows)),
+	}
+	for _, u := range rows {
+		out.Resources = append(out.Resources, u.toResource())
+	}
+	writeResource(w, http.StatusOK, out)
+}
+
+func (h *Handler) getUser(w http.ResponseWriter, r *http.Request, userID string) {
+	u, err := getUserRow(r.Context(), h.pool, userID)
+	if err != nil {
+		h.writeStoreError(w, "scim.users.get.fail", err)
+		return
+	}
+	writeResource(w, http.StatusOK, u.toResource())
+}
+
+func (h *Handler) createUser(w http.ResponseWriter, r *http.Request) {
+	var req userResource
+	if err := decodeJSON(w, r, h.cfg.MaxBodyBytes, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalidSyntax", "invalid request body")
+		return
+	}
+	username, email := identityFields(req.UserName, primaryEmail(req.Emails))
+	if username == nil && email == nil {
+		writeError(w, http.StatusBadRequest, "invalidValue", "userName is required")
+		return
+	}
+
+	// Provisioned accounts without a password get an unguessable one nobody knows;
+	// they sign in once a password is set through the regular account flows.
+	password := req.Password
+	if strings.TrimSpace(password) == "" {
+		p, err := randomPassword()
+		if err != nil {
+			h.log.Error("scim.users.create.password.fail", "err", err)
+			writeError(w, http.StatusInternalServerError, "", "internal error")
+			return
+		}
+		password = p
+	}
+
+	ctx := r.Context()
+	if v := strings.TrimSpace(req.ExternalID); v != "" {
+		// Checked up front so a duplicate link does not leave a half-provisioned account behind.
+		if taken, err := externalIDInUse(ctx, h.pool, v); err != nil || taken {
+			if err == nil {
+				err = errExternalIDConflict
+			}
+			h.writeStoreError(w, "scim.users.create.external_id.fail", err)
+			return
+		}
+	}
+
+	now := time.Now().UTC()
+	res, err := h.identity.CreateUser(ctx, identity.CreateUserInput{
+		Username: username,
+		Email:    email,
+		Password: password,
+		Now:      now,
+	})
+	if err != nil {
+		h.writeStoreError(w, "scim.users.create.fail", err)
+		return
+	}
+	userID := res.User.ID
+
+	changes := userChanges{Active: req.Active}
+	if v := strings.TrimSpace(req.DisplayName); v != "" {
+		changes.DisplayName = &v
+	}
+	if v := strings.TrimSpace(req.ExternalID); v != "" {
+		changes.ExternalID = &v
+	}
+	if err := h.applyChanges(ctx, now, userID, changes); err != nil {
+		h.writeStoreError(w, "scim.users.create.apply.fail", err)
+		return
+	}
+
+	h.audit(ctx, "scim.user.created", userID, changes.ExternalID)
+
+	u, err := getUserRow(ctx, h.pool, userID)
+	if err != nil {
+		h.writeStoreError(w, "scim.users.create.get.fail", err)
+		return
+	}
+	w.Header().Set("Location", usersPath+"/"+userID)
+	writeResource(w, http.StatusCreated, u.toResource())
+}
+
+func (h *Handler) patchUser(w http.ResponseWriter, r *http.Request, userID string) {
+	var req patchRequest
+	if err := decodeJSON(w, r, h.cfg.MaxBodyBytes, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalidSyntax", "invalid request body")
+		return
+	}
+	changes, err := parsePatch(req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalidValue", "unsupported patch operation")
+		return
+	}
+
+	ctx := r.Context()
+	if _, err := getUserRow(ctx, h.pool, userID); err != nil {
+		h.writeStoreError(w, "scim.users.patch.get.fail", err)
+		return
+	}
+	if err := h.applyChanges(ctx, time.Now().UTC(), userID, changes); err != nil {
+		h.writeStoreError(w, "scim.users.patch.fail", err)
+		return
+	}
+
+	action := "scim.user.updated"
+	if changes.Active != nil && !*changes.Active {
+		action = "scim.user.deactivated"
+	}
+	h.audit(ctx, action, userID, changes.ExternalID)
+
+	u, err := getUserRow(ctx, h.pool, userID)
+	if err != nil {
+		h.writeStoreError(w, "scim.users.patch.get.fail", err)
+		return
+	}
+	writeResource(w, http.StatusOK, u.toResource())
+}
+
+// deactivateUser handles DELETE by deactivating rather than deleting: the account
+// and its history are kept, and erasure stays an explicit admin purge.
+func (h *Handler) deactivateUser(w http.ResponseWriter, r *http.Request, userID string) {
+	ctx := r.Context()
+	inactive := false
+	if err := h.applyChanges(ctx, time.Now().UTC(), userID, userChanges{Active: &inactive}); err != nil {
+		h.writeStoreError(w, "scim.users.delete.fail", err)
+		return
+	}
+	h.audit(ctx, "scim.user.deactivated", userID, nil)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// applyChanges persists changes for userID. Deactivation locks the account first and
+// then revokes its sessions, so a refresh racing the revocation is rejected by the lock.
+func (h *Handler) applyChanges(ctx context.Context, now time.Time, userID string, c userChanges) error {
+	if c.UserName != nil || c.Email != nil || c.DisplayName != nil {
+		in := identity.UpdateUserInput{UserID: userID, DisplayName: c.DisplayName, Now: now}
+		if c.UserName != nil {
+			username, email := identityFields(*c.UserName, "")
+			if username != nil {
+				in.Username = username
+			} else {
+				in.Email = email
+			}
+		}
+		if c.Email != nil {
+			in.Email = c.Email
+		}
+		if _, err := h.identity.UpdateUser(ctx, in); err != nil {
+			return err
+		}
+	}
+	if c.ExternalID != nil {
+		if err := setExternalID(ctx, h.pool, userID, *c.ExternalID); err != nil {
+			return err
+		}
+	}
+	if c.Active != nil {
+		if *c.Active {
+			return h.identity.SetUserLocked(ctx, userID, false, nil, now)
+		}
+		reason := deactivateReason
+		if err := h.identity.SetUserLocked(ctx, userID, true, &reason, now); err != nil {
+			return err
+		}
+		return h.sessions.RevokeAllSessions(ctx, now, userID, "admin")
+	}
+	return nil
+}
+
+func (h *Handler) writeStoreError(w http.ResponseWriter, logMsg string, err error) {
+	switch {
+	case errors.Is(err, errUserNotFound), identity.IsNotFound(err):
+		writeError(w, http.StatusNotFound, "", "user not found")
+	case errors.Is(err, errExternalIDConflict):
+		writeError(w, http.StatusConflict, "uniqueness", err.Error())
+	case identity.IsConflict(err):
+		var ce identity.ConflictError
+		errors.As(err, &ce)
+		writeError(w, http.StatusConflict, "uniqueness", ce.Field+" already in use")
+	case identity.IsInvalidInput(err):
+		writeError(w, http.StatusBadRequest, "invalidValue", "invalid user attributes")
+	default:
+		h.log.Error(logMsg, "err", err)
+		writeError(w, http.StatusInternalServerError, "", "internal error")
+	}
+}
+
+func (h *Handler) audit(ctx context.Context, action string, userID string, externalID *string) {
+	var meta map[string]any
+	if externalID != nil && *externalID != "" {
+		meta = map[string]any{"external_id": *externalID}
+	}
+	if err := insertAudit(ctx, h.pool, action, userID, meta); err != nil {
+		h.log.Error("scim.audit.insert.fail", "err", err, "action", action)
+	}
+}
+
+func randomPassword() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func queryInt(raw string, def int) int {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return def
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil {
+		return def
+	}
+	return n
+}
@@ -0,0 +1,62 @@
+package scim
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"arc/cmd/security/token"
+)
+
+func TestHandlerAuthenticate(t *testing.T) {
+	h := &Handler{cfg: Config{TokenHashes: []string{token.HashSHA256Hex("other-token"), token.HashSHA256Hex("okta-token")}}}
+
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{header: "Bearer okta-token", want: true},
+		{header: "bearer okta-token", want: true},
+		{header: "Bearer wrong-token", want: false},
+		{header: "Basic b2t0YS10b2tlbg==", want: false},
+		{header: "", want: false},
+	}
+	for _, tc := range tests {
+		r := httptest.NewRequest(http.MethodGet, usersPath, nil)
+		if tc.header != "" {
+			r.Header.Set("Authorization", tc.header)
+		}
+		rr := httptest.NewRecorder()
+		if got := h.authenticate(rr, r); got != tc.want {
+			t.Fatalf("header %q: got %v want %v", tc.header, got, tc.want)
+		}
+		if !tc.want && rr.Code != http.StatusUnauthorized {
+			t.Fatalf("header %q: expected 401, got %d", tc.header, rr.Code)
+		}
+	}
+}
+
+func TestParseTokenHashes(t *testing.T) {
+	valid := token.HashSHA256Hex("t")
+	got := parseTokenHashes(" " + valid + " ,not-hex, ," + valid[:10])
+	if len(got) != 1 || got[0] != valid {
+		t.Fatalf("unexpected hashes: %v", got)
+	}
+	if (Config{}).Enabled() {
+		t.Fatalf("expected empty config to be disabled")
+	}
+}
+
+func TestUserRowToResource_EmailOnly(t *testing.T) {
+	email := "ada@example.com"
+	res := userRow{ID: "u1", Email: &email}.toResource()
+	if res.UserName != email || len(res.Emails) != 1 || !res.Emails[0].Primary {
+		t.Fatalf("unexpected resource: %+v", res)
+	}
+	if res.Active == nil || !*res.Active {
+		t.Fatalf("expected unlocked user to be active")
+	}
+	if res.Meta == nil || res.Meta.Location != usersPath+"/u1" {
+		t.Fatalf("unexpected meta: %+v", res.Meta)
+	}
+}
@@ -0,0 +1,168 @@
+package scim
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+var errInvalidPatch = errors.New("invalid patch")
+
+// userChanges is the normalized effect of a create or patch request.
+// Nil fields are unchanged; a pointer to "" clears the attribute.
+type userChanges struct {
+	UserName    *string
+	Email       *string
+	DisplayName *string
+	ExternalID  *string
+	Active      *bool
+}
+
+// parsePatch folds PatchOp operations into userChanges.
+//
+// Attributes Arc does not model (name.*, phoneNumbers, enterprise extensions) are
+// ignored rather than rejected, since IdPs send them unconditionally.
+func parsePatch(req patchRequest) (userChanges, error) {
+	var c userChanges
+	if len(req.Operations) == 0 {
+		return c, errInvalidPatch
+	}
+	for _, op := range req.Operations {
+		path := normalizePath(op.Path)
+		switch strings.ToLower(strings.TrimSpace(op.Op)) {
+		case "add", "replace":
+			if path == "" {
+				var attrs map[string]json.RawMessage
+				if err := json.Unmarshal(op.Value, &attrs); err != nil {
+					return c, errInvalidPatch
+				}
+				for k, v := range attrs {
+					if err := c.set(normalizePath(k), v); err != nil {
+						return c, err
+					}
+				}
+				continue
+			}
+			if err := c.set(path, op.Value); err != nil {
+				return c, err
+			}
+		case "remove":
+			empty := ""
+			switch path {
+			case "displayname":
+				c.DisplayName = &empty
+			case "externalid":
+				c.ExternalID = &empty
+			case "emails":
+				c.Email = &empty
+			default:
+				return c, errInvalidPatch
+			}
+		default:
+			return c, errInvalidPatch
+		}
+	}
+	return c, nil
+}
+
+func (c *userChanges) set(path string, raw json.RawMessage) error {
+	switch path {
+	case "username":
+		v, err := patchString(raw)
+		if err != nil || v == "" {
+			return errInvalidPatch
+		}
+		c.UserName = &v
+	case "displayname":
+		v, err := patchString(raw)
+		if err != nil {
+			return err
+		}
+		c.DisplayName = &v
+	case "externalid":
+		v, err := patchString(raw)
+		if err != nil {
+			return err
+		}
+		c.ExternalID = &v
+	case "emails":
+		var emails []emailValue
+		if err := json.Unmarshal(raw, &emails); err == nil {
+			v := primaryEmail(emails)
+			c.Email = &v
+			return nil
+		}
+		v, err := patchString(raw)
+		if err != nil {
+			return err
+		}
+		c.Email = &v
+	case "active":
+		v, err := patchBool(raw)
+		if err != nil {
+			return err
+		}
+		c.Active = &v
+	}
+	return nil
+}
+
+// normalizePath lower-cases a PatchOp path, strips the core schema URN prefix and
+// collapses value filters on emails (`emails[type eq "work"].value`) to "emails".
+func normalizePath(p string) string {
+	p = strings.ToLower(strings.TrimSpace(p))
+	p = strings.TrimPrefix(p, strings.ToLower(schemaUser)+":")
+	if strings.HasPrefix(p, "emails") {
+		return "emails"
+	}
+	return p
+}
+
+func patchString(raw json.RawMessage) (string, error) {
+	var v string
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return "", errInvalidPatch
+	}
+	return strings.TrimSpace(v), nil
+}
+
+// patchBool accepts JSON booleans and the "True"/"False" strings some IdPs send.
+func patchBool(raw json.RawMessage) (bool, error) {
+	var b bool
+	if err := json.Unmarshal(raw, &b); err == nil {
+		return b, nil
+	}
+	s, err := patchString(raw)
+	if err != nil {
+		return false, err
+	}
+	switch strings.ToLower(s) {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	default:
+		return false, errInvalidPatch
+	}
+}
+
+// identityFields maps a SCIM userName/email pair onto Arc's username and email.
+// IdPs commonly use the email address as userName; such a userName becomes the
+// account email so Arc usernames stay short handles.
+func identityFields(userName string, email string) (username *string, mail *string) {
+	userName = strings.TrimSpace(userName)
+	email = strings.TrimSpace(email)
+	if strings.Contains(userName, "@") {
+		if email == "" {
+			email = userName
+		}
+		userName = ""
+	}
+	if userName != "" {
+		username = &userName
+	}
+	if email != "" {
+		mail = &email
+	}
+	return username, mail
+}
@@ -0,0 +1,86 @@
+package scim
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParsePatch_OktaAndAzureShapes(t *testing.T) {
+	var req patchRequest
+	body := `{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+		"Operations": [
+			{"op": "replace", "value": {"active": false, "displayName": " Ada ", "name": {"givenName": "Ada"}}},
+			{"op": "Replace", "path": "emails[type eq \"work\"].value", "value": "ada@example.com"},
+			{"op": "add", "path": "externalId", "value": "00u1"}
+		]
+	}`
+	if err := json.Unmarshal([]byte(body), &req); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	c, err := parsePatch(req)
+	if err != nil {
+		t.Fatalf("parsePatch: %v", err)
+	}
+	if c.Active == nil || *c.Active {
+		t.Fatalf("expected active=false")
+	}
+	if c.DisplayName == nil || *c.DisplayName != "Ada" {
+		t.Fatalf("unexpected displayName: %v", c.DisplayName)
+	}
+	if c.Email == nil || *c.Email != "ada@example.com" {
+		t.Fatalf("unexpected email: %v", c.Email)
+	}
+	if c.ExternalID == nil || *c.ExternalID != "00u1" {
+		t.Fatalf("unexpected externalId: %v", c.ExternalID)
+	}
+	if c.UserName != nil {
+		t.Fatalf("expected userName to be unchanged")
+	}
+}
+
+func TestParsePatch_StringBooleanAndRemove(t *testing.T) {
+	req := patchRequest{Operations: []patchOperation{
+		{Op: "replace", Path: "active", Value: json.RawMessage(`"False"`)},
+		{Op: "remove", Path: "displayName"},
+	}}
+	c, err := parsePatch(req)
+	if err != nil {
+		t.Fatalf("parsePatch: %v", err)
+	}
+	if c.Active == nil || *c.Active {
+		t.Fatalf("expected string False to deactivate")
+	}
+	if c.DisplayName == nil || *c.DisplayName != "" {
+		t.Fatalf("expected displayName to be cleared")
+	}
+}
+
+func TestParsePatch_Rejects(t *testing.T) {
+	for i, req := range []patchRequest{
+		{},
+		{Operations: []patchOperation{{Op: "move", Path: "userName", Value: json.RawMessage(`"a"`)}}},
+		{Operations: []patchOperation{{Op: "remove", Path: "userName"}}},
+		{Operations: []patchOperation{{Op: "replace", Path: "userName", Value: json.RawMessage(`""`)}}},
+		{Operations: []patchOperation{{Op: "replace", Path: "active", Value: json.RawMessage(`"yes"`)}}},
+	} {
+		if _, err := parsePatch(req); err == nil {
+			t.Fatalf("case %d: expected error", i)
+		}
+	}
+}
+
+func TestIdentityFields(t *testing.T) {
+	u, e := identityFields("ada", "ada@example.com")
+	if u == nil || *u != "ada" || e == nil || *e != "ada@example.com" {
+		t.Fatalf("unexpected mapping for handle: %v %v", u, e)
+	}
+	u, e = identityFields("ada@example.com", "")
+	if u != nil || e == nil || *e != "ada@example.com" {
+		t.Fatalf("expected email-like userName to map to email: %v %v", u, e)
+	}
+	if u, e := identityFields(" ", ""); u != nil || e != nil {
+		t.Fatalf("expected empty mapping")
+	}
+}
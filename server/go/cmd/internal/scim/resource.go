@@ -0,0 +1,163 @@
+package scim
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	schemaUser         = "urn:ietf:params:scim:schemas:core:2.0:User"
+	schemaListResponse = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	schemaPatchOp      = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	schemaError        = "urn:ietf:params:scim:api:messages:2.0:Error"
+
+	contentType = "application/scim+json"
+)
+
+// userResource is the SCIM core User representation of an Arc account.
+type userResource struct {
+	Schemas     []string     `json:"schemas"`
+	ID          string       `json:"id,omitempty"`
+	ExternalID  string       `json:"externalId,omitempty"`
+	UserName    string       `json:"userName"`
+	DisplayName string       `json:"displayName,omitempty"`
+	Emails      []emailValue `json:"emails,omitempty"`
+	Active      *bool        `json:"active,omitempty"`
+	Password    string       `json:"password,omitempty"`
+	Meta        *meta        `json:"meta,omitempty"`
+}
+
+type emailValue struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+type meta struct {
+	ResourceType string    `json:"resourceType"`
+	Created      time.Time `json:"created"`
+	LastModified time.Time `json:"lastModified"`
+	Location     string    `json:"location"`
+}
+
+type listResponse struct {
+	Schemas      []string       `json:"schemas"`
+	TotalResults int64          `json:"totalResults"`
+	StartIndex   int            `json:"startIndex"`
+	ItemsPerPage int            `json:"itemsPerPage"`
+	Resources    []userResource `json:"Resources"`
+}
+
+type patchRequest struct {
+	Schemas    []string         `json:"schemas"`
+	Operations []patchOperation `json:"Operations"`
+}
+
+type patchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+type errorResponse struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+}
+
+// primaryEmail returns the primary email, or the first one when none is marked primary.
+func primaryEmail(emails []emailValue) string {
+	for _, e := range emails {
+		if e.Primary {
+			return strings.TrimSpace(e.Value)
+		}
+	}
+	if len(emails) > 0 {
+		return strings.TrimSpace(emails[0].Value)
+	}
+	return ""
+}
+
+// userRow is the persisted state rendered as a userResource.
+type userRow struct {
+	ID          string
+	Username    *string
+	Email       *string
+	DisplayName *string
+	LockedAt    *time.Time
+	ExternalID  *string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (u userRow) toResource() userResource {
+	active := u.LockedAt == nil
+	out := userResource{
+		Schemas: []string{schemaUser},
+		ID:      u.ID,
+		Active:  &active,
+		Meta: &meta{
+			ResourceType: "User",
+			Created:      u.CreatedAt,
+			LastModified: u.UpdatedAt,
+			Location:     usersPath + "/" + u.ID,
+		},
+	}
+	// Email-only accounts use their email as userName, which SCIM requires.
+	switch {
+	case u.Username != nil:
+		out.UserName = *u.Username
+	case u.Email != nil:
+		out.UserName = *u.Email
+	}
+	if u.Email != nil {
+		out.Emails = []emailValue{{Value: *u.Email, Type: "work", Primary: true}}
+	}
+	if u.DisplayName != nil {
+		out.DisplayName = *u.DisplayName
+	}
+	if u.ExternalID != nil {
+		out.ExternalID = *u.ExternalID
+	}
+	return out
+}
+
+func writeResource(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, scimType string, detail string) {
+	writeResource(w, status, errorResponse{
+		Schemas:  []string{schemaError},
+		Status:   strconv.Itoa(status),
+		ScimType: scimType,
+		Detail:   detail,
+	})
+}
+
+// decodeJSON decodes a single JSON value. Unknown fields are tolerated on purpose:
+// IdPs routinely send enterprise extensions and attributes Arc does not model.
+func decodeJSON(w http.ResponseWriter, r *http.Request, maxBytes int64, dst any) error {
+	if r.Body == nil {
+		return errors.New("empty body")
+	}
+	defer func() { _ = r.Body.Close() }()
+
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBytes))
+	if err := dec.Decode(dst); err != nil {
+		return err
+	}
+	if err := dec.Decode(&struct{}{}); err != io.EOF {
+		return errors.New("extra data after JSON object")
+	}
+	return nil
+}
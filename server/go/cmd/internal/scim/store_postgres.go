@@ -0,0 +1,128 @@
+package scim
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"arc/cmd/internal/dbtrace"
+	"arc/cmd/internal/redact"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	errUserNotFound       = errors.New("user not found")
+	errExternalIDConflict = errors.New("externalId already in use")
+)
+
+const userRowColumns = `u.id, u.username, u.email, u.display_name, u.locked_at, x.external_id, u.created_at, u.updated_at`
+
+func scanUserRow(row pgx.Row) (userRow, error) {
+	var u userRow
+	err := row.Scan(&u.ID, &u.Username, &u.Email, &u.DisplayName, &u.LockedAt, &u.ExternalID, &u.CreatedAt, &u.UpdatedAt)
+	return u, err
+}
+
+func getUserRow(ctx context.Context, pool *pgxpool.Pool, userID string) (userRow, error) {
+	ctx = dbtrace.WithOp(ctx, "scim.get_user_row")
+	u, err := scanUserRow(pool.QueryRow(ctx, `
+		SELECT `+userRowColumns+`
+		FROM arc.users u
+		LEFT JOIN arc.scim_users x ON x.user_id = u.id
+		WHERE u.id = $1
+	`, userID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return userRow{}, errUserNotFound
+	}
+	return u, err
+}
+
+// listUserRows returns one page of users matching clauses and the total match count.
+func listUserRows(ctx context.Context, pool *pgxpool.Pool, clauses []filterClause, offset int, limit int) ([]userRow, int64, error) {
+	ctx = dbtrace.WithOp(ctx, "scim.list_user_rows")
+	where, args := filterSQL(clauses, 1)
+	from := `
+		FROM arc.users u
+		LEFT JOIN arc.scim_users x ON x.user_id = u.id
+		WHERE ` + where
+
+	var total int64
+	if err := pool.QueryRow(ctx, `SELECT count(*) `+from, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	out := []userRow{}
+	if limit == 0 {
+		return out, total, nil
+	}
+
+	n := len(args)
+	rows, err := pool.Query(ctx, `
+		SELECT `+userRowColumns+from+`
+		ORDER BY u.created_at ASC, u.id ASC
+		OFFSET $`+strconv.Itoa(n+1)+` LIMIT $`+strconv.Itoa(n+2),
+		append(args, offset, limit)...,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		u, err := scanUserRow(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		out = append(out, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return out, total, nil
+}
+
+// setExternalID links (or, for "", unlinks) the IdP's externalId to a user.
+func setExternalID(ctx context.Context, pool *pgxpool.Pool, userID string, externalID string) error {
+	ctx = dbtrace.WithOp(ctx, "scim.set_external_id")
+	if externalID == "" {
+		_, err := pool.Exec(ctx, `DELETE FROM arc.scim_users WHERE user_id = $1`, userID)
+		return err
+	}
+	_, err := pool.Exec(ctx, `
+		INSERT INTO arc.scim_users (user_id, external_id, created_at, updated_at)
+		VALUES ($1, $2, now(), now())
+		ON CONFLICT (user_id) DO UPDATE SET external_id = EXCLUDED.external_id, updated_at = now()
+	`, userID, externalID)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return errExternalIDConflict
+	}
+	return err
+}
+
+func externalIDInUse(ctx context.Context, pool *pgxpool.Pool, externalID string) (bool, error) {
+	ctx = dbtrace.WithOp(ctx, "scim.external_id_in_use")
+	var exists bool
+	err := pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM arc.scim_users WHERE external_id = $1)`, externalID).Scan(&exists)
+	return exists, err
+}
+
+func insertAudit(ctx context.Context, pool *pgxpool.Pool, action string, userID string, meta map[string]any) error {
+	ctx = dbtrace.WithOp(ctx, "scim.insert_audit")
+	var metaVal *string
+	if len(meta) > 0 {
+		if b, err := json.Marshal(redact.Meta(meta)); err == nil {
+			s := string(b)
+			metaVal = &s
+		}
+	}
+	_, err := pool.Exec(ctx, `
+		INSERT INTO arc.audit_log (user_id, action, created_at, meta)
+		VALUES ($1, $2, now(), $3::jsonb)
+	`, userID, action, metaVal)
+	return err
+}
@@ -0,0 +1,29 @@
+// Package tenant routes requests to per-tenant stores (schema-per-tenant).
+//
+// Tenants are listed in a registry file (ARC_TENANTS_FILE, YAML or TOML like the
+// main config file):
+//
+//	tenants:
+//	  acme:
+//	    schema: tenant_acme
+//	    hosts: [chat.acme.example]
+//	    config:
+//	      auth:
+//	        access_ttl: 10m
+//
+// Each tenant names the Postgres schema holding its users, sessions, conversations
+// and auth tables, the hostnames it is served on, and ARC_* overrides its handlers
+// read instead of the environment, at startup and on config reloads
+// (config.auth.access_ttl overrides ARC_AUTH_ACCESS_TTL).
+// Tenant IDs are lower-case letters and digits.
+//
+// A Resolver picks the tenant of a request by hostname, by header
+// (ARC_TENANT_HEADER, default X-Arc-Tenant) or both, falling back to
+// ARC_TENANT_DEFAULT. Middleware stores it in the request context; package app
+// keeps one auth handler and realtime gateway per tenant, built over the tenant's
+// identity, session and realtime stores, and dispatches to them.
+//
+// Schemas are not created here: provision each one with the same tables and
+// triggers as arc before listing the tenant. Package app refuses to start when a
+// tenant schema lacks a table, column or trigger of arc.
+package tenant
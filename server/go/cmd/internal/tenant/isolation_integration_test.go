@@ -0,0 +1,122 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"arc/cmd/identity"
+	"arc/cmd/internal/auth/session"
+	"arc/cmd/internal/migrations/migrationstest"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oklog/ulid/v2"
+)
+
+// Integration tests are enabled when ARC_DATABASE_URL is set.
+
+// TestTenantIsolation resolves two tenants by host and checks that identity and
+// session rows written through one tenant's stores are invisible to the other.
+func TestTenantIsolation(t *testing.T) {
+	pool := openTestPool(t)
+	ctx := context.Background()
+
+	suffix := strings.ToLower(ulid.Make().String())
+	reg, err := NewRegistry([]Tenant{
+		{ID: "a", Schema: "arc_tenant_a_" + suffix, Hosts: []string{"a.example"}},
+		{ID: "b", Schema: "arc_tenant_b_" + suffix, Hosts: []string{"b.example"}},
+	})
+	if err != nil {
+		t.Fatalf("NewRegistry: %v", err)
+	}
+	res, err := NewResolver(reg, Config{Resolve: ResolveHost})
+	if err != nil {
+		t.Fatalf("NewResolver: %v", err)
+	}
+
+	type stores struct {
+		identity *identity.PostgresStore
+		sessions *session.PostgresStore
+	}
+	storesFor := func(host string) stores {
+		t.Helper()
+		r := httptest.NewRequest(http.MethodGet, "/me", nil)
+		r.Host = host
+		tn, err := res.Resolve(r)
+		if err != nil {
+			t.Fatalf("resolve %s: %v", host, err)
+		}
+		ids, err := identity.NewPostgresStore(pool, identity.WithSchema(tn.Schema))
+		if err != nil {
+			t.Fatalf("identity store: %v", err)
+		}
+		sess, err := session.NewPostgresStore(pool, session.WithSchema(tn.Schema))
+		if err != nil {
+			t.Fatalf("session store: %v", err)
+		}
+		return stores{identity: ids, sessions: sess}
+	}
+
+	for _, tn := range reg.Tenants() {
+		migrationstest.Apply(t, pool, tn.Schema)
+	}
+	a, b := storesFor("a.example"), storesFor("b.example")
+
+	now := time.Now().UTC()
+	username := "tenant_" + suffix[:12]
+	created, err := a.identity.CreateUser(ctx, identity.CreateUserInput{Username: &username, Password: "very-strong-password-8", Now: now})
+	if err != nil {
+		t.Fatalf("create user in a: %v", err)
+	}
+	userID := created.User.ID
+
+	if _, err := b.identity.GetUserByID(ctx, userID); !identity.IsNotFound(err) {
+		t.Fatalf("user of a visible in b: %v", err)
+	}
+	// The same username is free in b.
+	if _, err := b.identity.CreateUser(ctx, identity.CreateUserInput{Username: &username, Password: "very-strong-password-8", Now: now}); err != nil {
+		t.Fatalf("create same username in b: %v", err)
+	}
+
+	sessionID, err := a.sessions.Create(ctx, now, userID, session.DeviceContext{Platform: session.PlatformWeb},
+		strings.Repeat("a", 64), now.Add(time.Hour), nil)
+	if err != nil {
+		t.Fatalf("create session in a: %v", err)
+	}
+	if _, err := a.sessions.GetByID(ctx, sessionID); err != nil {
+		t.Fatalf("session in a: %v", err)
+	}
+	if _, err := b.sessions.GetByID(ctx, sessionID); !errors.Is(err, session.ErrSessionNotFound) {
+		t.Fatalf("session of a visible in b: %v", err)
+	}
+}
+
+func openTestPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+
+	dbURL := strings.TrimSpace(os.Getenv("ARC_DATABASE_URL"))
+	if dbURL == "" {
+		t.Skip("ARC_DATABASE_URL is not set; skipping Postgres integration test")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	pool, err := pgxpool.New(ctx, dbURL)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		if os.Getenv("CI") == "" {
+			t.Skipf("Postgres unreachable (ARC_DATABASE_URL set): %v", err)
+		}
+		t.Fatalf("ping: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	return pool
+}
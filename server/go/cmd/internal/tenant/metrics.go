@@ -0,0 +1,6 @@
+package tenant
+
+import "arc/cmd/internal/metrics"
+
+var resolveFailures = metrics.NewCounter("arc_tenant_resolve_failures_total",
+	"Requests rejected because they matched no tenant.")
@@ -0,0 +1,164 @@
+package tenant
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Resolution modes for ARC_TENANT_RESOLVE.
+const (
+	ResolveHost   = "host"
+	ResolveHeader = "header"
+	// ResolveHostOrHeader tries the host first, then the header.
+	ResolveHostOrHeader = "host,header"
+)
+
+// DefaultHeader carries the tenant ID when resolving by header.
+const DefaultHeader = "X-Arc-Tenant"
+
+// Config enables multi-tenancy and selects how requests are mapped to tenants.
+type Config struct {
+	// File is the registry file; empty disables multi-tenancy.
+	File    string
+	Resolve string
+	Header  string
+	// Default is the tenant ID used when nothing matches; empty rejects the request.
+	Default string
+}
+
+// LoadConfigFromEnv reads ARC_TENANTS_FILE, ARC_TENANT_RESOLVE, ARC_TENANT_HEADER
+// and ARC_TENANT_DEFAULT.
+func LoadConfigFromEnv() Config {
+	cfg := Config{
+		File:    strings.TrimSpace(os.Getenv("ARC_TENANTS_FILE")),
+		Resolve: strings.ToLower(strings.ReplaceAll(os.Getenv("ARC_TENANT_RESOLVE"), " ", "")),
+		Header:  strings.TrimSpace(os.Getenv("ARC_TENANT_HEADER")),
+		Default: strings.ToLower(strings.TrimSpace(os.Getenv("ARC_TENANT_DEFAULT"))),
+	}
+	if cfg.Resolve == "" {
+		cfg.Resolve = ResolveHost
+	}
+	if cfg.Header == "" {
+		cfg.Header = DefaultHeader
+	}
+	return cfg
+}
+
+// Enabled reports whether a registry file is configured.
+func (c Config) Enabled() bool {
+	return c.File != ""
+}
+
+// Resolver maps requests to tenants.
+type Resolver struct {
+	reg        *Registry
+	byHost     bool
+	byHeader   bool
+	header     string
+	defaultID  string
+	hasDefault bool
+}
+
+// NewResolver validates cfg against reg.
+func NewResolver(reg *Registry, cfg Config) (*Resolver, error) {
+	r := &Resolver{reg: reg, header: cfg.Header}
+	if r.header == "" {
+		r.header = DefaultHeader
+	}
+	switch cfg.Resolve {
+	case "", ResolveHost:
+		r.byHost = true
+	case ResolveHeader:
+		r.byHeader = true
+	case ResolveHostOrHeader, "header,host":
+		r.byHost, r.byHeader = true, true
+	default:
+		return nil, fmt.Errorf("tenant: ARC_TENANT_RESOLVE %q: want host, header or host,header", cfg.Resolve)
+	}
+	if cfg.Default != "" {
+		if _, ok := reg.Get(cfg.Default); !ok {
+			return nil, fmt.Errorf("tenant: ARC_TENANT_DEFAULT %q: not in the registry", cfg.Default)
+		}
+		r.defaultID, r.hasDefault = cfg.Default, true
+	}
+	return r, nil
+}
+
+// Registry returns the registry the resolver reads.
+func (r *Resolver) Registry() *Registry {
+	return r.reg
+}
+
+// Resolve returns the tenant of req. A header naming an unknown tenant is an error
+// even when a default is configured, so a typo never lands in another tenant.
+func (r *Resolver) Resolve(req *http.Request) (Tenant, error) {
+	if r.byHost {
+		if t, ok := r.reg.ByHost(req.Host); ok {
+			return t, nil
+		}
+	}
+	if r.byHeader {
+		if id := strings.TrimSpace(req.Header.Get(r.header)); id != "" {
+			if t, ok := r.reg.Get(id); ok {
+				return t, nil
+			}
+			return Tenant{}, ErrUnknownTenant
+		}
+	}
+	if r.hasDefault {
+		t, _ := r.reg.Get(r.defaultID)
+		return t, nil
+	}
+	return Tenant{}, ErrUnknownTenant
+}
+
+type ctxKey struct{}
+
+// NewContext returns ctx carrying t.
+func NewContext(ctx context.Context, t Tenant) context.Context {
+	return context.WithValue(ctx, ctxKey{}, t)
+}
+
+// FromContext returns the tenant stored by Middleware.
+func FromContext(ctx context.Context) (Tenant, bool) {
+	t, ok := ctx.Value(ctxKey{}).(Tenant)
+	return t, ok
+}
+
+// Middleware resolves the tenant of each request and stores it in the request
+// context. Unresolvable requests get 404 with {"error":{"code":"unknown_tenant"}}.
+// Paths in nodePaths (health checks, metrics) are served without a tenant.
+// The ServeMux pattern that handled a request is copied back to r.Pattern.
+func Middleware(next http.Handler, res *Resolver, nodePaths ...string) http.Handler {
+	skip := make(map[string]bool, len(nodePaths))
+	for _, p := range nodePaths {
+		skip[p] = true
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if skip[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+		t, err := res.Resolve(r)
+		if err != nil {
+			resolveFailures.Inc()
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(map[string]any{"error": map[string]string{
+				"code":    "unknown_tenant",
+				"message": "unknown tenant",
+			}})
+			return
+		}
+		tr := r.WithContext(NewContext(r.Context(), t))
+		// ServeMux records the matched pattern on the request it serves, which is
+		// tr. Copy it back, even when next panics, so the access log, metrics and
+		// panic recovery wrapping this middleware label the route.
+		defer func() { r.Pattern = tr.Pattern }()
+		next.ServeHTTP(w, tr)
+	})
+}
@@ -0,0 +1,92 @@
+package tenant
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResolver(t *testing.T) {
+	t.Parallel()
+
+	reg := mustRegistry(t, registryYAML)
+	req := func(host, header string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/me", nil)
+		r.Host = host
+		if header != "" {
+			r.Header.Set(DefaultHeader, header)
+		}
+		return r
+	}
+
+	byHost, err := NewResolver(reg, Config{Resolve: ResolveHost})
+	if err != nil {
+		t.Fatalf("NewResolver: %v", err)
+	}
+	if got, err := byHost.Resolve(req("globex.example", "acme")); err != nil || got.ID != "globex" {
+		t.Fatalf("host resolve = %+v, %v", got, err)
+	}
+	if _, err := byHost.Resolve(req("unknown.example", "acme")); !errors.Is(err, ErrUnknownTenant) {
+		t.Fatalf("host mode must ignore the header, err = %v", err)
+	}
+
+	both, err := NewResolver(reg, Config{Resolve: ResolveHostOrHeader, Default: "globex"})
+	if err != nil {
+		t.Fatalf("NewResolver: %v", err)
+	}
+	if got, _ := both.Resolve(req("unknown.example", "acme")); got.ID != "acme" {
+		t.Fatalf("header fallback = %+v", got)
+	}
+	if got, _ := both.Resolve(req("unknown.example", "")); got.ID != "globex" {
+		t.Fatalf("default = %+v", got)
+	}
+	if _, err := both.Resolve(req("unknown.example", "initech")); !errors.Is(err, ErrUnknownTenant) {
+		t.Fatalf("unknown header must not fall back to the default, err = %v", err)
+	}
+
+	if _, err := NewResolver(reg, Config{Resolve: "cookie"}); err == nil {
+		t.Fatal("expected error for unknown resolve mode")
+	}
+	if _, err := NewResolver(reg, Config{Default: "initech"}); err == nil {
+		t.Fatal("expected error for unknown default tenant")
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	res, err := NewResolver(mustRegistry(t, registryYAML), Config{Resolve: ResolveHeader})
+	if err != nil {
+		t.Fatalf("NewResolver: %v", err)
+	}
+	h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tn, ok := FromContext(r.Context())
+		if !ok {
+			_, _ = w.Write([]byte("node"))
+			return
+		}
+		_, _ = w.Write([]byte(tn.ID + ":" + tn.Schema))
+	}), res, "/healthz")
+
+	serve := func(path, header string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		if header != "" {
+			r.Header.Set(DefaultHeader, header)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := serve("/me", "acme"); w.Code != http.StatusOK || w.Body.String() != "acme:tenant_acme" {
+		t.Fatalf("acme: %d %q", w.Code, w.Body.String())
+	}
+	if w := serve("/me", ""); w.Code != http.StatusNotFound || !strings.Contains(w.Body.String(), `"unknown_tenant"`) {
+		t.Fatalf("no tenant: %d %q", w.Code, w.Body.String())
+	}
+	if w := serve("/healthz", ""); w.Code != http.StatusOK || w.Body.String() != "node" {
+		t.Fatalf("node path: %d %q", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,212 @@
+package tenant
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"arc/cmd/internal/config"
+)
+
+// DefaultSchema is the schema of single-tenant deployments.
+const DefaultSchema = "arc"
+
+var (
+	// ErrUnknownTenant is returned when a request matches no registered tenant.
+	ErrUnknownTenant = errors.New("tenant: unknown tenant")
+
+	idRE     = regexp.MustCompile(`^[a-z0-9]+$`)
+	schemaRE = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+)
+
+// Tenant is one registry entry.
+type Tenant struct {
+	ID     string
+	Schema string
+	// Hosts are matched case-insensitively against the request host (port ignored).
+	Hosts []string
+	// Config holds ARC_* overrides of the tenant's handlers; see Lookup.
+	Config map[string]string
+}
+
+// Registry is the validated, immutable set of tenants.
+type Registry struct {
+	tenants []Tenant
+	byID    map[string]*Tenant
+	byHost  map[string]*Tenant
+}
+
+// NewRegistry validates tenants: IDs, schemas and hosts must be unique, and IDs and
+// schemas must be legal identifiers.
+func NewRegistry(tenants []Tenant) (*Registry, error) {
+	r := &Registry{
+		tenants: make([]Tenant, 0, len(tenants)),
+		byID:    make(map[string]*Tenant, len(tenants)),
+		byHost:  map[string]*Tenant{},
+	}
+	schemas := map[string]string{}
+	var errs []error
+	for _, t := range tenants {
+		t.ID = strings.ToLower(strings.TrimSpace(t.ID))
+		t.Schema = strings.TrimSpace(t.Schema)
+		if !idRE.MatchString(t.ID) {
+			errs = append(errs, fmt.Errorf("tenant %q: id must be lower-case letters and digits", t.ID))
+			continue
+		}
+		if _, dup := r.byID[t.ID]; dup {
+			errs = append(errs, fmt.Errorf("tenant %q: duplicate id", t.ID))
+			continue
+		}
+		if !schemaRE.MatchString(t.Schema) {
+			errs = append(errs, fmt.Errorf("tenant %q: invalid schema %q", t.ID, t.Schema))
+		} else if other, dup := schemas[t.Schema]; dup {
+			errs = append(errs, fmt.Errorf("tenant %q: schema %q already used by %q", t.ID, t.Schema, other))
+		}
+		schemas[t.Schema] = t.ID
+		t.Hosts = append([]string(nil), t.Hosts...)
+		for i, h := range t.Hosts {
+			h = normalizeHost(h)
+			t.Hosts[i] = h
+			if h == "" {
+				errs = append(errs, fmt.Errorf("tenant %q: empty host", t.ID))
+			} else if other, dup := r.byHost[h]; dup {
+				errs = append(errs, fmt.Errorf("tenant %q: host %q already used by %q", t.ID, h, other.ID))
+			}
+		}
+		r.tenants = append(r.tenants, t)
+		tp := &r.tenants[len(r.tenants)-1]
+		r.byID[t.ID] = tp
+		for _, h := range t.Hosts {
+			if _, dup := r.byHost[h]; !dup && h != "" {
+				r.byHost[h] = tp
+			}
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	if len(r.tenants) == 0 {
+		return nil, errors.New("tenant: registry is empty")
+	}
+	return r, nil
+}
+
+// LoadRegistry reads the registry file at path (see the package documentation).
+func LoadRegistry(path string) (*Registry, error) {
+	values, err := config.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	tenants, err := parseRegistry(values)
+	if err != nil {
+		return nil, fmt.Errorf("tenant: %s: %w", path, err)
+	}
+	return NewRegistry(tenants)
+}
+
+// parseRegistry turns the flattened ARC_TENANTS_<ID>_<FIELD> keys of a registry
+// file back into tenants.
+func parseRegistry(values map[string]string) ([]Tenant, error) {
+	const prefix = "ARC_TENANTS_"
+	byID := map[string]*Tenant{}
+	var errs []error
+	for key, value := range values {
+		rest, ok := strings.CutPrefix(key, prefix)
+		if !ok {
+			errs = append(errs, fmt.Errorf("unexpected key %s (want tenants.<id>.*)", key))
+			continue
+		}
+		id, field, ok := strings.Cut(rest, "_")
+		if !ok {
+			errs = append(errs, fmt.Errorf("unexpected key %s", key))
+			continue
+		}
+		id = strings.ToLower(id)
+		t := byID[id]
+		if t == nil {
+			t = &Tenant{ID: id, Config: map[string]string{}}
+			byID[id] = t
+		}
+		switch {
+		case field == "SCHEMA":
+			t.Schema = value
+		case field == "HOSTS":
+			t.Hosts = splitCSV(value)
+		case strings.HasPrefix(field, "CONFIG_"):
+			name := "ARC_" + strings.TrimPrefix(field, "CONFIG_")
+			if !config.Known(name) {
+				errs = append(errs, fmt.Errorf("tenant %q: unknown setting %s", id, strings.ToLower(field)))
+				continue
+			}
+			t.Config[name] = value
+		default:
+			errs = append(errs, fmt.Errorf("tenant %q: unknown setting %s", id, strings.ToLower(field)))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	out := make([]Tenant, 0, len(byID))
+	for _, t := range byID {
+		out = append(out, *t)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, nil
+}
+
+// Tenants returns every tenant, sorted by ID.
+func (r *Registry) Tenants() []Tenant {
+	out := append([]Tenant(nil), r.tenants...)
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// Get returns the tenant with id.
+func (r *Registry) Get(id string) (Tenant, bool) {
+	t, ok := r.byID[strings.ToLower(strings.TrimSpace(id))]
+	if !ok {
+		return Tenant{}, false
+	}
+	return *t, true
+}
+
+// ByHost returns the tenant serving host (a Host header value; the port is ignored).
+func (r *Registry) ByHost(host string) (Tenant, bool) {
+	t, ok := r.byHost[normalizeHost(host)]
+	if !ok {
+		return Tenant{}, false
+	}
+	return *t, true
+}
+
+func normalizeHost(h string) string {
+	h = strings.ToLower(strings.TrimSpace(h))
+	if strings.HasPrefix(h, "[") {
+		if end := strings.IndexByte(h, ']'); end > 0 {
+			return h[1:end]
+		}
+	}
+	if i := strings.LastIndexByte(h, ':'); i >= 0 && strings.Count(h, ":") == 1 {
+		h = h[:i]
+	}
+	return strings.TrimSuffix(h, ".")
+}
+
+func splitCSV(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if p := strings.TrimSpace(part); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// Lookup reads settings with t's config overrides over the process environment.
+// Package loaders given it (LoadConfigFrom and friends) see the tenant's settings,
+// and the handlers that keep it re-read them on a config reload.
+func (t Tenant) Lookup() config.Lookup {
+	return config.Overlay(config.Env, t.Config)
+}
@@ -0,0 +1,124 @@
+package tenant
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"arc/cmd/internal/config"
+)
+
+const registryYAML = `
+tenants:
+  acme:
+    schema: tenant_acme
+    hosts: [Chat.Acme.Example, acme.localhost]
+    config:
+      auth:
+        access_ttl: 10m
+  globex:
+    schema: tenant_globex
+    hosts: [globex.example]
+`
+
+func mustRegistry(t *testing.T, src string) *Registry {
+	t.Helper()
+	values, err := config.Parse(".yaml", []byte(src))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	tenants, err := parseRegistry(values)
+	if err != nil {
+		t.Fatalf("parseRegistry: %v", err)
+	}
+	reg, err := NewRegistry(tenants)
+	if err != nil {
+		t.Fatalf("NewRegistry: %v", err)
+	}
+	return reg
+}
+
+func TestRegistry(t *testing.T) {
+	t.Parallel()
+
+	reg := mustRegistry(t, registryYAML)
+	if got := reg.Tenants(); len(got) != 2 || got[0].ID != "acme" || got[1].ID != "globex" {
+		t.Fatalf("tenants = %+v", got)
+	}
+	acme, ok := reg.Get("ACME")
+	if !ok || acme.Schema != "tenant_acme" || acme.Config["ARC_AUTH_ACCESS_TTL"] != "10m" {
+		t.Fatalf("acme = %+v", acme)
+	}
+	for _, host := range []string{"chat.acme.example", "CHAT.ACME.EXAMPLE:8443", "acme.localhost."} {
+		if got, ok := reg.ByHost(host); !ok || got.ID != "acme" {
+			t.Fatalf("ByHost(%q) = %+v, %v", host, got, ok)
+		}
+	}
+	if _, ok := reg.ByHost("other.example"); ok {
+		t.Fatal("unknown host resolved")
+	}
+}
+
+func TestNewRegistryRejectsConflicts(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string][]Tenant{
+		"empty":          nil,
+		"bad id":         {{ID: "acme-corp", Schema: "tenant_acme"}},
+		"bad schema":     {{ID: "acme", Schema: "tenant-acme"}},
+		"duplicate id":   {{ID: "acme", Schema: "a"}, {ID: "ACME", Schema: "b"}},
+		"shared schema":  {{ID: "acme", Schema: "shared"}, {ID: "globex", Schema: "shared"}},
+		"shared host":    {{ID: "acme", Schema: "a", Hosts: []string{"x.example"}}, {ID: "globex", Schema: "b", Hosts: []string{"X.example:443"}}},
+		"empty hostname": {{ID: "acme", Schema: "a", Hosts: []string{" "}}},
+	}
+	for name, tenants := range cases {
+		if _, err := NewRegistry(tenants); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestParseRegistryRejectsUnknownSettings(t *testing.T) {
+	t.Parallel()
+
+	values, err := config.Parse(".yaml", []byte("tenants:\n  acme:\n    shema: tenant_acme\n"))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if _, err := parseRegistry(values); err == nil || !strings.Contains(err.Error(), "shema") {
+		t.Fatalf("err = %v", err)
+	}
+
+	// Overrides are checked against the known settings too.
+	values, err = config.Parse(".yaml", []byte("tenants:\n  acme:\n    schema: tenant_acme\n    config:\n      auth:\n        acess_ttl: 10m\n"))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if _, err := parseRegistry(values); err == nil || !strings.Contains(err.Error(), "config_auth_acess_ttl") {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestTenantLookup(t *testing.T) {
+	t.Setenv("ARC_AUTH_ACCESS_TTL", "15m")
+	t.Setenv("ARC_AUTH_REFRESH_TTL_WEB", "24h")
+	os.Unsetenv("ARC_TENANT_TEST_ONLY")
+
+	tn := Tenant{ID: "acme", Config: map[string]string{
+		"ARC_AUTH_ACCESS_TTL":  "10m",
+		"ARC_TENANT_TEST_ONLY": "1",
+	}}
+	lookup := tn.Lookup()
+	if lookup.Get("ARC_AUTH_ACCESS_TTL") != "10m" || lookup.Get("ARC_TENANT_TEST_ONLY") != "1" {
+		t.Fatalf("overrides not applied")
+	}
+	if lookup.Get("ARC_AUTH_REFRESH_TTL_WEB") != "24h" {
+		t.Fatalf("ARC_AUTH_REFRESH_TTL_WEB not read from the environment")
+	}
+	if os.Getenv("ARC_AUTH_ACCESS_TTL") != "15m" {
+		t.Fatalf("ARC_AUTH_ACCESS_TTL changed in the environment")
+	}
+	if _, set := os.LookupEnv("ARC_TENANT_TEST_ONLY"); set {
+		t.Fatalf("ARC_TENANT_TEST_ONLY exported to the environment")
+	}
+}
@@ -0,0 +1,475 @@
+// Package main provides a WebSocket load generator for Arc realtime.
+//
+// It opens N connections, spreads them over M conversations, sends message.send at a
+// fixed total rate from round-robin connections and reports latency percentiles for:
+//   - ack: message.send written -> message.ack read by the sender
+//   - fanout: message.send written -> message.new read by every other member connection
+//
+// The gateway's per-connection and per-conversation rate limits apply; raise
+// ARC_WS_RATE_EVENTS and ARC_WS_CONVERSATION_SEND_* on the target for high rates.
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	v1 "arc/shared/contracts/realtime/v1"
+
+	"github.com/coder/websocket"
+)
+
+const (
+	defaultSubprotocol = "arc.realtime.v1"
+	maxReadBytes       = 1 << 20 // 1MiB
+
+	dialConcurrency = 32
+)
+
+type benchConfig struct {
+	url         string
+	origin      string
+	conns       int
+	convs       int
+	convPrefix  string
+	rate        float64
+	duration    time.Duration
+	drain       time.Duration
+	textSize    int
+	stepTimeout time.Duration
+	tokens      []string
+}
+
+// benchConn is one connection; conv is the conversation it joined.
+type benchConn struct {
+	id   int
+	conv string
+	conn *websocket.Conn
+	wmu  sync.Mutex
+}
+
+// recorder collects latencies and counters from all connections.
+type recorder struct {
+	sentAt sync.Map // client_msg_id -> time.Time
+
+	mu     sync.Mutex
+	ack    []time.Duration
+	fanout []time.Duration
+
+	sent       atomic.Int64
+	acked      atomic.Int64
+	errors     atomic.Int64
+	sendFailed atomic.Int64
+	disconnect atomic.Int64
+}
+
+type summary struct {
+	Conns        int           `json:"conns"`
+	Convs        int           `json:"convs"`
+	Duration     time.Duration `json:"duration_ns"`
+	Sent         int64         `json:"sent"`
+	Acked        int64         `json:"acked"`
+	SendFailed   int64         `json:"send_failed"`
+	ServerErrors int64         `json:"server_errors"`
+	Disconnects  int64         `json:"disconnects"`
+	SendRate     float64       `json:"send_rate"`
+	Ack          percentiles   `json:"ack"`
+	Fanout       percentiles   `json:"fanout"`
+}
+
+type percentiles struct {
+	Count int           `json:"count"`
+	P50   time.Duration `json:"p50_ns"`
+	P90   time.Duration `json:"p90_ns"`
+	P99   time.Duration `json:"p99_ns"`
+	Max   time.Duration `json:"max_ns"`
+}
+
+func main() {
+	var (
+		wsURL      = flag.String("url", "ws://127.0.0.1:8080/ws", "WebSocket URL")
+		origin     = flag.String("origin", "http://localhost", "Origin header to send")
+		conns      = flag.Int("conns", 50, "Number of concurrent connections")
+		convs      = flag.Int("convs", 5, "Number of conversations (connections are spread round-robin)")
+		convPrefix = flag.String("conv-prefix", "bench-", "Conversation ID prefix")
+		rate       = flag.Float64("rate", 50, "Total messages per second across all connections")
+		duration   = flag.Duration("duration", 30*time.Second, "Send phase duration")
+		drain      = flag.Duration("drain", 3*time.Second, "Wait for outstanding acks/fanout after sending")
+		textSize   = flag.Int("text-size", 64, "Message text size in bytes")
+		timeout    = flag.Duration("timeout", 10*time.Second, "Per-step timeout (dial, hello, join)")
+		// auth-bearer may be passed via flag or WS_BENCH_AUTH_BEARER env.
+		authBearer = flag.String("auth-bearer", "", "Access token shared by all connections")
+		tokensFile = flag.String("tokens-file", "", "File with one access token per line, assigned round-robin")
+		jsonOut    = flag.Bool("json", false, "Print the summary as JSON")
+	)
+	flag.Parse()
+
+	if *conns <= 0 || *convs <= 0 || *convs > *conns {
+		fatalf("need conns > 0 and 0 < convs <= conns")
+	}
+	if *rate <= 0 {
+		fatalf("rate must be > 0")
+	}
+	if u, err := url.Parse(*wsURL); err != nil || (u.Scheme != "ws" && u.Scheme != "wss") {
+		fatalf("invalid -url: %q", *wsURL)
+	}
+
+	cfg := benchConfig{
+		url:         *wsURL,
+		origin:      strings.TrimSpace(*origin),
+		conns:       *conns,
+		convs:       *convs,
+		convPrefix:  *convPrefix,
+		rate:        *rate,
+		duration:    *duration,
+		drain:       *drain,
+		textSize:    max(*textSize, 1),
+		stepTimeout: *timeout,
+	}
+	tokens, err := loadTokens(*authBearer, *tokensFile)
+	if err != nil {
+		fatalf("tokens: %v", err)
+	}
+	cfg.tokens = tokens
+
+	sum := run(context.Background(), cfg)
+	if *jsonOut {
+		_ = json.NewEncoder(os.Stdout).Encode(sum)
+		return
+	}
+	printSummary(sum)
+}
+
+func loadTokens(bearer, file string) ([]string, error) {
+	bearer = strings.TrimSpace(bearer)
+	if bearer == "" {
+		bearer = strings.TrimSpace(os.Getenv("WS_BENCH_AUTH_BEARER"))
+	}
+	if file == "" {
+		if bearer == "" {
+			return nil, nil
+		}
+		return []string{bearer}, nil
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var out []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if t := strings.TrimSpace(sc.Text()); t != "" && !strings.HasPrefix(t, "#") {
+			out = append(out, t)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(out) == 0 {
+		return nil, errors.New("no tokens in file")
+	}
+	return out, nil
+}
+
+func run(parent context.Context, cfg benchConfig) summary {
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	rec := &recorder{}
+	clients := connectAll(ctx, cfg, rec)
+	fmt.Fprintf(os.Stderr, "ws-bench: %d connections joined %d conversations; sending %.1f msg/s for %s\n",
+		len(clients), cfg.convs, cfg.rate, cfg.duration)
+
+	text := strings.Repeat("x", cfg.textSize)
+	interval := time.Duration(float64(time.Second) / cfg.rate)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	start := time.Now()
+	deadline := time.After(cfg.duration)
+	n := 0
+sendLoop:
+	for {
+		select {
+		case <-deadline:
+			break sendLoop
+		case <-ticker.C:
+			c := clients[n%len(clients)]
+			n++
+			go send(ctx, c, rec, fmt.Sprintf("bench-%d-%d-%d", start.UnixNano(), c.id, n), text, cfg.stepTimeout)
+		}
+	}
+	elapsed := time.Since(start)
+
+	time.Sleep(cfg.drain)
+	cancel()
+	for _, c := range clients {
+		_ = c.conn.Close(websocket.StatusNormalClosure, "bench done")
+	}
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return summary{
+		Conns:        len(clients),
+		Convs:        cfg.convs,
+		Duration:     elapsed,
+		Sent:         rec.sent.Load(),
+		Acked:        rec.acked.Load(),
+		SendFailed:   rec.sendFailed.Load(),
+		ServerErrors: rec.errors.Load(),
+		Disconnects:  rec.disconnect.Load(),
+		SendRate:     float64(rec.sent.Load()) / elapsed.Seconds(),
+		Ack:          percentilesOf(rec.ack),
+		Fanout:       percentilesOf(rec.fanout),
+	}
+}
+
+// connectAll dials, says hello and joins with bounded concurrency; any failure is fatal
+// so results always reflect the requested connection count.
+func connectAll(ctx context.Context, cfg benchConfig, rec *recorder) []*benchConn {
+	clients := make([]*benchConn, cfg.conns)
+	sem := make(chan struct{}, dialConcurrency)
+	errs := make(chan error, cfg.conns)
+	var wg sync.WaitGroup
+	for i := range clients {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			token := ""
+			if len(cfg.tokens) > 0 {
+				token = cfg.tokens[i%len(cfg.tokens)]
+			}
+			c, err := connect(ctx, cfg, i, token)
+			if err != nil {
+				errs <- fmt.Errorf("conn %d: %w", i, err)
+				return
+			}
+			clients[i] = c
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	if err := <-errs; err != nil {
+		fatalf("%v", err)
+	}
+
+	for _, c := range clients {
+		go readLoop(ctx, c, rec)
+	}
+	return clients
+}
+
+func connect(ctx context.Context, cfg benchConfig, id int, token string) (*benchConn, error) {
+	sctx, cancel := context.WithTimeout(ctx, cfg.stepTimeout)
+	defer cancel()
+
+	h := http.Header{}
+	if cfg.origin != "" {
+		h.Set("Origin", cfg.origin)
+	}
+	if token != "" {
+		h.Set("Authorization", "Bearer "+token)
+	}
+	conn, resp, err := websocket.Dial(sctx, cfg.url, &websocket.DialOptions{
+		Subprotocols: []string{defaultSubprotocol},
+		HTTPHeader:   h,
+	})
+	if resp != nil && resp.Body != nil {
+		_ = resp.Body.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+	conn.SetReadLimit(maxReadBytes)
+
+	c := &benchConn{id: id, conv: fmt.Sprintf("%s%d", cfg.convPrefix, id%cfg.convs), conn: conn}
+	if err := c.write(sctx, v1.TypeHello, v1.HelloPayload{}); err != nil {
+		return nil, err
+	}
+	if _, err := c.readUntil(sctx, v1.TypeHelloAck); err != nil {
+		return nil, fmt.Errorf("hello: %w", err)
+	}
+	if err := c.write(sctx, v1.TypeConversationJoin, v1.ConversationJoinPayload{ConversationID: c.conv}); err != nil {
+		return nil, err
+	}
+	if _, err := c.readUntil(sctx, v1.TypeConversationJoin); err != nil {
+		return nil, fmt.Errorf("join %s: %w", c.conv, err)
+	}
+	return c, nil
+}
+
+func send(ctx context.Context, c *benchConn, rec *recorder, clientMsgID, text string, timeout time.Duration) {
+	wctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	rec.sentAt.Store(clientMsgID, time.Now())
+	err := c.write(wctx, v1.TypeMessageSend, v1.MessageSendPayload{
+		ConversationID: c.conv,
+		ClientMsgID:    clientMsgID,
+		Text:           text,
+	})
+	if err != nil {
+		rec.sentAt.Delete(clientMsgID)
+		rec.sendFailed.Add(1)
+		return
+	}
+	rec.sent.Add(1)
+}
+
+// readLoop records ack latency for c's own messages and fanout latency for others'.
+func readLoop(ctx context.Context, c *benchConn, rec *recorder) {
+	for {
+		env, err := c.read(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				rec.disconnect.Add(1)
+			}
+			return
+		}
+		now := time.Now()
+
+		switch env.Type {
+		case v1.TypeMessageAck:
+			var p v1.MessageAckPayload
+			if json.Unmarshal(env.Payload, &p) != nil {
+				continue
+			}
+			if at, ok := rec.sentAt.Load(p.ClientMsgID); ok {
+				rec.acked.Add(1)
+				rec.observe(&rec.ack, now.Sub(at.(time.Time)))
+			}
+		case v1.TypeMessageNew:
+			var p v1.MessageNewPayload
+			if json.Unmarshal(env.Payload, &p) != nil {
+				continue
+			}
+			// The sender's own echo is not fanout.
+			if senderOf(p.ClientMsgID) == c.id {
+				continue
+			}
+			if at, ok := rec.sentAt.Load(p.ClientMsgID); ok {
+				rec.observe(&rec.fanout, now.Sub(at.(time.Time)))
+			}
+		case v1.TypeError:
+			rec.errors.Add(1)
+		}
+	}
+}
+
+// senderOf extracts the connection id from "bench-<run>-<conn>-<n>".
+func senderOf(clientMsgID string) int {
+	parts := strings.Split(clientMsgID, "-")
+	if len(parts) != 4 {
+		return -1
+	}
+	var id int
+	if _, err := fmt.Sscanf(parts[2], "%d", &id); err != nil {
+		return -1
+	}
+	return id
+}
+
+func (r *recorder) observe(dst *[]time.Duration, d time.Duration) {
+	r.mu.Lock()
+	*dst = append(*dst, d)
+	r.mu.Unlock()
+}
+
+func percentilesOf(ds []time.Duration) percentiles {
+	if len(ds) == 0 {
+		return percentiles{}
+	}
+	s := slices.Clone(ds)
+	slices.Sort(s)
+	at := func(q float64) time.Duration {
+		i := int(q*float64(len(s))+0.5) - 1
+		return s[min(max(i, 0), len(s)-1)]
+	}
+	return percentiles{Count: len(s), P50: at(0.50), P90: at(0.90), P99: at(0.99), Max: s[len(s)-1]}
+}
+
+func printSummary(s summary) {
+	fmt.Printf("conns=%d convs=%d duration=%s\n", s.Conns, s.Convs, s.Duration.Round(time.Millisecond))
+	fmt.Printf("sent=%d (%.1f msg/s) acked=%d send_failed=%d server_errors=%d disconnects=%d\n",
+		s.Sent, s.SendRate, s.Acked, s.SendFailed, s.ServerErrors, s.Disconnects)
+	for _, row := range []struct {
+		name string
+		p    percentiles
+	}{{"ack", s.Ack}, {"fanout", s.Fanout}} {
+		fmt.Printf("%-6s n=%-7d p50=%-9s p90=%-9s p99=%-9s max=%s\n", row.name, row.p.Count,
+			row.p.P50.Round(time.Microsecond), row.p.P90.Round(time.Microsecond),
+			row.p.P99.Round(time.Microsecond), row.p.Max.Round(time.Microsecond))
+	}
+}
+
+// ---- IO helpers ----
+
+func (c *benchConn) write(ctx context.Context, typ string, payload any) error {
+	p, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(v1.Envelope{
+		V:       v1.Version,
+		Type:    typ,
+		ID:      fmt.Sprintf("b%d-%d", c.id, time.Now().UnixNano()),
+		TS:      time.Now().UTC(),
+		Payload: p,
+	})
+	if err != nil {
+		return err
+	}
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	return c.conn.Write(ctx, websocket.MessageText, b)
+}
+
+func (c *benchConn) read(ctx context.Context) (v1.Envelope, error) {
+	_, data, err := c.conn.Read(ctx)
+	if err != nil {
+		return v1.Envelope{}, err
+	}
+	var env v1.Envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return v1.Envelope{}, fmt.Errorf("bad json: %w", err)
+	}
+	return env, nil
+}
+
+func (c *benchConn) readUntil(ctx context.Context, typ string) (v1.Envelope, error) {
+	for {
+		env, err := c.read(ctx)
+		if err != nil {
+			return v1.Envelope{}, err
+		}
+		if env.Type == v1.TypeError {
+			var p v1.ErrorPayload
+			_ = json.Unmarshal(env.Payload, &p)
+			return v1.Envelope{}, fmt.Errorf("server error: code=%q msg=%q", p.Code, p.Message)
+		}
+		if env.Type == typ {
+			return env, nil
+		}
+	}
+}
+
+func fatalf(format string, args ...any) {
+	_, _ = fmt.Fprintf(os.Stderr, "ws-bench: "+format+"\n", args...)
+	os.Exit(1)
+}
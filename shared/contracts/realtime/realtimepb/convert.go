@@ -0,0 +1,298 @@
+// Package realtimepb is the generated protobuf and gRPC code of the Arc Realtime
+// Protocol (see realtime.proto), with conversions to and from v1.Envelope.
+//
+// Payload messages mirror the v1 payload structs field for field: FromV1 and
+// ToV1 match struct fields to proto fields by their JSON name, so a field added
+// to a v1 payload must be added to realtime.proto as well.
+package realtimepb
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	v1 "arc/shared/contracts/realtime/v1"
+)
+
+// ErrMalformed reports an envelope whose payload does not match its type.
+var ErrMalformed = errors.New("realtimepb: malformed envelope")
+
+// payloads maps each envelope type to its v1 payload struct. The Envelope oneof
+// field of a type is the type with dots replaced by underscores.
+var payloads = map[string]reflect.Type{
+	v1.TypeHello:                    reflect.TypeFor[v1.HelloPayload](),
+	v1.TypeHelloAck:                 reflect.TypeFor[v1.HelloAckPayload](),
+	v1.TypeConversationJoin:         reflect.TypeFor[v1.ConversationJoinPayload](),
+	v1.TypeConversationLeave:        reflect.TypeFor[v1.ConversationLeavePayload](),
+	v1.TypeMessageSend:              reflect.TypeFor[v1.MessageSendPayload](),
+	v1.TypeMessageAck:               reflect.TypeFor[v1.MessageAckPayload](),
+	v1.TypeMessageNew:               reflect.TypeFor[v1.MessageNewPayload](),
+	v1.TypeMessageEdit:              reflect.TypeFor[v1.MessageEditPayload](),
+	v1.TypeMessageDelete:            reflect.TypeFor[v1.MessageDeletePayload](),
+	v1.TypeMessageEdited:            reflect.TypeFor[v1.MessageEditedPayload](),
+	v1.TypeMessageDeleted:           reflect.TypeFor[v1.MessageDeletedPayload](),
+	v1.TypeMessageRemoved:           reflect.TypeFor[v1.MessageRemovedPayload](),
+	v1.TypeMessageRead:              reflect.TypeFor[v1.MessageReadPayload](),
+	v1.TypeReadUpdate:               reflect.TypeFor[v1.ReadUpdatePayload](),
+	v1.TypeReadState:                reflect.TypeFor[v1.ReadStatePayload](),
+	v1.TypeMessageDelivered:         reflect.TypeFor[v1.MessageDeliveredPayload](),
+	v1.TypeSystemNew:                reflect.TypeFor[v1.SystemNewPayload](),
+	v1.TypeConversationHistoryFetch: reflect.TypeFor[v1.ConversationHistoryFetchPayload](),
+	v1.TypeConversationHistoryChunk: reflect.TypeFor[v1.ConversationHistoryChunkPayload](),
+	v1.TypeMemberAdded:              reflect.TypeFor[v1.MemberAddedPayload](),
+	v1.TypeMemberRemoved:            reflect.TypeFor[v1.MemberRemovedPayload](),
+	v1.TypePresenceSubscribe:        reflect.TypeFor[v1.PresenceSubscribePayload](),
+	v1.TypePresenceUpdate:           reflect.TypeFor[v1.PresenceUpdatePayload](),
+	v1.TypeInboxSubscribe:           reflect.TypeFor[v1.InboxSubscribePayload](),
+	v1.TypeInboxMessage:             reflect.TypeFor[v1.InboxMessagePayload](),
+	v1.TypeFirehoseSubscribe:        reflect.TypeFor[v1.FirehoseSubscribePayload](),
+	v1.TypeFirehoseCheckpoint:       reflect.TypeFor[v1.FirehoseCheckpointPayload](),
+	v1.TypeResume:                   reflect.TypeFor[v1.ResumePayload](),
+	v1.TypeResumeOK:                 reflect.TypeFor[v1.ResumeOKPayload](),
+	v1.TypeResumeFailed:             reflect.TypeFor[v1.ResumeFailedPayload](),
+	v1.TypeServerShutdown:           reflect.TypeFor[v1.ServerShutdownPayload](),
+	v1.TypeTimeSync:                 reflect.TypeFor[v1.TimeSyncPayload](),
+	v1.TypeError:                    reflect.TypeFor[v1.ErrorPayload](),
+}
+
+var timeType = reflect.TypeFor[time.Time]()
+
+// payloadField returns the oneof field of envelope type typ, or nil for types
+// without a payload.
+func payloadField(typ string) protoreflect.FieldDescriptor {
+	name := protoreflect.Name(strings.ReplaceAll(typ, ".", "_"))
+	return (*Envelope)(nil).ProtoReflect().Descriptor().Oneofs().ByName("payload").Fields().ByName(name)
+}
+
+// FromV1 converts a v1 envelope into its protobuf message. The JSON payload is
+// decoded into the payload message of env.Type; types without one (inbox.unsubscribe)
+// drop it.
+func FromV1(env v1.Envelope) (*Envelope, error) {
+	if env.V < math.MinInt32 || env.V > math.MaxInt32 {
+		return nil, fmt.Errorf("%w: version out of range: %d", ErrMalformed, env.V)
+	}
+	out := &Envelope{
+		V:      int32(env.V),
+		Type:   env.Type,
+		Id:     env.ID,
+		ConvId: env.ConvID,
+		Seq:    env.Seq,
+	}
+	if !env.TS.IsZero() {
+		out.Ts = timestamppb.New(env.TS)
+	}
+	t, ok := payloads[env.Type]
+	if !ok || len(env.Payload) == 0 {
+		return out, nil
+	}
+
+	p := reflect.New(t)
+	if err := json.Unmarshal(env.Payload, p.Interface()); err != nil {
+		return nil, fmt.Errorf("%w: %s payload: %v", ErrMalformed, env.Type, err)
+	}
+	fd := payloadField(env.Type)
+	m := out.ProtoReflect()
+	pm := m.NewField(fd).Message()
+	if err := toMessage(p.Elem(), pm); err != nil {
+		return nil, err
+	}
+	m.Set(fd, protoreflect.ValueOfMessage(pm))
+	return out, nil
+}
+
+// ToV1 converts a protobuf envelope into a v1 envelope with a JSON payload. The
+// payload set must be the one of env.Type.
+func ToV1(env *Envelope) (v1.Envelope, error) {
+	out := v1.Envelope{
+		V:      int(env.GetV()),
+		Type:   env.GetType(),
+		ID:     env.GetId(),
+		ConvID: env.GetConvId(),
+		Seq:    env.GetSeq(),
+	}
+	if ts := env.GetTs(); ts != nil {
+		out.TS = ts.AsTime()
+	}
+	m := env.ProtoReflect()
+	fd := m.WhichOneof(m.Descriptor().Oneofs().ByName("payload"))
+	if fd == nil {
+		return out, nil
+	}
+	t, ok := payloads[out.Type]
+	if !ok || fd != payloadField(out.Type) {
+		return v1.Envelope{}, fmt.Errorf("%w: %s payload set on type %q", ErrMalformed, fd.Name(), out.Type)
+	}
+
+	p := reflect.New(t)
+	if err := fromMessage(m.Get(fd).Message(), p.Elem()); err != nil {
+		return v1.Envelope{}, err
+	}
+	b, err := json.Marshal(p.Interface())
+	if err != nil {
+		return v1.Envelope{}, err
+	}
+	out.Payload = b
+	return out, nil
+}
+
+// jsonName returns the JSON object key of a struct field.
+func jsonName(f reflect.StructField) protoreflect.Name {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	return protoreflect.Name(name)
+}
+
+func fieldOf(m protoreflect.Message, f reflect.StructField) (protoreflect.FieldDescriptor, error) {
+	fd := m.Descriptor().Fields().ByName(jsonName(f))
+	if fd == nil {
+		return nil, fmt.Errorf("realtimepb: %s has no field for %s", m.Descriptor().FullName(), f.Name)
+	}
+	return fd, nil
+}
+
+// toMessage copies the payload struct v into m.
+func toMessage(v reflect.Value, m protoreflect.Message) error {
+	for i := range v.NumField() {
+		fd, err := fieldOf(m, v.Type().Field(i))
+		if err != nil {
+			return err
+		}
+		fv := v.Field(i)
+		if fd.IsList() {
+			if fv.Len() == 0 {
+				continue
+			}
+			list := m.Mutable(fd).List()
+			for j := range fv.Len() {
+				val, _, err := toValue(fv.Index(j), fd, list.NewElement)
+				if err != nil {
+					return err
+				}
+				list.Append(val)
+			}
+			continue
+		}
+		val, ok, err := toValue(fv, fd, func() protoreflect.Value { return m.NewField(fd) })
+		if err != nil {
+			return err
+		}
+		if ok {
+			m.Set(fd, val)
+		}
+	}
+	return nil
+}
+
+// toValue converts one field or list element. It reports false for nil pointers
+// and zero times, which leave the field unset.
+func toValue(fv reflect.Value, fd protoreflect.FieldDescriptor, newMessage func() protoreflect.Value) (protoreflect.Value, bool, error) {
+	if fv.Kind() == reflect.Pointer {
+		if fv.IsNil() {
+			return protoreflect.Value{}, false, nil
+		}
+		fv = fv.Elem()
+	}
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(fv.String()), true, nil
+	case protoreflect.BoolKind:
+		return protoreflect.ValueOfBool(fv.Bool()), true, nil
+	case protoreflect.Int32Kind:
+		n := fv.Int()
+		if n < math.MinInt32 || n > math.MaxInt32 {
+			return protoreflect.Value{}, false, fmt.Errorf("%w: %s out of range: %d", ErrMalformed, fd.FullName(), n)
+		}
+		return protoreflect.ValueOfInt32(int32(n)), true, nil
+	case protoreflect.Int64Kind:
+		return protoreflect.ValueOfInt64(fv.Int()), true, nil
+	case protoreflect.Uint64Kind:
+		return protoreflect.ValueOfUint64(fv.Uint()), true, nil
+	case protoreflect.BytesKind:
+		return protoreflect.ValueOfBytes(fv.Bytes()), true, nil
+	case protoreflect.MessageKind:
+		if fv.Type() == timeType {
+			t := fv.Interface().(time.Time)
+			if t.IsZero() {
+				return protoreflect.Value{}, false, nil
+			}
+			return protoreflect.ValueOfMessage(timestamppb.New(t).ProtoReflect()), true, nil
+		}
+		val := newMessage()
+		if err := toMessage(fv, val.Message()); err != nil {
+			return protoreflect.Value{}, false, err
+		}
+		return val, true, nil
+	}
+	return protoreflect.Value{}, false, fmt.Errorf("realtimepb: unsupported field kind %s of %s", fd.Kind(), fd.FullName())
+}
+
+// fromMessage copies m into the payload struct v.
+func fromMessage(m protoreflect.Message, v reflect.Value) error {
+	for i := range v.NumField() {
+		fd, err := fieldOf(m, v.Type().Field(i))
+		if err != nil {
+			return err
+		}
+		fv := v.Field(i)
+		if fd.IsList() {
+			list := m.Get(fd).List()
+			if list.Len() == 0 {
+				continue
+			}
+			s := reflect.MakeSlice(fv.Type(), list.Len(), list.Len())
+			for j := range list.Len() {
+				if err := fromValue(list.Get(j), fd, s.Index(j)); err != nil {
+					return err
+				}
+			}
+			fv.Set(s)
+			continue
+		}
+		if fd.HasPresence() && !m.Has(fd) {
+			continue
+		}
+		if fv.Kind() == reflect.Pointer {
+			fv.Set(reflect.New(fv.Type().Elem()))
+			fv = fv.Elem()
+		}
+		if err := fromValue(m.Get(fd), fd, fv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func fromValue(val protoreflect.Value, fd protoreflect.FieldDescriptor, fv reflect.Value) error {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		fv.SetString(val.String())
+	case protoreflect.BoolKind:
+		fv.SetBool(val.Bool())
+	case protoreflect.Int32Kind, protoreflect.Int64Kind:
+		fv.SetInt(val.Int())
+	case protoreflect.Uint64Kind:
+		fv.SetUint(val.Uint())
+	case protoreflect.BytesKind:
+		fv.SetBytes(bytes.Clone(val.Bytes()))
+	case protoreflect.MessageKind:
+		if fv.Type() == timeType {
+			ts, ok := val.Message().Interface().(*timestamppb.Timestamp)
+			if !ok || ts.CheckValid() != nil {
+				return fmt.Errorf("%w: invalid timestamp in %s", ErrMalformed, fd.FullName())
+			}
+			fv.Set(reflect.ValueOf(ts.AsTime()))
+			return nil
+		}
+		return fromMessage(val.Message(), fv)
+	default:
+		return fmt.Errorf("realtimepb: unsupported field kind %s of %s", fd.Kind(), fd.FullName())
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package realtimepb
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	v1 "arc/shared/contracts/realtime/v1"
+)
+
+func TestPayloads_MatchOneof(t *testing.T) {
+	fields := (*Envelope)(nil).ProtoReflect().Descriptor().Oneofs().ByName("payload").Fields()
+	if fields.Len() != len(payloads) {
+		t.Fatalf("oneof has %d fields, payloads has %d types", fields.Len(), len(payloads))
+	}
+	for i := range fields.Len() {
+		fd := fields.Get(i)
+		typ := strings.ReplaceAll(string(fd.Name()), "_", ".")
+		if err := (v1.Envelope{V: v1.Version, Type: typ}).Validate(); err != nil {
+			t.Fatalf("oneof field %s: %v", fd.Name(), err)
+		}
+		pt, ok := payloads[typ]
+		if !ok {
+			t.Fatalf("no payload struct for %q", typ)
+		}
+		if got := string(fd.Message().Name()); got != pt.Name() {
+			t.Fatalf("%s: message %s, struct %s", typ, got, pt.Name())
+		}
+	}
+}
+
+// fill sets every field of v to a non-zero value.
+func fill(t *testing.T, v reflect.Value, n int) {
+	t.Helper()
+	switch {
+	case v.Type() == timeType:
+		v.Set(reflect.ValueOf(time.Unix(1_700_000_000+int64(n), 123456789).UTC()))
+		return
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+		v.SetBytes([]byte{byte(n), 0, 0xff})
+		return
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString("s" + strings.Repeat("x", n))
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Int, reflect.Int64:
+		v.SetInt(int64(n) + 1)
+	case reflect.Uint64:
+		v.SetUint(uint64(n) + 7)
+	case reflect.Pointer:
+		v.Set(reflect.New(v.Type().Elem()))
+		fill(t, v.Elem(), n)
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(v.Type(), 2, 2))
+		fill(t, v.Index(0), n)
+		fill(t, v.Index(1), n+1)
+	case reflect.Struct:
+		for i := range v.NumField() {
+			fill(t, v.Field(i), n+i)
+		}
+	default:
+		t.Fatalf("fill: unsupported kind %s", v.Kind())
+	}
+}
+
+func TestFromV1_ToV1_RoundTrip(t *testing.T) {
+	for typ, pt := range payloads {
+		p := reflect.New(pt)
+		fill(t, p.Elem(), 1)
+		payload, err := json.Marshal(p.Interface())
+		if err != nil {
+			t.Fatalf("%s: marshal: %v", typ, err)
+		}
+		in := v1.Envelope{
+			V:       v1.Version,
+			Type:    typ,
+			ID:      "env-1",
+			ConvID:  "conv-1",
+			Seq:     42,
+			TS:      time.Date(2025, 3, 1, 12, 0, 0, 5, time.UTC),
+			Payload: payload,
+		}
+
+		pb, err := FromV1(in)
+		if err != nil {
+			t.Fatalf("%s: FromV1: %v", typ, err)
+		}
+		b, err := proto.Marshal(pb)
+		if err != nil {
+			t.Fatalf("%s: proto.Marshal: %v", typ, err)
+		}
+		var decoded Envelope
+		if err := proto.Unmarshal(b, &decoded); err != nil {
+			t.Fatalf("%s: proto.Unmarshal: %v", typ, err)
+		}
+		out, err := ToV1(&decoded)
+		if err != nil {
+			t.Fatalf("%s: ToV1: %v", typ, err)
+		}
+		if !bytes.Equal(out.Payload, in.Payload) {
+			t.Fatalf("%s: payload\n got %s\nwant %s", typ, out.Payload, in.Payload)
+		}
+		out.Payload, in.Payload = nil, nil
+		if !reflect.DeepEqual(out, in) {
+			t.Fatalf("%s: envelope\n got %+v\nwant %+v", typ, out, in)
+		}
+	}
+}
+
+func TestFromV1_ZeroValues(t *testing.T) {
+	payload, _ := json.Marshal(v1.ConversationHistoryFetchPayload{ConversationID: "c", AfterSeq: new(int64)})
+	pb, err := FromV1(v1.Envelope{V: v1.Version, Type: v1.TypeConversationHistoryFetch, Payload: payload})
+	if err != nil {
+		t.Fatalf("FromV1: %v", err)
+	}
+	if pb.Ts != nil {
+		t.Fatalf("expected no ts for a zero time, got %v", pb.Ts)
+	}
+	p := pb.GetConversationHistoryFetch()
+	if p == nil || p.AfterSeq == nil || *p.AfterSeq != 0 || p.BeforeSeq != nil {
+		t.Fatalf("expected after_seq=0 and no before_seq, got %+v", p)
+	}
+
+	out, err := ToV1(pb)
+	if err != nil {
+		t.Fatalf("ToV1: %v", err)
+	}
+	if !out.TS.IsZero() || string(out.Payload) != string(payload) {
+		t.Fatalf("got ts=%v payload=%s, want %s", out.TS, out.Payload, payload)
+	}
+}
+
+func TestFromV1_TypeWithoutPayload(t *testing.T) {
+	pb, err := FromV1(v1.Envelope{V: v1.Version, Type: v1.TypeInboxUnsubscribe, Payload: json.RawMessage(`{}`)})
+	if err != nil {
+		t.Fatalf("FromV1: %v", err)
+	}
+	if pb.Payload != nil {
+		t.Fatalf("expected no payload, got %T", pb.Payload)
+	}
+	out, err := ToV1(pb)
+	if err != nil || out.Payload != nil {
+		t.Fatalf("ToV1 = %+v, %v", out, err)
+	}
+}
+
+func TestConvert_Malformed(t *testing.T) {
+	if _, err := FromV1(v1.Envelope{V: v1.Version, Type: v1.TypeHello, Payload: json.RawMessage(`{"token":1}`)}); !errors.Is(err, ErrMalformed) {
+		t.Fatalf("FromV1 with a bad payload: expected ErrMalformed, got %v", err)
+	}
+	if _, err := FromV1(v1.Envelope{V: v1.Version, Type: v1.TypeError, Payload: json.RawMessage(`{"max_chars":4294967296}`)}); !errors.Is(err, ErrMalformed) {
+		t.Fatalf("FromV1 with an out of range int32: expected ErrMalformed, got %v", err)
+	}
+	env := &Envelope{V: v1.Version, Type: v1.TypeMessageSend, Payload: &Envelope_Hello{Hello: &HelloPayload{Token: "t"}}}
+	if _, err := ToV1(env); !errors.Is(err, ErrMalformed) {
+		t.Fatalf("ToV1 with a mismatched payload: expected ErrMalformed, got %v", err)
+	}
+}
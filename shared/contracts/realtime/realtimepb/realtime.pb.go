@@ -0,0 +1,3508 @@
+// Arc Realtime Protocol over gRPC.
+//
+// Envelope mirrors the v1 JSON envelope field for field. Each v1 payload is a
+// message named after its Go struct in arc/shared/contracts/realtime/v1, with
+// the JSON field names; the payload oneof has one field per envelope type,
+// named after the type with dots replaced by underscores.
+//
+// Regenerate realtime.pb.go and realtime_grpc.pb.go with
+// tools/scripts/gen-proto.sh after editing this file.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        (unknown)
+// source: realtime.proto
+
+package realtimepb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Envelope struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	V      int32                  `protobuf:"varint,1,opt,name=v,proto3" json:"v,omitempty"`
+	Type   string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Id     string                 `protobuf:"bytes,3,opt,name=id,proto3" json:"id,omitempty"`
+	ConvId string                 `protobuf:"bytes,4,opt,name=conv_id,json=convId,proto3" json:"conv_id,omitempty"`
+	Ts     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=ts,proto3" json:"ts,omitempty"`
+	Seq    int64                  `protobuf:"varint,7,opt,name=seq,proto3" json:"seq,omitempty"`
+	// payload is unset for types without one (inbox.unsubscribe, time.sync requests).
+	//
+	// Types that are valid to be assigned to Payload:
+	//
+	//	*Envelope_Hello
+	//	*Envelope_HelloAck
+	//	*Envelope_ConversationJoin
+	//	*Envelope_ConversationLeave
+	//	*Envelope_MessageSend
+	//	*Envelope_MessageAck
+	//	*Envelope_MessageNew
+	//	*Envelope_MessageEdit
+	//	*Envelope_MessageDelete
+	//	*Envelope_MessageEdited
+	//	*Envelope_MessageDeleted
+	//	*Envelope_MessageRemoved
+	//	*Envelope_MessageRead
+	//	*Envelope_ReadUpdate
+	//	*Envelope_ReadState
+	//	*Envelope_MessageDelivered
+	//	*Envelope_SystemNew
+	//	*Envelope_ConversationHistoryFetch
+	//	*Envelope_ConversationHistoryChunk
+	//	*Envelope_MemberAdded
+	//	*Envelope_MemberRemoved
+	//	*Envelope_PresenceSubscribe
+	//	*Envelope_PresenceUpdate
+	//	*Envelope_InboxSubscribe
+	//	*Envelope_InboxMessage
+	//	*Envelope_FirehoseSubscribe
+	//	*Envelope_FirehoseCheckpoint
+	//	*Envelope_Resume
+	//	*Envelope_ResumeOk
+	//	*Envelope_ResumeFailed
+	//	*Envelope_ServerShutdown
+	//	*Envelope_TimeSync
+	//	*Envelope_Error
+	Payload       isEnvelope_Payload `protobuf_oneof:"payload"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Envelope) Reset() {
+	*x = Envelope{}
+	mi := &file_realtime_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Envelope) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Envelope) ProtoMessage() {}
+
+func (x *Envelope) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Envelope.ProtoReflect.Descriptor instead.
+func (*Envelope) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Envelope) GetV() int32 {
+	if x != nil {
+		return x.V
+	}
+	return 0
+}
+
+func (x *Envelope) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Envelope) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Envelope) GetConvId() string {
+	if x != nil {
+		return x.ConvId
+	}
+	return ""
+}
+
+func (x *Envelope) GetTs() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Ts
+	}
+	return nil
+}
+
+func (x *Envelope) GetSeq() int64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *Envelope) GetPayload() isEnvelope_Payload {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *Envelope) GetHello() *HelloPayload {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_Hello); ok {
+			return x.Hello
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetHelloAck() *HelloAckPayload {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_HelloAck); ok {
+			return x.HelloAck
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetConversationJoin() *ConversationJoinPayload {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_ConversationJoin); ok {
+			return x.ConversationJoin
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetConversationLeave() *ConversationLeavePayload {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_ConversationLeave); ok {
+			return x.ConversationLeave
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetMessageSend() *MessageSendPayload {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_MessageSend); ok {
+			return x.MessageSend
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetMessageAck() *MessageAckPayload {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_MessageAck); ok {
+			return x.MessageAck
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetMessageNew() *MessageNewPayload {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_MessageNew); ok {
+			return x.MessageNew
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetMessageEdit() *MessageEditPayload {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_MessageEdit); ok {
+			return x.MessageEdit
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetMessageDelete() *MessageDeletePayload {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_MessageDelete); ok {
+			return x.MessageDelete
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetMessageEdited() *MessageEditedPayload {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_MessageEdited); ok {
+			return x.MessageEdited
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetMessageDeleted() *MessageDeletedPayload {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_MessageDeleted); ok {
+			return x.MessageDeleted
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetMessageRemoved() *MessageRemovedPayload {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_MessageRemoved); ok {
+			return x.MessageRemoved
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetMessageRead() *MessageReadPayload {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_MessageRead); ok {
+			return x.MessageRead
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetReadUpdate() *ReadUpdatePayload {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_ReadUpdate); ok {
+			return x.ReadUpdate
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetReadState() *ReadStatePayload {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_ReadState); ok {
+			return x.ReadState
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetMessageDelivered() *MessageDeliveredPayload {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_MessageDelivered); ok {
+			return x.MessageDelivered
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetSystemNew() *SystemNewPayload {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_SystemNew); ok {
+			return x.SystemNew
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetConversationHistoryFetch() *ConversationHistoryFetchPayload {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_ConversationHistoryFetch); ok {
+			return x.ConversationHistoryFetch
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetConversationHistoryChunk() *ConversationHistoryChunkPayload {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_ConversationHistoryChunk); ok {
+			return x.ConversationHistoryChunk
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetMemberAdded() *MemberAddedPayload {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_MemberAdded); ok {
+			return x.MemberAdded
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetMemberRemoved() *MemberRemovedPayload {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_MemberRemoved); ok {
+			return x.MemberRemoved
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetPresenceSubscribe() *PresenceSubscribePayload {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_PresenceSubscribe); ok {
+			return x.PresenceSubscribe
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetPresenceUpdate() *PresenceUpdatePayload {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_PresenceUpdate); ok {
+			return x.PresenceUpdate
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetInboxSubscribe() *InboxSubscribePayload {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_InboxSubscribe); ok {
+			return x.InboxSubscribe
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetInboxMessage() *InboxMessagePayload {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_InboxMessage); ok {
+			return x.InboxMessage
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetFirehoseSubscribe() *FirehoseSubscribePayload {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_FirehoseSubscribe); ok {
+			return x.FirehoseSubscribe
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetFirehoseCheckpoint() *FirehoseCheckpointPayload {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_FirehoseCheckpoint); ok {
+			return x.FirehoseCheckpoint
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetResume() *ResumePayload {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_Resume); ok {
+			return x.Resume
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetResumeOk() *ResumeOKPayload {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_ResumeOk); ok {
+			return x.ResumeOk
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetResumeFailed() *ResumeFailedPayload {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_ResumeFailed); ok {
+			return x.ResumeFailed
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetServerShutdown() *ServerShutdownPayload {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_ServerShutdown); ok {
+			return x.ServerShutdown
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetTimeSync() *TimeSyncPayload {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_TimeSync); ok {
+			return x.TimeSync
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetError() *ErrorPayload {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_Error); ok {
+			return x.Error
+		}
+	}
+	return nil
+}
+
+type isEnvelope_Payload interface {
+	isEnvelope_Payload()
+}
+
+type Envelope_Hello struct {
+	Hello *HelloPayload `protobuf:"bytes,10,opt,name=hello,proto3,oneof"`
+}
+
+type Envelope_HelloAck struct {
+	HelloAck *HelloAckPayload `protobuf:"bytes,11,opt,name=hello_ack,json=helloAck,proto3,oneof"`
+}
+
+type Envelope_ConversationJoin struct {
+	ConversationJoin *ConversationJoinPayload `protobuf:"bytes,12,opt,name=conversation_join,json=conversationJoin,proto3,oneof"`
+}
+
+type Envelope_ConversationLeave struct {
+	ConversationLeave *ConversationLeavePayload `protobuf:"bytes,13,opt,name=conversation_leave,json=conversationLeave,proto3,oneof"`
+}
+
+type Envelope_MessageSend struct {
+	MessageSend *MessageSendPayload `protobuf:"bytes,14,opt,name=message_send,json=messageSend,proto3,oneof"`
+}
+
+type Envelope_MessageAck struct {
+	MessageAck *MessageAckPayload `protobuf:"bytes,15,opt,name=message_ack,json=messageAck,proto3,oneof"`
+}
+
+type Envelope_MessageNew struct {
+	MessageNew *MessageNewPayload `protobuf:"bytes,16,opt,name=message_new,json=messageNew,proto3,oneof"`
+}
+
+type Envelope_MessageEdit struct {
+	MessageEdit *MessageEditPayload `protobuf:"bytes,17,opt,name=message_edit,json=messageEdit,proto3,oneof"`
+}
+
+type Envelope_MessageDelete struct {
+	MessageDelete *MessageDeletePayload `protobuf:"bytes,18,opt,name=message_delete,json=messageDelete,proto3,oneof"`
+}
+
+type Envelope_MessageEdited struct {
+	MessageEdited *MessageEditedPayload `protobuf:"bytes,19,opt,name=message_edited,json=messageEdited,proto3,oneof"`
+}
+
+type Envelope_MessageDeleted struct {
+	MessageDeleted *MessageDeletedPayload `protobuf:"bytes,20,opt,name=message_deleted,json=messageDeleted,proto3,oneof"`
+}
+
+type Envelope_MessageRemoved struct {
+	MessageRemoved *MessageRemovedPayload `protobuf:"bytes,21,opt,name=message_removed,json=messageRemoved,proto3,oneof"`
+}
+
+type Envelope_MessageRead struct {
+	MessageRead *MessageReadPayload `protobuf:"bytes,22,opt,name=message_read,json=messageRead,proto3,oneof"`
+}
+
+type Envelope_ReadUpdate struct {
+	ReadUpdate *ReadUpdatePayload `protobuf:"bytes,23,opt,name=read_update,json=readUpdate,proto3,oneof"`
+}
+
+type Envelope_ReadState struct {
+	ReadState *ReadStatePayload `protobuf:"bytes,24,opt,name=read_state,json=readState,proto3,oneof"`
+}
+
+type Envelope_MessageDelivered struct {
+	MessageDelivered *MessageDeliveredPayload `protobuf:"bytes,25,opt,name=message_delivered,json=messageDelivered,proto3,oneof"`
+}
+
+type Envelope_SystemNew struct {
+	SystemNew *SystemNewPayload `protobuf:"bytes,26,opt,name=system_new,json=systemNew,proto3,oneof"`
+}
+
+type Envelope_ConversationHistoryFetch struct {
+	ConversationHistoryFetch *ConversationHistoryFetchPayload `protobuf:"bytes,27,opt,name=conversation_history_fetch,json=conversationHistoryFetch,proto3,oneof"`
+}
+
+type Envelope_ConversationHistoryChunk struct {
+	ConversationHistoryChunk *ConversationHistoryChunkPayload `protobuf:"bytes,28,opt,name=conversation_history_chunk,json=conversationHistoryChunk,proto3,oneof"`
+}
+
+type Envelope_MemberAdded struct {
+	MemberAdded *MemberAddedPayload `protobuf:"bytes,29,opt,name=member_added,json=memberAdded,proto3,oneof"`
+}
+
+type Envelope_MemberRemoved struct {
+	MemberRemoved *MemberRemovedPayload `protobuf:"bytes,30,opt,name=member_removed,json=memberRemoved,proto3,oneof"`
+}
+
+type Envelope_PresenceSubscribe struct {
+	PresenceSubscribe *PresenceSubscribePayload `protobuf:"bytes,31,opt,name=presence_subscribe,json=presenceSubscribe,proto3,oneof"`
+}
+
+type Envelope_PresenceUpdate struct {
+	PresenceUpdate *PresenceUpdatePayload `protobuf:"bytes,32,opt,name=presence_update,json=presenceUpdate,proto3,oneof"`
+}
+
+type Envelope_InboxSubscribe struct {
+	InboxSubscribe *InboxSubscribePayload `protobuf:"bytes,33,opt,name=inbox_subscribe,json=inboxSubscribe,proto3,oneof"`
+}
+
+type Envelope_InboxMessage struct {
+	InboxMessage *InboxMessagePayload `protobuf:"bytes,34,opt,name=inbox_message,json=inboxMessage,proto3,oneof"`
+}
+
+type Envelope_FirehoseSubscribe struct {
+	FirehoseSubscribe *FirehoseSubscribePayload `protobuf:"bytes,35,opt,name=firehose_subscribe,json=firehoseSubscribe,proto3,oneof"`
+}
+
+type Envelope_FirehoseCheckpoint struct {
+	FirehoseCheckpoint *FirehoseCheckpointPayload `protobuf:"bytes,36,opt,name=firehose_checkpoint,json=firehoseCheckpoint,proto3,oneof"`
+}
+
+type Envelope_Resume struct {
+	Resume *ResumePayload `protobuf:"bytes,37,opt,name=resume,proto3,oneof"`
+}
+
+type Envelope_ResumeOk struct {
+	ResumeOk *ResumeOKPayload `protobuf:"bytes,38,opt,name=resume_ok,json=resumeOk,proto3,oneof"`
+}
+
+type Envelope_ResumeFailed struct {
+	ResumeFailed *ResumeFailedPayload `protobuf:"bytes,39,opt,name=resume_failed,json=resumeFailed,proto3,oneof"`
+}
+
+type Envelope_ServerShutdown struct {
+	ServerShutdown *ServerShutdownPayload `protobuf:"bytes,40,opt,name=server_shutdown,json=serverShutdown,proto3,oneof"`
+}
+
+type Envelope_TimeSync struct {
+	TimeSync *TimeSyncPayload `protobuf:"bytes,41,opt,name=time_sync,json=timeSync,proto3,oneof"`
+}
+
+type Envelope_Error struct {
+	Error *ErrorPayload `protobuf:"bytes,42,opt,name=error,proto3,oneof"`
+}
+
+func (*Envelope_Hello) isEnvelope_Payload() {}
+
+func (*Envelope_HelloAck) isEnvelope_Payload() {}
+
+func (*Envelope_ConversationJoin) isEnvelope_Payload() {}
+
+func (*Envelope_ConversationLeave) isEnvelope_Payload() {}
+
+func (*Envelope_MessageSend) isEnvelope_Payload() {}
+
+func (*Envelope_MessageAck) isEnvelope_Payload() {}
+
+func (*Envelope_MessageNew) isEnvelope_Payload() {}
+
+func (*Envelope_MessageEdit) isEnvelope_Payload() {}
+
+func (*Envelope_MessageDelete) isEnvelope_Payload() {}
+
+func (*Envelope_MessageEdited) isEnvelope_Payload() {}
+
+func (*Envelope_MessageDeleted) isEnvelope_Payload() {}
+
+func (*Envelope_MessageRemoved) isEnvelope_Payload() {}
+
+func (*Envelope_MessageRead) isEnvelope_Payload() {}
+
+func (*Envelope_ReadUpdate) isEnvelope_Payload() {}
+
+func (*Envelope_ReadState) isEnvelope_Payload() {}
+
+func (*Envelope_MessageDelivered) isEnvelope_Payload() {}
+
+func (*Envelope_SystemNew) isEnvelope_Payload() {}
+
+func (*Envelope_ConversationHistoryFetch) isEnvelope_Payload() {}
+
+func (*Envelope_ConversationHistoryChunk) isEnvelope_Payload() {}
+
+func (*Envelope_MemberAdded) isEnvelope_Payload() {}
+
+func (*Envelope_MemberRemoved) isEnvelope_Payload() {}
+
+func (*Envelope_PresenceSubscribe) isEnvelope_Payload() {}
+
+func (*Envelope_PresenceUpdate) isEnvelope_Payload() {}
+
+func (*Envelope_InboxSubscribe) isEnvelope_Payload() {}
+
+func (*Envelope_InboxMessage) isEnvelope_Payload() {}
+
+func (*Envelope_FirehoseSubscribe) isEnvelope_Payload() {}
+
+func (*Envelope_FirehoseCheckpoint) isEnvelope_Payload() {}
+
+func (*Envelope_Resume) isEnvelope_Payload() {}
+
+func (*Envelope_ResumeOk) isEnvelope_Payload() {}
+
+func (*Envelope_ResumeFailed) isEnvelope_Payload() {}
+
+func (*Envelope_ServerShutdown) isEnvelope_Payload() {}
+
+func (*Envelope_TimeSync) isEnvelope_Payload() {}
+
+func (*Envelope_Error) isEnvelope_Payload() {}
+
+type HelloPayload struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Token         string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	ResumeToken   string                 `protobuf:"bytes,2,opt,name=resume_token,json=resumeToken,proto3" json:"resume_token,omitempty"`
+	MaxFrameBytes int32                  `protobuf:"varint,3,opt,name=max_frame_bytes,json=maxFrameBytes,proto3" json:"max_frame_bytes,omitempty"`
+	ClientVersion string                 `protobuf:"bytes,4,opt,name=client_version,json=clientVersion,proto3" json:"client_version,omitempty"`
+	Platform      string                 `protobuf:"bytes,5,opt,name=platform,proto3" json:"platform,omitempty"`
+	Capabilities  uint64                 `protobuf:"varint,6,opt,name=capabilities,proto3" json:"capabilities,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HelloPayload) Reset() {
+	*x = HelloPayload{}
+	mi := &file_realtime_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HelloPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HelloPayload) ProtoMessage() {}
+
+func (x *HelloPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HelloPayload.ProtoReflect.Descriptor instead.
+func (*HelloPayload) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *HelloPayload) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *HelloPayload) GetResumeToken() string {
+	if x != nil {
+		return x.ResumeToken
+	}
+	return ""
+}
+
+func (x *HelloPayload) GetMaxFrameBytes() int32 {
+	if x != nil {
+		return x.MaxFrameBytes
+	}
+	return 0
+}
+
+func (x *HelloPayload) GetClientVersion() string {
+	if x != nil {
+		return x.ClientVersion
+	}
+	return ""
+}
+
+func (x *HelloPayload) GetPlatform() string {
+	if x != nil {
+		return x.Platform
+	}
+	return ""
+}
+
+func (x *HelloPayload) GetCapabilities() uint64 {
+	if x != nil {
+		return x.Capabilities
+	}
+	return 0
+}
+
+type HelloAckPayload struct {
+	state                protoimpl.MessageState `protogen:"open.v1"`
+	SessionId            string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	ResumeToken          string                 `protobuf:"bytes,2,opt,name=resume_token,json=resumeToken,proto3" json:"resume_token,omitempty"`
+	ResumeTokenExpiresAt *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=resume_token_expires_at,json=resumeTokenExpiresAt,proto3" json:"resume_token_expires_at,omitempty"`
+	MaxMessageChars      int32                  `protobuf:"varint,4,opt,name=max_message_chars,json=maxMessageChars,proto3" json:"max_message_chars,omitempty"`
+	MaxFrameBytes        int32                  `protobuf:"varint,5,opt,name=max_frame_bytes,json=maxFrameBytes,proto3" json:"max_frame_bytes,omitempty"`
+	HeartbeatIntervalMs  int64                  `protobuf:"varint,6,opt,name=heartbeat_interval_ms,json=heartbeatIntervalMs,proto3" json:"heartbeat_interval_ms,omitempty"`
+	ProtocolVersion      int32                  `protobuf:"varint,7,opt,name=protocol_version,json=protocolVersion,proto3" json:"protocol_version,omitempty"`
+	Capabilities         uint64                 `protobuf:"varint,8,opt,name=capabilities,proto3" json:"capabilities,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *HelloAckPayload) Reset() {
+	*x = HelloAckPayload{}
+	mi := &file_realtime_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HelloAckPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HelloAckPayload) ProtoMessage() {}
+
+func (x *HelloAckPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HelloAckPayload.ProtoReflect.Descriptor instead.
+func (*HelloAckPayload) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *HelloAckPayload) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *HelloAckPayload) GetResumeToken() string {
+	if x != nil {
+		return x.ResumeToken
+	}
+	return ""
+}
+
+func (x *HelloAckPayload) GetResumeTokenExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ResumeTokenExpiresAt
+	}
+	return nil
+}
+
+func (x *HelloAckPayload) GetMaxMessageChars() int32 {
+	if x != nil {
+		return x.MaxMessageChars
+	}
+	return 0
+}
+
+func (x *HelloAckPayload) GetMaxFrameBytes() int32 {
+	if x != nil {
+		return x.MaxFrameBytes
+	}
+	return 0
+}
+
+func (x *HelloAckPayload) GetHeartbeatIntervalMs() int64 {
+	if x != nil {
+		return x.HeartbeatIntervalMs
+	}
+	return 0
+}
+
+func (x *HelloAckPayload) GetProtocolVersion() int32 {
+	if x != nil {
+		return x.ProtocolVersion
+	}
+	return 0
+}
+
+func (x *HelloAckPayload) GetCapabilities() uint64 {
+	if x != nil {
+		return x.Capabilities
+	}
+	return 0
+}
+
+type ConversationJoinPayload struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ConversationId string                 `protobuf:"bytes,1,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	Kind           string                 `protobuf:"bytes,2,opt,name=kind,proto3" json:"kind,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ConversationJoinPayload) Reset() {
+	*x = ConversationJoinPayload{}
+	mi := &file_realtime_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConversationJoinPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConversationJoinPayload) ProtoMessage() {}
+
+func (x *ConversationJoinPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConversationJoinPayload.ProtoReflect.Descriptor instead.
+func (*ConversationJoinPayload) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ConversationJoinPayload) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+func (x *ConversationJoinPayload) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+type ConversationLeavePayload struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ConversationId string                 `protobuf:"bytes,1,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ConversationLeavePayload) Reset() {
+	*x = ConversationLeavePayload{}
+	mi := &file_realtime_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConversationLeavePayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConversationLeavePayload) ProtoMessage() {}
+
+func (x *ConversationLeavePayload) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConversationLeavePayload.ProtoReflect.Descriptor instead.
+func (*ConversationLeavePayload) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ConversationLeavePayload) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+type MessageSendPayload struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	ConversationId     string                 `protobuf:"bytes,1,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	ClientMsgId        string                 `protobuf:"bytes,2,opt,name=client_msg_id,json=clientMsgId,proto3" json:"client_msg_id,omitempty"`
+	Text               string                 `protobuf:"bytes,3,opt,name=text,proto3" json:"text,omitempty"`
+	ReplyToServerMsgId string                 `protobuf:"bytes,4,opt,name=reply_to_server_msg_id,json=replyToServerMsgId,proto3" json:"reply_to_server_msg_id,omitempty"`
+	AttachmentIds      []string               `protobuf:"bytes,5,rep,name=attachment_ids,json=attachmentIds,proto3" json:"attachment_ids,omitempty"`
+	ContentType        string                 `protobuf:"bytes,6,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	Ciphertext         []byte                 `protobuf:"bytes,7,opt,name=ciphertext,proto3" json:"ciphertext,omitempty"`
+	KeyIds             []string               `protobuf:"bytes,8,rep,name=key_ids,json=keyIds,proto3" json:"key_ids,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *MessageSendPayload) Reset() {
+	*x = MessageSendPayload{}
+	mi := &file_realtime_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MessageSendPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MessageSendPayload) ProtoMessage() {}
+
+func (x *MessageSendPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MessageSendPayload.ProtoReflect.Descriptor instead.
+func (*MessageSendPayload) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *MessageSendPayload) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+func (x *MessageSendPayload) GetClientMsgId() string {
+	if x != nil {
+		return x.ClientMsgId
+	}
+	return ""
+}
+
+func (x *MessageSendPayload) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *MessageSendPayload) GetReplyToServerMsgId() string {
+	if x != nil {
+		return x.ReplyToServerMsgId
+	}
+	return ""
+}
+
+func (x *MessageSendPayload) GetAttachmentIds() []string {
+	if x != nil {
+		return x.AttachmentIds
+	}
+	return nil
+}
+
+func (x *MessageSendPayload) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *MessageSendPayload) GetCiphertext() []byte {
+	if x != nil {
+		return x.Ciphertext
+	}
+	return nil
+}
+
+func (x *MessageSendPayload) GetKeyIds() []string {
+	if x != nil {
+		return x.KeyIds
+	}
+	return nil
+}
+
+type MessageEntity struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Offset        int32                  `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	Length        int32                  `protobuf:"varint,3,opt,name=length,proto3" json:"length,omitempty"`
+	UserId        string                 `protobuf:"bytes,4,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Url           string                 `protobuf:"bytes,5,opt,name=url,proto3" json:"url,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MessageEntity) Reset() {
+	*x = MessageEntity{}
+	mi := &file_realtime_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MessageEntity) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MessageEntity) ProtoMessage() {}
+
+func (x *MessageEntity) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MessageEntity.ProtoReflect.Descriptor instead.
+func (*MessageEntity) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *MessageEntity) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *MessageEntity) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *MessageEntity) GetLength() int32 {
+	if x != nil {
+		return x.Length
+	}
+	return 0
+}
+
+func (x *MessageEntity) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *MessageEntity) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+type MessageAckPayload struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ConversationId string                 `protobuf:"bytes,1,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	ClientMsgId    string                 `protobuf:"bytes,2,opt,name=client_msg_id,json=clientMsgId,proto3" json:"client_msg_id,omitempty"`
+	ServerMsgId    string                 `protobuf:"bytes,3,opt,name=server_msg_id,json=serverMsgId,proto3" json:"server_msg_id,omitempty"`
+	Seq            int64                  `protobuf:"varint,4,opt,name=seq,proto3" json:"seq,omitempty"`
+	ClockSkewMs    *int64                 `protobuf:"varint,5,opt,name=clock_skew_ms,json=clockSkewMs,proto3,oneof" json:"clock_skew_ms,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *MessageAckPayload) Reset() {
+	*x = MessageAckPayload{}
+	mi := &file_realtime_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MessageAckPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MessageAckPayload) ProtoMessage() {}
+
+func (x *MessageAckPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MessageAckPayload.ProtoReflect.Descriptor instead.
+func (*MessageAckPayload) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *MessageAckPayload) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+func (x *MessageAckPayload) GetClientMsgId() string {
+	if x != nil {
+		return x.ClientMsgId
+	}
+	return ""
+}
+
+func (x *MessageAckPayload) GetServerMsgId() string {
+	if x != nil {
+		return x.ServerMsgId
+	}
+	return ""
+}
+
+func (x *MessageAckPayload) GetSeq() int64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *MessageAckPayload) GetClockSkewMs() int64 {
+	if x != nil && x.ClockSkewMs != nil {
+		return *x.ClockSkewMs
+	}
+	return 0
+}
+
+type MessageNewPayload struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	ConversationId     string                 `protobuf:"bytes,1,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	ClientMsgId        string                 `protobuf:"bytes,2,opt,name=client_msg_id,json=clientMsgId,proto3" json:"client_msg_id,omitempty"`
+	ServerMsgId        string                 `protobuf:"bytes,3,opt,name=server_msg_id,json=serverMsgId,proto3" json:"server_msg_id,omitempty"`
+	Seq                int64                  `protobuf:"varint,4,opt,name=seq,proto3" json:"seq,omitempty"`
+	Sender             string                 `protobuf:"bytes,5,opt,name=sender,proto3" json:"sender,omitempty"`
+	SenderBotId        string                 `protobuf:"bytes,6,opt,name=sender_bot_id,json=senderBotId,proto3" json:"sender_bot_id,omitempty"`
+	Text               string                 `protobuf:"bytes,7,opt,name=text,proto3" json:"text,omitempty"`
+	ServerTs           *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=server_ts,json=serverTs,proto3" json:"server_ts,omitempty"`
+	Version            int64                  `protobuf:"varint,9,opt,name=version,proto3" json:"version,omitempty"`
+	EditedAt           *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=edited_at,json=editedAt,proto3" json:"edited_at,omitempty"`
+	Deleted            bool                   `protobuf:"varint,11,opt,name=deleted,proto3" json:"deleted,omitempty"`
+	ReplyToServerMsgId string                 `protobuf:"bytes,12,opt,name=reply_to_server_msg_id,json=replyToServerMsgId,proto3" json:"reply_to_server_msg_id,omitempty"`
+	AttachmentIds      []string               `protobuf:"bytes,13,rep,name=attachment_ids,json=attachmentIds,proto3" json:"attachment_ids,omitempty"`
+	Entities           []*MessageEntity       `protobuf:"bytes,14,rep,name=entities,proto3" json:"entities,omitempty"`
+	ContentType        string                 `protobuf:"bytes,15,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	Ciphertext         []byte                 `protobuf:"bytes,16,opt,name=ciphertext,proto3" json:"ciphertext,omitempty"`
+	KeyIds             []string               `protobuf:"bytes,17,rep,name=key_ids,json=keyIds,proto3" json:"key_ids,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *MessageNewPayload) Reset() {
+	*x = MessageNewPayload{}
+	mi := &file_realtime_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MessageNewPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MessageNewPayload) ProtoMessage() {}
+
+func (x *MessageNewPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MessageNewPayload.ProtoReflect.Descriptor instead.
+func (*MessageNewPayload) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *MessageNewPayload) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+func (x *MessageNewPayload) GetClientMsgId() string {
+	if x != nil {
+		return x.ClientMsgId
+	}
+	return ""
+}
+
+func (x *MessageNewPayload) GetServerMsgId() string {
+	if x != nil {
+		return x.ServerMsgId
+	}
+	return ""
+}
+
+func (x *MessageNewPayload) GetSeq() int64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *MessageNewPayload) GetSender() string {
+	if x != nil {
+		return x.Sender
+	}
+	return ""
+}
+
+func (x *MessageNewPayload) GetSenderBotId() string {
+	if x != nil {
+		return x.SenderBotId
+	}
+	return ""
+}
+
+func (x *MessageNewPayload) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *MessageNewPayload) GetServerTs() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ServerTs
+	}
+	return nil
+}
+
+func (x *MessageNewPayload) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *MessageNewPayload) GetEditedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.EditedAt
+	}
+	return nil
+}
+
+func (x *MessageNewPayload) GetDeleted() bool {
+	if x != nil {
+		return x.Deleted
+	}
+	return false
+}
+
+func (x *MessageNewPayload) GetReplyToServerMsgId() string {
+	if x != nil {
+		return x.ReplyToServerMsgId
+	}
+	return ""
+}
+
+func (x *MessageNewPayload) GetAttachmentIds() []string {
+	if x != nil {
+		return x.AttachmentIds
+	}
+	return nil
+}
+
+func (x *MessageNewPayload) GetEntities() []*MessageEntity {
+	if x != nil {
+		return x.Entities
+	}
+	return nil
+}
+
+func (x *MessageNewPayload) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *MessageNewPayload) GetCiphertext() []byte {
+	if x != nil {
+		return x.Ciphertext
+	}
+	return nil
+}
+
+func (x *MessageNewPayload) GetKeyIds() []string {
+	if x != nil {
+		return x.KeyIds
+	}
+	return nil
+}
+
+type MessageEditPayload struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ConversationId string                 `protobuf:"bytes,1,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	ServerMsgId    string                 `protobuf:"bytes,2,opt,name=server_msg_id,json=serverMsgId,proto3" json:"server_msg_id,omitempty"`
+	Text           string                 `protobuf:"bytes,3,opt,name=text,proto3" json:"text,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *MessageEditPayload) Reset() {
+	*x = MessageEditPayload{}
+	mi := &file_realtime_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MessageEditPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MessageEditPayload) ProtoMessage() {}
+
+func (x *MessageEditPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MessageEditPayload.ProtoReflect.Descriptor instead.
+func (*MessageEditPayload) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *MessageEditPayload) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+func (x *MessageEditPayload) GetServerMsgId() string {
+	if x != nil {
+		return x.ServerMsgId
+	}
+	return ""
+}
+
+func (x *MessageEditPayload) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+type MessageDeletePayload struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ConversationId string                 `protobuf:"bytes,1,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	ServerMsgId    string                 `protobuf:"bytes,2,opt,name=server_msg_id,json=serverMsgId,proto3" json:"server_msg_id,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *MessageDeletePayload) Reset() {
+	*x = MessageDeletePayload{}
+	mi := &file_realtime_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MessageDeletePayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MessageDeletePayload) ProtoMessage() {}
+
+func (x *MessageDeletePayload) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MessageDeletePayload.ProtoReflect.Descriptor instead.
+func (*MessageDeletePayload) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *MessageDeletePayload) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+func (x *MessageDeletePayload) GetServerMsgId() string {
+	if x != nil {
+		return x.ServerMsgId
+	}
+	return ""
+}
+
+type MessageEditedPayload struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ConversationId string                 `protobuf:"bytes,1,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	ServerMsgId    string                 `protobuf:"bytes,2,opt,name=server_msg_id,json=serverMsgId,proto3" json:"server_msg_id,omitempty"`
+	Seq            int64                  `protobuf:"varint,3,opt,name=seq,proto3" json:"seq,omitempty"`
+	Text           string                 `protobuf:"bytes,4,opt,name=text,proto3" json:"text,omitempty"`
+	Version        int64                  `protobuf:"varint,5,opt,name=version,proto3" json:"version,omitempty"`
+	EditedAt       *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=edited_at,json=editedAt,proto3" json:"edited_at,omitempty"`
+	Entities       []*MessageEntity       `protobuf:"bytes,7,rep,name=entities,proto3" json:"entities,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *MessageEditedPayload) Reset() {
+	*x = MessageEditedPayload{}
+	mi := &file_realtime_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MessageEditedPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MessageEditedPayload) ProtoMessage() {}
+
+func (x *MessageEditedPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MessageEditedPayload.ProtoReflect.Descriptor instead.
+func (*MessageEditedPayload) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *MessageEditedPayload) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+func (x *MessageEditedPayload) GetServerMsgId() string {
+	if x != nil {
+		return x.ServerMsgId
+	}
+	return ""
+}
+
+func (x *MessageEditedPayload) GetSeq() int64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *MessageEditedPayload) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *MessageEditedPayload) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *MessageEditedPayload) GetEditedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.EditedAt
+	}
+	return nil
+}
+
+func (x *MessageEditedPayload) GetEntities() []*MessageEntity {
+	if x != nil {
+		return x.Entities
+	}
+	return nil
+}
+
+type MessageDeletedPayload struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ConversationId string                 `protobuf:"bytes,1,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	ServerMsgId    string                 `protobuf:"bytes,2,opt,name=server_msg_id,json=serverMsgId,proto3" json:"server_msg_id,omitempty"`
+	Seq            int64                  `protobuf:"varint,3,opt,name=seq,proto3" json:"seq,omitempty"`
+	Version        int64                  `protobuf:"varint,4,opt,name=version,proto3" json:"version,omitempty"`
+	DeletedAt      *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=deleted_at,json=deletedAt,proto3" json:"deleted_at,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *MessageDeletedPayload) Reset() {
+	*x = MessageDeletedPayload{}
+	mi := &file_realtime_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MessageDeletedPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MessageDeletedPayload) ProtoMessage() {}
+
+func (x *MessageDeletedPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MessageDeletedPayload.ProtoReflect.Descriptor instead.
+func (*MessageDeletedPayload) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *MessageDeletedPayload) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+func (x *MessageDeletedPayload) GetServerMsgId() string {
+	if x != nil {
+		return x.ServerMsgId
+	}
+	return ""
+}
+
+func (x *MessageDeletedPayload) GetSeq() int64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *MessageDeletedPayload) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *MessageDeletedPayload) GetDeletedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DeletedAt
+	}
+	return nil
+}
+
+type MessageRemovedPayload struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ConversationId string                 `protobuf:"bytes,1,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	ServerMsgId    string                 `protobuf:"bytes,2,opt,name=server_msg_id,json=serverMsgId,proto3" json:"server_msg_id,omitempty"`
+	Seq            int64                  `protobuf:"varint,3,opt,name=seq,proto3" json:"seq,omitempty"`
+	Version        int64                  `protobuf:"varint,4,opt,name=version,proto3" json:"version,omitempty"`
+	DeletedAt      *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=deleted_at,json=deletedAt,proto3" json:"deleted_at,omitempty"`
+	Reason         string                 `protobuf:"bytes,6,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *MessageRemovedPayload) Reset() {
+	*x = MessageRemovedPayload{}
+	mi := &file_realtime_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MessageRemovedPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MessageRemovedPayload) ProtoMessage() {}
+
+func (x *MessageRemovedPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MessageRemovedPayload.ProtoReflect.Descriptor instead.
+func (*MessageRemovedPayload) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *MessageRemovedPayload) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+func (x *MessageRemovedPayload) GetServerMsgId() string {
+	if x != nil {
+		return x.ServerMsgId
+	}
+	return ""
+}
+
+func (x *MessageRemovedPayload) GetSeq() int64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *MessageRemovedPayload) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *MessageRemovedPayload) GetDeletedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DeletedAt
+	}
+	return nil
+}
+
+func (x *MessageRemovedPayload) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type MessageReadPayload struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ConversationId string                 `protobuf:"bytes,1,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	UpToSeq        int64                  `protobuf:"varint,2,opt,name=up_to_seq,json=upToSeq,proto3" json:"up_to_seq,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *MessageReadPayload) Reset() {
+	*x = MessageReadPayload{}
+	mi := &file_realtime_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MessageReadPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MessageReadPayload) ProtoMessage() {}
+
+func (x *MessageReadPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MessageReadPayload.ProtoReflect.Descriptor instead.
+func (*MessageReadPayload) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *MessageReadPayload) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+func (x *MessageReadPayload) GetUpToSeq() int64 {
+	if x != nil {
+		return x.UpToSeq
+	}
+	return 0
+}
+
+type ReadUpdatePayload struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ConversationId string                 `protobuf:"bytes,1,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	UpToSeq        int64                  `protobuf:"varint,2,opt,name=up_to_seq,json=upToSeq,proto3" json:"up_to_seq,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ReadUpdatePayload) Reset() {
+	*x = ReadUpdatePayload{}
+	mi := &file_realtime_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadUpdatePayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadUpdatePayload) ProtoMessage() {}
+
+func (x *ReadUpdatePayload) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadUpdatePayload.ProtoReflect.Descriptor instead.
+func (*ReadUpdatePayload) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *ReadUpdatePayload) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+func (x *ReadUpdatePayload) GetUpToSeq() int64 {
+	if x != nil {
+		return x.UpToSeq
+	}
+	return 0
+}
+
+type MessageDeliveredPayload struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ConversationId string                 `protobuf:"bytes,1,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	UpToSeq        int64                  `protobuf:"varint,2,opt,name=up_to_seq,json=upToSeq,proto3" json:"up_to_seq,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *MessageDeliveredPayload) Reset() {
+	*x = MessageDeliveredPayload{}
+	mi := &file_realtime_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MessageDeliveredPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MessageDeliveredPayload) ProtoMessage() {}
+
+func (x *MessageDeliveredPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MessageDeliveredPayload.ProtoReflect.Descriptor instead.
+func (*MessageDeliveredPayload) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *MessageDeliveredPayload) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+func (x *MessageDeliveredPayload) GetUpToSeq() int64 {
+	if x != nil {
+		return x.UpToSeq
+	}
+	return 0
+}
+
+type ReadStatePayload struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ConversationId string                 `protobuf:"bytes,1,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	UserId         string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	LastReadSeq    int64                  `protobuf:"varint,3,opt,name=last_read_seq,json=lastReadSeq,proto3" json:"last_read_seq,omitempty"`
+	LastSeq        int64                  `protobuf:"varint,4,opt,name=last_seq,json=lastSeq,proto3" json:"last_seq,omitempty"`
+	UnreadCount    int64                  `protobuf:"varint,5,opt,name=unread_count,json=unreadCount,proto3" json:"unread_count,omitempty"`
+	UpdatedAt      *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ReadStatePayload) Reset() {
+	*x = ReadStatePayload{}
+	mi := &file_realtime_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadStatePayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadStatePayload) ProtoMessage() {}
+
+func (x *ReadStatePayload) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadStatePayload.ProtoReflect.Descriptor instead.
+func (*ReadStatePayload) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *ReadStatePayload) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+func (x *ReadStatePayload) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *ReadStatePayload) GetLastReadSeq() int64 {
+	if x != nil {
+		return x.LastReadSeq
+	}
+	return 0
+}
+
+func (x *ReadStatePayload) GetLastSeq() int64 {
+	if x != nil {
+		return x.LastSeq
+	}
+	return 0
+}
+
+func (x *ReadStatePayload) GetUnreadCount() int64 {
+	if x != nil {
+		return x.UnreadCount
+	}
+	return 0
+}
+
+func (x *ReadStatePayload) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type SystemNewPayload struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ConversationId string                 `protobuf:"bytes,1,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	SystemMsgId    string                 `protobuf:"bytes,2,opt,name=system_msg_id,json=systemMsgId,proto3" json:"system_msg_id,omitempty"`
+	Seq            int64                  `protobuf:"varint,3,opt,name=seq,proto3" json:"seq,omitempty"`
+	Text           string                 `protobuf:"bytes,4,opt,name=text,proto3" json:"text,omitempty"`
+	ServerTs       *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=server_ts,json=serverTs,proto3" json:"server_ts,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *SystemNewPayload) Reset() {
+	*x = SystemNewPayload{}
+	mi := &file_realtime_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SystemNewPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SystemNewPayload) ProtoMessage() {}
+
+func (x *SystemNewPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SystemNewPayload.ProtoReflect.Descriptor instead.
+func (*SystemNewPayload) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *SystemNewPayload) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+func (x *SystemNewPayload) GetSystemMsgId() string {
+	if x != nil {
+		return x.SystemMsgId
+	}
+	return ""
+}
+
+func (x *SystemNewPayload) GetSeq() int64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *SystemNewPayload) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *SystemNewPayload) GetServerTs() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ServerTs
+	}
+	return nil
+}
+
+type ConversationHistoryFetchPayload struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ConversationId string                 `protobuf:"bytes,1,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	AfterSeq       *int64                 `protobuf:"varint,2,opt,name=after_seq,json=afterSeq,proto3,oneof" json:"after_seq,omitempty"`
+	BeforeSeq      *int64                 `protobuf:"varint,3,opt,name=before_seq,json=beforeSeq,proto3,oneof" json:"before_seq,omitempty"`
+	Limit          int32                  `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ConversationHistoryFetchPayload) Reset() {
+	*x = ConversationHistoryFetchPayload{}
+	mi := &file_realtime_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConversationHistoryFetchPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConversationHistoryFetchPayload) ProtoMessage() {}
+
+func (x *ConversationHistoryFetchPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConversationHistoryFetchPayload.ProtoReflect.Descriptor instead.
+func (*ConversationHistoryFetchPayload) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *ConversationHistoryFetchPayload) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+func (x *ConversationHistoryFetchPayload) GetAfterSeq() int64 {
+	if x != nil && x.AfterSeq != nil {
+		return *x.AfterSeq
+	}
+	return 0
+}
+
+func (x *ConversationHistoryFetchPayload) GetBeforeSeq() int64 {
+	if x != nil && x.BeforeSeq != nil {
+		return *x.BeforeSeq
+	}
+	return 0
+}
+
+func (x *ConversationHistoryFetchPayload) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type ConversationHistoryChunkPayload struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ConversationId string                 `protobuf:"bytes,1,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	Messages       []*MessageNewPayload   `protobuf:"bytes,2,rep,name=messages,proto3" json:"messages,omitempty"`
+	HasMore        bool                   `protobuf:"varint,3,opt,name=has_more,json=hasMore,proto3" json:"has_more,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ConversationHistoryChunkPayload) Reset() {
+	*x = ConversationHistoryChunkPayload{}
+	mi := &file_realtime_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConversationHistoryChunkPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConversationHistoryChunkPayload) ProtoMessage() {}
+
+func (x *ConversationHistoryChunkPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConversationHistoryChunkPayload.ProtoReflect.Descriptor instead.
+func (*ConversationHistoryChunkPayload) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *ConversationHistoryChunkPayload) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+func (x *ConversationHistoryChunkPayload) GetMessages() []*MessageNewPayload {
+	if x != nil {
+		return x.Messages
+	}
+	return nil
+}
+
+func (x *ConversationHistoryChunkPayload) GetHasMore() bool {
+	if x != nil {
+		return x.HasMore
+	}
+	return false
+}
+
+type MemberAddedPayload struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ConversationId string                 `protobuf:"bytes,1,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	UserId         string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Role           string                 `protobuf:"bytes,3,opt,name=role,proto3" json:"role,omitempty"`
+	AddedBy        string                 `protobuf:"bytes,4,opt,name=added_by,json=addedBy,proto3" json:"added_by,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *MemberAddedPayload) Reset() {
+	*x = MemberAddedPayload{}
+	mi := &file_realtime_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MemberAddedPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MemberAddedPayload) ProtoMessage() {}
+
+func (x *MemberAddedPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MemberAddedPayload.ProtoReflect.Descriptor instead.
+func (*MemberAddedPayload) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *MemberAddedPayload) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+func (x *MemberAddedPayload) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *MemberAddedPayload) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+func (x *MemberAddedPayload) GetAddedBy() string {
+	if x != nil {
+		return x.AddedBy
+	}
+	return ""
+}
+
+type MemberRemovedPayload struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ConversationId string                 `protobuf:"bytes,1,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	UserId         string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	RemovedBy      string                 `protobuf:"bytes,3,opt,name=removed_by,json=removedBy,proto3" json:"removed_by,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *MemberRemovedPayload) Reset() {
+	*x = MemberRemovedPayload{}
+	mi := &file_realtime_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MemberRemovedPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MemberRemovedPayload) ProtoMessage() {}
+
+func (x *MemberRemovedPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MemberRemovedPayload.ProtoReflect.Descriptor instead.
+func (*MemberRemovedPayload) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *MemberRemovedPayload) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+func (x *MemberRemovedPayload) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *MemberRemovedPayload) GetRemovedBy() string {
+	if x != nil {
+		return x.RemovedBy
+	}
+	return ""
+}
+
+type InboxSubscribePayload struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Conversations int32                  `protobuf:"varint,1,opt,name=conversations,proto3" json:"conversations,omitempty"`
+	Truncated     bool                   `protobuf:"varint,2,opt,name=truncated,proto3" json:"truncated,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InboxSubscribePayload) Reset() {
+	*x = InboxSubscribePayload{}
+	mi := &file_realtime_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InboxSubscribePayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InboxSubscribePayload) ProtoMessage() {}
+
+func (x *InboxSubscribePayload) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InboxSubscribePayload.ProtoReflect.Descriptor instead.
+func (*InboxSubscribePayload) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *InboxSubscribePayload) GetConversations() int32 {
+	if x != nil {
+		return x.Conversations
+	}
+	return 0
+}
+
+func (x *InboxSubscribePayload) GetTruncated() bool {
+	if x != nil {
+		return x.Truncated
+	}
+	return false
+}
+
+type InboxMessagePayload struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	ConversationId  string                 `protobuf:"bytes,1,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	ServerMsgId     string                 `protobuf:"bytes,2,opt,name=server_msg_id,json=serverMsgId,proto3" json:"server_msg_id,omitempty"`
+	Seq             int64                  `protobuf:"varint,3,opt,name=seq,proto3" json:"seq,omitempty"`
+	Sender          string                 `protobuf:"bytes,4,opt,name=sender,proto3" json:"sender,omitempty"`
+	SenderBotId     string                 `protobuf:"bytes,5,opt,name=sender_bot_id,json=senderBotId,proto3" json:"sender_bot_id,omitempty"`
+	Preview         string                 `protobuf:"bytes,6,opt,name=preview,proto3" json:"preview,omitempty"`
+	ContentType     string                 `protobuf:"bytes,7,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	AttachmentCount int32                  `protobuf:"varint,8,opt,name=attachment_count,json=attachmentCount,proto3" json:"attachment_count,omitempty"`
+	ServerTs        *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=server_ts,json=serverTs,proto3" json:"server_ts,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *InboxMessagePayload) Reset() {
+	*x = InboxMessagePayload{}
+	mi := &file_realtime_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InboxMessagePayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InboxMessagePayload) ProtoMessage() {}
+
+func (x *InboxMessagePayload) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InboxMessagePayload.ProtoReflect.Descriptor instead.
+func (*InboxMessagePayload) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *InboxMessagePayload) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+func (x *InboxMessagePayload) GetServerMsgId() string {
+	if x != nil {
+		return x.ServerMsgId
+	}
+	return ""
+}
+
+func (x *InboxMessagePayload) GetSeq() int64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *InboxMessagePayload) GetSender() string {
+	if x != nil {
+		return x.Sender
+	}
+	return ""
+}
+
+func (x *InboxMessagePayload) GetSenderBotId() string {
+	if x != nil {
+		return x.SenderBotId
+	}
+	return ""
+}
+
+func (x *InboxMessagePayload) GetPreview() string {
+	if x != nil {
+		return x.Preview
+	}
+	return ""
+}
+
+func (x *InboxMessagePayload) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *InboxMessagePayload) GetAttachmentCount() int32 {
+	if x != nil {
+		return x.AttachmentCount
+	}
+	return 0
+}
+
+func (x *InboxMessagePayload) GetServerTs() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ServerTs
+	}
+	return nil
+}
+
+type FirehoseSubscribePayload struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ResumeToken   string                 `protobuf:"bytes,1,opt,name=resume_token,json=resumeToken,proto3" json:"resume_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FirehoseSubscribePayload) Reset() {
+	*x = FirehoseSubscribePayload{}
+	mi := &file_realtime_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FirehoseSubscribePayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FirehoseSubscribePayload) ProtoMessage() {}
+
+func (x *FirehoseSubscribePayload) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FirehoseSubscribePayload.ProtoReflect.Descriptor instead.
+func (*FirehoseSubscribePayload) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *FirehoseSubscribePayload) GetResumeToken() string {
+	if x != nil {
+		return x.ResumeToken
+	}
+	return ""
+}
+
+type FirehoseCheckpointPayload struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ResumeToken   string                 `protobuf:"bytes,1,opt,name=resume_token,json=resumeToken,proto3" json:"resume_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FirehoseCheckpointPayload) Reset() {
+	*x = FirehoseCheckpointPayload{}
+	mi := &file_realtime_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FirehoseCheckpointPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FirehoseCheckpointPayload) ProtoMessage() {}
+
+func (x *FirehoseCheckpointPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FirehoseCheckpointPayload.ProtoReflect.Descriptor instead.
+func (*FirehoseCheckpointPayload) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *FirehoseCheckpointPayload) GetResumeToken() string {
+	if x != nil {
+		return x.ResumeToken
+	}
+	return ""
+}
+
+type PresenceSubscribePayload struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserIds       []string               `protobuf:"bytes,1,rep,name=user_ids,json=userIds,proto3" json:"user_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PresenceSubscribePayload) Reset() {
+	*x = PresenceSubscribePayload{}
+	mi := &file_realtime_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PresenceSubscribePayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PresenceSubscribePayload) ProtoMessage() {}
+
+func (x *PresenceSubscribePayload) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PresenceSubscribePayload.ProtoReflect.Descriptor instead.
+func (*PresenceSubscribePayload) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *PresenceSubscribePayload) GetUserIds() []string {
+	if x != nil {
+		return x.UserIds
+	}
+	return nil
+}
+
+type PresenceUpdatePayload struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	LastSeenAt    *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=last_seen_at,json=lastSeenAt,proto3" json:"last_seen_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PresenceUpdatePayload) Reset() {
+	*x = PresenceUpdatePayload{}
+	mi := &file_realtime_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PresenceUpdatePayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PresenceUpdatePayload) ProtoMessage() {}
+
+func (x *PresenceUpdatePayload) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PresenceUpdatePayload.ProtoReflect.Descriptor instead.
+func (*PresenceUpdatePayload) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *PresenceUpdatePayload) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *PresenceUpdatePayload) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *PresenceUpdatePayload) GetLastSeenAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastSeenAt
+	}
+	return nil
+}
+
+type ErrorPayload struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          string                 `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	Reason        string                 `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	MaxChars      int32                  `protobuf:"varint,4,opt,name=max_chars,json=maxChars,proto3" json:"max_chars,omitempty"`
+	ActualChars   int32                  `protobuf:"varint,5,opt,name=actual_chars,json=actualChars,proto3" json:"actual_chars,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ErrorPayload) Reset() {
+	*x = ErrorPayload{}
+	mi := &file_realtime_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ErrorPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ErrorPayload) ProtoMessage() {}
+
+func (x *ErrorPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ErrorPayload.ProtoReflect.Descriptor instead.
+func (*ErrorPayload) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *ErrorPayload) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *ErrorPayload) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *ErrorPayload) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *ErrorPayload) GetMaxChars() int32 {
+	if x != nil {
+		return x.MaxChars
+	}
+	return 0
+}
+
+func (x *ErrorPayload) GetActualChars() int32 {
+	if x != nil {
+		return x.ActualChars
+	}
+	return 0
+}
+
+type ResumePayload struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Conversations []*ResumeConversation  `protobuf:"bytes,1,rep,name=conversations,proto3" json:"conversations,omitempty"`
+	ResumeToken   string                 `protobuf:"bytes,2,opt,name=resume_token,json=resumeToken,proto3" json:"resume_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResumePayload) Reset() {
+	*x = ResumePayload{}
+	mi := &file_realtime_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResumePayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResumePayload) ProtoMessage() {}
+
+func (x *ResumePayload) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResumePayload.ProtoReflect.Descriptor instead.
+func (*ResumePayload) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *ResumePayload) GetConversations() []*ResumeConversation {
+	if x != nil {
+		return x.Conversations
+	}
+	return nil
+}
+
+func (x *ResumePayload) GetResumeToken() string {
+	if x != nil {
+		return x.ResumeToken
+	}
+	return ""
+}
+
+type ResumeConversation struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ConversationId string                 `protobuf:"bytes,1,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	LastSeq        int64                  `protobuf:"varint,2,opt,name=last_seq,json=lastSeq,proto3" json:"last_seq,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ResumeConversation) Reset() {
+	*x = ResumeConversation{}
+	mi := &file_realtime_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResumeConversation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResumeConversation) ProtoMessage() {}
+
+func (x *ResumeConversation) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResumeConversation.ProtoReflect.Descriptor instead.
+func (*ResumeConversation) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *ResumeConversation) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+func (x *ResumeConversation) GetLastSeq() int64 {
+	if x != nil {
+		return x.LastSeq
+	}
+	return 0
+}
+
+type ResumeOKPayload struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ConversationId string                 `protobuf:"bytes,1,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	Replayed       int32                  `protobuf:"varint,2,opt,name=replayed,proto3" json:"replayed,omitempty"`
+	LastSeq        int64                  `protobuf:"varint,3,opt,name=last_seq,json=lastSeq,proto3" json:"last_seq,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ResumeOKPayload) Reset() {
+	*x = ResumeOKPayload{}
+	mi := &file_realtime_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResumeOKPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResumeOKPayload) ProtoMessage() {}
+
+func (x *ResumeOKPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResumeOKPayload.ProtoReflect.Descriptor instead.
+func (*ResumeOKPayload) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *ResumeOKPayload) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+func (x *ResumeOKPayload) GetReplayed() int32 {
+	if x != nil {
+		return x.Replayed
+	}
+	return 0
+}
+
+func (x *ResumeOKPayload) GetLastSeq() int64 {
+	if x != nil {
+		return x.LastSeq
+	}
+	return 0
+}
+
+type ResumeFailedPayload struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ConversationId string                 `protobuf:"bytes,1,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	Reason         string                 `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	Message        string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ResumeFailedPayload) Reset() {
+	*x = ResumeFailedPayload{}
+	mi := &file_realtime_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResumeFailedPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResumeFailedPayload) ProtoMessage() {}
+
+func (x *ResumeFailedPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResumeFailedPayload.ProtoReflect.Descriptor instead.
+func (*ResumeFailedPayload) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *ResumeFailedPayload) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+func (x *ResumeFailedPayload) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *ResumeFailedPayload) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type ServerShutdownPayload struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RetryAfterMs  int64                  `protobuf:"varint,1,opt,name=retry_after_ms,json=retryAfterMs,proto3" json:"retry_after_ms,omitempty"`
+	DeadlineMs    int64                  `protobuf:"varint,2,opt,name=deadline_ms,json=deadlineMs,proto3" json:"deadline_ms,omitempty"`
+	ResumeToken   string                 `protobuf:"bytes,3,opt,name=resume_token,json=resumeToken,proto3" json:"resume_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ServerShutdownPayload) Reset() {
+	*x = ServerShutdownPayload{}
+	mi := &file_realtime_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ServerShutdownPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServerShutdownPayload) ProtoMessage() {}
+
+func (x *ServerShutdownPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ServerShutdownPayload.ProtoReflect.Descriptor instead.
+func (*ServerShutdownPayload) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *ServerShutdownPayload) GetRetryAfterMs() int64 {
+	if x != nil {
+		return x.RetryAfterMs
+	}
+	return 0
+}
+
+func (x *ServerShutdownPayload) GetDeadlineMs() int64 {
+	if x != nil {
+		return x.DeadlineMs
+	}
+	return 0
+}
+
+func (x *ServerShutdownPayload) GetResumeToken() string {
+	if x != nil {
+		return x.ResumeToken
+	}
+	return ""
+}
+
+type TimeSyncPayload struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ClientTs      *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=client_ts,json=clientTs,proto3" json:"client_ts,omitempty"`
+	ServerTs      *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=server_ts,json=serverTs,proto3" json:"server_ts,omitempty"`
+	ClockSkewMs   *int64                 `protobuf:"varint,3,opt,name=clock_skew_ms,json=clockSkewMs,proto3,oneof" json:"clock_skew_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TimeSyncPayload) Reset() {
+	*x = TimeSyncPayload{}
+	mi := &file_realtime_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TimeSyncPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TimeSyncPayload) ProtoMessage() {}
+
+func (x *TimeSyncPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TimeSyncPayload.ProtoReflect.Descriptor instead.
+func (*TimeSyncPayload) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *TimeSyncPayload) GetClientTs() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ClientTs
+	}
+	return nil
+}
+
+func (x *TimeSyncPayload) GetServerTs() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ServerTs
+	}
+	return nil
+}
+
+func (x *TimeSyncPayload) GetClockSkewMs() int64 {
+	if x != nil && x.ClockSkewMs != nil {
+		return *x.ClockSkewMs
+	}
+	return 0
+}
+
+var File_realtime_proto protoreflect.FileDescriptor
+
+const file_realtime_proto_rawDesc = "" +
+	"\n" +
+	"\x0erealtime.proto\x12\x0farc.realtime.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xc6\x15\n" +
+	"\bEnvelope\x12\f\n" +
+	"\x01v\x18\x01 \x01(\x05R\x01v\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x0e\n" +
+	"\x02id\x18\x03 \x01(\tR\x02id\x12\x17\n" +
+	"\aconv_id\x18\x04 \x01(\tR\x06convId\x12*\n" +
+	"\x02ts\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\x02ts\x12\x10\n" +
+	"\x03seq\x18\a \x01(\x03R\x03seq\x125\n" +
+	"\x05hello\x18\n" +
+	" \x01(\v2\x1d.arc.realtime.v1.HelloPayloadH\x00R\x05hello\x12?\n" +
+	"\thello_ack\x18\v \x01(\v2 .arc.realtime.v1.HelloAckPayloadH\x00R\bhelloAck\x12W\n" +
+	"\x11conversation_join\x18\f \x01(\v2(.arc.realtime.v1.ConversationJoinPayloadH\x00R\x10conversationJoin\x12Z\n" +
+	"\x12conversation_leave\x18\r \x01(\v2).arc.realtime.v1.ConversationLeavePayloadH\x00R\x11conversationLeave\x12H\n" +
+	"\fmessage_send\x18\x0e \x01(\v2#.arc.realtime.v1.MessageSendPayloadH\x00R\vmessageSend\x12E\n" +
+	"\vmessage_ack\x18\x0f \x01(\v2\".arc.realtime.v1.MessageAckPayloadH\x00R\n" +
+	"messageAck\x12E\n" +
+	"\vmessage_new\x18\x10 \x01(\v2\".arc.realtime.v1.MessageNewPayloadH\x00R\n" +
+	"messageNew\x12H\n" +
+	"\fmessage_edit\x18\x11 \x01(\v2#.arc.realtime.v1.MessageEditPayloadH\x00R\vmessageEdit\x12N\n" +
+	"\x0emessage_delete\x18\x12 \x01(\v2%.arc.realtime.v1.MessageDeletePayloadH\x00R\rmessageDelete\x12N\n" +
+	"\x0emessage_edited\x18\x13 \x01(\v2%.arc.realtime.v1.MessageEditedPayloadH\x00R\rmessageEdited\x12Q\n" +
+	"\x0fmessage_deleted\x18\x14 \x01(\v2&.arc.realtime.v1.MessageDeletedPayloadH\x00R\x0emessageDeleted\x12Q\n" +
+	"\x0fmessage_removed\x18\x15 \x01(\v2&.arc.realtime.v1.MessageRemovedPayloadH\x00R\x0emessageRemoved\x12H\n" +
+	"\fmessage_read\x18\x16 \x01(\v2#.arc.realtime.v1.MessageReadPayloadH\x00R\vmessageRead\x12E\n" +
+	"\vread_update\x18\x17 \x01(\v2\".arc.realtime.v1.ReadUpdatePayloadH\x00R\n" +
+	"readUpdate\x12B\n" +
+	"\n" +
+	"read_state\x18\x18 \x01(\v2!.arc.realtime.v1.ReadStatePayloadH\x00R\treadState\x12W\n" +
+	"\x11message_delivered\x18\x19 \x01(\v2(.arc.realtime.v1.MessageDeliveredPayloadH\x00R\x10messageDelivered\x12B\n" +
+	"\n" +
+	"system_new\x18\x1a \x01(\v2!.arc.realtime.v1.SystemNewPayloadH\x00R\tsystemNew\x12p\n" +
+	"\x1aconversation_history_fetch\x18\x1b \x01(\v20.arc.realtime.v1.ConversationHistoryFetchPayloadH\x00R\x18conversationHistoryFetch\x12p\n" +
+	"\x1aconversation_history_chunk\x18\x1c \x01(\v20.arc.realtime.v1.ConversationHistoryChunkPayloadH\x00R\x18conversationHistoryChunk\x12H\n" +
+	"\fmember_added\x18\x1d \x01(\v2#.arc.realtime.v1.MemberAddedPayloadH\x00R\vmemberAdded\x12N\n" +
+	"\x0emember_removed\x18\x1e \x01(\v2%.arc.realtime.v1.MemberRemovedPayloadH\x00R\rmemberRemoved\x12Z\n" +
+	"\x12presence_subscribe\x18\x1f \x01(\v2).arc.realtime.v1.PresenceSubscribePayloadH\x00R\x11presenceSubscribe\x12Q\n" +
+	"\x0fpresence_update\x18  \x01(\v2&.arc.realtime.v1.PresenceUpdatePayloadH\x00R\x0epresenceUpdate\x12Q\n" +
+	"\x0finbox_subscribe\x18! \x01(\v2&.arc.realtime.v1.InboxSubscribePayloadH\x00R\x0einboxSubscribe\x12K\n" +
+	"\rinbox_message\x18\" \x01(\v2$.arc.realtime.v1.InboxMessagePayloadH\x00R\finboxMessage\x12Z\n" +
+	"\x12firehose_subscribe\x18# \x01(\v2).arc.realtime.v1.FirehoseSubscribePayloadH\x00R\x11firehoseSubscribe\x12]\n" +
+	"\x13firehose_checkpoint\x18$ \x01(\v2*.arc.realtime.v1.FirehoseCheckpointPayloadH\x00R\x12firehoseCheckpoint\x128\n" +
+	"\x06resume\x18% \x01(\v2\x1e.arc.realtime.v1.ResumePayloadH\x00R\x06resume\x12?\n" +
+	"\tresume_ok\x18& \x01(\v2 .arc.realtime.v1.ResumeOKPayloadH\x00R\bresumeOk\x12K\n" +
+	"\rresume_failed\x18' \x01(\v2$.arc.realtime.v1.ResumeFailedPayloadH\x00R\fresumeFailed\x12Q\n" +
+	"\x0fserver_shutdown\x18( \x01(\v2&.arc.realtime.v1.ServerShutdownPayloadH\x00R\x0eserverShutdown\x12?\n" +
+	"\ttime_sync\x18) \x01(\v2 .arc.realtime.v1.TimeSyncPayloadH\x00R\btimeSync\x125\n" +
+	"\x05error\x18* \x01(\v2\x1d.arc.realtime.v1.ErrorPayloadH\x00R\x05errorB\t\n" +
+	"\apayloadJ\x04\b\x06\x10\a\"\xd6\x01\n" +
+	"\fHelloPayload\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\x12!\n" +
+	"\fresume_token\x18\x02 \x01(\tR\vresumeToken\x12&\n" +
+	"\x0fmax_frame_bytes\x18\x03 \x01(\x05R\rmaxFrameBytes\x12%\n" +
+	"\x0eclient_version\x18\x04 \x01(\tR\rclientVersion\x12\x1a\n" +
+	"\bplatform\x18\x05 \x01(\tR\bplatform\x12\"\n" +
+	"\fcapabilities\x18\x06 \x01(\x04R\fcapabilities\"\xfd\x02\n" +
+	"\x0fHelloAckPayload\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12!\n" +
+	"\fresume_token\x18\x02 \x01(\tR\vresumeToken\x12Q\n" +
+	"\x17resume_token_expires_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x14resumeTokenExpiresAt\x12*\n" +
+	"\x11max_message_chars\x18\x04 \x01(\x05R\x0fmaxMessageChars\x12&\n" +
+	"\x0fmax_frame_bytes\x18\x05 \x01(\x05R\rmaxFrameBytes\x122\n" +
+	"\x15heartbeat_interval_ms\x18\x06 \x01(\x03R\x13heartbeatIntervalMs\x12)\n" +
+	"\x10protocol_version\x18\a \x01(\x05R\x0fprotocolVersion\x12\"\n" +
+	"\fcapabilities\x18\b \x01(\x04R\fcapabilities\"V\n" +
+	"\x17ConversationJoinPayload\x12'\n" +
+	"\x0fconversation_id\x18\x01 \x01(\tR\x0econversationId\x12\x12\n" +
+	"\x04kind\x18\x02 \x01(\tR\x04kind\"C\n" +
+	"\x18ConversationLeavePayload\x12'\n" +
+	"\x0fconversation_id\x18\x01 \x01(\tR\x0econversationId\"\xac\x02\n" +
+	"\x12MessageSendPayload\x12'\n" +
+	"\x0fconversation_id\x18\x01 \x01(\tR\x0econversationId\x12\"\n" +
+	"\rclient_msg_id\x18\x02 \x01(\tR\vclientMsgId\x12\x12\n" +
+	"\x04text\x18\x03 \x01(\tR\x04text\x122\n" +
+	"\x16reply_to_server_msg_id\x18\x04 \x01(\tR\x12replyToServerMsgId\x12%\n" +
+	"\x0eattachment_ids\x18\x05 \x03(\tR\rattachmentIds\x12!\n" +
+	"\fcontent_type\x18\x06 \x01(\tR\vcontentType\x12\x1e\n" +
+	"\n" +
+	"ciphertext\x18\a \x01(\fR\n" +
+	"ciphertext\x12\x17\n" +
+	"\akey_ids\x18\b \x03(\tR\x06keyIds\"~\n" +
+	"\rMessageEntity\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x05R\x06offset\x12\x16\n" +
+	"\x06length\x18\x03 \x01(\x05R\x06length\x12\x17\n" +
+	"\auser_id\x18\x04 \x01(\tR\x06userId\x12\x10\n" +
+	"\x03url\x18\x05 \x01(\tR\x03url\"\xd1\x01\n" +
+	"\x11MessageAckPayload\x12'\n" +
+	"\x0fconversation_id\x18\x01 \x01(\tR\x0econversationId\x12\"\n" +
+	"\rclient_msg_id\x18\x02 \x01(\tR\vclientMsgId\x12\"\n" +
+	"\rserver_msg_id\x18\x03 \x01(\tR\vserverMsgId\x12\x10\n" +
+	"\x03seq\x18\x04 \x01(\x03R\x03seq\x12'\n" +
+	"\rclock_skew_ms\x18\x05 \x01(\x03H\x00R\vclockSkewMs\x88\x01\x01B\x10\n" +
+	"\x0e_clock_skew_ms\"\xff\x04\n" +
+	"\x11MessageNewPayload\x12'\n" +
+	"\x0fconversation_id\x18\x01 \x01(\tR\x0econversationId\x12\"\n" +
+	"\rclient_msg_id\x18\x02 \x01(\tR\vclientMsgId\x12\"\n" +
+	"\rserver_msg_id\x18\x03 \x01(\tR\vserverMsgId\x12\x10\n" +
+	"\x03seq\x18\x04 \x01(\x03R\x03seq\x12\x16\n" +
+	"\x06sender\x18\x05 \x01(\tR\x06sender\x12\"\n" +
+	"\rsender_bot_id\x18\x06 \x01(\tR\vsenderBotId\x12\x12\n" +
+	"\x04text\x18\a \x01(\tR\x04text\x127\n" +
+	"\tserver_ts\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\bserverTs\x12\x18\n" +
+	"\aversion\x18\t \x01(\x03R\aversion\x127\n" +
+	"\tedited_at\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\beditedAt\x12\x18\n" +
+	"\adeleted\x18\v \x01(\bR\adeleted\x122\n" +
+	"\x16reply_to_server_msg_id\x18\f \x01(\tR\x12replyToServerMsgId\x12%\n" +
+	"\x0eattachment_ids\x18\r \x03(\tR\rattachmentIds\x12:\n" +
+	"\bentities\x18\x0e \x03(\v2\x1e.arc.realtime.v1.MessageEntityR\bentities\x12!\n" +
+	"\fcontent_type\x18\x0f \x01(\tR\vcontentType\x12\x1e\n" +
+	"\n" +
+	"ciphertext\x18\x10 \x01(\fR\n" +
+	"ciphertext\x12\x17\n" +
+	"\akey_ids\x18\x11 \x03(\tR\x06keyIds\"u\n" +
+	"\x12MessageEditPayload\x12'\n" +
+	"\x0fconversation_id\x18\x01 \x01(\tR\x0econversationId\x12\"\n" +
+	"\rserver_msg_id\x18\x02 \x01(\tR\vserverMsgId\x12\x12\n" +
+	"\x04text\x18\x03 \x01(\tR\x04text\"c\n" +
+	"\x14MessageDeletePayload\x12'\n" +
+	"\x0fconversation_id\x18\x01 \x01(\tR\x0econversationId\x12\"\n" +
+	"\rserver_msg_id\x18\x02 \x01(\tR\vserverMsgId\"\x98\x02\n" +
+	"\x14MessageEditedPayload\x12'\n" +
+	"\x0fconversation_id\x18\x01 \x01(\tR\x0econversationId\x12\"\n" +
+	"\rserver_msg_id\x18\x02 \x01(\tR\vserverMsgId\x12\x10\n" +
+	"\x03seq\x18\x03 \x01(\x03R\x03seq\x12\x12\n" +
+	"\x04text\x18\x04 \x01(\tR\x04text\x12\x18\n" +
+	"\aversion\x18\x05 \x01(\x03R\aversion\x127\n" +
+	"\tedited_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\beditedAt\x12:\n" +
+	"\bentities\x18\a \x03(\v2\x1e.arc.realtime.v1.MessageEntityR\bentities\"\xcb\x01\n" +
+	"\x15MessageDeletedPayload\x12'\n" +
+	"\x0fconversation_id\x18\x01 \x01(\tR\x0econversationId\x12\"\n" +
+	"\rserver_msg_id\x18\x02 \x01(\tR\vserverMsgId\x12\x10\n" +
+	"\x03seq\x18\x03 \x01(\x03R\x03seq\x12\x18\n" +
+	"\aversion\x18\x04 \x01(\x03R\aversion\x129\n" +
+	"\n" +
+	"deleted_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tdeletedAt\"\xe3\x01\n" +
+	"\x15MessageRemovedPayload\x12'\n" +
+	"\x0fconversation_id\x18\x01 \x01(\tR\x0econversationId\x12\"\n" +
+	"\rserver_msg_id\x18\x02 \x01(\tR\vserverMsgId\x12\x10\n" +
+	"\x03seq\x18\x03 \x01(\x03R\x03seq\x12\x18\n" +
+	"\aversion\x18\x04 \x01(\x03R\aversion\x129\n" +
+	"\n" +
+	"deleted_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tdeletedAt\x12\x16\n" +
+	"\x06reason\x18\x06 \x01(\tR\x06reason\"Y\n" +
+	"\x12MessageReadPayload\x12'\n" +
+	"\x0fconversation_id\x18\x01 \x01(\tR\x0econversationId\x12\x1a\n" +
+	"\tup_to_seq\x18\x02 \x01(\x03R\aupToSeq\"X\n" +
+	"\x11ReadUpdatePayload\x12'\n" +
+	"\x0fconversation_id\x18\x01 \x01(\tR\x0econversationId\x12\x1a\n" +
+	"\tup_to_seq\x18\x02 \x01(\x03R\aupToSeq\"^\n" +
+	"\x17MessageDeliveredPayload\x12'\n" +
+	"\x0fconversation_id\x18\x01 \x01(\tR\x0econversationId\x12\x1a\n" +
+	"\tup_to_seq\x18\x02 \x01(\x03R\aupToSeq\"\xf1\x01\n" +
+	"\x10ReadStatePayload\x12'\n" +
+	"\x0fconversation_id\x18\x01 \x01(\tR\x0econversationId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\"\n" +
+	"\rlast_read_seq\x18\x03 \x01(\x03R\vlastReadSeq\x12\x19\n" +
+	"\blast_seq\x18\x04 \x01(\x03R\alastSeq\x12!\n" +
+	"\funread_count\x18\x05 \x01(\x03R\vunreadCount\x129\n" +
+	"\n" +
+	"updated_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"\xbe\x01\n" +
+	"\x10SystemNewPayload\x12'\n" +
+	"\x0fconversation_id\x18\x01 \x01(\tR\x0econversationId\x12\"\n" +
+	"\rsystem_msg_id\x18\x02 \x01(\tR\vsystemMsgId\x12\x10\n" +
+	"\x03seq\x18\x03 \x01(\x03R\x03seq\x12\x12\n" +
+	"\x04text\x18\x04 \x01(\tR\x04text\x127\n" +
+	"\tserver_ts\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\bserverTs\"\xc3\x01\n" +
+	"\x1fConversationHistoryFetchPayload\x12'\n" +
+	"\x0fconversation_id\x18\x01 \x01(\tR\x0econversationId\x12 \n" +
+	"\tafter_seq\x18\x02 \x01(\x03H\x00R\bafterSeq\x88\x01\x01\x12\"\n" +
+	"\n" +
+	"before_seq\x18\x03 \x01(\x03H\x01R\tbeforeSeq\x88\x01\x01\x12\x14\n" +
+	"\x05limit\x18\x04 \x01(\x05R\x05limitB\f\n" +
+	"\n" +
+	"_after_seqB\r\n" +
+	"\v_before_seq\"\xa5\x01\n" +
+	"\x1fConversationHistoryChunkPayload\x12'\n" +
+	"\x0fconversation_id\x18\x01 \x01(\tR\x0econversationId\x12>\n" +
+	"\bmessages\x18\x02 \x03(\v2\".arc.realtime.v1.MessageNewPayloadR\bmessages\x12\x19\n" +
+	"\bhas_more\x18\x03 \x01(\bR\ahasMore\"\x85\x01\n" +
+	"\x12MemberAddedPayload\x12'\n" +
+	"\x0fconversation_id\x18\x01 \x01(\tR\x0econversationId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x12\n" +
+	"\x04role\x18\x03 \x01(\tR\x04role\x12\x19\n" +
+	"\badded_by\x18\x04 \x01(\tR\aaddedBy\"w\n" +
+	"\x14MemberRemovedPayload\x12'\n" +
+	"\x0fconversation_id\x18\x01 \x01(\tR\x0econversationId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x1d\n" +
+	"\n" +
+	"removed_by\x18\x03 \x01(\tR\tremovedBy\"[\n" +
+	"\x15InboxSubscribePayload\x12$\n" +
+	"\rconversations\x18\x01 \x01(\x05R\rconversations\x12\x1c\n" +
+	"\ttruncated\x18\x02 \x01(\bR\ttruncated\"\xd1\x02\n" +
+	"\x13InboxMessagePayload\x12'\n" +
+	"\x0fconversation_id\x18\x01 \x01(\tR\x0econversationId\x12\"\n" +
+	"\rserver_msg_id\x18\x02 \x01(\tR\vserverMsgId\x12\x10\n" +
+	"\x03seq\x18\x03 \x01(\x03R\x03seq\x12\x16\n" +
+	"\x06sender\x18\x04 \x01(\tR\x06sender\x12\"\n" +
+	"\rsender_bot_id\x18\x05 \x01(\tR\vsenderBotId\x12\x18\n" +
+	"\apreview\x18\x06 \x01(\tR\apreview\x12!\n" +
+	"\fcontent_type\x18\a \x01(\tR\vcontentType\x12)\n" +
+	"\x10attachment_count\x18\b \x01(\x05R\x0fattachmentCount\x127\n" +
+	"\tserver_ts\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\bserverTs\"=\n" +
+	"\x18FirehoseSubscribePayload\x12!\n" +
+	"\fresume_token\x18\x01 \x01(\tR\vresumeToken\">\n" +
+	"\x19FirehoseCheckpointPayload\x12!\n" +
+	"\fresume_token\x18\x01 \x01(\tR\vresumeToken\"5\n" +
+	"\x18PresenceSubscribePayload\x12\x19\n" +
+	"\buser_ids\x18\x01 \x03(\tR\auserIds\"\x86\x01\n" +
+	"\x15PresenceUpdatePayload\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x12<\n" +
+	"\flast_seen_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"lastSeenAt\"\x94\x01\n" +
+	"\fErrorPayload\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\tR\x04code\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\x12\x1b\n" +
+	"\tmax_chars\x18\x04 \x01(\x05R\bmaxChars\x12!\n" +
+	"\factual_chars\x18\x05 \x01(\x05R\vactualChars\"}\n" +
+	"\rResumePayload\x12I\n" +
+	"\rconversations\x18\x01 \x03(\v2#.arc.realtime.v1.ResumeConversationR\rconversations\x12!\n" +
+	"\fresume_token\x18\x02 \x01(\tR\vresumeToken\"X\n" +
+	"\x12ResumeConversation\x12'\n" +
+	"\x0fconversation_id\x18\x01 \x01(\tR\x0econversationId\x12\x19\n" +
+	"\blast_seq\x18\x02 \x01(\x03R\alastSeq\"q\n" +
+	"\x0fResumeOKPayload\x12'\n" +
+	"\x0fconversation_id\x18\x01 \x01(\tR\x0econversationId\x12\x1a\n" +
+	"\breplayed\x18\x02 \x01(\x05R\breplayed\x12\x19\n" +
+	"\blast_seq\x18\x03 \x01(\x03R\alastSeq\"p\n" +
+	"\x13ResumeFailedPayload\x12'\n" +
+	"\x0fconversation_id\x18\x01 \x01(\tR\x0econversationId\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\"\x81\x01\n" +
+	"\x15ServerShutdownPayload\x12$\n" +
+	"\x0eretry_after_ms\x18\x01 \x01(\x03R\fretryAfterMs\x12\x1f\n" +
+	"\vdeadline_ms\x18\x02 \x01(\x03R\n" +
+	"deadlineMs\x12!\n" +
+	"\fresume_token\x18\x03 \x01(\tR\vresumeToken\"\xbe\x01\n" +
+	"\x0fTimeSyncPayload\x127\n" +
+	"\tclient_ts\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\bclientTs\x127\n" +
+	"\tserver_ts\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\bserverTs\x12'\n" +
+	"\rclock_skew_ms\x18\x03 \x01(\x03H\x00R\vclockSkewMs\x88\x01\x01B\x10\n" +
+	"\x0e_clock_skew_ms2\x9d\x02\n" +
+	"\bRealtime\x12B\n" +
+	"\x06Stream\x12\x19.arc.realtime.v1.Envelope\x1a\x19.arc.realtime.v1.Envelope(\x010\x01\x12C\n" +
+	"\vSendMessage\x12\x19.arc.realtime.v1.Envelope\x1a\x19.arc.realtime.v1.Envelope\x12D\n" +
+	"\fFetchHistory\x12\x19.arc.realtime.v1.Envelope\x1a\x19.arc.realtime.v1.Envelope\x12B\n" +
+	"\bFirehose\x12\x19.arc.realtime.v1.Envelope\x1a\x19.arc.realtime.v1.Envelope0\x01B*Z(arc/shared/contracts/realtime/realtimepbb\x06proto3"
+
+var (
+	file_realtime_proto_rawDescOnce sync.Once
+	file_realtime_proto_rawDescData []byte
+)
+
+func file_realtime_proto_rawDescGZIP() []byte {
+	file_realtime_proto_rawDescOnce.Do(func() {
+		file_realtime_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_realtime_proto_rawDesc), len(file_realtime_proto_rawDesc)))
+	})
+	return file_realtime_proto_rawDescData
+}
+
+var file_realtime_proto_msgTypes = make([]protoimpl.MessageInfo, 36)
+var file_realtime_proto_goTypes = []any{
+	(*Envelope)(nil),                        // 0: arc.realtime.v1.Envelope
+	(*HelloPayload)(nil),                    // 1: arc.realtime.v1.HelloPayload
+	(*HelloAckPayload)(nil),                 // 2: arc.realtime.v1.HelloAckPayload
+	(*ConversationJoinPayload)(nil),         // 3: arc.realtime.v1.ConversationJoinPayload
+	(*ConversationLeavePayload)(nil),        // 4: arc.realtime.v1.ConversationLeavePayload
+	(*MessageSendPayload)(nil),              // 5: arc.realtime.v1.MessageSendPayload
+	(*MessageEntity)(nil),                   // 6: arc.realtime.v1.MessageEntity
+	(*MessageAckPayload)(nil),               // 7: arc.realtime.v1.MessageAckPayload
+	(*MessageNewPayload)(nil),               // 8: arc.realtime.v1.MessageNewPayload
+	(*MessageEditPayload)(nil),              // 9: arc.realtime.v1.MessageEditPayload
+	(*MessageDeletePayload)(nil),            // 10: arc.realtime.v1.MessageDeletePayload
+	(*MessageEditedPayload)(nil),            // 11: arc.realtime.v1.MessageEditedPayload
+	(*MessageDeletedPayload)(nil),           // 12: arc.realtime.v1.MessageDeletedPayload
+	(*MessageRemovedPayload)(nil),           // 13: arc.realtime.v1.MessageRemovedPayload
+	(*MessageReadPayload)(nil),              // 14: arc.realtime.v1.MessageReadPayload
+	(*ReadUpdatePayload)(nil),               // 15: arc.realtime.v1.ReadUpdatePayload
+	(*MessageDeliveredPayload)(nil),         // 16: arc.realtime.v1.MessageDeliveredPayload
+	(*ReadStatePayload)(nil),                // 17: arc.realtime.v1.ReadStatePayload
+	(*SystemNewPayload)(nil),                // 18: arc.realtime.v1.SystemNewPayload
+	(*ConversationHistoryFetchPayload)(nil), // 19: arc.realtime.v1.ConversationHistoryFetchPayload
+	(*ConversationHistoryChunkPayload)(nil), // 20: arc.realtime.v1.ConversationHistoryChunkPayload
+	(*MemberAddedPayload)(nil),              // 21: arc.realtime.v1.MemberAddedPayload
+	(*MemberRemovedPayload)(nil),            // 22: arc.realtime.v1.MemberRemovedPayload
+	(*InboxSubscribePayload)(nil),           // 23: arc.realtime.v1.InboxSubscribePayload
+	(*InboxMessagePayload)(nil),             // 24: arc.realtime.v1.InboxMessagePayload
+	(*FirehoseSubscribePayload)(nil),        // 25: arc.realtime.v1.FirehoseSubscribePayload
+	(*FirehoseCheckpointPayload)(nil),       // 26: arc.realtime.v1.FirehoseCheckpointPayload
+	(*PresenceSubscribePayload)(nil),        // 27: arc.realtime.v1.PresenceSubscribePayload
+	(*PresenceUpdatePayload)(nil),           // 28: arc.realtime.v1.PresenceUpdatePayload
+	(*ErrorPayload)(nil),                    // 29: arc.realtime.v1.ErrorPayload
+	(*ResumePayload)(nil),                   // 30: arc.realtime.v1.ResumePayload
+	(*ResumeConversation)(nil),              // 31: arc.realtime.v1.ResumeConversation
+	(*ResumeOKPayload)(nil),                 // 32: arc.realtime.v1.ResumeOKPayload
+	(*ResumeFailedPayload)(nil),             // 33: arc.realtime.v1.ResumeFailedPayload
+	(*ServerShutdownPayload)(nil),           // 34: arc.realtime.v1.ServerShutdownPayload
+	(*TimeSyncPayload)(nil),                 // 35: arc.realtime.v1.TimeSyncPayload
+	(*timestamppb.Timestamp)(nil),           // 36: google.protobuf.Timestamp
+}
+var file_realtime_proto_depIdxs = []int32{
+	36, // 0: arc.realtime.v1.Envelope.ts:type_name -> google.protobuf.Timestamp
+	1,  // 1: arc.realtime.v1.Envelope.hello:type_name -> arc.realtime.v1.HelloPayload
+	2,  // 2: arc.realtime.v1.Envelope.hello_ack:type_name -> arc.realtime.v1.HelloAckPayload
+	3,  // 3: arc.realtime.v1.Envelope.conversation_join:type_name -> arc.realtime.v1.ConversationJoinPayload
+	4,  // 4: arc.realtime.v1.Envelope.conversation_leave:type_name -> arc.realtime.v1.ConversationLeavePayload
+	5,  // 5: arc.realtime.v1.Envelope.message_send:type_name -> arc.realtime.v1.MessageSendPayload
+	7,  // 6: arc.realtime.v1.Envelope.message_ack:type_name -> arc.realtime.v1.MessageAckPayload
+	8,  // 7: arc.realtime.v1.Envelope.message_new:type_name -> arc.realtime.v1.MessageNewPayload
+	9,  // 8: arc.realtime.v1.Envelope.message_edit:type_name -> arc.realtime.v1.MessageEditPayload
+	10, // 9: arc.realtime.v1.Envelope.message_delete:type_name -> arc.realtime.v1.MessageDeletePayload
+	11, // 10: arc.realtime.v1.Envelope.message_edited:type_name -> arc.realtime.v1.MessageEditedPayload
+	12, // 11: arc.realtime.v1.Envelope.message_deleted:type_name -> arc.realtime.v1.MessageDeletedPayload
+	13, // 12: arc.realtime.v1.Envelope.message_removed:type_name -> arc.realtime.v1.MessageRemovedPayload
+	14, // 13: arc.realtime.v1.Envelope.message_read:type_name -> arc.realtime.v1.MessageReadPayload
+	15, // 14: arc.realtime.v1.Envelope.read_update:type_name -> arc.realtime.v1.ReadUpdatePayload
+	17, // 15: arc.realtime.v1.Envelope.read_state:type_name -> arc.realtime.v1.ReadStatePayload
+	16, // 16: arc.realtime.v1.Envelope.message_delivered:type_name -> arc.realtime.v1.MessageDeliveredPayload
+	18, // 17: arc.realtime.v1.Envelope.system_new:type_name -> arc.realtime.v1.SystemNewPayload
+	19, // 18: arc.realtime.v1.Envelope.conversation_history_fetch:type_name -> arc.realtime.v1.ConversationHistoryFetchPayload
+	20, // 19: arc.realtime.v1.Envelope.conversation_history_chunk:type_name -> arc.realtime.v1.ConversationHistoryChunkPayload
+	21, // 20: arc.realtime.v1.Envelope.member_added:type_name -> arc.realtime.v1.MemberAddedPayload
+	22, // 21: arc.realtime.v1.Envelope.member_removed:type_name -> arc.realtime.v1.MemberRemovedPayload
+	27, // 22: arc.realtime.v1.Envelope.presence_subscribe:type_name -> arc.realtime.v1.PresenceSubscribePayload
+	28, // 23: arc.realtime.v1.Envelope.presence_update:type_name -> arc.realtime.v1.PresenceUpdatePayload
+	23, // 24: arc.realtime.v1.Envelope.inbox_subscribe:type_name -> arc.realtime.v1.InboxSubscribePayload
+	24, // 25: arc.realtime.v1.Envelope.inbox_message:type_name -> arc.realtime.v1.InboxMessagePayload
+	25, // 26: arc.realtime.v1.Envelope.firehose_subscribe:type_name -> arc.realtime.v1.FirehoseSubscribePayload
+	26, // 27: arc.realtime.v1.Envelope.firehose_checkpoint:type_name -> arc.realtime.v1.FirehoseCheckpointPayload
+	30, // 28: arc.realtime.v1.Envelope.resume:type_name -> arc.realtime.v1.ResumePayload
+	32, // 29: arc.realtime.v1.Envelope.resume_ok:type_name -> arc.realtime.v1.ResumeOKPayload
+	33, // 30: arc.realtime.v1.Envelope.resume_failed:type_name -> arc.realtime.v1.ResumeFailedPayload
+	34, // 31: arc.realtime.v1.Envelope.server_shutdown:type_name -> arc.realtime.v1.ServerShutdownPayload
+	35, // 32: arc.realtime.v1.Envelope.time_sync:type_name -> arc.realtime.v1.TimeSyncPayload
+	29, // 33: arc.realtime.v1.Envelope.error:type_name -> arc.realtime.v1.ErrorPayload
+	36, // 34: arc.realtime.v1.HelloAckPayload.resume_token_expires_at:type_name -> google.protobuf.Timestamp
+	36, // 35: arc.realtime.v1.MessageNewPayload.server_ts:type_name -> google.protobuf.Timestamp
+	36, // 36: arc.realtime.v1.MessageNewPayload.edited_at:type_name -> google.protobuf.Timestamp
+	6,  // 37: arc.realtime.v1.MessageNewPayload.entities:type_name -> arc.realtime.v1.MessageEntity
+	36, // 38: arc.realtime.v1.MessageEditedPayload.edited_at:type_name -> google.protobuf.Timestamp
+	6,  // 39: arc.realtime.v1.MessageEditedPayload.entities:type_name -> arc.realtime.v1.MessageEntity
+	36, // 40: arc.realtime.v1.MessageDeletedPayload.deleted_at:type_name -> google.protobuf.Timestamp
+	36, // 41: arc.realtime.v1.MessageRemovedPayload.deleted_at:type_name -> google.protobuf.Timestamp
+	36, // 42: arc.realtime.v1.ReadStatePayload.updated_at:type_name -> google.protobuf.Timestamp
+	36, // 43: arc.realtime.v1.SystemNewPayload.server_ts:type_name -> google.protobuf.Timestamp
+	8,  // 44: arc.realtime.v1.ConversationHistoryChunkPayload.messages:type_name -> arc.realtime.v1.MessageNewPayload
+	36, // 45: arc.realtime.v1.InboxMessagePayload.server_ts:type_name -> google.protobuf.Timestamp
+	36, // 46: arc.realtime.v1.PresenceUpdatePayload.last_seen_at:type_name -> google.protobuf.Timestamp
+	31, // 47: arc.realtime.v1.ResumePayload.conversations:type_name -> arc.realtime.v1.ResumeConversation
+	36, // 48: arc.realtime.v1.TimeSyncPayload.client_ts:type_name -> google.protobuf.Timestamp
+	36, // 49: arc.realtime.v1.TimeSyncPayload.server_ts:type_name -> google.protobuf.Timestamp
+	0,  // 50: arc.realtime.v1.Realtime.Stream:input_type -> arc.realtime.v1.Envelope
+	0,  // 51: arc.realtime.v1.Realtime.SendMessage:input_type -> arc.realtime.v1.Envelope
+	0,  // 52: arc.realtime.v1.Realtime.FetchHistory:input_type -> arc.realtime.v1.Envelope
+	0,  // 53: arc.realtime.v1.Realtime.Firehose:input_type -> arc.realtime.v1.Envelope
+	0,  // 54: arc.realtime.v1.Realtime.Stream:output_type -> arc.realtime.v1.Envelope
+	0,  // 55: arc.realtime.v1.Realtime.SendMessage:output_type -> arc.realtime.v1.Envelope
+	0,  // 56: arc.realtime.v1.Realtime.FetchHistory:output_type -> arc.realtime.v1.Envelope
+	0,  // 57: arc.realtime.v1.Realtime.Firehose:output_type -> arc.realtime.v1.Envelope
+	54, // [54:58] is the sub-list for method output_type
+	50, // [50:54] is the sub-list for method input_type
+	50, // [50:50] is the sub-list for extension type_name
+	50, // [50:50] is the sub-list for extension extendee
+	0,  // [0:50] is the sub-list for field type_name
+}
+
+func init() { file_realtime_proto_init() }
+func file_realtime_proto_init() {
+	if File_realtime_proto != nil {
+		return
+	}
+	file_realtime_proto_msgTypes[0].OneofWrappers = []any{
+		(*Envelope_Hello)(nil),
+		(*Envelope_HelloAck)(nil),
+		(*Envelope_ConversationJoin)(nil),
+		(*Envelope_ConversationLeave)(nil),
+		(*Envelope_MessageSend)(nil),
+		(*Envelope_MessageAck)(nil),
+		(*Envelope_MessageNew)(nil),
+		(*Envelope_MessageEdit)(nil),
+		(*Envelope_MessageDelete)(nil),
+		(*Envelope_MessageEdited)(nil),
+		(*Envelope_MessageDeleted)(nil),
+		(*Envelope_MessageRemoved)(nil),
+		(*Envelope_MessageRead)(nil),
+		(*Envelope_ReadUpdate)(nil),
+		(*Envelope_ReadState)(nil),
+		(*Envelope_MessageDelivered)(nil),
+		(*Envelope_SystemNew)(nil),
+		(*Envelope_ConversationHistoryFetch)(nil),
+		(*Envelope_ConversationHistoryChunk)(nil),
+		(*Envelope_MemberAdded)(nil),
+		(*Envelope_MemberRemoved)(nil),
+		(*Envelope_PresenceSubscribe)(nil),
+		(*Envelope_PresenceUpdate)(nil),
+		(*Envelope_InboxSubscribe)(nil),
+		(*Envelope_InboxMessage)(nil),
+		(*Envelope_FirehoseSubscribe)(nil),
+		(*Envelope_FirehoseCheckpoint)(nil),
+		(*Envelope_Resume)(nil),
+		(*Envelope_ResumeOk)(nil),
+		(*Envelope_ResumeFailed)(nil),
+		(*Envelope_ServerShutdown)(nil),
+		(*Envelope_TimeSync)(nil),
+		(*Envelope_Error)(nil),
+	}
+	file_realtime_proto_msgTypes[7].OneofWrappers = []any{}
+	file_realtime_proto_msgTypes[19].OneofWrappers = []any{}
+	file_realtime_proto_msgTypes[35].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_realtime_proto_rawDesc), len(file_realtime_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   36,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_realtime_proto_goTypes,
+		DependencyIndexes: file_realtime_proto_depIdxs,
+		MessageInfos:      file_realtime_proto_msgTypes,
+	}.Build()
+	File_realtime_proto = out.File
+	file_realtime_proto_goTypes = nil
+	file_realtime_proto_depIdxs = nil
+}
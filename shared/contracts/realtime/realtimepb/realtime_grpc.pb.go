@@ -0,0 +1,261 @@
+// Arc Realtime Protocol over gRPC.
+//
+// Envelope mirrors the v1 JSON envelope field for field. Each v1 payload is a
+// message named after its Go struct in arc/shared/contracts/realtime/v1, with
+// the JSON field names; the payload oneof has one field per envelope type,
+// named after the type with dots replaced by underscores.
+//
+// Regenerate realtime.pb.go and realtime_grpc.pb.go with
+// tools/scripts/gen-proto.sh after editing this file.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: realtime.proto
+
+package realtimepb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Realtime_Stream_FullMethodName       = "/arc.realtime.v1.Realtime/Stream"
+	Realtime_SendMessage_FullMethodName  = "/arc.realtime.v1.Realtime/SendMessage"
+	Realtime_FetchHistory_FullMethodName = "/arc.realtime.v1.Realtime/FetchHistory"
+	Realtime_Firehose_FullMethodName     = "/arc.realtime.v1.Realtime/Firehose"
+)
+
+// RealtimeClient is the client API for Realtime service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type RealtimeClient interface {
+	// Stream is the gRPC equivalent of a WebSocket session: the client sends
+	// hello, conversation.join, message.send, ... and receives every server event.
+	Stream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[Envelope, Envelope], error)
+	// SendMessage takes a message.send envelope and returns its message.ack.
+	SendMessage(ctx context.Context, in *Envelope, opts ...grpc.CallOption) (*Envelope, error)
+	// FetchHistory takes a conversation.history.fetch envelope and returns the
+	// conversation.history.chunk.
+	FetchHistory(ctx context.Context, in *Envelope, opts ...grpc.CallOption) (*Envelope, error)
+	// Firehose takes a firehose.subscribe envelope and streams the message and
+	// membership events of the configured conversations, with a
+	// firehose.checkpoint carrying a resume token every few seconds. It
+	// authenticates with a machine token instead of an access token.
+	Firehose(ctx context.Context, in *Envelope, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Envelope], error)
+}
+
+type realtimeClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRealtimeClient(cc grpc.ClientConnInterface) RealtimeClient {
+	return &realtimeClient{cc}
+}
+
+func (c *realtimeClient) Stream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[Envelope, Envelope], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Realtime_ServiceDesc.Streams[0], Realtime_Stream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[Envelope, Envelope]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Realtime_StreamClient = grpc.BidiStreamingClient[Envelope, Envelope]
+
+func (c *realtimeClient) SendMessage(ctx context.Context, in *Envelope, opts ...grpc.CallOption) (*Envelope, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Envelope)
+	err := c.cc.Invoke(ctx, Realtime_SendMessage_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *realtimeClient) FetchHistory(ctx context.Context, in *Envelope, opts ...grpc.CallOption) (*Envelope, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Envelope)
+	err := c.cc.Invoke(ctx, Realtime_FetchHistory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *realtimeClient) Firehose(ctx context.Context, in *Envelope, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Envelope], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Realtime_ServiceDesc.Streams[1], Realtime_Firehose_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[Envelope, Envelope]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Realtime_FirehoseClient = grpc.ServerStreamingClient[Envelope]
+
+// RealtimeServer is the server API for Realtime service.
+// All implementations must embed UnimplementedRealtimeServer
+// for forward compatibility.
+type RealtimeServer interface {
+	// Stream is the gRPC equivalent of a WebSocket session: the client sends
+	// hello, conversation.join, message.send, ... and receives every server event.
+	Stream(grpc.BidiStreamingServer[Envelope, Envelope]) error
+	// SendMessage takes a message.send envelope and returns its message.ack.
+	SendMessage(context.Context, *Envelope) (*Envelope, error)
+	// FetchHistory takes a conversation.history.fetch envelope and returns the
+	// conversation.history.chunk.
+	FetchHistory(context.Context, *Envelope) (*Envelope, error)
+	// Firehose takes a firehose.subscribe envelope and streams the message and
+	// membership events of the configured conversations, with a
+	// firehose.checkpoint carrying a resume token every few seconds. It
+	// authenticates with a machine token instead of an access token.
+	Firehose(*Envelope, grpc.ServerStreamingServer[Envelope]) error
+	mustEmbedUnimplementedRealtimeServer()
+}
+
+// UnimplementedRealtimeServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedRealtimeServer struct{}
+
+func (UnimplementedRealtimeServer) Stream(grpc.BidiStreamingServer[Envelope, Envelope]) error {
+	return status.Errorf(codes.Unimplemented, "method Stream not implemented")
+}
+func (UnimplementedRealtimeServer) SendMessage(context.Context, *Envelope) (*Envelope, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SendMessage not implemented")
+}
+func (UnimplementedRealtimeServer) FetchHistory(context.Context, *Envelope) (*Envelope, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FetchHistory not implemented")
+}
+func (UnimplementedRealtimeServer) Firehose(*Envelope, grpc.ServerStreamingServer[Envelope]) error {
+	return status.Errorf(codes.Unimplemented, "method Firehose not implemented")
+}
+func (UnimplementedRealtimeServer) mustEmbedUnimplementedRealtimeServer() {}
+func (UnimplementedRealtimeServer) testEmbeddedByValue()                  {}
+
+// UnsafeRealtimeServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RealtimeServer will
+// result in compilation errors.
+type UnsafeRealtimeServer interface {
+	mustEmbedUnimplementedRealtimeServer()
+}
+
+func RegisterRealtimeServer(s grpc.ServiceRegistrar, srv RealtimeServer) {
+	// If the following call pancis, it indicates UnimplementedRealtimeServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Realtime_ServiceDesc, srv)
+}
+
+func _Realtime_Stream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(RealtimeServer).Stream(&grpc.GenericServerStream[Envelope, Envelope]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Realtime_StreamServer = grpc.BidiStreamingServer[Envelope, Envelope]
+
+func _Realtime_SendMessage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Envelope)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RealtimeServer).SendMessage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Realtime_SendMessage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RealtimeServer).SendMessage(ctx, req.(*Envelope))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Realtime_FetchHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Envelope)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RealtimeServer).FetchHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Realtime_FetchHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RealtimeServer).FetchHistory(ctx, req.(*Envelope))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Realtime_Firehose_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(Envelope)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RealtimeServer).Firehose(m, &grpc.GenericServerStream[Envelope, Envelope]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Realtime_FirehoseServer = grpc.ServerStreamingServer[Envelope]
+
+// Realtime_ServiceDesc is the grpc.ServiceDesc for Realtime service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Realtime_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "arc.realtime.v1.Realtime",
+	HandlerType: (*RealtimeServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SendMessage",
+			Handler:    _Realtime_SendMessage_Handler,
+		},
+		{
+			MethodName: "FetchHistory",
+			Handler:    _Realtime_FetchHistory_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Stream",
+			Handler:       _Realtime_Stream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Firehose",
+			Handler:       _Realtime_Firehose_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "realtime.proto",
+}
@@ -25,6 +25,8 @@ const (
 
 	// TypeConversationJoin joins a conversation (client -> server) and is echoed back.
 	TypeConversationJoin = "conversation.join"
+	// TypeConversationLeave leaves a joined conversation (client -> server) and is echoed back.
+	TypeConversationLeave = "conversation.leave"
 
 	// TypeMessageSend requests sending a new message (client -> server).
 	TypeMessageSend = "message.send"
@@ -33,9 +35,29 @@ const (
 	// TypeMessageNew broadcasts a newly accepted message (server -> conversation members).
 	TypeMessageNew = "message.new"
 
-	// TypeMessageRead signals read position update (client -> server) (future-compatible for Phase 1/2).
+	// TypeMessageEdit requests editing an own message (client -> server).
+	TypeMessageEdit = "message.edit"
+	// TypeMessageDelete requests deleting an own message (client -> server).
+	TypeMessageDelete = "message.delete"
+	// TypeMessageEdited broadcasts an edited message (server -> conversation members).
+	TypeMessageEdited = "message.edited"
+	// TypeMessageDeleted broadcasts a message tombstone (server -> conversation members).
+	TypeMessageDeleted = "message.deleted"
+	// TypeMessageRemoved broadcasts a tombstone left by a moderator (server -> conversation members).
+	TypeMessageRemoved = "message.removed"
+
+	// TypeMessageRead advances the caller's read cursor (client -> server).
+	// Deprecated: use TypeReadUpdate; kept as an alias for older clients.
 	TypeMessageRead = "message.read"
 
+	// TypeReadUpdate advances the caller's read cursor (client -> server).
+	TypeReadUpdate = "read.update"
+	// TypeReadState broadcasts a member's read cursor (server -> conversation members and the reader's devices).
+	TypeReadState = "read.state"
+
+	// TypeMessageDelivered acknowledges that messages reached the client (client -> server).
+	TypeMessageDelivered = "message.delivered"
+
 	// TypeSystemNew is a server broadcast for system messages (future-compatible).
 	TypeSystemNew = "system.new"
 
@@ -44,16 +66,57 @@ const (
 	// TypeConversationHistoryChunk returns a window of history (server -> client).
 	TypeConversationHistoryChunk = "conversation.history.chunk"
 
+	// TypeMemberAdded broadcasts that a user was added to a conversation (server -> conversation members).
+	TypeMemberAdded = "member.added"
+	// TypeMemberRemoved broadcasts that a user left or was removed from a conversation (server -> conversation members).
+	TypeMemberRemoved = "member.removed"
+
+	// TypePresenceSubscribe subscribes to presence of a set of users (client -> server).
+	TypePresenceSubscribe = "presence.subscribe"
+	// TypePresenceUpdate sets the caller's status (client -> server) and carries
+	// presence changes of subscribed users (server -> client).
+	TypePresenceUpdate = "presence.update"
+
+	// TypeInboxSubscribe subscribes to the caller's inbox (client -> server) and is echoed back.
+	TypeInboxSubscribe = "inbox.subscribe"
+	// TypeInboxUnsubscribe ends an inbox subscription (client -> server) and is echoed back.
+	TypeInboxUnsubscribe = "inbox.unsubscribe"
+	// TypeInboxMessage previews a new message in one of the caller's conversations (server -> inbox subscribers).
+	TypeInboxMessage = "inbox.message"
+
+	// TypeFirehoseSubscribe opens an integration event stream (integration -> server).
+	TypeFirehoseSubscribe = "firehose.subscribe"
+	// TypeFirehoseCheckpoint carries the resume token of an event stream (server -> integration).
+	TypeFirehoseCheckpoint = "firehose.checkpoint"
+
+	// TypeResume asks the server to replay messages missed while disconnected (client -> server).
+	TypeResume = "resume"
+	// TypeResumeOK confirms the replay of one conversation (server -> client).
+	TypeResumeOK = "resume.ok"
+	// TypeResumeFailed reports a conversation that could not be replayed; clients
+	// fall back to conversation.history.fetch (server -> client).
+	TypeResumeFailed = "resume.failed"
+
+	// TypeServerShutdown announces that the node is draining; clients reconnect
+	// after retry_after_ms and resume (server -> client).
+	TypeServerShutdown = "server.shutdown"
+
+	// TypeTimeSync asks for the server clock (client -> server) and answers with
+	// the server's receive time (server -> client).
+	TypeTimeSync = "time.sync"
+
 	// TypeError is a generic error envelope (server -> client).
 	TypeError = "error"
 )
 
 // Envelope is the canonical wire wrapper.
+// message.new and message.ack are tagged with the message's ConvID and Seq.
 type Envelope struct {
 	V       int             `json:"v"`
 	Type    string          `json:"type"`
 	ID      string          `json:"id,omitempty"`
 	ConvID  string          `json:"conv_id,omitempty"`
+	Seq     int64           `json:"seq,omitempty"`
 	TS      time.Time       `json:"ts,omitempty"`
 	Payload json.RawMessage `json:"payload,omitempty"`
 }
@@ -74,13 +137,36 @@ func (e Envelope) Validate() error {
 	case TypeHello,
 		TypeHelloAck,
 		TypeConversationJoin,
+		TypeConversationLeave,
 		TypeMessageSend,
 		TypeMessageAck,
 		TypeMessageNew,
+		TypeMessageEdit,
+		TypeMessageDelete,
+		TypeMessageEdited,
+		TypeMessageDeleted,
+		TypeMessageRemoved,
 		TypeMessageRead,
+		TypeReadUpdate,
+		TypeReadState,
+		TypeMessageDelivered,
 		TypeSystemNew,
 		TypeConversationHistoryFetch,
 		TypeConversationHistoryChunk,
+		TypeMemberAdded,
+		TypeMemberRemoved,
+		TypePresenceSubscribe,
+		TypePresenceUpdate,
+		TypeInboxSubscribe,
+		TypeInboxUnsubscribe,
+		TypeInboxMessage,
+		TypeFirehoseSubscribe,
+		TypeFirehoseCheckpoint,
+		TypeResume,
+		TypeResumeOK,
+		TypeResumeFailed,
+		TypeServerShutdown,
+		TypeTimeSync,
 		TypeError:
 		return nil
 	default:
@@ -94,11 +180,60 @@ func (e Envelope) Validate() error {
 // token is required by docs/spec/realtime-v1.md (MVP baseline).
 type HelloPayload struct {
 	Token string `json:"token,omitempty"`
+	// ResumeToken is a token from a previous connection, possibly to another node;
+	// its cursors carry over into this connection.
+	ResumeToken string `json:"resume_token,omitempty"`
+	// MaxFrameBytes optionally lowers the largest frame the server reads on this
+	// connection; hello.ack reports the limit in effect.
+	MaxFrameBytes int `json:"max_frame_bytes,omitempty"`
+	// ClientVersion and Platform identify the client build ("2.4.1", "ios"); they
+	// are informational.
+	ClientVersion string `json:"client_version,omitempty"`
+	Platform      string `json:"platform,omitempty"`
+	// Capabilities are the optional features the client supports. 0 announces
+	// none and keeps the server's defaults.
+	Capabilities Capabilities `json:"capabilities,omitempty"`
+}
+
+// Capabilities is a bitmap of optional protocol features, negotiated in hello.
+// Unknown bits are ignored so features can be added without a new version.
+type Capabilities uint64
+
+// Capability bits (wire-stable).
+const (
+	// CapResume: the client resumes with resume tokens and cursors.
+	CapResume Capabilities = 1 << iota
+	// CapCompression: the client negotiates permessage-deflate.
+	CapCompression
+	// CapReactions: the client understands reaction events.
+	CapReactions
+	// CapMultiConversation: the client stays joined to several conversations at
+	// once and leaves them with conversation.leave.
+	CapMultiConversation
+)
+
+// Has reports whether every bit of f is set.
+func (c Capabilities) Has(f Capabilities) bool {
+	return c&f == f
 }
 
 // HelloAckPayload must carry SessionID (used by ws-smoke + server logic).
 type HelloAckPayload struct {
 	SessionID string `json:"session_id"`
+	// ResumeToken is a short-lived signed token embedding the session and its
+	// per-conversation cursors; empty when auth is not configured.
+	ResumeToken          string     `json:"resume_token,omitempty"`
+	ResumeTokenExpiresAt *time.Time `json:"resume_token_expires_at,omitempty"`
+	// MaxMessageChars and MaxFrameBytes are the connection's limits, for client-side validation.
+	MaxMessageChars int `json:"max_message_chars,omitempty"`
+	MaxFrameBytes   int `json:"max_frame_bytes,omitempty"`
+	// HeartbeatIntervalMS is how often the server pings the connection.
+	HeartbeatIntervalMS int64 `json:"heartbeat_interval_ms,omitempty"`
+	// ProtocolVersion is the envelope version the server speaks (Version).
+	ProtocolVersion int `json:"protocol_version,omitempty"`
+	// Capabilities are the features enabled for the connection: the client's
+	// announced ones the server supports, or every server feature when it announced none.
+	Capabilities Capabilities `json:"capabilities,omitempty"`
 }
 
 // ConversationJoinPayload requests membership in a conversation.
@@ -107,11 +242,44 @@ type ConversationJoinPayload struct {
 	Kind           string `json:"kind,omitempty"` // "direct" | "group" | "room" (optional hint)
 }
 
+// ConversationLeavePayload leaves a joined conversation.
+type ConversationLeavePayload struct {
+	ConversationID string `json:"conversation_id"`
+}
+
+// Message content types.
+const (
+	// ContentTypeText is plain text in Text (the default when content_type is omitted).
+	ContentTypeText = "text"
+	// ContentTypeE2EE is end-to-end encrypted content in Ciphertext; Text is empty
+	// and the server never inspects the content.
+	ContentTypeE2EE = "e2ee"
+)
+
 // MessageSendPayload requests sending a message into a conversation.
+// ReplyToServerMsgID optionally references an earlier message of the same conversation.
+// AttachmentIDs reference attachments issued by POST /attachments to the sender.
 type MessageSendPayload struct {
-	ConversationID string `json:"conversation_id"`
-	ClientMsgID    string `json:"client_msg_id"`
-	Text           string `json:"text"`
+	ConversationID     string   `json:"conversation_id"`
+	ClientMsgID        string   `json:"client_msg_id"`
+	Text               string   `json:"text"`
+	ReplyToServerMsgID string   `json:"reply_to_server_msg_id,omitempty"`
+	AttachmentIDs      []string `json:"attachment_ids,omitempty"`
+	// ContentType is ContentTypeText (default) or ContentTypeE2EE. E2EE messages
+	// carry Ciphertext (base64 in JSON) and the KeyIDs of the device keys it was
+	// encrypted for instead of Text.
+	ContentType string   `json:"content_type,omitempty"`
+	Ciphertext  []byte   `json:"ciphertext,omitempty"`
+	KeyIDs      []string `json:"key_ids,omitempty"`
+}
+
+// MessageEntity marks a span of message text. Offset and Length count UTF-16 code units.
+type MessageEntity struct {
+	Type   string `json:"type"` // "mention" | "url"
+	Offset int    `json:"offset"`
+	Length int    `json:"length"`
+	UserID string `json:"user_id,omitempty"`
+	URL    string `json:"url,omitempty"`
 }
 
 // MessageAckPayload acknowledges a send request and returns the canonical server ids.
@@ -120,25 +288,114 @@ type MessageAckPayload struct {
 	ClientMsgID    string `json:"client_msg_id"`
 	ServerMsgID    string `json:"server_msg_id"`
 	Seq            int64  `json:"seq"`
+	// ClockSkewMS is the send envelope's ts minus the server's receive time
+	// (positive when the client clock is ahead); omitted when ts was not set.
+	ClockSkewMS *int64 `json:"clock_skew_ms,omitempty"`
 }
 
 // MessageNewPayload is broadcast when a new message is accepted (non-duplicate).
+// In history chunks it also reflects edits (Version, EditedAt) and deletes
+// (Deleted with empty Text); a tombstone keeps its Seq.
 type MessageNewPayload struct {
+	ConversationID string `json:"conversation_id"`
+	ClientMsgID    string `json:"client_msg_id"`
+	ServerMsgID    string `json:"server_msg_id"`
+	Seq            int64  `json:"seq"`
+	Sender         string `json:"sender"`
+	// SenderBotID attributes messages posted by a bot; Sender is empty then.
+	SenderBotID string     `json:"sender_bot_id,omitempty"`
+	Text        string     `json:"text"`
+	ServerTS    time.Time  `json:"server_ts"`
+	Version     int64      `json:"version,omitempty"`
+	EditedAt    *time.Time `json:"edited_at,omitempty"`
+	Deleted     bool       `json:"deleted,omitempty"`
+	// ReplyToServerMsgID is set when the message replies to another message.
+	ReplyToServerMsgID string   `json:"reply_to_server_msg_id,omitempty"`
+	AttachmentIDs      []string `json:"attachment_ids,omitempty"`
+	// Entities are server-extracted mentions and links in Text.
+	Entities []MessageEntity `json:"entities,omitempty"`
+	// ContentType, Ciphertext and KeyIDs are echoed as sent for ContentTypeE2EE
+	// messages; ContentType is omitted for text.
+	ContentType string   `json:"content_type,omitempty"`
+	Ciphertext  []byte   `json:"ciphertext,omitempty"`
+	KeyIDs      []string `json:"key_ids,omitempty"`
+}
+
+// MessageEditPayload requests replacing the text of an own message.
+type MessageEditPayload struct {
+	ConversationID string `json:"conversation_id"`
+	ServerMsgID    string `json:"server_msg_id"`
+	Text           string `json:"text"`
+}
+
+// MessageDeletePayload requests deleting an own message.
+type MessageDeletePayload struct {
+	ConversationID string `json:"conversation_id"`
+	ServerMsgID    string `json:"server_msg_id"`
+}
+
+// MessageEditedPayload is broadcast after a successful edit.
+type MessageEditedPayload struct {
 	ConversationID string    `json:"conversation_id"`
-	ClientMsgID    string    `json:"client_msg_id"`
 	ServerMsgID    string    `json:"server_msg_id"`
 	Seq            int64     `json:"seq"`
-	Sender         string    `json:"sender"`
 	Text           string    `json:"text"`
-	ServerTS       time.Time `json:"server_ts"`
+	Version        int64     `json:"version"`
+	EditedAt       time.Time `json:"edited_at"`
+	// Entities are re-extracted from the edited text.
+	Entities []MessageEntity `json:"entities,omitempty"`
 }
 
-// MessageReadPayload updates the read cursor for a conversation (future-compatible).
+// MessageDeletedPayload is broadcast after a successful delete.
+type MessageDeletedPayload struct {
+	ConversationID string    `json:"conversation_id"`
+	ServerMsgID    string    `json:"server_msg_id"`
+	Seq            int64     `json:"seq"`
+	Version        int64     `json:"version"`
+	DeletedAt      time.Time `json:"deleted_at"`
+}
+
+// MessageRemovedPayload is broadcast after a moderator removes a message.
+type MessageRemovedPayload struct {
+	ConversationID string    `json:"conversation_id"`
+	ServerMsgID    string    `json:"server_msg_id"`
+	Seq            int64     `json:"seq"`
+	Version        int64     `json:"version"`
+	DeletedAt      time.Time `json:"deleted_at"`
+	// Reason is the moderator's report reason, e.g. "spam" ("" if none).
+	Reason string `json:"reason,omitempty"`
+}
+
+// MessageReadPayload updates the caller's read cursor for a conversation.
 type MessageReadPayload struct {
 	ConversationID string `json:"conversation_id"`
 	UpToSeq        int64  `json:"up_to_seq"`
 }
 
+// ReadUpdatePayload marks messages up to and including UpToSeq as read.
+type ReadUpdatePayload struct {
+	ConversationID string `json:"conversation_id"`
+	UpToSeq        int64  `json:"up_to_seq"`
+}
+
+// MessageDeliveredPayload acknowledges delivery of messages up to and including UpToSeq.
+// Acks are cumulative per user, so clients may batch them.
+type MessageDeliveredPayload struct {
+	ConversationID string `json:"conversation_id"`
+	UpToSeq        int64  `json:"up_to_seq"`
+}
+
+// ReadStatePayload carries a member's read cursor after an update.
+// UnreadCount is relative to UserID and is mainly useful to that user's other devices.
+type ReadStatePayload struct {
+	ConversationID string    `json:"conversation_id"`
+	UserID         string    `json:"user_id"`
+	LastReadSeq    int64     `json:"last_read_seq"`
+	LastSeq        int64     `json:"last_seq"`
+	UnreadCount    int64     `json:"unread_count"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
 // SystemNewPayload represents a server-emitted system message (future-compatible).
 type SystemNewPayload struct {
 	ConversationID string    `json:"conversation_id"`
@@ -149,9 +406,12 @@ type SystemNewPayload struct {
 }
 
 // ConversationHistoryFetchPayload requests a history window for a conversation.
+// AfterSeq pages forward; BeforeSeq pages backward (newest messages below it).
+// Messages in the chunk are always in ascending seq order.
 type ConversationHistoryFetchPayload struct {
 	ConversationID string `json:"conversation_id"`
 	AfterSeq       *int64 `json:"after_seq,omitempty"`
+	BeforeSeq      *int64 `json:"before_seq,omitempty"`
 	Limit          int    `json:"limit,omitempty"`
 }
 
@@ -162,8 +422,126 @@ type ConversationHistoryChunkPayload struct {
 	HasMore        bool                `json:"has_more"`
 }
 
+// MemberAddedPayload is broadcast when a user is added to a conversation.
+type MemberAddedPayload struct {
+	ConversationID string `json:"conversation_id"`
+	UserID         string `json:"user_id"`
+	Role           string `json:"role"`
+	AddedBy        string `json:"added_by"`
+}
+
+// MemberRemovedPayload is broadcast when a user leaves or is removed from a conversation.
+// RemovedBy equals UserID when the member left on their own.
+type MemberRemovedPayload struct {
+	ConversationID string `json:"conversation_id"`
+	UserID         string `json:"user_id"`
+	RemovedBy      string `json:"removed_by"`
+}
+
+// InboxSubscribePayload is echoed for inbox.subscribe with the number of
+// conversations the inbox follows; clients send an empty payload.
+// Truncated is set when the user belongs to more conversations than the server follows.
+type InboxSubscribePayload struct {
+	Conversations int  `json:"conversations"`
+	Truncated     bool `json:"truncated,omitempty"`
+}
+
+// InboxMessagePayload previews a message.new for conversation lists. Preview is
+// the start of Text; it is empty for end-to-end encrypted messages.
+type InboxMessagePayload struct {
+	ConversationID  string    `json:"conversation_id"`
+	ServerMsgID     string    `json:"server_msg_id"`
+	Seq             int64     `json:"seq"`
+	Sender          string    `json:"sender"`
+	SenderBotID     string    `json:"sender_bot_id,omitempty"`
+	Preview         string    `json:"preview"`
+	ContentType     string    `json:"content_type,omitempty"`
+	AttachmentCount int       `json:"attachment_count,omitempty"`
+	ServerTS        time.Time `json:"server_ts"`
+}
+
+// FirehoseSubscribePayload opens an integration event stream. ResumeToken is the
+// token of the last firehose.checkpoint received; messages after it are replayed.
+type FirehoseSubscribePayload struct {
+	ResumeToken string `json:"resume_token,omitempty"`
+}
+
+// FirehoseCheckpointPayload carries a resume token covering every event sent before it.
+type FirehoseCheckpointPayload struct {
+	ResumeToken string `json:"resume_token"`
+}
+
+// PresenceSubscribePayload lists users whose presence the client wants to follow.
+type PresenceSubscribePayload struct {
+	UserIDs []string `json:"user_ids"`
+}
+
+// PresenceUpdatePayload is a status change.
+// Client -> server: only Status ("online" | "away") is read.
+// Server -> client: UserID and Status ("online" | "away" | "offline") are set;
+// LastSeenAt is present only for offline users when the viewer may see it.
+type PresenceUpdatePayload struct {
+	UserID     string     `json:"user_id,omitempty"`
+	Status     string     `json:"status"`
+	LastSeenAt *time.Time `json:"last_seen_at,omitempty"`
+}
+
 // ErrorPayload is a generic error response payload.
 type ErrorPayload struct {
-	Code    string `json:"code"`
+	Code string `json:"code"`
+	// Reason refines Code, e.g. the filter that rejected a message ("" if none).
+	Reason  string `json:"reason,omitempty"`
 	Message string `json:"message"`
+	// MaxChars and ActualChars accompany reason "too_long".
+	MaxChars    int `json:"max_chars,omitempty"`
+	ActualChars int `json:"actual_chars,omitempty"`
+}
+
+// ResumePayload carries the last seq the client received per conversation.
+type ResumePayload struct {
+	Conversations []ResumeConversation `json:"conversations"`
+	// ResumeToken supplies cursors for conversations not listed in Conversations.
+	ResumeToken string `json:"resume_token,omitempty"`
+}
+
+// ResumeConversation is one conversation's resume position.
+type ResumeConversation struct {
+	ConversationID string `json:"conversation_id"`
+	LastSeq        int64  `json:"last_seq"`
+}
+
+// ResumeOKPayload follows the replayed message.new events of one conversation.
+type ResumeOKPayload struct {
+	ConversationID string `json:"conversation_id"`
+	Replayed       int    `json:"replayed"`
+	// LastSeq is the latest seq delivered (the requested last_seq when nothing was missed).
+	LastSeq int64 `json:"last_seq"`
+}
+
+// ServerShutdownPayload tells clients when to reconnect to a draining node's peers.
+type ServerShutdownPayload struct {
+	// RetryAfterMS is a jittered delay before reconnecting, spreading reconnects out.
+	RetryAfterMS int64 `json:"retry_after_ms"`
+	// DeadlineMS is how long the server keeps the connection open to flush queued events.
+	DeadlineMS int64 `json:"deadline_ms"`
+	// ResumeToken carries the cursors delivered so far, for resuming on another node.
+	ResumeToken string `json:"resume_token,omitempty"`
+}
+
+// TimeSyncPayload answers time.sync. ClientTS echoes the request envelope's ts and
+// ServerTS is when the server received it; clients estimate their offset as
+// server_ts - (client_ts + rtt/2).
+type TimeSyncPayload struct {
+	ClientTS *time.Time `json:"client_ts,omitempty"`
+	ServerTS time.Time  `json:"server_ts"`
+	// ClockSkewMS is client_ts minus server_ts, ignoring network latency.
+	ClockSkewMS *int64 `json:"clock_skew_ms,omitempty"`
+}
+
+// ResumeFailedPayload explains why a conversation was not replayed.
+type ResumeFailedPayload struct {
+	ConversationID string `json:"conversation_id"`
+	// Reason is one of: window_exceeded, forbidden, invalid, unavailable.
+	Reason  string `json:"reason"`
+	Message string `json:"message,omitempty"`
 }
@@ -0,0 +1,213 @@
+// Package v2 defines the binary framing of the Arc Realtime Protocol.
+//
+// v2 changes only the encoding: envelopes, event types and payload structs are the
+// v1 contract (including "v": 1). Each WebSocket binary message carries one
+// envelope encoded as a MessagePack map with the v1 JSON field names. The payload
+// is the v1 payload struct of the envelope type, encoded as a map with the same
+// field names; times, ts included, use the MessagePack timestamp extension (type
+// -1) and byte fields are bin values.
+//
+// Encode and Decode work on typed payloads. Marshal and Unmarshal convert from and
+// to v1.Envelope, whose payload is JSON.
+package v2
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	v1 "arc/shared/contracts/realtime/v1"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"github.com/vmihailenco/msgpack/v5/msgpcode"
+)
+
+// Subprotocol is the WebSocket subprotocol negotiated for binary framing.
+const Subprotocol = "arc.realtime.v2"
+
+// ErrMalformed reports a frame that is not a valid v2 envelope.
+var ErrMalformed = errors.New("v2: malformed frame")
+
+// payloads maps each envelope type to its v1 payload struct.
+var payloads = map[string]reflect.Type{
+	v1.TypeHello:                    reflect.TypeFor[v1.HelloPayload](),
+	v1.TypeHelloAck:                 reflect.TypeFor[v1.HelloAckPayload](),
+	v1.TypeConversationJoin:         reflect.TypeFor[v1.ConversationJoinPayload](),
+	v1.TypeConversationLeave:        reflect.TypeFor[v1.ConversationLeavePayload](),
+	v1.TypeMessageSend:              reflect.TypeFor[v1.MessageSendPayload](),
+	v1.TypeMessageAck:               reflect.TypeFor[v1.MessageAckPayload](),
+	v1.TypeMessageNew:               reflect.TypeFor[v1.MessageNewPayload](),
+	v1.TypeMessageEdit:              reflect.TypeFor[v1.MessageEditPayload](),
+	v1.TypeMessageDelete:            reflect.TypeFor[v1.MessageDeletePayload](),
+	v1.TypeMessageEdited:            reflect.TypeFor[v1.MessageEditedPayload](),
+	v1.TypeMessageDeleted:           reflect.TypeFor[v1.MessageDeletedPayload](),
+	v1.TypeMessageRemoved:           reflect.TypeFor[v1.MessageRemovedPayload](),
+	v1.TypeMessageRead:              reflect.TypeFor[v1.MessageReadPayload](),
+	v1.TypeReadUpdate:               reflect.TypeFor[v1.ReadUpdatePayload](),
+	v1.TypeReadState:                reflect.TypeFor[v1.ReadStatePayload](),
+	v1.TypeMessageDelivered:         reflect.TypeFor[v1.MessageDeliveredPayload](),
+	v1.TypeSystemNew:                reflect.TypeFor[v1.SystemNewPayload](),
+	v1.TypeConversationHistoryFetch: reflect.TypeFor[v1.ConversationHistoryFetchPayload](),
+	v1.TypeConversationHistoryChunk: reflect.TypeFor[v1.ConversationHistoryChunkPayload](),
+	v1.TypeMemberAdded:              reflect.TypeFor[v1.MemberAddedPayload](),
+	v1.TypeMemberRemoved:            reflect.TypeFor[v1.MemberRemovedPayload](),
+	v1.TypePresenceSubscribe:        reflect.TypeFor[v1.PresenceSubscribePayload](),
+	v1.TypePresenceUpdate:           reflect.TypeFor[v1.PresenceUpdatePayload](),
+	v1.TypeInboxSubscribe:           reflect.TypeFor[v1.InboxSubscribePayload](),
+	v1.TypeInboxMessage:             reflect.TypeFor[v1.InboxMessagePayload](),
+	v1.TypeFirehoseSubscribe:        reflect.TypeFor[v1.FirehoseSubscribePayload](),
+	v1.TypeFirehoseCheckpoint:       reflect.TypeFor[v1.FirehoseCheckpointPayload](),
+	v1.TypeResume:                   reflect.TypeFor[v1.ResumePayload](),
+	v1.TypeResumeOK:                 reflect.TypeFor[v1.ResumeOKPayload](),
+	v1.TypeResumeFailed:             reflect.TypeFor[v1.ResumeFailedPayload](),
+	v1.TypeServerShutdown:           reflect.TypeFor[v1.ServerShutdownPayload](),
+	v1.TypeTimeSync:                 reflect.TypeFor[v1.TimeSyncPayload](),
+	v1.TypeError:                    reflect.TypeFor[v1.ErrorPayload](),
+}
+
+// Envelope is a v1 envelope with a typed payload.
+type Envelope struct {
+	V      int       `msgpack:"v"`
+	Type   string    `msgpack:"type"`
+	ID     string    `msgpack:"id,omitempty"`
+	ConvID string    `msgpack:"conv_id,omitempty"`
+	Seq    int64     `msgpack:"seq,omitempty"`
+	TS     time.Time `msgpack:"ts,omitempty"`
+	// Payload is the v1 payload struct of Type (or a pointer to it), nil for
+	// types without one. Decode sets a pointer.
+	Payload any `msgpack:"payload,omitempty"`
+}
+
+// frame is Envelope with the payload left encoded until the type is known:
+// MessagePack maps are unordered, so "payload" may precede "type".
+type frame struct {
+	V       int                `msgpack:"v"`
+	Type    string             `msgpack:"type"`
+	ID      string             `msgpack:"id"`
+	ConvID  string             `msgpack:"conv_id"`
+	Seq     int64              `msgpack:"seq"`
+	TS      time.Time          `msgpack:"ts"`
+	Payload msgpack.RawMessage `msgpack:"payload"`
+}
+
+// Encode encodes env as a MessagePack frame. Payload struct fields are keyed by
+// their JSON names and honour omitempty.
+func Encode(env Envelope) ([]byte, error) {
+	if env.Payload != nil {
+		t := reflect.TypeOf(env.Payload)
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if want, ok := payloads[env.Type]; !ok || t != want {
+			return nil, fmt.Errorf("v2: %s payload for type %q", t, env.Type)
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := msgpack.GetEncoder()
+	defer msgpack.PutEncoder(enc)
+	enc.Reset(&buf)
+	enc.SetCustomStructTag("json")
+	enc.UseCompactInts(true)
+	if err := enc.Encode(env); err != nil {
+		return nil, fmt.Errorf("v2: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode decodes a MessagePack frame. Unknown keys are ignored, mirroring
+// encoding/json, as is the payload of a type without one (including unknown
+// types, which the caller rejects by name).
+func Decode(data []byte) (Envelope, error) {
+	r := bytes.NewReader(data)
+	dec := msgpack.GetDecoder()
+	defer msgpack.PutDecoder(dec)
+	dec.Reset(r)
+	var f frame
+	if err := dec.Decode(&f); err != nil {
+		return Envelope{}, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	if r.Len() != 0 {
+		return Envelope{}, fmt.Errorf("%w: trailing bytes", ErrMalformed)
+	}
+
+	env := Envelope{V: f.V, Type: f.Type, ID: f.ID, ConvID: f.ConvID, Seq: f.Seq, TS: f.TS.UTC()}
+	t, ok := payloads[f.Type]
+	if !ok || len(f.Payload) == 0 || (len(f.Payload) == 1 && f.Payload[0] == msgpcode.Nil) {
+		return env, nil
+	}
+	p := reflect.New(t)
+	dec.Reset(bytes.NewReader(f.Payload))
+	dec.SetCustomStructTag("json")
+	if err := dec.Decode(p.Interface()); err != nil {
+		return Envelope{}, fmt.Errorf("%w: payload: %v", ErrMalformed, err)
+	}
+	toUTC(p)
+	env.Payload = p.Interface()
+	return env, nil
+}
+
+var timeType = reflect.TypeFor[time.Time]()
+
+// toUTC converts the times in v to UTC, as encoding/json would have decoded them;
+// msgpack decodes timestamps in the local zone.
+func toUTC(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if !v.IsNil() {
+			toUTC(v.Elem())
+		}
+	case reflect.Slice:
+		if k := v.Type().Elem().Kind(); k == reflect.Struct || k == reflect.Pointer {
+			for i := range v.Len() {
+				toUTC(v.Index(i))
+			}
+		}
+	case reflect.Struct:
+		if v.Type() == timeType {
+			v.Set(reflect.ValueOf(v.Interface().(time.Time).UTC()))
+			return
+		}
+		for i := range v.NumField() {
+			if v.Type().Field(i).IsExported() {
+				toUTC(v.Field(i))
+			}
+		}
+	}
+}
+
+// Marshal encodes a v1 envelope, decoding its JSON payload into the payload
+// struct of env.Type first.
+func Marshal(env v1.Envelope) ([]byte, error) {
+	out := Envelope{V: env.V, Type: env.Type, ID: env.ID, ConvID: env.ConvID, Seq: env.Seq, TS: env.TS}
+	if len(env.Payload) > 0 && !bytes.Equal(env.Payload, []byte("null")) {
+		t, ok := payloads[env.Type]
+		if !ok {
+			return nil, fmt.Errorf("v2: type %q has no payload", env.Type)
+		}
+		p := reflect.New(t)
+		if err := json.Unmarshal(env.Payload, p.Interface()); err != nil {
+			return nil, fmt.Errorf("v2: payload: %w", err)
+		}
+		out.Payload = p.Interface()
+	}
+	return Encode(out)
+}
+
+// Unmarshal decodes a frame into a v1 envelope, re-encoding the payload as JSON.
+func Unmarshal(data []byte) (v1.Envelope, error) {
+	env, err := Decode(data)
+	if err != nil {
+		return v1.Envelope{}, err
+	}
+	out := v1.Envelope{V: env.V, Type: env.Type, ID: env.ID, ConvID: env.ConvID, Seq: env.Seq, TS: env.TS}
+	if env.Payload != nil {
+		if out.Payload, err = json.Marshal(env.Payload); err != nil {
+			return v1.Envelope{}, fmt.Errorf("%w: payload: %v", ErrMalformed, err)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,280 @@
+package v2
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	v1 "arc/shared/contracts/realtime/v1"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func sampleEnvelope(tb testing.TB) v1.Envelope {
+	tb.Helper()
+
+	p, err := json.Marshal(v1.MessageNewPayload{
+		ConversationID: "01HZY3M1Q6Z8V4K9C2B7D5E0FA",
+		ClientMsgID:    "c-123",
+		ServerMsgID:    "01HZY3M1Q6Z8V4K9C2B7D5E0FB",
+		Seq:            4242,
+		Sender:         "01HZY3M1Q6Z8V4K9C2B7D5E0FC",
+		Text:           "hello @alice, see https://example.com/docs — ünïcødé ✓",
+		ServerTS:       time.Date(2026, 1, 2, 3, 4, 5, 6000, time.UTC),
+	})
+	if err != nil {
+		tb.Fatalf("marshal payload: %v", err)
+	}
+	return v1.Envelope{
+		V:       v1.Version,
+		Type:    v1.TypeMessageNew,
+		ID:      "01HZY3M1Q6Z8V4K9C2B7D5E0FD",
+		ConvID:  "01HZY3M1Q6Z8V4K9C2B7D5E0FA",
+		Seq:     4242,
+		TS:      time.Date(2026, 1, 2, 3, 4, 5, 123456789, time.UTC),
+		Payload: p,
+	}
+}
+
+func TestMarshalUnmarshal_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	env := sampleEnvelope(t)
+	frame, err := Marshal(env)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	got, err := Unmarshal(frame)
+	if err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	if got.V != env.V || got.Type != env.Type || got.ID != env.ID || got.ConvID != env.ConvID || got.Seq != env.Seq {
+		t.Fatalf("header mismatch: got %+v", got)
+	}
+	if !got.TS.Equal(env.TS) {
+		t.Fatalf("ts mismatch: want %v, got %v", env.TS, got.TS)
+	}
+
+	// Payloads decode into the same v1 struct.
+	var want, have v1.MessageNewPayload
+	_ = json.Unmarshal(env.Payload, &want)
+	if err := json.Unmarshal(got.Payload, &have); err != nil {
+		t.Fatalf("decode payload: %v", err)
+	}
+	if have.Seq != want.Seq || have.Text != want.Text || !have.ServerTS.Equal(want.ServerTS) || have.Sender != want.Sender {
+		t.Fatalf("payload mismatch: want %+v, got %+v", want, have)
+	}
+
+	jsonFrame, _ := json.Marshal(env)
+	if len(frame) >= len(jsonFrame) {
+		t.Fatalf("expected binary frame (%d bytes) to be smaller than JSON (%d bytes)", len(frame), len(jsonFrame))
+	}
+}
+
+func TestEncodeDecode_TypedPayloads(t *testing.T) {
+	t.Parallel()
+
+	after := int64(10)
+	skew := int64(-250)
+	clientTS := time.Unix(1700000000, 1).UTC()
+	tests := []Envelope{
+		{V: 1, Type: v1.TypeMessageSend, Payload: &v1.MessageSendPayload{
+			ConversationID: "c1", ClientMsgID: "m1", ContentType: "application/vnd.arc.e2ee",
+			Ciphertext: []byte{0, 1, 2, 0xff}, KeyIDs: []string{"k1", "k2"},
+		}},
+		{V: 1, Type: v1.TypeConversationHistoryFetch, Payload: &v1.ConversationHistoryFetchPayload{ConversationID: "c1", AfterSeq: &after, Limit: 50}},
+		{V: 1, Type: v1.TypeConversationHistoryChunk, Payload: &v1.ConversationHistoryChunkPayload{
+			ConversationID: "c1",
+			Messages: []v1.MessageNewPayload{{
+				ConversationID: "c1", ServerMsgID: "s1", Seq: 1 << 40, Text: "hi",
+				ServerTS: time.Date(2026, 1, 2, 3, 4, 5, 6, time.UTC),
+				Entities: []v1.MessageEntity{{Type: "mention", Offset: 0, Length: 2, UserID: "u1"}},
+			}},
+			HasMore: true,
+		}},
+		{V: 1, Type: v1.TypeTimeSync, Payload: &v1.TimeSyncPayload{ClientTS: &clientTS, ServerTS: time.Unix(1700000001, 0).UTC(), ClockSkewMS: &skew}},
+		{V: 1, Type: v1.TypeHelloAck, Payload: &v1.HelloAckPayload{SessionID: "s", Capabilities: v1.Capabilities(1<<63 | 1)}},
+		{V: 1, Type: v1.TypeInboxUnsubscribe},
+	}
+
+	for _, env := range tests {
+		frame, err := Encode(env)
+		if err != nil {
+			t.Fatalf("%s: encode: %v", env.Type, err)
+		}
+		got, err := Decode(frame)
+		if err != nil {
+			t.Fatalf("%s: decode: %v", env.Type, err)
+		}
+		if !reflect.DeepEqual(got, env) {
+			t.Fatalf("%s: round trip mismatch:\nwant %+v\n got %+v", env.Type, env.Payload, got.Payload)
+		}
+	}
+}
+
+func TestEncode_JSONFieldNames(t *testing.T) {
+	t.Parallel()
+
+	frame, err := Encode(Envelope{V: 1, Type: v1.TypeConversationJoin, Payload: v1.ConversationJoinPayload{ConversationID: "c1"}})
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	var got map[string]any
+	if err := msgpack.Unmarshal(frame, &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	payload, _ := got["payload"].(map[string]any)
+	if len(got) != 3 || payload["conversation_id"] != "c1" || len(payload) != 1 {
+		t.Fatalf("unexpected frame %v", got)
+	}
+}
+
+func TestEncode_RejectsMismatchedPayload(t *testing.T) {
+	t.Parallel()
+
+	if _, err := Encode(Envelope{V: 1, Type: v1.TypeMessageSend, Payload: &v1.HelloPayload{}}); err == nil {
+		t.Fatalf("expected a hello payload on message.send to be rejected")
+	}
+	if _, err := Marshal(v1.Envelope{V: 1, Type: "nope", Payload: json.RawMessage(`{}`)}); err == nil {
+		t.Fatalf("expected a payload on an unknown type to be rejected")
+	}
+	if _, err := Marshal(v1.Envelope{V: 1, Type: v1.TypeMessageSend, Payload: json.RawMessage(`{"text":1}`)}); err == nil {
+		t.Fatalf("expected a payload that does not fit its struct to be rejected")
+	}
+}
+
+func TestDecode_IgnoresPayloadOfUnknownType(t *testing.T) {
+	t.Parallel()
+
+	frame, err := msgpack.Marshal(map[string]any{"v": 1, "type": "nope", "payload": map[string]any{"a": 1}, "extra": true})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	got, err := Unmarshal(frame)
+	if err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if got.Type != "nope" || got.Payload != nil {
+		t.Fatalf("unexpected envelope %+v", got)
+	}
+}
+
+func TestMarshal_Timestamps(t *testing.T) {
+	t.Parallel()
+
+	for _, ts := range []time.Time{
+		time.Unix(1700000000, 0).UTC(),              // timestamp 32
+		time.Unix(1700000000, 999999999).UTC(),      // timestamp 64
+		time.Unix(-1, 5).UTC(),                      // timestamp 96 (negative seconds)
+		time.Date(2600, 1, 1, 0, 0, 0, 1, time.UTC), // timestamp 96 (beyond 34 bits)
+	} {
+		frame, err := Marshal(v1.Envelope{V: 1, Type: v1.TypeHello, TS: ts})
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		got, err := Unmarshal(frame)
+		if err != nil {
+			t.Fatalf("unmarshal %v: %v", ts, err)
+		}
+		if !got.TS.Equal(ts) {
+			t.Fatalf("want %v, got %v", ts, got.TS)
+		}
+	}
+}
+
+func TestUnmarshal_RejectsMalformed(t *testing.T) {
+	t.Parallel()
+
+	valid, err := Marshal(sampleEnvelope(t))
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	tests := map[string][]byte{
+		"empty":            {},
+		"not a map":        {0x91, 0x01},
+		"truncated":        valid[:len(valid)-3],
+		"trailing bytes":   append(append([]byte(nil), valid...), 0xc0),
+		"forged length":    {0x82, 0xa4, 't', 'y', 'p', 'e', 0xa5, 'h', 'e', 'l', 'l', 'o', 0xa7, 'p', 'a', 'y', 'l', 'o', 'a', 'd', 0xdf, 0xff, 0xff, 0xff, 0xff},
+		"binary payload":   {0x82, 0xa4, 't', 'y', 'p', 'e', 0xa5, 'h', 'e', 'l', 'l', 'o', 0xa7, 'p', 'a', 'y', 'l', 'o', 'a', 'd', 0xc4, 0x01, 0x00},
+		"string field":     {0x82, 0xa4, 't', 'y', 'p', 'e', 0xa5, 'h', 'e', 'l', 'l', 'o', 0xa7, 'p', 'a', 'y', 'l', 'o', 'a', 'd', 0x81, 0xa5, 't', 'o', 'k', 'e', 'n', 0x01},
+		"string version":   {0x81, 0xa1, 'v', 0xa1, '1'},
+		"bad timestamp":    {0x81, 0xa2, 't', 's', 0xd6, 0x01, 0, 0, 0, 0},
+		"non-string value": {0x81, 0xa4, 't', 'y', 'p', 'e', 0x01},
+	}
+
+	for name, frame := range tests {
+		if _, err := Unmarshal(frame); !errors.Is(err, ErrMalformed) {
+			t.Fatalf("%s: expected ErrMalformed, got %v", name, err)
+		}
+	}
+}
+
+func BenchmarkMarshal_JSON(b *testing.B) {
+	env := sampleEnvelope(b)
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := json.Marshal(env); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMarshal_MessagePack(b *testing.B) {
+	env := sampleEnvelope(b)
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := Marshal(env); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkEncode and BenchmarkDecode measure the typed path, without the JSON
+// payload conversion of Marshal and Unmarshal.
+func BenchmarkEncode(b *testing.B) {
+	env := sampleEnvelope(b)
+	var p v1.MessageNewPayload
+	_ = json.Unmarshal(env.Payload, &p)
+	typed := Envelope{V: env.V, Type: env.Type, ID: env.ID, ConvID: env.ConvID, Seq: env.Seq, TS: env.TS, Payload: &p}
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := Encode(typed); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUnmarshal_JSON(b *testing.B) {
+	frame, _ := json.Marshal(sampleEnvelope(b))
+	b.ReportAllocs()
+	for b.Loop() {
+		var env v1.Envelope
+		if err := json.Unmarshal(frame, &env); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUnmarshal_MessagePack(b *testing.B) {
+	frame, _ := Marshal(sampleEnvelope(b))
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := Unmarshal(frame); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecode(b *testing.B) {
+	frame, _ := Marshal(sampleEnvelope(b))
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := Decode(frame); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -15,4 +15,10 @@ echo "fmt: gofmt (shared)"
   gofmt -w .
 )
 
+echo "fmt: gofmt (client/go)"
+(
+  cd "$ROOT_DIR/client/go"
+  gofmt -w .
+)
+
 echo "OK: gofmt"
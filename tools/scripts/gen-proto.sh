@@ -0,0 +1,30 @@
+#!/usr/bin/env bash
+set -euo pipefail
+
+ROOT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")/../.." && pwd)"
+
+# Plugin versions match the headers of the checked-in generated code.
+PROTOC_GEN_GO_VERSION="v1.36.10"
+PROTOC_GEN_GO_GRPC_VERSION="v1.5.1"
+
+if ! command -v protoc > /dev/null 2>&1; then
+  echo "ERROR: protoc not found (install protobuf-compiler)" >&2
+  exit 1
+fi
+
+bin_dir="$(mktemp -d "${TMPDIR:-/tmp}/arc-protoc-gen.XXXXXX")"
+trap 'rm -rf "${bin_dir}"' EXIT
+
+GOBIN="${bin_dir}" go install "google.golang.org/protobuf/cmd/protoc-gen-go@${PROTOC_GEN_GO_VERSION}"
+GOBIN="${bin_dir}" go install "google.golang.org/grpc/cmd/protoc-gen-go-grpc@${PROTOC_GEN_GO_GRPC_VERSION}"
+
+echo "gen-proto: shared/contracts/realtime/realtimepb"
+(
+  cd "${ROOT_DIR}/shared/contracts/realtime/realtimepb"
+  PATH="${bin_dir}:${PATH}" protoc -I . \
+    --go_out=. --go_opt=paths=source_relative \
+    --go-grpc_out=. --go-grpc_opt=paths=source_relative \
+    realtime.proto
+)
+
+echo "OK: gen-proto"
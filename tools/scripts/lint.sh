@@ -30,4 +30,10 @@ echo "lint: golangci-lint (shared)"
   golangci-lint run --config "$CONFIG_FILE"
 )
 
+echo "lint: golangci-lint (client/go)"
+(
+  cd "$ROOT_DIR/client/go"
+  golangci-lint run --config "$CONFIG_FILE"
+)
+
 echo "OK: lint"
@@ -20,6 +20,12 @@ echo "test: go (shared)"
   go test ./...
 )
 
+echo "test: go (client/go)"
+(
+  cd "$ROOT_DIR/client/go"
+  go test ./...
+)
+
 # Flutter tests are optional in environments where Flutter SDK is not installed.
 # The dedicated CI job "Flutter (test)" is responsible for guaranteeing Flutter tests run in CI.
 if command -v flutter > /dev/null 2>&1; then
@@ -0,0 +1,67 @@
+#!/usr/bin/env bash
+# ws-bench.sh - WebSocket load generator for Arc realtime.
+# Opens CONNS connections over CONVS conversations, sends RATE msg/s for DURATION and
+# reports ack and fanout latency percentiles.
+
+set -Eeuo pipefail
+
+on_err() {
+  local code=$?
+  echo "FAIL: ws-bench.sh: unexpected error (exit=${code})" >&2
+  exit "${code}"
+}
+trap on_err ERR
+
+ROOT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")/../.." && pwd)"
+cd "${ROOT_DIR}"
+
+URL="${URL:-ws://127.0.0.1:8080/ws}"
+CONNS="${CONNS:-50}"
+CONVS="${CONVS:-5}"
+RATE="${RATE:-50}"
+DURATION="${DURATION:-30s}"
+TEXT_SIZE="${TEXT_SIZE:-64}"
+AUTH_BEARER="${AUTH_BEARER:-}"
+TOKENS_FILE="${TOKENS_FILE:-}"
+JSON="${JSON:-false}"
+
+if [[ "${URL}" != ws://* && "${URL}" != wss://* ]]; then
+  echo "FAIL: ws-bench.sh: URL must start with ws:// or wss:// (got: ${URL})" >&2
+  exit 2
+fi
+
+if [[ -z "${ORIGIN:-}" ]]; then
+  if [[ "${URL}" == wss://* ]]; then
+    _origin_scheme="https"
+    _tmp="${URL#wss://}"
+  else
+    _origin_scheme="http"
+    _tmp="${URL#ws://}"
+  fi
+  _origin_host="${_tmp%%/*}"
+  ORIGIN="${_origin_scheme}://${_origin_host}"
+fi
+
+echo "ws-bench: url=${URL} conns=${CONNS} convs=${CONVS} rate=${RATE}/s duration=${DURATION}"
+
+args=(
+  -url "${URL}"
+  -origin "${ORIGIN}"
+  -conns "${CONNS}"
+  -convs "${CONVS}"
+  -rate "${RATE}"
+  -duration "${DURATION}"
+  -text-size "${TEXT_SIZE}"
+)
+if [[ -n "${TOKENS_FILE}" ]]; then
+  args+=(-tokens-file "${TOKENS_FILE}")
+fi
+if [[ "${JSON}" == "true" ]]; then
+  args+=(-json)
+fi
+
+if [[ -n "${AUTH_BEARER}" ]]; then
+  WS_BENCH_AUTH_BEARER="${AUTH_BEARER}" go run ./server/go/tools/scripts/ws-bench "${args[@]}"
+else
+  go run ./server/go/tools/scripts/ws-bench "${args[@]}"
+fi